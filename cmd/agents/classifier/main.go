@@ -13,39 +13,129 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/agile-defense/cjadc2/pkg/admission"
 	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/inference"
 	"github.com/agile-defense/cjadc2/pkg/messages"
 	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
+	"github.com/agile-defense/cjadc2/pkg/trackshard"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go/jetstream"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ShedReportInterval is how often detections shed by the rate limit are
+// summarized in a single admission event
+const ShedReportInterval = time.Second
+
+// ClassificationRulesRefreshInterval is how often classification rules are
+// reloaded from the database
+const ClassificationRulesRefreshInterval = 10 * time.Second
+
 // ClassifierAgent processes raw detections and enriches them with classification
 type ClassifierAgent struct {
 	*agent.BaseAgent
-	logger   zerolog.Logger
-	consumer jetstream.Consumer
+	logger zerolog.Logger
 
 	// Pause control
 	mu     sync.RWMutex
 	paused bool
+
+	// Admission control; detectionLimit is nil when unlimited
+	maxDetectionsPerSec int
+	detectionLimit      *admission.RateLimiter
+	shedTotal           *prometheus.CounterVec
+	shedMu              sync.Mutex
+	shedPending         map[admission.Rank]int // Shed since the last report
+
+	// Type and classification rules, reloaded from the database when available
+	db            *postgres.Pool
+	rules         *classify.Engine
+	rulesMu       sync.RWMutex
+	rulesSource   string
+	rulesLoadedAt time.Time
+
+	// Optional external model; nil when MODEL_URL is unset
+	model *inference.Client
+
+	// Damps classification flips; stateBucket selects a shared KV store
+	hysteresis  *classify.Hysteresis
+	stateBucket string
+
+	// Geographic cells stamped onto track subjects for sharded correlators
+	cells trackshard.Config
+}
+
+// modelScore records how the model contributed to a classification
+type modelScore struct {
+	Version string
+	Class   string
+	Outcome string // An inference outcome, or outcomeFallback when the request failed
 }
 
+// outcomeFallback marks detections classified by the rules because the model failed
+const outcomeFallback = "fallback"
+
 // NewClassifierAgent creates a new classifier agent
 func NewClassifierAgent(cfg agent.Config) (*ClassifierAgent, error) {
 	base, err := agent.NewBaseAgent(cfg)
 	if err != nil {
 		return nil, err
 	}
+	base.SetCapabilities(classifierCapabilities())
+
+	maxDetections, err := admission.ParseLimit("MAX_DETECTIONS_PER_SEC", cfg.ExtraVars["MAX_DETECTIONS_PER_SEC"], admission.DefaultMaxDetectionsPerSec)
+	if err != nil {
+		return nil, err
+	}
 
-	return &ClassifierAgent{
-		BaseAgent: base,
-		logger:    *base.Logger(),
-	}, nil
+	modelOpts, err := inference.OptionsFromVars(cfg.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+
+	hysteresisCfg, err := classify.ParseHysteresisConfig(cfg.ExtraVars["CLASSIFY_MIN_CONSECUTIVE"], cfg.ExtraVars["CLASSIFY_FLIP_MARGIN"], cfg.ExtraVars["CLASSIFY_STATE_TTL"])
+	if err != nil {
+		return nil, err
+	}
+	hysteresis := classify.NewHysteresis(hysteresisCfg, classify.NewMemoryStateStore(hysteresisCfg.StateTTL))
+	base.Metrics().MustRegister(hysteresis.Collectors()...)
+
+	cells, err := trackshard.ParseConfig(cfg.ExtraVars["GEOCELL_PRECISION"], cfg.ExtraVars["GEOCELL_MARGIN_METERS"])
+	if err != nil {
+		return nil, err
+	}
+
+	shedTotal := admission.NewShedCounter()
+	base.Metrics().MustRegister(shedTotal)
+
+	a := &ClassifierAgent{
+		BaseAgent:           base,
+		logger:              *base.Logger(),
+		maxDetectionsPerSec: maxDetections,
+		shedTotal:           shedTotal,
+		shedPending:         make(map[admission.Rank]int),
+		rules:               classify.NewDefaultEngine(),
+		rulesSource:         "default",
+		hysteresis:          hysteresis,
+		stateBucket:         cfg.ExtraVars["CLASSIFY_STATE_BUCKET"],
+		cells:               cells,
+	}
+	if maxDetections > 0 {
+		a.detectionLimit = admission.NewRateLimiter(maxDetections)
+	}
+	if modelOpts.Enabled() {
+		a.model = inference.NewClient(modelOpts)
+		base.Metrics().MustRegister(a.model.Collectors()...)
+	}
+	return a, nil
 }
 
 // Run starts the classifier agent
@@ -56,108 +146,79 @@ func (a *ClassifierAgent) Run(ctx context.Context) error {
 	}
 
 	// Ensure streams exist
-	if err := natsutil.SetupStreams(ctx, a.JetStream()); err != nil {
+	if err := natsutil.SetupStreams(ctx, a.JetStream(), a.StreamPolicies()); err != nil {
 		return fmt.Errorf("failed to setup streams: %w", err)
 	}
 
-	// Create consumer for detection events
-	consumer, err := natsutil.SetupConsumer(ctx, a.JetStream(), "DETECTIONS", "classifier")
-	if err != nil {
-		return fmt.Errorf("failed to setup consumer: %w", err)
+	// Share hysteresis state with other classifier instances when a bucket is configured
+	if a.stateBucket != "" && a.hysteresis.Config().Enabled() {
+		store, err := classify.NewKVStateStore(ctx, a.JetStream(), a.stateBucket, a.hysteresis.Config().StateTTL)
+		if err != nil {
+			return err
+		}
+		a.hysteresis.SetStore(store)
 	}
-	a.consumer = consumer
 
-	a.logger.Info().Msg("Classifier agent started, consuming from DETECTIONS stream")
+	// Load classification rules from the database, falling back to the built-in set
+	a.loadClassificationRules(ctx)
+
+	if a.detectionLimit != nil {
+		go a.shedReportLoop(ctx)
+	}
+
+	a.logger.Info().Int("max_detections_per_sec", a.maxDetectionsPerSec).Msg("Classifier agent started, consuming from DETECTIONS stream")
 
 	// Start consuming messages
 	return a.consumeMessages(ctx)
 }
 
-// consumeMessages processes detection messages
+// consumeMessages processes detection messages. Detections of one track
+// stay in order; different tracks are classified in parallel.
 func (a *ClassifierAgent) consumeMessages(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Check if paused
-		a.mu.RLock()
-		paused := a.paused
-		a.mu.RUnlock()
-		if paused {
-			time.Sleep(100 * time.Millisecond)
-			continue
-		}
-
-		// Fetch messages with timeout
-		msgs, err := a.consumer.Fetch(10, jetstream.FetchMaxWait(5*time.Second))
-		if err != nil {
-			if err == context.DeadlineExceeded || err == context.Canceled {
-				continue
-			}
-			// Check if consumer was deleted and needs to be recreated
-			errStr := err.Error()
-			if strings.Contains(errStr, "no responders") || strings.Contains(errStr, "consumer not found") || strings.Contains(errStr, "consumer deleted") {
-				a.logger.Warn().Err(err).Msg("Consumer was deleted, recreating...")
-				consumer, recreateErr := natsutil.SetupConsumer(ctx, a.JetStream(), "DETECTIONS", "classifier")
-				if recreateErr != nil {
-					a.logger.Error().Err(recreateErr).Msg("Failed to recreate consumer")
-					a.RecordError("consumer_recreate_error")
-					time.Sleep(time.Second)
-					continue
-				}
-				a.consumer = consumer
-				a.logger.Info().Msg("Consumer recreated successfully")
-				continue
-			}
-			a.logger.Error().Err(err).Msg("Failed to fetch messages")
-			a.RecordError("fetch_error")
-			time.Sleep(time.Second)
-			continue
-		}
+	return a.Consume(ctx, "DETECTIONS", "classifier", a.processMessage, agent.ConsumeOptions{
+		Schema: messages.SchemaDetection,
+		Key:    detectionTrackKey,
+		Hold:   a.hold,
+	})
+}
 
-		for msg := range msgs.Messages() {
-			if err := a.processMessage(ctx, msg); err != nil {
-				a.logger.Error().Err(err).Msg("Failed to process message")
-				a.RecordError("process_error")
-				msg.Nak()
-			} else {
-				msg.Ack()
-			}
-		}
+// hold holds detections in the stream while the operator or the simulation
+// clock has paused the classifier
+func (a *ClassifierAgent) hold() time.Duration {
+	if a.IsPaused() || a.SimClock().Paused() {
+		return agent.PausePollInterval
+	}
+	return 0
+}
 
-		if msgs.Error() != nil && msgs.Error() != context.DeadlineExceeded {
-			errStr := msgs.Error().Error()
-			// Check if consumer was deleted and needs to be recreated
-			if strings.Contains(errStr, "no responders") || strings.Contains(errStr, "consumer not found") || strings.Contains(errStr, "consumer deleted") {
-				a.logger.Warn().Err(msgs.Error()).Msg("Consumer was deleted (batch error), recreating...")
-				consumer, recreateErr := natsutil.SetupConsumer(ctx, a.JetStream(), "DETECTIONS", "classifier")
-				if recreateErr != nil {
-					a.logger.Error().Err(recreateErr).Msg("Failed to recreate consumer")
-					a.RecordError("consumer_recreate_error")
-				} else {
-					a.consumer = consumer
-					a.logger.Info().Msg("Consumer recreated successfully")
-				}
-				continue
-			}
-			a.logger.Warn().Err(msgs.Error()).Msg("Message batch error")
-		}
+// detectionTrackKey partitions detections by track, so a track's hysteresis
+// state is updated by one worker at a time. A detection that does not decode
+// is keyed by nothing and refused by processMessage.
+func detectionTrackKey(msg jetstream.Msg) string {
+	var detection messages.Detection
+	if err := agent.Decode(msg, &detection); err != nil {
+		return ""
 	}
+	return detection.TrackID
 }
 
 // processMessage handles a single detection message
-func (a *ClassifierAgent) processMessage(ctx context.Context, msg jetstream.Msg) error {
+func (a *ClassifierAgent) processMessage(ctx context.Context, msg jetstream.Msg) (err error) {
 	start := time.Now()
 
 	// Parse detection
 	var detection messages.Detection
-	if err := json.Unmarshal(msg.Data(), &detection); err != nil {
-		return fmt.Errorf("failed to unmarshal detection: %w", err)
+	if err := agent.Decode(msg, &detection); err != nil {
+		return agent.Permanent(fmt.Errorf("failed to unmarshal detection: %w", err))
 	}
 
+	ctx, span := tracing.StartFromEnvelope(ctx, "classifier.classify", detection.Envelope,
+		tracing.AttrTrackID.String(detection.TrackID))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	correlationID := detection.Envelope.CorrelationID
 	if correlationID == "" {
 		correlationID = detection.Envelope.MessageID
@@ -189,31 +250,46 @@ func (a *ClassifierAgent) processMessage(ctx context.Context, msg jetstream.Msg)
 		track.Envelope.CorrelationID = detection.Envelope.CorrelationID
 	}
 
+	track.Envelope = tracing.InjectEnvelope(ctx, track.Envelope)
+
 	// Classify the track
-	a.classify(track, &detection)
+	result, score := a.classify(ctx, track, &detection)
 
-	a.logger.Info().
+	logEvent := a.logger.Info().
 		Str("correlation_id", correlationID).
 		Str("track_id", track.TrackID).
 		Str("classification", track.Classification).
 		Str("type", track.Type).
-		Msg("Track classified")
-
-	// Publish to TRACKS stream
-	subject := track.Subject()
-	data, err := json.Marshal(track)
-	if err != nil {
-		return fmt.Errorf("failed to marshal track: %w", err)
+		Str("type_rule", result.TypeRule).
+		Str("classification_rule", result.ClassificationRule)
+	if score != nil {
+		span.SetAttributes(
+			attribute.String("cjadc2.model_version", score.Version),
+			attribute.String("cjadc2.model_outcome", score.Outcome))
+		logEvent = logEvent.Str("model_version", score.Version).Str("model_outcome", score.Outcome)
+	}
+	logEvent.Msg("Track classified")
+
+	// Admission control: over the rate limit, the lowest-threat detections are shed first
+	if a.detectionLimit != nil {
+		rank := admission.RankForClassification(track.Classification, track.Type)
+		if !a.detectionLimit.Admit(rank, time.Now()) {
+			a.shedDetection(rank)
+			a.RecordMessage("shed", "detection")
+			span.SetAttributes(attribute.String("cjadc2.outcome", "shed"))
+			return nil
+		}
 	}
 
-	_, err = a.JetStream().Publish(ctx, subject, data)
+	// Publish to TRACKS stream
+	subject, err := a.publishTrack(ctx, track)
 	if err != nil {
 		return fmt.Errorf("failed to publish track: %w", err)
 	}
 
 	duration := time.Since(start)
 	a.RecordMessage("success", "detection")
-	a.RecordLatency("detection", duration)
+	a.RecordLatency(ctx, "detection", duration)
 
 	a.logger.Info().
 		Str("correlation_id", correlationID).
@@ -224,124 +300,235 @@ func (a *ClassifierAgent) processMessage(ctx context.Context, msg jetstream.Msg)
 	return nil
 }
 
-// classify determines the classification and type of a track
-func (a *ClassifierAgent) classify(track *messages.Track, detection *messages.Detection) {
-	// Determine track type based on sensor type and characteristics
-	track.Type = a.determineTrackType(detection)
+// publishTrack publishes a classified track, returning its subject. With
+// geographic cells enabled the subject carries the track's cell, and a border
+// copy is handed off to each neighbouring cell the track is near.
+func (a *ClassifierAgent) publishTrack(ctx context.Context, track *messages.Track) (string, error) {
+	if !a.cells.Enabled() {
+		_, err := a.PublishMessage(ctx, track)
+		return track.Subject(), err
+	}
+
+	m, err := a.EncodeMessage(track)
+	if err != nil {
+		return "", err
+	}
+	home, border := a.cells.Cells(track.Position)
+	m.Subject = trackshard.Subject(track, home)
+	if _, err := a.JetStream().PublishMsg(ctx, m); err != nil {
+		return "", err
+	}
+	for _, cell := range border {
+		m.Subject = trackshard.HandoffSubject(track, cell)
+		if _, err := a.JetStream().PublishMsg(ctx, m); err != nil {
+			return "", fmt.Errorf("failed to hand off track to cell %s: %w", cell, err)
+		}
+	}
+	return trackshard.Subject(track, home), nil
+}
 
-	// Determine classification based on various factors
-	track.Classification = a.determineClassification(detection, track.Type)
+// shedDetection counts a detection shed by the rate limit
+func (a *ClassifierAgent) shedDetection(rank admission.Rank) {
+	a.shedTotal.WithLabelValues(admission.KindDetection, admission.ReasonRateLimit, rank.String()).Inc()
 
-	// Adjust confidence based on classification certainty
-	track.Confidence = a.adjustConfidence(detection.Confidence, track.Classification)
+	a.shedMu.Lock()
+	a.shedPending[rank]++
+	a.shedMu.Unlock()
 }
 
-// determineTrackType infers the type of track from detection characteristics
-func (a *ClassifierAgent) determineTrackType(detection *messages.Detection) string {
-	// If the sensor provided a track type hint, use it (trusted sensor data)
-	if detection.Type != "" {
-		return detection.Type
-	}
-
-	// Fallback to heuristics if no type provided
-	speed := detection.Velocity.Speed
-	alt := detection.Position.Alt
-
-	// Simple heuristics for track type classification
-	switch {
-	case alt > 10000 && speed > 200:
-		return "aircraft"
-	case alt > 1000 && speed > 500:
-		return "missile"
-	case alt < 100 && speed > 0 && speed < 50:
-		// Could be ground or vessel based on position
-		if a.isOverWater(detection.Position) {
-			return "vessel"
+// shedReportLoop publishes one admission event per threat rank summarizing the
+// detections shed since the last report, rather than one event per detection
+func (a *ClassifierAgent) shedReportLoop(ctx context.Context) {
+	ticker := time.NewTicker(ShedReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.shedMu.Lock()
+			pending := a.shedPending
+			a.shedPending = make(map[admission.Rank]int)
+			a.shedMu.Unlock()
+
+			for rank, count := range pending {
+				shed := &messages.AdmissionShed{
+					Envelope:       messages.NewEnvelope(a.ID(), "classifier"),
+					NotificationID: uuid.New().String(),
+					Kind:           admission.KindDetection,
+					Reason:         admission.ReasonRateLimit,
+					ThreatLevel:    rank.String(),
+					Count:          count,
+					Limit:          a.maxDetectionsPerSec,
+				}
+				if _, err := a.PublishMessage(ctx, shed); err != nil {
+					a.logger.Warn().Err(err).Msg("Failed to publish admission event")
+					a.RecordError("admission_event_error")
+				}
+			}
+
+			if len(pending) > 0 {
+				a.logger.Warn().
+					Interface("shed_by_rank", pending).
+					Int("limit", a.maxDetectionsPerSec).
+					Msg("Detections shed by admission control")
+			}
 		}
-		return "ground"
-	case alt < 5000 && speed > 50 && speed < 300:
-		return "aircraft"
-	case speed == 0:
-		return "ground"
-	default:
-		return "unknown"
 	}
 }
 
-// isOverWater is a simplified check for maritime classification
-func (a *ClassifierAgent) isOverWater(pos messages.Position) bool {
-	// Simplified: use longitude ranges to approximate ocean areas
-	// In production, this would use proper GIS data
-	return pos.Lon < -100 || pos.Lon > 100 || (pos.Lon > -50 && pos.Lon < 50 && pos.Lat < 0)
-}
+// classify determines the classification and type of a track from the active
+// rules, consulting the model when one is configured
+func (a *ClassifierAgent) classify(ctx context.Context, track *messages.Track, detection *messages.Detection) (classify.Result, *modelScore) {
+	result := a.rules.Classify(detection)
+	track.Type = result.Type
+	track.Classification = result.Classification
 
-// determineClassification determines if a track is friendly, hostile, unknown, or neutral
-func (a *ClassifierAgent) determineClassification(detection *messages.Detection, trackType string) string {
-	// Simplified classification logic
-	// In production, this would use IFF data, known track databases, etc.
+	// A classification reported with the detection is not second-guessed
+	var score *modelScore
+	if a.model != nil && detection.Classification == "" {
+		score = a.scoreWithModel(ctx, detection, result)
+		track.Classification = score.Class
+	}
 
-	confidence := detection.Confidence
+	// Adjust confidence based on classification certainty
+	track.Confidence = a.adjustConfidence(detection.Confidence, track.Classification)
 
-	// Check for known neutral tracks first (commercial/civilian)
-	if a.isNeutralTrack(detection) {
-		return "neutral"
+	// Hold the track's current classification until the change is consistent or confident
+	if a.hysteresis.Config().Enabled() {
+		decision, err := a.hysteresis.Decide(ctx, track.TrackID, track.Classification, track.Confidence, time.Now().UTC())
+		if err != nil {
+			a.logger.Warn().Err(err).Str("track_id", track.TrackID).Msg("Classification hysteresis unavailable, publishing proposed classification")
+			a.RecordError("classification_state_error")
+		}
+		switch {
+		case decision.Changed:
+			a.logger.Info().
+				Str("track_id", track.TrackID).
+				Str("classification", decision.Classification).
+				Msg("Track classification changed")
+		case decision.Held:
+			track.Classification = decision.Classification
+			track.Confidence = a.adjustConfidence(detection.Confidence, track.Classification)
+			a.logger.Debug().
+				Str("track_id", track.TrackID).
+				Str("classification", decision.Classification).
+				Str("proposed", decision.Proposed).
+				Msg("Classification change held by hysteresis")
+		}
 	}
+	return result, score
+}
 
-	// Check for IFF-confirmed friendly tracks
-	if a.simulateIFFCheck(detection) {
-		return "friendly"
+// scoreWithModel asks the model for class probabilities and decides whether its
+// prediction replaces the rule-based classification. Failures fall back to the
+// rules; in shadow mode disagreements are only logged.
+func (a *ClassifierAgent) scoreWithModel(ctx context.Context, detection *messages.Detection, result classify.Result) *modelScore {
+	pred, err := a.model.Score(ctx, inference.FeaturesFor(detection, result.Type))
+	if err != nil {
+		a.logger.Debug().Err(err).Str("track_id", detection.TrackID).Msg("Model scoring failed, using classification rules")
+		return &modelScore{Version: "none", Class: result.Classification, Outcome: outcomeFallback}
 	}
 
-	// Check against known hostile patterns
-	if a.checkHostilePatterns(detection, trackType) {
-		return "hostile"
+	class, outcome := a.model.Decide(pred, result.Classification)
+	modelClass, probability := pred.Top()
+	if modelClass != result.Classification {
+		a.logger.Info().
+			Str("track_id", detection.TrackID).
+			Str("model_version", pred.Version()).
+			Str("model_class", modelClass).
+			Float64("model_probability", probability).
+			Str("heuristic_class", result.Classification).
+			Str("classification_rule", result.ClassificationRule).
+			Str("outcome", outcome).
+			Msg("Model disagrees with classification rules")
+	}
+	return &modelScore{Version: pred.Version(), Class: class, Outcome: outcome}
+}
+
+// loadClassificationRules connects to the database and loads the rules, keeping
+// the built-in rule set when no database is configured or reachable
+func (a *ClassifierAgent) loadClassificationRules(ctx context.Context) {
+	if a.Config().DBUrl == "" {
+		a.logger.Info().Msg("No database configured, using default classification rules")
+		return
+	}
+
+	db, err := postgres.NewPoolFromURL(ctx, a.Config().DBUrl)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to connect to database, using default classification rules")
+		return
 	}
+	if err := a.PrepareSchema(ctx, db.Pool); err != nil {
+		db.Close()
+		a.logger.Warn().Err(err).Msg("Database schema unavailable, using default classification rules")
+		return
+	}
+	a.db = db
+	a.AddDependency(agent.DependencyPostgres, db.Health)
 
-	// High confidence detections without matches are neutral
-	if confidence > 0.85 {
-		return "neutral"
+	if err := a.refreshClassificationRules(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to load classification rules from database, using default rules")
 	}
 
-	// Medium confidence - unknown
-	return "unknown"
+	go a.classificationRulesRefreshLoop(ctx)
 }
 
-// simulateIFFCheck simulates an IFF (Identification Friend or Foe) check
-func (a *ClassifierAgent) simulateIFFCheck(detection *messages.Detection) bool {
-	// In production, this would query actual IFF systems
-	// For simulation, track IDs starting with 'F' are friendly
-	hash := detection.TrackID
-	if len(hash) > 0 && hash[0] == 'F' {
-		return true
+// classificationRulesRefreshLoop periodically reloads rules so edits apply without a restart
+func (a *ClassifierAgent) classificationRulesRefreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(ClassificationRulesRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.refreshClassificationRules(ctx); err != nil {
+				a.logger.Warn().Err(err).Msg("Failed to refresh classification rules")
+				a.RecordError("classification_rules_refresh_error")
+			}
+		}
 	}
-	return false
 }
 
-// isNeutralTrack checks if the track is from a known neutral entity
-func (a *ClassifierAgent) isNeutralTrack(detection *messages.Detection) bool {
-	// Track IDs starting with 'N' are neutral (commercial/civilian)
-	if len(detection.TrackID) > 0 && detection.TrackID[0] == 'N' {
-		return true
+// refreshClassificationRules replaces the active rules with the enabled rules in the database
+func (a *ClassifierAgent) refreshClassificationRules(ctx context.Context) error {
+	if a.db == nil {
+		return fmt.Errorf("no database connection")
 	}
-	return false
-}
 
-// checkHostilePatterns checks if the detection matches known hostile patterns
-func (a *ClassifierAgent) checkHostilePatterns(detection *messages.Detection, trackType string) bool {
-	// Simplified pattern matching
-	// In production, this would use ML models and threat databases
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	// High-speed missiles are assumed hostile unless identified
-	if trackType == "missile" && detection.Velocity.Speed > 500 {
-		return true
+	enabled := true
+	rows, err := a.db.ListClassificationRules(queryCtx, postgres.ClassificationRuleFilter{Enabled: &enabled})
+	if err != nil {
+		return err
 	}
 
-	// Tracks with specific ID patterns (simulation)
-	if len(detection.TrackID) > 0 && detection.TrackID[0] == 'H' {
-		return true
+	rules := make([]classify.Rule, 0, len(rows))
+	for _, row := range rows {
+		rule := row.ToRule()
+		if err := rule.Validate(); err != nil {
+			a.logger.Warn().Err(err).Msg("Skipping invalid classification rule")
+			continue
+		}
+		rules = append(rules, rule)
 	}
 
-	return false
+	a.rules.SetRules(rules)
+	a.rulesMu.Lock()
+	first := a.rulesSource != "database"
+	a.rulesSource = "database"
+	a.rulesLoadedAt = time.Now().UTC()
+	a.rulesMu.Unlock()
+
+	if first {
+		a.logger.Info().Int("rules", len(rules)).Msg("Loaded classification rules from database")
+	}
+	return nil
 }
 
 // adjustConfidence adjusts the confidence based on classification certainty
@@ -376,43 +563,40 @@ func (a *ClassifierAgent) IsPaused() bool {
 	return a.paused
 }
 
-// startHTTPServer starts the HTTP server for control API
-func (a *ClassifierAgent) startHTTPServer() {
-	r := chi.NewRouter()
-
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		AllowCredentials: true,
-	}))
-
-	r.Handle("/metrics", promhttp.HandlerFor(a.Metrics(), promhttp.HandlerOpts{}))
-	r.Get("/health", a.handleHealth)
-	r.Get("/api/v1/config", a.handleGetConfig)
-	r.Patch("/api/v1/config", a.handlePatchConfig)
+// serveAdmin serves the control API on the admin server until ctx is done
+func (a *ClassifierAgent) serveAdmin(ctx context.Context) {
+	admin := a.NewAdminServer()
+	admin.Get(agent.ConfigPath, a.handleGetConfig)
+	admin.Patch(agent.ConfigPath, a.handlePatchConfig)
+	admin.Get("/api/v1/rules", a.handleGetRules)
+	admin.Post("/api/v1/rules/reload", a.handleReloadRules)
 
-	a.logger.Info().Msg("Starting HTTP server on :9090")
-	if err := http.ListenAndServe(":9090", r); err != nil {
-		a.logger.Error().Err(err).Msg("HTTP server error")
+	if err := admin.Serve(ctx); err != nil {
+		a.logger.Error().Err(err).Msg("Admin server error")
 	}
 }
 
-func (a *ClassifierAgent) handleHealth(w http.ResponseWriter, r *http.Request) {
-	health := a.Health()
-	w.Header().Set("Content-Type", "application/json")
-	if health.Healthy {
-		w.WriteHeader(http.StatusOK)
-	} else {
-		w.WriteHeader(http.StatusServiceUnavailable)
-	}
-	json.NewEncoder(w).Encode(health)
-}
-
 func (a *ClassifierAgent) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	config := map[string]interface{}{
 		"paused": a.IsPaused(),
 	}
+	if h := a.hysteresis.Config(); h.Enabled() {
+		config["hysteresis"] = map[string]interface{}{
+			"min_consecutive": h.MinConsecutive,
+			"flip_margin":     h.Margin,
+			"state_ttl":       h.StateTTL.String(),
+			"state_bucket":    a.stateBucket,
+		}
+	}
+	if a.model != nil {
+		opts := a.model.Options()
+		config["model"] = map[string]interface{}{
+			"url":            opts.URL,
+			"mode":           opts.Mode,
+			"timeout":        opts.Timeout.String(),
+			"min_confidence": opts.MinConfidence,
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(config)
 }
@@ -422,7 +606,7 @@ func (a *ClassifierAgent) handlePatchConfig(w http.ResponseWriter, r *http.Reque
 		Paused *bool `json:"paused"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		problem.Write(w, problem.New(problem.CodeValidation, "Invalid JSON").For(r))
 		return
 	}
 
@@ -434,14 +618,81 @@ func (a *ClassifierAgent) handlePatchConfig(w http.ResponseWriter, r *http.Reque
 	a.handleGetConfig(w, r)
 }
 
+func (a *ClassifierAgent) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	a.rulesMu.RLock()
+	source, loadedAt := a.rulesSource, a.rulesLoadedAt
+	a.rulesMu.RUnlock()
+
+	response := map[string]interface{}{
+		"source": source,
+		"rules":  a.rules.Rules(),
+	}
+	if !loadedAt.IsZero() {
+		response["loaded_at"] = loadedAt
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *ClassifierAgent) handleReloadRules(w http.ResponseWriter, r *http.Request) {
+	if err := a.refreshClassificationRules(r.Context()); err != nil {
+		problem.Write(w, problem.New(problem.CodeUpstreamUnavailable, fmt.Sprintf("Failed to reload classification rules: %v", err)).For(r))
+		return
+	}
+	a.handleGetRules(w, r)
+}
+
 func main() {
-	// Configuration from environment
+	// Configuration from environment; secrets may also come from *_FILE
+	// variables or mounted secret files
+	secrets := config.NewLoader()
 	cfg := agent.Config{
 		ID:      getEnv("AGENT_ID", "classifier-"+uuid.New().String()[:8]),
 		Type:    agent.AgentTypeClassifier,
 		NATSUrl: getEnv("NATS_URL", "nats://localhost:4222"),
 		OPAUrl:  getEnv("OPA_URL", "http://localhost:8181"),
-		Secret:  []byte(getEnv("AGENT_SECRET", "classifier-secret")),
+		DBUrl:   secrets.DatabaseURL("POSTGRES_URL", ""),
+		OTELUrl: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		ExtraVars: map[string]string{
+			"DRAIN_TIMEOUT":            getEnv("DRAIN_TIMEOUT", ""),
+			"METRICS_ADDR":             getEnv("METRICS_ADDR", ""),
+			"ADMIN_SHUTDOWN_TIMEOUT":   getEnv("ADMIN_SHUTDOWN_TIMEOUT", ""),
+			"SEED":                     getEnv("SEED", ""),
+			"HEARTBEAT_INTERVAL":       getEnv("HEARTBEAT_INTERVAL", ""),
+			"CONSUMER_LAG_INTERVAL":    getEnv("CONSUMER_LAG_INTERVAL", ""),
+			"HUB_JS_DOMAIN":            getEnv("HUB_JS_DOMAIN", ""),
+			"LINK_CHECK_INTERVAL":      getEnv("LINK_CHECK_INTERVAL", ""),
+			"MAX_DETECTIONS_PER_SEC":   getEnv("MAX_DETECTIONS_PER_SEC", ""),
+			"CHAOS_ENABLED":            getEnv("CHAOS_ENABLED", ""),
+			"DB_MIGRATE":               getEnv("DB_MIGRATE", ""),
+			"MESSAGE_ENCODING":         getEnv("MESSAGE_ENCODING", ""),
+			"DATA_CLASSIFICATION":      getEnv("DATA_CLASSIFICATION", ""),
+			"DATA_RELEASABILITY":       getEnv("DATA_RELEASABILITY", ""),
+			"ENCRYPT_CLASSIFICATIONS":  getEnv("ENCRYPT_CLASSIFICATIONS", ""),
+			"PAYLOAD_KEYS":             secrets.Secret("PAYLOAD_KEYS", ""),
+			"PAYLOAD_KEY_ID":           getEnv("PAYLOAD_KEY_ID", ""),
+			"SIGNATURE_POLICY":         getEnv("SIGNATURE_POLICY", ""),
+			"SIGNING_KEY_LIFETIME":     getEnv("SIGNING_KEY_LIFETIME", ""),
+			"SIGNING_KEY_OVERLAP":      getEnv("SIGNING_KEY_OVERLAP", ""),
+			"STREAM_POLICY_FILE":       getEnv("STREAM_POLICY_FILE", ""),
+			"MODEL_URL":                getEnv("MODEL_URL", ""),
+			"MODEL_TIMEOUT":            getEnv("MODEL_TIMEOUT", ""),
+			"MODEL_MODE":               getEnv("MODEL_MODE", ""),
+			"MODEL_MIN_CONFIDENCE":     getEnv("MODEL_MIN_CONFIDENCE", ""),
+			"CLASSIFY_MIN_CONSECUTIVE": getEnv("CLASSIFY_MIN_CONSECUTIVE", ""),
+			"CLASSIFY_FLIP_MARGIN":     getEnv("CLASSIFY_FLIP_MARGIN", ""),
+			"CLASSIFY_STATE_TTL":       getEnv("CLASSIFY_STATE_TTL", ""),
+			"CLASSIFY_STATE_BUCKET":    getEnv("CLASSIFY_STATE_BUCKET", ""),
+			"GEOCELL_PRECISION":        getEnv("GEOCELL_PRECISION", ""),
+			"GEOCELL_MARGIN_METERS":    getEnv("GEOCELL_MARGIN_METERS", ""),
+			"WORKER_CONCURRENCY":       getEnv("WORKER_CONCURRENCY", ""),
+		},
+		NATSSecurity: natsutil.SecurityFromEnv(),
+		PostgresTLS:  postgres.TLSConfigFromEnv(),
+	}
+	if err := secrets.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid secrets: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Create agent
@@ -459,8 +710,8 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start HTTP server for control API
-	go classifier.startHTTPServer()
+	// Start the admin server for the control API
+	go classifier.serveAdmin(ctx)
 
 	// Run agent
 	go func() {
@@ -473,6 +724,11 @@ func main() {
 	// Wait for shutdown signal
 	sig := <-sigChan
 	classifier.logger.Info().Str("signal", sig.String()).Msg("Received shutdown signal")
+
+	// Stop fetching and let in-flight messages finish before tearing down
+	if err := classifier.Drain(context.Background()); err != nil {
+		classifier.logger.Warn().Err(err).Msg("Shutting down with messages still in flight")
+	}
 	cancel()
 
 	// Graceful shutdown
@@ -483,9 +739,50 @@ func main() {
 		classifier.logger.Error().Err(err).Msg("Error during shutdown")
 	}
 
+	if classifier.db != nil {
+		classifier.db.Close()
+	}
+
 	classifier.logger.Info().Msg("Classifier agent stopped")
 }
 
+// classifierCapabilities describes the classifier agent for capability discovery
+func classifierCapabilities() agent.Capabilities {
+	return agent.Capabilities{
+		Messages: []agent.MessageCapability{
+			{Type: "detection", Subject: "detect.>", Stream: "DETECTIONS", Direction: agent.DirectionConsumes},
+			{Type: "track", Subject: "track.classified.<classification>[.<cell>]", Stream: "TRACKS", Direction: agent.DirectionProduces},
+			{Type: "track", Subject: "track.handoff.<classification>.<cell>", Stream: "TRACKS", Direction: agent.DirectionProduces},
+			{Type: "admission_shed", Subject: "notify.admission.detection", Stream: "NOTIFICATIONS", Direction: agent.DirectionProduces},
+		},
+		ConfigSchema: append([]agent.ConfigField{
+			{Name: "postgres_url", Type: "url", Env: "POSTGRES_URL", Description: "PostgreSQL URL for classification rules; the built-in rules are used without it"},
+			agent.DBMigrateConfig,
+			{Name: "paused", Type: "bool", Default: "false", Description: "Pause classification", Runtime: true},
+			{Name: "model_url", Type: "url", Env: "MODEL_URL", Description: "Model-serving endpoint that returns class probabilities for detection features; unset uses the rules only"},
+			{Name: "model_timeout", Type: "duration", Env: "MODEL_TIMEOUT", Default: inference.DefaultTimeout.String(), Description: "Deadline for a model request before falling back to the rules"},
+			{Name: "model_mode", Type: "string", Env: "MODEL_MODE", Default: inference.ModeActive, Description: "active uses confident predictions; shadow only logs and measures disagreement with the rules"},
+			{Name: "model_min_confidence", Type: "float", Env: "MODEL_MIN_CONFIDENCE", Default: "0.6", Description: "Top class probability required to use a prediction in active mode"},
+			{Name: "classify_min_consecutive", Type: "int", Env: "CLASSIFY_MIN_CONSECUTIVE", Default: "3", Description: "Consecutive detections that must agree before a track's classification changes (1 disables hysteresis)"},
+			{Name: "classify_flip_margin", Type: "float", Env: "CLASSIFY_FLIP_MARGIN", Default: "0.2", Description: "Confidence lead over the current classification that changes it at once (0 disables)"},
+			{Name: "classify_state_ttl", Type: "duration", Env: "CLASSIFY_STATE_TTL", Default: classify.DefaultStateTTL.String(), Description: "Idle time after which a track's classification history is forgotten"},
+			{Name: "classify_state_bucket", Type: "string", Env: "CLASSIFY_STATE_BUCKET", Description: "JetStream KV bucket for classification history shared by classifier instances; unset keeps it in memory"},
+			{Name: "geocell_precision", Type: "int", Env: "GEOCELL_PRECISION", Default: "0", Description: "Geohash characters of the cell stamped onto track subjects for sharded correlators (0 disables, at most 6)"},
+			{Name: "geocell_margin_meters", Type: "float", Env: "GEOCELL_MARGIN_METERS", Default: "1000", Description: "Distance from a neighbouring cell within which a track is also handed off to it"},
+			{Name: "max_detections_per_sec", Type: "int", Env: "MAX_DETECTIONS_PER_SEC", Default: "500", Description: "Detections admitted per second; the lowest-threat are shed first above it (0 disables)"},
+		}, agent.EdgeLinkConfig...),
+		Commands: []agent.ControlCommand{
+			{Name: "pause", Method: http.MethodPatch, Path: "/api/v1/config", Description: "Pause or resume classification with {\"paused\": bool}"},
+			{Name: "reload_rules", Method: http.MethodPost, Path: "/api/v1/rules/reload", Description: "Apply classification rule edits without waiting for the next refresh"},
+		},
+		Routes: []agent.Route{
+			{Method: http.MethodPatch, Path: "/api/v1/config", Description: "Update classifier configuration"},
+			{Method: http.MethodGet, Path: "/api/v1/rules", Description: "Active type and classification rules"},
+			{Method: http.MethodPost, Path: "/api/v1/rules/reload", Description: "Reload classification rules from the database now"},
+		},
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
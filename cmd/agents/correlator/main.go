@@ -6,22 +6,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
-	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/agile-defense/cjadc2/pkg/admission"
 	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/bda"
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/federation"
+	"github.com/agile-defense/cjadc2/pkg/geo"
 	"github.com/agile-defense/cjadc2/pkg/messages"
 	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/picture"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/threat"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
+	"github.com/agile-defense/cjadc2/pkg/trackid"
+	"github.com/agile-defense/cjadc2/pkg/trackshard"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
@@ -29,30 +39,80 @@ const (
 	WindowDuration = 10 * time.Second
 	// CleanupInterval is how often to clean expired tracks from the window
 	CleanupInterval = 5 * time.Second
-	// PositionThresholdMeters is the max distance to consider tracks as the same entity
+	// PositionThresholdMeters is the default max distance to consider tracks as the same entity
 	PositionThresholdMeters = 500.0
+	// ThreatRulesRefreshInterval is how often scoring rules are reloaded from the database
+	ThreatRulesRefreshInterval = 30 * time.Second
+	// NeutralizedRetention is how long a neutralized track stays suppressed
+	NeutralizedRetention = 24 * time.Hour
+	// PictureWriteTimeout bounds each write to the persisted track picture
+	PictureWriteTimeout = 2 * time.Second
+	// SplitDistanceFactor is how many position thresholds a sensor track must
+	// be from another sharing its system track ID before it splits off, so
+	// tracks hovering around the merge distance do not merge and split by turns
+	SplitDistanceFactor = 2.0
 )
 
 // TrackWindow holds tracks within the correlation window
 type TrackWindow struct {
 	mu     sync.RWMutex
 	tracks map[string]*trackEntry
+
+	// Max distance between merged tracks, set at runtime by position_threshold_meters
+	thresholdMeters float64
 }
 
 type trackEntry struct {
-	track     *messages.Track
-	expiresAt time.Time
-	merged    bool
+	track      *messages.Track
+	correlated *messages.CorrelatedTrack // Last correlated track fused from track
+	assignment trackid.Assignment        // System track the sensor track belongs to
+	expiresAt  time.Time
+	merged     bool
+	foreign    bool // Handed off from a cell another shard owns; a merge candidate only
+}
+
+// pictureEntry converts a window entry for the persisted picture
+func (e *trackEntry) pictureEntry() picture.Entry {
+	return picture.Entry{
+		Track:      e.track,
+		Correlated: e.correlated,
+		ExpiresAt:  e.expiresAt,
+		Merged:     e.merged,
+	}
 }
 
 // CorrelatorAgent correlates and deduplicates tracks
 type CorrelatorAgent struct {
 	*agent.BaseAgent
 	logger          zerolog.Logger
-	consumer        jetstream.Consumer
 	window          *TrackWindow
+	picture         *picture.Picture  // Persisted copy of the window; nil until Run
+	trackIDs        *trackid.Registry // Sensor track IDs to system track IDs; stored from Run
+	threatEngine    *threat.Engine
+	zones           []geo.Zone
+	zonesMu         sync.RWMutex
+	db              *pgxpool.Pool
 	correlatedGauge prometheus.Gauge
 	mergedCounter   prometheus.Counter
+	splitCounter    prometheus.Counter
+	conflictCounter *prometheus.CounterVec
+	threatScoreHist prometheus.Histogram
+
+	// Admission control; activeTracks is nil when unlimited
+	maxActiveTracks int
+	activeTracks    *admission.ActiveSet
+	shedTotal       *prometheus.CounterVec
+
+	// Battle damage assessment: neutralized track IDs and when they were neutralized
+	neutralized       map[string]time.Time
+	neutralizedMu     sync.RWMutex
+	neutralizedGauge  prometheus.Gauge
+	suppressedCounter prometheus.Counter
+
+	// Geographic sharding; shard is nil when this correlator takes every cell
+	shard          *trackshard.Shard
+	consumerCfg    jetstream.ConsumerConfig
+	handoffCounter prometheus.Counter
 }
 
 // NewCorrelatorAgent creates a new correlator agent
@@ -61,6 +121,7 @@ func NewCorrelatorAgent(cfg agent.Config) (*CorrelatorAgent, error) {
 	if err != nil {
 		return nil, err
 	}
+	base.SetCapabilities(correlatorCapabilities())
 
 	// Additional metrics for correlation
 	correlatedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
@@ -73,15 +134,90 @@ func NewCorrelatorAgent(cfg agent.Config) (*CorrelatorAgent, error) {
 		Help: "Total number of tracks merged",
 	})
 
-	base.Metrics().MustRegister(correlatedGauge, mergedCounter)
+	splitCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "correlator_tracks_split_total",
+		Help: "Total number of sensor tracks split off a system track they no longer correlate with",
+	})
+
+	conflictCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "correlator_federation_conflicts_total",
+		Help: "Fields on which merged reports of a track from different enclaves disagreed",
+	}, []string{"field"})
+
+	threatScoreHist := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "correlator_threat_score",
+		Help:    "Distribution of computed track threat scores",
+		Buckets: []float64{10, 20, 35, 50, 60, 75, 85, 100, 125},
+	})
 
-	return &CorrelatorAgent{
+	shedTotal := admission.NewShedCounter()
+
+	neutralizedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "correlator_neutralized_tracks",
+		Help: "Number of tracks suppressed after a battle damage assessment neutralized them",
+	})
+
+	suppressedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "correlator_tracks_suppressed_total",
+		Help: "Total number of track updates dropped because the track was neutralized",
+	})
+
+	handoffCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "correlator_handoff_tracks_total",
+		Help: "Total number of border copies of tracks in neighbouring cells held as merge candidates",
+	})
+
+	base.Metrics().MustRegister(correlatedGauge, mergedCounter, splitCounter, conflictCounter, threatScoreHist, shedTotal, neutralizedGauge, suppressedCounter, handoffCounter)
+
+	maxActiveTracks, err := admission.ParseLimit("MAX_ACTIVE_TRACKS", cfg.ExtraVars["MAX_ACTIVE_TRACKS"], admission.DefaultMaxActiveTracks)
+	if err != nil {
+		return nil, err
+	}
+
+	reuseGap, err := trackid.ParseReuseGap(cfg.ExtraVars["TRACK_ID_REUSE_GAP"])
+	if err != nil {
+		return nil, err
+	}
+	trackIDs := trackid.New(nil, reuseGap)
+	base.Metrics().MustRegister(trackIDs.Collectors()...)
+
+	shard, err := trackshard.ParseShard(cfg.ExtraVars["CORRELATOR_SHARD"], cfg.ExtraVars["CORRELATOR_CELLS"])
+	if err != nil {
+		return nil, err
+	}
+	consumerCfg := natsutil.ConsumerConfigFor("correlator")
+	if shard != nil {
+		consumerCfg = natsutil.CorrelatorShardConsumer(shard.Name, shard.Cells)
+		base.SetConsumerName(consumerCfg.Durable)
+	}
+
+	a := &CorrelatorAgent{
 		BaseAgent:       base,
 		logger:          *base.Logger(),
-		window:          &TrackWindow{tracks: make(map[string]*trackEntry)},
+		window:          &TrackWindow{tracks: make(map[string]*trackEntry), thresholdMeters: PositionThresholdMeters},
+		threatEngine:    threat.NewDefaultEngine(),
+		trackIDs:        trackIDs,
 		correlatedGauge: correlatedGauge,
 		mergedCounter:   mergedCounter,
-	}, nil
+		splitCounter:    splitCounter,
+		conflictCounter: conflictCounter,
+		threatScoreHist: threatScoreHist,
+		maxActiveTracks: maxActiveTracks,
+		shedTotal:       shedTotal,
+
+		neutralized:       make(map[string]time.Time),
+		neutralizedGauge:  neutralizedGauge,
+		suppressedCounter: suppressedCounter,
+
+		shard:          shard,
+		consumerCfg:    consumerCfg,
+		handoffCounter: handoffCounter,
+	}
+	if maxActiveTracks > 0 {
+		a.activeTracks = admission.NewActiveSet(maxActiveTracks, admission.ActiveTrackTTL)
+	}
+	base.RuntimeConfig().WatchFloat("position_threshold_meters", PositionThresholdMeters, a.setPositionThreshold)
+	return a, nil
 }
 
 // Run starts the correlator agent
@@ -92,21 +228,44 @@ func (a *CorrelatorAgent) Run(ctx context.Context) error {
 	}
 
 	// Ensure streams exist
-	if err := natsutil.SetupStreams(ctx, a.JetStream()); err != nil {
+	if err := natsutil.SetupStreams(ctx, a.JetStream(), a.StreamPolicies()); err != nil {
 		return fmt.Errorf("failed to setup streams: %w", err)
 	}
 
-	// Create consumer for classified tracks
-	consumer, err := natsutil.SetupConsumer(ctx, a.JetStream(), "TRACKS", "correlator")
+	// Load threat scoring rules (file, then database, then built-in defaults)
+	a.loadThreatRules(ctx)
+
+	// System track IDs outlive the correlation window, so a sensor track that
+	// returns keeps the identity it had
+	idKV, err := trackid.EnsureBucket(ctx, a.JetStream())
 	if err != nil {
-		return fmt.Errorf("failed to setup consumer: %w", err)
+		return fmt.Errorf("failed to setup track IDs: %w", err)
+	}
+	a.trackIDs.SetStore(idKV)
+
+	// Resume the correlation window persisted by a previous run. This happens
+	// before following assessments so replayed neutralizations remove restored tracks.
+	kv, err := picture.EnsureBucket(ctx, a.JetStream())
+	if err != nil {
+		return fmt.Errorf("failed to setup track picture: %w", err)
+	}
+	a.picture = picture.New(kv)
+	a.restoreWindow(ctx)
+
+	// Follow battle damage assessments so neutralized tracks are not
+	// re-proposed, acting only on those signed as SIGNATURE_POLICY requires
+	if err := bda.Watch(ctx, a.JetStream(), a.Verifier(), a.markNeutralized); err != nil {
+		return fmt.Errorf("failed to watch assessments: %w", err)
 	}
-	a.consumer = consumer
 
 	// Start window cleanup goroutine
 	go a.cleanupLoop(ctx)
 
-	a.logger.Info().Msg("Correlator agent started, consuming from TRACKS stream")
+	if a.shard != nil {
+		a.logger.Info().Str("shard", a.shard.Name).Strs("cells", a.shard.Cells).Msg("Correlator shard started, consuming its cells from TRACKS stream")
+	} else {
+		a.logger.Info().Msg("Correlator agent started, consuming from TRACKS stream")
+	}
 
 	// Start consuming messages
 	return a.consumeMessages(ctx)
@@ -122,141 +281,382 @@ func (a *CorrelatorAgent) cleanupLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			a.cleanupWindow()
+			a.cleanupWindow(ctx)
 		}
 	}
 }
 
-// cleanupWindow removes expired tracks
-func (a *CorrelatorAgent) cleanupWindow() {
+// restoreWindow loads the persisted picture into the window, dropping tracks
+// that expired while the correlator was down
+func (a *CorrelatorAgent) restoreWindow(ctx context.Context) {
+	entries, err := a.picture.Load(ctx)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to load track picture, starting with an empty window")
+		a.RecordError("picture_error")
+		return
+	}
+
+	now := a.SimClock().Now()
+	var expired []string
+
 	a.window.mu.Lock()
-	defer a.window.mu.Unlock()
+	for _, e := range entries {
+		if now.After(e.ExpiresAt) {
+			expired = append(expired, e.Track.TrackID)
+			continue
+		}
+		// The picture is shared by every shard; other shards restore their own tracks
+		if !a.shard.OwnsPosition(e.Track.Position) {
+			continue
+		}
+		a.window.tracks[e.Track.TrackID] = &trackEntry{
+			track:      e.Track,
+			correlated: e.Correlated,
+			assignment: a.restoredAssignment(ctx, e),
+			expiresAt:  e.ExpiresAt,
+			merged:     e.Merged,
+		}
+	}
+	restored := len(a.window.tracks)
+	a.correlatedGauge.Set(float64(restored))
+	a.window.mu.Unlock()
 
-	now := time.Now()
-	for id, entry := range a.window.tracks {
-		if now.After(entry.expiresAt) {
-			delete(a.window.tracks, id)
+	a.deletePicture(ctx, expired...)
+
+	a.logger.Info().
+		Int("restored", restored).
+		Int("expired", len(expired)).
+		Msg("Restored correlation window from track picture")
+}
+
+// restoredAssignment returns the system track a restored window entry belongs
+// to, preferring the stored assignment over the ID on its correlated track
+func (a *CorrelatorAgent) restoredAssignment(ctx context.Context, e picture.Entry) trackid.Assignment {
+	stored, err := a.trackIDs.Lookup(ctx, e.Track.TrackID)
+	if err == nil && stored != nil {
+		return *stored
+	}
+	restored := trackid.Assignment{SensorTrackID: e.Track.TrackID, SystemTrackID: e.Track.TrackID}
+	if e.Correlated != nil {
+		restored.SystemTrackID = e.Correlated.TrackID
+		restored.LastSeen = e.Correlated.LastUpdated
+		restored.Position = e.Correlated.Position
+	}
+	return restored
+}
+
+// persistPicture writes the window entries for the given tracks to the
+// persisted picture. Tracks handed off from other shards are theirs to persist.
+func (a *CorrelatorAgent) persistPicture(ctx context.Context, trackIDs ...string) {
+	if a.picture == nil {
+		return
+	}
+
+	a.window.mu.RLock()
+	entries := make([]picture.Entry, 0, len(trackIDs))
+	seen := make(map[string]bool, len(trackIDs))
+	for _, id := range trackIDs {
+		if entry, ok := a.window.tracks[id]; ok && !entry.foreign && !seen[id] {
+			seen[id] = true
+			entries = append(entries, entry.pictureEntry())
 		}
 	}
+	a.window.mu.RUnlock()
 
-	a.correlatedGauge.Set(float64(len(a.window.tracks)))
+	ctx, cancel := context.WithTimeout(ctx, PictureWriteTimeout)
+	defer cancel()
+	for _, entry := range entries {
+		if err := a.picture.Put(ctx, entry); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to persist track picture")
+			a.RecordError("picture_error")
+			return
+		}
+	}
 }
 
-// consumeMessages processes track messages
-func (a *CorrelatorAgent) consumeMessages(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+// deletePicture removes tracks that left the window from the persisted picture
+func (a *CorrelatorAgent) deletePicture(ctx context.Context, trackIDs ...string) {
+	if a.picture == nil || len(trackIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, PictureWriteTimeout)
+	defer cancel()
+	for _, id := range trackIDs {
+		if err := a.picture.Delete(ctx, id); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to remove track from picture")
+			a.RecordError("picture_error")
+			return
 		}
+	}
+}
 
-		// Fetch messages with timeout
-		msgs, err := a.consumer.Fetch(10, jetstream.FetchMaxWait(5*time.Second))
-		if err != nil {
-			if err == context.DeadlineExceeded || err == context.Canceled {
-				continue
-			}
-			// Check if consumer was deleted and needs to be recreated
-			errStr := err.Error()
-			if strings.Contains(errStr, "no responders") || strings.Contains(errStr, "consumer not found") || strings.Contains(errStr, "consumer deleted") {
-				a.logger.Warn().Err(err).Msg("Consumer was deleted, recreating...")
-				consumer, recreateErr := natsutil.SetupConsumer(ctx, a.JetStream(), "TRACKS", "correlator")
-				if recreateErr != nil {
-					a.logger.Error().Err(recreateErr).Msg("Failed to recreate consumer")
-					a.RecordError("consumer_recreate_error")
-					time.Sleep(time.Second)
-					continue
-				}
-				a.consumer = consumer
-				a.logger.Info().Msg("Consumer recreated successfully")
-				continue
+// cleanupWindow removes expired tracks
+func (a *CorrelatorAgent) cleanupWindow(ctx context.Context) {
+	a.window.mu.Lock()
+	now := a.SimClock().Now()
+	var expired []string
+	for id, entry := range a.window.tracks {
+		if now.After(entry.expiresAt) {
+			delete(a.window.tracks, id)
+			if !entry.foreign {
+				expired = append(expired, id)
 			}
-			a.logger.Error().Err(err).Msg("Failed to fetch messages")
-			a.RecordError("fetch_error")
-			time.Sleep(time.Second)
-			continue
 		}
+	}
+	a.correlatedGauge.Set(float64(len(a.window.tracks)))
+	a.window.mu.Unlock()
 
-		for msg := range msgs.Messages() {
-			if err := a.processMessage(ctx, msg); err != nil {
-				a.logger.Error().Err(err).Msg("Failed to process message")
-				a.RecordError("process_error")
-				msg.Nak()
-			} else {
-				msg.Ack()
-			}
+	a.deletePicture(ctx, expired...)
+
+	// Tracks idle past the reuse gap get a new system ID if they return, so
+	// their cached assignments are no longer needed
+	a.trackIDs.Forget(now.Add(-a.trackIDs.ReuseGap()))
+
+	a.neutralizedMu.Lock()
+	defer a.neutralizedMu.Unlock()
+
+	cutoff := time.Now().Add(-NeutralizedRetention)
+	for id, at := range a.neutralized {
+		if at.Before(cutoff) {
+			delete(a.neutralized, id)
 		}
+	}
+	a.neutralizedGauge.Set(float64(len(a.neutralized)))
+}
 
-		if msgs.Error() != nil && msgs.Error() != context.DeadlineExceeded {
-			errStr := msgs.Error().Error()
-			// Check if consumer was deleted and needs to be recreated
-			if strings.Contains(errStr, "no responders") || strings.Contains(errStr, "consumer not found") || strings.Contains(errStr, "consumer deleted") {
-				a.logger.Warn().Err(msgs.Error()).Msg("Consumer was deleted (batch error), recreating...")
-				consumer, recreateErr := natsutil.SetupConsumer(ctx, a.JetStream(), "TRACKS", "correlator")
-				if recreateErr != nil {
-					a.logger.Error().Err(recreateErr).Msg("Failed to recreate consumer")
-					a.RecordError("consumer_recreate_error")
-				} else {
-					a.consumer = consumer
-					a.logger.Info().Msg("Consumer recreated successfully")
-				}
-				continue
-			}
-			a.logger.Warn().Err(msgs.Error()).Msg("Message batch error")
+// markNeutralized records a track neutralized by an executed effect and drops it
+// from the correlation window and active track set
+func (a *CorrelatorAgent) markNeutralized(assessment *messages.EffectAssessment) {
+	if !assessment.Neutralized || assessment.TrackID == "" {
+		return
+	}
+
+	a.neutralizedMu.Lock()
+	a.neutralized[assessment.TrackID] = assessment.AssessedAt
+	a.neutralizedGauge.Set(float64(len(a.neutralized)))
+	a.neutralizedMu.Unlock()
+
+	// Assessments name the system track; the window holds its sensor tracks
+	a.window.mu.Lock()
+	removed := []string{}
+	for id, entry := range a.window.tracks {
+		if id == assessment.TrackID || entry.assignment.SystemTrackID == assessment.TrackID {
+			delete(a.window.tracks, id)
+			removed = append(removed, id)
 		}
 	}
+	a.window.mu.Unlock()
+	a.deletePicture(context.Background(), removed...)
+
+	if a.activeTracks != nil {
+		a.activeTracks.Remove(assessment.TrackID)
+	}
+
+	a.logger.Info().
+		Str("correlation_id", assessment.Envelope.CorrelationID).
+		Str("track_id", assessment.TrackID).
+		Str("effect_id", assessment.EffectID).
+		Str("action_type", assessment.ActionType).
+		Float64("success_probability", assessment.SuccessProbability).
+		Msg("Track neutralized, suppressing further updates")
+}
+
+// isNeutralized reports whether an assessment has neutralized the track
+func (a *CorrelatorAgent) isNeutralized(trackID string) bool {
+	a.neutralizedMu.RLock()
+	defer a.neutralizedMu.RUnlock()
+	_, ok := a.neutralized[trackID]
+	return ok
+}
+
+// consumeMessages processes classified tracks from a consumer in the edge
+// domain when the classifier runs on a leaf node; a shard reads only its own
+// cells
+func (a *CorrelatorAgent) consumeMessages(ctx context.Context) error {
+	return a.Consume(ctx, "TRACKS", a.consumerCfg.Durable, a.processMessage, agent.ConsumeOptions{
+		Config:   &a.consumerCfg,
+		Upstream: true,
+		Schema:   messages.SchemaTrack,
+	})
 }
 
 // processMessage handles a single track message
-func (a *CorrelatorAgent) processMessage(ctx context.Context, msg jetstream.Msg) error {
+func (a *CorrelatorAgent) processMessage(ctx context.Context, msg jetstream.Msg) (err error) {
 	start := time.Now()
 
 	// Parse track
 	var track messages.Track
-	if err := json.Unmarshal(msg.Data(), &track); err != nil {
-		return fmt.Errorf("failed to unmarshal track: %w", err)
+	if err := agent.Decode(msg, &track); err != nil {
+		return agent.Permanent(fmt.Errorf("failed to unmarshal track: %w", err))
 	}
 
+	ctx, span := tracing.StartFromEnvelope(ctx, "correlator.correlate", track.Envelope,
+		tracing.AttrTrackID.String(track.TrackID))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	correlationID := track.Envelope.CorrelationID
 	if correlationID == "" {
 		correlationID = track.Envelope.MessageID
 	}
 
+	// A border copy from a neighbouring cell is only a merge candidate; the
+	// shard owning the track's cell reports it
+	if trackshard.IsHandoff(msg.Subject()) {
+		span.SetAttributes(attribute.String("cjadc2.outcome", "handoff"))
+		return a.holdHandoff(ctx, &track)
+	}
+
 	a.logger.Info().
 		Str("correlation_id", correlationID).
 		Str("track_id", track.TrackID).
 		Str("classification", track.Classification).
 		Msg("Processing classified track")
 
-	// Correlate with existing tracks
-	correlatedTrack, mergedTrackIDs := a.correlate(&track)
+	// A track arriving from another shard's cell may have had its system
+	// track ID changed there
+	if a.shard != nil && !a.ownsWindowEntry(track.TrackID) {
+		a.trackIDs.Evict(track.TrackID)
+	}
+
+	// Resolve the sensor's track ID to the system track ID used downstream
+	assignment, reason, err := a.trackIDs.Resolve(ctx, track.TrackID, track.Position, a.SimClock().Now())
+	if err != nil {
+		return fmt.Errorf("failed to resolve system track ID: %w", err)
+	}
+	if reason != "" {
+		a.logger.Info().
+			Str("correlation_id", correlationID).
+			Str("sensor_track_id", track.TrackID).
+			Str("system_track_id", assignment.SystemTrackID).
+			Str("reason", reason).
+			Msg("Assigned system track ID")
+	}
 
-	// Determine threat level
-	correlatedTrack.ThreatLevel = a.determineThreatLevel(correlatedTrack)
+	// A sensor track that has moved away from the others reported under its
+	// system track ID is a different object and takes an ID of its own
+	if a.diverged(&track, assignment) {
+		splitFrom := assignment.SystemTrackID
+		assignment, err = a.trackIDs.Split(ctx, assignment, a.SimClock().Now())
+		if err != nil {
+			return fmt.Errorf("failed to split system track ID: %w", err)
+		}
+		a.splitCounter.Inc()
+		a.publishTrackEvent(ctx, &messages.TrackSplit{
+			Envelope:      messages.NewEnvelope(a.ID(), "correlator").WithCorrelation(correlationID, track.Envelope.MessageID).WithLabels(track.Envelope),
+			TrackID:       assignment.SystemTrackID,
+			SplitFrom:     splitFrom,
+			SensorTrackID: track.TrackID,
+			SplitAt:       time.Now().UTC(),
+		})
+		a.logger.Info().
+			Str("correlation_id", correlationID).
+			Str("sensor_track_id", track.TrackID).
+			Str("split_from", splitFrom).
+			Str("system_track_id", assignment.SystemTrackID).
+			Msg("Sensor track split from its system track")
+	}
+
+	// A neutralized track is no longer forwarded, so the planner raises no
+	// further proposals against it
+	if a.isNeutralized(assignment.SystemTrackID) {
+		a.suppressedCounter.Inc()
+		a.RecordMessage("suppressed", "track")
+		span.SetAttributes(attribute.String("cjadc2.outcome", "neutralized"))
+		a.logger.Debug().
+			Str("correlation_id", correlationID).
+			Str("track_id", track.TrackID).
+			Msg("Dropping update for neutralized track")
+		return nil
+	}
+
+	// Correlate with existing tracks
+	fused := a.correlate(&track, assignment)
+	correlatedTrack, mergedTrackIDs := fused.correlated, fused.mergedTrackIDs
+	for _, id := range fused.adopt {
+		if err := a.trackIDs.Adopt(ctx, id, fused.survivor); err != nil {
+			a.logger.Warn().Err(err).Str("sensor_track_id", id).Msg("Failed to move sensor track to fused system track")
+			a.RecordError("track_id_error")
+		}
+	}
+	if len(fused.superseded) > 0 {
+		a.publishTrackEvent(ctx, &messages.TrackMerge{
+			Envelope:           messages.NewEnvelope(a.ID(), "correlator").WithCorrelation(correlationID, track.Envelope.MessageID).WithLabels(correlatedTrack.Envelope),
+			TrackID:            fused.survivor.SystemTrackID,
+			SupersededTrackIDs: fused.superseded,
+			SensorTrackIDs:     correlatedTrack.MergedFrom,
+			MergedAt:           time.Now().UTC(),
+		})
+		a.logger.Info().
+			Str("correlation_id", correlationID).
+			Str("system_track_id", fused.survivor.SystemTrackID).
+			Strs("superseded_track_ids", fused.superseded).
+			Msg("System tracks merged")
+	}
+	span.SetAttributes(attribute.String("cjadc2.system_track_id", correlatedTrack.TrackID))
+
+	// Assess proximity to protected assets and restricted zones, then score the track
+	correlatedTrack.ZoneProximity = a.assessZones(correlatedTrack)
+	score := a.threatEngine.Score(correlatedTrack)
+	correlatedTrack.ThreatScore = score.Score
+	correlatedTrack.ThreatLevel = score.Level
+	a.threatScoreHist.Observe(score.Score)
+	correlatedTrack.Envelope = tracing.InjectEnvelope(ctx, correlatedTrack.Envelope)
+	span.SetAttributes(
+		attribute.String("cjadc2.threat_level", correlatedTrack.ThreatLevel),
+		attribute.Float64("cjadc2.threat_score", correlatedTrack.ThreatScore),
+		attribute.Int("cjadc2.merged_count", len(mergedTrackIDs)),
+	)
+
+	// Persist the updated window so a restarted correlator resumes the fused picture
+	a.persistPicture(ctx, append([]string{track.TrackID}, mergedTrackIDs...)...)
 
 	a.logger.Info().
 		Str("correlation_id", correlationID).
 		Str("track_id", correlatedTrack.TrackID).
 		Str("threat_level", correlatedTrack.ThreatLevel).
+		Float64("threat_score", correlatedTrack.ThreatScore).
 		Int("merged_count", len(mergedTrackIDs)).
 		Msg("Track correlated")
 
-	// Publish to TRACKS stream with threat level
-	subject := correlatedTrack.Subject()
-	data, err := json.Marshal(correlatedTrack)
-	if err != nil {
-		return fmt.Errorf("failed to marshal correlated track: %w", err)
+	// Admission control: at the active track limit a new track only enters by
+	// displacing a less threatening one
+	if a.activeTracks != nil {
+		admitted, evicted := a.activeTracks.Admit(correlatedTrack.TrackID, correlatedTrack.ThreatScore, a.SimClock().Now())
+		if evicted != "" {
+			a.publishShed(ctx, &messages.AdmissionShed{
+				Envelope:    messages.NewEnvelope(a.ID(), "correlator").WithCorrelation(correlationID, correlatedTrack.Envelope.MessageID),
+				Reason:      admission.ReasonDisplaced,
+				TrackID:     evicted,
+				DisplacedBy: correlatedTrack.TrackID,
+			})
+		}
+		if !admitted {
+			a.publishShed(ctx, &messages.AdmissionShed{
+				Envelope:    messages.NewEnvelope(a.ID(), "correlator").WithCorrelation(correlationID, correlatedTrack.Envelope.MessageID),
+				Reason:      admission.ReasonCapacity,
+				TrackID:     correlatedTrack.TrackID,
+				ThreatLevel: correlatedTrack.ThreatLevel,
+			})
+			a.RecordMessage("shed", "track")
+			span.SetAttributes(attribute.String("cjadc2.outcome", "shed"))
+			return nil
+		}
 	}
 
-	_, err = a.JetStream().Publish(ctx, subject, data)
+	// Publish to TRACKS stream with threat level
+	subject := correlatedTrack.Subject()
+	_, err = a.PublishMessage(ctx, correlatedTrack)
 	if err != nil {
 		return fmt.Errorf("failed to publish correlated track: %w", err)
 	}
 
 	duration := time.Since(start)
 	a.RecordMessage("success", "track")
-	a.RecordLatency("track", duration)
+	a.RecordLatency(ctx, "track", duration)
 
 	a.logger.Info().
 		Str("correlation_id", correlationID).
@@ -267,12 +667,125 @@ func (a *CorrelatorAgent) processMessage(ctx context.Context, msg jetstream.Msg)
 	return nil
 }
 
-// correlate finds and merges related tracks within the window
-func (a *CorrelatorAgent) correlate(track *messages.Track) (*messages.CorrelatedTrack, []string) {
+// holdHandoff places a track handed off from a neighbouring cell in the window
+// as a merge candidate under its stored system track ID. A track this shard
+// reported until now has moved into the neighbouring cell, whose shard
+// persists and reports it from here on.
+func (a *CorrelatorAgent) holdHandoff(ctx context.Context, track *messages.Track) error {
+	a.trackIDs.Evict(track.TrackID)
+	assignment := trackid.Assignment{SensorTrackID: track.TrackID, SystemTrackID: track.TrackID}
+	stored, err := a.trackIDs.Lookup(ctx, track.TrackID)
+	if err != nil {
+		return fmt.Errorf("failed to look up system track ID: %w", err)
+	}
+	if stored != nil {
+		assignment = *stored
+	}
+	if a.isNeutralized(assignment.SystemTrackID) {
+		a.suppressedCounter.Inc()
+		a.RecordMessage("suppressed", "track")
+		return nil
+	}
+
+	a.window.mu.Lock()
+	a.window.tracks[track.TrackID] = &trackEntry{
+		track:      track,
+		assignment: assignment,
+		expiresAt:  a.SimClock().Now().Add(WindowDuration),
+		foreign:    true,
+	}
+	a.correlatedGauge.Set(float64(len(a.window.tracks)))
+	a.window.mu.Unlock()
+
+	a.handoffCounter.Inc()
+	a.RecordMessage("handoff", "track")
+	a.logger.Debug().
+		Str("track_id", track.TrackID).
+		Str("system_track_id", assignment.SystemTrackID).
+		Msg("Holding track handed off from a neighbouring cell")
+	return nil
+}
+
+// ownsWindowEntry reports whether the window holds a track this shard reports
+func (a *CorrelatorAgent) ownsWindowEntry(trackID string) bool {
+	a.window.mu.RLock()
+	defer a.window.mu.RUnlock()
+	entry, ok := a.window.tracks[trackID]
+	return ok && !entry.foreign
+}
+
+// publishShed counts and announces a track shed by admission control
+func (a *CorrelatorAgent) publishShed(ctx context.Context, shed *messages.AdmissionShed) {
+	shed.NotificationID = uuid.New().String()
+	shed.Kind = admission.KindTrack
+	shed.Count = 1
+	shed.Limit = a.maxActiveTracks
+	a.shedTotal.WithLabelValues(shed.Kind, shed.Reason, admission.RankForThreatLevel(shed.ThreatLevel).String()).Inc()
+
+	a.logger.Warn().
+		Str("correlation_id", shed.Envelope.CorrelationID).
+		Str("track_id", shed.TrackID).
+		Str("reason", shed.Reason).
+		Str("threat_level", shed.ThreatLevel).
+		Str("displaced_by", shed.DisplacedBy).
+		Int("limit", shed.Limit).
+		Msg("Track shed by admission control")
+
+	if _, err := a.PublishMessage(ctx, shed); err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to publish admission event")
+		a.RecordError("admission_event_error")
+	}
+}
+
+// publishTrackEvent announces a track merge or split. The events are signed
+// control messages on the TRACKS stream; a failure to publish one is logged
+// and the track itself is still published.
+func (a *CorrelatorAgent) publishTrackEvent(ctx context.Context, event messages.Message) {
+	if _, err := a.PublishMessage(ctx, event); err != nil {
+		a.logger.Warn().Err(err).Str("subject", event.Subject()).Msg("Failed to publish track event")
+		a.RecordError("track_event_error")
+	}
+}
+
+// diverged reports whether another sensor track in the window shares the
+// track's system track ID but is now more than SplitDistanceFactor position
+// thresholds away, so the two no longer describe one object
+func (a *CorrelatorAgent) diverged(track *messages.Track, assignment trackid.Assignment) bool {
+	a.window.mu.RLock()
+	defer a.window.mu.RUnlock()
+
+	limit := a.window.thresholdMeters * SplitDistanceFactor
+	for id, entry := range a.window.tracks {
+		if id == track.TrackID || entry.merged || entry.assignment.SystemTrackID != assignment.SystemTrackID {
+			continue
+		}
+		if geo.Distance(track.Position, entry.track.Position) > limit {
+			return true
+		}
+	}
+	return false
+}
+
+// fusion is the outcome of correlating one sensor track
+type fusion struct {
+	correlated     *messages.CorrelatedTrack
+	mergedTrackIDs []string           // Sensor track IDs fused with the track
+	survivor       trackid.Assignment // System track the fused track is reported under
+	adopt          []string           // Sensor track IDs that must adopt the survivor
+	superseded     []string           // System track IDs replaced by the survivor
+}
+
+// correlate finds and merges related tracks within the window. The fused track
+// takes the oldest system track ID among the tracks merged, returned as the
+// survivor along with the sensor track IDs that must adopt it and the system
+// track IDs it supersedes.
+func (a *CorrelatorAgent) correlate(track *messages.Track, assignment trackid.Assignment) fusion {
 	a.window.mu.Lock()
 	defer a.window.mu.Unlock()
 
-	now := time.Now()
+	// The window is measured in simulated time, so tracks do not age out
+	// during a pause and the window shortens in wall time at higher speeds
+	now := a.SimClock().Now()
 	windowStart := now.Add(-WindowDuration)
 	mergedTrackIDs := []string{}
 	mergedEntries := []*trackEntry{}
@@ -282,6 +795,10 @@ func (a *CorrelatorAgent) correlate(track *messages.Track) (*messages.Correlated
 		if entry.merged {
 			continue
 		}
+		// A reused sensor track ID no longer describes the object in the window
+		if id == track.TrackID && entry.assignment.SystemTrackID != assignment.SystemTrackID {
+			continue
+		}
 
 		// Check if tracks are within spatial threshold and same classification
 		if a.shouldMerge(track, entry.track) {
@@ -292,8 +809,38 @@ func (a *CorrelatorAgent) correlate(track *messages.Track) (*messages.Correlated
 		}
 	}
 
+	// The fused track keeps the oldest identity among those merged
+	assignments := []trackid.Assignment{assignment}
+	for _, entry := range mergedEntries {
+		assignments = append(assignments, entry.assignment)
+	}
+	survivor := trackid.Survivor(assignments...)
+	var superseded []string
+	seen := map[string]bool{survivor.SystemTrackID: true}
+	for _, as := range assignments {
+		if !seen[as.SystemTrackID] {
+			seen[as.SystemTrackID] = true
+			superseded = append(superseded, as.SystemTrackID)
+		}
+	}
+	var adopt []string
+	if assignment.SystemTrackID != survivor.SystemTrackID {
+		adopt = append(adopt, track.TrackID)
+	}
+	// Every sensor track of a superseded system track moves to the survivor,
+	// not only those merged now, so no superseded ID is reported again
+	for id, entry := range a.window.tracks {
+		if id == track.TrackID || !seen[entry.assignment.SystemTrackID] || entry.assignment.SystemTrackID == survivor.SystemTrackID {
+			continue
+		}
+		adopt = append(adopt, id)
+		entry.assignment.SystemTrackID, entry.assignment.AssignedAt = survivor.SystemTrackID, survivor.AssignedAt
+	}
+	assignment.SystemTrackID, assignment.AssignedAt = survivor.SystemTrackID, survivor.AssignedAt
+
 	// Create correlated track
 	correlatedTrack := messages.NewCorrelatedTrack(track, a.ID())
+	correlatedTrack.TrackID = survivor.SystemTrackID
 	correlatedTrack.WindowStart = windowStart
 	correlatedTrack.WindowEnd = now
 
@@ -303,6 +850,8 @@ func (a *CorrelatorAgent) correlate(track *messages.Track) (*messages.Correlated
 
 		// Aggregate data from merged tracks
 		for _, entry := range mergedEntries {
+			correlatedTrack.Envelope = correlatedTrack.Envelope.WithMergedLabels(entry.track.Envelope).WithMergedOrigins(entry.track.Envelope)
+			a.resolveConflicts(correlatedTrack, track, entry.track)
 			correlatedTrack.DetectionCount += entry.track.DetectionCount
 			correlatedTrack.Sources = a.mergeSources(correlatedTrack.Sources, entry.track.Sources)
 
@@ -319,36 +868,88 @@ func (a *CorrelatorAgent) correlate(track *messages.Track) (*messages.Correlated
 
 	// Add current track to window
 	a.window.tracks[track.TrackID] = &trackEntry{
-		track:     track,
-		expiresAt: now.Add(WindowDuration),
-		merged:    false,
+		track:      track,
+		correlated: correlatedTrack,
+		assignment: assignment,
+		expiresAt:  now.Add(WindowDuration),
+		merged:     false,
 	}
 
 	a.correlatedGauge.Set(float64(len(a.window.tracks)))
 
-	return correlatedTrack, mergedTrackIDs
+	return fusion{
+		correlated:     correlatedTrack,
+		mergedTrackIDs: mergedTrackIDs,
+		survivor:       survivor,
+		adopt:          adopt,
+		superseded:     superseded,
+	}
+}
+
+// resolveConflicts settles disagreements between reports of the same object
+// from different enclaves. This enclave's report wins over a partner's; between
+// two partners the report being correlated stands.
+func (a *CorrelatorAgent) resolveConflicts(correlatedTrack *messages.CorrelatedTrack, track, other *messages.Track) {
+	fields := federation.Conflicts(track, other)
+	if len(fields) == 0 {
+		return
+	}
+	for _, field := range fields {
+		a.conflictCounter.WithLabelValues(field).Inc()
+	}
+	winner := track
+	if track.Envelope.Federated() && !other.Envelope.Federated() {
+		winner = other
+		correlatedTrack.Classification = other.Classification
+		correlatedTrack.Type = other.Type
+	}
+	a.logger.Warn().
+		Str("track_id", track.TrackID).
+		Str("other_track_id", other.TrackID).
+		Strs("fields", fields).
+		Str("classification", winner.Classification).
+		Str("type", winner.Type).
+		Str("origin", federation.Origin(winner.Envelope)).
+		Msg("Federated reports of a track conflict")
 }
 
-// shouldMerge determines if two tracks should be merged
+// setPositionThreshold changes the merge distance when position_threshold_meters is updated
+func (a *CorrelatorAgent) setPositionThreshold(meters float64) {
+	if meters <= 0 {
+		a.logger.Warn().Float64("value", meters).Msg("Ignoring non-positive position threshold")
+		return
+	}
+
+	a.window.mu.Lock()
+	defer a.window.mu.Unlock()
+	if meters != a.window.thresholdMeters {
+		a.logger.Info().Float64("meters", meters).Msg("Position threshold changed")
+	}
+	a.window.thresholdMeters = meters
+}
+
+// shouldMerge determines if two tracks should be merged. The caller holds the window lock.
 func (a *CorrelatorAgent) shouldMerge(t1 *messages.Track, t2 *messages.Track) bool {
 	// Same track ID is definitely a match
 	if t1.TrackID == t2.TrackID {
 		return true
 	}
 
-	// Must be same classification
-	if t1.Classification != t2.Classification {
-		return false
-	}
-
-	// Must be same type
-	if t1.Type != t2.Type {
-		return false
+	// Must be same classification and type, unless the reports come from
+	// different enclaves: a partner may see the same object differently, and
+	// correlate resolves the conflict
+	if len(federation.Conflicts(t1, t2)) == 0 {
+		if t1.Classification != t2.Classification {
+			return false
+		}
+		if t1.Type != t2.Type {
+			return false
+		}
 	}
 
 	// Check spatial proximity
-	distance := a.haversineDistance(t1.Position, t2.Position)
-	if distance > PositionThresholdMeters {
+	distance := geo.Distance(t1.Position, t2.Position)
+	if distance > a.window.thresholdMeters {
 		return false
 	}
 
@@ -362,24 +963,6 @@ func (a *CorrelatorAgent) shouldMerge(t1 *messages.Track, t2 *messages.Track) bo
 	return true
 }
 
-// haversineDistance calculates distance between two positions in meters
-func (a *CorrelatorAgent) haversineDistance(p1, p2 messages.Position) float64 {
-	const earthRadius = 6371000 // meters
-
-	lat1 := p1.Lat * math.Pi / 180
-	lat2 := p2.Lat * math.Pi / 180
-	dLat := (p2.Lat - p1.Lat) * math.Pi / 180
-	dLon := (p2.Lon - p1.Lon) * math.Pi / 180
-
-	a1 := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1)*math.Cos(lat2)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-
-	c := 2 * math.Atan2(math.Sqrt(a1), math.Sqrt(1-a1))
-
-	return earthRadius * c
-}
-
 // averagePosition computes average position
 func (a *CorrelatorAgent) averagePosition(p1, p2 messages.Position) messages.Position {
 	return messages.Position{
@@ -436,41 +1019,236 @@ func (a *CorrelatorAgent) mergeSources(s1, s2 []string) []string {
 	return result
 }
 
-// determineThreatLevel assigns threat level based on track characteristics
-func (a *CorrelatorAgent) determineThreatLevel(ct *messages.CorrelatedTrack) string {
-	// Critical: Hostile missiles or aircraft approaching at high speed
-	if ct.Classification == "hostile" {
-		if ct.Type == "missile" {
-			return "critical"
+// loadThreatRules configures the threat engine from a rules file or the database,
+// and loads protected assets and restricted zones when a database is available.
+// Falls back to the built-in rule set when no rule source is available.
+func (a *CorrelatorAgent) loadThreatRules(ctx context.Context) {
+	rulesFromDB := true
+	if path := a.Config().ExtraVars["THREAT_RULES_FILE"]; path != "" {
+		cfg, err := threat.LoadConfigFile(path)
+		if err != nil {
+			a.logger.Warn().Err(err).Str("path", path).Msg("Failed to load threat rules file, using defaults")
+		} else {
+			a.threatEngine.SetRules(cfg.Rules, cfg.Thresholds)
+			a.logger.Info().Str("path", path).Int("rules", len(cfg.Rules)).Msg("Loaded threat scoring rules from file")
+		}
+		rulesFromDB = false
+	}
+
+	if a.Config().DBUrl == "" {
+		if rulesFromDB {
+			a.logger.Info().Msg("No threat rules source configured, using default scoring rules")
+		}
+		return
+	}
+
+	if err := a.connectDB(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to connect to database, using default scoring rules without zones")
+		return
+	}
+
+	if rulesFromDB {
+		if err := a.refreshThreatRules(ctx); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to load threat rules from database, using default scoring rules")
 		}
-		if ct.Type == "aircraft" && ct.Velocity.Speed > 300 {
-			return "high"
+	}
+	if err := a.refreshZones(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Failed to load zones from database")
+	}
+
+	go a.threatRulesRefreshLoop(ctx, rulesFromDB)
+}
+
+// connectDB establishes PostgreSQL connection
+func (a *CorrelatorAgent) connectDB(ctx context.Context) error {
+	config, err := pgxpool.ParseConfig(a.Config().DBUrl)
+	if err != nil {
+		return fmt.Errorf("failed to parse database config: %w", err)
+	}
+
+	config.MaxConns = 2
+	config.MinConns = 1
+	config.MaxConnLifetime = time.Hour
+	config.MaxConnIdleTime = 30 * time.Minute
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to create pool: %w", err)
+	}
+
+	// Test connection
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if err := a.PrepareSchema(ctx, pool); err != nil {
+		pool.Close()
+		return err
+	}
+
+	a.db = pool
+	a.AddDependency(agent.DependencyPostgres, pool.Ping)
+	a.logger.Info().Msg("Connected to PostgreSQL for threat scoring rules")
+	return nil
+}
+
+// threatRulesRefreshLoop periodically reloads scoring rules and zones so edits apply without a restart
+func (a *CorrelatorAgent) threatRulesRefreshLoop(ctx context.Context, rulesFromDB bool) {
+	ticker := time.NewTicker(ThreatRulesRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if rulesFromDB {
+				if err := a.refreshThreatRules(ctx); err != nil {
+					a.logger.Warn().Err(err).Msg("Failed to refresh threat scoring rules")
+					a.RecordError("threat_rules_refresh_error")
+				}
+			}
+			if err := a.refreshZones(ctx); err != nil {
+				a.logger.Warn().Err(err).Msg("Failed to refresh zones")
+				a.RecordError("zones_refresh_error")
+			}
 		}
-		return "medium"
 	}
+}
+
+// refreshThreatRules loads enabled rules and level thresholds from the database
+func (a *CorrelatorAgent) refreshThreatRules(ctx context.Context) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-	// Unknown tracks with high speed are concerning
-	if ct.Classification == "unknown" {
-		if ct.Velocity.Speed > 500 {
-			return "high"
+	rows, err := a.db.Query(queryCtx, `
+		SELECT name, factor, weight, COALESCE(match_values, '{}'), min_value, max_value,
+		       COALESCE(asset_lat, 0), COALESCE(asset_lon, 0), COALESCE(tolerance_deg, 0),
+		       COALESCE(range_meters, 0), COALESCE(classifications, '{}'),
+		       COALESCE(track_types, '{}'), evaluation_order
+		FROM threat_scoring_rules
+		WHERE enabled = true
+		ORDER BY evaluation_order ASC
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query threat scoring rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []threat.Rule
+	for rows.Next() {
+		var r threat.Rule
+		var factor string
+		if err := rows.Scan(&r.Name, &factor, &r.Weight, &r.Match, &r.Min, &r.Max,
+			&r.AssetLat, &r.AssetLon, &r.ToleranceDeg, &r.RangeMeters,
+			&r.Classifications, &r.Types, &r.EvaluationOrder); err != nil {
+			return fmt.Errorf("failed to scan threat scoring rule: %w", err)
 		}
-		if ct.Velocity.Speed > 200 {
-			return "medium"
+		r.Factor = threat.Factor(factor)
+		r.Enabled = true
+		if err := r.Validate(); err != nil {
+			a.logger.Warn().Err(err).Msg("Skipping invalid threat scoring rule")
+			continue
 		}
-		return "low"
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate threat scoring rules: %w", err)
 	}
 
-	// Neutral tracks are low threat
-	if ct.Classification == "neutral" {
-		return "low"
+	thresholds := threat.DefaultThresholds()
+	levelRows, err := a.db.Query(queryCtx, `SELECT threat_level, min_score FROM threat_level_thresholds`)
+	if err != nil {
+		return fmt.Errorf("failed to query threat level thresholds: %w", err)
 	}
+	defer levelRows.Close()
 
-	// Friendly tracks are low threat (but still tracked)
-	if ct.Classification == "friendly" {
-		return "low"
+	for levelRows.Next() {
+		var level string
+		var minScore float64
+		if err := levelRows.Scan(&level, &minScore); err != nil {
+			return fmt.Errorf("failed to scan threat level threshold: %w", err)
+		}
+		switch level {
+		case threat.LevelMedium:
+			thresholds.Medium = minScore
+		case threat.LevelHigh:
+			thresholds.High = minScore
+		case threat.LevelCritical:
+			thresholds.Critical = minScore
+		}
+	}
+	if err := levelRows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate threat level thresholds: %w", err)
 	}
 
-	return "low"
+	a.threatEngine.SetRules(rules, thresholds)
+	a.logger.Debug().Int("rules", len(rules)).Msg("Refreshed threat scoring rules")
+	return nil
+}
+
+// refreshZones loads enabled protected assets and restricted zones from the database
+func (a *CorrelatorAgent) refreshZones(ctx context.Context) error {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := a.db.Query(queryCtx, `
+		SELECT zone_id, name, kind, shape,
+		       COALESCE(center_lat, 0), COALESCE(center_lon, 0), COALESCE(radius_meters, 0),
+		       polygon, priority
+		FROM zones
+		WHERE enabled = true
+		ORDER BY priority DESC
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query zones: %w", err)
+	}
+	defer rows.Close()
+
+	var zones []geo.Zone
+	for rows.Next() {
+		var z geo.Zone
+		var polygon []byte
+		if err := rows.Scan(&z.ZoneID, &z.Name, &z.Kind, &z.Shape,
+			&z.Center.Lat, &z.Center.Lon, &z.RadiusMeters,
+			&polygon, &z.Priority); err != nil {
+			return fmt.Errorf("failed to scan zone: %w", err)
+		}
+		if len(polygon) > 0 {
+			if err := json.Unmarshal(polygon, &z.Polygon); err != nil {
+				a.logger.Warn().Err(err).Str("zone", z.Name).Msg("Skipping zone with invalid polygon")
+				continue
+			}
+		}
+		z.Enabled = true
+		if err := z.Validate(); err != nil {
+			a.logger.Warn().Err(err).Msg("Skipping invalid zone")
+			continue
+		}
+		zones = append(zones, z)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate zones: %w", err)
+	}
+
+	a.zonesMu.Lock()
+	a.zones = zones
+	a.zonesMu.Unlock()
+
+	a.logger.Debug().Int("zones", len(zones)).Msg("Refreshed zones")
+	return nil
+}
+
+// assessZones computes proximity of a track to all known zones
+func (a *CorrelatorAgent) assessZones(ct *messages.CorrelatedTrack) []messages.ZoneProximity {
+	a.zonesMu.RLock()
+	defer a.zonesMu.RUnlock()
+
+	if len(a.zones) == 0 {
+		return nil
+	}
+	return geo.AssessAll(a.zones, ct.Position, ct.Velocity)
 }
 
 func min(a, b float64) float64 {
@@ -481,13 +1259,49 @@ func min(a, b float64) float64 {
 }
 
 func main() {
-	// Configuration from environment
+	// Configuration from environment; secrets may also come from *_FILE
+	// variables or mounted secret files
+	secrets := config.NewLoader()
 	cfg := agent.Config{
 		ID:      getEnv("AGENT_ID", "correlator-"+uuid.New().String()[:8]),
 		Type:    agent.AgentTypeCorrelator,
 		NATSUrl: getEnv("NATS_URL", "nats://localhost:4222"),
 		OPAUrl:  getEnv("OPA_URL", "http://localhost:8181"),
-		Secret:  []byte(getEnv("AGENT_SECRET", "correlator-secret")),
+		DBUrl:   secrets.DatabaseURL("POSTGRES_URL", ""),
+		OTELUrl: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		ExtraVars: map[string]string{
+			"THREAT_RULES_FILE":       getEnv("THREAT_RULES_FILE", ""),
+			"DRAIN_TIMEOUT":           getEnv("DRAIN_TIMEOUT", ""),
+			"METRICS_ADDR":            getEnv("METRICS_ADDR", ""),
+			"ADMIN_SHUTDOWN_TIMEOUT":  getEnv("ADMIN_SHUTDOWN_TIMEOUT", ""),
+			"SEED":                    getEnv("SEED", ""),
+			"HEARTBEAT_INTERVAL":      getEnv("HEARTBEAT_INTERVAL", ""),
+			"CONSUMER_LAG_INTERVAL":   getEnv("CONSUMER_LAG_INTERVAL", ""),
+			"UPSTREAM_JS_DOMAIN":      getEnv("UPSTREAM_JS_DOMAIN", ""),
+			"LINK_CHECK_INTERVAL":     getEnv("LINK_CHECK_INTERVAL", ""),
+			"MAX_ACTIVE_TRACKS":       getEnv("MAX_ACTIVE_TRACKS", ""),
+			"TRACK_ID_REUSE_GAP":      getEnv("TRACK_ID_REUSE_GAP", ""),
+			"CHAOS_ENABLED":           getEnv("CHAOS_ENABLED", ""),
+			"DB_MIGRATE":              getEnv("DB_MIGRATE", ""),
+			"MESSAGE_ENCODING":        getEnv("MESSAGE_ENCODING", ""),
+			"DATA_CLASSIFICATION":     getEnv("DATA_CLASSIFICATION", ""),
+			"DATA_RELEASABILITY":      getEnv("DATA_RELEASABILITY", ""),
+			"ENCRYPT_CLASSIFICATIONS": getEnv("ENCRYPT_CLASSIFICATIONS", ""),
+			"PAYLOAD_KEYS":            secrets.Secret("PAYLOAD_KEYS", ""),
+			"PAYLOAD_KEY_ID":          getEnv("PAYLOAD_KEY_ID", ""),
+			"SIGNATURE_POLICY":        getEnv("SIGNATURE_POLICY", ""),
+			"SIGNING_KEY_LIFETIME":    getEnv("SIGNING_KEY_LIFETIME", ""),
+			"SIGNING_KEY_OVERLAP":     getEnv("SIGNING_KEY_OVERLAP", ""),
+			"STREAM_POLICY_FILE":      getEnv("STREAM_POLICY_FILE", ""),
+			"CORRELATOR_SHARD":        getEnv("CORRELATOR_SHARD", ""),
+			"CORRELATOR_CELLS":        getEnv("CORRELATOR_CELLS", ""),
+		},
+		NATSSecurity: natsutil.SecurityFromEnv(),
+		PostgresTLS:  postgres.TLSConfigFromEnv(),
+	}
+	if err := secrets.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid secrets: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Create agent
@@ -505,23 +1319,11 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start metrics server
+	// Start the admin server
 	go func() {
-		metricsAddr := getEnv("METRICS_ADDR", ":9090")
-		mux := http.NewServeMux()
-		mux.Handle("/metrics", promhttp.HandlerFor(correlator.Metrics(), promhttp.HandlerOpts{}))
-		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-			health := correlator.Health()
-			if health.Healthy {
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusServiceUnavailable)
-			}
-			json.NewEncoder(w).Encode(health)
-		})
-		correlator.logger.Info().Str("addr", metricsAddr).Msg("Starting metrics server")
-		if err := http.ListenAndServe(metricsAddr, mux); err != nil {
-			correlator.logger.Error().Err(err).Msg("Metrics server error")
+		admin := correlator.NewAdminServer()
+		if err := admin.Serve(ctx); err != nil {
+			correlator.logger.Error().Err(err).Msg("Admin server error")
 		}
 	}()
 
@@ -536,6 +1338,11 @@ func main() {
 	// Wait for shutdown signal
 	sig := <-sigChan
 	correlator.logger.Info().Str("signal", sig.String()).Msg("Received shutdown signal")
+
+	// Stop fetching and let in-flight messages finish before tearing down
+	if err := correlator.Drain(context.Background()); err != nil {
+		correlator.logger.Warn().Err(err).Msg("Shutting down with messages still in flight")
+	}
 	cancel()
 
 	// Graceful shutdown
@@ -546,9 +1353,40 @@ func main() {
 		correlator.logger.Error().Err(err).Msg("Error during shutdown")
 	}
 
+	if correlator.db != nil {
+		correlator.db.Close()
+	}
+
 	correlator.logger.Info().Msg("Correlator agent stopped")
 }
 
+// correlatorCapabilities describes the correlator agent for capability discovery
+func correlatorCapabilities() agent.Capabilities {
+	return agent.Capabilities{
+		Messages: []agent.MessageCapability{
+			{Type: "track", Subject: "track.classified.>", Stream: "TRACKS", Direction: agent.DirectionConsumes},
+			{Type: "track", Subject: "track.handoff.*.<cell>", Stream: "TRACKS", Direction: agent.DirectionConsumes},
+			{Type: "effect_assessment", Subject: "assessment.neutralized.>", Stream: "ASSESSMENTS", Direction: agent.DirectionConsumes},
+			{Type: "correlated_track", Subject: "track.correlated.<threat_level>", Stream: "TRACKS", Direction: agent.DirectionProduces},
+			{Type: "track_merge", Subject: messages.TrackMergedSubject, Stream: "TRACKS", Direction: agent.DirectionProduces},
+			{Type: "track_split", Subject: messages.TrackSplitSubject, Stream: "TRACKS", Direction: agent.DirectionProduces},
+			{Type: "admission_shed", Subject: "notify.admission.track", Stream: "NOTIFICATIONS", Direction: agent.DirectionProduces},
+		},
+		ConfigSchema: append([]agent.ConfigField{
+			{Name: "postgres_url", Type: "url", Env: "POSTGRES_URL", Description: "PostgreSQL URL for threat scoring rules and zones"},
+			agent.DBMigrateConfig,
+			{Name: "threat_rules_file", Type: "string", Env: "THREAT_RULES_FILE", Description: "JSON threat scoring rules file, overrides database rules"},
+			{Name: "position_threshold_meters", Type: "float", Default: "500", Description: "Max distance between tracks merged as one entity; set through /api/v1/agent-config", Runtime: true},
+			{Name: "max_active_tracks", Type: "int", Env: "MAX_ACTIVE_TRACKS", Default: "500", Description: "Active tracks admitted; new tracks must outscore the least threatening to enter (0 disables)"},
+			{Name: "track_id_reuse_gap", Type: "duration", Env: "TRACK_ID_REUSE_GAP", Default: trackid.DefaultReuseGap.String(), Description: "Time a sensor track ID may go unreported and keep its system track ID; a returning ID after longer is a new object"},
+			{Name: "correlator_shard", Type: "string", Env: "CORRELATOR_SHARD", Description: "Name of this correlator's shard when correlation is split by geographic cell; needs correlator_cells"},
+			{Name: "correlator_cells", Type: "string", Env: "CORRELATOR_CELLS", Description: "Comma-separated geohash cells, of the classifier's geocell_precision, this shard correlates; unset takes every track"},
+		}, agent.UpstreamLinkConfig...),
+		Commands: []agent.ControlCommand{},
+		Routes:   []agent.Route{},
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
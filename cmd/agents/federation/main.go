@@ -0,0 +1,480 @@
+// Federation Agent - Exports releasable tracks to a coalition partner's instance
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/federation"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// RemotePublishTimeout bounds each export to the partner's NATS
+	RemotePublishTimeout = 5 * time.Second
+	// RemoteRetryDelay is how long a track waits before it is exported again
+	// after the partner was unreachable
+	RemoteRetryDelay = 5 * time.Second
+)
+
+// FederationAgent exports this enclave's classified tracks that are
+// releasable to a partner onto the partner's NATS
+type FederationAgent struct {
+	*agent.BaseAgent
+	logger zerolog.Logger
+	cfg    federation.Config
+
+	// Partner connection; remote is nil until Run
+	remoteSecurity natsutil.Security
+	remote         *nats.Conn
+	remoteJS       jetstream.JetStream
+
+	// Signs exports with keys registered in the partner's registry; nil
+	// until the bridge has registered one
+	exportSigner atomic.Pointer[signing.Signer]
+
+	// Pause control
+	mu     sync.RWMutex
+	paused bool
+
+	exportedCounter prometheus.Counter
+	skippedCounter  *prometheus.CounterVec
+	connectedGauge  prometheus.Gauge
+}
+
+// NewFederationAgent creates a new federation agent
+func NewFederationAgent(cfg agent.Config) (*FederationAgent, error) {
+	base, err := agent.NewBaseAgent(cfg)
+	if err != nil {
+		return nil, err
+	}
+	base.SetCapabilities(federationCapabilities())
+
+	fedCfg, err := federation.ConfigFromVars(cfg.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+	remoteSecurity := natsutil.Security{
+		CAFile:    cfg.ExtraVars["FEDERATION_REMOTE_CA_FILE"],
+		CredsFile: cfg.ExtraVars["FEDERATION_REMOTE_CREDS_FILE"],
+	}
+	if err := remoteSecurity.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid partner NATS settings: %w", err)
+	}
+
+	exportedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "federation_tracks_exported_total",
+		Help:        "Total number of tracks exported to the partner",
+		ConstLabels: prometheus.Labels{"partner": fedCfg.Partner},
+	})
+	skippedCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "federation_tracks_skipped_total",
+		Help:        "Total number of tracks not exported to the partner, by reason",
+		ConstLabels: prometheus.Labels{"partner": fedCfg.Partner},
+	}, []string{"reason"})
+	connectedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "federation_remote_connected",
+		Help:        "Whether the bridge is connected to the partner's NATS (1) or not (0)",
+		ConstLabels: prometheus.Labels{"partner": fedCfg.Partner},
+	})
+	base.Metrics().MustRegister(exportedCounter, skippedCounter, connectedGauge)
+
+	return &FederationAgent{
+		BaseAgent:       base,
+		logger:          *base.Logger(),
+		cfg:             fedCfg,
+		remoteSecurity:  remoteSecurity,
+		exportedCounter: exportedCounter,
+		skippedCounter:  skippedCounter,
+		connectedGauge:  connectedGauge,
+	}, nil
+}
+
+// Run starts the federation agent
+func (a *FederationAgent) Run(ctx context.Context) error {
+	// Start base agent (connects to NATS)
+	if err := a.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start base agent: %w", err)
+	}
+
+	// Ensure streams exist
+	if err := natsutil.SetupStreams(ctx, a.JetStream(), a.StreamPolicies()); err != nil {
+		return fmt.Errorf("failed to setup streams: %w", err)
+	}
+
+	if err := a.connectRemote(); err != nil {
+		return err
+	}
+	go a.joinPartnerRegistry(ctx)
+
+	a.logger.Info().
+		Str("enclave", a.cfg.Enclave).
+		Str("partner", a.cfg.Partner).
+		Str("max_classification", a.cfg.MaxClassification).
+		Msg("Federation agent started, exporting classified tracks")
+
+	return a.consumeMessages(ctx)
+}
+
+// connectRemote connects to the partner's NATS. The connection is retried in
+// the background, so tracks wait in the local stream while the partner is
+// unreachable rather than the bridge failing to start.
+func (a *FederationAgent) connectRemote() error {
+	opts := []nats.Option{
+		nats.Name(a.ID() + "-" + strings.ToLower(a.cfg.Partner)),
+		nats.RetryOnFailedConnect(true),
+		nats.ReconnectWait(2 * time.Second),
+		nats.MaxReconnects(-1),
+		nats.ConnectHandler(func(nc *nats.Conn) {
+			a.connectedGauge.Set(1)
+			a.logger.Info().Str("partner", a.cfg.Partner).Msg("Connected to partner NATS")
+		}),
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			a.connectedGauge.Set(0)
+			a.logger.Warn().Err(err).Str("partner", a.cfg.Partner).Msg("Partner NATS disconnected")
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			a.connectedGauge.Set(1)
+			a.logger.Info().Str("partner", a.cfg.Partner).Msg("Partner NATS reconnected")
+		}),
+	}
+	security, err := a.remoteSecurity.Options()
+	if err != nil {
+		return fmt.Errorf("invalid partner NATS settings: %w", err)
+	}
+	opts = append(opts, security...)
+
+	nc, err := nats.Connect(a.cfg.RemoteURL, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to connect to partner NATS: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return fmt.Errorf("failed to create partner JetStream context: %w", err)
+	}
+	if nc.IsConnected() {
+		a.connectedGauge.Set(1)
+	}
+	a.remote, a.remoteJS = nc, js
+	a.AddDependency("partner", func(context.Context) error {
+		if !nc.IsConnected() {
+			return fmt.Errorf("partner NATS %s", nc.Status())
+		}
+		return nil
+	})
+	return nil
+}
+
+// exportSource is the source exported tracks name and the agent ID the
+// bridge's keys are registered under in the partner's registry. The enclave
+// keeps it apart from the partner's own agent IDs.
+func (a *FederationAgent) exportSource() string {
+	return strings.ToLower(a.cfg.Enclave) + "-" + a.ID()
+}
+
+// joinPartnerRegistry registers the bridge's signing key in the partner's
+// key registry, retrying while the partner is unreachable, and keeps
+// rotating it until ctx is done. Tracks wait in the local stream meanwhile.
+func (a *FederationAgent) joinPartnerRegistry(ctx context.Context) {
+	for {
+		signer, err := a.StartSigner(ctx, a.remoteJS, a.exportSource())
+		if err == nil {
+			a.exportSigner.Store(signer)
+			return
+		}
+		a.logger.Warn().Err(err).Str("partner", a.cfg.Partner).Msg("Failed to register signing key with partner, retrying")
+		a.RecordError("partner_signing_error")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(signing.RotationRetryInterval):
+		}
+	}
+}
+
+// consumeMessages exports classified tracks until the agent drains. A track
+// the partner could not take is retried after RemoteRetryDelay.
+func (a *FederationAgent) consumeMessages(ctx context.Context) error {
+	cfg := natsutil.FederationConsumer(a.cfg.Partner)
+	return a.Consume(ctx, "TRACKS", cfg.Durable, a.processMessage, agent.ConsumeOptions{
+		Config:   &cfg,
+		Schema:   messages.SchemaTrack,
+		Hold:     a.hold,
+		NakDelay: RemoteRetryDelay,
+	})
+}
+
+// hold holds tracks in the stream while the operator has paused exports
+func (a *FederationAgent) hold() time.Duration {
+	if a.IsPaused() {
+		return agent.PausePollInterval
+	}
+	return 0
+}
+
+// processMessage exports a single classified track when the partner may have it
+func (a *FederationAgent) processMessage(ctx context.Context, msg jetstream.Msg) (err error) {
+	start := time.Now()
+
+	var track messages.Track
+	if err := agent.Decode(msg, &track); err != nil {
+		return agent.Permanent(fmt.Errorf("failed to unmarshal track: %w", err))
+	}
+
+	ctx, span := tracing.StartFromEnvelope(ctx, "federation.export", track.Envelope,
+		tracing.AttrTrackID.String(track.TrackID))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if ok, reason := a.cfg.Export(track.Envelope); !ok {
+		a.skippedCounter.WithLabelValues(reason).Inc()
+		a.RecordMessage("skipped", "track")
+		span.SetAttributes(attribute.String("cjadc2.outcome", reason))
+		a.logger.Debug().
+			Str("track_id", track.TrackID).
+			Str("classification", track.Envelope.Classification).
+			Strs("releasability", track.Envelope.Releasability).
+			Strs("origins", track.Envelope.Origins).
+			Str("reason", reason).
+			Msg("Track not exported")
+		return nil
+	}
+
+	// The partner holds none of this enclave's signing keys, so the bridge
+	// signs exports as their source with a key in the partner's registry
+	signer := a.exportSigner.Load()
+	if signer == nil {
+		return fmt.Errorf("no signing key registered with %s yet", a.cfg.Partner)
+	}
+	a.cfg.Stamp(&track)
+	track.Envelope.Source = a.exportSource()
+	track.Envelope.SourceType = string(agent.AgentTypeFederation)
+	m, err := a.EncodeMessageFor(&track, signer)
+	if err != nil {
+		return err
+	}
+
+	// The message ID lets the partner's stream drop a track exported twice
+	// after a redelivery
+	pubCtx, cancel := context.WithTimeout(ctx, RemotePublishTimeout)
+	defer cancel()
+	if _, err := a.remoteJS.PublishMsg(pubCtx, m, jetstream.WithMsgID(track.Envelope.MessageID)); err != nil {
+		return fmt.Errorf("failed to publish track %s to %s: %w", track.TrackID, a.cfg.Partner, err)
+	}
+
+	a.exportedCounter.Inc()
+	a.RecordMessage("success", "track")
+	a.RecordLatency(ctx, "track", time.Since(start))
+
+	a.logger.Debug().
+		Str("track_id", track.TrackID).
+		Str("partner", a.cfg.Partner).
+		Str("subject", m.Subject).
+		Msg("Exported track")
+	return nil
+}
+
+// SetPaused sets the paused state
+func (a *FederationAgent) SetPaused(paused bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.paused = paused
+	a.logger.Info().Bool("paused", paused).Msg("Updated paused state")
+}
+
+// IsPaused returns the current paused state
+func (a *FederationAgent) IsPaused() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.paused
+}
+
+// serveAdmin serves the control API on the admin server until ctx is done
+func (a *FederationAgent) serveAdmin(ctx context.Context) {
+	admin := a.NewAdminServer()
+	admin.Get(agent.ConfigPath, a.handleGetConfig)
+	admin.Patch(agent.ConfigPath, a.handlePatchConfig)
+
+	if err := admin.Serve(ctx); err != nil {
+		a.logger.Error().Err(err).Msg("Admin server error")
+	}
+}
+
+func (a *FederationAgent) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	config := map[string]interface{}{
+		"paused":             a.IsPaused(),
+		"enclave":            a.cfg.Enclave,
+		"partner":            a.cfg.Partner,
+		"max_classification": a.cfg.MaxClassification,
+		"remote_connected":   a.remote != nil && a.remote.IsConnected(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+func (a *FederationAgent) handlePatchConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Paused *bool `json:"paused"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, problem.New(problem.CodeValidation, "Invalid JSON").For(r))
+		return
+	}
+
+	if req.Paused != nil {
+		a.SetPaused(*req.Paused)
+	}
+
+	// Return updated config
+	a.handleGetConfig(w, r)
+}
+
+func main() {
+	// Configuration from environment; secrets may also come from *_FILE
+	// variables or mounted secret files
+	secrets := config.NewLoader()
+	cfg := agent.Config{
+		ID:      getEnv("AGENT_ID", "federation-"+uuid.New().String()[:8]),
+		Type:    agent.AgentTypeFederation,
+		NATSUrl: getEnv("NATS_URL", "nats://localhost:4222"),
+		OPAUrl:  getEnv("OPA_URL", "http://localhost:8181"),
+		OTELUrl: getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		ExtraVars: map[string]string{
+			"DRAIN_TIMEOUT":                 getEnv("DRAIN_TIMEOUT", ""),
+			"METRICS_ADDR":                  getEnv("METRICS_ADDR", ""),
+			"ADMIN_SHUTDOWN_TIMEOUT":        getEnv("ADMIN_SHUTDOWN_TIMEOUT", ""),
+			"SEED":                          getEnv("SEED", ""),
+			"HEARTBEAT_INTERVAL":            getEnv("HEARTBEAT_INTERVAL", ""),
+			"MESSAGE_ENCODING":              getEnv("MESSAGE_ENCODING", ""),
+			"DATA_CLASSIFICATION":           getEnv("DATA_CLASSIFICATION", ""),
+			"DATA_RELEASABILITY":            getEnv("DATA_RELEASABILITY", ""),
+			"ENCRYPT_CLASSIFICATIONS":       getEnv("ENCRYPT_CLASSIFICATIONS", ""),
+			"PAYLOAD_KEYS":                  secrets.Secret("PAYLOAD_KEYS", ""),
+			"PAYLOAD_KEY_ID":                getEnv("PAYLOAD_KEY_ID", ""),
+			"SIGNATURE_POLICY":              getEnv("SIGNATURE_POLICY", ""),
+			"SIGNING_KEY_LIFETIME":          getEnv("SIGNING_KEY_LIFETIME", ""),
+			"SIGNING_KEY_OVERLAP":           getEnv("SIGNING_KEY_OVERLAP", ""),
+			"STREAM_POLICY_FILE":            getEnv("STREAM_POLICY_FILE", ""),
+			"FEDERATION_ENCLAVE":            getEnv("FEDERATION_ENCLAVE", ""),
+			"FEDERATION_PARTNER":            getEnv("FEDERATION_PARTNER", ""),
+			"FEDERATION_REMOTE_NATS_URL":    getEnv("FEDERATION_REMOTE_NATS_URL", ""),
+			"FEDERATION_REMOTE_CA_FILE":     getEnv("FEDERATION_REMOTE_CA_FILE", ""),
+			"FEDERATION_REMOTE_CREDS_FILE":  getEnv("FEDERATION_REMOTE_CREDS_FILE", ""),
+			"FEDERATION_MAX_CLASSIFICATION": getEnv("FEDERATION_MAX_CLASSIFICATION", ""),
+		},
+		NATSSecurity: natsutil.SecurityFromEnv(),
+		PostgresTLS:  postgres.TLSConfigFromEnv(),
+	}
+	if err := secrets.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid secrets: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Create agent
+	bridge, err := NewFederationAgent(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create federation agent: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Setup context with cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Handle shutdown signals
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Start the admin server for the control API
+	go bridge.serveAdmin(ctx)
+
+	// Run agent
+	go func() {
+		if err := bridge.Run(ctx); err != nil && err != context.Canceled {
+			bridge.logger.Error().Err(err).Msg("Federation agent error")
+			cancel()
+		}
+	}()
+
+	// Wait for shutdown signal
+	sig := <-sigChan
+	bridge.logger.Info().Str("signal", sig.String()).Msg("Received shutdown signal")
+
+	// Stop fetching and let in-flight exports finish before tearing down
+	if err := bridge.Drain(context.Background()); err != nil {
+		bridge.logger.Warn().Err(err).Msg("Shutting down with messages still in flight")
+	}
+	cancel()
+
+	// Graceful shutdown
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+
+	if err := bridge.Stop(shutdownCtx); err != nil {
+		bridge.logger.Error().Err(err).Msg("Error during shutdown")
+	}
+
+	if bridge.remote != nil {
+		bridge.remote.Close()
+	}
+
+	bridge.logger.Info().Msg("Federation agent stopped")
+}
+
+// federationCapabilities describes the federation agent for capability discovery
+func federationCapabilities() agent.Capabilities {
+	return agent.Capabilities{
+		Messages: []agent.MessageCapability{
+			{Type: "track", Subject: "track.classified.<classification>", Stream: "TRACKS", Direction: agent.DirectionConsumes},
+			{Type: "track", Subject: "track.classified.<classification>", Stream: "TRACKS (partner)", Direction: agent.DirectionProduces},
+		},
+		ConfigSchema: []agent.ConfigField{
+			{Name: "federation_enclave", Type: "string", Env: "FEDERATION_ENCLAVE", Description: "This enclave's country or coalition code, added to the origins of every exported track"},
+			{Name: "federation_partner", Type: "string", Env: "FEDERATION_PARTNER", Description: "The partner's releasability code; only tracks releasable to it are exported"},
+			{Name: "federation_remote_nats_url", Type: "url", Env: "FEDERATION_REMOTE_NATS_URL", Description: "The partner's NATS, where exported tracks are published"},
+			{Name: "federation_remote_ca_file", Type: "string", Env: "FEDERATION_REMOTE_CA_FILE", Description: "PEM roots that verify the partner's NATS"},
+			{Name: "federation_remote_creds_file", Type: "string", Env: "FEDERATION_REMOTE_CREDS_FILE", Description: "Credentials the bridge authenticates to the partner's NATS with"},
+			{Name: "federation_max_classification", Type: "string", Env: "FEDERATION_MAX_CLASSIFICATION", Default: "unclassified", Description: "Highest classification exported to the partner"},
+			{Name: "paused", Type: "bool", Default: "false", Description: "Pause exports; tracks wait in the local stream", Runtime: true},
+		},
+		Commands: []agent.ControlCommand{
+			{Name: "pause", Method: http.MethodPatch, Path: "/api/v1/config", Description: "Pause or resume exports with {\"paused\": bool}"},
+		},
+		Routes: []agent.Route{
+			{Method: http.MethodPatch, Path: "/api/v1/config", Description: "Update federation configuration"},
+		},
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,144 @@
+// Archiver - Mirrors the platform's JetStream streams to S3 or MinIO as hourly NDJSON segments
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/streamarchive"
+)
+
+func main() {
+	log.Logger = zerolog.New(os.Stdout).With().Timestamp().Str("service", "archiver").Logger()
+	if getEnv("LOG_FORMAT", "json") != "json" {
+		log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}).
+			With().Timestamp().Str("service", "archiver").Logger()
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Archiver failed")
+	}
+	log.Info().Msg("Archiver stopped")
+}
+
+func run(ctx context.Context) error {
+	cfg, err := streamarchive.ParseConfig(
+		os.Getenv("ARCHIVE_URL"),
+		os.Getenv("ARCHIVE_STREAMS"),
+		os.Getenv("ARCHIVE_GZIP"),
+		os.Getenv("ARCHIVE_SEGMENT_MAX_BYTES"),
+	)
+	if err != nil {
+		return err
+	}
+	cfg.S3, err = streamarchive.S3ConfigFromEnv(cfg.URL)
+	if err != nil {
+		return err
+	}
+	shutdownTimeout := agent.DefaultAdminShutdownTimeout
+	if v := os.Getenv("ADMIN_SHUTDOWN_TIMEOUT"); v != "" {
+		if shutdownTimeout, err = time.ParseDuration(v); err != nil || shutdownTimeout <= 0 {
+			return fmt.Errorf("invalid ADMIN_SHUTDOWN_TIMEOUT %q", v)
+		}
+	}
+
+	store, err := streamarchive.OpenStore(cfg.URL, cfg.S3)
+	if err != nil {
+		return err
+	}
+	archive, err := streamarchive.OpenArchive(ctx, store, cfg.Compress)
+	if err != nil {
+		return err
+	}
+
+	security, err := natsutil.SecurityFromEnv().Options()
+	if err != nil {
+		return err
+	}
+	natsURL := getEnv("NATS_URL", "nats://localhost:4222")
+	nc, err := nats.Connect(natsURL, append([]nats.Option{
+		nats.Name("cjadc2-archiver"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2 * time.Second),
+	}, security...)...)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return err
+	}
+
+	archiver := streamarchive.NewArchiver(cfg, nc, js, archive, log.Logger)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(archiver.Collectors()...)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		health := map[string]string{"status": "healthy", "nats": nc.Status().String()}
+		status := http.StatusOK
+		if !nc.IsConnected() {
+			health["status"] = "unhealthy"
+			status = http.StatusServiceUnavailable
+		}
+		if err := archiver.Err(); err != nil {
+			health["status"] = "degraded"
+			health["error"] = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(health)
+	})
+	server := &http.Server{Addr: getEnv("METRICS_ADDR", ":9090"), Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("HTTP server failed")
+		}
+	}()
+	defer func() {
+		// Let scrapes and health checks in flight finish, as the agents do
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			server.Close()
+			log.Warn().Err(err).Msg("Closed metrics connections still open at the shutdown deadline")
+		}
+	}()
+
+	log.Info().
+		Str("archive", cfg.URL).
+		Strs("streams", cfg.Streams).
+		Bool("gzip", cfg.Compress).
+		Int("segments", len(archive.Manifest().Segments)).
+		Msg("Archiver started")
+	return archiver.Run(ctx)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
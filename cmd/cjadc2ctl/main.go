@@ -0,0 +1,463 @@
+// cjadc2ctl - Operator CLI for deciding proposals, following the audit trail,
+// tuning the sensor, purging streams, and checking pipeline health
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/spf13/cobra"
+
+	"github.com/agile-defense/cjadc2/pkg/ctl"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// Exit codes
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUnhealthy = 2 // health ran but the pipeline is degraded
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// options are the persistent flags shared by every command
+type options struct {
+	gatewayURL string
+	sensorURL  string
+	natsURL    string
+	userID     string
+	output     string
+	timeout    time.Duration
+}
+
+// client creates a gateway and sensor client from the flags
+func (o *options) client() *ctl.Client {
+	return ctl.NewClient(o.gatewayURL, o.sensorURL, o.userID, o.timeout)
+}
+
+// errUnhealthy makes health exit with exitUnhealthy
+var errUnhealthy = errors.New("pipeline is unhealthy")
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	err := newRootCommand().ExecuteContext(ctx)
+	switch {
+	case err == nil:
+		os.Exit(exitOK)
+	case errors.Is(err, errUnhealthy):
+		os.Exit(exitUnhealthy)
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitError)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:           "cjadc2ctl",
+		Short:         "Operate the CJADC2 pipeline from the command line",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.output != outputTable && opts.output != outputJSON {
+				return fmt.Errorf("invalid --output %q: must be %s or %s", opts.output, outputTable, outputJSON)
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.gatewayURL, "gateway", getEnv("API_URL", ctl.DefaultGatewayURL), "API gateway URL (API_URL)")
+	flags.StringVar(&opts.sensorURL, "sensor", getEnv("SENSOR_URL", ctl.DefaultSensorURL), "Sensor control API URL (SENSOR_URL)")
+	flags.StringVar(&opts.natsURL, "nats", getEnv("NATS_URL", ctl.DefaultNATSURL), "NATS URL for stream administration (NATS_URL)")
+	flags.StringVarP(&opts.userID, "user", "u", getEnv("CJADC2_USER", ""), "Operator the requests are made as, sent as X-User-ID (CJADC2_USER)")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "Output format: table or json")
+	flags.DurationVar(&opts.timeout, "timeout", ctl.DefaultTimeout, "Timeout for each request")
+
+	root.AddCommand(
+		newProposalsCommand(opts),
+		newAuditCommand(opts),
+		newSensorCommand(opts),
+		newStreamsCommand(opts),
+		newHealthCommand(opts),
+	)
+	return root
+}
+
+func newProposalsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proposals",
+		Short: "List and decide action proposals",
+	}
+
+	var status string
+	var limit int
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List proposals",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := opts.client().ListProposals(cmd.Context(), status, limit)
+			if err != nil {
+				return err
+			}
+			if opts.output == outputJSON {
+				return writeJSON(resp.Proposals)
+			}
+			tw := newTable("ID", "TRACK", "ACTION", "PRIORITY", "THREAT", "STATUS", "APPROVALS", "EXPIRES IN")
+			for _, p := range resp.Proposals {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%d/%d\t%s\n",
+					p.ProposalID, p.TrackID, p.ActionType, p.Priority, p.ThreatLevel, p.Status,
+					len(p.Approvals), p.RequiredApprovals, expiresIn(p.TimeRemainingSeconds))
+			}
+			return tw.Flush()
+		},
+	}
+	list.Flags().StringVar(&status, "status", "pending", "Proposal status to list; empty lists every status")
+	list.Flags().IntVar(&limit, "limit", 50, "Maximum proposals to list")
+
+	cmd.AddCommand(list, newDecideCommand(opts, true), newDecideCommand(opts, false))
+	return cmd
+}
+
+// newDecideCommand creates the approve or deny command
+func newDecideCommand(opts *options, approved bool) *cobra.Command {
+	use, short := "approve", "Approve a proposal"
+	if !approved {
+		use, short = "deny", "Deny a proposal"
+	}
+
+	var reason string
+	cmd := &cobra.Command{
+		Use:   use + " <proposal-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.userID == "" {
+				return fmt.Errorf("--user is required to decide proposals")
+			}
+			outcome, err := opts.client().Decide(cmd.Context(), args[0], approved, reason)
+			if err != nil {
+				return err
+			}
+			if opts.output == outputJSON {
+				if outcome.Pending != nil {
+					return writeJSON(outcome.Pending)
+				}
+				return writeJSON(outcome.Decision)
+			}
+			if p := outcome.Pending; p != nil {
+				fmt.Printf("Proposal %s awaits a second approval (%d/%d)\n", p.ProposalID, len(p.Approvals), p.RequiredApprovals)
+				return nil
+			}
+			verb := "approved"
+			if !outcome.Decision.Approved {
+				verb = "denied"
+			}
+			fmt.Printf("Proposal %s %s by %s (decision %s)\n", outcome.Decision.ProposalID, verb, outcome.Decision.ApprovedBy, outcome.Decision.DecisionID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "Reason recorded with the decision")
+	return cmd
+}
+
+func newAuditCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the decision audit trail",
+	}
+
+	var query ctl.AuditQuery
+	var follow bool
+	var interval time.Duration
+	tail := &cobra.Command{
+		Use:   "tail",
+		Short: "Print the latest audit entries, optionally following new ones",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			client := opts.client()
+			follower := ctl.NewAuditFollower()
+			header := true
+
+			for {
+				resp, err := client.Audit(ctx, query)
+				if err != nil {
+					return err
+				}
+				if err := printAuditEntries(opts.output, follower.Next(resp.Entries), header); err != nil {
+					return err
+				}
+				header = false
+				if !follow {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+	tail.Flags().IntVarP(&query.Limit, "limit", "n", 20, "Number of entries to show, and to poll when following")
+	tail.Flags().StringVar(&query.ActionType, "action-type", "", "Only show entries for this action type")
+	tail.Flags().StringVar(&query.UserID, "user-id", "", "Only show entries by this user")
+	tail.Flags().StringVar(&query.TrackID, "track", "", "Only show entries for this track")
+	tail.Flags().BoolVarP(&follow, "follow", "f", false, "Keep polling and print new entries as they arrive")
+	tail.Flags().DurationVar(&interval, "interval", 2*time.Second, "Poll interval when following")
+
+	cmd.AddCommand(tail)
+	return cmd
+}
+
+// printAuditEntries prints entries oldest first. JSON output is one object per
+// line so followed output can be piped into jq.
+func printAuditEntries(output string, entries []handler.AuditEntryResponse, header bool) error {
+	if output == outputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var tw *tabwriter.Writer
+	if header {
+		tw = newTable("TIME", "ACTION", "TRACK", "STATUS", "USER", "REASON")
+	} else {
+		tw = newTable()
+	}
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Timestamp, e.ActionType, e.TrackID, e.Status, deref(e.UserID), deref(e.Reason))
+	}
+	return tw.Flush()
+}
+
+func newSensorCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sensor",
+		Short: "Inspect and adjust the sensor simulator",
+	}
+
+	config := &cobra.Command{
+		Use:   "config [key=value...]",
+		Short: "Show the sensor configuration, or update the given fields",
+		Long: "Show the sensor configuration, or update the given fields.\n\n" +
+			"Values are parsed as JSON where possible, for example:\n" +
+			"  cjadc2ctl sensor config emission_interval_ms=500 track_count=20\n" +
+			"  cjadc2ctl sensor config paused=true\n" +
+			"  cjadc2ctl sensor config 'type_weights={\"aircraft\":70,\"vessel\":30}'",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := opts.client()
+			var config map[string]interface{}
+			var err error
+			if len(args) == 0 {
+				config, err = client.SensorConfig(cmd.Context())
+			} else {
+				var fields map[string]interface{}
+				if fields, err = ctl.ParseConfigFields(args); err != nil {
+					return err
+				}
+				config, err = client.UpdateSensorConfig(cmd.Context(), fields)
+			}
+			if err != nil {
+				return err
+			}
+			if opts.output == outputJSON {
+				return writeJSON(config)
+			}
+
+			keys := make([]string, 0, len(config))
+			for k := range config {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			tw := newTable("SETTING", "VALUE")
+			for _, k := range keys {
+				value, _ := json.Marshal(config[k])
+				fmt.Fprintf(tw, "%s\t%s\n", k, value)
+			}
+			return tw.Flush()
+		},
+	}
+
+	cmd.AddCommand(config)
+	return cmd
+}
+
+func newStreamsCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "streams",
+		Short: "Administer the pipeline's JetStream streams",
+	}
+
+	var all bool
+	purge := &cobra.Command{
+		Use:   "purge [STREAM...]",
+		Short: "Discard every message on the given streams",
+		Long: "Discard every message on the given streams, or every pipeline stream with --all.\n\n" +
+			"Unlike an exercise reset this leaves the database and the simulator alone.\n" +
+			"Protected streams such as SIMCONTROL are never purged.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := ctl.ResolveStreams(args, all)
+			if err != nil {
+				return err
+			}
+
+			security, err := natsutil.SecurityFromEnv().Options()
+			if err != nil {
+				return err
+			}
+			nc, err := nats.Connect(opts.natsURL, append([]nats.Option{nats.Name("cjadc2ctl"), nats.Timeout(opts.timeout)}, security...)...)
+			if err != nil {
+				return fmt.Errorf("failed to connect to NATS: %w", err)
+			}
+			defer nc.Close()
+
+			js, err := jetstream.New(nc)
+			if err != nil {
+				return fmt.Errorf("failed to create JetStream context: %w", err)
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), opts.timeout)
+			defer cancel()
+			purged, err := ctl.PurgeStreams(ctx, js, names)
+			if err != nil {
+				return err
+			}
+			if opts.output == outputJSON {
+				return writeJSON(purged)
+			}
+			tw := newTable("STREAM", "PURGED")
+			for _, name := range names {
+				fmt.Fprintf(tw, "%s\t%d\n", name, purged[name])
+			}
+			return tw.Flush()
+		},
+	}
+	purge.Flags().BoolVar(&all, "all", false, "Purge every pipeline stream")
+
+	cmd.AddCommand(purge)
+	return cmd
+}
+
+func newHealthCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "health",
+		Short: "Show gateway, agent, and pipeline stage health; exits 2 when degraded",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			health, err := opts.client().Health(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if opts.output == outputJSON {
+				if err := writeJSON(health); err != nil {
+					return err
+				}
+			} else {
+				printHealth(health)
+			}
+			if !health.Healthy() {
+				return errUnhealthy
+			}
+			return nil
+		},
+	}
+}
+
+// printHealth prints pipeline health as tables
+func printHealth(health *ctl.PipelineHealth) {
+	g := health.Gateway
+	fmt.Printf("Gateway: %s (version %s, up %s, schema %d)\n\n", g.Status, g.Version, g.Uptime, g.SchemaVersion)
+
+	components := make([]string, 0, len(g.Components))
+	for name := range g.Components {
+		components = append(components, name)
+	}
+	sort.Strings(components)
+	tw := newTable("COMPONENT", "STATUS")
+	for _, name := range components {
+		fmt.Fprintf(tw, "%s\t%s\n", name, g.Components[name])
+	}
+	tw.Flush()
+	fmt.Println()
+
+	tw = newTable("AGENT", "REACHABLE", "URL", "ERROR")
+	for _, a := range health.Agents {
+		fmt.Fprintf(tw, "%s\t%t\t%s\t%s\n", a.Name, a.Reachable, a.URL, a.Error)
+	}
+	tw.Flush()
+	fmt.Println()
+
+	tw = newTable("STAGE", "TOTAL", "FAILED", "SUCCESS RATE", "AVG MS", "P99 MS")
+	for _, s := range health.Stages {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f%%\t%.1f\t%.1f\n", s.Stage, s.MessagesTotal, s.MessagesFailed, s.SuccessRate, s.AvgLatencyMs, s.P99LatencyMs)
+	}
+	tw.Flush()
+}
+
+// newTable creates a tab-aligned writer on stdout, printing the header if given
+func newTable(header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(header) > 0 {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	return tw
+}
+
+// writeJSON prints v as indented JSON on stdout
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// expiresIn formats the time a proposal has left
+func expiresIn(seconds float64) string {
+	if seconds <= 0 {
+		return "expired"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// deref returns an optional audit field, or a dash when unset
+func deref(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,172 @@
+// Hydrate Archive - Replays an archived exercise into a NATS server so a local environment can be rebuilt from it
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/streamarchive"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	archiveURL := flag.String("archive", os.Getenv("ARCHIVE_URL"), "Archive to read: s3://bucket/prefix or file:///path (default $ARCHIVE_URL)")
+	streams := flag.String("streams", "", "Comma-separated streams to replay (default all archived streams)")
+	from := flag.String("from", "", "Replay records stored at or after this RFC 3339 time")
+	to := flag.String("to", "", "Replay records stored before this RFC 3339 time")
+	speed := flag.Float64("speed", 0, "Replay at this multiple of real time, e.g. 1 or 10 (default as fast as possible)")
+	list := flag.Bool("list", false, "List the archive's segments instead of replaying")
+	dryRun := flag.Bool("dry-run", false, "Read the archive and count records without publishing")
+	jsonOut := flag.Bool("json", false, "Print the listing or report as JSON")
+	flag.Parse()
+
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).With().Timestamp().Logger()
+
+	opts, err := parseOptions(*streams, *from, *to, *speed, *dryRun)
+	if err != nil || *archiveURL == "" {
+		if err == nil {
+			err = fmt.Errorf("-archive or ARCHIVE_URL is required")
+		}
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	os.Exit(run(ctx, *archiveURL, opts, *list, *jsonOut))
+}
+
+func run(ctx context.Context, archiveURL string, opts streamarchive.HydrateOptions, list, jsonOut bool) int {
+	s3, err := streamarchive.S3ConfigFromEnv(archiveURL)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid S3 settings")
+		return exitError
+	}
+	store, err := streamarchive.OpenStore(archiveURL, s3)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid archive")
+		return exitError
+	}
+	archive, err := streamarchive.OpenArchive(ctx, store, false)
+	if err != nil {
+		log.Error().Err(err).Str("archive", archiveURL).Msg("Failed to open archive")
+		return exitError
+	}
+
+	if list {
+		segs := archive.Segments(opts.Filter)
+		if jsonOut {
+			json.NewEncoder(os.Stdout).Encode(segs)
+			return exitOK
+		}
+		for _, s := range segs {
+			fmt.Printf("%-14s %s part %d  %7d records  %10d bytes  %s\n",
+				s.Stream, s.Hour.Format("2006-01-02T15Z"), s.Part, s.Records, s.Bytes, s.Key)
+		}
+		return exitOK
+	}
+
+	var pub streamarchive.Publisher
+	if !opts.DryRun {
+		natsURL := getEnv("NATS_URL", "nats://localhost:4222")
+		security, err := natsutil.SecurityFromEnv().Options()
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid NATS security settings")
+			return exitError
+		}
+		nc, err := nats.Connect(natsURL, append([]nats.Option{nats.Name("cjadc2-hydrate-archive")}, security...)...)
+		if err != nil {
+			log.Error().Err(err).Str("url", natsURL).Msg("Failed to connect to NATS")
+			return exitError
+		}
+		defer nc.Close()
+
+		js, err := jetstream.New(nc)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create JetStream context")
+			return exitError
+		}
+		if err := natsutil.SetupStreams(ctx, js, nil); err != nil {
+			log.Error().Err(err).Msg("Failed to set up streams")
+			return exitError
+		}
+		pub = js
+	}
+
+	log.Info().
+		Str("archive", archiveURL).
+		Strs("streams", opts.Filter.Streams).
+		Float64("speed", opts.Speed).
+		Bool("dry_run", opts.DryRun).
+		Msg("Hydrating from archive")
+
+	started := time.Now()
+	report, err := streamarchive.Hydrate(ctx, archive, pub, opts)
+	if jsonOut {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		for stream, n := range report.ByStream {
+			fmt.Printf("published %-14s %d records\n", stream, n)
+		}
+		fmt.Printf("total                    %d records\n", report.Records)
+	}
+	if err != nil {
+		log.Error().Err(err).Int("published", report.Records).Msg("Hydration failed")
+		return exitError
+	}
+	log.Info().Dur("duration", time.Since(started)).Msg("Hydration complete")
+	return exitOK
+}
+
+// parseOptions validates the replay flags
+func parseOptions(streams, from, to string, speed float64, dryRun bool) (streamarchive.HydrateOptions, error) {
+	opts := streamarchive.HydrateOptions{Speed: speed, DryRun: dryRun}
+	for _, s := range strings.Split(streams, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			opts.Filter.Streams = append(opts.Filter.Streams, s)
+		}
+	}
+	var err error
+	if from != "" {
+		if opts.Filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return opts, fmt.Errorf("invalid -from %q: must be RFC 3339", from)
+		}
+	}
+	if to != "" {
+		if opts.Filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return opts, fmt.Errorf("invalid -to %q: must be RFC 3339", to)
+		}
+	}
+	if speed < 0 {
+		return opts, fmt.Errorf("invalid -speed %v: must not be negative", speed)
+	}
+	return opts, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,178 @@
+// Load Generator - Publishes synthetic detections and reports end-to-end pipeline throughput and latency
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/agile-defense/cjadc2/pkg/loadgen"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitSLO   = 2 // The run finished but missed a threshold
+)
+
+func main() {
+	defaults := loadgen.DefaultOptions()
+	rate := flag.Float64("rate", defaults.Rate, "Detections published per second")
+	duration := flag.Duration("duration", defaults.Duration, "How long to publish")
+	tracks := flag.Int("tracks", defaults.Tracks, "Distinct synthetic tracks the detections are spread over")
+	settle := flag.Duration("settle", defaults.Settle, "How long to keep observing after publishing stops")
+	runID := flag.String("run-id", defaults.RunID, "Run identifier used in synthetic track IDs")
+	approve := flag.Bool("approve", false, "Approve proposals for synthetic tracks through the gateway so decisions and effects are measured")
+	approvedBy := flag.String("approved-by", defaults.ApprovedBy, "User approvals are made as; needs approval authority")
+	stage := flag.String("stage", loadgen.StageCorrelated, "Stage the thresholds apply to")
+	maxP99 := flag.Duration("max-p99", 0, "Fail when the stage's p99 latency exceeds this (0 disables)")
+	minReached := flag.Float64("min-reached", 0, "Fail when fewer than this share of detections (0-1) reach the stage")
+	jsonOut := flag.Bool("json", false, "Print the report as JSON")
+	flag.Parse()
+
+	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339}).With().Timestamp().Logger()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	opts := loadgen.Options{
+		Rate:       *rate,
+		Duration:   *duration,
+		Tracks:     *tracks,
+		Settle:     *settle,
+		RunID:      *runID,
+		ApprovedBy: *approvedBy,
+	}
+	if *approve {
+		opts.ApproveURL = getEnv("API_URL", "http://localhost:8080")
+	}
+
+	os.Exit(run(ctx, opts, thresholds{stage: *stage, maxP99: *maxP99, minReached: *minReached}, *jsonOut))
+}
+
+// thresholds are the pass criteria checked after the run
+type thresholds struct {
+	stage      string
+	maxP99     time.Duration
+	minReached float64
+}
+
+func run(ctx context.Context, opts loadgen.Options, th thresholds, jsonOut bool) int {
+	natsURL := getEnv("NATS_URL", "nats://localhost:4222")
+
+	security, err := natsutil.SecurityFromEnv().Options()
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid NATS security settings")
+		return exitError
+	}
+
+	nc, err := nats.Connect(natsURL, append([]nats.Option{nats.Name("cjadc2-loadgen")}, security...)...)
+	if err != nil {
+		log.Error().Err(err).Str("url", natsURL).Msg("Failed to connect to NATS")
+		return exitError
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create JetStream context")
+		return exitError
+	}
+
+	// Detections are signed as the run's sensor so agents enforcing
+	// SIGNATURE_POLICY accept them
+	signingCfg, err := signing.ConfigFromVars(map[string]string{
+		"SIGNATURE_POLICY":     getEnv("SIGNATURE_POLICY", ""),
+		"SIGNING_KEY_LIFETIME": getEnv("SIGNING_KEY_LIFETIME", ""),
+		"SIGNING_KEY_OVERLAP":  getEnv("SIGNING_KEY_OVERLAP", ""),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid signing configuration")
+		return exitError
+	}
+	signer, err := signing.Start(ctx, js, opts.SensorID(), "sensor", signingCfg, nil)
+	if err != nil {
+		if signingCfg.Policy == signing.PolicyEnforce {
+			log.Error().Err(err).Msg("Message signing unavailable")
+			return exitError
+		}
+		log.Warn().Err(err).Msg("Message signing unavailable, publishing unsigned")
+	}
+
+	report, err := loadgen.Run(ctx, nc, js, signer, opts, log.Logger)
+	if err != nil {
+		log.Error().Err(err).Msg("Load run failed")
+		return exitError
+	}
+
+	if jsonOut {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		fmt.Printf("run                %s\n", report.RunID)
+		fmt.Printf("published          %d (%d errors, %.1f/s)\n", report.Published, report.PublishErrors, report.PublishRate)
+		if opts.ApproveURL != "" {
+			fmt.Printf("approved           %d (%d errors)\n", report.Approved, report.ApproveErrors)
+		}
+		fmt.Printf("\n%-12s %8s %10s %10s %10s %10s %10s\n", "stage", "count", "per_sec", "p50_ms", "p90_ms", "p99_ms", "max_ms")
+		for _, s := range report.Stages {
+			fmt.Printf("%-12s %8d %10.1f %10.1f %10.1f %10.1f %10.1f\n", s.Stage, s.Count, s.Throughput, s.P50Ms, s.P90Ms, s.P99Ms, s.MaxMs)
+		}
+	}
+
+	return check(report, th)
+}
+
+// check applies the thresholds to the report
+func check(report *loadgen.Report, th thresholds) int {
+	if th.maxP99 == 0 && th.minReached == 0 {
+		return exitOK
+	}
+
+	s, ok := report.Stage(th.stage)
+	if !ok {
+		log.Error().Str("stage", th.stage).Strs("stages", loadgen.Stages).Msg("Unknown stage")
+		return exitError
+	}
+
+	failed := false
+	if s.Count == 0 {
+		log.Error().Str("stage", s.Stage).Msg("No detections reached the stage")
+		failed = true
+	}
+	if th.maxP99 > 0 && s.P99Ms > float64(th.maxP99)/float64(time.Millisecond) {
+		log.Error().Str("stage", s.Stage).Float64("p99_ms", s.P99Ms).Dur("max_p99", th.maxP99).Msg("p99 latency over threshold")
+		failed = true
+	}
+	if report.Published > 0 {
+		if reached := float64(s.Count) / float64(report.Published); reached < th.minReached {
+			log.Error().Str("stage", s.Stage).Float64("reached", reached).Float64("min_reached", th.minReached).Msg("Too few detections reached the stage")
+			failed = true
+		}
+	}
+
+	if failed {
+		return exitSLO
+	}
+	log.Info().Str("stage", s.Stage).Float64("p99_ms", s.P99Ms).Msg("Thresholds met")
+	return exitOK
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,257 @@
+// Package admission bounds the load the pipeline accepts during saturation.
+//
+// Three limits are enforced where each kind of item enters the pipeline: the
+// classifier caps detections accepted per second, the correlator caps the
+// number of active tracks, and the authorizer caps pending proposals in the
+// human-in-the-loop queue. When a limit is reached the lowest-threat items are
+// shed first, so a flood of low-threat traffic cannot crowd out a missile.
+// Every shed item is counted in admission_shed_total and announced on
+// notify.admission.<kind>.
+package admission
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default limits. Zero disables a limit.
+const (
+	DefaultMaxDetectionsPerSec = 500
+	DefaultMaxActiveTracks     = 500
+	DefaultMaxPendingProposals = 100
+)
+
+// ActiveTrackTTL is how long a track stays active without updates, matching
+// the active track count reported by the gateway
+const ActiveTrackTTL = 60 * time.Second
+
+// Shed item kinds
+const (
+	KindDetection = "detection"
+	KindTrack     = "track"
+	KindProposal  = "proposal"
+)
+
+// Shed reasons
+const (
+	ReasonRateLimit = "rate_limit" // Over the detections per second limit
+	ReasonCapacity  = "capacity"   // At capacity and not more threatening than anything admitted
+	ReasonDisplaced = "displaced"  // Already admitted, evicted to make room for a higher threat
+)
+
+// Rank orders items by threat; lower ranks are shed first
+type Rank int
+
+// Ranks, lowest first
+const (
+	RankLow Rank = iota
+	RankMedium
+	RankHigh
+	RankCritical
+)
+
+// String returns the threat level name for the rank
+func (r Rank) String() string {
+	switch r {
+	case RankCritical:
+		return "critical"
+	case RankHigh:
+		return "high"
+	case RankMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// RankForThreatLevel converts a threat level name into a rank
+func RankForThreatLevel(level string) Rank {
+	switch strings.ToLower(level) {
+	case "critical":
+		return RankCritical
+	case "high":
+		return RankHigh
+	case "medium":
+		return RankMedium
+	default:
+		return RankLow
+	}
+}
+
+// RankForClassification estimates the threat of a freshly classified track,
+// before the correlator has scored it
+func RankForClassification(classification, trackType string) Rank {
+	if trackType == "missile" {
+		return RankCritical
+	}
+	switch classification {
+	case "hostile":
+		return RankHigh
+	case "unknown":
+		return RankMedium
+	default:
+		return RankLow
+	}
+}
+
+// ParseLimit parses an integer limit from the environment, returning def when
+// unset. Zero disables the limit.
+func ParseLimit(name, value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// NewShedCounter creates the admission_shed_total counter shared by every agent
+func NewShedCounter() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "admission_shed_total",
+			Help: "Items shed by admission control, by kind, reason, and threat rank",
+		},
+		[]string{"kind", "reason", "rank"},
+	)
+}
+
+// RateLimiter is a token bucket that holds part of its capacity in reserve
+// for higher-threat items. A critical item needs one token; each rank below
+// it needs a further quarter of the bucket to be available, so as the bucket
+// drains low-threat items are refused first.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter admitting up to perSec items per second
+// with a one second burst. The burst is at least four items so every rank can
+// be admitted at very low limits.
+func NewRateLimiter(perSec int) *RateLimiter {
+	burst := float64(perSec)
+	if burst < 4 {
+		burst = 4
+	}
+	return &RateLimiter{
+		rate:   float64(perSec),
+		burst:  burst,
+		tokens: burst,
+	}
+}
+
+// Admit reports whether an item of rank may enter at now, taking a token if so
+func (l *RateLimiter) Admit(rank Rank, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	if l.last.IsZero() || now.After(l.last) {
+		l.last = now
+	}
+
+	reserve := l.burst * float64(RankCritical-rank) / 4
+	if l.tokens < 1+reserve {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// ActiveSet bounds the number of distinct active items. Items already in the
+// set are always admitted; a new item is admitted when there is room, or when
+// it outranks the least threatening item, which is evicted in its place.
+type ActiveSet struct {
+	mu    sync.Mutex
+	max   int
+	ttl   time.Duration
+	items map[string]activeItem
+}
+
+type activeItem struct {
+	score    float64
+	lastSeen time.Time
+}
+
+// NewActiveSet creates a set holding at most max items, each expiring ttl after it was last seen
+func NewActiveSet(max int, ttl time.Duration) *ActiveSet {
+	return &ActiveSet{
+		max:   max,
+		ttl:   ttl,
+		items: make(map[string]activeItem),
+	}
+}
+
+// Admit records id as active with threat score at now. It reports whether the
+// item was admitted and, if another item was evicted to make room, its ID.
+func (s *ActiveSet) Admit(id string, score float64, now time.Time) (admitted bool, evicted string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[id]; ok || len(s.items) < s.max {
+		s.items[id] = activeItem{score: score, lastSeen: now}
+		return true, ""
+	}
+
+	s.expire(now)
+	if len(s.items) < s.max {
+		s.items[id] = activeItem{score: score, lastSeen: now}
+		return true, ""
+	}
+
+	// Full: displace the least threatening item, oldest first on ties
+	var lowestID string
+	var lowest activeItem
+	for itemID, item := range s.items {
+		if lowestID == "" || item.score < lowest.score ||
+			(item.score == lowest.score && item.lastSeen.Before(lowest.lastSeen)) {
+			lowestID, lowest = itemID, item
+		}
+	}
+	if score <= lowest.score {
+		return false, ""
+	}
+
+	delete(s.items, lowestID)
+	s.items[id] = activeItem{score: score, lastSeen: now}
+	return true, lowestID
+}
+
+// Remove drops id from the set
+func (s *ActiveSet) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+}
+
+// Len returns the number of items active at now
+func (s *ActiveSet) Len(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(now)
+	return len(s.items)
+}
+
+// expire removes items not seen within the TTL. The caller holds s.mu.
+func (s *ActiveSet) expire(now time.Time) {
+	for id, item := range s.items {
+		if now.Sub(item.lastSeen) > s.ttl {
+			delete(s.items, id)
+		}
+	}
+}
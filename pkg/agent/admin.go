@@ -0,0 +1,233 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+)
+
+// DefaultAdminAddr is where an agent serves its admin API unless METRICS_ADDR
+// says otherwise
+const DefaultAdminAddr = ":9090"
+
+// DefaultAdminShutdownTimeout bounds how long an admin server waits for
+// requests in flight when it shuts down, unless ADMIN_SHUTDOWN_TIMEOUT says
+// otherwise. Connections still open after it are closed.
+const DefaultAdminShutdownTimeout = 5 * time.Second
+
+// ConfigPath is the admin API route of an agent's configuration
+const ConfigPath = "/api/v1/config"
+
+// parseAdminAddr parses METRICS_ADDR, the admin API listen address
+func parseAdminAddr(value string) (string, error) {
+	if value == "" {
+		return DefaultAdminAddr, nil
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return "", fmt.Errorf("invalid METRICS_ADDR %q: %w", value, err)
+	}
+	return value, nil
+}
+
+// AdminAddr returns the address the agent serves its admin API on
+func (a *BaseAgent) AdminAddr() string {
+	return a.adminAddr
+}
+
+// AdminServer is an agent's HTTP admin API. Every agent serves the same
+// standard routes on it: /health, /health/live, /health/ready, /metrics,
+// /capabilities, and GET /api/v1/config. An agent adds its own routes with the
+// chi.Router methods before Serve, including its own /api/v1/config handlers,
+// which replace the default listing of its runtime settings.
+type AdminServer struct {
+	chi.Router
+	agent *BaseAgent
+}
+
+// NewAdminServer creates the agent's admin server with the standard routes
+func (a *BaseAgent) NewAdminServer() *AdminServer {
+	r := chi.NewRouter()
+	r.Use(cors.Handler(cors.Options{
+		AllowedOrigins:   []string{"*"},
+		AllowedMethods:   []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Correlation-ID"},
+		ExposedHeaders:   []string{"X-Correlation-ID"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}))
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, problem.New(problem.CodeNotFound, "Not found").For(r))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.Write(w, problem.New(problem.CodeMethodNotAllowed, "Method not allowed").For(r))
+	})
+
+	r.Handle("/metrics", promhttp.HandlerFor(a.registry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	r.Get("/health", a.HealthHandler())
+	r.Get("/health/live", a.LiveHandler())
+	r.Get("/health/ready", a.ReadyHandler())
+	r.Get("/capabilities", a.CapabilitiesHandler())
+
+	return &AdminServer{Router: r, agent: a}
+}
+
+// HealthHandler serves GET /health: 200 while the agent is healthy, 503 otherwise
+func (a *BaseAgent) HealthHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health := a.Health()
+		writeProbe(w, health.Healthy, health)
+	}
+}
+
+// runtimeConfigHandler serves GET /api/v1/config for agents without their own
+// configuration routes
+func (a *BaseAgent) runtimeConfigHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"agent_id":   a.id,
+		"agent_type": a.agentType,
+		"runtime":    a.runtimeConfig.Values(),
+	})
+}
+
+// Handler returns the admin API with the agent's routes, adding the default
+// GET /api/v1/config if the agent has not registered its own
+func (s *AdminServer) Handler() http.Handler {
+	if !s.Match(chi.NewRouteContext(), http.MethodGet, ConfigPath) {
+		s.Get(ConfigPath, s.agent.runtimeConfigHandler)
+	}
+	return s.Router
+}
+
+// Serve listens on the agent's admin address until ctx is done or the agent
+// stops, then shuts the server down gracefully: it stops accepting
+// connections and lets requests in flight finish for up to
+// ADMIN_SHUTDOWN_TIMEOUT before closing what is left. It returns an error if
+// the address cannot be bound or requests were cut off.
+func (s *AdminServer) Serve(ctx context.Context) error {
+	a := s.agent
+	handler := s.Handler()
+
+	ln, err := net.Listen("tcp", a.adminAddr)
+	if err != nil {
+		return fmt.Errorf("failed to bind admin server: %w", err)
+	}
+	srv := &adminHTTP{
+		Server:  &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second},
+		stopped: make(chan struct{}),
+	}
+	if !a.admin.add(srv) {
+		ln.Close()
+		return nil // The agent has already stopped
+	}
+	defer a.admin.remove(srv)
+
+	a.logger.Info().Str("addr", ln.Addr().String()).Msg("Starting admin server")
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	// Stop may be shutting the server down already; either way wait for it
+	return a.shutdownAdminServer(context.Background(), srv)
+}
+
+// adminHTTP is a served admin server, shut down once by whichever of Serve
+// and Stop gets there first
+type adminHTTP struct {
+	*http.Server
+	once    sync.Once
+	stopped chan struct{}
+	err     error
+}
+
+// adminServers are the admin servers an agent is serving
+type adminServers struct {
+	mu      sync.Mutex
+	servers map[*adminHTTP]struct{}
+	closed  bool
+}
+
+func newAdminServers() *adminServers {
+	return &adminServers{servers: make(map[*adminHTTP]struct{})}
+}
+
+// add records a server being served; it returns false once Stop has run
+func (s *adminServers) add(srv *adminHTTP) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.servers[srv] = struct{}{}
+	return true
+}
+
+func (s *adminServers) remove(srv *adminHTTP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.servers, srv)
+}
+
+// close returns the servers being served and refuses new ones
+func (s *adminServers) close() []*adminHTTP {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	servers := make([]*adminHTTP, 0, len(s.servers))
+	for srv := range s.servers {
+		servers = append(servers, srv)
+	}
+	return servers
+}
+
+// shutdownAdminServer shuts srv down within ADMIN_SHUTDOWN_TIMEOUT, or sooner
+// if ctx ends first, closing connections still open at the deadline. Every
+// caller waits for the same shutdown and gets its result.
+func (a *BaseAgent) shutdownAdminServer(ctx context.Context, srv *adminHTTP) error {
+	srv.once.Do(func() {
+		defer close(srv.stopped)
+		ctx, cancel := context.WithTimeout(ctx, a.adminShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			srv.err = fmt.Errorf("admin server shutdown: %w", err)
+			a.logger.Warn().Err(err).Msg("Closed admin connections still open at the shutdown deadline")
+			return
+		}
+		a.logger.Info().Msg("Admin server stopped")
+	})
+	<-srv.stopped
+	return srv.err
+}
+
+// shutdownAdmin shuts down every admin server the agent is serving, in
+// parallel, within ctx and ADMIN_SHUTDOWN_TIMEOUT
+func (a *BaseAgent) shutdownAdmin(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, srv := range a.admin.close() {
+		wg.Add(1)
+		go func(srv *adminHTTP) {
+			defer wg.Done()
+			a.shutdownAdminServer(ctx, srv)
+		}(srv)
+	}
+	wg.Wait()
+}
@@ -7,6 +7,9 @@ import (
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
 )
 
 // AgentType identifies the type of agent
@@ -19,6 +22,7 @@ const (
 	AgentTypePlanner    AgentType = "planner"
 	AgentTypeAuthorizer AgentType = "authorizer"
 	AgentTypeEffector   AgentType = "effector"
+	AgentTypeFederation AgentType = "federation"
 )
 
 // HealthStatus represents agent health
@@ -26,6 +30,8 @@ type HealthStatus struct {
 	Healthy bool   `json:"healthy"`
 	Status  string `json:"status"`
 	Details string `json:"details,omitempty"`
+
+	SchemaVersion uint `json:"schema_version,omitempty"` // Database schema version, for agents that use PostgreSQL
 }
 
 // Agent is the interface that all agents must implement
@@ -51,8 +57,12 @@ type Config struct {
 	OPAUrl    string
 	DBUrl     string
 	OTELUrl   string
-	Secret    []byte
 	ExtraVars map[string]string
+
+	// TLS and credentials for zero-trust networks; the zero values connect
+	// as before
+	NATSSecurity natsutil.Security
+	PostgresTLS  postgres.TLSConfig
 }
 
 // Factory creates agents of a specific type
@@ -3,14 +3,29 @@ package agent
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/chaos"
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/crypto"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+	"github.com/agile-defense/cjadc2/pkg/simclock"
+	"github.com/agile-defense/cjadc2/pkg/simrand"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
 )
 
 // BaseAgent provides common functionality for all agents
@@ -23,6 +38,15 @@ type BaseAgent struct {
 	nc *nats.Conn
 	js jetstream.JetStream
 
+	// Leaf node link; see HUB_JS_DOMAIN and UPSTREAM_JS_DOMAIN. remoteJS is
+	// the remote domain, nil without one; upstreamJS is set only for stages
+	// that consume from it.
+	linkConfig  linkConfig
+	linkMetrics linkMetrics
+	link        linkState
+	remoteJS    jetstream.JetStream
+	upstreamJS  jetstream.JetStream
+
 	// Logging
 	logger zerolog.Logger
 
@@ -31,6 +55,79 @@ type BaseAgent struct {
 	messagesTotal   *prometheus.CounterVec
 	latencyHist     *prometheus.HistogramVec
 	errorsTotal     *prometheus.CounterVec
+	consumerLag     consumerLagMetrics
+	consumeMetrics  consumeMetrics
+	signingMetrics  signingMetrics
+
+	// Readiness checks and what each dependency last reported
+	deps *dependencies
+
+	// Consumer lag gauge refresh; backlog is the last pending plus ack pending
+	// count of the agent's pipeline consumer, -1 until first read
+	consumerLagInterval time.Duration
+	backlog             atomic.Int64
+	consumerName        string // Pipeline durable, when not named after the agent type
+
+	// Self-description served at /capabilities
+	capabilities Capabilities
+
+	// Listen address of the admin API, from METRICS_ADDR
+	adminAddr string
+
+	// Admin servers being served, finished by Stop within adminShutdownTimeout
+	admin                *adminServers
+	adminShutdownTimeout time.Duration
+
+	// Tracing
+	shutdownTracing tracing.ShutdownFunc
+
+	// Graceful shutdown of consume loops
+	drain        *drainer
+	drainTimeout time.Duration
+
+	// Pipeline-wide simulation clock, kept in step with SIMCONTROL
+	simClock *simclock.Clock
+
+	// Fault injection for resilience testing; nil unless CHAOS_ENABLED=true
+	chaos *chaos.Injector
+
+	// Seeded randomness of simulated behavior, from SEED
+	randSource *simrand.Source
+	rng        *rand.Rand
+
+	// Settings changed at runtime through the AGENT_CONFIG bucket
+	runtimeConfig     *RuntimeConfig
+	fetchBatchSize    atomic.Int64
+	workerConcurrency atomic.Int64 // Messages of a batch ProcessBatch handles at once
+	messageEncoding   atomic.Value // messages.Encoding used by PublishMessage
+
+	// Security labels of published messages and their encryption; see
+	// DATA_CLASSIFICATION, DATA_RELEASABILITY, and ENCRYPT_CLASSIFICATIONS
+	classification string
+	releasability  []string
+	encryption     crypto.Policy
+	payloadKeys    *crypto.Keyring
+
+	// Message signatures; see SIGNATURE_POLICY. signing is set once Start
+	// joins the key registry.
+	signingConfig signing.Config
+	signing       atomic.Pointer[signingState]
+
+	// Retention and limits overriding the stream defaults; see STREAM_POLICY_FILE
+	streamPolicies natsutil.StreamPolicies
+
+	// Schema migrations of agents that use PostgreSQL; see PrepareSchema
+	dbMigrate     bool
+	schemaVersion uint
+
+	// Heartbeats on the HEARTBEATS stream; zero interval disables them
+	heartbeatInterval time.Duration
+	startedAt         time.Time
+
+	// Most recent error recorded, reported in heartbeats
+	errMu       sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
 
 	// State
 	running bool
@@ -75,17 +172,173 @@ func NewBaseAgent(cfg Config) (*BaseAgent, error) {
 		[]string{"error_type"},
 	)
 
+	consumerLag := newConsumerLagMetrics()
+	linkMetrics := newLinkMetrics()
+	consumeMetrics := newConsumeMetrics()
+	signingMetrics := newSigningMetrics()
+
 	registry.MustRegister(messagesTotal, latencyHist, errorsTotal)
+	registry.MustRegister(consumerLag.collectors()...)
+	registry.MustRegister(consumeMetrics.collectors()...)
+	registry.MustRegister(signingMetrics.collectors()...)
+	registry.MustRegister(linkMetrics.collectors()...)
+
+	adminAddr, err := parseAdminAddr(cfg.ExtraVars["METRICS_ADDR"])
+	if err != nil {
+		return nil, err
+	}
+
+	adminShutdownTimeout := DefaultAdminShutdownTimeout
+	if v := cfg.ExtraVars["ADMIN_SHUTDOWN_TIMEOUT"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid ADMIN_SHUTDOWN_TIMEOUT %q", v)
+		}
+		adminShutdownTimeout = d
+	}
+
+	drainTimeout := DefaultDrainTimeout
+	if v := cfg.ExtraVars["DRAIN_TIMEOUT"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid DRAIN_TIMEOUT %q", v)
+		}
+		drainTimeout = d
+	}
+
+	dbMigrate := true
+	if v := cfg.ExtraVars["DB_MIGRATE"]; v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB_MIGRATE %q", v)
+		}
+		dbMigrate = b
+	}
+
+	var streamPolicies natsutil.StreamPolicies
+	if path := cfg.ExtraVars["STREAM_POLICY_FILE"]; path != "" {
+		policies, err := natsutil.LoadStreamPolicies(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STREAM_POLICY_FILE: %w", err)
+		}
+		streamPolicies = policies
+	}
+
+	heartbeatInterval, err := topology.ParseInterval(cfg.ExtraVars["HEARTBEAT_INTERVAL"])
+	if err != nil {
+		return nil, err
+	}
+
+	consumerLagInterval, err := parseConsumerLagInterval(cfg.ExtraVars["CONSUMER_LAG_INTERVAL"])
+	if err != nil {
+		return nil, err
+	}
+
+	linkConfig, err := parseLinkConfig(cfg.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+
+	workerConcurrency, err := parseWorkerConcurrency(cfg.ExtraVars["WORKER_CONCURRENCY"])
+	if err != nil {
+		return nil, err
+	}
+
+	encoding, err := messages.ParseEncoding(cfg.ExtraVars["MESSAGE_ENCODING"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid MESSAGE_ENCODING: %w", err)
+	}
+
+	classification, err := seclabel.ParseClassification(cfg.ExtraVars["DATA_CLASSIFICATION"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid DATA_CLASSIFICATION: %w", err)
+	}
+	releasability, err := seclabel.ParseReleasability(cfg.ExtraVars["DATA_RELEASABILITY"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid DATA_RELEASABILITY: %w", err)
+	}
+	encryption, err := crypto.ParsePolicy(cfg.ExtraVars["ENCRYPT_CLASSIFICATIONS"])
+	if err != nil {
+		return nil, err
+	}
+	seed, err := simrand.ParseSeed(cfg.ExtraVars["SEED"])
+	if err != nil {
+		return nil, err
+	}
+
+	payloadKeys, err := crypto.ParseKeyring(cfg.ExtraVars["PAYLOAD_KEYS"], cfg.ExtraVars["PAYLOAD_KEY_ID"])
+	if err != nil {
+		return nil, err
+	}
+	if encryption.Requires(classification) && payloadKeys == nil {
+		return nil, fmt.Errorf("%s data must be encrypted; set PAYLOAD_KEYS", classification)
+	}
+
+	signingConfig, err := signing.ConfigFromVars(cfg.ExtraVars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Agents open their pools from DBUrl, so it carries the TLS settings
+	if err := cfg.NATSSecurity.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Mode() != config.ModeDev && !cfg.NATSSecurity.HasCredentials() {
+		return nil, fmt.Errorf("the built-in NATS passwords are for development; set NATS_CREDS_FILE or NATS_NKEY_FILE")
+	}
+	if cfg.DBUrl, err = cfg.PostgresTLS.Apply(cfg.DBUrl); err != nil {
+		return nil, err
+	}
 
 	agent := &BaseAgent{
-		id:            cfg.ID,
-		agentType:     cfg.Type,
-		config:        cfg,
-		logger:        logger,
-		registry:      registry,
-		messagesTotal: messagesTotal,
-		latencyHist:   latencyHist,
-		errorsTotal:   errorsTotal,
+		id:             cfg.ID,
+		agentType:      cfg.Type,
+		config:         cfg,
+		logger:         logger,
+		registry:       registry,
+		messagesTotal:  messagesTotal,
+		latencyHist:    latencyHist,
+		errorsTotal:    errorsTotal,
+		consumerLag:    consumerLag,
+		consumeMetrics: consumeMetrics,
+		signingMetrics: signingMetrics,
+		linkConfig:     linkConfig,
+		linkMetrics:    linkMetrics,
+		drain:          newDrainer(),
+		deps:           newDependencies(),
+		drainTimeout:   drainTimeout,
+		adminAddr:      adminAddr,
+		admin:          newAdminServers(),
+		simClock:       simclock.New(),
+		dbMigrate:      dbMigrate,
+		streamPolicies: streamPolicies,
+		classification: classification,
+		releasability:  releasability,
+		encryption:     encryption,
+		payloadKeys:    payloadKeys,
+		signingConfig:  signingConfig,
+		randSource:     simrand.NewSource(seed),
+
+		heartbeatInterval:    heartbeatInterval,
+		consumerLagInterval:  consumerLagInterval,
+		adminShutdownTimeout: adminShutdownTimeout,
+	}
+	agent.backlog.Store(-1)
+	agent.rng = rand.New(agent.randSource)
+
+	agent.messageEncoding.Store(encoding)
+	agent.workerConcurrency.Store(int64(workerConcurrency))
+	if payloadKeys != nil {
+		// Consumers open sealed messages through the messages package
+		messages.SetPayloadKeys(payloadKeys)
+	}
+	agent.runtimeConfig = NewRuntimeConfig(cfg.Type, cfg.ID, logger)
+	agent.watchBaseRuntimeConfig()
+
+	if cfg.ExtraVars["CHAOS_ENABLED"] == "true" {
+		agent.chaos = chaos.NewInjector(string(cfg.Type))
+		agent.chaos.Seed(seed)
+		registry.MustRegister(agent.chaos.Collector())
 	}
 
 	return agent, nil
@@ -121,6 +374,18 @@ func (a *BaseAgent) JetStream() jetstream.JetStream {
 	return a.js
 }
 
+// SimClock returns the simulation clock. Agents pause consumption while it is
+// paused and scale simulated intervals by its speed.
+func (a *BaseAgent) SimClock() *simclock.Clock {
+	return a.simClock
+}
+
+// Chaos returns the agent's fault injector, nil when chaos testing is disabled.
+// Consume loops call Intercept on each fetched message before processing it.
+func (a *BaseAgent) Chaos() *chaos.Injector {
+	return a.chaos
+}
+
 // Metrics returns the Prometheus registry
 func (a *BaseAgent) Metrics() *prometheus.Registry {
 	return a.registry
@@ -131,36 +396,59 @@ func (a *BaseAgent) RecordMessage(status, msgType string) {
 	a.messagesTotal.WithLabelValues(status, msgType).Inc()
 }
 
-// RecordLatency records processing latency
-func (a *BaseAgent) RecordLatency(msgType string, duration time.Duration) {
-	a.latencyHist.WithLabelValues(msgType).Observe(duration.Seconds())
+// RecordLatency records processing latency. When ctx carries a span the
+// observation is attached to its trace as an exemplar, so a slow bucket in
+// Prometheus links straight to the trace in Jaeger.
+func (a *BaseAgent) RecordLatency(ctx context.Context, msgType string, duration time.Duration) {
+	observer := a.latencyHist.WithLabelValues(msgType)
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		if eo, ok := observer.(prometheus.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(duration.Seconds(), prometheus.Labels{"trace_id": traceID})
+			return
+		}
+	}
+	observer.Observe(duration.Seconds())
 }
 
-// RecordError records an error metric
+// RecordError records an error metric and reports it as the last error in heartbeats
 func (a *BaseAgent) RecordError(errorType string) {
 	a.errorsTotal.WithLabelValues(errorType).Inc()
+
+	a.errMu.Lock()
+	a.lastError = errorType
+	a.lastErrorAt = time.Now().UTC()
+	a.errMu.Unlock()
 }
 
 // Connect establishes NATS connection
 func (a *BaseAgent) Connect(ctx context.Context) error {
 	a.logger.Info().Str("url", a.config.NATSUrl).Msg("Connecting to NATS")
 
-	// Get credentials for this agent type
-	user, pass := a.getNATSCredentials()
-
 	opts := []nats.Option{
 		nats.Name(a.id),
-		nats.UserInfo(user, pass),
 		nats.ReconnectWait(2 * time.Second),
 		nats.MaxReconnects(-1),
 		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			a.linkMetrics.connected.Set(0)
 			a.logger.Warn().Err(err).Msg("NATS disconnected")
 		}),
 		nats.ReconnectHandler(func(nc *nats.Conn) {
+			a.linkMetrics.connected.Set(1)
 			a.logger.Info().Msg("NATS reconnected")
 		}),
 	}
 
+	// A creds file or NKey replaces the per-type password
+	security, err := a.config.NATSSecurity.Options()
+	if err != nil {
+		return err
+	}
+	opts = append(opts, security...)
+	if !a.config.NATSSecurity.HasCredentials() {
+		user, pass := a.getNATSCredentials()
+		opts = append(opts, nats.UserInfo(user, pass))
+	}
+
 	nc, err := nats.Connect(a.config.NATSUrl, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to connect to NATS: %w", err)
@@ -176,8 +464,23 @@ func (a *BaseAgent) Connect(ctx context.Context) error {
 	}
 
 	a.js = js
+	a.linkMetrics.connected.Set(1)
 	a.logger.Info().Msg("Connected to NATS with JetStream")
 
+	// The remote domain is reached through the leaf node the agent connects to
+	if domain := a.linkConfig.remoteDomain(); domain != "" {
+		remote, err := jetstream.NewWithDomain(nc, domain)
+		if err != nil {
+			nc.Close()
+			return fmt.Errorf("failed to create JetStream context for domain %s: %w", domain, err)
+		}
+		a.remoteJS = remote
+		if a.linkConfig.upstreamDomain != "" {
+			a.upstreamJS = remote
+		}
+		a.logger.Info().Str("domain", domain).Msg("Following leaf node link")
+	}
+
 	return nil
 }
 
@@ -191,6 +494,7 @@ func (a *BaseAgent) getNATSCredentials() (string, string) {
 		AgentTypePlanner:    {"planner", "planner-secret"},
 		AgentTypeAuthorizer: {"authorizer", "authorizer-secret"},
 		AgentTypeEffector:   {"effector", "effector-secret"},
+		AgentTypeFederation: {"federation", "federation-secret"},
 	}
 
 	if creds, ok := credentials[a.agentType]; ok {
@@ -204,14 +508,33 @@ func (a *BaseAgent) Health() HealthStatus {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
+	status := a.health()
+	status.SchemaVersion = a.schemaVersion
+	return status
+}
+
+// health reports liveness; callers hold a.mu
+func (a *BaseAgent) health() HealthStatus {
 	if !a.running {
 		return HealthStatus{Healthy: false, Status: "stopped"}
 	}
 
+	if a.IsDraining() {
+		return HealthStatus{Healthy: false, Status: "draining", Details: "Finishing in-flight messages before shutdown"}
+	}
+
 	if a.nc == nil || !a.nc.IsConnected() {
 		return HealthStatus{Healthy: false, Status: "disconnected", Details: "NATS connection lost"}
 	}
 
+	if status, degraded := a.linkHealth(); degraded {
+		return status
+	}
+
+	if a.simClock.Paused() {
+		return HealthStatus{Healthy: true, Status: "running", Details: "Simulation paused"}
+	}
+
 	return HealthStatus{Healthy: true, Status: "running"}
 }
 
@@ -223,11 +546,26 @@ func (a *BaseAgent) Start(ctx context.Context) error {
 		return fmt.Errorf("agent already running")
 	}
 	a.running = true
+	a.startedAt = time.Now().UTC()
 
 	ctx, cancel := context.WithCancel(ctx)
 	a.cancel = cancel
 	a.mu.Unlock()
 
+	// The seed is logged so a run can be replayed with SEED
+	a.logger.Info().Int64("seed", a.Seed()).Msg("Simulation seed")
+
+	// Set up tracing; failure to reach the collector should not stop the agent
+	shutdown, err := tracing.Init(ctx, string(a.agentType), a.id, a.config.OTELUrl)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("Tracing disabled")
+	} else {
+		a.shutdownTracing = shutdown
+		if a.config.OTELUrl != "" {
+			a.logger.Info().Str("endpoint", a.config.OTELUrl).Msg("Exporting traces over OTLP")
+		}
+	}
+
 	// Connect to NATS
 	if err := a.Connect(ctx); err != nil {
 		a.mu.Lock()
@@ -236,12 +574,68 @@ func (a *BaseAgent) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Invalid messages are dead-lettered; without the stream they are only logged and nacked
+	if err := a.ensureDeadLetterStream(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Dead letter stream unavailable")
+	}
+
+	// Sign what the agent publishes; a permissive agent runs on unsigned
+	if err := a.startSigning(ctx); err != nil {
+		if a.signingConfig.Policy == signing.PolicyEnforce {
+			cancel()
+			a.nc.Close()
+			a.mu.Lock()
+			a.running = false
+			a.mu.Unlock()
+			return fmt.Errorf("message signing unavailable: %w", err)
+		}
+		a.logger.Warn().Err(err).Msg("Message signing unavailable, publishing unsigned and accepting messages unverified")
+	}
+
+	// Follow the simulation clock; without it the agent runs in real time
+	if err := a.watchSimClock(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Simulation control unavailable, running in real time")
+	}
+
+	// Follow runtime configuration; without it the agent keeps its startup settings
+	if err := a.followRuntimeConfig(ctx); err != nil {
+		a.logger.Warn().Err(err).Msg("Runtime configuration unavailable, keeping startup settings")
+	}
+
+	if a.chaos != nil {
+		if err := a.watchChaos(ctx); err != nil {
+			a.logger.Warn().Err(err).Msg("Chaos plan unavailable, no faults will be injected")
+		}
+	}
+
+	// Export the backlog of the stage's consumer for alerting and heartbeats
+	if stream, consumer, ok := a.pipelineConsumer(); ok && a.consumerLagInterval > 0 {
+		go a.runConsumerLag(ctx, stream, consumer)
+	}
+
+	// Follow the NATS connection and any leaf node link
+	go a.runLinkChecks(ctx)
+
+	// Report health and lag to the gateway's topology registry
+	if a.heartbeatInterval > 0 {
+		if _, err := a.EnsureStream(ctx, a.streamPolicies.Apply(natsutil.StreamConfigs[topology.StreamName])); err != nil {
+			a.logger.Warn().Err(err).Msg("Heartbeat stream unavailable, agent will not appear in the topology")
+		} else {
+			go a.runHeartbeats(ctx)
+		}
+	}
+
 	a.logger.Info().Msg("Agent started")
 	return nil
 }
 
-// Stop gracefully stops the agent
+// Stop gracefully stops the agent, closing its NATS connection. Call Drain
+// first so in-flight messages are acknowledged before the connection closes.
+// Admin servers are shut down first, so requests in flight are answered while
+// the connections they use are still open.
 func (a *BaseAgent) Stop(ctx context.Context) error {
+	a.shutdownAdmin(ctx)
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -255,15 +649,140 @@ func (a *BaseAgent) Stop(ctx context.Context) error {
 		a.cancel()
 	}
 
+	// Tell the topology registry now rather than after missed heartbeats
+	if a.heartbeatInterval > 0 && a.nc != nil && a.nc.IsConnected() {
+		a.publishHeartbeat(ctx, HealthStatus{Healthy: false, Status: topology.StatusStopped})
+	}
+
 	if a.nc != nil {
 		a.nc.Close()
 	}
 
+	if a.shutdownTracing != nil {
+		if err := a.shutdownTracing(ctx); err != nil {
+			a.logger.Warn().Err(err).Msg("Failed to flush traces")
+		}
+	}
+
 	a.running = false
 	a.logger.Info().Msg("Agent stopped")
 	return nil
 }
 
+// watchSimClock keeps the simulation clock in step with the SIMCONTROL stream
+func (a *BaseAgent) watchSimClock(ctx context.Context) error {
+	if _, err := a.EnsureStream(ctx, a.streamPolicies.Apply(natsutil.StreamConfigs[simclock.StreamName])); err != nil {
+		return err
+	}
+
+	a.simClock.OnChange(func(prev, next simclock.State) {
+		a.logger.Info().
+			Bool("paused", next.Paused).
+			Float64("speed", next.Speed).
+			Time("sim_time", next.SimTime).
+			Str("updated_by", next.UpdatedBy).
+			Msg("Simulation clock changed")
+	})
+	return simclock.Watch(ctx, a.js, a.simClock)
+}
+
+// StreamPolicies returns the stream retention and limits set by STREAM_POLICY_FILE
+func (a *BaseAgent) StreamPolicies() natsutil.StreamPolicies {
+	return a.streamPolicies
+}
+
+// RuntimeConfig returns the settings the agent receives at runtime
+func (a *BaseAgent) RuntimeConfig() *RuntimeConfig {
+	return a.runtimeConfig
+}
+
+// FetchBatchSize returns how many messages a consume loop should fetch at once
+func (a *BaseAgent) FetchBatchSize() int {
+	return int(a.fetchBatchSize.Load())
+}
+
+// MessageEncoding returns the encoding the agent publishes pipeline messages in
+func (a *BaseAgent) MessageEncoding() messages.Encoding {
+	return a.messageEncoding.Load().(messages.Encoding)
+}
+
+// watchBaseRuntimeConfig applies the runtime settings common to every agent
+func (a *BaseAgent) watchBaseRuntimeConfig() {
+	startLevel := zerolog.GlobalLevel()
+	a.runtimeConfig.Watch(ConfigLogLevel, func(value string, set bool) {
+		level := startLevel
+		if set {
+			parsed, err := zerolog.ParseLevel(value)
+			if err != nil || value == "" {
+				a.logger.Warn().Str("value", value).Msg("Ignoring invalid log level")
+				return
+			}
+			level = parsed
+		}
+		zerolog.SetGlobalLevel(level)
+	})
+
+	a.runtimeConfig.WatchInt(ConfigFetchBatchSize, DefaultFetchBatchSize, func(n int) {
+		if n < 1 || n > MaxFetchBatchSize {
+			a.logger.Warn().Int("value", n).Msg("Ignoring out of range fetch batch size")
+			return
+		}
+		a.fetchBatchSize.Store(int64(n))
+	})
+
+	startWorkers := a.WorkerConcurrency()
+	a.runtimeConfig.WatchInt(ConfigWorkerConcurrency, startWorkers, func(n int) {
+		if n < 1 || n > MaxWorkerConcurrency {
+			a.logger.Warn().Int("value", n).Msg("Ignoring out of range worker concurrency")
+			return
+		}
+		a.workerConcurrency.Store(int64(n))
+	})
+
+	startEncoding := a.MessageEncoding()
+	a.runtimeConfig.Watch(ConfigMessageEncoding, func(value string, set bool) {
+		encoding := startEncoding
+		if set {
+			parsed, err := messages.ParseEncoding(value)
+			if err != nil {
+				a.logger.Warn().Str("value", value).Msg("Ignoring invalid message encoding")
+				return
+			}
+			encoding = parsed
+		}
+		a.messageEncoding.Store(encoding)
+	})
+}
+
+// followRuntimeConfig keeps the runtime config in step with the AGENT_CONFIG bucket
+func (a *BaseAgent) followRuntimeConfig(ctx context.Context) error {
+	kv, err := EnsureConfigBucket(ctx, a.js)
+	if err != nil {
+		return err
+	}
+	return a.runtimeConfig.Follow(ctx, kv)
+}
+
+// watchChaos keeps the fault injector in step with the CHAOS stream
+func (a *BaseAgent) watchChaos(ctx context.Context) error {
+	if _, err := a.EnsureStream(ctx, a.streamPolicies.Apply(natsutil.StreamConfigs[chaos.StreamName])); err != nil {
+		return err
+	}
+
+	a.logger.Warn().Msg("Chaos testing enabled")
+	return chaos.Watch(ctx, a.js, func(plan chaos.Plan) {
+		if a.chaos.Apply(plan) {
+			f := a.chaos.Fault()
+			a.logger.Warn().
+				Int64("latency_ms", f.LatencyMs).
+				Float64("drop_percent", f.DropPercent).
+				Float64("nak_percent", f.NakPercent).
+				Str("updated_by", plan.UpdatedBy).
+				Msg("Chaos plan changed")
+		}
+	})
+}
+
 // EnsureStream creates a stream if it doesn't exist
 func (a *BaseAgent) EnsureStream(ctx context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error) {
 	stream, err := a.js.Stream(ctx, cfg.Name)
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/agile-defense/cjadc2/pkg/crypto"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// Version is the agent build version, overridable at link time with
+// -ldflags "-X github.com/agile-defense/cjadc2/pkg/agent.Version=..."
+var Version = "dev"
+
+// CapabilitiesSchemaVersion is the version of the capabilities document format
+const CapabilitiesSchemaVersion = "1"
+
+// Message directions
+const (
+	DirectionConsumes = "consumes"
+	DirectionProduces = "produces"
+)
+
+// MessageCapability describes a message type an agent consumes or produces
+type MessageCapability struct {
+	Type      string `json:"type"`
+	Subject   string `json:"subject"`
+	Stream    string `json:"stream,omitempty"`
+	Direction string `json:"direction"`
+}
+
+// ConfigField describes a configuration option accepted by an agent
+type ConfigField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"` // string, int, bool, duration, float, url
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+	Env         string `json:"env,omitempty"`
+	Runtime     bool   `json:"runtime"` // Can be changed without a restart
+}
+
+// ControlCommand describes an operational command exposed by an agent
+type ControlCommand struct {
+	Name        string `json:"name"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Route describes an HTTP route served by an agent
+type Route struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Capabilities is the machine-readable self-description served at GET /capabilities
+type Capabilities struct {
+	SchemaVersion string              `json:"schema_version"`
+	AgentID       string              `json:"agent_id"`
+	AgentType     AgentType           `json:"agent_type"`
+	Version       string              `json:"version"`
+	Messages      []MessageCapability `json:"messages"`
+	ConfigSchema  []ConfigField       `json:"config_schema"`
+	Commands      []ControlCommand    `json:"commands"`
+	Routes        []Route             `json:"routes"`
+}
+
+// baseRoutes are served by every agent
+var baseRoutes = []Route{
+	{Method: http.MethodGet, Path: "/health", Description: "Agent health status"},
+	{Method: http.MethodGet, Path: "/health/live", Description: "Liveness probe: whether the process is up"},
+	{Method: http.MethodGet, Path: "/health/ready", Description: "Readiness probe: NATS, consumer, database, and OPA status with last errors"},
+	{Method: http.MethodGet, Path: "/metrics", Description: "Prometheus metrics"},
+	{Method: http.MethodGet, Path: "/capabilities", Description: "Agent capability discovery"},
+	{Method: http.MethodGet, Path: ConfigPath, Description: "Agent configuration"},
+}
+
+// baseConfig lists configuration common to every agent
+var baseConfig = []ConfigField{
+	{Name: "agent_id", Type: "string", Env: "AGENT_ID", Description: "Unique agent instance identifier"},
+	{Name: "nats_url", Type: "url", Env: "NATS_URL", Default: "nats://localhost:4222", Description: "NATS server URL"},
+	{Name: "opa_url", Type: "url", Env: "OPA_URL", Default: "http://localhost:8181", Description: "OPA server URL"},
+	{Name: "metrics_addr", Type: "string", Env: "METRICS_ADDR", Default: DefaultAdminAddr, Description: "Listen address of the admin API: health probes, metrics, capabilities, and configuration"},
+	{Name: "admin_shutdown_timeout", Type: "duration", Env: "ADMIN_SHUTDOWN_TIMEOUT", Default: DefaultAdminShutdownTimeout.String(), Description: "How long shutdown waits for admin API requests in flight to finish"},
+	{Name: "signature_policy", Type: "string", Env: "SIGNATURE_POLICY", Default: string(signing.PolicyEnforce), Description: "How signatures of consumed messages are checked: enforce refuses unsigned messages and bad signatures, permissive accepts unsigned ones and is refused outside CJADC2_MODE=dev, off skips checks"},
+	{Name: "signing_key_lifetime", Type: "duration", Env: "SIGNING_KEY_LIFETIME", Default: signing.DefaultKeyLifetime.String(), Description: "How long each signing key the agent generates is valid"},
+	{Name: "signing_key_overlap", Type: "duration", Env: "SIGNING_KEY_OVERLAP", Default: signing.DefaultKeyOverlap.String(), Description: "How long before a signing key expires its successor takes over; both verify meanwhile"},
+	{Name: "drain_timeout", Type: "duration", Env: "DRAIN_TIMEOUT", Default: DefaultDrainTimeout.String(), Description: "How long shutdown waits for in-flight messages to finish"},
+	{Name: "chaos_enabled", Type: "bool", Env: "CHAOS_ENABLED", Default: "false", Description: "Apply the fault plan set through /api/v1/chaos to consumed messages"},
+	{Name: "seed", Type: "int", Env: "SEED", Description: "Seed for simulated randomness such as generated tracks, sensor errors, battle damage rolls, and chaos faults; unset picks one from the clock, logged at startup so the run can be replayed"},
+	{Name: "heartbeat_interval", Type: "duration", Env: "HEARTBEAT_INTERVAL", Default: topology.DefaultInterval.String(), Description: "How often the agent reports health and consumer lag on the HEARTBEATS stream (0 disables)"},
+	{Name: "consumer_lag_interval", Type: "duration", Env: "CONSUMER_LAG_INTERVAL", Default: DefaultConsumerLagInterval.String(), Description: "How often the consumer pending, ack pending, and redelivered gauges are refreshed (0 disables)"},
+	{Name: "stream_policy_file", Type: "string", Env: "STREAM_POLICY_FILE", Description: "JSON file of per-stream retention, age, and size limits overriding the defaults"},
+	{Name: "data_classification", Type: "string", Env: "DATA_CLASSIFICATION", Default: seclabel.Unclassified, Description: "Classification label of published messages that carry none: unclassified, confidential, secret, or top_secret"},
+	{Name: "data_releasability", Type: "string", Env: "DATA_RELEASABILITY", Description: "Coalition partners published messages that carry no releasability are released to, as comma-separated tags such as GBR,FVEY; unset releases to none"},
+	{Name: "encrypt_classifications", Type: "string", Env: "ENCRYPT_CLASSIFICATIONS", Default: crypto.DefaultEncryptClassifications, Description: "Classification labels whose messages are sealed with AES-GCM, or none"},
+	{Name: "payload_keys", Type: "string", Env: "PAYLOAD_KEYS", Description: "Payload encryption keys as id:base64-key pairs; keep retired keys listed until their messages have aged out (supports _FILE)"},
+	{Name: "payload_key_id", Type: "string", Env: "PAYLOAD_KEY_ID", Description: "Key new messages are sealed under; defaults to the last key in PAYLOAD_KEYS"},
+}
+
+// DBMigrateConfig describes DB_MIGRATE for agents that use PostgreSQL
+var DBMigrateConfig = ConfigField{Name: "db_migrate", Type: "bool", Env: "DB_MIGRATE", Default: "true", Description: "Apply pending schema migrations at startup; false only verifies the schema is current"}
+
+// EdgeLinkConfig lists the settings of agents that can run on an edge leaf
+// node, buffering while the link to the hub is down
+var EdgeLinkConfig = []ConfigField{
+	{Name: "hub_js_domain", Type: "string", Env: "HUB_JS_DOMAIN", Description: "JetStream domain of the hub when the agent runs on an edge leaf node; the agent reports degraded and the depth of its held messages while the hub is unreachable"},
+	{Name: "link_check_interval", Type: "duration", Env: "LINK_CHECK_INTERVAL", Default: DefaultLinkCheckInterval.String(), Description: "How often the leaf node link is checked"},
+}
+
+// UpstreamLinkConfig lists the settings of hub stages whose input stream can
+// live on an edge leaf node
+var UpstreamLinkConfig = []ConfigField{
+	{Name: "upstream_js_domain", Type: "string", Env: "UPSTREAM_JS_DOMAIN", Description: "JetStream domain of the edge leaf node holding the stage's input stream; the durable consumer is kept there and resumes after a link outage"},
+	{Name: "link_check_interval", Type: "duration", Env: "LINK_CHECK_INTERVAL", Default: DefaultLinkCheckInterval.String(), Description: "How often the leaf node link is checked"},
+}
+
+// OPAClientConfig lists the OPA evaluation mode, decision cache, and circuit
+// breaker settings of agents that check policy
+var OPAClientConfig = []ConfigField{
+	{Name: "opa_cache_ttl", Type: "duration", Env: "OPA_CACHE_TTL", Default: opa.DefaultCacheTTL.String(), Description: "How long identical policy decisions are cached (0 disables)"},
+	{Name: "opa_breaker_threshold", Type: "int", Env: "OPA_BREAKER_THRESHOLD", Default: strconv.Itoa(opa.DefaultBreakerThreshold), Description: "Consecutive OPA failures that open the circuit breaker (0 disables)"},
+	{Name: "opa_breaker_cooldown", Type: "duration", Env: "OPA_BREAKER_COOLDOWN", Default: opa.DefaultBreakerCooldown.String(), Description: "How long the breaker stays open before probing OPA again"},
+	{Name: "opa_fallback_decision", Type: "string", Env: "OPA_FALLBACK_DECISION", Default: opa.FallbackNone, Description: "Decision used while OPA is unavailable: none, allow, or deny"},
+	{Name: "opa_mode", Type: "string", Env: "OPA_MODE", Default: opa.ModeServer, Description: "Policy evaluation: server queries OPA_URL, embedded evaluates the bundle in-process"},
+	{Name: "opa_bundle_path", Type: "string", Env: "OPA_BUNDLE_PATH", Default: opa.DefaultBundlePath, Description: "Policy bundle directory compiled in embedded mode"},
+}
+
+// SetCapabilities registers the agent-specific part of the capabilities document.
+// Identity, version, and the routes and config common to all agents are filled in automatically.
+func (a *BaseAgent) SetCapabilities(caps Capabilities) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.capabilities = caps
+}
+
+// Capabilities returns the agent's full capabilities document
+func (a *BaseAgent) Capabilities() Capabilities {
+	a.mu.RLock()
+	caps := a.capabilities
+	a.mu.RUnlock()
+
+	caps.SchemaVersion = CapabilitiesSchemaVersion
+	caps.AgentID = a.id
+	caps.AgentType = a.agentType
+	caps.Version = Version
+
+	caps.Messages = append([]MessageCapability{}, caps.Messages...)
+	caps.ConfigSchema = append(append(append([]ConfigField{}, baseConfig...), RuntimeConfigFields...), caps.ConfigSchema...)
+	caps.Commands = append([]ControlCommand{}, caps.Commands...)
+	caps.Routes = append(append([]Route{}, baseRoutes...), caps.Routes...)
+
+	return caps
+}
+
+// CapabilitiesHandler serves the capabilities document as JSON
+func (a *BaseAgent) CapabilitiesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(a.Capabilities())
+	}
+}
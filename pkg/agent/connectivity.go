@@ -0,0 +1,259 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// StatusDegraded is the health status of an agent that keeps running while
+// the link to the other side of a leaf node is down. It stays healthy, since
+// restarting it would not restore the link.
+const StatusDegraded = "degraded"
+
+// DefaultLinkCheckInterval is how often the NATS connection and the link to
+// a remote JetStream domain are checked
+const DefaultLinkCheckInterval = 5 * time.Second
+
+// linkMetrics are the connection state gauges of an agent
+type linkMetrics struct {
+	connected  prometheus.Gauge
+	buffered   prometheus.Gauge
+	linkUp     *prometheus.GaugeVec
+	queueDepth *prometheus.GaugeVec
+}
+
+func newLinkMetrics() linkMetrics {
+	return linkMetrics{
+		connected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_nats_connected",
+			Help: "Whether the agent is connected to its NATS server (1) or not (0)",
+		}),
+		buffered: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_nats_buffered_bytes",
+			Help: "Bytes published while disconnected and held by the client until it reconnects",
+		}),
+		linkUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_link_up",
+			Help: "Whether the remote JetStream domain across the leaf node link answers (1) or not (0)",
+		}, []string{"domain"}),
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_link_queue_depth",
+			Help: "Messages the agent published that are held in the local stream until a consumer, such as a hub stage across the link, reads them",
+		}, []string{"stream"}),
+	}
+}
+
+func (m linkMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.connected, m.buffered, m.linkUp, m.queueDepth}
+}
+
+// linkConfig selects the remote JetStream domain an agent depends on across a
+// leaf node link. Edge agents name the hub, whose stages read what they
+// publish; hub stages name the edge domain holding the stream they consume.
+type linkConfig struct {
+	hubDomain      string // HUB_JS_DOMAIN: the hub, from an agent on an edge leaf node
+	upstreamDomain string // UPSTREAM_JS_DOMAIN: the domain of the stage's input stream
+	interval       time.Duration
+}
+
+// parseLinkConfig parses HUB_JS_DOMAIN, UPSTREAM_JS_DOMAIN, and LINK_CHECK_INTERVAL
+func parseLinkConfig(vars map[string]string) (linkConfig, error) {
+	cfg := linkConfig{
+		hubDomain:      vars["HUB_JS_DOMAIN"],
+		upstreamDomain: vars["UPSTREAM_JS_DOMAIN"],
+		interval:       DefaultLinkCheckInterval,
+	}
+	if cfg.hubDomain != "" && cfg.upstreamDomain != "" {
+		return cfg, fmt.Errorf("set only one of HUB_JS_DOMAIN, for edge agents, and UPSTREAM_JS_DOMAIN, for hub stages")
+	}
+	if v := vars["LINK_CHECK_INTERVAL"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid LINK_CHECK_INTERVAL %q: must be a positive duration", v)
+		}
+		cfg.interval = d
+	}
+	return cfg, nil
+}
+
+// remoteDomain returns the domain the agent checks the link to
+func (c linkConfig) remoteDomain() string {
+	if c.upstreamDomain != "" {
+		return c.upstreamDomain
+	}
+	return c.hubDomain
+}
+
+// linkState records whether the remote domain answers and since when it has not
+type linkState struct {
+	mu        sync.RWMutex
+	down      bool
+	downSince time.Time
+}
+
+// set records the result of a link check, returning whether it changed
+func (s *linkState) set(up bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if up == !s.down {
+		return false
+	}
+	s.down = !up
+	if s.down {
+		s.downSince = time.Now().UTC()
+	}
+	return true
+}
+
+// get returns whether the link is down and since when
+func (s *linkState) get() (bool, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.down, s.downSince
+}
+
+// UpstreamJetStream returns the JetStream the agent's input stream lives in:
+// the UPSTREAM_JS_DOMAIN across a leaf node link when set, else the agent's own
+func (a *BaseAgent) UpstreamJetStream() jetstream.JetStream {
+	if a.upstreamJS != nil {
+		return a.upstreamJS
+	}
+	return a.js
+}
+
+// LinkUp reports whether the remote JetStream domain answered its last check.
+// It is always true for agents that do not depend on one.
+func (a *BaseAgent) LinkUp() bool {
+	down, _ := a.link.get()
+	return !down
+}
+
+// SetupUpstreamConsumer creates or resumes the stage's durable consumer on its
+// input stream. Across a leaf node link the consumer lives in the edge domain,
+// so messages published at the edge wait in it through an outage and are
+// delivered from where the stage left off once the link returns. Until the
+// upstream answers, creation is retried every LINK_CHECK_INTERVAL.
+func (a *BaseAgent) SetupUpstreamConsumer(ctx context.Context, stream, consumerName string) (jetstream.Consumer, error) {
+	return a.SetupUpstreamConsumerConfig(ctx, stream, natsutil.ConsumerConfigFor(consumerName))
+}
+
+// SetupUpstreamConsumerConfig is SetupUpstreamConsumer for a consumer not in
+// natsutil.ConsumerConfigs
+func (a *BaseAgent) SetupUpstreamConsumerConfig(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	for {
+		consumer, err := natsutil.SetupConsumerConfig(ctx, a.UpstreamJetStream(), stream, cfg)
+		if err == nil || a.upstreamJS == nil {
+			return consumer, err
+		}
+		a.logger.Warn().Err(err).
+			Str("domain", a.linkConfig.upstreamDomain).
+			Str("stream", stream).
+			Msg("Upstream stream unreachable, waiting for the leaf node link")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(a.linkConfig.interval):
+		}
+	}
+}
+
+// runLinkChecks refreshes the connection state immediately and then every
+// interval until ctx is done
+func (a *BaseAgent) runLinkChecks(ctx context.Context) {
+	ticker := time.NewTicker(a.linkConfig.interval)
+	defer ticker.Stop()
+
+	for {
+		a.checkLink(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkLink records the NATS connection state, probes the remote domain, and
+// on an edge agent measures what waits locally for consumers across the link
+func (a *BaseAgent) checkLink(ctx context.Context) {
+	if a.nc.IsConnected() {
+		a.linkMetrics.connected.Set(1)
+	} else {
+		a.linkMetrics.connected.Set(0)
+	}
+	if buffered, err := a.nc.Buffered(); err == nil {
+		a.linkMetrics.buffered.Set(float64(buffered))
+	}
+
+	domain := a.linkConfig.remoteDomain()
+	if domain == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+
+	_, err := a.remoteJS.AccountInfo(ctx)
+	up := err == nil
+	if up {
+		a.linkMetrics.linkUp.WithLabelValues(domain).Set(1)
+	} else {
+		a.linkMetrics.linkUp.WithLabelValues(domain).Set(0)
+	}
+	if a.link.set(up) {
+		if up {
+			a.logger.Info().Str("domain", domain).Msg("Leaf node link restored")
+		} else {
+			a.logger.Warn().Err(err).Str("domain", domain).Msg("Leaf node link down, operating disconnected")
+		}
+	}
+
+	if a.linkConfig.hubDomain != "" {
+		for _, stream := range a.producedStreams() {
+			depth, err := natsutil.StreamBacklog(ctx, a.js, stream)
+			if err != nil {
+				continue
+			}
+			a.linkMetrics.queueDepth.WithLabelValues(stream).Set(float64(depth))
+		}
+	}
+}
+
+// producedStreams returns the pipeline streams the agent publishes to
+func (a *BaseAgent) producedStreams() []string {
+	var streams []string
+	seen := make(map[string]bool)
+	for _, m := range a.Capabilities().Messages {
+		if m.Direction != DirectionProduces || seen[m.Stream] {
+			continue
+		}
+		if _, ok := natsutil.PipelineConsumers[m.Stream]; ok {
+			seen[m.Stream] = true
+			streams = append(streams, m.Stream)
+		}
+	}
+	return streams
+}
+
+// linkHealth reports a degraded status while the remote domain is unreachable
+func (a *BaseAgent) linkHealth() (HealthStatus, bool) {
+	down, since := a.link.get()
+	if !down {
+		return HealthStatus{}, false
+	}
+	details := fmt.Sprintf("JetStream domain %s unreachable since %s", a.linkConfig.remoteDomain(), since.Format(time.RFC3339))
+	if a.linkConfig.hubDomain != "" {
+		details += "; messages are held by the leaf node until the link returns"
+	} else {
+		details += "; the stage resumes its consumer when the link returns"
+	}
+	return HealthStatus{Healthy: true, Status: StatusDegraded, Details: details}, true
+}
@@ -0,0 +1,317 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// FetchMaxWait is how long a fetch waits for messages before Consume checks
+// again for a drain or pause
+const FetchMaxWait = 5 * time.Second
+
+// PausePollInterval is how often Consume checks whether a hold has lifted
+const PausePollInterval = 100 * time.Millisecond
+
+// ConsumeOptions tailors Consume to a stage. The zero value fetches batches
+// of FetchBatchSize from the durable's entry in natsutil.ConsumerConfigs,
+// handles them in fetch order, holds while the simulation clock is paused,
+// and acknowledges each message by the handler's outcome.
+type ConsumeOptions struct {
+	// Config replaces the durable's entry in natsutil.ConsumerConfigs, such
+	// as for a correlator shard filtering on its own cells
+	Config *jetstream.ConsumerConfig
+
+	// Upstream reads from the consumer in the upstream JetStream domain and
+	// holds fetching while the leaf node link to it is down
+	Upstream bool
+
+	// Schema, when set, validates each message and verifies its signature
+	// before it is handled. A message that breaks the schema or whose
+	// signature SIGNATURE_POLICY refuses is dead-lettered and terminated.
+	Schema string
+
+	// Key partitions each batch across WorkerConcurrency workers; without
+	// one the batch is handled in fetch order
+	Key PartitionKey
+
+	// Hold returns how long to wait before fetching again, such as while the
+	// operator has paused the agent; zero fetches. Without one Consume holds
+	// while the simulation clock is paused.
+	Hold func() time.Duration
+
+	// ManualAck leaves a successfully handled message unacknowledged, for the
+	// handler to settle later
+	ManualAck bool
+
+	// NakDelay delays redelivery of a message the handler failed on
+	NakDelay time.Duration
+
+	// Fetch replaces fetching a batch of FetchBatchSize, such as to take
+	// messages through a priority queue. It returns the messages to handle,
+	// or an error from the consumer.
+	Fetch func(ctx context.Context, consumer jetstream.Consumer) (<-chan jetstream.Msg, error)
+
+	// OnDrain is called when Consume stops for a drain, such as to return
+	// messages held outside the consumer to the stream
+	OnDrain func()
+}
+
+// consumeMetrics counts how consumed messages were settled and how often
+// deleted consumers were recreated
+type consumeMetrics struct {
+	settled   *prometheus.CounterVec
+	recreated *prometheus.CounterVec
+}
+
+func newConsumeMetrics() consumeMetrics {
+	return consumeMetrics{
+		settled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_messages_settled_total",
+			Help: "Consumed messages by how they were settled: ack, nak, term, or dead_letter",
+		}, []string{"outcome"}),
+		recreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_consumer_recreations_total",
+			Help: "Times the agent recreated its durable consumer after it was deleted",
+		}, []string{"stream", "consumer"}),
+	}
+}
+
+func (m consumeMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.settled, m.recreated}
+}
+
+// permanentError is a handler failure redelivery cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as one redelivery cannot fix, such as a
+// message that does not decode. The message is terminated instead of
+// negatively acknowledged.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Consume runs the stage's consume loop on a durable consumer of stream until
+// ctx is done or the agent drains. It creates the consumer, fetches batches,
+// hands each message to handle through HandleBatch, and recreates the
+// consumer if it is deleted, as an exercise reset does.
+func (a *BaseAgent) Consume(ctx context.Context, stream, durable string, handle MessageHandler, opts ConsumeOptions) error {
+	cfg := natsutil.ConsumerConfigFor(durable)
+	if opts.Config != nil {
+		cfg = *opts.Config
+	}
+
+	a.bindConsumer(stream, cfg.Durable, false, nil)
+	var consumer jetstream.Consumer
+	var err error
+	if opts.Upstream {
+		consumer, err = a.SetupUpstreamConsumerConfig(ctx, stream, cfg)
+	} else {
+		consumer, err = natsutil.SetupConsumerConfig(ctx, a.js, stream, cfg)
+	}
+	if err != nil {
+		a.bindConsumer(stream, cfg.Durable, false, err)
+		return fmt.Errorf("failed to setup consumer: %w", err)
+	}
+	a.bindConsumer(stream, cfg.Durable, true, nil)
+
+	done, ok := a.BeginConsuming()
+	if !ok {
+		return nil
+	}
+	defer done()
+
+	hold := opts.Hold
+	if hold == nil {
+		hold = a.holdWhileSimPaused
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-a.Draining():
+			if opts.OnDrain != nil {
+				opts.OnDrain()
+			}
+			return nil // Stop fetching; the current batch has been handled
+		default:
+		}
+
+		if wait := hold(); wait > 0 {
+			time.Sleep(wait)
+			continue
+		}
+
+		// While the link to the edge is down, messages wait in its stream and
+		// the durable consumer resumes from where it left off
+		if opts.Upstream && !a.LinkUp() {
+			time.Sleep(time.Second)
+			continue
+		}
+
+		msgs, batchErr, err := a.fetch(ctx, consumer, opts)
+		if err != nil {
+			consumer = a.handleFetchError(ctx, stream, cfg, consumer, opts.Upstream, err)
+			continue
+		}
+
+		a.HandleBatch(ctx, msgs, handle, opts)
+
+		if err := batchErr(); natsutil.ConsumerGone(err) {
+			consumer = a.handleFetchError(ctx, stream, cfg, consumer, opts.Upstream, err)
+		} else if err != nil && !fetchTimedOut(err) {
+			a.logger.Warn().Err(err).Str("stream", stream).Str("consumer", cfg.Durable).Msg("Message batch error")
+		}
+	}
+}
+
+// holdWhileSimPaused holds messages in the stream while the simulation is paused
+func (a *BaseAgent) holdWhileSimPaused() time.Duration {
+	if a.SimClock().Paused() {
+		return PausePollInterval
+	}
+	return 0
+}
+
+// fetch takes the next messages to handle. The returned function reports an
+// error that ended the batch, once its messages have been read.
+func (a *BaseAgent) fetch(ctx context.Context, consumer jetstream.Consumer, opts ConsumeOptions) (<-chan jetstream.Msg, func() error, error) {
+	if opts.Fetch != nil {
+		msgs, err := opts.Fetch(ctx, consumer)
+		return msgs, func() error { return nil }, err
+	}
+	batch, err := consumer.Fetch(a.FetchBatchSize(), jetstream.FetchMaxWait(FetchMaxWait))
+	if err != nil {
+		return nil, nil, err
+	}
+	return batch.Messages(), batch.Error, nil
+}
+
+// fetchTimedOut reports whether a fetch ended without error, by waiting out
+// FetchMaxWait or being cancelled
+func fetchTimedOut(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, nats.ErrTimeout)
+}
+
+// handleFetchError recreates the consumer if it was deleted and otherwise
+// backs off before fetching again. It returns the consumer to fetch from next.
+func (a *BaseAgent) handleFetchError(ctx context.Context, stream string, cfg jetstream.ConsumerConfig, consumer jetstream.Consumer, upstream bool, err error) jetstream.Consumer {
+	if fetchTimedOut(err) {
+		return consumer
+	}
+	if !natsutil.ConsumerGone(err) {
+		a.logger.Error().Err(err).Str("stream", stream).Str("consumer", cfg.Durable).Msg("Failed to fetch messages")
+		a.RecordError("fetch_error")
+		time.Sleep(time.Second)
+		return consumer
+	}
+
+	a.logger.Warn().Err(err).Str("stream", stream).Str("consumer", cfg.Durable).Msg("Consumer was deleted, recreating...")
+	a.bindConsumer(stream, cfg.Durable, false, err)
+	js := a.js
+	if upstream {
+		js = a.UpstreamJetStream()
+	}
+	recreated, err := natsutil.SetupConsumerConfig(ctx, js, stream, cfg)
+	if err != nil {
+		a.logger.Error().Err(err).Str("stream", stream).Str("consumer", cfg.Durable).Msg("Failed to recreate consumer")
+		a.RecordError("consumer_recreate_error")
+		time.Sleep(time.Second)
+		return consumer
+	}
+	a.consumeMetrics.recreated.WithLabelValues(stream, cfg.Durable).Inc()
+	a.bindConsumer(stream, cfg.Durable, true, nil)
+	a.logger.Info().Str("stream", stream).Str("consumer", cfg.Durable).Msg("Consumer recreated successfully")
+	return recreated
+}
+
+// HandleBatch handles fetched messages as Consume does with opts and returns
+// once every message has been handled and settled. Messages are partitioned
+// by opts.Key across WorkerConcurrency workers, so messages about one track
+// keep their order while different tracks are handled in parallel.
+func (a *BaseAgent) HandleBatch(ctx context.Context, msgs <-chan jetstream.Msg, handle MessageHandler, opts ConsumeOptions) {
+	workers := a.WorkerConcurrency()
+	if workers <= 1 || opts.Key == nil {
+		for msg := range msgs {
+			a.processOne(ctx, msg, handle, opts)
+		}
+		return
+	}
+
+	queues := make([]chan jetstream.Msg, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan jetstream.Msg, a.FetchBatchSize())
+		wg.Add(1)
+		go func(queue <-chan jetstream.Msg) {
+			defer wg.Done()
+			for msg := range queue {
+				a.processOne(ctx, msg, handle, opts)
+			}
+		}(queues[i])
+	}
+
+	for msg := range msgs {
+		queues[Partition(opts.Key(msg), workers)] <- msg
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// processOne applies chaos faults, schema validation, and signature checks to
+// a message, handles it, and settles it by the outcome
+func (a *BaseAgent) processOne(ctx context.Context, msg jetstream.Msg, handle MessageHandler, opts ConsumeOptions) {
+	if a.Chaos().Intercept(ctx, msg) {
+		return
+	}
+	if opts.Schema != "" && !a.ValidateMessage(ctx, msg, opts.Schema) {
+		a.consumeMetrics.settled.WithLabelValues("dead_letter").Inc()
+		return
+	}
+	if opts.Schema != "" && !a.verifyMessage(ctx, msg, opts.Schema, opts.Upstream) {
+		return
+	}
+
+	err := handle(ctx, msg)
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		if !opts.ManualAck {
+			msg.Ack()
+			a.consumeMetrics.settled.WithLabelValues("ack").Inc()
+		}
+	case errors.As(err, &permanent):
+		a.logger.Error().Err(err).Msg("Failed to process message, terminating")
+		a.RecordError("process_error")
+		msg.Term()
+		a.consumeMetrics.settled.WithLabelValues("term").Inc()
+	default:
+		a.logger.Error().Err(err).Msg("Failed to process message")
+		a.RecordError("process_error")
+		if opts.NakDelay > 0 {
+			msg.NakWithDelay(opts.NakDelay)
+		} else {
+			msg.Nak()
+		}
+		a.consumeMetrics.settled.WithLabelValues("nak").Inc()
+	}
+}
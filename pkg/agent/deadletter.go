@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// ValidateMessage checks a fetched message against its schema before it is
+// processed. An invalid message is published to the DLQ stream with the
+// validation errors and terminated, so it is neither redelivered nor allowed
+// to carry wrong fields down the pipeline; the caller should skip it. If the
+// dead letter cannot be published the message is nacked instead, so it is
+// retried up to the consumer's MaxDeliver.
+func (a *BaseAgent) ValidateMessage(ctx context.Context, msg jetstream.Msg, schema string) bool {
+	verr := messages.ValidateEncoded(schema, msg.Headers().Get(messages.ContentTypeHeader), msg.Data())
+	if verr == nil {
+		return true
+	}
+
+	a.RecordMessage("invalid", schema)
+	a.logger.Warn().
+		Err(verr).
+		Str("subject", msg.Subject()).
+		Str("schema", schema).
+		Msg("Message failed schema validation, dead-lettering")
+
+	if err := a.DeadLetter(ctx, msg, schema, verr); err != nil {
+		a.logger.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to dead-letter message")
+		a.RecordError("dead_letter_error")
+		msg.Nak()
+		return false
+	}
+	msg.Term()
+	return false
+}
+
+// DeadLetter publishes a refused message to the DLQ stream with the reason
+// it was refused. It does not acknowledge the message.
+func (a *BaseAgent) DeadLetter(ctx context.Context, msg jetstream.Msg, schema string, cause error) error {
+	dl := messages.NewDeadLetter(a.id, string(a.agentType), schema, msg.Data(), cause)
+	dl.OriginalSubject = msg.Subject()
+	dl.ContentType = msg.Headers().Get(messages.ContentTypeHeader)
+	if meta, err := msg.Metadata(); err == nil {
+		dl.Stream = meta.Stream
+		dl.Consumer = meta.Consumer
+	}
+	if dl.Consumer == "" {
+		dl.Consumer = string(a.agentType)
+	}
+
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+	if _, err := a.js.Publish(ctx, dl.Subject(), data); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+	return nil
+}
+
+// ensureDeadLetterStream creates the DLQ stream refused messages are sent to
+func (a *BaseAgent) ensureDeadLetterStream(ctx context.Context) error {
+	_, err := a.EnsureStream(ctx, a.streamPolicies.Apply(natsutil.StreamConfigs[natsutil.DeadLetterStream]))
+	return err
+}
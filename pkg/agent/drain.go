@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultDrainTimeout bounds how long shutdown waits for in-flight messages.
+// It covers one full fetch wait plus processing of the batch it returns.
+const DefaultDrainTimeout = 15 * time.Second
+
+// drainer tracks consume loops so shutdown can wait for them to finish their
+// current batch
+type drainer struct {
+	mu       sync.Mutex
+	draining chan struct{}
+	started  bool
+	loops    sync.WaitGroup
+}
+
+func newDrainer() *drainer {
+	return &drainer{draining: make(chan struct{})}
+}
+
+// BeginConsuming registers a consume loop with the drain. The loop must stop
+// fetching once Draining is closed and call the returned function when it
+// exits. It returns false if the agent is already draining, in which case the
+// loop should not start.
+func (a *BaseAgent) BeginConsuming() (func(), bool) {
+	a.drain.mu.Lock()
+	defer a.drain.mu.Unlock()
+	if a.drain.started {
+		return func() {}, false
+	}
+	a.drain.loops.Add(1)
+
+	var once sync.Once
+	return func() { once.Do(a.drain.loops.Done) }, true
+}
+
+// Draining is closed when shutdown begins. Consume loops select on it before
+// each fetch so no new batch is taken once the agent is going down.
+func (a *BaseAgent) Draining() <-chan struct{} {
+	return a.drain.draining
+}
+
+// IsDraining reports whether shutdown has begun
+func (a *BaseAgent) IsDraining() bool {
+	select {
+	case <-a.drain.draining:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drain stops consume loops from fetching new batches and waits, up to the
+// configured drain timeout, for them to finish processing and acknowledging
+// the messages they already hold. Call it before cancelling the agent's
+// context and before closing NATS or database connections. Loops still
+// running at the deadline are abandoned; their unacknowledged messages are
+// redelivered after the consumer's AckWait.
+func (a *BaseAgent) Drain(ctx context.Context) error {
+	a.drain.mu.Lock()
+	if !a.drain.started {
+		a.drain.started = true
+		close(a.drain.draining)
+	}
+	a.drain.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, a.drainTimeout)
+	defer cancel()
+
+	a.logger.Info().Dur("drain_timeout", a.drainTimeout).Msg("Draining in-flight messages")
+	start := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		a.drain.loops.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		a.logger.Info().Dur("duration", time.Since(start)).Msg("Drain complete")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("drain did not finish within %s: %w", a.drainTimeout, ctx.Err())
+	}
+}
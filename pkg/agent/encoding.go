@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// PublishMessage publishes a pipeline message on its subject in the agent's
+// message encoding, naming the encoding in the Content-Type header
+func (a *BaseAgent) PublishMessage(ctx context.Context, msg messages.Message, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	m, err := a.EncodeMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return a.js.PublishMsg(ctx, m, opts...)
+}
+
+// EncodeMessage encodes a pipeline message for publishing on its subject.
+// Messages without security labels, rather than those inherited from the
+// message they derive from, carry the agent's DATA_CLASSIFICATION and
+// DATA_RELEASABILITY, and those whose label ENCRYPT_CLASSIFICATIONS selects
+// are sealed. The encoding, sealed or not, is signed with the agent's key.
+func (a *BaseAgent) EncodeMessage(msg messages.Message) (*nats.Msg, error) {
+	return a.encodeMessage(msg, a.signingKey())
+}
+
+// EncodeMessageFor encodes a pipeline message as EncodeMessage does but signs
+// it with the current key of signer, from StartSigner, for receivers that
+// verify against another registry. A nil signer leaves the message unsigned.
+func (a *BaseAgent) EncodeMessageFor(msg messages.Message, signer *signing.Signer) (*nats.Msg, error) {
+	var key *signing.Key
+	if signer != nil {
+		key = signer.Key()
+	}
+	return a.encodeMessage(msg, key)
+}
+
+func (a *BaseAgent) encodeMessage(msg messages.Message, key *signing.Key) (*nats.Msg, error) {
+	// The envelope names the signing key inside what is signed. A key ID
+	// kept from a message this one was decoded from is cleared.
+	if env := msg.GetEnvelope(); env.SigningKeyID != signingKeyID(key) {
+		env.SigningKeyID = signingKeyID(key)
+		msg.SetEnvelope(env)
+	}
+
+	encoder := messages.Encoder{
+		Encoding:       a.MessageEncoding(),
+		Classification: a.classification,
+		Releasability:  a.releasability,
+		Encryption:     a.encryption,
+		PayloadKeys:    a.payloadKeys,
+	}
+	data, contentType, err := encoder.Encode(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := nats.NewMsg(msg.Subject())
+	m.Header.Set(messages.ContentTypeHeader, contentType)
+	m.Data = data
+	signing.SignMsg(m, key)
+	return m, nil
+}
+
+// Decode decodes a consumed pipeline message in whichever encoding its
+// Content-Type header names; messages without the header are JSON, and
+// sealed messages are opened with the agent's payload keys
+func Decode(msg jetstream.Msg, v messages.Message) error {
+	return messages.Unmarshal(msg.Headers().Get(messages.ContentTypeHeader), msg.Data(), v)
+}
@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dependencies reported by readiness
+const (
+	DependencyNATS     = "nats"
+	DependencyLink     = "link"
+	DependencyConsumer = "consumer"
+	DependencyPostgres = "postgres"
+	DependencyOPA      = "opa"
+)
+
+// ReadinessTimeout bounds each dependency check of a readiness probe
+const ReadinessTimeout = 2 * time.Second
+
+// DependencyCheck returns nil when a dependency is reachable
+type DependencyCheck func(ctx context.Context) error
+
+// DependencyStatus is one dependency's part in readiness. The last error is
+// kept after the dependency recovers, so a probe shows what went wrong last.
+type DependencyStatus struct {
+	Name        string     `json:"name"`
+	Ready       bool       `json:"ready"`
+	Detail      string     `json:"detail,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Liveness is served at GET /health/live. An agent is live while its process
+// runs; a failing liveness probe means the process should be restarted.
+type Liveness struct {
+	Live      bool      `json:"live"`
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
+
+// Readiness is served at GET /health/ready. An agent is ready when every
+// dependency it needs to do work is available; a failing readiness probe
+// means traffic should go elsewhere until it recovers.
+type Readiness struct {
+	Ready        bool               `json:"ready"`
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// dependencies holds the checks an agent added and what each last reported
+type dependencies struct {
+	mu        sync.Mutex
+	names     []string
+	checks    map[string]DependencyCheck
+	consumers map[string]consumerBinding
+	lastErr   map[string]lastError
+}
+
+// consumerBinding is whether a durable consumer Consume reads from exists
+type consumerBinding struct {
+	stream string
+	bound  bool
+}
+
+type lastError struct {
+	msg string
+	at  time.Time
+}
+
+func newDependencies() *dependencies {
+	return &dependencies{
+		checks:    make(map[string]DependencyCheck),
+		consumers: make(map[string]consumerBinding),
+		lastErr:   make(map[string]lastError),
+	}
+}
+
+// AddDependency makes readiness depend on check, such as a database ping.
+// Adding a name again replaces its check.
+func (a *BaseAgent) AddDependency(name string, check DependencyCheck) {
+	d := a.deps
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.checks[name]; !ok {
+		d.names = append(d.names, name)
+	}
+	d.checks[name] = check
+}
+
+// bindConsumer records whether Consume holds its durable consumer, with the
+// error that lost it
+func (a *BaseAgent) bindConsumer(stream, durable string, bound bool, err error) {
+	d := a.deps
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.consumers[durable] = consumerBinding{stream: stream, bound: bound}
+	if err != nil {
+		d.lastErr[DependencyConsumer+":"+durable] = lastError{msg: err.Error(), at: time.Now().UTC()}
+	}
+}
+
+// status fills in a dependency's last error, recording err as the latest
+func (d *dependencies) status(key string, s DependencyStatus, err error) DependencyStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.lastErr[key] = lastError{msg: err.Error(), at: time.Now().UTC()}
+	}
+	if last, ok := d.lastErr[key]; ok {
+		at := last.at
+		s.LastError, s.LastErrorAt = last.msg, &at
+	}
+	return s
+}
+
+// Liveness reports whether the agent's process is up
+func (a *BaseAgent) Liveness() Liveness {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	switch {
+	case a.running:
+		return Liveness{Live: true, Status: "running", StartedAt: a.startedAt}
+	case a.startedAt.IsZero():
+		return Liveness{Live: true, Status: "starting"}
+	default:
+		return Liveness{Live: false, Status: "stopped", StartedAt: a.startedAt}
+	}
+}
+
+// Readiness checks each dependency the agent needs to do work: the NATS
+// connection, the remote JetStream domain when there is one, the durable
+// consumers Consume reads from, and the checks added with AddDependency.
+func (a *BaseAgent) Readiness(ctx context.Context) Readiness {
+	a.mu.RLock()
+	running := a.running
+	a.mu.RUnlock()
+
+	var deps []DependencyStatus
+
+	var natsErr error
+	if a.nc == nil || !a.nc.IsConnected() {
+		natsErr = fmt.Errorf("not connected")
+		if a.nc != nil && a.nc.LastError() != nil {
+			natsErr = a.nc.LastError()
+		}
+	}
+	deps = append(deps, a.deps.status(DependencyNATS, DependencyStatus{Name: DependencyNATS, Ready: natsErr == nil}, natsErr))
+
+	if domain := a.linkConfig.remoteDomain(); domain != "" {
+		down, since := a.link.get()
+		s := DependencyStatus{Name: DependencyLink, Ready: !down, Detail: "JetStream domain " + domain}
+		var err error
+		if down {
+			err = fmt.Errorf("unreachable since %s", since.Format(time.RFC3339))
+		}
+		deps = append(deps, a.deps.status(DependencyLink, s, err))
+	}
+
+	a.deps.mu.Lock()
+	durables := make([]string, 0, len(a.deps.consumers))
+	for durable := range a.deps.consumers {
+		durables = append(durables, durable)
+	}
+	sort.Strings(durables)
+	bindings := make([]consumerBinding, len(durables))
+	for i, durable := range durables {
+		bindings[i] = a.deps.consumers[durable]
+	}
+	names := append([]string(nil), a.deps.names...)
+	checks := make([]DependencyCheck, len(names))
+	for i, name := range names {
+		checks[i] = a.deps.checks[name]
+	}
+	a.deps.mu.Unlock()
+
+	for i, durable := range durables {
+		s := DependencyStatus{Name: DependencyConsumer, Ready: bindings[i].bound, Detail: bindings[i].stream + "/" + durable}
+		deps = append(deps, a.deps.status(DependencyConsumer+":"+durable, s, nil))
+	}
+
+	// Dependencies are checked in parallel, so one slow dependency costs a
+	// probe at most ReadinessTimeout
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+			defer cancel()
+			errs[i] = check(checkCtx)
+		}(i, check)
+	}
+	wg.Wait()
+	for i, name := range names {
+		deps = append(deps, a.deps.status(name, DependencyStatus{Name: name, Ready: errs[i] == nil}, errs[i]))
+	}
+
+	r := Readiness{Ready: running, Status: "ready", Dependencies: deps}
+	for _, d := range deps {
+		if !d.Ready {
+			r.Ready, r.Status = false, "not_ready"
+		}
+	}
+	switch {
+	case !running:
+		r.Ready, r.Status = false, "not_running"
+	case a.IsDraining():
+		r.Ready, r.Status = false, "draining"
+	}
+	return r
+}
+
+// LiveHandler serves GET /health/live: 200 while the agent is live, 503 once
+// it has stopped
+func (a *BaseAgent) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		live := a.Liveness()
+		writeProbe(w, live.Live, live)
+	}
+}
+
+// ReadyHandler serves GET /health/ready: 200 while every dependency is
+// available, 503 otherwise
+func (a *BaseAgent) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready := a.Readiness(r.Context())
+		writeProbe(w, ready.Ready, ready)
+	}
+}
+
+func writeProbe(w http.ResponseWriter, ok bool, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// heartbeatTimeout bounds publishing one heartbeat or one consumer lag lookup
+const heartbeatTimeout = 3 * time.Second
+
+// runHeartbeats publishes a heartbeat immediately and then every interval
+// until ctx is done
+func (a *BaseAgent) runHeartbeats(ctx context.Context) {
+	ticker := time.NewTicker(a.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		a.mu.RLock()
+		status := a.health()
+		a.mu.RUnlock()
+		a.publishHeartbeat(ctx, status)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishHeartbeat reports the agent's state on the HEARTBEATS stream. It
+// does not take a.mu, so Stop can announce the agent stopped while holding it.
+func (a *BaseAgent) publishHeartbeat(ctx context.Context, status HealthStatus) {
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+
+	hb := topology.Heartbeat{
+		AgentID:    a.id,
+		AgentType:  string(a.agentType),
+		Version:    Version,
+		Healthy:    status.Healthy,
+		Status:     status.Status,
+		Details:    status.Details,
+		StartedAt:  a.startedAt,
+		SentAt:     time.Now().UTC(),
+		IntervalMs: a.heartbeatInterval.Milliseconds(),
+	}
+
+	a.errMu.Lock()
+	hb.LastError = a.lastError
+	if !a.lastErrorAt.IsZero() {
+		at := a.lastErrorAt
+		hb.LastErrorAt = &at
+	}
+	a.errMu.Unlock()
+
+	if stream, consumer, ok := a.pipelineConsumer(); ok {
+		hb.Stream, hb.Consumer = stream, consumer
+		if backlog := a.backlog.Load(); backlog >= 0 {
+			lag := int(backlog)
+			hb.ConsumerLag = &lag
+		}
+	}
+
+	if err := topology.Publish(ctx, a.js, hb); err != nil {
+		a.logger.Debug().Err(err).Msg("Failed to publish heartbeat")
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// DefaultConsumerLagInterval is how often consumer lag gauges are refreshed
+const DefaultConsumerLagInterval = 15 * time.Second
+
+// consumerLagMetrics are the JetStream consumer backlog gauges of an agent
+type consumerLagMetrics struct {
+	pending     *prometheus.GaugeVec
+	ackPending  *prometheus.GaugeVec
+	redelivered *prometheus.GaugeVec
+}
+
+func newConsumerLagMetrics() consumerLagMetrics {
+	labels := []string{"stream", "consumer"}
+	return consumerLagMetrics{
+		pending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_consumer_pending",
+			Help: "Messages on the stream not yet delivered to the agent's consumer",
+		}, labels),
+		ackPending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_consumer_ack_pending",
+			Help: "Messages delivered to the agent's consumer and not yet acknowledged",
+		}, labels),
+		redelivered: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_consumer_redelivered",
+			Help: "Messages delivered to the agent's consumer more than once and not yet acknowledged",
+		}, labels),
+	}
+}
+
+func (m consumerLagMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.pending, m.ackPending, m.redelivered}
+}
+
+// parseConsumerLagInterval parses CONSUMER_LAG_INTERVAL; zero disables the gauges
+func parseConsumerLagInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultConsumerLagInterval, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid CONSUMER_LAG_INTERVAL %q: must be a non-negative duration", value)
+	}
+	return d, nil
+}
+
+// SetConsumerName names the durable consumer the agent fetches from when it
+// is not the one named after the agent type, such as a correlator shard's.
+// Call before Start.
+func (a *BaseAgent) SetConsumerName(name string) {
+	a.consumerName = name
+}
+
+// pipelineConsumer returns the stream and durable consumer the agent fetches
+// from. Pipeline agents consume a durable named after their type unless
+// SetConsumerName chose another on the same stream.
+func (a *BaseAgent) pipelineConsumer() (stream, consumer string, ok bool) {
+	stream, ok = natsutil.PipelineStream(string(a.agentType))
+	consumer = string(a.agentType)
+	if a.consumerName != "" {
+		consumer = a.consumerName
+	}
+	return stream, consumer, ok
+}
+
+// runConsumerLag refreshes the consumer lag gauges immediately and then every
+// interval until ctx is done
+func (a *BaseAgent) runConsumerLag(ctx context.Context, stream, consumer string) {
+	ticker := time.NewTicker(a.consumerLagInterval)
+	defer ticker.Stop()
+
+	for {
+		a.refreshConsumerLag(ctx, stream, consumer)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshConsumerLag reads the consumer's state through the Info API. The
+// backlog is kept for heartbeats; a failed lookup leaves the gauges as they were.
+func (a *BaseAgent) refreshConsumerLag(ctx context.Context, stream, consumer string) {
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+
+	c, err := a.UpstreamJetStream().Consumer(ctx, stream, consumer)
+	if err != nil {
+		a.logger.Debug().Err(err).Str("consumer", consumer).Msg("Consumer lag unavailable")
+		return
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		a.logger.Debug().Err(err).Str("consumer", consumer).Msg("Consumer lag unavailable")
+		return
+	}
+
+	a.consumerLag.pending.WithLabelValues(stream, consumer).Set(float64(info.NumPending))
+	a.consumerLag.ackPending.WithLabelValues(stream, consumer).Set(float64(info.NumAckPending))
+	a.consumerLag.redelivered.WithLabelValues(stream, consumer).Set(float64(info.NumRedelivered))
+	a.backlog.Store(int64(info.NumPending) + int64(info.NumAckPending))
+}
@@ -0,0 +1,362 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// ConfigBucket is the KV bucket holding runtime configuration. Each key is a
+// scope, either an agent type such as "planner" or an agent ID such as
+// "planner-001", and settings under an agent ID override those of its type.
+const ConfigBucket = "AGENT_CONFIG"
+
+// Runtime settings every agent applies without a restart
+const (
+	ConfigLogLevel          = "log_level"
+	ConfigFetchBatchSize    = "fetch_batch_size"
+	ConfigWorkerConcurrency = "worker_concurrency"
+	ConfigMessageEncoding   = "message_encoding"
+)
+
+// DefaultFetchBatchSize is how many messages a consume loop fetches at once
+const DefaultFetchBatchSize = 10
+
+// MaxFetchBatchSize bounds fetch_batch_size
+const MaxFetchBatchSize = 1000
+
+// RuntimeConfigFields describes the runtime settings common to every agent
+var RuntimeConfigFields = []ConfigField{
+	{Name: ConfigLogLevel, Type: "string", Default: "trace", Description: "Minimum log level: trace, debug, info, warn, or error", Runtime: true},
+	{Name: ConfigFetchBatchSize, Type: "int", Default: strconv.Itoa(DefaultFetchBatchSize), Description: "Messages fetched per batch by the consume loop", Runtime: true},
+	{Name: ConfigWorkerConcurrency, Type: "int", Env: "WORKER_CONCURRENCY", Default: "1", Description: "Fetched messages the classifier and planner process at once; messages about one track stay in order", Runtime: true},
+	{Name: ConfigMessageEncoding, Type: "string", Default: string(messages.EncodingJSON), Description: "Encoding of published pipeline messages: json or protobuf", Runtime: true},
+}
+
+// ConfigDocument is the value stored under one scope in the config bucket
+type ConfigDocument struct {
+	Values    map[string]string `json:"values"`
+	UpdatedBy string            `json:"updated_by,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// ValidateConfigValue checks value against a field's declared type
+func ValidateConfigValue(field ConfigField, value string) error {
+	var err error
+	switch field.Type {
+	case "int":
+		_, err = strconv.Atoi(value)
+	case "float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "duration":
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: must be a %s", field.Name, value, field.Type)
+	}
+
+	switch field.Name {
+	case ConfigLogLevel:
+		if _, err := zerolog.ParseLevel(value); err != nil || value == "" {
+			return fmt.Errorf("invalid %s %q", field.Name, value)
+		}
+	case ConfigFetchBatchSize:
+		if n, _ := strconv.Atoi(value); n < 1 || n > MaxFetchBatchSize {
+			return fmt.Errorf("invalid %s %q: must be between 1 and %d", field.Name, value, MaxFetchBatchSize)
+		}
+	case ConfigWorkerConcurrency:
+		if n, _ := strconv.Atoi(value); n < 1 || n > MaxWorkerConcurrency {
+			return fmt.Errorf("invalid %s %q: must be between 1 and %d", field.Name, value, MaxWorkerConcurrency)
+		}
+	case ConfigMessageEncoding:
+		if _, err := messages.ParseEncoding(value); err != nil || value == "" {
+			return fmt.Errorf("invalid %s %q: must be json or protobuf", field.Name, value)
+		}
+	}
+	return nil
+}
+
+// EnsureConfigBucket returns the runtime configuration bucket, creating it if needed
+func EnsureConfigBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, ConfigBucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, jetstream.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get config bucket: %w", err)
+	}
+
+	kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      ConfigBucket,
+		Description: "Runtime agent configuration by agent type or ID",
+		History:     10,
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// RuntimeConfig holds the settings an agent receives at runtime from the
+// config bucket. Watchers are called when a setting they follow changes.
+type RuntimeConfig struct {
+	agentType AgentType
+	agentID   string
+	logger    zerolog.Logger
+
+	mu       sync.RWMutex
+	scopes   map[string]map[string]string // Values by scope
+	values   map[string]string            // Effective values, agent ID over agent type
+	watchers map[string][]func(value string, set bool)
+}
+
+// NewRuntimeConfig creates an empty runtime config for one agent
+func NewRuntimeConfig(agentType AgentType, agentID string, logger zerolog.Logger) *RuntimeConfig {
+	return &RuntimeConfig{
+		agentType: agentType,
+		agentID:   agentID,
+		logger:    logger,
+		scopes:    make(map[string]map[string]string),
+		values:    make(map[string]string),
+		watchers:  make(map[string][]func(string, bool)),
+	}
+}
+
+// Applies reports whether settings stored under scope apply to this agent
+func (c *RuntimeConfig) Applies(scope string) bool {
+	return scope == string(c.agentType) || scope == c.agentID
+}
+
+// Apply replaces the settings of one scope; a nil document removes them.
+// Watchers of every effective value that changed are called.
+func (c *RuntimeConfig) Apply(scope string, doc *ConfigDocument) {
+	if !c.Applies(scope) {
+		return
+	}
+
+	c.mu.Lock()
+	if doc == nil {
+		delete(c.scopes, scope)
+	} else {
+		values := make(map[string]string, len(doc.Values))
+		for k, v := range doc.Values {
+			values[k] = v
+		}
+		c.scopes[scope] = values
+	}
+
+	next := make(map[string]string)
+	for _, s := range []string{string(c.agentType), c.agentID} {
+		for k, v := range c.scopes[s] {
+			next[k] = v
+		}
+	}
+
+	type change struct {
+		fn    func(string, bool)
+		value string
+		set   bool
+	}
+	var changes []change
+	for name, fns := range c.watchers {
+		prev, hadPrev := c.values[name]
+		value, set := next[name]
+		if prev == value && hadPrev == set {
+			continue
+		}
+		for _, fn := range fns {
+			changes = append(changes, change{fn: fn, value: value, set: set})
+		}
+	}
+	c.values = next
+	c.mu.Unlock()
+
+	for _, ch := range changes {
+		ch.fn(ch.value, ch.set)
+	}
+}
+
+// Values returns the effective settings
+func (c *RuntimeConfig) Values() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	values := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+	return values
+}
+
+// Get returns a setting and whether it is set
+func (c *RuntimeConfig) Get(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.values[name]
+	return v, ok
+}
+
+// String returns a setting, or def when it is unset
+func (c *RuntimeConfig) String(name, def string) string {
+	if v, ok := c.Get(name); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns a setting as an int, or def when it is unset or invalid
+func (c *RuntimeConfig) Int(name string, def int) int {
+	return parseOr(c, name, def, strconv.Atoi)
+}
+
+// Float returns a setting as a float64, or def when it is unset or invalid
+func (c *RuntimeConfig) Float(name string, def float64) float64 {
+	return parseOr(c, name, def, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) })
+}
+
+// Bool returns a setting as a bool, or def when it is unset or invalid
+func (c *RuntimeConfig) Bool(name string, def bool) bool {
+	return parseOr(c, name, def, strconv.ParseBool)
+}
+
+// Duration returns a setting as a duration, or def when it is unset or invalid
+func (c *RuntimeConfig) Duration(name string, def time.Duration) time.Duration {
+	return parseOr(c, name, def, time.ParseDuration)
+}
+
+// Watch calls fn with the current value of a setting and again whenever it
+// changes. set is false while the setting is unset.
+func (c *RuntimeConfig) Watch(name string, fn func(value string, set bool)) {
+	c.mu.Lock()
+	c.watchers[name] = append(c.watchers[name], fn)
+	value, set := c.values[name]
+	c.mu.Unlock()
+
+	fn(value, set)
+}
+
+// WatchString calls fn with a setting, or def when it is unset, now and on every change
+func (c *RuntimeConfig) WatchString(name, def string, fn func(string)) {
+	watchParsed(c, name, def, func(v string) (string, error) { return v, nil }, fn)
+}
+
+// WatchInt calls fn with a setting as an int, or def when it is unset, now and on every change.
+// Invalid values are logged and ignored.
+func (c *RuntimeConfig) WatchInt(name string, def int, fn func(int)) {
+	watchParsed(c, name, def, strconv.Atoi, fn)
+}
+
+// WatchFloat calls fn with a setting as a float64, or def when it is unset, now and on every change.
+// Invalid values are logged and ignored.
+func (c *RuntimeConfig) WatchFloat(name string, def float64, fn func(float64)) {
+	watchParsed(c, name, def, func(v string) (float64, error) { return strconv.ParseFloat(v, 64) }, fn)
+}
+
+// WatchBool calls fn with a setting as a bool, or def when it is unset, now and on every change.
+// Invalid values are logged and ignored.
+func (c *RuntimeConfig) WatchBool(name string, def bool, fn func(bool)) {
+	watchParsed(c, name, def, strconv.ParseBool, fn)
+}
+
+// WatchDuration calls fn with a setting as a duration, or def when it is unset, now and on every change.
+// Invalid values are logged and ignored.
+func (c *RuntimeConfig) WatchDuration(name string, def time.Duration, fn func(time.Duration)) {
+	watchParsed(c, name, def, time.ParseDuration, fn)
+}
+
+func parseOr[T any](c *RuntimeConfig, name string, def T, parse func(string) (T, error)) T {
+	v, ok := c.Get(name)
+	if !ok {
+		return def
+	}
+	parsed, err := parse(v)
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+func watchParsed[T any](c *RuntimeConfig, name string, def T, parse func(string) (T, error), fn func(T)) {
+	c.Watch(name, func(value string, set bool) {
+		if !set {
+			fn(def)
+			return
+		}
+		parsed, err := parse(value)
+		if err != nil {
+			c.logger.Warn().Err(err).Str("setting", name).Str("value", value).Msg("Ignoring invalid runtime setting")
+			return
+		}
+		fn(parsed)
+	})
+}
+
+// Follow keeps the config in step with the config bucket until ctx is done
+func (c *RuntimeConfig) Follow(ctx context.Context, kv jetstream.KeyValue) error {
+	watcher, err := kv.WatchAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to watch config bucket: %w", err)
+	}
+
+	go func() {
+		defer watcher.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				if entry == nil {
+					continue // Initial values delivered
+				}
+				c.applyEntry(entry)
+			}
+		}
+	}()
+	return nil
+}
+
+// applyEntry applies one config bucket update
+func (c *RuntimeConfig) applyEntry(entry jetstream.KeyValueEntry) {
+	scope := entry.Key()
+	if !c.Applies(scope) {
+		return
+	}
+	if entry.Operation() != jetstream.KeyValuePut {
+		c.Apply(scope, nil)
+		c.logger.Info().Str("scope", scope).Msg("Runtime configuration removed")
+		return
+	}
+
+	var doc ConfigDocument
+	if err := json.Unmarshal(entry.Value(), &doc); err != nil {
+		c.logger.Warn().Err(err).Str("scope", scope).Msg("Ignoring malformed runtime configuration")
+		return
+	}
+	c.Apply(scope, &doc)
+
+	names := make([]string, 0, len(doc.Values))
+	for name := range doc.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.logger.Info().
+		Str("scope", scope).
+		Strs("settings", names).
+		Str("updated_by", doc.UpdatedBy).
+		Msg("Runtime configuration changed")
+}
@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres/migrations"
+)
+
+// PrepareSchema applies pending schema migrations, or with DB_MIGRATE=false
+// only verifies the schema is current, and reports the version in /health
+func (a *BaseAgent) PrepareSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	status, applied, err := migrations.Prepare(ctx, pool, a.dbMigrate)
+	if err != nil {
+		return fmt.Errorf("failed to prepare database schema: %w", err)
+	}
+
+	for _, m := range applied {
+		a.logger.Info().Uint("version", m.Version).Str("name", m.Name).Msg("Applied schema migration")
+	}
+	a.logger.Info().Uint("schema_version", status.Version).Bool("migrate", a.dbMigrate).Msg("Database schema ready")
+
+	a.mu.Lock()
+	a.schemaVersion = status.Version
+	a.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,21 @@
+package agent
+
+import "math/rand"
+
+// Rand returns the agent's generator for simulated behavior, started from
+// SEED. It is safe for concurrent use; for a run to replay exactly, draw
+// from it in an order that does not depend on timing.
+func (a *BaseAgent) Rand() *rand.Rand {
+	return a.rng
+}
+
+// Seed returns the seed the agent's generator was last started from
+func (a *BaseAgent) Seed() int64 {
+	return a.randSource.CurrentSeed()
+}
+
+// Reseed restarts the agent's generator from seed
+func (a *BaseAgent) Reseed(seed int64) {
+	a.randSource.Seed(seed)
+	a.logger.Info().Int64("seed", seed).Msg("Random seed set")
+}
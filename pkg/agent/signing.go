@@ -0,0 +1,208 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// signingMetrics counts signature checks by result and key rotations
+type signingMetrics struct {
+	verified  *prometheus.CounterVec
+	rotations prometheus.Counter
+}
+
+func newSigningMetrics() signingMetrics {
+	return signingMetrics{
+		verified: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_message_signatures_total",
+			Help: "Signatures of consumed messages by result: verified, unsigned, unverified, or rejected",
+		}, []string{"result"}),
+		rotations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_signing_key_rotations_total",
+			Help: "Signing keys the agent generated and registered",
+		}),
+	}
+}
+
+func (m signingMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.verified, m.rotations}
+}
+
+// signingState is the key registry and the agent's signer once Start has
+// joined the registry. upstream is the registry of UPSTREAM_JS_DOMAIN, where
+// the agents publishing the stage's input register their keys.
+type signingState struct {
+	registry *signing.Registry
+	upstream *signing.Registry
+	signer   *signing.Signer
+}
+
+// SigningKeyID returns the ID of the key the agent signs with, empty while it
+// publishes unsigned
+func (a *BaseAgent) SigningKeyID() string {
+	return signingKeyID(a.signingKey())
+}
+
+// signingKey returns the key the agent signs with, nil while it publishes unsigned
+func (a *BaseAgent) signingKey() *signing.Key {
+	if s := a.signing.Load(); s != nil {
+		return s.signer.Key()
+	}
+	return nil
+}
+
+func signingKeyID(key *signing.Key) string {
+	if key == nil {
+		return ""
+	}
+	return key.KeyID
+}
+
+// startSigning joins the key registry, registers the agent's first signing
+// key, and keeps rotating it until ctx is done
+func (a *BaseAgent) startSigning(ctx context.Context) error {
+	kv, err := signing.EnsureBucket(ctx, a.js)
+	if err != nil {
+		return err
+	}
+	state := &signingState{registry: signing.NewRegistry(kv)}
+	state.upstream = state.registry
+	if a.upstreamJS != nil {
+		upstream, err := signing.EnsureBucket(ctx, a.upstreamJS)
+		if err != nil {
+			return fmt.Errorf("domain %s: %w", a.linkConfig.upstreamDomain, err)
+		}
+		state.upstream = signing.NewRegistry(upstream)
+	}
+
+	state.signer = signing.NewSigner(state.registry, a.id, string(a.agentType), a.signingConfig.Lifetime, a.signingConfig.Overlap)
+	key, err := state.signer.Rotate(ctx, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	a.signingMetrics.rotations.Inc()
+	a.signing.Store(state)
+	a.logger.Info().
+		Str("key_id", key.KeyID).
+		Time("not_after", key.NotAfter).
+		Str("policy", string(a.signingConfig.Policy)).
+		Msg("Signing messages")
+
+	go state.signer.KeepRotating(ctx, a.reportRotation(a.id))
+	return nil
+}
+
+// reportRotation returns a report of each rotation of agentID's signing
+// key, which logs and counts it
+func (a *BaseAgent) reportRotation(agentID string) func(*signing.Key, error) {
+	return func(key *signing.Key, err error) {
+		if err != nil {
+			a.logger.Error().Err(err).Str("agent_id", agentID).Msg("Failed to rotate signing key, retrying")
+			a.RecordError("key_rotation_error")
+			return
+		}
+		a.signingMetrics.rotations.Inc()
+		a.logger.Info().Str("agent_id", agentID).Str("key_id", key.KeyID).Time("not_after", key.NotAfter).Msg("Rotated signing key")
+	}
+}
+
+// StartSigner registers signing keys for agentID in the key registry of
+// another JetStream, such as a federation partner's, and keeps rotating them
+// until ctx is done. Messages encoded with EncodeMessageFor and the signer
+// verify there.
+func (a *BaseAgent) StartSigner(ctx context.Context, js jetstream.JetStream, agentID string) (*signing.Signer, error) {
+	signer, err := signing.Start(ctx, js, agentID, string(a.agentType), a.signingConfig, a.reportRotation(agentID))
+	if err != nil {
+		return nil, err
+	}
+	a.signingMetrics.rotations.Inc()
+	key := signer.Key()
+	a.logger.Info().
+		Str("agent_id", agentID).
+		Str("key_id", key.KeyID).
+		Time("not_after", key.NotAfter).
+		Msg("Signing messages for another registry")
+	return signer, nil
+}
+
+// verifyMessage checks the signature of a consumed message of the named
+// schema against SIGNATURE_POLICY. A refused message is dead-lettered and
+// terminated, and one that cannot be checked yet is negatively acknowledged;
+// either way it returns false.
+func (a *BaseAgent) verifyMessage(ctx context.Context, msg jetstream.Msg, schema string, upstream bool) bool {
+	if a.signingConfig.Policy == signing.PolicyOff {
+		return true
+	}
+	s := a.signing.Load()
+	if s == nil {
+		a.signingMetrics.verified.WithLabelValues("unverified").Inc()
+		return true // Without the registry, which Start only tolerates when permissive
+	}
+	registry := s.registry
+	if upstream {
+		registry = s.upstream
+	}
+
+	err := a.checkSignature(ctx, msg, schema, registry)
+	switch {
+	case err == nil:
+		a.signingMetrics.verified.WithLabelValues("verified").Inc()
+		return true
+	case errors.Is(err, signing.ErrUnsigned) && a.signingConfig.Policy == signing.PolicyPermissive:
+		a.signingMetrics.verified.WithLabelValues("unsigned").Inc()
+		return true
+	case !signing.Rejected(err):
+		a.logger.Warn().Err(err).Str("subject", msg.Subject()).Msg("Cannot verify message signature yet")
+		a.RecordError("signature_check_error")
+		msg.Nak()
+		a.consumeMetrics.settled.WithLabelValues("nak").Inc()
+		return false
+	}
+
+	a.signingMetrics.verified.WithLabelValues("rejected").Inc()
+	a.RecordMessage("rejected", schema)
+	a.logger.Warn().
+		Err(err).
+		Str("subject", msg.Subject()).
+		Str("schema", schema).
+		Msg("Message signature refused, dead-lettering")
+
+	if err := a.DeadLetter(ctx, msg, schema, err); err != nil {
+		a.logger.Error().Err(err).Str("subject", msg.Subject()).Msg("Failed to dead-letter message")
+		a.RecordError("dead_letter_error")
+		msg.Nak()
+		a.consumeMetrics.settled.WithLabelValues("nak").Inc()
+		return false
+	}
+	msg.Term()
+	a.consumeMetrics.settled.WithLabelValues("dead_letter").Inc()
+	return false
+}
+
+// checkSignature verifies a message's signature under its source agent's key
+// as of when the stream stored it
+func (a *BaseAgent) checkSignature(ctx context.Context, msg jetstream.Msg, schema string, registry *signing.Registry) error {
+	var storedAt time.Time
+	if meta, err := msg.Metadata(); err == nil {
+		storedAt = meta.Timestamp
+	}
+	return registry.VerifyMsg(ctx, schema, msg.Headers(), msg.Data(), storedAt)
+}
+
+// Verifier returns a check of consumed messages against SIGNATURE_POLICY
+// under the agent's key registry, for consumers outside Consume such as
+// ordered consumers
+func (a *BaseAgent) Verifier() signing.Verifier {
+	v := signing.Verifier{Policy: a.signingConfig.Policy}
+	if s := a.signing.Load(); s != nil {
+		v.Registry = s.registry
+	}
+	return v
+}
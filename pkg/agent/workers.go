@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// MaxWorkerConcurrency bounds worker_concurrency
+const MaxWorkerConcurrency = 64
+
+// PartitionKey returns the key that orders a message among the others of a
+// batch, such as its track ID. Messages with the same key are handled one at
+// a time in the order fetched; an empty key is a key like any other.
+type PartitionKey func(msg jetstream.Msg) string
+
+// MessageHandler processes one fetched message. The message is acknowledged
+// when it returns nil, terminated when it returns a Permanent error, and
+// negatively acknowledged when it returns any other error.
+type MessageHandler func(ctx context.Context, msg jetstream.Msg) error
+
+// parseWorkerConcurrency parses WORKER_CONCURRENCY, the starting
+// worker_concurrency. Empty yields one worker, processing in fetch order.
+func parseWorkerConcurrency(value string) (int, error) {
+	if value == "" {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > MaxWorkerConcurrency {
+		return 0, fmt.Errorf("invalid WORKER_CONCURRENCY %q: must be between 1 and %d", value, MaxWorkerConcurrency)
+	}
+	return n, nil
+}
+
+// WorkerConcurrency returns how many messages of a batch ProcessBatch handles at once
+func (a *BaseAgent) WorkerConcurrency() int {
+	return int(a.workerConcurrency.Load())
+}
+
+// ProcessBatch handles fetched messages across WorkerConcurrency workers and
+// returns once every message has been handled and acknowledged. It is
+// HandleBatch partitioned by key with the default settlement.
+func (a *BaseAgent) ProcessBatch(ctx context.Context, msgs <-chan jetstream.Msg, key PartitionKey, handle MessageHandler) {
+	a.HandleBatch(ctx, msgs, handle, ConsumeOptions{Key: key})
+}
+
+// Partition returns the worker of n that handles messages with key
+func Partition(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,140 @@
+// Package assets models the effectors available to answer a track.
+//
+// Interceptors, jammers, and sensors each have a location, a range, and a
+// readiness. The planner recommends the nearest ready asset in range for the
+// actions that need one, engage and intercept, and the effector commits the
+// asset when it executes the effect. A committed asset is not recommended
+// again until an operator returns it to readiness.
+package assets
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Asset kinds
+const (
+	KindInterceptor = "interceptor"
+	KindJammer      = "jammer"
+	KindSensor      = "sensor"
+)
+
+// Readiness states
+const (
+	ReadinessReady       = "ready"
+	ReadinessCommitted   = "committed"
+	ReadinessMaintenance = "maintenance"
+	ReadinessOffline     = "offline"
+)
+
+// kindActions lists the actions each kind of asset can carry out
+var kindActions = map[string][]string{
+	KindInterceptor: {"engage", "intercept"},
+	KindJammer:      {"engage"},
+	KindSensor:      {"identify", "track", "monitor"},
+}
+
+// assignedActions are the actions a proposal recommends an asset for
+var assignedActions = map[string]bool{
+	"engage":    true,
+	"intercept": true,
+}
+
+// Asset is an effector or sensor that can be tasked against a track
+type Asset struct {
+	AssetID     string     `json:"asset_id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	Location    geo.Point  `json:"location"`
+	RangeMeters float64    `json:"range_meters"`
+	Readiness   string     `json:"readiness"`
+	CommittedTo string     `json:"committed_to,omitempty"` // Effect the asset is committed to
+	CommittedAt *time.Time `json:"committed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Validate checks that an asset is well-formed
+func (a Asset) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("asset name is required")
+	}
+	if _, ok := kindActions[a.Kind]; !ok {
+		return fmt.Errorf("asset %q: kind must be %s, %s, or %s", a.Name, KindInterceptor, KindJammer, KindSensor)
+	}
+	if !ValidReadiness(a.Readiness) {
+		return fmt.Errorf("asset %q: invalid readiness %q", a.Name, a.Readiness)
+	}
+	if a.Location.Lat < -90 || a.Location.Lat > 90 || a.Location.Lon < -180 || a.Location.Lon > 180 {
+		return fmt.Errorf("asset %q: location is out of range", a.Name)
+	}
+	if a.RangeMeters <= 0 {
+		return fmt.Errorf("asset %q: range_meters must be positive", a.Name)
+	}
+	return nil
+}
+
+// ValidReadiness reports whether s is a readiness state
+func ValidReadiness(s string) bool {
+	switch s {
+	case ReadinessReady, ReadinessCommitted, ReadinessMaintenance, ReadinessOffline:
+		return true
+	}
+	return false
+}
+
+// Supports reports whether an asset of the given kind can carry out an action
+func Supports(kind, actionType string) bool {
+	for _, a := range kindActions[kind] {
+		if a == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// KindsFor returns the kinds of asset that can carry out an action, sorted
+func KindsFor(actionType string) []string {
+	var kinds []string
+	for kind := range kindActions {
+		if Supports(kind, actionType) {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// RequiresAsset reports whether proposals for an action recommend an asset
+func RequiresAsset(actionType string) bool {
+	return assignedActions[actionType]
+}
+
+// DistanceTo returns the distance in meters from the asset to a position
+func (a Asset) DistanceTo(pos messages.Position) float64 {
+	return geo.Distance(a.Location.Position(), pos)
+}
+
+// Nearest returns the closest ready asset able to carry out the action with
+// the position in range. Ties go to the asset with the longer reach.
+func Nearest(assets []Asset, actionType string, pos messages.Position) (*messages.AssetAssignment, bool) {
+	var best *messages.AssetAssignment
+	var bestRange float64
+	for _, a := range assets {
+		if a.Readiness != ReadinessReady || !Supports(a.Kind, actionType) {
+			continue
+		}
+		d := a.DistanceTo(pos)
+		if d > a.RangeMeters {
+			continue
+		}
+		if best == nil || d < best.DistanceMeters || (d == best.DistanceMeters && a.RangeMeters > bestRange) {
+			best = &messages.AssetAssignment{AssetID: a.AssetID, Name: a.Name, Kind: a.Kind, DistanceMeters: d}
+			bestRange = a.RangeMeters
+		}
+	}
+	return best, best != nil
+}
@@ -0,0 +1,156 @@
+package assets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrNotFound is returned when an asset does not exist
+var ErrNotFound = errors.New("asset not found")
+
+// Querier is the subset of pgx used to load assets
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// RowQuerier is the subset of pgx used to change an asset and read it back
+type RowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Execer is the subset of pgx used to commit assets
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// columns lists the assets columns read by scan
+const columns = `asset_id::text, name, kind, lat, lon, range_meters, readiness,
+	COALESCE(committed_to, ''), committed_at, updated_at`
+
+// scan reads a row selected with columns
+func scan(row pgx.Row) (Asset, error) {
+	var a Asset
+	err := row.Scan(
+		&a.AssetID, &a.Name, &a.Kind, &a.Location.Lat, &a.Location.Lon, &a.RangeMeters, &a.Readiness,
+		&a.CommittedTo, &a.CommittedAt, &a.UpdatedAt,
+	)
+	return a, err
+}
+
+// List returns assets ordered by name, optionally only those of a kind or readiness
+func List(ctx context.Context, q Querier, kind, readiness string) ([]Asset, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+columns+`
+		FROM assets
+		WHERE ($1 = '' OR kind = $1) AND ($2 = '' OR readiness = $2)
+		ORDER BY name
+	`, kind, readiness)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assets: %w", err)
+	}
+	defer rows.Close()
+
+	assets := []Asset{}
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan asset: %w", err)
+		}
+		assets = append(assets, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assets: %w", err)
+	}
+	return assets, nil
+}
+
+// Available returns the ready assets able to carry out an action
+func Available(ctx context.Context, q Querier, actionType string) ([]Asset, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+columns+`
+		FROM assets
+		WHERE readiness = $1 AND kind = ANY($2)
+		ORDER BY name
+	`, ReadinessReady, KindsFor(actionType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query available assets: %w", err)
+	}
+	defer rows.Close()
+
+	var assets []Asset
+	for rows.Next() {
+		a, err := scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan asset: %w", err)
+		}
+		assets = append(assets, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating assets: %w", err)
+	}
+	return assets, nil
+}
+
+// Create inserts an asset and returns it as stored
+func Create(ctx context.Context, q RowQuerier, a Asset) (Asset, error) {
+	if a.Readiness == "" {
+		a.Readiness = ReadinessReady
+	}
+	if err := a.Validate(); err != nil {
+		return Asset{}, err
+	}
+	created, err := scan(q.QueryRow(ctx, `
+		INSERT INTO assets (name, kind, lat, lon, range_meters, readiness)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+columns,
+		a.Name, a.Kind, a.Location.Lat, a.Location.Lon, a.RangeMeters, a.Readiness,
+	))
+	if err != nil {
+		return Asset{}, fmt.Errorf("failed to create asset: %w", err)
+	}
+	return created, nil
+}
+
+// SetReadiness changes an asset's readiness and returns it. Leaving the
+// committed state clears what the asset was committed to.
+func SetReadiness(ctx context.Context, q RowQuerier, assetID, readiness string) (Asset, error) {
+	if !ValidReadiness(readiness) {
+		return Asset{}, fmt.Errorf("invalid readiness %q", readiness)
+	}
+	a, err := scan(q.QueryRow(ctx, `
+		UPDATE assets SET
+			readiness = $2,
+			committed_to = CASE WHEN $2 = 'committed' THEN committed_to END,
+			committed_at = CASE WHEN $2 = 'committed' THEN committed_at END,
+			updated_at = NOW()
+		WHERE asset_id = $1
+		RETURNING `+columns,
+		assetID, readiness,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Asset{}, ErrNotFound
+	}
+	if err != nil {
+		return Asset{}, fmt.Errorf("failed to update asset readiness: %w", err)
+	}
+	return a, nil
+}
+
+// Commit marks a ready asset committed to an effect. It reports false if the
+// asset was not ready, for example because another effect committed it first.
+func Commit(ctx context.Context, q Execer, assetID, effectID string, now time.Time) (bool, error) {
+	tag, err := q.Exec(ctx, `
+		UPDATE assets
+		SET readiness = $2, committed_to = $3, committed_at = $4, updated_at = $4
+		WHERE asset_id = $1 AND readiness = $5
+	`, assetID, ReadinessCommitted, effectID, now.UTC(), ReadinessReady)
+	if err != nil {
+		return false, fmt.Errorf("failed to commit asset: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
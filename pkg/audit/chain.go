@@ -0,0 +1,240 @@
+// Package audit provides a tamper-evident, append-only hash chain over decision
+// and effect records. Each record stores the hash of the previous link and a hash
+// of (previous hash + its own canonical payload), so altering, removing, or
+// reordering any chained row breaks every later link.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Chained entity types
+const (
+	EntityDecision = "decision"
+	EntityEffect   = "effect"
+)
+
+// GenesisHash is the previous hash of the first link in the chain
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// DecisionRecord is the hashed content of a decision row
+type DecisionRecord struct {
+	DecisionID string    `json:"decision_id"`
+	ProposalID string    `json:"proposal_id"`
+	Approved   bool      `json:"approved"`
+	ApprovedBy string    `json:"approved_by"`
+	ApprovedAt time.Time `json:"approved_at"`
+	Reason     string    `json:"reason"`
+	Conditions []string  `json:"conditions"`
+	ActionType string    `json:"action_type"`
+	TrackID    string    `json:"track_id"`
+
+	// Omitted when empty so decisions made without break-glass keep their original hashes
+	BreakGlassGrantID string `json:"break_glass_grant_id,omitempty"`
+
+	// Omitted when empty so decisions on proposals without options keep their original hashes
+	SelectedOption string `json:"selected_option,omitempty"`
+
+	// Omitted when unset so decisions made by operators keep their original hashes
+	MachineApproved   bool   `json:"machine_approved,omitempty"`
+	AutoApproveRuleID string `json:"auto_approve_rule_id,omitempty"`
+}
+
+// Payload returns the canonical encoding of the record
+func (r DecisionRecord) Payload() []byte {
+	r.DecisionID = strings.ToLower(r.DecisionID)
+	r.ProposalID = strings.ToLower(r.ProposalID)
+	r.BreakGlassGrantID = strings.ToLower(r.BreakGlassGrantID)
+	r.AutoApproveRuleID = strings.ToLower(r.AutoApproveRuleID)
+	r.ApprovedAt = canonicalTime(r.ApprovedAt)
+	if r.Conditions == nil {
+		r.Conditions = []string{}
+	}
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// EffectRecord is the hashed content of an effect row
+type EffectRecord struct {
+	EffectID      string    `json:"effect_id"`
+	DecisionID    string    `json:"decision_id"`
+	ProposalID    string    `json:"proposal_id"`
+	TrackID       string    `json:"track_id"`
+	ActionType    string    `json:"action_type"`
+	Status        string    `json:"status"`
+	Result        string    `json:"result"`
+	IdempotentKey string    `json:"idempotent_key"`
+	ExecutedAt    time.Time `json:"executed_at"`
+	FencingToken  int64     `json:"fencing_token"`
+	Outcome       string    `json:"outcome,omitempty"` // Omitted when empty so effects recorded before outcomes keep their hashes
+	Collateral    bool      `json:"collateral,omitempty"`
+}
+
+// Payload returns the canonical encoding of the record
+func (r EffectRecord) Payload() []byte {
+	r.EffectID = strings.ToLower(r.EffectID)
+	r.DecisionID = strings.ToLower(r.DecisionID)
+	r.ProposalID = strings.ToLower(r.ProposalID)
+	r.ExecutedAt = canonicalTime(r.ExecutedAt)
+	data, _ := json.Marshal(r)
+	return data
+}
+
+// canonicalTime matches PostgreSQL timestamptz precision so a record hashes the
+// same before it is written and after it is read back
+func canonicalTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// Hash computes a link hash. The sequence number and entity type are included
+// so links cannot be reordered or moved between tables.
+func Hash(prevHash string, seq int64, entityType string, payload []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(strconv.FormatInt(seq, 10)))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(entityType))
+	h.Write([]byte{'\n'})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Link is one entry in the chain
+type Link struct {
+	Seq        int64
+	EntityType string
+	EntityID   string
+	PrevHash   string
+	Hash       string
+	Payload    []byte
+}
+
+// Head is the latest link recorded in audit_chain_head
+type Head struct {
+	Seq  int64
+	Hash string
+}
+
+// BrokenLink describes the first link that failed verification
+type BrokenLink struct {
+	Seq          int64  `json:"seq"`
+	EntityType   string `json:"entity_type,omitempty"`
+	EntityID     string `json:"entity_id,omitempty"`
+	Reason       string `json:"reason"`
+	ExpectedHash string `json:"expected_hash,omitempty"`
+	ActualHash   string `json:"actual_hash,omitempty"`
+}
+
+// VerifyResult reports the outcome of a chain verification
+type VerifyResult struct {
+	Valid        bool        `json:"valid"`
+	LinksChecked int         `json:"links_checked"`
+	HeadSeq      int64       `json:"head_seq"`
+	HeadHash     string      `json:"head_hash"`
+	FirstBroken  *BrokenLink `json:"first_broken,omitempty"`
+}
+
+// Verify walks links in sequence order and checks every hash, every back-pointer,
+// that no sequence number is missing, and that the chain ends at head
+func Verify(links []Link, head Head) VerifyResult {
+	result := VerifyResult{HeadSeq: head.Seq, HeadHash: head.Hash}
+
+	prevHash := GenesisHash
+	expectedSeq := int64(1)
+	for _, link := range links {
+		if link.Seq != expectedSeq {
+			result.FirstBroken = &BrokenLink{
+				Seq:    expectedSeq,
+				Reason: fmt.Sprintf("missing link: expected seq %d, found %d", expectedSeq, link.Seq),
+			}
+			return result
+		}
+		if link.PrevHash != prevHash {
+			result.FirstBroken = &BrokenLink{
+				Seq:          link.Seq,
+				EntityType:   link.EntityType,
+				EntityID:     link.EntityID,
+				Reason:       "previous hash does not match the preceding link",
+				ExpectedHash: prevHash,
+				ActualHash:   link.PrevHash,
+			}
+			return result
+		}
+		if expected := Hash(link.PrevHash, link.Seq, link.EntityType, link.Payload); link.Hash != expected {
+			result.FirstBroken = &BrokenLink{
+				Seq:          link.Seq,
+				EntityType:   link.EntityType,
+				EntityID:     link.EntityID,
+				Reason:       "record content does not match its hash",
+				ExpectedHash: expected,
+				ActualHash:   link.Hash,
+			}
+			return result
+		}
+
+		result.LinksChecked++
+		prevHash = link.Hash
+		expectedSeq++
+	}
+
+	if head.Seq != expectedSeq-1 || head.Hash != prevHash {
+		result.FirstBroken = &BrokenLink{
+			Seq:          expectedSeq,
+			Reason:       fmt.Sprintf("chain truncated: head is seq %d but last link is seq %d", head.Seq, expectedSeq-1),
+			ExpectedHash: head.Hash,
+			ActualHash:   prevHash,
+		}
+		return result
+	}
+
+	result.Valid = true
+	return result
+}
+
+// Tx is the subset of pgx.Tx used to append to the chain
+type Tx interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Append reserves the next link for payload and advances the chain head.
+// It must run in the same transaction that writes the link onto the record,
+// which serializes writers on the head row.
+func Append(ctx context.Context, tx Tx, entityType, entityID string, payload []byte) (Link, error) {
+	var head Head
+	err := tx.QueryRow(ctx, "SELECT seq, hash FROM audit_chain_head WHERE id FOR UPDATE").Scan(&head.Seq, &head.Hash)
+	if err != nil {
+		return Link{}, fmt.Errorf("failed to lock audit chain head: %w", err)
+	}
+
+	link := Link{
+		Seq:        head.Seq + 1,
+		EntityType: entityType,
+		EntityID:   entityID,
+		PrevHash:   head.Hash,
+		Payload:    payload,
+	}
+	link.Hash = Hash(link.PrevHash, link.Seq, entityType, payload)
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE audit_chain_head SET seq = $1, hash = $2, updated_at = NOW() WHERE id",
+		link.Seq, link.Hash,
+	); err != nil {
+		return Link{}, fmt.Errorf("failed to advance audit chain head: %w", err)
+	}
+	return link, nil
+}
@@ -0,0 +1,221 @@
+// Package auditexport packages the audit trail of a time range as a signed
+// report bundle for after-action review and compliance hand-off.
+//
+// A bundle is a zip archive holding the entries as audit.csv and audit.json
+// plus a manifest.json that lists each file's size and SHA-256 digest. The
+// manifest is signed with HMAC-SHA256 over its JSON encoding without the
+// signature, so a recipient holding the export key can confirm with Verify
+// that neither the manifest nor any file was altered after export.
+package auditexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Files in a bundle
+const (
+	CSVFile      = "audit.csv"
+	JSONFile     = "audit.json"
+	ManifestFile = "manifest.json"
+)
+
+// ManifestVersion is the layout version of manifests written by Build
+const ManifestVersion = 1
+
+// Algorithm is the manifest signature algorithm
+const Algorithm = "HMAC-SHA256"
+
+// MinKeyLength is the shortest accepted AUDIT_EXPORT_KEY, in bytes
+const MinKeyLength = 16
+
+// ErrInvalidSignature is returned by Verify when a bundle was not signed with
+// the key or was altered after export
+var ErrInvalidSignature = errors.New("bundle signature does not match")
+
+// csvHeader is the first row of audit.csv
+var csvHeader = []string{
+	"id", "timestamp", "action_type", "user_id", "track_id", "threat_level",
+	"proposal_id", "decision_id", "effect_id", "status", "reason", "details",
+}
+
+// File describes one file of a bundle
+type File struct {
+	Name   string `json:"name"`
+	Bytes  int    `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes and signs the contents of a bundle
+type Manifest struct {
+	Version     int       `json:"version"`
+	ExportID    string    `json:"export_id"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Records     int       `json:"records"`
+	Files       []File    `json:"files"`
+	Algorithm   string    `json:"algorithm"`
+	KeyID       string    `json:"key_id"` // Identifies the signing key without revealing it
+	Signature   string    `json:"signature,omitempty"`
+}
+
+// ParseKey parses an AUDIT_EXPORT_KEY setting. Empty yields a random key, so
+// bundles can only be verified against the gateway run that signed them.
+func ParseKey(value string) (key []byte, generated bool, err error) {
+	if value == "" {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, false, fmt.Errorf("failed to generate audit export key: %w", err)
+		}
+		return key, true, nil
+	}
+	if len(value) < MinKeyLength {
+		return nil, false, fmt.Errorf("invalid AUDIT_EXPORT_KEY: must be at least %d bytes", MinKeyLength)
+	}
+	return []byte(value), false, nil
+}
+
+// KeyID returns a short fingerprint of a signing key
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign returns the signature of a manifest, ignoring any signature it holds
+func Sign(m Manifest, key []byte) (string, error) {
+	m.Signature = ""
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Build writes entries to a signed bundle. m supplies the export ID, range,
+// requester, and generation time; Build fills in the rest.
+func Build(entries []postgres.AuditEntry, m Manifest, key []byte) ([]byte, Manifest, error) {
+	csvData, err := encodeCSV(entries)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+	if entries == nil {
+		entries = []postgres.AuditEntry{}
+	}
+	jsonData, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, Manifest{}, fmt.Errorf("failed to encode audit entries: %w", err)
+	}
+
+	m.Version = ManifestVersion
+	m.Records = len(entries)
+	m.Files = []File{describe(CSVFile, csvData), describe(JSONFile, jsonData)}
+	m.Algorithm = Algorithm
+	m.KeyID = KeyID(key)
+	if m.Signature, err = Sign(m, key); err != nil {
+		return nil, Manifest{}, err
+	}
+	manifestData, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, Manifest{}, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{{CSVFile, csvData}, {JSONFile, jsonData}, {ManifestFile, manifestData}} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: m.GeneratedAt})
+		if err != nil {
+			return nil, Manifest{}, fmt.Errorf("failed to add %s: %w", f.name, err)
+		}
+		if _, err := w.Write(f.data); err != nil {
+			return nil, Manifest{}, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, Manifest{}, fmt.Errorf("failed to close bundle: %w", err)
+	}
+	return buf.Bytes(), m, nil
+}
+
+// Verify checks a bundle's manifest signature and every file digest it lists
+func Verify(bundle []byte, key []byte) (Manifest, error) {
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	files := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return Manifest{}, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return Manifest{}, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(files[ManifestFile], &m); err != nil {
+		return Manifest{}, fmt.Errorf("failed to read %s: %w", ManifestFile, err)
+	}
+	want, err := Sign(m, key)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if !hmac.Equal([]byte(want), []byte(m.Signature)) {
+		return m, ErrInvalidSignature
+	}
+	for _, f := range m.Files {
+		data, ok := files[f.Name]
+		if !ok {
+			return m, fmt.Errorf("bundle is missing %s", f.Name)
+		}
+		if describe(f.Name, data) != f {
+			return m, fmt.Errorf("%s does not match its manifest digest", f.Name)
+		}
+	}
+	return m, nil
+}
+
+func describe(name string, data []byte) File {
+	sum := sha256.Sum256(data)
+	return File{Name: name, Bytes: len(data), SHA256: hex.EncodeToString(sum[:])}
+}
+
+func encodeCSV(entries []postgres.AuditEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(csvHeader)
+	for _, e := range entries {
+		w.Write([]string{
+			e.ID, e.Timestamp, e.ActionType, e.UserID, e.TrackID, e.ThreatLevel,
+			e.ProposalID, e.DecisionID, e.EffectID, e.Status, e.Reason, e.Details,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode audit entries as CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,135 @@
+// Package autoapprove lets the authorizer approve low-risk proposals without an operator.
+//
+// A proposal qualifies only when the first enabled intervention rule matching
+// it, in evaluation order, has auto_approve set, and the cjadc2/auto_approve
+// policy allows the action at the proposal's priority and threat level. The
+// resulting decision is attributed to the rule - approved_by is
+// "system:rule:<rule_id>" - and flagged machine_approved on the published
+// decision, in the decisions table, and in the audit hash chain. Proposals
+// that do not qualify, and any for which the rules or policy cannot be
+// evaluated, stay in the queue for an operator.
+package autoapprove
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+)
+
+// ApproverPrefix marks approved_by on decisions made by a rule rather than an operator
+const ApproverPrefix = "system:rule:"
+
+// PolicyPath is the policy consulted before a rule may approve a proposal
+const PolicyPath = "cjadc2/auto_approve"
+
+// Outcomes counted by the authorizer for each proposal offered to the worker
+const (
+	OutcomeApproved     = "approved"
+	OutcomeNoRule       = "no_rule"       // The first matching rule does not auto-approve
+	OutcomePolicyDenied = "policy_denied" // Policy kept the proposal for an operator
+	OutcomeSuperseded   = "superseded"    // Decided, shed or expired before the worker reached it
+	OutcomeError        = "error"
+)
+
+// Rule is the intervention rule that decides a proposal
+type Rule struct {
+	RuleID      string
+	Name        string
+	Version     int // The rule's version when it matched, to trace it in the rule's history
+	AutoApprove bool
+}
+
+// Approver returns the approved_by recorded for a decision made by a rule
+func Approver(ruleID string) string {
+	return ApproverPrefix + ruleID
+}
+
+// ParseEnabled parses AUTO_APPROVE; auto-approval is off unless set
+func ParseEnabled(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid AUTO_APPROVE %q: must be true or false", value)
+	}
+	return enabled, nil
+}
+
+// Querier is the subset of pgx used to match intervention rules
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// MatchRule returns the first enabled intervention rule matching the proposal,
+// the same rule the planner consults. ok is false when no rule matches.
+func MatchRule(ctx context.Context, db Querier, proposal *messages.ActionProposal) (rule Rule, ok bool, err error) {
+	err = db.QueryRow(ctx, `
+		SELECT rule_id::text, name, version, auto_approve
+		FROM intervention_rules
+		WHERE enabled = true
+		  AND (cardinality(action_types) = 0 OR $1 = ANY(action_types))
+		  AND (cardinality(classifications) = 0 OR $2 = ANY(classifications))
+		  AND (cardinality(threat_levels) = 0 OR $3 = ANY(threat_levels))
+		  AND (min_priority IS NULL OR $4 >= min_priority)
+		  AND (max_priority IS NULL OR $4 <= max_priority)
+		ORDER BY evaluation_order ASC
+		LIMIT 1
+	`, proposal.ActionType, classification(proposal), proposal.ThreatLevel, proposal.Priority,
+	).Scan(&rule.RuleID, &rule.Name, &rule.Version, &rule.AutoApprove)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Rule{}, false, nil
+	}
+	if err != nil {
+		return Rule{}, false, fmt.Errorf("failed to match intervention rules: %w", err)
+	}
+	return rule, true, nil
+}
+
+// PolicyChecker evaluates the auto-approval policy
+type PolicyChecker interface {
+	Decide(ctx context.Context, policyPath string, input interface{}) (*opa.Decision, error)
+}
+
+// Check asks the policy whether rule may approve the proposal. A fallback
+// decision made while OPA is unavailable never approves.
+func Check(ctx context.Context, checker PolicyChecker, proposal *messages.ActionProposal, rule Rule) (bool, []string, error) {
+	decision, err := checker.Decide(ctx, PolicyPath, map[string]interface{}{
+		"rule_id":        rule.RuleID,
+		"rule_name":      rule.Name,
+		"rule_version":   rule.Version,
+		"action_type":    proposal.ActionType,
+		"priority":       proposal.Priority,
+		"threat_level":   proposal.ThreatLevel,
+		"classification": classification(proposal),
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to check auto-approval policy: %w", err)
+	}
+	if fallback, _ := decision.Metadata["fallback"].(bool); fallback {
+		return false, append(decision.Reasons, "auto-approval requires a policy decision; OPA is unavailable"), nil
+	}
+	return decision.Allowed, decision.Reasons, nil
+}
+
+// Apply marks a decision as made by rule without an operator
+func Apply(decision *messages.Decision, rule Rule) {
+	decision.Approved = true
+	decision.ApprovedBy = Approver(rule.RuleID)
+	decision.Reason = fmt.Sprintf("Auto-approved by intervention rule %q (version %d)", rule.Name, rule.Version)
+	decision.MachineApproved = true
+	decision.AutoApproveRuleID = rule.RuleID
+}
+
+func classification(proposal *messages.ActionProposal) string {
+	if proposal.Track == nil {
+		return ""
+	}
+	return proposal.Track.Classification
+}
@@ -0,0 +1,159 @@
+// Package backpressure throttles a producer according to how far its downstream
+// consumers have fallen behind.
+//
+// Below the throttle threshold the producer runs at full rate. Between the
+// throttle and pause thresholds its emission interval is stretched linearly up
+// to MaxSlowdown times the configured interval. At or above the pause threshold
+// emission stops until the backlog drains below the resume threshold, so the
+// producer does not flap around a single boundary.
+package backpressure
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Default thresholds, in pending messages
+const (
+	DefaultThrottleThreshold = 500
+	DefaultPauseThreshold    = 2000
+	DefaultMaxSlowdown       = 8.0
+	DefaultPollInterval      = 2 * time.Second
+)
+
+// Level is the throttling state of the producer
+type Level int
+
+const (
+	LevelNormal Level = iota
+	LevelThrottled
+	LevelPaused
+)
+
+// String returns the level name used in logs and API responses
+func (l Level) String() string {
+	switch l {
+	case LevelThrottled:
+		return "throttled"
+	case LevelPaused:
+		return "paused"
+	default:
+		return "normal"
+	}
+}
+
+// Config configures a Controller
+type Config struct {
+	ThrottleThreshold int     `json:"throttle_threshold"` // Backlog at which emission starts to slow
+	PauseThreshold    int     `json:"pause_threshold"`    // Backlog at which emission stops
+	ResumeThreshold   int     `json:"resume_threshold"`   // Backlog below which a paused producer resumes
+	MaxSlowdown       float64 `json:"max_slowdown"`       // Interval multiplier just below the pause threshold
+}
+
+// DefaultConfig returns the default thresholds
+func DefaultConfig() Config {
+	return Config{
+		ThrottleThreshold: DefaultThrottleThreshold,
+		PauseThreshold:    DefaultPauseThreshold,
+		ResumeThreshold:   DefaultThrottleThreshold,
+		MaxSlowdown:       DefaultMaxSlowdown,
+	}
+}
+
+// Validate checks that the thresholds are ordered and the slowdown is sensible
+func (c Config) Validate() error {
+	if c.ThrottleThreshold <= 0 {
+		return fmt.Errorf("throttle threshold must be positive")
+	}
+	if c.PauseThreshold <= c.ThrottleThreshold {
+		return fmt.Errorf("pause threshold must be greater than throttle threshold")
+	}
+	if c.ResumeThreshold <= 0 || c.ResumeThreshold > c.PauseThreshold {
+		return fmt.Errorf("resume threshold must be between 1 and the pause threshold")
+	}
+	if c.MaxSlowdown < 1 {
+		return fmt.Errorf("max slowdown must be at least 1")
+	}
+	return nil
+}
+
+// State is the controller's current decision
+type State struct {
+	Level     Level     `json:"-"`
+	LevelName string    `json:"level"`
+	Backlog   int       `json:"backlog"`
+	Slowdown  float64   `json:"slowdown"` // Multiplier applied to the emission interval
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Interval returns the emission interval to use in place of base
+func (s State) Interval(base time.Duration) time.Duration {
+	if s.Slowdown <= 1 {
+		return base
+	}
+	return time.Duration(float64(base) * s.Slowdown)
+}
+
+// Controller turns backlog observations into a throttling state
+type Controller struct {
+	mu     sync.RWMutex
+	config Config
+	state  State
+}
+
+// NewController creates a controller in the normal state
+func NewController(cfg Config) (*Controller, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Controller{
+		config: cfg,
+		state:  State{Level: LevelNormal, LevelName: LevelNormal.String(), Slowdown: 1},
+	}, nil
+}
+
+// Config returns the controller's thresholds
+func (c *Controller) Config() Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.config
+}
+
+// State returns the most recent decision
+func (c *Controller) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// Observe records a backlog measurement and returns the previous and new states
+func (c *Controller) Observe(backlog int, now time.Time) (prev, next State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev = c.state
+	next = State{Backlog: backlog, Slowdown: 1, UpdatedAt: now}
+
+	switch {
+	case backlog >= c.config.PauseThreshold:
+		next.Level = LevelPaused
+	case prev.Level == LevelPaused && backlog >= c.config.ResumeThreshold:
+		// Stay paused until the backlog drains below the resume threshold
+		next.Level = LevelPaused
+	case backlog >= c.config.ThrottleThreshold:
+		next.Level = LevelThrottled
+		span := float64(c.config.PauseThreshold - c.config.ThrottleThreshold)
+		fraction := float64(backlog-c.config.ThrottleThreshold) / span
+		next.Slowdown = 1 + fraction*(c.config.MaxSlowdown-1)
+	default:
+		next.Level = LevelNormal
+	}
+	if next.Level == LevelPaused {
+		next.Slowdown = c.config.MaxSlowdown
+	}
+	next.LevelName = next.Level.String()
+
+	c.state = next
+	return prev, next
+}
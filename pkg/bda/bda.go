@@ -0,0 +1,119 @@
+// Package bda provides battle damage assessment, the feedback stage of the kill chain.
+//
+// After an effect executes, the effector assesses it and publishes an
+// EffectAssessment on the ASSESSMENTS stream. The correlator follows the
+// stream, marks neutralized tracks, and stops forwarding them to the planner
+// so no further proposals are raised against a target already dealt with.
+// Assessments are signed by the effector, and one whose signature fails
+// SIGNATURE_POLICY is ignored so a forged neutralization cannot hide a track.
+package bda
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// ASSESSMENTS stream and subjects
+const (
+	StreamName  = "ASSESSMENTS"
+	Subjects    = "assessment.>"
+	Neutralized = "assessment.neutralized.>"
+)
+
+// Assessment bases
+const (
+	BasisReported  = "reported"  // The effector backend reported the outcome
+	BasisEstimated = "estimated" // Drawn against the action's kill probability
+)
+
+// Detail keys an effector backend may set on its result to report the outcome
+// instead of leaving it to be estimated
+const (
+	DetailNeutralized        = "neutralized"
+	DetailSuccessProbability = "success_probability"
+)
+
+// KillProbabilities are the estimated chances that an action neutralizes its
+// target. Actions not listed, such as identify or monitor, are not assessed.
+var KillProbabilities = map[string]float64{
+	"engage":    0.8,
+	"intercept": 0.9,
+}
+
+// Outcome is the result of assessing one effect
+type Outcome struct {
+	SuccessProbability float64
+	Neutralized        bool
+	Basis              string
+}
+
+// Assess determines the outcome of an executed action from the details its
+// backend reported. draw is a uniform random number in [0, 1) used when the
+// backend did not report whether the target was neutralized. It returns false
+// for actions that are not assessed.
+func Assess(actionType string, details map[string]interface{}, draw float64) (Outcome, bool) {
+	probability, assessed := KillProbabilities[actionType]
+	if p, ok := details[DetailSuccessProbability].(float64); ok && p >= 0 && p <= 1 {
+		probability, assessed = p, true
+	}
+	if neutralized, ok := details[DetailNeutralized].(bool); ok {
+		return Outcome{SuccessProbability: probability, Neutralized: neutralized, Basis: BasisReported}, true
+	}
+	if !assessed {
+		return Outcome{}, false
+	}
+	return Outcome{SuccessProbability: probability, Neutralized: draw < probability, Basis: BasisEstimated}, true
+}
+
+// Watch calls fn with every assessment of a neutralized target that passes
+// verifier, starting from the oldest retained, until ctx is done. Each caller
+// gets its own ordered consumer, so every replica learns of every neutralized
+// track.
+func Watch(ctx context.Context, js jetstream.JetStream, verifier signing.Verifier, fn func(*messages.EffectAssessment)) error {
+	consumer, err := js.OrderedConsumer(ctx, StreamName, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{Neutralized},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create assessment consumer: %w", err)
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		var storedAt time.Time
+		if meta, err := msg.Metadata(); err == nil {
+			storedAt = meta.Timestamp
+		}
+		Handle(ctx, verifier, msg.Headers(), msg.Data(), storedAt, fn)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch assessments: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		cc.Stop()
+	}()
+	return nil
+}
+
+// Handle calls fn with a delivered assessment, stored at storedAt, if its
+// signature passes verifier and it decodes. It returns why an assessment was
+// ignored; an ordered consumer cannot redeliver one, so Watch drops it.
+func Handle(ctx context.Context, verifier signing.Verifier, header nats.Header, data []byte, storedAt time.Time, fn func(*messages.EffectAssessment)) error {
+	if err := verifier.Check(ctx, "", header, data, storedAt); err != nil {
+		return fmt.Errorf("assessment signature refused: %w", err)
+	}
+	var assessment messages.EffectAssessment
+	if err := messages.Unmarshal(header.Get(messages.ContentTypeHeader), data, &assessment); err != nil {
+		return fmt.Errorf("failed to decode assessment: %w", err)
+	}
+	fn(&assessment)
+	return nil
+}
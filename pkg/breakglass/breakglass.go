@@ -0,0 +1,204 @@
+// Package breakglass implements time-boxed elevated approval authority.
+//
+// An operator whose role cannot approve an action (for example a watch officer
+// who needs to approve an engage while the commander is unreachable) can
+// activate a break-glass grant by presenting a TOTP second factor and a
+// justification. The grant carries the elevated role, expires on its own after
+// a bounded duration, and can be revoked early. The approval authority policy
+// in OPA accepts a live grant in place of the operator's normal role, and every
+// activation, use, revocation, and expiry is written to the audit log.
+package breakglass
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Grant durations
+const (
+	DefaultDuration = 15 * time.Minute
+	MaxDuration     = 60 * time.Minute
+)
+
+// DefaultRole is the role a grant elevates to when none is requested
+const DefaultRole = "commander"
+
+// Grant statuses
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+)
+
+// Second factor methods
+const (
+	SecondFactorTOTP = "totp"
+)
+
+// TOTP parameters (RFC 6238 defaults used by common authenticator apps)
+const (
+	TOTPStep   = 30 * time.Second
+	TOTPDigits = 6
+	TOTPSkew   = 1 // Accept one step either side for clock drift
+)
+
+// Grant is a time-boxed elevation of one operator's approval authority
+type Grant struct {
+	GrantID              string     `json:"grant_id"`
+	UserID               string     `json:"user_id"`
+	Role                 string     `json:"role"`
+	Justification        string     `json:"justification"`
+	SecondFactor         string     `json:"second_factor"`
+	SecondFactorVerified bool       `json:"second_factor_verified"`
+	Status               string     `json:"status"`
+	ActivatedAt          time.Time  `json:"activated_at"`
+	ExpiresAt            time.Time  `json:"expires_at"`
+	RevokedAt            *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy            string     `json:"revoked_by,omitempty"`
+	RevokeReason         string     `json:"revoke_reason,omitempty"`
+	UseCount             int        `json:"use_count"`
+}
+
+// Active reports whether the grant confers authority at now
+func (g Grant) Active(now time.Time) bool {
+	return g.Status == StatusActive && g.SecondFactorVerified && g.RevokedAt == nil && now.Before(g.ExpiresAt)
+}
+
+// Notification builds the notification announcing event on the grant
+func (g Grant) Notification(event, actor, source, sourceType string) *messages.BreakGlassNotification {
+	return &messages.BreakGlassNotification{
+		Envelope:       messages.NewEnvelope(source, sourceType),
+		NotificationID: uuid.New().String(),
+		Event:          event,
+		GrantID:        g.GrantID,
+		UserID:         g.UserID,
+		Role:           g.Role,
+		Justification:  g.Justification,
+		ActivatedAt:    g.ActivatedAt,
+		ExpiresAt:      g.ExpiresAt,
+		Actor:          actor,
+		Reason:         g.RevokeReason,
+	}
+}
+
+// ClampDuration bounds a requested grant duration to (0, MaxDuration], using
+// DefaultDuration when none is requested
+func ClampDuration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultDuration
+	}
+	if d > MaxDuration {
+		return MaxDuration
+	}
+	return d
+}
+
+// ParseSecrets parses a comma-separated list of user=BASE32SECRET pairs
+func ParseSecrets(spec string) (map[string][]byte, error) {
+	secrets := make(map[string][]byte)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		user, encoded, ok := strings.Cut(pair, "=")
+		if !ok || user == "" || encoded == "" {
+			return nil, fmt.Errorf("invalid TOTP secret entry %q: expected user=BASE32SECRET", pair)
+		}
+		secret, err := decodeSecret(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOTP secret for %s: %w", user, err)
+		}
+		secrets[strings.TrimSpace(user)] = secret
+	}
+	return secrets, nil
+}
+
+func decodeSecret(encoded string) ([]byte, error) {
+	encoded = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(encoded), " ", ""))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(encoded, "="))
+}
+
+// TOTP returns the time-based one-time code for secret at t
+func TOTP(secret []byte, t time.Time) string {
+	return hotp(secret, uint64(t.Unix()/int64(TOTPStep/time.Second)))
+}
+
+// VerifyTOTP checks code against secret at now, allowing TOTPSkew steps of drift
+func VerifyTOTP(secret []byte, code string, now time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(secret) == 0 || len(code) != TOTPDigits {
+		return false
+	}
+	counter := now.Unix() / int64(TOTPStep/time.Second)
+	for i := -TOTPSkew; i <= TOTPSkew; i++ {
+		if hmac.Equal([]byte(hotp(secret, uint64(counter+int64(i)))), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hotp implements RFC 4226 with HMAC-SHA1 and dynamic truncation
+func hotp(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod)
+}
+
+// PolicyChecker evaluates the approval authority policy
+type PolicyChecker interface {
+	CheckApprovalAuthority(ctx context.Context, userID, actionType string, grants interface{}) (map[string]interface{}, error)
+}
+
+// Authority is the outcome of an approval authority check
+type Authority struct {
+	Allowed bool     `json:"allowed"`
+	Role    string   `json:"role"`
+	GrantID string   `json:"break_glass_grant_id,omitempty"` // Set when only a break-glass grant authorized the approval
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Authorize asks the policy whether userID may approve actionType, given the
+// user's active break-glass grants
+func Authorize(ctx context.Context, checker PolicyChecker, userID, actionType string, grants []Grant) (Authority, error) {
+	decision, err := checker.CheckApprovalAuthority(ctx, userID, actionType, grants)
+	if err != nil {
+		return Authority{}, fmt.Errorf("failed to check approval authority: %w", err)
+	}
+
+	var auth Authority
+	auth.Allowed, _ = decision["allowed"].(bool)
+	auth.Role, _ = decision["role"].(string)
+	auth.GrantID, _ = decision["break_glass_grant"].(string)
+	if reasons, ok := decision["reasons"].([]interface{}); ok {
+		for _, r := range reasons {
+			if s, ok := r.(string); ok {
+				auth.Reasons = append(auth.Reasons, s)
+			}
+		}
+	}
+	return auth, nil
+}
@@ -0,0 +1,113 @@
+package breakglass
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Audit log entity type for grant events
+const AuditEntityType = "break_glass_grant"
+
+// Querier is the subset of pgx used to load grants
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Execer is the subset of pgx.Tx used to record grant events
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// GrantColumns lists the break_glass_grants columns read by ScanGrant
+const GrantColumns = `grant_id::text, user_id, role, justification, second_factor,
+	second_factor_verified, status, activated_at, expires_at,
+	revoked_at, COALESCE(revoked_by, ''), COALESCE(revoke_reason, ''), use_count`
+
+// ScanGrant scans a row selected with GrantColumns
+func ScanGrant(row pgx.Row) (Grant, error) {
+	var g Grant
+	err := row.Scan(
+		&g.GrantID, &g.UserID, &g.Role, &g.Justification, &g.SecondFactor,
+		&g.SecondFactorVerified, &g.Status, &g.ActivatedAt, &g.ExpiresAt,
+		&g.RevokedAt, &g.RevokedBy, &g.RevokeReason, &g.UseCount,
+	)
+	return g, err
+}
+
+// ActiveGrants returns the unexpired, unrevoked grants held by userID at now
+func ActiveGrants(ctx context.Context, q Querier, userID string, now time.Time) ([]Grant, error) {
+	rows, err := q.Query(ctx, `
+		SELECT `+GrantColumns+`
+		FROM break_glass_grants
+		WHERE user_id = $1 AND status = 'active' AND second_factor_verified
+			AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY expires_at DESC
+	`, userID, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query break-glass grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := []Grant{}
+	for rows.Next() {
+		g, err := ScanGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan break-glass grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating break-glass grants: %w", err)
+	}
+	return grants, nil
+}
+
+// LogEvent writes a grant event to the audit log
+func LogEvent(ctx context.Context, tx Execer, event, grantID, actorID, actorType string, details interface{}, correlationID string) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal break-glass audit details: %w", err)
+	}
+
+	// audit_log.correlation_id is a UUID; fall back to the grant ID for free-form IDs
+	if _, err := uuid.Parse(correlationID); err != nil {
+		correlationID = grantID
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor_id, actor_type, new_value, correlation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, AuditEntityType, grantID, "break_glass_"+event, actorID, actorType, detailsJSON, correlationID)
+	if err != nil {
+		return fmt.Errorf("failed to write break-glass audit entry: %w", err)
+	}
+	return nil
+}
+
+// RecordUse counts an approval made under a grant and audits it. It must run in
+// the transaction that inserts the decision.
+func RecordUse(ctx context.Context, tx Execer, grantID, decisionID, proposalID, actionType, actorID, correlationID string) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE break_glass_grants
+		SET use_count = use_count + 1, last_used_at = NOW()
+		WHERE grant_id = $1 AND status = 'active' AND revoked_at IS NULL AND expires_at > NOW()
+	`, grantID)
+	if err != nil {
+		return fmt.Errorf("failed to record break-glass use: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("break-glass grant %s is no longer active", grantID)
+	}
+
+	return LogEvent(ctx, tx, "used", grantID, actorID, "human", map[string]string{
+		"decision_id": decisionID,
+		"proposal_id": proposalID,
+		"action_type": actionType,
+	}, correlationID)
+}
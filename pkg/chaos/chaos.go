@@ -0,0 +1,265 @@
+// Package chaos injects faults into the pipeline for resilience testing.
+//
+// Chaos is off unless CHAOS_ENABLED=true. When enabled, operators set a fault
+// plan through /api/v1/chaos; the gateway publishes it on the CHAOS stream and
+// every agent watches the stream. For each message its stage fetches, an
+// agent may delay processing, drop the message (acknowledge it unprocessed),
+// or Nak it for redelivery, exercising redelivery, idempotency, and alerting.
+// Every injected fault is counted in chaos_injections_total.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CHAOS stream and subject
+const (
+	StreamName  = "CHAOS"
+	PlanSubject = "chaos.plan"
+)
+
+// MaxLatency bounds the injected processing delay
+const MaxLatency = 30 * time.Second
+
+// Stages lists the agents that consume pipeline messages and can have faults injected
+var Stages = []string{"classifier", "correlator", "planner", "authorizer", "effector"}
+
+// Injected fault kinds, also the fault label of chaos_injections_total
+const (
+	FaultLatency = "latency"
+	FaultDrop    = "drop"
+	FaultNak     = "nak"
+)
+
+// Fault is the chaos applied to every message one stage fetches
+type Fault struct {
+	LatencyMs   int64   `json:"latency_ms"`   // Delay before processing
+	DropPercent float64 `json:"drop_percent"` // Share of messages acknowledged without processing
+	NakPercent  float64 `json:"nak_percent"`  // Share of messages Nak'd for redelivery
+}
+
+// Validate checks the fault is within bounds
+func (f Fault) Validate() error {
+	if f.LatencyMs < 0 || time.Duration(f.LatencyMs)*time.Millisecond > MaxLatency {
+		return fmt.Errorf("latency_ms must be between 0 and %d", MaxLatency.Milliseconds())
+	}
+	if f.DropPercent < 0 || f.DropPercent > 100 || f.NakPercent < 0 || f.NakPercent > 100 {
+		return fmt.Errorf("drop_percent and nak_percent must be between 0 and 100")
+	}
+	if f.DropPercent+f.NakPercent > 100 {
+		return fmt.Errorf("drop_percent and nak_percent together must not exceed 100")
+	}
+	return nil
+}
+
+// IsZero reports whether the fault injects nothing
+func (f Fault) IsZero() bool {
+	return f == Fault{}
+}
+
+// Plan is the fault plan for the whole pipeline, keyed by stage
+type Plan struct {
+	Seq       uint64           `json:"seq"` // Increases with every change; older plans are ignored
+	Faults    map[string]Fault `json:"faults"`
+	UpdatedBy string           `json:"updated_by,omitempty"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// IsStage reports whether stage can have faults injected
+func IsStage(stage string) bool {
+	for _, s := range Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// next copies the plan and advances the sequence
+func (p Plan) next(by string, now time.Time) Plan {
+	faults := make(map[string]Fault, len(p.Faults))
+	for stage, f := range p.Faults {
+		faults[stage] = f
+	}
+	return Plan{Seq: p.Seq + 1, Faults: faults, UpdatedBy: by, UpdatedAt: now}
+}
+
+// With returns the plan with stage's fault set to f. A zero fault clears it.
+func (p Plan) With(stage string, f Fault, by string, now time.Time) (Plan, error) {
+	if !IsStage(stage) {
+		return p, fmt.Errorf("unknown stage %q", stage)
+	}
+	if err := f.Validate(); err != nil {
+		return p, err
+	}
+	n := p.next(by, now)
+	if f.IsZero() {
+		delete(n.Faults, stage)
+	} else {
+		n.Faults[stage] = f
+	}
+	return n, nil
+}
+
+// Cleared returns the plan with every fault removed
+func (p Plan) Cleared(by string, now time.Time) Plan {
+	n := p.next(by, now)
+	n.Faults = map[string]Fault{}
+	return n
+}
+
+// Injector applies the current plan to the messages of one stage
+type Injector struct {
+	stage    string
+	injected *prometheus.CounterVec
+
+	mu   sync.Mutex
+	plan Plan
+	rng  *rand.Rand
+}
+
+// NewInjector creates an injector for stage with an empty plan
+func NewInjector(stage string) *Injector {
+	return &Injector{
+		stage: stage,
+		injected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "chaos_injections_total",
+			Help: "Faults injected by chaos testing, by stage and fault",
+		}, []string{"stage", "fault"}),
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Collector returns the injection counter for registration
+func (i *Injector) Collector() prometheus.Collector {
+	return i.injected
+}
+
+// Seed restarts the injector's dice from seed, so the same faults fall on the
+// same messages when a run is replayed
+func (i *Injector) Seed(seed int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rng = rand.New(rand.NewSource(seed))
+}
+
+// Apply adopts plan if it is newer than the current one, reporting whether it did
+func (i *Injector) Apply(plan Plan) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if plan.Seq <= i.plan.Seq {
+		return false
+	}
+	i.plan = plan
+	return true
+}
+
+// Fault returns the fault currently applied to the injector's stage
+func (i *Injector) Fault() Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.plan.Faults[i.stage]
+}
+
+// Decide rolls the dice for one message: the delay before processing, and
+// which of FaultDrop or FaultNak to apply instead of processing, if any
+func (i *Injector) Decide() (time.Duration, string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	f := i.plan.Faults[i.stage]
+	delay := time.Duration(f.LatencyMs) * time.Millisecond
+	if f.DropPercent == 0 && f.NakPercent == 0 {
+		return delay, ""
+	}
+	roll := i.rng.Float64() * 100
+	switch {
+	case roll < f.DropPercent:
+		return delay, FaultDrop
+	case roll < f.DropPercent+f.NakPercent:
+		return delay, FaultNak
+	default:
+		return delay, ""
+	}
+}
+
+// Intercept applies the stage's fault to a fetched message. It reports
+// whether the message was dropped or Nak'd, in which case the caller must
+// skip it. A nil injector injects nothing.
+func (i *Injector) Intercept(ctx context.Context, msg jetstream.Msg) bool {
+	if i == nil {
+		return false
+	}
+
+	delay, fault := i.Decide()
+	if delay > 0 {
+		i.injected.WithLabelValues(i.stage, FaultLatency).Inc()
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+
+	switch fault {
+	case FaultDrop:
+		i.injected.WithLabelValues(i.stage, FaultDrop).Inc()
+		msg.Ack()
+		return true
+	case FaultNak:
+		i.injected.WithLabelValues(i.stage, FaultNak).Inc()
+		msg.Nak()
+		return true
+	default:
+		return false
+	}
+}
+
+// Watch calls apply with each plan published on the CHAOS stream until ctx is
+// done. The last published plan is delivered first, so an agent that starts
+// after chaos was configured joins it.
+func Watch(ctx context.Context, js jetstream.JetStream, apply func(Plan)) error {
+	consumer, err := js.OrderedConsumer(ctx, StreamName, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{PlanSubject},
+		DeliverPolicy:  jetstream.DeliverLastPerSubjectPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create chaos consumer: %w", err)
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		var plan Plan
+		if err := json.Unmarshal(msg.Data(), &plan); err != nil {
+			return
+		}
+		apply(plan)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch chaos plan: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		cc.Stop()
+	}()
+	return nil
+}
+
+// Publish announces a new plan to every agent
+func Publish(ctx context.Context, js jetstream.JetStream, plan Plan) error {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chaos plan: %w", err)
+	}
+	if _, err := js.Publish(ctx, PlanSubject, data); err != nil {
+		return fmt.Errorf("failed to publish chaos plan: %w", err)
+	}
+	return nil
+}
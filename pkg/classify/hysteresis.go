@@ -0,0 +1,286 @@
+package classify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Hysteresis defaults. A track changes classification only after
+// DefaultMinConsecutive detections agree on the new one, or sooner when one
+// detection is more confident than the current classification by the margin.
+const (
+	DefaultMinConsecutive = 3
+	DefaultFlipMargin     = 0.2
+	DefaultStateTTL       = 10 * time.Minute
+	MaxMinConsecutive     = 100
+)
+
+// HysteresisConfig controls when a track's classification may change
+type HysteresisConfig struct {
+	MinConsecutive int           // Consistent detections required; 1 disables hysteresis
+	Margin         float64       // Confidence lead that changes classification at once; 0 disables
+	StateTTL       time.Duration // Idle time after which a track's state is forgotten
+}
+
+// DefaultHysteresisConfig returns the default hysteresis settings
+func DefaultHysteresisConfig() HysteresisConfig {
+	return HysteresisConfig{MinConsecutive: DefaultMinConsecutive, Margin: DefaultFlipMargin, StateTTL: DefaultStateTTL}
+}
+
+// Enabled reports whether classification changes are damped
+func (c HysteresisConfig) Enabled() bool {
+	return c.MinConsecutive > 1
+}
+
+// ParseHysteresisConfig parses CLASSIFY_MIN_CONSECUTIVE, CLASSIFY_FLIP_MARGIN
+// and CLASSIFY_STATE_TTL, using the defaults for unset values
+func ParseHysteresisConfig(consecutive, margin, ttl string) (HysteresisConfig, error) {
+	cfg := DefaultHysteresisConfig()
+	if consecutive != "" {
+		n, err := strconv.Atoi(consecutive)
+		if err != nil || n < 1 || n > MaxMinConsecutive {
+			return cfg, fmt.Errorf("invalid CLASSIFY_MIN_CONSECUTIVE %q: must be between 1 and %d", consecutive, MaxMinConsecutive)
+		}
+		cfg.MinConsecutive = n
+	}
+	if margin != "" {
+		m, err := strconv.ParseFloat(margin, 64)
+		if err != nil || m < 0 || m > 1 || math.IsNaN(m) {
+			return cfg, fmt.Errorf("invalid CLASSIFY_FLIP_MARGIN %q: must be between 0 and 1", margin)
+		}
+		cfg.Margin = m
+	}
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < time.Second {
+			return cfg, fmt.Errorf("invalid CLASSIFY_STATE_TTL %q: must be a duration of at least 1s", ttl)
+		}
+		cfg.StateTTL = d
+	}
+	return cfg, nil
+}
+
+// TrackState is what hysteresis remembers about one track
+type TrackState struct {
+	Classification string    `json:"classification"` // Classification last published
+	Confidence     float64   `json:"confidence"`     // Confidence it was published with
+	Candidate      string    `json:"candidate,omitempty"`
+	CandidateCount int       `json:"candidate_count,omitempty"` // Consecutive detections proposing Candidate
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// StateStore holds per-track hysteresis state. Load returns nil for tracks it
+// has no state for.
+type StateStore interface {
+	Load(ctx context.Context, trackID string) (*TrackState, error)
+	Save(ctx context.Context, trackID string, state TrackState) error
+}
+
+// Decision is the outcome of one classification under hysteresis
+type Decision struct {
+	Classification string // Classification to publish
+	Proposed       string // Classification the rules or model proposed
+	Changed        bool   // The track's classification flipped
+	Held           bool   // A proposed change was suppressed
+}
+
+// Hysteresis damps noise-driven classification flips, such as a track
+// alternating between unknown and hostile, which would otherwise churn
+// proposals downstream
+type Hysteresis struct {
+	cfg   HysteresisConfig
+	store StateStore
+
+	flips      *prometheus.CounterVec
+	suppressed *prometheus.CounterVec
+}
+
+// NewHysteresis creates hysteresis over store
+func NewHysteresis(cfg HysteresisConfig, store StateStore) *Hysteresis {
+	return &Hysteresis{
+		cfg:   cfg,
+		store: store,
+		flips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "classifier_classification_flips_total",
+			Help: "Track classification changes published, by previous and new classification",
+		}, []string{"from", "to"}),
+		suppressed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "classifier_classification_flips_suppressed_total",
+			Help: "Proposed classification changes held back by hysteresis, by current and proposed classification",
+		}, []string{"from", "to"}),
+	}
+}
+
+// SetStore replaces the state store. It must be called before Decide.
+func (h *Hysteresis) SetStore(store StateStore) {
+	h.store = store
+}
+
+// Config returns the hysteresis settings
+func (h *Hysteresis) Config() HysteresisConfig {
+	return h.cfg
+}
+
+// Collectors returns the hysteresis metrics for registration
+func (h *Hysteresis) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.flips, h.suppressed}
+}
+
+// Decide returns the classification to publish for a track given the one
+// proposed for its latest detection and that proposal's confidence. A track's
+// first classification is published as proposed. When the state cannot be
+// loaded the proposal is published, so a store outage never stalls tracks.
+func (h *Hysteresis) Decide(ctx context.Context, trackID, proposed string, confidence float64, now time.Time) (Decision, error) {
+	state, err := h.store.Load(ctx, trackID)
+	if err != nil {
+		return Decision{Classification: proposed, Proposed: proposed}, fmt.Errorf("failed to load classification state: %w", err)
+	}
+
+	next, d := h.step(state, proposed, confidence, now)
+	if d.Changed {
+		h.flips.WithLabelValues(state.Classification, proposed).Inc()
+	}
+	if d.Held {
+		h.suppressed.WithLabelValues(state.Classification, proposed).Inc()
+	}
+	if err := h.store.Save(ctx, trackID, next); err != nil {
+		return d, fmt.Errorf("failed to save classification state: %w", err)
+	}
+	return d, nil
+}
+
+// step advances a track's state by one proposal
+func (h *Hysteresis) step(state *TrackState, proposed string, confidence float64, now time.Time) (TrackState, Decision) {
+	d := Decision{Classification: proposed, Proposed: proposed}
+	commit := TrackState{Classification: proposed, Confidence: confidence, UpdatedAt: now}
+
+	switch {
+	case state == nil:
+		return commit, d
+	case state.Classification == proposed:
+		// Agreement clears any pending change and refreshes the confidence
+		return commit, d
+	}
+
+	next := *state
+	next.UpdatedAt = now
+	if next.Candidate == proposed {
+		next.CandidateCount++
+	} else {
+		next.Candidate, next.CandidateCount = proposed, 1
+	}
+
+	confident := h.cfg.Margin > 0 && confidence-state.Confidence >= h.cfg.Margin
+	if !h.cfg.Enabled() || next.CandidateCount >= h.cfg.MinConsecutive || confident {
+		d.Changed = true
+		return commit, d
+	}
+
+	d.Classification = state.Classification
+	d.Held = true
+	return next, d
+}
+
+// MemoryStateStore keeps track state in memory, forgetting tracks idle for
+// longer than the TTL. It suits a single classifier instance.
+type MemoryStateStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	states    map[string]TrackState
+	lastSweep time.Time
+}
+
+// NewMemoryStateStore creates an empty in-memory store
+func NewMemoryStateStore(ttl time.Duration) *MemoryStateStore {
+	return &MemoryStateStore{ttl: ttl, states: make(map[string]TrackState)}
+}
+
+// Load returns the state for a track, or nil when it has none or it expired
+func (s *MemoryStateStore) Load(ctx context.Context, trackID string) (*TrackState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[trackID]
+	if !ok || time.Since(state.UpdatedAt) > s.ttl {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save stores the state for a track, sweeping expired tracks at most once per TTL
+func (s *MemoryStateStore) Save(ctx context.Context, trackID string, state TrackState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[trackID] = state
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= s.ttl {
+		for id, st := range s.states {
+			if now.Sub(st.UpdatedAt) > s.ttl {
+				delete(s.states, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	return nil
+}
+
+// KVStateStore keeps track state in a JetStream KV bucket, so classifier
+// instances sharing the DETECTIONS consumer agree on each track's history and
+// restarts keep it
+type KVStateStore struct {
+	kv jetstream.KeyValue
+}
+
+// NewKVStateStore creates a store on the named bucket, creating the bucket
+// with the TTL if needed
+func NewKVStateStore(ctx context.Context, js jetstream.JetStream, bucket string, ttl time.Duration) (*KVStateStore, error) {
+	kv, err := js.KeyValue(ctx, bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+			Bucket:      bucket,
+			Description: "Classifier hysteresis state per track",
+			TTL:         ttl,
+			Storage:     jetstream.FileStorage,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open classification state bucket: %w", err)
+	}
+	return &KVStateStore{kv: kv}, nil
+}
+
+// Load returns the state for a track, or nil when it has none
+func (s *KVStateStore) Load(ctx context.Context, trackID string) (*TrackState, error) {
+	entry, err := s.kv.Get(ctx, trackID)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state TrackState
+	if err := json.Unmarshal(entry.Value(), &state); err != nil {
+		return nil, nil // Unreadable state is treated as a new track
+	}
+	return &state, nil
+}
+
+// Save stores the state for a track
+func (s *KVStateStore) Save(ctx context.Context, trackID string, state TrackState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(ctx, trackID, data)
+	return err
+}
@@ -0,0 +1,269 @@
+// Package classify provides the classifier's rule engine, which infers a track's
+// type and classification from a detection using ordered, editable rules
+package classify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Kind identifies what a rule decides
+type Kind string
+
+const (
+	KindType           Kind = "type"           // Infers the track type when the sensor gave no hint
+	KindClassification Kind = "classification" // Assigns friendly, hostile, neutral or unknown
+)
+
+// Results used when no rule matches
+const (
+	FallbackType           = "unknown"
+	FallbackClassification = "unknown"
+)
+
+// ReportedRule names the classification rule of a detection that reports its
+// own classification, as manual contacts entered by an operator do
+const ReportedRule = "reported"
+
+// Classifications lists the results a classification rule may assign
+var Classifications = []string{"friendly", "hostile", "neutral", "unknown"}
+
+// Rule assigns Result to detections matching every condition it sets. Unset
+// conditions match anything, and numeric bounds are inclusive.
+type Rule struct {
+	Name    string `json:"name"`
+	Kind    Kind   `json:"kind"`
+	Result  string `json:"result"`
+	Enabled bool   `json:"enabled"`
+
+	// Speed (m/s), altitude (m) and sensor confidence bounds
+	MinSpeed      *float64 `json:"min_speed,omitempty"`
+	MaxSpeed      *float64 `json:"max_speed,omitempty"`
+	MinAlt        *float64 `json:"min_alt,omitempty"`
+	MaxAlt        *float64 `json:"max_alt,omitempty"`
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+	MaxConfidence *float64 `json:"max_confidence,omitempty"`
+
+	// Region the detection must lie in
+	MinLat *float64 `json:"min_lat,omitempty"`
+	MaxLat *float64 `json:"max_lat,omitempty"`
+	MinLon *float64 `json:"min_lon,omitempty"`
+	MaxLon *float64 `json:"max_lon,omitempty"`
+
+	SensorTypes     []string `json:"sensor_types,omitempty"`
+	TrackIDPrefixes []string `json:"track_id_prefixes,omitempty"`
+	TrackTypes      []string `json:"track_types,omitempty"` // Classification rules only: the inferred track type
+
+	EvaluationOrder int `json:"evaluation_order"`
+}
+
+// Validate checks that a rule is well-formed
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	if r.Result == "" {
+		return fmt.Errorf("rule %q: result is required", r.Name)
+	}
+	switch r.Kind {
+	case KindType:
+		if len(r.TrackTypes) > 0 {
+			return fmt.Errorf("rule %q: track_types only applies to classification rules", r.Name)
+		}
+	case KindClassification:
+		if !contains(Classifications, r.Result) {
+			return fmt.Errorf("rule %q: result must be one of %s", r.Name, strings.Join(Classifications, ", "))
+		}
+	default:
+		return fmt.Errorf("rule %q: unknown kind %q", r.Name, r.Kind)
+	}
+	for _, b := range []struct {
+		name     string
+		min, max *float64
+	}{
+		{"speed", r.MinSpeed, r.MaxSpeed},
+		{"alt", r.MinAlt, r.MaxAlt},
+		{"confidence", r.MinConfidence, r.MaxConfidence},
+		{"lat", r.MinLat, r.MaxLat},
+		{"lon", r.MinLon, r.MaxLon},
+	} {
+		if b.min != nil && b.max != nil && *b.min > *b.max {
+			return fmt.Errorf("rule %q: min_%s must not exceed max_%s", r.Name, b.name, b.name)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a detection satisfies every condition of the rule
+func (r Rule) Matches(d *messages.Detection, trackType string) bool {
+	if !inRange(d.Velocity.Speed, r.MinSpeed, r.MaxSpeed) ||
+		!inRange(d.Position.Alt, r.MinAlt, r.MaxAlt) ||
+		!inRange(d.Confidence, r.MinConfidence, r.MaxConfidence) ||
+		!inRange(d.Position.Lat, r.MinLat, r.MaxLat) ||
+		!inRange(d.Position.Lon, r.MinLon, r.MaxLon) {
+		return false
+	}
+	if len(r.SensorTypes) > 0 && !contains(r.SensorTypes, d.SensorType) {
+		return false
+	}
+	if len(r.TrackTypes) > 0 && !contains(r.TrackTypes, trackType) {
+		return false
+	}
+	if len(r.TrackIDPrefixes) > 0 {
+		matched := false
+		for _, prefix := range r.TrackIDPrefixes {
+			if strings.HasPrefix(d.TrackID, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Result is the outcome of classifying a detection
+type Result struct {
+	Type               string `json:"type"`
+	Classification     string `json:"classification"`
+	TypeRule           string `json:"type_rule,omitempty"` // Empty when the sensor supplied the type
+	ClassificationRule string `json:"classification_rule,omitempty"`
+}
+
+// Engine classifies detections with the first matching rule of each kind
+type Engine struct {
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// NewEngine creates an engine with the given rules
+func NewEngine(rules []Rule) *Engine {
+	e := &Engine{}
+	e.SetRules(rules)
+	return e
+}
+
+// NewDefaultEngine creates an engine using the built-in rule set
+func NewDefaultEngine() *Engine {
+	return NewEngine(DefaultRules())
+}
+
+// SetRules atomically replaces the engine's rules
+func (e *Engine) SetRules(rules []Rule) {
+	sorted := make([]Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EvaluationOrder < sorted[j].EvaluationOrder
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = sorted
+}
+
+// Rules returns a copy of the active rules
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]Rule, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+// Classify infers the type and classification of a detection. A type supplied
+// by the sensor is trusted and no type rule is consulted, and likewise a
+// reported classification.
+func (e *Engine) Classify(d *messages.Detection) Result {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := Result{Type: d.Type, Classification: FallbackClassification}
+	if result.Type == "" {
+		result.Type = FallbackType
+		if rule, ok := e.first(KindType, d, ""); ok {
+			result.Type, result.TypeRule = rule.Result, rule.Name
+		}
+	}
+	if d.Classification != "" {
+		result.Classification, result.ClassificationRule = d.Classification, ReportedRule
+		return result
+	}
+	if rule, ok := e.first(KindClassification, d, result.Type); ok {
+		result.Classification, result.ClassificationRule = rule.Result, rule.Name
+	}
+	return result
+}
+
+// first returns the first enabled rule of a kind matching the detection
+func (e *Engine) first(kind Kind, d *messages.Detection, trackType string) (Rule, bool) {
+	for _, rule := range e.rules {
+		if rule.Enabled && rule.Kind == kind && rule.Matches(d, trackType) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// DefaultRules reproduces the classifier's original hardcoded heuristics
+func DefaultRules() []Rule {
+	return []Rule{
+		// Track type from kinematics
+		{Name: "high-altitude-aircraft", Kind: KindType, Result: "aircraft", Enabled: true,
+			MinAlt: floatPtr(10000.01), MinSpeed: floatPtr(200.01), EvaluationOrder: 10},
+		{Name: "fast-climbing-missile", Kind: KindType, Result: "missile", Enabled: true,
+			MinAlt: floatPtr(1000.01), MinSpeed: floatPtr(500.01), EvaluationOrder: 20},
+		{Name: "slow-surface-vessel-pacific-west", Kind: KindType, Result: "vessel", Enabled: true,
+			MaxAlt: floatPtr(99.99), MinSpeed: floatPtr(0.01), MaxSpeed: floatPtr(49.99), MaxLon: floatPtr(-100.01), EvaluationOrder: 30},
+		{Name: "slow-surface-vessel-pacific-east", Kind: KindType, Result: "vessel", Enabled: true,
+			MaxAlt: floatPtr(99.99), MinSpeed: floatPtr(0.01), MaxSpeed: floatPtr(49.99), MinLon: floatPtr(100.01), EvaluationOrder: 31},
+		{Name: "slow-surface-vessel-south-atlantic", Kind: KindType, Result: "vessel", Enabled: true,
+			MaxAlt: floatPtr(99.99), MinSpeed: floatPtr(0.01), MaxSpeed: floatPtr(49.99), MinLon: floatPtr(-49.99), MaxLon: floatPtr(49.99), MaxLat: floatPtr(-0.01), EvaluationOrder: 32},
+		{Name: "slow-surface-ground", Kind: KindType, Result: "ground", Enabled: true,
+			MaxAlt: floatPtr(99.99), MinSpeed: floatPtr(0.01), MaxSpeed: floatPtr(49.99), EvaluationOrder: 40},
+		{Name: "low-altitude-aircraft", Kind: KindType, Result: "aircraft", Enabled: true,
+			MaxAlt: floatPtr(4999.99), MinSpeed: floatPtr(50.01), MaxSpeed: floatPtr(299.99), EvaluationOrder: 50},
+		{Name: "stationary-ground", Kind: KindType, Result: "ground", Enabled: true,
+			MaxSpeed: floatPtr(0), EvaluationOrder: 60},
+
+		// Classification from track ID conventions, kinematics and confidence
+		{Name: "neutral-track-id", Kind: KindClassification, Result: "neutral", Enabled: true,
+			TrackIDPrefixes: []string{"N"}, EvaluationOrder: 10},
+		{Name: "iff-friendly-track-id", Kind: KindClassification, Result: "friendly", Enabled: true,
+			TrackIDPrefixes: []string{"F"}, EvaluationOrder: 20},
+		{Name: "fast-missile", Kind: KindClassification, Result: "hostile", Enabled: true,
+			TrackTypes: []string{"missile"}, MinSpeed: floatPtr(500.01), EvaluationOrder: 30},
+		{Name: "hostile-track-id", Kind: KindClassification, Result: "hostile", Enabled: true,
+			TrackIDPrefixes: []string{"H"}, EvaluationOrder: 40},
+		{Name: "high-confidence-neutral", Kind: KindClassification, Result: "neutral", Enabled: true,
+			MinConfidence: floatPtr(0.8501), EvaluationOrder: 50},
+	}
+}
+
+func inRange(v float64, min, max *float64) bool {
+	if min != nil && v < *min {
+		return false
+	}
+	if max != nil && v > *max {
+		return false
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,303 @@
+// Package clutter injects spurious detections into the sensor simulation.
+//
+// Real sensors report returns from birds, weather cells, and jamming that
+// look like tracks for a few scans and then vanish. The clutter generator
+// spawns such objects at a configurable rate, keeps each alive for a short
+// lifetime, and reports it with low confidence under its own unknown track ID,
+// so the classifier and correlator can be tested on suppressing false tracks.
+// Detections carry nothing that marks them as clutter; the sensor records
+// which ones were spurious on a separate ground-truth subject instead.
+package clutter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/sensormodel"
+)
+
+// Clutter kinds
+const (
+	KindBirds   = "birds"
+	KindWeather = "weather"
+	KindJamming = "jamming"
+)
+
+// KindFalseAlarm marks ground truth for a sensor error model's false alarm
+const KindFalseAlarm = "false_alarm"
+
+// Kinds lists the clutter kinds in the order they are described
+var Kinds = []string{KindBirds, KindWeather, KindJamming}
+
+// Limits on clutter configuration
+const (
+	MaxRatePerMinute = 600.0
+	MaxLifetimeSec   = 600
+	MaxObjects       = 200 // Live objects; spawning pauses at the cap
+)
+
+// Default clutter lifetimes
+const (
+	DefaultMinLifetimeSec = 5
+	DefaultMaxLifetimeSec = 30
+)
+
+// TruthSubjectPrefix is where ground truth for spurious detections is published
+const TruthSubjectPrefix = "truth.detection"
+
+// Simulation area clutter is spawned in, matching the simulated tracks
+const (
+	minLat, latSpan = 35.0, 5.0
+	minLon, lonSpan = -120.0, 10.0
+)
+
+// jammingSpreadMeters is how far a jamming return strays from its source each scan
+const jammingSpreadMeters = 20000.0
+
+// Config controls clutter injection. The zero value injects nothing.
+type Config struct {
+	RatePerMinute  float64  `json:"rate_per_minute"` // New clutter objects per simulated minute; 0 disables
+	MinLifetimeSec int      `json:"min_lifetime_sec"`
+	MaxLifetimeSec int      `json:"max_lifetime_sec"`
+	Kinds          []string `json:"kinds"` // Empty injects every kind
+}
+
+// DefaultConfig returns a disabled configuration with the default lifetimes
+func DefaultConfig() Config {
+	return Config{MinLifetimeSec: DefaultMinLifetimeSec, MaxLifetimeSec: DefaultMaxLifetimeSec}
+}
+
+// Enabled reports whether clutter is being spawned
+func (c Config) Enabled() bool {
+	return c.RatePerMinute > 0
+}
+
+// WithDefaults fills unset lifetimes with the defaults
+func (c Config) WithDefaults() Config {
+	if c.MinLifetimeSec == 0 {
+		c.MinLifetimeSec = DefaultMinLifetimeSec
+	}
+	if c.MaxLifetimeSec == 0 {
+		c.MaxLifetimeSec = max(DefaultMaxLifetimeSec, c.MinLifetimeSec)
+	}
+	return c
+}
+
+// Validate checks the clutter knobs are in range
+func (c Config) Validate() error {
+	switch {
+	case c.RatePerMinute < 0 || c.RatePerMinute > MaxRatePerMinute:
+		return fmt.Errorf("rate_per_minute must be between 0 and %.0f", MaxRatePerMinute)
+	case c.MinLifetimeSec < 1 || c.MaxLifetimeSec > MaxLifetimeSec || c.MinLifetimeSec > c.MaxLifetimeSec:
+		return fmt.Errorf("lifetimes must satisfy 1 <= min_lifetime_sec <= max_lifetime_sec <= %d", MaxLifetimeSec)
+	}
+	for _, kind := range c.Kinds {
+		if kind != KindBirds && kind != KindWeather && kind != KindJamming {
+			return fmt.Errorf("unknown clutter kind %q: must be birds, weather, or jamming", kind)
+		}
+	}
+	return nil
+}
+
+// Parse reads CLUTTER_RATE, new objects per minute, and CLUTTER_KINDS, a
+// comma-separated list of kinds. Empty values leave clutter disabled and all
+// kinds enabled.
+func Parse(rate, kinds string) (Config, error) {
+	cfg := DefaultConfig()
+	if rate != "" {
+		r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid CLUTTER_RATE %q: must be a number", rate)
+		}
+		cfg.RatePerMinute = r
+	}
+	for _, kind := range strings.Split(kinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			cfg.Kinds = append(cfg.Kinds, kind)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid clutter configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// Object is a live source of spurious returns
+type Object struct {
+	TrackID   string
+	Kind      string
+	SensorID  string // Sensor that reports it
+	Position  messages.Position
+	Velocity  messages.Velocity
+	Remaining time.Duration // Simulated time left before it vanishes
+
+	origin messages.Position // Source a jamming return strays from
+}
+
+// Generator spawns, moves, and expires clutter objects. It is not safe for
+// concurrent use; the sensor's emission loop owns it.
+type Generator struct {
+	objects []*Object
+}
+
+// NewGenerator creates a generator with no live clutter
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// Len returns the number of live clutter objects
+func (g *Generator) Len() int {
+	return len(g.objects)
+}
+
+// Step advances clutter by elapsed simulated time: objects that have lived
+// out their lifetime vanish, survivors move, and new objects are spawned at
+// the configured rate, each reported by one of sensorIDs. It returns the live
+// objects to report this scan.
+func (g *Generator) Step(rng *rand.Rand, cfg Config, elapsed time.Duration, sensorIDs []string) []Object {
+	live := g.objects[:0]
+	for _, o := range g.objects {
+		o.Remaining -= elapsed
+		if o.Remaining <= 0 {
+			continue
+		}
+		o.move(rng, elapsed)
+		live = append(live, o)
+	}
+	g.objects = live
+
+	if cfg.Enabled() && len(sensorIDs) > 0 {
+		kinds := cfg.Kinds
+		if len(kinds) == 0 {
+			kinds = Kinds
+		}
+		for n := spawnCount(rng, cfg.RatePerMinute*elapsed.Minutes()); n > 0 && len(g.objects) < MaxObjects; n-- {
+			g.objects = append(g.objects, spawn(rng, cfg, kinds[rng.Intn(len(kinds))], sensorIDs[rng.Intn(len(sensorIDs))]))
+		}
+	}
+
+	out := make([]Object, len(g.objects))
+	for i, o := range g.objects {
+		out[i] = *o
+	}
+	return out
+}
+
+// spawnCount returns the whole part of expected and the fraction by chance
+func spawnCount(rng *rand.Rand, expected float64) int {
+	n := int(expected)
+	if frac := expected - float64(n); frac > 0 && rng.Float64() < frac {
+		n++
+	}
+	return n
+}
+
+// spawn creates a clutter object of kind somewhere in the simulation area
+func spawn(rng *rand.Rand, cfg Config, kind, sensorID string) *Object {
+	lifetime := cfg.MinLifetimeSec + rng.Intn(cfg.MaxLifetimeSec-cfg.MinLifetimeSec+1)
+	o := &Object{
+		TrackID:   fmt.Sprintf("U-CL-%08x", rng.Uint32()),
+		Kind:      kind,
+		SensorID:  sensorID,
+		Remaining: time.Duration(lifetime) * time.Second,
+		Position: messages.Position{
+			Lat: minLat + rng.Float64()*latSpan,
+			Lon: minLon + rng.Float64()*lonSpan,
+		},
+		Velocity: messages.Velocity{Heading: rng.Float64() * 360},
+	}
+	switch kind {
+	case KindBirds:
+		o.Position.Alt = 50 + rng.Float64()*1450
+		o.Velocity.Speed = 8 + rng.Float64()*12
+	case KindWeather:
+		o.Position.Alt = 500 + rng.Float64()*5500
+		o.Velocity.Speed = 5 + rng.Float64()*15
+	case KindJamming:
+		o.Position.Alt = rng.Float64() * 15000
+		o.Velocity.Speed = rng.Float64() * 900 // Implausible speeds are part of the signature
+	}
+	o.origin = o.Position
+	return o
+}
+
+// move advances an object. Jamming returns strobe around their source rather
+// than moving along a heading.
+func (o *Object) move(rng *rand.Rand, elapsed time.Duration) {
+	if o.Kind == KindJamming {
+		o.Position = sensormodel.Offset(o.origin, (rng.Float64()*2-1)*jammingSpreadMeters, (rng.Float64()*2-1)*jammingSpreadMeters)
+		o.Position.Alt = rng.Float64() * 15000
+		o.Velocity.Heading = rng.Float64() * 360
+		return
+	}
+	distance := o.Velocity.Speed * elapsed.Seconds()
+	heading := o.Velocity.Heading * math.Pi / 180
+	north, east := distance*math.Cos(heading), distance*math.Sin(heading)
+	alt := o.Position.Alt
+	o.Position = sensormodel.Offset(o.Position, north, east)
+	o.Position.Alt = alt
+}
+
+// Confidence returns a low detection confidence for an object's kind
+func Confidence(rng *rand.Rand, kind string) float64 {
+	switch kind {
+	case KindBirds:
+		return 0.15 + rng.Float64()*0.2
+	case KindWeather:
+		return 0.1 + rng.Float64()*0.2
+	default:
+		return 0.05 + rng.Float64()*0.2
+	}
+}
+
+// Truth records that a published detection was spurious. It is published on
+// the ground-truth subject only, never on the detection itself, and signed
+// by the sensor like the detection.
+type Truth struct {
+	Envelope   messages.Envelope `json:"envelope"`
+	MessageID  string            `json:"message_id"` // Envelope message ID of the detection
+	TrackID    string    `json:"track_id"`
+	SensorID   string    `json:"sensor_id"`
+	Kind       string    `json:"kind"` // birds, weather, jamming, or false_alarm
+	Spurious   bool      `json:"spurious"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// NewTruth records a detection as spurious clutter of kind
+func NewTruth(det *messages.Detection, kind string) Truth {
+	return Truth{
+		Envelope: messages.NewEnvelope(det.Envelope.Source, det.Envelope.SourceType).
+			WithCorrelation(det.Envelope.CorrelationID, det.Envelope.MessageID).
+			WithLabels(det.Envelope),
+		MessageID:  det.Envelope.MessageID,
+		TrackID:    det.TrackID,
+		SensorID:   det.SensorID,
+		Kind:       kind,
+		Spurious:   true,
+		ObservedAt: det.Envelope.Timestamp,
+	}
+}
+
+func (t *Truth) GetEnvelope() messages.Envelope {
+	return t.Envelope
+}
+
+func (t *Truth) SetEnvelope(e messages.Envelope) {
+	t.Envelope = e
+}
+
+// Subject returns the ground-truth subject for the record
+func (t Truth) Subject() string {
+	return TruthSubjectPrefix + "." + t.SensorID
+}
+
+// Marshal encodes the record for publishing
+func (t Truth) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
@@ -0,0 +1,159 @@
+// Package coa generates the ranked courses of action a proposal offers.
+//
+// The planner recommends one action per proposal. Alongside it, Generate
+// scores the other actions that suit the track on the benefit they bring
+// against its threat and the risk they carry, so the approver can choose a
+// more or less forceful response than the one recommended. The recommended
+// action is always ranked first and the alternatives follow by score.
+package coa
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// DefaultMaxOptions is how many courses of action a proposal offers,
+// including the recommended one
+const DefaultMaxOptions = 3
+
+// ErrUnknownOption is returned when an approver selects an action the proposal does not offer
+var ErrUnknownOption = errors.New("proposal does not offer the selected course of action")
+
+// profile describes how forcefully an action answers a threat and what it risks
+type profile struct {
+	strength   float64 // 0-1, how decisively the action answers a threat
+	escalation float64 // 0-1, risk of escalation or collateral harm if the threat is not real
+	summary    string
+}
+
+var profiles = map[string]profile{
+	"engage":    {strength: 1.0, escalation: 0.9, summary: "Defeat the track"},
+	"intercept": {strength: 0.75, escalation: 0.5, summary: "Close with the track to force identification or turn it away"},
+	"identify":  {strength: 0.5, escalation: 0.2, summary: "Interrogate the track to establish its identity"},
+	"track":     {strength: 0.3, escalation: 0.05, summary: "Hold a continuous track and report changes"},
+	"monitor":   {strength: 0.15, escalation: 0, summary: "Watch the track passively"},
+}
+
+// levelThreat stands in for the threat score of tracks scored without one
+var levelThreat = map[string]float64{
+	"low":      0.15,
+	"medium":   0.4,
+	"high":     0.7,
+	"critical": 0.9,
+}
+
+// hostility weighs the threat by how likely the track is to act on it
+var hostility = map[string]float64{
+	"hostile":  1.0,
+	"unknown":  0.6,
+	"neutral":  0.25,
+	"friendly": 0.1,
+}
+
+// ParseMaxOptions parses PROPOSAL_OPTIONS, using DefaultMaxOptions when unset.
+// One offers only the recommended action.
+func ParseMaxOptions(value string) (int, error) {
+	if value == "" {
+		return DefaultMaxOptions, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid PROPOSAL_OPTIONS %q: must be a positive integer", value)
+	}
+	return n, nil
+}
+
+// Need returns how forceful a response the track calls for, from 0 to 1
+func Need(track *messages.CorrelatedTrack) float64 {
+	threat := levelThreat[track.ThreatLevel]
+	if track.ThreatScore > 0 {
+		threat = math.Min(track.ThreatScore/100, 1)
+	}
+	h, ok := hostility[track.Classification]
+	if !ok {
+		h = hostility["unknown"]
+	}
+	return threat * h
+}
+
+// Score estimates the benefit and risk of answering a track with an action.
+// Benefit is highest for the action whose force best fits the need; risk
+// combines escalating against a track that is no threat with leaving a real
+// threat unanswered. Actions without a profile, such as ignore, are not scored.
+func Score(track *messages.CorrelatedTrack, actionType string) (messages.CourseOfAction, bool) {
+	p, ok := profiles[actionType]
+	if !ok {
+		return messages.CourseOfAction{}, false
+	}
+	need := Need(track)
+	benefit := 100 * (1 - math.Abs(p.strength-need))
+	risk := 100 * (p.escalation*(1-need) + (1-p.strength)*need)
+	return messages.CourseOfAction{
+		ActionType: actionType,
+		Benefit:    round(benefit),
+		Risk:       round(risk),
+		Score:      round(benefit - risk),
+		Rationale:  fmt.Sprintf("%s. Estimated benefit %.0f, risk %.0f.", p.summary, benefit, risk),
+	}, true
+}
+
+// Generate returns up to max courses of action for a track, the recommended
+// action first and the best scoring alternatives after it. Engagement is only
+// offered against hostile tracks. Nil is returned if the recommended action
+// cannot be scored.
+func Generate(track *messages.CorrelatedTrack, recommended string, max int) []messages.CourseOfAction {
+	first, ok := Score(track, recommended)
+	if !ok || max < 1 {
+		return nil
+	}
+
+	var alternatives []messages.CourseOfAction
+	for actionType := range profiles {
+		if actionType == recommended || (actionType == "engage" && track.Classification != "hostile") {
+			continue
+		}
+		if option, ok := Score(track, actionType); ok {
+			alternatives = append(alternatives, option)
+		}
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		if alternatives[i].Score != alternatives[j].Score {
+			return alternatives[i].Score > alternatives[j].Score
+		}
+		return alternatives[i].ActionType < alternatives[j].ActionType
+	})
+
+	options := append([]messages.CourseOfAction{first}, alternatives...)
+	if len(options) > max {
+		options = options[:max]
+	}
+	for i := range options {
+		options[i].Rank = i + 1
+	}
+	return options
+}
+
+// Select resolves the action an approver chose. An empty selection is the
+// recommended action; anything else must be one of the options offered.
+func Select(options []messages.CourseOfAction, recommended, selected string) (string, error) {
+	if selected == "" || selected == recommended {
+		return recommended, nil
+	}
+	for _, o := range options {
+		if o.ActionType == selected {
+			return selected, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownOption, selected)
+}
+
+// round keeps scores to one decimal place
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,143 @@
+// Package config loads secrets from the environment or from mounted files.
+//
+// A secret named NAME is read, in order, from the file named by NAME_FILE,
+// from NAME itself, and from a Docker or Kubernetes secret mount at
+// SECRETS_DIR/name (default /run/secrets/name, lower case). Only then does
+// the development default apply. Setting both NAME and NAME_FILE is an error,
+// so a stale inline value cannot shadow a mounted one.
+//
+// CJADC2_MODE is dev unless set otherwise. Outside dev mode a service refuses
+// to start when any secret falls back to, or is set to, its development
+// default, so a lab deployment cannot run with "dev-secret" by accident.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Deployment modes
+const (
+	ModeDev        = "dev"
+	ModeProduction = "production"
+)
+
+// DefaultSecretsDir is where Docker and Kubernetes mount secrets
+const DefaultSecretsDir = "/run/secrets"
+
+// PasswordSecret overrides the password in every PostgreSQL URL when set
+const PasswordSecret = "POSTGRES_PASSWORD"
+
+// Mode returns the deployment mode from CJADC2_MODE
+func Mode() string {
+	if mode := os.Getenv("CJADC2_MODE"); mode != "" {
+		return mode
+	}
+	return ModeDev
+}
+
+// Loader reads secrets and collects every problem with them, so a service
+// reports all of its misconfigured secrets at once
+type Loader struct {
+	mode string
+	dir  string
+	errs []error
+}
+
+// NewLoader creates a loader for the current mode and secrets directory
+func NewLoader() *Loader {
+	l := &Loader{mode: Mode(), dir: os.Getenv("SECRETS_DIR")}
+	if l.dir == "" {
+		l.dir = DefaultSecretsDir
+	}
+	if l.mode != ModeDev && l.mode != ModeProduction {
+		l.errs = append(l.errs, fmt.Errorf("invalid CJADC2_MODE %q: must be %s or %s", l.mode, ModeDev, ModeProduction))
+	}
+	return l
+}
+
+// Dev reports whether development defaults are allowed
+func (l *Loader) Dev() bool {
+	return l.mode == ModeDev
+}
+
+// Err returns every problem found by the secrets read so far
+func (l *Loader) Err() error {
+	return errors.Join(l.errs...)
+}
+
+// Secret returns the named secret, or devDefault when it is not set anywhere
+func (l *Loader) Secret(name, devDefault string) string {
+	value, ok := l.lookup(name)
+	if !ok {
+		value = devDefault
+	}
+	if !l.Dev() && devDefault != "" && value == devDefault {
+		l.errs = append(l.errs, fmt.Errorf("%s is the development default; set %s or %s_FILE", name, name, name))
+	}
+	return value
+}
+
+// DatabaseURL returns the named PostgreSQL URL with its password replaced by
+// POSTGRES_PASSWORD when that secret is set. Outside dev mode the password
+// may not be the one in devDefault.
+func (l *Loader) DatabaseURL(name, devDefault string) string {
+	value, ok := l.lookup(name)
+	if !ok {
+		value = devDefault
+	}
+	if value == "" {
+		return value
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if password, ok := l.lookup(PasswordSecret); ok {
+		u.User = url.UserPassword(u.User.Username(), password)
+		value = u.String()
+	}
+
+	if !l.Dev() {
+		password, _ := u.User.Password()
+		if def, err := url.Parse(devDefault); err == nil && def.User != nil {
+			if devPassword, _ := def.User.Password(); devPassword != "" && password == devPassword {
+				l.errs = append(l.errs, fmt.Errorf("%s uses the development database password; set %s or %s_FILE", name, PasswordSecret, PasswordSecret))
+			}
+		}
+	}
+	return value
+}
+
+// lookup finds a secret in NAME_FILE, NAME, or the secrets directory
+func (l *Loader) lookup(name string) (string, bool) {
+	inline := os.Getenv(name)
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		if inline != "" {
+			l.errs = append(l.errs, fmt.Errorf("set only one of %s and %s_FILE", name, name))
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("invalid %s_FILE: %w", name, err))
+			return "", false
+		}
+		return trimSecret(data), true
+	}
+	if inline != "" {
+		return inline, true
+	}
+	if data, err := os.ReadFile(filepath.Join(l.dir, strings.ToLower(name))); err == nil {
+		return trimSecret(data), true
+	}
+	return "", false
+}
+
+// trimSecret drops the trailing newline editors and kubectl leave on files
+func trimSecret(data []byte) string {
+	return strings.TrimRight(string(data), "\r\n")
+}
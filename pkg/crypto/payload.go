@@ -0,0 +1,125 @@
+// Package crypto encrypts message payloads whose classification requires it.
+// Payloads are sealed with AES-256-GCM under a named key from a keyring; the
+// key ID travels in the clear beside the ciphertext, so keys can be rotated
+// by adding a new key, making it active, and retiring the old one once no
+// message sealed under it remains in a stream.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KeySize is the length of a payload key: AES-256
+const KeySize = 32
+
+// ErrUnknownKey is returned when opening a payload sealed under a key the
+// keyring does not hold, such as one already retired
+var ErrUnknownKey = errors.New("unknown payload key")
+
+// Keyring holds the payload keys by ID. New payloads are sealed under the
+// active key; any key in the ring opens the payloads sealed under it.
+type Keyring struct {
+	active string
+	aeads  map[string]cipher.AEAD
+}
+
+// ParseKeyring parses PAYLOAD_KEYS, a comma-separated list of id:key pairs
+// with each key 32 bytes in standard base64, and PAYLOAD_KEY_ID, the key new
+// payloads are sealed under. An empty PAYLOAD_KEY_ID selects the last key
+// listed. Unset PAYLOAD_KEYS returns a nil keyring.
+func ParseKeyring(keys, activeID string) (*Keyring, error) {
+	if strings.TrimSpace(keys) == "" {
+		if activeID != "" {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEY_ID %q: no PAYLOAD_KEYS are set", activeID)
+		}
+		return nil, nil
+	}
+
+	ring := &Keyring{aeads: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(keys, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEYS entry: want id:base64-key")
+		}
+		if _, dup := ring.aeads[id]; dup {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEYS: key %q is listed twice", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != KeySize {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEYS: key %q must be %d bytes of base64", id, KeySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEYS: key %q: %w", id, err)
+		}
+		ring.aeads[id] = aead
+		ring.active = id
+	}
+
+	if activeID != "" {
+		if _, ok := ring.aeads[activeID]; !ok {
+			return nil, fmt.Errorf("invalid PAYLOAD_KEY_ID %q: not in PAYLOAD_KEYS", activeID)
+		}
+		ring.active = activeID
+	}
+	return ring, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ActiveKeyID returns the ID of the key new payloads are sealed under
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// KeyIDs returns the IDs of every key in the ring, sorted
+func (k *Keyring) KeyIDs() []string {
+	ids := make([]string, 0, len(k.aeads))
+	for id := range k.aeads {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Seal encrypts plaintext under the active key, authenticating aad with it.
+// It returns the key ID and the nonce followed by the ciphertext.
+func (k *Keyring) Seal(plaintext, aad []byte) (string, []byte, error) {
+	aead := k.aeads[k.active]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.active, aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Open decrypts a payload sealed under keyID, checking it and aad have not
+// been altered
+func (k *Keyring) Open(keyID string, sealed, aad []byte) ([]byte, error) {
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed payload is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open payload sealed under %q: %w", keyID, err)
+	}
+	return plaintext, nil
+}
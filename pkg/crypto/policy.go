@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// DefaultEncryptClassifications are the labels sealed when
+// ENCRYPT_CLASSIFICATIONS is unset; the data handling policy requires
+// encryption for secret data and above
+const DefaultEncryptClassifications = seclabel.Secret + "," + seclabel.TopSecret
+
+// Policy selects the classification labels whose payloads are sealed
+type Policy struct {
+	labels map[string]bool
+}
+
+// ParsePolicy parses ENCRYPT_CLASSIFICATIONS, a comma-separated list of
+// labels. Unset selects DefaultEncryptClassifications; "none" seals nothing.
+func ParsePolicy(value string) (Policy, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = DefaultEncryptClassifications
+	}
+	policy := Policy{labels: make(map[string]bool)}
+	if strings.EqualFold(value, "none") {
+		return policy, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		label, err := seclabel.ParseClassification(entry)
+		if err != nil || strings.TrimSpace(entry) == "" {
+			return Policy{}, fmt.Errorf("invalid ENCRYPT_CLASSIFICATIONS %q: must be none or labels from %s", value, strings.Join(seclabel.Classifications, ", "))
+		}
+		policy.labels[label] = true
+	}
+	return policy, nil
+}
+
+// Requires reports whether payloads with the label must be sealed
+func (p Policy) Requires(label string) bool {
+	if label == "" {
+		label = seclabel.Unclassified
+	}
+	return p.labels[label]
+}
+
+// Labels returns the labels that are sealed, lowest first
+func (p Policy) Labels() []string {
+	labels := []string{}
+	for _, c := range seclabel.Classifications {
+		if p.labels[c] {
+			labels = append(labels, c)
+		}
+	}
+	return labels
+}
@@ -0,0 +1,297 @@
+// Package ctl is the client side of cjadc2ctl, the operator CLI.
+//
+// Client wraps the gateway's REST API and the sensor's control endpoint so
+// proposals can be decided, the audit trail followed, the simulator tuned, and
+// pipeline health checked from scripts and headless environments. Streams are
+// purged directly over JetStream, since the gateway only purges them as part
+// of a full exercise reset.
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// Defaults for connecting to a local stack
+const (
+	DefaultGatewayURL = "http://localhost:8080"
+	DefaultSensorURL  = "http://localhost:9091"
+	DefaultNATSURL    = "nats://localhost:4222"
+	DefaultTimeout    = 10 * time.Second
+)
+
+// APIError is a non-success response from the gateway or an agent
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements error
+func (e *APIError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client talks to the gateway and the sensor on behalf of an operator
+type Client struct {
+	gatewayURL string
+	sensorURL  string
+	userID     string
+	http       *http.Client
+}
+
+// NewClient creates a new Client. userID is sent as X-User-ID and attributes
+// decisions to the operator.
+func NewClient(gatewayURL, sensorURL, userID string, timeout time.Duration) *Client {
+	return &Client{
+		gatewayURL: strings.TrimRight(gatewayURL, "/"),
+		sensorURL:  strings.TrimRight(sensorURL, "/"),
+		userID:     userID,
+		http:       &http.Client{Timeout: timeout},
+	}
+}
+
+// ListProposals returns proposals with the given status, or every status when empty
+func (c *Client) ListProposals(ctx context.Context, status string, limit int) (*handler.ProposalListResponse, error) {
+	q := url.Values{}
+	if status != "" {
+		q.Set("status", status)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp handler.ProposalListResponse
+	if err := c.do(ctx, http.MethodGet, c.gatewayURL+"/api/v1/proposals?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DecisionOutcome is the gateway's answer to a decision. Exactly one of
+// Decision and Pending is set.
+type DecisionOutcome struct {
+	Decision *handler.DecisionResponse        // The decision was published
+	Pending  *handler.PartialApprovalResponse // The approval awaits a second operator
+}
+
+// Decide approves or denies a proposal through the gateway, which checks the
+// operator's authority and forwards the decision to the authorizer
+func (c *Client) Decide(ctx context.Context, proposalID string, approved bool, reason string) (*DecisionOutcome, error) {
+	body := handler.DecisionRequest{
+		Approved:   approved,
+		ApprovedBy: c.userID,
+		Reason:     reason,
+	}
+
+	var raw json.RawMessage
+	path := c.gatewayURL + "/api/v1/proposals/" + url.PathEscape(proposalID) + "/decision"
+	status, err := c.doStatus(ctx, http.MethodPost, path, body, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var outcome DecisionOutcome
+	if status == http.StatusAccepted {
+		outcome.Pending = &handler.PartialApprovalResponse{}
+		err = json.Unmarshal(raw, outcome.Pending)
+	} else {
+		outcome.Decision = &handler.DecisionResponse{}
+		err = json.Unmarshal(raw, outcome.Decision)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode decision response: %w", err)
+	}
+	return &outcome, nil
+}
+
+// AuditQuery filters the audit trail
+type AuditQuery struct {
+	Limit      int
+	ActionType string
+	UserID     string
+	TrackID    string
+}
+
+// Audit returns audit entries, newest first
+func (c *Client) Audit(ctx context.Context, query AuditQuery) (*handler.AuditEntriesResponse, error) {
+	q := url.Values{}
+	if query.Limit > 0 {
+		q.Set("limit", strconv.Itoa(query.Limit))
+	}
+	if query.ActionType != "" {
+		q.Set("action_type", query.ActionType)
+	}
+	if query.UserID != "" {
+		q.Set("user_id", query.UserID)
+	}
+	if query.TrackID != "" {
+		q.Set("track_id", query.TrackID)
+	}
+
+	var resp handler.AuditEntriesResponse
+	if err := c.do(ctx, http.MethodGet, c.gatewayURL+"/api/v1/audit?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SensorConfig returns the sensor's current configuration
+func (c *Client) SensorConfig(ctx context.Context) (map[string]interface{}, error) {
+	var resp map[string]interface{}
+	if err := c.do(ctx, http.MethodGet, c.sensorURL+"/api/v1/config", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateSensorConfig patches the sensor's configuration and returns the result
+func (c *Client) UpdateSensorConfig(ctx context.Context, fields map[string]interface{}) (map[string]interface{}, error) {
+	var resp map[string]interface{}
+	if err := c.do(ctx, http.MethodPatch, c.sensorURL+"/api/v1/config", fields, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GatewayHealth is the gateway's /health response
+type GatewayHealth struct {
+	Status        string            `json:"status"`
+	Version       string            `json:"version"`
+	Uptime        string            `json:"uptime"`
+	Components    map[string]string `json:"components"`
+	SchemaVersion uint              `json:"schema_version"`
+}
+
+// PipelineHealth combines the gateway's health, agent reachability, and
+// per-stage processing metrics
+type PipelineHealth struct {
+	Gateway GatewayHealth                       `json:"gateway"`
+	Agents  []handler.AgentCapabilitiesResponse `json:"agents"`
+	Stages  []handler.StageMetricResponse       `json:"stages"`
+}
+
+// Healthy reports whether the gateway and every agent are healthy
+func (h *PipelineHealth) Healthy() bool {
+	if h.Gateway.Status != "healthy" {
+		return false
+	}
+	for _, a := range h.Agents {
+		if !a.Reachable {
+			return false
+		}
+	}
+	return true
+}
+
+// Health gathers pipeline health from the gateway. An unhealthy gateway still
+// answers with its component status, so it is not an error.
+func (c *Client) Health(ctx context.Context) (*PipelineHealth, error) {
+	var health PipelineHealth
+
+	if err := c.do(ctx, http.MethodGet, c.gatewayURL+"/health", nil, &health.Gateway); err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+			return nil, err
+		}
+	}
+
+	var agents handler.AgentListResponse
+	if err := c.do(ctx, http.MethodGet, c.gatewayURL+"/api/v1/agents", nil, &agents); err != nil {
+		return nil, err
+	}
+	health.Agents = agents.Agents
+
+	var stages handler.StageMetricsResponse
+	if err := c.do(ctx, http.MethodGet, c.gatewayURL+"/api/v1/metrics/stages", nil, &stages); err != nil {
+		return nil, err
+	}
+	health.Stages = stages.Stages
+
+	return &health, nil
+}
+
+// do sends a request and decodes a successful JSON response into out
+func (c *Client) do(ctx context.Context, method, rawURL string, body, out interface{}) error {
+	_, err := c.doStatus(ctx, method, rawURL, body, out)
+	return err
+}
+
+// doStatus sends a request, decodes a successful JSON response into out, and
+// returns the status code. Error responses are returned as *APIError.
+func (c *Client) doStatus(ctx context.Context, method, rawURL string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.userID != "" {
+		req.Header.Set(handler.UserIDHeader, c.userID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16*1024*1024))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		// The gateway's health check reports its components even when unhealthy
+		if out != nil && resp.StatusCode == http.StatusServiceUnavailable {
+			_ = json.Unmarshal(data, out)
+		}
+		return resp.StatusCode, &APIError{StatusCode: resp.StatusCode, Message: errorMessage(data)}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// errorMessage extracts the message from a gateway or agent error body,
+// normally problem+json
+func errorMessage(data []byte) string {
+	var body struct {
+		Detail  string `json:"detail"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil {
+		for _, msg := range []string{body.Detail, body.Title, body.Message, body.Error} {
+			if msg != "" {
+				return msg
+			}
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
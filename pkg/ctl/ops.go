@@ -0,0 +1,113 @@
+package ctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// ResolveStreams validates the streams to purge. all selects every pipeline
+// stream; protected streams are always refused.
+func ResolveStreams(names []string, all bool) ([]string, error) {
+	if all {
+		if len(names) > 0 {
+			return nil, fmt.Errorf("name streams or use --all, not both")
+		}
+		for name := range natsutil.PipelineConsumers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no streams selected")
+	}
+
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if _, ok := natsutil.StreamConfigs[name]; !ok {
+			return nil, fmt.Errorf("unknown stream %q", name)
+		}
+		if handler.ProtectedStreams[name] {
+			return nil, fmt.Errorf("stream %q is protected and cannot be purged", name)
+		}
+		resolved = append(resolved, name)
+	}
+	return resolved, nil
+}
+
+// PurgeStreams discards every message on the named streams and returns how
+// many each held. Consumers keep their positions, so agents continue with the
+// next message published.
+func PurgeStreams(ctx context.Context, js jetstream.JetStream, names []string) (map[string]uint64, error) {
+	purged := make(map[string]uint64, len(names))
+	for _, name := range names {
+		stream, err := js.Stream(ctx, name)
+		if err != nil {
+			return purged, fmt.Errorf("failed to get stream %s: %w", name, err)
+		}
+		info, err := stream.Info(ctx)
+		if err != nil {
+			return purged, fmt.Errorf("failed to get stream %s info: %w", name, err)
+		}
+		if err := stream.Purge(ctx); err != nil {
+			return purged, fmt.Errorf("failed to purge stream %s: %w", name, err)
+		}
+		purged[name] = info.State.Msgs
+	}
+	return purged, nil
+}
+
+// ParseConfigFields parses key=value arguments into a config patch. Values
+// are decoded as JSON where possible, so numbers, booleans, and objects keep
+// their types, and are otherwise sent as strings.
+func ParseConfigFields(args []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid setting %q: must be key=value", arg)
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		fields[key] = decoded
+	}
+	return fields, nil
+}
+
+// AuditFollower picks out audit entries not seen in earlier polls
+type AuditFollower struct {
+	seen map[string]bool
+}
+
+// NewAuditFollower creates a new AuditFollower
+func NewAuditFollower() *AuditFollower {
+	return &AuditFollower{seen: make(map[string]bool)}
+}
+
+// Next takes one poll of the audit trail, newest first, and returns the
+// entries not returned before, oldest first. Only the IDs in the latest poll
+// are remembered, since older entries fall out of the newest-first window.
+func (f *AuditFollower) Next(entries []handler.AuditEntryResponse) []handler.AuditEntryResponse {
+	var fresh []handler.AuditEntryResponse
+	seen := make(map[string]bool, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		seen[e.ID] = true
+		if !f.seen[e.ID] {
+			fresh = append(fresh, e)
+		}
+	}
+	f.seen = seen
+	return fresh
+}
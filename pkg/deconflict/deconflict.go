@@ -0,0 +1,152 @@
+// Package deconflict detects engagements that compete with each other.
+//
+// Two hostile tracks close together can each draw an engage or intercept
+// proposal. Approved together, the engagements may task the same asset twice
+// or put effects into the same airspace. Before a proposal is validated the
+// planner checks it against the engagements still in play, those pending
+// approval and those approved within the window whose effects may still be
+// in progress. A shared asset blocks the proposal through policy; overlapping
+// airspace is noted as a warning for the approver.
+package deconflict
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// DefaultRadiusMeters is how close two engaged tracks may be before their airspace overlaps
+const DefaultRadiusMeters = 10000.0
+
+// DefaultWindow is how long an approved engagement stays in play
+const DefaultWindow = 5 * time.Minute
+
+// Conflict kinds
+const (
+	KindAsset    = "asset"
+	KindAirspace = "airspace"
+)
+
+// engagements are the actions deconfliction applies to
+var engagements = map[string]bool{
+	"engage":    true,
+	"intercept": true,
+}
+
+// Config controls when engagements conflict
+type Config struct {
+	RadiusMeters float64       // Tracks this close share airspace; 0 disables airspace checks
+	Window       time.Duration // How long an approved engagement stays in play
+}
+
+// ParseConfig parses DECONFLICT_RADIUS_METERS and DECONFLICT_WINDOW, using
+// the defaults when unset
+func ParseConfig(radius, window string) (Config, error) {
+	cfg := Config{RadiusMeters: DefaultRadiusMeters, Window: DefaultWindow}
+	if radius != "" {
+		r, err := strconv.ParseFloat(strings.TrimSpace(radius), 64)
+		if err != nil || r < 0 {
+			return Config{}, fmt.Errorf("invalid DECONFLICT_RADIUS_METERS %q: must be a non-negative number", radius)
+		}
+		cfg.RadiusMeters = r
+	}
+	if window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("invalid DECONFLICT_WINDOW %q: must be a non-negative duration", window)
+		}
+		cfg.Window = d
+	}
+	return cfg, nil
+}
+
+// Engagement is an engage or intercept proposal still in play
+type Engagement struct {
+	ProposalID string            `json:"proposal_id"`
+	TrackID    string            `json:"track_id"`
+	ActionType string            `json:"action_type"`
+	Position   messages.Position `json:"position"`
+	AssetID    string            `json:"asset_id,omitempty"`
+	Status     string            `json:"status"` // pending or approved
+}
+
+// Applies reports whether deconfliction checks an action
+func Applies(actionType string) bool {
+	return engagements[actionType]
+}
+
+// FromProposal describes a proposal as an engagement
+func FromProposal(p *messages.ActionProposal) Engagement {
+	e := Engagement{
+		ProposalID: p.ProposalID,
+		TrackID:    p.TrackID,
+		ActionType: p.ActionType,
+		Status:     "pending",
+	}
+	if p.Track != nil {
+		e.Position = p.Track.Position
+	}
+	if p.Asset != nil {
+		e.AssetID = p.Asset.AssetID
+	}
+	return e
+}
+
+// Check returns the engagements a proposed one conflicts with. Engagements of
+// the same track are not conflicts; the authorizer merges them. A shared asset
+// is reported ahead of overlapping airspace for the same engagement.
+func Check(proposed Engagement, active []Engagement, cfg Config) []messages.ProposalConflict {
+	if !Applies(proposed.ActionType) {
+		return nil
+	}
+
+	var conflicts []messages.ProposalConflict
+	for _, e := range active {
+		if e.ProposalID == proposed.ProposalID || e.TrackID == proposed.TrackID || !Applies(e.ActionType) {
+			continue
+		}
+		distance := geo.Distance(proposed.Position, e.Position)
+		conflict := messages.ProposalConflict{
+			ProposalID:     e.ProposalID,
+			TrackID:        e.TrackID,
+			ActionType:     e.ActionType,
+			DistanceMeters: distance,
+		}
+		switch {
+		case proposed.AssetID != "" && e.AssetID == proposed.AssetID:
+			conflict.Kind = KindAsset
+			conflict.AssetID = e.AssetID
+		case cfg.RadiusMeters > 0 && distance <= cfg.RadiusMeters:
+			conflict.Kind = KindAirspace
+		default:
+			continue
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// Reserved returns the assets held by engagements of other tracks
+func Reserved(trackID string, active []Engagement) map[string]bool {
+	reserved := make(map[string]bool)
+	for _, e := range active {
+		if e.AssetID != "" && e.TrackID != trackID {
+			reserved[e.AssetID] = true
+		}
+	}
+	return reserved
+}
+
+// HasAssetConflict reports whether any conflict is over a shared asset
+func HasAssetConflict(conflicts []messages.ProposalConflict) bool {
+	for _, c := range conflicts {
+		if c.Kind == KindAsset {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package deconflict
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is the subset of pgx used to load engagements
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Active returns the engagements in play at now: engage and intercept
+// proposals pending approval, and those approved within the window
+func Active(ctx context.Context, q Querier, window time.Duration, now time.Time) ([]Engagement, error) {
+	rows, err := q.Query(ctx, `
+		SELECT proposal_id::text, track_id, action_type,
+			COALESCE((track_data->'position'->>'lat')::float8, 0),
+			COALESCE((track_data->'position'->>'lon')::float8, 0),
+			COALESCE(asset->>'asset_id', ''), status
+		FROM proposals
+		WHERE action_type = ANY($1)
+		  AND ((status = 'pending' AND expires_at > $2) OR (status = 'approved' AND updated_at > $3))
+	`, []string{"engage", "intercept"}, now, now.Add(-window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active engagements: %w", err)
+	}
+	defer rows.Close()
+
+	var active []Engagement
+	for rows.Next() {
+		var e Engagement
+		if err := rows.Scan(&e.ProposalID, &e.TrackID, &e.ActionType, &e.Position.Lat, &e.Position.Lon, &e.AssetID, &e.Status); err != nil {
+			return nil, fmt.Errorf("failed to scan engagement: %w", err)
+		}
+		active = append(active, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating engagements: %w", err)
+	}
+	return active, nil
+}
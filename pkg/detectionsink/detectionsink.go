@@ -0,0 +1,252 @@
+// Package detectionsink archives raw detections from the DETECTIONS stream to PostgreSQL.
+//
+// The gateway runs a durable pull consumer and fetches detections in batches
+// of up to BatchSize, waiting at most FlushInterval for a batch to fill. Each
+// batch is written with a single COPY, so the archive keeps up with high
+// emission rates. Messages are acknowledged only once their batch is stored;
+// a failed write is retried after RetryDelay. The consumer is an archive
+// consumer, so a lagging archive never throttles the sensor.
+package detectionsink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// Stream and durable consumer the sink reads
+const (
+	StreamName   = "DETECTIONS"
+	ConsumerName = "detection-persister"
+)
+
+// Defaults and limits for batching
+const (
+	DefaultBatchSize     = 500
+	MaxBatchSize         = 5000
+	DefaultFlushInterval = time.Second
+	RetryDelay           = 5 * time.Second
+)
+
+// Config controls detection archiving
+type Config struct {
+	Enabled       bool
+	BatchSize     int           // Detections written per COPY
+	FlushInterval time.Duration // Longest wait for a batch to fill
+}
+
+// DefaultConfig returns the default batching
+func DefaultConfig() Config {
+	return Config{Enabled: true, BatchSize: DefaultBatchSize, FlushInterval: DefaultFlushInterval}
+}
+
+// ParseConfig parses DETECTION_PERSISTENCE, DETECTION_BATCH_SIZE, and
+// DETECTION_FLUSH_INTERVAL, using the defaults for unset values
+func ParseConfig(enabled, batchSize, flushInterval string) (Config, error) {
+	cfg := DefaultConfig()
+	if enabled != "" {
+		b, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid DETECTION_PERSISTENCE %q: must be true or false", enabled)
+		}
+		cfg.Enabled = b
+	}
+	if batchSize != "" {
+		n, err := strconv.Atoi(batchSize)
+		if err != nil || n < 1 || n > MaxBatchSize {
+			return cfg, fmt.Errorf("invalid DETECTION_BATCH_SIZE %q: must be between 1 and %d", batchSize, MaxBatchSize)
+		}
+		cfg.BatchSize = n
+	}
+	if flushInterval != "" {
+		d, err := time.ParseDuration(flushInterval)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid DETECTION_FLUSH_INTERVAL %q: must be a positive duration", flushInterval)
+		}
+		cfg.FlushInterval = d
+	}
+	return cfg, nil
+}
+
+// Writer stores a batch of detections, returning how many rows were inserted
+type Writer interface {
+	BatchInsertDetections(ctx context.Context, detections []*messages.Detection) (int64, error)
+}
+
+// Sink moves detections from the DETECTIONS stream into a Writer
+type Sink struct {
+	cfg    Config
+	js     jetstream.JetStream
+	writer Writer
+	logger zerolog.Logger
+
+	persisted prometheus.Counter
+	skipped   prometheus.Counter
+	errors    prometheus.Counter
+	batchSize prometheus.Histogram
+	latency   prometheus.Histogram
+}
+
+// New creates a sink. js may be nil when only Flush is used.
+func New(cfg Config, js jetstream.JetStream, writer Writer, logger zerolog.Logger) *Sink {
+	return &Sink{
+		cfg:    cfg,
+		js:     js,
+		writer: writer,
+		logger: logger.With().Str("component", "detection_sink").Logger(),
+		persisted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_detections_persisted_total",
+			Help: "Detections written to PostgreSQL",
+		}),
+		skipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_detections_skipped_total",
+			Help: "Detections not written because they were malformed or already stored",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_detection_persist_errors_total",
+			Help: "Detection batches that failed to write and were retried",
+		}),
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cjadc2_api_detection_persist_batch_size",
+			Help:    "Detections per COPY batch",
+			Buckets: []float64{1, 10, 50, 100, 250, 500, 1000, 2500, 5000},
+		}),
+		latency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cjadc2_api_detection_persist_seconds",
+			Help:    "Time to write one detection batch",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}),
+	}
+}
+
+// Collectors returns the sink's metrics for registration
+func (s *Sink) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{s.persisted, s.skipped, s.errors, s.batchSize, s.latency}
+}
+
+// Run archives detections until ctx is done
+func (s *Sink) Run(ctx context.Context) error {
+	if !s.cfg.Enabled {
+		s.logger.Info().Msg("Detection persistence disabled")
+		return nil
+	}
+	if s.js == nil {
+		return fmt.Errorf("detection persistence requires JetStream")
+	}
+
+	if _, err := s.js.Stream(ctx, StreamName); err != nil {
+		if _, err := s.js.CreateStream(ctx, natsutil.StreamConfigs[StreamName]); err != nil {
+			return fmt.Errorf("failed to create %s stream: %w", StreamName, err)
+		}
+	}
+	consumer, err := natsutil.SetupConsumer(ctx, s.js, StreamName, ConsumerName)
+	if err != nil {
+		return fmt.Errorf("failed to setup detection consumer: %w", err)
+	}
+
+	s.logger.Info().
+		Int("batch_size", s.cfg.BatchSize).
+		Dur("flush_interval", s.cfg.FlushInterval).
+		Msg("Archiving detections to PostgreSQL")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		batch, err := consumer.Fetch(s.cfg.BatchSize, jetstream.FetchMaxWait(s.cfg.FlushInterval))
+		if err == nil {
+			var msgs []jetstream.Msg
+			for msg := range batch.Messages() {
+				msgs = append(msgs, msg)
+			}
+			s.Flush(ctx, msgs)
+			err = batch.Error()
+		}
+		if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, jetstream.ErrNoMessages) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// An exercise reset deletes the consumer along with its in-flight messages
+		if natsutil.ConsumerGone(err) {
+			if c, recreateErr := natsutil.SetupConsumer(ctx, s.js, StreamName, ConsumerName); recreateErr == nil {
+				consumer = c
+				s.logger.Info().Msg("Detection consumer recreated")
+				continue
+			}
+		}
+		s.logger.Warn().Err(err).Msg("Failed to fetch detections")
+		time.Sleep(time.Second)
+	}
+}
+
+// Flush writes one batch of messages and acknowledges them. Messages that are
+// not valid detections are terminated, leaving dead-lettering to the
+// classifier; if the write fails every detection is redelivered after
+// RetryDelay.
+func (s *Sink) Flush(ctx context.Context, msgs []jetstream.Msg) {
+	if len(msgs) == 0 {
+		return
+	}
+
+	detections := make([]*messages.Detection, 0, len(msgs))
+	pending := make([]jetstream.Msg, 0, len(msgs))
+	for _, msg := range msgs {
+		var d messages.Detection
+		contentType := msg.Headers().Get(messages.ContentTypeHeader)
+		if err := messages.ValidateEncoded(messages.SchemaDetection, contentType, msg.Data()); err != nil {
+			s.skipped.Inc()
+			msg.Term()
+			continue
+		}
+		if err := messages.Unmarshal(contentType, msg.Data(), &d); err != nil {
+			s.skipped.Inc()
+			msg.Term()
+			continue
+		}
+		detections = append(detections, &d)
+		pending = append(pending, msg)
+	}
+	if len(detections) == 0 {
+		return
+	}
+
+	start := time.Now()
+	inserted, err := s.writer.BatchInsertDetections(ctx, detections)
+	if err != nil {
+		s.errors.Inc()
+		s.logger.Error().Err(err).Int("detections", len(detections)).Msg("Failed to persist detections, retrying")
+		for _, msg := range pending {
+			msg.NakWithDelay(RetryDelay)
+		}
+		return
+	}
+	s.latency.Observe(time.Since(start).Seconds())
+	s.batchSize.Observe(float64(len(detections)))
+	s.persisted.Add(float64(inserted))
+	s.skipped.Add(float64(int64(len(detections)) - inserted))
+
+	for _, msg := range pending {
+		msg.Ack()
+	}
+
+	s.logger.Debug().
+		Int("batch", len(detections)).
+		Int64("inserted", inserted).
+		Dur("latency", time.Since(start)).
+		Msg("Persisted detections")
+}
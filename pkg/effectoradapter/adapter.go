@@ -0,0 +1,110 @@
+// Package effectoradapter is the SDK for plugging external effect systems, such
+// as C2 gateways and weapon or sensor simulators, into the effector agent.
+//
+// The effector owns the safety-critical parts of execution: it only acts on
+// human-approved decisions, re-checks them against OPA, fences execution with
+// its active/standby lease, claims an idempotency key in PostgreSQL, and writes
+// the result to the audit chain. An adapter only has to carry out the action
+// against its backend and report what happened.
+//
+// To add a backend, implement Adapter, register a Factory under a name with
+// Register (typically from an init function), and start the effector with
+// EFFECTOR_BACKEND set to that name. Environment variables prefixed with
+// EFFECTOR_BACKEND_ are passed to the factory as lower-cased config keys, for
+// example EFFECTOR_BACKEND_URL becomes "url". The adaptertest package checks
+// an adapter against the contract below.
+package effectoradapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Request asks an adapter to carry out one approved action
+type Request struct {
+	EffectID       string    `json:"effect_id"`
+	DecisionID     string    `json:"decision_id"`
+	ProposalID     string    `json:"proposal_id"`
+	TrackID        string    `json:"track_id"`
+	ActionType     string    `json:"action_type"`
+	ApprovedBy     string    `json:"approved_by"`
+	ApprovedAt     time.Time `json:"approved_at"`
+	Conditions     []string  `json:"conditions,omitempty"`
+	CorrelationID  string    `json:"correlation_id"`
+	IdempotencyKey string    `json:"idempotency_key"` // Stable across redeliveries of the same decision
+	FencingToken   uint64    `json:"fencing_token"`   // Backends that support fencing should reject older tokens
+}
+
+// Validate checks the fields every adapter relies on
+func (r Request) Validate() error {
+	switch {
+	case r.EffectID == "":
+		return fmt.Errorf("effect_id is required")
+	case r.DecisionID == "":
+		return fmt.Errorf("decision_id is required")
+	case r.ActionType == "":
+		return fmt.Errorf("action_type is required")
+	case r.IdempotencyKey == "":
+		return fmt.Errorf("idempotency_key is required")
+	}
+	return nil
+}
+
+// Result describes a completed action
+type Result struct {
+	Summary     string                 `json:"summary"`                // Human-readable outcome stored on the effect log
+	ExternalRef string                 `json:"external_ref,omitempty"` // The backend's identifier for the action, if any
+	Details     map[string]interface{} `json:"details,omitempty"`
+	Duration    time.Duration          `json:"duration"`
+	Outcome     string                 `json:"outcome,omitempty"`    // OutcomeSuccess, OutcomePartial, or OutcomeFailure; empty if the backend cannot tell
+	Collateral  bool                   `json:"collateral,omitempty"` // The action caused collateral damage
+}
+
+// Adapter executes approved actions against an external effect system.
+// Implementations must be safe for concurrent use.
+type Adapter interface {
+	// Name identifies the backend in logs and effect results
+	Name() string
+
+	// Supports reports whether the backend can carry out an action type
+	Supports(actionType string) bool
+
+	// Execute carries out the action, reporting progress as it goes. It must
+	// honour ctx cancellation. Executing the same IdempotencyKey twice must not
+	// repeat the action; see WithIdempotency.
+	Execute(ctx context.Context, req Request, progress Reporter) (Result, error)
+}
+
+// HealthChecker is implemented by adapters that can check their backend
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// ErrUnsupportedAction is returned when an adapter cannot carry out an action type
+var ErrUnsupportedAction = errors.New("action type not supported by backend")
+
+// permanentError marks a failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, for example a rejected target.
+// The effector records the effect as failed instead of redelivering the decision.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked Permanent.
+// ErrUnsupportedAction is always permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p) || errors.Is(err, ErrUnsupportedAction)
+}
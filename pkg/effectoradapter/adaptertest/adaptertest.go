@@ -0,0 +1,208 @@
+// Package adaptertest provides conformance tests for effectoradapter.Adapter
+// implementations. Call Run from a test in the adapter's own package:
+//
+//	func TestConformance(t *testing.T) {
+//		adaptertest.Run(t, func(t *testing.T) effectoradapter.Adapter {
+//			return mybackend.New(testConfig)
+//		}, adaptertest.Options{ActionTypes: []string{"track", "identify"}})
+//	}
+package adaptertest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/effectoradapter"
+)
+
+// Options configures the conformance run
+type Options struct {
+	// ActionTypes the backend supports; at least one is required
+	ActionTypes []string
+
+	// Timeout bounds a single Execute call (default 10s)
+	Timeout time.Duration
+}
+
+// Run checks that adapters built by newAdapter honour the Adapter contract:
+// they validate requests, report well-formed progress, execute each
+// idempotency key once, stop on cancellation, and refuse unsupported actions
+// with a permanent error.
+func Run(t *testing.T, newAdapter func(t *testing.T) effectoradapter.Adapter, opts Options) {
+	t.Helper()
+	require.NotEmpty(t, opts.ActionTypes, "adaptertest: Options.ActionTypes is required")
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	t.Run("Name", func(t *testing.T) {
+		assert.NotEmpty(t, newAdapter(t).Name())
+	})
+
+	t.Run("SupportsDeclaredActions", func(t *testing.T) {
+		adapter := newAdapter(t)
+		for _, actionType := range opts.ActionTypes {
+			assert.True(t, adapter.Supports(actionType), "adapter should support %s", actionType)
+		}
+	})
+
+	t.Run("RejectsInvalidRequest", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		defer cancel()
+
+		req := NewRequest(opts.ActionTypes[0])
+		req.IdempotencyKey = ""
+		_, err := newAdapter(t).Execute(ctx, req, effectoradapter.NopReporter)
+		require.Error(t, err)
+		assert.True(t, effectoradapter.IsPermanent(err), "invalid requests should fail permanently")
+	})
+
+	t.Run("ExecutesAndReportsProgress", func(t *testing.T) {
+		adapter := newAdapter(t)
+		for _, actionType := range opts.ActionTypes {
+			ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+			recorder := &Recorder{}
+
+			result, err := adapter.Execute(ctx, NewRequest(actionType), recorder)
+			cancel()
+			require.NoError(t, err, actionType)
+			assert.NotEmpty(t, result.Summary, actionType)
+
+			updates := recorder.Updates()
+			last := -1
+			for _, p := range updates {
+				assert.GreaterOrEqual(t, p.Percent, 0)
+				assert.LessOrEqual(t, p.Percent, 100)
+				assert.GreaterOrEqual(t, p.Percent, last, "progress should not go backwards")
+				assert.NotEqual(t, effectoradapter.StageFailed, p.Stage, "successful execution reported failure")
+				last = p.Percent
+			}
+			if len(updates) > 0 {
+				final := updates[len(updates)-1]
+				assert.Equal(t, effectoradapter.StageCompleted, final.Stage)
+				assert.Equal(t, 100, final.Percent)
+			}
+		}
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		adapter := newAdapter(t)
+		req := NewRequest(opts.ActionTypes[0])
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		defer cancel()
+		first, err := adapter.Execute(ctx, req, effectoradapter.NopReporter)
+		require.NoError(t, err)
+
+		// A redelivery carries a new effect ID but the same idempotency key
+		retry := req
+		retry.EffectID = uuid.New().String()
+		second, err := adapter.Execute(ctx, retry, effectoradapter.NopReporter)
+		require.NoError(t, err)
+		assert.Equal(t, first.Summary, second.Summary)
+		assert.Equal(t, first.ExternalRef, second.ExternalRef)
+	})
+
+	t.Run("ConcurrentDuplicates", func(t *testing.T) {
+		adapter := newAdapter(t)
+		req := NewRequest(opts.ActionTypes[0])
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		defer cancel()
+
+		const n = 8
+		results := make([]effectoradapter.Result, n)
+		errs := make([]error, n)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = adapter.Execute(ctx, req, effectoradapter.NopReporter)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, errs[i])
+			assert.Equal(t, results[0].ExternalRef, results[i].ExternalRef, "duplicate requests should share one execution")
+		}
+	})
+
+	t.Run("HonoursCancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := newAdapter(t).Execute(ctx, NewRequest(opts.ActionTypes[0]), effectoradapter.NopReporter)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			assert.Error(t, err, "execution with a cancelled context should fail")
+		case <-time.After(opts.Timeout):
+			t.Fatal("Execute did not return after cancellation")
+		}
+	})
+
+	t.Run("RefusesUnsupportedAction", func(t *testing.T) {
+		adapter := newAdapter(t)
+		const unsupported = "adaptertest-unsupported-action"
+		if adapter.Supports(unsupported) {
+			t.Skip("adapter accepts every action type")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+		defer cancel()
+		_, err := adapter.Execute(ctx, NewRequest(unsupported), effectoradapter.NopReporter)
+		require.Error(t, err)
+		assert.True(t, effectoradapter.IsPermanent(err), "unsupported actions should fail permanently")
+	})
+}
+
+// NewRequest builds a valid request for actionType with fresh IDs
+func NewRequest(actionType string) effectoradapter.Request {
+	decisionID := uuid.New().String()
+	proposalID := uuid.New().String()
+	return effectoradapter.Request{
+		EffectID:       uuid.New().String(),
+		DecisionID:     decisionID,
+		ProposalID:     proposalID,
+		TrackID:        "TRK-CONFORMANCE",
+		ActionType:     actionType,
+		ApprovedBy:     "conformance",
+		ApprovedAt:     time.Now().UTC(),
+		CorrelationID:  uuid.New().String(),
+		IdempotencyKey: effectoradapter.IdempotencyKey(decisionID, proposalID, actionType),
+		FencingToken:   1,
+	}
+}
+
+// Recorder is a Reporter that keeps every update
+type Recorder struct {
+	mu      sync.Mutex
+	updates []effectoradapter.Progress
+}
+
+// Report records p
+func (r *Recorder) Report(_ context.Context, p effectoradapter.Progress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updates = append(r.updates, p)
+	return nil
+}
+
+// Updates returns the recorded updates in order
+func (r *Recorder) Updates() []effectoradapter.Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]effectoradapter.Progress(nil), r.updates...)
+}
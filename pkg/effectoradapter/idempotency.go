@@ -0,0 +1,120 @@
+package effectoradapter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// IdempotencyKey derives the key the effector uses for a decision. It is the
+// same for every redelivery of the decision, so backends can use it to
+// deduplicate requests.
+func IdempotencyKey(decisionID, proposalID, actionType string) string {
+	return fmt.Sprintf("%s-%s-%s", decisionID, proposalID, actionType)
+}
+
+// Store remembers the results of completed requests by idempotency key
+type Store interface {
+	Get(ctx context.Context, key string) (Result, bool, error)
+	Put(ctx context.Context, key string, result Result) error
+}
+
+// MemoryStore is an in-process Store, suitable for simulators and tests
+type MemoryStore struct {
+	mu      sync.RWMutex
+	results map[string]Result
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{results: make(map[string]Result)}
+}
+
+// Get returns the stored result for key
+func (s *MemoryStore) Get(_ context.Context, key string) (Result, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result, ok := s.results[key]
+	return result, ok, nil
+}
+
+// Put stores the result for key
+func (s *MemoryStore) Put(_ context.Context, key string, result Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[key] = result
+	return nil
+}
+
+// WithIdempotency wraps an adapter so each idempotency key executes at most
+// once. Concurrent requests for the same key wait for the first to finish, and
+// later requests get its stored result. Failed executions are not stored, so
+// they can be retried.
+func WithIdempotency(adapter Adapter, store Store) Adapter {
+	return &idempotentAdapter{Adapter: adapter, store: store, locks: make(map[string]*keyLock)}
+}
+
+type idempotentAdapter struct {
+	Adapter
+	store Store
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Execute runs the wrapped adapter unless the key has already completed
+func (a *idempotentAdapter) Execute(ctx context.Context, req Request, progress Reporter) (Result, error) {
+	unlock := a.lock(req.IdempotencyKey)
+	defer unlock()
+
+	if result, ok, err := a.store.Get(ctx, req.IdempotencyKey); err != nil {
+		return Result{}, fmt.Errorf("failed to read idempotency store: %w", err)
+	} else if ok {
+		return result, nil
+	}
+
+	result, err := a.Adapter.Execute(ctx, req, progress)
+	if err != nil {
+		return result, err
+	}
+	if err := a.store.Put(ctx, req.IdempotencyKey, result); err != nil {
+		return result, fmt.Errorf("failed to record idempotent result: %w", err)
+	}
+	return result, nil
+}
+
+// lock serializes executions of one key and releases the lock entry when unused
+func (a *idempotentAdapter) lock(key string) func() {
+	a.mu.Lock()
+	l, ok := a.locks[key]
+	if !ok {
+		l = &keyLock{}
+		a.locks[key] = l
+	}
+	l.refs++
+	a.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		a.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(a.locks, key)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// Health forwards to the wrapped adapter when it checks its backend
+func (a *idempotentAdapter) Health(ctx context.Context) error {
+	if hc, ok := a.Adapter.(HealthChecker); ok {
+		return hc.Health(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package effectoradapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Outcomes of an executed action, reported on Result.Outcome
+const (
+	OutcomeSuccess = "success" // The action achieved its purpose
+	OutcomePartial = "partial" // The action was carried out but fell short, e.g. a damaged target
+	OutcomeFailure = "failure" // The action was carried out and missed
+)
+
+// OutcomeModel describes how the simulator's executions of one action type
+// turn out. The remainder after the success and partial probabilities is the
+// chance of a failure.
+type OutcomeModel struct {
+	SuccessProbability    float64 `json:"success_probability"`
+	PartialProbability    float64 `json:"partial_probability"`
+	DurationMs            int64   `json:"duration_ms"`        // Mean execution time
+	DurationStdDevMs      int64   `json:"duration_stddev_ms"` // Spread of execution times around the mean
+	CollateralProbability float64 `json:"collateral_probability"`
+	Neutralizes           bool    `json:"neutralizes"` // A success neutralizes the target, reported for battle damage assessment
+}
+
+// Validate checks the model's probabilities and durations
+func (m OutcomeModel) Validate() error {
+	for name, p := range map[string]float64{
+		"success_probability":    m.SuccessProbability,
+		"partial_probability":    m.PartialProbability,
+		"collateral_probability": m.CollateralProbability,
+	} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+	if m.SuccessProbability+m.PartialProbability > 1 {
+		return fmt.Errorf("success_probability and partial_probability together must not exceed 1")
+	}
+	if m.DurationMs < 0 || m.DurationStdDevMs < 0 {
+		return fmt.Errorf("duration_ms and duration_stddev_ms must not be negative")
+	}
+	return nil
+}
+
+// Draw is one simulated execution drawn from an OutcomeModel
+type Draw struct {
+	Outcome    string
+	Collateral bool
+	Duration   time.Duration
+}
+
+// Roll draws one execution from the model
+func (m OutcomeModel) Roll(rng *rand.Rand) Draw {
+	d := Draw{Outcome: OutcomeFailure}
+	switch roll := rng.Float64(); {
+	case roll < m.SuccessProbability:
+		d.Outcome = OutcomeSuccess
+	case roll < m.SuccessProbability+m.PartialProbability:
+		d.Outcome = OutcomePartial
+	}
+	d.Collateral = rng.Float64() < m.CollateralProbability
+
+	ms := float64(m.DurationMs) + rng.NormFloat64()*float64(m.DurationStdDevMs)
+	if ms < 0 {
+		ms = 0
+	}
+	d.Duration = time.Duration(ms * float64(time.Millisecond))
+	return d
+}
+
+// defaultOutcomeModel applies to action types without a model
+var defaultOutcomeModel = OutcomeModel{SuccessProbability: 1, DurationMs: 25}
+
+// DefaultOutcomeModels returns the simulator's models by action type. Kinetic
+// actions succeed at their kill probability, sometimes only damage the target,
+// and occasionally cause collateral damage; the others nearly always succeed.
+func DefaultOutcomeModels() map[string]OutcomeModel {
+	return map[string]OutcomeModel{
+		"engage":    {SuccessProbability: 0.8, PartialProbability: 0.1, DurationMs: 100, DurationStdDevMs: 25, CollateralProbability: 0.05, Neutralizes: true},
+		"intercept": {SuccessProbability: 0.9, PartialProbability: 0.05, DurationMs: 75, DurationStdDevMs: 20, CollateralProbability: 0.02, Neutralizes: true},
+		"identify":  {SuccessProbability: 0.95, PartialProbability: 0.05, DurationMs: 50, DurationStdDevMs: 10},
+		"track":     {SuccessProbability: 0.98, PartialProbability: 0.02, DurationMs: 25, DurationStdDevMs: 5},
+		"monitor":   {SuccessProbability: 1, DurationMs: 10, DurationStdDevMs: 2},
+	}
+}
+
+// ParseOutcomeModels parses a JSON object of outcome models keyed by action
+// type, as set in EFFECTOR_BACKEND_OUTCOMES. Models given replace the defaults
+// for their action types; an empty value keeps the defaults.
+func ParseOutcomeModels(value string) (map[string]OutcomeModel, error) {
+	models := DefaultOutcomeModels()
+	if strings.TrimSpace(value) == "" {
+		return models, nil
+	}
+
+	var overrides map[string]OutcomeModel
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("invalid outcome models: %w", err)
+	}
+	for actionType, model := range overrides {
+		if err := model.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid outcome model for %s: %w", actionType, err)
+		}
+		models[actionType] = model
+	}
+	return models, nil
+}
@@ -0,0 +1,97 @@
+package effectoradapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Progress stages
+const (
+	StageAccepted  = "accepted"
+	StageExecuting = "executing"
+	StageCompleted = "completed"
+	StageFailed    = "failed"
+)
+
+// Progress is one progress update from an adapter
+type Progress struct {
+	Stage   string `json:"stage"`
+	Percent int    `json:"percent"` // 0-100
+	Message string `json:"message,omitempty"`
+}
+
+// Reporter receives progress updates for one request. Reporting is best
+// effort: adapters should log a failed report and carry on executing.
+type Reporter interface {
+	Report(ctx context.Context, p Progress) error
+}
+
+// ReporterFunc adapts a function to the Reporter interface
+type ReporterFunc func(ctx context.Context, p Progress) error
+
+// Report calls f
+func (f ReporterFunc) Report(ctx context.Context, p Progress) error {
+	return f(ctx, p)
+}
+
+// NopReporter discards progress updates
+var NopReporter Reporter = ReporterFunc(func(context.Context, Progress) error { return nil })
+
+// Publisher is the subset of *nats.Conn used to publish progress
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// ProgressClient publishes progress updates as EffectProgress notifications,
+// which the gateway relays to the UI over WebSocket
+type ProgressClient struct {
+	publisher Publisher
+	source    string
+	backend   string
+}
+
+// NewProgressClient creates a progress client that publishes as source
+func NewProgressClient(publisher Publisher, source, backend string) *ProgressClient {
+	return &ProgressClient{publisher: publisher, source: source, backend: backend}
+}
+
+// For returns a Reporter bound to one request
+func (c *ProgressClient) For(req Request) Reporter {
+	return ReporterFunc(func(_ context.Context, p Progress) error {
+		return c.Publish(req, p)
+	})
+}
+
+// Publish sends one progress update for req
+func (c *ProgressClient) Publish(req Request, p Progress) error {
+	if p.Percent < 0 {
+		p.Percent = 0
+	} else if p.Percent > 100 {
+		p.Percent = 100
+	}
+
+	update := &messages.EffectProgress{
+		Envelope: messages.NewEnvelope(c.source, "effector").
+			WithCorrelation(req.CorrelationID, req.DecisionID),
+		EffectID:   req.EffectID,
+		DecisionID: req.DecisionID,
+		TrackID:    req.TrackID,
+		ActionType: req.ActionType,
+		Backend:    c.backend,
+		Stage:      p.Stage,
+		Percent:    p.Percent,
+		Message:    p.Message,
+	}
+
+	data, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to marshal effect progress: %w", err)
+	}
+	if err := c.publisher.Publish(update.Subject(), data); err != nil {
+		return fmt.Errorf("failed to publish effect progress: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package effectoradapter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory creates an adapter from backend configuration
+type Factory func(config map[string]string) (Adapter, error)
+
+// Registry maps backend names to adapter factories
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// Register adds a factory under name. Names must be unique.
+func (r *Registry) Register(name string, factory Factory) error {
+	if name == "" || factory == nil {
+		return fmt.Errorf("backend name and factory are required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("backend %q is already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// New creates the adapter registered under name
+func (r *Registry) New(name string, config map[string]string) (Adapter, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown effector backend %q (registered: %v)", name, r.Names())
+	}
+	adapter, err := factory(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create effector backend %q: %w", name, err)
+	}
+	return adapter, nil
+}
+
+// Names returns the registered backend names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultRegistry holds the backends available to the effector. The reference
+// simulator is always registered.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.Register(SimulatorName, NewSimulatorFromConfig)
+}
+
+// Register adds a factory to DefaultRegistry. It panics on a duplicate name so
+// conflicting backends fail at startup rather than silently replacing each other.
+func Register(name string, factory Factory) {
+	if err := DefaultRegistry.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// New creates an adapter from DefaultRegistry
+func New(name string, config map[string]string) (Adapter, error) {
+	return DefaultRegistry.New(name, config)
+}
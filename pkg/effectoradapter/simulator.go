@@ -0,0 +1,133 @@
+package effectoradapter
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/agile-defense/cjadc2/pkg/bda"
+	"github.com/agile-defense/cjadc2/pkg/simrand"
+)
+
+// SimulatorName is the backend name of the reference simulator
+const SimulatorName = "simulated"
+
+// Simulator is the reference adapter. It performs no real action: it draws
+// each execution's outcome, collateral damage, and duration from the action's
+// OutcomeModel, waits out the duration, reports progress, and describes what
+// it would have done. It doubles as an example for third-party adapters.
+type Simulator struct {
+	speedup float64 // Divides execution times; 1 is real time
+	models  map[string]OutcomeModel
+	rng     *rand.Rand
+}
+
+// NewSimulator creates a simulator with the default outcome models. A speedup
+// above 1 shortens execution times.
+func NewSimulator(speedup float64) *Simulator {
+	if speedup <= 0 {
+		speedup = 1
+	}
+	return &Simulator{
+		speedup: speedup,
+		models:  DefaultOutcomeModels(),
+		rng:     simrand.New(time.Now().UnixNano()),
+	}
+}
+
+// NewSimulatorFromConfig is the simulator's Factory. It accepts optional
+// "speedup", "outcomes" (JSON outcome models by action type), and "seed" keys.
+func NewSimulatorFromConfig(config map[string]string) (Adapter, error) {
+	speedup := 1.0
+	if v := config["speedup"]; v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			return nil, fmt.Errorf("invalid speedup %q", v)
+		}
+		speedup = f
+	}
+	models, err := ParseOutcomeModels(config["outcomes"])
+	if err != nil {
+		return nil, err
+	}
+
+	sim := NewSimulator(speedup)
+	sim.models = models
+	if v := config["seed"]; v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q", v)
+		}
+		sim.rng = simrand.New(seed)
+	}
+	return WithIdempotency(sim, NewMemoryStore()), nil
+}
+
+// Name returns SimulatorName
+func (s *Simulator) Name() string {
+	return SimulatorName
+}
+
+// Supports accepts every action type; unknown types use a default outcome model
+func (s *Simulator) Supports(string) bool {
+	return true
+}
+
+// Execute simulates the action
+func (s *Simulator) Execute(ctx context.Context, req Request, progress Reporter) (Result, error) {
+	if err := req.Validate(); err != nil {
+		return Result{}, Permanent(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	if progress == nil {
+		progress = NopReporter
+	}
+
+	model, ok := s.models[req.ActionType]
+	if !ok {
+		model = defaultOutcomeModel
+	}
+	draw := model.Roll(s.rng)
+	executionTime := time.Duration(float64(draw.Duration) / s.speedup)
+
+	progress.Report(ctx, Progress{Stage: StageAccepted, Percent: 0, Message: "SIMULATED: request accepted"})
+	progress.Report(ctx, Progress{Stage: StageExecuting, Percent: 50})
+
+	timer := time.NewTimer(executionTime)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		progress.Report(context.WithoutCancel(ctx), Progress{Stage: StageFailed, Percent: 50, Message: "cancelled"})
+		return Result{}, ctx.Err()
+	case <-timer.C:
+	}
+
+	summary := fmt.Sprintf("SIMULATED: Action '%s' executed against track '%s' with outcome %s. Approved by: %s. Execution time: %v",
+		req.ActionType, req.TrackID, draw.Outcome, req.ApprovedBy, executionTime)
+	if draw.Collateral {
+		summary += ". Collateral damage reported"
+	}
+	progress.Report(ctx, Progress{Stage: StageCompleted, Percent: 100, Message: summary})
+
+	result := Result{
+		Summary:     summary,
+		ExternalRef: "SIM-" + uuid.New().String()[:8],
+		Duration:    executionTime,
+		Outcome:     draw.Outcome,
+		Collateral:  draw.Collateral,
+	}
+	// The simulator knows whether it hit, so damage is reported rather than estimated
+	if model.Neutralizes {
+		result.Details = map[string]interface{}{
+			bda.DetailNeutralized:        draw.Outcome == OutcomeSuccess,
+			bda.DetailSuccessProbability: model.SuccessProbability,
+		}
+	}
+	return result, nil
+}
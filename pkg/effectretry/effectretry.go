@@ -0,0 +1,155 @@
+// Package effectretry bounds how long and how often the effector tries to
+// carry out an approved action.
+//
+// Each attempt runs under a timeout chosen by action type. An attempt that
+// fails transiently is retried after an exponentially growing backoff until
+// the attempt budget is spent, when the effect is recorded with the terminal
+// status failed_permanent. Failures an adapter marks permanent are recorded as
+// failed without being retried.
+package effectretry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/effectoradapter"
+)
+
+// StatusFailedPermanent is the effect status recorded once every attempt has failed
+const StatusFailedPermanent = "failed_permanent"
+
+// Defaults used when the corresponding setting is unset
+const (
+	DefaultTimeout     = 30 * time.Second
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = 15 * time.Second
+)
+
+// Policy is the effector's execution timeout and retry policy
+type Policy struct {
+	DefaultTimeout time.Duration
+	Timeouts       map[string]time.Duration // Per action type, overriding DefaultTimeout
+	MaxAttempts    int                      // Attempts per effect, including the first
+	Backoff        time.Duration            // Wait before the first retry; doubles for each later one
+	MaxBackoff     time.Duration
+}
+
+// DefaultPolicy returns the policy used when nothing is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		DefaultTimeout: DefaultTimeout,
+		MaxAttempts:    DefaultMaxAttempts,
+		Backoff:        DefaultBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+	}
+}
+
+// ParsePolicy builds a policy from EFFECT_TIMEOUT, EFFECT_MAX_ATTEMPTS,
+// EFFECT_RETRY_BACKOFF and EFFECT_RETRY_MAX_BACKOFF. EFFECT_TIMEOUT is a
+// comma-separated list of a default duration and action:duration overrides,
+// such as "30s,engage:10s,intercept:20s".
+func ParsePolicy(vars map[string]string) (Policy, error) {
+	p := DefaultPolicy()
+
+	if spec := strings.TrimSpace(vars["EFFECT_TIMEOUT"]); spec != "" {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			action, value, override := strings.Cut(part, ":")
+			if !override {
+				action, value = "", part
+			}
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return Policy{}, fmt.Errorf("invalid EFFECT_TIMEOUT %q: must be a positive duration or action:duration", part)
+			}
+			if !override {
+				p.DefaultTimeout = d
+				continue
+			}
+			if action == "" {
+				return Policy{}, fmt.Errorf("invalid EFFECT_TIMEOUT %q: action type is empty", part)
+			}
+			if p.Timeouts == nil {
+				p.Timeouts = make(map[string]time.Duration)
+			}
+			if _, dup := p.Timeouts[action]; dup {
+				return Policy{}, fmt.Errorf("invalid EFFECT_TIMEOUT: action %s listed twice", action)
+			}
+			p.Timeouts[action] = d
+		}
+	}
+
+	if v := strings.TrimSpace(vars["EFFECT_MAX_ATTEMPTS"]); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return Policy{}, fmt.Errorf("invalid EFFECT_MAX_ATTEMPTS %q: must be at least 1", v)
+		}
+		p.MaxAttempts = n
+	}
+
+	for _, setting := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"EFFECT_RETRY_BACKOFF", &p.Backoff},
+		{"EFFECT_RETRY_MAX_BACKOFF", &p.MaxBackoff},
+	} {
+		if v := strings.TrimSpace(vars[setting.env]); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return Policy{}, fmt.Errorf("invalid %s %q: must be a positive duration", setting.env, v)
+			}
+			*setting.dst = d
+		}
+	}
+	if p.MaxBackoff < p.Backoff {
+		return Policy{}, fmt.Errorf("invalid EFFECT_RETRY_MAX_BACKOFF %s: shorter than EFFECT_RETRY_BACKOFF %s", p.MaxBackoff, p.Backoff)
+	}
+
+	return p, nil
+}
+
+// Timeout returns how long one attempt at an action may run
+func (p Policy) Timeout(actionType string) time.Duration {
+	if d, ok := p.Timeouts[actionType]; ok {
+		return d
+	}
+	return p.DefaultTimeout
+}
+
+// Delay returns the backoff before retrying after the given attempt failed;
+// attempts are numbered from 1
+func (p Policy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// Retryable reports whether an effect whose given attempt failed with err
+// should be tried again
+func (p Policy) Retryable(attempt int, err error) bool {
+	return err != nil && !effectoradapter.IsPermanent(err) && attempt < p.MaxAttempts
+}
+
+// String formats the timeouts in EFFECT_TIMEOUT syntax
+func (p Policy) String() string {
+	parts := []string{p.DefaultTimeout.String()}
+	actions := make([]string, 0, len(p.Timeouts))
+	for action := range p.Timeouts {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		parts = append(parts, action+":"+p.Timeouts[action].String())
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,123 @@
+// Package federation shares tracks between the CJADC2 instances of coalition
+// partners, each running in its own enclave. A bridge exports the tracks of
+// its enclave that are releasable to a partner onto the partner's NATS, where
+// the partner's correlator fuses them with its own. Every federated message
+// carries the enclaves it passed through, so no track is exported back to an
+// enclave it came from.
+package federation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// Reasons a bridge does not export a track
+const (
+	SkipLoop           = "loop"           // The track came from the partner or through it
+	SkipReturned       = "returned"       // The track already left this enclave and came back
+	SkipNotReleasable  = "not_releasable" // The track is not releasable to the partner
+	SkipClassification = "classification" // The track is classified above what the bridge exports
+)
+
+// Config describes a bridge from this enclave to one partner
+type Config struct {
+	Enclave           string // This enclave's code, e.g. USA
+	Partner           string // The partner's releasability code, e.g. GBR
+	RemoteURL         string // The partner's NATS
+	MaxClassification string // Highest classification exported
+}
+
+// ConfigFromVars parses FEDERATION_ENCLAVE, FEDERATION_PARTNER,
+// FEDERATION_REMOTE_NATS_URL, and FEDERATION_MAX_CLASSIFICATION from agent
+// config variables. Only unclassified tracks are exported unless
+// FEDERATION_MAX_CLASSIFICATION allows more.
+func ConfigFromVars(vars map[string]string) (Config, error) {
+	cfg := Config{RemoteURL: strings.TrimSpace(vars["FEDERATION_REMOTE_NATS_URL"])}
+
+	for name, dst := range map[string]*string{
+		"FEDERATION_ENCLAVE": &cfg.Enclave,
+		"FEDERATION_PARTNER": &cfg.Partner,
+	} {
+		tags, err := seclabel.ParseReleasability(vars[name])
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if len(tags) != 1 {
+			return cfg, fmt.Errorf("%s must name one enclave, such as USA or GBR", name)
+		}
+		*dst = tags[0]
+	}
+	if cfg.Enclave == cfg.Partner {
+		return cfg, fmt.Errorf("FEDERATION_PARTNER must differ from FEDERATION_ENCLAVE %s", cfg.Enclave)
+	}
+	if cfg.RemoteURL == "" {
+		return cfg, fmt.Errorf("FEDERATION_REMOTE_NATS_URL is required")
+	}
+
+	label, err := seclabel.ParseClassification(vars["FEDERATION_MAX_CLASSIFICATION"])
+	if err != nil {
+		return cfg, fmt.Errorf("invalid FEDERATION_MAX_CLASSIFICATION: %w", err)
+	}
+	cfg.MaxClassification = label
+	return cfg, nil
+}
+
+// Export decides whether a track may be sent to the partner, returning the
+// reason when it may not
+func (c Config) Export(env messages.Envelope) (bool, string) {
+	for _, origin := range env.Origins {
+		switch origin {
+		case c.Partner:
+			return false, SkipLoop
+		case c.Enclave:
+			return false, SkipReturned
+		}
+	}
+	if seclabel.Level(env.Classification) > seclabel.Level(c.MaxClassification) {
+		return false, SkipClassification
+	}
+	partner := seclabel.Access{Releasability: []string{c.Partner}}
+	if !partner.Permits(env.Classification, env.Releasability) {
+		return false, SkipNotReleasable
+	}
+	return true, ""
+}
+
+// Stamp prepares a track for the partner. The enclave is added to the
+// envelope's origins, and a track reported in this enclave has its ID
+// prefixed with the enclave so it cannot collide with the partner's own
+// sensor track IDs. The message ID is kept, tying the copy to the original.
+func (c Config) Stamp(track *messages.Track) {
+	if !track.Envelope.Federated() {
+		track.TrackID = c.Enclave + ":" + track.TrackID
+	}
+	track.Envelope.Origins = append(append([]string(nil), track.Envelope.Origins...), c.Enclave)
+}
+
+// Origin returns the enclave a message was first reported in, empty for this
+// enclave
+func Origin(env messages.Envelope) string {
+	if len(env.Origins) == 0 {
+		return ""
+	}
+	return env.Origins[0]
+}
+
+// Conflicts returns the fields on which two reports of the same object from
+// different enclaves disagree; reports from the same enclave never conflict
+func Conflicts(a, b *messages.Track) []string {
+	if Origin(a.Envelope) == Origin(b.Envelope) {
+		return nil
+	}
+	var fields []string
+	if a.Classification != b.Classification {
+		fields = append(fields, "classification")
+	}
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	return fields
+}
@@ -0,0 +1,200 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Backend names
+const (
+	BackendAuto      = "auto"      // PostGIS when the extension is installed, otherwise spherical
+	BackendSpherical = "spherical" // Pure-Go great-circle math
+	BackendPostGIS   = "postgis"   // Evaluate in PostgreSQL with the PostGIS extension
+)
+
+// Backend performs the spatial operations shared by zones, asset coverage,
+// and track queries. Spherical is the pure-Go default; PostGIS evaluates the
+// same operations in the database when the extension is available.
+type Backend interface {
+	// Name identifies the backend in logs and health output
+	Name() string
+	// Distance returns the great-circle distance between two points in meters
+	Distance(ctx context.Context, a, b Point) (float64, error)
+	// Contains reports whether a point lies inside a zone
+	Contains(ctx context.Context, z Zone, p Point) (bool, error)
+	// BBoxPredicate returns a SQL condition selecting rows whose latitude and
+	// longitude columns fall inside the box, with placeholders numbered from argNum
+	BBoxPredicate(latCol, lonCol string, box BBox, argNum int) (string, []interface{})
+}
+
+// Distance returns the great-circle distance between two positions in meters
+func Distance(p1, p2 messages.Position) float64 {
+	lat1 := p1.Lat * math.Pi / 180
+	lat2 := p2.Lat * math.Pi / 180
+	dLat := (p2.Lat - p1.Lat) * math.Pi / 180
+	dLon := (p2.Lon - p1.Lon) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// InitialBearing returns the bearing in degrees true from p1 to p2
+func InitialBearing(p1, p2 messages.Position) float64 {
+	lat1 := p1.Lat * math.Pi / 180
+	lat2 := p2.Lat * math.Pi / 180
+	dLon := (p2.Lon - p1.Lon) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
+// Position converts a point to a message position
+func (p Point) Position() messages.Position {
+	return messages.Position{Lat: p.Lat, Lon: p.Lon}
+}
+
+// Contains reports whether a point lies inside the zone
+func (z Zone) Contains(p Point) bool {
+	switch z.Shape {
+	case ShapeCircle:
+		return Distance(z.Center.Position(), p.Position()) <= z.RadiusMeters
+	case ShapePolygon:
+		origin := p.Position()
+		verts := make([]vec, len(z.Polygon))
+		for i, v := range z.Polygon {
+			verts[i] = project(origin, v)
+		}
+		return pointInPolygon(vec{}, verts)
+	}
+	return false
+}
+
+// BBox is a latitude/longitude bounding box. A box whose MinLon is greater
+// than its MaxLon crosses the antimeridian.
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// ParseBBox parses "minLon,minLat,maxLon,maxLat", the GeoJSON bbox order
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, fmt.Errorf("bbox value %q is not a number", part)
+		}
+		v[i] = f
+	}
+	box := BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if err := box.Validate(); err != nil {
+		return BBox{}, err
+	}
+	return box, nil
+}
+
+// Validate checks that the box lies on the globe and its latitudes are ordered
+func (b BBox) Validate() error {
+	if !validPoint(Point{Lat: b.MinLat, Lon: b.MinLon}) || !validPoint(Point{Lat: b.MaxLat, Lon: b.MaxLon}) {
+		return fmt.Errorf("bbox is out of range")
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("bbox min_lat must not exceed max_lat")
+	}
+	return nil
+}
+
+// CrossesAntimeridian reports whether the box wraps from 180 to -180 degrees longitude
+func (b BBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+// Contains reports whether a point lies inside the box, edges included
+func (b BBox) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return p.Lon >= b.MinLon || p.Lon <= b.MaxLon
+	}
+	return p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// Spherical computes everything in process on a spherical earth
+type Spherical struct{}
+
+// Name returns the backend name
+func (Spherical) Name() string { return BackendSpherical }
+
+// Distance returns the great-circle distance between two points in meters
+func (Spherical) Distance(_ context.Context, a, b Point) (float64, error) {
+	return Distance(a.Position(), b.Position()), nil
+}
+
+// Contains reports whether a point lies inside a zone
+func (Spherical) Contains(_ context.Context, z Zone, p Point) (bool, error) {
+	return z.Contains(p), nil
+}
+
+// BBoxPredicate compares the columns against the box bounds directly
+func (Spherical) BBoxPredicate(latCol, lonCol string, box BBox, argNum int) (string, []interface{}) {
+	lonOp := "AND"
+	if box.CrossesAntimeridian() {
+		lonOp = "OR"
+	}
+	clause := fmt.Sprintf("(%s BETWEEN $%d AND $%d AND (%s >= $%d %s %s <= $%d))",
+		latCol, argNum, argNum+1, lonCol, argNum+2, lonOp, lonCol, argNum+3)
+	return clause, []interface{}{box.MinLat, box.MaxLat, box.MinLon, box.MaxLon}
+}
+
+// Select returns the backend for a mode. Auto picks PostGIS when the database
+// has the extension and falls back to spherical otherwise, including when
+// there is no database.
+func Select(ctx context.Context, db Querier, mode string) (Backend, error) {
+	switch mode {
+	case "", BackendAuto:
+		if db == nil {
+			return Spherical{}, nil
+		}
+		ok, err := PostGISAvailable(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return NewPostGIS(db), nil
+		}
+		return Spherical{}, nil
+	case BackendSpherical:
+		return Spherical{}, nil
+	case BackendPostGIS:
+		if db == nil {
+			return nil, fmt.Errorf("geo backend %s requires a database", BackendPostGIS)
+		}
+		ok, err := PostGISAvailable(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("geo backend %s requested but the postgis extension is not installed", BackendPostGIS)
+		}
+		return NewPostGIS(db), nil
+	default:
+		return nil, fmt.Errorf("unknown geo backend %q: must be %s, %s, or %s", mode, BackendAuto, BackendSpherical, BackendPostGIS)
+	}
+}
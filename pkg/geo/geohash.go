@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// geohashAlphabet is the base-32 alphabet geohashes are written in
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeohashPrecision is the longest geohash handled, cells of about 1.2 km
+const MaxGeohashPrecision = 6
+
+// Geohash encodes a position as a geohash of precision characters
+func Geohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+	var b strings.Builder
+	bits, ch, even := 0, 0, true
+	for b.Len() < precision {
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch <<= 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch <<= 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			b.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// ValidateGeohash checks that cell is a geohash no longer than MaxGeohashPrecision
+func ValidateGeohash(cell string) error {
+	if cell == "" || len(cell) > MaxGeohashPrecision {
+		return fmt.Errorf("geohash %q must have 1 to %d characters", cell, MaxGeohashPrecision)
+	}
+	for _, c := range cell {
+		if !strings.ContainsRune(geohashAlphabet, c) {
+			return fmt.Errorf("geohash %q has invalid character %q", cell, c)
+		}
+	}
+	return nil
+}
+
+// GeohashBox returns the area a geohash cell covers. The cell must be valid.
+func GeohashBox(cell string) BBox {
+	box := BBox{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	even := true
+	for i := 0; i < len(cell); i++ {
+		v := strings.IndexByte(geohashAlphabet, cell[i])
+		for bit := 4; bit >= 0; bit-- {
+			set := v>>bit&1 == 1
+			if even {
+				mid := (box.MinLon + box.MaxLon) / 2
+				if set {
+					box.MinLon = mid
+				} else {
+					box.MaxLon = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if set {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return box
+}
+
+// GeohashNeighbors returns the cells of the same precision around cell,
+// wrapping across the antimeridian. Cells beyond a pole are omitted.
+func GeohashNeighbors(cell string) []string {
+	box := GeohashBox(cell)
+	dLat, dLon := box.MaxLat-box.MinLat, box.MaxLon-box.MinLon
+	lat, lon := (box.MinLat+box.MaxLat)/2, (box.MinLon+box.MaxLon)/2
+
+	var neighbors []string
+	for _, dy := range []float64{-1, 0, 1} {
+		for _, dx := range []float64{-1, 0, 1} {
+			if dx == 0 && dy == 0 {
+				continue
+			}
+			nLat := lat + dy*dLat
+			if nLat < -90 || nLat > 90 {
+				continue
+			}
+			nLon := math.Mod(lon+dx*dLon+540, 360) - 180
+			neighbors = append(neighbors, Geohash(nLat, nLon, len(cell)))
+		}
+	}
+	return neighbors
+}
+
+// DistanceToBox returns the distance in meters from a position to the
+// nearest point of a box that does not cross the antimeridian, zero inside it
+func DistanceToBox(p messages.Position, box BBox) float64 {
+	nearest := messages.Position{
+		Lat: math.Max(box.MinLat, math.Min(box.MaxLat, p.Lat)),
+		Lon: math.Max(box.MinLon, math.Min(box.MaxLon, p.Lon)),
+	}
+	return Distance(messages.Position{Lat: p.Lat, Lon: p.Lon}, nearest)
+}
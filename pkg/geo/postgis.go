@@ -0,0 +1,116 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Querier is the part of a pgx pool or connection the PostGIS backend uses
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// PostGIS evaluates spatial operations in PostgreSQL. Distances use the
+// geography type so they agree with the spherical backend to within the
+// difference between the sphere and the WGS84 spheroid.
+type PostGIS struct {
+	db Querier
+}
+
+// NewPostGIS creates a backend that queries db
+func NewPostGIS(db Querier) *PostGIS {
+	return &PostGIS{db: db}
+}
+
+// PostGISAvailable reports whether the postgis extension is installed in the database
+func PostGISAvailable(ctx context.Context, db Querier) (bool, error) {
+	var version string
+	err := db.QueryRow(ctx, `SELECT extversion FROM pg_extension WHERE extname = 'postgis'`).Scan(&version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check for postgis: %w", err)
+	}
+	return true, nil
+}
+
+// Name returns the backend name
+func (b *PostGIS) Name() string { return BackendPostGIS }
+
+// Distance returns the geodesic distance between two points in meters
+func (b *PostGIS) Distance(ctx context.Context, p1, p2 Point) (float64, error) {
+	var d float64
+	err := b.db.QueryRow(ctx, `
+		SELECT ST_Distance(
+			ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
+			ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography)`,
+		p1.Lon, p1.Lat, p2.Lon, p2.Lat,
+	).Scan(&d)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute distance: %w", err)
+	}
+	return d, nil
+}
+
+// Contains reports whether a point lies inside a zone
+func (b *PostGIS) Contains(ctx context.Context, z Zone, p Point) (bool, error) {
+	var inside bool
+	var err error
+	switch z.Shape {
+	case ShapeCircle:
+		err = b.db.QueryRow(ctx, `
+			SELECT ST_DWithin(
+				ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
+				ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography,
+				$5)`,
+			z.Center.Lon, z.Center.Lat, p.Lon, p.Lat, z.RadiusMeters,
+		).Scan(&inside)
+	case ShapePolygon:
+		err = b.db.QueryRow(ctx, `
+			SELECT ST_Contains(ST_GeomFromText($1, 4326), ST_SetSRID(ST_MakePoint($2, $3), 4326))`,
+			polygonWKT(z.Polygon), p.Lon, p.Lat,
+		).Scan(&inside)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to test zone %q containment: %w", z.Name, err)
+	}
+	return inside, nil
+}
+
+// BBoxPredicate intersects a point built from the columns with an envelope,
+// splitting boxes that cross the antimeridian into two
+func (b *PostGIS) BBoxPredicate(latCol, lonCol string, box BBox, argNum int) (string, []interface{}) {
+	point := fmt.Sprintf("ST_SetSRID(ST_MakePoint(%s, %s), 4326)", lonCol, latCol)
+	envelope := func(n int) string {
+		return fmt.Sprintf("ST_MakeEnvelope($%d, $%d, $%d, $%d, 4326)", n, n+1, n+2, n+3)
+	}
+	if !box.CrossesAntimeridian() {
+		return fmt.Sprintf("ST_Intersects(%s, %s)", point, envelope(argNum)),
+			[]interface{}{box.MinLon, box.MinLat, box.MaxLon, box.MaxLat}
+	}
+	clause := fmt.Sprintf("(ST_Intersects(%s, %s) OR ST_Intersects(%s, %s))",
+		point, envelope(argNum), point, envelope(argNum+4))
+	return clause, []interface{}{
+		box.MinLon, box.MinLat, 180.0, box.MaxLat,
+		-180.0, box.MinLat, box.MaxLon, box.MaxLat,
+	}
+}
+
+// polygonWKT renders polygon vertices as a closed WKT ring
+func polygonWKT(verts []Point) string {
+	coords := make([]string, 0, len(verts)+1)
+	for _, v := range verts {
+		coords = append(coords, fmt.Sprintf("%g %g", v.Lon, v.Lat))
+	}
+	if len(verts) > 0 && verts[0] != verts[len(verts)-1] {
+		coords = append(coords, coords[0])
+	}
+	return "POLYGON((" + strings.Join(coords, ", ") + "))"
+}
@@ -0,0 +1,288 @@
+// Package geo provides the protected-asset and restricted-zone model shared across agents,
+// along with closest-point-of-approach and time-to-zone calculations for moving tracks.
+//
+// Distance, bearing, containment, and bounding-box math lives here so agents do not
+// carry their own. A Backend runs the same operations either in process (Spherical)
+// or in PostgreSQL when the PostGIS extension is installed.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Zone kinds
+const (
+	KindProtectedAsset = "protected_asset"
+	KindRestrictedZone = "restricted_zone"
+)
+
+// Zone shapes
+const (
+	ShapeCircle  = "circle"
+	ShapePolygon = "polygon"
+)
+
+const earthRadius = 6371000 // meters
+
+// Point is a geographic coordinate
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Zone is a named protected asset or restricted area.
+// Circles use Center and RadiusMeters; polygons use Polygon vertices in order.
+type Zone struct {
+	ZoneID       string  `json:"zone_id"`
+	Name         string  `json:"name"`
+	Kind         string  `json:"kind"`
+	Shape        string  `json:"shape"`
+	Center       Point   `json:"center"`
+	RadiusMeters float64 `json:"radius_meters"`
+	Polygon      []Point `json:"polygon,omitempty"`
+	Priority     int     `json:"priority"`
+	Enabled      bool    `json:"enabled"`
+}
+
+// Validate checks that a zone is well-formed
+func (z Zone) Validate() error {
+	if z.Name == "" {
+		return fmt.Errorf("zone name is required")
+	}
+	if z.Kind != KindProtectedAsset && z.Kind != KindRestrictedZone {
+		return fmt.Errorf("zone %q: kind must be %s or %s", z.Name, KindProtectedAsset, KindRestrictedZone)
+	}
+	switch z.Shape {
+	case ShapeCircle:
+		if z.RadiusMeters < 0 {
+			return fmt.Errorf("zone %q: radius_meters must not be negative", z.Name)
+		}
+		if !validPoint(z.Center) {
+			return fmt.Errorf("zone %q: center is out of range", z.Name)
+		}
+	case ShapePolygon:
+		if len(z.Polygon) < 3 {
+			return fmt.Errorf("zone %q: polygon requires at least 3 vertices", z.Name)
+		}
+		for _, p := range z.Polygon {
+			if !validPoint(p) {
+				return fmt.Errorf("zone %q: polygon vertex is out of range", z.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("zone %q: shape must be %s or %s", z.Name, ShapeCircle, ShapePolygon)
+	}
+	return nil
+}
+
+// vec is a local east/north offset in meters
+type vec struct {
+	x, y float64
+}
+
+func (a vec) sub(b vec) vec       { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) dot(b vec) float64   { return a.x*b.x + a.y*b.y }
+func (a vec) cross(b vec) float64 { return a.x*b.y - a.y*b.x }
+func (a vec) norm() float64       { return math.Hypot(a.x, a.y) }
+func (a vec) scale(k float64) vec { return vec{a.x * k, a.y * k} }
+func (a vec) add(b vec) vec       { return vec{a.x + b.x, a.y + b.y} }
+
+// project converts a point to a local tangent-plane offset from origin.
+// Accurate enough for the few-hundred-kilometre ranges used in threat assessment.
+func project(origin messages.Position, p Point) vec {
+	lat0 := origin.Lat * math.Pi / 180
+	return vec{
+		x: (p.Lon - origin.Lon) * math.Pi / 180 * earthRadius * math.Cos(lat0),
+		y: (p.Lat - origin.Lat) * math.Pi / 180 * earthRadius,
+	}
+}
+
+// velocityVector converts speed/heading to an east/north velocity in m/s
+func velocityVector(v messages.Velocity) vec {
+	h := v.Heading * math.Pi / 180
+	return vec{x: v.Speed * math.Sin(h), y: v.Speed * math.Cos(h)}
+}
+
+// Assess computes the track's proximity to a zone assuming constant velocity
+func Assess(z Zone, pos messages.Position, vel messages.Velocity) messages.ZoneProximity {
+	prox := messages.ZoneProximity{
+		ZoneID:   z.ZoneID,
+		ZoneName: z.Name,
+		ZoneKind: z.Kind,
+	}
+	v := velocityVector(vel)
+
+	switch z.Shape {
+	case ShapeCircle:
+		assessCircle(&prox, z, pos, v)
+	case ShapePolygon:
+		assessPolygon(&prox, z, pos, v)
+	}
+	return prox
+}
+
+// AssessAll evaluates every enabled zone and returns results ordered by urgency:
+// zones the track is inside, then soonest entry, then smallest closest approach
+func AssessAll(zones []Zone, pos messages.Position, vel messages.Velocity) []messages.ZoneProximity {
+	results := make([]messages.ZoneProximity, 0, len(zones))
+	for _, z := range zones {
+		if !z.Enabled {
+			continue
+		}
+		results = append(results, Assess(z, pos, vel))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Inside != b.Inside {
+			return a.Inside
+		}
+		if (a.TimeToZoneSeconds != nil) != (b.TimeToZoneSeconds != nil) {
+			return a.TimeToZoneSeconds != nil
+		}
+		if a.TimeToZoneSeconds != nil && *a.TimeToZoneSeconds != *b.TimeToZoneSeconds {
+			return *a.TimeToZoneSeconds < *b.TimeToZoneSeconds
+		}
+		return a.CPAMeters < b.CPAMeters
+	})
+	return results
+}
+
+func assessCircle(prox *messages.ZoneProximity, z Zone, pos messages.Position, v vec) {
+	// Track sits at the origin; c is the zone centre relative to it
+	c := project(pos, z.Center)
+	dist := c.norm()
+	prox.DistanceMeters = math.Max(0, dist-z.RadiusMeters)
+
+	if dist <= z.RadiusMeters {
+		prox.Inside = true
+		prox.TimeToZoneSeconds = floatPtr(0)
+		return
+	}
+
+	vv := v.dot(v)
+	if vv == 0 {
+		prox.CPAMeters = prox.DistanceMeters
+		return
+	}
+
+	tCPA := math.Max(0, c.dot(v)/vv)
+	prox.TimeToCPASeconds = tCPA
+	prox.CPAMeters = math.Max(0, c.sub(v.scale(tCPA)).norm()-z.RadiusMeters)
+
+	// Solve |c - v t| = r for the first non-negative t
+	b := c.dot(v)
+	disc := b*b - vv*(c.dot(c)-z.RadiusMeters*z.RadiusMeters)
+	if disc < 0 {
+		return
+	}
+	t := (b - math.Sqrt(disc)) / vv
+	if t >= 0 {
+		prox.TimeToZoneSeconds = floatPtr(t)
+	}
+}
+
+func assessPolygon(prox *messages.ZoneProximity, z Zone, pos messages.Position, v vec) {
+	verts := make([]vec, len(z.Polygon))
+	for i, p := range z.Polygon {
+		verts[i] = project(pos, p)
+	}
+
+	origin := vec{}
+	prox.DistanceMeters = distanceToPolygon(origin, verts)
+	if pointInPolygon(origin, verts) {
+		prox.Inside = true
+		prox.DistanceMeters = 0
+		prox.TimeToZoneSeconds = floatPtr(0)
+		return
+	}
+
+	vv := v.dot(v)
+	if vv == 0 {
+		prox.CPAMeters = prox.DistanceMeters
+		return
+	}
+
+	// Earliest crossing of the course ray with any edge
+	var entry *float64
+	for i := range verts {
+		a, b := verts[i], verts[(i+1)%len(verts)]
+		if t, ok := raySegmentIntersect(origin, v, a, b); ok && (entry == nil || t < *entry) {
+			entry = floatPtr(t)
+		}
+	}
+	if entry != nil {
+		prox.TimeToZoneSeconds = entry
+		prox.TimeToCPASeconds = *entry
+		prox.CPAMeters = 0
+		return
+	}
+
+	// Course misses the polygon: the closest approach between the ray and an
+	// edge is at the ray origin or at one of the edge endpoints
+	prox.CPAMeters = prox.DistanceMeters
+	for _, p := range verts {
+		t := math.Max(0, p.dot(v)/vv)
+		if d := p.sub(v.scale(t)).norm(); d < prox.CPAMeters {
+			prox.CPAMeters = d
+			prox.TimeToCPASeconds = t
+		}
+	}
+}
+
+// pointInPolygon uses ray casting to test containment
+func pointInPolygon(p vec, verts []vec) bool {
+	inside := false
+	for i, j := 0, len(verts)-1; i < len(verts); j, i = i, i+1 {
+		a, b := verts[i], verts[j]
+		if (a.y > p.y) != (b.y > p.y) && p.x < (b.x-a.x)*(p.y-a.y)/(b.y-a.y)+a.x {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// distanceToPolygon returns the distance from p to the nearest polygon edge
+func distanceToPolygon(p vec, verts []vec) float64 {
+	best := math.Inf(1)
+	for i := range verts {
+		a, b := verts[i], verts[(i+1)%len(verts)]
+		ab := b.sub(a)
+		t := 0.0
+		if l := ab.dot(ab); l > 0 {
+			t = math.Max(0, math.Min(1, p.sub(a).dot(ab)/l))
+		}
+		if d := p.sub(a.add(ab.scale(t))).norm(); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// raySegmentIntersect returns the time t >= 0 at which origin + v*t crosses segment ab
+func raySegmentIntersect(origin, v, a, b vec) (float64, bool) {
+	ab := b.sub(a)
+	denom := v.cross(ab)
+	if denom == 0 {
+		return 0, false
+	}
+	ao := a.sub(origin)
+	t := ao.cross(ab) / denom
+	u := ao.cross(v) / denom
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+func validPoint(p Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+var (
+	// configScopePattern matches agent types and IDs usable as config bucket keys
+	configScopePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// configNamePattern matches runtime setting names
+	configNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// AgentConfigHandler sets the runtime configuration agents follow in the
+// AGENT_CONFIG bucket. Settings are stored per agent type or agent ID, and an
+// agent ID's settings override its type's.
+type AgentConfigHandler struct {
+	js     jetstream.JetStream
+	logger zerolog.Logger
+}
+
+// NewAgentConfigHandler creates a new AgentConfigHandler. js may be nil when
+// the gateway has no NATS connection, in which case requests are refused.
+func NewAgentConfigHandler(js jetstream.JetStream, logger zerolog.Logger) *AgentConfigHandler {
+	return &AgentConfigHandler{
+		js:     js,
+		logger: logger.With().Str("handler", "agent_config").Logger(),
+	}
+}
+
+// Routes returns the agent config routes
+func (h *AgentConfigHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListConfig)
+	r.Get("/{scope}", h.GetConfig)
+	r.Put("/{scope}", h.SetConfig)
+	r.Delete("/{scope}", h.DeleteConfig)
+
+	return r
+}
+
+// AgentConfigRequest represents the request body for setting a scope's configuration.
+// Values may be JSON strings, numbers, or booleans.
+type AgentConfigRequest struct {
+	Values    map[string]interface{} `json:"values"`
+	UpdatedBy string                 `json:"updated_by,omitempty"`
+}
+
+// AgentConfigResponse represents one scope's configuration in API responses
+type AgentConfigResponse struct {
+	Scope     string            `json:"scope"`
+	Values    map[string]string `json:"values"`
+	UpdatedBy string            `json:"updated_by,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// toConfigDocument validates the request and converts its values to strings
+func (req AgentConfigRequest) toConfigDocument() (agent.ConfigDocument, error) {
+	known := make(map[string]agent.ConfigField, len(agent.RuntimeConfigFields))
+	for _, f := range agent.RuntimeConfigFields {
+		known[f.Name] = f
+	}
+
+	doc := agent.ConfigDocument{Values: make(map[string]string, len(req.Values)), UpdatedBy: req.UpdatedBy}
+	for name, raw := range req.Values {
+		if !configNamePattern.MatchString(name) {
+			return doc, fmt.Errorf("invalid setting name %q", name)
+		}
+
+		var value string
+		switch v := raw.(type) {
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			return doc, fmt.Errorf("setting %s must be a string, number, or boolean", name)
+		}
+
+		if field, ok := known[name]; ok {
+			if err := agent.ValidateConfigValue(field, value); err != nil {
+				return doc, err
+			}
+		}
+		doc.Values[name] = value
+	}
+	return doc, nil
+}
+
+// ListConfig handles GET /api/v1/agent-config
+func (h *AgentConfigHandler) ListConfig(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	kv, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := kv.Keys(r.Context())
+	if err != nil && !errors.Is(err, jetstream.ErrNoKeysFound) {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list agent config")
+		WriteError(w, http.StatusInternalServerError, "Failed to list agent config", correlationID)
+		return
+	}
+	sort.Strings(keys)
+
+	scopes := make([]AgentConfigResponse, 0, len(keys))
+	for _, key := range keys {
+		resp, err := h.load(r.Context(), kv, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to read agent config")
+			WriteError(w, http.StatusInternalServerError, "Failed to read agent config", correlationID)
+			return
+		}
+		scopes = append(scopes, resp)
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"scopes":         scopes,
+		"runtime_fields": agent.RuntimeConfigFields,
+		"correlation_id": correlationID,
+	})
+}
+
+// GetConfig handles GET /api/v1/agent-config/{scope}
+func (h *AgentConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	scope := chi.URLParam(r, "scope")
+	if !configScopePattern.MatchString(scope) {
+		WriteError(w, http.StatusBadRequest, "Invalid agent type or ID", correlationID)
+		return
+	}
+
+	kv, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.load(r.Context(), kv, scope)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		WriteError(w, http.StatusNotFound, "No runtime configuration for "+scope, correlationID)
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to read agent config")
+		WriteError(w, http.StatusInternalServerError, "Failed to read agent config", correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// SetConfig handles PUT /api/v1/agent-config/{scope}, replacing the scope's settings
+func (h *AgentConfigHandler) SetConfig(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	scope := chi.URLParam(r, "scope")
+	if !configScopePattern.MatchString(scope) {
+		WriteError(w, http.StatusBadRequest, "Invalid agent type or ID", correlationID)
+		return
+	}
+
+	var req AgentConfigRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	if req.UpdatedBy == "" {
+		req.UpdatedBy = GetUserID(r.Context())
+	}
+	doc, err := req.toConfigDocument()
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+	doc.UpdatedAt = time.Now().UTC()
+
+	kv, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Failed to encode agent config", correlationID)
+		return
+	}
+	if _, err := kv.Put(r.Context(), scope, data); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to store agent config")
+		WriteError(w, http.StatusInternalServerError, "Failed to store agent config", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("scope", scope).
+		Interface("values", doc.Values).
+		Str("updated_by", doc.UpdatedBy).
+		Msg("Agent runtime configuration changed")
+
+	WriteJSON(w, http.StatusOK, AgentConfigResponse{
+		Scope:     scope,
+		Values:    doc.Values,
+		UpdatedBy: doc.UpdatedBy,
+		UpdatedAt: doc.UpdatedAt,
+	})
+}
+
+// DeleteConfig handles DELETE /api/v1/agent-config/{scope}, returning the
+// scope's agents to their startup settings
+func (h *AgentConfigHandler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	scope := chi.URLParam(r, "scope")
+	if !configScopePattern.MatchString(scope) {
+		WriteError(w, http.StatusBadRequest, "Invalid agent type or ID", correlationID)
+		return
+	}
+
+	kv, ok := h.bucket(w, r)
+	if !ok {
+		return
+	}
+
+	if err := kv.Delete(r.Context(), scope); err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to delete agent config")
+		WriteError(w, http.StatusInternalServerError, "Failed to delete agent config", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("scope", scope).
+		Str("updated_by", GetUserID(r.Context())).
+		Msg("Agent runtime configuration removed")
+
+	WriteSuccess(w, http.StatusOK, "Runtime configuration removed for "+scope, nil, correlationID)
+}
+
+// bucket returns the config bucket, writing an error response if it is unavailable
+func (h *AgentConfigHandler) bucket(w http.ResponseWriter, r *http.Request) (jetstream.KeyValue, bool) {
+	correlationID := GetCorrelationID(r.Context())
+	if h.js == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Agent configuration requires NATS", correlationID)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	kv, err := agent.EnsureConfigBucket(ctx, h.js)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to open agent config bucket")
+		WriteError(w, http.StatusInternalServerError, "Failed to open agent config", correlationID)
+		return nil, false
+	}
+	return kv, true
+}
+
+// load reads one scope's configuration
+func (h *AgentConfigHandler) load(ctx context.Context, kv jetstream.KeyValue, scope string) (AgentConfigResponse, error) {
+	entry, err := kv.Get(ctx, scope)
+	if err != nil {
+		return AgentConfigResponse{}, err
+	}
+
+	var doc agent.ConfigDocument
+	if err := json.Unmarshal(entry.Value(), &doc); err != nil {
+		return AgentConfigResponse{}, fmt.Errorf("failed to decode config for %s: %w", scope, err)
+	}
+	if doc.Values == nil {
+		doc.Values = map[string]string{}
+	}
+	return AgentConfigResponse{
+		Scope:     scope,
+		Values:    doc.Values,
+		UpdatedBy: doc.UpdatedBy,
+		UpdatedAt: doc.UpdatedAt,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+// DefaultAgentURLs are the agent control endpoints in the docker-compose network
+var DefaultAgentURLs = map[string]string{
+	"sensor":     "http://sensor-sim:9090",
+	"classifier": "http://classifier:9090",
+	"correlator": "http://correlator:9090",
+	"planner":    "http://planner:9090",
+	"authorizer": "http://authorizer:9090",
+	"effector":   "http://effector:9090",
+}
+
+// ParseAgentURLs parses a comma-separated list of name=url pairs.
+// Entries override DefaultAgentURLs; an empty value yields the defaults.
+func ParseAgentURLs(spec string) map[string]string {
+	urls := make(map[string]string, len(DefaultAgentURLs))
+	for name, url := range DefaultAgentURLs {
+		urls[name] = url
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		name, url, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || url == "" {
+			continue
+		}
+		urls[name] = strings.TrimRight(url, "/")
+	}
+	return urls
+}
+
+// AgentHandler exposes discovery of agent capabilities through the gateway
+type AgentHandler struct {
+	agentURLs map[string]string
+	client    *http.Client
+	logger    zerolog.Logger
+}
+
+// NewAgentHandler creates a new AgentHandler
+func NewAgentHandler(agentURLs map[string]string, logger zerolog.Logger) *AgentHandler {
+	return &AgentHandler{
+		agentURLs: agentURLs,
+		client: &http.Client{
+			Timeout: 3 * time.Second,
+		},
+		logger: logger.With().Str("handler", "agents").Logger(),
+	}
+}
+
+// Routes returns the agent routes
+func (h *AgentHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.ListAgents)
+	r.Get("/{agentName}/capabilities", h.GetAgentCapabilities)
+	return r
+}
+
+// AgentCapabilitiesResponse describes one agent and its discovered capabilities
+type AgentCapabilitiesResponse struct {
+	Name         string              `json:"name"`
+	URL          string              `json:"url"`
+	Reachable    bool                `json:"reachable"`
+	Error        string              `json:"error,omitempty"`
+	Capabilities *agent.Capabilities `json:"capabilities,omitempty"`
+}
+
+// AgentListResponse represents the response for listing agents
+type AgentListResponse struct {
+	Agents        []AgentCapabilitiesResponse `json:"agents"`
+	Total         int                         `json:"total"`
+	CorrelationID string                      `json:"correlation_id"`
+}
+
+// AgentDetailResponse represents the response for a single agent
+type AgentDetailResponse struct {
+	Agent         AgentCapabilitiesResponse `json:"agent"`
+	CorrelationID string                    `json:"correlation_id"`
+}
+
+// ListAgents handles GET /api/v1/agents
+func (h *AgentHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	names := make([]string, 0, len(h.agentURLs))
+	for name := range h.agentURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	agents := make([]AgentCapabilitiesResponse, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			agents[i] = h.discover(ctx, name, h.agentURLs[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	WriteJSON(w, http.StatusOK, AgentListResponse{
+		Agents:        agents,
+		Total:         len(agents),
+		CorrelationID: correlationID,
+	})
+}
+
+// GetAgentCapabilities handles GET /api/v1/agents/{agentName}/capabilities
+func (h *AgentHandler) GetAgentCapabilities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	name := chi.URLParam(r, "agentName")
+
+	url, ok := h.agentURLs[name]
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Agent not found", correlationID)
+		return
+	}
+
+	result := h.discover(ctx, name, url)
+	if !result.Reachable {
+		WriteError(w, http.StatusBadGateway, fmt.Sprintf("Failed to reach %s agent: %s", name, result.Error), correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, AgentDetailResponse{
+		Agent:         result,
+		CorrelationID: correlationID,
+	})
+}
+
+// discover fetches an agent's capabilities document
+func (h *AgentHandler) discover(ctx context.Context, name, url string) AgentCapabilitiesResponse {
+	result := AgentCapabilitiesResponse{Name: name, URL: url}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+"/capabilities", nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.logger.Debug().Err(err).Str("agent", name).Msg("Failed to reach agent")
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		result.Error = fmt.Sprintf("agent returned status %d", resp.StatusCode)
+		return result
+	}
+
+	var caps agent.Capabilities
+	if err := json.NewDecoder(resp.Body).Decode(&caps); err != nil {
+		result.Error = fmt.Sprintf("invalid capabilities document: %v", err)
+		return result
+	}
+
+	result.Reachable = true
+	result.Capabilities = &caps
+	return result
+}
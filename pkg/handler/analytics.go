@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Heatmap defaults and limits
+const (
+	DefaultHeatmapCell   = 0.1       // Degrees
+	DefaultHeatmapWindow = time.Hour // How far back an unbounded heatmap looks
+	MinHeatmapCell       = 0.001
+	MaxHeatmapCells      = 250000 // Largest grid a heatmap may span
+)
+
+// AnalyticsHandler serves aggregate views of pipeline data
+type AnalyticsHandler struct {
+	db     *postgres.Pool
+	logger zerolog.Logger
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler
+func NewAnalyticsHandler(db *postgres.Pool, logger zerolog.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		db:     db,
+		logger: logger.With().Str("handler", "analytics").Logger(),
+	}
+}
+
+// Routes returns the analytics routes
+func (h *AnalyticsHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/heatmap", h.GetHeatmap)
+	r.Get("/approvers", h.GetApproverStats)
+
+	return r
+}
+
+// HeatmapResponse is a detection density grid over a bounding box
+type HeatmapResponse struct {
+	BBox          geo.BBox               `json:"bbox"`
+	Cell          float64                `json:"cell"`
+	Since         time.Time              `json:"since"`
+	Until         *time.Time             `json:"until,omitempty"`
+	Rows          int                    `json:"rows"`
+	Cols          int                    `json:"cols"`
+	Cells         []postgres.HeatmapCell `json:"cells"`
+	Total         int64                  `json:"total"`     // Detections across all cells
+	MaxCount      int64                  `json:"max_count"` // Busiest cell, for scaling colors
+	CorrelationID string                 `json:"correlation_id"`
+}
+
+// GetHeatmap handles GET /api/v1/analytics/heatmap
+func (h *AnalyticsHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	q := r.URL.Query()
+
+	filter := postgres.HeatmapFilter{Cell: DefaultHeatmapCell}
+
+	// Bounding box in GeoJSON order: minLon,minLat,maxLon,maxLat
+	if q.Get("bbox") == "" {
+		WriteError(w, http.StatusBadRequest, "bbox is required", correlationID)
+		return
+	}
+	box, err := geo.ParseBBox(q.Get("bbox"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid bbox: "+err.Error(), correlationID)
+		return
+	}
+	filter.BBox = box
+
+	if v := q.Get("cell"); v != "" {
+		cell, err := strconv.ParseFloat(v, 64)
+		if err != nil || cell < MinHeatmapCell || cell > 90 {
+			WriteError(w, http.StatusBadRequest, "Invalid cell: must be a size in degrees between 0.001 and 90", correlationID)
+			return
+		}
+		filter.Cell = cell
+	}
+	if rows, cols := filter.HeatmapGrid(); rows*cols > MaxHeatmapCells {
+		WriteError(w, http.StatusBadRequest, "Grid too large: use a larger cell or smaller bbox", correlationID)
+		return
+	}
+
+	if v := q.Get("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid until: must be an RFC 3339 timestamp", correlationID)
+			return
+		}
+		filter.Until = &until
+	}
+	filter.Since = time.Now().UTC().Add(-DefaultHeatmapWindow)
+	if filter.Until != nil {
+		filter.Since = filter.Until.Add(-DefaultHeatmapWindow)
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid since: must be an RFC 3339 timestamp", correlationID)
+			return
+		}
+		filter.Since = since
+	}
+	if filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		WriteError(w, http.StatusBadRequest, "Invalid time range: since must be before until", correlationID)
+		return
+	}
+
+	cells, err := h.db.DetectionHeatmap(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to build detection heatmap")
+		WriteError(w, http.StatusInternalServerError, "Failed to build heatmap", correlationID)
+		return
+	}
+
+	rows, cols := filter.HeatmapGrid()
+	response := HeatmapResponse{
+		BBox:          filter.BBox,
+		Cell:          filter.Cell,
+		Since:         filter.Since,
+		Until:         filter.Until,
+		Rows:          rows,
+		Cols:          cols,
+		Cells:         cells,
+		CorrelationID: correlationID,
+	}
+	if response.Cells == nil {
+		response.Cells = []postgres.HeatmapCell{}
+	}
+	for _, c := range cells {
+		response.Total += c.Count
+		if c.Count > response.MaxCount {
+			response.MaxCount = c.Count
+		}
+	}
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// ApproverStatsResponse summarizes human decisions per approver
+type ApproverStatsResponse struct {
+	Approvers     []postgres.ApproverStats `json:"approvers"`
+	From          *time.Time               `json:"from,omitempty"`
+	To            *time.Time               `json:"to,omitempty"`
+	CorrelationID string                   `json:"correlation_id"`
+}
+
+// GetApproverStats handles GET /api/v1/analytics/approvers
+func (h *AnalyticsHandler) GetApproverStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	q := r.URL.Query()
+
+	filter := postgres.ApproverStatsFilter{
+		ApprovedBy: q.Get("approved_by"),
+		ActionType: q.Get("action_type"),
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "Invalid "+name+": must be an RFC 3339 timestamp", correlationID)
+				return
+			}
+			*dst = &t
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		WriteError(w, http.StatusBadRequest, "Invalid time range: from must be before to", correlationID)
+		return
+	}
+
+	stats, err := h.db.GetApproverStats(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to get approver stats")
+		WriteError(w, http.StatusInternalServerError, "Failed to get approver stats", correlationID)
+		return
+	}
+	if stats == nil {
+		stats = []postgres.ApproverStats{}
+	}
+	WriteJSON(w, http.StatusOK, ApproverStatsResponse{
+		Approvers:     stats,
+		From:          filter.From,
+		To:            filter.To,
+		CorrelationID: correlationID,
+	})
+}
@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+)
+
+// RedactedOperator replaces operator names in anonymized responses
+const RedactedOperator = "[redacted]"
+
+// Keys rewritten by the anonymizer, matched anywhere in a JSON document
+var (
+	anonymizeTrackIDKeys  = map[string]bool{"track_id": true, "external_track_id": true, "external_id": true}
+	anonymizeOperatorKeys = map[string]bool{"approved_by": true, "user_id": true, "actor_id": true, "created_by": true, "updated_by": true, "operator": true}
+	anonymizeLatKeys      = map[string]bool{"lat": true, "latitude": true, "center_lat": true}
+	anonymizeLonKeys      = map[string]bool{"lon": true, "longitude": true, "center_lon": true}
+)
+
+// Anonymizer rewrites API and WebSocket payloads for demos and screenshots.
+// When enabled it shifts every coordinate by a fixed offset (preserving the
+// relative geometry of tracks and zones), replaces track IDs with stable
+// pseudonyms, and redacts operator names. Stored data is never modified.
+type Anonymizer struct {
+	enabled atomic.Bool
+	key     []byte
+	dLat    float64
+	dLon    float64
+	logger  zerolog.Logger
+}
+
+// NewAnonymizer creates an anonymizer. The seed determines pseudonyms and the
+// coordinate offset; an empty seed picks a random one per gateway run.
+func NewAnonymizer(enabled bool, seed string, logger zerolog.Logger) *Anonymizer {
+	key := []byte(seed)
+	if seed == "" {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	// Derive a repeatable offset of up to ±5 degrees on each axis
+	sum := hmac.New(sha256.New, key)
+	sum.Write([]byte("coordinate-offset"))
+	digest := sum.Sum(nil)
+
+	a := &Anonymizer{
+		key:    key,
+		dLat:   (float64(digest[0])/255*2 - 1) * 5,
+		dLon:   (float64(digest[1])/255*2 - 1) * 5,
+		logger: logger.With().Str("component", "anonymizer").Logger(),
+	}
+	a.enabled.Store(enabled)
+	return a
+}
+
+// Enabled reports whether responses are being anonymized
+func (a *Anonymizer) Enabled() bool {
+	return a != nil && a.enabled.Load()
+}
+
+// SetEnabled turns anonymization on or off
+func (a *Anonymizer) SetEnabled(enabled bool) {
+	a.enabled.Store(enabled)
+}
+
+// Pseudonym returns the stable replacement for a track ID
+func (a *Anonymizer) Pseudonym(trackID string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(trackID))
+	return "TRK-" + strings.ToUpper(hex.EncodeToString(mac.Sum(nil))[:6])
+}
+
+// Transform anonymizes a JSON document. Non-JSON input is returned unchanged.
+func (a *Anonymizer) Transform(data []byte) []byte {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return data
+	}
+
+	// Collect track IDs first so they can also be replaced inside free text
+	// such as rationales and audit details
+	ids := make(map[string]string)
+	a.collectTrackIDs(doc, ids)
+	replacer := a.textReplacer(ids)
+
+	out, err := json.Marshal(a.rewrite(doc, "", ids, replacer))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+func (a *Anonymizer) collectTrackIDs(v interface{}, ids map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if anonymizeTrackIDKeys[k] {
+				switch id := child.(type) {
+				case string:
+					if id != "" {
+						ids[id] = a.Pseudonym(id)
+					}
+				case []interface{}:
+					for _, item := range id {
+						if s, ok := item.(string); ok && s != "" {
+							ids[s] = a.Pseudonym(s)
+						}
+					}
+				}
+			}
+			a.collectTrackIDs(child, ids)
+		}
+	case []interface{}:
+		for _, child := range val {
+			a.collectTrackIDs(child, ids)
+		}
+	}
+}
+
+// textReplacer replaces longer IDs first so one ID that prefixes another is not split
+func (a *Anonymizer) textReplacer(ids map[string]string) *strings.Replacer {
+	keys := make([]string, 0, len(ids))
+	for id := range ids {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	pairs := make([]string, 0, len(keys)*2)
+	for _, id := range keys {
+		pairs = append(pairs, id, ids[id])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func (a *Anonymizer) rewrite(v interface{}, key string, ids map[string]string, replacer *strings.Replacer) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = a.rewrite(child, k, ids, replacer)
+		}
+		return val
+	case []interface{}:
+		if key == "coordinates" && len(val) >= 2 {
+			// GeoJSON position: [lon, lat, alt]
+			lon, lonOK := val[0].(json.Number)
+			lat, latOK := val[1].(json.Number)
+			if lonOK && latOK {
+				if f, err := lon.Float64(); err == nil {
+					val[0] = a.shiftLon(f)
+				}
+				if f, err := lat.Float64(); err == nil {
+					val[1] = a.shiftLat(f)
+				}
+				return val
+			}
+		}
+		for i, child := range val {
+			val[i] = a.rewrite(child, key, ids, replacer)
+		}
+		return val
+	case string:
+		switch {
+		case anonymizeOperatorKeys[key]:
+			if val == "" {
+				return val
+			}
+			return RedactedOperator
+		case anonymizeTrackIDKeys[key]:
+			if p, ok := ids[val]; ok {
+				return p
+			}
+			return val
+		default:
+			return replacer.Replace(val)
+		}
+	case json.Number:
+		if anonymizeLatKeys[key] || anonymizeLonKeys[key] {
+			f, err := val.Float64()
+			if err != nil {
+				return val
+			}
+			if anonymizeLatKeys[key] {
+				return a.shiftLat(f)
+			}
+			return a.shiftLon(f)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func (a *Anonymizer) shiftLat(lat float64) float64 {
+	return math.Max(-90, math.Min(90, lat+a.dLat))
+}
+
+func (a *Anonymizer) shiftLon(lon float64) float64 {
+	lon += a.dLon
+	if lon > 180 {
+		lon -= 360
+	} else if lon < -180 {
+		lon += 360
+	}
+	return lon
+}
+
+// Middleware anonymizes JSON and GeoJSON responses while enabled
+func (a *Anonymizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &anonymizingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		body := rec.buf.Bytes()
+		if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, GeoJSONContentType) {
+			body = a.Transform(body)
+		}
+		w.Header().Del("Content-Length")
+		w.Header().Set("X-Anonymized", "true")
+		w.WriteHeader(rec.status)
+		w.Write(body)
+	})
+}
+
+// anonymizingWriter buffers a response so it can be rewritten before sending
+type anonymizingWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *anonymizingWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *anonymizingWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// Routes returns the anonymization toggle routes
+func (a *Anonymizer) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", a.GetStatus)
+	r.Put("/", a.UpdateStatus)
+	return r
+}
+
+// AnonymizationStatus is the toggle state
+type AnonymizationStatus struct {
+	Enabled       bool   `json:"enabled"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// GetStatus handles GET /api/v1/anonymization
+func (a *Anonymizer) GetStatus(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, AnonymizationStatus{
+		Enabled:       a.Enabled(),
+		CorrelationID: GetCorrelationID(r.Context()),
+	})
+}
+
+// UpdateStatus handles PUT /api/v1/anonymization
+func (a *Anonymizer) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := DecodeJSON(r, &req); err != nil || req.Enabled == nil {
+		WriteError(w, http.StatusBadRequest, "enabled is required", correlationID)
+		return
+	}
+
+	a.SetEnabled(*req.Enabled)
+	a.logger.Info().
+		Str("correlation_id", correlationID).
+		Bool("enabled", *req.Enabled).
+		Msg("Anonymization mode changed")
+
+	WriteJSON(w, http.StatusOK, AnonymizationStatus{
+		Enabled:       *req.Enabled,
+		CorrelationID: correlationID,
+	})
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/assets"
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// AssetHandler handles effector and sensor asset HTTP requests
+type AssetHandler struct {
+	db     *postgres.Pool
+	logger zerolog.Logger
+}
+
+// NewAssetHandler creates a new AssetHandler
+func NewAssetHandler(db *postgres.Pool, logger zerolog.Logger) *AssetHandler {
+	return &AssetHandler{
+		db:     db,
+		logger: logger.With().Str("handler", "assets").Logger(),
+	}
+}
+
+// Routes returns the asset routes
+func (h *AssetHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListAssets)
+	r.Post("/", h.CreateAsset)
+	r.Put("/{assetId}/readiness", h.SetReadiness)
+
+	return r
+}
+
+// AssetListResponse represents the response for listing assets
+type AssetListResponse struct {
+	Assets        []assets.Asset `json:"assets"`
+	Total         int            `json:"total"`
+	CorrelationID string         `json:"correlation_id"`
+}
+
+// AssetResponse represents a single asset in API responses
+type AssetResponse struct {
+	Asset         assets.Asset `json:"asset"`
+	CorrelationID string       `json:"correlation_id"`
+}
+
+// AssetRequest represents the request body for creating an asset
+type AssetRequest struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	Location    geo.Point `json:"location"`
+	RangeMeters float64   `json:"range_meters"`
+	Readiness   string    `json:"readiness,omitempty"`
+}
+
+// ReadinessRequest represents the request body for changing an asset's readiness
+type ReadinessRequest struct {
+	Readiness string `json:"readiness"`
+}
+
+// ListAssets handles GET /api/v1/assets
+func (h *AssetHandler) ListAssets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	kind := r.URL.Query().Get("kind")
+	readiness := r.URL.Query().Get("readiness")
+	if readiness != "" && !assets.ValidReadiness(readiness) {
+		WriteError(w, http.StatusBadRequest, "Invalid readiness filter", correlationID)
+		return
+	}
+
+	list, err := assets.List(ctx, h.db, kind, readiness)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list assets")
+		WriteError(w, http.StatusInternalServerError, "Failed to list assets", correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, AssetListResponse{
+		Assets:        list,
+		Total:         len(list),
+		CorrelationID: correlationID,
+	})
+}
+
+// CreateAsset handles POST /api/v1/assets
+func (h *AssetHandler) CreateAsset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req AssetRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	asset := assets.Asset{
+		Name:        strings.TrimSpace(req.Name),
+		Kind:        req.Kind,
+		Location:    req.Location,
+		RangeMeters: req.RangeMeters,
+		Readiness:   req.Readiness,
+	}
+	if asset.Readiness == "" {
+		asset.Readiness = assets.ReadinessReady
+	}
+	if err := asset.Validate(); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	created, err := assets.Create(ctx, h.db, asset)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique_asset_name") || strings.Contains(err.Error(), "duplicate key") {
+			WriteError(w, http.StatusConflict, "An asset with this name already exists", correlationID)
+			return
+		}
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to create asset")
+		WriteError(w, http.StatusInternalServerError, "Failed to create asset", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("asset_id", created.AssetID).
+		Str("name", created.Name).
+		Str("kind", created.Kind).
+		Msg("Asset created")
+
+	WriteJSON(w, http.StatusCreated, AssetResponse{
+		Asset:         created,
+		CorrelationID: correlationID,
+	})
+}
+
+// SetReadiness handles PUT /api/v1/assets/{assetId}/readiness
+func (h *AssetHandler) SetReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	assetID := chi.URLParam(r, "assetId")
+
+	if _, err := uuid.Parse(assetID); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid asset ID", correlationID)
+		return
+	}
+
+	var req ReadinessRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	if !assets.ValidReadiness(req.Readiness) {
+		WriteError(w, http.StatusBadRequest, "readiness must be ready, committed, maintenance, or offline", correlationID)
+		return
+	}
+
+	asset, err := assets.SetReadiness(ctx, h.db, assetID, req.Readiness)
+	if errors.Is(err, assets.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, "Asset not found", correlationID)
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("asset_id", assetID).Msg("Failed to update asset readiness")
+		WriteError(w, http.StatusInternalServerError, "Failed to update asset readiness", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("asset_id", asset.AssetID).
+		Str("readiness", asset.Readiness).
+		Msg("Asset readiness changed")
+
+	WriteJSON(w, http.StatusOK, AssetResponse{
+		Asset:         asset,
+		CorrelationID: correlationID,
+	})
+}
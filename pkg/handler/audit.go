@@ -3,24 +3,31 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 
+	"github.com/agile-defense/cjadc2/pkg/audit"
 	"github.com/agile-defense/cjadc2/pkg/postgres"
 )
 
 // AuditHandler handles audit-related HTTP requests
 type AuditHandler struct {
-	db     *postgres.Pool
-	logger zerolog.Logger
+	db        *postgres.Pool
+	exportKey []byte // Signs audit export manifests
+	exports   *auditExports
+	logger    zerolog.Logger
 }
 
-// NewAuditHandler creates a new AuditHandler
-func NewAuditHandler(db *postgres.Pool, logger zerolog.Logger) *AuditHandler {
+// NewAuditHandler creates a new AuditHandler. exportKey signs the manifests
+// of audit export bundles.
+func NewAuditHandler(db *postgres.Pool, exportKey []byte, logger zerolog.Logger) *AuditHandler {
 	return &AuditHandler{
-		db:     db,
-		logger: logger.With().Str("handler", "audit").Logger(),
+		db:        db,
+		exportKey: exportKey,
+		exports:   &auditExports{jobs: make(map[string]*AuditExportJob)},
+		logger:    logger.With().Str("handler", "audit").Logger(),
 	}
 }
 
@@ -29,6 +36,11 @@ func (h *AuditHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 
 	r.Get("/", h.GetAuditEntries)
+	r.Get("/search", h.SearchAuditEntries)
+	r.Post("/export", h.StartAuditExport)
+	r.Get("/export/{exportId}", h.GetAuditExport)
+	r.Get("/export/{exportId}/download", h.DownloadAuditExport)
+	r.Get("/verify", h.VerifyChain)
 
 	return r
 }
@@ -125,3 +137,121 @@ func (h *AuditHandler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
 	// Return the entries array directly (frontend expects AuditEntry[])
 	WriteJSON(w, http.StatusOK, responseEntries)
 }
+
+// AuditSearchResponse represents the response for an audit search
+type AuditSearchResponse struct {
+	Results       []postgres.AuditSearchResult `json:"results"`
+	Total         int64                        `json:"total"` // Matching entries across all pages
+	Limit         int                          `json:"limit"`
+	Offset        int                          `json:"offset"`
+	CorrelationID string                       `json:"correlation_id"`
+}
+
+// SearchAuditEntries handles GET /api/v1/audit/search
+func (h *AuditHandler) SearchAuditEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	q := r.URL.Query()
+
+	filter := postgres.AuditSearchFilter{
+		Text:        q.Get("q"),
+		ActionType:  q.Get("action_type"),
+		ApprovedBy:  q.Get("approved_by"),
+		ThreatLevel: q.Get("threat_level"),
+		Outcome:     q.Get("outcome"),
+		TrackID:     q.Get("track_id"),
+		Limit:       DefaultPageLimit,
+	}
+
+	if filter.Outcome != "" && !postgres.IsValidAuditOutcome(filter.Outcome) {
+		WriteError(w, http.StatusBadRequest, "Invalid outcome: must be approved, denied, executed, or failed", correlationID)
+		return
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "Invalid "+name+": must be an RFC 3339 timestamp", correlationID)
+				return
+			}
+			*dst = &t
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		WriteError(w, http.StatusBadRequest, "Invalid time range: from must be before to", correlationID)
+		return
+	}
+
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 {
+		filter.Limit = limit
+	}
+	if offset, err := strconv.Atoi(q.Get("offset")); err == nil && offset >= 0 {
+		filter.Offset = offset
+	}
+
+	results, err := h.db.SearchAuditEntries(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to search audit entries")
+		WriteError(w, http.StatusInternalServerError, "Failed to search audit entries", correlationID)
+		return
+	}
+	total, err := h.db.CountAuditSearch(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to count audit search results")
+		WriteError(w, http.StatusInternalServerError, "Failed to search audit entries", correlationID)
+		return
+	}
+
+	if results == nil {
+		results = []postgres.AuditSearchResult{}
+	}
+	w.Header().Set(TotalCountHeader, strconv.FormatInt(total, 10))
+	WriteJSON(w, http.StatusOK, AuditSearchResponse{
+		Results:       results,
+		Total:         total,
+		Limit:         filter.Limit,
+		Offset:        filter.Offset,
+		CorrelationID: correlationID,
+	})
+}
+
+// AuditVerifyResponse reports the integrity of the decision/effect hash chain
+type AuditVerifyResponse struct {
+	audit.VerifyResult
+	CorrelationID string `json:"correlation_id"`
+}
+
+// VerifyChain handles GET /api/v1/audit/verify
+func (h *AuditHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	head, err := h.db.GetAuditChainHead(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to get audit chain head")
+		WriteError(w, http.StatusInternalServerError, "Failed to verify audit chain", correlationID)
+		return
+	}
+
+	links, err := h.db.ListAuditChain(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to load audit chain")
+		WriteError(w, http.StatusInternalServerError, "Failed to verify audit chain", correlationID)
+		return
+	}
+
+	result := audit.Verify(links, head)
+	if !result.Valid {
+		h.logger.Warn().
+			Str("correlation_id", correlationID).
+			Int64("seq", result.FirstBroken.Seq).
+			Str("reason", result.FirstBroken.Reason).
+			Msg("Audit chain verification failed")
+	}
+
+	WriteJSON(w, http.StatusOK, AuditVerifyResponse{
+		VerifyResult:  result,
+		CorrelationID: correlationID,
+	})
+}
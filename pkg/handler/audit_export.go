@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/agile-defense/cjadc2/pkg/auditexport"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Audit export job statuses
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// Audit export limits
+const (
+	ExportTimeout   = 5 * time.Minute // Longest a bundle may take to generate
+	ExportRetention = time.Hour       // How long finished bundles stay downloadable
+	MaxExportJobs   = 20              // Jobs held at once; the oldest finished ones are dropped first
+)
+
+// AuditExportRequest is the body of POST /api/v1/audit/export
+type AuditExportRequest struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// AuditExportJob reports the progress of one export
+type AuditExportJob struct {
+	ExportID    string     `json:"export_id"`
+	Status      string     `json:"status"`
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	RequestedBy string     `json:"requested_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+
+	// Set once completed
+	Records     int    `json:"records,omitempty"`
+	Bytes       int    `json:"bytes,omitempty"`
+	SHA256      string `json:"sha256,omitempty"`    // Digest of the whole bundle
+	Signature   string `json:"signature,omitempty"` // Manifest signature
+	KeyID       string `json:"key_id,omitempty"`
+	DownloadURL string `json:"download_url,omitempty"`
+
+	bundle []byte
+}
+
+// AuditExportResponse wraps an export job
+type AuditExportResponse struct {
+	Export        AuditExportJob `json:"export"`
+	CorrelationID string         `json:"correlation_id"`
+}
+
+// auditExports holds export jobs in memory until they expire
+type auditExports struct {
+	mu   sync.Mutex
+	jobs map[string]*AuditExportJob
+}
+
+// add stores a job, dropping expired jobs and, past MaxExportJobs, the oldest
+// finished ones. It reports false when every held job is still in progress.
+func (e *auditExports) add(job *AuditExportJob, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var finished []*AuditExportJob
+	for id, j := range e.jobs {
+		if j.CompletedAt == nil {
+			continue
+		}
+		if now.Sub(*j.CompletedAt) > ExportRetention {
+			delete(e.jobs, id)
+			continue
+		}
+		finished = append(finished, j)
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i].CompletedAt.Before(*finished[k].CompletedAt) })
+	for len(e.jobs) >= MaxExportJobs && len(finished) > 0 {
+		delete(e.jobs, finished[0].ExportID)
+		finished = finished[1:]
+	}
+	if len(e.jobs) >= MaxExportJobs {
+		return false
+	}
+	e.jobs[job.ExportID] = job
+	return true
+}
+
+// get returns a copy of a job
+func (e *auditExports) get(id string) (AuditExportJob, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	job, ok := e.jobs[id]
+	if !ok {
+		return AuditExportJob{}, false
+	}
+	return *job, true
+}
+
+// update applies fn to a job under the lock
+func (e *auditExports) update(id string, fn func(*AuditExportJob)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if job, ok := e.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// StartAuditExport handles POST /api/v1/audit/export
+func (h *AuditHandler) StartAuditExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req AuditExportRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	if req.From.IsZero() || req.To.IsZero() {
+		WriteError(w, http.StatusBadRequest, "from and to are required", correlationID)
+		return
+	}
+	if !req.From.Before(req.To) {
+		WriteError(w, http.StatusBadRequest, "Invalid time range: from must be before to", correlationID)
+		return
+	}
+
+	now := time.Now().UTC()
+	job := &AuditExportJob{
+		ExportID:    uuid.New().String(),
+		Status:      ExportStatusPending,
+		From:        req.From.UTC(),
+		To:          req.To.UTC(),
+		RequestedBy: GetUserID(ctx),
+		CreatedAt:   now,
+	}
+	if !h.exports.add(job, now) {
+		WriteError(w, http.StatusServiceUnavailable, "Too many audit exports in progress", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("export_id", job.ExportID).
+		Time("from", job.From).
+		Time("to", job.To).
+		Str("requested_by", job.RequestedBy).
+		Str("correlation_id", correlationID).
+		Msg("Audit export started")
+
+	go h.runAuditExport(job.ExportID, *job)
+
+	snapshot, _ := h.exports.get(job.ExportID)
+	w.Header().Set("Location", "/api/v1/audit/export/"+job.ExportID)
+	WriteJSON(w, http.StatusAccepted, AuditExportResponse{Export: snapshot, CorrelationID: correlationID})
+}
+
+// runAuditExport generates the bundle of a job outside the request
+func (h *AuditHandler) runAuditExport(id string, job AuditExportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), ExportTimeout)
+	defer cancel()
+
+	h.exports.update(id, func(j *AuditExportJob) { j.Status = ExportStatusRunning })
+
+	fail := func(err error) {
+		h.logger.Error().Err(err).Str("export_id", id).Msg("Audit export failed")
+		now := time.Now().UTC()
+		h.exports.update(id, func(j *AuditExportJob) {
+			j.Status = ExportStatusFailed
+			j.Error = err.Error()
+			j.CompletedAt = &now
+		})
+	}
+
+	results, err := h.db.SearchAuditEntries(ctx, postgres.AuditSearchFilter{From: &job.From, To: &job.To})
+	if err != nil {
+		fail(err)
+		return
+	}
+	// Reports read oldest first
+	entries := make([]postgres.AuditEntry, len(results))
+	for i, r := range results {
+		entries[len(results)-1-i] = r.AuditEntry
+	}
+
+	bundle, manifest, err := auditexport.Build(entries, auditexport.Manifest{
+		ExportID:    id,
+		From:        job.From,
+		To:          job.To,
+		RequestedBy: job.RequestedBy,
+		GeneratedAt: time.Now().UTC(),
+	}, h.exportKey)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	sum := sha256.Sum256(bundle)
+	now := time.Now().UTC()
+	h.exports.update(id, func(j *AuditExportJob) {
+		j.Status = ExportStatusCompleted
+		j.CompletedAt = &now
+		j.Records = manifest.Records
+		j.Bytes = len(bundle)
+		j.SHA256 = hex.EncodeToString(sum[:])
+		j.Signature = manifest.Signature
+		j.KeyID = manifest.KeyID
+		j.DownloadURL = "/api/v1/audit/export/" + id + "/download"
+		j.bundle = bundle
+	})
+	h.logger.Info().Str("export_id", id).Int("records", manifest.Records).Int("bytes", len(bundle)).Msg("Audit export completed")
+}
+
+// GetAuditExport handles GET /api/v1/audit/export/{exportId}
+func (h *AuditHandler) GetAuditExport(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	job, ok := h.exports.get(chi.URLParam(r, "exportId"))
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Audit export not found", correlationID)
+		return
+	}
+	WriteJSON(w, http.StatusOK, AuditExportResponse{Export: job, CorrelationID: correlationID})
+}
+
+// DownloadAuditExport handles GET /api/v1/audit/export/{exportId}/download
+func (h *AuditHandler) DownloadAuditExport(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	job, ok := h.exports.get(chi.URLParam(r, "exportId"))
+	if !ok {
+		WriteError(w, http.StatusNotFound, "Audit export not found", correlationID)
+		return
+	}
+	if job.Status != ExportStatusCompleted {
+		WriteError(w, http.StatusConflict, "Audit export is "+job.Status, correlationID)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-export-`+job.ExportID+`.zip"`)
+	w.Header().Set("X-Content-SHA256", job.SHA256)
+	w.WriteHeader(http.StatusOK)
+	w.Write(job.bundle)
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/breakglass"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// BreakGlassExpiryInterval is how often lapsed grants are marked expired
+const BreakGlassExpiryInterval = 10 * time.Second
+
+// BreakGlassHandler handles activation, listing, and revocation of break-glass grants
+type BreakGlassHandler struct {
+	db      *postgres.Pool
+	pub     *Publisher
+	secrets map[string][]byte // TOTP secrets by user ID
+	logger  zerolog.Logger
+}
+
+// NewBreakGlassHandler creates a new BreakGlassHandler. Users without a TOTP
+// secret cannot activate a grant.
+func NewBreakGlassHandler(db *postgres.Pool, pub *Publisher, secrets map[string][]byte, logger zerolog.Logger) *BreakGlassHandler {
+	return &BreakGlassHandler{
+		db:      db,
+		pub:     pub,
+		secrets: secrets,
+		logger:  logger.With().Str("handler", "break_glass").Logger(),
+	}
+}
+
+// Routes returns the break-glass routes
+func (h *BreakGlassHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListGrants)
+	r.Post("/", h.ActivateGrant)
+	r.Delete("/{grantId}", h.RevokeGrant)
+
+	return r
+}
+
+// BreakGlassListResponse represents the response for listing grants
+type BreakGlassListResponse struct {
+	Grants        []breakglass.Grant `json:"grants"`
+	Total         int                `json:"total"`
+	CorrelationID string             `json:"correlation_id"`
+}
+
+// BreakGlassRequest represents the request body for activating a grant
+type BreakGlassRequest struct {
+	UserID          string `json:"user_id"`
+	Role            string `json:"role,omitempty"`
+	Justification   string `json:"justification"`
+	DurationMinutes int    `json:"duration_minutes,omitempty"`
+	TOTPCode        string `json:"totp_code"`
+}
+
+// BreakGlassRevokeRequest represents the optional request body for revoking a grant
+type BreakGlassRevokeRequest struct {
+	RevokedBy string `json:"revoked_by"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// BreakGlassResponse represents a single grant in API responses
+type BreakGlassResponse struct {
+	Grant         breakglass.Grant `json:"grant"`
+	CorrelationID string           `json:"correlation_id"`
+}
+
+// ListGrants handles GET /api/v1/break-glass
+func (h *BreakGlassHandler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	filter := postgres.BreakGlassFilter{
+		UserID:     r.URL.Query().Get("user_id"),
+		ActiveOnly: strings.ToLower(r.URL.Query().Get("active")) == "true",
+		Limit:      100,
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+	}
+
+	grants, err := h.db.ListBreakGlassGrants(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list break-glass grants")
+		WriteError(w, http.StatusInternalServerError, "Failed to list break-glass grants", correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, BreakGlassListResponse{
+		Grants:        grants,
+		Total:         len(grants),
+		CorrelationID: correlationID,
+	})
+}
+
+// ActivateGrant handles POST /api/v1/break-glass
+func (h *BreakGlassHandler) ActivateGrant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req BreakGlassRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	if req.UserID == "" {
+		req.UserID = GetUserID(ctx)
+	}
+	if req.UserID == "" {
+		WriteError(w, http.StatusBadRequest, "user_id is required", correlationID)
+		return
+	}
+	req.Justification = strings.TrimSpace(req.Justification)
+	if req.Justification == "" {
+		WriteError(w, http.StatusBadRequest, "justification is required", correlationID)
+		return
+	}
+	if req.Role == "" {
+		req.Role = breakglass.DefaultRole
+	}
+
+	secret, ok := h.secrets[req.UserID]
+	if !ok {
+		h.logger.Warn().Str("correlation_id", correlationID).Str("user_id", req.UserID).Msg("Break-glass refused: no second factor enrolled")
+		WriteError(w, http.StatusForbidden, "No second factor enrolled for user", correlationID)
+		return
+	}
+
+	now := time.Now().UTC()
+	if !breakglass.VerifyTOTP(secret, req.TOTPCode, now) {
+		h.logger.Warn().Str("correlation_id", correlationID).Str("user_id", req.UserID).Msg("Break-glass refused: invalid second factor")
+		WriteError(w, http.StatusUnauthorized, "Invalid second factor code", correlationID)
+		return
+	}
+
+	duration := breakglass.ClampDuration(time.Duration(req.DurationMinutes) * time.Minute)
+	grant := &breakglass.Grant{
+		UserID:               req.UserID,
+		Role:                 req.Role,
+		Justification:        req.Justification,
+		SecondFactor:         breakglass.SecondFactorTOTP,
+		SecondFactorVerified: true,
+		Status:               breakglass.StatusActive,
+		ActivatedAt:          now,
+		ExpiresAt:            now.Add(duration),
+	}
+
+	if err := h.db.InsertBreakGlassGrant(ctx, grant, correlationID); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to activate break-glass grant")
+		WriteError(w, http.StatusInternalServerError, "Failed to activate break-glass grant", correlationID)
+		return
+	}
+
+	h.logger.Warn().
+		Str("correlation_id", correlationID).
+		Str("grant_id", grant.GrantID).
+		Str("user_id", grant.UserID).
+		Str("role", grant.Role).
+		Str("justification", grant.Justification).
+		Time("expires_at", grant.ExpiresAt).
+		Msg("BREAK GLASS activated: elevated approval authority granted")
+
+	h.publish(grant.Notification(messages.BreakGlassActivated, grant.UserID, "api-gateway", "api-gateway"), correlationID)
+
+	WriteJSON(w, http.StatusCreated, BreakGlassResponse{
+		Grant:         *grant,
+		CorrelationID: correlationID,
+	})
+}
+
+// RevokeGrant handles DELETE /api/v1/break-glass/{grantId}
+func (h *BreakGlassHandler) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	grantID := chi.URLParam(r, "grantId")
+
+	// The body is optional; revocation by the grant holder needs no details
+	var req BreakGlassRevokeRequest
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+			return
+		}
+	}
+	if req.RevokedBy == "" {
+		req.RevokedBy = GetUserID(ctx)
+	}
+	if req.RevokedBy == "" {
+		req.RevokedBy = "operator"
+	}
+
+	grant, err := h.db.RevokeBreakGlassGrant(ctx, grantID, req.RevokedBy, req.Reason, correlationID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("grant_id", grantID).Msg("Failed to revoke break-glass grant")
+		WriteError(w, http.StatusInternalServerError, "Failed to revoke break-glass grant", correlationID)
+		return
+	}
+	if grant == nil {
+		WriteError(w, http.StatusNotFound, "Active break-glass grant not found", correlationID)
+		return
+	}
+
+	h.logger.Warn().
+		Str("correlation_id", correlationID).
+		Str("grant_id", grant.GrantID).
+		Str("user_id", grant.UserID).
+		Str("revoked_by", req.RevokedBy).
+		Int("use_count", grant.UseCount).
+		Msg("BREAK GLASS revoked")
+
+	h.publish(grant.Notification(messages.BreakGlassRevoked, req.RevokedBy, "api-gateway", "api-gateway"), correlationID)
+
+	WriteJSON(w, http.StatusOK, BreakGlassResponse{
+		Grant:         *grant,
+		CorrelationID: correlationID,
+	})
+}
+
+// RunExpiry marks lapsed grants expired and announces them until ctx is done.
+// Authority checks already ignore lapsed grants; this keeps the stored status,
+// audit log, and operator notifications in step with them.
+func (h *BreakGlassHandler) RunExpiry(ctx context.Context) {
+	ticker := time.NewTicker(BreakGlassExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			grants, err := h.db.ExpireBreakGlassGrants(ctx, time.Now())
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error().Err(err).Msg("Failed to expire break-glass grants")
+				}
+				continue
+			}
+			for _, g := range grants {
+				h.logger.Warn().
+					Str("grant_id", g.GrantID).
+					Str("user_id", g.UserID).
+					Int("use_count", g.UseCount).
+					Msg("BREAK GLASS expired")
+				h.publish(g.Notification(messages.BreakGlassExpired, "system", "api-gateway", "api-gateway"), g.GrantID)
+			}
+		}
+	}
+}
+
+// publish sends a break-glass notification to NATS for the UI and audit consumers
+func (h *BreakGlassHandler) publish(n *messages.BreakGlassNotification, correlationID string) {
+	if h.pub == nil {
+		return
+	}
+	n.Envelope = n.Envelope.WithCorrelation(correlationID, "")
+	if err := h.pub.Publish(n); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", n.Subject()).Msg("Failed to publish break-glass notification")
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/chaos"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// ChaosHandler sets the fault plan that agents inject into their stages by
+// publishing it on the CHAOS stream. Changes are refused unless chaos testing
+// is enabled on the gateway.
+type ChaosHandler struct {
+	js      jetstream.JetStream
+	enabled bool
+	logger  zerolog.Logger
+
+	mu   sync.Mutex // Serializes plan changes so sequence numbers never collide
+	plan chaos.Plan
+}
+
+// NewChaosHandler creates a new ChaosHandler. js may be nil when the gateway
+// has no NATS connection, in which case changes are refused.
+func NewChaosHandler(js jetstream.JetStream, enabled bool, logger zerolog.Logger) *ChaosHandler {
+	return &ChaosHandler{
+		js:      js,
+		enabled: enabled,
+		logger:  logger.With().Str("handler", "chaos").Logger(),
+		plan:    chaos.Plan{Faults: map[string]chaos.Fault{}},
+	}
+}
+
+// Run follows the CHAOS stream so the handler starts from the plan an earlier
+// run published
+func (h *ChaosHandler) Run(ctx context.Context) error {
+	if h.js == nil || !h.enabled {
+		return nil
+	}
+	if _, err := h.js.Stream(ctx, chaos.StreamName); err != nil {
+		if _, err := h.js.CreateStream(ctx, natsutil.StreamConfigs[chaos.StreamName]); err != nil {
+			return fmt.Errorf("failed to create %s stream: %w", chaos.StreamName, err)
+		}
+	}
+	return chaos.Watch(ctx, h.js, h.apply)
+}
+
+// apply adopts plan if it is newer than the current one
+func (h *ChaosHandler) apply(plan chaos.Plan) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if plan.Seq > h.plan.Seq {
+		h.plan = plan
+	}
+}
+
+// Routes returns the chaos testing routes
+func (h *ChaosHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.GetPlan)
+	r.Delete("/", h.ClearPlan)
+	r.Put("/{stage}", h.SetFault)
+	r.Delete("/{stage}", h.ClearFault)
+
+	return r
+}
+
+// ChaosFaultRequest represents the request body for setting one stage's fault
+type ChaosFaultRequest struct {
+	chaos.Fault
+	UpdatedBy string `json:"updated_by,omitempty"`
+}
+
+// ChaosResponse represents the fault plan in API responses
+type ChaosResponse struct {
+	Enabled       bool       `json:"enabled"`
+	Stages        []string   `json:"stages"`
+	Plan          chaos.Plan `json:"plan"`
+	CorrelationID string     `json:"correlation_id"`
+}
+
+// GetPlan handles GET /api/v1/chaos
+func (h *ChaosHandler) GetPlan(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	plan := h.plan
+	h.mu.Unlock()
+
+	WriteJSON(w, http.StatusOK, ChaosResponse{
+		Enabled:       h.enabled,
+		Stages:        chaos.Stages,
+		Plan:          plan,
+		CorrelationID: GetCorrelationID(r.Context()),
+	})
+}
+
+// SetFault handles PUT /api/v1/chaos/{stage}
+func (h *ChaosHandler) SetFault(w http.ResponseWriter, r *http.Request) {
+	var req ChaosFaultRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", GetCorrelationID(r.Context()))
+		return
+	}
+	if req.UpdatedBy == "" {
+		req.UpdatedBy = GetUserID(r.Context())
+	}
+	stage := chi.URLParam(r, "stage")
+	h.change(w, r, func(p chaos.Plan, now time.Time) (chaos.Plan, error) {
+		return p.With(stage, req.Fault, req.UpdatedBy, now)
+	})
+}
+
+// ClearFault handles DELETE /api/v1/chaos/{stage}
+func (h *ChaosHandler) ClearFault(w http.ResponseWriter, r *http.Request) {
+	stage := chi.URLParam(r, "stage")
+	h.change(w, r, func(p chaos.Plan, now time.Time) (chaos.Plan, error) {
+		return p.With(stage, chaos.Fault{}, GetUserID(r.Context()), now)
+	})
+}
+
+// ClearPlan handles DELETE /api/v1/chaos, removing every fault
+func (h *ChaosHandler) ClearPlan(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, func(p chaos.Plan, now time.Time) (chaos.Plan, error) {
+		return p.Cleared(GetUserID(r.Context()), now), nil
+	})
+}
+
+// change validates and publishes one plan change
+func (h *ChaosHandler) change(w http.ResponseWriter, r *http.Request, apply func(chaos.Plan, time.Time) (chaos.Plan, error)) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	if !h.enabled {
+		WriteError(w, http.StatusForbidden, "Chaos testing is disabled; set CHAOS_ENABLED=true", correlationID)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next, err := apply(h.plan, time.Now().UTC())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	if h.js == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Chaos testing requires NATS", correlationID)
+		return
+	}
+	if err := chaos.Publish(ctx, h.js, next); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to publish chaos plan")
+		WriteError(w, http.StatusInternalServerError, "Failed to publish chaos plan", correlationID)
+		return
+	}
+	h.plan = next
+
+	h.logger.Warn().
+		Str("correlation_id", correlationID).
+		Interface("faults", next.Faults).
+		Str("updated_by", next.UpdatedBy).
+		Msg("Chaos plan changed")
+
+	WriteJSON(w, http.StatusOK, ChaosResponse{
+		Enabled:       h.enabled,
+		Stages:        chaos.Stages,
+		Plan:          next,
+		CorrelationID: correlationID,
+	})
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Proposal claim timing
+const (
+	DefaultClaimTimeout = 5 * time.Minute  // Inactivity after which a claim lapses
+	ClaimExpiryInterval = 10 * time.Second // How often lapsed claims are released
+)
+
+// ParseClaimTimeout parses CLAIM_TIMEOUT, using DefaultClaimTimeout when unset
+func ParseClaimTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultClaimTimeout, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid CLAIM_TIMEOUT %q: must be a positive duration", value)
+	}
+	return d, nil
+}
+
+// ClaimHandler lets an operator claim a pending proposal so no other operator
+// decides it while they work the engagement. Claims lapse after a period
+// without activity; claiming again renews one.
+type ClaimHandler struct {
+	db      *postgres.Pool
+	pub     *Publisher
+	timeout time.Duration
+	logger  zerolog.Logger
+}
+
+// NewClaimHandler creates a new ClaimHandler whose claims lapse after timeout
+// without activity
+func NewClaimHandler(db *postgres.Pool, pub *Publisher, timeout time.Duration, logger zerolog.Logger) *ClaimHandler {
+	if timeout <= 0 {
+		timeout = DefaultClaimTimeout
+	}
+	return &ClaimHandler{
+		db:      db,
+		pub:     pub,
+		timeout: timeout,
+		logger:  logger.With().Str("handler", "claims").Logger(),
+	}
+}
+
+// ClaimRequest represents the optional request body for claiming or releasing a proposal
+type ClaimRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// ClaimResponse represents a claim in API responses
+type ClaimResponse struct {
+	Claim         postgres.ProposalClaim `json:"claim"`
+	CorrelationID string                 `json:"correlation_id"`
+}
+
+// Claim handles POST /api/v1/proposals/{proposalId}/claim
+func (h *ClaimHandler) Claim(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	proposalID := chi.URLParam(r, "proposalId")
+
+	userID, ok := claimUser(w, r)
+	if !ok {
+		return
+	}
+	if !h.visible(w, r, proposalID) {
+		return
+	}
+
+	claim, err := h.db.ClaimProposal(ctx, proposalID, userID, h.timeout, time.Now())
+	switch {
+	case errors.Is(err, postgres.ErrProposalClaimed):
+		WriteError(w, http.StatusConflict, "Proposal is claimed by "+claim.ClaimedBy, correlationID)
+		return
+	case errors.Is(err, postgres.ErrProposalNotPending):
+		WriteError(w, http.StatusConflict, "Proposal is not pending", correlationID)
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to claim proposal")
+		WriteError(w, http.StatusInternalServerError, "Failed to claim proposal", correlationID)
+		return
+	case claim == nil:
+		WriteError(w, http.StatusNotFound, "Proposal not found", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("proposal_id", proposalID).
+		Str("claimed_by", userID).
+		Time("expires_at", claim.ExpiresAt).
+		Msg("Proposal claimed")
+	h.publish(*claim, messages.ClaimClaimed, correlationID)
+
+	WriteJSON(w, http.StatusOK, ClaimResponse{Claim: *claim, CorrelationID: correlationID})
+}
+
+// Release handles DELETE /api/v1/proposals/{proposalId}/claim
+func (h *ClaimHandler) Release(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	proposalID := chi.URLParam(r, "proposalId")
+
+	userID, ok := claimUser(w, r)
+	if !ok {
+		return
+	}
+	if !h.visible(w, r, proposalID) {
+		return
+	}
+
+	claim, err := h.db.ReleaseProposalClaim(ctx, proposalID, userID, time.Now())
+	switch {
+	case errors.Is(err, postgres.ErrProposalClaimed):
+		WriteError(w, http.StatusConflict, "Proposal is claimed by "+claim.ClaimedBy, correlationID)
+		return
+	case err != nil:
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to release proposal claim")
+		WriteError(w, http.StatusInternalServerError, "Failed to release proposal claim", correlationID)
+		return
+	case claim == nil:
+		WriteError(w, http.StatusNotFound, "Proposal claim not found", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("proposal_id", proposalID).
+		Str("claimed_by", userID).
+		Msg("Proposal claim released")
+	h.publish(*claim, messages.ClaimReleased, correlationID)
+
+	WriteJSON(w, http.StatusOK, ClaimResponse{Claim: *claim, CorrelationID: correlationID})
+}
+
+// claimUser returns the operator claiming or releasing a proposal: the
+// authenticated caller, whom the optional body may only repeat, so one
+// operator cannot claim or release for another. If there is none, or the body
+// names someone else, it writes the error response and returns false.
+func claimUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req ClaimRequest
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body", GetCorrelationID(r.Context()))
+			return "", false
+		}
+	}
+	return actingUser(w, r, req.UserID, "user_id")
+}
+
+// visible reports whether the caller may see the proposal, writing 404 if not
+// so that the existence of proposals they may not see is not disclosed
+func (h *ClaimHandler) visible(w http.ResponseWriter, r *http.Request, proposalID string) bool {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	proposal, err := h.db.GetProposal(ctx, proposalID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to get proposal")
+		WriteError(w, http.StatusInternalServerError, "Failed to get proposal", correlationID)
+		return false
+	}
+	if proposal == nil || !GetAccess(ctx).Permits(proposal.SecurityLabel, proposal.Releasability) {
+		WriteError(w, http.StatusNotFound, "Proposal not found", correlationID)
+		return false
+	}
+	return true
+}
+
+// claimsOn returns the live claims on the given proposals by proposal ID
+func (h *ClaimHandler) claimsOn(ctx context.Context, proposalIDs []string) (map[string]postgres.ProposalClaim, error) {
+	return h.db.GetProposalClaims(ctx, proposalIDs, time.Now())
+}
+
+// releaseHeld releases userID's claim on a proposal, if they hold one, and
+// announces it. Approvers under the two-person rule hand the proposal on this
+// way so the second operator can decide it.
+func (h *ClaimHandler) releaseHeld(ctx context.Context, proposalID, userID, correlationID string) {
+	claim, err := h.db.ReleaseProposalClaim(ctx, proposalID, userID, time.Now())
+	if err != nil && !errors.Is(err, postgres.ErrProposalClaimed) {
+		h.logger.Warn().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to release proposal claim")
+		return
+	}
+	if err == nil && claim != nil {
+		h.publish(*claim, messages.ClaimReleased, correlationID)
+	}
+}
+
+// RunExpiry releases claims that lapsed without activity, or whose proposal
+// was decided or expired, and announces them until ctx is done. Decisions
+// already ignore lapsed claims; this keeps operators' views in step with them.
+func (h *ClaimHandler) RunExpiry(ctx context.Context) {
+	if h.db == nil {
+		return
+	}
+	ticker := time.NewTicker(ClaimExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			claims, err := h.db.ExpireProposalClaims(ctx, time.Now())
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Error().Err(err).Msg("Failed to expire proposal claims")
+				}
+				continue
+			}
+			for _, c := range claims {
+				h.logger.Info().
+					Str("proposal_id", c.ProposalID).
+					Str("claimed_by", c.ClaimedBy).
+					Time("last_active_at", c.LastActiveAt).
+					Msg("Proposal claim lapsed")
+				h.publish(c, messages.ClaimExpired, c.ProposalID)
+			}
+		}
+	}
+}
+
+// publish sends a claim notification to NATS for the UI
+func (h *ClaimHandler) publish(c postgres.ProposalClaim, event, correlationID string) {
+	if h.pub == nil {
+		return
+	}
+	n := &messages.ProposalClaimNotification{
+		Envelope:       messages.NewEnvelope("api-gateway", "api-gateway").WithCorrelation(correlationID, ""),
+		NotificationID: uuid.New().String(),
+		Event:          event,
+		ProposalID:     c.ProposalID,
+		ClaimedBy:      c.ClaimedBy,
+		ClaimedAt:      c.ClaimedAt,
+		ExpiresAt:      c.ExpiresAt,
+	}
+	if err := h.pub.Publish(n); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", n.Subject()).Msg("Failed to publish claim notification")
+	}
+}
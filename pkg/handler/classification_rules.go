@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// ClassificationRuleHandler handles classifier rule HTTP requests. The
+// classifier reloads the rules periodically, so edits apply without a restart.
+type ClassificationRuleHandler struct {
+	db     *postgres.Pool
+	logger zerolog.Logger
+}
+
+// NewClassificationRuleHandler creates a new ClassificationRuleHandler
+func NewClassificationRuleHandler(db *postgres.Pool, logger zerolog.Logger) *ClassificationRuleHandler {
+	return &ClassificationRuleHandler{
+		db:     db,
+		logger: logger.With().Str("handler", "classification_rules").Logger(),
+	}
+}
+
+// Routes returns the classification rule routes
+func (h *ClassificationRuleHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListClassificationRules)
+	r.Get("/{ruleId}", h.GetClassificationRule)
+	r.Post("/", h.CreateClassificationRule)
+	r.Put("/{ruleId}", h.UpdateClassificationRule)
+	r.Delete("/{ruleId}", h.DeleteClassificationRule)
+
+	return r
+}
+
+// ClassificationRuleResponse represents a classification rule in API responses
+type ClassificationRuleResponse struct {
+	RuleID          string    `json:"rule_id"`
+	Name            string    `json:"name"`
+	Description     *string   `json:"description,omitempty"`
+	Kind            string    `json:"kind"`
+	Result          string    `json:"result"`
+	MinSpeed        *float64  `json:"min_speed,omitempty"`
+	MaxSpeed        *float64  `json:"max_speed,omitempty"`
+	MinAlt          *float64  `json:"min_alt,omitempty"`
+	MaxAlt          *float64  `json:"max_alt,omitempty"`
+	MinConfidence   *float64  `json:"min_confidence,omitempty"`
+	MaxConfidence   *float64  `json:"max_confidence,omitempty"`
+	MinLat          *float64  `json:"min_lat,omitempty"`
+	MaxLat          *float64  `json:"max_lat,omitempty"`
+	MinLon          *float64  `json:"min_lon,omitempty"`
+	MaxLon          *float64  `json:"max_lon,omitempty"`
+	SensorTypes     []string  `json:"sensor_types"`
+	TrackIDPrefixes []string  `json:"track_id_prefixes"`
+	TrackTypes      []string  `json:"track_types"`
+	Enabled         bool      `json:"enabled"`
+	EvaluationOrder int       `json:"evaluation_order"`
+	CreatedBy       *string   `json:"created_by,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedBy       *string   `json:"updated_by,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ClassificationRuleListResponse represents the response for listing classification rules
+type ClassificationRuleListResponse struct {
+	Rules         []ClassificationRuleResponse `json:"rules"`
+	Total         int                          `json:"total"`
+	Limit         int                          `json:"limit"`
+	Offset        int                          `json:"offset"`
+	CorrelationID string                       `json:"correlation_id"`
+}
+
+// ClassificationRuleDetailResponse represents the detailed response for a single classification rule
+type ClassificationRuleDetailResponse struct {
+	Rule          ClassificationRuleResponse `json:"rule"`
+	CorrelationID string                     `json:"correlation_id"`
+}
+
+// ClassificationRuleRequest represents the request body for creating or updating a classification rule
+type ClassificationRuleRequest struct {
+	Name            string   `json:"name"`
+	Description     *string  `json:"description,omitempty"`
+	Kind            string   `json:"kind"`
+	Result          string   `json:"result"`
+	MinSpeed        *float64 `json:"min_speed,omitempty"`
+	MaxSpeed        *float64 `json:"max_speed,omitempty"`
+	MinAlt          *float64 `json:"min_alt,omitempty"`
+	MaxAlt          *float64 `json:"max_alt,omitempty"`
+	MinConfidence   *float64 `json:"min_confidence,omitempty"`
+	MaxConfidence   *float64 `json:"max_confidence,omitempty"`
+	MinLat          *float64 `json:"min_lat,omitempty"`
+	MaxLat          *float64 `json:"max_lat,omitempty"`
+	MinLon          *float64 `json:"min_lon,omitempty"`
+	MaxLon          *float64 `json:"max_lon,omitempty"`
+	SensorTypes     []string `json:"sensor_types"`
+	TrackIDPrefixes []string `json:"track_id_prefixes"`
+	TrackTypes      []string `json:"track_types"`
+	Enabled         bool     `json:"enabled"`
+	EvaluationOrder int      `json:"evaluation_order"`
+	User            *string  `json:"user,omitempty"`
+}
+
+// toClassificationRuleResponse converts a database row to an API response
+func toClassificationRuleResponse(r postgres.ClassificationRuleRow) ClassificationRuleResponse {
+	return ClassificationRuleResponse{
+		RuleID:          r.RuleID,
+		Name:            r.Name,
+		Description:     r.Description,
+		Kind:            r.Kind,
+		Result:          r.Result,
+		MinSpeed:        r.MinSpeed,
+		MaxSpeed:        r.MaxSpeed,
+		MinAlt:          r.MinAlt,
+		MaxAlt:          r.MaxAlt,
+		MinConfidence:   r.MinConfidence,
+		MaxConfidence:   r.MaxConfidence,
+		MinLat:          r.MinLat,
+		MaxLat:          r.MaxLat,
+		MinLon:          r.MinLon,
+		MaxLon:          r.MaxLon,
+		SensorTypes:     ensureSlice(r.SensorTypes),
+		TrackIDPrefixes: ensureSlice(r.TrackIDPrefixes),
+		TrackTypes:      ensureSlice(r.TrackTypes),
+		Enabled:         r.Enabled,
+		EvaluationOrder: r.EvaluationOrder,
+		CreatedBy:       r.CreatedBy,
+		CreatedAt:       r.CreatedAt,
+		UpdatedBy:       r.UpdatedBy,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+// toClassificationRuleRow validates a request and converts it to a database row
+func (req ClassificationRuleRequest) toClassificationRuleRow(ruleID string) (*postgres.ClassificationRuleRow, error) {
+	if req.EvaluationOrder == 0 {
+		req.EvaluationOrder = 100
+	}
+
+	row := &postgres.ClassificationRuleRow{
+		RuleID:          ruleID,
+		Name:            req.Name,
+		Description:     req.Description,
+		Kind:            req.Kind,
+		Result:          req.Result,
+		MinSpeed:        req.MinSpeed,
+		MaxSpeed:        req.MaxSpeed,
+		MinAlt:          req.MinAlt,
+		MaxAlt:          req.MaxAlt,
+		MinConfidence:   req.MinConfidence,
+		MaxConfidence:   req.MaxConfidence,
+		MinLat:          req.MinLat,
+		MaxLat:          req.MaxLat,
+		MinLon:          req.MinLon,
+		MaxLon:          req.MaxLon,
+		SensorTypes:     ensureSlice(req.SensorTypes),
+		TrackIDPrefixes: ensureSlice(req.TrackIDPrefixes),
+		TrackTypes:      ensureSlice(req.TrackTypes),
+		Enabled:         req.Enabled,
+		EvaluationOrder: req.EvaluationOrder,
+	}
+
+	if err := row.ToRule().Validate(); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// ListClassificationRules handles GET /api/v1/classification-rules
+func (h *ClassificationRuleHandler) ListClassificationRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	filter := postgres.ClassificationRuleFilter{
+		Kind: r.URL.Query().Get("kind"),
+	}
+
+	// Parse enabled filter
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		enabled := strings.ToLower(enabledStr) == "true"
+		filter.Enabled = &enabled
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			filter.Offset = offset
+		}
+	}
+
+	rules, err := h.db.ListClassificationRules(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list classification rules")
+		WriteError(w, http.StatusInternalServerError, "Failed to list classification rules", correlationID)
+		return
+	}
+
+	response := ClassificationRuleListResponse{
+		Rules:         make([]ClassificationRuleResponse, 0, len(rules)),
+		Total:         len(rules),
+		Limit:         filter.Limit,
+		Offset:        filter.Offset,
+		CorrelationID: correlationID,
+	}
+
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, toClassificationRuleResponse(rule))
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// GetClassificationRule handles GET /api/v1/classification-rules/{ruleId}
+func (h *ClassificationRuleHandler) GetClassificationRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	ruleID := chi.URLParam(r, "ruleId")
+
+	if ruleID == "" {
+		WriteError(w, http.StatusBadRequest, "Rule ID is required", correlationID)
+		return
+	}
+
+	rule, err := h.db.GetClassificationRule(ctx, ruleID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to get classification rule")
+		WriteError(w, http.StatusInternalServerError, "Failed to get classification rule", correlationID)
+		return
+	}
+
+	if rule == nil {
+		WriteError(w, http.StatusNotFound, "Classification rule not found", correlationID)
+		return
+	}
+
+	response := ClassificationRuleDetailResponse{
+		Rule:          toClassificationRuleResponse(*rule),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// CreateClassificationRule handles POST /api/v1/classification-rules
+func (h *ClassificationRuleHandler) CreateClassificationRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req ClassificationRuleRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	rule, err := req.toClassificationRuleRow(uuid.New().String())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	// Get user ID from request or context
+	rule.CreatedBy = req.User
+	if rule.CreatedBy == nil {
+		userID := GetUserID(ctx)
+		if userID != "" {
+			rule.CreatedBy = &userID
+		}
+	}
+	rule.UpdatedBy = rule.CreatedBy
+
+	if err := h.db.CreateClassificationRule(ctx, rule); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_name", req.Name).Msg("Failed to create classification rule")
+		// Check for unique constraint violation
+		if strings.Contains(err.Error(), "unique_classification_rule_name") || strings.Contains(err.Error(), "duplicate key") {
+			WriteError(w, http.StatusConflict, "A rule with this name already exists", correlationID)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "Failed to create classification rule", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("rule_id", rule.RuleID).
+		Str("rule_name", rule.Name).
+		Str("kind", rule.Kind).
+		Msg("Created classification rule")
+
+	response := ClassificationRuleDetailResponse{
+		Rule:          toClassificationRuleResponse(*rule),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusCreated, response)
+}
+
+// UpdateClassificationRule handles PUT /api/v1/classification-rules/{ruleId}
+func (h *ClassificationRuleHandler) UpdateClassificationRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	ruleID := chi.URLParam(r, "ruleId")
+
+	if ruleID == "" {
+		WriteError(w, http.StatusBadRequest, "Rule ID is required", correlationID)
+		return
+	}
+
+	var req ClassificationRuleRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	rule, err := req.toClassificationRuleRow(ruleID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	// Check if rule exists
+	existing, err := h.db.GetClassificationRule(ctx, ruleID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to get classification rule")
+		WriteError(w, http.StatusInternalServerError, "Failed to get classification rule", correlationID)
+		return
+	}
+
+	if existing == nil {
+		WriteError(w, http.StatusNotFound, "Classification rule not found", correlationID)
+		return
+	}
+
+	// Get user ID from request or context
+	rule.UpdatedBy = req.User
+	if rule.UpdatedBy == nil {
+		userID := GetUserID(ctx)
+		if userID != "" {
+			rule.UpdatedBy = &userID
+		}
+	}
+	rule.CreatedBy = existing.CreatedBy
+	rule.CreatedAt = existing.CreatedAt
+
+	if err := h.db.UpdateClassificationRule(ctx, rule); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to update classification rule")
+		// Check for unique constraint violation
+		if strings.Contains(err.Error(), "unique_classification_rule_name") || strings.Contains(err.Error(), "duplicate key") {
+			WriteError(w, http.StatusConflict, "A rule with this name already exists", correlationID)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "Failed to update classification rule", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("rule_id", rule.RuleID).
+		Str("rule_name", rule.Name).
+		Msg("Updated classification rule")
+
+	response := ClassificationRuleDetailResponse{
+		Rule:          toClassificationRuleResponse(*rule),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteClassificationRule handles DELETE /api/v1/classification-rules/{ruleId}
+func (h *ClassificationRuleHandler) DeleteClassificationRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	ruleID := chi.URLParam(r, "ruleId")
+
+	if ruleID == "" {
+		WriteError(w, http.StatusBadRequest, "Rule ID is required", correlationID)
+		return
+	}
+
+	if err := h.db.DeleteClassificationRule(ctx, ruleID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteError(w, http.StatusNotFound, "Classification rule not found", correlationID)
+			return
+		}
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to delete classification rule")
+		WriteError(w, http.StatusInternalServerError, "Failed to delete classification rule", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("rule_id", ruleID).
+		Msg("Deleted classification rule")
+
+	WriteSuccess(w, http.StatusOK, "Classification rule deleted successfully", nil, correlationID)
+}
@@ -32,6 +32,8 @@ func (h *ClassifierHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/config", h.GetConfig)
 	r.Patch("/config", h.PatchConfig)
+	r.Get("/rules", h.GetRules)
+	r.Post("/rules/reload", h.ReloadRules)
 	return r
 }
 
@@ -40,21 +42,19 @@ func (h *ClassifierHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.client.Get(h.classifierURL + "/api/v1/config")
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to reach classifier agent")
-		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", "")
+		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", GetCorrelationID(r.Context()))
 		return
 	}
 	defer resp.Body.Close()
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	copyResponse(w, resp)
 }
 
 // PatchConfig proxies PATCH /api/v1/classifier/config to the classifier agent
 func (h *ClassifierHandler) PatchConfig(w http.ResponseWriter, r *http.Request) {
 	req, err := http.NewRequest("PATCH", h.classifierURL+"/api/v1/config", r.Body)
 	if err != nil {
-		WriteError(w, http.StatusInternalServerError, "Failed to create request", "")
+		WriteError(w, http.StatusInternalServerError, "Failed to create request", GetCorrelationID(r.Context()))
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -62,12 +62,48 @@ func (h *ClassifierHandler) PatchConfig(w http.ResponseWriter, r *http.Request)
 	resp, err := h.client.Do(req)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("Failed to reach classifier agent")
-		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", "")
+		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", GetCorrelationID(r.Context()))
 		return
 	}
 	defer resp.Body.Close()
 
-	w.Header().Set("Content-Type", "application/json")
+	copyResponse(w, resp)
+}
+
+// GetRules proxies GET /api/v1/classifier/rules to the classifier agent
+func (h *ClassifierHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Get(h.classifierURL + "/api/v1/rules")
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to reach classifier agent")
+		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", GetCorrelationID(r.Context()))
+		return
+	}
+	defer resp.Body.Close()
+
+	copyResponse(w, resp)
+}
+
+// ReloadRules proxies POST /api/v1/classifier/rules/reload to the classifier agent
+func (h *ClassifierHandler) ReloadRules(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.client.Post(h.classifierURL+"/api/v1/rules/reload", "application/json", nil)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("Failed to reach classifier agent")
+		WriteError(w, http.StatusBadGateway, "Failed to reach classifier agent", GetCorrelationID(r.Context()))
+		return
+	}
+	defer resp.Body.Close()
+
+	copyResponse(w, resp)
+}
+
+// copyResponse relays an agent response, keeping its content type so agent
+// errors reach the client as problem+json
+func copyResponse(w http.ResponseWriter, resp *http.Response) {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(resp.StatusCode)
 	io.Copy(w, resp.Body)
 }
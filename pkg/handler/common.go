@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
+
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
 )
 
 // Context keys for request-scoped values
@@ -14,6 +18,7 @@ type contextKey string
 const (
 	correlationIDKey contextKey = "correlation_id"
 	userIDKey        contextKey = "user_id"
+	accessKey        contextKey = "access"
 )
 
 // WithCorrelationID adds a correlation ID to the context
@@ -42,11 +47,88 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
-// ErrorResponse represents a structured error response
-type ErrorResponse struct {
-	Error         string `json:"error"`
-	Message       string `json:"message"`
-	CorrelationID string `json:"correlation_id"`
+// actingUser returns the user a request acts for: the authenticated user, whom
+// a user named in the request body may only repeat, or without an
+// authenticating proxy the user named. It writes 403 for a body naming
+// someone else and 400 when there is no user, and returns false.
+func actingUser(w http.ResponseWriter, r *http.Request, named, field string) (string, bool) {
+	correlationID := GetCorrelationID(r.Context())
+	userID := GetUserID(r.Context())
+	switch {
+	case userID == "":
+		userID = named
+	case named != "" && named != userID:
+		WriteError(w, http.StatusForbidden, field+" "+named+" is not the authenticated user "+userID, correlationID)
+		return "", false
+	}
+	if userID == "" {
+		WriteError(w, http.StatusBadRequest, field+" is required", correlationID)
+		return "", false
+	}
+	return userID, true
+}
+
+// UserIDHeader carries the authenticated user's ID, set by the authenticating proxy in front of the gateway
+const UserIDHeader = "X-User-ID"
+
+// UserIDMiddleware adds the user ID from UserIDHeader to the request context
+func UserIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID := strings.TrimSpace(r.Header.Get(UserIDHeader)); userID != "" {
+			r = r.WithContext(WithUserID(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Caller attribute headers, set by the authenticating proxy from the caller's
+// token. Callers without them see every row.
+const (
+	ClearanceHeader     = "X-User-Clearance"     // Highest classification the caller is cleared for
+	ReleasabilityHeader = "X-User-Releasability" // Comma-separated partner tags, e.g. GBR,FVEY, of a coalition caller
+)
+
+// WithAccess adds the caller's security access to the context
+func WithAccess(ctx context.Context, access seclabel.Access) context.Context {
+	return context.WithValue(ctx, accessKey, access)
+}
+
+// GetAccess retrieves the caller's security access from the context;
+// unrestricted when none was set
+func GetAccess(ctx context.Context) seclabel.Access {
+	if access, ok := ctx.Value(accessKey).(seclabel.Access); ok {
+		return access
+	}
+	return seclabel.Access{}
+}
+
+// AccessMiddleware adds the caller's security access from ClearanceHeader and
+// ReleasabilityHeader to the request context. Malformed attributes are
+// refused rather than ignored, which would show the caller every row.
+func AccessMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access, err := seclabel.ParseAccess(r.Header.Get(ClearanceHeader), r.Header.Get(ReleasabilityHeader))
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid caller attributes: "+err.Error(), GetCorrelationID(r.Context()))
+			return
+		}
+		if access.Restricted() {
+			r = r.WithContext(WithAccess(r.Context(), access))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireUnrestricted refuses callers with restricted access, for endpoints
+// such as the live feeds that cannot filter what they send by security label
+func RequireUnrestricted(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetAccess(r.Context()).Restricted() {
+			WriteError(w, http.StatusForbidden, r.URL.Path+" is not filtered by security label and is not available to restricted callers", GetCorrelationID(r.Context()))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // WriteJSON writes a JSON response with the given status code
@@ -58,29 +140,19 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
-// WriteError writes a JSON error response
+// WriteError writes a problem+json error response classified by its status
 func WriteError(w http.ResponseWriter, status int, message, correlationID string) {
-	errorType := "internal_error"
-	switch status {
-	case http.StatusBadRequest:
-		errorType = "bad_request"
-	case http.StatusNotFound:
-		errorType = "not_found"
-	case http.StatusUnauthorized:
-		errorType = "unauthorized"
-	case http.StatusForbidden:
-		errorType = "forbidden"
-	case http.StatusConflict:
-		errorType = "conflict"
-	case http.StatusUnprocessableEntity:
-		errorType = "validation_error"
-	}
+	p := problem.ForStatus(status, message)
+	p.CorrelationID = correlationID
+	problem.Write(w, p)
+}
 
-	WriteJSON(w, status, ErrorResponse{
-		Error:         errorType,
-		Message:       message,
-		CorrelationID: correlationID,
-	})
+// WriteProblem writes a problem+json error response with an explicit code,
+// for errors whose status alone does not say what went wrong
+func WriteProblem(w http.ResponseWriter, code problem.Code, message, correlationID string) {
+	p := problem.New(code, message)
+	p.CorrelationID = correlationID
+	problem.Write(w, p)
 }
 
 // DecodeJSON decodes JSON from the request body
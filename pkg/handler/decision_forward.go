@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// Ways the gateway can forward a decision to the authorizer
+const (
+	DecisionForwardNATS = "nats" // Request/reply on messages.DecisionCommandSubject
+	DecisionForwardHTTP = "http" // POST to the authorizer's /api/decisions
+)
+
+// DefaultDecisionForwardTimeout bounds one forwarded decision
+const DefaultDecisionForwardTimeout = 10 * time.Second
+
+// ErrNoAuthorizer is returned when no authorizer answers a forwarded decision
+var ErrNoAuthorizer = natsutil.ErrNoAuthorizer
+
+// DecisionForwardConfig controls how decisions reach the authorizer
+type DecisionForwardConfig struct {
+	Mode          string
+	AuthorizerURL string
+	Timeout       time.Duration
+}
+
+// ParseDecisionForwardConfig parses DECISION_FORWARD, AUTHORIZER_URL, and
+// DECISION_FORWARD_TIMEOUT, using NATS, the compose authorizer URL, and
+// DefaultDecisionForwardTimeout for unset values
+func ParseDecisionForwardConfig(mode, authorizerURL, timeout string) (DecisionForwardConfig, error) {
+	cfg := DecisionForwardConfig{
+		Mode:          DecisionForwardNATS,
+		AuthorizerURL: DefaultAgentURLs["authorizer"],
+		Timeout:       DefaultDecisionForwardTimeout,
+	}
+	switch mode {
+	case "":
+	case DecisionForwardNATS, DecisionForwardHTTP:
+		cfg.Mode = mode
+	default:
+		return cfg, fmt.Errorf("invalid DECISION_FORWARD %q: must be %s or %s", mode, DecisionForwardNATS, DecisionForwardHTTP)
+	}
+	if authorizerURL != "" {
+		cfg.AuthorizerURL = strings.TrimRight(authorizerURL, "/")
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid DECISION_FORWARD_TIMEOUT %q: must be a positive duration", timeout)
+		}
+		cfg.Timeout = d
+	}
+	return cfg, nil
+}
+
+// DecisionForwarder submits a decision command to the authorizer and returns its reply
+type DecisionForwarder interface {
+	Forward(ctx context.Context, cmd messages.DecisionCommand) (messages.DecisionCommandReply, error)
+}
+
+// NewDecisionForwarder creates the forwarder cfg selects. nc may be nil for HTTP forwarding.
+func NewDecisionForwarder(cfg DecisionForwardConfig, nc *nats.Conn) DecisionForwarder {
+	if cfg.Mode == DecisionForwardHTTP {
+		return &HTTPDecisionForwarder{url: cfg.AuthorizerURL, client: &http.Client{Timeout: cfg.Timeout}}
+	}
+	return &NATSDecisionForwarder{nc: nc, timeout: cfg.Timeout}
+}
+
+// NATSDecisionForwarder forwards decisions over NATS request/reply
+type NATSDecisionForwarder struct {
+	nc      *nats.Conn
+	timeout time.Duration
+}
+
+// Forward sends the command on messages.DecisionCommandSubject and waits for a reply
+func (f *NATSDecisionForwarder) Forward(ctx context.Context, cmd messages.DecisionCommand) (messages.DecisionCommandReply, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	return natsutil.RequestDecision(ctx, f.nc, cmd)
+}
+
+// HTTPDecisionForwarder forwards decisions to the authorizer's HTTP API
+type HTTPDecisionForwarder struct {
+	url    string
+	client *http.Client
+}
+
+// Forward posts the command to the authorizer's /api/decisions endpoint, which
+// answers with the same reply as the NATS interface
+func (f *HTTPDecisionForwarder) Forward(ctx context.Context, cmd messages.DecisionCommand) (messages.DecisionCommandReply, error) {
+	var reply messages.DecisionCommandReply
+
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return reply, fmt.Errorf("failed to marshal decision command: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url+"/api/decisions", bytes.NewReader(body))
+	if err != nil {
+		return reply, fmt.Errorf("failed to create authorizer request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Correlation-ID", cmd.Envelope.CorrelationID)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return reply, fmt.Errorf("%w: %v", ErrNoAuthorizer, err)
+	}
+	defer resp.Body.Close()
+
+	// Replies are JSON; fall back to the body text for errors from elsewhere
+	// such as a proxy in front of the authorizer
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if p, ok := problem.Parse(resp.Header.Get("Content-Type"), text); ok {
+		reply = messages.DecisionCommandReply{
+			Status:    messages.DecisionCommandRejected,
+			Error:     p.Detail,
+			ErrorCode: string(p.Code),
+		}
+	} else if err := json.Unmarshal(text, &reply); err != nil || reply.Status == "" {
+		reply = messages.DecisionCommandReply{
+			Status: messages.DecisionCommandRejected,
+			Error:  strings.TrimSpace(string(text)),
+		}
+	}
+	reply.Code = resp.StatusCode
+	return reply, nil
+}
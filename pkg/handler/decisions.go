@@ -2,7 +2,6 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,8 +12,9 @@ import (
 
 // DecisionHandler handles decision-related HTTP requests
 type DecisionHandler struct {
-	db     *postgres.Pool
-	logger zerolog.Logger
+	db        *postgres.Pool
+	proposals *ProposalHandler // Records decisions; nil leaves POST / unavailable
+	logger    zerolog.Logger
 }
 
 // NewDecisionHandler creates a new DecisionHandler
@@ -25,11 +25,17 @@ func NewDecisionHandler(db *postgres.Pool, logger zerolog.Logger) *DecisionHandl
 	}
 }
 
+// SetWriter sets the proposal handler that records decisions posted to POST /
+func (h *DecisionHandler) SetWriter(p *ProposalHandler) {
+	h.proposals = p
+}
+
 // Routes returns the decision routes
 func (h *DecisionHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 
 	r.Get("/", h.ListDecisions)
+	r.Post("/", h.CreateDecision)
 
 	return r
 }
@@ -37,9 +43,10 @@ func (h *DecisionHandler) Routes() chi.Router {
 // DecisionListResponse represents the response for listing decisions
 type DecisionListResponse struct {
 	Decisions     []DecisionAuditResponse `json:"decisions"`
-	Total         int                     `json:"total"`
+	Total         int64                   `json:"total"` // Matching decisions across all pages
 	Limit         int                     `json:"limit"`
 	Offset        int                     `json:"offset"`
+	NextCursor    string                  `json:"next_cursor,omitempty"`
 	CorrelationID string                  `json:"correlation_id"`
 }
 
@@ -55,11 +62,51 @@ type DecisionAuditResponse struct {
 	Reason     string    `json:"reason,omitempty"`
 	Conditions []string  `json:"conditions,omitempty"`
 
+	// Set when an intervention rule approved the proposal without an operator
+	MachineApproved   bool   `json:"machine_approved"`
+	AutoApproveRuleID string `json:"auto_approve_rule_id,omitempty"`
+
+	// Set when the decision was revoked before its effect executed
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy    string     `json:"revoked_by,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+
 	// Audit fields
 	CorrelationID string    `json:"correlation_id"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+// CreateDecisionRequest is the body of POST /api/v1/decisions: a decision
+// naming the proposal it decides
+type CreateDecisionRequest struct {
+	ProposalID string `json:"proposal_id"`
+	DecisionRequest
+}
+
+// CreateDecision handles POST /api/v1/decisions. It records the decision as
+// POST /api/v1/proposals/{proposalId}/decide does, answering 409 Conflict if
+// the proposal has already been decided or has expired.
+func (h *DecisionHandler) CreateDecision(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	if h.proposals == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Decision recording is not configured", correlationID)
+		return
+	}
+
+	var req CreateDecisionRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	if req.ProposalID == "" {
+		WriteError(w, http.StatusBadRequest, "proposal_id is required", correlationID)
+		return
+	}
+
+	h.proposals.decide(w, r, req.ProposalID, req.DecisionRequest)
+}
+
 // ListDecisions handles GET /api/v1/decisions
 func (h *DecisionHandler) ListDecisions(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -76,20 +123,18 @@ func (h *DecisionHandler) ListDecisions(w http.ResponseWriter, r *http.Request)
 		filter.Approved = &approved
 	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filter.Limit = limit
-		}
-	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
+	if machineStr := r.URL.Query().Get("machine_approved"); machineStr != "" {
+		machine := machineStr == "true"
+		filter.MachineApproved = &machine
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filter.Offset = offset
-		}
+	page, err := ParsePage(r, postgres.DecisionSort)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
 	}
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+	filter.Sort, filter.After = page.Sort, page.After
 
 	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
 		if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
@@ -103,17 +148,26 @@ func (h *DecisionHandler) ListDecisions(w http.ResponseWriter, r *http.Request)
 		WriteError(w, http.StatusInternalServerError, "Failed to list decisions", correlationID)
 		return
 	}
+	total, err := h.db.CountFilteredDecisions(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to count decisions")
+		WriteError(w, http.StatusInternalServerError, "Failed to list decisions", correlationID)
+		return
+	}
+	next := page.NextCursor(len(decisions), func() string { return decisions[len(decisions)-1].Cursor })
+	WritePageHeaders(w, r, total, next)
 
 	response := DecisionListResponse{
 		Decisions:     make([]DecisionAuditResponse, 0, len(decisions)),
-		Total:         len(decisions),
+		Total:         total,
 		Limit:         filter.Limit,
 		Offset:        filter.Offset,
+		NextCursor:    next,
 		CorrelationID: correlationID,
 	}
 
 	for _, d := range decisions {
-		response.Decisions = append(response.Decisions, DecisionAuditResponse{
+		item := DecisionAuditResponse{
 			DecisionID:    d.DecisionID,
 			ProposalID:    d.ProposalID,
 			TrackID:       d.TrackID,
@@ -125,7 +179,20 @@ func (h *DecisionHandler) ListDecisions(w http.ResponseWriter, r *http.Request)
 			Conditions:    d.Conditions,
 			CorrelationID: correlationID,
 			CreatedAt:     d.CreatedAt,
-		})
+
+			MachineApproved: d.MachineApproved,
+			RevokedAt:       d.RevokedAt,
+		}
+		if d.AutoApproveRuleID != nil {
+			item.AutoApproveRuleID = *d.AutoApproveRuleID
+		}
+		if d.RevokedBy != nil {
+			item.RevokedBy = *d.RevokedBy
+		}
+		if d.RevokeReason != nil {
+			item.RevokeReason = *d.RevokeReason
+		}
+		response.Decisions = append(response.Decisions, item)
 	}
 
 	WriteJSON(w, http.StatusOK, response)
@@ -157,9 +224,10 @@ func (h *EffectHandler) Routes() chi.Router {
 // EffectListResponse represents the response for listing effects
 type EffectListResponse struct {
 	Effects       []EffectResponse `json:"effects"`
-	Total         int              `json:"total"`
+	Total         int64            `json:"total"` // Matching effects across all pages
 	Limit         int              `json:"limit"`
 	Offset        int              `json:"offset"`
+	NextCursor    string           `json:"next_cursor,omitempty"`
 	CorrelationID string           `json:"correlation_id"`
 }
 
@@ -174,6 +242,9 @@ type EffectResponse struct {
 	ExecutedAt    time.Time `json:"executed_at"`
 	Result        string    `json:"result"`
 	IdempotentKey string    `json:"idempotent_key"`
+	FencingToken  *int64    `json:"fencing_token,omitempty"`
+	ExecutorID    *string   `json:"executor_id,omitempty"`
+	Attempts      int       `json:"attempts"` // Execution attempts made under the retry policy
 }
 
 // ListEffects handles GET /api/v1/effects
@@ -189,20 +260,13 @@ func (h *EffectHandler) ListEffects(w http.ResponseWriter, r *http.Request) {
 		Status:     r.URL.Query().Get("status"),
 	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filter.Limit = limit
-		}
-	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filter.Offset = offset
-		}
+	page, err := ParsePage(r, postgres.EffectSort)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
 	}
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+	filter.Sort, filter.After = page.Sort, page.After
 
 	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
 		if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
@@ -216,12 +280,21 @@ func (h *EffectHandler) ListEffects(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, http.StatusInternalServerError, "Failed to list effects", correlationID)
 		return
 	}
+	total, err := h.db.CountFilteredEffects(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to count effects")
+		WriteError(w, http.StatusInternalServerError, "Failed to list effects", correlationID)
+		return
+	}
+	next := page.NextCursor(len(effects), func() string { return effects[len(effects)-1].Cursor })
+	WritePageHeaders(w, r, total, next)
 
 	response := EffectListResponse{
 		Effects:       make([]EffectResponse, 0, len(effects)),
-		Total:         len(effects),
+		Total:         total,
 		Limit:         filter.Limit,
 		Offset:        filter.Offset,
+		NextCursor:    next,
 		CorrelationID: correlationID,
 	}
 
@@ -236,6 +309,9 @@ func (h *EffectHandler) ListEffects(w http.ResponseWriter, r *http.Request) {
 			ExecutedAt:    e.ExecutedAt,
 			Result:        e.Result,
 			IdempotentKey: e.IdempotentKey,
+			FencingToken:  e.FencingToken,
+			ExecutorID:    e.ExecutorID,
+			Attempts:      e.Attempts,
 		})
 	}
 
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// MessageTypeEngagementCompleted follows each effect log with the proposal and
+// track it was taken against, so clients can show the outcome on the right
+// track without fetching either
+const MessageTypeEngagementCompleted = "engagement.completed"
+
+// engagementTimeout bounds the lookups for one engagement.completed event
+const engagementTimeout = 2 * time.Second
+
+// EngagementStore loads the proposal and track an effect was taken against
+type EngagementStore interface {
+	GetProposal(ctx context.Context, proposalID string) (*postgres.ProposalRow, error)
+	GetTrack(ctx context.Context, trackID string) (*postgres.TrackRow, error)
+}
+
+// EngagementCompleted is the payload of an engagement.completed event. It
+// carries the effect log's envelope, so the event shares its correlation ID.
+// Proposal and Track are omitted when they are no longer stored.
+type EngagementCompleted struct {
+	Envelope   messages.Envelope `json:"envelope"`
+	EffectID   string            `json:"effect_id"`
+	DecisionID string            `json:"decision_id"`
+	ProposalID string            `json:"proposal_id"`
+	TrackID    string            `json:"track_id"`
+	ActionType string            `json:"action_type"`
+	Status     string            `json:"status"`
+	Result     string            `json:"result"`
+	ExecutedAt time.Time         `json:"executed_at"`
+	Proposal   *ProposalResponse `json:"proposal,omitempty"`
+	Track      *TrackResponse    `json:"track,omitempty"`
+}
+
+// SetEngagementStore follows every effect log with an engagement.completed
+// event built from the store
+func (h *WebSocketHub) SetEngagementStore(store EngagementStore) {
+	h.engagements = store
+}
+
+// announceEngagement broadcasts the engagement.completed event for an effect
+// log. Replayed effects were announced when they first ran and are skipped.
+func (h *WebSocketHub) announceEngagement(ctx context.Context, data []byte) {
+	var effect messages.EffectLog
+	if err := json.Unmarshal(data, &effect); err != nil || effect.EffectID == "" || effect.Idempotent {
+		return
+	}
+
+	payload, err := BuildEngagementCompleted(ctx, h.engagements, &effect)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("effect_id", effect.EffectID).Msg("Failed to load engagement for effect")
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	ev, err := NewEvent(MessageTypeEngagementCompleted, raw, time.Now().UTC())
+	if err != nil {
+		return
+	}
+
+	select {
+	case h.broadcast <- ev:
+	default:
+		h.logger.Warn().Str("effect_id", effect.EffectID).Msg("Broadcast buffer full, dropping engagement")
+	}
+}
+
+// BuildEngagementCompleted joins an effect log with its proposal and track. A
+// failed lookup is returned alongside whatever could be loaded, so the event
+// is still sent.
+func BuildEngagementCompleted(ctx context.Context, store EngagementStore, effect *messages.EffectLog) (EngagementCompleted, error) {
+	ctx, cancel := context.WithTimeout(ctx, engagementTimeout)
+	defer cancel()
+
+	ec := EngagementCompleted{
+		Envelope:   effect.Envelope,
+		EffectID:   effect.EffectID,
+		DecisionID: effect.DecisionID,
+		ProposalID: effect.ProposalID,
+		TrackID:    effect.TrackID,
+		ActionType: effect.ActionType,
+		Status:     effect.Status,
+		Result:     effect.Result,
+		ExecutedAt: effect.ExecutedAt,
+	}
+
+	proposal, err := store.GetProposal(ctx, effect.ProposalID)
+	if err != nil {
+		return ec, err
+	}
+	if proposal != nil {
+		pr := newProposalResponse(proposal, time.Now())
+		ec.Proposal = &pr
+	}
+
+	track, err := store.GetTrack(ctx, effect.TrackID)
+	if err != nil {
+		return ec, err
+	}
+	if track != nil {
+		tr := newTrackResponse(track)
+		ec.Track = &tr
+	}
+	return ec, nil
+}
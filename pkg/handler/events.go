@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outbound schema versions a WebSocket or SSE client can request with
+// ?schema_version
+const (
+	SchemaVersionLegacy = 1 // WebSocketMessage carrying the raw NATS payload
+	SchemaVersionEvents = 2 // Event envelopes
+
+	// DefaultSchemaVersion is sent to clients that do not ask for a version,
+	// so clients written before events keep working
+	DefaultSchemaVersion = SchemaVersionLegacy
+	// CurrentSchemaVersion is the newest version the gateway speaks
+	CurrentSchemaVersion = SchemaVersionEvents
+)
+
+// SupportedSchemaVersions lists every outbound schema version, oldest first
+var SupportedSchemaVersions = []int{SchemaVersionLegacy, SchemaVersionEvents}
+
+// MessageTypeConnectionStatus is sent once when a client connects and reports
+// the schema the gateway will use for it
+const MessageTypeConnectionStatus = "connection.status"
+
+// EventVersions is the payload version of each event type. A version is
+// bumped when a payload changes incompatibly, so clients can ignore events
+// newer than they understand.
+var EventVersions = map[string]int{
+	MessageTypeTrackUpdate:         1,
+	MessageTypeTrackNew:            1,
+	MessageTypeTrackStale:          1,
+	MessageTypeTrackDelete:         1,
+	MessageTypeTrackMerged:         1,
+	MessageTypeTrackSplit:          1,
+	MessageTypeProposalNew:         1,
+	MessageTypeProposalEscalated:   1,
+	MessageTypeProposalSLA:         1,
+	MessageTypeProposalRescored:    1,
+	MessageTypeBreakGlass:          1,
+	MessageTypeDecisionMade:        1,
+	MessageTypeDecisionRevoked:     1,
+	MessageTypeEffectExecuted:      1,
+	MessageTypeEffectProgress:      1,
+	MessageTypeEffectAssessment:    1,
+	MessageTypeEngagementCompleted: 1,
+	MessageTypeSimControl:          1,
+	MessageTypeAdmissionShed:       1,
+	MessageTypeMetricsUpdate:       1,
+	MessageTypeStreamReset:         1,
+	MessageTypeSessionReplay:       1,
+	MessageTypeConnectionStatus:    1,
+	MessageTypePictureSnapshot:     1,
+	MessageTypePing:                1,
+	MessageTypeError:               1,
+}
+
+// Event is the typed envelope every outbound message is sent in. The routing
+// and identity fields of the NATS message's envelope are lifted into the
+// event, so clients can filter and trace without decoding the payload.
+type Event struct {
+	SchemaVersion int             `json:"schema_version"`
+	EventType     string          `json:"event_type"`
+	Version       int             `json:"version"`
+	Seq           uint64          `json:"seq,omitempty"` // Broadcast sequence number; zero for events sent to one client
+	TS            time.Time       `json:"ts"`            // When the source message was created, else when the gateway received it
+	MessageID     string          `json:"message_id,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	CausationID   string          `json:"causation_id,omitempty"`
+	Source        string          `json:"source,omitempty"`
+	SourceType    string          `json:"source_type,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Replay        *EventReplay    `json:"replay,omitempty"` // Set on events re-broadcast from a recorded session
+
+	received time.Time // Sent as the legacy timestamp
+}
+
+// NewEvent transforms a raw message into an event. received is when the
+// gateway got the message. A payload that is not valid JSON is rejected,
+// since it could not be embedded in the event.
+func NewEvent(eventType string, payload json.RawMessage, received time.Time) (Event, error) {
+	ev := Event{
+		SchemaVersion: CurrentSchemaVersion,
+		EventType:     eventType,
+		Version:       EventVersions[eventType],
+		TS:            received,
+		Payload:       payload,
+		received:      received,
+	}
+	if ev.Version == 0 {
+		ev.Version = 1
+	}
+	if len(payload) == 0 {
+		return ev, nil
+	}
+	if !json.Valid(payload) {
+		return ev, fmt.Errorf("invalid %s payload: not JSON", eventType)
+	}
+
+	var msg struct {
+		Envelope struct {
+			MessageID     string    `json:"message_id"`
+			CorrelationID string    `json:"correlation_id"`
+			CausationID   string    `json:"causation_id"`
+			Source        string    `json:"source"`
+			SourceType    string    `json:"source_type"`
+			Timestamp     time.Time `json:"timestamp"`
+		} `json:"envelope"`
+	}
+	if err := json.Unmarshal(payload, &msg); err == nil {
+		env := msg.Envelope
+		ev.MessageID = env.MessageID
+		ev.CorrelationID = env.CorrelationID
+		ev.CausationID = env.CausationID
+		ev.Source = env.Source
+		ev.SourceType = env.SourceType
+		if !env.Timestamp.IsZero() {
+			ev.TS = env.Timestamp.UTC()
+		}
+	}
+	return ev, nil
+}
+
+// newControlEvent creates an event generated by the gateway itself
+func newControlEvent(eventType string, payload interface{}) Event {
+	now := time.Now().UTC()
+	ev, _ := NewEvent(eventType, nil, now)
+	if payload != nil {
+		ev.Payload, _ = json.Marshal(payload)
+	}
+	return ev
+}
+
+// Legacy converts the event to the schema version 1 message
+func (e Event) Legacy() WebSocketMessage {
+	return WebSocketMessage{
+		Type:          e.EventType,
+		Payload:       e.Payload,
+		Timestamp:     e.received,
+		CorrelationID: e.CorrelationID,
+	}
+}
+
+// Encode returns the event in the given schema version, ready to marshal
+func (e Event) Encode(schemaVersion int) interface{} {
+	if schemaVersion == SchemaVersionLegacy {
+		return e.Legacy()
+	}
+	return e
+}
+
+// ParseSchemaVersion parses a client's requested schema version; empty
+// selects DefaultSchemaVersion
+func ParseSchemaVersion(param string) (int, error) {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return DefaultSchemaVersion, nil
+	}
+	v, err := strconv.Atoi(param)
+	if err == nil {
+		for _, supported := range SupportedSchemaVersions {
+			if v == supported {
+				return v, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported schema_version %q: supported versions are %s", param, formatSchemaVersions())
+}
+
+// formatSchemaVersions lists SupportedSchemaVersions for error messages
+func formatSchemaVersions() string {
+	parts := make([]string, len(SupportedSchemaVersions))
+	for i, v := range SupportedSchemaVersions {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ConnectionStatus is the payload of the connection.status event, telling a
+// client which schema it will receive and what else the gateway supports
+type ConnectionStatus struct {
+	ClientID                string         `json:"client_id"`
+	SchemaVersion           int            `json:"schema_version"`
+	CurrentSchemaVersion    int            `json:"current_schema_version"`
+	SupportedSchemaVersions []int          `json:"supported_schema_versions"`
+	EventVersions           map[string]int `json:"event_versions"`
+}
+
+// newConnectionStatus creates the connection.status event for a client
+func newConnectionStatus(clientID string, schemaVersion int) Event {
+	return newControlEvent(MessageTypeConnectionStatus, ConnectionStatus{
+		ClientID:                clientID,
+		SchemaVersion:           schemaVersion,
+		CurrentSchemaVersion:    CurrentSchemaVersion,
+		SupportedSchemaVersions: SupportedSchemaVersions,
+		EventVersions:           EventVersions,
+	})
+}
@@ -0,0 +1,420 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// DefaultResetSettle is how long a reset waits after the first purge for agents
+// to finish messages they had already fetched
+const DefaultResetSettle = 2 * time.Second
+
+// MaxResetSettle bounds a requested settle period
+const MaxResetSettle = 10 * time.Second
+
+// ProtectedKVBuckets can never be reset. The effector lease carries fencing
+// tokens that must keep increasing across exercises.
+var ProtectedKVBuckets = map[string]bool{
+	"EFFECTOR_LEASE": true,
+}
+
+// ProtectedStreams can never be reset. SIMCONTROL holds the simulation clock
+// state that agents joining later depend on, and AUDIT is the compliance
+// record of decisions and effects.
+var ProtectedStreams = map[string]bool{
+	"SIMCONTROL":         true,
+	natsutil.AuditStream: true,
+}
+
+// Exercise reset step statuses
+const (
+	ResetStepOK      = "ok"
+	ResetStepFailed  = "failed"
+	ResetStepSkipped = "skipped"
+)
+
+// ExerciseHandler resets the whole pipeline between exercises
+type ExerciseHandler struct {
+	db        *postgres.Pool
+	js        jetstream.JetStream
+	sensorURL string
+	client    *http.Client
+	logger    zerolog.Logger
+
+	resetMu sync.Mutex // Held for the duration of a reset
+}
+
+// NewExerciseHandler creates a new ExerciseHandler. js may be nil when the
+// gateway has no NATS connection, in which case resets are refused.
+func NewExerciseHandler(db *postgres.Pool, js jetstream.JetStream, sensorURL string, logger zerolog.Logger) *ExerciseHandler {
+	return &ExerciseHandler{
+		db:        db,
+		js:        js,
+		sensorURL: sensorURL,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		logger: logger.With().Str("handler", "exercise").Logger(),
+	}
+}
+
+// Routes returns the exercise routes
+func (h *ExerciseHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/reset", h.Reset)
+	return r
+}
+
+// ExerciseResetRequest selects what an exercise reset clears. Every field is optional.
+type ExerciseResetRequest struct {
+	Streams   []string               `json:"streams,omitempty"`    // Defaults to every pipeline stream
+	Tables    []string               `json:"tables,omitempty"`     // Defaults to the exercise data tables
+	KVBuckets []string               `json:"kv_buckets,omitempty"` // Buckets whose keys are purged
+	Scenario  map[string]interface{} `json:"scenario,omitempty"`   // Sensor config fields applied on top of the defaults
+	Resume    *bool                  `json:"resume,omitempty"`     // Resume emission afterwards (default true)
+	SettleMS  *int                   `json:"settle_ms,omitempty"`
+}
+
+// ExerciseResetStep reports one step of a reset
+type ExerciseResetStep struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// ExerciseResetResponse represents the response for an exercise reset
+type ExerciseResetResponse struct {
+	Success       bool                `json:"success"`
+	Message       string              `json:"message"`
+	Steps         []ExerciseResetStep `json:"steps"`
+	Purged        map[string]uint64   `json:"purged"`  // Messages discarded per stream
+	Deleted       map[string]int64    `json:"deleted"` // Rows deleted per table
+	KVKeysPurged  map[string]int      `json:"kv_keys_purged,omitempty"`
+	Resumed       bool                `json:"resumed"`
+	CorrelationID string              `json:"correlation_id"`
+}
+
+// exerciseReset is a validated reset plan
+type exerciseReset struct {
+	streams   []string
+	tables    []string
+	kvBuckets []string
+	scenario  map[string]interface{}
+	resume    bool
+	settle    time.Duration
+}
+
+// parseExerciseReset validates a reset request and fills in defaults
+func parseExerciseReset(req ExerciseResetRequest) (*exerciseReset, error) {
+	plan := &exerciseReset{
+		streams:   req.Streams,
+		kvBuckets: req.KVBuckets,
+		resume:    req.Resume == nil || *req.Resume,
+		settle:    DefaultResetSettle,
+	}
+
+	if len(plan.streams) == 0 {
+		for name := range natsutil.PipelineConsumers {
+			plan.streams = append(plan.streams, name)
+		}
+		sort.Strings(plan.streams)
+	}
+	for _, name := range plan.streams {
+		if _, ok := natsutil.StreamConfigs[name]; !ok {
+			return nil, fmt.Errorf("unknown stream %q", name)
+		}
+		if ProtectedStreams[name] {
+			return nil, fmt.Errorf("stream %q is protected and cannot be reset", name)
+		}
+	}
+
+	tables := req.Tables
+	if len(tables) == 0 {
+		tables = postgres.DefaultClearTables
+	}
+	expanded, err := postgres.ExpandClearTables(tables)
+	if err != nil {
+		return nil, err
+	}
+	plan.tables = expanded
+
+	for _, bucket := range plan.kvBuckets {
+		if ProtectedKVBuckets[bucket] {
+			return nil, fmt.Errorf("kv bucket %q is protected and cannot be reset", bucket)
+		}
+	}
+
+	if req.SettleMS != nil {
+		plan.settle = time.Duration(*req.SettleMS) * time.Millisecond
+		if plan.settle < 0 || plan.settle > MaxResetSettle {
+			return nil, fmt.Errorf("settle_ms must be between 0 and %d", MaxResetSettle.Milliseconds())
+		}
+	}
+
+	// The reset owns the paused state and stream purging
+	if req.Scenario != nil {
+		plan.scenario = make(map[string]interface{}, len(req.Scenario))
+		for k, v := range req.Scenario {
+			switch k {
+			case "paused", "clear_streams", "reset":
+				return nil, fmt.Errorf("scenario cannot set %q", k)
+			}
+			plan.scenario[k] = v
+		}
+	}
+
+	return plan, nil
+}
+
+// Reset handles POST /api/v1/exercise/reset.
+//
+// It pauses the sensor, purges the selected streams (deleting their consumers
+// so fetched but unacknowledged messages are dropped), waits for agents to
+// finish in-flight work, purges again to catch anything they published, clears
+// the selected tables and system counters in one transaction, purges the
+// selected KV buckets, reloads the sensor defaults plus the requested scenario,
+// and resumes emission. The sensor stays paused for the whole sequence, so no
+// new messages race the clear. If a step fails the remaining steps are skipped
+// and the sensor is left paused for the operator to retry.
+func (h *ExerciseHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req ExerciseResetRequest
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+			return
+		}
+	}
+
+	plan, err := parseExerciseReset(req)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	if h.js == nil {
+		WriteError(w, http.StatusServiceUnavailable, "NATS is not connected", correlationID)
+		return
+	}
+
+	if !h.resetMu.TryLock() {
+		WriteError(w, http.StatusConflict, "An exercise reset is already in progress", correlationID)
+		return
+	}
+	defer h.resetMu.Unlock()
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Strs("streams", plan.streams).
+		Strs("tables", plan.tables).
+		Strs("kv_buckets", plan.kvBuckets).
+		Msg("Starting exercise reset")
+
+	resp := h.run(ctx, plan, correlationID)
+
+	status := http.StatusOK
+	if !resp.Success {
+		status = http.StatusInternalServerError
+		h.logger.Error().
+			Str("correlation_id", correlationID).
+			Interface("steps", resp.Steps).
+			Msg("Exercise reset failed, sensor left paused")
+	} else {
+		h.logger.Info().
+			Str("correlation_id", correlationID).
+			Interface("purged", resp.Purged).
+			Interface("deleted", resp.Deleted).
+			Bool("resumed", resp.Resumed).
+			Msg("Exercise reset complete")
+	}
+
+	WriteJSON(w, status, resp)
+}
+
+// run executes the reset steps in order, stopping at the first failure
+func (h *ExerciseHandler) run(ctx context.Context, plan *exerciseReset, correlationID string) ExerciseResetResponse {
+	resp := ExerciseResetResponse{
+		Purged:        make(map[string]uint64),
+		Deleted:       make(map[string]int64),
+		CorrelationID: correlationID,
+	}
+
+	failed := false
+	step := func(name string, fn func() (string, error)) {
+		if failed {
+			resp.Steps = append(resp.Steps, ExerciseResetStep{Name: name, Status: ResetStepSkipped})
+			return
+		}
+		start := time.Now()
+		detail, err := fn()
+		s := ExerciseResetStep{Name: name, Status: ResetStepOK, Detail: detail, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			s.Status = ResetStepFailed
+			s.Detail = err.Error()
+			failed = true
+		}
+		resp.Steps = append(resp.Steps, s)
+	}
+
+	step("pause_sensor", func() (string, error) {
+		return "", h.patchSensor(ctx, correlationID, map[string]interface{}{"paused": true})
+	})
+
+	step("purge_streams", func() (string, error) {
+		return h.purgeStreams(ctx, plan.streams, resp.Purged)
+	})
+
+	step("drain", func() (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(plan.settle):
+		}
+		// Catch messages published by agents that were mid-flight during the first purge
+		return h.purgeStreams(ctx, plan.streams, resp.Purged)
+	})
+
+	step("clear_tables", func() (string, error) {
+		deleted, err := h.db.ClearTables(ctx, plan.tables)
+		if err != nil {
+			return "", err
+		}
+		for table, n := range deleted {
+			resp.Deleted[table] = n
+		}
+		return fmt.Sprintf("cleared %d tables and reset system counters", len(deleted)), nil
+	})
+
+	if len(plan.kvBuckets) > 0 {
+		resp.KVKeysPurged = make(map[string]int)
+	}
+	step("reset_kv", func() (string, error) {
+		if len(plan.kvBuckets) == 0 {
+			return "no buckets selected", nil
+		}
+		for _, bucket := range plan.kvBuckets {
+			n, err := h.purgeBucket(ctx, bucket)
+			if err != nil {
+				return "", err
+			}
+			resp.KVKeysPurged[bucket] = n
+		}
+		return "", nil
+	})
+
+	step("load_scenario", func() (string, error) {
+		fields := map[string]interface{}{"reset": true}
+		for k, v := range plan.scenario {
+			fields[k] = v
+		}
+		if err := h.patchSensor(ctx, correlationID, fields); err != nil {
+			return "", err
+		}
+		if len(plan.scenario) == 0 {
+			return "defaults", nil
+		}
+		return fmt.Sprintf("defaults with %d scenario fields", len(plan.scenario)), nil
+	})
+
+	step("resume_sensor", func() (string, error) {
+		if !plan.resume {
+			return "left paused on request", nil
+		}
+		if err := h.patchSensor(ctx, correlationID, map[string]interface{}{"paused": false}); err != nil {
+			return "", err
+		}
+		resp.Resumed = true
+		return "", nil
+	})
+
+	resp.Success = !failed
+	if resp.Success {
+		resp.Message = "Exercise reset complete"
+	} else {
+		resp.Message = "Exercise reset failed; the sensor is left paused"
+	}
+	return resp
+}
+
+// purgeStreams purges each stream and adds the discarded message counts to purged
+func (h *ExerciseHandler) purgeStreams(ctx context.Context, streams []string, purged map[string]uint64) (string, error) {
+	var total uint64
+	for _, name := range streams {
+		n, err := natsutil.PurgeStream(ctx, h.js, name, natsutil.PipelineConsumers[name])
+		if err != nil {
+			return "", err
+		}
+		purged[name] += n
+		total += n
+	}
+	return fmt.Sprintf("%d messages discarded", total), nil
+}
+
+// purgeBucket purges every key in a KV bucket
+func (h *ExerciseHandler) purgeBucket(ctx context.Context, bucket string) (int, error) {
+	kv, err := h.js.KeyValue(ctx, bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get kv bucket %s: %w", bucket, err)
+	}
+
+	keys, err := kv.Keys(ctx)
+	if errors.Is(err, jetstream.ErrNoKeysFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list keys in %s: %w", bucket, err)
+	}
+
+	for _, key := range keys {
+		if err := kv.Purge(ctx, key); err != nil {
+			return 0, fmt.Errorf("failed to purge %s/%s: %w", bucket, key, err)
+		}
+	}
+	return len(keys), nil
+}
+
+// patchSensor applies a partial configuration update to the sensor
+func (h *ExerciseHandler) patchSensor(ctx context.Context, correlationID string, fields map[string]interface{}) error {
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sensor config: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, h.sensorURL+"/api/v1/config", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create sensor request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Correlation-ID", correlationID)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach sensor: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var p problem.Problem
+		json.NewDecoder(resp.Body).Decode(&p)
+		return fmt.Errorf("sensor returned status %d: %s", resp.StatusCode, p.Detail)
+	}
+	return nil
+}
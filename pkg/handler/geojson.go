@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GeoJSON output mode, selected with ?format=geojson
+const (
+	FormatJSON         = "json"
+	FormatGeoJSON      = "geojson"
+	GeoJSONContentType = "application/geo+json"
+)
+
+// FeatureCollection is a GeoJSON FeatureCollection (RFC 7946). CorrelationID
+// is a foreign member, which GeoJSON readers ignore.
+type FeatureCollection struct {
+	Type          string    `json:"type"`
+	Features      []Feature `json:"features"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// Feature is a GeoJSON Feature
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *PointGeometry         `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// PointGeometry is a GeoJSON Point. Coordinates are longitude, latitude, and
+// altitude in meters when known.
+type PointGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// NewFeatureCollection creates an empty FeatureCollection
+func NewFeatureCollection(correlationID string) *FeatureCollection {
+	return &FeatureCollection{
+		Type:          "FeatureCollection",
+		Features:      make([]Feature, 0),
+		CorrelationID: correlationID,
+	}
+}
+
+// ParseFormat returns the output format requested by ?format, defaulting to JSON
+func ParseFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatGeoJSON:
+		return FormatGeoJSON, nil
+	default:
+		return "", fmt.Errorf("invalid format %q (valid: %s, %s)", format, FormatJSON, FormatGeoJSON)
+	}
+}
+
+// WriteGeoJSON writes a GeoJSON response
+func WriteGeoJSON(w http.ResponseWriter, status int, fc *FeatureCollection) {
+	w.Header().Set("Content-Type", GeoJSONContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(fc)
+}
+
+// PointFromPosition builds a Point from a position object with lat, lon, and
+// optional alt. A position without coordinates has no geometry.
+func PointFromPosition(position json.RawMessage) *PointGeometry {
+	var pos struct {
+		Lat *float64 `json:"lat"`
+		Lon *float64 `json:"lon"`
+		Alt *float64 `json:"alt"`
+	}
+	if err := json.Unmarshal(position, &pos); err != nil || pos.Lat == nil || pos.Lon == nil {
+		return nil
+	}
+	coords := []float64{*pos.Lon, *pos.Lat}
+	if pos.Alt != nil {
+		coords = append(coords, *pos.Alt)
+	}
+	return &PointGeometry{Type: "Point", Coordinates: coords}
+}
+
+// TrackFeature converts a track into a Feature identified by its track ID
+func TrackFeature(t TrackResponse) Feature {
+	props := map[string]interface{}{
+		"track_id":        t.TrackID,
+		"classification":  t.Classification,
+		"type":            t.Type,
+		"threat_level":    t.ThreatLevel,
+		"threat_score":    t.ThreatScore,
+		"velocity":        t.Velocity,
+		"confidence":      t.Confidence,
+		"sources":         t.Sources,
+		"detection_count": t.DetectionCount,
+		"state":           t.State,
+		"first_seen":      t.FirstSeen,
+		"last_updated":    t.LastUpdated,
+	}
+	if t.StateChangedAt != nil {
+		props["state_changed_at"] = t.StateChangedAt
+	}
+	return Feature{
+		Type:       "Feature",
+		ID:         t.TrackID,
+		Geometry:   PointFromPosition(t.Position),
+		Properties: props,
+	}
+}
+
+// DetectionFeature converts one detection in a track's history into a Feature.
+// seq numbers the history oldest first, so features can be played back in order.
+func DetectionFeature(trackID string, seq int, d DetectionResponse) Feature {
+	return Feature{
+		Type:     "Feature",
+		Geometry: PointFromPosition(d.Position),
+		Properties: map[string]interface{}{
+			"track_id":    trackID,
+			"seq":         seq,
+			"sensor_id":   d.SensorID,
+			"sensor_type": d.SensorType,
+			"velocity":    d.Velocity,
+			"confidence":  d.Confidence,
+			"timestamp":   d.Timestamp,
+		},
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
+	"github.com/agile-defense/cjadc2/pkg/intervention"
 	"github.com/agile-defense/cjadc2/pkg/postgres"
 )
 
@@ -33,6 +36,7 @@ func (h *InterventionRuleHandler) Routes() chi.Router {
 
 	r.Get("/", h.ListInterventionRules)
 	r.Get("/{ruleId}", h.GetInterventionRule)
+	r.Get("/{ruleId}/versions", h.ListInterventionRuleVersions)
 	r.Post("/", h.CreateInterventionRule)
 	r.Put("/{ruleId}", h.UpdateInterventionRule)
 	r.Delete("/{ruleId}", h.DeleteInterventionRule)
@@ -55,6 +59,7 @@ type InterventionRuleResponse struct {
 	AutoApprove      bool      `json:"auto_approve"`
 	Enabled          bool      `json:"enabled"`
 	EvaluationOrder  int       `json:"evaluation_order"`
+	Version          int       `json:"version"`
 	CreatedBy        *string   `json:"created_by,omitempty"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedBy        *string   `json:"updated_by,omitempty"`
@@ -72,8 +77,25 @@ type InterventionRuleListResponse struct {
 
 // InterventionRuleDetailResponse represents the detailed response for a single intervention rule
 type InterventionRuleDetailResponse struct {
-	Rule          InterventionRuleResponse `json:"rule"`
-	CorrelationID string                   `json:"correlation_id"`
+	Rule          InterventionRuleResponse  `json:"rule"`
+	Overlaps      []InterventionRuleOverlap `json:"overlaps,omitempty"` // Set on create and update
+	CorrelationID string                    `json:"correlation_id"`
+}
+
+// InterventionRuleOverlap identifies an enabled rule that can match the same
+// proposals as the rule just saved. The rule with the lower evaluation_order
+// decides those proposals.
+type InterventionRuleOverlap struct {
+	RuleID          string `json:"rule_id"`
+	Name            string `json:"name"`
+	EvaluationOrder int    `json:"evaluation_order"`
+}
+
+// InterventionRuleVersionsResponse represents the response for a rule's version history
+type InterventionRuleVersionsResponse struct {
+	RuleID        string                 `json:"rule_id"`
+	Versions      []intervention.Version `json:"versions"`
+	CorrelationID string                 `json:"correlation_id"`
 }
 
 // CreateInterventionRuleRequest represents the request body for creating an intervention rule
@@ -126,6 +148,7 @@ func toInterventionRuleResponse(r postgres.InterventionRuleRow) InterventionRule
 		AutoApprove:      r.AutoApprove,
 		Enabled:          r.Enabled,
 		EvaluationOrder:  r.EvaluationOrder,
+		Version:          r.Version,
 		CreatedBy:        r.CreatedBy,
 		CreatedAt:        r.CreatedAt,
 		UpdatedBy:        r.UpdatedBy,
@@ -141,6 +164,39 @@ func ensureSlice(s []string) []string {
 	return s
 }
 
+// checkInterventionRule validates a rule and compares it with the enabled
+// rules. It returns the rules it overlaps, or an error status and message when
+// the rule is invalid or ties with an overlapping rule's evaluation order.
+func (h *InterventionRuleHandler) checkInterventionRule(ctx context.Context, row *postgres.InterventionRuleRow) ([]InterventionRuleOverlap, int, string) {
+	rule := row.ToRule()
+	if err := rule.Validate(); err != nil {
+		return nil, http.StatusBadRequest, err.Error()
+	}
+
+	enabled := true
+	rows, err := h.db.ListInterventionRules(ctx, postgres.InterventionRuleFilter{Enabled: &enabled})
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", GetCorrelationID(ctx)).Msg("Failed to list intervention rules for overlap check")
+		return nil, http.StatusInternalServerError, "Failed to check intervention rule overlaps"
+	}
+	others := make([]intervention.Rule, 0, len(rows))
+	for _, r := range rows {
+		others = append(others, r.ToRule())
+	}
+
+	if conflicts := intervention.Conflicts(rule, others); len(conflicts) > 0 {
+		return nil, http.StatusConflict, fmt.Sprintf(
+			"Rule overlaps %q at the same evaluation_order %d; give one of them a different evaluation_order",
+			conflicts[0].Name, rule.EvaluationOrder)
+	}
+
+	var overlaps []InterventionRuleOverlap
+	for _, o := range intervention.Overlapping(rule, others) {
+		overlaps = append(overlaps, InterventionRuleOverlap{RuleID: o.RuleID, Name: o.Name, EvaluationOrder: o.EvaluationOrder})
+	}
+	return overlaps, 0, ""
+}
+
 // ListInterventionRules handles GET /api/v1/intervention-rules
 func (h *InterventionRuleHandler) ListInterventionRules(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -235,18 +291,6 @@ func (h *InterventionRuleHandler) CreateInterventionRule(w http.ResponseWriter,
 		return
 	}
 
-	// Validate required fields
-	if req.Name == "" {
-		WriteError(w, http.StatusBadRequest, "Name is required", correlationID)
-		return
-	}
-
-	// Validate priority range
-	if req.MinPriority != nil && req.MaxPriority != nil && *req.MinPriority > *req.MaxPriority {
-		WriteError(w, http.StatusBadRequest, "min_priority must be less than or equal to max_priority", correlationID)
-		return
-	}
-
 	// Get user ID from request or context
 	createdBy := req.CreatedBy
 	if createdBy == nil {
@@ -274,7 +318,13 @@ func (h *InterventionRuleHandler) CreateInterventionRule(w http.ResponseWriter,
 		UpdatedBy:        createdBy,
 	}
 
-	if err := h.db.CreateInterventionRule(ctx, rule); err != nil {
+	overlaps, status, msg := h.checkInterventionRule(ctx, rule)
+	if status != 0 {
+		WriteError(w, status, msg, correlationID)
+		return
+	}
+
+	if err := h.db.CreateInterventionRule(ctx, rule, correlationID); err != nil {
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_name", req.Name).Msg("Failed to create intervention rule")
 		// Check for unique constraint violation
 		if strings.Contains(err.Error(), "unique_rule_name") || strings.Contains(err.Error(), "duplicate key") {
@@ -289,10 +339,12 @@ func (h *InterventionRuleHandler) CreateInterventionRule(w http.ResponseWriter,
 		Str("correlation_id", correlationID).
 		Str("rule_id", rule.RuleID).
 		Str("rule_name", rule.Name).
+		Int("overlaps", len(overlaps)).
 		Msg("Created intervention rule")
 
 	response := InterventionRuleDetailResponse{
 		Rule:          toInterventionRuleResponse(*rule),
+		Overlaps:      overlaps,
 		CorrelationID: correlationID,
 	}
 
@@ -316,18 +368,6 @@ func (h *InterventionRuleHandler) UpdateInterventionRule(w http.ResponseWriter,
 		return
 	}
 
-	// Validate required fields
-	if req.Name == "" {
-		WriteError(w, http.StatusBadRequest, "Name is required", correlationID)
-		return
-	}
-
-	// Validate priority range
-	if req.MinPriority != nil && req.MaxPriority != nil && *req.MinPriority > *req.MaxPriority {
-		WriteError(w, http.StatusBadRequest, "min_priority must be less than or equal to max_priority", correlationID)
-		return
-	}
-
 	// Check if rule exists
 	existingRule, err := h.db.GetInterventionRule(ctx, ruleID)
 	if err != nil {
@@ -369,7 +409,17 @@ func (h *InterventionRuleHandler) UpdateInterventionRule(w http.ResponseWriter,
 		CreatedAt:        existingRule.CreatedAt,
 	}
 
-	if err := h.db.UpdateInterventionRule(ctx, rule); err != nil {
+	overlaps, status, msg := h.checkInterventionRule(ctx, rule)
+	if status != 0 {
+		WriteError(w, status, msg, correlationID)
+		return
+	}
+
+	if err := h.db.UpdateInterventionRule(ctx, rule, correlationID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteError(w, http.StatusNotFound, "Intervention rule not found", correlationID)
+			return
+		}
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to update intervention rule")
 		// Check for unique constraint violation
 		if strings.Contains(err.Error(), "unique_rule_name") || strings.Contains(err.Error(), "duplicate key") {
@@ -384,10 +434,13 @@ func (h *InterventionRuleHandler) UpdateInterventionRule(w http.ResponseWriter,
 		Str("correlation_id", correlationID).
 		Str("rule_id", rule.RuleID).
 		Str("rule_name", rule.Name).
+		Int("version", rule.Version).
+		Int("overlaps", len(overlaps)).
 		Msg("Updated intervention rule")
 
 	response := InterventionRuleDetailResponse{
 		Rule:          toInterventionRuleResponse(*rule),
+		Overlaps:      overlaps,
 		CorrelationID: correlationID,
 	}
 
@@ -405,7 +458,7 @@ func (h *InterventionRuleHandler) DeleteInterventionRule(w http.ResponseWriter,
 		return
 	}
 
-	if err := h.db.DeleteInterventionRule(ctx, ruleID); err != nil {
+	if err := h.db.DeleteInterventionRule(ctx, ruleID, GetUserID(ctx), correlationID); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			WriteError(w, http.StatusNotFound, "Intervention rule not found", correlationID)
 			return
@@ -422,3 +475,34 @@ func (h *InterventionRuleHandler) DeleteInterventionRule(w http.ResponseWriter,
 
 	WriteSuccess(w, http.StatusOK, "Intervention rule deleted successfully", nil, correlationID)
 }
+
+// ListInterventionRuleVersions handles GET /api/v1/intervention-rules/{ruleId}/versions.
+// History outlives the rule, so deleted rules can still be traced.
+func (h *InterventionRuleHandler) ListInterventionRuleVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	ruleID := chi.URLParam(r, "ruleId")
+
+	if _, err := uuid.Parse(ruleID); err != nil {
+		WriteError(w, http.StatusBadRequest, "Rule ID must be a UUID", correlationID)
+		return
+	}
+
+	versions, err := h.db.ListInterventionRuleVersions(ctx, ruleID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("rule_id", ruleID).Msg("Failed to list intervention rule versions")
+		WriteError(w, http.StatusInternalServerError, "Failed to list intervention rule versions", correlationID)
+		return
+	}
+
+	if len(versions) == 0 {
+		WriteError(w, http.StatusNotFound, "Intervention rule not found", correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, InterventionRuleVersionsResponse{
+		RuleID:        ruleID,
+		Versions:      versions,
+		CorrelationID: correlationID,
+	})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+)
+
+// ManualSourceID is the envelope source of manual contacts, matching the
+// origin policy's pattern for API sources
+const ManualSourceID = "api-gateway"
+
+// ManualTrackIDPrefix starts the sensor track IDs given to manual contacts
+const ManualTrackIDPrefix = "MAN-"
+
+// manualTrackTypes are the track types a manual contact may declare
+var manualTrackTypes = []string{"aircraft", "vessel", "ground", "missile", "unknown"}
+
+// ManualTrackRequest represents the request body for POST /api/v1/tracks/manual.
+// Sending the track_id of an earlier manual contact updates it.
+type ManualTrackRequest struct {
+	TrackID        string             `json:"track_id,omitempty"` // Sensor track ID; generated when empty
+	Position       *messages.Position `json:"position"`
+	Velocity       messages.Velocity  `json:"velocity"`
+	Type           string             `json:"type,omitempty"`           // aircraft, vessel, ground, missile, unknown
+	Classification string             `json:"classification,omitempty"` // friendly, hostile, neutral, unknown; classifier rules decide when empty
+	Confidence     *float64           `json:"confidence,omitempty"`     // Defaults to 1.0
+	Note           string             `json:"note,omitempty"`           // Operator's description of the contact
+}
+
+// ManualTrackResponse acknowledges a manual contact published to DETECTIONS.
+// The system track ID is assigned by the correlator once the detection reaches it.
+type ManualTrackResponse struct {
+	TrackID       string `json:"track_id"`   // Sensor track ID of the contact
+	MessageID     string `json:"message_id"` // Message ID of the published detection
+	Subject       string `json:"subject"`    // Subject the detection was published on
+	EnteredBy     string `json:"entered_by,omitempty"`
+	CorrelationID string `json:"correlation_id"`
+}
+
+// manualReport is kept in a manual detection's raw data so the contact can be
+// traced to the operator who entered it
+type manualReport struct {
+	EnteredBy string `json:"entered_by,omitempty"`
+	Note      string `json:"note,omitempty"`
+}
+
+// SetManualEntry enables POST /api/v1/tracks/manual, which publishes operator
+// entered contacts to DETECTIONS once the origin policy accepts them. pub is
+// nil when the gateway has no NATS connection, in which case entries are refused.
+func (h *TrackHandler) SetManualEntry(pub *Publisher, opaClient *opa.Client) {
+	h.pub = pub
+	h.opa = opaClient
+}
+
+// CreateManualTrack handles POST /api/v1/tracks/manual
+func (h *TrackHandler) CreateManualTrack(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req ManualTrackRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	det, err := req.Detection(GetUserID(ctx))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+	det.Envelope = det.Envelope.WithCorrelation(correlationID, "")
+
+	// The detection must be one the pipeline accepts from any sensor
+	data, err := json.Marshal(det)
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Failed to encode detection", correlationID)
+		return
+	}
+	if err := messages.Validate(messages.SchemaDetection, data); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	if h.pub == nil || h.opa == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Manual track entry requires NATS and OPA", correlationID)
+		return
+	}
+	decision, err := h.opa.CheckOrigin(ctx, det.Envelope)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Origin policy check failed")
+		WriteError(w, http.StatusServiceUnavailable, "Origin policy check unavailable", correlationID)
+		return
+	}
+	if !decision.Allowed {
+		h.logger.Warn().
+			Str("correlation_id", correlationID).
+			Str("track_id", det.TrackID).
+			Strs("reasons", decision.Reasons).
+			Msg("Manual track refused by origin policy")
+		WriteProblem(w, problem.CodePolicyDenied, "Manual track refused by origin policy: "+strings.Join(decision.Reasons, "; "), correlationID)
+		return
+	}
+
+	subject := det.Subject()
+	if err := h.pub.PublishStream(ctx, det, jetstream.WithMsgID(det.Envelope.MessageID)); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", subject).Msg("Failed to publish manual track")
+		WriteError(w, http.StatusServiceUnavailable, "Failed to publish manual track", correlationID)
+		return
+	}
+
+	enteredBy := GetUserID(ctx)
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("track_id", det.TrackID).
+		Str("entered_by", enteredBy).
+		Str("classification", det.Classification).
+		Msg("Manual track published")
+
+	WriteJSON(w, http.StatusAccepted, ManualTrackResponse{
+		TrackID:       det.TrackID,
+		MessageID:     det.Envelope.MessageID,
+		Subject:       subject,
+		EnteredBy:     enteredBy,
+		CorrelationID: correlationID,
+	})
+}
+
+// Detection validates the request and builds the detection it describes
+func (req ManualTrackRequest) Detection(enteredBy string) (*messages.Detection, error) {
+	switch {
+	case req.Position == nil:
+		return nil, fmt.Errorf("position is required")
+	case math.Abs(req.Position.Lat) > 90 || math.Abs(req.Position.Lon) > 180:
+		return nil, fmt.Errorf("position must have lat within ±90 and lon within ±180")
+	case req.Velocity.Speed < 0:
+		return nil, fmt.Errorf("velocity speed must not be negative")
+	case req.Velocity.Heading < 0 || req.Velocity.Heading >= 360:
+		return nil, fmt.Errorf("velocity heading must be in [0, 360)")
+	case req.Type != "" && !oneOf(manualTrackTypes, req.Type):
+		return nil, fmt.Errorf("type must be one of %s", strings.Join(manualTrackTypes, ", "))
+	case req.Classification != "" && !oneOf(classify.Classifications, req.Classification):
+		return nil, fmt.Errorf("classification must be one of %s", strings.Join(classify.Classifications, ", "))
+	case req.Confidence != nil && (*req.Confidence < 0 || *req.Confidence > 1):
+		return nil, fmt.Errorf("confidence must be between 0 and 1")
+	}
+
+	trackID := strings.TrimSpace(req.TrackID)
+	if trackID == "" {
+		trackID = ManualTrackIDPrefix + strings.ToUpper(uuid.New().String()[:8])
+	} else if !strings.HasPrefix(trackID, ManualTrackIDPrefix) {
+		return nil, fmt.Errorf("track_id must start with %s so it cannot collide with a sensor's", ManualTrackIDPrefix)
+	}
+
+	det := messages.NewDetection(ManualSourceID, messages.ManualSensorType)
+	det.Envelope.SourceType = "api"
+	det.TrackID = trackID
+	det.Type = req.Type
+	det.Classification = req.Classification
+	det.Position = *req.Position
+	det.Velocity = req.Velocity
+	det.Confidence = 1.0
+	if req.Confidence != nil {
+		det.Confidence = *req.Confidence
+	}
+	if enteredBy != "" || req.Note != "" {
+		raw, err := json.Marshal(manualReport{EnteredBy: enteredBy, Note: req.Note})
+		if err != nil {
+			return nil, err
+		}
+		det.RawData = raw
+	}
+	return det, nil
+}
+
+// oneOf reports whether v is in list
+func oneOf(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
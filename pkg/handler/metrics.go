@@ -12,12 +12,14 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/scoring"
 )
 
 // MetricsHandler handles metrics-related HTTP requests
 type MetricsHandler struct {
 	db     *postgres.Pool
 	nc     *nats.Conn
+	scorer *scoring.Scorer
 	logger zerolog.Logger
 }
 
@@ -30,6 +32,11 @@ func NewMetricsHandler(db *postgres.Pool, nc *nats.Conn, logger zerolog.Logger)
 	}
 }
 
+// SetScorer enables the accuracy endpoints, which report pipeline output against simulation ground truth
+func (h *MetricsHandler) SetScorer(scorer *scoring.Scorer) {
+	h.scorer = scorer
+}
+
 // GetNATSQueueDepth returns the total number of pending messages in the automatic processing pipeline
 // This excludes the authorizer consumer since those are proposals awaiting human approval
 func (h *MetricsHandler) GetNATSQueueDepth(ctx context.Context) (int64, error) {
@@ -73,10 +80,47 @@ func (h *MetricsHandler) Routes() chi.Router {
 	r.Get("/", h.GetCurrentMetrics)
 	r.Get("/stages", h.GetStageMetrics)
 	r.Get("/latency", h.GetLatencyMetrics)
+	r.Get("/accuracy", h.GetAccuracy)
+	r.Delete("/accuracy", h.ResetAccuracy)
 
 	return r
 }
 
+// AccuracyResponse represents pipeline accuracy against simulation ground truth
+type AccuracyResponse struct {
+	scoring.Report
+	CorrelationID string `json:"correlation_id"`
+}
+
+// GetAccuracy handles GET /api/v1/metrics/accuracy
+func (h *MetricsHandler) GetAccuracy(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	if h.scorer == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Accuracy scoring is unavailable", correlationID)
+		return
+	}
+	WriteJSON(w, http.StatusOK, AccuracyResponse{
+		Report:        h.scorer.Report(time.Now().UTC()),
+		CorrelationID: correlationID,
+	})
+}
+
+// ResetAccuracy handles DELETE /api/v1/metrics/accuracy, starting a new scoring period
+func (h *MetricsHandler) ResetAccuracy(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	if h.scorer == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Accuracy scoring is unavailable", correlationID)
+		return
+	}
+	now := time.Now().UTC()
+	h.scorer.Reset(now)
+	h.logger.Info().Str("correlation_id", correlationID).Msg("Accuracy scoring reset")
+	WriteJSON(w, http.StatusOK, AccuracyResponse{
+		Report:        h.scorer.Report(now),
+		CorrelationID: correlationID,
+	})
+}
+
 // StageMetricsResponse represents the response for stage metrics
 type StageMetricsResponse struct {
 	Stages        []StageMetricResponse `json:"stages"`
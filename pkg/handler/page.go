@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Paging defaults for list endpoints
+const (
+	DefaultPageLimit = 100
+	TotalCountHeader = "X-Total-Count"
+)
+
+// Page holds the paging parameters of a list request
+type Page struct {
+	Limit  int
+	Offset int
+	Sort   []postgres.SortKey
+	After  []string // Sort key values decoded from the cursor parameter
+}
+
+// ParsePage reads the limit, offset, sort, and cursor query parameters of a
+// list sorted by fields. A cursor is only valid with the sort it was issued
+// for and cannot be combined with an offset.
+func ParsePage(r *http.Request, fields *postgres.SortFields) (Page, error) {
+	q := r.URL.Query()
+	page := Page{Limit: DefaultPageLimit}
+
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 {
+		page.Limit = limit
+	}
+	if offset, err := strconv.Atoi(q.Get("offset")); err == nil && offset >= 0 {
+		page.Offset = offset
+	}
+
+	sort, err := fields.ParseSort(q.Get("sort"))
+	if err != nil {
+		return Page{}, err
+	}
+	page.Sort = sort
+
+	if token := q.Get("cursor"); token != "" {
+		if page.Offset > 0 {
+			return Page{}, errors.New("cursor and offset cannot be combined")
+		}
+		if page.After, err = fields.DecodeCursor(token, sort); err != nil {
+			return Page{}, err
+		}
+	}
+	return page, nil
+}
+
+// NextCursor returns the cursor of the last row when the page came back full,
+// so another page may follow, and empty otherwise
+func (p Page) NextCursor(rows int, last func() string) string {
+	if rows == 0 || rows < p.Limit {
+		return ""
+	}
+	return last()
+}
+
+// WritePageHeaders sets the total number of matching rows and, when another
+// page follows, a Link to it with the same filters and sort
+func WritePageHeaders(w http.ResponseWriter, r *http.Request, total int64, next string) {
+	w.Header().Set(TotalCountHeader, strconv.FormatInt(total, 10))
+	if next == "" {
+		return
+	}
+	u := *r.URL
+	q := u.Query()
+	q.Del("offset")
+	q.Set("cursor", next)
+	u.RawQuery = q.Encode()
+	w.Header().Set("Link", "<"+u.RequestURI()+`>; rel="next"`)
+}
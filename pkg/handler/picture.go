@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/picture"
+)
+
+// PictureHandler serves the fused common operating picture the correlator
+// persists in JetStream KV
+type PictureHandler struct {
+	js     jetstream.JetStream
+	logger zerolog.Logger
+}
+
+// NewPictureHandler creates a new PictureHandler. js may be nil when the
+// gateway has no NATS connection, in which case requests are refused.
+func NewPictureHandler(js jetstream.JetStream, logger zerolog.Logger) *PictureHandler {
+	return &PictureHandler{
+		js:     js,
+		logger: logger.With().Str("handler", "picture").Logger(),
+	}
+}
+
+// Routes returns the picture routes
+func (h *PictureHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.GetPicture)
+
+	return r
+}
+
+// PictureResponse represents the common operating picture in API responses
+type PictureResponse struct {
+	Tracks        []*messages.CorrelatedTrack `json:"tracks"` // Most threatening first
+	Count         int                         `json:"count"`
+	GeneratedAt   time.Time                   `json:"generated_at"`
+	CorrelationID string                      `json:"correlation_id"`
+}
+
+// GetPicture handles GET /api/v1/picture
+func (h *PictureHandler) GetPicture(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	if h.js == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Track picture requires NATS", correlationID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tracks := []*messages.CorrelatedTrack{}
+	kv, err := h.js.KeyValue(ctx, picture.Bucket)
+	switch {
+	case errors.Is(err, jetstream.ErrBucketNotFound):
+		// No correlator has run yet, so the picture is empty
+	case err != nil:
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to open track picture")
+		WriteError(w, http.StatusInternalServerError, "Failed to read track picture", correlationID)
+		return
+	default:
+		if tracks, err = picture.New(kv).Tracks(ctx); err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to read track picture")
+			WriteError(w, http.StatusInternalServerError, "Failed to read track picture", correlationID)
+			return
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, PictureResponse{
+		Tracks:        tracks,
+		Count:         len(tracks),
+		GeneratedAt:   time.Now().UTC(),
+		CorrelationID: correlationID,
+	})
+}
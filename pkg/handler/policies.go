@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/opa"
+	"github.com/agile-defense/cjadc2/pkg/policydata"
+)
+
+// policyStatusTimeout bounds the OPA calls made for one status request
+const policyStatusTimeout = 5 * time.Second
+
+// PolicyHandler reports the OPA policies in force and pushes policy data into OPA
+type PolicyHandler struct {
+	opa    *opa.Client
+	syncer *policydata.Syncer
+	logger zerolog.Logger
+}
+
+// NewPolicyHandler creates a new PolicyHandler. syncer may be nil when there
+// is no database to sync policy data from.
+func NewPolicyHandler(opaClient *opa.Client, syncer *policydata.Syncer, logger zerolog.Logger) *PolicyHandler {
+	return &PolicyHandler{
+		opa:    opaClient,
+		syncer: syncer,
+		logger: logger.With().Str("handler", "policies").Logger(),
+	}
+}
+
+// Routes returns the policy routes
+func (h *PolicyHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetStatus)
+	r.Post("/data/sync", h.SyncData)
+	return r
+}
+
+// PolicyDataSyncStatus describes policy data sync
+type PolicyDataSyncStatus struct {
+	Enabled  bool               `json:"enabled"`            // Data can be pushed: a database is connected and OPA runs as a server
+	Interval string             `json:"interval,omitempty"` // Periodic sync interval; empty when syncing only on request
+	Last     *policydata.Result `json:"last,omitempty"`
+}
+
+// PolicyStatusResponse represents the response for GET /api/v1/policies
+type PolicyStatusResponse struct {
+	OPA           *opa.Status          `json:"opa"`
+	DataSync      PolicyDataSyncStatus `json:"data_sync"`
+	CorrelationID string               `json:"correlation_id"`
+}
+
+// PolicyDataSyncResponse represents the response for a policy data sync
+type PolicyDataSyncResponse struct {
+	Result        policydata.Result `json:"result"`
+	CorrelationID string            `json:"correlation_id"`
+}
+
+// GetStatus handles GET /api/v1/policies
+func (h *PolicyHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), policyStatusTimeout)
+	defer cancel()
+
+	resp := PolicyStatusResponse{
+		OPA:           h.opa.Status(ctx),
+		DataSync:      PolicyDataSyncStatus{Enabled: h.syncEnabled()},
+		CorrelationID: GetCorrelationID(r.Context()),
+	}
+	if h.syncer != nil {
+		if interval := h.syncer.Interval(); interval > 0 {
+			resp.DataSync.Interval = interval.String()
+		}
+		resp.DataSync.Last = h.syncer.Last()
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// SyncData handles POST /api/v1/policies/data/sync, pushing the policy data
+// documents into OPA now
+func (h *PolicyHandler) SyncData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	if h.syncer == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Policy data sync requires a database connection", correlationID)
+		return
+	}
+	if h.opa.Mode() == opa.ModeEmbedded {
+		WriteError(w, http.StatusConflict, "Policy data is compiled into the bundle in embedded OPA mode", correlationID)
+		return
+	}
+
+	result, err := h.syncer.Sync(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Policy data sync failed")
+		status := http.StatusBadGateway
+		if errors.Is(err, opa.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		WriteError(w, status, err.Error(), correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Strs("documents", result.Documents).
+		Msg("Pushed policy data to OPA")
+
+	WriteJSON(w, http.StatusOK, PolicyDataSyncResponse{Result: result, CorrelationID: correlationID})
+}
+
+func (h *PolicyHandler) syncEnabled() bool {
+	return h.syncer != nil && h.opa.Mode() != opa.ModeEmbedded
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Preference kinds
+const (
+	PreferenceMapFilter     = "map_filter"     // Saved map filter
+	PreferenceColumnLayout  = "column_layout"  // Column order, widths, and visibility for one table
+	PreferenceDefaultSector = "default_sector" // Area the map opens on
+)
+
+// Preference limits
+const (
+	MaxPreferenceValueBytes = 64 * 1024
+	MaxPreferencesPerUser   = 200
+)
+
+// preferenceNamePattern restricts names to short identifiers safe in URLs
+var preferenceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.-]{0,63}$`)
+
+// PreferenceHandler stores operator workspace state per authenticated user, so
+// the HITL console looks the same on every machine an operator signs in to
+type PreferenceHandler struct {
+	db     *postgres.Pool
+	logger zerolog.Logger
+}
+
+// NewPreferenceHandler creates a new PreferenceHandler
+func NewPreferenceHandler(db *postgres.Pool, logger zerolog.Logger) *PreferenceHandler {
+	return &PreferenceHandler{
+		db:     db,
+		logger: logger.With().Str("handler", "preferences").Logger(),
+	}
+}
+
+// Routes returns the preference routes. Every route acts on the caller's own preferences.
+func (h *PreferenceHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListPreferences)
+	r.Get("/{kind}/{name}", h.GetPreference)
+	r.Put("/{kind}/{name}", h.PutPreference)
+	r.Delete("/{kind}/{name}", h.DeletePreference)
+
+	return r
+}
+
+// PreferenceResponse represents a preference in API responses
+type PreferenceResponse struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Value     json.RawMessage `json:"value"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// PreferenceListResponse represents the response for listing preferences
+type PreferenceListResponse struct {
+	UserID        string               `json:"user_id"`
+	Preferences   []PreferenceResponse `json:"preferences"`
+	Total         int                  `json:"total"`
+	CorrelationID string               `json:"correlation_id"`
+}
+
+// PreferenceDetailResponse represents the response for a single preference
+type PreferenceDetailResponse struct {
+	Preference    PreferenceResponse `json:"preference"`
+	CorrelationID string             `json:"correlation_id"`
+}
+
+// PreferenceRequest represents the request body for saving a preference
+type PreferenceRequest struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// toPreferenceResponse converts a database row to an API response
+func toPreferenceResponse(p postgres.PreferenceRow) PreferenceResponse {
+	return PreferenceResponse{
+		Kind:      p.Kind,
+		Name:      p.Name,
+		Value:     p.Value,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// validatePreferenceKey checks a preference kind and name
+func validatePreferenceKey(kind, name string) error {
+	switch kind {
+	case PreferenceMapFilter, PreferenceColumnLayout, PreferenceDefaultSector:
+	default:
+		return fmt.Errorf("unknown preference kind %q (valid: %s, %s, %s)", kind, PreferenceMapFilter, PreferenceColumnLayout, PreferenceDefaultSector)
+	}
+	if !preferenceNamePattern.MatchString(name) {
+		return fmt.Errorf("preference name must be 1-64 letters, digits, spaces, '.', '_' or '-'")
+	}
+	return nil
+}
+
+// requireUser returns the caller's user ID, writing 401 if the request is unauthenticated
+func (h *PreferenceHandler) requireUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := GetUserID(r.Context())
+	if userID == "" {
+		WriteError(w, http.StatusUnauthorized, "Preferences require an authenticated user ("+UserIDHeader+" header)", GetCorrelationID(r.Context()))
+		return "", false
+	}
+	return userID, true
+}
+
+// ListPreferences handles GET /api/v1/preferences
+func (h *PreferenceHandler) ListPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	userID, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind != "" {
+		if err := validatePreferenceKey(kind, "x"); err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+			return
+		}
+	}
+
+	prefs, err := h.db.ListPreferences(ctx, userID, kind)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("user_id", userID).Msg("Failed to list preferences")
+		WriteError(w, http.StatusInternalServerError, "Failed to list preferences", correlationID)
+		return
+	}
+
+	response := PreferenceListResponse{
+		UserID:        userID,
+		Preferences:   make([]PreferenceResponse, 0, len(prefs)),
+		Total:         len(prefs),
+		CorrelationID: correlationID,
+	}
+	for _, p := range prefs {
+		response.Preferences = append(response.Preferences, toPreferenceResponse(p))
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// GetPreference handles GET /api/v1/preferences/{kind}/{name}
+func (h *PreferenceHandler) GetPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	userID, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	kind, name := chi.URLParam(r, "kind"), chi.URLParam(r, "name")
+	if err := validatePreferenceKey(kind, name); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	pref, err := h.db.GetPreference(ctx, userID, kind, name)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("user_id", userID).Msg("Failed to get preference")
+		WriteError(w, http.StatusInternalServerError, "Failed to get preference", correlationID)
+		return
+	}
+	if pref == nil {
+		WriteError(w, http.StatusNotFound, "Preference not found", correlationID)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, PreferenceDetailResponse{
+		Preference:    toPreferenceResponse(*pref),
+		CorrelationID: correlationID,
+	})
+}
+
+// PutPreference handles PUT /api/v1/preferences/{kind}/{name}
+func (h *PreferenceHandler) PutPreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	userID, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	kind, name := chi.URLParam(r, "kind"), chi.URLParam(r, "name")
+	if err := validatePreferenceKey(kind, name); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxPreferenceValueBytes+1024)
+	var req PreferenceRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	if len(req.Value) == 0 || string(req.Value) == "null" {
+		WriteError(w, http.StatusBadRequest, "value is required", correlationID)
+		return
+	}
+	if len(req.Value) > MaxPreferenceValueBytes {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("value must be at most %d bytes", MaxPreferenceValueBytes), correlationID)
+		return
+	}
+
+	pref := &postgres.PreferenceRow{
+		UserID: userID,
+		Kind:   kind,
+		Name:   name,
+		Value:  req.Value,
+	}
+	saved, err := h.db.PutPreference(ctx, pref, MaxPreferencesPerUser)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("user_id", userID).Msg("Failed to save preference")
+		WriteError(w, http.StatusInternalServerError, "Failed to save preference", correlationID)
+		return
+	}
+	if !saved {
+		WriteError(w, http.StatusConflict, fmt.Sprintf("At most %d preferences can be saved per user", MaxPreferencesPerUser), correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("user_id", userID).
+		Str("kind", kind).
+		Str("name", name).
+		Msg("Saved preference")
+
+	WriteJSON(w, http.StatusOK, PreferenceDetailResponse{
+		Preference:    toPreferenceResponse(*pref),
+		CorrelationID: correlationID,
+	})
+}
+
+// DeletePreference handles DELETE /api/v1/preferences/{kind}/{name}
+func (h *PreferenceHandler) DeletePreference(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	userID, ok := h.requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	kind, name := chi.URLParam(r, "kind"), chi.URLParam(r, "name")
+	if err := validatePreferenceKey(kind, name); err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	deleted, err := h.db.DeletePreference(ctx, userID, kind, name)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("user_id", userID).Msg("Failed to delete preference")
+		WriteError(w, http.StatusInternalServerError, "Failed to delete preference", correlationID)
+		return
+	}
+	if !deleted {
+		WriteError(w, http.StatusNotFound, "Preference not found", correlationID)
+		return
+	}
+
+	WriteSuccess(w, http.StatusOK, "Preference deleted", nil, correlationID)
+}
@@ -0,0 +1,171 @@
+// Package problem renders HTTP errors as RFC 7807 problem details.
+//
+// Every error response from the gateway and the agents is an
+// application/problem+json document with a machine-readable code from a small
+// taxonomy, so clients branch on the code rather than parsing messages, and
+// the correlation ID of the request so an operator can find it in the logs
+// and traces:
+//
+//	{
+//	  "type": "urn:cjadc2:problem:not_found",
+//	  "title": "Resource not found",
+//	  "status": 404,
+//	  "detail": "Proposal not found",
+//	  "instance": "/api/v1/proposals/9b1c...",
+//	  "code": "not_found",
+//	  "correlation_id": "..."
+//	}
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// TypePrefix prefixes the code in a problem's type URI
+const TypePrefix = "urn:cjadc2:problem:"
+
+// CorrelationIDHeader carries the correlation ID of a request
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// Code classifies an error
+type Code string
+
+// Error codes
+const (
+	CodeValidation          Code = "validation"           // The request is malformed or its values are invalid
+	CodeUnauthorized        Code = "unauthorized"         // The caller is not identified
+	CodeForbidden           Code = "forbidden"            // The operation is switched off or not permitted for the caller
+	CodePolicyDenied        Code = "policy_denied"        // Policy or approval authority refused the operation
+	CodeNotFound            Code = "not_found"            // The resource does not exist
+	CodeMethodNotAllowed    Code = "method_not_allowed"   // The route does not accept the method
+	CodeConflict            Code = "conflict"             // The resource's state does not allow the operation
+	CodeRateLimited         Code = "rate_limited"         // The caller sent too many requests; retry after the Retry-After header
+	CodeUpstreamUnavailable Code = "upstream_unavailable" // A dependency such as NATS, PostgreSQL, OPA, or an agent failed
+	CodeInternal            Code = "internal"             // An unexpected failure
+)
+
+var codes = map[Code]struct {
+	status int
+	title  string
+}{
+	CodeValidation:          {http.StatusBadRequest, "Invalid request"},
+	CodeUnauthorized:        {http.StatusUnauthorized, "Authentication required"},
+	CodeForbidden:           {http.StatusForbidden, "Operation not permitted"},
+	CodePolicyDenied:        {http.StatusForbidden, "Denied by policy"},
+	CodeNotFound:            {http.StatusNotFound, "Resource not found"},
+	CodeMethodNotAllowed:    {http.StatusMethodNotAllowed, "Method not allowed"},
+	CodeConflict:            {http.StatusConflict, "Conflict with current state"},
+	CodeRateLimited:         {http.StatusTooManyRequests, "Too many requests"},
+	CodeUpstreamUnavailable: {http.StatusServiceUnavailable, "Upstream service unavailable"},
+	CodeInternal:            {http.StatusInternalServerError, "Internal error"},
+}
+
+// Status returns the HTTP status a code is served with by default
+func (c Code) Status() int {
+	if info, ok := codes[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title returns the short summary of a code
+func (c Code) Title() string {
+	if info, ok := codes[c]; ok {
+		return info.title
+	}
+	return codes[CodeInternal].title
+}
+
+// CodeForStatus classifies an HTTP error status
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusRequestEntityTooLarge:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeUpstreamUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// Problem is an RFC 7807 problem details document with the code and
+// correlation ID as extension members
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	Code          Code   `json:"code"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// New describes an error with the status its code is served with
+func New(code Code, detail string) Problem {
+	return Problem{Status: code.Status(), Code: code, Detail: detail}
+}
+
+// ForStatus describes an error served with status, classified by CodeForStatus
+func ForStatus(status int, detail string) Problem {
+	return Problem{Status: status, Code: CodeForStatus(status), Detail: detail}
+}
+
+// For sets the instance to the request path and, unless already set, the
+// correlation ID to the one the request carries
+func (p Problem) For(r *http.Request) Problem {
+	p.Instance = r.URL.Path
+	if p.CorrelationID == "" {
+		p.CorrelationID = r.Header.Get(CorrelationIDHeader)
+	}
+	return p
+}
+
+// Write sends the problem, filling in its type and title from its code
+func Write(w http.ResponseWriter, p Problem) {
+	if p.Code == "" {
+		p.Code = CodeForStatus(p.Status)
+	}
+	if p.Status == 0 {
+		p.Status = p.Code.Status()
+	}
+	if p.Type == "" {
+		p.Type = TypePrefix + string(p.Code)
+	}
+	if p.Title == "" {
+		p.Title = p.Code.Title()
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Parse reads a problem from a response body. It reports false if the body is
+// not a problem document.
+func Parse(contentType string, body []byte) (Problem, bool) {
+	if !strings.HasPrefix(contentType, ContentType) {
+		return Problem{}, false
+	}
+	var p Problem
+	if err := json.Unmarshal(body, &p); err != nil || p.Code == "" {
+		return Problem{}, false
+	}
+	return p, true
+}
@@ -1,39 +1,67 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
 
+	"github.com/agile-defense/cjadc2/pkg/breakglass"
+	"github.com/agile-defense/cjadc2/pkg/coa"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
 	"github.com/agile-defense/cjadc2/pkg/messages"
 	"github.com/agile-defense/cjadc2/pkg/opa"
 	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
 )
 
 // ProposalHandler handles proposal-related HTTP requests
 type ProposalHandler struct {
-	db     *postgres.Pool
-	nc     *nats.Conn
-	opa    *opa.Client
-	logger zerolog.Logger
+	db        *postgres.Pool
+	pub       *Publisher
+	opa       *opa.Client
+	twoPerson twoperson.Rule
+	forwarder DecisionForwarder
+	claims    *ClaimHandler // Nil disables proposal claims
+	cache     *ReadCache    // Nil queries the database on every list request
+	logger    zerolog.Logger
 }
 
 // NewProposalHandler creates a new ProposalHandler
-func NewProposalHandler(db *postgres.Pool, nc *nats.Conn, opaClient *opa.Client, logger zerolog.Logger) *ProposalHandler {
+func NewProposalHandler(db *postgres.Pool, pub *Publisher, opaClient *opa.Client, twoPerson twoperson.Rule, logger zerolog.Logger) *ProposalHandler {
 	return &ProposalHandler{
-		db:     db,
-		nc:     nc,
-		opa:    opaClient,
-		logger: logger.With().Str("handler", "proposals").Logger(),
+		db:        db,
+		pub:       pub,
+		opa:       opaClient,
+		twoPerson: twoPerson,
+		logger:    logger.With().Str("handler", "proposals").Logger(),
 	}
 }
 
+// SetDecisionForwarder sets how POST /{proposalId}/decision reaches the authorizer
+func (h *ProposalHandler) SetDecisionForwarder(f DecisionForwarder) {
+	h.forwarder = f
+}
+
+// SetClaims lets operators claim proposals through POST and DELETE
+// /{proposalId}/claim, shows claimants in proposal responses, and refuses
+// decisions on proposals another operator has claimed
+func (h *ProposalHandler) SetClaims(c *ClaimHandler) {
+	h.claims = c
+}
+
+// SetCache serves repeated proposal lists from cache
+func (h *ProposalHandler) SetCache(cache *ReadCache) {
+	h.cache = cache
+}
+
 // Routes returns the proposal routes
 func (h *ProposalHandler) Routes() chi.Router {
 	r := chi.NewRouter()
@@ -41,6 +69,11 @@ func (h *ProposalHandler) Routes() chi.Router {
 	r.Get("/", h.ListProposals)
 	r.Get("/{proposalId}", h.GetProposal)
 	r.Post("/{proposalId}/decide", h.DecideProposal)
+	r.Post("/{proposalId}/decision", h.ForwardDecision)
+	if h.claims != nil {
+		r.Post("/{proposalId}/claim", h.claims.Claim)
+		r.Delete("/{proposalId}/claim", h.claims.Release)
+	}
 
 	return r
 }
@@ -48,9 +81,10 @@ func (h *ProposalHandler) Routes() chi.Router {
 // ProposalListResponse represents the response for listing proposals
 type ProposalListResponse struct {
 	Proposals     []ProposalResponse `json:"proposals"`
-	Total         int                `json:"total"`
+	Total         int64              `json:"total"` // Matching proposals across all pages
 	Limit         int                `json:"limit"`
 	Offset        int                `json:"offset"`
+	NextCursor    string             `json:"next_cursor,omitempty"`
 	CorrelationID string             `json:"correlation_id"`
 }
 
@@ -78,6 +112,109 @@ type ProposalResponse struct {
 	Track          *TrackInfo      `json:"track,omitempty"`
 	HitCount       int             `json:"hit_count"`
 	LastHitAt      time.Time       `json:"last_hit_at"`
+
+	// Escalation state; time remaining is computed at request time
+	EscalationLevel      int     `json:"escalation_level"`
+	Urgency              string  `json:"urgency"`
+	TimeRemainingSeconds float64 `json:"time_remaining_seconds"`
+
+	// Approvals recorded so far under the two-person rule
+	Approvals         []messages.Approval `json:"approvals,omitempty"`
+	RequiredApprovals int                 `json:"required_approvals"`
+
+	// Ranked courses of action the approver may choose from; the first is ActionType
+	Options []messages.CourseOfAction `json:"options,omitempty"`
+
+	// Asset the planner recommended for engage and intercept proposals
+	Asset *messages.AssetAssignment `json:"asset,omitempty"`
+
+	// Other engagements competing for the same asset or airspace when planned
+	Conflicts []messages.ProposalConflict `json:"conflicts,omitempty"`
+
+	// Security labels; callers see only proposals their access permits
+	SecurityLabel string   `json:"security_label"`
+	Releasability []string `json:"releasability"`
+
+	// Operator working the proposal, if anyone has claimed it
+	Claim *postgres.ProposalClaim `json:"claim,omitempty"`
+}
+
+// setClaims fills in the live claims on the given proposals
+func (h *ProposalHandler) setClaims(ctx context.Context, prs []ProposalResponse) {
+	if h.claims == nil || len(prs) == 0 {
+		return
+	}
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.ProposalID
+	}
+	claims, err := h.claims.claimsOn(ctx, ids)
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Failed to load proposal claims")
+		return
+	}
+	for i := range prs {
+		if c, ok := claims[prs[i].ProposalID]; ok {
+			prs[i].Claim = &c
+		}
+	}
+}
+
+// setApprovals fills in the approvals a proposal needs and those recorded so far
+func (h *ProposalHandler) setApprovals(ctx context.Context, pr *ProposalResponse) {
+	pr.RequiredApprovals = 1
+	if !h.twoPerson.Applies(pr.ActionType, pr.Priority) {
+		return
+	}
+	pr.RequiredApprovals = twoperson.RequiredApprovals
+	approvals, err := twoperson.Approvals(ctx, h.db, pr.ProposalID)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("proposal_id", pr.ProposalID).Msg("Failed to load approvals")
+		return
+	}
+	pr.Approvals = approvals
+}
+
+// setEscalation fills in escalation state and the time remaining before expiry.
+// Pending proposals are shown at the level their elapsed TTL implies even if the
+// authorizer has not escalated them yet.
+func (pr *ProposalResponse) setEscalation(level int, now time.Time) {
+	if pr.Status == "pending" {
+		if implied := messages.EscalationLevelFor(messages.TTLFractionElapsed(pr.CreatedAt, pr.ExpiresAt, now)); implied > level {
+			level = implied
+		}
+		if remaining := pr.ExpiresAt.Sub(now).Seconds(); remaining > 0 {
+			pr.TimeRemainingSeconds = remaining
+		}
+	}
+	pr.EscalationLevel = level
+	pr.Urgency = messages.Urgency(level)
+}
+
+// newProposalResponse converts a proposal row to its API representation with
+// escalation state as of now. Approvals and track details are filled in by the caller.
+func newProposalResponse(p *postgres.ProposalRow, now time.Time) ProposalResponse {
+	pr := ProposalResponse{
+		ProposalID:     p.ProposalID,
+		TrackID:        p.TrackID,
+		ActionType:     p.ActionType,
+		Priority:       p.Priority,
+		ThreatLevel:    p.ThreatLevel,
+		Rationale:      p.Rationale,
+		Status:         p.Status,
+		ExpiresAt:      p.ExpiresAt,
+		CreatedAt:      p.CreatedAt,
+		PolicyDecision: p.PolicyDecision,
+		HitCount:       p.HitCount,
+		LastHitAt:      p.LastHitAt,
+		Options:        p.Options,
+		Asset:          p.Asset,
+		Conflicts:      p.Conflicts,
+		SecurityLabel:  p.SecurityLabel,
+		Releasability:  p.Releasability,
+	}
+	pr.setEscalation(p.EscalationLevel, now)
+	return pr
 }
 
 // ListProposals handles GET /api/v1/proposals
@@ -90,36 +227,36 @@ func (h *ProposalHandler) ListProposals(w http.ResponseWriter, r *http.Request)
 		TrackID:     r.URL.Query().Get("track_id"),
 		ActionType:  r.URL.Query().Get("action_type"),
 		ThreatLevel: r.URL.Query().Get("threat_level"),
+		Access:      GetAccess(ctx),
 	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filter.Limit = limit
-		}
-	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
-	}
-
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filter.Offset = offset
-		}
+	page, err := ParsePage(r, postgres.ProposalSort)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
 	}
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+	filter.Sort, filter.After = page.Sort, page.After
 
-	proposals, err := h.db.ListProposals(ctx, filter)
+	loaded, err := h.cache.Load(CacheTableProposals, ReadCacheKey(r), func() (interface{}, error) {
+		return h.loadProposals(ctx, filter)
+	})
 	if err != nil {
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list proposals")
 		WriteError(w, http.StatusInternalServerError, "Failed to list proposals", correlationID)
 		return
 	}
+	result := loaded.(proposalPage)
+	proposals, total := result.proposals, result.total
+	next := page.NextCursor(len(proposals), func() string { return proposals[len(proposals)-1].Cursor })
+	WritePageHeaders(w, r, total, next)
 
 	// Collect unique track IDs and fetch track data
 	trackMap := make(map[string]*TrackInfo)
 	for _, p := range proposals {
 		if _, exists := trackMap[p.TrackID]; !exists {
 			track, err := h.db.GetTrack(ctx, p.TrackID)
-			if err == nil && track != nil {
+			if err == nil && track != nil && filter.Access.Permits(track.SecurityLabel, track.Releasability) {
 				trackMap[p.TrackID] = &TrackInfo{
 					TrackID:        track.ExternalID,
 					Classification: track.Classification,
@@ -133,36 +270,45 @@ func (h *ProposalHandler) ListProposals(w http.ResponseWriter, r *http.Request)
 
 	response := ProposalListResponse{
 		Proposals:     make([]ProposalResponse, 0, len(proposals)),
-		Total:         len(proposals),
+		Total:         total,
 		Limit:         filter.Limit,
 		Offset:        filter.Offset,
+		NextCursor:    next,
 		CorrelationID: correlationID,
 	}
 
 	for _, p := range proposals {
-		pr := ProposalResponse{
-			ProposalID:     p.ProposalID,
-			TrackID:        p.TrackID,
-			ActionType:     p.ActionType,
-			Priority:       p.Priority,
-			ThreatLevel:    p.ThreatLevel,
-			Rationale:      p.Rationale,
-			Status:         p.Status,
-			ExpiresAt:      p.ExpiresAt,
-			CreatedAt:      p.CreatedAt,
-			PolicyDecision: p.PolicyDecision,
-			HitCount:       p.HitCount,
-			LastHitAt:      p.LastHitAt,
-		}
+		pr := newProposalResponse(&p, time.Now())
+		h.setApprovals(ctx, &pr)
 		if track, exists := trackMap[p.TrackID]; exists {
 			pr.Track = track
 		}
 		response.Proposals = append(response.Proposals, pr)
 	}
+	h.setClaims(ctx, response.Proposals)
 
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// proposalPage is one page of a proposal list and the number of proposals
+// matching it
+type proposalPage struct {
+	proposals []postgres.ProposalRow
+	total     int64
+}
+
+func (h *ProposalHandler) loadProposals(ctx context.Context, filter postgres.ProposalFilter) (proposalPage, error) {
+	proposals, err := h.db.ListProposals(ctx, filter)
+	if err != nil {
+		return proposalPage{}, err
+	}
+	total, err := h.db.CountFilteredProposals(ctx, filter)
+	if err != nil {
+		return proposalPage{}, err
+	}
+	return proposalPage{proposals: proposals, total: total}, nil
+}
+
 // ProposalDetailResponse represents the detailed response for a single proposal
 type ProposalDetailResponse struct {
 	Proposal      ProposalResponse `json:"proposal"`
@@ -187,7 +333,10 @@ func (h *ProposalHandler) GetProposal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if proposal == nil {
+	// Proposals the caller may not see are reported missing, not forbidden,
+	// so their existence is not disclosed
+	access := GetAccess(ctx)
+	if proposal == nil || !access.Permits(proposal.SecurityLabel, proposal.Releasability) {
 		WriteError(w, http.StatusNotFound, "Proposal not found", correlationID)
 		return
 	}
@@ -195,7 +344,7 @@ func (h *ProposalHandler) GetProposal(w http.ResponseWriter, r *http.Request) {
 	// Fetch track data
 	var trackInfo *TrackInfo
 	track, err := h.db.GetTrack(ctx, proposal.TrackID)
-	if err == nil && track != nil {
+	if err == nil && track != nil && access.Permits(track.SecurityLabel, track.Releasability) {
 		trackInfo = &TrackInfo{
 			TrackID:        track.ExternalID,
 			Classification: track.Classification,
@@ -206,23 +355,14 @@ func (h *ProposalHandler) GetProposal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := ProposalDetailResponse{
-		Proposal: ProposalResponse{
-			ProposalID:     proposal.ProposalID,
-			TrackID:        proposal.TrackID,
-			ActionType:     proposal.ActionType,
-			Priority:       proposal.Priority,
-			ThreatLevel:    proposal.ThreatLevel,
-			Rationale:      proposal.Rationale,
-			Status:         proposal.Status,
-			ExpiresAt:      proposal.ExpiresAt,
-			CreatedAt:      proposal.CreatedAt,
-			PolicyDecision: proposal.PolicyDecision,
-			Track:          trackInfo,
-			HitCount:       proposal.HitCount,
-			LastHitAt:      proposal.LastHitAt,
-		},
+		Proposal:      newProposalResponse(proposal, time.Now()),
 		CorrelationID: correlationID,
 	}
+	response.Proposal.Track = trackInfo
+	h.setApprovals(ctx, &response.Proposal)
+	claimed := []ProposalResponse{response.Proposal}
+	h.setClaims(ctx, claimed)
+	response.Proposal = claimed[0]
 
 	WriteJSON(w, http.StatusOK, response)
 }
@@ -233,6 +373,7 @@ type DecisionRequest struct {
 	ApprovedBy string   `json:"approved_by"`
 	Reason     string   `json:"reason,omitempty"`
 	Conditions []string `json:"conditions,omitempty"`
+	Option     string   `json:"option,omitempty"` // Course of action to approve; empty approves the recommended one
 }
 
 // DecisionResponse represents the response for a decision
@@ -244,23 +385,52 @@ type DecisionResponse struct {
 	ApprovedAt    time.Time `json:"approved_at"`
 	Reason        string    `json:"reason,omitempty"`
 	CorrelationID string    `json:"correlation_id"`
+
+	BreakGlassGrantID string `json:"break_glass_grant_id,omitempty"`
+	SelectedOption    string `json:"selected_option,omitempty"`
 }
 
-// DecideProposal handles POST /api/v1/proposals/{proposalId}/decide
-func (h *ProposalHandler) DecideProposal(w http.ResponseWriter, r *http.Request) {
+// PartialApprovalResponse is returned when an approval awaits a second operator
+type PartialApprovalResponse struct {
+	ProposalID        string              `json:"proposal_id"`
+	Status            string              `json:"status"`
+	Approvals         []messages.Approval `json:"approvals"`
+	RequiredApprovals int                 `json:"required_approvals"`
+	CorrelationID     string              `json:"correlation_id"`
+}
+
+// pendingDecision is a validated decision on a pending proposal by an approver
+// with authority for it
+type pendingDecision struct {
+	proposal  *postgres.ProposalRow
+	userID    string
+	authority breakglass.Authority
+	grants    []breakglass.Grant
+
+	// Action being decided: the selected course of action for approvals, set
+	// in selected when the proposal offers options
+	actionType string
+	selected   string
+}
+
+// prepareDecision validates a decision request and checks the approver's
+// authority. If the decision cannot proceed it writes the error response and
+// returns false.
+func (h *ProposalHandler) prepareDecision(w http.ResponseWriter, r *http.Request, proposalID string, req *DecisionRequest) (pendingDecision, bool) {
 	ctx := r.Context()
 	correlationID := GetCorrelationID(ctx)
-	proposalID := chi.URLParam(r, "proposalId")
+	var d pendingDecision
 
 	if proposalID == "" {
 		WriteError(w, http.StatusBadRequest, "Proposal ID is required", correlationID)
-		return
+		return d, false
 	}
 
-	var req DecisionRequest
-	if err := DecodeJSON(r, &req); err != nil {
-		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
-		return
+	// The approver is the authenticated user, so one operator cannot approve
+	// twice under the two-person rule by naming someone else in approved_by
+	var ok bool
+	if d.userID, ok = actingUser(w, r, req.ApprovedBy, "approved_by"); !ok {
+		return d, false
 	}
 
 	// Get the proposal
@@ -268,86 +438,218 @@ func (h *ProposalHandler) DecideProposal(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to get proposal")
 		WriteError(w, http.StatusInternalServerError, "Failed to get proposal", correlationID)
-		return
+		return d, false
 	}
 
-	if proposal == nil {
+	if proposal == nil || !GetAccess(ctx).Permits(proposal.SecurityLabel, proposal.Releasability) {
 		WriteError(w, http.StatusNotFound, "Proposal not found", correlationID)
-		return
+		return d, false
 	}
+	d.proposal = proposal
 
 	// Check if proposal is still pending
 	if proposal.Status != "pending" {
 		WriteError(w, http.StatusConflict, "Proposal is not pending", correlationID)
-		return
+		return d, false
 	}
 
 	// Check if proposal has expired
 	if time.Now().UTC().After(proposal.ExpiresAt) {
 		WriteError(w, http.StatusConflict, "Proposal has expired", correlationID)
-		return
+		return d, false
+	}
+
+	// Only the operator working a claimed proposal may decide it
+	if h.claims != nil {
+		claims, err := h.claims.claimsOn(ctx, []string{proposalID})
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to get proposal claim")
+			WriteError(w, http.StatusInternalServerError, "Failed to get proposal claim", correlationID)
+			return d, false
+		}
+		if c, ok := claims[proposalID]; ok && c.ClaimedBy != d.userID {
+			WriteError(w, http.StatusConflict, "Proposal is claimed by "+c.ClaimedBy, correlationID)
+			return d, false
+		}
 	}
 
-	// Get user ID from request or context (set by auth middleware)
-	userID := req.ApprovedBy
-	if userID == "" {
-		userID = GetUserID(ctx)
+	// An approval may select one of the proposal's courses of action
+	d.actionType = proposal.ActionType
+	if req.Approved {
+		d.actionType, err = coa.Select(proposal.Options, proposal.ActionType, req.Option)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+			return d, false
+		}
+		if len(proposal.Options) > 0 {
+			d.selected = d.actionType
+		}
+	}
+
+	// Approvals require authority for the action, from the user's role or a
+	// live break-glass grant. Denials are always allowed.
+	if req.Approved {
+		d.grants, err = h.db.GetActiveBreakGlassGrants(ctx, d.userID)
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("user_id", d.userID).Msg("Failed to load break-glass grants")
+			WriteError(w, http.StatusInternalServerError, "Failed to check approval authority", correlationID)
+			return d, false
+		}
+
+		d.authority, err = breakglass.Authorize(ctx, h.opa, d.userID, d.actionType, d.grants)
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Approval authority check failed")
+			WriteError(w, http.StatusServiceUnavailable, "Approval authority check unavailable", correlationID)
+			return d, false
+		}
+		if !d.authority.Allowed {
+			h.logger.Warn().
+				Str("correlation_id", correlationID).
+				Str("proposal_id", proposalID).
+				Str("user_id", d.userID).
+				Str("role", d.authority.Role).
+				Strs("reasons", d.authority.Reasons).
+				Msg("Approval refused: insufficient authority")
+			WriteProblem(w, problem.CodePolicyDenied, "Not authorized to approve "+d.actionType+": "+strings.Join(d.authority.Reasons, "; "), correlationID)
+			return d, false
+		}
+	}
+
+	return d, true
+}
+
+// DecideProposal handles POST /api/v1/proposals/{proposalId}/decide
+func (h *ProposalHandler) DecideProposal(w http.ResponseWriter, r *http.Request) {
+	var req DecisionRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", GetCorrelationID(r.Context()))
+		return
 	}
-	if userID == "" {
-		WriteError(w, http.StatusBadRequest, "approved_by is required", correlationID)
+	h.decide(w, r, chi.URLParam(r, "proposalId"), req)
+}
+
+// decide records an operator's decision on a proposal and publishes it on
+// DECISIONS. The proposal is claimed when the decision is stored, so of two
+// operators deciding it at once the second is refused with 409.
+func (h *ProposalHandler) decide(w http.ResponseWriter, r *http.Request, proposalID string, req DecisionRequest) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	d, ok := h.prepareDecision(w, r, proposalID, &req)
+	if !ok {
 		return
 	}
+	proposal, userID, authority, grants := d.proposal, d.userID, d.authority, d.grants
+	var err error
+
+	// Engage approvals under the two-person rule wait for a second operator
+	var approvals []messages.Approval
+	if req.Approved && h.twoPerson.Applies(d.actionType, proposal.Priority) {
+		approvals, err = twoperson.Record(ctx, h.db, proposalID, messages.Approval{
+			ApprovedBy:        userID,
+			Role:              authority.Role,
+			ApprovedAt:        time.Now().UTC(),
+			Reason:            req.Reason,
+			Option:            d.selected,
+			BreakGlassGrantID: authority.GrantID,
+		})
+		if errors.Is(err, twoperson.ErrAlreadyApproved) || errors.Is(err, twoperson.ErrNotPending) || errors.Is(err, twoperson.ErrOptionMismatch) {
+			WriteError(w, http.StatusConflict, err.Error(), correlationID)
+			return
+		}
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to record approval")
+			WriteError(w, http.StatusInternalServerError, "Failed to record approval", correlationID)
+			return
+		}
+		if !twoperson.Complete(approvals) {
+			h.logger.Info().
+				Str("correlation_id", correlationID).
+				Str("proposal_id", proposalID).
+				Str("approved_by", userID).
+				Str("role", authority.Role).
+				Msg("Approval recorded; awaiting second operator")
+			// Hand the proposal on so the second operator can decide it
+			if h.claims != nil {
+				h.claims.releaseHeld(ctx, proposalID, userID, correlationID)
+			}
+			WriteJSON(w, http.StatusAccepted, PartialApprovalResponse{
+				ProposalID:        proposalID,
+				Status:            twoperson.StatusAwaitingApproval,
+				Approvals:         approvals,
+				RequiredApprovals: twoperson.RequiredApprovals,
+				CorrelationID:     correlationID,
+			})
+			return
+		}
+	}
 
 	// Create the decision
 	decision := &messages.Decision{
 		Envelope: messages.NewEnvelope("api-gateway", "authorizer").
-			WithCorrelation(correlationID, proposal.ProposalID),
+			WithCorrelation(correlationID, proposal.ProposalID).
+			WithLabels(messages.Envelope{Classification: proposal.SecurityLabel, Releasability: proposal.Releasability}),
 		DecisionID: uuid.New().String(),
 		ProposalID: proposalID,
 		TrackID:    proposal.TrackID,
-		ActionType: proposal.ActionType,
+		ActionType: d.actionType,
 		Approved:   req.Approved,
 		ApprovedBy: userID,
 		ApprovedAt: time.Now().UTC(),
 		Reason:     req.Reason,
 		Conditions: req.Conditions,
+
+		BreakGlassGrantID: authority.GrantID,
+		Approvals:         approvals,
+		SelectedOption:    d.selected,
 	}
 
-	// Store decision in database
+	// Continue the trace the authorizer stored with the proposal
+	decision.Envelope = decision.Envelope.WithTracing(proposal.TraceID, proposal.SpanID)
+	ctx, span := tracing.StartFromEnvelope(ctx, "gateway.decide", decision.Envelope,
+		tracing.AttrProposalID.String(proposalID),
+		tracing.AttrDecisionID.String(decision.DecisionID),
+		tracing.AttrTrackID.String(proposal.TrackID))
+	defer span.End()
+	decision.Envelope = tracing.InjectEnvelope(ctx, decision.Envelope)
+
+	// Store the decision, settling the proposal unless it was decided,
+	// expired, or claimed by another operator since it was read
 	if err := h.db.InsertDecision(ctx, decision); err != nil {
+		tracing.RecordError(span, err)
+		if errors.Is(err, postgres.ErrProposalClaimed) {
+			WriteError(w, http.StatusConflict, "Proposal is claimed by another operator", correlationID)
+			return
+		}
+		if errors.Is(err, postgres.ErrProposalNotPending) {
+			h.logger.Warn().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Str("approved_by", userID).Msg("Decision refused: proposal already decided")
+			WriteError(w, http.StatusConflict, "Proposal is not pending", correlationID)
+			return
+		}
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to insert decision")
 		WriteError(w, http.StatusInternalServerError, "Failed to save decision", correlationID)
 		return
 	}
 
-	// Update proposal status
-	newStatus := "denied"
-	if req.Approved {
-		newStatus = "approved"
-	}
-	if err := h.db.UpdateProposalStatus(ctx, proposalID, newStatus); err != nil {
-		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", proposalID).Msg("Failed to update proposal status")
-		// Don't return error - decision was saved
+	for _, g := range grants {
+		if g.GrantID == decision.BreakGlassGrantID {
+			h.announceBreakGlassUse(g, decision, correlationID)
+		}
 	}
 
 	// Publish decision to NATS
-	if h.nc != nil {
+	if h.pub != nil {
 		subject := decision.Subject()
-		data, err := json.Marshal(decision)
-		if err != nil {
-			h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to marshal decision")
+		if err := h.pub.Publish(decision); err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", subject).Msg("Failed to publish decision")
 		} else {
-			if err := h.nc.Publish(subject, data); err != nil {
-				h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", subject).Msg("Failed to publish decision")
-			} else {
-				h.logger.Info().
-					Str("correlation_id", correlationID).
-					Str("decision_id", decision.DecisionID).
-					Str("proposal_id", proposalID).
-					Bool("approved", req.Approved).
-					Str("subject", subject).
-					Msg("Decision published")
-			}
+			h.logger.Info().
+				Str("correlation_id", correlationID).
+				Str("decision_id", decision.DecisionID).
+				Str("proposal_id", proposalID).
+				Bool("approved", req.Approved).
+				Str("subject", subject).
+				Msg("Decision published")
 		}
 	}
 
@@ -359,7 +661,129 @@ func (h *ProposalHandler) DecideProposal(w http.ResponseWriter, r *http.Request)
 		ApprovedAt:    decision.ApprovedAt,
 		Reason:        decision.Reason,
 		CorrelationID: correlationID,
+
+		BreakGlassGrantID: decision.BreakGlassGrantID,
+		SelectedOption:    decision.SelectedOption,
 	}
 
 	WriteJSON(w, http.StatusCreated, response)
 }
+
+// ForwardDecision handles POST /api/v1/proposals/{proposalId}/decision. The
+// decision is validated and the approver's authority checked here, then it is
+// forwarded to the authorizer, which records and publishes it.
+func (h *ProposalHandler) ForwardDecision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	if h.forwarder == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Decision forwarding is not configured", correlationID)
+		return
+	}
+
+	var req DecisionRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	d, ok := h.prepareDecision(w, r, chi.URLParam(r, "proposalId"), &req)
+	if !ok {
+		return
+	}
+
+	cmd := messages.DecisionCommand{
+		Envelope: messages.NewEnvelope("api-gateway", "api-gateway").
+			WithCorrelation(correlationID, d.proposal.ProposalID),
+		ProposalID: d.proposal.ProposalID,
+		Approved:   req.Approved,
+		ApprovedBy: d.userID,
+		Reason:     req.Reason,
+		Conditions: req.Conditions,
+		Option:     req.Option,
+	}
+
+	reply, err := h.forwarder.Forward(ctx, cmd)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("proposal_id", cmd.ProposalID).Msg("Failed to forward decision")
+		switch {
+		case errors.Is(err, ErrNoAuthorizer):
+			WriteError(w, http.StatusServiceUnavailable, "No authorizer available", correlationID)
+		case errors.Is(err, context.DeadlineExceeded):
+			WriteError(w, http.StatusGatewayTimeout, "Authorizer did not respond", correlationID)
+		default:
+			WriteError(w, http.StatusBadGateway, "Failed to forward decision", correlationID)
+		}
+		return
+	}
+
+	switch reply.Status {
+	case messages.DecisionCommandPublished:
+		h.logger.Info().
+			Str("correlation_id", correlationID).
+			Str("decision_id", reply.DecisionID).
+			Str("proposal_id", cmd.ProposalID).
+			Bool("approved", cmd.Approved).
+			Msg("Decision forwarded to authorizer")
+		WriteJSON(w, http.StatusCreated, DecisionResponse{
+			DecisionID:    reply.DecisionID,
+			ProposalID:    cmd.ProposalID,
+			Approved:      cmd.Approved,
+			ApprovedBy:    cmd.ApprovedBy,
+			ApprovedAt:    time.Now().UTC(),
+			Reason:        cmd.Reason,
+			CorrelationID: correlationID,
+
+			BreakGlassGrantID: d.authority.GrantID,
+			SelectedOption:    d.selected,
+		})
+	case messages.DecisionCommandPending:
+		WriteJSON(w, http.StatusAccepted, PartialApprovalResponse{
+			ProposalID:        cmd.ProposalID,
+			Status:            twoperson.StatusAwaitingApproval,
+			Approvals:         reply.Approvals,
+			RequiredApprovals: twoperson.RequiredApprovals,
+			CorrelationID:     correlationID,
+		})
+	default:
+		code := reply.Code
+		if code < http.StatusBadRequest {
+			code = http.StatusBadGateway
+		}
+		message := reply.Error
+		if message == "" {
+			message = "Authorizer rejected the decision"
+		}
+		p := problem.ForStatus(code, message)
+		if reply.ErrorCode != "" {
+			p.Code = problem.Code(reply.ErrorCode)
+		}
+		p.CorrelationID = correlationID
+		problem.Write(w, p)
+	}
+}
+
+// announceBreakGlassUse logs and publishes an approval made under a break-glass grant
+func (h *ProposalHandler) announceBreakGlassUse(grant breakglass.Grant, decision *messages.Decision, correlationID string) {
+	h.logger.Warn().
+		Str("correlation_id", correlationID).
+		Str("grant_id", grant.GrantID).
+		Str("user_id", decision.ApprovedBy).
+		Str("role", grant.Role).
+		Str("proposal_id", decision.ProposalID).
+		Str("action_type", decision.ActionType).
+		Msg("BREAK GLASS used: approval made under elevated authority")
+
+	if h.pub == nil {
+		return
+	}
+
+	n := grant.Notification(messages.BreakGlassUsed, decision.ApprovedBy, "api-gateway", "api-gateway")
+	n.Envelope = n.Envelope.WithCorrelation(correlationID, decision.Envelope.MessageID)
+	n.ProposalID = decision.ProposalID
+	n.DecisionID = decision.DecisionID
+	n.ActionType = decision.ActionType
+
+	if err := h.pub.Publish(n); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("subject", n.Subject()).Msg("Failed to publish break-glass notification")
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// Publisher sends the gateway's messages to NATS encoded as the agents encode
+// theirs: labelled, in MESSAGE_ENCODING, sealed when ENCRYPT_CLASSIFICATIONS
+// selects their label, and signed with the gateway's key. A nil Publisher, as
+// handlers get without NATS, sends nothing.
+type Publisher struct {
+	nc      *nats.Conn
+	js      jetstream.JetStream
+	encoder messages.Encoder
+	signer  *signing.Signer
+}
+
+// NewPublisher creates a Publisher, or returns nil without a NATS connection.
+// Without JetStream, PublishStream fails, and without a signer messages are
+// published unsigned.
+func NewPublisher(nc *nats.Conn, js jetstream.JetStream, encoder messages.Encoder, signer *signing.Signer) *Publisher {
+	if nc == nil {
+		return nil
+	}
+	return &Publisher{nc: nc, js: js, encoder: encoder, signer: signer}
+}
+
+// Publish sends a message on its subject over core NATS
+func (p *Publisher) Publish(msg messages.Message) error {
+	if p == nil {
+		return nil
+	}
+	m, err := p.encode(msg)
+	if err != nil {
+		return err
+	}
+	return p.nc.PublishMsg(m)
+}
+
+// PublishStream publishes a message on its subject to JetStream and waits
+// for the stream to store it
+func (p *Publisher) PublishStream(ctx context.Context, msg messages.Message, opts ...jetstream.PublishOpt) error {
+	if p == nil || p.js == nil {
+		return fmt.Errorf("JetStream is not available")
+	}
+	m, err := p.encode(msg)
+	if err != nil {
+		return err
+	}
+	_, err = p.js.PublishMsg(ctx, m, opts...)
+	return err
+}
+
+// encode encodes and signs a message for its subject, naming its encoding in
+// the Content-Type header
+func (p *Publisher) encode(msg messages.Message) (*nats.Msg, error) {
+	// The envelope names the signing key inside what is signed
+	var key *signing.Key
+	if p.signer != nil {
+		key = p.signer.Key()
+	}
+	env := msg.GetEnvelope()
+	env.SigningKeyID = ""
+	if key != nil {
+		env.SigningKeyID = key.KeyID
+	}
+	msg.SetEnvelope(env)
+
+	data, contentType, err := p.encoder.Encode(msg)
+	if err != nil {
+		return nil, err
+	}
+	m := nats.NewMsg(msg.Subject())
+	m.Header.Set(messages.ContentTypeHeader, contentType)
+	m.Data = data
+	signing.SignMsg(m, key)
+	return m, nil
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// Read cache defaults
+const (
+	DefaultReadCacheMaxAge = 5 * time.Second // Bounds staleness of time-windowed queries such as active tracks
+	ReadCacheMaxEntries    = 256             // Cached results per table before the table's cache is emptied
+	readCacheRetryMin      = time.Second     // First wait before listening again after the connection fails
+	readCacheRetryMax      = 30 * time.Second
+)
+
+// Tables whose list queries the gateway caches
+const (
+	CacheTableTracks    = "tracks"
+	CacheTableProposals = "proposals"
+)
+
+// ParseReadCacheMaxAge parses READ_CACHE_MAX_AGE. Empty yields
+// DefaultReadCacheMaxAge; zero disables the cache.
+func ParseReadCacheMaxAge(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultReadCacheMaxAge, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid READ_CACHE_MAX_AGE %q: must be a duration, 0 to disable", value)
+	}
+	return d, nil
+}
+
+// ReadCacheKey identifies a list request by its query and the caller's access,
+// which decides the rows it may see
+func ReadCacheKey(r *http.Request) string {
+	access := GetAccess(r.Context())
+	return access.Clearance + "|" + strings.Join(access.Releasability, ",") + "|" + r.URL.Query().Encode()
+}
+
+// TableNotifier reports writes to tables, as postgres.Pool does through
+// LISTEN/NOTIFY
+type TableNotifier interface {
+	ListenTableChanges(ctx context.Context, ready func(), changed func(table string)) error
+}
+
+// ReadCache is a read-through cache for hot list queries. A table's cached
+// results are dropped whenever the database reports the table changed, and
+// none are older than the maximum age. Results are only cached while the
+// cache is listening for changes, so a lost connection cannot leave stale
+// results behind. A nil ReadCache loads every time.
+type ReadCache struct {
+	notifier TableNotifier
+	maxAge   time.Duration
+	logger   zerolog.Logger
+
+	mu        sync.Mutex
+	listening bool
+	tables    map[string]*cachedTable
+
+	hits          *prometheus.CounterVec
+	misses        *prometheus.CounterVec
+	invalidations *prometheus.CounterVec
+	listenGauge   prometheus.Gauge
+}
+
+// cachedTable holds one table's cached results. The generation advances on
+// every invalidation, so a load that raced a change is not stored.
+type cachedTable struct {
+	generation uint64
+	entries    map[string]cachedResult
+}
+
+type cachedResult struct {
+	value    interface{}
+	loadedAt time.Time
+}
+
+// NewReadCache creates a ReadCache that caches results for up to maxAge
+func NewReadCache(notifier TableNotifier, maxAge time.Duration, logger zerolog.Logger) *ReadCache {
+	return &ReadCache{
+		notifier: notifier,
+		maxAge:   maxAge,
+		logger:   logger.With().Str("component", "read_cache").Logger(),
+		tables:   make(map[string]*cachedTable),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_read_cache_hits_total",
+			Help: "List queries answered from the gateway read cache",
+		}, []string{"table"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_read_cache_misses_total",
+			Help: "List queries the gateway read cache passed to the database",
+		}, []string{"table"}),
+		invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_read_cache_invalidations_total",
+			Help: "Table change notifications that emptied the gateway read cache for a table",
+		}, []string{"table"}),
+		listenGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cjadc2_api_read_cache_listening",
+			Help: "Whether the gateway read cache is receiving table change notifications (1) or bypassed (0)",
+		}),
+	}
+}
+
+// Collectors returns the cache's metrics for registration
+func (c *ReadCache) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.hits, c.misses, c.invalidations, c.listenGauge}
+}
+
+// Run listens for table changes until ctx ends, listening again with backoff
+// whenever the connection fails
+func (c *ReadCache) Run(ctx context.Context) {
+	delay := readCacheRetryMin
+	for {
+		err := c.notifier.ListenTableChanges(ctx, func() {
+			c.setListening(true)
+			delay = readCacheRetryMin
+			c.logger.Info().Msg("Listening for table changes; read cache enabled")
+		}, c.Invalidate)
+		c.setListening(false)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn().Err(err).Dur("retry_in", delay).Msg("Stopped receiving table changes; read cache bypassed")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > readCacheRetryMax {
+			delay = readCacheRetryMax
+		}
+	}
+}
+
+// Listening reports whether results are being cached
+func (c *ReadCache) Listening() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.listening
+}
+
+// Load returns the cached result of the query identified by key on table, or
+// runs load and caches what it returns. Errors are not cached.
+func (c *ReadCache) Load(table, key string, load func() (interface{}, error)) (interface{}, error) {
+	if c == nil || c.maxAge <= 0 {
+		return load()
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	t := c.table(table)
+	if r, ok := t.entries[key]; ok && c.listening && now.Sub(r.loadedAt) < c.maxAge {
+		c.mu.Unlock()
+		c.hits.WithLabelValues(table).Inc()
+		return r.value, nil
+	}
+	generation, listening := t.generation, c.listening
+	c.mu.Unlock()
+	c.misses.WithLabelValues(table).Inc()
+
+	value, err := load()
+	if err != nil || !listening {
+		return value, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.listening || t.generation != generation {
+		return value, nil // The table changed while loading
+	}
+	if len(t.entries) >= ReadCacheMaxEntries {
+		t.entries = make(map[string]cachedResult)
+	}
+	// Aged from before the query ran, when its time window was taken
+	t.entries[key] = cachedResult{value: value, loadedAt: now}
+	return value, nil
+}
+
+// Invalidate drops every cached result for table
+func (c *ReadCache) Invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.table(table).reset()
+	c.invalidations.WithLabelValues(table).Inc()
+}
+
+func (c *ReadCache) setListening(listening bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listening = listening
+	for _, t := range c.tables {
+		t.reset()
+	}
+	if listening {
+		c.listenGauge.Set(1)
+	} else {
+		c.listenGauge.Set(0)
+	}
+}
+
+// table returns table's cache, creating it. c.mu must be held.
+func (c *ReadCache) table(name string) *cachedTable {
+	t, ok := c.tables[name]
+	if !ok {
+		t = &cachedTable{entries: make(map[string]cachedResult)}
+		c.tables[name] = t
+	}
+	return t
+}
+
+func (t *cachedTable) reset() {
+	t.generation++
+	t.entries = make(map[string]cachedResult)
+}
@@ -0,0 +1,753 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// MessageTypeSessionReplay announces that a session replay started or ended
+const MessageTypeSessionReplay = "session.replay"
+
+// Session replay statuses
+const (
+	ReplayStarted   = "started"
+	ReplayCompleted = "completed"
+	ReplayStopped   = "stopped"
+	ReplayFailed    = "failed"
+)
+
+// Session recording and replay limits
+const (
+	SessionRecordBuffer  = 4096            // Events queued for recording before new ones are dropped
+	SessionFlushInterval = time.Second     // How often queued events are written
+	SessionFlushBatch    = 500             // Events written per batch
+	SessionReplayPage    = 500             // Events read per query during a replay
+	MaxReplaySpeed       = 100.0           // Fastest replay, as a multiple of real time
+	MaxSessionNameLength = 200             // Matches sessions.name
+	sessionShutdownFlush = 5 * time.Second // Time allowed to write queued events at shutdown
+)
+
+// sessionEventTypes are the events a session records: what operators act on
+// and the outcome. Track updates are recorded only when a session asks for
+// them, since they dominate the broadcast volume.
+var sessionEventTypes = map[string]bool{
+	MessageTypeProposalNew:         true,
+	MessageTypeProposalEscalated:   true,
+	MessageTypeProposalSLA:         true,
+	MessageTypeProposalRescored:    true,
+	MessageTypeProposalExpired:     true,
+	MessageTypeProposalClaimed:     true,
+	MessageTypeProposalReleased:    true,
+	MessageTypeBreakGlass:          true,
+	MessageTypeDecisionMade:        true,
+	MessageTypeDecisionRevoked:     true,
+	MessageTypeEffectExecuted:      true,
+	MessageTypeEffectProgress:      true,
+	MessageTypeEffectAssessment:    true,
+	MessageTypeEngagementCompleted: true,
+	MessageTypeSimControl:          true,
+}
+
+// SessionRecords reports whether a session records events of eventType
+func SessionRecords(eventType string, includeTracks bool) bool {
+	if strings.HasPrefix(eventType, "track.") {
+		return includeTracks
+	}
+	return sessionEventTypes[eventType]
+}
+
+// SessionStore is the storage the session handler needs
+type SessionStore interface {
+	StartSession(ctx context.Context, s *postgres.SessionRow) error
+	StopSession(ctx context.Context, sessionID string, now time.Time) (*postgres.SessionRow, error)
+	GetSession(ctx context.Context, sessionID string) (*postgres.SessionRow, error)
+	RecordingSession(ctx context.Context) (*postgres.SessionRow, error)
+	ListSessions(ctx context.Context, limit int) ([]postgres.SessionRow, error)
+	DeleteSession(ctx context.Context, sessionID string) (bool, error)
+	InsertSessionEvents(ctx context.Context, sessionID string, events []postgres.SessionEventRow) error
+	ListSessionEvents(ctx context.Context, sessionID string, afterSeq, limit int) ([]postgres.SessionEventRow, error)
+}
+
+// EventReplay marks an event re-broadcast from a recorded session, so clients
+// can tell it from live traffic
+type EventReplay struct {
+	ReplayID  string `json:"replay_id"`
+	SessionID string `json:"session_id"`
+	Seq       int    `json:"seq"` // The event's position in the session
+}
+
+// SessionReplayStatus is the payload of session.replay events and the
+// response to starting a replay
+type SessionReplayStatus struct {
+	ReplayID  string    `json:"replay_id"`
+	SessionID string    `json:"session_id"`
+	Status    string    `json:"status"`
+	Speed     float64   `json:"speed"`
+	MaxGap    string    `json:"max_gap,omitempty"`
+	FromSeq   int       `json:"from_seq,omitempty"`
+	Events    int       `json:"events"` // Events in the session, or replayed once it ends
+	StartedAt time.Time `json:"started_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// sessionEvent is an event queued for recording
+type sessionEvent struct {
+	sessionID string
+	row       postgres.SessionEventRow
+}
+
+// activeReplay is the replay in progress
+type activeReplay struct {
+	status SessionReplayStatus
+	cancel context.CancelFunc
+}
+
+// SessionHandler records the events broadcast to operators into sessions and
+// replays a session's timeline over the WebSocket for debriefs. The hub
+// passes it every broadcast; while a session is recording, the events it
+// records are queued and written in batches, so recording never holds up the
+// broadcast.
+type SessionHandler struct {
+	store  SessionStore
+	hub    *WebSocketHub
+	logger zerolog.Logger
+
+	mu        sync.Mutex
+	recording *postgres.SessionRow
+	nextSeq   int
+	replay    *activeReplay
+	runCtx    context.Context // Replays stop with the gateway
+
+	queue    chan sessionEvent
+	recorded prometheus.Counter
+	dropped  prometheus.Counter
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(store SessionStore, hub *WebSocketHub, logger zerolog.Logger) *SessionHandler {
+	return &SessionHandler{
+		store:  store,
+		hub:    hub,
+		logger: logger.With().Str("handler", "sessions").Logger(),
+		runCtx: context.Background(),
+		queue:  make(chan sessionEvent, SessionRecordBuffer),
+		recorded: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_session_events_recorded_total",
+			Help: "Broadcast events recorded into sessions",
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_session_events_dropped_total",
+			Help: "Broadcast events a recording session lost because its queue was full or the write failed",
+		}),
+	}
+}
+
+// Collectors returns the handler's metrics for registration
+func (h *SessionHandler) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.recorded, h.dropped}
+}
+
+// Routes returns the session routes
+func (h *SessionHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.List)
+	r.Post("/", h.Start)
+	r.Get("/{sessionId}", h.Get)
+	r.Delete("/{sessionId}", h.Delete)
+	r.Post("/{sessionId}/stop", h.Stop)
+	r.Get("/{sessionId}/events", h.Events)
+	r.Post("/{sessionId}/replay", h.Replay)
+	r.Delete("/{sessionId}/replay", h.StopReplay)
+	return r
+}
+
+// StartSessionRequest represents the request body for starting a session
+type StartSessionRequest struct {
+	Name          string `json:"name"`
+	IncludeTracks bool   `json:"include_tracks"`
+}
+
+// SessionResponse represents a session in API responses
+type SessionResponse struct {
+	Session       postgres.SessionRow  `json:"session"`
+	Replay        *SessionReplayStatus `json:"replay,omitempty"`
+	CorrelationID string               `json:"correlation_id"`
+}
+
+// List handles GET /api/v1/sessions
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			WriteError(w, http.StatusBadRequest, "limit must be between 1 and 500", correlationID)
+			return
+		}
+		limit = n
+	}
+
+	sessions, err := h.store.ListSessions(ctx, limit)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list sessions")
+		WriteError(w, http.StatusInternalServerError, "Failed to list sessions", correlationID)
+		return
+	}
+	if sessions == nil {
+		sessions = []postgres.SessionRow{}
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"sessions":       sessions,
+		"count":          len(sessions),
+		"correlation_id": correlationID,
+	})
+}
+
+// Start handles POST /api/v1/sessions, which starts recording a session
+func (h *SessionHandler) Start(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req StartSessionRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		WriteError(w, http.StatusBadRequest, "name is required", correlationID)
+		return
+	}
+	if len(req.Name) > MaxSessionNameLength {
+		WriteError(w, http.StatusBadRequest, fmt.Sprintf("name must be at most %d characters", MaxSessionNameLength), correlationID)
+		return
+	}
+	startedBy := GetUserID(ctx)
+	if startedBy == "" {
+		startedBy = "anonymous"
+	}
+
+	session := postgres.SessionRow{Name: req.Name, StartedBy: startedBy, IncludeTracks: req.IncludeTracks}
+	err := h.store.StartSession(ctx, &session)
+	if errors.Is(err, postgres.ErrSessionRecording) {
+		WriteError(w, http.StatusConflict, "Another session is recording", correlationID)
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to start session")
+		WriteError(w, http.StatusInternalServerError, "Failed to start session", correlationID)
+		return
+	}
+
+	h.mu.Lock()
+	h.recording = &session
+	h.nextSeq = 1
+	h.mu.Unlock()
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("session_id", session.SessionID).
+		Str("name", session.Name).
+		Str("started_by", startedBy).
+		Bool("include_tracks", session.IncludeTracks).
+		Msg("Session recording started")
+
+	WriteJSON(w, http.StatusCreated, SessionResponse{Session: session, CorrelationID: correlationID})
+}
+
+// Get handles GET /api/v1/sessions/{sessionId}
+func (h *SessionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	WriteJSON(w, http.StatusOK, SessionResponse{Session: *session, Replay: h.replayOf(session.SessionID), CorrelationID: correlationID})
+}
+
+// Stop handles POST /api/v1/sessions/{sessionId}/stop, which ends recording
+func (h *SessionHandler) Stop(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	sessionID := chi.URLParam(r, "sessionId")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		WriteError(w, http.StatusNotFound, "Session not found", correlationID)
+		return
+	}
+
+	session, err := h.store.StopSession(ctx, sessionID, time.Now().UTC())
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("session_id", sessionID).Msg("Failed to stop session")
+		WriteError(w, http.StatusInternalServerError, "Failed to stop session", correlationID)
+		return
+	}
+	if session == nil {
+		WriteError(w, http.StatusNotFound, "Session not found", correlationID)
+		return
+	}
+
+	h.mu.Lock()
+	if h.recording != nil && h.recording.SessionID == sessionID {
+		h.recording = nil
+	}
+	h.mu.Unlock()
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("session_id", sessionID).
+		Int("events", session.EventCount).
+		Msg("Session recording stopped")
+
+	WriteJSON(w, http.StatusOK, SessionResponse{Session: *session, CorrelationID: correlationID})
+}
+
+// Delete handles DELETE /api/v1/sessions/{sessionId}. A recording or
+// replaying session must be stopped first.
+func (h *SessionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	if session.Recording() {
+		WriteError(w, http.StatusConflict, "Session is recording", correlationID)
+		return
+	}
+	if h.replayOf(session.SessionID) != nil {
+		WriteError(w, http.StatusConflict, "Session is replaying", correlationID)
+		return
+	}
+
+	if _, err := h.store.DeleteSession(ctx, session.SessionID); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("session_id", session.SessionID).Msg("Failed to delete session")
+		WriteError(w, http.StatusInternalServerError, "Failed to delete session", correlationID)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Events handles GET /api/v1/sessions/{sessionId}/events, listing recorded
+// events in order. ?after_seq pages through them.
+func (h *SessionHandler) Events(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	afterSeq, limit := 0, SessionReplayPage
+	if v := q.Get("after_seq"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			WriteError(w, http.StatusBadRequest, "after_seq must be a non-negative integer", correlationID)
+			return
+		}
+		afterSeq = n
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > SessionReplayPage {
+			WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", SessionReplayPage), correlationID)
+			return
+		}
+		limit = n
+	}
+
+	events, err := h.store.ListSessionEvents(ctx, session.SessionID, afterSeq, limit)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("session_id", session.SessionID).Msg("Failed to list session events")
+		WriteError(w, http.StatusInternalServerError, "Failed to list session events", correlationID)
+		return
+	}
+	if events == nil {
+		events = []postgres.SessionEventRow{}
+	}
+
+	WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"session_id":     session.SessionID,
+		"events":         events,
+		"count":          len(events),
+		"correlation_id": correlationID,
+	})
+}
+
+// ReplayRequest represents the request body for replaying a session
+type ReplayRequest struct {
+	// Speed is the multiple of real time to replay at; default 1
+	Speed float64 `json:"speed"`
+	// MaxGap caps the pause between consecutive events, e.g. "5s", so quiet
+	// stretches do not stall a debrief; empty keeps every gap
+	MaxGap string `json:"max_gap"`
+	// FromSeq starts the replay at this event; default the first
+	FromSeq int `json:"from_seq"`
+}
+
+// parseReplayRequest validates a replay request, returning the gap cap
+func parseReplayRequest(req *ReplayRequest) (time.Duration, error) {
+	if req.Speed == 0 {
+		req.Speed = 1
+	}
+	if req.Speed < 0 || req.Speed > MaxReplaySpeed {
+		return 0, fmt.Errorf("speed must be greater than 0 and at most %g", MaxReplaySpeed)
+	}
+	if req.FromSeq < 0 {
+		return 0, fmt.Errorf("from_seq must not be negative")
+	}
+	if req.MaxGap == "" {
+		return 0, nil
+	}
+	gap, err := time.ParseDuration(req.MaxGap)
+	if err != nil || gap <= 0 {
+		return 0, fmt.Errorf("max_gap must be a positive duration")
+	}
+	return gap, nil
+}
+
+// Replay handles POST /api/v1/sessions/{sessionId}/replay. It re-broadcasts
+// the session's events over the WebSocket in their recorded order and
+// spacing, scaled by speed, and returns once the replay has started. Each
+// replayed event carries a replay marker; session.replay events announce the
+// start and end. One replay runs at a time.
+func (h *SessionHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := DecodeJSON(r, &req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+			return
+		}
+	}
+	maxGap, err := parseReplayRequest(&req)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+	session, ok := h.session(w, r)
+	if !ok {
+		return
+	}
+	if h.hub == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Replay requires the WebSocket hub", correlationID)
+		return
+	}
+
+	status := SessionReplayStatus{
+		ReplayID:  uuid.New().String(),
+		SessionID: session.SessionID,
+		Status:    ReplayStarted,
+		Speed:     req.Speed,
+		MaxGap:    req.MaxGap,
+		FromSeq:   req.FromSeq,
+		Events:    session.EventCount,
+		StartedAt: time.Now().UTC(),
+	}
+
+	h.mu.Lock()
+	if h.replay != nil {
+		running := h.replay.status.SessionID
+		h.mu.Unlock()
+		WriteError(w, http.StatusConflict, "A replay of session "+running+" is running", correlationID)
+		return
+	}
+	replayCtx, cancel := context.WithCancel(h.runCtx)
+	h.replay = &activeReplay{status: status, cancel: cancel}
+	h.mu.Unlock()
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("session_id", session.SessionID).
+		Str("replay_id", status.ReplayID).
+		Float64("speed", req.Speed).
+		Str("max_gap", req.MaxGap).
+		Msg("Session replay started")
+
+	go h.runReplay(replayCtx, status, maxGap)
+
+	WriteJSON(w, http.StatusAccepted, status)
+}
+
+// StopReplay handles DELETE /api/v1/sessions/{sessionId}/replay
+func (h *SessionHandler) StopReplay(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+	sessionID := chi.URLParam(r, "sessionId")
+
+	h.mu.Lock()
+	replay := h.replay
+	h.mu.Unlock()
+	if replay == nil || replay.status.SessionID != sessionID {
+		WriteError(w, http.StatusNotFound, "Session is not replaying", correlationID)
+		return
+	}
+	replay.cancel()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session loads the session named in the URL, writing 404 if it does not exist
+func (h *SessionHandler) session(w http.ResponseWriter, r *http.Request) (*postgres.SessionRow, bool) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	sessionID := chi.URLParam(r, "sessionId")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		WriteError(w, http.StatusNotFound, "Session not found", correlationID)
+		return nil, false
+	}
+
+	session, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("session_id", sessionID).Msg("Failed to get session")
+		WriteError(w, http.StatusInternalServerError, "Failed to get session", correlationID)
+		return nil, false
+	}
+	if session == nil {
+		WriteError(w, http.StatusNotFound, "Session not found", correlationID)
+		return nil, false
+	}
+	return session, true
+}
+
+// replayOf returns the status of the session's running replay, or nil
+func (h *SessionHandler) replayOf(sessionID string) *SessionReplayStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.replay == nil || h.replay.status.SessionID != sessionID {
+		return nil
+	}
+	status := h.replay.status
+	return &status
+}
+
+// runReplay re-broadcasts a session's events until they run out or ctx is
+// done, then announces how the replay ended
+func (h *SessionHandler) runReplay(ctx context.Context, status SessionReplayStatus, maxGap time.Duration) {
+	h.hub.broadcastWait(ctx, newControlEvent(MessageTypeSessionReplay, status))
+
+	sent, err := h.replayEvents(ctx, status, maxGap)
+
+	status.Events = sent
+	switch {
+	case err == nil:
+		status.Status = ReplayCompleted
+	case ctx.Err() != nil:
+		status.Status = ReplayStopped
+	default:
+		status.Status = ReplayFailed
+		status.Error = err.Error()
+		h.logger.Error().Err(err).Str("session_id", status.SessionID).Str("replay_id", status.ReplayID).Msg("Session replay failed")
+	}
+
+	h.mu.Lock()
+	h.replay.cancel()
+	h.replay = nil
+	h.mu.Unlock()
+
+	// The end is announced even when the replay was stopped
+	endCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h.hub.broadcastWait(endCtx, newControlEvent(MessageTypeSessionReplay, status))
+
+	h.logger.Info().
+		Str("session_id", status.SessionID).
+		Str("replay_id", status.ReplayID).
+		Str("status", status.Status).
+		Int("events", sent).
+		Msg("Session replay ended")
+}
+
+// replayEvents broadcasts the session's events from status.FromSeq, spaced
+// as they were recorded divided by status.Speed with pauses capped at
+// maxGap, and returns how many it sent
+func (h *SessionHandler) replayEvents(ctx context.Context, status SessionReplayStatus, maxGap time.Duration) (int, error) {
+	afterSeq := status.FromSeq - 1
+	if afterSeq < 0 {
+		afterSeq = 0
+	}
+	start := time.Now()
+	var offset time.Duration // Time into the replay, in recorded time
+	var last time.Time
+	sent := 0
+
+	for {
+		page, err := h.store.ListSessionEvents(ctx, status.SessionID, afterSeq, SessionReplayPage)
+		if err != nil {
+			return sent, err
+		}
+		for _, row := range page {
+			afterSeq = row.Seq
+			var ev Event
+			if err := json.Unmarshal(row.Event, &ev); err != nil {
+				h.logger.Warn().Err(err).Str("session_id", status.SessionID).Int("seq", row.Seq).Msg("Skipping unreadable session event")
+				continue
+			}
+
+			if !last.IsZero() {
+				gap := row.RecordedAt.Sub(last)
+				if maxGap > 0 && gap > maxGap {
+					gap = maxGap
+				}
+				if gap > 0 {
+					offset += gap
+				}
+			}
+			last = row.RecordedAt
+			due := start.Add(time.Duration(float64(offset) / status.Speed))
+			if wait := time.Until(due); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return sent, ctx.Err()
+				case <-timer.C:
+				}
+			}
+
+			ev.Seq = 0
+			ev.received = row.RecordedAt
+			ev.Replay = &EventReplay{ReplayID: status.ReplayID, SessionID: status.SessionID, Seq: row.Seq}
+			if err := h.hub.broadcastWait(ctx, ev); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if len(page) < SessionReplayPage {
+			return sent, nil
+		}
+	}
+}
+
+// Record queues a broadcast event for the recording session, if there is one
+// and it records events of this type. It never blocks: when the queue is full
+// the event is dropped and counted. Replayed events are not recorded again.
+func (h *SessionHandler) Record(ev Event) {
+	if ev.Replay != nil {
+		return
+	}
+
+	h.mu.Lock()
+	session := h.recording
+	if session == nil || !SessionRecords(ev.EventType, session.IncludeTracks) {
+		h.mu.Unlock()
+		return
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		h.mu.Unlock()
+		h.dropped.Inc()
+		return
+	}
+	queued := sessionEvent{
+		sessionID: session.SessionID,
+		row: postgres.SessionEventRow{
+			Seq:        h.nextSeq,
+			EventType:  ev.EventType,
+			RecordedAt: time.Now().UTC(),
+			Event:      data,
+		},
+	}
+	select {
+	case h.queue <- queued:
+		h.nextSeq++
+	default:
+		h.dropped.Inc()
+		h.logger.Warn().Str("session_id", session.SessionID).Str("event_type", ev.EventType).Msg("Session recording queue full, dropping event")
+	}
+	h.mu.Unlock()
+}
+
+// Run resumes a session left recording by an earlier gateway run and writes
+// queued events until ctx is done. Replays stop with it.
+func (h *SessionHandler) Run(ctx context.Context) {
+	h.mu.Lock()
+	h.runCtx = ctx
+	h.mu.Unlock()
+
+	if session, err := h.store.RecordingSession(ctx); err != nil {
+		h.logger.Warn().Err(err).Msg("Failed to load recording session")
+	} else if session != nil {
+		h.mu.Lock()
+		if h.recording == nil {
+			h.recording = session
+			h.nextSeq = session.EventCount + 1
+		}
+		h.mu.Unlock()
+		h.logger.Info().Str("session_id", session.SessionID).Int("events", session.EventCount).Msg("Resumed session recording")
+	}
+
+	ticker := time.NewTicker(SessionFlushInterval)
+	defer ticker.Stop()
+	var pending []sessionEvent
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.drain(&pending)
+			flushCtx, cancel := context.WithTimeout(context.Background(), sessionShutdownFlush)
+			h.flush(flushCtx, pending)
+			cancel()
+			return
+		case ev := <-h.queue:
+			pending = append(pending, ev)
+			if len(pending) >= SessionFlushBatch {
+				h.flush(ctx, pending)
+				pending = nil
+			}
+		case <-ticker.C:
+			h.drain(&pending)
+			h.flush(ctx, pending)
+			pending = nil
+		}
+	}
+}
+
+// drain moves every queued event into pending without waiting
+func (h *SessionHandler) drain(pending *[]sessionEvent) {
+	for {
+		select {
+		case ev := <-h.queue:
+			*pending = append(*pending, ev)
+		default:
+			return
+		}
+	}
+}
+
+// flush writes queued events, grouped by session in the order recorded
+func (h *SessionHandler) flush(ctx context.Context, pending []sessionEvent) {
+	for start := 0; start < len(pending); {
+		end := start
+		var rows []postgres.SessionEventRow
+		for end < len(pending) && pending[end].sessionID == pending[start].sessionID {
+			rows = append(rows, pending[end].row)
+			end++
+		}
+		sessionID := pending[start].sessionID
+		if err := h.store.InsertSessionEvents(ctx, sessionID, rows); err != nil {
+			h.dropped.Add(float64(len(rows)))
+			h.logger.Error().Err(err).Str("session_id", sessionID).Int("events", len(rows)).Msg("Failed to record session events")
+		} else {
+			h.recorded.Add(float64(len(rows)))
+		}
+		start = end
+	}
+}
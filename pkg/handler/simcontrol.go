@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/simclock"
+)
+
+// SimControlHandler pauses, resumes, and changes the speed of the whole
+// pipeline by publishing simulation clock state on SIMCONTROL
+type SimControlHandler struct {
+	db     *postgres.Pool
+	js     jetstream.JetStream
+	clock  *simclock.Clock
+	logger zerolog.Logger
+
+	mu sync.Mutex // Serializes state changes so sequence numbers never collide
+}
+
+// NewSimControlHandler creates a new SimControlHandler. js may be nil when the
+// gateway has no NATS connection, in which case changes are refused.
+func NewSimControlHandler(db *postgres.Pool, js jetstream.JetStream, logger zerolog.Logger) *SimControlHandler {
+	return &SimControlHandler{
+		db:     db,
+		js:     js,
+		clock:  simclock.New(),
+		logger: logger.With().Str("handler", "simcontrol").Logger(),
+	}
+}
+
+// Run follows the SIMCONTROL stream so the handler starts from the state other
+// gateways or an earlier run published
+func (h *SimControlHandler) Run(ctx context.Context) error {
+	if h.js == nil {
+		return nil
+	}
+	if _, err := h.js.Stream(ctx, simclock.StreamName); err != nil {
+		if _, err := h.js.CreateStream(ctx, natsutil.StreamConfigs[simclock.StreamName]); err != nil {
+			return fmt.Errorf("failed to create %s stream: %w", simclock.StreamName, err)
+		}
+	}
+	return simclock.Watch(ctx, h.js, h.clock)
+}
+
+// Clock returns the gateway's view of the simulation clock
+func (h *SimControlHandler) Clock() *simclock.Clock {
+	return h.clock
+}
+
+// Routes returns the simulation control routes
+func (h *SimControlHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.GetState)
+	r.Post("/pause", h.Pause)
+	r.Post("/resume", h.Resume)
+	r.Post("/speed", h.SetSpeed)
+
+	return r
+}
+
+// SimControlRequest represents the request body for a simulation control change.
+// Every field is optional for pause and resume; speed is required for /speed.
+type SimControlRequest struct {
+	Speed     float64 `json:"speed,omitempty"`
+	UpdatedBy string  `json:"updated_by,omitempty"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// SimControlResponse represents the simulation clock in API responses
+type SimControlResponse struct {
+	State             simclock.State `json:"state"`
+	SimNow            time.Time      `json:"sim_now"`
+	ProposalsExtended int64          `json:"proposals_extended,omitempty"` // Pending proposals given back the paused time
+	CorrelationID     string         `json:"correlation_id"`
+}
+
+// GetState handles GET /api/v1/sim
+func (h *SimControlHandler) GetState(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusOK, SimControlResponse{
+		State:         h.clock.State(),
+		SimNow:        h.clock.Now(),
+		CorrelationID: GetCorrelationID(r.Context()),
+	})
+}
+
+// Pause handles POST /api/v1/sim/pause
+func (h *SimControlHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, func(s simclock.State, req SimControlRequest, now time.Time) (simclock.State, error) {
+		return s.Pause(now, req.UpdatedBy, req.Reason), nil
+	})
+}
+
+// Resume handles POST /api/v1/sim/resume
+func (h *SimControlHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, func(s simclock.State, req SimControlRequest, now time.Time) (simclock.State, error) {
+		return s.Resume(now, req.UpdatedBy, req.Reason), nil
+	})
+}
+
+// SetSpeed handles POST /api/v1/sim/speed
+func (h *SimControlHandler) SetSpeed(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, func(s simclock.State, req SimControlRequest, now time.Time) (simclock.State, error) {
+		return s.WithSpeed(req.Speed, now, req.UpdatedBy, req.Reason)
+	})
+}
+
+// change validates and publishes one state change
+func (h *SimControlHandler) change(w http.ResponseWriter, r *http.Request, apply func(simclock.State, SimControlRequest, time.Time) (simclock.State, error)) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req SimControlRequest
+	if err := DecodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.clock.State()
+	next, err := apply(prev, req, time.Now().UTC())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	if h.js == nil {
+		WriteError(w, http.StatusServiceUnavailable, "Simulation control requires NATS", correlationID)
+		return
+	}
+	if err := simclock.Publish(ctx, h.js, next); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to publish simulation state")
+		WriteError(w, http.StatusInternalServerError, "Failed to publish simulation state", correlationID)
+		return
+	}
+	h.clock.Apply(next)
+
+	resp := SimControlResponse{
+		State:         next,
+		SimNow:        h.clock.Now(),
+		CorrelationID: correlationID,
+	}
+
+	// Give pending proposals back the time they spent frozen
+	if paused, ok := simclock.PauseEnded(prev, next); ok && h.db != nil {
+		n, err := h.db.ExtendPendingProposals(ctx, prev.PausedAt, paused)
+		if err != nil {
+			h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to extend pending proposals after pause")
+		}
+		resp.ProposalsExtended = n
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Bool("paused", next.Paused).
+		Float64("speed", next.Speed).
+		Time("sim_time", next.SimTime).
+		Str("updated_by", next.UpdatedBy).
+		Str("reason", next.Reason).
+		Msg("Simulation clock changed")
+
+	WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// Snapshot limits and timing
+const (
+	SnapshotTrackLimit    = 1000
+	SnapshotProposalLimit = 500
+	snapshotTimeout       = 5 * time.Second
+)
+
+// MessageTypePictureSnapshot carries the full common operating picture. It is
+// sent to event clients when they connect and whenever they ask to resync.
+const MessageTypePictureSnapshot = "picture.snapshot"
+
+// MessageTypeResync is sent by a client that detected a gap in event
+// sequence numbers and wants a fresh snapshot
+const MessageTypeResync = "resync"
+
+// Snapshot reasons
+const (
+	SnapshotReasonConnect = "connect"
+	SnapshotReasonResync  = "resync"
+)
+
+// SnapshotStore loads the common operating picture snapshots are built from
+type SnapshotStore interface {
+	ListTracks(ctx context.Context, filter postgres.TrackFilter) ([]postgres.TrackRow, error)
+	ListProposals(ctx context.Context, filter postgres.ProposalFilter) ([]postgres.ProposalRow, error)
+}
+
+// PictureSnapshot is the payload of a picture.snapshot event. Seq is the last
+// event sequence number the snapshot covers: clients replace their state with
+// the snapshot, discard later events numbered at or below Seq, and apply the
+// rest as deltas. A jump in sequence numbers after that means events were
+// dropped and the client should send a resync.
+type PictureSnapshot struct {
+	Seq       uint64             `json:"seq"`
+	Reason    string             `json:"reason"`
+	Tracks    []TrackResponse    `json:"tracks"`
+	Proposals []ProposalResponse `json:"proposals"`
+}
+
+// buildSnapshot loads active tracks and pending proposals. seq must be read
+// before the query so that every event after it is delivered to the client.
+func buildSnapshot(ctx context.Context, store SnapshotStore, seq uint64, reason string) (PictureSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	snapshot := PictureSnapshot{
+		Seq:       seq,
+		Reason:    reason,
+		Tracks:    []TrackResponse{},
+		Proposals: []ProposalResponse{},
+	}
+
+	tracks, err := store.ListTracks(ctx, postgres.TrackFilter{Limit: SnapshotTrackLimit})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to list tracks: %w", err)
+	}
+	for i := range tracks {
+		snapshot.Tracks = append(snapshot.Tracks, newTrackResponse(&tracks[i]))
+	}
+
+	proposals, err := store.ListProposals(ctx, postgres.ProposalFilter{Status: "pending", Limit: SnapshotProposalLimit})
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to list proposals: %w", err)
+	}
+	now := time.Now()
+	for i := range proposals {
+		snapshot.Proposals = append(snapshot.Proposals, newProposalResponse(&proposals[i], now))
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// Server-Sent Events settings
+const (
+	SSEHeartbeatInterval = 15 * time.Second
+	SSEReplayBufferSize  = 1000 // Broadcast messages kept for Last-Event-ID reconnects
+	SSERetryMillis       = 3000 // Reconnect delay suggested to EventSource clients
+	sseWriteTimeout      = 10 * time.Second
+	sseClientBufferSize  = 64
+)
+
+// MessageTypeStreamReset tells an SSE client that events were missed since its
+// Last-Event-ID and it should refetch current state
+const MessageTypeStreamReset = "stream.reset"
+
+// StreamTopics lists the message types an SSE client can filter on with
+// ?topics. A topic also matches every type it prefixes, so "track" selects
+// every track event, from track.update to track.split.
+var StreamTopics = []string{
+	MessageTypeTrackUpdate,
+	MessageTypeTrackNew,
+	MessageTypeTrackStale,
+	MessageTypeTrackDelete,
+	MessageTypeTrackMerged,
+	MessageTypeTrackSplit,
+	MessageTypeProposalNew,
+	MessageTypeProposalEscalated,
+	MessageTypeProposalSLA,
+	MessageTypeProposalRescored,
+	MessageTypeBreakGlass,
+	MessageTypeDecisionMade,
+	MessageTypeDecisionRevoked,
+	MessageTypeEffectExecuted,
+	MessageTypeEffectProgress,
+	MessageTypeEffectAssessment,
+	MessageTypeEngagementCompleted,
+	MessageTypeSimControl,
+	MessageTypeAdmissionShed,
+	MessageTypeMetricsUpdate,
+}
+
+// streamEvent is a broadcast event numbered for Last-Event-ID
+type streamEvent struct {
+	seq uint64
+	ev  Event
+}
+
+// streamClient is one connected SSE client
+type streamClient struct {
+	id     string
+	topics []string
+	send   chan streamEvent
+}
+
+// ParseStreamTopics parses a comma-separated topic filter; empty selects every message
+func ParseStreamTopics(param string) ([]string, error) {
+	var topics []string
+	for _, topic := range strings.Split(param, ",") {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		known := false
+		for _, t := range StreamTopics {
+			if matchesTopic(topic, t) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown topic %q", topic)
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// matchesTopic reports whether msgType is topic or belongs to it
+func matchesTopic(topic, msgType string) bool {
+	return msgType == topic || strings.HasPrefix(msgType, topic+".")
+}
+
+// wants reports whether the client subscribed to msgType
+func (c *streamClient) wants(msgType string) bool {
+	if len(c.topics) == 0 {
+		return true
+	}
+	for _, topic := range c.topics {
+		if matchesTopic(topic, msgType) {
+			return true
+		}
+	}
+	return false
+}
+
+// eventID formats a sequence number as an SSE event ID
+func (h *WebSocketHub) eventID(seq uint64) string {
+	return h.streamEpoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventID returns the sequence number of an event ID from this gateway run
+func (h *WebSocketHub) parseEventID(id string) (uint64, bool) {
+	i := strings.LastIndex(id, "-")
+	if i < 0 || id[:i] != h.streamEpoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// publishStream numbers a broadcast event, keeps it for replay, sends it to
+// SSE clients, and returns it numbered for WebSocket clients. A client too slow to keep up is disconnected; it reconnects
+// with Last-Event-ID and is replayed what it missed.
+func (h *WebSocketHub) publishStream(event Event) Event {
+	h.streamMu.Lock()
+	defer h.streamMu.Unlock()
+
+	h.streamSeq++
+	event.Seq = h.streamSeq
+	ev := streamEvent{seq: h.streamSeq, ev: event}
+	h.streamHistory = append(h.streamHistory, ev)
+	if len(h.streamHistory) > SSEReplayBufferSize {
+		h.streamHistory = h.streamHistory[len(h.streamHistory)-SSEReplayBufferSize:]
+	}
+
+	for id, client := range h.streamClients {
+		if !client.wants(event.EventType) {
+			continue
+		}
+		select {
+		case client.send <- ev:
+		default:
+			h.logger.Warn().Str("client_id", id).Msg("SSE client send buffer full, disconnecting")
+			delete(h.streamClients, id)
+			close(client.send)
+		}
+	}
+	return event
+}
+
+// LastSeq returns the sequence number of the latest broadcast
+func (h *WebSocketHub) LastSeq() uint64 {
+	h.streamMu.Lock()
+	defer h.streamMu.Unlock()
+	return h.streamSeq
+}
+
+// subscribeStream registers an SSE client. When lastEventID is set the
+// messages broadcast after it are returned for replay. If some of them are no
+// longer buffered, or the ID is from an earlier gateway run, nothing is
+// replayed and reset is reported instead.
+func (h *WebSocketHub) subscribeStream(topics []string, lastEventID string) (client *streamClient, replay []streamEvent, reset bool) {
+	client = &streamClient{
+		id:     uuid.New().String(),
+		topics: topics,
+		send:   make(chan streamEvent, sseClientBufferSize),
+	}
+
+	h.streamMu.Lock()
+	defer h.streamMu.Unlock()
+
+	if lastEventID != "" {
+		seq, ok := h.parseEventID(lastEventID)
+		oldest := h.streamSeq + 1
+		if len(h.streamHistory) > 0 {
+			oldest = h.streamHistory[0].seq
+		}
+		reset = !ok || seq > h.streamSeq || seq+1 < oldest
+		for _, ev := range h.streamHistory {
+			if !reset && ev.seq > seq && client.wants(ev.ev.EventType) {
+				replay = append(replay, ev)
+			}
+		}
+	}
+
+	h.streamClients[client.id] = client
+	return client, replay, reset
+}
+
+// unsubscribeStream removes an SSE client
+func (h *WebSocketHub) unsubscribeStream(client *streamClient) {
+	h.streamMu.Lock()
+	defer h.streamMu.Unlock()
+	if _, ok := h.streamClients[client.id]; ok {
+		delete(h.streamClients, client.id)
+		close(client.send)
+	}
+}
+
+// StreamClientCount returns the number of connected SSE clients
+func (h *WebSocketHub) StreamClientCount() int {
+	h.streamMu.Lock()
+	defer h.streamMu.Unlock()
+	return len(h.streamClients)
+}
+
+// SSEHandler streams hub broadcasts as Server-Sent Events for clients that
+// cannot use WebSockets. Each event's data is the same JSON the WebSocket
+// sends in the client's schema version.
+type SSEHandler struct {
+	hub    *WebSocketHub
+	logger zerolog.Logger
+}
+
+// NewSSEHandler creates a new SSEHandler
+func NewSSEHandler(hub *WebSocketHub, logger zerolog.Logger) *SSEHandler {
+	return &SSEHandler{
+		hub:    hub,
+		logger: logger.With().Str("handler", "sse").Logger(),
+	}
+}
+
+// ServeHTTP handles GET /api/v1/stream?topics=...&schema_version=...
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	correlationID := GetCorrelationID(r.Context())
+
+	topics, err := ParseStreamTopics(r.URL.Query().Get("topics"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+	schemaVersion, err := ParseSchemaVersion(r.URL.Query().Get("schema_version"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	// EventSource sends Last-Event-ID when it reconnects; the query parameter
+	// serves clients that reconnect by hand
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	rc := http.NewResponseController(w)
+	client, replay, reset := h.hub.subscribeStream(topics, lastEventID)
+	defer h.hub.unsubscribeStream(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	h.logger.Info().
+		Str("client_id", client.id).
+		Strs("topics", topics).
+		Int("schema_version", schemaVersion).
+		Str("last_event_id", lastEventID).
+		Int("replayed", len(replay)).
+		Bool("reset", reset).
+		Msg("SSE client connected")
+	defer h.logger.Info().Str("client_id", client.id).Msg("SSE client disconnected")
+
+	if err := h.write(w, rc, fmt.Sprintf("retry: %d\n\n", SSERetryMillis)); err != nil {
+		return
+	}
+	if schemaVersion != SchemaVersionLegacy {
+		if err := h.writeEvent(w, rc, "", schemaVersion, newConnectionStatus(client.id, schemaVersion)); err != nil {
+			return
+		}
+	}
+	if reset {
+		if err := h.writeEvent(w, rc, "", schemaVersion, newControlEvent(MessageTypeStreamReset, nil)); err != nil {
+			return
+		}
+	}
+	for _, ev := range replay {
+		if err := h.writeEvent(w, rc, h.hub.eventID(ev.seq), schemaVersion, ev.ev); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(SSEHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case ev, ok := <-client.send:
+			if !ok {
+				return // Too slow or the hub shut down; the client reconnects
+			}
+			if err := h.writeEvent(w, rc, h.hub.eventID(ev.seq), schemaVersion, ev.ev); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			// Heartbeats carry no ID so they do not move the client's Last-Event-ID
+			if err := h.writeEvent(w, rc, "", schemaVersion, newControlEvent(MessageTypePing, nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes one event as an SSE event in the client's schema version
+func (h *SSEHandler) writeEvent(w http.ResponseWriter, rc *http.ResponseController, id string, schemaVersion int, ev Event) error {
+	data, err := json.Marshal(ev.Encode(schemaVersion))
+	if err != nil {
+		h.logger.Error().Err(err).Str("message_type", ev.EventType).Msg("Failed to marshal SSE message")
+		return nil
+	}
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("data: ")
+	b.Write(data)
+	b.WriteString("\n\n")
+	return h.write(w, rc, b.String())
+}
+
+// write sends raw event stream text and flushes it to the client. The write
+// deadline is extended first, since the server's WriteTimeout would otherwise
+// end the stream.
+func (h *SSEHandler) write(w http.ResponseWriter, rc *http.ResponseController, text string) error {
+	if err := rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := w.Write([]byte(text)); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// DefaultSummaryTTL is how long a dashboard summary is served from memory
+// before the counts are queried again
+const DefaultSummaryTTL = 2 * time.Second
+
+// Stage health derived from agent heartbeats
+const (
+	StageHealthy  = "healthy"  // Every live agent reports healthy
+	StageDegraded = "degraded" // Some agents are down or unhealthy
+	StageDown     = "down"     // No agent is alive
+)
+
+// SummaryStore is the storage the summary handler needs
+type SummaryStore interface {
+	GetDashboardSummary(ctx context.Context) (*postgres.DashboardSummary, error)
+}
+
+// SummaryHandler serves the counts dashboards poll for in one response. The
+// counts are cached briefly so many dashboards cost one query batch.
+type SummaryHandler struct {
+	store    SummaryStore
+	registry *topology.Registry
+	ttl      time.Duration
+	logger   zerolog.Logger
+
+	mu        sync.Mutex
+	cached    *SummaryResponse
+	expiresAt time.Time
+}
+
+// NewSummaryHandler creates a new SummaryHandler
+func NewSummaryHandler(store SummaryStore, logger zerolog.Logger) *SummaryHandler {
+	return &SummaryHandler{
+		store:  store,
+		ttl:    DefaultSummaryTTL,
+		logger: logger.With().Str("handler", "summary").Logger(),
+	}
+}
+
+// SetRegistry enables stage health, reported from the agents' heartbeats
+func (h *SummaryHandler) SetRegistry(registry *topology.Registry) {
+	h.registry = registry
+}
+
+// SetTTL changes how long a summary is cached, discarding the cached one;
+// zero disables caching
+func (h *SummaryHandler) SetTTL(ttl time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ttl = ttl
+	h.cached = nil
+}
+
+// Routes returns the summary routes
+func (h *SummaryHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.GetSummary)
+	return r
+}
+
+// TrackSummary counts active tracks
+type TrackSummary struct {
+	Total            int64                 `json:"total"`
+	ByClassification map[string]int64      `json:"by_classification"`
+	ByThreatLevel    map[string]int64      `json:"by_threat_level"`
+	Breakdown        []postgres.TrackCount `json:"breakdown"` // By classification and threat level
+}
+
+// ProposalSummary counts pending proposals
+type ProposalSummary struct {
+	Total      int64            `json:"total"`
+	ByPriority map[string]int64 `json:"by_priority"`
+}
+
+// StageHealth summarizes the agents of one pipeline stage
+type StageHealth struct {
+	Stage    string    `json:"stage"`
+	Status   string    `json:"status"`
+	Agents   int       `json:"agents"`
+	Alive    int       `json:"alive"`
+	Healthy  int       `json:"healthy"`
+	MaxLag   *int      `json:"max_lag,omitempty"` // Largest consumer lag reported by a live agent
+	LastSeen time.Time `json:"last_seen"`         // Latest heartbeat from any of the stage's agents
+}
+
+// SummaryResponse is the dashboard summary
+type SummaryResponse struct {
+	ActiveTracks     TrackSummary                `json:"active_tracks"`
+	PendingProposals ProposalSummary             `json:"pending_proposals"`
+	Decisions        postgres.DecisionThroughput `json:"decisions"`
+	MessagesLastMin  postgres.MessageRates       `json:"messages_last_minute"`
+	Stages           []StageHealth               `json:"stages"`
+	GeneratedAt      time.Time                   `json:"generated_at"`
+	CorrelationID    string                      `json:"correlation_id"`
+}
+
+// GetSummary handles GET /api/v1/summary
+func (h *SummaryHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	summary, err := h.summary(ctx)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to get dashboard summary")
+		WriteError(w, http.StatusInternalServerError, "Failed to get dashboard summary", correlationID)
+		return
+	}
+
+	resp := *summary
+	resp.CorrelationID = correlationID
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// summary returns the cached summary, building a new one once it expires.
+// Requests arriving while it is built wait for it rather than querying too.
+func (h *SummaryHandler) summary(ctx context.Context) (*SummaryResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now().UTC()
+	if h.cached != nil && now.Before(h.expiresAt) {
+		return h.cached, nil
+	}
+
+	counts, err := h.store.GetDashboardSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.cached = BuildSummary(counts, h.agents(now), now)
+	h.expiresAt = now.Add(h.ttl)
+	return h.cached, nil
+}
+
+func (h *SummaryHandler) agents(now time.Time) []topology.AgentState {
+	if h.registry == nil {
+		return nil
+	}
+	return h.registry.Agents(now)
+}
+
+// BuildSummary totals the dashboard counts and groups agents by stage
+func BuildSummary(counts *postgres.DashboardSummary, agents []topology.AgentState, now time.Time) *SummaryResponse {
+	s := &SummaryResponse{
+		ActiveTracks: TrackSummary{
+			ByClassification: make(map[string]int64),
+			ByThreatLevel:    make(map[string]int64),
+			Breakdown:        counts.ActiveTracks,
+		},
+		PendingProposals: ProposalSummary{ByPriority: make(map[string]int64)},
+		Decisions:        counts.Decisions,
+		MessagesLastMin:  counts.MessagesLastMin,
+		Stages:           StageHealthFor(agents),
+		GeneratedAt:      now,
+	}
+	for _, c := range counts.ActiveTracks {
+		s.ActiveTracks.Total += c.Count
+		s.ActiveTracks.ByClassification[c.Classification] += c.Count
+		s.ActiveTracks.ByThreatLevel[c.ThreatLevel] += c.Count
+	}
+	for _, c := range counts.PendingProposals {
+		s.PendingProposals.Total += c.Count
+		s.PendingProposals.ByPriority[strconv.Itoa(c.Priority)] += c.Count
+	}
+	return s
+}
+
+// StageHealthFor groups agents, ordered by type, into one entry per stage
+func StageHealthFor(agents []topology.AgentState) []StageHealth {
+	stages := []StageHealth{}
+	for _, a := range agents {
+		if len(stages) == 0 || stages[len(stages)-1].Stage != a.AgentType {
+			stages = append(stages, StageHealth{Stage: a.AgentType})
+		}
+		st := &stages[len(stages)-1]
+		st.Agents++
+		if a.SentAt.After(st.LastSeen) {
+			st.LastSeen = a.SentAt
+		}
+		if !a.Alive {
+			continue
+		}
+		st.Alive++
+		if a.Healthy {
+			st.Healthy++
+		}
+		if a.ConsumerLag != nil && (st.MaxLag == nil || *a.ConsumerLag > *st.MaxLag) {
+			lag := *a.ConsumerLag
+			st.MaxLag = &lag
+		}
+	}
+
+	for i := range stages {
+		st := &stages[i]
+		switch {
+		case st.Alive == 0:
+			st.Status = StageDown
+		case st.Healthy < st.Agents:
+			st.Status = StageDegraded
+		default:
+			st.Status = StageHealthy
+		}
+	}
+	return stages
+}
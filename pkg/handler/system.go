@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// SystemHandler serves the pipeline topology assembled from agent heartbeats
+type SystemHandler struct {
+	js       jetstream.JetStream
+	registry *topology.Registry
+	logger   zerolog.Logger
+}
+
+// NewSystemHandler creates a new SystemHandler. js may be nil when the gateway
+// has no NATS connection, in which case no agents are reported.
+func NewSystemHandler(js jetstream.JetStream, logger zerolog.Logger) *SystemHandler {
+	return &SystemHandler{
+		js:       js,
+		registry: topology.NewRegistry(),
+		logger:   logger.With().Str("handler", "system").Logger(),
+	}
+}
+
+// Run follows the HEARTBEATS stream, starting from each agent's latest heartbeat
+func (h *SystemHandler) Run(ctx context.Context) error {
+	if h.js == nil {
+		return nil
+	}
+	if _, err := h.js.Stream(ctx, topology.StreamName); err != nil {
+		if _, err := h.js.CreateStream(ctx, natsutil.StreamConfigs[topology.StreamName]); err != nil {
+			return fmt.Errorf("failed to create %s stream: %w", topology.StreamName, err)
+		}
+	}
+	return topology.Watch(ctx, h.js, h.registry.Observe)
+}
+
+// Registry returns the agents known from heartbeats
+func (h *SystemHandler) Registry() *topology.Registry {
+	return h.registry
+}
+
+// Routes returns the system routes
+func (h *SystemHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/agents", h.ListAgents)
+	return r
+}
+
+// SystemAgentsResponse lists every agent that has sent a heartbeat
+type SystemAgentsResponse struct {
+	Agents        []topology.AgentState `json:"agents"`
+	Total         int                   `json:"total"`
+	Alive         int                   `json:"alive"`
+	Healthy       int                   `json:"healthy"` // Alive and reporting healthy
+	CorrelationID string                `json:"correlation_id"`
+}
+
+// ListAgents handles GET /api/v1/system/agents
+func (h *SystemHandler) ListAgents(w http.ResponseWriter, r *http.Request) {
+	agents := h.registry.Agents(time.Now().UTC())
+
+	resp := SystemAgentsResponse{
+		Agents:        agents,
+		Total:         len(agents),
+		CorrelationID: GetCorrelationID(r.Context()),
+	}
+	for _, a := range agents {
+		if a.Alive {
+			resp.Alive++
+			if a.Healthy {
+				resp.Healthy++
+			}
+		}
+	}
+
+	WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/simclock"
+	"github.com/agile-defense/cjadc2/pkg/tracklifecycle"
+)
+
+// TrackLifecycleManager moves tracks that stop receiving detections to stale
+// and then dropped, and announces each transition
+type TrackLifecycleManager struct {
+	db     *postgres.Pool
+	pub    *Publisher
+	cfg    tracklifecycle.Config
+	clock  *simclock.Clock
+	logger zerolog.Logger
+
+	mu        sync.Mutex
+	resumedAt time.Time // Wall time the simulation last resumed from a pause
+}
+
+// NewTrackLifecycleManager creates a new TrackLifecycleManager. Sweeps are
+// skipped while clock is paused. pub may be nil, in which case transitions are
+// recorded but not announced.
+func NewTrackLifecycleManager(db *postgres.Pool, pub *Publisher, cfg tracklifecycle.Config, clock *simclock.Clock, logger zerolog.Logger) *TrackLifecycleManager {
+	m := &TrackLifecycleManager{
+		db:     db,
+		pub:    pub,
+		cfg:    cfg,
+		clock:  clock,
+		logger: logger.With().Str("component", "track_lifecycle").Logger(),
+	}
+	clock.OnChange(func(prev, next simclock.State) {
+		if _, ok := simclock.PauseEnded(prev, next); ok {
+			m.mu.Lock()
+			m.resumedAt = next.WallTime
+			m.mu.Unlock()
+		}
+	})
+	return m
+}
+
+// Run sweeps the tracks table every interval until ctx is done
+func (m *TrackLifecycleManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if m.clock.Paused() {
+				continue
+			}
+			m.Sweep(ctx, time.Now().UTC())
+		}
+	}
+}
+
+// Sweep applies the transitions due at now. Stale tracks are dropped before
+// active tracks go stale, so every track spends at least one sweep stale.
+func (m *TrackLifecycleManager) Sweep(ctx context.Context, now time.Time) {
+	m.mu.Lock()
+	resumedAt := m.resumedAt
+	m.mu.Unlock()
+
+	staleCutoff, dropCutoff := m.cfg.Cutoffs(now, resumedAt)
+	if !dropCutoff.IsZero() {
+		m.transition(ctx, tracklifecycle.StateStale, tracklifecycle.StateDropped, dropCutoff, now)
+	}
+	if !staleCutoff.IsZero() {
+		m.transition(ctx, tracklifecycle.StateActive, tracklifecycle.StateStale, staleCutoff, now)
+	}
+}
+
+// transition moves idle tracks from one state to another and announces them
+func (m *TrackLifecycleManager) transition(ctx context.Context, from, to string, cutoff, now time.Time) {
+	moved, err := m.db.TransitionIdleTracks(ctx, from, to, cutoff, now)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error().Err(err).Str("from", from).Str("to", to).Msg("Failed to transition idle tracks")
+		}
+		return
+	}
+
+	for _, t := range moved {
+		m.logger.Info().
+			Str("track_id", t.TrackID).
+			Str("previous_state", t.PreviousState).
+			Str("state", t.State).
+			Time("last_updated", t.LastUpdated).
+			Msg("Track lifecycle changed")
+		m.publish(t)
+	}
+}
+
+// publish announces a transition on track.lifecycle.<state>
+func (m *TrackLifecycleManager) publish(t postgres.TrackTransition) {
+	if m.pub == nil {
+		return
+	}
+	event := &messages.TrackLifecycle{
+		Envelope:       messages.NewEnvelope("api-gateway", "api-gateway"),
+		TrackID:        t.TrackID,
+		Classification: t.Classification,
+		Type:           t.Type,
+		ThreatLevel:    t.ThreatLevel,
+		PreviousState:  t.PreviousState,
+		State:          t.State,
+		LastUpdated:    t.LastUpdated,
+		ChangedAt:      t.ChangedAt,
+	}
+	if err := m.pub.Publish(event); err != nil {
+		m.logger.Error().Err(err).Str("track_id", t.TrackID).Str("subject", event.Subject()).Msg("Failed to publish track lifecycle event")
+	}
+}
@@ -2,20 +2,28 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/opa"
 	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/tracklifecycle"
 )
 
 // TrackHandler handles track-related HTTP requests
 type TrackHandler struct {
 	db     *postgres.Pool
+	pub    *Publisher // Publishes manual contacts; see SetManualEntry
+	opa    *opa.Client
+	cache  *ReadCache // Nil queries the database on every list request
 	logger zerolog.Logger
 }
 
@@ -27,11 +35,17 @@ func NewTrackHandler(db *postgres.Pool, logger zerolog.Logger) *TrackHandler {
 	}
 }
 
+// SetCache serves repeated track lists without a time window from cache
+func (h *TrackHandler) SetCache(cache *ReadCache) {
+	h.cache = cache
+}
+
 // Routes returns the track routes
 func (h *TrackHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 
 	r.Get("/", h.ListTracks)
+	r.Post("/manual", h.CreateManualTrack)
 	r.Get("/{trackId}", h.GetTrack)
 	r.Get("/{trackId}/history", h.GetTrackHistory)
 
@@ -41,25 +55,32 @@ func (h *TrackHandler) Routes() chi.Router {
 // TrackListResponse represents the response for listing tracks
 type TrackListResponse struct {
 	Tracks        []TrackResponse `json:"tracks"`
-	Total         int             `json:"total"`
+	Total         int64           `json:"total"` // Matching tracks across all pages
 	Limit         int             `json:"limit"`
 	Offset        int             `json:"offset"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
 	CorrelationID string          `json:"correlation_id"`
 }
 
 // TrackResponse represents a single track in API responses
 type TrackResponse struct {
-	TrackID        string          `json:"track_id"`
+	TrackID        string          `json:"track_id"`         // System track ID
+	SensorTrackIDs []string        `json:"sensor_track_ids"` // Sensor track IDs fused into the track
 	Classification string          `json:"classification"`
 	Type           string          `json:"type"`
 	ThreatLevel    string          `json:"threat_level"`
+	ThreatScore    float64         `json:"threat_score"`
 	Position       json.RawMessage `json:"position"`
 	Velocity       json.RawMessage `json:"velocity"`
 	Confidence     float64         `json:"confidence"`
 	Sources        []string        `json:"sources"`
 	DetectionCount int             `json:"detection_count"`
+	State          string          `json:"state"`
+	StateChangedAt *time.Time      `json:"state_changed_at,omitempty"`
 	FirstSeen      time.Time       `json:"first_seen"`
 	LastUpdated    time.Time       `json:"last_updated"`
+	SecurityLabel  string          `json:"security_label"`
+	Releasability  []string        `json:"releasability"` // Coalition partners the track is releasable to
 }
 
 // ListTracks handles GET /api/v1/tracks
@@ -67,72 +88,127 @@ func (h *TrackHandler) ListTracks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	correlationID := GetCorrelationID(ctx)
 
+	format, err := ParseFormat(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
 	filter := postgres.TrackFilter{
 		Classification: r.URL.Query().Get("classification"),
 		ThreatLevel:    r.URL.Query().Get("threat_level"),
 		Type:           r.URL.Query().Get("type"),
+		Access:         GetAccess(ctx),
 	}
 
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filter.Limit = limit
-		}
+	page, err := ParsePage(r, postgres.TrackSort)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
 	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
+	filter.Limit, filter.Offset = page.Limit, page.Offset
+	filter.Sort, filter.After = page.Sort, page.After
+
+	// Lifecycle states, comma-separated; "all" includes every state
+	stateParam := r.URL.Query().Get("state")
+	if stateParam != "" {
+		for _, state := range strings.Split(stateParam, ",") {
+			state = strings.TrimSpace(state)
+			if state == "all" {
+				filter.States = []string{"all"}
+				break
+			}
+			if !tracklifecycle.IsValidState(state) {
+				WriteError(w, http.StatusBadRequest, "Invalid state: "+state, correlationID)
+				return
+			}
+			filter.States = append(filter.States, state)
+		}
 	}
 
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
-			filter.Offset = offset
+	// Bounding box in GeoJSON order: minLon,minLat,maxLon,maxLat
+	if bboxStr := r.URL.Query().Get("bbox"); bboxStr != "" {
+		box, err := geo.ParseBBox(bboxStr)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid bbox: "+err.Error(), correlationID)
+			return
 		}
+		filter.BBox = &box
 	}
 
 	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
 		if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
 			filter.Since = &since
 		}
-	} else {
+	} else if stateParam == "" {
 		// Default: only return tracks updated within the last 60 seconds
 		// This ensures stale tracks are automatically filtered out
 		defaultSince := time.Now().Add(-60 * time.Second)
 		filter.Since = &defaultSince
 	}
 
-	tracks, err := h.db.ListTracks(ctx, filter)
+	// An explicit since changes with every poll, so only the default window
+	// and state filters are worth caching
+	load := func() (interface{}, error) { return h.loadTracks(ctx, filter) }
+	var loaded interface{}
+	if r.URL.Query().Get("since") == "" {
+		loaded, err = h.cache.Load(CacheTableTracks, ReadCacheKey(r), load)
+	} else {
+		loaded, err = load()
+	}
 	if err != nil {
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list tracks")
 		WriteError(w, http.StatusInternalServerError, "Failed to list tracks", correlationID)
 		return
 	}
+	result := loaded.(trackPage)
+	tracks, total := result.tracks, result.total
+	next := page.NextCursor(len(tracks), func() string { return tracks[len(tracks)-1].Cursor })
+	WritePageHeaders(w, r, total, next)
 
 	response := TrackListResponse{
 		Tracks:        make([]TrackResponse, 0, len(tracks)),
-		Total:         len(tracks),
+		Total:         total,
 		Limit:         filter.Limit,
 		Offset:        filter.Offset,
+		NextCursor:    next,
 		CorrelationID: correlationID,
 	}
 
 	for _, t := range tracks {
-		response.Tracks = append(response.Tracks, TrackResponse{
-			TrackID:        t.ExternalID,
-			Classification: t.Classification,
-			Type:           t.Type,
-			ThreatLevel:    t.ThreatLevel,
-			Position:       t.Position,
-			Velocity:       t.Velocity,
-			Confidence:     t.Confidence,
-			Sources:        t.Sources,
-			DetectionCount: t.DetectionCount,
-			FirstSeen:      t.FirstSeen,
-			LastUpdated:    t.LastUpdated,
-		})
+		response.Tracks = append(response.Tracks, newTrackResponse(&t))
+	}
+
+	if format == FormatGeoJSON {
+		fc := NewFeatureCollection(correlationID)
+		for _, t := range response.Tracks {
+			fc.Features = append(fc.Features, TrackFeature(t))
+		}
+		WriteGeoJSON(w, http.StatusOK, fc)
+		return
 	}
 
 	WriteJSON(w, http.StatusOK, response)
 }
 
+// trackPage is one page of a track list and the number of tracks matching it
+type trackPage struct {
+	tracks []postgres.TrackRow
+	total  int64
+}
+
+func (h *TrackHandler) loadTracks(ctx context.Context, filter postgres.TrackFilter) (trackPage, error) {
+	tracks, err := h.db.ListTracks(ctx, filter)
+	if err != nil {
+		return trackPage{}, err
+	}
+	total, err := h.db.CountFilteredTracks(ctx, filter)
+	if err != nil {
+		return trackPage{}, err
+	}
+	return trackPage{tracks: tracks, total: total}, nil
+}
+
 // TrackDetailResponse represents the detailed response for a single track
 type TrackDetailResponse struct {
 	Track         TrackResponse `json:"track"`
@@ -150,6 +226,12 @@ func (h *TrackHandler) GetTrack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, err := ParseFormat(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
 	track, err := h.db.GetTrack(ctx, trackID)
 	if err != nil {
 		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("track_id", trackID).Msg("Failed to get track")
@@ -157,28 +239,25 @@ func (h *TrackHandler) GetTrack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if track == nil {
+	// Tracks the caller may not see are reported missing, not forbidden, so
+	// their existence is not disclosed
+	if track == nil || !GetAccess(ctx).Permits(track.SecurityLabel, track.Releasability) {
 		WriteError(w, http.StatusNotFound, "Track not found", correlationID)
 		return
 	}
 
 	response := TrackDetailResponse{
-		Track: TrackResponse{
-			TrackID:        track.ExternalID,
-			Classification: track.Classification,
-			Type:           track.Type,
-			ThreatLevel:    track.ThreatLevel,
-			Position:       track.Position,
-			Velocity:       track.Velocity,
-			Confidence:     track.Confidence,
-			Sources:        track.Sources,
-			DetectionCount: track.DetectionCount,
-			FirstSeen:      track.FirstSeen,
-			LastUpdated:    track.LastUpdated,
-		},
+		Track:         newTrackResponse(track),
 		CorrelationID: correlationID,
 	}
 
+	if format == FormatGeoJSON {
+		fc := NewFeatureCollection(correlationID)
+		fc.Features = append(fc.Features, TrackFeature(response.Track))
+		WriteGeoJSON(w, http.StatusOK, fc)
+		return
+	}
+
 	WriteJSON(w, http.StatusOK, response)
 }
 
@@ -211,6 +290,12 @@ func (h *TrackHandler) GetTrackHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	format, err := ParseFormat(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
 	limit := 100
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
@@ -226,7 +311,7 @@ func (h *TrackHandler) GetTrackHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if track == nil {
+	if track == nil || !GetAccess(ctx).Permits(track.SecurityLabel, track.Releasability) {
 		WriteError(w, http.StatusNotFound, "Track not found", correlationID)
 		return
 	}
@@ -256,5 +341,38 @@ func (h *TrackHandler) GetTrackHistory(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	if format == FormatGeoJSON {
+		// History is newest first; number it oldest first for playback
+		fc := NewFeatureCollection(correlationID)
+		for i, d := range response.Detections {
+			fc.Features = append(fc.Features, DetectionFeature(trackID, len(response.Detections)-1-i, d))
+		}
+		WriteGeoJSON(w, http.StatusOK, fc)
+		return
+	}
+
 	WriteJSON(w, http.StatusOK, response)
 }
+
+// newTrackResponse converts a track row to its API representation
+func newTrackResponse(t *postgres.TrackRow) TrackResponse {
+	return TrackResponse{
+		TrackID:        t.ExternalID,
+		SensorTrackIDs: t.SensorTrackIDs,
+		Classification: t.Classification,
+		Type:           t.Type,
+		ThreatLevel:    t.ThreatLevel,
+		ThreatScore:    t.ThreatScore,
+		Position:       t.Position,
+		Velocity:       t.Velocity,
+		Confidence:     t.Confidence,
+		Sources:        t.Sources,
+		DetectionCount: t.DetectionCount,
+		State:          t.State,
+		StateChangedAt: t.StateChangedAt,
+		FirstSeen:      t.FirstSeen,
+		LastUpdated:    t.LastUpdated,
+		SecurityLabel:  t.SecurityLabel,
+		Releasability:  t.Releasability,
+	}
+}
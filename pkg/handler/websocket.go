@@ -3,18 +3,26 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"nhooyr.io/websocket"
 	"nhooyr.io/websocket/wsjson"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
 )
 
-// WebSocketMessage represents a message sent over WebSocket
+// WebSocketMessage represents a message sent over WebSocket. It is the
+// outbound schema for SchemaVersionLegacy clients and the inbound schema for
+// every client.
 type WebSocketMessage struct {
 	Type          string          `json:"type"`
 	Payload       json.RawMessage `json:"payload"`
@@ -24,49 +32,145 @@ type WebSocketMessage struct {
 
 // MessageType constants
 const (
-	MessageTypeTrackUpdate    = "track.update"
-	MessageTypeTrackNew       = "track.new"
-	MessageTypeProposalNew    = "proposal.new"
-	MessageTypeDecisionMade   = "decision.made"
-	MessageTypeEffectExecuted = "effect.executed"
-	MessageTypeMetricsUpdate  = "metrics.update"
-	MessageTypePing           = "ping"
-	MessageTypePong           = "pong"
-	MessageTypeError          = "error"
+	MessageTypeTrackUpdate       = "track.update"
+	MessageTypeTrackNew          = "track.new"
+	MessageTypeTrackStale        = "track.stale"
+	MessageTypeTrackDelete       = "track.delete"
+	MessageTypeTrackMerged       = "track.merged"
+	MessageTypeTrackSplit        = "track.split"
+	MessageTypeProposalNew       = "proposal.new"
+	MessageTypeProposalEscalated = "proposal.escalated"
+	MessageTypeProposalSLA       = "proposal.sla_breach"
+	MessageTypeProposalRescored  = "proposal.rescored"
+	MessageTypeProposalExpired   = "proposal.expired"
+	MessageTypeProposalClaimed   = "proposal.claimed"
+	MessageTypeProposalReleased  = "proposal.released"
+	MessageTypeBreakGlass        = "break_glass.event"
+	MessageTypeDecisionMade      = "decision.made"
+	MessageTypeDecisionRevoked   = "decision.revoked"
+	MessageTypeEffectExecuted    = "effect.executed"
+	MessageTypeEffectProgress    = "effect.progress"
+	MessageTypeEffectAssessment  = "effect.assessment"
+	MessageTypeSimControl        = "sim.control"
+	MessageTypeAdmissionShed     = "admission.shed"
+	MessageTypeMetricsUpdate     = "metrics.update"
+	MessageTypePing              = "ping"
+	MessageTypePong              = "pong"
+	MessageTypeError             = "error"
 )
 
+// Overflow policies for a WebSocket client whose send queue is full
+const (
+	OverflowDropOldest = "drop_oldest" // Discard the oldest queued message to make room
+	OverflowDisconnect = "disconnect"  // Close the connection; the client reconnects and gets a snapshot
+)
+
+// Send queue defaults and limits
+const (
+	DefaultSendQueueSize = 64
+	MaxSendQueueSize     = 4096
+)
+
+// SendQueueConfig bounds each WebSocket client's queue of outbound messages
+type SendQueueConfig struct {
+	Size     int
+	Overflow string
+}
+
+// DefaultSendQueueConfig returns the default per-client send queue
+func DefaultSendQueueConfig() SendQueueConfig {
+	return SendQueueConfig{Size: DefaultSendQueueSize, Overflow: OverflowDropOldest}
+}
+
+// ParseSendQueueConfig parses WS_SEND_QUEUE_SIZE and WS_OVERFLOW_POLICY, using
+// the defaults for unset values
+func ParseSendQueueConfig(size, overflow string) (SendQueueConfig, error) {
+	cfg := DefaultSendQueueConfig()
+	if size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 || n > MaxSendQueueSize {
+			return cfg, fmt.Errorf("invalid WS_SEND_QUEUE_SIZE %q: must be between 1 and %d", size, MaxSendQueueSize)
+		}
+		cfg.Size = n
+	}
+	switch overflow {
+	case "":
+	case OverflowDropOldest, OverflowDisconnect:
+		cfg.Overflow = overflow
+	default:
+		return cfg, fmt.Errorf("invalid WS_OVERFLOW_POLICY %q: must be %s or %s", overflow, OverflowDropOldest, OverflowDisconnect)
+	}
+	return cfg, nil
+}
+
 // WebSocketClient represents a connected WebSocket client
 type WebSocketClient struct {
-	id         string
-	conn       *websocket.Conn
-	send       chan WebSocketMessage
-	hub        *WebSocketHub
-	subscribed map[string]bool
-	mu         sync.RWMutex
+	id            string
+	conn          *websocket.Conn
+	send          chan Event
+	hub           *WebSocketHub
+	schemaVersion int
+	resync        chan struct{} // Requests a fresh snapshot
+	subscribed    map[string]bool
+	mu            sync.RWMutex
+
+	// Set by the hub before it closes send on overflow
+	overflowed bool
 }
 
 // WebSocketHub manages WebSocket connections and message broadcasting
 type WebSocketHub struct {
 	clients    map[string]*WebSocketClient
-	broadcast  chan WebSocketMessage
+	broadcast  chan Event
 	register   chan *WebSocketClient
 	unregister chan *WebSocketClient
 	mu         sync.RWMutex
 	logger     zerolog.Logger
 	nc         *nats.Conn
 	subs       []*nats.Subscription
+	anonymizer *Anonymizer
+	snapshots  SnapshotStore // Sends event clients a snapshot on connect when set
+	sendQueue  SendQueueConfig
+
+	// Follows effect logs with engagement.completed when set
+	engagements EngagementStore
+
+	// Sees every broadcast before it is anonymized, to record sessions
+	recorder EventRecorder
+
+	dropped             *prometheus.CounterVec
+	overflowDisconnects prometheus.Counter
+
+	// Server-Sent Events clients and the replay buffer for Last-Event-ID
+	streamMu      sync.Mutex
+	streamClients map[string]*streamClient
+	streamEpoch   string // Distinguishes event IDs from an earlier gateway run
+	streamSeq     uint64
+	streamHistory []streamEvent
 }
 
 // NewWebSocketHub creates a new WebSocket hub
 func NewWebSocketHub(nc *nats.Conn, logger zerolog.Logger) *WebSocketHub {
 	return &WebSocketHub{
 		clients:    make(map[string]*WebSocketClient),
-		broadcast:  make(chan WebSocketMessage, 256),
+		broadcast:  make(chan Event, 256),
 		register:   make(chan *WebSocketClient),
 		unregister: make(chan *WebSocketClient),
 		logger:     logger.With().Str("component", "websocket_hub").Logger(),
 		nc:         nc,
 		subs:       make([]*nats.Subscription, 0),
+		sendQueue:  DefaultSendQueueConfig(),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_websocket_dropped_messages_total",
+			Help: "Messages dropped because a WebSocket client's send queue was full",
+		}, []string{"client_id"}),
+		overflowDisconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_websocket_overflow_disconnects_total",
+			Help: "WebSocket clients disconnected because their send queue was full",
+		}),
+
+		streamClients: make(map[string]*streamClient),
+		streamEpoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
 	}
 }
 
@@ -96,61 +200,160 @@ func (h *WebSocketHub) Run(ctx context.Context) {
 				close(client.send)
 			}
 			h.mu.Unlock()
+			h.dropped.DeleteLabelValues(client.id)
 			h.logger.Info().Str("client_id", client.id).Int("total_clients", len(h.clients)).Msg("Client disconnected")
 
 		case message := <-h.broadcast:
+			if h.recorder != nil {
+				h.recorder.Record(message)
+			}
+			if h.anonymizer.Enabled() {
+				message.Payload = h.anonymizer.Transform(message.Payload)
+			}
+			message = h.publishStream(message)
+			var overflowed []*WebSocketClient
 			h.mu.RLock()
 			for _, client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					// Client send buffer full, skip this message
-					h.logger.Warn().Str("client_id", client.id).Str("message_type", message.Type).Msg("Client send buffer full, dropping message")
+				if !h.enqueue(client, message) {
+					overflowed = append(overflowed, client)
 				}
 			}
 			h.mu.RUnlock()
+			for _, client := range overflowed {
+				h.disconnectOverflowed(client)
+			}
 		}
 	}
 }
 
+// enqueue queues a message for one client without blocking the broadcast. A
+// full queue loses its oldest message under OverflowDropOldest; under
+// OverflowDisconnect enqueue reports false and the client must be dropped.
+func (h *WebSocketHub) enqueue(client *WebSocketClient, message Event) bool {
+	select {
+	case client.send <- message:
+		return true
+	default:
+	}
+	if h.sendQueue.Overflow == OverflowDisconnect {
+		return false
+	}
+
+	// The writer may drain the queue meanwhile, so neither step blocks
+	select {
+	case <-client.send:
+		h.dropped.WithLabelValues(client.id).Inc()
+	default:
+	}
+	select {
+	case client.send <- message:
+	default:
+		h.dropped.WithLabelValues(client.id).Inc()
+	}
+	h.logger.Debug().Str("client_id", client.id).Str("message_type", message.EventType).Msg("Client send queue full, dropped oldest message")
+	return true
+}
+
+// disconnectOverflowed drops a client whose send queue overflowed. Its writer
+// closes the connection once it sees the closed queue.
+func (h *WebSocketHub) disconnectOverflowed(client *WebSocketClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client.id]; !ok {
+		return
+	}
+	delete(h.clients, client.id)
+	client.overflowed = true
+	close(client.send)
+	h.overflowDisconnects.Inc()
+	h.logger.Warn().Str("client_id", client.id).Int("queue_size", cap(client.send)).Msg("Client send queue full, disconnecting")
+}
+
 // subscribeToNATS subscribes to relevant NATS subjects
 func (h *WebSocketHub) subscribeToNATS(ctx context.Context) {
 	subjects := map[string]string{
-		"track.>":             MessageTypeTrackUpdate,
-		"proposal.pending.>":  MessageTypeProposalNew,
-		"decision.>":          MessageTypeDecisionMade,
-		"effect.>":            MessageTypeEffectExecuted,
+		"track.>":                  MessageTypeTrackUpdate,
+		"proposal.pending.>":       MessageTypeProposalNew,
+		"notify.escalation.>":      MessageTypeProposalEscalated,
+		"notify.sla.>":             MessageTypeProposalSLA,
+		"notify.rescore.>":         MessageTypeProposalRescored,
+		"notify.breakglass.>":      MessageTypeBreakGlass,
+		"notify.effect_progress.>": MessageTypeEffectProgress,
+		"notify.admission.>":       MessageTypeAdmissionShed,
+		"notify.claim.>":           MessageTypeProposalClaimed,
+		"decision.>":               MessageTypeDecisionMade,
+		"effect.>":                 MessageTypeEffectExecuted,
+		"assessment.>":             MessageTypeEffectAssessment,
+		"simcontrol.>":             MessageTypeSimControl,
 	}
 
 	for subject, msgType := range subjects {
 		messageType := msgType // Capture for closure
 		sub, err := h.nc.Subscribe(subject, func(msg *nats.Msg) {
-			wsMsg := WebSocketMessage{
-				Type:      messageType,
-				Payload:   msg.Data,
-				Timestamp: time.Now().UTC(),
+			// Border copies for sharded correlators duplicate a classified track
+			if strings.HasPrefix(msg.Subject, messages.TrackHandoffPrefix) {
+				return
+			}
+
+			data, err := messages.ToJSON(messages.SchemaForSubject(msg.Subject), msg.Header.Get(messages.ContentTypeHeader), msg.Data)
+			if err != nil {
+				h.logger.Warn().Err(err).Str("subject", msg.Subject).Msg("Dropping undecodable message")
+				return
 			}
+			eventType, payload := messageType, json.RawMessage(data)
 
-			// Try to extract correlation ID from the message
-			var envelope struct {
-				Envelope struct {
-					CorrelationID string `json:"correlation_id"`
-				} `json:"envelope"`
+			// Distinguish between new and updated tracks; with geographic
+			// cells enabled the subject carries the track's cell
+			if messageType == MessageTypeTrackUpdate && (msg.Subject == "track.classified.unknown" || strings.HasPrefix(msg.Subject, "track.classified.unknown.")) {
+				eventType = MessageTypeTrackNew
 			}
-			if err := json.Unmarshal(msg.Data, &envelope); err == nil {
-				wsMsg.CorrelationID = envelope.Envelope.CorrelationID
+
+			// Revocations share the DECISIONS stream but are not new decisions
+			if messageType == MessageTypeDecisionMade && strings.HasPrefix(msg.Subject, messages.DecisionRevokedPrefix) {
+				eventType = MessageTypeDecisionRevoked
+			}
+
+			// Expiry decisions take the proposal off the queue; no one decided it
+			if messageType == MessageTypeDecisionMade && strings.HasPrefix(msg.Subject, messages.DecisionExpiredPrefix) {
+				eventType, payload = expiredProposalMessage(data)
+			}
+
+			// Released and lapsed claims free the proposal for any operator
+			if messageType == MessageTypeProposalClaimed && msg.Subject != "notify.claim."+messages.ClaimClaimed {
+				eventType = MessageTypeProposalReleased
+			}
+
+			// Merges and splits remap track IDs rather than update a track
+			if messageType == MessageTypeTrackUpdate {
+				switch msg.Subject {
+				case messages.TrackMergedSubject:
+					eventType = MessageTypeTrackMerged
+				case messages.TrackSplitSubject:
+					eventType = MessageTypeTrackSplit
+				}
 			}
 
-			// Distinguish between new and updated tracks
-			if messageType == MessageTypeTrackUpdate && msg.Subject == "track.classified.unknown" {
-				wsMsg.Type = MessageTypeTrackNew
+			// Lifecycle events are not track updates; a dropped track leaves the picture
+			if messageType == MessageTypeTrackUpdate && strings.HasPrefix(msg.Subject, "track.lifecycle.") {
+				eventType, payload = trackLifecycleMessage(msg.Subject, msg.Data)
+			}
+
+			ev, err := NewEvent(eventType, payload, time.Now().UTC())
+			if err != nil {
+				h.logger.Warn().Err(err).Str("subject", msg.Subject).Msg("Dropping malformed message")
+				return
 			}
 
 			select {
-			case h.broadcast <- wsMsg:
+			case h.broadcast <- ev:
 			default:
 				h.logger.Warn().Str("subject", msg.Subject).Msg("Broadcast buffer full, dropping message")
 			}
+
+			// The lookups run off the subscription so effects are not held up
+			if messageType == MessageTypeEffectExecuted && h.engagements != nil {
+				go h.announceEngagement(ctx, data)
+			}
 		})
 
 		if err != nil {
@@ -163,6 +366,31 @@ func (h *WebSocketHub) subscribeToNATS(ctx context.Context) {
 	}
 }
 
+// trackLifecycleMessage converts a track lifecycle event into a WebSocket message.
+// Dropped tracks are sent as track.delete with the track ID as the payload.
+func trackLifecycleMessage(subject string, data []byte) (string, json.RawMessage) {
+	if subject != "track.lifecycle.dropped" {
+		return MessageTypeTrackStale, data
+	}
+	var event messages.TrackLifecycle
+	if err := json.Unmarshal(data, &event); err != nil {
+		return MessageTypeTrackStale, data
+	}
+	payload, _ := json.Marshal(event.TrackID)
+	return MessageTypeTrackDelete, payload
+}
+
+// expiredProposalMessage returns the UI event for an expiry decision: the
+// expired proposal's ID, or the decision itself if it cannot be read
+func expiredProposalMessage(data []byte) (string, json.RawMessage) {
+	var decision messages.Decision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return MessageTypeDecisionMade, data
+	}
+	payload, _ := json.Marshal(decision.ProposalID)
+	return MessageTypeProposalExpired, payload
+}
+
 // shutdown cleanly shuts down the hub
 func (h *WebSocketHub) shutdown() {
 	// Unsubscribe from NATS
@@ -178,18 +406,77 @@ func (h *WebSocketHub) shutdown() {
 	h.clients = make(map[string]*WebSocketClient)
 	h.mu.Unlock()
 
+	h.streamMu.Lock()
+	for _, client := range h.streamClients {
+		close(client.send)
+	}
+	h.streamClients = make(map[string]*streamClient)
+	h.streamMu.Unlock()
+
 	h.logger.Info().Msg("WebSocket hub shutdown complete")
 }
 
-// Broadcast sends a message to all connected clients
+// SetAnonymizer applies demo anonymization to broadcast payloads while it is enabled
+func (h *WebSocketHub) SetAnonymizer(a *Anonymizer) {
+	h.anonymizer = a
+}
+
+// EventRecorder receives every event the hub broadcasts. Record is called
+// from the broadcast loop and must not block.
+type EventRecorder interface {
+	Record(ev Event)
+}
+
+// SetRecorder passes every broadcast to r, such as the session recorder
+func (h *WebSocketHub) SetRecorder(r EventRecorder) {
+	h.recorder = r
+}
+
+// SetSendQueue sets the size and overflow policy of each new client's send queue
+func (h *WebSocketHub) SetSendQueue(cfg SendQueueConfig) {
+	h.sendQueue = cfg
+}
+
+// Collectors returns the hub's metrics for registration
+func (h *WebSocketHub) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.dropped, h.overflowDisconnects}
+}
+
+// SetSnapshotStore sends schema version 2 clients a snapshot of active tracks
+// and pending proposals when they connect or resync
+func (h *WebSocketHub) SetSnapshotStore(store SnapshotStore) {
+	h.snapshots = store
+}
+
+// Broadcast transforms a message into an event and sends it to all connected clients
 func (h *WebSocketHub) Broadcast(msg WebSocketMessage) {
+	ev, err := NewEvent(msg.Type, msg.Payload, msg.Timestamp)
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Dropping malformed broadcast")
+		return
+	}
+	if ev.CorrelationID == "" {
+		ev.CorrelationID = msg.CorrelationID
+	}
+
 	select {
-	case h.broadcast <- msg:
+	case h.broadcast <- ev:
 	default:
 		h.logger.Warn().Str("message_type", msg.Type).Msg("Broadcast buffer full")
 	}
 }
 
+// broadcastWait sends an event to all connected clients, waiting for room in
+// the broadcast buffer rather than dropping it
+func (h *WebSocketHub) broadcastWait(ctx context.Context, ev Event) error {
+	select {
+	case h.broadcast <- ev:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ClientCount returns the number of connected clients
 func (h *WebSocketHub) ClientCount() int {
 	h.mu.RLock()
@@ -211,8 +498,16 @@ func NewWebSocketHandler(hub *WebSocketHub, logger zerolog.Logger) *WebSocketHan
 	}
 }
 
-// ServeHTTP handles the WebSocket upgrade and connection
+// ServeHTTP handles the WebSocket upgrade and connection. Clients choose the
+// outbound schema with ?schema_version; an unsupported version is refused
+// before the upgrade.
 func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	schemaVersion, err := ParseSchemaVersion(r.URL.Query().Get("schema_version"))
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), GetCorrelationID(r.Context()))
+		return
+	}
+
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 		OriginPatterns: []string{"localhost:3000", "127.0.0.1:3000", "localhost:3001", "127.0.0.1:3001"},
 	})
@@ -223,11 +518,13 @@ func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	clientID := uuid.New().String()
 	client := &WebSocketClient{
-		id:         clientID,
-		conn:       conn,
-		send:       make(chan WebSocketMessage, 64),
-		hub:        h.hub,
-		subscribed: make(map[string]bool),
+		id:            clientID,
+		conn:          conn,
+		send:          make(chan Event, h.hub.sendQueue.Size),
+		hub:           h.hub,
+		schemaVersion: schemaVersion,
+		resync:        make(chan struct{}, 1),
+		subscribed:    make(map[string]bool),
 	}
 
 	h.hub.register <- client
@@ -241,44 +538,54 @@ func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	client.readPump(ctx)
 }
 
-// writePump pumps messages from the hub to the WebSocket connection
+// writePump pumps messages from the hub to the WebSocket connection. Event
+// clients are first told their schema and sent a snapshot; broadcasts queued
+// meanwhile follow it, so every delta after the snapshot reaches the client.
 func (c *WebSocketClient) writePump(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	if c.schemaVersion != SchemaVersionLegacy {
+		if err := c.write(ctx, newConnectionStatus(c.id, c.schemaVersion)); err != nil {
+			c.hub.logger.Error().Err(err).Str("client_id", c.id).Msg("Failed to send connection status")
+			return
+		}
+		if err := c.writeSnapshot(ctx, SnapshotReasonConnect); err != nil {
+			c.hub.logger.Error().Err(err).Str("client_id", c.id).Msg("Failed to send snapshot")
+			return
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
+		case <-c.resync:
+			if err := c.writeSnapshot(ctx, SnapshotReasonResync); err != nil {
+				c.hub.logger.Error().Err(err).Str("client_id", c.id).Msg("Failed to send snapshot")
+				return
+			}
+
 		case message, ok := <-c.send:
 			if !ok {
 				// Channel closed
+				if c.overflowed {
+					c.conn.Close(websocket.StatusTryAgainLater, "send queue overflow")
+					return
+				}
 				c.conn.Close(websocket.StatusNormalClosure, "connection closed")
 				return
 			}
 
-			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-			err := wsjson.Write(ctx, c.conn, message)
-			cancel()
-
-			if err != nil {
+			if err := c.write(ctx, message); err != nil {
 				c.hub.logger.Error().Err(err).Str("client_id", c.id).Msg("Failed to write message")
 				return
 			}
 
 		case <-ticker.C:
 			// Send ping
-			pingMsg := WebSocketMessage{
-				Type:      MessageTypePing,
-				Timestamp: time.Now().UTC(),
-			}
-
-			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-			err := wsjson.Write(ctx, c.conn, pingMsg)
-			cancel()
-
-			if err != nil {
+			if err := c.write(ctx, newControlEvent(MessageTypePing, nil)); err != nil {
 				c.hub.logger.Error().Err(err).Str("client_id", c.id).Msg("Failed to send ping")
 				return
 			}
@@ -286,6 +593,38 @@ func (c *WebSocketClient) writePump(ctx context.Context) {
 	}
 }
 
+// write sends one event in the client's schema version
+func (c *WebSocketClient) write(ctx context.Context, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return wsjson.Write(ctx, c.conn, ev.Encode(c.schemaVersion))
+}
+
+// writeSnapshot sends a snapshot of the common operating picture when the hub
+// has a snapshot store. A snapshot that cannot be loaded is reported to the
+// client as an error event rather than closing the connection; the client can
+// resync later.
+func (c *WebSocketClient) writeSnapshot(ctx context.Context, reason string) error {
+	h := c.hub
+	if h.snapshots == nil {
+		return nil
+	}
+
+	snapshot, err := buildSnapshot(ctx, h.snapshots, h.LastSeq(), reason)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("client_id", c.id).Msg("Failed to build snapshot")
+		return c.write(ctx, newControlEvent(MessageTypeError, map[string]string{"error": "snapshot unavailable"}))
+	}
+
+	ev := newControlEvent(MessageTypePictureSnapshot, snapshot)
+	if h.anonymizer.Enabled() {
+		ev.Payload = h.anonymizer.Transform(ev.Payload)
+	}
+	h.logger.Debug().Str("client_id", c.id).Str("reason", reason).Uint64("seq", snapshot.Seq).
+		Int("tracks", len(snapshot.Tracks)).Int("proposals", len(snapshot.Proposals)).Msg("Sent snapshot")
+	return c.write(ctx, ev)
+}
+
 // readPump pumps messages from the WebSocket connection to the hub
 func (c *WebSocketClient) readPump(ctx context.Context) {
 	defer func() {
@@ -324,6 +663,13 @@ func (c *WebSocketClient) readPump(ctx context.Context) {
 				c.mu.Unlock()
 			}
 
+		case MessageTypeResync:
+			// Coalesce requests; one pending snapshot covers them all
+			select {
+			case c.resync <- struct{}{}:
+			default:
+			}
+
 		case "unsubscribe":
 			// Handle unsubscription requests
 			var unsubRequest struct {
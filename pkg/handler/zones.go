@@ -0,0 +1,385 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// ZoneHandler handles protected asset and restricted zone HTTP requests
+type ZoneHandler struct {
+	db     *postgres.Pool
+	logger zerolog.Logger
+}
+
+// NewZoneHandler creates a new ZoneHandler
+func NewZoneHandler(db *postgres.Pool, logger zerolog.Logger) *ZoneHandler {
+	return &ZoneHandler{
+		db:     db,
+		logger: logger.With().Str("handler", "zones").Logger(),
+	}
+}
+
+// Routes returns the zone routes
+func (h *ZoneHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ListZones)
+	r.Get("/{zoneId}", h.GetZone)
+	r.Post("/", h.CreateZone)
+	r.Put("/{zoneId}", h.UpdateZone)
+	r.Delete("/{zoneId}", h.DeleteZone)
+
+	return r
+}
+
+// ZoneResponse represents a zone in API responses
+type ZoneResponse struct {
+	ZoneID       string      `json:"zone_id"`
+	Name         string      `json:"name"`
+	Description  *string     `json:"description,omitempty"`
+	Kind         string      `json:"kind"`
+	Shape        string      `json:"shape"`
+	Center       *geo.Point  `json:"center,omitempty"`
+	RadiusMeters *float64    `json:"radius_meters,omitempty"`
+	Polygon      []geo.Point `json:"polygon,omitempty"`
+	Priority     int         `json:"priority"`
+	Enabled      bool        `json:"enabled"`
+	CreatedBy    *string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedBy    *string     `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// ZoneListResponse represents the response for listing zones
+type ZoneListResponse struct {
+	Zones         []ZoneResponse `json:"zones"`
+	Total         int            `json:"total"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+	CorrelationID string         `json:"correlation_id"`
+}
+
+// ZoneDetailResponse represents the detailed response for a single zone
+type ZoneDetailResponse struct {
+	Zone          ZoneResponse `json:"zone"`
+	CorrelationID string       `json:"correlation_id"`
+}
+
+// ZoneRequest represents the request body for creating or updating a zone
+type ZoneRequest struct {
+	Name         string      `json:"name"`
+	Description  *string     `json:"description,omitempty"`
+	Kind         string      `json:"kind"`
+	Shape        string      `json:"shape"`
+	Center       *geo.Point  `json:"center,omitempty"`
+	RadiusMeters *float64    `json:"radius_meters,omitempty"`
+	Polygon      []geo.Point `json:"polygon,omitempty"`
+	Priority     int         `json:"priority"`
+	Enabled      bool        `json:"enabled"`
+	User         *string     `json:"user,omitempty"`
+}
+
+// toZoneResponse converts a database row to an API response
+func toZoneResponse(z postgres.ZoneRow) ZoneResponse {
+	resp := ZoneResponse{
+		ZoneID:       z.ZoneID,
+		Name:         z.Name,
+		Description:  z.Description,
+		Kind:         z.Kind,
+		Shape:        z.Shape,
+		RadiusMeters: z.RadiusMeters,
+		Polygon:      z.Polygon,
+		Priority:     z.Priority,
+		Enabled:      z.Enabled,
+		CreatedBy:    z.CreatedBy,
+		CreatedAt:    z.CreatedAt,
+		UpdatedBy:    z.UpdatedBy,
+		UpdatedAt:    z.UpdatedAt,
+	}
+	if z.CenterLat != nil && z.CenterLon != nil {
+		resp.Center = &geo.Point{Lat: *z.CenterLat, Lon: *z.CenterLon}
+	}
+	return resp
+}
+
+// toZoneRow validates a request and converts it to a database row
+func (req ZoneRequest) toZoneRow(zoneID string) (*postgres.ZoneRow, error) {
+	if req.Priority == 0 {
+		req.Priority = 5
+	}
+
+	row := &postgres.ZoneRow{
+		ZoneID:      zoneID,
+		Name:        req.Name,
+		Description: req.Description,
+		Kind:        req.Kind,
+		Shape:       req.Shape,
+		Priority:    req.Priority,
+		Enabled:     req.Enabled,
+	}
+	if req.Shape == geo.ShapeCircle {
+		if req.Center == nil || req.RadiusMeters == nil {
+			return nil, fmt.Errorf("circle zones require center and radius_meters")
+		}
+		row.CenterLat = &req.Center.Lat
+		row.CenterLon = &req.Center.Lon
+		row.RadiusMeters = req.RadiusMeters
+	} else {
+		row.Polygon = req.Polygon
+	}
+
+	if err := row.ToZone().Validate(); err != nil {
+		return nil, err
+	}
+	if row.Priority < 1 || row.Priority > 10 {
+		return nil, fmt.Errorf("priority must be between 1 and 10")
+	}
+	return row, nil
+}
+
+// ListZones handles GET /api/v1/zones
+func (h *ZoneHandler) ListZones(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	filter := postgres.ZoneFilter{
+		Kind: r.URL.Query().Get("kind"),
+	}
+
+	// Parse enabled filter
+	if enabledStr := r.URL.Query().Get("enabled"); enabledStr != "" {
+		enabled := strings.ToLower(enabledStr) == "true"
+		filter.Enabled = &enabled
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			filter.Offset = offset
+		}
+	}
+
+	zones, err := h.db.ListZones(ctx, filter)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Msg("Failed to list zones")
+		WriteError(w, http.StatusInternalServerError, "Failed to list zones", correlationID)
+		return
+	}
+
+	response := ZoneListResponse{
+		Zones:         make([]ZoneResponse, 0, len(zones)),
+		Total:         len(zones),
+		Limit:         filter.Limit,
+		Offset:        filter.Offset,
+		CorrelationID: correlationID,
+	}
+
+	for _, z := range zones {
+		response.Zones = append(response.Zones, toZoneResponse(z))
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// GetZone handles GET /api/v1/zones/{zoneId}
+func (h *ZoneHandler) GetZone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	zoneID := chi.URLParam(r, "zoneId")
+
+	if zoneID == "" {
+		WriteError(w, http.StatusBadRequest, "Zone ID is required", correlationID)
+		return
+	}
+
+	zone, err := h.db.GetZone(ctx, zoneID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("zone_id", zoneID).Msg("Failed to get zone")
+		WriteError(w, http.StatusInternalServerError, "Failed to get zone", correlationID)
+		return
+	}
+
+	if zone == nil {
+		WriteError(w, http.StatusNotFound, "Zone not found", correlationID)
+		return
+	}
+
+	response := ZoneDetailResponse{
+		Zone:          toZoneResponse(*zone),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// CreateZone handles POST /api/v1/zones
+func (h *ZoneHandler) CreateZone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+
+	var req ZoneRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	zone, err := req.toZoneRow(uuid.New().String())
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	// Get user ID from request or context
+	zone.CreatedBy = req.User
+	if zone.CreatedBy == nil {
+		userID := GetUserID(ctx)
+		if userID != "" {
+			zone.CreatedBy = &userID
+		}
+	}
+	zone.UpdatedBy = zone.CreatedBy
+
+	if err := h.db.CreateZone(ctx, zone); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("zone_name", req.Name).Msg("Failed to create zone")
+		// Check for unique constraint violation
+		if strings.Contains(err.Error(), "unique_zone_name") || strings.Contains(err.Error(), "duplicate key") {
+			WriteError(w, http.StatusConflict, "A zone with this name already exists", correlationID)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "Failed to create zone", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("zone_id", zone.ZoneID).
+		Str("zone_name", zone.Name).
+		Str("kind", zone.Kind).
+		Msg("Created zone")
+
+	response := ZoneDetailResponse{
+		Zone:          toZoneResponse(*zone),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusCreated, response)
+}
+
+// UpdateZone handles PUT /api/v1/zones/{zoneId}
+func (h *ZoneHandler) UpdateZone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	zoneID := chi.URLParam(r, "zoneId")
+
+	if zoneID == "" {
+		WriteError(w, http.StatusBadRequest, "Zone ID is required", correlationID)
+		return
+	}
+
+	var req ZoneRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid request body", correlationID)
+		return
+	}
+
+	zone, err := req.toZoneRow(zoneID)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error(), correlationID)
+		return
+	}
+
+	// Check if zone exists
+	existing, err := h.db.GetZone(ctx, zoneID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("zone_id", zoneID).Msg("Failed to get zone")
+		WriteError(w, http.StatusInternalServerError, "Failed to get zone", correlationID)
+		return
+	}
+
+	if existing == nil {
+		WriteError(w, http.StatusNotFound, "Zone not found", correlationID)
+		return
+	}
+
+	// Get user ID from request or context
+	zone.UpdatedBy = req.User
+	if zone.UpdatedBy == nil {
+		userID := GetUserID(ctx)
+		if userID != "" {
+			zone.UpdatedBy = &userID
+		}
+	}
+	zone.CreatedBy = existing.CreatedBy
+	zone.CreatedAt = existing.CreatedAt
+
+	if err := h.db.UpdateZone(ctx, zone); err != nil {
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("zone_id", zoneID).Msg("Failed to update zone")
+		// Check for unique constraint violation
+		if strings.Contains(err.Error(), "unique_zone_name") || strings.Contains(err.Error(), "duplicate key") {
+			WriteError(w, http.StatusConflict, "A zone with this name already exists", correlationID)
+			return
+		}
+		WriteError(w, http.StatusInternalServerError, "Failed to update zone", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("zone_id", zone.ZoneID).
+		Str("zone_name", zone.Name).
+		Msg("Updated zone")
+
+	response := ZoneDetailResponse{
+		Zone:          toZoneResponse(*zone),
+		CorrelationID: correlationID,
+	}
+
+	WriteJSON(w, http.StatusOK, response)
+}
+
+// DeleteZone handles DELETE /api/v1/zones/{zoneId}
+func (h *ZoneHandler) DeleteZone(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	correlationID := GetCorrelationID(ctx)
+	zoneID := chi.URLParam(r, "zoneId")
+
+	if zoneID == "" {
+		WriteError(w, http.StatusBadRequest, "Zone ID is required", correlationID)
+		return
+	}
+
+	if err := h.db.DeleteZone(ctx, zoneID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteError(w, http.StatusNotFound, "Zone not found", correlationID)
+			return
+		}
+		h.logger.Error().Err(err).Str("correlation_id", correlationID).Str("zone_id", zoneID).Msg("Failed to delete zone")
+		WriteError(w, http.StatusInternalServerError, "Failed to delete zone", correlationID)
+		return
+	}
+
+	h.logger.Info().
+		Str("correlation_id", correlationID).
+		Str("zone_id", zoneID).
+		Msg("Deleted zone")
+
+	WriteSuccess(w, http.StatusOK, "Zone deleted successfully", nil, correlationID)
+}
@@ -0,0 +1,299 @@
+// Package inference scores detections with an external model-serving endpoint.
+//
+// The classifier POSTs a detection's features to MODEL_URL and receives class
+// probabilities tagged with the model version. In active mode the most likely
+// class replaces the rule-based classification when it is confident enough; in
+// shadow mode the prediction is only compared with the rules and logged. Any
+// error or timeout falls back to the rule-based classification.
+package inference
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Modes of the model integration
+const (
+	ModeActive = "active" // Confident predictions replace the rule-based classification
+	ModeShadow = "shadow" // Predictions are logged and measured but never change the output
+)
+
+// Defaults for the model client
+const (
+	DefaultTimeout       = 200 * time.Millisecond
+	DefaultMinConfidence = 0.6
+)
+
+// Outcomes recorded per model version
+const (
+	OutcomeUsed          = "used"           // The prediction set the classification
+	OutcomeLowConfidence = "low_confidence" // The top probability was below the minimum; the rules were used
+	OutcomeShadow        = "shadow"         // Shadow mode; the rules were used
+	OutcomeInvalidClass  = "invalid_class"  // The top class is not a known classification; the rules were used
+)
+
+// unknownVersion labels metrics for requests that returned no model version
+const unknownVersion = "unknown"
+
+// Options configures the model client
+type Options struct {
+	URL           string        // Model-serving endpoint; empty disables the integration
+	Timeout       time.Duration // Per-request deadline before falling back to the rules
+	Mode          string        // ModeActive or ModeShadow
+	MinConfidence float64       // Top probability required to use a prediction in active mode
+}
+
+// Enabled reports whether a model endpoint is configured
+func (o Options) Enabled() bool {
+	return o.URL != ""
+}
+
+// OptionsFromVars parses MODEL_URL, MODEL_TIMEOUT, MODEL_MODE, and
+// MODEL_MIN_CONFIDENCE from agent config variables, using the defaults for
+// unset values
+func OptionsFromVars(vars map[string]string) (Options, error) {
+	opts := Options{
+		URL:           strings.TrimRight(vars["MODEL_URL"], "/"),
+		Timeout:       DefaultTimeout,
+		Mode:          ModeActive,
+		MinConfidence: DefaultMinConfidence,
+	}
+	if v := vars["MODEL_TIMEOUT"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid MODEL_TIMEOUT %q: must be a positive duration", v)
+		}
+		opts.Timeout = d
+	}
+	if v := vars["MODEL_MODE"]; v != "" {
+		switch m := strings.ToLower(v); m {
+		case ModeActive, ModeShadow:
+			opts.Mode = m
+		default:
+			return opts, fmt.Errorf("invalid MODEL_MODE %q: must be active or shadow", v)
+		}
+	}
+	if v := vars["MODEL_MIN_CONFIDENCE"]; v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return opts, fmt.Errorf("invalid MODEL_MIN_CONFIDENCE %q: must be between 0 and 1", v)
+		}
+		opts.MinConfidence = f
+	}
+	return opts, nil
+}
+
+// Features are the detection attributes sent to the model
+type Features struct {
+	TrackID    string  `json:"track_id"`
+	SensorType string  `json:"sensor_type"`
+	TypeHint   string  `json:"type_hint,omitempty"` // Track type reported by the sensor
+	TrackType  string  `json:"track_type"`          // Track type after the rules
+	Lat        float64 `json:"lat"`
+	Lon        float64 `json:"lon"`
+	Alt        float64 `json:"alt"`
+	Speed      float64 `json:"speed"`
+	Heading    float64 `json:"heading"`
+	Confidence float64 `json:"confidence"`
+}
+
+// FeaturesFor extracts model features from a detection and its rule-based track type
+func FeaturesFor(d *messages.Detection, trackType string) Features {
+	return Features{
+		TrackID:    d.TrackID,
+		SensorType: d.SensorType,
+		TypeHint:   d.Type,
+		TrackType:  trackType,
+		Lat:        d.Position.Lat,
+		Lon:        d.Position.Lon,
+		Alt:        d.Position.Alt,
+		Speed:      d.Velocity.Speed,
+		Heading:    d.Velocity.Heading,
+		Confidence: d.Confidence,
+	}
+}
+
+// Prediction is the model's response
+type Prediction struct {
+	ModelVersion  string             `json:"model_version"`
+	Probabilities map[string]float64 `json:"probabilities"` // Classification to probability
+}
+
+// Top returns the most probable class and its probability, breaking ties by name
+func (p Prediction) Top() (string, float64) {
+	var class string
+	best := -1.0
+	for c, prob := range p.Probabilities {
+		if prob > best || (prob == best && c < class) {
+			class, best = c, prob
+		}
+	}
+	if class == "" {
+		return "", 0
+	}
+	return class, best
+}
+
+// Version returns the model version for metric labels
+func (p Prediction) Version() string {
+	if p.ModelVersion == "" {
+		return unknownVersion
+	}
+	return p.ModelVersion
+}
+
+// Client calls the model-serving endpoint
+type Client struct {
+	opts    Options
+	http    *http.Client
+	metrics *clientMetrics
+}
+
+// NewClient creates a model client
+func NewClient(opts Options) *Client {
+	return &Client{
+		opts:    opts,
+		http:    &http.Client{},
+		metrics: newClientMetrics(),
+	}
+}
+
+// Options returns the client's configuration
+func (c *Client) Options() Options {
+	return c.opts
+}
+
+// Collectors returns the client's per-model-version metrics for registration
+func (c *Client) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.metrics.requests, c.metrics.errors, c.metrics.latency, c.metrics.disagreements}
+}
+
+// Score requests class probabilities for a detection, giving up after the timeout
+func (c *Client) Score(ctx context.Context, f Features) (*Prediction, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	pred, err := c.score(ctx, f)
+	if err != nil {
+		reason := "error"
+		if ctx.Err() == context.DeadlineExceeded {
+			reason = "timeout"
+		}
+		c.metrics.errors.WithLabelValues(reason).Inc()
+		return nil, err
+	}
+	return pred, nil
+}
+
+func (c *Client) score(ctx context.Context, f Features) (*Prediction, error) {
+	body, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal features: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call model endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("model endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var pred Prediction
+	if err := json.NewDecoder(resp.Body).Decode(&pred); err != nil {
+		return nil, fmt.Errorf("failed to decode prediction: %w", err)
+	}
+	if len(pred.Probabilities) == 0 {
+		return nil, fmt.Errorf("model %s returned no probabilities", pred.Version())
+	}
+	c.metrics.latency.WithLabelValues(pred.Version()).Observe(time.Since(start).Seconds())
+	return &pred, nil
+}
+
+// Decide chooses between a prediction and the rule-based classification,
+// recording the outcome and any disagreement under the model version. It
+// returns the classification to publish and the outcome.
+func (c *Client) Decide(pred *Prediction, heuristic string) (string, string) {
+	version := pred.Version()
+	class, prob := pred.Top()
+	if class != heuristic {
+		c.metrics.disagreements.WithLabelValues(version, heuristic, class).Inc()
+	}
+
+	outcome := OutcomeUsed
+	switch {
+	case c.opts.Mode == ModeShadow:
+		outcome = OutcomeShadow
+	case !isClassification(class):
+		outcome = OutcomeInvalidClass
+	case prob < c.opts.MinConfidence:
+		outcome = OutcomeLowConfidence
+	}
+	c.metrics.requests.WithLabelValues(version, outcome).Inc()
+
+	if outcome == OutcomeUsed {
+		return class, outcome
+	}
+	return heuristic, outcome
+}
+
+func isClassification(class string) bool {
+	for _, c := range classify.Classifications {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// clientMetrics are the per-model-version metrics of one client
+type clientMetrics struct {
+	requests      *prometheus.CounterVec
+	errors        *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	disagreements *prometheus.CounterVec
+}
+
+func newClientMetrics() *clientMetrics {
+	return &clientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "classifier_model_predictions_total",
+			Help: "Model predictions by model version and outcome (used, low_confidence, invalid_class, shadow)",
+		}, []string{"model_version", "outcome"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "classifier_model_errors_total",
+			Help: "Model requests that failed and fell back to the rules, by reason (timeout, error)",
+		}, []string{"reason"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "classifier_model_latency_seconds",
+			Help:    "Model request latency by model version",
+			Buckets: []float64{.005, .01, .025, .05, .1, .2, .5, 1},
+		}, []string{"model_version"}),
+		disagreements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "classifier_model_disagreements_total",
+			Help: "Predictions whose top class differed from the rule-based classification",
+		}, []string{"model_version", "heuristic", "model"}),
+	}
+}
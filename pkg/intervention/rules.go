@@ -0,0 +1,235 @@
+// Package intervention validates the planner's intervention rules, which decide
+// whether a proposed action needs human approval, and records every change to
+// them so a rule's effect on past proposals can be traced.
+//
+// Rules are matched in evaluation_order and the first match decides, so two
+// enabled rules that can match the same proposal at the same evaluation order
+// leave the outcome to the database's row order. Those are rejected; overlaps
+// at different orders are legal, since a specific rule ahead of a broad one is
+// how exceptions are written, and are reported to the editor instead.
+package intervention
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/agile-defense/cjadc2/pkg/classify"
+)
+
+// Audit log entity type for rule changes
+const AuditEntityType = "intervention_rule"
+
+// Priority bounds of a proposal, which min_priority and max_priority must lie in
+const (
+	MinPriority = 1
+	MaxPriority = 10
+)
+
+// Changes recorded in a rule's version history
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Values a rule may match on. Classifications are shared with the classifier.
+var (
+	ActionTypes  = []string{"engage", "intercept", "identify", "track", "monitor", "ignore"}
+	ThreatLevels = []string{"low", "medium", "high", "critical"}
+	TrackTypes   = []string{"aircraft", "missile", "vessel", "ground", "unknown"}
+)
+
+// Rule is an intervention rule as the planner evaluates it. Empty lists and
+// unset priority bounds match anything.
+type Rule struct {
+	RuleID           string   `json:"rule_id"`
+	Name             string   `json:"name"`
+	Description      *string  `json:"description,omitempty"`
+	ActionTypes      []string `json:"action_types"`
+	ThreatLevels     []string `json:"threat_levels"`
+	Classifications  []string `json:"classifications"`
+	TrackTypes       []string `json:"track_types"`
+	MinPriority      *int     `json:"min_priority,omitempty"`
+	MaxPriority      *int     `json:"max_priority,omitempty"`
+	RequiresApproval bool     `json:"requires_approval"`
+	AutoApprove      bool     `json:"auto_approve"`
+	Enabled          bool     `json:"enabled"`
+	EvaluationOrder  int      `json:"evaluation_order"`
+}
+
+// Validate checks that a rule is well-formed
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	for _, f := range []struct {
+		name    string
+		values  []string
+		allowed []string
+	}{
+		{"action_types", r.ActionTypes, ActionTypes},
+		{"threat_levels", r.ThreatLevels, ThreatLevels},
+		{"classifications", r.Classifications, classify.Classifications},
+		{"track_types", r.TrackTypes, TrackTypes},
+	} {
+		for _, v := range f.values {
+			if !contains(f.allowed, v) {
+				return fmt.Errorf("rule %q: %s may only contain %s, got %q", r.Name, f.name, strings.Join(f.allowed, ", "), v)
+			}
+		}
+	}
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{
+		{"min_priority", r.MinPriority},
+		{"max_priority", r.MaxPriority},
+	} {
+		if p.value != nil && (*p.value < MinPriority || *p.value > MaxPriority) {
+			return fmt.Errorf("rule %q: %s must be between %d and %d", r.Name, p.name, MinPriority, MaxPriority)
+		}
+	}
+	if r.MinPriority != nil && r.MaxPriority != nil && *r.MinPriority > *r.MaxPriority {
+		return fmt.Errorf("rule %q: min_priority must not exceed max_priority", r.Name)
+	}
+	if r.EvaluationOrder < 0 {
+		return fmt.Errorf("rule %q: evaluation_order must not be negative", r.Name)
+	}
+	if r.AutoApprove && r.RequiresApproval {
+		return fmt.Errorf("rule %q: auto_approve and requires_approval are mutually exclusive", r.Name)
+	}
+	return nil
+}
+
+// Overlaps reports whether some proposal could match both rules. Disabled
+// rules match nothing.
+func Overlaps(a, b Rule) bool {
+	if !a.Enabled || !b.Enabled {
+		return false
+	}
+	return intersects(a.ActionTypes, b.ActionTypes) &&
+		intersects(a.ThreatLevels, b.ThreatLevels) &&
+		intersects(a.Classifications, b.Classifications) &&
+		intersects(a.TrackTypes, b.TrackTypes) &&
+		priorityOr(a.MinPriority, MinPriority) <= priorityOr(b.MaxPriority, MaxPriority) &&
+		priorityOr(b.MinPriority, MinPriority) <= priorityOr(a.MaxPriority, MaxPriority)
+}
+
+// Overlapping returns the rules in others, other than rule itself, that could
+// match a proposal rule matches
+func Overlapping(rule Rule, others []Rule) []Rule {
+	var out []Rule
+	for _, o := range others {
+		if o.RuleID != rule.RuleID && Overlaps(rule, o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Conflicts returns the overlapping rules that share rule's evaluation order,
+// leaving which of them decides undefined
+func Conflicts(rule Rule, others []Rule) []Rule {
+	var out []Rule
+	for _, o := range Overlapping(rule, others) {
+		if o.EvaluationOrder == rule.EvaluationOrder {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Version is one entry in a rule's history: the rule as it stood after a change
+type Version struct {
+	RuleID        string    `json:"rule_id"`
+	Version       int       `json:"version"`
+	Change        string    `json:"change"`
+	Rule          Rule      `json:"rule"`
+	ChangedBy     *string   `json:"changed_by,omitempty"`
+	ChangedAt     time.Time `json:"changed_at"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// Execer is the subset of pgx.Tx used to record rule changes
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// RecordChange writes a rule's new version to its history and the audit log.
+// previous is nil for a new rule. It must run in the transaction that changes
+// the rule.
+func RecordChange(ctx context.Context, tx Execer, change string, version int, rule Rule, previous *Rule, changedBy *string, correlationID string) error {
+	snapshot, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal intervention rule: %w", err)
+	}
+	var old []byte
+	if previous != nil {
+		if old, err = json.Marshal(previous); err != nil {
+			return fmt.Errorf("failed to marshal previous intervention rule: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO intervention_rule_versions (rule_id, version, change, snapshot, changed_by, correlation_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, rule.RuleID, version, change, snapshot, changedBy, correlationID)
+	if err != nil {
+		return fmt.Errorf("failed to record intervention rule version: %w", err)
+	}
+
+	actor, actorType := "unknown", "system"
+	if changedBy != nil && *changedBy != "" {
+		actor, actorType = *changedBy, "human"
+	}
+	// audit_log.correlation_id is a UUID; fall back to the rule ID for free-form IDs
+	if _, err := uuid.Parse(correlationID); err != nil {
+		correlationID = rule.RuleID
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor_id, actor_type, old_value, new_value, correlation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, AuditEntityType, rule.RuleID, "intervention_rule_"+change, actor, actorType, old, snapshot, correlationID)
+	if err != nil {
+		return fmt.Errorf("failed to write intervention rule audit entry: %w", err)
+	}
+	return nil
+}
+
+// intersects reports whether two match lists share a value, treating an
+// empty list as matching everything
+func intersects(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, v := range a {
+		if contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// priorityOr returns a priority bound, or def when it is unset
+func priorityOr(p *int, def int) int {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
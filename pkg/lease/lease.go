@@ -0,0 +1,297 @@
+// Package lease provides a leader lease stored in JetStream KV with fencing tokens.
+//
+// A single key holds the current lease record. Holders renew it with
+// compare-and-set updates, so at most one instance can hold an unexpired lease.
+// Every new tenure receives a strictly larger fencing token. Downstream
+// resources should reject writes that carry a token older than the newest
+// one they have seen, so a former holder cut off by a partition cannot act.
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+)
+
+// Default lease timing
+const (
+	DefaultTTL           = 10 * time.Second
+	DefaultRenewInterval = 3 * time.Second
+)
+
+// Store is the subset of jetstream.KeyValue used by a lease
+type Store interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Create(ctx context.Context, key string, value []byte) (uint64, error)
+	Update(ctx context.Context, key string, value []byte, revision uint64) (uint64, error)
+}
+
+// Record is the lease value stored in the KV bucket
+type Record struct {
+	Holder     string    `json:"holder"`
+	Token      uint64    `json:"token"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lease is no longer held at now
+func (r Record) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// Claim computes the record holder should write to take or renew the lease.
+// It returns false if another holder's lease is still valid. A renewal keeps
+// the current token; a takeover or release-and-reacquire increments it.
+func Claim(current *Record, holder string, now time.Time, ttl time.Duration) (Record, bool) {
+	next := Record{
+		Holder:     holder,
+		Token:      1,
+		AcquiredAt: now,
+		RenewedAt:  now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if current == nil {
+		return next, true
+	}
+
+	if current.Holder == holder && !current.Expired(now) {
+		next.Token = current.Token
+		next.AcquiredAt = current.AcquiredAt
+		return next, true
+	}
+	if !current.Expired(now) {
+		return Record{}, false
+	}
+
+	next.Token = current.Token + 1
+	return next, true
+}
+
+// Config configures a lease
+type Config struct {
+	Key           string
+	Holder        string
+	TTL           time.Duration
+	RenewInterval time.Duration
+}
+
+// Lease campaigns for and holds a single KV-backed lease
+type Lease struct {
+	store  Store
+	cfg    Config
+	logger zerolog.Logger
+
+	mu         sync.RWMutex
+	held       bool
+	token      uint64
+	revision   uint64
+	validUntil time.Time // Local deadline; conservative because it is measured from before the write
+	onChange   func(active bool, token uint64)
+}
+
+// EnsureBucket returns the KV bucket, creating it if needed
+func EnsureBucket(ctx context.Context, js jetstream.JetStream, bucket string) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, bucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, jetstream.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get lease bucket: %w", err)
+	}
+
+	kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      bucket,
+		Description: "Leader leases with fencing tokens",
+		History:     5,
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lease bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// New creates a lease on store. The lease is not held until Run or TryAcquire succeeds.
+func New(store Store, cfg Config, logger zerolog.Logger) *Lease {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.RenewInterval <= 0 || cfg.RenewInterval >= cfg.TTL {
+		cfg.RenewInterval = cfg.TTL / 3
+	}
+	return &Lease{
+		store:  store,
+		cfg:    cfg,
+		logger: logger.With().Str("lease", cfg.Key).Logger(),
+	}
+}
+
+// OnChange registers a callback invoked when the lease is gained or lost
+func (l *Lease) OnChange(fn func(active bool, token uint64)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = fn
+}
+
+// Active returns the fencing token and true while this instance holds the lease
+func (l *Lease) Active() (uint64, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if !l.held || !time.Now().Before(l.validUntil) {
+		return 0, false
+	}
+	return l.token, true
+}
+
+// Current returns the lease record as stored, or nil if no lease has been written
+func (l *Lease) Current(ctx context.Context) (*Record, error) {
+	rec, _, err := l.get(ctx)
+	return rec, err
+}
+
+// Run campaigns for the lease and renews it until ctx is done, then releases it
+func (l *Lease) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.cfg.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.TryAcquire(ctx); err != nil && ctx.Err() == nil {
+			l.logger.Warn().Err(err).Msg("Lease acquisition failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			if err := l.Release(releaseCtx); err != nil {
+				l.logger.Warn().Err(err).Msg("Failed to release lease")
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// TryAcquire makes one attempt to acquire or renew the lease
+func (l *Lease) TryAcquire(ctx context.Context) (bool, error) {
+	start := time.Now()
+
+	current, revision, err := l.get(ctx)
+	if err != nil {
+		l.lose()
+		return false, err
+	}
+
+	next, ok := Claim(current, l.cfg.Holder, start.UTC(), l.cfg.TTL)
+	if !ok {
+		l.lose()
+		return false, nil
+	}
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	if current == nil {
+		revision, err = l.store.Create(ctx, l.cfg.Key, data)
+	} else {
+		revision, err = l.store.Update(ctx, l.cfg.Key, data, revision)
+	}
+	if err != nil {
+		// Another instance won the compare-and-set
+		l.lose()
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to write lease: %w", err)
+	}
+
+	l.gain(next.Token, revision, start.Add(l.cfg.TTL))
+	return true, nil
+}
+
+// Release gives up the lease early so a standby can take over without waiting for expiry
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.RLock()
+	held, revision := l.held, l.revision
+	l.mu.RUnlock()
+	if !held {
+		return nil
+	}
+	l.lose()
+
+	current, currentRevision, err := l.get(ctx)
+	if err != nil || current == nil || current.Holder != l.cfg.Holder || currentRevision != revision {
+		return err
+	}
+
+	current.ExpiresAt = time.Now().UTC()
+	data, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease: %w", err)
+	}
+	if _, err := l.store.Update(ctx, l.cfg.Key, data, revision); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// get reads the current lease record and its revision
+func (l *Lease) get(ctx context.Context) (*Record, uint64, error) {
+	entry, err := l.store.Get(ctx, l.cfg.Key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read lease: %w", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(entry.Value(), &rec); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode lease: %w", err)
+	}
+	return &rec, entry.Revision(), nil
+}
+
+func (l *Lease) gain(token, revision uint64, validUntil time.Time) {
+	l.mu.Lock()
+	changed := !l.held || l.token != token
+	l.held = true
+	l.token = token
+	l.revision = revision
+	l.validUntil = validUntil
+	onChange := l.onChange
+	l.mu.Unlock()
+
+	if changed {
+		l.logger.Info().Uint64("fencing_token", token).Msg("Lease acquired, now active")
+		if onChange != nil {
+			onChange(true, token)
+		}
+	}
+}
+
+func (l *Lease) lose() {
+	l.mu.Lock()
+	wasHeld := l.held
+	token := l.token
+	l.held = false
+	l.revision = 0
+	onChange := l.onChange
+	l.mu.Unlock()
+
+	if wasHeld {
+		l.logger.Warn().Uint64("fencing_token", token).Msg("Lease lost, now standby")
+		if onChange != nil {
+			onChange(false, token)
+		}
+	}
+}
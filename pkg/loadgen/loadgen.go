@@ -0,0 +1,360 @@
+// Package loadgen drives synthetic load through the whole pipeline and
+// measures it end to end.
+//
+// Synthetic detections are published straight to the DETECTIONS stream at a
+// fixed rate, each with its own correlation ID and a track ID carrying the run
+// prefix. Downstream messages are matched back to the detection that caused
+// them by correlation ID, or by track ID when an agent started a new chain,
+// and the first arrival at each stage gives that detection's latency to the
+// stage. The report holds per-stage counts, throughput, and latency
+// percentiles so regressions can be caught in CI or before a demo.
+package loadgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Pipeline stages observed by the harness, in pipeline order
+const (
+	StageClassified = "classified"
+	StageCorrelated = "correlated"
+	StageProposal   = "proposal"
+	StageDecision   = "decision"
+	StageEffect     = "effect"
+)
+
+// Stages lists the observed stages in pipeline order
+var Stages = []string{StageClassified, StageCorrelated, StageProposal, StageDecision, StageEffect}
+
+// StageSubjects maps each stage to the subject its messages are published on
+var StageSubjects = map[string]string{
+	StageClassified: "track.classified.>",
+	StageCorrelated: "track.correlated.>",
+	StageProposal:   "proposal.>",
+	StageDecision:   "decision.>",
+	StageEffect:     "effect.>",
+}
+
+// Defaults for a run
+const (
+	DefaultRate     = 50.0
+	DefaultDuration = 30 * time.Second
+	DefaultTracks   = 20
+	DefaultSettle   = 30 * time.Second
+
+	DefaultApprovedBy = "commander"
+)
+
+// Options configures a load run
+type Options struct {
+	Rate     float64       // Detections per second
+	Duration time.Duration // How long to publish
+	Tracks   int           // Distinct synthetic tracks the detections are spread over
+	Settle   time.Duration // How long to keep observing after publishing stops
+	RunID    string        // Prefix of synthetic track and sensor IDs
+
+	// ApproveURL is the gateway base URL used to approve proposals for
+	// synthetic tracks so decisions and effects are measured; empty skips approval
+	ApproveURL string
+	ApprovedBy string // User the approvals are made as
+}
+
+// DefaultOptions returns the default run settings with a fresh run ID
+func DefaultOptions() Options {
+	return Options{
+		Rate:     DefaultRate,
+		Duration: DefaultDuration,
+		Tracks:   DefaultTracks,
+		Settle:   DefaultSettle,
+		RunID:    fmt.Sprintf("%x", time.Now().UnixNano()&0xffffff),
+
+		ApprovedBy: DefaultApprovedBy,
+	}
+}
+
+// Validate checks the options describe a run that can be made
+func (o Options) Validate() error {
+	if o.Rate <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if o.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if o.Tracks <= 0 {
+		return fmt.Errorf("tracks must be positive")
+	}
+	if o.Settle < 0 {
+		return fmt.Errorf("settle must not be negative")
+	}
+	if o.RunID == "" {
+		return fmt.Errorf("run ID is required")
+	}
+	return nil
+}
+
+// TrackPrefix returns the prefix of every synthetic track ID in the run
+func (o Options) TrackPrefix() string {
+	return "LOAD-" + o.RunID + "-"
+}
+
+// SensorID returns the sensor ID the run's detections are published under
+func (o Options) SensorID() string {
+	return "loadgen-" + o.RunID
+}
+
+// Generator produces synthetic detections spread over a fixed set of tracks
+type Generator struct {
+	opts   Options
+	rng    *rand.Rand
+	tracks []generatedTrack
+	next   int
+}
+
+type generatedTrack struct {
+	id       string
+	kind     string
+	position messages.Position
+	velocity messages.Velocity
+}
+
+// generatedKinds weights synthetic track types toward aircraft, with enough
+// hostile-looking missiles to exercise the planner
+var generatedKinds = []string{"aircraft", "aircraft", "aircraft", "vessel", "ground", "missile"}
+
+// NewGenerator creates a generator for the run
+func NewGenerator(opts Options, seed int64) *Generator {
+	rng := rand.New(rand.NewSource(seed))
+	g := &Generator{opts: opts, rng: rng}
+	for i := 0; i < opts.Tracks; i++ {
+		g.tracks = append(g.tracks, generatedTrack{
+			id:   fmt.Sprintf("%s%04d", opts.TrackPrefix(), i),
+			kind: generatedKinds[rng.Intn(len(generatedKinds))],
+			position: messages.Position{
+				Lat: 33.5 + rng.Float64()*2,
+				Lon: -118.5 + rng.Float64()*2,
+				Alt: 1000 + rng.Float64()*9000,
+			},
+			velocity: messages.Velocity{
+				Speed:   100 + rng.Float64()*500,
+				Heading: rng.Float64() * 360,
+			},
+		})
+	}
+	return g
+}
+
+// Next returns the next detection, cycling through the tracks and moving each
+// along its heading
+func (g *Generator) Next() *messages.Detection {
+	t := &g.tracks[g.next%len(g.tracks)]
+	g.next++
+
+	heading := t.velocity.Heading * math.Pi / 180
+	t.position.Lat += math.Cos(heading) * 0.001
+	t.position.Lon += math.Sin(heading) * 0.001
+
+	det := messages.NewDetection(g.opts.SensorID(), "radar")
+	det.Envelope.CorrelationID = det.Envelope.MessageID
+	det.TrackID = t.id
+	det.Type = t.kind
+	det.Position = t.position
+	det.Velocity = t.velocity
+	det.Confidence = 0.7 + g.rng.Float64()*0.3
+	return det
+}
+
+// observed holds the fields the harness reads from any stage's message
+type observed struct {
+	Envelope   messages.Envelope `json:"envelope"`
+	TrackID    string            `json:"track_id"`
+	MergedFrom []string          `json:"merged_from"`
+	ProposalID string            `json:"proposal_id"`
+}
+
+// Recorder matches stage messages to published detections and keeps the
+// first-arrival latency of each detection at each stage
+type Recorder struct {
+	prefix string
+
+	mu            sync.Mutex
+	published     map[string]time.Time // Correlation ID to publish time
+	firstByTrack  map[string]string    // Track ID to the correlation ID of its first detection
+	seen          map[string]map[string]bool
+	latencies     map[string][]time.Duration
+	firstAt       map[string]time.Time
+	lastAt        map[string]time.Time
+	publishCount  int
+	publishErrors int
+	startedAt     time.Time
+	stoppedAt     time.Time
+}
+
+// NewRecorder creates a recorder for the run's synthetic tracks
+func NewRecorder(opts Options) *Recorder {
+	r := &Recorder{
+		prefix:       opts.TrackPrefix(),
+		published:    make(map[string]time.Time),
+		firstByTrack: make(map[string]string),
+		seen:         make(map[string]map[string]bool),
+		latencies:    make(map[string][]time.Duration),
+		firstAt:      make(map[string]time.Time),
+		lastAt:       make(map[string]time.Time),
+	}
+	for _, stage := range Stages {
+		r.seen[stage] = make(map[string]bool)
+	}
+	return r
+}
+
+// Published records a detection published at at, or a failed publish when err is set
+func (r *Recorder) Published(det *messages.Detection, at time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.startedAt.IsZero() {
+		r.startedAt = at
+	}
+	r.stoppedAt = at
+	if err != nil {
+		r.publishErrors++
+		return
+	}
+	r.publishCount++
+	r.published[det.Envelope.CorrelationID] = at
+	if _, ok := r.firstByTrack[det.TrackID]; !ok {
+		r.firstByTrack[det.TrackID] = det.Envelope.CorrelationID
+	}
+}
+
+// Observe matches a stage message received at at to its detection. It
+// returns the message's proposal ID when a proposal for a synthetic track was
+// seen for the first time, so the caller can approve it.
+func (r *Recorder) Observe(stage string, data []byte, at time.Time) (proposalID string, matched bool) {
+	var msg observed
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return "", false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := msg.Envelope.CorrelationID
+	if _, ok := r.published[key]; !ok {
+		key = ""
+		for _, id := range append([]string{msg.TrackID}, msg.MergedFrom...) {
+			if first, ok := r.firstByTrack[id]; ok && strings.HasPrefix(id, r.prefix) {
+				key = first
+				break
+			}
+		}
+	}
+	if key == "" || r.seen[stage][key] {
+		return "", key != ""
+	}
+
+	r.seen[stage][key] = true
+	r.latencies[stage] = append(r.latencies[stage], at.Sub(r.published[key]))
+	if r.firstAt[stage].IsZero() {
+		r.firstAt[stage] = at
+	}
+	r.lastAt[stage] = at
+	if stage == StageProposal {
+		return msg.ProposalID, true
+	}
+	return "", true
+}
+
+// StageReport summarizes one stage
+type StageReport struct {
+	Stage      string  `json:"stage"`
+	Count      int     `json:"count"`      // Detections that reached the stage
+	Throughput float64 `json:"throughput"` // Arrivals per second between the first and last
+	P50Ms      float64 `json:"p50_ms"`
+	P90Ms      float64 `json:"p90_ms"`
+	P99Ms      float64 `json:"p99_ms"`
+	MaxMs      float64 `json:"max_ms"`
+}
+
+// Report summarizes a load run
+type Report struct {
+	RunID         string        `json:"run_id"`
+	Published     int           `json:"published"`
+	PublishErrors int           `json:"publish_errors"`
+	PublishRate   float64       `json:"publish_rate"` // Detections per second achieved
+	Approved      int           `json:"approved,omitempty"`
+	ApproveErrors int           `json:"approve_errors,omitempty"`
+	Stages        []StageReport `json:"stages"`
+}
+
+// Stage returns the report for stage
+func (r *Report) Stage(stage string) (StageReport, bool) {
+	for _, s := range r.Stages {
+		if s.Stage == stage {
+			return s, true
+		}
+	}
+	return StageReport{}, false
+}
+
+// Report builds the run report from everything recorded so far
+func (r *Recorder) Report(runID string) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rep := &Report{
+		RunID:         runID,
+		Published:     r.publishCount,
+		PublishErrors: r.publishErrors,
+	}
+	if elapsed := r.stoppedAt.Sub(r.startedAt).Seconds(); elapsed > 0 {
+		rep.PublishRate = float64(r.publishCount) / elapsed
+	}
+
+	for _, stage := range Stages {
+		lat := append([]time.Duration(nil), r.latencies[stage]...)
+		sort.Slice(lat, func(i, j int) bool { return lat[i] < lat[j] })
+
+		s := StageReport{
+			Stage: stage,
+			Count: len(lat),
+			P50Ms: millis(Percentile(lat, 50)),
+			P90Ms: millis(Percentile(lat, 90)),
+			P99Ms: millis(Percentile(lat, 99)),
+			MaxMs: millis(Percentile(lat, 100)),
+		}
+		if window := r.lastAt[stage].Sub(r.firstAt[stage]).Seconds(); window > 0 {
+			s.Throughput = float64(len(lat)) / window
+		}
+		rep.Stages = append(rep.Stages, s)
+	}
+	return rep
+}
+
+// Percentile returns the nearest-rank pth percentile of sorted durations, or
+// zero when there are none
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// millis converts a duration to fractional milliseconds
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
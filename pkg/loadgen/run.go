@@ -0,0 +1,211 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// approveRetryInterval is how long to wait before retrying an approval for a
+// proposal the gateway has not stored yet
+const approveRetryInterval = 250 * time.Millisecond
+
+// Run publishes synthetic detections for opts.Duration, keeps observing the
+// pipeline for opts.Settle, and returns the report. Detections are signed
+// with signer, whose keys are registered under opts.SensorID(); a nil signer
+// publishes them unsigned.
+func Run(ctx context.Context, nc *nats.Conn, js jetstream.JetStream, signer *signing.Signer, opts Options, logger zerolog.Logger) (*Report, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	rec := NewRecorder(opts)
+	approver := newApprover(opts, logger)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Observe with core subscriptions so the run leaves no consumers behind
+	for _, stage := range Stages {
+		stage := stage
+		sub, err := nc.Subscribe(StageSubjects[stage], func(msg *nats.Msg) {
+			data, err := messages.ToJSON(messages.SchemaForSubject(msg.Subject), msg.Header.Get(messages.ContentTypeHeader), msg.Data)
+			if err != nil {
+				return
+			}
+			proposalID, _ := rec.Observe(stage, data, time.Now())
+			if proposalID != "" && approver != nil {
+				approver.approve(runCtx, proposalID)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", StageSubjects[stage], err)
+		}
+		defer sub.Unsubscribe()
+	}
+	if err := nc.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush subscriptions: %w", err)
+	}
+
+	gen := NewGenerator(opts, time.Now().UnixNano())
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+	defer ticker.Stop()
+	deadline := time.After(opts.Duration)
+
+	logger.Info().
+		Str("run_id", opts.RunID).
+		Float64("rate", opts.Rate).
+		Dur("duration", opts.Duration).
+		Int("tracks", opts.Tracks).
+		Msg("Publishing synthetic detections")
+
+publish:
+	for {
+		select {
+		case <-ctx.Done():
+			break publish
+		case <-deadline:
+			break publish
+		case <-ticker.C:
+			det := gen.Next()
+			var key *signing.Key
+			if signer != nil {
+				key = signer.Key()
+				det.Envelope.SigningKeyID = key.KeyID
+			}
+			data, err := json.Marshal(det)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal detection: %w", err)
+			}
+			m := nats.NewMsg(det.Subject())
+			m.Data = data
+			signing.SignMsg(m, key)
+			_, err = js.PublishMsg(ctx, m, jetstream.WithMsgID(det.Envelope.MessageID))
+			rec.Published(det, time.Now(), err)
+			if err != nil {
+				logger.Debug().Err(err).Str("track_id", det.TrackID).Msg("Failed to publish detection")
+			}
+		}
+	}
+
+	logger.Info().Dur("settle", opts.Settle).Msg("Publishing stopped; waiting for the pipeline to settle")
+	select {
+	case <-ctx.Done():
+	case <-time.After(opts.Settle):
+	}
+
+	cancel()
+	report := rec.Report(opts.RunID)
+	if approver != nil {
+		approver.wait()
+		report.Approved, report.ApproveErrors = approver.counts()
+	}
+	return report, nil
+}
+
+// approver approves proposals for synthetic tracks through the gateway
+type approver struct {
+	url    string
+	by     string
+	client *http.Client
+	logger zerolog.Logger
+
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	approved int
+	errors   int
+}
+
+// newApprover returns an approver, or nil when approval is not configured
+func newApprover(opts Options, logger zerolog.Logger) *approver {
+	if opts.ApproveURL == "" {
+		return nil
+	}
+	return &approver{
+		url:    strings.TrimRight(opts.ApproveURL, "/"),
+		by:     opts.ApprovedBy,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+// approve approves proposalID in the background, retrying while the gateway
+// has not stored the proposal yet
+func (a *approver) approve(ctx context.Context, proposalID string) {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		for {
+			status, err := a.post(ctx, proposalID)
+			if err == nil && status == http.StatusNotFound {
+				select {
+				case <-ctx.Done():
+				case <-time.After(approveRetryInterval):
+					continue
+				}
+			}
+
+			a.mu.Lock()
+			if err == nil && status == http.StatusOK {
+				a.approved++
+			} else {
+				a.errors++
+			}
+			a.mu.Unlock()
+
+			if err != nil || status != http.StatusOK {
+				a.logger.Debug().Err(err).Int("status", status).Str("proposal_id", proposalID).Msg("Failed to approve proposal")
+			}
+			return
+		}
+	}()
+}
+
+// post sends one approval and returns the response status
+func (a *approver) post(ctx context.Context, proposalID string) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"approved":    true,
+		"approved_by": a.by,
+		"reason":      "loadgen auto-approval",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal approval: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url+"/api/v1/proposals/"+proposalID+"/decide", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to approve proposal: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// wait blocks until every approval has finished
+func (a *approver) wait() {
+	a.wg.Wait()
+}
+
+// counts returns the approvals made and failed
+func (a *approver) counts() (int, int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.approved, a.errors
+}
@@ -0,0 +1,64 @@
+package messages
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeadLetter records a message a consumer refused to process, so it can be
+// inspected and replayed once the producer is fixed. It is published to the
+// DLQ stream on dlq.<consumer>.<schema>.
+type DeadLetter struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Where the message came from
+	OriginalSubject string `json:"original_subject"` // Subject the message was published on
+	Stream          string `json:"stream"`           // Stream it was consumed from
+	Consumer        string `json:"consumer"`         // Durable consumer that refused it
+
+	// Why it was refused
+	Schema           string   `json:"schema"`
+	Error            string   `json:"error"`
+	ValidationErrors []string `json:"validation_errors,omitempty"` // One entry per violated schema rule
+
+	Payload     []byte    `json:"payload"`                // Original message bytes, which may not be JSON
+	ContentType string    `json:"content_type,omitempty"` // Content-Type header of the original message
+	RejectedAt  time.Time `json:"rejected_at"`
+}
+
+func (dl *DeadLetter) GetEnvelope() Envelope {
+	return dl.Envelope
+}
+
+func (dl *DeadLetter) SetEnvelope(e Envelope) {
+	dl.Envelope = e
+}
+
+func (dl *DeadLetter) Subject() string {
+	return "dlq." + dl.Consumer + "." + dl.Schema
+}
+
+// NewDeadLetter creates a dead letter for a refused payload. The envelope
+// continues the original message's correlation chain when its envelope can
+// still be read.
+func NewDeadLetter(source, sourceType, schema string, payload []byte, cause error) *DeadLetter {
+	envelope := NewEnvelope(source, sourceType)
+	var original struct {
+		Envelope Envelope `json:"envelope"`
+	}
+	if json.Unmarshal(payload, &original) == nil {
+		envelope = envelope.WithCorrelation(original.Envelope.CorrelationID, original.Envelope.MessageID)
+	}
+
+	dl := &DeadLetter{
+		Envelope:   envelope,
+		Schema:     schema,
+		Error:      cause.Error(),
+		Payload:    payload,
+		RejectedAt: envelope.Timestamp,
+	}
+	if verr, ok := cause.(*ValidationError); ok {
+		dl.ValidationErrors = verr.Errors
+	}
+	return dl
+}
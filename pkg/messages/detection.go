@@ -15,6 +15,10 @@ type Detection struct {
 	SensorType string   `json:"sensor_type"`           // radar, eo, sigint, etc.
 	SensorID   string   `json:"sensor_id"`             // Sensor that made detection
 	RawData    []byte   `json:"raw_data,omitempty"`
+
+	// Classification reported with the detection; only manual contacts
+	// entered by an operator carry one
+	Classification string `json:"classification,omitempty"`
 }
 
 func (d *Detection) GetEnvelope() Envelope {
@@ -29,6 +33,10 @@ func (d *Detection) Subject() string {
 	return "detect." + d.SensorID + "." + d.SensorType
 }
 
+// ManualSensorType is the sensor type of contacts entered by operators
+// through the API rather than reported by a sensor
+const ManualSensorType = "manual"
+
 // NewDetection creates a new detection message
 func NewDetection(sensorID, sensorType string) *Detection {
 	return &Detection{
@@ -72,12 +80,17 @@ func (t *Track) Subject() string {
 	return "track.classified." + t.Classification
 }
 
+// TrackHandoffPrefix begins the subjects of classified tracks handed off to a
+// neighbouring geographic cell for sharded correlators
+const TrackHandoffPrefix = "track.handoff."
+
 // NewTrack creates a new track from a detection
 func NewTrack(det *Detection, classifierID string) *Track {
 	now := time.Now().UTC()
 	return &Track{
 		Envelope: NewEnvelope(classifierID, "classifier").
-			WithCorrelation(det.Envelope.CorrelationID, det.Envelope.MessageID),
+			WithCorrelation(det.Envelope.CorrelationID, det.Envelope.MessageID).
+			WithLabels(det.Envelope),
 		TrackID:        det.TrackID,
 		Classification: "unknown",
 		Type:           "unknown",
@@ -106,6 +119,10 @@ type CorrelatedTrack struct {
 	Velocity    Velocity `json:"velocity"`
 	Confidence  float64  `json:"confidence"`  // Fused confidence
 	ThreatLevel string   `json:"threat_level"` // low, medium, high, critical
+	ThreatScore float64  `json:"threat_score"` // Weighted score from the threat scoring engine
+
+	// Geometry relative to protected assets and restricted zones, most urgent first
+	ZoneProximity []ZoneProximity `json:"zone_proximity,omitempty"`
 
 	// Correlation window
 	WindowStart time.Time `json:"window_start"`
@@ -117,6 +134,18 @@ type CorrelatedTrack struct {
 	Sources        []string `json:"sources"`
 }
 
+// ZoneProximity describes a track's projected approach to a protected asset or restricted zone
+type ZoneProximity struct {
+	ZoneID            string   `json:"zone_id"`
+	ZoneName          string   `json:"zone_name"`
+	ZoneKind          string   `json:"zone_kind"`                      // protected_asset, restricted_zone
+	Inside            bool     `json:"inside"`                         // Track is currently inside the zone
+	DistanceMeters    float64  `json:"distance_meters"`                // Current distance to the zone boundary
+	CPAMeters         float64  `json:"cpa_meters"`                     // Closest point of approach on current course
+	TimeToCPASeconds  float64  `json:"time_to_cpa_seconds"`            // Time until closest point of approach
+	TimeToZoneSeconds *float64 `json:"time_to_zone_seconds,omitempty"` // Time until zone entry, nil if course does not enter
+}
+
 func (ct *CorrelatedTrack) GetEnvelope() Envelope {
 	return ct.Envelope
 }
@@ -134,7 +163,9 @@ func NewCorrelatedTrack(track *Track, correlatorID string) *CorrelatedTrack {
 	now := time.Now().UTC()
 	return &CorrelatedTrack{
 		Envelope: NewEnvelope(correlatorID, "correlator").
-			WithCorrelation(track.Envelope.CorrelationID, track.Envelope.MessageID),
+			WithCorrelation(track.Envelope.CorrelationID, track.Envelope.MessageID).
+			WithLabels(track.Envelope).
+			WithOrigins(track.Envelope),
 		TrackID:        track.TrackID,
 		MergedFrom:     []string{track.TrackID},
 		Classification: track.Classification,
@@ -150,3 +181,84 @@ func NewCorrelatedTrack(track *Track, correlatorID string) *CorrelatedTrack {
 		Sources:        track.Sources,
 	}
 }
+
+// TrackLifecycle is published when a track ages out of the active picture
+// because no detections have arrived for it
+type TrackLifecycle struct {
+	Envelope Envelope `json:"envelope"`
+
+	TrackID        string    `json:"track_id"`
+	Classification string    `json:"classification"`
+	Type           string    `json:"type"`
+	ThreatLevel    string    `json:"threat_level"`
+	PreviousState  string    `json:"previous_state"`
+	State          string    `json:"state"` // stale, dropped
+	LastUpdated    time.Time `json:"last_updated"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+func (tl *TrackLifecycle) GetEnvelope() Envelope {
+	return tl.Envelope
+}
+
+func (tl *TrackLifecycle) SetEnvelope(e Envelope) {
+	tl.Envelope = e
+}
+
+func (tl *TrackLifecycle) Subject() string {
+	return "track.lifecycle." + tl.State
+}
+
+// Subjects of track merge and split events
+const (
+	TrackMergedSubject = "track.merged"
+	TrackSplitSubject  = "track.split"
+)
+
+// TrackMerge is published when the correlator fuses tracks reported under
+// different system track IDs. The fused track keeps TrackID; the superseded
+// IDs are not reported again.
+type TrackMerge struct {
+	Envelope Envelope `json:"envelope"`
+
+	TrackID            string    `json:"track_id"`             // Surviving system track ID
+	SupersededTrackIDs []string  `json:"superseded_track_ids"` // System track IDs merged into it
+	SensorTrackIDs     []string  `json:"sensor_track_ids"`     // Sensor tracks now reported under TrackID
+	MergedAt           time.Time `json:"merged_at"`
+}
+
+func (tm *TrackMerge) GetEnvelope() Envelope {
+	return tm.Envelope
+}
+
+func (tm *TrackMerge) SetEnvelope(e Envelope) {
+	tm.Envelope = e
+}
+
+func (tm *TrackMerge) Subject() string {
+	return TrackMergedSubject
+}
+
+// TrackSplit is published when a sensor track stops correlating with the
+// others sharing its system track ID and is reported under a new one. The
+// track it split from keeps its ID.
+type TrackSplit struct {
+	Envelope Envelope `json:"envelope"`
+
+	TrackID       string    `json:"track_id"`   // New system track ID
+	SplitFrom     string    `json:"split_from"` // System track ID the sensor track was reported under
+	SensorTrackID string    `json:"sensor_track_id"`
+	SplitAt       time.Time `json:"split_at"`
+}
+
+func (ts *TrackSplit) GetEnvelope() Envelope {
+	return ts.Envelope
+}
+
+func (ts *TrackSplit) SetEnvelope(e Envelope) {
+	ts.Envelope = e
+}
+
+func (ts *TrackSplit) Subject() string {
+	return TrackSplitSubject
+}
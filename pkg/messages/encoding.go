@@ -0,0 +1,708 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/agile-defense/cjadc2/pkg/messages/pb"
+)
+
+// ContentTypeHeader is the NATS header naming a message's encoding. Messages
+// without it are JSON, as every message was before protobuf was added.
+const ContentTypeHeader = "Content-Type"
+
+// Content types of encoded messages
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// Encoding selects the wire format an agent publishes pipeline messages in
+type Encoding string
+
+const (
+	EncodingJSON     Encoding = "json"
+	EncodingProtobuf Encoding = "protobuf"
+)
+
+// ParseEncoding parses a MESSAGE_ENCODING setting; empty selects JSON
+func ParseEncoding(s string) (Encoding, error) {
+	switch Encoding(strings.ToLower(strings.TrimSpace(s))) {
+	case "", EncodingJSON:
+		return EncodingJSON, nil
+	case EncodingProtobuf:
+		return EncodingProtobuf, nil
+	}
+	return "", fmt.Errorf("invalid message encoding %q: must be json or protobuf", s)
+}
+
+// ContentType returns the Content-Type header value of the encoding
+func (e Encoding) ContentType() string {
+	if e == EncodingProtobuf {
+		return ContentTypeProtobuf
+	}
+	return ContentTypeJSON
+}
+
+// IsProtobuf reports whether a Content-Type header names the protobuf encoding
+func IsProtobuf(contentType string) bool {
+	return contentType == ContentTypeProtobuf
+}
+
+// Marshal encodes a message and returns its content type. Messages without a
+// protobuf version, such as notifications, are always JSON.
+func Marshal(msg Message, enc Encoding) ([]byte, string, error) {
+	if enc == EncodingProtobuf {
+		if p := toProto(msg); p != nil {
+			data, err := proto.Marshal(p)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to marshal %T as protobuf: %w", msg, err)
+			}
+			return data, ContentTypeProtobuf, nil
+		}
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, ContentTypeJSON, nil
+}
+
+// Unmarshal decodes a message in the given content type; an empty content
+// type is JSON. Sealed messages are opened with the installed payload keys.
+// Messages of an older schema version are upgraded to the current one.
+func Unmarshal(contentType string, data []byte, msg Message) error {
+	if IsSealed(contentType) {
+		return unmarshalSealed(data, msg)
+	}
+	if IsProtobuf(contentType) {
+		if err := unmarshalProtobuf(data, msg); err != nil {
+			return err
+		}
+		return upgradeMessage(msg)
+	}
+
+	if err := json.Unmarshal(data, msg); err != nil {
+		return err
+	}
+	schema := schemaOf(msg)
+	if schema == "" || msg.GetEnvelope().Version() >= CurrentSchemaVersion {
+		return nil
+	}
+	upgraded, err := Upgrade(schema, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, msg)
+}
+
+// unmarshalProtobuf decodes a protobuf message into its struct
+func unmarshalProtobuf(data []byte, msg Message) error {
+
+	switch m := msg.(type) {
+	case *Detection:
+		var p pb.Detection
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *detectionFromProto(&p)
+	case *Track:
+		var p pb.Track
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *trackFromProto(&p)
+	case *CorrelatedTrack:
+		var p pb.CorrelatedTrack
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *correlatedTrackFromProto(&p)
+	case *ActionProposal:
+		var p pb.ActionProposal
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *proposalFromProto(&p)
+	case *Decision:
+		var p pb.Decision
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *decisionFromProto(&p)
+	case *EffectLog:
+		var p pb.EffectLog
+		if err := proto.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = *effectLogFromProto(&p)
+	default:
+		return fmt.Errorf("no protobuf encoding for %T", msg)
+	}
+	return nil
+}
+
+// newSchemaMessage returns an empty message of the named schema
+func newSchemaMessage(schema string) Message {
+	switch schema {
+	case SchemaDetection:
+		return &Detection{}
+	case SchemaTrack:
+		return &Track{}
+	case SchemaCorrelatedTrack:
+		return &CorrelatedTrack{}
+	case SchemaActionProposal:
+		return &ActionProposal{}
+	case SchemaDecision:
+		return &Decision{}
+	case SchemaEffectLog:
+		return &EffectLog{}
+	}
+	return nil
+}
+
+// SchemaForSubject returns the schema of the pipeline messages published on
+// a subject, or empty for subjects outside the pipeline
+func SchemaForSubject(subject string) string {
+	switch {
+	case strings.HasPrefix(subject, "detect."):
+		return SchemaDetection
+	case strings.HasPrefix(subject, "track.classified."), strings.HasPrefix(subject, TrackHandoffPrefix):
+		return SchemaTrack
+	case strings.HasPrefix(subject, "track.correlated."):
+		return SchemaCorrelatedTrack
+	case strings.HasPrefix(subject, "proposal."):
+		return SchemaActionProposal
+	case strings.HasPrefix(subject, DecisionRevokedPrefix):
+		return "" // Revocations are JSON-only control messages
+	case strings.HasPrefix(subject, "decision."):
+		return SchemaDecision
+	case strings.HasPrefix(subject, "effect."):
+		return SchemaEffectLog
+	}
+	return ""
+}
+
+// ToJSON returns a message of the named schema as JSON, opening it if it is
+// sealed and transcoding it if it is protobuf. JSON payloads are returned
+// unchanged.
+func ToJSON(schema, contentType string, data []byte) ([]byte, error) {
+	if IsSealed(contentType) {
+		_, inner, plaintext, err := openSealed(data)
+		if err != nil {
+			return nil, err
+		}
+		contentType, data = inner, plaintext
+	}
+	if !IsProtobuf(contentType) {
+		return data, nil
+	}
+	msg := newSchemaMessage(schema)
+	if msg == nil {
+		return nil, fmt.Errorf("no protobuf encoding for schema %q", schema)
+	}
+	if err := Unmarshal(contentType, data, msg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", schema, err)
+	}
+	return json.Marshal(msg)
+}
+
+// EnvelopeOf decodes the envelope of a message of the named schema in any
+// encoding. A sealed message is not opened: its envelope travels in the clear.
+func EnvelopeOf(schema, contentType string, data []byte) (Envelope, error) {
+	if IsSealed(contentType) {
+		var sealed SealedMessage
+		if err := json.Unmarshal(data, &sealed); err != nil {
+			return Envelope{}, fmt.Errorf("failed to decode sealed message: %w", err)
+		}
+		return sealed.Envelope, nil
+	}
+	if IsProtobuf(contentType) {
+		msg := newSchemaMessage(schema)
+		if msg == nil {
+			return Envelope{}, fmt.Errorf("no protobuf encoding for schema %q", schema)
+		}
+		if err := unmarshalProtobuf(data, msg); err != nil {
+			return Envelope{}, fmt.Errorf("failed to decode %s: %w", schema, err)
+		}
+		return msg.GetEnvelope(), nil
+	}
+	var msg BaseMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Envelope{}, err
+	}
+	return msg.Envelope, nil
+}
+
+// ValidateEncoded checks a message in either encoding against its schema.
+// Sealed payloads are opened first, protobuf payloads are decoded and checked
+// as the JSON they convert to, and payloads of an older schema version are
+// checked as they upgrade.
+func ValidateEncoded(schema, contentType string, data []byte) error {
+	if _, ok := schemas[schema]; !ok {
+		return fmt.Errorf("unknown message schema %q", schema)
+	}
+	if IsSealed(contentType) {
+		_, inner, plaintext, err := openSealed(data)
+		if err != nil {
+			return &ValidationError{Schema: schema, Errors: []string{"sealed payload cannot be opened: " + err.Error()}}
+		}
+		contentType, data = inner, plaintext
+	}
+	if !IsProtobuf(contentType) {
+		if upgraded, err := Upgrade(schema, data); err == nil {
+			data = upgraded
+		}
+		return Validate(schema, data)
+	}
+	converted, err := ToJSON(schema, contentType, data)
+	if err != nil {
+		return &ValidationError{Schema: schema, Errors: []string{"payload is not valid protobuf"}}
+	}
+	return Validate(schema, converted)
+}
+
+// toProto converts a message to its protobuf version, nil if it has none
+func toProto(msg Message) proto.Message {
+	switch m := msg.(type) {
+	case *Detection:
+		return detectionToProto(m)
+	case *Track:
+		return trackToProto(m)
+	case *CorrelatedTrack:
+		return correlatedTrackToProto(m)
+	case *ActionProposal:
+		return proposalToProto(m)
+	case *Decision:
+		return decisionToProto(m)
+	case *EffectLog:
+		return effectLogToProto(m)
+	}
+	return nil
+}
+
+// timestampToProto leaves zero times unset so they decode back to zero
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func envelopeToProto(e Envelope) *pb.Envelope {
+	return &pb.Envelope{
+		MessageId:      e.MessageID,
+		CorrelationId:  e.CorrelationID,
+		CausationId:    e.CausationID,
+		Source:         e.Source,
+		SourceType:     e.SourceType,
+		Timestamp:      timestampToProto(e.Timestamp),
+		PolicyVersion:  e.PolicyVersion,
+		TraceId:        e.TraceID,
+		SpanId:         e.SpanID,
+		SchemaVersion:  int32(e.SchemaVersion),
+		Classification: e.Classification,
+		KeyId:          e.KeyID,
+		Releasability:  e.Releasability,
+		Origins:        e.Origins,
+		SigningKeyId:   e.SigningKeyID,
+	}
+}
+
+// envelopeFromProto reads an envelope. Protobuf was added in schema version
+// 2, so an envelope without a version is version 2 rather than 1.
+func envelopeFromProto(p *pb.Envelope) Envelope {
+	version := int(p.GetSchemaVersion())
+	if version == 0 {
+		version = firstProtobufSchemaVersion
+	}
+	return Envelope{
+		MessageID:      p.GetMessageId(),
+		CorrelationID:  p.GetCorrelationId(),
+		CausationID:    p.GetCausationId(),
+		Source:         p.GetSource(),
+		SourceType:     p.GetSourceType(),
+		Timestamp:      timestampFromProto(p.GetTimestamp()),
+		PolicyVersion:  p.GetPolicyVersion(),
+		TraceID:        p.GetTraceId(),
+		SpanID:         p.GetSpanId(),
+		SchemaVersion:  version,
+		Classification: p.GetClassification(),
+		KeyID:          p.GetKeyId(),
+		Releasability:  p.GetReleasability(),
+		Origins:        p.GetOrigins(),
+		SigningKeyID:   p.GetSigningKeyId(),
+	}
+}
+
+func positionToProto(p Position) *pb.Position {
+	return &pb.Position{Lat: p.Lat, Lon: p.Lon, Alt: p.Alt}
+}
+
+func positionFromProto(p *pb.Position) Position {
+	return Position{Lat: p.GetLat(), Lon: p.GetLon(), Alt: p.GetAlt()}
+}
+
+func velocityToProto(v Velocity) *pb.Velocity {
+	return &pb.Velocity{Speed: v.Speed, Heading: v.Heading}
+}
+
+func velocityFromProto(p *pb.Velocity) Velocity {
+	return Velocity{Speed: p.GetSpeed(), Heading: p.GetHeading()}
+}
+
+func detectionToProto(d *Detection) *pb.Detection {
+	return &pb.Detection{
+		Envelope:       envelopeToProto(d.Envelope),
+		TrackId:        d.TrackID,
+		Type:           d.Type,
+		Position:       positionToProto(d.Position),
+		Velocity:       velocityToProto(d.Velocity),
+		Confidence:     d.Confidence,
+		SensorType:     d.SensorType,
+		SensorId:       d.SensorID,
+		RawData:        d.RawData,
+		Classification: d.Classification,
+	}
+}
+
+func detectionFromProto(p *pb.Detection) *Detection {
+	return &Detection{
+		Envelope:       envelopeFromProto(p.GetEnvelope()),
+		TrackID:        p.GetTrackId(),
+		Type:           p.GetType(),
+		Position:       positionFromProto(p.GetPosition()),
+		Velocity:       velocityFromProto(p.GetVelocity()),
+		Confidence:     p.GetConfidence(),
+		SensorType:     p.GetSensorType(),
+		SensorID:       p.GetSensorId(),
+		RawData:        p.GetRawData(),
+		Classification: p.GetClassification(),
+	}
+}
+
+func trackToProto(t *Track) *pb.Track {
+	return &pb.Track{
+		Envelope:       envelopeToProto(t.Envelope),
+		TrackId:        t.TrackID,
+		Classification: t.Classification,
+		Type:           t.Type,
+		Position:       positionToProto(t.Position),
+		Velocity:       velocityToProto(t.Velocity),
+		Confidence:     t.Confidence,
+		FirstSeen:      timestampToProto(t.FirstSeen),
+		LastUpdated:    timestampToProto(t.LastUpdated),
+		DetectionCount: int64(t.DetectionCount),
+		Sources:        t.Sources,
+	}
+}
+
+func trackFromProto(p *pb.Track) *Track {
+	return &Track{
+		Envelope:       envelopeFromProto(p.GetEnvelope()),
+		TrackID:        p.GetTrackId(),
+		Classification: p.GetClassification(),
+		Type:           p.GetType(),
+		Position:       positionFromProto(p.GetPosition()),
+		Velocity:       velocityFromProto(p.GetVelocity()),
+		Confidence:     p.GetConfidence(),
+		FirstSeen:      timestampFromProto(p.GetFirstSeen()),
+		LastUpdated:    timestampFromProto(p.GetLastUpdated()),
+		DetectionCount: int(p.GetDetectionCount()),
+		Sources:        p.GetSources(),
+	}
+}
+
+func correlatedTrackToProto(ct *CorrelatedTrack) *pb.CorrelatedTrack {
+	p := &pb.CorrelatedTrack{
+		Envelope:       envelopeToProto(ct.Envelope),
+		TrackId:        ct.TrackID,
+		MergedFrom:     ct.MergedFrom,
+		Classification: ct.Classification,
+		Type:           ct.Type,
+		Position:       positionToProto(ct.Position),
+		Velocity:       velocityToProto(ct.Velocity),
+		Confidence:     ct.Confidence,
+		ThreatLevel:    ct.ThreatLevel,
+		ThreatScore:    ct.ThreatScore,
+		WindowStart:    timestampToProto(ct.WindowStart),
+		WindowEnd:      timestampToProto(ct.WindowEnd),
+		LastUpdated:    timestampToProto(ct.LastUpdated),
+		DetectionCount: int64(ct.DetectionCount),
+		Sources:        ct.Sources,
+	}
+	for _, z := range ct.ZoneProximity {
+		p.ZoneProximity = append(p.ZoneProximity, &pb.ZoneProximity{
+			ZoneId:            z.ZoneID,
+			ZoneName:          z.ZoneName,
+			ZoneKind:          z.ZoneKind,
+			Inside:            z.Inside,
+			DistanceMeters:    z.DistanceMeters,
+			CpaMeters:         z.CPAMeters,
+			TimeToCpaSeconds:  z.TimeToCPASeconds,
+			TimeToZoneSeconds: z.TimeToZoneSeconds,
+		})
+	}
+	return p
+}
+
+func correlatedTrackFromProto(p *pb.CorrelatedTrack) *CorrelatedTrack {
+	ct := &CorrelatedTrack{
+		Envelope:       envelopeFromProto(p.GetEnvelope()),
+		TrackID:        p.GetTrackId(),
+		MergedFrom:     p.GetMergedFrom(),
+		Classification: p.GetClassification(),
+		Type:           p.GetType(),
+		Position:       positionFromProto(p.GetPosition()),
+		Velocity:       velocityFromProto(p.GetVelocity()),
+		Confidence:     p.GetConfidence(),
+		ThreatLevel:    p.GetThreatLevel(),
+		ThreatScore:    p.GetThreatScore(),
+		WindowStart:    timestampFromProto(p.GetWindowStart()),
+		WindowEnd:      timestampFromProto(p.GetWindowEnd()),
+		LastUpdated:    timestampFromProto(p.GetLastUpdated()),
+		DetectionCount: int(p.GetDetectionCount()),
+		Sources:        p.GetSources(),
+	}
+	for _, z := range p.GetZoneProximity() {
+		ct.ZoneProximity = append(ct.ZoneProximity, ZoneProximity{
+			ZoneID:            z.GetZoneId(),
+			ZoneName:          z.GetZoneName(),
+			ZoneKind:          z.GetZoneKind(),
+			Inside:            z.GetInside(),
+			DistanceMeters:    z.GetDistanceMeters(),
+			CPAMeters:         z.GetCpaMeters(),
+			TimeToCPASeconds:  z.GetTimeToCpaSeconds(),
+			TimeToZoneSeconds: z.TimeToZoneSeconds,
+		})
+	}
+	return ct
+}
+
+func proposalToProto(ap *ActionProposal) *pb.ActionProposal {
+	p := &pb.ActionProposal{
+		Envelope:    envelopeToProto(ap.Envelope),
+		ProposalId:  ap.ProposalID,
+		TrackId:     ap.TrackID,
+		ActionType:  ap.ActionType,
+		Priority:    int64(ap.Priority),
+		Rationale:   ap.Rationale,
+		Constraints: ap.Constraints,
+		ThreatLevel: ap.ThreatLevel,
+		ExpiresAt:   timestampToProto(ap.ExpiresAt),
+		HitCount:    int64(ap.HitCount),
+		LastHitAt:   timestampToProto(ap.LastHitAt),
+		PolicyDecision: &pb.PolicyDecision{
+			Allowed:    ap.PolicyDecision.Allowed,
+			Reasons:    ap.PolicyDecision.Reasons,
+			Violations: ap.PolicyDecision.Violations,
+			Warnings:   ap.PolicyDecision.Warnings,
+			Metadata:   ap.PolicyDecision.Metadata,
+		},
+	}
+	for _, o := range ap.Options {
+		p.Options = append(p.Options, &pb.CourseOfAction{
+			ActionType:  o.ActionType,
+			Rank:        int64(o.Rank),
+			Benefit:     o.Benefit,
+			Risk:        o.Risk,
+			Score:       o.Score,
+			Rationale:   o.Rationale,
+			Constraints: o.Constraints,
+		})
+	}
+	if ap.Asset != nil {
+		p.Asset = &pb.AssetAssignment{
+			AssetId:        ap.Asset.AssetID,
+			Name:           ap.Asset.Name,
+			Kind:           ap.Asset.Kind,
+			DistanceMeters: ap.Asset.DistanceMeters,
+		}
+	}
+	for _, c := range ap.Conflicts {
+		p.Conflicts = append(p.Conflicts, &pb.ProposalConflict{
+			ProposalId:     c.ProposalID,
+			TrackId:        c.TrackID,
+			ActionType:     c.ActionType,
+			Kind:           c.Kind,
+			AssetId:        c.AssetID,
+			DistanceMeters: c.DistanceMeters,
+		})
+	}
+	if ap.Track != nil {
+		p.Track = correlatedTrackToProto(ap.Track)
+	}
+	return p
+}
+
+func proposalFromProto(p *pb.ActionProposal) *ActionProposal {
+	policy := p.GetPolicyDecision()
+	ap := &ActionProposal{
+		Envelope:    envelopeFromProto(p.GetEnvelope()),
+		ProposalID:  p.GetProposalId(),
+		TrackID:     p.GetTrackId(),
+		ActionType:  p.GetActionType(),
+		Priority:    int(p.GetPriority()),
+		Rationale:   p.GetRationale(),
+		Constraints: p.GetConstraints(),
+		ThreatLevel: p.GetThreatLevel(),
+		ExpiresAt:   timestampFromProto(p.GetExpiresAt()),
+		HitCount:    int(p.GetHitCount()),
+		LastHitAt:   timestampFromProto(p.GetLastHitAt()),
+		PolicyDecision: PolicyDecision{
+			Allowed:    policy.GetAllowed(),
+			Reasons:    policy.GetReasons(),
+			Violations: policy.GetViolations(),
+			Warnings:   policy.GetWarnings(),
+			Metadata:   policy.GetMetadata(),
+		},
+	}
+	for _, o := range p.GetOptions() {
+		ap.Options = append(ap.Options, CourseOfAction{
+			ActionType:  o.GetActionType(),
+			Rank:        int(o.GetRank()),
+			Benefit:     o.GetBenefit(),
+			Risk:        o.GetRisk(),
+			Score:       o.GetScore(),
+			Rationale:   o.GetRationale(),
+			Constraints: o.GetConstraints(),
+		})
+	}
+	if asset := p.GetAsset(); asset != nil {
+		ap.Asset = &AssetAssignment{
+			AssetID:        asset.GetAssetId(),
+			Name:           asset.GetName(),
+			Kind:           asset.GetKind(),
+			DistanceMeters: asset.GetDistanceMeters(),
+		}
+	}
+	for _, c := range p.GetConflicts() {
+		ap.Conflicts = append(ap.Conflicts, ProposalConflict{
+			ProposalID:     c.GetProposalId(),
+			TrackID:        c.GetTrackId(),
+			ActionType:     c.GetActionType(),
+			Kind:           c.GetKind(),
+			AssetID:        c.GetAssetId(),
+			DistanceMeters: c.GetDistanceMeters(),
+		})
+	}
+	if p.GetTrack() != nil {
+		ap.Track = correlatedTrackFromProto(p.GetTrack())
+	}
+	return ap
+}
+
+func decisionToProto(d *Decision) *pb.Decision {
+	p := &pb.Decision{
+		Envelope:          envelopeToProto(d.Envelope),
+		DecisionId:        d.DecisionID,
+		ProposalId:        d.ProposalID,
+		Approved:          d.Approved,
+		ApprovedBy:        d.ApprovedBy,
+		ApprovedAt:        timestampToProto(d.ApprovedAt),
+		Reason:            d.Reason,
+		Conditions:        d.Conditions,
+		ActionType:        d.ActionType,
+		TrackId:           d.TrackID,
+		BreakGlassGrantId: d.BreakGlassGrantID,
+		SelectedOption:    d.SelectedOption,
+		MachineApproved:   d.MachineApproved,
+		AutoApproveRuleId: d.AutoApproveRuleID,
+	}
+	for _, a := range d.Approvals {
+		p.Approvals = append(p.Approvals, &pb.Approval{
+			ApprovedBy:        a.ApprovedBy,
+			Role:              a.Role,
+			ApprovedAt:        timestampToProto(a.ApprovedAt),
+			Reason:            a.Reason,
+			BreakGlassGrantId: a.BreakGlassGrantID,
+			Option:            a.Option,
+		})
+	}
+	return p
+}
+
+func decisionFromProto(p *pb.Decision) *Decision {
+	d := &Decision{
+		Envelope:          envelopeFromProto(p.GetEnvelope()),
+		DecisionID:        p.GetDecisionId(),
+		ProposalID:        p.GetProposalId(),
+		Approved:          p.GetApproved(),
+		ApprovedBy:        p.GetApprovedBy(),
+		ApprovedAt:        timestampFromProto(p.GetApprovedAt()),
+		Reason:            p.GetReason(),
+		Conditions:        p.GetConditions(),
+		ActionType:        p.GetActionType(),
+		TrackID:           p.GetTrackId(),
+		BreakGlassGrantID: p.GetBreakGlassGrantId(),
+		SelectedOption:    p.GetSelectedOption(),
+		MachineApproved:   p.GetMachineApproved(),
+		AutoApproveRuleID: p.GetAutoApproveRuleId(),
+	}
+	for _, a := range p.GetApprovals() {
+		d.Approvals = append(d.Approvals, Approval{
+			ApprovedBy:        a.GetApprovedBy(),
+			Role:              a.GetRole(),
+			ApprovedAt:        timestampFromProto(a.GetApprovedAt()),
+			Reason:            a.GetReason(),
+			BreakGlassGrantID: a.GetBreakGlassGrantId(),
+			Option:            a.GetOption(),
+		})
+	}
+	return d
+}
+
+func effectLogToProto(el *EffectLog) *pb.EffectLog {
+	return &pb.EffectLog{
+		Envelope:      envelopeToProto(el.Envelope),
+		EffectId:      el.EffectID,
+		DecisionId:    el.DecisionID,
+		ProposalId:    el.ProposalID,
+		TrackId:       el.TrackID,
+		ActionType:    el.ActionType,
+		Status:        el.Status,
+		ExecutedAt:    timestampToProto(el.ExecutedAt),
+		Result:        el.Result,
+		IdempotentKey: el.IdempotentKey,
+		Idempotent:    el.Idempotent,
+		FencingToken:  el.FencingToken,
+		Attempts:      int32(el.Attempts),
+		Outcome:       el.Outcome,
+		Collateral:    el.Collateral,
+		DurationMs:    el.DurationMs,
+	}
+}
+
+func effectLogFromProto(p *pb.EffectLog) *EffectLog {
+	return &EffectLog{
+		Envelope:      envelopeFromProto(p.GetEnvelope()),
+		EffectID:      p.GetEffectId(),
+		DecisionID:    p.GetDecisionId(),
+		ProposalID:    p.GetProposalId(),
+		TrackID:       p.GetTrackId(),
+		ActionType:    p.GetActionType(),
+		Status:        p.GetStatus(),
+		ExecutedAt:    timestampFromProto(p.GetExecutedAt()),
+		Result:        p.GetResult(),
+		IdempotentKey: p.GetIdempotentKey(),
+		Idempotent:    p.GetIdempotent(),
+		FencingToken:  p.GetFencingToken(),
+		Attempts:      int(p.GetAttempts()),
+		Outcome:       p.GetOutcome(),
+		Collateral:    p.GetCollateral(),
+		DurationMs:    p.GetDurationMs(),
+	}
+}
@@ -2,13 +2,11 @@
 package messages
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
 )
 
 // Envelope contains metadata common to all messages for tracing and security
@@ -22,25 +20,37 @@ type Envelope struct {
 	Source     string `json:"source"`      // Agent ID that sent this message
 	SourceType string `json:"source_type"` // Agent type (sensor, classifier, etc.)
 
+	// Federation
+	Origins []string `json:"origins,omitempty"` // Enclaves the message was federated through, the originating one first; empty for local data
+
 	// Timing
 	Timestamp time.Time `json:"timestamp"` // When message was created
 
 	// Security
-	Signature     string `json:"signature"`      // HMAC-SHA256 of payload
-	PolicyVersion string `json:"policy_version"` // OPA bundle version used
+	PolicyVersion string `json:"policy_version"`           // OPA bundle version used
+	SigningKeyID  string `json:"signing_key_id,omitempty"` // Source agent's key the message is signed with; the signature travels in a header
+
+	// Data handling
+	Classification string   `json:"classification,omitempty"` // Classification label; empty is unclassified
+	KeyID          string   `json:"key_id,omitempty"`         // Payload key that sealed the message; empty when sent in the clear
+	Releasability  []string `json:"releasability,omitempty"`  // Coalition partners the message may be released to, e.g. GBR or FVEY; empty releases to none
 
 	// Tracing (OpenTelemetry)
 	TraceID string `json:"trace_id,omitempty"`
 	SpanID  string `json:"span_id,omitempty"`
+
+	// Versioning
+	SchemaVersion int `json:"schema_version,omitempty"` // Message format version; missing means 1
 }
 
 // NewEnvelope creates a new envelope with generated IDs
 func NewEnvelope(source, sourceType string) Envelope {
 	return Envelope{
-		MessageID:  uuid.New().String(),
-		Source:     source,
-		SourceType: sourceType,
-		Timestamp:  time.Now().UTC(),
+		MessageID:     uuid.New().String(),
+		Source:        source,
+		SourceType:    sourceType,
+		Timestamp:     time.Now().UTC(),
+		SchemaVersion: CurrentSchemaVersion,
 	}
 }
 
@@ -51,26 +61,56 @@ func (e Envelope) WithCorrelation(correlationID, causationID string) Envelope {
 	return e
 }
 
-// WithTracing sets OpenTelemetry trace context
-func (e Envelope) WithTracing(traceID, spanID string) Envelope {
-	e.TraceID = traceID
-	e.SpanID = spanID
+// WithLabels copies the security labels of the message this one is derived
+// from, so a track stays as classified and releasable as its detection
+func (e Envelope) WithLabels(parent Envelope) Envelope {
+	e.Classification = parent.Classification
+	e.Releasability = append([]string(nil), parent.Releasability...)
+	return e
+}
+
+// WithMergedLabels combines the security labels of a message fused into this
+// one: the higher classification, releasable only where both are
+func (e Envelope) WithMergedLabels(other Envelope) Envelope {
+	e.Classification, e.Releasability = seclabel.Combine(e.Classification, e.Releasability, other.Classification, other.Releasability)
+	return e
+}
+
+// WithOrigins copies the enclaves the message this one is derived from was
+// federated through, so a track fused from a partner's report stays marked
+// as the partner's and is never federated back to it
+func (e Envelope) WithOrigins(parent Envelope) Envelope {
+	e.Origins = append([]string(nil), parent.Origins...)
 	return e
 }
 
-// Sign generates an HMAC signature for the message
-func (e *Envelope) Sign(payload []byte, secret []byte) {
-	h := hmac.New(sha256.New, secret)
-	h.Write(payload)
-	e.Signature = hex.EncodeToString(h.Sum(nil))
+// WithMergedOrigins adds the enclaves a message fused into this one was
+// federated through
+func (e Envelope) WithMergedOrigins(other Envelope) Envelope {
+	origins := append([]string(nil), e.Origins...)
+	for _, origin := range other.Origins {
+		known := false
+		for _, o := range origins {
+			known = known || o == origin
+		}
+		if !known {
+			origins = append(origins, origin)
+		}
+	}
+	e.Origins = origins
+	return e
+}
+
+// Federated reports whether the message was received from another enclave
+func (e Envelope) Federated() bool {
+	return len(e.Origins) > 0
 }
 
-// VerifySignature checks the HMAC signature
-func (e *Envelope) VerifySignature(payload []byte, secret []byte) bool {
-	expected := hmac.New(sha256.New, secret)
-	expected.Write(payload)
-	expectedSig := hex.EncodeToString(expected.Sum(nil))
-	return hmac.Equal([]byte(e.Signature), []byte(expectedSig))
+// WithTracing sets OpenTelemetry trace context
+func (e Envelope) WithTracing(traceID, spanID string) Envelope {
+	e.TraceID = traceID
+	e.SpanID = spanID
+	return e
 }
 
 // Message is an interface for all message types
@@ -93,20 +133,6 @@ func (m *BaseMessage) SetEnvelope(e Envelope) {
 	m.Envelope = e
 }
 
-// MarshalWithSignature marshals the message and signs it
-func MarshalWithSignature(msg Message, secret []byte) ([]byte, error) {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return nil, err
-	}
-
-	env := msg.GetEnvelope()
-	env.Sign(data, secret)
-	msg.SetEnvelope(env)
-
-	return json.Marshal(msg)
-}
-
 // PolicyDecision captures an OPA policy evaluation result
 type PolicyDecision struct {
 	Allowed    bool              `json:"allowed"`
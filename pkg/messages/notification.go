@@ -0,0 +1,360 @@
+package messages
+
+import "time"
+
+// Escalation levels for pending proposals
+const (
+	EscalationNone    = 0 // Less than half of the TTL has elapsed
+	EscalationWarning = 1 // At least 50% of the TTL has elapsed
+	EscalationUrgent  = 2 // At least 80% of the TTL has elapsed
+)
+
+// Fractions of a proposal's TTL at which it escalates
+const (
+	EscalationWarningFraction = 0.5
+	EscalationUrgentFraction  = 0.8
+)
+
+// TTLFractionElapsed returns how much of the window between createdAt and expiresAt
+// has passed at now, clamped to [0, 1]
+func TTLFractionElapsed(createdAt, expiresAt, now time.Time) float64 {
+	ttl := expiresAt.Sub(createdAt)
+	if ttl <= 0 {
+		return 1
+	}
+	fraction := float64(now.Sub(createdAt)) / float64(ttl)
+	if fraction < 0 {
+		return 0
+	}
+	if fraction > 1 {
+		return 1
+	}
+	return fraction
+}
+
+// EscalationLevelFor returns the escalation level for an elapsed TTL fraction
+func EscalationLevelFor(fraction float64) int {
+	switch {
+	case fraction >= EscalationUrgentFraction:
+		return EscalationUrgent
+	case fraction >= EscalationWarningFraction:
+		return EscalationWarning
+	default:
+		return EscalationNone
+	}
+}
+
+// Urgency returns the display name for an escalation level
+func Urgency(level int) string {
+	switch {
+	case level >= EscalationUrgent:
+		return "urgent"
+	case level == EscalationWarning:
+		return "warning"
+	default:
+		return "normal"
+	}
+}
+
+// ProposalEscalation is published when a pending proposal crosses an escalation threshold
+type ProposalEscalation struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	ProposalID     string `json:"proposal_id"`
+	TrackID        string `json:"track_id"`
+
+	// Proposal context
+	ActionType  string `json:"action_type"`
+	ThreatLevel string `json:"threat_level"`
+
+	// Escalation
+	EscalationLevel  int    `json:"escalation_level"`
+	Urgency          string `json:"urgency"` // warning, urgent
+	PreviousPriority int    `json:"previous_priority"`
+	Priority         int    `json:"priority"`
+
+	// Timing
+	TTLFractionElapsed   float64   `json:"ttl_fraction_elapsed"`
+	TimeRemainingSeconds float64   `json:"time_remaining_seconds"`
+	ExpiresAt            time.Time `json:"expires_at"`
+}
+
+func (pe *ProposalEscalation) GetEnvelope() Envelope {
+	return pe.Envelope
+}
+
+func (pe *ProposalEscalation) SetEnvelope(e Envelope) {
+	pe.Envelope = e
+}
+
+func (pe *ProposalEscalation) Subject() string {
+	return "notify.escalation." + pe.Urgency
+}
+
+// NewProposalEscalation creates an escalation notification for a pending proposal
+func NewProposalEscalation(proposal *ActionProposal, authorizerID string, level int) *ProposalEscalation {
+	return &ProposalEscalation{
+		Envelope: NewEnvelope(authorizerID, "authorizer").
+			WithCorrelation(proposal.Envelope.CorrelationID, proposal.Envelope.MessageID),
+		ProposalID:       proposal.ProposalID,
+		TrackID:          proposal.TrackID,
+		ActionType:       proposal.ActionType,
+		ThreatLevel:      proposal.ThreatLevel,
+		EscalationLevel:  level,
+		Urgency:          Urgency(level),
+		PreviousPriority: proposal.Priority,
+		Priority:         proposal.Priority,
+		ExpiresAt:        proposal.ExpiresAt,
+	}
+}
+
+// DecisionSLABreach is published when a pending proposal has waited longer
+// for a human decision than its priority's SLA allows
+type DecisionSLABreach struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	ProposalID     string `json:"proposal_id"`
+	TrackID        string `json:"track_id"`
+
+	// Proposal context
+	ActionType  string `json:"action_type"`
+	ThreatLevel string `json:"threat_level"`
+	Priority    int    `json:"priority"`
+
+	// SLA threshold that was breached
+	SLAMinPriority int     `json:"sla_min_priority"`
+	SLASeconds     float64 `json:"sla_seconds"`
+
+	// Timing
+	PendingSeconds       float64   `json:"pending_seconds"`
+	TimeRemainingSeconds float64   `json:"time_remaining_seconds"`
+	CreatedAt            time.Time `json:"created_at"`
+	ExpiresAt            time.Time `json:"expires_at"`
+}
+
+func (sb *DecisionSLABreach) GetEnvelope() Envelope {
+	return sb.Envelope
+}
+
+func (sb *DecisionSLABreach) SetEnvelope(e Envelope) {
+	sb.Envelope = e
+}
+
+func (sb *DecisionSLABreach) Subject() string {
+	return "notify.sla." + sb.ActionType
+}
+
+// NewDecisionSLABreach creates an SLA breach notification for a pending proposal
+func NewDecisionSLABreach(proposal *ActionProposal, authorizerID string, createdAt time.Time) *DecisionSLABreach {
+	return &DecisionSLABreach{
+		Envelope: NewEnvelope(authorizerID, "authorizer").
+			WithCorrelation(proposal.Envelope.CorrelationID, proposal.Envelope.MessageID),
+		ProposalID:  proposal.ProposalID,
+		TrackID:     proposal.TrackID,
+		ActionType:  proposal.ActionType,
+		ThreatLevel: proposal.ThreatLevel,
+		Priority:    proposal.Priority,
+		CreatedAt:   createdAt,
+		ExpiresAt:   proposal.ExpiresAt,
+	}
+}
+
+// ProposalRescore is published when a pending proposal's priority, threat
+// level, or deadline changes because its track was reported at a new threat level
+type ProposalRescore struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	ProposalID     string `json:"proposal_id"`
+	TrackID        string `json:"track_id"`
+	ActionType     string `json:"action_type"`
+
+	// Scoring before and after
+	PreviousPriority    int       `json:"previous_priority"`
+	Priority            int       `json:"priority"`
+	PreviousThreatLevel string    `json:"previous_threat_level"`
+	ThreatLevel         string    `json:"threat_level"`
+	PreviousExpiresAt   time.Time `json:"previous_expires_at"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+func (pr *ProposalRescore) GetEnvelope() Envelope {
+	return pr.Envelope
+}
+
+func (pr *ProposalRescore) SetEnvelope(e Envelope) {
+	pr.Envelope = e
+}
+
+func (pr *ProposalRescore) Subject() string {
+	return "notify.rescore." + pr.ActionType
+}
+
+// NewProposalRescore creates a re-scoring notification for a pending proposal,
+// starting from its current scoring
+func NewProposalRescore(proposal *ActionProposal, authorizerID string) *ProposalRescore {
+	return &ProposalRescore{
+		Envelope: NewEnvelope(authorizerID, "authorizer").
+			WithCorrelation(proposal.Envelope.CorrelationID, proposal.Envelope.MessageID),
+		ProposalID:          proposal.ProposalID,
+		TrackID:             proposal.TrackID,
+		ActionType:          proposal.ActionType,
+		PreviousPriority:    proposal.Priority,
+		Priority:            proposal.Priority,
+		PreviousThreatLevel: proposal.ThreatLevel,
+		ThreatLevel:         proposal.ThreatLevel,
+		PreviousExpiresAt:   proposal.ExpiresAt,
+		ExpiresAt:           proposal.ExpiresAt,
+	}
+}
+
+// Break-glass events
+const (
+	BreakGlassActivated = "activated"
+	BreakGlassUsed      = "used"
+	BreakGlassRevoked   = "revoked"
+	BreakGlassExpired   = "expired"
+)
+
+// BreakGlassNotification is published whenever elevated approval authority is
+// activated, used to approve a proposal, revoked, or expires
+type BreakGlassNotification struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	Event          string `json:"event"` // activated, used, revoked, expired
+	GrantID        string `json:"grant_id"`
+
+	// Grant
+	UserID        string    `json:"user_id"`
+	Role          string    `json:"role"`
+	Justification string    `json:"justification"`
+	ActivatedAt   time.Time `json:"activated_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+
+	// Event context
+	Actor      string `json:"actor"`                 // Who caused the event; "system" for expiry
+	Reason     string `json:"reason,omitempty"`      // Revocation reason
+	ProposalID string `json:"proposal_id,omitempty"` // Set for use events
+	DecisionID string `json:"decision_id,omitempty"`
+	ActionType string `json:"action_type,omitempty"`
+}
+
+func (bn *BreakGlassNotification) GetEnvelope() Envelope {
+	return bn.Envelope
+}
+
+func (bn *BreakGlassNotification) SetEnvelope(e Envelope) {
+	bn.Envelope = e
+}
+
+func (bn *BreakGlassNotification) Subject() string {
+	return "notify.breakglass." + bn.Event
+}
+
+// EffectProgress reports an effect's progress through an external effect system
+type EffectProgress struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	EffectID   string `json:"effect_id"`
+	DecisionID string `json:"decision_id"`
+	TrackID    string `json:"track_id"`
+	ActionType string `json:"action_type"`
+	Backend    string `json:"backend"`
+
+	// Progress
+	Stage   string `json:"stage"`             // accepted, executing, completed, failed
+	Percent int    `json:"percent"`           // 0-100
+	Message string `json:"message,omitempty"` // Backend-specific detail
+}
+
+func (ep *EffectProgress) GetEnvelope() Envelope {
+	return ep.Envelope
+}
+
+func (ep *EffectProgress) SetEnvelope(e Envelope) {
+	ep.Envelope = e
+}
+
+func (ep *EffectProgress) Subject() string {
+	return "notify.effect_progress." + ep.ActionType
+}
+
+// AdmissionShed is published when admission control sheds an item to keep the
+// pipeline bounded during saturation
+type AdmissionShed struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	Kind           string `json:"kind"`   // detection, track, proposal
+	Reason         string `json:"reason"` // rate_limit, capacity, displaced
+
+	// Shed item; detections shed by the rate limit are summarized by Count
+	TrackID     string `json:"track_id,omitempty"`
+	ProposalID  string `json:"proposal_id,omitempty"`
+	ThreatLevel string `json:"threat_level,omitempty"`
+	Priority    int    `json:"priority,omitempty"`
+	Count       int    `json:"count"`
+
+	// Limit that was reached
+	Limit int `json:"limit"`
+
+	// Set when the item was displaced by a higher threat
+	DisplacedBy string `json:"displaced_by,omitempty"`
+}
+
+func (as *AdmissionShed) GetEnvelope() Envelope {
+	return as.Envelope
+}
+
+func (as *AdmissionShed) SetEnvelope(e Envelope) {
+	as.Envelope = e
+}
+
+func (as *AdmissionShed) Subject() string {
+	return "notify.admission." + as.Kind
+}
+
+// Proposal claim events
+const (
+	ClaimClaimed  = "claimed"
+	ClaimReleased = "released"
+	ClaimExpired  = "expired"
+)
+
+// ProposalClaimNotification is published when an operator claims a pending
+// proposal to work it, releases the claim, or lets it lapse, so other
+// operators know who holds the engagement
+type ProposalClaimNotification struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Identification
+	NotificationID string `json:"notification_id"`
+	Event          string `json:"event"` // claimed, released, expired
+	ProposalID     string `json:"proposal_id"`
+
+	// Claim
+	ClaimedBy string    `json:"claimed_by"`
+	ClaimedAt time.Time `json:"claimed_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (pc *ProposalClaimNotification) GetEnvelope() Envelope {
+	return pc.Envelope
+}
+
+func (pc *ProposalClaimNotification) SetEnvelope(e Envelope) {
+	pc.Envelope = e
+}
+
+func (pc *ProposalClaimNotification) Subject() string {
+	return "notify.claim." + pc.Event
+}
@@ -16,6 +16,15 @@ type ActionProposal struct {
 	Rationale  string   `json:"rationale"`   // Why this action is proposed
 	Constraints []string `json:"constraints,omitempty"`
 
+	// Ranked alternatives the approver may choose from; the first is ActionType
+	Options []CourseOfAction `json:"options,omitempty"`
+
+	// Asset recommended to carry out the action, for actions that need one
+	Asset *AssetAssignment `json:"asset,omitempty"`
+
+	// Other engagements this one conflicts with over an asset or airspace
+	Conflicts []ProposalConflict `json:"conflicts,omitempty"`
+
 	// Context
 	Track       *CorrelatedTrack `json:"track,omitempty"`
 	ThreatLevel string           `json:"threat_level"`
@@ -31,6 +40,37 @@ type ActionProposal struct {
 	PolicyDecision PolicyDecision `json:"policy_decision"`
 }
 
+// CourseOfAction is one action a proposal offers the approver, with the
+// planner's estimate of what it gains and risks against the track
+type CourseOfAction struct {
+	ActionType  string   `json:"action_type"`
+	Rank        int      `json:"rank"`    // 1 is the recommended action
+	Benefit     float64  `json:"benefit"` // 0-100, how well the action answers the threat
+	Risk        float64  `json:"risk"`    // 0-100, risk of escalation or of leaving the threat unanswered
+	Score       float64  `json:"score"`   // Benefit less risk; alternatives are ranked by it
+	Rationale   string   `json:"rationale,omitempty"`
+	Constraints []string `json:"constraints,omitempty"`
+}
+
+// AssetAssignment is the asset a proposal recommends for its action
+type AssetAssignment struct {
+	AssetID        string  `json:"asset_id"`
+	Name           string  `json:"name"`
+	Kind           string  `json:"kind"`            // interceptor, jammer, sensor
+	DistanceMeters float64 `json:"distance_meters"` // From the asset to the track when proposed
+}
+
+// ProposalConflict is another pending engagement that competes with a
+// proposal for the same asset or overlapping airspace
+type ProposalConflict struct {
+	ProposalID     string  `json:"proposal_id"`
+	TrackID        string  `json:"track_id"`
+	ActionType     string  `json:"action_type"`
+	Kind           string  `json:"kind"`               // asset, airspace
+	AssetID        string  `json:"asset_id,omitempty"` // Shared asset, for asset conflicts
+	DistanceMeters float64 `json:"distance_meters"`    // Between the two tracks when proposed
+}
+
 func (ap *ActionProposal) GetEnvelope() Envelope {
 	return ap.Envelope
 }
@@ -54,7 +94,8 @@ func NewActionProposal(track *CorrelatedTrack, plannerID string) *ActionProposal
 	now := time.Now().UTC()
 	return &ActionProposal{
 		Envelope: NewEnvelope(plannerID, "planner").
-			WithCorrelation(track.Envelope.CorrelationID, track.Envelope.MessageID),
+			WithCorrelation(track.Envelope.CorrelationID, track.Envelope.MessageID).
+			WithLabels(track.Envelope),
 		ProposalID:  "", // Set by planner
 		TrackID:     track.TrackID,
 		ActionType:  "track",
@@ -83,8 +124,28 @@ type Decision struct {
 	Conditions []string  `json:"conditions,omitempty"`
 
 	// Context
-	ActionType string `json:"action_type"`
-	TrackID    string `json:"track_id"`
+	ActionType     string `json:"action_type"`               // The action approved or denied
+	SelectedOption string `json:"selected_option,omitempty"` // Course of action the approver chose, when the proposal offered options
+	TrackID        string `json:"track_id"`
+
+	// Authority
+	BreakGlassGrantID string     `json:"break_glass_grant_id,omitempty"` // Set when approved under break-glass elevation
+	Approvals         []Approval `json:"approvals,omitempty"`            // Every approval counted under the two-person rule
+
+	// Set when an auto_approve intervention rule approved the proposal without an operator
+	MachineApproved   bool   `json:"machine_approved,omitempty"`
+	AutoApproveRuleID string `json:"auto_approve_rule_id,omitempty"`
+}
+
+// Approval is one operator's approval of a proposal that needs more than one
+type Approval struct {
+	ApprovedBy string    `json:"approved_by"`
+	Role       string    `json:"role"`
+	ApprovedAt time.Time `json:"approved_at"`
+	Reason     string    `json:"reason,omitempty"`
+	Option     string    `json:"option,omitempty"` // Course of action approved
+
+	BreakGlassGrantID string `json:"break_glass_grant_id,omitempty"`
 }
 
 func (d *Decision) GetEnvelope() Envelope {
@@ -99,14 +160,34 @@ func (d *Decision) Subject() string {
 	if d.Approved {
 		return "decision.approved." + d.ActionType
 	}
+	if d.Expired() {
+		return DecisionExpiredPrefix + d.ActionType
+	}
 	return "decision.denied." + d.ActionType
 }
 
+// ExpiryApprover is approved_by on decisions recording a proposal that expired
+// before anyone decided it
+const ExpiryApprover = "system:expiry"
+
+// DecisionReasonExpired is the reason given on expiry decisions
+const DecisionReasonExpired = "expired"
+
+// DecisionExpiredPrefix starts the subjects expiry decisions are published on
+const DecisionExpiredPrefix = "decision.expired."
+
+// Expired reports whether the decision records a proposal's expiry rather
+// than an operator's or rule's decision
+func (d *Decision) Expired() bool {
+	return !d.Approved && d.ApprovedBy == ExpiryApprover
+}
+
 // NewDecision creates a new decision for a proposal
 func NewDecision(proposal *ActionProposal, authorizerID string) *Decision {
 	return &Decision{
 		Envelope: NewEnvelope(authorizerID, "authorizer").
-			WithCorrelation(proposal.Envelope.CorrelationID, proposal.Envelope.MessageID),
+			WithCorrelation(proposal.Envelope.CorrelationID, proposal.Envelope.MessageID).
+			WithLabels(proposal.Envelope),
 		ProposalID: proposal.ProposalID,
 		ActionType: proposal.ActionType,
 		TrackID:    proposal.TrackID,
@@ -114,6 +195,79 @@ func NewDecision(proposal *ActionProposal, authorizerID string) *Decision {
 	}
 }
 
+// NewExpiredDecision creates the decision recording that a proposal expired
+// undecided: not approved, by ExpiryApprover, for DecisionReasonExpired
+func NewExpiredDecision(proposal *ActionProposal, authorizerID string) *Decision {
+	d := NewDecision(proposal, authorizerID)
+	d.ApprovedBy = ExpiryApprover
+	d.Reason = DecisionReasonExpired
+	return d
+}
+
+// DecisionRevocation recalls an approved decision before its effect executes
+type DecisionRevocation struct {
+	Envelope Envelope `json:"envelope"`
+
+	DecisionID string    `json:"decision_id"`
+	ProposalID string    `json:"proposal_id"`
+	TrackID    string    `json:"track_id"`
+	ActionType string    `json:"action_type"`
+	RevokedBy  string    `json:"revoked_by"`
+	RevokedAt  time.Time `json:"revoked_at"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+func (dr *DecisionRevocation) GetEnvelope() Envelope {
+	return dr.Envelope
+}
+
+func (dr *DecisionRevocation) SetEnvelope(e Envelope) {
+	dr.Envelope = e
+}
+
+// DecisionRevokedPrefix starts the subjects revocations are published on
+const DecisionRevokedPrefix = "decision.revoked."
+
+func (dr *DecisionRevocation) Subject() string {
+	return DecisionRevokedPrefix + dr.ActionType
+}
+
+// DecisionCommandSubject is the request/reply subject authorizers answer
+// decision commands on, so the gateway can submit decisions over NATS
+const DecisionCommandSubject = "cmd.authorizer.decide"
+
+// DecisionCommand asks an authorizer to record a human decision on a proposal
+type DecisionCommand struct {
+	Envelope Envelope `json:"envelope"`
+
+	ProposalID string   `json:"proposal_id"`
+	Approved   bool     `json:"approved"`
+	ApprovedBy string   `json:"approved_by"`
+	Reason     string   `json:"reason,omitempty"`
+	Conditions []string `json:"conditions,omitempty"`
+	Option     string   `json:"option,omitempty"` // Course of action to approve; empty approves the recommended one
+}
+
+// Decision command outcomes
+const (
+	DecisionCommandPublished = "published" // Decision stored and published
+	DecisionCommandPending   = "pending"   // Approval recorded; awaiting another operator
+	DecisionCommandRejected  = "rejected"  // Decision refused; see Code and Error
+)
+
+// DecisionCommandReply is an authorizer's acknowledgement of a DecisionCommand
+type DecisionCommandReply struct {
+	ProposalID string `json:"proposal_id,omitempty"`
+	Status     string `json:"status"`
+	Code       int    `json:"code"` // HTTP status describing the outcome
+	Error      string `json:"error,omitempty"`
+	ErrorCode  string `json:"error_code,omitempty"` // Problem code classifying Error, such as policy_denied
+
+	DecisionID        string     `json:"decision_id,omitempty"`
+	Approvals         []Approval `json:"approvals,omitempty"`
+	RequiredApprovals int        `json:"required_approvals,omitempty"`
+}
+
 // EffectLog represents the execution of an approved action
 type EffectLog struct {
 	Envelope Envelope `json:"envelope"`
@@ -126,11 +280,23 @@ type EffectLog struct {
 
 	// Execution
 	ActionType   string    `json:"action_type"`
-	Status       string    `json:"status"` // executed, failed, simulated
+	Status       string    `json:"status"` // executed, failed, failed_permanent, simulated, aborted
 	ExecutedAt   time.Time `json:"executed_at"`
 	Result       string    `json:"result"`
 	IdempotentKey string   `json:"idempotent_key"`
 	Idempotent   bool      `json:"idempotent"` // True if this was a replay
+
+	// Failover
+	FencingToken uint64 `json:"fencing_token,omitempty"` // Lease tenure of the executing effector
+
+	// Execution attempts made under the retry policy
+	Attempts int `json:"attempts,omitempty"`
+
+	// What the backend reported of an executed action: success, partial, or
+	// failure, whether it caused collateral damage, and how long it took
+	Outcome    string `json:"outcome,omitempty"`
+	Collateral bool   `json:"collateral,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
 }
 
 func (el *EffectLog) GetEnvelope() Envelope {
@@ -149,7 +315,8 @@ func (el *EffectLog) Subject() string {
 func NewEffectLog(decision *Decision, effectorID string) *EffectLog {
 	return &EffectLog{
 		Envelope: NewEnvelope(effectorID, "effector").
-			WithCorrelation(decision.Envelope.CorrelationID, decision.Envelope.MessageID),
+			WithCorrelation(decision.Envelope.CorrelationID, decision.Envelope.MessageID).
+			WithLabels(decision.Envelope),
 		DecisionID: decision.DecisionID,
 		ProposalID: decision.ProposalID,
 		TrackID:    decision.TrackID,
@@ -158,3 +325,54 @@ func NewEffectLog(decision *Decision, effectorID string) *EffectLog {
 		ExecutedAt: time.Now().UTC(),
 	}
 }
+
+// EffectAssessment is the battle damage assessment of an executed effect
+type EffectAssessment struct {
+	Envelope Envelope `json:"envelope"`
+
+	// Assessment identification
+	AssessmentID string `json:"assessment_id"`
+	EffectID     string `json:"effect_id"`
+	DecisionID   string `json:"decision_id"`
+	ProposalID   string `json:"proposal_id"`
+	TrackID      string `json:"track_id"`
+	ActionType   string `json:"action_type"`
+
+	// Outcome
+	SuccessProbability float64   `json:"success_probability"` // Estimated probability the effect achieved its aim
+	Neutralized        bool      `json:"neutralized"`         // The track no longer poses a threat
+	Basis              string    `json:"basis"`               // What the assessment rests on: reported or estimated
+	AssessedAt         time.Time `json:"assessed_at"`
+}
+
+func (ea *EffectAssessment) GetEnvelope() Envelope {
+	return ea.Envelope
+}
+
+func (ea *EffectAssessment) SetEnvelope(e Envelope) {
+	ea.Envelope = e
+}
+
+// Subject returns assessment.neutralized.<action_type> or assessment.survived.<action_type>
+func (ea *EffectAssessment) Subject() string {
+	outcome := "survived"
+	if ea.Neutralized {
+		outcome = "neutralized"
+	}
+	return "assessment." + outcome + "." + ea.ActionType
+}
+
+// NewEffectAssessment creates an assessment for an executed effect
+func NewEffectAssessment(effectLog *EffectLog, assessorID string) *EffectAssessment {
+	return &EffectAssessment{
+		Envelope: NewEnvelope(assessorID, "effector").
+			WithCorrelation(effectLog.Envelope.CorrelationID, effectLog.Envelope.MessageID).
+			WithLabels(effectLog.Envelope),
+		EffectID:   effectLog.EffectID,
+		DecisionID: effectLog.DecisionID,
+		ProposalID: effectLog.ProposalID,
+		TrackID:    effectLog.TrackID,
+		ActionType: effectLog.ActionType,
+		AssessedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,108 @@
+package messages
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Schema names, matching the message types agents advertise at /capabilities
+const (
+	SchemaDetection       = "detection"
+	SchemaTrack           = "track"
+	SchemaCorrelatedTrack = "correlated_track"
+	SchemaActionProposal  = "action_proposal"
+	SchemaDecision        = "decision"
+	SchemaEffectLog       = "effect_log"
+)
+
+// SchemaNames lists every message schema
+var SchemaNames = []string{
+	SchemaDetection,
+	SchemaTrack,
+	SchemaCorrelatedTrack,
+	SchemaActionProposal,
+	SchemaDecision,
+	SchemaEffectLog,
+}
+
+// schemaBaseURI is the $id prefix the embedded schemas refer to each other by
+const schemaBaseURI = "https://cjadc2/schemas/"
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schemas holds the compiled schema of each message type
+var schemas = mustCompileSchemas()
+
+// ValidationError reports why a payload does not match its message schema
+type ValidationError struct {
+	Schema string
+	Errors []string // One entry per violated rule, as field: description
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Schema, strings.Join(e.Errors, "; "))
+}
+
+// Validate checks a raw message against the named schema. A payload that is
+// not JSON or breaks the schema returns a *ValidationError; an unknown schema
+// name is a programming error and returns a plain error.
+func Validate(schema string, data []byte) error {
+	s, ok := schemas[schema]
+	if !ok {
+		return fmt.Errorf("unknown message schema %q", schema)
+	}
+
+	if !json.Valid(data) {
+		return &ValidationError{Schema: schema, Errors: []string{"payload is not valid JSON"}}
+	}
+
+	result, err := s.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return &ValidationError{Schema: schema, Errors: []string{err.Error()}}
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	verr := &ValidationError{Schema: schema}
+	for _, e := range result.Errors() {
+		verr.Errors = append(verr.Errors, e.String())
+	}
+	return verr
+}
+
+// SchemaDocument returns the JSON Schema of a message type
+func SchemaDocument(schema string) ([]byte, error) {
+	return schemaFiles.ReadFile("schemas/" + schema + ".json")
+}
+
+// mustCompileSchemas compiles the embedded schemas. The shared definitions and
+// every message schema are registered first so proposals can refer to the
+// correlated track schema.
+func mustCompileSchemas() map[string]*gojsonschema.Schema {
+	loader := gojsonschema.NewSchemaLoader()
+	for _, name := range append([]string{"common"}, SchemaNames...) {
+		doc, err := schemaFiles.ReadFile("schemas/" + name + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("failed to read %s schema: %v", name, err))
+		}
+		if err := loader.AddSchema(schemaBaseURI+name+".json", gojsonschema.NewBytesLoader(doc)); err != nil {
+			panic(fmt.Sprintf("failed to register %s schema: %v", name, err))
+		}
+	}
+
+	compiled := make(map[string]*gojsonschema.Schema, len(SchemaNames))
+	for _, name := range SchemaNames {
+		s, err := loader.Compile(gojsonschema.NewReferenceLoader(schemaBaseURI + name + ".json"))
+		if err != nil {
+			panic(fmt.Sprintf("failed to compile %s schema: %v", name, err))
+		}
+		compiled[name] = s
+	}
+	return compiled
+}
@@ -0,0 +1,132 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/agile-defense/cjadc2/pkg/crypto"
+)
+
+// ContentTypeSealed names a message whose payload is encrypted; the body is
+// a SealedMessage wrapping the message in its own encoding
+const ContentTypeSealed = "application/vnd.cjadc2.sealed+json"
+
+// ErrNoPayloadKeys is returned when decoding a sealed message in a process
+// that has no payload keys
+var ErrNoPayloadKeys = errors.New("message is sealed but no payload keys are configured")
+
+// IsSealed reports whether a Content-Type header names a sealed message
+func IsSealed(contentType string) bool {
+	return contentType == ContentTypeSealed
+}
+
+// SealedMessage carries an encrypted message. The envelope is a copy of the
+// inner message's with the key ID set, left in the clear so sealed messages
+// can still be routed, traced, and deduplicated without the key.
+type SealedMessage struct {
+	Envelope    Envelope `json:"envelope"`
+	ContentType string   `json:"content_type"`   // Encoding of the inner message
+	Payload     []byte   `json:"sealed_payload"` // Nonce and AES-GCM ciphertext
+}
+
+// payloadKeys opens sealed messages in Unmarshal, ToJSON, and ValidateEncoded
+var payloadKeys atomic.Pointer[crypto.Keyring]
+
+// SetPayloadKeys installs the keyring sealed messages are opened with
+func SetPayloadKeys(keys *crypto.Keyring) {
+	payloadKeys.Store(keys)
+}
+
+// Seal encrypts a message encoded by Marshal under the keyring's active key.
+// The message ID is authenticated with the payload, so a sealed payload
+// cannot be replayed under another envelope.
+func Seal(msg Message, data []byte, contentType string, keys *crypto.Keyring) ([]byte, error) {
+	env := msg.GetEnvelope()
+	keyID, sealed, err := keys.Seal(data, []byte(env.MessageID))
+	if err != nil {
+		return nil, err
+	}
+	env.KeyID = keyID
+	return json.Marshal(SealedMessage{Envelope: env, ContentType: contentType, Payload: sealed})
+}
+
+// Encoder encodes messages for publishing. Messages without a security label
+// take Classification and Releasability, and those whose label Encryption
+// selects are sealed under PayloadKeys. Agents and the gateway publish
+// through it, so a message is sealed whichever process sends it.
+type Encoder struct {
+	Encoding       Encoding
+	Classification string
+	Releasability  []string
+	Encryption     crypto.Policy
+	PayloadKeys    *crypto.Keyring
+}
+
+// Encode labels and marshals a message, sealing it when its label requires,
+// and returns the encoding with its content type
+func (e Encoder) Encode(msg Message) ([]byte, string, error) {
+	env := msg.GetEnvelope()
+	if env.Classification == "" {
+		env.Classification = e.Classification
+		env.Releasability = e.Releasability
+		msg.SetEnvelope(env)
+	}
+
+	data, contentType, err := Marshal(msg, e.Encoding)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	if e.Encryption.Requires(env.Classification) {
+		if e.PayloadKeys == nil {
+			return nil, "", fmt.Errorf("%s message must be encrypted but no PAYLOAD_KEYS are set", env.Classification)
+		}
+		if data, err = Seal(msg, data, contentType, e.PayloadKeys); err != nil {
+			return nil, "", fmt.Errorf("failed to seal message: %w", err)
+		}
+		contentType = ContentTypeSealed
+	}
+	return data, contentType, nil
+}
+
+// openSealed decrypts a sealed message, returning its outer envelope and the
+// inner message's content type and encoding
+func openSealed(data []byte) (Envelope, string, []byte, error) {
+	var sealed SealedMessage
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return Envelope{}, "", nil, fmt.Errorf("failed to decode sealed message: %w", err)
+	}
+	if IsSealed(sealed.ContentType) {
+		return Envelope{}, "", nil, errors.New("sealed message wraps another sealed message")
+	}
+	keys := payloadKeys.Load()
+	if keys == nil {
+		return Envelope{}, "", nil, ErrNoPayloadKeys
+	}
+	plaintext, err := keys.Open(sealed.Envelope.KeyID, sealed.Payload, []byte(sealed.Envelope.MessageID))
+	if err != nil {
+		return Envelope{}, "", nil, err
+	}
+	return sealed.Envelope, sealed.ContentType, plaintext, nil
+}
+
+// unmarshalSealed opens a sealed message and decodes the message inside,
+// keeping the key ID it was sealed under on its envelope
+func unmarshalSealed(data []byte, msg Message) error {
+	outer, contentType, plaintext, err := openSealed(data)
+	if err != nil {
+		return err
+	}
+	if err := Unmarshal(contentType, plaintext, msg); err != nil {
+		return err
+	}
+	env := msg.GetEnvelope()
+	if env.MessageID != outer.MessageID {
+		return fmt.Errorf("sealed message %s wraps message %s", outer.MessageID, env.MessageID)
+	}
+	env.KeyID = outer.KeyID
+	msg.SetEnvelope(env)
+	return nil
+}
@@ -0,0 +1,179 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the message format version this build publishes.
+// Bump it when old payloads no longer decode into the current structs as
+// they are, and register the upgrade from the previous version in upgrades.
+//
+// Version 1 is the format before envelopes carried schema_version, when
+// correlated tracks had a threat level but no threat score.
+const CurrentSchemaVersion = 2
+
+// firstProtobufSchemaVersion is the version protobuf payloads without a
+// schema_version are read as, since none were published before it
+const firstProtobufSchemaVersion = 2
+
+// upgradeFunc rewrites the top-level fields of a payload from one version to the next
+type upgradeFunc func(fields map[string]json.RawMessage) error
+
+// upgrades holds, for each schema, the upgrade from each version to the next.
+// Schemas without an entry for a version read it unchanged.
+var upgrades = map[string]map[int]upgradeFunc{
+	SchemaCorrelatedTrack: {1: upgradeCorrelatedTrackV1},
+	SchemaActionProposal:  {1: upgradeActionProposalV1},
+}
+
+// Version returns the envelope's schema version, 1 for messages that predate it
+func (e Envelope) Version() int {
+	if e.SchemaVersion < 1 {
+		return 1
+	}
+	return e.SchemaVersion
+}
+
+// Upgrade rewrites a JSON payload of the named schema from an older version
+// to the current one. Current payloads are returned unchanged, as are newer
+// ones, which are read by ignoring the fields this build does not know.
+func Upgrade(schema string, data []byte) ([]byte, error) {
+	var probe struct {
+		Envelope Envelope `json:"envelope"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	version := probe.Envelope.Version()
+	if version >= CurrentSchemaVersion {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		if upgrade := upgrades[schema][v]; upgrade != nil {
+			if err := upgrade(fields); err != nil {
+				return nil, fmt.Errorf("failed to upgrade %s from version %d: %w", schema, v, err)
+			}
+		}
+	}
+	if err := setSchemaVersion(fields, CurrentSchemaVersion); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// upgradeMessage brings a decoded protobuf message of an older version up to
+// date by re-reading it through Upgrade as JSON. Upgrades from protobuf
+// versions must not depend on telling missing fields from zero values.
+func upgradeMessage(msg Message) error {
+	schema := schemaOf(msg)
+	if schema == "" || msg.GetEnvelope().Version() >= CurrentSchemaVersion {
+		return nil
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	upgraded, err := Upgrade(schema, data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, msg)
+}
+
+// schemaOf returns the schema of a pipeline message, or empty for other messages
+func schemaOf(msg Message) string {
+	switch msg.(type) {
+	case *Detection:
+		return SchemaDetection
+	case *Track:
+		return SchemaTrack
+	case *CorrelatedTrack:
+		return SchemaCorrelatedTrack
+	case *ActionProposal:
+		return SchemaActionProposal
+	case *Decision:
+		return SchemaDecision
+	case *EffectLog:
+		return SchemaEffectLog
+	}
+	return ""
+}
+
+// setSchemaVersion stamps the version into a payload's envelope
+func setSchemaVersion(fields map[string]json.RawMessage, version int) error {
+	var envelope map[string]json.RawMessage
+	if raw, ok := fields["envelope"]; ok {
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return fmt.Errorf("failed to read envelope: %w", err)
+		}
+	}
+	if envelope == nil {
+		envelope = make(map[string]json.RawMessage)
+	}
+	envelope["schema_version"] = json.RawMessage(fmt.Sprint(version))
+
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	fields["envelope"] = raw
+	return nil
+}
+
+// v1ThreatScores are the lowest scores of each threat level under the default
+// thresholds of pkg/threat, used for tracks scored before threat scores existed
+var v1ThreatScores = map[string]float64{
+	"low":      0,
+	"medium":   35,
+	"high":     60,
+	"critical": 85,
+}
+
+// upgradeCorrelatedTrackV1 gives a version 1 track the score its threat level implies
+func upgradeCorrelatedTrackV1(fields map[string]json.RawMessage) error {
+	if _, ok := fields["threat_score"]; ok {
+		return nil
+	}
+	var level string
+	if raw, ok := fields["threat_level"]; ok {
+		if err := json.Unmarshal(raw, &level); err != nil {
+			return fmt.Errorf("failed to read threat_level: %w", err)
+		}
+	}
+	score, err := json.Marshal(v1ThreatScores[level])
+	if err != nil {
+		return err
+	}
+	fields["threat_score"] = score
+	return nil
+}
+
+// upgradeActionProposalV1 upgrades the correlated track a version 1 proposal carries
+func upgradeActionProposalV1(fields map[string]json.RawMessage) error {
+	raw, ok := fields["track"]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	var track map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &track); err != nil {
+		return fmt.Errorf("failed to read track: %w", err)
+	}
+	if err := upgradeCorrelatedTrackV1(track); err != nil {
+		return err
+	}
+	if err := setSchemaVersion(track, 2); err != nil {
+		return err
+	}
+	upgraded, err := json.Marshal(track)
+	if err != nil {
+		return err
+	}
+	fields["track"] = upgraded
+	return nil
+}
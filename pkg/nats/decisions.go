@@ -0,0 +1,43 @@
+package natsutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// ErrNoAuthorizer is returned when no authorizer answers a decision request
+var ErrNoAuthorizer = errors.New("no authorizer available")
+
+// RequestDecision submits a decision command to the authorizers over
+// request/reply and returns the authorizer's acknowledgement. ctx bounds the
+// wait for a reply.
+func RequestDecision(ctx context.Context, nc *nats.Conn, cmd messages.DecisionCommand) (messages.DecisionCommandReply, error) {
+	var reply messages.DecisionCommandReply
+	if nc == nil {
+		return reply, ErrNoAuthorizer
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return reply, fmt.Errorf("failed to marshal decision command: %w", err)
+	}
+
+	msg, err := nc.RequestWithContext(ctx, messages.DecisionCommandSubject, data)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return reply, ErrNoAuthorizer
+	}
+	if err != nil {
+		return reply, fmt.Errorf("failed to request decision: %w", err)
+	}
+
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return reply, fmt.Errorf("failed to decode authorizer reply: %w", err)
+	}
+	return reply, nil
+}
@@ -0,0 +1,130 @@
+package natsutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// StreamPolicy overrides the retention and limits of one stream. Unset
+// fields keep the stream's default from StreamConfigs.
+type StreamPolicy struct {
+	Retention string `json:"retention,omitempty"` // limits, interest, or workqueue; applied when the stream is created
+	MaxAge    string `json:"max_age,omitempty"`   // Duration such as 168h; 0 keeps messages until another limit is hit
+	MaxBytes  int64  `json:"max_bytes,omitempty"`
+	MaxMsgs   int64  `json:"max_msgs,omitempty"`
+	Replicas  int    `json:"replicas,omitempty"`
+}
+
+// StreamPolicies holds stream policies by stream name
+type StreamPolicies map[string]StreamPolicy
+
+// retentionPolicies maps policy retention names to JetStream policies
+var retentionPolicies = map[string]jetstream.RetentionPolicy{
+	"limits":    jetstream.LimitsPolicy,
+	"interest":  jetstream.InterestPolicy,
+	"workqueue": jetstream.WorkQueuePolicy,
+}
+
+// LoadStreamPolicies reads a JSON file of stream policies keyed by stream
+// name, such as {"TRACKS": {"max_age": "168h"}}, and validates it
+func LoadStreamPolicies(path string) (StreamPolicies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream policy file: %w", err)
+	}
+
+	var policies StreamPolicies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse stream policy file: %w", err)
+	}
+	if err := policies.Validate(); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Validate checks that every policy names a known stream and holds valid limits
+func (p StreamPolicies) Validate() error {
+	for name, policy := range p {
+		if _, ok := StreamConfigs[name]; !ok {
+			return fmt.Errorf("stream policy for unknown stream %q", name)
+		}
+		if policy.Retention != "" {
+			if _, ok := retentionPolicies[strings.ToLower(policy.Retention)]; !ok {
+				return fmt.Errorf("invalid retention %q for stream %s: must be limits, interest, or workqueue", policy.Retention, name)
+			}
+		}
+		if policy.MaxAge != "" {
+			if d, err := time.ParseDuration(policy.MaxAge); err != nil || d < 0 {
+				return fmt.Errorf("invalid max_age %q for stream %s", policy.MaxAge, name)
+			}
+		}
+		if policy.MaxBytes < 0 || policy.MaxMsgs < 0 || policy.Replicas < 0 || policy.Replicas > 5 {
+			return fmt.Errorf("invalid limits for stream %s: sizes must not be negative and replicas must be at most 5", name)
+		}
+	}
+	return nil
+}
+
+// Apply returns a stream config with the policy for its stream applied
+func (p StreamPolicies) Apply(cfg jetstream.StreamConfig) jetstream.StreamConfig {
+	policy, ok := p[cfg.Name]
+	if !ok {
+		return cfg
+	}
+
+	if r, ok := retentionPolicies[strings.ToLower(policy.Retention)]; ok {
+		cfg.Retention = r
+	}
+	if d, err := time.ParseDuration(policy.MaxAge); err == nil && policy.MaxAge != "" {
+		cfg.MaxAge = d
+	}
+	if policy.MaxBytes > 0 {
+		cfg.MaxBytes = policy.MaxBytes
+	}
+	if policy.MaxMsgs > 0 {
+		cfg.MaxMsgs = policy.MaxMsgs
+	}
+	if policy.Replicas > 0 {
+		cfg.Replicas = policy.Replicas
+	}
+	return cfg
+}
+
+// updateStreamLimits brings an existing stream's age, size, and replica limits
+// in line with cfg, leaving its retention and other settings as they are
+func updateStreamLimits(ctx context.Context, js jetstream.JetStream, stream jetstream.Stream, cfg jetstream.StreamConfig) error {
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get stream info for %s: %w", cfg.Name, err)
+	}
+
+	current := info.Config
+	if current.MaxAge == cfg.MaxAge && sameLimit(current.MaxBytes, cfg.MaxBytes) &&
+		sameLimit(current.MaxMsgs, cfg.MaxMsgs) && current.Replicas == cfg.Replicas {
+		return nil
+	}
+	current.MaxAge = cfg.MaxAge
+	current.MaxBytes = cfg.MaxBytes
+	current.MaxMsgs = cfg.MaxMsgs
+	current.Replicas = cfg.Replicas
+
+	if _, err := js.UpdateStream(ctx, current); err != nil {
+		return fmt.Errorf("failed to update limits of stream %s: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// sameLimit compares stream limits, where the server reports unset as -1
+func sameLimit(a, b int64) bool {
+	if a <= 0 && b <= 0 {
+		return true
+	}
+	return a == b
+}
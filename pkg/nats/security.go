@@ -0,0 +1,87 @@
+package natsutil
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Security holds the TLS and credential files a client connects to NATS with.
+// The zero value connects in plaintext with whatever user the caller supplies.
+type Security struct {
+	CAFile    string // PEM roots that verify the server; system roots when empty
+	CertFile  string // Client certificate for mutual TLS
+	KeyFile   string // Key for CertFile
+	CredsFile string // Decentralized JWT user credentials (.creds)
+	NKeyFile  string // NKey user seed
+}
+
+// SecurityFromEnv reads NATS_CA_FILE, NATS_CERT_FILE, NATS_KEY_FILE,
+// NATS_CREDS_FILE, and NATS_NKEY_FILE
+func SecurityFromEnv() Security {
+	return Security{
+		CAFile:    os.Getenv("NATS_CA_FILE"),
+		CertFile:  os.Getenv("NATS_CERT_FILE"),
+		KeyFile:   os.Getenv("NATS_KEY_FILE"),
+		CredsFile: os.Getenv("NATS_CREDS_FILE"),
+		NKeyFile:  os.Getenv("NATS_NKEY_FILE"),
+	}
+}
+
+// HasCredentials reports whether the client authenticates with a creds file
+// or an NKey rather than a username and password
+func (s Security) HasCredentials() bool {
+	return s.CredsFile != "" || s.NKeyFile != ""
+}
+
+// Validate checks the settings are consistent and every file can be read
+func (s Security) Validate() error {
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return fmt.Errorf("NATS_CERT_FILE and NATS_KEY_FILE must be set together")
+	}
+	if s.CredsFile != "" && s.NKeyFile != "" {
+		return fmt.Errorf("set only one of NATS_CREDS_FILE and NATS_NKEY_FILE")
+	}
+	for name, path := range map[string]string{
+		"NATS_CA_FILE":    s.CAFile,
+		"NATS_CERT_FILE":  s.CertFile,
+		"NATS_KEY_FILE":   s.KeyFile,
+		"NATS_CREDS_FILE": s.CredsFile,
+		"NATS_NKEY_FILE":  s.NKeyFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Options returns the connection options for the settings
+func (s Security) Options() ([]nats.Option, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []nats.Option
+	if s.CAFile != "" {
+		opts = append(opts, nats.RootCAs(s.CAFile))
+	}
+	if s.CertFile != "" {
+		opts = append(opts, nats.ClientCert(s.CertFile, s.KeyFile))
+	}
+	if s.CredsFile != "" {
+		opts = append(opts, nats.UserCredentials(s.CredsFile))
+	}
+	if s.NKeyFile != "" {
+		opt, err := nats.NkeyOptionFromSeed(s.NKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS_NKEY_FILE: %w", err)
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
@@ -3,11 +3,24 @@ package natsutil
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nats-io/nats.go/jetstream"
 )
 
+// DeadLetterStream holds messages consumers refused, such as payloads that
+// fail schema validation
+const DeadLetterStream = "DLQ"
+
+// AuditStream keeps decisions and effects long after the working streams
+// have delivered them, for compliance review. It cannot be purged and its
+// messages cannot be deleted.
+const AuditStream = "AUDIT"
+
 // StreamConfigs defines all streams used by the CJADC2 platform
 var StreamConfigs = map[string]jetstream.StreamConfig{
 	"DETECTIONS": {
@@ -47,7 +60,7 @@ var StreamConfigs = map[string]jetstream.StreamConfig{
 		Name:        "DECISIONS",
 		Description: "Human decisions on proposals",
 		Subjects:    []string{"decision.>"},
-		Retention:   jetstream.LimitsPolicy,
+		Retention:   jetstream.InterestPolicy, // Kept until every consumer, AUDIT included, has it
 		MaxBytes:    1 * 1024 * 1024 * 1024,
 		MaxAge:      7 * 24 * time.Hour,
 		Storage:     jetstream.FileStorage,
@@ -57,12 +70,94 @@ var StreamConfigs = map[string]jetstream.StreamConfig{
 		Name:        "EFFECTS",
 		Description: "Executed effect logs",
 		Subjects:    []string{"effect.>"},
-		Retention:   jetstream.LimitsPolicy,
+		Retention:   jetstream.InterestPolicy, // Kept until every consumer, AUDIT included, has it
 		MaxBytes:    512 * 1024 * 1024,
 		MaxAge:      30 * 24 * time.Hour,
 		Storage:     jetstream.FileStorage,
 		Replicas:    1,
 	},
+	AuditStream: {
+		Name:        AuditStream,
+		Description: "Long-term record of decisions and effects for compliance",
+		Sources:     []*jetstream.StreamSource{{Name: "DECISIONS"}, {Name: "EFFECTS"}},
+		Retention:   jetstream.LimitsPolicy,
+		MaxBytes:    4 * 1024 * 1024 * 1024, // 4GB
+		MaxAge:      365 * 24 * time.Hour,
+		Storage:     jetstream.FileStorage,
+		Replicas:    1,
+		Discard:     jetstream.DiscardOld,
+		DenyDelete:  true,
+		DenyPurge:   true,
+	},
+	"ASSESSMENTS": {
+		Name:        "ASSESSMENTS",
+		Description: "Battle damage assessments of executed effects",
+		Subjects:    []string{"assessment.>"},
+		Retention:   jetstream.LimitsPolicy,
+		MaxBytes:    256 * 1024 * 1024,
+		MaxAge:      24 * time.Hour,
+		Storage:     jetstream.FileStorage,
+		Replicas:    1,
+	},
+	"NOTIFICATIONS": {
+		Name:        "NOTIFICATIONS",
+		Description: "Operator notifications such as proposal escalations",
+		Subjects:    []string{"notify.>"},
+		Retention:   jetstream.LimitsPolicy,
+		MaxBytes:    256 * 1024 * 1024,
+		MaxAge:      24 * time.Hour,
+		Storage:     jetstream.FileStorage,
+		Replicas:    1,
+	},
+	"SIMCONTROL": {
+		Name:              "SIMCONTROL",
+		Description:       "Simulation clock state: pause, resume, and speed",
+		Subjects:          []string{"simcontrol.>"},
+		Retention:         jetstream.LimitsPolicy,
+		MaxMsgsPerSubject: 1, // Only the current state matters
+		Storage:           jetstream.FileStorage,
+		Replicas:          1,
+	},
+	DeadLetterStream: {
+		Name:        DeadLetterStream,
+		Description: "Messages consumers refused, with the reason, for inspection and replay",
+		Subjects:    []string{"dlq.>"},
+		Retention:   jetstream.LimitsPolicy,
+		MaxBytes:    256 * 1024 * 1024,
+		MaxAge:      7 * 24 * time.Hour,
+		Storage:     jetstream.FileStorage,
+		Replicas:    1,
+	},
+	"GROUNDTRUTH": {
+		Name:        "GROUNDTRUTH",
+		Description: "Simulator ground truth: the true identity of every simulated track and which detections were clutter or false alarms, for accuracy scoring",
+		Subjects:    []string{"truth.>"},
+		Retention:   jetstream.LimitsPolicy,
+		MaxBytes:    256 * 1024 * 1024,
+		MaxAge:      24 * time.Hour,
+		Storage:     jetstream.FileStorage,
+		Replicas:    1,
+		Discard:     jetstream.DiscardOld,
+	},
+	"HEARTBEATS": {
+		Name:              "HEARTBEATS",
+		Description:       "Periodic agent heartbeats with health, version, and consumer lag",
+		Subjects:          []string{"heartbeat.>"},
+		Retention:         jetstream.LimitsPolicy,
+		MaxMsgsPerSubject: 1, // Only each agent's latest heartbeat matters
+		MaxAge:            time.Hour,
+		Storage:           jetstream.FileStorage,
+		Replicas:          1,
+	},
+	"CHAOS": {
+		Name:              "CHAOS",
+		Description:       "Chaos testing fault plan for pipeline stages",
+		Subjects:          []string{"chaos.>"},
+		Retention:         jetstream.LimitsPolicy,
+		MaxMsgsPerSubject: 1, // Only the current plan matters
+		Storage:           jetstream.FileStorage,
+		Replicas:          1,
+	},
 }
 
 // ConsumerConfigs defines consumers for each agent type
@@ -112,13 +207,80 @@ var ConsumerConfigs = map[string]jetstream.ConsumerConfig{
 		MaxDeliver:    5, // Higher retry for effects
 		MaxAckPending: 50,
 	},
+	"detection-persister": {
+		Durable:       "detection-persister",
+		Description:   "Gateway consumer archiving raw detections to PostgreSQL",
+		FilterSubject: "detect.>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       60 * time.Second,
+		MaxDeliver:    -1, // Keep retrying through database outages
+		MaxAckPending: 10000,
+	},
+}
+
+// FederationConsumer returns the consumer a federation bridge reads this
+// enclave's classified tracks from to export them to a partner. Each partner
+// has its own durable, so every bridge sees every track, and deliveries are
+// retried until the partner is reachable again.
+func FederationConsumer(partner string) jetstream.ConsumerConfig {
+	return jetstream.ConsumerConfig{
+		Durable:       "federation-" + strings.ToLower(partner),
+		Description:   "Federation bridge consumer exporting classified tracks to " + partner,
+		FilterSubject: "track.classified.>",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       30 * time.Second,
+		MaxDeliver:    -1,
+		MaxAckPending: 500,
+	}
+}
+
+// CorrelatorShardConsumer returns the consumer a sharded correlator reads the
+// classified tracks of its geographic cells from, including border copies
+// handed off from neighbouring cells. Each shard has its own durable, so the
+// shards split the stream between them.
+func CorrelatorShardConsumer(shard string, cells []string) jetstream.ConsumerConfig {
+	cfg := ConsumerConfigs["correlator"]
+	cfg.Durable = "correlator-" + strings.ToLower(shard)
+	cfg.Description = "Correlator shard " + shard + " consumer for classified tracks in cells " + strings.Join(cells, ",")
+	cfg.FilterSubject = ""
+	cfg.FilterSubjects = make([]string, 0, 2*len(cells))
+	for _, cell := range cells {
+		cfg.FilterSubjects = append(cfg.FilterSubjects, "track.classified.*."+cell, "track.handoff.*."+cell)
+	}
+	return cfg
+}
+
+// PipelineConsumers maps each pipeline stream to the durable consumers that
+// hold its in-flight messages
+var PipelineConsumers = map[string][]string{
+	"DETECTIONS":  {"classifier", "detection-persister"},
+	"TRACKS":      {"correlator", "planner"},
+	"PROPOSALS":   {"authorizer"},
+	"DECISIONS":   {"effector"},
+	"EFFECTS":     {},
+	"ASSESSMENTS": {}, // Followed by ordered consumers only
+	"GROUNDTRUTH": {}, // Read only by the gateway scorer's ordered consumers
 }
 
-// SetupStreams creates all required streams
-func SetupStreams(ctx context.Context, js jetstream.JetStream) error {
-	for name, cfg := range StreamConfigs {
-		_, err := js.Stream(ctx, name)
+// SetupStreams creates all required streams with the limits of any stream
+// policies applied. Existing streams with a policy have its limits updated;
+// their retention can only be changed by recreating them.
+func SetupStreams(ctx context.Context, js jetstream.JetStream, policies StreamPolicies) error {
+	names := make([]string, 0, len(StreamConfigs))
+	for name := range StreamConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cfg := policies.Apply(StreamConfigs[name])
+		stream, err := js.Stream(ctx, name)
 		if err == nil {
+			if _, ok := policies[name]; ok {
+				if err := updateStreamLimits(ctx, js, stream, cfg); err != nil {
+					return err
+				}
+			}
 			continue // Stream exists
 		}
 
@@ -130,19 +292,31 @@ func SetupStreams(ctx context.Context, js jetstream.JetStream) error {
 	return nil
 }
 
-// SetupConsumer creates a consumer for an agent
+// SetupConsumer creates a consumer for an agent. An existing consumer whose
+// subject filter differs from its configuration is updated to the configured
+// filter, so each stage only receives the message types it handles.
 func SetupConsumer(ctx context.Context, js jetstream.JetStream, streamName, consumerName string) (jetstream.Consumer, error) {
-	cfg, ok := ConsumerConfigs[consumerName]
-	if !ok {
-		cfg = jetstream.ConsumerConfig{
-			Durable:       consumerName,
-			AckPolicy:     jetstream.AckExplicitPolicy,
-			AckWait:       30 * time.Second,
-			MaxDeliver:    3,
-			MaxAckPending: 100,
-		}
+	return SetupConsumerConfig(ctx, js, streamName, ConsumerConfigFor(consumerName))
+}
+
+// ConsumerConfigFor returns the configuration of a named consumer, or the
+// defaults for one not in ConsumerConfigs
+func ConsumerConfigFor(consumerName string) jetstream.ConsumerConfig {
+	if cfg, ok := ConsumerConfigs[consumerName]; ok {
+		return cfg
+	}
+	return jetstream.ConsumerConfig{
+		Durable:       consumerName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       30 * time.Second,
+		MaxDeliver:    3,
+		MaxAckPending: 100,
 	}
+}
 
+// SetupConsumerConfig is SetupConsumer for a consumer configuration not in
+// ConsumerConfigs. Filters set as FilterSubjects are kept up to date too.
+func SetupConsumerConfig(ctx context.Context, js jetstream.JetStream, streamName string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error) {
 	stream, err := js.Stream(ctx, streamName)
 	if err != nil {
 		return nil, err
@@ -150,8 +324,110 @@ func SetupConsumer(ctx context.Context, js jetstream.JetStream, streamName, cons
 
 	consumer, err := stream.Consumer(ctx, cfg.Durable)
 	if err == nil {
+		// A durable created before its filter was set would otherwise keep
+		// delivering every subject on the stream, including the stage's own output
+		if info := consumer.CachedInfo(); info != nil && !sameFilter(info.Config, cfg) {
+			updated, err := stream.UpdateConsumer(ctx, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update filter of consumer %s from %q to %q: %w",
+					cfg.Durable, filterString(info.Config), filterString(cfg), err)
+			}
+			return updated, nil
+		}
 		return consumer, nil
 	}
 
 	return stream.CreateConsumer(ctx, cfg)
 }
+
+// ConsumerGone reports whether a fetch failed because the durable consumer no
+// longer exists, such as after an exercise reset deleted it, so it should be
+// recreated rather than fetched from again
+func ConsumerGone(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "no responders") || strings.Contains(msg, "consumer not found") || strings.Contains(msg, "consumer deleted")
+}
+
+// sameFilter reports whether two consumer configurations select the same subjects
+func sameFilter(a, b jetstream.ConsumerConfig) bool {
+	return filterString(a) == filterString(b)
+}
+
+func filterString(cfg jetstream.ConsumerConfig) string {
+	if len(cfg.FilterSubjects) > 0 {
+		return strings.Join(cfg.FilterSubjects, ",")
+	}
+	return cfg.FilterSubject
+}
+
+// PipelineStream returns the pipeline stream a durable consumer reads from
+func PipelineStream(consumerName string) (string, bool) {
+	for stream, consumers := range PipelineConsumers {
+		for _, name := range consumers {
+			if name == consumerName {
+				return stream, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ArchiveConsumers only record messages. They are left out of StreamBacklog so
+// a stopped or lagging archive never throttles the pipeline.
+var ArchiveConsumers = map[string]bool{
+	"detection-persister": true,
+}
+
+// StreamBacklog returns the largest number of messages any consumer of the stream
+// has yet to process, counting both undelivered and unacknowledged messages.
+// A stream without consumers has no backlog. Archive consumers are ignored.
+func StreamBacklog(ctx context.Context, js jetstream.JetStream, streamName string) (int, error) {
+	stream, err := js.Stream(ctx, streamName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream %s: %w", streamName, err)
+	}
+
+	backlog := 0
+	consumers := stream.ListConsumers(ctx)
+	for info := range consumers.Info() {
+		if ArchiveConsumers[info.Name] {
+			continue
+		}
+		if pending := int(info.NumPending) + info.NumAckPending; pending > backlog {
+			backlog = pending
+		}
+	}
+	if err := consumers.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list consumers for %s: %w", streamName, err)
+	}
+	return backlog, nil
+}
+
+// PurgeStream deletes the given consumers, discarding the messages they hold in
+// flight, then purges the stream. Agents recreate their consumers when they next
+// fetch. Returns the number of messages the stream held before the purge.
+func PurgeStream(ctx context.Context, js jetstream.JetStream, streamName string, consumers []string) (uint64, error) {
+	stream, err := js.Stream(ctx, streamName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream %s: %w", streamName, err)
+	}
+
+	for _, consumerName := range consumers {
+		err := stream.DeleteConsumer(ctx, consumerName)
+		if err != nil && !errors.Is(err, jetstream.ErrConsumerNotFound) {
+			return 0, fmt.Errorf("failed to delete consumer %s on %s: %w", consumerName, streamName, err)
+		}
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream info for %s: %w", streamName, err)
+	}
+	if err := stream.Purge(ctx); err != nil {
+		return 0, fmt.Errorf("failed to purge stream %s: %w", streamName, err)
+	}
+	return info.State.Msgs, nil
+}
@@ -5,28 +5,106 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-// Client is an OPA API client
+// Client is an OPA API client. Query results are cached for a short TTL and
+// a circuit breaker stops calls to an OPA that keeps failing, so a policy
+// outage costs callers one fast error instead of a timeout per check. In
+// embedded mode policies are evaluated in-process instead of over HTTP.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	embedded   *embeddedPolicies // nil in server mode
+	cache      *decisionCache    // nil when caching is disabled
+	breaker    *breaker          // nil when the breaker is disabled
+	fallback   string
+	metrics    *clientMetrics
+	now        func() time.Time
 }
 
-// NewClient creates a new OPA client
+// NewClient creates a new OPA client with the default cache and breaker settings
 func NewClient(baseURL string) *Client {
-	return &Client{
+	return NewClientWithOptions(baseURL, DefaultOptions())
+}
+
+// NewClientWithOptions creates a new OPA client with the given cache and breaker settings
+func NewClientWithOptions(baseURL string, opts Options) *Client {
+	c := &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
+		fallback: opts.Fallback,
+		metrics:  newClientMetrics(),
+		now:      time.Now,
+	}
+	if opts.CacheTTL > 0 {
+		size := opts.CacheSize
+		if size <= 0 {
+			size = DefaultCacheSize
+		}
+		c.cache = &decisionCache{ttl: opts.CacheTTL, size: size, entries: make(map[string]cacheEntry)}
 	}
+	if opts.BreakerThreshold > 0 {
+		c.breaker = &breaker{threshold: opts.BreakerThreshold, cooldown: opts.BreakerCooldown, metrics: c.metrics}
+	}
+	return c
 }
 
+// NewClientFromOptions creates a client in the mode selected by opts. In
+// embedded mode the policy bundle is loaded and compiled before returning.
+func NewClientFromOptions(ctx context.Context, baseURL string, opts Options) (*Client, error) {
+	c := NewClientWithOptions(baseURL, opts)
+	if opts.Mode == ModeEmbedded {
+		embedded, err := loadEmbeddedPolicies(ctx, opts.BundlePath)
+		if err != nil {
+			return nil, err
+		}
+		c.embedded = embedded
+	}
+	return c, nil
+}
+
+// NewEmbeddedClient creates a client that evaluates the policy bundle at
+// bundlePath in-process, with no OPA server
+func NewEmbeddedClient(ctx context.Context, bundlePath string) (*Client, error) {
+	opts := DefaultOptions()
+	opts.Mode = ModeEmbedded
+	opts.BundlePath = bundlePath
+	return NewClientFromOptions(ctx, "", opts)
+}
+
+// Collectors returns the client's cache and breaker metrics for registration
+func (c *Client) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.metrics.cacheLookups,
+		c.metrics.breakerState,
+		c.metrics.breakerTrips,
+		c.metrics.fallbacksUsed,
+	}
+}
+
+// ErrUnavailable marks errors caused by OPA being unreachable or failing, as
+// opposed to rejecting the query
+var ErrUnavailable = errors.New("OPA unavailable")
+
+// unavailableError wraps an error so it matches ErrUnavailable
+type unavailableError struct {
+	err error
+}
+
+func (e unavailableError) Error() string        { return e.err.Error() }
+func (e unavailableError) Unwrap() error        { return e.err }
+func (e unavailableError) Is(target error) bool { return target == ErrUnavailable }
+
 // Decision represents an OPA policy decision
 type Decision struct {
 	Allowed    bool                   `json:"allowed"`
@@ -46,15 +124,58 @@ type QueryResult struct {
 	Result map[string]interface{} `json:"result"`
 }
 
-// Query evaluates a policy and returns the result
+// Query evaluates a policy and returns the result. Identical queries within
+// the cache TTL are answered from the cache.
 func (c *Client) Query(ctx context.Context, path string, input interface{}) (*QueryResult, error) {
-	url := fmt.Sprintf("%s/v1/data/%s", c.baseURL, path)
-
 	body, err := json.Marshal(QueryInput{Input: input})
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal input: %w", err)
 	}
 
+	var key string
+	if c.cache != nil {
+		key = cacheKey(path, body)
+		if result, ok := c.cache.get(key, c.now()); ok {
+			c.metrics.cacheLookups.WithLabelValues("hit").Inc()
+			return result, nil
+		}
+		c.metrics.cacheLookups.WithLabelValues("miss").Inc()
+	}
+
+	if c.breaker != nil && !c.breaker.allow(c.now()) {
+		return nil, ErrCircuitOpen
+	}
+
+	var result *QueryResult
+	if c.embedded != nil {
+		result, err = c.embedded.eval(ctx, path, body)
+	} else {
+		result, err = c.query(ctx, path, body)
+	}
+	if c.breaker != nil {
+		switch {
+		case err == nil || !errors.Is(err, ErrUnavailable):
+			c.breaker.record(true, c.now()) // OPA answered, even if it rejected the query
+		case ctx.Err() != nil:
+			c.breaker.release() // The caller gave up; says nothing about OPA
+		default:
+			c.breaker.record(false, c.now())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		c.cache.put(key, result, c.now())
+	}
+	return result, nil
+}
+
+// query sends one query to OPA
+func (c *Client) query(ctx context.Context, path string, body []byte) (*QueryResult, error) {
+	url := fmt.Sprintf("%s/v1/data/%s", c.baseURL, path)
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -63,13 +184,17 @@ func (c *Client) Query(ctx context.Context, path string, input interface{}) (*Qu
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, unavailableError{fmt.Errorf("failed to send request: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OPA returned status %d: %s", resp.StatusCode, string(respBody))
+		err := fmt.Errorf("OPA returned status %d: %s", resp.StatusCode, string(respBody))
+		if resp.StatusCode >= 500 {
+			return nil, unavailableError{err}
+		}
+		return nil, err
 	}
 
 	var result QueryResult
@@ -80,10 +205,18 @@ func (c *Client) Query(ctx context.Context, path string, input interface{}) (*Qu
 	return &result, nil
 }
 
-// Decide evaluates a policy and returns a structured decision
+// Decide evaluates a policy and returns a structured decision. When OPA is
+// unavailable and a fallback decision is configured, the fallback is returned
+// instead of the error.
 func (c *Client) Decide(ctx context.Context, policyPath string, input interface{}) (*Decision, error) {
 	result, err := c.Query(ctx, policyPath, input)
 	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrUnavailable) {
+			if fallback := fallbackDecision(c.fallback, err); fallback != nil {
+				c.metrics.fallbacksUsed.WithLabelValues(c.fallback).Inc()
+				return fallback, nil
+			}
+		}
 		return nil, err
 	}
 
@@ -107,28 +240,8 @@ func (c *Client) Decide(ctx context.Context, policyPath string, input interface{
 			}
 		}
 
-		// Check multiple possible field names for deny reasons
-		if reasons, ok := result.Result["deny"].([]interface{}); ok {
-			for _, r := range reasons {
-				if s, ok := r.(string); ok {
-					decision.Reasons = append(decision.Reasons, s)
-				}
-			}
-		} else if reasons, ok := result.Result["deny"].(map[string]interface{}); ok {
-			for _, r := range reasons {
-				if s, ok := r.(string); ok {
-					decision.Reasons = append(decision.Reasons, s)
-				}
-			}
-		}
-
-		if warnings, ok := result.Result["warnings"].([]interface{}); ok {
-			for _, w := range warnings {
-				if s, ok := w.(string); ok {
-					decision.Warnings = append(decision.Warnings, s)
-				}
-			}
-		}
+		decision.Reasons = ruleMessages(result.Result["deny"])
+		decision.Warnings = ruleMessages(result.Result["warnings"])
 
 		// Store full result as metadata
 		decision.Metadata["raw_result"] = result.Result
@@ -137,6 +250,35 @@ func (c *Client) Decide(ctx context.Context, policyPath string, input interface{
 	return decision, nil
 }
 
+// ruleMessages collects the messages of a deny or warnings rule. Rules written
+// as deny[msg] if {...} evaluate to an object keyed by message; older rules
+// evaluate to an array or an object of messages.
+func ruleMessages(v interface{}) []string {
+	var msgs []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, m := range v {
+			if s, ok := m.(string); ok {
+				msgs = append(msgs, s)
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if s, ok := v[k].(string); ok {
+				msgs = append(msgs, s)
+			} else {
+				msgs = append(msgs, k)
+			}
+		}
+	}
+	return msgs
+}
+
 // CheckOrigin validates message origin using the origin attestation policy
 func (c *Client) CheckOrigin(ctx context.Context, envelope interface{}) (*Decision, error) {
 	input := map[string]interface{}{
@@ -180,8 +322,31 @@ func (c *Client) CheckEffectRelease(ctx context.Context, decision, proposal inte
 	return c.Decide(ctx, "cjadc2/effects", input)
 }
 
-// Health checks if OPA is healthy
+// CheckApprovalAuthority evaluates whether a user's role, or one of their
+// break-glass grants, allows them to approve an action. It returns the
+// policy's decision document.
+func (c *Client) CheckApprovalAuthority(ctx context.Context, userID, actionType string, grants interface{}) (map[string]interface{}, error) {
+	input := map[string]interface{}{
+		"user_id":     userID,
+		"action_type": actionType,
+		"break_glass": grants,
+	}
+	result, err := c.Query(ctx, "cjadc2/authority/decision", input)
+	if err != nil {
+		return nil, err
+	}
+	if result.Result == nil {
+		return nil, fmt.Errorf("approval authority policy returned no decision")
+	}
+	return result.Result, nil
+}
+
+// Health checks if OPA is healthy. Embedded policies are always available.
 func (c *Client) Health(ctx context.Context) error {
+	if c.embedded != nil {
+		return nil
+	}
+
 	url := fmt.Sprintf("%s/health", c.baseURL)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
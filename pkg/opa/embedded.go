@@ -0,0 +1,133 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/loader"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Evaluation modes
+const (
+	ModeServer   = "server"   // Query an OPA server over HTTP
+	ModeEmbedded = "embedded" // Compile and evaluate the policy bundle in-process
+)
+
+// DefaultBundlePath is the policy bundle compiled in embedded mode
+const DefaultBundlePath = "policies/bundles/cjadc2"
+
+// DefaultBundleName is the name the embedded bundle is evaluated under
+const DefaultBundleName = "cjadc2"
+
+// embeddedPolicies evaluates a policy bundle in-process. Queries are prepared
+// on first use of each policy path and reused afterwards.
+type embeddedPolicies struct {
+	bundle *bundle.Bundle
+
+	mu      sync.Mutex
+	queries map[string]rego.PreparedEvalQuery
+}
+
+// loadEmbeddedPolicies loads and compiles the bundle at path, so policy errors
+// surface at startup rather than on the first decision
+func loadEmbeddedPolicies(ctx context.Context, path string) (*embeddedPolicies, error) {
+	b, err := loader.NewFileLoader().AsBundle(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy bundle %s: %w", path, err)
+	}
+
+	e := &embeddedPolicies{
+		bundle:  b,
+		queries: make(map[string]rego.PreparedEvalQuery),
+	}
+	if _, err := e.prepare(ctx, ""); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// prepare returns the compiled query for a policy path such as "cjadc2/proposals"
+func (e *embeddedPolicies) prepare(ctx context.Context, path string) (rego.PreparedEvalQuery, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if q, ok := e.queries[path]; ok {
+		return q, nil
+	}
+
+	query := "data"
+	if path != "" {
+		query += "." + strings.ReplaceAll(strings.Trim(path, "/"), "/", ".")
+	}
+	q, err := rego.New(
+		rego.Query(query),
+		rego.ParsedBundle(DefaultBundleName, e.bundle),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, fmt.Errorf("failed to compile policy %s: %w", query, err)
+	}
+	e.queries[path] = q
+	return q, nil
+}
+
+// eval evaluates a policy path against a marshaled QueryInput, returning the
+// same result an OPA server would for POST /v1/data/<path>
+func (e *embeddedPolicies) eval(ctx context.Context, path string, body []byte) (*QueryResult, error) {
+	q, err := e.prepare(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the marshaled input so structs are seen exactly as the server sees them
+	var doc struct {
+		Input interface{} `json:"input"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode input: %w", err)
+	}
+
+	rs, err := q.Eval(ctx, rego.EvalInput(doc.Input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy %s: %w", path, err)
+	}
+
+	var result QueryResult
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return &result, nil // Undefined, as the server reports it
+	}
+
+	// Round-trip through JSON so numbers and nested values decode exactly as
+	// they do from a server response
+	data, err := json.Marshal(map[string]interface{}{"result": rs[0].Expressions[0].Value})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode result: %w", err)
+	}
+	return &result, nil
+}
+
+// status reports the embedded bundle, which is compiled and so always healthy
+func (e *embeddedPolicies) status() *Status {
+	status := &Status{Mode: ModeEmbedded, Healthy: true, Policies: []string{}}
+	b := BundleStatus{Name: DefaultBundleName, Revision: e.bundle.Manifest.Revision}
+	if e.bundle.Manifest.Roots != nil {
+		b.Roots = *e.bundle.Manifest.Roots
+	}
+	status.Bundles = []BundleStatus{b}
+	for _, m := range e.bundle.Modules {
+		status.Policies = append(status.Policies, m.Path)
+	}
+	sort.Strings(status.Policies)
+	return status
+}
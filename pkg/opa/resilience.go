@@ -0,0 +1,312 @@
+package opa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Defaults for the decision cache and circuit breaker
+const (
+	DefaultCacheTTL         = 10 * time.Second
+	DefaultCacheSize        = 10000
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// Fallback decisions used when OPA is unavailable
+const (
+	FallbackNone  = "none"  // Return the error; callers apply their own handling
+	FallbackAllow = "allow" // Allow, with a warning recording the fallback
+	FallbackDeny  = "deny"  // Deny, with a reason recording the fallback
+)
+
+// Circuit breaker states, also the values of the opa_breaker_state gauge
+const (
+	BreakerClosed   = 0
+	BreakerHalfOpen = 1
+	BreakerOpen     = 2
+)
+
+// ErrCircuitOpen is returned without contacting OPA while the breaker is open
+var ErrCircuitOpen = errors.New("OPA circuit breaker open")
+
+// Options configures the decision cache and circuit breaker
+type Options struct {
+	CacheTTL         time.Duration // Zero disables caching
+	CacheSize        int
+	BreakerThreshold int           // Consecutive failures that open the breaker; zero disables it
+	BreakerCooldown  time.Duration // Time the breaker stays open before a probe is let through
+	Fallback         string        // FallbackNone, FallbackAllow, or FallbackDeny
+	Mode             string        // ModeServer or ModeEmbedded
+	BundlePath       string        // Policy bundle compiled in embedded mode
+}
+
+// DefaultOptions returns the default cache and breaker settings
+func DefaultOptions() Options {
+	return Options{
+		CacheTTL:         DefaultCacheTTL,
+		CacheSize:        DefaultCacheSize,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+		Fallback:         FallbackNone,
+		Mode:             ModeServer,
+		BundlePath:       DefaultBundlePath,
+	}
+}
+
+// ParseOptions parses OPA_CACHE_TTL, OPA_BREAKER_THRESHOLD,
+// OPA_BREAKER_COOLDOWN, and OPA_FALLBACK_DECISION, using the defaults for
+// unset values
+func ParseOptions(cacheTTL, breakerThreshold, breakerCooldown, fallback string) (Options, error) {
+	opts := DefaultOptions()
+	if cacheTTL != "" {
+		d, err := time.ParseDuration(cacheTTL)
+		if err != nil || d < 0 {
+			return opts, fmt.Errorf("invalid OPA_CACHE_TTL %q: must be a non-negative duration", cacheTTL)
+		}
+		opts.CacheTTL = d
+	}
+	if breakerThreshold != "" {
+		n, err := strconv.Atoi(breakerThreshold)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid OPA_BREAKER_THRESHOLD %q: must be a non-negative integer", breakerThreshold)
+		}
+		opts.BreakerThreshold = n
+	}
+	if breakerCooldown != "" {
+		d, err := time.ParseDuration(breakerCooldown)
+		if err != nil || d <= 0 {
+			return opts, fmt.Errorf("invalid OPA_BREAKER_COOLDOWN %q: must be a positive duration", breakerCooldown)
+		}
+		opts.BreakerCooldown = d
+	}
+	if fallback != "" {
+		switch f := strings.ToLower(fallback); f {
+		case FallbackNone, FallbackAllow, FallbackDeny:
+			opts.Fallback = f
+		default:
+			return opts, fmt.Errorf("invalid OPA_FALLBACK_DECISION %q: must be none, allow, or deny", fallback)
+		}
+	}
+	return opts, nil
+}
+
+// ParseMode parses OPA_MODE, defaulting to server mode
+func ParseMode(mode string) (string, error) {
+	switch m := strings.ToLower(mode); m {
+	case "":
+		return ModeServer, nil
+	case ModeServer, ModeEmbedded:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid OPA_MODE %q: must be server or embedded", mode)
+	}
+}
+
+// OptionsFromVars parses the client options from agent config variables
+func OptionsFromVars(vars map[string]string) (Options, error) {
+	opts, err := ParseOptions(vars["OPA_CACHE_TTL"], vars["OPA_BREAKER_THRESHOLD"], vars["OPA_BREAKER_COOLDOWN"], vars["OPA_FALLBACK_DECISION"])
+	if err != nil {
+		return opts, err
+	}
+	if opts.Mode, err = ParseMode(vars["OPA_MODE"]); err != nil {
+		return opts, err
+	}
+	if path := vars["OPA_BUNDLE_PATH"]; path != "" {
+		opts.BundlePath = path
+	}
+	return opts, nil
+}
+
+// clientMetrics are the cache and breaker metrics of one client
+type clientMetrics struct {
+	cacheLookups  *prometheus.CounterVec
+	breakerState  prometheus.Gauge
+	breakerTrips  prometheus.Counter
+	fallbacksUsed *prometheus.CounterVec
+}
+
+func newClientMetrics() *clientMetrics {
+	return &clientMetrics{
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "opa_cache_lookups_total",
+			Help: "OPA decision cache lookups by result (hit, miss)",
+		}, []string{"result"}),
+		breakerState: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "opa_breaker_state",
+			Help: "OPA circuit breaker state (0=closed, 1=half-open, 2=open)",
+		}),
+		breakerTrips: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "opa_breaker_trips_total",
+			Help: "Times the OPA circuit breaker opened",
+		}),
+		fallbacksUsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "opa_fallback_decisions_total",
+			Help: "Fallback decisions returned while OPA was unavailable, by decision",
+		}, []string{"decision"}),
+	}
+}
+
+// decisionCache holds query results keyed on a hash of the policy path and input
+type decisionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result    *QueryResult
+	expiresAt time.Time
+}
+
+// cacheKey hashes a policy path and its marshaled query body
+func cacheKey(path string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(path))
+	sum.Write([]byte{0})
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+func (c *decisionCache) get(key string, now time.Time) (*QueryResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		return nil, false
+	}
+	return e.result, true
+}
+
+// clear drops every cached result
+func (c *decisionCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+func (c *decisionCache) put(key string, result *QueryResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= c.size {
+		// Still full of live entries: evict the one closest to expiry
+		var oldest string
+		for k, e := range c.entries {
+			if oldest == "" || e.expiresAt.Before(c.entries[oldest].expiresAt) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cacheEntry{result: result, expiresAt: now.Add(c.ttl)}
+}
+
+// breaker is a consecutive-failure circuit breaker. After threshold failures
+// it opens and refuses calls for the cooldown, then lets a single probe
+// through; the probe's outcome closes or reopens it.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     int
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	metrics   *clientMetrics
+}
+
+// allow reports whether a call may be made at now
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record reports the outcome of an allowed call
+func (b *breaker) record(ok bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		if b.state != BreakerOpen {
+			b.metrics.breakerTrips.Inc()
+		}
+		b.openedAt = now
+		b.setState(BreakerOpen)
+	}
+}
+
+// release ends an allowed call without judging OPA, such as one the caller canceled
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if b.state == BreakerHalfOpen {
+		b.setState(BreakerOpen) // Keep the cooldown elapsed so the next call probes
+	}
+}
+
+// setState changes state and updates the gauge. The caller holds b.mu.
+func (b *breaker) setState(state int) {
+	b.state = state
+	b.metrics.breakerState.Set(float64(state))
+}
+
+// fallbackDecision returns the configured fallback for a failed policy check,
+// or nil when callers should receive the error
+func fallbackDecision(fallback string, cause error) *Decision {
+	switch fallback {
+	case FallbackAllow:
+		return &Decision{
+			Allowed:  true,
+			Warnings: []string{fmt.Sprintf("OPA unavailable, fallback decision allow: %v", cause)},
+			Metadata: map[string]interface{}{"fallback": true},
+		}
+	case FallbackDeny:
+		return &Decision{
+			Allowed:  false,
+			Reasons:  []string{fmt.Sprintf("OPA unavailable, fallback decision deny: %v", cause)},
+			Metadata: map[string]interface{}{"fallback": true},
+		}
+	default:
+		return nil
+	}
+}
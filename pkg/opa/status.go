@@ -0,0 +1,159 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrEmbeddedData is returned when pushing data to a client in embedded mode,
+// whose policy data is compiled in from the bundle
+var ErrEmbeddedData = errors.New("policy data cannot be pushed in embedded mode")
+
+// BundleStatus describes an activated policy bundle
+type BundleStatus struct {
+	Name     string   `json:"name"`
+	Revision string   `json:"revision"`
+	Roots    []string `json:"roots,omitempty"` // Data paths the bundle owns; others may be pushed
+}
+
+// Status reports the policies OPA is enforcing
+type Status struct {
+	Mode     string         `json:"mode"`
+	Healthy  bool           `json:"healthy"`
+	Error    string         `json:"error,omitempty"`
+	Bundles  []BundleStatus `json:"bundles"`
+	Policies []string       `json:"policies"` // IDs of the loaded policy modules
+}
+
+// Mode returns the client's evaluation mode
+func (c *Client) Mode() string {
+	if c.embedded != nil {
+		return ModeEmbedded
+	}
+	return ModeServer
+}
+
+// Status reports bundle revisions, loaded modules, and health. It bypasses the
+// cache and circuit breaker, so it shows OPA as it is now. An unreachable OPA
+// is reported as unhealthy rather than as an error.
+func (c *Client) Status(ctx context.Context) *Status {
+	if c.embedded != nil {
+		return c.embedded.status()
+	}
+
+	status := &Status{Mode: ModeServer, Bundles: []BundleStatus{}, Policies: []string{}}
+
+	// bundles=true fails the check until every configured bundle has activated
+	if err := c.get(ctx, "/health?bundles=true", nil); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Healthy = true
+
+	var bundles struct {
+		Result map[string]struct {
+			Manifest struct {
+				Revision string    `json:"revision"`
+				Roots    *[]string `json:"roots"`
+			} `json:"manifest"`
+		} `json:"result"`
+	}
+	if err := c.get(ctx, "/v1/data/system/bundles", &bundles); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	for name, b := range bundles.Result {
+		bs := BundleStatus{Name: name, Revision: b.Manifest.Revision}
+		if b.Manifest.Roots != nil {
+			bs.Roots = *b.Manifest.Roots
+		}
+		status.Bundles = append(status.Bundles, bs)
+	}
+	sort.Slice(status.Bundles, func(i, j int) bool { return status.Bundles[i].Name < status.Bundles[j].Name })
+
+	var policies struct {
+		Result []struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := c.get(ctx, "/v1/policies", &policies); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	for _, p := range policies.Result {
+		status.Policies = append(status.Policies, p.ID)
+	}
+	sort.Strings(status.Policies)
+
+	return status
+}
+
+// PutData replaces the data document at path, such as "external/zones", with
+// doc. The path must lie outside the roots owned by the bundle. Cached
+// decisions are dropped, since they may depend on the old document.
+func (c *Client) PutData(ctx context.Context, path string, doc interface{}) error {
+	if c.embedded != nil {
+		return ErrEmbeddedData
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data document: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/data/%s", c.baseURL, strings.Trim(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return unavailableError{fmt.Errorf("failed to send request: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("OPA refused data at %s: status %d: %s", path, resp.StatusCode, string(respBody))
+	}
+
+	if c.cache != nil {
+		c.cache.clear()
+	}
+	return nil
+}
+
+// get fetches an OPA API path, decoding the response into out when it is not nil
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("OPA returned status %d for %s: %s", resp.StatusCode, path, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package picture persists the correlator's fused track picture in JetStream KV.
+//
+// Each track in the correlation window is stored under its own key together
+// with the last correlated track fused from it. A restarted correlator reloads
+// the window from the bucket instead of starting empty, and the gateway serves
+// the stored correlated tracks as the common operating picture.
+package picture
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Bucket is the KV bucket holding the track picture
+const Bucket = "TRACK_PICTURE"
+
+// BucketTTL bounds how long an entry survives if no correlator is running to
+// remove it when it leaves the window
+const BucketTTL = 10 * time.Minute
+
+// Entry is one track in the correlation window
+type Entry struct {
+	Track      *messages.Track           `json:"track"`
+	Correlated *messages.CorrelatedTrack `json:"correlated,omitempty"` // Last correlated track fused from Track
+	ExpiresAt  time.Time                 `json:"expires_at"`           // Simulated time the track leaves the window
+	Merged     bool                      `json:"merged"`               // Already merged into another track
+}
+
+// Store is the subset of jetstream.KeyValue used by the picture
+type Store interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+	Delete(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error
+	Keys(ctx context.Context, opts ...jetstream.WatchOpt) ([]string, error)
+}
+
+// Picture reads and writes window entries in a KV store
+type Picture struct {
+	store Store
+}
+
+// New creates a picture backed by store
+func New(store Store) *Picture {
+	return &Picture{store: store}
+}
+
+// EnsureBucket returns the picture bucket, creating it if needed
+func EnsureBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, Bucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, jetstream.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get picture bucket: %w", err)
+	}
+
+	kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      Bucket,
+		Description: "Correlation window and fused common operating picture",
+		History:     1,
+		TTL:         BucketTTL,
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create picture bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// Key returns the KV key for a track ID, replacing characters KV keys do not allow
+func Key(trackID string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '=':
+			return r
+		}
+		return '_'
+	}, trackID)
+}
+
+// Put stores an entry under its track ID
+func (p *Picture) Put(ctx context.Context, entry Entry) error {
+	if entry.Track == nil || entry.Track.TrackID == "" {
+		return fmt.Errorf("picture entry has no track ID")
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal picture entry: %w", err)
+	}
+	if _, err := p.store.Put(ctx, Key(entry.Track.TrackID), data); err != nil {
+		return fmt.Errorf("failed to store picture entry %s: %w", entry.Track.TrackID, err)
+	}
+	return nil
+}
+
+// Delete removes a track from the picture
+func (p *Picture) Delete(ctx context.Context, trackID string) error {
+	if err := p.store.Delete(ctx, Key(trackID)); err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+		return fmt.Errorf("failed to delete picture entry %s: %w", trackID, err)
+	}
+	return nil
+}
+
+// Load returns every stored entry. Entries that cannot be decoded are skipped.
+func (p *Picture) Load(ctx context.Context) ([]Entry, error) {
+	keys, err := p.store.Keys(ctx)
+	if errors.Is(err, jetstream.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list picture keys: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		kve, err := p.store.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue // Deleted since the keys were listed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get picture entry %s: %w", key, err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(kve.Value(), &entry); err != nil || entry.Track == nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Tracks returns the fused correlated tracks in the picture, most threatening
+// first. Tracks merged into another track are left out.
+func (p *Picture) Tracks(ctx context.Context) ([]*messages.CorrelatedTrack, error) {
+	entries, err := p.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]*messages.CorrelatedTrack, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Merged || entry.Correlated == nil {
+			continue
+		}
+		tracks = append(tracks, entry.Correlated)
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].ThreatScore != tracks[j].ThreatScore {
+			return tracks[i].ThreatScore > tracks[j].ThreatScore
+		}
+		return tracks[i].TrackID < tracks[j].TrackID
+	})
+	return tracks, nil
+}
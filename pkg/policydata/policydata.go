@@ -0,0 +1,165 @@
+// Package policydata keeps policy data documents in OPA in step with their
+// source of truth in PostgreSQL. Documents such as the protected zones and
+// the active break-glass grants are pushed through OPA's data API under
+// data.external, outside the roots owned by the policy bundle, so policies can
+// consult them without a bundle rebuild.
+package policydata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// Root is the data path documents are pushed under
+const Root = "external"
+
+// MinInterval is the shortest periodic sync allowed
+const MinInterval = 5 * time.Second
+
+// ParseInterval parses POLICY_DATA_SYNC_INTERVAL. Unset or zero disables
+// periodic sync; documents can still be pushed on request.
+func ParseInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 || (d > 0 && d < MinInterval) {
+		return 0, fmt.Errorf("invalid POLICY_DATA_SYNC_INTERVAL %q: must be 0 or a duration of at least %s", value, MinInterval)
+	}
+	return d, nil
+}
+
+// Store loads the documents to push, keyed by name
+type Store interface {
+	PolicyDocuments(ctx context.Context) (map[string]interface{}, error)
+}
+
+// Pusher writes a data document into OPA
+type Pusher interface {
+	PutData(ctx context.Context, path string, doc interface{}) error
+}
+
+// Result describes one sync
+type Result struct {
+	SyncedAt  time.Time `json:"synced_at"`
+	Documents []string  `json:"documents"` // Paths written, such as external/zones
+	Error     string    `json:"error,omitempty"`
+}
+
+// Syncer pushes the store's documents into OPA on request and, when an
+// interval is set, periodically
+type Syncer struct {
+	store    Store
+	pusher   Pusher
+	interval time.Duration
+	logger   zerolog.Logger
+
+	mu   sync.Mutex
+	last *Result
+
+	syncs *prometheus.CounterVec
+}
+
+// New creates a syncer. An interval of zero disables periodic sync.
+func New(store Store, pusher Pusher, interval time.Duration, logger zerolog.Logger) *Syncer {
+	return &Syncer{
+		store:    store,
+		pusher:   pusher,
+		interval: interval,
+		logger:   logger.With().Str("component", "policy_data_sync").Logger(),
+		syncs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "policy_data_syncs_total",
+			Help: "Pushes of policy data documents into OPA, by result (ok, error)",
+		}, []string{"result"}),
+	}
+}
+
+// Interval returns the periodic sync interval, zero when disabled
+func (s *Syncer) Interval() time.Duration {
+	return s.interval
+}
+
+// Collectors returns the syncer metrics for registration
+func (s *Syncer) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{s.syncs}
+}
+
+// Last returns the most recent sync, or nil before the first
+func (s *Syncer) Last() *Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return nil
+	}
+	last := *s.last
+	return &last
+}
+
+// Sync loads every document and pushes each to Root/<name>. It stops at the
+// first failure; documents already pushed stay in OPA.
+func (s *Syncer) Sync(ctx context.Context) (Result, error) {
+	result := Result{SyncedAt: time.Now().UTC(), Documents: []string{}}
+	err := s.sync(ctx, &result)
+	if err != nil {
+		result.Error = err.Error()
+		s.syncs.WithLabelValues("error").Inc()
+	} else {
+		s.syncs.WithLabelValues("ok").Inc()
+	}
+
+	s.mu.Lock()
+	s.last = &result
+	s.mu.Unlock()
+	return result, err
+}
+
+func (s *Syncer) sync(ctx context.Context, result *Result) error {
+	docs, err := s.store.PolicyDocuments(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load policy data: %w", err)
+	}
+
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := Root + "/" + name
+		if err := s.pusher.PutData(ctx, path, docs[name]); err != nil {
+			return fmt.Errorf("failed to push %s: %w", path, err)
+		}
+		result.Documents = append(result.Documents, path)
+	}
+	return nil
+}
+
+// Run syncs at startup and then on the interval until ctx is cancelled. It
+// returns at once when periodic sync is disabled.
+func (s *Syncer) Run(ctx context.Context) {
+	if s.interval == 0 {
+		return
+	}
+	s.logger.Info().Dur("interval", s.interval).Msg("Syncing policy data to OPA")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn().Err(err).Msg("Policy data sync failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
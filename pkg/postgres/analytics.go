@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+)
+
+// HeatmapFilter selects the detections aggregated into a heatmap
+type HeatmapFilter struct {
+	BBox  geo.BBox
+	Cell  float64 // Cell edge in degrees of latitude and longitude
+	Since time.Time
+	Until *time.Time
+}
+
+// HeatmapGrid returns the rows and columns of cells covering the filter's box
+func (f HeatmapFilter) HeatmapGrid() (rows, cols int) {
+	lonSpan := f.BBox.MaxLon - f.BBox.MinLon
+	if f.BBox.CrossesAntimeridian() {
+		lonSpan += 360
+	}
+	rows = int((f.BBox.MaxLat-f.BBox.MinLat)/f.Cell) + 1
+	cols = int(lonSpan/f.Cell) + 1
+	return rows, cols
+}
+
+// HeatmapCell is one grid cell holding detections. Row and Col count cells
+// from the box's south-west corner.
+type HeatmapCell struct {
+	Row    int     `json:"row"`
+	Col    int     `json:"col"`
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+	Count  int64   `json:"count"`
+
+	// Classification of the correlated tracks most detections in the cell
+	// belong to; unknown for detections not yet correlated
+	DominantClassification string `json:"dominant_classification"`
+	DominantCount          int64  `json:"dominant_count"`
+}
+
+// DetectionHeatmap counts the detections in each cell of a grid over the
+// filter's box, with the dominant track classification per cell. Cells
+// without detections are omitted.
+func (p *Pool) DetectionHeatmap(ctx context.Context, filter HeatmapFilter) ([]HeatmapCell, error) {
+	box := filter.BBox
+	args := []interface{}{box.MinLat, box.MinLon, filter.Cell, filter.Since}
+	where := "d.created_at >= $4"
+	if filter.Until != nil {
+		args = append(args, *filter.Until)
+		where += fmt.Sprintf(" AND d.created_at < $%d", len(args))
+	}
+	clause, boxArgs := p.SpatialBackend().BBoxPredicate("d.position_lat", "d.position_lon", box, len(args)+1)
+	where += " AND " + clause
+	args = append(args, boxArgs...)
+
+	// Longitudes west of the box's minimum lie past the antimeridian
+	query := `
+		WITH binned AS (
+			SELECT
+				floor((d.position_lat - $1) / $3)::int AS cell_row,
+				floor((CASE WHEN d.position_lon < $2 THEN d.position_lon + 360 ELSE d.position_lon END - $2) / $3)::int AS cell_col,
+				COALESCE(t.classification::text, 'unknown') AS classification
+			FROM detections d
+			LEFT JOIN LATERAL (
+				SELECT classification FROM tracks
+				WHERE sensor_track_ids @> ARRAY[d.external_track_id::text] OR external_track_id = d.external_track_id
+				ORDER BY last_updated DESC
+				LIMIT 1
+			) t ON TRUE
+			WHERE ` + where + `
+		),
+		per_class AS (
+			SELECT cell_row, cell_col, classification, COUNT(*) AS n
+			FROM binned
+			GROUP BY cell_row, cell_col, classification
+		)
+		SELECT DISTINCT ON (cell_row, cell_col)
+			cell_row, cell_col,
+			SUM(n) OVER (PARTITION BY cell_row, cell_col)::bigint,
+			classification, n
+		FROM per_class
+		ORDER BY cell_row, cell_col, n DESC, classification
+	`
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate detection heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []HeatmapCell
+	for rows.Next() {
+		var c HeatmapCell
+		if err := rows.Scan(&c.Row, &c.Col, &c.Count, &c.DominantClassification, &c.DominantCount); err != nil {
+			return nil, fmt.Errorf("failed to scan heatmap cell: %w", err)
+		}
+		c.MinLat = box.MinLat + float64(c.Row)*filter.Cell
+		c.MaxLat = c.MinLat + filter.Cell
+		c.MinLon = wrapLon(box.MinLon + float64(c.Col)*filter.Cell)
+		c.MaxLon = wrapLon(c.MinLon + filter.Cell)
+		cells = append(cells, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating heatmap cells: %w", err)
+	}
+	return cells, nil
+}
+
+// wrapLon maps a longitude past the antimeridian back into [-180, 180]
+func wrapLon(lon float64) float64 {
+	if lon > 180 {
+		return lon - 360
+	}
+	return lon
+}
+
+// ApproverStatsFilter selects the decisions summarized per approver
+type ApproverStatsFilter struct {
+	From       *time.Time
+	To         *time.Time
+	ApprovedBy string
+	ActionType string
+}
+
+// DecisionStats summarizes a set of human decisions. Latency runs from the
+// proposal's creation to the decision.
+type DecisionStats struct {
+	Decisions            int64    `json:"decisions"`
+	Approved             int64    `json:"approved"`
+	Denied               int64    `json:"denied"`
+	ApprovalRate         float64  `json:"approval_rate"`
+	MedianLatencySeconds *float64 `json:"median_latency_seconds,omitempty"`
+	P90LatencySeconds    *float64 `json:"p90_latency_seconds,omitempty"`
+}
+
+// ActionTypeStats is an approver's decisions on one action type
+type ActionTypeStats struct {
+	ActionType string `json:"action_type"`
+	DecisionStats
+}
+
+// ApproverStats is one approver's decisions overall and by action type
+type ApproverStats struct {
+	ApprovedBy string `json:"approved_by"`
+	DecisionStats
+	Revoked         int64             `json:"revoked"`
+	FirstDecisionAt time.Time         `json:"first_decision_at"`
+	LastDecisionAt  time.Time         `json:"last_decision_at"`
+	ByActionType    []ActionTypeStats `json:"by_action_type"`
+}
+
+// GetApproverStats summarizes human decisions per approver, most active first.
+// Auto-approvals are attributed to a rule rather than a person and are left out.
+func (p *Pool) GetApproverStats(ctx context.Context, filter ApproverStatsFilter) ([]ApproverStats, error) {
+	where := "NOT d.machine_approved"
+	args := []interface{}{}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where += fmt.Sprintf(" AND d.approved_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where += fmt.Sprintf(" AND d.approved_at < $%d", len(args))
+	}
+	if filter.ApprovedBy != "" {
+		args = append(args, filter.ApprovedBy)
+		where += fmt.Sprintf(" AND d.approved_by = $%d", len(args))
+	}
+	if filter.ActionType != "" {
+		args = append(args, filter.ActionType)
+		where += fmt.Sprintf(" AND d.action_type = $%d", len(args))
+	}
+
+	// One row per approver, then one per approver and action type
+	query := `
+		SELECT
+			d.approved_by,
+			COALESCE(d.action_type, ''),
+			GROUPING(d.action_type) = 1,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE d.approved),
+			COUNT(*) FILTER (WHERE d.revoked_at IS NOT NULL),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM d.approved_at - p.created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM d.approved_at - p.created_at)),
+			MIN(d.approved_at),
+			MAX(d.approved_at)
+		FROM decisions d
+		LEFT JOIN proposals p ON p.proposal_id = d.proposal_id
+		WHERE ` + where + `
+		GROUP BY GROUPING SETS ((d.approved_by), (d.approved_by, d.action_type))
+		ORDER BY d.approved_by, GROUPING(d.action_type) DESC, COUNT(*) DESC, d.action_type
+	`
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate approver stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []ApproverStats
+	for rows.Next() {
+		var (
+			approver, actionType string
+			overall              bool
+			s                    DecisionStats
+			revoked              int64
+			first, last          time.Time
+		)
+		if err := rows.Scan(&approver, &actionType, &overall, &s.Decisions, &s.Approved, &revoked,
+			&s.MedianLatencySeconds, &s.P90LatencySeconds, &first, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan approver stats: %w", err)
+		}
+		s.Denied = s.Decisions - s.Approved
+		s.ApprovalRate = float64(s.Approved) / float64(s.Decisions)
+
+		// The approver's overall row sorts ahead of its action types
+		if overall {
+			stats = append(stats, ApproverStats{
+				ApprovedBy:      approver,
+				DecisionStats:   s,
+				Revoked:         revoked,
+				FirstDecisionAt: first,
+				LastDecisionAt:  last,
+				ByActionType:    []ActionTypeStats{},
+			})
+			continue
+		}
+		if n := len(stats); n > 0 && stats[n-1].ApprovedBy == approver {
+			stats[n-1].ByActionType = append(stats[n-1].ByActionType, ActionTypeStats{ActionType: actionType, DecisionStats: s})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating approver stats: %w", err)
+	}
+
+	sort.SliceStable(stats, func(i, k int) bool { return stats[i].Decisions > stats[k].Decisions })
+	return stats, nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// archiveTables maps each table the retention job archives to its archive,
+// which is range-partitioned by month of created_at
+var archiveTables = map[string]string{
+	"detections": "detections_archive",
+	"effects":    "effects_archive",
+}
+
+// archivableEffect selects effects that may be archived: only pending effects
+// may still be claimed and executed
+const archivableEffect = "status <> 'pending'"
+
+// CountArchivable counts the rows of detections or effects created before
+// cutoff that the retention job would archive
+func (p *Pool) CountArchivable(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	query := "SELECT COUNT(*) FROM detections WHERE created_at < $1"
+	switch table {
+	case "detections":
+	case "effects":
+		query = "SELECT COUNT(*) FROM effects WHERE created_at < $1 AND " + archivableEffect
+	default:
+		return 0, fmt.Errorf("table %q is not archived", table)
+	}
+
+	var count int64
+	if err := p.QueryRow(ctx, query, cutoff).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count archivable %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// ArchiveDetections moves up to limit of the oldest detections created before
+// cutoff to detections_archive, returning how many were moved. Whole expired
+// days are cheaper to move with ArchiveDetectionPartition; this covers the
+// rest of the day holding cutoff and rows in detections_default.
+func (p *Pool) ArchiveDetections(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	if err := p.ensureArchivePartitions(ctx, "detections", cutoff); err != nil {
+		return 0, err
+	}
+
+	tag, err := p.Exec(ctx, `
+		WITH moved AS (
+			DELETE FROM detections
+			WHERE (detection_id, created_at) IN (
+				SELECT detection_id, created_at FROM detections
+				WHERE created_at < $1
+				ORDER BY created_at
+				LIMIT $2
+			)
+			RETURNING *
+		)
+		INSERT INTO detections_archive SELECT * FROM moved
+	`, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive detections: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ArchiveEffects moves up to limit of the oldest finished effects created
+// before cutoff to effects_archive, returning how many were moved. Chained
+// effects are copied before they are deleted, which the audit chain guard
+// requires, and stay verifiable through the effects_all view.
+func (p *Pool) ArchiveEffects(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	if err := p.ensureArchivePartitions(ctx, "effects", cutoff); err != nil {
+		return 0, err
+	}
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL cjadc2.audit_archive = 'on'"); err != nil {
+		return 0, fmt.Errorf("failed to enable audit archive: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH batch AS (
+			SELECT effect_id FROM effects
+			WHERE created_at < $1 AND `+archivableEffect+`
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		INSERT INTO effects_archive
+		SELECT e.* FROM effects e JOIN batch USING (effect_id)
+		RETURNING effect_id::text
+	`, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy effects to archive: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan archived effect: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to copy effects to archive: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	tag, err := tx.Exec(ctx, "DELETE FROM effects WHERE effect_id = ANY($1::uuid[])", ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived effects: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit archived effects: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ensureArchivePartitions creates the monthly archive partitions for every
+// row of table created before cutoff
+func (p *Pool) ensureArchivePartitions(ctx context.Context, table string, cutoff time.Time) error {
+	archive := archiveTables[table]
+
+	var oldest *time.Time
+	// Table names come from archiveTables, never from the caller
+	if err := p.QueryRow(ctx, "SELECT MIN(created_at) FROM "+table+" WHERE created_at < $1", cutoff).Scan(&oldest); err != nil {
+		return fmt.Errorf("failed to find oldest %s: %w", table, err)
+	}
+	if oldest == nil {
+		return nil
+	}
+
+	for month := ArchiveMonth(*oldest); month.Before(cutoff); month = month.AddDate(0, 1, 0) {
+		_, err := p.Exec(ctx, fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			ArchivePartition(table, month), archive,
+			month.Format(time.RFC3339), month.AddDate(0, 1, 0).Format(time.RFC3339),
+		))
+		if err != nil {
+			return fmt.Errorf("failed to create %s partition for %s: %w", archive, month.Format("2006-01"), err)
+		}
+	}
+	return nil
+}
+
+// ArchiveMonth returns the start of the UTC month holding t, the lower bound
+// of its archive partition
+func ArchiveMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ArchivePartition names the archive partition of table for the month starting at month
+func ArchivePartition(table string, month time.Time) string {
+	return fmt.Sprintf("%s_y%04dm%02d", archiveTables[table], month.Year(), int(month.Month()))
+}
@@ -0,0 +1,71 @@
+-- Migration 005: Add threat_scoring_rules table for the correlator's weighted threat scoring engine
+-- Each enabled rule contributes its weight to a track's threat score when its factor condition matches
+
+CREATE TABLE IF NOT EXISTS threat_scoring_rules (
+    rule_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Rule identification
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+
+    -- Factor evaluated by this rule: classification, type, speed, altitude, heading_to_asset
+    factor VARCHAR(32) NOT NULL,
+    weight DOUBLE PRECISION NOT NULL,
+
+    -- Factor parameters
+    match_values TEXT[] NOT NULL DEFAULT '{}',           -- classification/type values
+    min_value DOUBLE PRECISION,                          -- speed (m/s) or altitude (m) lower bound
+    max_value DOUBLE PRECISION,                          -- speed (m/s) or altitude (m) upper bound
+    asset_lat DOUBLE PRECISION,                          -- protected asset for heading_to_asset
+    asset_lon DOUBLE PRECISION,
+    tolerance_deg DOUBLE PRECISION,
+    range_meters DOUBLE PRECISION,
+
+    -- Optional gates restricting the rule to certain tracks
+    classifications TEXT[] NOT NULL DEFAULT '{}',
+    track_types TEXT[] NOT NULL DEFAULT '{}',
+
+    -- Rule metadata
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    evaluation_order INTEGER NOT NULL DEFAULT 100,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_threat_factor CHECK (factor IN ('classification', 'type', 'speed', 'altitude', 'heading_to_asset')),
+    CONSTRAINT unique_threat_rule_name UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_threat_scoring_rules_enabled ON threat_scoring_rules(enabled) WHERE enabled = true;
+
+CREATE TRIGGER update_threat_scoring_rules_updated_at
+    BEFORE UPDATE ON threat_scoring_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Level thresholds (score >= threshold maps to the level)
+CREATE TABLE IF NOT EXISTS threat_level_thresholds (
+    threat_level VARCHAR(16) PRIMARY KEY,
+    min_score DOUBLE PRECISION NOT NULL
+);
+
+INSERT INTO threat_level_thresholds (threat_level, min_score)
+VALUES ('medium', 35), ('high', 60), ('critical', 85)
+ON CONFLICT (threat_level) DO NOTHING;
+
+-- Default rules reproduce the previous hardcoded threat cascade
+INSERT INTO threat_scoring_rules (name, factor, weight, match_values, min_value, max_value, classifications, track_types, evaluation_order)
+VALUES
+    ('hostile-classification',  'classification', 50, ARRAY['hostile'], NULL,   NULL, '{}',                    '{}',                       10),
+    ('unknown-classification',  'classification', 20, ARRAY['unknown'], NULL,   NULL, '{}',                    '{}',                       20),
+    ('hostile-missile',         'type',           40, ARRAY['missile'], NULL,   NULL, ARRAY['hostile'],        '{}',                       30),
+    ('hostile-fast-aircraft',   'speed',          25, '{}',             300.01, NULL, ARRAY['hostile'],        ARRAY['aircraft'],          40),
+    ('unknown-fast-mover',      'speed',          20, '{}',             200.01, NULL, ARRAY['unknown'],        '{}',                       50),
+    ('unknown-very-fast-mover', 'speed',          25, '{}',             500.01, NULL, ARRAY['unknown'],        '{}',                       60),
+    ('low-altitude-ingress',    'altitude',        8, '{}',             NULL,   150,  ARRAY['hostile','unknown'], ARRAY['aircraft','missile'], 70)
+ON CONFLICT (name) DO NOTHING;
+
+-- Persist the numeric score alongside the level
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS threat_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+COMMENT ON TABLE threat_scoring_rules IS 'Weighted rules used by the correlator to compute track threat scores';
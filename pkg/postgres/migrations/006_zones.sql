@@ -0,0 +1,69 @@
+-- Migration 006: Add zones table for protected assets and restricted zones
+-- Zones are shared by the correlator (closest-point-of-approach, time-to-zone) and the planner (rationale)
+
+CREATE TABLE IF NOT EXISTS zones (
+    zone_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Zone identification
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    kind VARCHAR(32) NOT NULL,                           -- protected_asset, restricted_zone
+
+    -- Geometry: circles use center + radius, polygons use an ordered vertex list
+    shape VARCHAR(16) NOT NULL,                          -- circle, polygon
+    center_lat DOUBLE PRECISION,
+    center_lon DOUBLE PRECISION,
+    radius_meters DOUBLE PRECISION,
+    polygon JSONB,                                       -- e.g., [{"lat": 35.1, "lon": -117.2}, ...]
+
+    -- Zone metadata
+    priority INTEGER NOT NULL DEFAULT 5,                 -- 1 (lowest) to 10 (highest)
+    enabled BOOLEAN NOT NULL DEFAULT true,
+
+    -- Audit
+    created_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_zone_kind CHECK (kind IN ('protected_asset', 'restricted_zone')),
+    CONSTRAINT valid_zone_shape CHECK (shape IN ('circle', 'polygon')),
+    CONSTRAINT valid_zone_circle CHECK (shape <> 'circle' OR (center_lat IS NOT NULL AND center_lon IS NOT NULL AND radius_meters >= 0)),
+    CONSTRAINT valid_zone_polygon CHECK (shape <> 'polygon' OR jsonb_array_length(polygon) >= 3),
+    CONSTRAINT valid_zone_priority CHECK (priority >= 1 AND priority <= 10),
+    CONSTRAINT unique_zone_name UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_zones_enabled ON zones(enabled) WHERE enabled = true;
+CREATE INDEX IF NOT EXISTS idx_zones_kind ON zones(kind);
+
+CREATE TRIGGER update_zones_updated_at
+    BEFORE UPDATE ON zones
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Example zones inside the simulated sensor coverage area
+INSERT INTO zones (name, description, kind, shape, center_lat, center_lon, radius_meters, polygon, priority, created_by)
+VALUES
+    ('Forward Operating Base Alpha',
+     'Protected airfield and command post',
+     'protected_asset', 'circle', 37.5, -115.0, 15000, NULL, 9, 'system'),
+    ('Restricted Area R-2508',
+     'Restricted airspace over the test range',
+     'restricted_zone', 'polygon', NULL, NULL, NULL,
+     '[{"lat": 35.5, "lon": -118.0}, {"lat": 36.5, "lon": -118.0}, {"lat": 36.5, "lon": -116.5}, {"lat": 35.5, "lon": -116.5}]',
+     7, 'system')
+ON CONFLICT (name) DO NOTHING;
+
+-- Allow threat scoring rules to use zone proximity factors
+ALTER TABLE threat_scoring_rules DROP CONSTRAINT IF EXISTS valid_threat_factor;
+ALTER TABLE threat_scoring_rules ADD CONSTRAINT valid_threat_factor
+    CHECK (factor IN ('classification', 'type', 'speed', 'altitude', 'heading_to_asset', 'time_to_zone', 'zone_cpa'));
+
+INSERT INTO threat_scoring_rules (name, factor, weight, min_value, max_value, classifications, evaluation_order)
+VALUES
+    ('zone-entry-imminent', 'time_to_zone', 15, NULL, 300,  ARRAY['hostile','unknown'], 80),
+    ('zone-close-approach', 'zone_cpa',     10, NULL, 5000, ARRAY['hostile','unknown'], 90)
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE zones IS 'Named protected assets and restricted zones used for proximity-based threat assessment';
@@ -0,0 +1,17 @@
+-- Migration 007: Proposal escalation before expiration
+-- The authorizer raises a pending proposal's priority when 50% and 80% of its TTL has elapsed
+
+-- Escalation level reached so far: 0 (none), 1 (warning, 50%), 2 (urgent, 80%)
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS escalation_level INTEGER NOT NULL DEFAULT 0;
+
+-- When the proposal last escalated
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS last_escalated_at TIMESTAMPTZ;
+
+ALTER TABLE proposals DROP CONSTRAINT IF EXISTS proposals_escalation_level_check;
+ALTER TABLE proposals ADD CONSTRAINT proposals_escalation_level_check
+    CHECK (escalation_level BETWEEN 0 AND 2);
+
+-- Escalation scans only look at pending proposals
+CREATE INDEX IF NOT EXISTS idx_proposals_pending_escalation
+    ON proposals(expires_at, escalation_level)
+    WHERE status = 'pending';
@@ -0,0 +1,20 @@
+-- Migration 008: Effector active/standby failover with fencing
+-- Effectors coordinate through a lease in JetStream KV. Each lease tenure carries a
+-- fencing token; the database keeps the newest token seen and rejects older ones,
+-- so an instance that lost its lease during a partition cannot execute effects.
+
+-- Record which effector tenure executed each effect
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS fencing_token BIGINT;
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS executor_id TEXT;
+
+-- High-water mark of fencing tokens per fenced resource
+CREATE TABLE IF NOT EXISTS effector_fence (
+    resource TEXT PRIMARY KEY,
+    token BIGINT NOT NULL DEFAULT 0,
+    holder TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO effector_fence (resource, token, holder)
+VALUES ('effector', 0, '')
+ON CONFLICT (resource) DO NOTHING;
@@ -0,0 +1,49 @@
+-- Migration 009: Tamper-evident audit hash chain for decisions and effects
+-- Every decision and completed effect is appended to a single chain. Each row stores
+-- its sequence number, the previous link's hash, and sha256(previous hash + payload).
+-- See pkg/audit for the canonical payload encoding.
+
+-- Chain head: the latest sequence number and hash (single row)
+CREATE TABLE IF NOT EXISTS audit_chain_head (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    seq BIGINT NOT NULL DEFAULT 0,
+    hash TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO audit_chain_head (id, seq, hash)
+VALUES (TRUE, 0, repeat('0', 64))
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS chain_seq BIGINT UNIQUE;
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS chain_hash TEXT;
+
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS chain_seq BIGINT UNIQUE;
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS prev_hash TEXT;
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS chain_hash TEXT;
+
+-- Chained rows are append-only. A deliberate data reset may bypass the guard by
+-- setting cjadc2.audit_reset for its transaction, which also restarts the chain.
+CREATE OR REPLACE FUNCTION audit_chain_guard()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.chain_seq IS NOT NULL AND current_setting('cjadc2.audit_reset', true) IS DISTINCT FROM 'on' THEN
+        RAISE EXCEPTION 'chained % rows are append-only (chain_seq %)', TG_TABLE_NAME, OLD.chain_seq;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS decisions_audit_chain_guard ON decisions;
+CREATE TRIGGER decisions_audit_chain_guard
+    BEFORE UPDATE OR DELETE ON decisions
+    FOR EACH ROW EXECUTE FUNCTION audit_chain_guard();
+
+DROP TRIGGER IF EXISTS effects_audit_chain_guard ON effects;
+CREATE TRIGGER effects_audit_chain_guard
+    BEFORE UPDATE OR DELETE ON effects
+    FOR EACH ROW EXECUTE FUNCTION audit_chain_guard();
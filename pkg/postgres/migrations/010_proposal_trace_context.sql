@@ -0,0 +1,8 @@
+-- Migration 010: Trace context on proposals
+-- A decision can be made minutes after its proposal was stored, by the gateway or the
+-- authorizer API, so the proposal keeps the span it was stored under. The decision
+-- span becomes its child and the whole detection-to-effect journey stays in one trace.
+
+-- OpenTelemetry trace ID (32 hex characters) and span ID (16 hex characters)
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32);
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS span_id VARCHAR(16);
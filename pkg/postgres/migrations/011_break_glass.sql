@@ -0,0 +1,38 @@
+-- Migration 011: Break-glass elevated approval authority
+-- An operator can activate a time-boxed grant of a higher role after passing a second
+-- factor. The approval authority policy (cjadc2.authority) accepts a live grant in place
+-- of the operator's own role. Grants are never deleted; every activation, use,
+-- revocation, and expiry is recorded in audit_log.
+
+CREATE TABLE IF NOT EXISTS break_glass_grants (
+    grant_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(128) NOT NULL,
+    role VARCHAR(64) NOT NULL,
+    justification TEXT NOT NULL,
+    second_factor VARCHAR(32) NOT NULL,
+    second_factor_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(16) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'expired', 'revoked')),
+    activated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    revoked_by VARCHAR(128),
+    revoke_reason TEXT,
+    use_count INTEGER NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMPTZ,
+    correlation_id TEXT,
+    CHECK (expires_at > activated_at)
+);
+
+-- Authority checks look up a user's live grants; the expiry sweep scans active grants
+CREATE INDEX IF NOT EXISTS idx_break_glass_active
+    ON break_glass_grants(user_id, expires_at)
+    WHERE status = 'active';
+
+-- Decisions approved under a break-glass grant reference it
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS break_glass_grant_id UUID
+    REFERENCES break_glass_grants(grant_id);
+
+CREATE INDEX IF NOT EXISTS idx_decisions_break_glass
+    ON decisions(break_glass_grant_id)
+    WHERE break_glass_grant_id IS NOT NULL;
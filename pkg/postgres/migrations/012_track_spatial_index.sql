@@ -0,0 +1,26 @@
+-- Migration 012: Spatial track queries
+-- Track list bounding-box filters run on plain latitude/longitude columns or,
+-- where the PostGIS extension is available, on a geometry built from them.
+
+-- Spherical backend: range scans over latitude then longitude
+CREATE INDEX IF NOT EXISTS idx_tracks_position ON tracks(position_lat, position_lon);
+
+-- PostGIS backend: enable the extension when the server ships it and index the
+-- same point expression the bbox predicate uses. Databases without PostGIS, or
+-- roles not allowed to create extensions, keep the spherical backend.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis') THEN
+        BEGIN
+            CREATE EXTENSION IF NOT EXISTS postgis;
+        EXCEPTION WHEN insufficient_privilege THEN
+            RAISE NOTICE 'postgis is available but could not be created; spatial queries use the spherical backend';
+        END;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis') THEN
+        EXECUTE 'CREATE INDEX IF NOT EXISTS idx_tracks_position_geom ON tracks
+                 USING GIST (ST_SetSRID(ST_MakePoint(position_lon, position_lat), 4326))';
+    END IF;
+END
+$$;
@@ -0,0 +1,26 @@
+-- Migration 013: Battle damage assessment
+-- After an effect executes the effector publishes an assessment of it. The gateway
+-- records each assessment and marks the track neutralized when the target was
+-- destroyed; neutralized tracks drop out of the active track picture.
+
+ALTER TYPE track_state ADD VALUE IF NOT EXISTS 'neutralized';
+
+CREATE TABLE IF NOT EXISTS effect_assessments (
+    assessment_id UUID PRIMARY KEY,
+    message_id UUID UNIQUE,
+    correlation_id TEXT,
+    effect_id UUID NOT NULL REFERENCES effects(effect_id) ON DELETE CASCADE,
+    decision_id UUID,
+    proposal_id UUID,
+    track_id TEXT NOT NULL,
+    action_type TEXT NOT NULL,
+    success_probability DECIMAL(4,3) NOT NULL CHECK (success_probability >= 0 AND success_probability <= 1),
+    neutralized BOOLEAN NOT NULL,
+    basis VARCHAR(16) NOT NULL CHECK (basis IN ('reported', 'estimated')),
+    assessor_id VARCHAR(64),
+    assessed_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_effect_assessments_effect_id ON effect_assessments(effect_id);
+CREATE INDEX IF NOT EXISTS idx_effect_assessments_track_id ON effect_assessments(track_id);
@@ -0,0 +1,15 @@
+-- Migration 014: Per-user UI preferences
+-- The HITL console stores operator workspace state server-side so it follows the
+-- operator across machines: saved map filters, table column layouts, and default
+-- sectors. Each preference is a named JSON document owned by one user.
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id VARCHAR(128) NOT NULL,
+    kind VARCHAR(32) NOT NULL
+        CHECK (kind IN ('map_filter', 'column_layout', 'default_sector')),
+    name VARCHAR(64) NOT NULL,
+    value JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind, name)
+);
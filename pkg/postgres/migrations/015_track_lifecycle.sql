@@ -0,0 +1,10 @@
+-- Migration 015: Track lifecycle
+-- The gateway ages out tracks that stop receiving detections: an active track
+-- becomes stale after TRACK_STALE_AFTER and dropped after TRACK_DROP_AFTER. A
+-- new detection returns the track to active.
+
+ALTER TYPE track_state ADD VALUE IF NOT EXISTS 'dropped';
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS state_changed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_state_last_updated ON tracks(state, last_updated);
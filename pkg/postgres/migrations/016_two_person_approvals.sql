@@ -0,0 +1,15 @@
+-- Migration 016: Two-person integrity for engage actions
+-- Engage proposals at or above TWO_PERSON_MIN_PRIORITY need approvals from two
+-- distinct operators. Each approval is recorded here with the role (or
+-- break-glass grant) that authorized it; the proposal stays pending, and no
+-- decision is published, until the second approval arrives.
+
+CREATE TABLE IF NOT EXISTS proposal_approvals (
+    proposal_id UUID NOT NULL REFERENCES proposals(proposal_id) ON DELETE CASCADE,
+    approved_by VARCHAR(128) NOT NULL,
+    role VARCHAR(64) NOT NULL,
+    break_glass_grant_id UUID REFERENCES break_glass_grants(grant_id),
+    reason TEXT,
+    approved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (proposal_id, approved_by)
+);
@@ -0,0 +1,69 @@
+-- Migration 017: Add classification_rules table for the classifier's rule engine
+-- Type rules infer a track type when the sensor gives no hint; classification rules
+-- assign friendly, hostile, neutral or unknown. The first enabled match of each kind
+-- wins, and the classifier reloads the table periodically so edits apply without a restart.
+
+CREATE TABLE IF NOT EXISTS classification_rules (
+    rule_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Rule identification
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    kind VARCHAR(32) NOT NULL,                           -- type, classification
+    result VARCHAR(32) NOT NULL,                         -- track type or classification assigned on match
+
+    -- Conditions; NULL bounds and empty lists match anything, bounds are inclusive
+    min_speed DOUBLE PRECISION,                          -- m/s
+    max_speed DOUBLE PRECISION,
+    min_alt DOUBLE PRECISION,                            -- meters
+    max_alt DOUBLE PRECISION,
+    min_confidence DOUBLE PRECISION,
+    max_confidence DOUBLE PRECISION,
+    min_lat DOUBLE PRECISION,                            -- region bounding box
+    max_lat DOUBLE PRECISION,
+    min_lon DOUBLE PRECISION,
+    max_lon DOUBLE PRECISION,
+    sensor_types TEXT[] NOT NULL DEFAULT '{}',
+    track_id_prefixes TEXT[] NOT NULL DEFAULT '{}',
+    track_types TEXT[] NOT NULL DEFAULT '{}',            -- classification rules only
+
+    -- Rule metadata
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    evaluation_order INTEGER NOT NULL DEFAULT 100,
+
+    -- Audit
+    created_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_by VARCHAR(255),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_classification_rule_kind CHECK (kind IN ('type', 'classification')),
+    CONSTRAINT unique_classification_rule_name UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_classification_rules_enabled ON classification_rules(kind, evaluation_order) WHERE enabled = true;
+
+CREATE TRIGGER update_classification_rules_updated_at
+    BEFORE UPDATE ON classification_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Default rules reproduce the previous hardcoded classifier heuristics
+INSERT INTO classification_rules (name, kind, result, min_speed, max_speed, min_alt, max_alt, min_confidence, min_lat, max_lat, min_lon, max_lon, track_id_prefixes, track_types, evaluation_order, created_by)
+VALUES
+    ('high-altitude-aircraft',             'type',           'aircraft', 200.01, NULL,   10000.01, NULL,    NULL,   NULL, NULL,  NULL,   NULL,    '{}',         '{}',               10, 'system'),
+    ('fast-climbing-missile',              'type',           'missile',  500.01, NULL,   1000.01,  NULL,    NULL,   NULL, NULL,  NULL,   NULL,    '{}',         '{}',               20, 'system'),
+    ('slow-surface-vessel-pacific-west',   'type',           'vessel',   0.01,   49.99,  NULL,     99.99,   NULL,   NULL, NULL,  NULL,   -100.01, '{}',         '{}',               30, 'system'),
+    ('slow-surface-vessel-pacific-east',   'type',           'vessel',   0.01,   49.99,  NULL,     99.99,   NULL,   NULL, NULL,  100.01, NULL,    '{}',         '{}',               31, 'system'),
+    ('slow-surface-vessel-south-atlantic', 'type',           'vessel',   0.01,   49.99,  NULL,     99.99,   NULL,   NULL, -0.01, -49.99, 49.99,   '{}',         '{}',               32, 'system'),
+    ('slow-surface-ground',                'type',           'ground',   0.01,   49.99,  NULL,     99.99,   NULL,   NULL, NULL,  NULL,   NULL,    '{}',         '{}',               40, 'system'),
+    ('low-altitude-aircraft',              'type',           'aircraft', 50.01,  299.99, NULL,     4999.99, NULL,   NULL, NULL,  NULL,   NULL,    '{}',         '{}',               50, 'system'),
+    ('stationary-ground',                  'type',           'ground',   NULL,   0,      NULL,     NULL,    NULL,   NULL, NULL,  NULL,   NULL,    '{}',         '{}',               60, 'system'),
+    ('neutral-track-id',                   'classification', 'neutral',  NULL,   NULL,   NULL,     NULL,    NULL,   NULL, NULL,  NULL,   NULL,    ARRAY['N'],   '{}',               10, 'system'),
+    ('iff-friendly-track-id',              'classification', 'friendly', NULL,   NULL,   NULL,     NULL,    NULL,   NULL, NULL,  NULL,   NULL,    ARRAY['F'],   '{}',               20, 'system'),
+    ('fast-missile',                       'classification', 'hostile',  500.01, NULL,   NULL,     NULL,    NULL,   NULL, NULL,  NULL,   NULL,    '{}',         ARRAY['missile'],   30, 'system'),
+    ('hostile-track-id',                   'classification', 'hostile',  NULL,   NULL,   NULL,     NULL,    NULL,   NULL, NULL,  NULL,   NULL,    ARRAY['H'],   '{}',               40, 'system'),
+    ('high-confidence-neutral',            'classification', 'neutral',  NULL,   NULL,   NULL,     NULL,    0.8501, NULL, NULL,  NULL,   NULL,    '{}',         '{}',               50, 'system')
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE classification_rules IS 'Ordered rules used by the classifier to infer track type and classification';
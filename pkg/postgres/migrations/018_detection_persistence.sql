@@ -0,0 +1,10 @@
+-- Migration 018: Store raw detections by external track ID
+-- The gateway bulk-loads detections from the DETECTIONS stream as they are
+-- emitted, before the correlator has created their track, so rows carry the
+-- sensor's track identifier rather than a reference to the tracks table.
+
+ALTER TABLE detections ADD COLUMN IF NOT EXISTS external_track_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_detections_external_track_id ON detections(external_track_id, created_at DESC);
+
+COMMENT ON COLUMN detections.external_track_id IS 'Sensor track identifier; matches tracks.external_track_id once the track is correlated';
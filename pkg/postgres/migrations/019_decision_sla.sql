@@ -0,0 +1,10 @@
+-- Migration 019: Decision latency SLA alerts
+-- The authorizer alerts once when a pending proposal waits longer than its priority's SLA
+
+-- When the proposal breached its decision SLA; NULL while within it
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS sla_breached_at TIMESTAMPTZ;
+
+-- SLA scans only look at pending proposals by priority
+CREATE INDEX IF NOT EXISTS idx_proposals_pending_priority
+    ON proposals(priority, created_at)
+    WHERE status = 'pending';
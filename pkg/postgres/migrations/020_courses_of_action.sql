@@ -0,0 +1,12 @@
+-- Migration 020: Ranked courses of action
+-- Proposals offer alternative actions alongside the recommended one, and the
+-- approver may approve any of them. Decisions record which option was chosen.
+
+-- Ranked options the planner offered; NULL for proposals made before options
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS options JSONB;
+
+-- Action the approver selected from the proposal's options
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS selected_option VARCHAR(32);
+
+-- Under the two-person rule both approvers must approve the same option
+ALTER TABLE proposal_approvals ADD COLUMN IF NOT EXISTS option VARCHAR(32);
@@ -0,0 +1,52 @@
+-- Migration 021: Asset availability
+-- Interceptors, jammers, and sensors available to answer tracks. The planner
+-- recommends the nearest ready asset in range for engage and intercept
+-- proposals, and the effector commits it when the effect executes.
+
+CREATE TABLE IF NOT EXISTS assets (
+    asset_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    -- Asset identification
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL,                           -- interceptor, jammer, sensor
+
+    -- Location and reach
+    lat DOUBLE PRECISION NOT NULL,
+    lon DOUBLE PRECISION NOT NULL,
+    range_meters DOUBLE PRECISION NOT NULL,
+
+    -- Availability
+    readiness VARCHAR(16) NOT NULL DEFAULT 'ready',      -- ready, committed, maintenance, offline
+    committed_to VARCHAR(64),                            -- Effect the asset is committed to
+    committed_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_asset_kind CHECK (kind IN ('interceptor', 'jammer', 'sensor')),
+    CONSTRAINT valid_asset_readiness CHECK (readiness IN ('ready', 'committed', 'maintenance', 'offline')),
+    CONSTRAINT valid_asset_location CHECK (lat BETWEEN -90 AND 90 AND lon BETWEEN -180 AND 180),
+    CONSTRAINT valid_asset_range CHECK (range_meters > 0),
+    CONSTRAINT unique_asset_name UNIQUE (name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_assets_ready ON assets(kind) WHERE readiness = 'ready';
+
+CREATE TRIGGER update_assets_updated_at
+    BEFORE UPDATE ON assets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Asset the planner recommended for a proposal's action
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS asset JSONB;
+
+-- Example assets around the simulated sensor coverage area
+INSERT INTO assets (name, kind, lat, lon, range_meters)
+VALUES
+    ('Interceptor Flight Alpha', 'interceptor', 37.5, -115.0, 150000),
+    ('SAM Battery Bravo',        'interceptor', 36.8, -116.0, 80000),
+    ('EW Jammer Charlie',        'jammer',      37.2, -115.5, 60000),
+    ('Radar Site Delta',         'sensor',      36.5, -115.5, 250000)
+ON CONFLICT (name) DO NOTHING;
+
+COMMENT ON TABLE assets IS 'Effectors and sensors with location, range, and readiness used to recommend assets for proposals';
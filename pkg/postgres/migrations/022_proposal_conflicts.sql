@@ -0,0 +1,10 @@
+-- Migration 022: Engagement deconfliction
+-- Engage and intercept proposals record the other engagements they compete
+-- with for an asset or airspace when they were planned.
+
+-- Conflicting engagements found by the planner; NULL when there were none
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS conflicts JSONB;
+
+-- Deconfliction looks up engagements in play by action and status
+CREATE INDEX IF NOT EXISTS idx_proposals_engagements ON proposals(status, action_type)
+    WHERE action_type IN ('engage', 'intercept');
@@ -0,0 +1,14 @@
+-- Migration 023: Rule-based auto-approval
+-- The authorizer may approve a low-risk proposal without an operator when an
+-- auto_approve intervention rule matches it and policy allows. Such decisions
+-- are attributed to the rule and flagged so they are never mistaken for a
+-- human approval.
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS machine_approved BOOLEAN NOT NULL DEFAULT false;
+
+-- Rule that approved the proposal. Not a foreign key: the decision and its
+-- audit hash must outlive edits to or deletion of the rule.
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS auto_approve_rule_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_decisions_machine_approved ON decisions(approved_at)
+    WHERE machine_approved;
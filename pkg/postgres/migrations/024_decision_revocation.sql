@@ -0,0 +1,11 @@
+-- Migration 024: Decision revocation
+-- An approved decision can be revoked until the effector claims its effect.
+-- The effector checks the flag before executing and records an 'aborted'
+-- effect for a revoked decision.
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMPTZ;
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS revoked_by TEXT;
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS revoke_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_decisions_revoked ON decisions(revoked_at)
+    WHERE revoked_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Migration 025: Effect execution retry policy
+-- The effector retries transient execution failures with exponential backoff
+-- and records how many attempts it made. An effect that is still failing when
+-- its attempts are spent ends with the terminal status 'failed_permanent'.
+
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+-- Migration 026: Audit trail search
+-- GET /api/v1/audit/search matches free text against proposal rationales and
+-- decision reasons. Both are indexed as generated English tsvectors so a
+-- search does not scan every engagement.
+
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS rationale_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', COALESCE(rationale, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_proposals_rationale_tsv ON proposals USING GIN (rationale_tsv);
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS reason_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', COALESCE(reason, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_decisions_reason_tsv ON decisions USING GIN (reason_tsv);
+
+-- Time-range filters over the audit trail
+CREATE INDEX IF NOT EXISTS idx_decisions_approved_at ON decisions(approved_at);
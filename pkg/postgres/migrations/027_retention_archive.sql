@@ -0,0 +1,49 @@
+-- Migration 027: Retention archive for detections and effects
+-- The gateway's retention job moves detections and finished effects older than
+-- their retention age into archive tables range-partitioned by month of
+-- created_at. Partitions are created by the job as it needs them, so an old
+-- month can be detached and dropped or dumped without touching live tables.
+-- The archives copy the live columns; a migration that adds a column to
+-- detections or effects must add it to the archive too.
+
+CREATE TABLE IF NOT EXISTS detections_archive (LIKE detections)
+    PARTITION BY RANGE (created_at);
+
+CREATE TABLE IF NOT EXISTS effects_archive (LIKE effects)
+    PARTITION BY RANGE (created_at);
+
+CREATE INDEX IF NOT EXISTS idx_effects_archive_effect_id ON effects_archive(effect_id);
+CREATE INDEX IF NOT EXISTS idx_effects_archive_decision_id ON effects_archive(decision_id);
+CREATE INDEX IF NOT EXISTS idx_effects_created_at ON effects(created_at);
+
+-- Live and archived effects; the audit trail and hash chain read through this
+CREATE OR REPLACE VIEW effects_all AS
+    SELECT * FROM effects
+    UNION ALL
+    SELECT * FROM effects_archive;
+
+-- Assessments outlive the effects they assess once those are archived
+ALTER TABLE effect_assessments DROP CONSTRAINT IF EXISTS effect_assessments_effect_id_fkey;
+
+-- Chained effects may leave the live table only when the retention job has
+-- copied them, unchanged, to the archive in the same transaction
+CREATE OR REPLACE FUNCTION audit_chain_guard()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND TG_TABLE_NAME = 'effects'
+        AND current_setting('cjadc2.audit_archive', true) = 'on'
+        AND EXISTS (
+            SELECT 1 FROM effects_archive a
+            WHERE a.effect_id = OLD.effect_id AND a.chain_hash IS NOT DISTINCT FROM OLD.chain_hash
+        ) THEN
+        RETURN OLD;
+    END IF;
+    IF OLD.chain_seq IS NOT NULL AND current_setting('cjadc2.audit_reset', true) IS DISTINCT FROM 'on' THEN
+        RAISE EXCEPTION 'chained % rows are append-only (chain_seq %)', TG_TABLE_NAME, OLD.chain_seq;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,57 @@
+-- Migration 028: Partition detections by day
+-- Detections are range-partitioned by UTC day of created_at, so history and
+-- analytics queries bounded by time scan only the days they cover, and the
+-- retention job archives a whole expired day by copying and dropping its
+-- partition instead of deleting rows. The gateway creates partitions a week
+-- ahead; a detection for a day without one lands in detections_default and is
+-- moved when that day's partition is created.
+-- Unique constraints on a partitioned table must include the partition key, so
+-- message_id is unique per created_at. A redelivered detection carries its
+-- original envelope timestamp and is still skipped.
+
+ALTER TABLE detections RENAME TO detections_unpartitioned;
+ALTER TABLE detections_unpartitioned DROP CONSTRAINT detections_pkey;
+ALTER TABLE detections_unpartitioned DROP CONSTRAINT detections_message_id_key;
+ALTER TABLE detections_unpartitioned DROP CONSTRAINT IF EXISTS detections_track_id_fkey;
+DROP INDEX IF EXISTS idx_detections_correlation_id;
+DROP INDEX IF EXISTS idx_detections_track_id;
+DROP INDEX IF EXISTS idx_detections_sensor_id;
+DROP INDEX IF EXISTS idx_detections_created_at;
+DROP INDEX IF EXISTS idx_detections_external_track_id;
+
+CREATE TABLE detections (
+    LIKE detections_unpartitioned INCLUDING DEFAULTS INCLUDING COMMENTS,
+    PRIMARY KEY (detection_id, created_at),
+    UNIQUE (message_id, created_at),
+    FOREIGN KEY (track_id) REFERENCES tracks(track_id) ON DELETE SET NULL
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE detections_default PARTITION OF detections DEFAULT;
+
+-- A partition for every day holding detections and for the week ahead
+DO $$
+DECLARE
+    day DATE;
+BEGIN
+    FOR day IN
+        SELECT DISTINCT (created_at AT TIME ZONE 'UTC')::date FROM detections_unpartitioned
+        UNION
+        SELECT generate_series(0, 7) + (NOW() AT TIME ZONE 'UTC')::date
+    LOOP
+        EXECUTE format(
+            'CREATE TABLE %I PARTITION OF detections FOR VALUES FROM (%L) TO (%L)',
+            'detections_p' || to_char(day, 'YYYYMMDD'),
+            day::timestamp AT TIME ZONE 'UTC',
+            (day + 1)::timestamp AT TIME ZONE 'UTC'
+        );
+    END LOOP;
+END $$;
+
+INSERT INTO detections SELECT * FROM detections_unpartitioned;
+DROP TABLE detections_unpartitioned;
+
+CREATE INDEX idx_detections_correlation_id ON detections(correlation_id);
+CREATE INDEX idx_detections_track_id ON detections(track_id);
+CREATE INDEX idx_detections_sensor_id ON detections(sensor_id);
+CREATE INDEX idx_detections_created_at ON detections(created_at);
+CREATE INDEX idx_detections_external_track_id ON detections(external_track_id, created_at DESC);
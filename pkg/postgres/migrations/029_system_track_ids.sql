@@ -0,0 +1,13 @@
+-- Migration 029: System track IDs
+-- The correlator now assigns each tracked object a system track ID (a UUID)
+-- and publishes it as track_id, so external_track_id holds that ID rather than
+-- the sensor's own. sensor_track_ids records every sensor track ID fused into
+-- the track, so detection history, which sensors report under their own IDs,
+-- can still be found. Rows written before this migration keep the sensor ID
+-- as external_track_id and list it as their only sensor track ID.
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS sensor_track_ids TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE tracks SET sensor_track_ids = ARRAY[external_track_id] WHERE sensor_track_ids = '{}';
+
+CREATE INDEX IF NOT EXISTS idx_tracks_sensor_track_ids ON tracks USING GIN (sensor_track_ids);
@@ -0,0 +1,32 @@
+-- Migration 030: Intervention rule version history
+-- Every create, update, and delete of an intervention rule writes the rule as it
+-- stood to intervention_rule_versions and to audit_log, so the rule that
+-- decided a proposal's approval path can be recovered after later edits.
+-- History is kept when a rule is deleted.
+
+ALTER TABLE intervention_rules ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS intervention_rule_versions (
+    rule_id UUID NOT NULL,
+    version INTEGER NOT NULL,
+    change VARCHAR(16) NOT NULL CHECK (change IN ('created', 'updated', 'deleted')),
+    snapshot JSONB NOT NULL,
+    changed_by VARCHAR(255),
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    correlation_id TEXT,
+    PRIMARY KEY (rule_id, version)
+);
+
+-- Rules that predate versioning start their history at version 1
+INSERT INTO intervention_rule_versions (rule_id, version, change, snapshot, changed_by, changed_at)
+SELECT rule_id, version, 'created',
+       jsonb_build_object(
+           'rule_id', rule_id, 'name', name, 'description', description,
+           'action_types', action_types, 'threat_levels', threat_levels,
+           'classifications', classifications, 'track_types', track_types,
+           'min_priority', min_priority, 'max_priority', max_priority,
+           'requires_approval', requires_approval, 'auto_approve', auto_approve,
+           'enabled', enabled, 'evaluation_order', evaluation_order),
+       COALESCE(updated_by, created_by), updated_at
+FROM intervention_rules
+ON CONFLICT (rule_id, version) DO NOTHING;
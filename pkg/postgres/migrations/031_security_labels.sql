@@ -0,0 +1,16 @@
+-- Migration 031: Security labels on tracks and proposals
+-- Rows keep the classification and coalition releasability of the message
+-- they were stored from, so the gateway can show a caller only the rows their
+-- clearance and releasability permit. Rows written before this migration are
+-- unclassified and releasable to no coalition partner.
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS security_label TEXT NOT NULL DEFAULT 'unclassified'
+    CHECK (security_label IN ('unclassified', 'confidential', 'secret', 'top_secret'));
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS releasability TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS security_label TEXT NOT NULL DEFAULT 'unclassified'
+    CHECK (security_label IN ('unclassified', 'confidential', 'secret', 'top_secret'));
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS releasability TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_tracks_releasability ON tracks USING GIN (releasability);
+CREATE INDEX IF NOT EXISTS idx_proposals_releasability ON proposals USING GIN (releasability);
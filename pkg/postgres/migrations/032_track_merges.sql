@@ -0,0 +1,8 @@
+-- Migration 032: Track merges
+-- When the correlator fuses tracks reported under different system track IDs,
+-- the superseded tracks are marked merged and record the track they were
+-- fused into, so the picture shows each object once.
+
+ALTER TABLE tracks ADD COLUMN IF NOT EXISTS merged_into TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_merged_into ON tracks(merged_into) WHERE merged_into IS NOT NULL;
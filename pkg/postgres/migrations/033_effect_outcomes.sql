@@ -0,0 +1,18 @@
+-- Migration 033: Effect outcomes
+-- Effector backends report whether an executed action succeeded, only
+-- partially succeeded, or failed, and whether it caused collateral damage.
+-- The archive copies the live columns, so it gains them too.
+
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS outcome TEXT;
+ALTER TABLE effects ADD COLUMN IF NOT EXISTS collateral BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE effects_archive ADD COLUMN IF NOT EXISTS outcome TEXT;
+ALTER TABLE effects_archive ADD COLUMN IF NOT EXISTS collateral BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_effects_collateral ON effects(action_type) WHERE collateral;
+
+-- Pick up the new columns
+CREATE OR REPLACE VIEW effects_all AS
+    SELECT * FROM effects
+    UNION ALL
+    SELECT * FROM effects_archive;
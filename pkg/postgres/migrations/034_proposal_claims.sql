@@ -0,0 +1,17 @@
+-- Migration 034: Proposal claims
+-- An operator claims a pending proposal to work the engagement, so two
+-- commanders do not decide it at once. A claim lapses after a period without
+-- activity from its holder; while it is live, decisions by anyone else are
+-- refused. A proposal has at most one claim, replaced when it lapses.
+
+CREATE TABLE IF NOT EXISTS proposal_claims (
+    proposal_id UUID PRIMARY KEY REFERENCES proposals(proposal_id) ON DELETE CASCADE,
+    claimed_by VARCHAR(128) NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_active_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    CHECK (expires_at > last_active_at)
+);
+
+-- The expiry sweep scans claims by expiry
+CREATE INDEX IF NOT EXISTS idx_proposal_claims_expires ON proposal_claims(expires_at);
@@ -0,0 +1,28 @@
+-- Migration 035: Recorded sessions
+-- A session records the events operators saw over the WebSocket (proposals
+-- appearing, decisions made, effects executed) so a trainer can replay the
+-- timeline at a debrief. Sessions outlive exercise resets. At most one
+-- session records at a time.
+
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(200) NOT NULL,
+    started_by VARCHAR(128) NOT NULL,
+    include_tracks BOOLEAN NOT NULL DEFAULT FALSE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ,
+    event_count INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_recording ON sessions((TRUE)) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sessions_started ON sessions(started_at DESC);
+
+-- Each event is stored as the envelope clients received, in broadcast order
+CREATE TABLE IF NOT EXISTS session_events (
+    session_id UUID NOT NULL REFERENCES sessions(session_id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    event JSONB NOT NULL,
+    PRIMARY KEY (session_id, seq)
+);
@@ -0,0 +1,24 @@
+-- Migration 036: Cache invalidation notifications
+-- The gateway caches hot list queries (active tracks, pending proposals) and
+-- drops a table's cached results when that table changes. Each statement that
+-- writes a watched table sends the table name on the table_changed channel.
+-- Triggers fire per statement rather than per row, and PostgreSQL folds
+-- identical notifications within a transaction, so a batch upsert costs one.
+
+CREATE OR REPLACE FUNCTION notify_table_changed()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('table_changed', TG_TABLE_NAME);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tracks_changed ON tracks;
+CREATE TRIGGER tracks_changed
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON tracks
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_table_changed();
+
+DROP TRIGGER IF EXISTS proposals_changed ON proposals;
+CREATE TRIGGER proposals_changed
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON proposals
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_table_changed();
@@ -0,0 +1,37 @@
+-- Migration 037: Let revocations through the audit chain guard
+-- Revoking a decision sets its revoked_* columns, which 009's guard refused
+-- for every chained row. The chain hashes a decision as it was made and not
+-- its revocation, which is audited separately, so the guard now lets an
+-- update through that only sets the revocation of an unrevoked decision.
+-- Any other change to a chained row is still refused. reason_tsv is left
+-- out of the comparison: generated columns are not computed for NEW in a
+-- BEFORE trigger, and it follows reason, which is compared.
+
+CREATE OR REPLACE FUNCTION audit_chain_guard()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND TG_TABLE_NAME = 'effects'
+        AND current_setting('cjadc2.audit_archive', true) = 'on'
+        AND EXISTS (
+            SELECT 1 FROM effects_archive a
+            WHERE a.effect_id = OLD.effect_id AND a.chain_hash IS NOT DISTINCT FROM OLD.chain_hash
+        ) THEN
+        RETURN OLD;
+    END IF;
+    -- Nested so effects rows, which have no revoked_at, never reach the test
+    IF TG_OP = 'UPDATE' AND TG_TABLE_NAME = 'decisions' THEN
+        IF OLD.revoked_at IS NULL AND NEW.revoked_at IS NOT NULL
+            AND to_jsonb(NEW) - 'revoked_at' - 'revoked_by' - 'revoke_reason' - 'reason_tsv'
+                = to_jsonb(OLD) - 'revoked_at' - 'revoked_by' - 'revoke_reason' - 'reason_tsv' THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+    IF OLD.chain_seq IS NOT NULL AND current_setting('cjadc2.audit_reset', true) IS DISTINCT FROM 'on' THEN
+        RAISE EXCEPTION 'chained % rows are append-only (chain_seq %)', TG_TABLE_NAME, OLD.chain_seq;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,293 @@
+// Package migrations embeds the PostgreSQL schema and applies it in order.
+//
+// Each NNN_name.sql file is one migration whose version is its numeric
+// prefix. Applied versions are tracked golang-migrate style in a single-row
+// schema_migrations(version, dirty) table. Services apply pending migrations
+// at startup under an advisory lock, so replicas starting together apply each
+// migration exactly once; with DB_MIGRATE=false they only verify the schema
+// is current and cmd/migrate applies it instead.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockID is the advisory lock key held while migrating ("cjadc2" in ASCII)
+const lockID int64 = 0x636a61646332
+
+// createTable creates the version table in golang-migrate's layout
+const createTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)
+`
+
+// ErrUnversioned is returned when the database has tables but no
+// schema_migrations, as databases initialized before versioning do. Record
+// the version its schema matches with cmd/migrate -force.
+var ErrUnversioned = errors.New("database schema is not versioned; run cmd/migrate -force <version> to record the version it matches")
+
+// Migration is one embedded schema change
+type Migration struct {
+	Version uint
+	Name    string
+	SQL     string
+}
+
+// Status is the schema version recorded in the database
+type Status struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`  // A migration failed part way; fix the schema and force the version
+	Latest  uint `json:"latest"` // Newest embedded migration
+}
+
+// Current reports whether the database is at the latest embedded version
+func (s Status) Current() bool {
+	return !s.Dirty && s.Version >= s.Latest
+}
+
+// ParseName splits a migration file name into its version and name
+func ParseName(file string) (uint, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+	if base == file {
+		return 0, "", fmt.Errorf("migration %q is not a .sql file", file)
+	}
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", fmt.Errorf("migration %q is not named NNN_name.sql", file)
+	}
+	version, err := strconv.ParseUint(prefix, 10, 32)
+	if err != nil || version == 0 {
+		return 0, "", fmt.Errorf("migration %q has an invalid version", file)
+	}
+	return uint(version), name, nil
+}
+
+// All returns the embedded migrations in version order
+func All() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var all []Migration
+	for _, entry := range entries {
+		version, name, err := ParseName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		sql, err := files.ReadFile(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		all = append(all, Migration{Version: version, Name: name, SQL: string(sql)})
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	for i, m := range all {
+		if m.Version != uint(i+1) {
+			return nil, fmt.Errorf("migration versions must run 1..n without gaps; found %d at position %d", m.Version, i+1)
+		}
+	}
+	return all, nil
+}
+
+// Latest returns the newest embedded migration version
+func Latest() uint {
+	all, err := All()
+	if err != nil || len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
+
+// rowQuerier is satisfied by pools and connections
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Version reads the recorded schema version. A database without
+// schema_migrations is at version 0 when empty and ErrUnversioned otherwise.
+func Version(ctx context.Context, pool *pgxpool.Pool) (Status, error) {
+	return version(ctx, pool)
+}
+
+// version reads the recorded schema version through q
+func version(ctx context.Context, q rowQuerier) (Status, error) {
+	status := Status{Latest: Latest()}
+
+	var tracked, populated bool
+	if err := q.QueryRow(ctx, `
+		SELECT to_regclass('schema_migrations') IS NOT NULL,
+		       to_regclass('tracks') IS NOT NULL
+	`).Scan(&tracked, &populated); err != nil {
+		return status, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if !tracked {
+		if populated {
+			return status, ErrUnversioned
+		}
+		return status, nil
+	}
+
+	var v int64
+	err := q.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&v, &status.Dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return status, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	status.Version = uint(v)
+	return status, nil
+}
+
+// Up applies every pending migration and returns the resulting status and the
+// migrations it applied. Each migration runs in its own transaction together
+// with its version bump, so a failure leaves the previous version recorded.
+func Up(ctx context.Context, pool *pgxpool.Pool) (Status, []Migration, error) {
+	all, err := All()
+	if err != nil {
+		return Status{}, nil, err
+	}
+
+	conn, err := lock(ctx, pool)
+	if err != nil {
+		return Status{}, nil, err
+	}
+	defer unlock(conn)
+
+	status, err := version(ctx, conn)
+	if err != nil {
+		return status, nil, err
+	}
+	if status.Dirty {
+		return status, nil, fmt.Errorf("schema version %d is dirty; fix the schema and run cmd/migrate -force %d", status.Version, status.Version)
+	}
+
+	if _, err := conn.Exec(ctx, createTable); err != nil {
+		return status, nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var applied []Migration
+	for _, m := range all {
+		if m.Version <= status.Version {
+			continue
+		}
+		if err := apply(ctx, conn, m); err != nil {
+			return status, applied, err
+		}
+		status.Version = m.Version
+		applied = append(applied, m)
+	}
+	return status, applied, nil
+}
+
+// apply runs one migration and records its version in a single transaction
+func apply(ctx context.Context, conn *pgxpool.Conn, m Migration) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, m.SQL); err != nil {
+		return fmt.Errorf("failed to apply migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	if err := setVersion(ctx, tx, m.Version); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// Force records version as applied and clean without running any migration.
+// It baselines databases created before versioning and clears a dirty version
+// once the schema has been repaired by hand.
+func Force(ctx context.Context, pool *pgxpool.Pool, v uint) error {
+	if latest := Latest(); v > latest {
+		return fmt.Errorf("version %d is newer than the latest migration %d", v, latest)
+	}
+
+	conn, err := lock(ctx, pool)
+	if err != nil {
+		return err
+	}
+	defer unlock(conn)
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	if err := setVersion(ctx, tx, v); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit forced version: %w", err)
+	}
+	return nil
+}
+
+// setVersion replaces the single schema_migrations row with a clean version
+func setVersion(ctx context.Context, tx pgx.Tx, v uint) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(v)); err != nil {
+		return fmt.Errorf("failed to record schema version %d: %w", v, err)
+	}
+	return nil
+}
+
+// Prepare brings the schema up to date when migrate is set and otherwise only
+// verifies it, returning an error that names the problem instead of leaving
+// queries to fail on missing tables
+func Prepare(ctx context.Context, pool *pgxpool.Pool, migrate bool) (Status, []Migration, error) {
+	if migrate {
+		return Up(ctx, pool)
+	}
+	status, err := Version(ctx, pool)
+	if err != nil {
+		return status, nil, err
+	}
+	if !status.Current() {
+		return status, nil, fmt.Errorf("schema is at version %d (dirty=%t) but version %d is required; run cmd/migrate", status.Version, status.Dirty, status.Latest)
+	}
+	return status, nil, nil
+}
+
+// lock acquires a connection holding the migration advisory lock
+func lock(ctx context.Context, pool *pgxpool.Pool) (*pgxpool.Conn, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return conn, nil
+}
+
+// unlock releases the migration advisory lock and its connection
+func unlock(conn *pgxpool.Conn) {
+	conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+	conn.Release()
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+)
+
+// TableChangedChannel is the channel notify_table_changed sends the name of
+// each changed table on
+const TableChangedChannel = "table_changed"
+
+// ListenTableChanges calls changed with the name of each table written while
+// it listens, until ctx ends or the connection fails. ready is called once
+// listening has begun; changes before then are not reported. The connection
+// is taken out of the pool so it never returns still listening.
+func (p *Pool) ListenTableChanges(ctx context.Context, ready func(), changed func(table string)) error {
+	pooled, err := p.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+TableChangedChannel); err != nil {
+		return fmt.Errorf("failed to listen for table changes: %w", err)
+	}
+	ready()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed waiting for table changes: %w", err)
+		}
+		changed(n.Payload)
+	}
+}
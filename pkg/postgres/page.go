@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errors returned while parsing list sort orders and cursors
+var (
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortKey orders a list by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// FormatSort renders sort keys as a sort parameter, e.g. "-priority,created_at"
+func FormatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Field
+		if k.Desc {
+			parts[i] = "-" + k.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// sortColumn is the SQL expression behind a sortable field and the type its
+// cursor values are cast back to
+type sortColumn struct {
+	expr string
+	typ  string
+}
+
+// SortFields describes how one list can be ordered and paged. Every order ends
+// with the table's UUID primary key, so rows with equal sort values still have
+// a stable position for keyset pagination.
+type SortFields struct {
+	columns  map[string]sortColumn
+	id       string
+	defaults []SortKey
+}
+
+// Sortable fields of each list
+var (
+	TrackSort = &SortFields{
+		columns: map[string]sortColumn{
+			"last_updated":    {"last_updated", "timestamptz"},
+			"first_seen":      {"first_seen", "timestamptz"},
+			"threat_score":    {"threat_score", "double precision"},
+			"confidence":      {"confidence", "numeric"},
+			"detection_count": {"detection_count", "integer"},
+		},
+		id:       "track_id",
+		defaults: []SortKey{{Field: "last_updated", Desc: true}},
+	}
+	ProposalSort = &SortFields{
+		columns: map[string]sortColumn{
+			"priority":   {"p.priority", "integer"},
+			"created_at": {"p.created_at", "timestamptz"},
+			"expires_at": {"p.expires_at", "timestamptz"},
+		},
+		id:       "p.proposal_id",
+		defaults: []SortKey{{Field: "priority", Desc: true}, {Field: "created_at", Desc: true}},
+	}
+	DecisionSort = &SortFields{
+		columns: map[string]sortColumn{
+			"approved_at": {"d.approved_at", "timestamptz"},
+			"created_at":  {"d.created_at", "timestamptz"},
+		},
+		id:       "d.decision_id",
+		defaults: []SortKey{{Field: "approved_at", Desc: true}},
+	}
+	EffectSort = &SortFields{
+		columns: map[string]sortColumn{
+			// Effects still pending execution are placed by when they were logged
+			"executed_at": {"COALESCE(e.executed_at, e.created_at)", "timestamptz"},
+			"created_at":  {"e.created_at", "timestamptz"},
+		},
+		id:       "e.effect_id",
+		defaults: []SortKey{{Field: "executed_at", Desc: true}},
+	}
+)
+
+// Fields returns the names the list can be sorted by
+func (f *SortFields) Fields() []string {
+	names := make([]string, 0, len(f.columns))
+	for name := range f.columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseSort parses a comma-separated sort parameter; a leading "-" sorts a
+// field descending. Empty yields the list's default order.
+func (f *SortFields) ParseSort(value string) ([]SortKey, error) {
+	if value == "" {
+		return append([]SortKey(nil), f.defaults...), nil
+	}
+
+	var keys []SortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		key := SortKey{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := f.columns[key.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q, must be one of %s", ErrInvalidSort, key.Field, strings.Join(f.Fields(), ", "))
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("%w: field %q given twice", ErrInvalidSort, key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// cursorToken is the encoded position of a row in a sorted list
+type cursorToken struct {
+	Sort string   `json:"s"`
+	Key  []string `json:"k"` // Sort field values then the row ID
+}
+
+// EncodeCursor returns an opaque token for resuming a list after the row
+// with the given sort key values
+func EncodeCursor(keys []SortKey, values []string) string {
+	data, _ := json.Marshal(cursorToken{Sort: FormatSort(keys), Key: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor returns the sort key values of a cursor token. A cursor is
+// only valid for the sort order it was issued under.
+func (f *SortFields) DecodeCursor(token string, keys []SortKey) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	var c cursorToken
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	if c.Sort != FormatSort(keys) {
+		return nil, fmt.Errorf("%w: issued for sort %q", ErrInvalidCursor, c.Sort)
+	}
+	if len(c.Key) != len(keys)+1 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidCursor)
+	}
+	return c.Key, nil
+}
+
+// keyset holds the SQL for reading one page of a sorted list
+type keyset struct {
+	keys    []SortKey
+	orderBy string // ORDER BY clause
+	key     string // Select expression of a row's cursor key values
+	after   string // Predicate for rows after the cursor, empty on the first page
+	args    []interface{}
+}
+
+// keyset builds the ORDER BY clause, cursor key expression, and the predicate
+// for rows after the cursor values, numbering placeholders from argNum. The
+// ID tie-breaker follows the direction of the first sort key.
+func (f *SortFields) keyset(keys []SortKey, after []string, argNum int) keyset {
+	if len(keys) == 0 {
+		keys = f.defaults
+	}
+
+	cols := make([]sortColumn, 0, len(keys)+1)
+	desc := make([]bool, 0, len(keys)+1)
+	for _, k := range keys {
+		cols = append(cols, f.columns[k.Field])
+		desc = append(desc, k.Desc)
+	}
+	cols = append(cols, sortColumn{expr: f.id, typ: "uuid"})
+	desc = append(desc, keys[0].Desc)
+
+	ks := keyset{keys: keys}
+	order := make([]string, len(cols))
+	texts := make([]string, len(cols))
+	for i, c := range cols {
+		order[i] = c.expr + " ASC"
+		if desc[i] {
+			order[i] = c.expr + " DESC"
+		}
+		texts[i] = "(" + c.expr + ")::text"
+	}
+	ks.orderBy = " ORDER BY " + strings.Join(order, ", ")
+	ks.key = "ARRAY[" + strings.Join(texts, ", ") + "]"
+
+	if len(after) != len(cols) {
+		return ks
+	}
+
+	// (k1 after v1) OR (k1 = v1 AND k2 after v2) OR ...
+	placeholders := make([]string, len(cols))
+	for i, c := range cols {
+		placeholders[i] = fmt.Sprintf("$%d::%s", argNum+i, c.typ)
+		ks.args = append(ks.args, after[i])
+	}
+	branches := make([]string, len(cols))
+	for i, c := range cols {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, cols[j].expr+" = "+placeholders[j])
+		}
+		op := " > "
+		if desc[i] {
+			op = " < "
+		}
+		terms = append(terms, c.expr+op+placeholders[i])
+		branches[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	ks.after = " AND (" + strings.Join(branches, " OR ") + ")"
+	return ks
+}
+
+// cursor encodes the position of a row from its scanned key values
+func (ks keyset) cursor(values []string) string {
+	return EncodeCursor(ks.keys, values)
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// detectionPartitionPrefix starts the name of every daily detections partition
+const detectionPartitionPrefix = "detections_p"
+
+// DetectionDay returns the start of the UTC day holding t, the lower bound of
+// its detections partition
+func DetectionDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DetectionPartition names the detections partition for the day starting at day
+func DetectionPartition(day time.Time) string {
+	return detectionPartitionPrefix + day.Format("20060102")
+}
+
+// DetectionPartitionDays returns the days that have a detections partition,
+// oldest first
+func (p *Pool) DetectionPartitionDays(ctx context.Context) ([]time.Time, error) {
+	rows, err := p.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'detections'::regclass
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list detection partitions: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list detection partitions: %w", err)
+	}
+
+	var days []time.Time
+	for _, name := range names {
+		// detections_default and any partition not created here are skipped
+		if !strings.HasPrefix(name, detectionPartitionPrefix) {
+			continue
+		}
+		day, err := time.Parse("20060102", strings.TrimPrefix(name, detectionPartitionPrefix))
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, k int) bool { return days[i].Before(days[k]) })
+	return days, nil
+}
+
+// EnsureDetectionPartitions creates the missing daily detections partitions
+// from the day holding from through days after it, moving rows for those days
+// out of detections_default. Returns the names of the partitions created.
+func (p *Pool) EnsureDetectionPartitions(ctx context.Context, from time.Time, days int) ([]string, error) {
+	existing, err := p.DetectionPartitionDays(ctx)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[time.Time]bool, len(existing))
+	for _, day := range existing {
+		have[day] = true
+	}
+
+	var created []string
+	for day, last := DetectionDay(from), DetectionDay(from).AddDate(0, 0, days); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if have[day] {
+			continue
+		}
+		if err := p.createDetectionPartition(ctx, day); err != nil {
+			return created, err
+		}
+		created = append(created, DetectionPartition(day))
+	}
+	return created, nil
+}
+
+// createDetectionPartition creates one day's partition. Postgres refuses a
+// partition whose rows are already in the default partition, so they are set
+// aside and reinserted once it exists.
+func (p *Pool) createDetectionPartition(ctx context.Context, day time.Time) error {
+	name := DetectionPartition(day)
+	next := day.AddDate(0, 0, 1)
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Holds off writes to the default partition until the day's rows have moved
+	if _, err := tx.Exec(ctx, "LOCK TABLE detections_default IN ACCESS EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("failed to lock detections_default: %w", err)
+	}
+	if _, err := tx.Exec(ctx, "CREATE TEMP TABLE detections_moving (LIKE detections) ON COMMIT DROP"); err != nil {
+		return fmt.Errorf("failed to create detection staging table: %w", err)
+	}
+	moved, err := tx.Exec(ctx, `
+		WITH moved AS (
+			DELETE FROM detections_default WHERE created_at >= $1 AND created_at < $2
+			RETURNING *
+		)
+		INSERT INTO detections_moving SELECT * FROM moved
+	`, day, next)
+	if err != nil {
+		return fmt.Errorf("failed to move detections out of detections_default: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF detections FOR VALUES FROM ('%s') TO ('%s')",
+		name, day.Format(time.RFC3339), next.Format(time.RFC3339),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create partition %s: %w", name, err)
+	}
+	if moved.RowsAffected() > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO detections SELECT * FROM detections_moving"); err != nil {
+			return fmt.Errorf("failed to move detections into %s: %w", name, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit partition %s: %w", name, err)
+	}
+	return nil
+}
+
+// ArchiveDetectionPartition moves the oldest daily detections partition that
+// ends at or before cutoff to detections_archive and drops it, returning its
+// name and row count. It returns an empty name when no partition has expired.
+func (p *Pool) ArchiveDetectionPartition(ctx context.Context, cutoff time.Time) (string, int64, error) {
+	days, err := p.DetectionPartitionDays(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(days) == 0 || days[0].AddDate(0, 0, 1).After(cutoff) {
+		return "", 0, nil
+	}
+	day := days[0]
+	name := DetectionPartition(day)
+
+	if err := p.ensureArchivePartitions(ctx, "detections", day.AddDate(0, 0, 1)); err != nil {
+		return "", 0, err
+	}
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Partition names come from DetectionPartition, never from the caller
+	tag, err := tx.Exec(ctx, "INSERT INTO detections_archive SELECT * FROM "+name)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to archive partition %s: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, "DROP TABLE "+name); err != nil {
+		return "", 0, fmt.Errorf("failed to drop partition %s: %w", name, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", 0, fmt.Errorf("failed to commit archived partition %s: %w", name, err)
+	}
+	return name, tag.RowsAffected(), nil
+}
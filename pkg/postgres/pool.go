@@ -4,19 +4,29 @@ package postgres
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/agile-defense/cjadc2/pkg/audit"
+	"github.com/agile-defense/cjadc2/pkg/breakglass"
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/intervention"
 	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
 )
 
 // Pool wraps pgxpool.Pool with domain-specific query methods
 type Pool struct {
 	*pgxpool.Pool
+	spatial geo.Backend
 }
 
 // Config holds PostgreSQL connection configuration
@@ -108,47 +118,76 @@ func NewPoolFromURL(ctx context.Context, url string) (*Pool, error) {
 	return &Pool{Pool: pool}, nil
 }
 
+// UseSpatialBackend selects the backend that builds spatial query conditions.
+// Pools use the spherical backend until one is set.
+func (p *Pool) UseSpatialBackend(b geo.Backend) {
+	p.spatial = b
+}
+
+// SpatialBackend returns the backend used for spatial query conditions
+func (p *Pool) SpatialBackend() geo.Backend {
+	if p.spatial == nil {
+		return geo.Spherical{}
+	}
+	return p.spatial
+}
+
 // TrackRow represents a track stored in the database
 type TrackRow struct {
 	TrackID        string          `json:"track_id"`
-	ExternalID     string          `json:"external_track_id"`
+	ExternalID     string          `json:"external_track_id"` // System track ID assigned by the correlator
+	SensorTrackIDs []string        `json:"sensor_track_ids"`  // Sensor track IDs fused into the track
 	Classification string          `json:"classification"`
 	Type           string          `json:"type"`
 	ThreatLevel    string          `json:"threat_level"`
+	ThreatScore    float64         `json:"threat_score"`
 	Position       json.RawMessage `json:"position"`
 	Velocity       json.RawMessage `json:"velocity"`
 	Confidence     float64         `json:"confidence"`
 	Sources        []string        `json:"sources"`
 	DetectionCount int             `json:"detection_count"`
+	State          string          `json:"state"`
+	StateChangedAt *time.Time      `json:"state_changed_at,omitempty"`
+	MergedInto     *string         `json:"merged_into,omitempty"` // System track ID a merged track was fused into
 	FirstSeen      time.Time       `json:"first_seen"`
 	LastUpdated    time.Time       `json:"last_updated"`
+	SecurityLabel  string          `json:"security_label"`
+	Releasability  []string        `json:"releasability"` // Coalition partners the track is releasable to
+
+	Cursor string `json:"-"` // Set by ListTracks; resumes the list after this track
 }
 
 // TrackFilter defines filter options for track queries
 type TrackFilter struct {
+	States         []string // Lifecycle states to include; active only when empty, every state when "all"
 	Classification string
 	ThreatLevel    string
 	Type           string
 	Since          *time.Time
+	BBox           *geo.BBox       // Only tracks positioned inside the box
+	Access         seclabel.Access // Only tracks the caller's clearance and releasability permit
+	Sort           []SortKey       // Fields of TrackSort; most recently updated first when empty
+	After          []string        // Cursor values from TrackSort.DecodeCursor; only tracks after it
 	Limit          int
 	Offset         int
 }
 
-// ListTracks retrieves tracks with optional filtering
-func (p *Pool) ListTracks(ctx context.Context, filter TrackFilter) ([]TrackRow, error) {
-	query := `
-		SELECT
-			track_id, external_track_id, classification, type, threat_level,
-			position_lat, position_lon, position_alt,
-			velocity_speed, velocity_heading,
-			confidence, sources, detection_count,
-			first_seen, last_updated
-		FROM tracks
-		WHERE state = 'active'
-	`
+// trackConditions returns the WHERE conditions of a track filter and their arguments
+func (p *Pool) trackConditions(filter TrackFilter) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 	argNum := 1
 
+	switch {
+	case len(filter.States) == 0:
+		query += " AND state = 'active'"
+	case len(filter.States) == 1 && filter.States[0] == "all":
+	default:
+		query += fmt.Sprintf(" AND state::text = ANY($%d)", argNum)
+		args = append(args, filter.States)
+		argNum++
+	}
+
 	if filter.Classification != "" {
 		query += fmt.Sprintf(" AND classification = $%d", argNum)
 		args = append(args, filter.Classification)
@@ -173,7 +212,80 @@ func (p *Pool) ListTracks(ctx context.Context, filter TrackFilter) ([]TrackRow,
 		argNum++
 	}
 
-	query += " ORDER BY last_updated DESC"
+	if filter.BBox != nil {
+		clause, boxArgs := p.SpatialBackend().BBoxPredicate("position_lat", "position_lon", *filter.BBox, argNum)
+		query += " AND " + clause
+		args = append(args, boxArgs...)
+		argNum += len(boxArgs)
+	}
+
+	clause, accessArgs := accessConditions(filter.Access, "", argNum)
+	query += clause
+	args = append(args, accessArgs...)
+
+	return query, args
+}
+
+// accessConditions returns the WHERE conditions limiting a labelled table to
+// the rows an access permits, with columns qualified by prefix
+func accessConditions(access seclabel.Access, prefix string, argNum int) (string, []interface{}) {
+	query := ""
+	args := []interface{}{}
+	if labels := access.Labels(); labels != nil {
+		query += fmt.Sprintf(" AND %ssecurity_label = ANY($%d)", prefix, argNum)
+		args = append(args, labels)
+		argNum++
+	}
+	if len(access.Releasability) > 0 {
+		query += fmt.Sprintf(" AND %sreleasability && $%d", prefix, argNum)
+		args = append(args, access.Releasability)
+	}
+	return query, args
+}
+
+// SecurityLabels returns the labels a row stored from a message carries
+func SecurityLabels(env messages.Envelope) (string, []string) {
+	label := env.Classification
+	if label == "" {
+		label = seclabel.Unclassified
+	}
+	releasability := env.Releasability
+	if releasability == nil {
+		releasability = []string{}
+	}
+	return label, releasability
+}
+
+// CountFilteredTracks counts the tracks matching a filter, ignoring its cursor and paging
+func (p *Pool) CountFilteredTracks(ctx context.Context, filter TrackFilter) (int64, error) {
+	where, args := p.trackConditions(filter)
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*) FROM tracks WHERE TRUE"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tracks: %w", err)
+	}
+	return count, nil
+}
+
+// ListTracks retrieves tracks with optional filtering. Each row's Cursor
+// resumes the list after it.
+func (p *Pool) ListTracks(ctx context.Context, filter TrackFilter) ([]TrackRow, error) {
+	where, args := p.trackConditions(filter)
+	ks := TrackSort.keyset(filter.Sort, filter.After, len(args)+1)
+	args = append(args, ks.args...)
+	argNum := len(args) + 1
+
+	query := `
+		SELECT
+			track_id, external_track_id, classification, type, threat_level, threat_score,
+			position_lat, position_lon, position_alt,
+			velocity_speed, velocity_heading,
+			confidence, sources, detection_count,
+			state, state_changed_at, merged_into,
+			first_seen, last_updated, sensor_track_ids,
+			security_label, releasability, ` + ks.key + `
+		FROM tracks
+		WHERE TRUE
+	` + where + ks.after + ks.orderBy
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argNum)
@@ -197,17 +309,21 @@ func (p *Pool) ListTracks(ctx context.Context, filter TrackFilter) ([]TrackRow,
 		var t TrackRow
 		var posLat, posLon float64
 		var posAlt, velSpeed, velHeading *float64
+		var key []string
 
 		err := rows.Scan(
-			&t.TrackID, &t.ExternalID, &t.Classification, &t.Type, &t.ThreatLevel,
+			&t.TrackID, &t.ExternalID, &t.Classification, &t.Type, &t.ThreatLevel, &t.ThreatScore,
 			&posLat, &posLon, &posAlt,
 			&velSpeed, &velHeading,
 			&t.Confidence, &t.Sources, &t.DetectionCount,
-			&t.FirstSeen, &t.LastUpdated,
+			&t.State, &t.StateChangedAt, &t.MergedInto,
+			&t.FirstSeen, &t.LastUpdated, &t.SensorTrackIDs,
+			&t.SecurityLabel, &t.Releasability, &key,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan track: %w", err)
 		}
+		t.Cursor = ks.cursor(key)
 
 		// Build position JSON
 		pos := map[string]interface{}{"lat": posLat, "lon": posLon}
@@ -236,17 +352,22 @@ func (p *Pool) ListTracks(ctx context.Context, filter TrackFilter) ([]TrackRow,
 	return tracks, nil
 }
 
-// GetTrack retrieves a single track by ID
+// GetTrack retrieves a single track by its system track ID, or else the most
+// recently updated track a sensor reported under trackID
 func (p *Pool) GetTrack(ctx context.Context, trackID string) (*TrackRow, error) {
 	query := `
 		SELECT
-			track_id, external_track_id, classification, type, threat_level,
+			track_id, external_track_id, classification, type, threat_level, threat_score,
 			position_lat, position_lon, position_alt,
 			velocity_speed, velocity_heading,
 			confidence, sources, detection_count,
-			first_seen, last_updated
+			state, state_changed_at, merged_into,
+			first_seen, last_updated, sensor_track_ids,
+			security_label, releasability
 		FROM tracks
-		WHERE external_track_id = $1
+		WHERE external_track_id = $1 OR sensor_track_ids @> ARRAY[$1::text]
+		ORDER BY external_track_id = $1 DESC, last_updated DESC
+		LIMIT 1
 	`
 
 	var t TrackRow
@@ -254,11 +375,13 @@ func (p *Pool) GetTrack(ctx context.Context, trackID string) (*TrackRow, error)
 	var posAlt, velSpeed, velHeading *float64
 
 	err := p.QueryRow(ctx, query, trackID).Scan(
-		&t.TrackID, &t.ExternalID, &t.Classification, &t.Type, &t.ThreatLevel,
+		&t.TrackID, &t.ExternalID, &t.Classification, &t.Type, &t.ThreatLevel, &t.ThreatScore,
 		&posLat, &posLon, &posAlt,
 		&velSpeed, &velHeading,
 		&t.Confidence, &t.Sources, &t.DetectionCount,
-		&t.FirstSeen, &t.LastUpdated,
+		&t.State, &t.StateChangedAt, &t.MergedInto,
+		&t.FirstSeen, &t.LastUpdated, &t.SensorTrackIDs,
+		&t.SecurityLabel, &t.Releasability,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -289,24 +412,234 @@ func (p *Pool) GetTrack(ctx context.Context, trackID string) (*TrackRow, error)
 
 // UpsertTrack inserts or updates a track from a CorrelatedTrack message
 func (p *Pool) UpsertTrack(ctx context.Context, track *messages.CorrelatedTrack) error {
+	if _, err := p.Exec(ctx, upsertTrackQuery, upsertTrackArgs(track)...); err != nil {
+		return fmt.Errorf("failed to upsert track: %w", err)
+	}
+	return nil
+}
+
+// BatchUpsertTracks upserts correlated tracks in a single round trip. The
+// batch runs as one implicit transaction, so either every track is written or
+// none is. Updates to the same track apply in order.
+func (p *Pool) BatchUpsertTracks(ctx context.Context, tracks []*messages.CorrelatedTrack) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, track := range tracks {
+		batch.Queue(upsertTrackQuery, upsertTrackArgs(track)...)
+	}
+
+	results := p.SendBatch(ctx, batch)
+	for _, track := range tracks {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return fmt.Errorf("failed to upsert track %s: %w", track.TrackID, err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("failed to upsert tracks: %w", err)
+	}
+	return nil
+}
+
+// upsertTrackQuery inserts a correlated track or updates the existing row,
+// counting the update as one more detection
+const upsertTrackQuery = `
+	INSERT INTO tracks (
+		external_track_id, classification, type, threat_level, threat_score,
+		position_lat, position_lon, position_alt,
+		velocity_speed, velocity_heading,
+		confidence, sources, detection_count,
+		first_seen, last_updated, state, sensor_track_ids,
+		security_label, releasability
+	) VALUES (
+		$1, $2, $3, $4, $5,
+		$6, $7, $8,
+		$9, $10,
+		$11, $12, $13,
+		$14, $15, 'active', $16,
+		$17, $18
+	)
+	ON CONFLICT (external_track_id) DO UPDATE SET
+		classification = EXCLUDED.classification,
+		type = EXCLUDED.type,
+		threat_level = EXCLUDED.threat_level,
+		threat_score = EXCLUDED.threat_score,
+		position_lat = EXCLUDED.position_lat,
+		position_lon = EXCLUDED.position_lon,
+		position_alt = EXCLUDED.position_alt,
+		velocity_speed = EXCLUDED.velocity_speed,
+		velocity_heading = EXCLUDED.velocity_heading,
+		confidence = EXCLUDED.confidence,
+		sources = EXCLUDED.sources,
+		detection_count = tracks.detection_count + 1,
+		last_updated = EXCLUDED.last_updated,
+		state = CASE WHEN tracks.state IN ('neutralized', 'merged') THEN tracks.state ELSE 'active' END,
+		state_changed_at = CASE WHEN tracks.state IN ('stale', 'dropped') THEN EXCLUDED.last_updated ELSE tracks.state_changed_at END,
+		sensor_track_ids = ARRAY(
+			SELECT DISTINCT id FROM unnest(tracks.sensor_track_ids || EXCLUDED.sensor_track_ids) AS id ORDER BY id
+		),
+		security_label = EXCLUDED.security_label,
+		releasability = EXCLUDED.releasability
+`
+
+// upsertTrackArgs returns the upsertTrackQuery arguments for a track
+func upsertTrackArgs(track *messages.CorrelatedTrack) []interface{} {
+	firstSeen := track.WindowStart
+	if track.LastUpdated.Before(firstSeen) {
+		firstSeen = track.LastUpdated
+	}
+	label, releasability := SecurityLabels(track.Envelope)
+
+	return []interface{}{
+		track.TrackID,
+		track.Classification,
+		track.Type,
+		track.ThreatLevel,
+		track.ThreatScore,
+		track.Position.Lat,
+		track.Position.Lon,
+		track.Position.Alt,
+		track.Velocity.Speed,
+		track.Velocity.Heading,
+		track.Confidence,
+		track.Sources,
+		track.DetectionCount,
+		firstSeen,
+		track.LastUpdated,
+		SensorTrackIDs(track),
+		label,
+		releasability,
+	}
+}
+
+// SensorTrackIDs returns the sensor track IDs fused into a correlated track.
+// The correlator lists them in MergedFrom; older messages carried the sensor
+// ID as the track ID itself.
+func SensorTrackIDs(track *messages.CorrelatedTrack) []string {
+	ids := make([]string, 0, len(track.MergedFrom))
+	seen := make(map[string]bool, len(track.MergedFrom))
+	for _, id := range track.MergedFrom {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		ids = append(ids, track.TrackID)
+	}
+	return ids
+}
+
+// MarkTracksMerged marks the tracks superseded by a merge, recording the
+// track they were fused into. A merged track stays merged if a report of it
+// published before the merge is persisted after it; neutralized tracks keep
+// their state.
+func (p *Pool) MarkTracksMerged(ctx context.Context, survivor string, superseded []string) (int64, error) {
+	tag, err := p.Exec(ctx, `
+		UPDATE tracks
+		SET state = 'merged', merged_into = $1, state_changed_at = NOW(), updated_at = NOW()
+		WHERE external_track_id = ANY($2) AND state <> 'neutralized'
+	`, survivor, superseded)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark tracks merged: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// TrackTransition is a track moved from one lifecycle state to another
+type TrackTransition struct {
+	TrackID        string
+	Classification string
+	Type           string
+	ThreatLevel    string
+	PreviousState  string
+	State          string
+	LastUpdated    time.Time
+	ChangedAt      time.Time
+}
+
+// TransitionIdleTracks moves tracks in state from to state to when they were
+// last updated before cutoff, returning the tracks moved
+func (p *Pool) TransitionIdleTracks(ctx context.Context, from, to string, cutoff, now time.Time) ([]TrackTransition, error) {
+	query := `
+		UPDATE tracks
+		SET state = $2, state_changed_at = $4, updated_at = NOW()
+		WHERE state = $1 AND last_updated < $3
+		RETURNING external_track_id, classification, type, threat_level, last_updated
+	`
+
+	rows, err := p.Query(ctx, query, from, to, cutoff, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transition %s tracks to %s: %w", from, to, err)
+	}
+	defer rows.Close()
+
+	var transitions []TrackTransition
+	for rows.Next() {
+		t := TrackTransition{PreviousState: from, State: to, ChangedAt: now}
+		if err := rows.Scan(&t.TrackID, &t.Classification, &t.Type, &t.ThreatLevel, &t.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan track transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating track transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// RebuiltTrack is a track reconstructed by replaying its correlated track messages
+type RebuiltTrack struct {
+	Track          *messages.CorrelatedTrack // Most recent message
+	FirstSeen      time.Time
+	DetectionCount int
+	Neutralized    bool
+	SensorTrackIDs []string // Every sensor track ID fused into the track
+}
+
+// WriteRebuiltTracks sets each track row to its rebuilt state in one transaction.
+// Rows are overwritten rather than incremented, so writing the same tracks twice
+// leaves the table unchanged. With reset, every existing row is deleted first.
+// Returns the number of rows written.
+func (p *Pool) WriteRebuiltTracks(ctx context.Context, tracks []RebuiltTrack, reset bool) (int64, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if reset {
+		if _, err := tx.Exec(ctx, "DELETE FROM tracks"); err != nil {
+			return 0, fmt.Errorf("failed to delete tracks: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO tracks (
-			external_track_id, classification, type, threat_level,
+			external_track_id, classification, type, threat_level, threat_score,
 			position_lat, position_lon, position_alt,
 			velocity_speed, velocity_heading,
 			confidence, sources, detection_count,
-			first_seen, last_updated, state
+			first_seen, last_updated, state, sensor_track_ids,
+			security_label, releasability
 		) VALUES (
-			$1, $2, $3, $4,
-			$5, $6, $7,
-			$8, $9,
-			$10, $11, $12,
-			$13, $14, 'active'
+			$1, $2, $3, $4, $5,
+			$6, $7, $8,
+			$9, $10,
+			$11, $12, $13,
+			$14, $15, $16, $17,
+			$18, $19
 		)
 		ON CONFLICT (external_track_id) DO UPDATE SET
 			classification = EXCLUDED.classification,
 			type = EXCLUDED.type,
 			threat_level = EXCLUDED.threat_level,
+			threat_score = EXCLUDED.threat_score,
 			position_lat = EXCLUDED.position_lat,
 			position_lon = EXCLUDED.position_lon,
 			position_alt = EXCLUDED.position_alt,
@@ -314,37 +647,64 @@ func (p *Pool) UpsertTrack(ctx context.Context, track *messages.CorrelatedTrack)
 			velocity_heading = EXCLUDED.velocity_heading,
 			confidence = EXCLUDED.confidence,
 			sources = EXCLUDED.sources,
-			detection_count = tracks.detection_count + 1,
+			detection_count = EXCLUDED.detection_count,
+			first_seen = EXCLUDED.first_seen,
 			last_updated = EXCLUDED.last_updated,
-			state = 'active'
+			state = EXCLUDED.state,
+			sensor_track_ids = EXCLUDED.sensor_track_ids,
+			security_label = EXCLUDED.security_label,
+			releasability = EXCLUDED.releasability,
+			updated_at = NOW()
 	`
 
-	firstSeen := track.WindowStart
-	if track.LastUpdated.Before(firstSeen) {
-		firstSeen = track.LastUpdated
+	var written int64
+	for _, rt := range tracks {
+		track := rt.Track
+		state := "active"
+		if rt.Neutralized {
+			state = "neutralized"
+		}
+		label, releasability := SecurityLabels(track.Envelope)
+		tag, err := tx.Exec(ctx, query,
+			track.TrackID,
+			track.Classification,
+			track.Type,
+			track.ThreatLevel,
+			track.ThreatScore,
+			track.Position.Lat,
+			track.Position.Lon,
+			track.Position.Alt,
+			track.Velocity.Speed,
+			track.Velocity.Heading,
+			track.Confidence,
+			track.Sources,
+			rt.DetectionCount,
+			rt.FirstSeen,
+			track.LastUpdated,
+			state,
+			rt.SensorTrackIDs,
+			label,
+			releasability,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write track %s: %w", track.TrackID, err)
+		}
+		written += tag.RowsAffected()
 	}
 
-	_, err := p.Exec(ctx, query,
-		track.TrackID,
-		track.Classification,
-		track.Type,
-		track.ThreatLevel,
-		track.Position.Lat,
-		track.Position.Lon,
-		track.Position.Alt,
-		track.Velocity.Speed,
-		track.Velocity.Heading,
-		track.Confidence,
-		track.Sources,
-		track.DetectionCount,
-		firstSeen,
-		track.LastUpdated,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to upsert track: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit rebuilt tracks: %w", err)
 	}
+	return written, nil
+}
 
-	return nil
+// CountTracks returns the number of rows in the tracks table, in any state
+func (p *Pool) CountTracks(ctx context.Context) (int64, error) {
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*) FROM tracks").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tracks: %w", err)
+	}
+	return count, nil
 }
 
 // DetectionRow represents a detection stored in the database
@@ -359,35 +719,144 @@ type DetectionRow struct {
 	Timestamp     time.Time       `json:"timestamp"`
 }
 
-// GetTrackHistory retrieves detection history for a track
-func (p *Pool) GetTrackHistory(ctx context.Context, trackID string, limit int) ([]DetectionRow, error) {
-	if limit <= 0 {
-		limit = 100
+// detectionCopyColumns are the detections columns written by BatchInsertDetections
+var detectionCopyColumns = []string{
+	"message_id", "correlation_id", "external_track_id",
+	"sensor_id", "sensor_type",
+	"position_lat", "position_lon", "position_alt",
+	"velocity_speed", "velocity_heading",
+	"confidence", "raw_data", "created_at",
+}
+
+// DetectionCopyRow converts a detection to a row of detectionCopyColumns. The
+// correlation ID falls back to the message ID when it is missing or not a
+// UUID. Detections without a UUID message ID are rejected.
+func DetectionCopyRow(d *messages.Detection) ([]interface{}, error) {
+	messageID, err := uuid.Parse(d.Envelope.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("detection message ID %q is not a UUID", d.Envelope.MessageID)
 	}
+	correlationID, err := uuid.Parse(d.Envelope.CorrelationID)
+	if err != nil {
+		correlationID = messageID
+	}
+	// Values the column types cannot hold would fail the whole COPY
+	switch {
+	case d.SensorID == "" || d.SensorType == "":
+		return nil, fmt.Errorf("detection %s has no sensor", messageID)
+	case len(d.SensorID) > 64 || len(d.SensorType) > 32 || len(d.TrackID) > 64:
+		return nil, fmt.Errorf("detection %s has an identifier too long to store", messageID)
+	case math.Abs(d.Position.Lat) > 90 || math.Abs(d.Position.Lon) > 180:
+		return nil, fmt.Errorf("detection %s has an invalid position", messageID)
+	case d.Confidence < 0 || d.Confidence > 1:
+		return nil, fmt.Errorf("detection %s has an invalid confidence", messageID)
+	case math.Abs(d.Velocity.Heading) >= 1000 || math.Abs(d.Velocity.Speed) >= 1e8 || math.Abs(d.Position.Alt) >= 1e8:
+		return nil, fmt.Errorf("detection %s has an out of range velocity or altitude", messageID)
+	}
+
+	var trackID *string
+	if d.TrackID != "" {
+		trackID = &d.TrackID
+	}
+	createdAt := d.Envelope.Timestamp
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	return []interface{}{
+		messageID, correlationID, trackID,
+		d.SensorID, d.SensorType,
+		d.Position.Lat, d.Position.Lon, d.Position.Alt,
+		d.Velocity.Speed, d.Velocity.Heading,
+		d.Confidence, d.RawData, createdAt,
+	}, nil
+}
 
-	// First get the internal UUID for the track
-	var internalTrackID string
-	err := p.QueryRow(ctx, "SELECT track_id FROM tracks WHERE external_track_id = $1", trackID).Scan(&internalTrackID)
-	if err == pgx.ErrNoRows {
-		return nil, nil
+// BatchInsertDetections bulk-loads detections with COPY through a staging table, so
+// redelivered detections already stored are skipped rather than failing the
+// batch. Detections DetectionCopyRow rejects are skipped. Returns the number
+// of rows inserted.
+func (p *Pool) BatchInsertDetections(ctx context.Context, detections []*messages.Detection) (int64, error) {
+	rows := make([][]interface{}, 0, len(detections))
+	for _, d := range detections {
+		row, err := DetectionCopyRow(d)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin detection copy: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `CREATE TEMP TABLE detections_staging (LIKE detections INCLUDING DEFAULTS) ON COMMIT DROP`); err != nil {
+		return 0, fmt.Errorf("failed to create detection staging table: %w", err)
 	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"detections_staging"}, detectionCopyColumns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, fmt.Errorf("failed to copy detections: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO detections (
+			message_id, correlation_id, external_track_id,
+			sensor_id, sensor_type,
+			position_lat, position_lon, position_alt,
+			velocity_speed, velocity_heading,
+			confidence, raw_data, created_at
+		)
+		SELECT
+			message_id, correlation_id, external_track_id,
+			sensor_id, sensor_type,
+			position_lat, position_lon, position_alt,
+			velocity_speed, velocity_heading,
+			confidence, raw_data, created_at
+		FROM detections_staging
+		ON CONFLICT (message_id, created_at) DO NOTHING
+	`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get track ID: %w", err)
+		return 0, fmt.Errorf("failed to insert detections: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit detections: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// GetTrackHistory retrieves detection history for a track
+func (p *Pool) GetTrackHistory(ctx context.Context, trackID string, limit int) ([]DetectionRow, error) {
+	if limit <= 0 {
+		limit = 100
 	}
 
+	// Persisted detections carry the external track ID; older rows reference the
+	// track's internal UUID. Nothing is detected for a track before it was first
+	// seen, allowing an hour of sensor clock skew, so the bound on created_at
+	// keeps the scan to the partitions of the track's lifetime.
 	query := `
+		WITH track AS (
+			SELECT track_id, first_seen, sensor_track_ids FROM tracks WHERE external_track_id = $1
+		)
 		SELECT
 			detection_id, sensor_id, sensor_type,
 			position_lat, position_lon, position_alt,
 			velocity_speed, velocity_heading,
 			confidence, created_at
 		FROM detections
-		WHERE track_id = $1
+		WHERE (external_track_id = $1 OR track_id = (SELECT track_id FROM track)
+		       OR external_track_id = ANY((SELECT sensor_track_ids FROM track)))
+		  AND created_at >= COALESCE((SELECT first_seen FROM track) - INTERVAL '1 hour', '-infinity')
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
 
-	rows, err := p.Query(ctx, query, internalTrackID, limit)
+	rows, err := p.Query(ctx, query, trackID, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query detection history: %w", err)
 	}
@@ -440,19 +909,32 @@ func (p *Pool) GetTrackHistory(ctx context.Context, trackID string, limit int) (
 
 // ProposalRow represents a proposal stored in the database
 type ProposalRow struct {
-	ProposalID     string          `json:"proposal_id"`
-	TrackID        string          `json:"track_id"`
-	ActionType     string          `json:"action_type"`
-	Priority       int             `json:"priority"`
-	ThreatLevel    string          `json:"threat_level"`
-	Rationale      string          `json:"rationale"`
-	Status         string          `json:"status"`
-	ExpiresAt      time.Time       `json:"expires_at"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
-	PolicyDecision json.RawMessage `json:"policy_decision"`
-	HitCount       int             `json:"hit_count"`
-	LastHitAt      time.Time       `json:"last_hit_at"`
+	ProposalID      string          `json:"proposal_id"`
+	TrackID         string          `json:"track_id"`
+	ActionType      string          `json:"action_type"`
+	Priority        int             `json:"priority"`
+	ThreatLevel     string          `json:"threat_level"`
+	Rationale       string          `json:"rationale"`
+	Status          string          `json:"status"`
+	ExpiresAt       time.Time       `json:"expires_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	PolicyDecision  json.RawMessage `json:"policy_decision"`
+	HitCount        int             `json:"hit_count"`
+	LastHitAt       time.Time       `json:"last_hit_at"`
+	EscalationLevel int             `json:"escalation_level"`
+	TraceID         string          `json:"-"`
+	SpanID          string          `json:"-"`
+
+	Options []messages.CourseOfAction `json:"options,omitempty"`
+	Asset   *messages.AssetAssignment `json:"asset,omitempty"`
+
+	Conflicts []messages.ProposalConflict `json:"conflicts,omitempty"`
+
+	SecurityLabel string   `json:"security_label"`
+	Releasability []string `json:"releasability"` // Coalition partners the proposal is releasable to
+
+	Cursor string `json:"-"` // Set by ListProposals; resumes the list after this proposal
 }
 
 // ProposalFilter defines filter options for proposal queries
@@ -461,21 +943,16 @@ type ProposalFilter struct {
 	TrackID     string
 	ActionType  string
 	ThreatLevel string
+	Access      seclabel.Access // Only proposals the caller's clearance and releasability permit
+	Sort        []SortKey       // Fields of ProposalSort; highest priority, then newest, first when empty
+	After       []string        // Cursor values from ProposalSort.DecodeCursor; only proposals after it
 	Limit       int
 	Offset      int
 }
 
-// ListProposals retrieves proposals with optional filtering
-func (p *Pool) ListProposals(ctx context.Context, filter ProposalFilter) ([]ProposalRow, error) {
-	query := `
-		SELECT
-			p.proposal_id, p.track_id as external_track_id, p.action_type, p.priority,
-			p.threat_level, p.rationale, p.status, p.expires_at,
-			p.created_at, p.updated_at, p.policy_decision as policy_result,
-			COALESCE(p.hit_count, 1) as hit_count, COALESCE(p.last_hit_at, p.created_at) as last_hit_at
-		FROM proposals p
-		WHERE 1=1
-	`
+// proposalConditions returns the WHERE conditions of a proposal filter and their arguments
+func proposalConditions(filter ProposalFilter) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 	argNum := 1
 
@@ -503,7 +980,44 @@ func (p *Pool) ListProposals(ctx context.Context, filter ProposalFilter) ([]Prop
 		argNum++
 	}
 
-	query += " ORDER BY p.priority DESC, p.created_at DESC"
+	clause, accessArgs := accessConditions(filter.Access, "p.", argNum)
+	query += clause
+	args = append(args, accessArgs...)
+
+	return query, args
+}
+
+// CountFilteredProposals counts the proposals matching a filter, ignoring its cursor and paging
+func (p *Pool) CountFilteredProposals(ctx context.Context, filter ProposalFilter) (int64, error) {
+	where, args := proposalConditions(filter)
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*) FROM proposals p WHERE 1=1"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count proposals: %w", err)
+	}
+	return count, nil
+}
+
+// ListProposals retrieves proposals with optional filtering. Each row's
+// Cursor resumes the list after it.
+func (p *Pool) ListProposals(ctx context.Context, filter ProposalFilter) ([]ProposalRow, error) {
+	where, args := proposalConditions(filter)
+	ks := ProposalSort.keyset(filter.Sort, filter.After, len(args)+1)
+	args = append(args, ks.args...)
+	argNum := len(args) + 1
+
+	query := `
+		SELECT
+			p.proposal_id, p.track_id as external_track_id, p.action_type, p.priority,
+			p.threat_level, p.rationale, p.status, p.expires_at,
+			p.created_at, p.updated_at, p.policy_decision as policy_result,
+			COALESCE(p.hit_count, 1) as hit_count, COALESCE(p.last_hit_at, p.created_at) as last_hit_at,
+			COALESCE(p.escalation_level, 0) as escalation_level,
+			COALESCE(p.trace_id, '') as trace_id, COALESCE(p.span_id, '') as span_id,
+			p.options, p.asset, p.conflicts,
+			p.security_label, p.releasability, ` + ks.key + `
+		FROM proposals p
+		WHERE 1=1
+	` + where + ks.after + ks.orderBy
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argNum)
@@ -525,15 +1039,20 @@ func (p *Pool) ListProposals(ctx context.Context, filter ProposalFilter) ([]Prop
 	var proposals []ProposalRow
 	for rows.Next() {
 		var pr ProposalRow
+		var key []string
 		err := rows.Scan(
 			&pr.ProposalID, &pr.TrackID, &pr.ActionType, &pr.Priority,
 			&pr.ThreatLevel, &pr.Rationale, &pr.Status, &pr.ExpiresAt,
 			&pr.CreatedAt, &pr.UpdatedAt, &pr.PolicyDecision,
-			&pr.HitCount, &pr.LastHitAt,
+			&pr.HitCount, &pr.LastHitAt, &pr.EscalationLevel,
+			&pr.TraceID, &pr.SpanID,
+			&pr.Options, &pr.Asset, &pr.Conflicts,
+			&pr.SecurityLabel, &pr.Releasability, &key,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan proposal: %w", err)
 		}
+		pr.Cursor = ks.cursor(key)
 		proposals = append(proposals, pr)
 	}
 
@@ -551,7 +1070,11 @@ func (p *Pool) GetProposal(ctx context.Context, proposalID string) (*ProposalRow
 			p.proposal_id, p.track_id as external_track_id, p.action_type, p.priority,
 			p.threat_level, p.rationale, p.status, p.expires_at,
 			p.created_at, p.updated_at, p.policy_decision as policy_result,
-			COALESCE(p.hit_count, 1) as hit_count, COALESCE(p.last_hit_at, p.created_at) as last_hit_at
+			COALESCE(p.hit_count, 1) as hit_count, COALESCE(p.last_hit_at, p.created_at) as last_hit_at,
+			COALESCE(p.escalation_level, 0) as escalation_level,
+			COALESCE(p.trace_id, '') as trace_id, COALESCE(p.span_id, '') as span_id,
+			p.options, p.asset, p.conflicts,
+			p.security_label, p.releasability
 		FROM proposals p
 		WHERE p.proposal_id = $1
 	`
@@ -561,7 +1084,10 @@ func (p *Pool) GetProposal(ctx context.Context, proposalID string) (*ProposalRow
 		&pr.ProposalID, &pr.TrackID, &pr.ActionType, &pr.Priority,
 		&pr.ThreatLevel, &pr.Rationale, &pr.Status, &pr.ExpiresAt,
 		&pr.CreatedAt, &pr.UpdatedAt, &pr.PolicyDecision,
-		&pr.HitCount, &pr.LastHitAt,
+		&pr.HitCount, &pr.LastHitAt, &pr.EscalationLevel,
+		&pr.TraceID, &pr.SpanID,
+		&pr.Options, &pr.Asset, &pr.Conflicts,
+		&pr.SecurityLabel, &pr.Releasability,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, nil
@@ -587,6 +1113,21 @@ func (p *Pool) UpdateProposalStatus(ctx context.Context, proposalID, status stri
 	return nil
 }
 
+// ExtendPendingProposals pushes back the expiry of every pending proposal that
+// was still live at since by d. Returns the number of proposals extended.
+func (p *Pool) ExtendPendingProposals(ctx context.Context, since time.Time, d time.Duration) (int64, error) {
+	query := `
+		UPDATE proposals
+		SET expires_at = expires_at + make_interval(secs => $2), updated_at = NOW()
+		WHERE status = 'pending' AND expires_at > $1
+	`
+	tag, err := p.Exec(ctx, query, since, d.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to extend pending proposals: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
 // DecisionRow represents a decision stored in the database
 type DecisionRow struct {
 	DecisionID   string    `json:"decision_id"`
@@ -599,6 +1140,17 @@ type DecisionRow struct {
 	Reason       string    `json:"reason"`
 	Conditions   []string  `json:"conditions"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	BreakGlassGrantID *string `json:"break_glass_grant_id,omitempty"`
+	SelectedOption    *string `json:"selected_option,omitempty"`
+	MachineApproved   bool    `json:"machine_approved"`
+	AutoApproveRuleID *string `json:"auto_approve_rule_id,omitempty"`
+
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy    *string    `json:"revoked_by,omitempty"`
+	RevokeReason *string    `json:"revoke_reason,omitempty"`
+
+	Cursor string `json:"-"` // Set by ListDecisions; resumes the list after this decision
 }
 
 // DecisionFilter defines filter options for decision queries
@@ -608,20 +1160,17 @@ type DecisionFilter struct {
 	Approved   *bool
 	ApprovedBy string
 	Since      *time.Time
+
+	MachineApproved *bool     // Only decisions made, or not made, by auto_approve rules
+	Sort            []SortKey // Fields of DecisionSort; most recently approved first when empty
+	After           []string  // Cursor values from DecisionSort.DecodeCursor; only decisions after it
 	Limit      int
 	Offset     int
 }
 
-// ListDecisions retrieves decisions with optional filtering
-func (p *Pool) ListDecisions(ctx context.Context, filter DecisionFilter) ([]DecisionRow, error) {
-	query := `
-		SELECT
-			d.decision_id, d.proposal_id, d.track_id as external_track_id, d.action_type,
-			d.approved, d.approved_by, d.approved_at, d.reason, d.conditions,
-			d.created_at
-		FROM decisions d
-		WHERE 1=1
-	`
+// decisionConditions returns the WHERE conditions of a decision filter and their arguments
+func decisionConditions(filter DecisionFilter) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 	argNum := 1
 
@@ -649,23 +1198,58 @@ func (p *Pool) ListDecisions(ctx context.Context, filter DecisionFilter) ([]Deci
 		argNum++
 	}
 
+	if filter.MachineApproved != nil {
+		query += fmt.Sprintf(" AND d.machine_approved = $%d", argNum)
+		args = append(args, *filter.MachineApproved)
+		argNum++
+	}
+
 	if filter.Since != nil {
 		query += fmt.Sprintf(" AND d.approved_at >= $%d", argNum)
 		args = append(args, *filter.Since)
-		argNum++
 	}
 
-	query += " ORDER BY d.approved_at DESC"
+	return query, args
+}
 
-	if filter.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d", argNum)
-		args = append(args, filter.Limit)
-		argNum++
+// CountFilteredDecisions counts the decisions matching a filter, ignoring its cursor and paging
+func (p *Pool) CountFilteredDecisions(ctx context.Context, filter DecisionFilter) (int64, error) {
+	where, args := decisionConditions(filter)
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*) FROM decisions d WHERE 1=1"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count decisions: %w", err)
 	}
+	return count, nil
+}
 
-	if filter.Offset > 0 {
-		query += fmt.Sprintf(" OFFSET $%d", argNum)
-		args = append(args, filter.Offset)
+// ListDecisions retrieves decisions with optional filtering. Each row's
+// Cursor resumes the list after it.
+func (p *Pool) ListDecisions(ctx context.Context, filter DecisionFilter) ([]DecisionRow, error) {
+	where, args := decisionConditions(filter)
+	ks := DecisionSort.keyset(filter.Sort, filter.After, len(args)+1)
+	args = append(args, ks.args...)
+	argNum := len(args) + 1
+
+	query := `
+		SELECT
+			d.decision_id, d.proposal_id, d.track_id as external_track_id, d.action_type,
+			d.approved, d.approved_by, d.approved_at, d.reason, d.conditions,
+			d.created_at, d.break_glass_grant_id::text, d.selected_option,
+			d.machine_approved, d.auto_approve_rule_id::text,
+			d.revoked_at, d.revoked_by, d.revoke_reason, ` + ks.key + `
+		FROM decisions d
+		WHERE 1=1
+	` + where + ks.after + ks.orderBy
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
 	}
 
 	rows, err := p.Query(ctx, query, args...)
@@ -678,14 +1262,18 @@ func (p *Pool) ListDecisions(ctx context.Context, filter DecisionFilter) ([]Deci
 	for rows.Next() {
 		var d DecisionRow
 		var reason *string
+		var key []string
 		err := rows.Scan(
 			&d.DecisionID, &d.ProposalID, &d.TrackID, &d.ActionType,
 			&d.Approved, &d.ApprovedBy, &d.ApprovedAt, &reason, &d.Conditions,
-			&d.CreatedAt,
+			&d.CreatedAt, &d.BreakGlassGrantID, &d.SelectedOption,
+			&d.MachineApproved, &d.AutoApproveRuleID,
+			&d.RevokedAt, &d.RevokedBy, &d.RevokeReason, &key,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan decision: %w", err)
 		}
+		d.Cursor = ks.cursor(key)
 		if reason != nil {
 			d.Reason = *reason
 		}
@@ -699,29 +1287,424 @@ func (p *Pool) ListDecisions(ctx context.Context, filter DecisionFilter) ([]Deci
 	return decisions, nil
 }
 
-// InsertDecision inserts a new decision
+// ErrProposalNotPending is returned by InsertDecision when the proposal was
+// decided or expired before the decision could settle it
+var ErrProposalNotPending = errors.New("proposal is not pending")
+
+// ErrProposalClaimed is returned when another operator holds a live claim on
+// the proposal
+var ErrProposalClaimed = errors.New("proposal is claimed by another operator")
+
+// InsertDecision inserts a new decision and appends it to the audit chain.
+// A decision made under a break-glass grant also records the grant's use.
+//
+// The decision settles its proposal in the same transaction, moving it from
+// pending to approved or denied only if it is still pending, unexpired, and
+// not claimed by another operator, and releasing any claim on it. When two
+// operators decide one proposal at once the second gets ErrProposalNotPending,
+// naming the status the first left it in.
 func (p *Pool) InsertDecision(ctx context.Context, decision *messages.Decision) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := SettleProposal(ctx, tx, decision); err != nil {
+		return err
+	}
+
+	if err := StoreDecision(ctx, tx, decision); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit decision: %w", err)
+	}
+
+	return nil
+}
+
+// StoreDecision inserts a decision and appends it to the audit chain in the
+// transaction that settles or expires its proposal. A decision made under a
+// break-glass grant also records the grant's use.
+func StoreDecision(ctx context.Context, tx pgx.Tx, decision *messages.Decision) error {
+	record := audit.DecisionRecord{
+		DecisionID: decision.DecisionID,
+		ProposalID: decision.ProposalID,
+		Approved:   decision.Approved,
+		ApprovedBy: decision.ApprovedBy,
+		ApprovedAt: decision.ApprovedAt,
+		Reason:     decision.Reason,
+		Conditions: decision.Conditions,
+		ActionType: decision.ActionType,
+		TrackID:    decision.TrackID,
+
+		BreakGlassGrantID: decision.BreakGlassGrantID,
+		SelectedOption:    decision.SelectedOption,
+		MachineApproved:   decision.MachineApproved,
+		AutoApproveRuleID: decision.AutoApproveRuleID,
+	}
+	link, err := audit.Append(ctx, tx, audit.EntityDecision, decision.DecisionID, record.Payload())
+	if err != nil {
+		return err
+	}
+
+	var grantID *string
+	if decision.BreakGlassGrantID != "" {
+		grantID = &decision.BreakGlassGrantID
+	}
+
 	query := `
 		INSERT INTO decisions (
 			decision_id, message_id, correlation_id, proposal_id,
 			approved, approved_by, approved_at, reason, conditions,
-			action_type, track_id
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			action_type, track_id, chain_seq, prev_hash, chain_hash,
+			break_glass_grant_id, selected_option, machine_approved, auto_approve_rule_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''), $17, NULLIF($18, '')::uuid)
 	`
 
-	_, err := p.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		decision.DecisionID, decision.Envelope.MessageID, decision.Envelope.CorrelationID,
 		decision.ProposalID, decision.Approved, decision.ApprovedBy, decision.ApprovedAt,
 		decision.Reason, decision.Conditions,
 		decision.ActionType, decision.TrackID,
+		link.Seq, link.PrevHash, link.Hash,
+		grantID, decision.SelectedOption, decision.MachineApproved, decision.AutoApproveRuleID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert decision: %w", err)
 	}
 
+	if grantID != nil {
+		if err := breakglass.RecordUse(ctx, tx, *grantID, decision.DecisionID, decision.ProposalID,
+			decision.ActionType, decision.ApprovedBy, decision.Envelope.CorrelationID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ExpireProposal moves a proposal that is still pending to expired in the
+// transaction that stores its expiry decision. It reports false, changing
+// nothing, for a proposal decided meanwhile.
+func ExpireProposal(ctx context.Context, tx pgx.Tx, proposalID string) (bool, error) {
+	tag, err := tx.Exec(ctx,
+		"UPDATE proposals SET status = 'expired', updated_at = NOW() WHERE proposal_id = $1 AND status = 'pending'",
+		proposalID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update expired proposal: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SettleProposal moves a decision's proposal out of pending and releases any
+// claim on it, failing with ErrProposalNotPending if it has been decided or
+// has expired, or ErrProposalClaimed if another operator has claimed it. It
+// runs in the transaction that stores the decision.
+func SettleProposal(ctx context.Context, tx pgx.Tx, decision *messages.Decision) error {
+	status := "denied"
+	if decision.Approved {
+		status = "approved"
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE proposals
+		SET status = $2, updated_at = NOW()
+		WHERE proposal_id = $1 AND status = 'pending' AND expires_at > NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM proposal_claims
+				WHERE proposal_id = $1 AND claimed_by <> $3 AND expires_at > NOW()
+			)
+	`, decision.ProposalID, status, decision.ApprovedBy)
+	if err != nil {
+		return fmt.Errorf("failed to settle proposal: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		if _, err := tx.Exec(ctx, `DELETE FROM proposal_claims WHERE proposal_id = $1`, decision.ProposalID); err != nil {
+			return fmt.Errorf("failed to release proposal claim: %w", err)
+		}
+		return nil
+	}
+
+	var current, claimedBy string
+	err = tx.QueryRow(ctx, `
+		SELECT CASE WHEN p.status = 'pending' AND p.expires_at <= NOW() THEN 'expired' ELSE p.status END,
+			COALESCE(c.claimed_by, '')
+		FROM proposals p
+		LEFT JOIN proposal_claims c ON c.proposal_id = p.proposal_id AND c.expires_at > NOW()
+		WHERE p.proposal_id = $1
+	`, decision.ProposalID).Scan(&current, &claimedBy)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("proposal %s not found", decision.ProposalID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check proposal status: %w", err)
+	}
+	if current == "pending" && claimedBy != "" {
+		return fmt.Errorf("%w: %s", ErrProposalClaimed, claimedBy)
+	}
+	return fmt.Errorf("%w: proposal is %s", ErrProposalNotPending, current)
+}
+
+// ProposalClaim is an operator's claim to work a pending proposal. It lapses
+// at ExpiresAt unless its holder stays active.
+type ProposalClaim struct {
+	ProposalID   string    `json:"proposal_id"`
+	ClaimedBy    string    `json:"claimed_by"`
+	ClaimedAt    time.Time `json:"claimed_at"`
+	LastActiveAt time.Time `json:"last_active_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// proposalClaimColumns lists the proposal_claims columns read by scanProposalClaim
+const proposalClaimColumns = `proposal_id::text, claimed_by, claimed_at, last_active_at, expires_at`
+
+func scanProposalClaim(row pgx.Row) (ProposalClaim, error) {
+	var c ProposalClaim
+	err := row.Scan(&c.ProposalID, &c.ClaimedBy, &c.ClaimedAt, &c.LastActiveAt, &c.ExpiresAt)
+	return c, err
+}
+
+// ClaimProposal claims a pending proposal for userID until timeout passes
+// without activity. Claiming a proposal the user already holds renews the
+// claim. If another operator holds a live claim it is returned with
+// ErrProposalClaimed; a proposal that is decided or expired gives
+// ErrProposalNotPending, and a missing one gives nil.
+func (p *Pool) ClaimProposal(ctx context.Context, proposalID, userID string, timeout time.Duration, now time.Time) (*ProposalClaim, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the proposal so a decision cannot settle it under the claim
+	var status string
+	var expiresAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT status, expires_at FROM proposals WHERE proposal_id = $1 FOR UPDATE
+	`, proposalID).Scan(&status, &expiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+	if status == "pending" && !now.Before(expiresAt) {
+		status = "expired"
+	}
+	if status != "pending" {
+		return nil, fmt.Errorf("%w: proposal is %s", ErrProposalNotPending, status)
+	}
+
+	// A lapsed claim is replaced; a live one is renewed only by its holder
+	claim, err := scanProposalClaim(tx.QueryRow(ctx, `
+		INSERT INTO proposal_claims (proposal_id, claimed_by, claimed_at, last_active_at, expires_at)
+		VALUES ($1, $2, $3, $3, $4)
+		ON CONFLICT (proposal_id) DO UPDATE SET
+			claimed_by = EXCLUDED.claimed_by,
+			claimed_at = CASE WHEN proposal_claims.claimed_by = EXCLUDED.claimed_by AND proposal_claims.expires_at > $3
+				THEN proposal_claims.claimed_at ELSE EXCLUDED.claimed_at END,
+			last_active_at = EXCLUDED.last_active_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE proposal_claims.claimed_by = EXCLUDED.claimed_by OR proposal_claims.expires_at <= $3
+		RETURNING `+proposalClaimColumns,
+		proposalID, userID, now.UTC(), now.Add(timeout).UTC()))
+	if err == pgx.ErrNoRows {
+		held, err := scanProposalClaim(tx.QueryRow(ctx, `
+			SELECT `+proposalClaimColumns+` FROM proposal_claims WHERE proposal_id = $1
+		`, proposalID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proposal claim: %w", err)
+		}
+		return &held, fmt.Errorf("%w: %s", ErrProposalClaimed, held.ClaimedBy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim proposal: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &claim, nil
+}
+
+// ReleaseProposalClaim releases userID's claim on a proposal and returns it.
+// If another operator holds a live claim it is returned with
+// ErrProposalClaimed; if there is no live claim it returns nil.
+func (p *Pool) ReleaseProposalClaim(ctx context.Context, proposalID, userID string, now time.Time) (*ProposalClaim, error) {
+	claim, err := scanProposalClaim(p.QueryRow(ctx, `
+		DELETE FROM proposal_claims
+		WHERE proposal_id = $1 AND claimed_by = $2 AND expires_at > $3
+		RETURNING `+proposalClaimColumns, proposalID, userID, now.UTC()))
+	if err == nil {
+		return &claim, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to release proposal claim: %w", err)
+	}
+
+	claims, err := p.GetProposalClaims(ctx, []string{proposalID}, now)
+	if err != nil {
+		return nil, err
+	}
+	if held, ok := claims[proposalID]; ok {
+		return &held, fmt.Errorf("%w: %s", ErrProposalClaimed, held.ClaimedBy)
+	}
+	return nil, nil
+}
+
+// GetProposalClaims returns the live claims on the given proposals by proposal ID
+func (p *Pool) GetProposalClaims(ctx context.Context, proposalIDs []string, now time.Time) (map[string]ProposalClaim, error) {
+	claims := make(map[string]ProposalClaim)
+	if len(proposalIDs) == 0 {
+		return claims, nil
+	}
+
+	rows, err := p.Query(ctx, `
+		SELECT `+proposalClaimColumns+`
+		FROM proposal_claims
+		WHERE proposal_id::text = ANY($1) AND expires_at > $2
+	`, proposalIDs, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query proposal claims: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanProposalClaim(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proposal claim: %w", err)
+		}
+		claims[c.ProposalID] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating proposal claims: %w", err)
+	}
+	return claims, nil
+}
+
+// ExpireProposalClaims deletes claims that lapsed without activity, or whose
+// proposal is no longer pending, and returns them
+func (p *Pool) ExpireProposalClaims(ctx context.Context, now time.Time) ([]ProposalClaim, error) {
+	rows, err := p.Query(ctx, `
+		DELETE FROM proposal_claims c
+		USING proposals p
+		WHERE c.proposal_id = p.proposal_id
+			AND (c.expires_at <= $1 OR p.status <> 'pending' OR p.expires_at <= $1)
+		RETURNING c.proposal_id::text, c.claimed_by, c.claimed_at, c.last_active_at, c.expires_at
+	`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire proposal claims: %w", err)
+	}
+	defer rows.Close()
+
+	claims := []ProposalClaim{}
+	for rows.Next() {
+		c, err := scanProposalClaim(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expired proposal claim: %w", err)
+		}
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired proposal claims: %w", err)
+	}
+	return claims, nil
+}
+
+// GetAuditChainHead returns the latest link recorded in the audit chain
+func (p *Pool) GetAuditChainHead(ctx context.Context) (audit.Head, error) {
+	var head audit.Head
+	err := p.QueryRow(ctx, "SELECT seq, hash FROM audit_chain_head WHERE id").Scan(&head.Seq, &head.Hash)
+	if err != nil {
+		return head, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	return head, nil
+}
+
+// ListAuditChain rebuilds every chained decision and effect as an audit link,
+// ordered by sequence number. Payloads are recomputed from the stored columns
+// so any edit to a chained row shows up as a hash mismatch.
+func (p *Pool) ListAuditChain(ctx context.Context) ([]audit.Link, error) {
+	var links []audit.Link
+
+	rows, err := p.Query(ctx, `
+		SELECT chain_seq, prev_hash, chain_hash,
+			decision_id::text, COALESCE(proposal_id::text, ''), approved, approved_by,
+			approved_at, COALESCE(reason, ''), conditions, action_type, track_id,
+			COALESCE(break_glass_grant_id::text, ''), COALESCE(selected_option, ''),
+			machine_approved, COALESCE(auto_approve_rule_id::text, '')
+		FROM decisions
+		WHERE chain_seq IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chained decisions: %w", err)
+	}
+	for rows.Next() {
+		var link audit.Link
+		var r audit.DecisionRecord
+		if err := rows.Scan(
+			&link.Seq, &link.PrevHash, &link.Hash,
+			&r.DecisionID, &r.ProposalID, &r.Approved, &r.ApprovedBy,
+			&r.ApprovedAt, &r.Reason, &r.Conditions, &r.ActionType, &r.TrackID,
+			&r.BreakGlassGrantID, &r.SelectedOption,
+			&r.MachineApproved, &r.AutoApproveRuleID,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan chained decision: %w", err)
+		}
+		link.EntityType = audit.EntityDecision
+		link.EntityID = r.DecisionID
+		link.Payload = r.Payload()
+		links = append(links, link)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chained decisions: %w", err)
+	}
+
+	rows, err = p.Query(ctx, `
+		SELECT chain_seq, prev_hash, chain_hash,
+			effect_id::text, COALESCE(decision_id::text, ''), COALESCE(proposal_id::text, ''),
+			track_id, action_type, status, COALESCE(result, ''), idempotent_key,
+			executed_at, COALESCE(fencing_token, 0), COALESCE(outcome, ''), collateral
+		FROM effects_all
+		WHERE chain_seq IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chained effects: %w", err)
+	}
+	for rows.Next() {
+		var link audit.Link
+		var r audit.EffectRecord
+		var executedAt *time.Time
+		if err := rows.Scan(
+			&link.Seq, &link.PrevHash, &link.Hash,
+			&r.EffectID, &r.DecisionID, &r.ProposalID,
+			&r.TrackID, &r.ActionType, &r.Status, &r.Result, &r.IdempotentKey,
+			&executedAt, &r.FencingToken, &r.Outcome, &r.Collateral,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan chained effect: %w", err)
+		}
+		if executedAt != nil {
+			r.ExecutedAt = *executedAt
+		}
+		link.EntityType = audit.EntityEffect
+		link.EntityID = r.EffectID
+		link.Payload = r.Payload()
+		links = append(links, link)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating chained effects: %w", err)
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].Seq < links[j].Seq })
+	return links, nil
+}
+
 // EffectRow represents an effect log stored in the database
 type EffectRow struct {
 	EffectID      string    `json:"effect_id"`
@@ -733,6 +1716,13 @@ type EffectRow struct {
 	ExecutedAt    time.Time `json:"executed_at"`
 	Result        string    `json:"result"`
 	IdempotentKey string    `json:"idempotent_key"`
+	FencingToken  *int64    `json:"fencing_token,omitempty"`
+	ExecutorID    *string   `json:"executor_id,omitempty"`
+	Attempts      int       `json:"attempts"`
+	Outcome       string    `json:"outcome,omitempty"`
+	Collateral    bool      `json:"collateral"`
+
+	Cursor string `json:"-"` // Set by ListEffects; resumes the list after this effect
 }
 
 // EffectFilter defines filter options for effect queries
@@ -743,19 +1733,15 @@ type EffectFilter struct {
 	ActionType string
 	Status     string
 	Since      *time.Time
+	Sort       []SortKey // Fields of EffectSort; most recently executed first when empty
+	After      []string  // Cursor values from EffectSort.DecodeCursor; only effects after it
 	Limit      int
 	Offset     int
 }
 
-// ListEffects retrieves effects with optional filtering
-func (p *Pool) ListEffects(ctx context.Context, filter EffectFilter) ([]EffectRow, error) {
-	query := `
-		SELECT
-			e.effect_id, e.decision_id, e.proposal_id, e.track_id as external_track_id,
-			e.action_type, e.status, e.executed_at, e.result, e.idempotent_key
-		FROM effects e
-		WHERE 1=1
-	`
+// effectConditions returns the WHERE conditions of an effect filter and their arguments
+func effectConditions(filter EffectFilter) (string, []interface{}) {
+	query := ""
 	args := []interface{}{}
 	argNum := 1
 
@@ -792,10 +1778,37 @@ func (p *Pool) ListEffects(ctx context.Context, filter EffectFilter) ([]EffectRo
 	if filter.Since != nil {
 		query += fmt.Sprintf(" AND e.executed_at >= $%d", argNum)
 		args = append(args, *filter.Since)
-		argNum++
 	}
 
-	query += " ORDER BY e.executed_at DESC"
+	return query, args
+}
+
+// CountFilteredEffects counts the effects matching a filter, ignoring its cursor and paging
+func (p *Pool) CountFilteredEffects(ctx context.Context, filter EffectFilter) (int64, error) {
+	where, args := effectConditions(filter)
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*) FROM effects e WHERE 1=1"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count effects: %w", err)
+	}
+	return count, nil
+}
+
+// ListEffects retrieves effects with optional filtering. Each row's Cursor
+// resumes the list after it.
+func (p *Pool) ListEffects(ctx context.Context, filter EffectFilter) ([]EffectRow, error) {
+	where, args := effectConditions(filter)
+	ks := EffectSort.keyset(filter.Sort, filter.After, len(args)+1)
+	args = append(args, ks.args...)
+	argNum := len(args) + 1
+
+	query := `
+		SELECT
+			e.effect_id, e.decision_id, e.proposal_id, e.track_id as external_track_id,
+			e.action_type, e.status, e.executed_at, e.result, e.idempotent_key,
+			e.fencing_token, e.executor_id, e.attempts, COALESCE(e.outcome, ''), e.collateral, ` + ks.key + `
+		FROM effects e
+		WHERE 1=1
+	` + where + ks.after + ks.orderBy
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argNum)
@@ -819,13 +1832,16 @@ func (p *Pool) ListEffects(ctx context.Context, filter EffectFilter) ([]EffectRo
 		var e EffectRow
 		var result *string
 		var executedAt *time.Time
+		var key []string
 		err := rows.Scan(
 			&e.EffectID, &e.DecisionID, &e.ProposalID, &e.TrackID,
 			&e.ActionType, &e.Status, &executedAt, &result, &e.IdempotentKey,
+			&e.FencingToken, &e.ExecutorID, &e.Attempts, &e.Outcome, &e.Collateral, &key,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan effect: %w", err)
 		}
+		e.Cursor = ks.cursor(key)
 		if result != nil {
 			e.Result = *result
 		}
@@ -842,6 +1858,48 @@ func (p *Pool) ListEffects(ctx context.Context, filter EffectFilter) ([]EffectRo
 	return effects, nil
 }
 
+// RecordAssessment stores a battle damage assessment and, when the target was
+// neutralized, takes its track out of the active picture. Replays are ignored.
+func (p *Pool) RecordAssessment(ctx context.Context, assessment *messages.EffectAssessment) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO effect_assessments (
+			assessment_id, message_id, correlation_id, effect_id, decision_id, proposal_id,
+			track_id, action_type, success_probability, neutralized, basis,
+			assessor_id, assessed_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, NULLIF($6, '')::uuid, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (assessment_id) DO NOTHING
+	`,
+		assessment.AssessmentID, assessment.Envelope.MessageID, assessment.Envelope.CorrelationID,
+		assessment.EffectID, assessment.DecisionID, assessment.ProposalID,
+		assessment.TrackID, assessment.ActionType, assessment.SuccessProbability,
+		assessment.Neutralized, assessment.Basis,
+		assessment.Envelope.Source, assessment.AssessedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert assessment: %w", err)
+	}
+
+	if assessment.Neutralized {
+		_, err = tx.Exec(ctx,
+			"UPDATE tracks SET state = 'neutralized', updated_at = NOW() WHERE external_track_id = $1",
+			assessment.TrackID)
+		if err != nil {
+			return fmt.Errorf("failed to mark track neutralized: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit assessment: %w", err)
+	}
+	return nil
+}
+
 // StageMetrics represents metrics for a pipeline stage
 type StageMetrics struct {
 	Stage           string  `json:"stage"`
@@ -1233,6 +2291,8 @@ type AuditEntry struct {
 	Status     string `json:"status"`
 	Details    string `json:"details"`
 	Reason     string `json:"reason"`
+
+	ThreatLevel string `json:"threat_level,omitempty"`
 }
 
 // AuditFilter defines filter options for audit queries
@@ -1244,11 +2304,9 @@ type AuditFilter struct {
 	Offset     int
 }
 
-// ListAuditEntries retrieves audit entries by querying the decision_audit_trail view
-func (p *Pool) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
-	// Query the decision_audit_trail view and map to AuditEntry format
-	query := `
-		SELECT
+// auditEntryColumns are the columns scanned by scanAuditEntry, selected from
+// decisions d joined with their proposal p and effect e
+const auditEntryColumns = `
 			d.decision_id,
 			d.approved,
 			d.approved_by,
@@ -1261,12 +2319,99 @@ func (p *Pool) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]Audi
 			p.threat_level,
 			e.effect_id,
 			e.status as effect_status,
-			e.executed_at
+			e.executed_at`
+
+// auditEntryFrom joins each decision with its proposal and live or archived effect
+const auditEntryFrom = `
 		FROM decisions d
 		JOIN proposals p ON d.proposal_id = p.proposal_id
-		LEFT JOIN effects e ON d.decision_id = e.decision_id
-		WHERE 1=1
-	`
+		LEFT JOIN effects_all e ON d.decision_id = e.decision_id`
+
+// scanAuditEntry scans auditEntryColumns followed by any extra columns into dest
+func scanAuditEntry(rows pgx.Rows, dest ...interface{}) (AuditEntry, error) {
+	var (
+		decisionID   string
+		approved     bool
+		approvedBy   string
+		approvedAt   time.Time
+		reason       *string
+		proposalID   string
+		actionType   string
+		rationale    *string
+		trackID      string
+		threatLevel  *string
+		effectID     *string
+		effectStatus *string
+		executedAt   *time.Time
+	)
+
+	err := rows.Scan(append([]interface{}{
+		&decisionID, &approved, &approvedBy, &approvedAt, &reason,
+		&proposalID, &actionType, &rationale, &trackID, &threatLevel,
+		&effectID, &effectStatus, &executedAt,
+	}, dest...)...)
+	if err != nil {
+		return AuditEntry{}, fmt.Errorf("failed to scan audit entry: %w", err)
+	}
+
+	// Determine status based on decision and effect
+	status := "proposed"
+	if approved {
+		status = "approved"
+		if effectID != nil && effectStatus != nil {
+			switch *effectStatus {
+			case "executed":
+				status = "executed"
+			case "failed", "failed_permanent":
+				status = "failed"
+			case "pending":
+				status = "approved"
+			}
+		}
+	} else {
+		status = "denied"
+	}
+
+	// Build details string
+	details := ""
+	if rationale != nil {
+		details = *rationale
+	}
+	if reason != nil && *reason != "" {
+		details = *reason
+	}
+
+	// Set reason from decision
+	reasonStr := ""
+	if reason != nil {
+		reasonStr = *reason
+	}
+
+	entry := AuditEntry{
+		ID:         decisionID,
+		Timestamp:  approvedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ActionType: actionType,
+		UserID:     approvedBy,
+		TrackID:    trackID,
+		ProposalID: proposalID,
+		DecisionID: decisionID,
+		Status:     status,
+		Details:    details,
+		Reason:     reasonStr,
+	}
+	if threatLevel != nil {
+		entry.ThreatLevel = *threatLevel
+	}
+	if effectID != nil {
+		entry.EffectID = *effectID
+	}
+	return entry, nil
+}
+
+// ListAuditEntries retrieves audit entries by querying the decision_audit_trail view
+func (p *Pool) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	// Query the decision_audit_trail view and map to AuditEntry format
+	query := "SELECT" + auditEntryColumns + auditEntryFrom + " WHERE 1=1"
 	args := []interface{}{}
 	argNum := 1
 
@@ -1309,81 +2454,10 @@ func (p *Pool) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]Audi
 
 	var entries []AuditEntry
 	for rows.Next() {
-		var (
-			decisionID    string
-			approved      bool
-			approvedBy    string
-			approvedAt    time.Time
-			reason        *string
-			proposalID    string
-			actionType    string
-			rationale     *string
-			trackID       string
-			threatLevel   *string
-			effectID      *string
-			effectStatus  *string
-			executedAt    *time.Time
-		)
-
-		err := rows.Scan(
-			&decisionID, &approved, &approvedBy, &approvedAt, &reason,
-			&proposalID, &actionType, &rationale, &trackID, &threatLevel,
-			&effectID, &effectStatus, &executedAt,
-		)
+		entry, err := scanAuditEntry(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+			return nil, err
 		}
-
-		// Determine status based on decision and effect
-		status := "proposed"
-		if approved {
-			status = "approved"
-			if effectID != nil && effectStatus != nil {
-				switch *effectStatus {
-				case "executed":
-					status = "executed"
-				case "failed":
-					status = "failed"
-				case "pending":
-					status = "approved"
-				}
-			}
-		} else {
-			status = "denied"
-		}
-
-		// Build details string
-		details := ""
-		if rationale != nil {
-			details = *rationale
-		}
-		if reason != nil && *reason != "" {
-			details = *reason
-		}
-
-		// Set reason from decision
-		reasonStr := ""
-		if reason != nil {
-			reasonStr = *reason
-		}
-
-		entry := AuditEntry{
-			ID:         decisionID,
-			Timestamp:  approvedAt.Format("2006-01-02T15:04:05Z07:00"),
-			ActionType: actionType,
-			UserID:     approvedBy,
-			TrackID:    trackID,
-			ProposalID: proposalID,
-			DecisionID: decisionID,
-			Status:     status,
-			Details:    details,
-			Reason:     reasonStr,
-		}
-
-		if effectID != nil {
-			entry.EffectID = *effectID
-		}
-
 		entries = append(entries, entry)
 	}
 
@@ -1394,24 +2468,181 @@ func (p *Pool) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]Audi
 	return entries, nil
 }
 
-// CountActiveTracks returns the count of active tracks updated within the last 60 seconds
-// This matches the default filter used by the tracks API endpoint
-func (p *Pool) CountActiveTracks(ctx context.Context) (int64, error) {
-	var count int64
-	err := p.QueryRow(ctx, "SELECT COUNT(*) FROM tracks WHERE state = 'active' AND last_updated > NOW() - INTERVAL '60 seconds'").Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count active tracks: %w", err)
-	}
-	return count, nil
+// Audit search outcomes, as reported in AuditEntry.Status
+const (
+	AuditOutcomeApproved = "approved" // Approved, effect not yet executed or failed
+	AuditOutcomeDenied   = "denied"
+	AuditOutcomeExecuted = "executed"
+	AuditOutcomeFailed   = "failed"
+)
+
+// auditOutcomeConditions selects the entries whose computed status is each outcome
+var auditOutcomeConditions = map[string]string{
+	AuditOutcomeApproved: "d.approved AND (e.status IS NULL OR e.status NOT IN ('executed', 'failed', 'failed_permanent'))",
+	AuditOutcomeDenied:   "NOT d.approved",
+	AuditOutcomeExecuted: "d.approved AND e.status = 'executed'",
+	AuditOutcomeFailed:   "d.approved AND e.status IN ('failed', 'failed_permanent')",
 }
 
-// CountPendingProposals returns the count of pending proposals
-func (p *Pool) CountPendingProposals(ctx context.Context) (int64, error) {
-	var count int64
-	err := p.QueryRow(ctx, "SELECT COUNT(*) FROM proposals WHERE status = 'pending' AND expires_at > NOW()").Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to count pending proposals: %w", err)
-	}
+// IsValidAuditOutcome reports whether outcome can be searched for
+func IsValidAuditOutcome(outcome string) bool {
+	_, ok := auditOutcomeConditions[outcome]
+	return ok
+}
+
+// AuditSearchFilter defines the free text and attribute filters of an audit search
+type AuditSearchFilter struct {
+	Text        string // Web search syntax over rationales and reasons: words, "phrases", or, -word
+	From        *time.Time
+	To          *time.Time
+	ActionType  string
+	ApprovedBy  string
+	ThreatLevel string
+	Outcome     string // One of the AuditOutcome constants
+	TrackID     string
+	Limit       int
+	Offset      int
+}
+
+// AuditSearchResult is an audit entry matched by a search
+type AuditSearchResult struct {
+	AuditEntry
+	Rank     float64 `json:"rank,omitempty"`     // Text relevance; zero without free text
+	Headline string  `json:"headline,omitempty"` // Matching excerpt with terms in <b></b>
+}
+
+// auditSearchConditions returns the WHERE conditions of an audit search and
+// their arguments. The free text query, if any, is always $1.
+func auditSearchConditions(filter AuditSearchFilter) (string, []interface{}) {
+	query := ""
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.Text != "" {
+		query += fmt.Sprintf(" AND (p.rationale_tsv @@ websearch_to_tsquery('english', $%d) OR d.reason_tsv @@ websearch_to_tsquery('english', $%d))", argNum, argNum)
+		args = append(args, filter.Text)
+		argNum++
+	}
+
+	if filter.From != nil {
+		query += fmt.Sprintf(" AND d.approved_at >= $%d", argNum)
+		args = append(args, *filter.From)
+		argNum++
+	}
+
+	if filter.To != nil {
+		query += fmt.Sprintf(" AND d.approved_at < $%d", argNum)
+		args = append(args, *filter.To)
+		argNum++
+	}
+
+	if filter.ActionType != "" {
+		query += fmt.Sprintf(" AND p.action_type = $%d", argNum)
+		args = append(args, filter.ActionType)
+		argNum++
+	}
+
+	if filter.ApprovedBy != "" {
+		query += fmt.Sprintf(" AND d.approved_by = $%d", argNum)
+		args = append(args, filter.ApprovedBy)
+		argNum++
+	}
+
+	if filter.ThreatLevel != "" {
+		query += fmt.Sprintf(" AND p.threat_level = $%d", argNum)
+		args = append(args, filter.ThreatLevel)
+		argNum++
+	}
+
+	if filter.TrackID != "" {
+		query += fmt.Sprintf(" AND p.track_id = $%d", argNum)
+		args = append(args, filter.TrackID)
+	}
+
+	if cond, ok := auditOutcomeConditions[filter.Outcome]; ok {
+		query += " AND " + cond
+	}
+
+	return query, args
+}
+
+// SearchAuditEntries finds audit entries by free text and attributes, most
+// relevant first when searching text and most recent first otherwise
+func (p *Pool) SearchAuditEntries(ctx context.Context, filter AuditSearchFilter) ([]AuditSearchResult, error) {
+	where, args := auditSearchConditions(filter)
+	argNum := len(args) + 1
+
+	extra := ", 0::float8, ''"
+	order := " ORDER BY d.approved_at DESC, d.decision_id"
+	if filter.Text != "" {
+		extra = `,
+			ts_rank(p.rationale_tsv || d.reason_tsv, websearch_to_tsquery('english', $1))::float8 AS search_rank,
+			ts_headline('english', concat_ws(' / ', p.rationale, d.reason), websearch_to_tsquery('english', $1))`
+		order = " ORDER BY search_rank DESC, d.approved_at DESC, d.decision_id"
+	}
+	query := "SELECT" + auditEntryColumns + extra + auditEntryFrom + " WHERE 1=1" + where + order
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var results []AuditSearchResult
+	for rows.Next() {
+		var r AuditSearchResult
+		if r.AuditEntry, err = scanAuditEntry(rows, &r.Rank, &r.Headline); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// CountAuditSearch counts the audit entries matching a search, ignoring its paging
+func (p *Pool) CountAuditSearch(ctx context.Context, filter AuditSearchFilter) (int64, error) {
+	where, args := auditSearchConditions(filter)
+	var count int64
+	if err := p.QueryRow(ctx, "SELECT COUNT(*)"+auditEntryFrom+" WHERE 1=1"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit search results: %w", err)
+	}
+	return count, nil
+}
+
+// CountActiveTracks returns the count of active tracks updated within the last 60 seconds
+// This matches the default filter used by the tracks API endpoint
+func (p *Pool) CountActiveTracks(ctx context.Context) (int64, error) {
+	var count int64
+	err := p.QueryRow(ctx, "SELECT COUNT(*) FROM tracks WHERE state = 'active' AND last_updated > NOW() - INTERVAL '60 seconds'").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active tracks: %w", err)
+	}
+	return count, nil
+}
+
+// CountPendingProposals returns the count of pending proposals
+func (p *Pool) CountPendingProposals(ctx context.Context) (int64, error) {
+	var count int64
+	err := p.QueryRow(ctx, "SELECT COUNT(*) FROM proposals WHERE status = 'pending' AND expires_at > NOW()").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending proposals: %w", err)
+	}
 	return count, nil
 }
 
@@ -1461,59 +2692,144 @@ type ClearAllResult struct {
 // to respect foreign key constraints. Uses a transaction for atomicity.
 // Returns the counts of deleted records per table.
 func (p *Pool) ClearAll(ctx context.Context) (*ClearAllResult, error) {
-	tx, err := p.Begin(ctx)
+	deleted, err := p.ClearTables(ctx, DefaultClearTables)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
+	}
+	return &ClearAllResult{
+		Effects:    deleted["effects"],
+		Decisions:  deleted["decisions"],
+		Proposals:  deleted["proposals"],
+		Detections: deleted["detections"],
+		Tracks:     deleted["tracks"],
+	}, nil
+}
+
+// ClearableTables lists the tables an exercise reset may clear, in an order
+// that respects foreign key constraints
+var ClearableTables = []string{
+	"effects",
+	"decisions",
+	"proposals",
+	"detections",
+	"tracks",
+	"audit_log",
+	"stage_metrics",
+	"idempotency_keys",
+}
+
+// DefaultClearTables are the exercise data tables cleared when none are selected
+var DefaultClearTables = []string{"tracks", "detections", "proposals", "decisions", "effects"}
+
+// clearTableDependencies lists the tables that must be cleared alongside each
+// table. Decisions and effects always go together because they share the audit
+// hash chain, which can only be restarted, not partially truncated.
+var clearTableDependencies = map[string][]string{
+	"proposals": {"decisions", "effects"},
+	"decisions": {"effects"},
+	"effects":   {"decisions"},
+}
+
+// ExpandClearTables validates a table selection and adds the tables it depends
+// on. The result is in ClearableTables order.
+func ExpandClearTables(tables []string) ([]string, error) {
+	selected := make(map[string]bool)
+	for _, table := range tables {
+		if !isClearableTable(table) {
+			return nil, fmt.Errorf("table %q cannot be cleared", table)
+		}
+		selected[table] = true
+		for _, dep := range clearTableDependencies[table] {
+			selected[dep] = true
+		}
 	}
-	defer tx.Rollback(ctx)
 
-	result := &ClearAllResult{}
+	expanded := make([]string, 0, len(selected))
+	for _, table := range ClearableTables {
+		if selected[table] {
+			expanded = append(expanded, table)
+		}
+	}
+	return expanded, nil
+}
 
-	// Delete in order respecting foreign key constraints:
-	// effects -> decisions -> proposals -> detections -> tracks
-	var tag pgconn.CommandTag
+func isClearableTable(table string) bool {
+	for _, t := range ClearableTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
 
-	tag, err = tx.Exec(ctx, "DELETE FROM effects")
+// ClearTables deletes all rows from the selected tables and their dependents,
+// restarts the audit chain when decisions are cleared, and zeroes the system
+// counters, all in one transaction. Returns the counts of deleted records per table.
+func (p *Pool) ClearTables(ctx context.Context, tables []string) (map[string]int64, error) {
+	expanded, err := ExpandClearTables(tables)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete from effects: %w", err)
+		return nil, err
 	}
-	result.Effects = tag.RowsAffected()
 
-	tag, err = tx.Exec(ctx, "DELETE FROM decisions")
+	tx, err := p.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete from decisions: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	result.Decisions = tag.RowsAffected()
+	defer tx.Rollback(ctx)
 
-	tag, err = tx.Exec(ctx, "DELETE FROM proposals")
-	if err != nil {
-		return nil, fmt.Errorf("failed to delete from proposals: %w", err)
+	// Chained decisions and effects are append-only; a reset bypasses the guard
+	// for this transaction only and restarts the audit chain
+	if _, err := tx.Exec(ctx, "SET LOCAL cjadc2.audit_reset = 'on'"); err != nil {
+		return nil, fmt.Errorf("failed to enable audit reset: %w", err)
 	}
-	result.Proposals = tag.RowsAffected()
 
-	tag, err = tx.Exec(ctx, "DELETE FROM detections")
-	if err != nil {
-		return nil, fmt.Errorf("failed to delete from detections: %w", err)
+	deleted := make(map[string]int64, len(expanded))
+	for _, table := range expanded {
+		// Table names come from ClearableTables, never from the caller
+		tag, err := tx.Exec(ctx, "DELETE FROM "+table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+		deleted[table] = tag.RowsAffected()
+
+		// Archived rows belong to the same exercise
+		if archive, ok := archiveTables[table]; ok {
+			tag, err := tx.Exec(ctx, "DELETE FROM "+archive)
+			if err != nil {
+				return nil, fmt.Errorf("failed to delete from %s: %w", archive, err)
+			}
+			deleted[table] += tag.RowsAffected()
+		}
 	}
-	result.Detections = tag.RowsAffected()
 
-	tag, err = tx.Exec(ctx, "DELETE FROM tracks")
-	if err != nil {
-		return nil, fmt.Errorf("failed to delete from tracks: %w", err)
+	if _, ok := deleted["decisions"]; ok {
+		_, err = tx.Exec(ctx, "UPDATE audit_chain_head SET seq = 0, hash = $1, updated_at = NOW() WHERE id", audit.GenesisHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reset audit chain: %w", err)
+		}
+	}
+
+	// Assets committed to cleared effects are available again
+	if _, ok := deleted["effects"]; ok {
+		_, err = tx.Exec(ctx, `
+			UPDATE assets SET readiness = 'ready', committed_to = NULL, committed_at = NULL
+			WHERE readiness = 'committed'
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to release committed assets: %w", err)
+		}
 	}
-	result.Tracks = tag.RowsAffected()
 
-	// Reset the messages_processed counter to 0
-	_, err = tx.Exec(ctx, "UPDATE system_counters SET counter_value = 0, last_updated = NOW() WHERE counter_name = 'messages_processed'")
+	_, err = tx.Exec(ctx, "UPDATE system_counters SET counter_value = 0, last_updated = NOW()")
 	if err != nil {
-		return nil, fmt.Errorf("failed to reset messages_processed counter: %w", err)
+		return nil, fmt.Errorf("failed to reset system counters: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return result, nil
+	return deleted, nil
 }
 
 // Health checks if the database connection is healthy
@@ -1536,12 +2852,32 @@ type InterventionRuleRow struct {
 	AutoApprove      bool      `json:"auto_approve"`
 	Enabled          bool      `json:"enabled"`
 	EvaluationOrder  int       `json:"evaluation_order"`
+	Version          int       `json:"version"`
 	CreatedBy        *string   `json:"created_by"`
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedBy        *string   `json:"updated_by"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
+// ToRule converts a database row to the intervention rule model
+func (r InterventionRuleRow) ToRule() intervention.Rule {
+	return intervention.Rule{
+		RuleID:           r.RuleID,
+		Name:             r.Name,
+		Description:      r.Description,
+		ActionTypes:      r.ActionTypes,
+		ThreatLevels:     r.ThreatLevels,
+		Classifications:  r.Classifications,
+		TrackTypes:       r.TrackTypes,
+		MinPriority:      r.MinPriority,
+		MaxPriority:      r.MaxPriority,
+		RequiresApproval: r.RequiresApproval,
+		AutoApprove:      r.AutoApprove,
+		Enabled:          r.Enabled,
+		EvaluationOrder:  r.EvaluationOrder,
+	}
+}
+
 // InterventionRuleFilter defines filter options for intervention rule queries
 type InterventionRuleFilter struct {
 	Enabled    *bool
@@ -1557,7 +2893,7 @@ func (p *Pool) ListInterventionRules(ctx context.Context, filter InterventionRul
 			rule_id, name, description,
 			action_types, threat_levels, classifications, track_types,
 			min_priority, max_priority,
-			requires_approval, auto_approve, enabled, evaluation_order,
+			requires_approval, auto_approve, enabled, evaluation_order, version,
 			created_by, created_at, updated_by, updated_at
 		FROM intervention_rules
 		WHERE 1=1
@@ -1603,7 +2939,7 @@ func (p *Pool) ListInterventionRules(ctx context.Context, filter InterventionRul
 			&r.RuleID, &r.Name, &r.Description,
 			&r.ActionTypes, &r.ThreatLevels, &r.Classifications, &r.TrackTypes,
 			&r.MinPriority, &r.MaxPriority,
-			&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder,
+			&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder, &r.Version,
 			&r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt,
 		)
 		if err != nil {
@@ -1626,7 +2962,7 @@ func (p *Pool) GetInterventionRule(ctx context.Context, ruleID string) (*Interve
 			rule_id, name, description,
 			action_types, threat_levels, classifications, track_types,
 			min_priority, max_priority,
-			requires_approval, auto_approve, enabled, evaluation_order,
+			requires_approval, auto_approve, enabled, evaluation_order, version,
 			created_by, created_at, updated_by, updated_at
 		FROM intervention_rules
 		WHERE rule_id = $1
@@ -1637,7 +2973,7 @@ func (p *Pool) GetInterventionRule(ctx context.Context, ruleID string) (*Interve
 		&r.RuleID, &r.Name, &r.Description,
 		&r.ActionTypes, &r.ThreatLevels, &r.Classifications, &r.TrackTypes,
 		&r.MinPriority, &r.MaxPriority,
-		&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder,
+		&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder, &r.Version,
 		&r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt,
 	)
 	if err == pgx.ErrNoRows {
@@ -1650,8 +2986,15 @@ func (p *Pool) GetInterventionRule(ctx context.Context, ruleID string) (*Interve
 	return &r, nil
 }
 
-// CreateInterventionRule inserts a new intervention rule
-func (p *Pool) CreateInterventionRule(ctx context.Context, rule *InterventionRuleRow) error {
+// CreateInterventionRule inserts a new intervention rule as version 1 and
+// records it in the rule's history and the audit log
+func (p *Pool) CreateInterventionRule(ctx context.Context, rule *InterventionRuleRow, correlationID string) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO intervention_rules (
 			rule_id, name, description,
@@ -1660,25 +3003,62 @@ func (p *Pool) CreateInterventionRule(ctx context.Context, rule *InterventionRul
 			requires_approval, auto_approve, enabled, evaluation_order,
 			created_by, updated_by
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-		RETURNING created_at, updated_at
+		RETURNING version, created_at, updated_at
 	`
 
-	err := p.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		rule.RuleID, rule.Name, rule.Description,
 		rule.ActionTypes, rule.ThreatLevels, rule.Classifications, rule.TrackTypes,
 		rule.MinPriority, rule.MaxPriority,
 		rule.RequiresApproval, rule.AutoApprove, rule.Enabled, rule.EvaluationOrder,
 		rule.CreatedBy, rule.UpdatedBy,
-	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	).Scan(&rule.Version, &rule.CreatedAt, &rule.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create intervention rule: %w", err)
 	}
 
+	if err := intervention.RecordChange(ctx, tx, intervention.ChangeCreated, rule.Version, rule.ToRule(), nil, rule.CreatedBy, correlationID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit intervention rule: %w", err)
+	}
+
 	return nil
 }
 
-// UpdateInterventionRule updates an existing intervention rule
-func (p *Pool) UpdateInterventionRule(ctx context.Context, rule *InterventionRuleRow) error {
+// UpdateInterventionRule updates an existing intervention rule, bumping its
+// version and recording the change in its history and the audit log
+func (p *Pool) UpdateInterventionRule(ctx context.Context, rule *InterventionRuleRow, correlationID string) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var previous InterventionRuleRow
+	err = tx.QueryRow(ctx, `
+		SELECT rule_id, name, description,
+			action_types, threat_levels, classifications, track_types,
+			min_priority, max_priority,
+			requires_approval, auto_approve, enabled, evaluation_order
+		FROM intervention_rules
+		WHERE rule_id = $1
+		FOR UPDATE
+	`, rule.RuleID).Scan(
+		&previous.RuleID, &previous.Name, &previous.Description,
+		&previous.ActionTypes, &previous.ThreatLevels, &previous.Classifications, &previous.TrackTypes,
+		&previous.MinPriority, &previous.MaxPriority,
+		&previous.RequiresApproval, &previous.AutoApprove, &previous.Enabled, &previous.EvaluationOrder,
+	)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("intervention rule not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock intervention rule: %w", err)
+	}
+
 	query := `
 		UPDATE intervention_rules SET
 			name = $2,
@@ -1693,44 +3073,117 @@ func (p *Pool) UpdateInterventionRule(ctx context.Context, rule *InterventionRul
 			auto_approve = $11,
 			enabled = $12,
 			evaluation_order = $13,
-			updated_by = $14
+			updated_by = $14,
+			version = version + 1
 		WHERE rule_id = $1
-		RETURNING updated_at
+		RETURNING version, updated_at
 	`
 
-	err := p.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		rule.RuleID, rule.Name, rule.Description,
 		rule.ActionTypes, rule.ThreatLevels, rule.Classifications, rule.TrackTypes,
 		rule.MinPriority, rule.MaxPriority,
 		rule.RequiresApproval, rule.AutoApprove, rule.Enabled, rule.EvaluationOrder,
 		rule.UpdatedBy,
-	).Scan(&rule.UpdatedAt)
-	if err == pgx.ErrNoRows {
-		return fmt.Errorf("intervention rule not found")
-	}
+	).Scan(&rule.Version, &rule.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update intervention rule: %w", err)
 	}
 
+	old := previous.ToRule()
+	if err := intervention.RecordChange(ctx, tx, intervention.ChangeUpdated, rule.Version, rule.ToRule(), &old, rule.UpdatedBy, correlationID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit intervention rule update: %w", err)
+	}
+
 	return nil
 }
 
-// DeleteInterventionRule deletes an intervention rule by ID
-func (p *Pool) DeleteInterventionRule(ctx context.Context, ruleID string) error {
-	query := `DELETE FROM intervention_rules WHERE rule_id = $1`
+// DeleteInterventionRule deletes an intervention rule by ID. Its history is
+// kept, ending with a deleted version holding the rule as it last stood.
+func (p *Pool) DeleteInterventionRule(ctx context.Context, ruleID, deletedBy, correlationID string) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	tag, err := p.Exec(ctx, query, ruleID)
+	var r InterventionRuleRow
+	err = tx.QueryRow(ctx, `
+		DELETE FROM intervention_rules
+		WHERE rule_id = $1
+		RETURNING rule_id, name, description,
+			action_types, threat_levels, classifications, track_types,
+			min_priority, max_priority,
+			requires_approval, auto_approve, enabled, evaluation_order, version
+	`, ruleID).Scan(
+		&r.RuleID, &r.Name, &r.Description,
+		&r.ActionTypes, &r.ThreatLevels, &r.Classifications, &r.TrackTypes,
+		&r.MinPriority, &r.MaxPriority,
+		&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder, &r.Version,
+	)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("intervention rule not found")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete intervention rule: %w", err)
 	}
 
-	if tag.RowsAffected() == 0 {
-		return fmt.Errorf("intervention rule not found")
+	var actor *string
+	if deletedBy != "" {
+		actor = &deletedBy
+	}
+	rule := r.ToRule()
+	if err := intervention.RecordChange(ctx, tx, intervention.ChangeDeleted, r.Version+1, rule, &rule, actor, correlationID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit intervention rule deletion: %w", err)
 	}
 
 	return nil
 }
 
+// ListInterventionRuleVersions retrieves a rule's history, newest first. It
+// includes deleted rules.
+func (p *Pool) ListInterventionRuleVersions(ctx context.Context, ruleID string) ([]intervention.Version, error) {
+	query := `
+		SELECT rule_id::text, version, change, snapshot, changed_by, changed_at, COALESCE(correlation_id, '')
+		FROM intervention_rule_versions
+		WHERE rule_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := p.Query(ctx, query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query intervention rule versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []intervention.Version
+	for rows.Next() {
+		var v intervention.Version
+		var snapshot []byte
+		if err := rows.Scan(&v.RuleID, &v.Version, &v.Change, &snapshot, &v.ChangedBy, &v.ChangedAt, &v.CorrelationID); err != nil {
+			return nil, fmt.Errorf("failed to scan intervention rule version: %w", err)
+		}
+		if err := json.Unmarshal(snapshot, &v.Rule); err != nil {
+			return nil, fmt.Errorf("failed to decode intervention rule version %d: %w", v.Version, err)
+		}
+		versions = append(versions, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating intervention rule versions: %w", err)
+	}
+
+	return versions, nil
+}
+
 // GetMatchingInterventionRules retrieves rules that match the given criteria
 // Rules are returned in evaluation_order, so the first match should be used
 func (p *Pool) GetMatchingInterventionRules(ctx context.Context, actionType, classification, threatLevel string, priority int) ([]InterventionRuleRow, error) {
@@ -1739,7 +3192,7 @@ func (p *Pool) GetMatchingInterventionRules(ctx context.Context, actionType, cla
 			rule_id, name, description,
 			action_types, threat_levels, classifications, track_types,
 			min_priority, max_priority,
-			requires_approval, auto_approve, enabled, evaluation_order,
+			requires_approval, auto_approve, enabled, evaluation_order, version,
 			created_by, created_at, updated_by, updated_at
 		FROM intervention_rules
 		WHERE enabled = true
@@ -1764,7 +3217,7 @@ func (p *Pool) GetMatchingInterventionRules(ctx context.Context, actionType, cla
 			&r.RuleID, &r.Name, &r.Description,
 			&r.ActionTypes, &r.ThreatLevels, &r.Classifications, &r.TrackTypes,
 			&r.MinPriority, &r.MaxPriority,
-			&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder,
+			&r.RequiresApproval, &r.AutoApprove, &r.Enabled, &r.EvaluationOrder, &r.Version,
 			&r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt,
 		)
 		if err != nil {
@@ -1780,3 +3233,826 @@ func (p *Pool) GetMatchingInterventionRules(ctx context.Context, actionType, cla
 	return rules, nil
 }
 
+
+// ZoneRow represents a protected asset or restricted zone from the database
+type ZoneRow struct {
+	ZoneID       string      `json:"zone_id"`
+	Name         string      `json:"name"`
+	Description  *string     `json:"description"`
+	Kind         string      `json:"kind"`
+	Shape        string      `json:"shape"`
+	CenterLat    *float64    `json:"center_lat"`
+	CenterLon    *float64    `json:"center_lon"`
+	RadiusMeters *float64    `json:"radius_meters"`
+	Polygon      []geo.Point `json:"polygon"`
+	Priority     int         `json:"priority"`
+	Enabled      bool        `json:"enabled"`
+	CreatedBy    *string     `json:"created_by"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedBy    *string     `json:"updated_by"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// ToZone converts a database row to the shared geo.Zone model
+func (z ZoneRow) ToZone() geo.Zone {
+	zone := geo.Zone{
+		ZoneID:   z.ZoneID,
+		Name:     z.Name,
+		Kind:     z.Kind,
+		Shape:    z.Shape,
+		Polygon:  z.Polygon,
+		Priority: z.Priority,
+		Enabled:  z.Enabled,
+	}
+	if z.CenterLat != nil && z.CenterLon != nil {
+		zone.Center = geo.Point{Lat: *z.CenterLat, Lon: *z.CenterLon}
+	}
+	if z.RadiusMeters != nil {
+		zone.RadiusMeters = *z.RadiusMeters
+	}
+	return zone
+}
+
+// ZoneFilter defines filter options for zone queries
+type ZoneFilter struct {
+	Kind    string
+	Enabled *bool
+	Limit   int
+	Offset  int
+}
+
+const zoneColumns = `
+			zone_id, name, description, kind, shape,
+			center_lat, center_lon, radius_meters, polygon,
+			priority, enabled,
+			created_by, created_at, updated_by, updated_at`
+
+// scanZone scans a zone row, decoding the JSONB polygon
+func scanZone(row pgx.Row) (*ZoneRow, error) {
+	var z ZoneRow
+	var polygon []byte
+	err := row.Scan(
+		&z.ZoneID, &z.Name, &z.Description, &z.Kind, &z.Shape,
+		&z.CenterLat, &z.CenterLon, &z.RadiusMeters, &polygon,
+		&z.Priority, &z.Enabled,
+		&z.CreatedBy, &z.CreatedAt, &z.UpdatedBy, &z.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(polygon) > 0 {
+		if err := json.Unmarshal(polygon, &z.Polygon); err != nil {
+			return nil, fmt.Errorf("failed to decode zone polygon: %w", err)
+		}
+	}
+	return &z, nil
+}
+
+// zonePolygonParam encodes a polygon for a JSONB column, returning nil for circles
+func zonePolygonParam(polygon []geo.Point) (interface{}, error) {
+	if len(polygon) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(polygon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode zone polygon: %w", err)
+	}
+	return data, nil
+}
+
+// ListZones retrieves zones with optional filtering
+func (p *Pool) ListZones(ctx context.Context, filter ZoneFilter) ([]ZoneRow, error) {
+	query := `SELECT` + zoneColumns + `
+		FROM zones
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.Kind != "" {
+		query += fmt.Sprintf(" AND kind = $%d", argNum)
+		args = append(args, filter.Kind)
+		argNum++
+	}
+
+	if filter.Enabled != nil {
+		query += fmt.Sprintf(" AND enabled = $%d", argNum)
+		args = append(args, *filter.Enabled)
+		argNum++
+	}
+
+	query += " ORDER BY priority DESC, name ASC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zones: %w", err)
+	}
+	defer rows.Close()
+
+	var zones []ZoneRow
+	for rows.Next() {
+		z, err := scanZone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan zone: %w", err)
+		}
+		zones = append(zones, *z)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating zones: %w", err)
+	}
+
+	return zones, nil
+}
+
+// GetZone retrieves a single zone by ID
+func (p *Pool) GetZone(ctx context.Context, zoneID string) (*ZoneRow, error) {
+	query := `SELECT` + zoneColumns + `
+		FROM zones
+		WHERE zone_id = $1
+	`
+
+	z, err := scanZone(p.QueryRow(ctx, query, zoneID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zone: %w", err)
+	}
+
+	return z, nil
+}
+
+// CreateZone inserts a new zone
+func (p *Pool) CreateZone(ctx context.Context, zone *ZoneRow) error {
+	polygon, err := zonePolygonParam(zone.Polygon)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO zones (
+			zone_id, name, description, kind, shape,
+			center_lat, center_lon, radius_meters, polygon,
+			priority, enabled,
+			created_by, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING created_at, updated_at
+	`
+
+	err = p.QueryRow(ctx, query,
+		zone.ZoneID, zone.Name, zone.Description, zone.Kind, zone.Shape,
+		zone.CenterLat, zone.CenterLon, zone.RadiusMeters, polygon,
+		zone.Priority, zone.Enabled,
+		zone.CreatedBy, zone.UpdatedBy,
+	).Scan(&zone.CreatedAt, &zone.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create zone: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateZone updates an existing zone
+func (p *Pool) UpdateZone(ctx context.Context, zone *ZoneRow) error {
+	polygon, err := zonePolygonParam(zone.Polygon)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE zones SET
+			name = $2,
+			description = $3,
+			kind = $4,
+			shape = $5,
+			center_lat = $6,
+			center_lon = $7,
+			radius_meters = $8,
+			polygon = $9,
+			priority = $10,
+			enabled = $11,
+			updated_by = $12
+		WHERE zone_id = $1
+		RETURNING updated_at
+	`
+
+	err = p.QueryRow(ctx, query,
+		zone.ZoneID, zone.Name, zone.Description, zone.Kind, zone.Shape,
+		zone.CenterLat, zone.CenterLon, zone.RadiusMeters, polygon,
+		zone.Priority, zone.Enabled,
+		zone.UpdatedBy,
+	).Scan(&zone.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("zone not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update zone: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteZone deletes a zone by ID
+func (p *Pool) DeleteZone(ctx context.Context, zoneID string) error {
+	query := `DELETE FROM zones WHERE zone_id = $1`
+
+	tag, err := p.Exec(ctx, query, zoneID)
+	if err != nil {
+		return fmt.Errorf("failed to delete zone: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("zone not found")
+	}
+
+	return nil
+}
+
+// BreakGlassFilter defines filter options for break-glass grant queries
+type BreakGlassFilter struct {
+	UserID     string
+	ActiveOnly bool
+	Limit      int
+}
+
+// InsertBreakGlassGrant records a newly activated grant and audits the activation
+func (p *Pool) InsertBreakGlassGrant(ctx context.Context, grant *breakglass.Grant, correlationID string) error {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO break_glass_grants (
+			user_id, role, justification, second_factor, second_factor_verified,
+			status, activated_at, expires_at, correlation_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING grant_id::text
+	`
+
+	err = tx.QueryRow(ctx, query,
+		grant.UserID, grant.Role, grant.Justification, grant.SecondFactor, grant.SecondFactorVerified,
+		grant.Status, grant.ActivatedAt, grant.ExpiresAt, correlationID,
+	).Scan(&grant.GrantID)
+	if err != nil {
+		return fmt.Errorf("failed to insert break-glass grant: %w", err)
+	}
+
+	if err := breakglass.LogEvent(ctx, tx, "activated", grant.GrantID, grant.UserID, "human", grant, correlationID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit break-glass grant: %w", err)
+	}
+
+	return nil
+}
+
+// ListBreakGlassGrants retrieves grants, newest first
+func (p *Pool) ListBreakGlassGrants(ctx context.Context, filter BreakGlassFilter) ([]breakglass.Grant, error) {
+	query := `SELECT ` + breakglass.GrantColumns + `
+		FROM break_glass_grants
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.UserID != "" {
+		query += fmt.Sprintf(" AND user_id = $%d", argNum)
+		args = append(args, filter.UserID)
+		argNum++
+	}
+
+	if filter.ActiveOnly {
+		query += " AND status = 'active' AND expires_at > NOW()"
+	}
+
+	query += " ORDER BY activated_at DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query break-glass grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := []breakglass.Grant{}
+	for rows.Next() {
+		g, err := breakglass.ScanGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan break-glass grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating break-glass grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+// PolicyDocuments loads the policy data documents pushed into OPA: the
+// enabled zones, and the active break-glass grants with the fields the
+// authority policy checks
+func (p *Pool) PolicyDocuments(ctx context.Context) (map[string]interface{}, error) {
+	enabled := true
+	zones, err := p.ListZones(ctx, ZoneFilter{Enabled: &enabled})
+	if err != nil {
+		return nil, err
+	}
+	if zones == nil {
+		zones = []ZoneRow{}
+	}
+
+	active, err := p.ListBreakGlassGrants(ctx, BreakGlassFilter{ActiveOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	type policyGrant struct {
+		GrantID              string    `json:"grant_id"`
+		UserID               string    `json:"user_id"`
+		Role                 string    `json:"role"`
+		Status               string    `json:"status"`
+		SecondFactorVerified bool      `json:"second_factor_verified"`
+		ExpiresAt            time.Time `json:"expires_at"`
+	}
+	grants := make([]policyGrant, 0, len(active))
+	for _, g := range active {
+		grants = append(grants, policyGrant{
+			GrantID:              g.GrantID,
+			UserID:               g.UserID,
+			Role:                 g.Role,
+			Status:               g.Status,
+			SecondFactorVerified: g.SecondFactorVerified,
+			ExpiresAt:            g.ExpiresAt,
+		})
+	}
+
+	return map[string]interface{}{
+		"zones":              zones,
+		"break_glass_grants": grants,
+	}, nil
+}
+
+// GetActiveBreakGlassGrants returns the grants currently conferring authority on userID
+func (p *Pool) GetActiveBreakGlassGrants(ctx context.Context, userID string) ([]breakglass.Grant, error) {
+	return breakglass.ActiveGrants(ctx, p, userID, time.Now())
+}
+
+// RevokeBreakGlassGrant ends an active grant early and audits the revocation.
+// Returns nil if the grant does not exist or is no longer active.
+func (p *Pool) RevokeBreakGlassGrant(ctx context.Context, grantID, revokedBy, reason, correlationID string) (*breakglass.Grant, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE break_glass_grants
+		SET status = 'revoked', revoked_at = NOW(), revoked_by = $2, revoke_reason = $3
+		WHERE grant_id = $1 AND status = 'active'
+		RETURNING ` + breakglass.GrantColumns
+
+	grant, err := breakglass.ScanGrant(tx.QueryRow(ctx, query, grantID, revokedBy, reason))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke break-glass grant: %w", err)
+	}
+
+	if err := breakglass.LogEvent(ctx, tx, "revoked", grant.GrantID, revokedBy, "human", map[string]interface{}{
+		"user_id":   grant.UserID,
+		"role":      grant.Role,
+		"reason":    reason,
+		"use_count": grant.UseCount,
+	}, correlationID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit break-glass revocation: %w", err)
+	}
+
+	return &grant, nil
+}
+
+// ExpireBreakGlassGrants marks active grants past their expiry as expired,
+// audits each one, and returns them
+func (p *Pool) ExpireBreakGlassGrants(ctx context.Context, now time.Time) ([]breakglass.Grant, error) {
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		UPDATE break_glass_grants
+		SET status = 'expired'
+		WHERE status = 'active' AND expires_at <= $1
+		RETURNING `+breakglass.GrantColumns, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire break-glass grants: %w", err)
+	}
+
+	grants := []breakglass.Grant{}
+	for rows.Next() {
+		g, err := breakglass.ScanGrant(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan expired break-glass grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired break-glass grants: %w", err)
+	}
+
+	for _, g := range grants {
+		if err := breakglass.LogEvent(ctx, tx, "expired", g.GrantID, "system", "system", map[string]interface{}{
+			"user_id":    g.UserID,
+			"role":       g.Role,
+			"expires_at": g.ExpiresAt,
+			"use_count":  g.UseCount,
+		}, g.GrantID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit break-glass expiry: %w", err)
+	}
+
+	return grants, nil
+}
+
+// PreferenceRow represents one saved UI preference owned by a user
+type PreferenceRow struct {
+	UserID    string          `json:"user_id"`
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	Value     json.RawMessage `json:"value"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ListPreferences retrieves a user's preferences, optionally of one kind
+func (p *Pool) ListPreferences(ctx context.Context, userID, kind string) ([]PreferenceRow, error) {
+	query := `
+		SELECT user_id, kind, name, value, created_at, updated_at
+		FROM user_preferences
+		WHERE user_id = $1 AND ($2 = '' OR kind = $2)
+		ORDER BY kind, name
+	`
+
+	rows, err := p.Query(ctx, query, userID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []PreferenceRow
+	for rows.Next() {
+		var pref PreferenceRow
+		if err := rows.Scan(&pref.UserID, &pref.Kind, &pref.Name, &pref.Value, &pref.CreatedAt, &pref.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan preference: %w", err)
+		}
+		prefs = append(prefs, pref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// GetPreference retrieves one of a user's preferences, or nil if it is not saved
+func (p *Pool) GetPreference(ctx context.Context, userID, kind, name string) (*PreferenceRow, error) {
+	query := `
+		SELECT user_id, kind, name, value, created_at, updated_at
+		FROM user_preferences
+		WHERE user_id = $1 AND kind = $2 AND name = $3
+	`
+
+	var pref PreferenceRow
+	err := p.QueryRow(ctx, query, userID, kind, name).
+		Scan(&pref.UserID, &pref.Kind, &pref.Name, &pref.Value, &pref.CreatedAt, &pref.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preference: %w", err)
+	}
+
+	return &pref, nil
+}
+
+// PutPreference saves a preference, replacing any saved under the same kind and
+// name. Returns false when the user already holds max preferences and this one
+// is new.
+func (p *Pool) PutPreference(ctx context.Context, pref *PreferenceRow, max int) (bool, error) {
+	query := `
+		INSERT INTO user_preferences (user_id, kind, name, value)
+		SELECT $1, $2, $3, $4
+		WHERE (SELECT COUNT(*) FROM user_preferences WHERE user_id = $1) < $5
+		   OR EXISTS (SELECT 1 FROM user_preferences WHERE user_id = $1 AND kind = $2 AND name = $3)
+		ON CONFLICT (user_id, kind, name) DO UPDATE SET
+			value = EXCLUDED.value,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := p.QueryRow(ctx, query, pref.UserID, pref.Kind, pref.Name, pref.Value, max).
+		Scan(&pref.CreatedAt, &pref.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save preference: %w", err)
+	}
+
+	return true, nil
+}
+
+// DeletePreference deletes one of a user's preferences, reporting whether it existed
+func (p *Pool) DeletePreference(ctx context.Context, userID, kind, name string) (bool, error) {
+	tag, err := p.Exec(ctx,
+		"DELETE FROM user_preferences WHERE user_id = $1 AND kind = $2 AND name = $3",
+		userID, kind, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete preference: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClassificationRuleRow represents a classifier rule from the database
+type ClassificationRuleRow struct {
+	RuleID          string    `json:"rule_id"`
+	Name            string    `json:"name"`
+	Description     *string   `json:"description"`
+	Kind            string    `json:"kind"`
+	Result          string    `json:"result"`
+	MinSpeed        *float64  `json:"min_speed"`
+	MaxSpeed        *float64  `json:"max_speed"`
+	MinAlt          *float64  `json:"min_alt"`
+	MaxAlt          *float64  `json:"max_alt"`
+	MinConfidence   *float64  `json:"min_confidence"`
+	MaxConfidence   *float64  `json:"max_confidence"`
+	MinLat          *float64  `json:"min_lat"`
+	MaxLat          *float64  `json:"max_lat"`
+	MinLon          *float64  `json:"min_lon"`
+	MaxLon          *float64  `json:"max_lon"`
+	SensorTypes     []string  `json:"sensor_types"`
+	TrackIDPrefixes []string  `json:"track_id_prefixes"`
+	TrackTypes      []string  `json:"track_types"`
+	Enabled         bool      `json:"enabled"`
+	EvaluationOrder int       `json:"evaluation_order"`
+	CreatedBy       *string   `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedBy       *string   `json:"updated_by"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ToRule converts a database row to the classifier's rule model
+func (r ClassificationRuleRow) ToRule() classify.Rule {
+	return classify.Rule{
+		Name:            r.Name,
+		Kind:            classify.Kind(r.Kind),
+		Result:          r.Result,
+		Enabled:         r.Enabled,
+		MinSpeed:        r.MinSpeed,
+		MaxSpeed:        r.MaxSpeed,
+		MinAlt:          r.MinAlt,
+		MaxAlt:          r.MaxAlt,
+		MinConfidence:   r.MinConfidence,
+		MaxConfidence:   r.MaxConfidence,
+		MinLat:          r.MinLat,
+		MaxLat:          r.MaxLat,
+		MinLon:          r.MinLon,
+		MaxLon:          r.MaxLon,
+		SensorTypes:     r.SensorTypes,
+		TrackIDPrefixes: r.TrackIDPrefixes,
+		TrackTypes:      r.TrackTypes,
+		EvaluationOrder: r.EvaluationOrder,
+	}
+}
+
+// ClassificationRuleFilter defines filter options for classification rule queries
+type ClassificationRuleFilter struct {
+	Kind    string
+	Enabled *bool
+	Limit   int
+	Offset  int
+}
+
+const classificationRuleColumns = `
+			rule_id, name, description, kind, result,
+			min_speed, max_speed, min_alt, max_alt, min_confidence, max_confidence,
+			min_lat, max_lat, min_lon, max_lon,
+			sensor_types, track_id_prefixes, track_types,
+			enabled, evaluation_order,
+			created_by, created_at, updated_by, updated_at`
+
+// scanClassificationRule scans a classification rule row
+func scanClassificationRule(row pgx.Row) (*ClassificationRuleRow, error) {
+	var r ClassificationRuleRow
+	err := row.Scan(
+		&r.RuleID, &r.Name, &r.Description, &r.Kind, &r.Result,
+		&r.MinSpeed, &r.MaxSpeed, &r.MinAlt, &r.MaxAlt, &r.MinConfidence, &r.MaxConfidence,
+		&r.MinLat, &r.MaxLat, &r.MinLon, &r.MaxLon,
+		&r.SensorTypes, &r.TrackIDPrefixes, &r.TrackTypes,
+		&r.Enabled, &r.EvaluationOrder,
+		&r.CreatedBy, &r.CreatedAt, &r.UpdatedBy, &r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListClassificationRules retrieves classification rules in evaluation order
+func (p *Pool) ListClassificationRules(ctx context.Context, filter ClassificationRuleFilter) ([]ClassificationRuleRow, error) {
+	query := `SELECT` + classificationRuleColumns + `
+		FROM classification_rules
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.Kind != "" {
+		query += fmt.Sprintf(" AND kind = $%d", argNum)
+		args = append(args, filter.Kind)
+		argNum++
+	}
+
+	if filter.Enabled != nil {
+		query += fmt.Sprintf(" AND enabled = $%d", argNum)
+		args = append(args, *filter.Enabled)
+		argNum++
+	}
+
+	query += " ORDER BY kind ASC, evaluation_order ASC, name ASC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := p.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query classification rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []ClassificationRuleRow
+	for rows.Next() {
+		r, err := scanClassificationRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan classification rule: %w", err)
+		}
+		rules = append(rules, *r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating classification rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// GetClassificationRule retrieves a single classification rule by ID
+func (p *Pool) GetClassificationRule(ctx context.Context, ruleID string) (*ClassificationRuleRow, error) {
+	query := `SELECT` + classificationRuleColumns + `
+		FROM classification_rules
+		WHERE rule_id = $1
+	`
+
+	r, err := scanClassificationRule(p.QueryRow(ctx, query, ruleID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get classification rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// CreateClassificationRule inserts a new classification rule
+func (p *Pool) CreateClassificationRule(ctx context.Context, rule *ClassificationRuleRow) error {
+	query := `
+		INSERT INTO classification_rules (
+			rule_id, name, description, kind, result,
+			min_speed, max_speed, min_alt, max_alt, min_confidence, max_confidence,
+			min_lat, max_lat, min_lon, max_lon,
+			sensor_types, track_id_prefixes, track_types,
+			enabled, evaluation_order,
+			created_by, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+		RETURNING created_at, updated_at
+	`
+
+	err := p.QueryRow(ctx, query,
+		rule.RuleID, rule.Name, rule.Description, rule.Kind, rule.Result,
+		rule.MinSpeed, rule.MaxSpeed, rule.MinAlt, rule.MaxAlt, rule.MinConfidence, rule.MaxConfidence,
+		rule.MinLat, rule.MaxLat, rule.MinLon, rule.MaxLon,
+		rule.SensorTypes, rule.TrackIDPrefixes, rule.TrackTypes,
+		rule.Enabled, rule.EvaluationOrder,
+		rule.CreatedBy, rule.UpdatedBy,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create classification rule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateClassificationRule updates an existing classification rule
+func (p *Pool) UpdateClassificationRule(ctx context.Context, rule *ClassificationRuleRow) error {
+	query := `
+		UPDATE classification_rules SET
+			name = $2,
+			description = $3,
+			kind = $4,
+			result = $5,
+			min_speed = $6,
+			max_speed = $7,
+			min_alt = $8,
+			max_alt = $9,
+			min_confidence = $10,
+			max_confidence = $11,
+			min_lat = $12,
+			max_lat = $13,
+			min_lon = $14,
+			max_lon = $15,
+			sensor_types = $16,
+			track_id_prefixes = $17,
+			track_types = $18,
+			enabled = $19,
+			evaluation_order = $20,
+			updated_by = $21
+		WHERE rule_id = $1
+		RETURNING updated_at
+	`
+
+	err := p.QueryRow(ctx, query,
+		rule.RuleID, rule.Name, rule.Description, rule.Kind, rule.Result,
+		rule.MinSpeed, rule.MaxSpeed, rule.MinAlt, rule.MaxAlt, rule.MinConfidence, rule.MaxConfidence,
+		rule.MinLat, rule.MaxLat, rule.MinLon, rule.MaxLon,
+		rule.SensorTypes, rule.TrackIDPrefixes, rule.TrackTypes,
+		rule.Enabled, rule.EvaluationOrder,
+		rule.UpdatedBy,
+	).Scan(&rule.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("classification rule not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update classification rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteClassificationRule deletes a classification rule by ID
+func (p *Pool) DeleteClassificationRule(ctx context.Context, ruleID string) error {
+	query := `DELETE FROM classification_rules WHERE rule_id = $1`
+
+	tag, err := p.Exec(ctx, query, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete classification rule: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("classification rule not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrSessionRecording is returned when a session is started while another
+// is still recording
+var ErrSessionRecording = errors.New("another session is recording")
+
+// SessionRow represents a recorded session
+type SessionRow struct {
+	SessionID     string     `json:"session_id"`
+	Name          string     `json:"name"`
+	StartedBy     string     `json:"started_by"`
+	IncludeTracks bool       `json:"include_tracks"`
+	StartedAt     time.Time  `json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	EventCount    int        `json:"event_count"`
+}
+
+// Recording reports whether the session is still recording
+func (s SessionRow) Recording() bool {
+	return s.EndedAt == nil
+}
+
+// SessionEventRow is one event recorded in a session, as broadcast
+type SessionEventRow struct {
+	Seq        int             `json:"seq"`
+	EventType  string          `json:"event_type"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Event      json.RawMessage `json:"event"`
+}
+
+const sessionColumns = `session_id, name, started_by, include_tracks, started_at, ended_at, event_count`
+
+func scanSession(row pgx.Row) (*SessionRow, error) {
+	var s SessionRow
+	err := row.Scan(&s.SessionID, &s.Name, &s.StartedBy, &s.IncludeTracks, &s.StartedAt, &s.EndedAt, &s.EventCount)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// StartSession starts recording a new session, filling in its ID and start
+// time. Returns ErrSessionRecording if another session is recording.
+func (p *Pool) StartSession(ctx context.Context, s *SessionRow) error {
+	row, err := scanSession(p.QueryRow(ctx, `
+		INSERT INTO sessions (name, started_by, include_tracks)
+		VALUES ($1, $2, $3)
+		RETURNING `+sessionColumns,
+		s.Name, s.StartedBy, s.IncludeTracks))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrSessionRecording
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	*s = *row
+	return nil
+}
+
+// StopSession ends a session's recording. Stopping a session that already
+// ended returns it unchanged; a missing session gives nil.
+func (p *Pool) StopSession(ctx context.Context, sessionID string, now time.Time) (*SessionRow, error) {
+	s, err := scanSession(p.QueryRow(ctx, `
+		UPDATE sessions SET ended_at = COALESCE(ended_at, $2)
+		WHERE session_id = $1
+		RETURNING `+sessionColumns,
+		sessionID, now))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stop session: %w", err)
+	}
+	return s, nil
+}
+
+// GetSession retrieves a session, or nil if it does not exist
+func (p *Pool) GetSession(ctx context.Context, sessionID string) (*SessionRow, error) {
+	s, err := scanSession(p.QueryRow(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE session_id = $1`, sessionID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return s, nil
+}
+
+// RecordingSession retrieves the session being recorded, or nil if none is
+func (p *Pool) RecordingSession(ctx context.Context) (*SessionRow, error) {
+	s, err := scanSession(p.QueryRow(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE ended_at IS NULL`))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recording session: %w", err)
+	}
+	return s, nil
+}
+
+// ListSessions retrieves sessions, most recently started first
+func (p *Pool) ListSessions(ctx context.Context, limit int) ([]SessionRow, error) {
+	rows, err := p.Query(ctx, `
+		SELECT `+sessionColumns+` FROM sessions
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []SessionRow
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// DeleteSession deletes a session and its events, reporting whether it existed
+func (p *Pool) DeleteSession(ctx context.Context, sessionID string) (bool, error) {
+	tag, err := p.Exec(ctx, "DELETE FROM sessions WHERE session_id = $1", sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete session: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// InsertSessionEvents appends events to a session in one transaction and
+// counts them. Events whose sequence number is already stored are skipped,
+// so a retried batch is not recorded twice.
+func (p *Pool) InsertSessionEvents(ctx context.Context, sessionID string, events []SessionEventRow) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := p.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue(`
+			INSERT INTO session_events (session_id, seq, event_type, recorded_at, event)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (session_id, seq) DO NOTHING
+		`, sessionID, e.Seq, e.EventType, e.RecordedAt, e.Event)
+	}
+	results := tx.SendBatch(ctx, batch)
+	var inserted int64
+	for range events {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			return fmt.Errorf("failed to record session events: %w", err)
+		}
+		inserted += tag.RowsAffected()
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("failed to record session events: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE sessions SET event_count = event_count + $2 WHERE session_id = $1", sessionID, inserted); err != nil {
+		return fmt.Errorf("failed to count session events: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit session events: %w", err)
+	}
+	return nil
+}
+
+// ListSessionEvents retrieves up to limit of a session's events after
+// afterSeq, in order
+func (p *Pool) ListSessionEvents(ctx context.Context, sessionID string, afterSeq, limit int) ([]SessionEventRow, error) {
+	rows, err := p.Query(ctx, `
+		SELECT seq, event_type, recorded_at, event
+		FROM session_events
+		WHERE session_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`, sessionID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []SessionEventRow
+	for rows.Next() {
+		var e SessionEventRow
+		if err := rows.Scan(&e.Seq, &e.EventType, &e.RecordedAt, &e.Event); err != nil {
+			return nil, fmt.Errorf("failed to scan session event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TrackCount counts active tracks of one classification and threat level
+type TrackCount struct {
+	Classification string `json:"classification"`
+	ThreatLevel    string `json:"threat_level"`
+	Count          int64  `json:"count"`
+}
+
+// PriorityCount counts pending proposals of one priority
+type PriorityCount struct {
+	Priority int   `json:"priority"`
+	Count    int64 `json:"count"`
+}
+
+// DecisionThroughput counts decisions made over recent windows
+type DecisionThroughput struct {
+	LastMinute     int64 `json:"last_minute"`
+	Last5Minutes   int64 `json:"last_5_minutes"`
+	LastHour       int64 `json:"last_hour"`
+	ApprovedLast5m int64 `json:"approved_last_5m"`
+	DeniedLast5m   int64 `json:"denied_last_5m"`
+}
+
+// MessageRates counts the rows each pipeline stage wrote in the last minute
+type MessageRates struct {
+	Detections int64 `json:"detections"`
+	Tracks     int64 `json:"tracks"` // Tracks updated
+	Proposals  int64 `json:"proposals"`
+	Decisions  int64 `json:"decisions"`
+	Effects    int64 `json:"effects"`
+}
+
+// DashboardSummary holds the counts dashboards poll for
+type DashboardSummary struct {
+	ActiveTracks     []TrackCount       `json:"active_tracks"`
+	PendingProposals []PriorityCount    `json:"pending_proposals"`
+	Decisions        DecisionThroughput `json:"decisions"`
+	MessagesLastMin  MessageRates       `json:"messages_last_minute"`
+}
+
+// Queries GetDashboardSummary sends in one batch. Active tracks and pending
+// proposals are counted as CountActiveTracks and CountPendingProposals do.
+const (
+	summaryTracksQuery = `
+		SELECT COALESCE(classification, 'unknown'), COALESCE(threat_level, 'unknown'), COUNT(*)
+		FROM tracks
+		WHERE state = 'active' AND last_updated > NOW() - INTERVAL '60 seconds'
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`
+	summaryProposalsQuery = `
+		SELECT priority, COUNT(*)
+		FROM proposals
+		WHERE status = 'pending' AND expires_at > NOW()
+		GROUP BY priority
+		ORDER BY priority DESC
+	`
+	summaryDecisionsQuery = `
+		SELECT
+			COUNT(*) FILTER (WHERE approved_at > NOW() - INTERVAL '1 minute'),
+			COUNT(*) FILTER (WHERE approved_at > NOW() - INTERVAL '5 minutes'),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE approved AND approved_at > NOW() - INTERVAL '5 minutes'),
+			COUNT(*) FILTER (WHERE NOT approved AND approved_at > NOW() - INTERVAL '5 minutes')
+		FROM decisions
+		WHERE approved_at > NOW() - INTERVAL '1 hour'
+	`
+	summaryRatesQuery = `
+		SELECT
+			(SELECT COUNT(*) FROM detections WHERE created_at > NOW() - INTERVAL '1 minute'),
+			(SELECT COUNT(*) FROM tracks WHERE last_updated > NOW() - INTERVAL '1 minute'),
+			(SELECT COUNT(*) FROM proposals WHERE created_at > NOW() - INTERVAL '1 minute'),
+			(SELECT COUNT(*) FROM decisions WHERE approved_at > NOW() - INTERVAL '1 minute'),
+			(SELECT COUNT(*) FROM effects WHERE created_at > NOW() - INTERVAL '1 minute')
+	`
+)
+
+// GetDashboardSummary gathers the dashboard counts in a single round trip
+func (p *Pool) GetDashboardSummary(ctx context.Context) (*DashboardSummary, error) {
+	batch := &pgx.Batch{}
+	batch.Queue(summaryTracksQuery)
+	batch.Queue(summaryProposalsQuery)
+	batch.Queue(summaryDecisionsQuery)
+	batch.Queue(summaryRatesQuery)
+
+	results := p.SendBatch(ctx, batch)
+	defer results.Close()
+
+	s := &DashboardSummary{
+		ActiveTracks:     []TrackCount{},
+		PendingProposals: []PriorityCount{},
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active tracks: %w", err)
+	}
+	for rows.Next() {
+		var c TrackCount
+		if err := rows.Scan(&c.Classification, &c.ThreatLevel, &c.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan track count: %w", err)
+		}
+		s.ActiveTracks = append(s.ActiveTracks, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count active tracks: %w", err)
+	}
+
+	rows, err = results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pending proposals: %w", err)
+	}
+	for rows.Next() {
+		var c PriorityCount
+		if err := rows.Scan(&c.Priority, &c.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan proposal count: %w", err)
+		}
+		s.PendingProposals = append(s.PendingProposals, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count pending proposals: %w", err)
+	}
+
+	d := &s.Decisions
+	if err := results.QueryRow().Scan(&d.LastMinute, &d.Last5Minutes, &d.LastHour, &d.ApprovedLast5m, &d.DeniedLast5m); err != nil {
+		return nil, fmt.Errorf("failed to count decisions: %w", err)
+	}
+
+	m := &s.MessagesLastMin
+	if err := results.QueryRow().Scan(&m.Detections, &m.Tracks, &m.Proposals, &m.Decisions, &m.Effects); err != nil {
+		return nil, fmt.Errorf("failed to count message rates: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// SSL modes libpq and pgx accept
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// TLSConfig overrides the TLS settings of a connection URL, so certificates
+// can be mounted without rewriting each service's URL. Empty fields leave the
+// URL's own setting in place.
+type TLSConfig struct {
+	SSLMode  string
+	RootCert string // PEM roots that verify the server
+	Cert     string // Client certificate
+	Key      string // Key for Cert
+}
+
+// TLSConfigFromEnv reads POSTGRES_SSLMODE, POSTGRES_SSLROOTCERT,
+// POSTGRES_SSLCERT, and POSTGRES_SSLKEY
+func TLSConfigFromEnv() TLSConfig {
+	return TLSConfig{
+		SSLMode:  os.Getenv("POSTGRES_SSLMODE"),
+		RootCert: os.Getenv("POSTGRES_SSLROOTCERT"),
+		Cert:     os.Getenv("POSTGRES_SSLCERT"),
+		Key:      os.Getenv("POSTGRES_SSLKEY"),
+	}
+}
+
+// Validate checks the settings are consistent and every file can be read
+func (c TLSConfig) Validate() error {
+	if c.SSLMode != "" && !sslModes[c.SSLMode] {
+		return fmt.Errorf("invalid POSTGRES_SSLMODE %q: must be disable, allow, prefer, require, verify-ca, or verify-full", c.SSLMode)
+	}
+	if (c.Cert == "") != (c.Key == "") {
+		return fmt.Errorf("POSTGRES_SSLCERT and POSTGRES_SSLKEY must be set together")
+	}
+	for name, path := range map[string]string{
+		"POSTGRES_SSLROOTCERT": c.RootCert,
+		"POSTGRES_SSLCERT":     c.Cert,
+		"POSTGRES_SSLKEY":      c.Key,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Apply returns the connection URL with the TLS settings in place of its own.
+// An empty URL stays empty.
+func (c TLSConfig) Apply(connURL string) (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	if connURL == "" || c == (TLSConfig{}) {
+		return connURL, nil
+	}
+	if !strings.HasPrefix(connURL, "postgres://") && !strings.HasPrefix(connURL, "postgresql://") {
+		return "", fmt.Errorf("TLS settings need a postgres:// connection URL")
+	}
+
+	u, err := url.Parse(connURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse connection URL: %w", err)
+	}
+	q := u.Query()
+	for param, value := range map[string]string{
+		"sslmode":     c.SSLMode,
+		"sslrootcert": c.RootCert,
+		"sslcert":     c.Cert,
+		"sslkey":      c.Key,
+	} {
+		if value != "" {
+			q.Set(param, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,123 @@
+// Package priorityqueue orders pipeline work by threat, so that during a burst
+// critical tracks are handled before the low-threat contacts that arrived
+// ahead of them.
+//
+// Items are popped highest admission.Rank first and, within a rank, in the
+// order they were pushed, so equally threatening items keep their stream
+// order. A Queue is not safe for concurrent use.
+package priorityqueue
+
+import (
+	"container/heap"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/admission"
+)
+
+// DefaultSize is how many fetched messages a consumer holds for ordering
+const DefaultSize = 100
+
+// ParseSize parses a queue size setting. Zero disables reordering: the queue
+// then holds one message at a time and processing is FIFO.
+func ParseSize(name, value string) (int, error) {
+	size, err := admission.ParseLimit(name, value, DefaultSize)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return 1, nil
+	}
+	return size, nil
+}
+
+// RankForSubject ranks a message by the threat level ending its subject, as
+// on track.correlated.<threat_level>
+func RankForSubject(subject string) admission.Rank {
+	return admission.RankForThreatLevel(subject[strings.LastIndex(subject, ".")+1:])
+}
+
+// Entry is a queued item
+type Entry[T any] struct {
+	Item       T
+	Rank       admission.Rank
+	EnqueuedAt time.Time
+
+	seq uint64
+}
+
+// Queue holds items until they are popped in threat order
+type Queue[T any] struct {
+	entries entryHeap[T]
+	seq     uint64
+	depth   map[admission.Rank]int
+}
+
+// New creates an empty queue
+func New[T any]() *Queue[T] {
+	return &Queue[T]{depth: make(map[admission.Rank]int)}
+}
+
+// Push queues an item at a rank
+func (q *Queue[T]) Push(item T, rank admission.Rank, now time.Time) {
+	q.seq++
+	heap.Push(&q.entries, Entry[T]{Item: item, Rank: rank, EnqueuedAt: now, seq: q.seq})
+	q.depth[rank]++
+}
+
+// Pop removes the most threatening item, the earliest pushed among equals
+func (q *Queue[T]) Pop() (Entry[T], bool) {
+	if len(q.entries) == 0 {
+		return Entry[T]{}, false
+	}
+	e := heap.Pop(&q.entries).(Entry[T])
+	q.depth[e.Rank]--
+	return e, true
+}
+
+// Drain removes every queued item in pop order
+func (q *Queue[T]) Drain() []Entry[T] {
+	drained := make([]Entry[T], 0, len(q.entries))
+	for {
+		e, ok := q.Pop()
+		if !ok {
+			return drained
+		}
+		drained = append(drained, e)
+	}
+}
+
+// Len returns the number of queued items
+func (q *Queue[T]) Len() int {
+	return len(q.entries)
+}
+
+// Depth returns the number of queued items at a rank
+func (q *Queue[T]) Depth(rank admission.Rank) int {
+	return q.depth[rank]
+}
+
+type entryHeap[T any] []Entry[T]
+
+func (h entryHeap[T]) Len() int { return len(h) }
+
+func (h entryHeap[T]) Less(i, j int) bool {
+	if h[i].Rank != h[j].Rank {
+		return h[i].Rank > h[j].Rank
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h entryHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *entryHeap[T]) Push(x any) { *h = append(*h, x.(Entry[T])) }
+
+func (h *entryHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	var zero Entry[T]
+	old[n-1] = zero
+	*h = old[:n-1]
+	return e
+}
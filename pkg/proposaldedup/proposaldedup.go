@@ -0,0 +1,114 @@
+// Package proposaldedup keeps the planner from publishing the same proposal
+// over and over.
+//
+// The planner plans on every correlated track update, so a track updated a
+// few times a second would otherwise yield a proposal per update, each of
+// which the authorizer has to merge into the pending one. A proposal for a
+// track and action is suppressed while one of at least its priority was
+// published for the same track and action within the window. A proposal of
+// higher priority always goes out, so an escalating threat is never held back.
+package proposaldedup
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWindow is how long a published proposal suppresses repeats
+const DefaultWindow = 10 * time.Second
+
+// ParseWindow parses PROPOSAL_DEDUP_WINDOW; empty selects DefaultWindow and 0 disables suppression
+func ParseWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultWindow, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid PROPOSAL_DEDUP_WINDOW %q: must be a non-negative duration", s)
+	}
+	return d, nil
+}
+
+// Cache remembers the proposals published within the window, by track and action
+type Cache struct {
+	mu        sync.Mutex
+	window    time.Duration
+	published map[string]publishedProposal
+	swept     time.Time // Last time expired proposals were forgotten
+}
+
+type publishedProposal struct {
+	priority int
+	at       time.Time
+}
+
+// NewCache creates a cache suppressing repeats for window; 0 disables it
+func NewCache(window time.Duration) *Cache {
+	return &Cache{
+		window:    window,
+		published: make(map[string]publishedProposal),
+	}
+}
+
+// Window returns how long a published proposal suppresses repeats
+func (c *Cache) Window() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.window
+}
+
+// SetWindow changes how long a published proposal suppresses repeats
+func (c *Cache) SetWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window
+}
+
+// Suppressed reports whether a proposal for the track and action at priority
+// repeats one published within the window before now
+func (c *Cache) Suppressed(trackID, actionType string, priority int, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.window <= 0 {
+		return false
+	}
+	last, ok := c.published[key(trackID, actionType)]
+	return ok && now.Sub(last.at) < c.window && priority <= last.priority
+}
+
+// Published records a proposal for the track and action as published at now,
+// starting its window. Call it only once the proposal is published, so one
+// that failed to publish is not suppressed when it is retried.
+func (c *Cache) Published(trackID, actionType string, priority int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.swept) >= c.window {
+		c.expire(now)
+	}
+	c.published[key(trackID, actionType)] = publishedProposal{priority: priority, at: now}
+}
+
+// Len returns the number of proposals still suppressing repeats at now
+func (c *Cache) Len(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expire(now)
+	return len(c.published)
+}
+
+// expire forgets proposals whose window has passed. The caller holds c.mu.
+func (c *Cache) expire(now time.Time) {
+	c.swept = now
+	for k, p := range c.published {
+		if now.Sub(p.at) >= c.window {
+			delete(c.published, k)
+		}
+	}
+}
+
+func key(trackID, actionType string) string {
+	return trackID + "|" + actionType
+}
@@ -0,0 +1,256 @@
+// Package ratelimit throttles mutating API requests at the gateway.
+//
+// Every POST, PUT, PATCH, and DELETE draws a token from two buckets: one for
+// the client IP and one for the caller's identity, the bearer token it sends
+// or else its X-User-ID. A request is refused with 429 Too Many Requests and
+// a Retry-After header when either bucket is empty, so one demo station
+// cannot clear the picture or flood decisions for everyone, however many
+// addresses or accounts it spreads across. Reads are never limited.
+//
+// Buckets are held in memory. The gateway runs as a single instance, so the
+// limits are exact; buckets idle long enough to have refilled are forgotten.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+)
+
+// Defaults. A zero rate disables limiting.
+const (
+	DefaultRate  = 5.0 // Tokens per second per bucket
+	DefaultBurst = 20
+	MaxBurst     = 10000
+)
+
+// Bucket scopes
+const (
+	ScopeIP    = "ip"
+	ScopeToken = "token"
+)
+
+// Config sets the refill rate and capacity of every bucket
+type Config struct {
+	Rate  float64 // Tokens added per second
+	Burst int     // Bucket capacity
+}
+
+// DefaultConfig returns the default limits
+func DefaultConfig() Config {
+	return Config{Rate: DefaultRate, Burst: DefaultBurst}
+}
+
+// Enabled reports whether requests are limited
+func (c Config) Enabled() bool {
+	return c.Rate > 0
+}
+
+// ParseConfig parses RATE_LIMIT_RATE and RATE_LIMIT_BURST, using the defaults
+// for unset values
+func ParseConfig(rate, burst string) (Config, error) {
+	cfg := DefaultConfig()
+	if rate != "" {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 || math.IsInf(r, 0) || math.IsNaN(r) {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_RATE %q: must be a non-negative number of requests per second", rate)
+		}
+		cfg.Rate = r
+	}
+	if burst != "" {
+		n, err := strconv.Atoi(burst)
+		if err != nil || n < 1 || n > MaxBurst {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_BURST %q: must be between 1 and %d", burst, MaxBurst)
+		}
+		cfg.Burst = n
+	}
+	return cfg, nil
+}
+
+// bucket is one token bucket, refilled lazily when it is drawn from
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter holds the token buckets for every client IP and identity
+type Limiter struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	limited *prometheus.CounterVec
+	allowed prometheus.Counter
+	tracked prometheus.GaugeFunc
+}
+
+// New creates a limiter
+func New(cfg Config) *Limiter {
+	l := &Limiter{
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+		limited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_rate_limited_total",
+			Help: "Mutating requests refused with 429 by the bucket that ran out",
+		}, []string{"scope"}),
+		allowed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cjadc2_api_rate_limit_allowed_total",
+			Help: "Mutating requests admitted by the rate limiter",
+		}),
+	}
+	l.tracked = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cjadc2_api_rate_limit_buckets",
+		Help: "Client IPs and identities with a token bucket in memory",
+	}, func() float64 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return float64(len(l.buckets))
+	})
+	return l
+}
+
+// Collectors returns the limiter's metrics for registration
+func (l *Limiter) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{l.limited, l.allowed, l.tracked}
+}
+
+// SetClock replaces the limiter's clock, for tests
+func (l *Limiter) SetClock(now func() time.Time) {
+	l.now = now
+}
+
+// Allow draws a token from each key's bucket. Either every bucket gives one
+// or none does; when one is empty Allow returns its index and how long until
+// it holds a token again.
+func (l *Limiter) Allow(keys ...string) (ok bool, empty int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	burst := float64(l.cfg.Burst)
+	refilled := make([]*bucket, len(keys))
+	for i, key := range keys {
+		b, found := l.buckets[key]
+		if !found {
+			b = &bucket{tokens: burst, updated: now}
+			l.buckets[key] = b
+		}
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*l.cfg.Rate)
+		b.updated = now
+		if b.tokens < 1 {
+			wait := time.Duration((1 - b.tokens) / l.cfg.Rate * float64(time.Second))
+			return false, i, wait
+		}
+		refilled[i] = b
+	}
+	for _, b := range refilled {
+		b.tokens--
+	}
+	return true, -1, 0
+}
+
+// sweep forgets buckets idle long enough to be full again, at most once per
+// refill period
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(float64(l.cfg.Burst) / l.cfg.Rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < full {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware refuses mutating requests over the limit with 429 and
+// Retry-After. Safe methods pass straight through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.cfg.Enabled() || !isMutation(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scopes := []string{ScopeIP}
+		keys := []string{ScopeIP + ":" + clientIP(r)}
+		if id := identity(r); id != "" {
+			scopes = append(scopes, ScopeToken)
+			keys = append(keys, ScopeToken+":"+id)
+		}
+
+		ok, empty, retryAfter := l.Allow(keys...)
+		if !ok {
+			l.limited.WithLabelValues(scopes[empty]).Inc()
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			handler.WriteProblem(w, problem.CodeRateLimited,
+				fmt.Sprintf("Too many requests from this %s; retry in %ds", scopeNoun(scopes[empty]), seconds),
+				handler.GetCorrelationID(r.Context()))
+			return
+		}
+		l.allowed.Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isMutation reports whether a method changes state
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// clientIP returns the request's client address without its port. RealIP
+// middleware has already replaced it with X-Forwarded-For when present.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// identity keys the caller's bucket by its bearer token, hashed so tokens are
+// not held in memory, or else by its user ID
+func identity(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		sum := sha256.Sum256([]byte(strings.TrimSpace(auth[len("Bearer "):])))
+		return "bearer:" + hex.EncodeToString(sum[:16])
+	}
+	if userID := handler.GetUserID(r.Context()); userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
+// scopeNoun names a bucket scope in an error message
+func scopeNoun(scope string) string {
+	if scope == ScopeToken {
+		return "caller"
+	}
+	return "address"
+}
@@ -0,0 +1,166 @@
+// Package rescore holds the rules that set a proposal's priority and TTL from
+// its track, and re-scores pending proposals as their tracks change.
+//
+// The planner scores a proposal once, from the track it was planned on. A
+// track that escalates while its proposal waits for an operator, say from
+// medium to critical, would otherwise leave the proposal at its old priority
+// and deadline. Whenever a pending proposal's track is reported at a new
+// threat level, the proposal takes the priority the planner would give it
+// now, keeping the steps it gained from escalation. A raised priority can
+// only bring its deadline forward and a lowered one only push it back, so
+// re-scoring never expires a proposal on the spot or shortens an operator's
+// remaining time below the new priority's TTL.
+package rescore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Proposal priorities range from MinPriority to MaxPriority
+const (
+	MinPriority = 1
+	MaxPriority = 10
+)
+
+// Pending is the current scoring of a pending proposal
+type Pending struct {
+	Priority        int
+	ThreatLevel     string
+	EscalationLevel int // Priority steps gained from escalation
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
+
+// Update is a pending proposal's new scoring
+type Update struct {
+	Priority    int
+	ThreatLevel string
+	ExpiresAt   time.Time
+}
+
+// Rescore re-scores a pending proposal against the latest report of its
+// track. It returns false while the track's threat level is unchanged. wall
+// converts a simulated duration to wall time, as the proposal's deadline is
+// stored in wall time.
+func Rescore(p Pending, track *messages.CorrelatedTrack, now time.Time, wall func(time.Duration) time.Duration) (Update, bool) {
+	if track.ThreatLevel == "" || track.ThreatLevel == p.ThreatLevel {
+		return Update{}, false
+	}
+
+	_, priority, _ := Recommend(track)
+	priority += p.EscalationLevel
+	if priority > MaxPriority {
+		priority = MaxPriority
+	}
+	if priority < MinPriority {
+		priority = MinPriority
+	}
+
+	u := Update{Priority: priority, ThreatLevel: track.ThreatLevel, ExpiresAt: p.ExpiresAt}
+	ttl := wall(TTL(priority))
+	switch {
+	case priority > p.Priority:
+		if deadline := now.Add(ttl); deadline.Before(p.ExpiresAt) {
+			u.ExpiresAt = deadline
+		}
+	case priority < p.Priority:
+		if deadline := p.CreatedAt.Add(ttl); deadline.After(p.ExpiresAt) {
+			u.ExpiresAt = deadline
+		}
+	}
+	return u, true
+}
+
+// Recommend decides what action to take for a track and at what priority,
+// from its threat level, classification, and type
+func Recommend(track *messages.CorrelatedTrack) (actionType string, priority int, rationale string) {
+	classification := track.Classification
+	threatLevel := track.ThreatLevel
+	trackType := track.Type
+
+	// Critical threat - immediate engagement consideration
+	if threatLevel == "critical" {
+		if classification == "hostile" && trackType == "missile" {
+			return "engage", 10, fmt.Sprintf(
+				"Critical threat: hostile missile detected at position (%.4f, %.4f) with speed %.1f m/s. Immediate defensive action recommended.",
+				track.Position.Lat, track.Position.Lon, track.Velocity.Speed,
+			)
+		}
+		return "intercept", 9, fmt.Sprintf(
+			"Critical threat: %s %s requires immediate interception.",
+			classification, trackType,
+		)
+	}
+
+	// High threat - intercept or identify
+	if threatLevel == "high" {
+		if classification == "hostile" {
+			return "intercept", 8, fmt.Sprintf(
+				"High threat: hostile %s approaching. Interception recommended for defensive posture.",
+				trackType,
+			)
+		}
+		if classification == "unknown" {
+			return "identify", 7, fmt.Sprintf(
+				"High threat unknown %s detected. Identification required before further action.",
+				trackType,
+			)
+		}
+	}
+
+	// Medium threat - track or identify
+	if threatLevel == "medium" {
+		if classification == "unknown" {
+			return "identify", 5, fmt.Sprintf(
+				"Medium threat: unknown %s requires identification.",
+				trackType,
+			)
+		}
+		if classification == "hostile" {
+			return "track", 6, fmt.Sprintf(
+				"Medium threat: hostile %s should be tracked for situational awareness.",
+				trackType,
+			)
+		}
+	}
+
+	// Low threat - monitor or ignore
+	if threatLevel == "low" {
+		if classification == "friendly" {
+			return "monitor", 2, fmt.Sprintf(
+				"Friendly %s detected. Continued monitoring for coordination.",
+				trackType,
+			)
+		}
+		if classification == "neutral" {
+			return "monitor", 3, fmt.Sprintf(
+				"Neutral %s detected. Monitoring for situational awareness.",
+				trackType,
+			)
+		}
+	}
+
+	// Default action
+	return "track", 4, fmt.Sprintf(
+		"Standard tracking recommended for %s %s.",
+		classification, trackType,
+	)
+}
+
+// TTL returns how long a proposal of the given priority stays open, in
+// simulated time
+func TTL(priority int) time.Duration {
+	switch {
+	case priority >= 9:
+		return 10 * time.Minute // Critical - short window but enough time for review
+	case priority >= 7:
+		return 15 * time.Minute // High priority
+	case priority >= 5:
+		return 30 * time.Minute // Medium priority
+	default:
+		return 60 * time.Minute // Low priority - longer consideration time
+	}
+}
@@ -0,0 +1,308 @@
+// Package retention keeps the detections and effects tables from growing
+// without bound.
+//
+// The gateway runs an Archiver that, every Interval, moves detections older
+// than DetectionAge and finished effects older than EffectAge into archive
+// tables partitioned by month. Detections are themselves partitioned by day:
+// a day wholly past DetectionAge is moved as one partition and dropped, and
+// the rest go in batches of BatchSize so live writers are never blocked for
+// long. Archived effects remain part of the audit trail and hash chain. In
+// dry-run mode the archiver only counts and logs what it would move.
+//
+// Each run also creates the detection partitions for the next
+// PartitionDaysAhead days, which continues hourly when archiving is disabled.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+)
+
+// Archived tables
+const (
+	TableDetections = "detections"
+	TableEffects    = "effects"
+)
+
+// Defaults and limits
+const (
+	DefaultInterval     = time.Hour
+	DefaultDetectionAge = 7 * 24 * time.Hour
+	DefaultEffectAge    = 90 * 24 * time.Hour
+	BatchSize           = 1000
+	PartitionDaysAhead  = 7
+
+	// Detections stay until the DETECTIONS stream has discarded them, so a
+	// redelivered detection is still recognized as stored
+	MinDetectionAge = 24 * time.Hour
+
+	// Effects stay until the DECISIONS stream has discarded their decisions,
+	// so a redelivered decision still finds its effect's idempotency key
+	MinEffectAge = 7 * 24 * time.Hour
+)
+
+// Config controls the retention job
+type Config struct {
+	Interval     time.Duration // Zero disables archiving but not partition creation
+	DetectionAge time.Duration
+	EffectAge    time.Duration
+	DryRun       bool
+}
+
+// DefaultConfig returns the default retention
+func DefaultConfig() Config {
+	return Config{Interval: DefaultInterval, DetectionAge: DefaultDetectionAge, EffectAge: DefaultEffectAge}
+}
+
+// ParseConfig parses RETENTION_INTERVAL, RETENTION_DETECTIONS,
+// RETENTION_EFFECTS, and RETENTION_DRY_RUN, using the defaults for unset values
+func ParseConfig(interval, detectionAge, effectAge, dryRun string) (Config, error) {
+	cfg := DefaultConfig()
+	if interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid RETENTION_INTERVAL %q: must be a non-negative duration", interval)
+		}
+		cfg.Interval = d
+	}
+	if detectionAge != "" {
+		d, err := time.ParseDuration(detectionAge)
+		if err != nil || d < MinDetectionAge {
+			return cfg, fmt.Errorf("invalid RETENTION_DETECTIONS %q: must be a duration of at least %s", detectionAge, MinDetectionAge)
+		}
+		cfg.DetectionAge = d
+	}
+	if effectAge != "" {
+		d, err := time.ParseDuration(effectAge)
+		if err != nil || d < MinEffectAge {
+			return cfg, fmt.Errorf("invalid RETENTION_EFFECTS %q: must be a duration of at least %s", effectAge, MinEffectAge)
+		}
+		cfg.EffectAge = d
+	}
+	if dryRun != "" {
+		b, err := strconv.ParseBool(dryRun)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid RETENTION_DRY_RUN %q: must be true or false", dryRun)
+		}
+		cfg.DryRun = b
+	}
+	return cfg, nil
+}
+
+// Store counts and archives old rows and manages detection partitions
+type Store interface {
+	CountArchivable(ctx context.Context, table string, cutoff time.Time) (int64, error)
+	ArchiveDetections(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	ArchiveEffects(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	EnsureDetectionPartitions(ctx context.Context, from time.Time, days int) ([]string, error)
+	ArchiveDetectionPartition(ctx context.Context, cutoff time.Time) (string, int64, error)
+}
+
+// TableReport is the outcome of one run for one table
+type TableReport struct {
+	Table    string    `json:"table"`
+	Cutoff   time.Time `json:"cutoff"`
+	Eligible int64     `json:"eligible"` // Rows older than the cutoff when the run began
+	Archived int64     `json:"archived"`
+
+	PartitionsDropped []string `json:"partitions_dropped,omitempty"` // Archived whole
+}
+
+// Report is the outcome of one run
+type Report struct {
+	DryRun            bool          `json:"dry_run"`
+	PartitionsCreated []string      `json:"partitions_created,omitempty"`
+	Tables            []TableReport `json:"tables"`
+	Duration          time.Duration `json:"duration"`
+}
+
+// Archiver periodically moves old rows to the archive tables
+type Archiver struct {
+	cfg    Config
+	store  Store
+	logger zerolog.Logger
+
+	archived    *prometheus.CounterVec
+	eligible    *prometheus.GaugeVec
+	partitions  *prometheus.CounterVec
+	runs        *prometheus.CounterVec
+	duration    prometheus.Histogram
+	lastSuccess prometheus.Gauge
+}
+
+// New creates an archiver
+func New(cfg Config, store Store, logger zerolog.Logger) *Archiver {
+	return &Archiver{
+		cfg:    cfg,
+		store:  store,
+		logger: logger.With().Str("component", "retention").Logger(),
+		archived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_retention_archived_total",
+			Help: "Rows moved to the archive tables by the retention job",
+		}, []string{"table"}),
+		eligible: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cjadc2_api_retention_eligible_rows",
+			Help: "Rows older than their retention age at the start of the last run",
+		}, []string{"table"}),
+		partitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_retention_partitions_total",
+			Help: "Detection partitions created ahead or archived and dropped by the retention job",
+		}, []string{"action"}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cjadc2_api_retention_runs_total",
+			Help: "Retention job runs by result (success, dry_run, error)",
+		}, []string{"result"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cjadc2_api_retention_run_seconds",
+			Help:    "Time to complete one retention run",
+			Buckets: []float64{.1, .5, 1, 5, 15, 30, 60, 300, 900},
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cjadc2_api_retention_last_success_timestamp_seconds",
+			Help: "Unix time the last retention run completed without error",
+		}),
+	}
+}
+
+// Collectors returns the archiver's metrics for registration
+func (a *Archiver) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{a.archived, a.eligible, a.partitions, a.runs, a.duration, a.lastSuccess}
+}
+
+// Run archives every Interval until ctx is done, starting with a run now
+func (a *Archiver) Run(ctx context.Context) error {
+	interval := a.cfg.Interval
+	if interval == 0 {
+		a.logger.Info().Msg("Retention disabled; creating detection partitions only")
+		interval = DefaultInterval
+	} else {
+		a.logger.Info().
+			Dur("interval", a.cfg.Interval).
+			Dur("detection_age", a.cfg.DetectionAge).
+			Dur("effect_age", a.cfg.EffectAge).
+			Bool("dry_run", a.cfg.DryRun).
+			Msg("Archiving old detections and effects")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := a.RunOnce(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			a.logger.Error().Err(err).Msg("Retention run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce creates upcoming detection partitions, then archives, or in
+// dry-run mode counts, every row past its retention age as of now. With
+// archiving disabled it only creates partitions.
+func (a *Archiver) RunOnce(ctx context.Context, now time.Time) (Report, error) {
+	start := time.Now()
+	report := Report{DryRun: a.cfg.DryRun}
+
+	created, runErr := a.store.EnsureDetectionPartitions(ctx, now, PartitionDaysAhead)
+	report.PartitionsCreated = created
+	a.partitions.WithLabelValues("created").Add(float64(len(created)))
+	for _, name := range created {
+		a.logger.Info().Str("partition", name).Msg("Created detection partition")
+	}
+
+	tables := []struct {
+		name      string
+		age       time.Duration
+		partition func(context.Context, time.Time) (string, int64, error)
+		archive   func(context.Context, time.Time, int) (int64, error)
+	}{
+		{TableDetections, a.cfg.DetectionAge, a.store.ArchiveDetectionPartition, a.store.ArchiveDetections},
+		{TableEffects, a.cfg.EffectAge, nil, a.store.ArchiveEffects},
+	}
+	if a.cfg.Interval == 0 {
+		tables = nil
+	}
+
+	for _, t := range tables {
+		if runErr != nil {
+			break
+		}
+		tr := TableReport{Table: t.name, Cutoff: now.Add(-t.age)}
+		tr.Eligible, runErr = a.store.CountArchivable(ctx, t.name, tr.Cutoff)
+		if runErr != nil {
+			break
+		}
+		a.eligible.WithLabelValues(t.name).Set(float64(tr.Eligible))
+
+		// Whole expired partitions first, including empty ones, then what
+		// remains row by row
+		for !a.cfg.DryRun && t.partition != nil {
+			name, n, err := t.partition(ctx, tr.Cutoff)
+			if err != nil {
+				runErr = err
+				break
+			}
+			if name == "" {
+				break
+			}
+			tr.Archived += n
+			tr.PartitionsDropped = append(tr.PartitionsDropped, name)
+			a.archived.WithLabelValues(t.name).Add(float64(n))
+			a.partitions.WithLabelValues("dropped").Inc()
+		}
+
+		// Rows archived in one run are bounded by those eligible when it began,
+		// so a steady stream of newly aged rows cannot keep it running
+		for !a.cfg.DryRun && runErr == nil && tr.Archived < tr.Eligible {
+			n, err := t.archive(ctx, tr.Cutoff, BatchSize)
+			if err != nil {
+				runErr = err
+				break
+			}
+			tr.Archived += n
+			a.archived.WithLabelValues(t.name).Add(float64(n))
+			if n < BatchSize {
+				break
+			}
+		}
+		report.Tables = append(report.Tables, tr)
+	}
+
+	report.Duration = time.Since(start)
+	a.duration.Observe(report.Duration.Seconds())
+
+	switch {
+	case runErr != nil:
+		a.runs.WithLabelValues("error").Inc()
+		return report, runErr
+	case a.cfg.DryRun:
+		a.runs.WithLabelValues("dry_run").Inc()
+	default:
+		a.runs.WithLabelValues("success").Inc()
+	}
+	a.lastSuccess.Set(float64(time.Now().Unix()))
+
+	for _, tr := range report.Tables {
+		event := a.logger.Info()
+		if tr.Eligible == 0 {
+			event = a.logger.Debug()
+		}
+		event.
+			Str("table", tr.Table).
+			Time("cutoff", tr.Cutoff).
+			Int64("eligible", tr.Eligible).
+			Int64("archived", tr.Archived).
+			Strs("partitions_dropped", tr.PartitionsDropped).
+			Bool("dry_run", a.cfg.DryRun).
+			Msg("Retention run")
+	}
+	return report, nil
+}
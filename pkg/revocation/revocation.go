@@ -0,0 +1,173 @@
+// Package revocation recalls approved decisions before their effect executes.
+//
+// An operator revokes a decision through the authorizer, which flags the
+// decisions row and publishes a DecisionRevocation on the DECISIONS stream.
+// The effector checks the flag in the transaction that claims the effect and
+// records an aborted effect instead of executing. The authorizer locks the
+// decision row for update and the effector for share, so a decision is either
+// revoked before its effect is claimed or refused because the effect has
+// already started. Chained decision rows are otherwise append-only; the audit
+// chain guard lets through only an update that sets the revoked_* columns of
+// an unrevoked decision.
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// StatusAborted is the effect status recorded for a revoked decision
+const StatusAborted = "aborted"
+
+// AuditAction is the audit_log action recorded for a revocation
+const AuditAction = "revoked"
+
+var (
+	// ErrNotApproved is returned when revoking a denied decision
+	ErrNotApproved = errors.New("only approved decisions can be revoked")
+	// ErrAlreadyRevoked is returned when the decision was revoked before
+	ErrAlreadyRevoked = errors.New("decision already revoked")
+	// ErrEffectStarted is returned once the effector has claimed the decision's effect
+	ErrEffectStarted = errors.New("effect already started")
+	// ErrRevoked is returned by the effector's claim when the decision is revoked
+	ErrRevoked = errors.New("decision revoked")
+)
+
+// Tx is the subset of pgx used inside a revocation or claim transaction
+type Tx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Decision is a decision row locked for revocation
+type Decision struct {
+	DecisionID    string
+	ProposalID    string
+	TrackID       string
+	ActionType    string
+	CorrelationID string
+	Approved      bool
+	RevokedAt     *time.Time
+}
+
+// Revocation records who recalled a decision, when, and why
+type Revocation struct {
+	RevokedBy string
+	RevokedAt time.Time
+	Reason    string
+}
+
+// Lock loads a decision and locks its row until the transaction ends. It
+// returns pgx.ErrNoRows when the decision does not exist.
+func Lock(ctx context.Context, tx Tx, decisionID string) (Decision, error) {
+	var d Decision
+	err := tx.QueryRow(ctx, `
+		SELECT d.decision_id::text, COALESCE(d.proposal_id::text, ''), d.track_id, d.action_type,
+			COALESCE(d.correlation_id, p.correlation_id, ''), d.approved, d.revoked_at
+		FROM decisions d
+		LEFT JOIN proposals p ON p.proposal_id = d.proposal_id
+		WHERE d.decision_id = $1
+		FOR UPDATE OF d
+	`, decisionID).Scan(&d.DecisionID, &d.ProposalID, &d.TrackID, &d.ActionType,
+		&d.CorrelationID, &d.Approved, &d.RevokedAt)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to load decision: %w", err)
+	}
+	return d, nil
+}
+
+// Record flags a decision locked by Lock as revoked and audits it. It refuses
+// decisions that were denied, already revoked, or whose effect has started.
+func Record(ctx context.Context, tx Tx, d Decision, rev Revocation) error {
+	switch {
+	case !d.Approved:
+		return ErrNotApproved
+	case d.RevokedAt != nil:
+		return ErrAlreadyRevoked
+	}
+
+	// The row lock makes an effector's claim wait, so this sees any committed claim
+	var status string
+	err := tx.QueryRow(ctx, `
+		SELECT status FROM effects WHERE decision_id = $1 ORDER BY created_at LIMIT 1
+	`, d.DecisionID).Scan(&status)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrEffectStarted, status)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check decision effects: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE decisions SET revoked_at = $2, revoked_by = $3, revoke_reason = NULLIF($4, '')
+		WHERE decision_id = $1
+	`, d.DecisionID, rev.RevokedAt, rev.RevokedBy, rev.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to revoke decision: %w", err)
+	}
+
+	details, err := json.Marshal(map[string]interface{}{
+		"proposal_id": d.ProposalID,
+		"action_type": d.ActionType,
+		"track_id":    d.TrackID,
+		"revoked_at":  rev.RevokedAt,
+		"reason":      rev.Reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation audit details: %w", err)
+	}
+
+	// audit_log.correlation_id is a UUID; fall back to the decision ID for free-form IDs
+	correlationID := d.CorrelationID
+	if _, err := uuid.Parse(correlationID); err != nil {
+		correlationID = d.DecisionID
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (entity_type, entity_id, action, actor_id, actor_type, new_value, correlation_id)
+		VALUES ('decision', $1, $2, $3, 'human', $4, $5)
+	`, d.DecisionID, AuditAction, rev.RevokedBy, details, correlationID)
+	if err != nil {
+		return fmt.Errorf("failed to write revocation audit entry: %w", err)
+	}
+	return nil
+}
+
+// Check returns the revocation of a decision, or nil if it stands. It holds a
+// share lock on the decision row so a revocation cannot land between the
+// check and the effect claim made in the same transaction. Decisions missing
+// from the database are treated as standing.
+func Check(ctx context.Context, tx Tx, decisionID string) (*Revocation, error) {
+	var revokedAt *time.Time
+	var rev Revocation
+	err := tx.QueryRow(ctx, `
+		SELECT revoked_at, COALESCE(revoked_by, ''), COALESCE(revoke_reason, '')
+		FROM decisions WHERE decision_id = $1
+		FOR SHARE
+	`, decisionID).Scan(&revokedAt, &rev.RevokedBy, &rev.Reason)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check decision revocation: %w", err)
+	}
+	if revokedAt == nil {
+		return nil, nil
+	}
+	rev.RevokedAt = *revokedAt
+	return &rev, nil
+}
+
+// Result describes an aborted effect
+func (r Revocation) Result() string {
+	if r.Reason == "" {
+		return fmt.Sprintf("Aborted: decision revoked by %s", r.RevokedBy)
+	}
+	return fmt.Sprintf("Aborted: decision revoked by %s: %s", r.RevokedBy, r.Reason)
+}
@@ -0,0 +1,346 @@
+// Package scoring measures how faithfully the pipeline recovers simulation ground truth.
+//
+// Each scan the sensor publishes the true type and classification of every
+// simulated track on truth.track.<agent_id> in the GROUNDTRUTH stream, next
+// to the truth.detection records that mark injected clutter and false alarms.
+// The scorer follows that stream alongside the classifier's and correlator's
+// output on TRACKS and keeps running counts since it started or was last
+// reset:
+//
+//   - classification and type precision and recall per class, from classified tracks
+//   - track precision and recall, from correlated tracks matched to truth or clutter
+//   - track continuity: how many correlated IDs each true track was reported
+//     under, and the share of its scans in which it was reported at all
+//
+// Messages about tracks with no ground truth yet are counted as unscored.
+package scoring
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/clutter"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TruthTrackSubjectPrefix is where the sensor publishes per-track ground truth
+const TruthTrackSubjectPrefix = "truth.track"
+
+// Limits on scorer state; the oldest tracks are forgotten first
+const (
+	MaxTruthTracks    = 10000
+	MaxSpuriousTracks = 10000
+)
+
+// TrackTruth is the true identity and kinematics of one simulated track at one
+// scan. MessageIDs lists the detections the sensors reported for it that scan.
+type TrackTruth struct {
+	Envelope       messages.Envelope `json:"envelope"`
+	TrackID        string            `json:"track_id"`
+	SimulatorID    string            `json:"simulator_id"` // Sensor agent that simulates the track
+	Type           string            `json:"type"`
+	Classification string            `json:"classification"`
+	Position       messages.Position `json:"position"`
+	Velocity       messages.Velocity `json:"velocity"`
+	MessageIDs     []string          `json:"message_ids,omitempty"`
+	ObservedAt     time.Time         `json:"observed_at"`
+}
+
+func (t *TrackTruth) GetEnvelope() messages.Envelope {
+	return t.Envelope
+}
+
+func (t *TrackTruth) SetEnvelope(e messages.Envelope) {
+	t.Envelope = e
+}
+
+// Subject returns the ground-truth subject for the record
+func (t TrackTruth) Subject() string {
+	return TruthTrackSubjectPrefix + "." + t.SimulatorID
+}
+
+// Marshal encodes the record for publishing
+func (t TrackTruth) Marshal() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// ClassScore is precision and recall for one class
+type ClassScore struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Predicted int     `json:"predicted"` // Outputs assigned the class
+	Actual    int     `json:"actual"`    // Outputs whose truth is the class
+	Correct   int     `json:"correct"`
+}
+
+// LabelScores summarizes predictions of one label against truth
+type LabelScores struct {
+	Scored   int                   `json:"scored"`
+	Accuracy float64               `json:"accuracy"`
+	Classes  map[string]ClassScore `json:"classes"`
+}
+
+// TrackScores summarizes correlated tracks against truth and clutter
+type TrackScores struct {
+	TruthTracks    int     `json:"truth_tracks"`    // Simulated tracks seen in ground truth
+	ReportedTracks int     `json:"reported_tracks"` // Of those, reported by the correlator at least once
+	TrueTracks     int     `json:"true_tracks"`     // Correlated track IDs matching a simulated track
+	FalseTracks    int     `json:"false_tracks"`    // Correlated track IDs built only from clutter
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+}
+
+// ContinuityScores summarizes how steadily true tracks were reported
+type ContinuityScores struct {
+	Tracks          int     `json:"tracks"`             // Reported simulated tracks
+	Fragmented      int     `json:"fragmented"`         // Reported under more than one correlated ID
+	MeanIDsPerTrack float64 `json:"mean_ids_per_track"` // 1 is perfect continuity
+	MeanCoverage    float64 `json:"mean_coverage"`      // Share of scans with a correlated report
+}
+
+// Report is the scorer's view of pipeline accuracy
+type Report struct {
+	Since          time.Time        `json:"since"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Classification LabelScores      `json:"classification"`
+	Type           LabelScores      `json:"type"`
+	Tracks         TrackScores      `json:"tracks"`
+	Continuity     ContinuityScores `json:"continuity"`
+	Unscored       int              `json:"unscored"` // Outputs for tracks with no ground truth
+}
+
+type truthTrack struct {
+	classification string
+	trackType      string
+	lastSeen       time.Time
+	scans          int
+	coveredScans   int
+	lastCovered    int // Scan number last covered by a correlated report
+	correlatedIDs  map[string]bool
+}
+
+// confusion counts predicted labels against true labels
+type confusion map[string]map[string]int
+
+func (c confusion) add(actual, predicted string) {
+	row, ok := c[actual]
+	if !ok {
+		row = make(map[string]int)
+		c[actual] = row
+	}
+	row[predicted]++
+}
+
+func (c confusion) scores() LabelScores {
+	out := LabelScores{Classes: make(map[string]ClassScore)}
+	correct := 0
+	for actual, row := range c {
+		for predicted, n := range row {
+			out.Scored += n
+			a := out.Classes[actual]
+			a.Actual += n
+			if actual == predicted {
+				a.Correct += n
+				correct += n
+			}
+			out.Classes[actual] = a
+			p := out.Classes[predicted]
+			p.Predicted += n
+			out.Classes[predicted] = p
+		}
+	}
+	for name, cs := range out.Classes {
+		cs.Precision = ratio(cs.Correct, cs.Predicted)
+		cs.Recall = ratio(cs.Correct, cs.Actual)
+		out.Classes[name] = cs
+	}
+	out.Accuracy = ratio(correct, out.Scored)
+	return out
+}
+
+// Scorer accumulates pipeline output against ground truth. It is safe for concurrent use.
+type Scorer struct {
+	mu             sync.Mutex
+	since          time.Time
+	truth          map[string]*truthTrack
+	spurious       map[string]time.Time
+	classification confusion
+	trackType      confusion
+	falseTracks    map[string]bool
+	unscored       int
+}
+
+// NewScorer creates a scorer counting from now
+func NewScorer(now time.Time) *Scorer {
+	s := &Scorer{}
+	s.Reset(now)
+	return s
+}
+
+// Reset discards everything scored so far and starts counting from now
+func (s *Scorer) Reset(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.since = now
+	s.truth = make(map[string]*truthTrack)
+	s.spurious = make(map[string]time.Time)
+	s.classification = make(confusion)
+	s.trackType = make(confusion)
+	s.falseTracks = make(map[string]bool)
+	s.unscored = 0
+}
+
+// ObserveTruth records one scan of a simulated track
+func (s *Scorer) ObserveTruth(t TrackTruth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tt, ok := s.truth[t.TrackID]
+	if !ok {
+		if len(s.truth) >= MaxTruthTracks {
+			s.evictOldestTruth()
+		}
+		tt = &truthTrack{correlatedIDs: make(map[string]bool)}
+		s.truth[t.TrackID] = tt
+	}
+	tt.classification = t.Classification
+	tt.trackType = t.Type
+	tt.lastSeen = t.ObservedAt
+	tt.scans++
+}
+
+// ObserveSpurious records a detection the sensor injected as clutter or a false alarm
+func (s *Scorer) ObserveSpurious(t clutter.Truth) {
+	if !t.Spurious {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.spurious[t.TrackID]; !ok && len(s.spurious) >= MaxSpuriousTracks {
+		oldest, oldestAt := "", time.Time{}
+		for id, at := range s.spurious {
+			if oldest == "" || at.Before(oldestAt) {
+				oldest, oldestAt = id, at
+			}
+		}
+		delete(s.spurious, oldest)
+	}
+	s.spurious[t.TrackID] = t.ObservedAt
+}
+
+// ObserveClassified scores a classifier output against the track's truth
+func (s *Scorer) ObserveClassified(track *messages.Track) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tt, ok := s.truth[track.TrackID]
+	if !ok {
+		if _, spurious := s.spurious[track.TrackID]; !spurious {
+			s.unscored++
+		}
+		return
+	}
+	s.classification.add(tt.classification, track.Classification)
+	s.trackType.add(tt.trackType, track.Type)
+}
+
+// ObserveCorrelated matches a correlator output to the simulated tracks it was built from
+func (s *Scorer) ObserveCorrelated(track *messages.CorrelatedTrack) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sources := track.MergedFrom
+	if len(sources) == 0 {
+		sources = []string{track.TrackID}
+	}
+
+	matched, spurious := false, 0
+	for _, id := range sources {
+		if tt, ok := s.truth[id]; ok {
+			matched = true
+			tt.correlatedIDs[track.TrackID] = true
+			if tt.lastCovered != tt.scans {
+				tt.lastCovered = tt.scans
+				tt.coveredScans++
+			}
+			continue
+		}
+		if _, ok := s.spurious[id]; ok {
+			spurious++
+		}
+	}
+
+	switch {
+	case matched:
+		delete(s.falseTracks, track.TrackID)
+	case spurious == len(sources):
+		s.falseTracks[track.TrackID] = true
+	default:
+		s.unscored++
+	}
+}
+
+// Report summarizes everything scored since the last reset
+func (s *Scorer) Report(now time.Time) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := Report{
+		Since:          s.since,
+		GeneratedAt:    now,
+		Classification: s.classification.scores(),
+		Type:           s.trackType.scores(),
+		Unscored:       s.unscored,
+	}
+
+	trueIDs := make(map[string]bool)
+	var idTotal int
+	var coverage float64
+	for _, tt := range s.truth {
+		report.Tracks.TruthTracks++
+		if len(tt.correlatedIDs) == 0 {
+			continue
+		}
+		report.Tracks.ReportedTracks++
+		report.Continuity.Tracks++
+		if len(tt.correlatedIDs) > 1 {
+			report.Continuity.Fragmented++
+		}
+		idTotal += len(tt.correlatedIDs)
+		if tt.scans > 0 {
+			coverage += float64(tt.coveredScans) / float64(tt.scans)
+		}
+		for id := range tt.correlatedIDs {
+			trueIDs[id] = true
+		}
+	}
+	report.Tracks.TrueTracks = len(trueIDs)
+	report.Tracks.FalseTracks = len(s.falseTracks)
+	report.Tracks.Precision = ratio(report.Tracks.TrueTracks, report.Tracks.TrueTracks+report.Tracks.FalseTracks)
+	report.Tracks.Recall = ratio(report.Tracks.ReportedTracks, report.Tracks.TruthTracks)
+	if report.Continuity.Tracks > 0 {
+		report.Continuity.MeanIDsPerTrack = float64(idTotal) / float64(report.Continuity.Tracks)
+		report.Continuity.MeanCoverage = coverage / float64(report.Continuity.Tracks)
+	}
+	return report
+}
+
+// evictOldestTruth forgets the simulated track seen least recently. The caller holds the lock.
+func (s *Scorer) evictOldestTruth() {
+	oldest, oldestAt := "", time.Time{}
+	for id, tt := range s.truth {
+		if oldest == "" || tt.lastSeen.Before(oldestAt) {
+			oldest, oldestAt = id, tt.lastSeen
+		}
+	}
+	delete(s.truth, oldest)
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
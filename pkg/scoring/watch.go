@@ -0,0 +1,95 @@
+package scoring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/clutter"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Streams and subjects the scorer follows
+const (
+	TruthStream       = "GROUNDTRUTH"
+	TracksStream      = "TRACKS"
+	classifiedSubject = "track.classified.>"
+	correlatedSubject = "track.correlated.>"
+)
+
+// Watch feeds the scorer from ground truth and the pipeline's track output
+// until ctx is done. Ordered consumers start at new messages, so scoring
+// covers the run from when the scorer started and never holds up the
+// pipeline's own consumers.
+func Watch(ctx context.Context, js jetstream.JetStream, scorer *Scorer) error {
+	truth, err := js.OrderedConsumer(ctx, TruthStream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{"truth.>"},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ground truth consumer: %w", err)
+	}
+	tracks, err := js.OrderedConsumer(ctx, TracksStream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{classifiedSubject, correlatedSubject},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create track consumer: %w", err)
+	}
+
+	truthCC, err := truth.Consume(func(msg jetstream.Msg) {
+		HandleTruth(scorer, msg.Subject(), msg.Headers().Get(messages.ContentTypeHeader), msg.Data())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch ground truth: %w", err)
+	}
+	tracksCC, err := tracks.Consume(func(msg jetstream.Msg) {
+		HandleTrack(scorer, msg.Subject(), msg.Headers().Get(messages.ContentTypeHeader), msg.Data())
+	})
+	if err != nil {
+		truthCC.Stop()
+		return fmt.Errorf("failed to watch tracks: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		truthCC.Stop()
+		tracksCC.Stop()
+	}()
+	return nil
+}
+
+// HandleTruth scores one ground-truth message in either encoding, sealed or
+// not; malformed records are ignored
+func HandleTruth(scorer *Scorer, subject, contentType string, data []byte) {
+	switch {
+	case strings.HasPrefix(subject, TruthTrackSubjectPrefix+"."):
+		var t TrackTruth
+		if err := messages.Unmarshal(contentType, data, &t); err == nil {
+			scorer.ObserveTruth(t)
+		}
+	case strings.HasPrefix(subject, clutter.TruthSubjectPrefix+"."):
+		var t clutter.Truth
+		if err := messages.Unmarshal(contentType, data, &t); err == nil {
+			scorer.ObserveSpurious(t)
+		}
+	}
+}
+
+// HandleTrack scores one classified or correlated track message in either encoding
+func HandleTrack(scorer *Scorer, subject, contentType string, data []byte) {
+	switch {
+	case strings.HasPrefix(subject, "track.classified."):
+		var t messages.Track
+		if err := messages.Unmarshal(contentType, data, &t); err == nil {
+			scorer.ObserveClassified(&t)
+		}
+	case strings.HasPrefix(subject, "track.correlated."):
+		var t messages.CorrelatedTrack
+		if err := messages.Unmarshal(contentType, data, &t); err == nil {
+			scorer.ObserveCorrelated(&t)
+		}
+	}
+}
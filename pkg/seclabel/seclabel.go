@@ -0,0 +1,158 @@
+// Package seclabel defines the security labels carried by messages and the
+// rows stored from them: a classification, and the coalition partners the
+// data is releasable to. It decides which labelled data a caller may see from
+// the clearance and releasability attributes of the caller's identity.
+package seclabel
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Classification labels, lowest first, as in the data handling policy
+const (
+	Unclassified = "unclassified"
+	Confidential = "confidential"
+	Secret       = "secret"
+	TopSecret    = "top_secret"
+)
+
+// Classifications lists the labels data may carry, lowest first
+var Classifications = []string{Unclassified, Confidential, Secret, TopSecret}
+
+// Level returns the rank of a classification label, -1 when it is unknown.
+// An empty label is unclassified.
+func Level(label string) int {
+	if label == "" {
+		return 0
+	}
+	for i, c := range Classifications {
+		if c == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseClassification parses a classification label; empty is unclassified
+func ParseClassification(value string) (string, error) {
+	label := strings.ToLower(strings.TrimSpace(value))
+	if label == "" {
+		return Unclassified, nil
+	}
+	if Level(label) < 0 {
+		return "", fmt.Errorf("invalid classification %q: must be one of %s", value, strings.Join(Classifications, ", "))
+	}
+	return label, nil
+}
+
+// releasabilityTag matches a releasability tag: a country code such as GBR or
+// a coalition such as FVEY or NATO
+var releasabilityTag = regexp.MustCompile(`^[A-Z][A-Z0-9_]{1,15}$`)
+
+// ParseReleasability parses a comma-separated list of releasability tags,
+// returning them upper-cased, sorted, and without duplicates. Empty returns
+// nil: data releasable to no coalition partner.
+func ParseReleasability(value string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		tag := strings.ToUpper(strings.TrimSpace(entry))
+		if tag == "" {
+			continue
+		}
+		if !releasabilityTag.MatchString(tag) {
+			return nil, fmt.Errorf("invalid releasability tag %q: want a country or coalition code such as GBR or FVEY", entry)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// Access describes what a caller may see. The zero value is unrestricted, as
+// for operators of the system itself. A clearance hides data classified above
+// it; releasability tags mark the caller as a coalition partner, who sees only
+// data released to at least one of them.
+type Access struct {
+	Clearance     string   `json:"clearance,omitempty"`
+	Releasability []string `json:"releasability,omitempty"`
+}
+
+// ParseAccess parses a caller's clearance and comma-separated releasability
+// tags; both empty is unrestricted
+func ParseAccess(clearance, releasability string) (Access, error) {
+	var access Access
+	if strings.TrimSpace(clearance) != "" {
+		label, err := ParseClassification(clearance)
+		if err != nil {
+			return Access{}, err
+		}
+		access.Clearance = label
+	}
+	tags, err := ParseReleasability(releasability)
+	if err != nil {
+		return Access{}, err
+	}
+	access.Releasability = tags
+	return access, nil
+}
+
+// Restricted reports whether the caller sees only part of the data
+func (a Access) Restricted() bool {
+	return a.Clearance != "" || len(a.Releasability) > 0
+}
+
+// Labels returns the classifications the caller is cleared for, lowest
+// first, or nil when the clearance does not restrict
+func (a Access) Labels() []string {
+	if a.Clearance == "" {
+		return nil
+	}
+	return append([]string{}, Classifications[:Level(a.Clearance)+1]...)
+}
+
+// Permits reports whether the caller may see data with the given labels
+func (a Access) Permits(classification string, releasability []string) bool {
+	if a.Clearance != "" {
+		level := Level(classification)
+		if level < 0 || level > Level(a.Clearance) {
+			return false
+		}
+	}
+	if len(a.Releasability) == 0 {
+		return true
+	}
+	for _, tag := range releasability {
+		for _, allowed := range a.Releasability {
+			if tag == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Combine returns the labels of data fused from two labelled sources: the
+// higher classification, releasable only to partners both are released to
+func Combine(classA string, relA []string, classB string, relB []string) (string, []string) {
+	classification := classA
+	if Level(classB) > Level(classA) {
+		classification = classB
+	}
+	var releasability []string
+	for _, tag := range relA {
+		for _, other := range relB {
+			if tag == other {
+				releasability = append(releasability, tag)
+				break
+			}
+		}
+	}
+	return classification, releasability
+}
@@ -0,0 +1,134 @@
+// Package sensormodel degrades simulated sensor observations.
+//
+// A perfect simulated sensor reports every track exactly where it is, which
+// leaves nothing for correlation and fusion to do. Each simulated sensor can
+// instead carry an error model: random position noise, a systematic bias that
+// shifts every report the same way, a chance of missing a track on any given
+// scan, and a rate of false alarms reported where there is no track. Several
+// sensors with different models observing the same tracks give the correlator
+// realistic, disagreeing data to fuse.
+package sensormodel
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Limits on sensor configuration
+const (
+	MaxSensors             = 10
+	MaxPositionSigmaMeters = 10000.0
+	MaxBiasMeters          = 50000.0
+	MaxFalseAlarmRate      = 10.0
+)
+
+// metersPerDegreeLat is the length of a degree of latitude
+const metersPerDegreeLat = 111320.0
+
+// ErrorModel describes how a sensor's reports differ from the truth. The zero
+// value is a perfect sensor.
+type ErrorModel struct {
+	PositionSigmaMeters float64 `json:"position_sigma_meters"` // Standard deviation of horizontal position noise
+	BiasNorthMeters     float64 `json:"bias_north_meters"`     // Systematic offset added to every report
+	BiasEastMeters      float64 `json:"bias_east_meters"`
+	DropoutProbability  float64 `json:"dropout_probability"` // 0-1, chance a track is missed on a scan
+	FalseAlarmRate      float64 `json:"false_alarm_rate"`    // Mean false detections per scan
+}
+
+// Sensor is a simulated sensor and the errors in what it reports
+type Sensor struct {
+	SensorID   string     `json:"sensor_id"`
+	SensorType string     `json:"sensor_type"` // radar, eo, ir, esm
+	Model      ErrorModel `json:"error_model"`
+}
+
+// Validate checks an error model's knobs are in range
+func (m ErrorModel) Validate() error {
+	switch {
+	case m.PositionSigmaMeters < 0 || m.PositionSigmaMeters > MaxPositionSigmaMeters:
+		return fmt.Errorf("position_sigma_meters must be between 0 and %.0f", MaxPositionSigmaMeters)
+	case math.Abs(m.BiasNorthMeters) > MaxBiasMeters || math.Abs(m.BiasEastMeters) > MaxBiasMeters:
+		return fmt.Errorf("bias_north_meters and bias_east_meters must be within ±%.0f", MaxBiasMeters)
+	case m.DropoutProbability < 0 || m.DropoutProbability > 1:
+		return fmt.Errorf("dropout_probability must be between 0 and 1")
+	case m.FalseAlarmRate < 0 || m.FalseAlarmRate > MaxFalseAlarmRate:
+		return fmt.Errorf("false_alarm_rate must be between 0 and %.0f", MaxFalseAlarmRate)
+	}
+	return nil
+}
+
+// Validate checks a set of sensors has unique IDs and valid error models
+func Validate(sensors []Sensor) error {
+	if len(sensors) > MaxSensors {
+		return fmt.Errorf("at most %d sensors may be simulated", MaxSensors)
+	}
+	seen := make(map[string]bool, len(sensors))
+	for _, s := range sensors {
+		if s.SensorID == "" || len(s.SensorID) > 64 {
+			return fmt.Errorf("sensor_id is required and must be at most 64 characters")
+		}
+		if s.SensorType == "" || len(s.SensorType) > 32 {
+			return fmt.Errorf("sensor %s: sensor_type is required and must be at most 32 characters", s.SensorID)
+		}
+		if seen[s.SensorID] {
+			return fmt.Errorf("duplicate sensor_id %s", s.SensorID)
+		}
+		seen[s.SensorID] = true
+		if err := s.Model.Validate(); err != nil {
+			return fmt.Errorf("sensor %s: %w", s.SensorID, err)
+		}
+	}
+	return nil
+}
+
+// Parse reads SENSOR_ERROR_MODELS, a JSON array of sensors. Empty means none.
+func Parse(value string) ([]Sensor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var sensors []Sensor
+	if err := json.Unmarshal([]byte(value), &sensors); err != nil {
+		return nil, fmt.Errorf("invalid SENSOR_ERROR_MODELS: %w", err)
+	}
+	if err := Validate(sensors); err != nil {
+		return nil, fmt.Errorf("invalid SENSOR_ERROR_MODELS: %w", err)
+	}
+	return sensors, nil
+}
+
+// Observe returns where the sensor reports a track at pos, or false if the
+// sensor misses it on this scan. Altitude is reported as is.
+func (m ErrorModel) Observe(rng *rand.Rand, pos messages.Position) (messages.Position, bool) {
+	if m.DropoutProbability > 0 && rng.Float64() < m.DropoutProbability {
+		return messages.Position{}, false
+	}
+	north, east := m.BiasNorthMeters, m.BiasEastMeters
+	if m.PositionSigmaMeters > 0 {
+		north += rng.NormFloat64() * m.PositionSigmaMeters
+		east += rng.NormFloat64() * m.PositionSigmaMeters
+	}
+	return Offset(pos, north, east), true
+}
+
+// FalseAlarms returns how many false detections the sensor reports on a scan.
+// The whole part of the rate is reported every scan and the fraction by chance.
+func (m ErrorModel) FalseAlarms(rng *rand.Rand) int {
+	n := int(m.FalseAlarmRate)
+	if frac := m.FalseAlarmRate - float64(n); frac > 0 && rng.Float64() < frac {
+		n++
+	}
+	return n
+}
+
+// Offset moves a position by meters north and east
+func Offset(pos messages.Position, north, east float64) messages.Position {
+	pos.Lat += north / metersPerDegreeLat
+	if cos := math.Cos(pos.Lat * math.Pi / 180); cos > 1e-9 {
+		pos.Lon += east / (metersPerDegreeLat * cos)
+	}
+	return pos
+}
@@ -0,0 +1,138 @@
+// Package sensorsite keeps the track IDs of concurrently running sensor
+// simulators apart. Each simulator represents a radar site and puts the
+// site's code in every track ID it generates, e.g. H-WEST-TRK-0001. Sites are
+// claimed as leases in the SENSOR_SITES KV bucket, so a second simulator
+// configured with a site already in use refuses to start instead of reporting
+// colliding IDs, and simulators set to auto take the lowest free site number.
+package sensorsite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/agile-defense/cjadc2/pkg/lease"
+)
+
+// BucketName is the KV bucket holding site claims
+const BucketName = "SENSOR_SITES"
+
+// Auto is the SENSOR_SITE value that allocates the lowest free site number
+const Auto = "auto"
+
+// MaxAutoSites bounds the site numbers tried by Allocate
+const MaxAutoSites = 99
+
+// ErrSiteTaken is returned when another live simulator holds a site
+var ErrSiteTaken = errors.New("sensor site is held by another simulator")
+
+// siteCode is an uppercase site code short enough to keep track IDs readable
+var siteCode = regexp.MustCompile(`^[A-Z0-9]{1,8}$`)
+
+// Parse validates a SENSOR_SITE value. An empty value derives the site from
+// the agent ID, and Auto is returned as is for Allocate.
+func Parse(value, agentID string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, Auto) {
+		return Auto, nil
+	}
+	if value == "" {
+		value = FromAgentID(agentID)
+	}
+	site := strings.ToUpper(value)
+	if !siteCode.MatchString(site) {
+		return "", fmt.Errorf("invalid SENSOR_SITE %q: must be 1 to 8 letters or digits, or %s", value, Auto)
+	}
+	return site, nil
+}
+
+// FromAgentID derives a site code from an agent ID by dropping the sensor-
+// prefix and anything but letters and digits: sensor-001 is site 001
+func FromAgentID(agentID string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(strings.TrimPrefix(agentID, "sensor-")) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	site := b.String()
+	if len(site) > 8 {
+		site = site[len(site)-8:]
+	}
+	return site
+}
+
+// TrackID returns the ID of a simulated track: the classification prefix
+// default classifier rules key on, the site, and the track's number
+func TrackID(classPrefix, site string, n int) string {
+	return fmt.Sprintf("%s-%s-TRK-%04d", classPrefix, site, n)
+}
+
+// key returns the KV key of a site's claim
+func key(site string) string {
+	return "site." + site
+}
+
+// Claim takes the site's lease for holder, returning ErrSiteTaken while
+// another simulator's claim is live. The caller keeps the claim by running
+// the returned lease, which renews it and releases it on shutdown.
+func Claim(ctx context.Context, store lease.Store, site, holder string, logger zerolog.Logger) (*lease.Lease, error) {
+	l := lease.New(store, lease.Config{Key: key(site), Holder: holder}, logger)
+	ok, err := l.TryAcquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim sensor site %s: %w", site, err)
+	}
+	if !ok {
+		if current, err := l.Current(ctx); err == nil && current != nil {
+			return nil, fmt.Errorf("%w: site %s is held by %s", ErrSiteTaken, site, current.Holder)
+		}
+		return nil, fmt.Errorf("%w: site %s", ErrSiteTaken, site)
+	}
+	return l, nil
+}
+
+// Allocate claims a site numbered S1 through S99 for holder. A restarted
+// simulator gets back a claim of its own that has not expired; otherwise the
+// lowest numbered site no other live simulator holds is claimed.
+func Allocate(ctx context.Context, store lease.Store, holder string, logger zerolog.Logger) (string, *lease.Lease, error) {
+	now := time.Now().UTC()
+	for n := 1; n <= MaxAutoSites; n++ {
+		site := autoSite(n)
+		current, err := lease.New(store, lease.Config{Key: key(site), Holder: holder}, logger).Current(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read sensor site %s: %w", site, err)
+		}
+		if current == nil {
+			break // Sites are claimed in order, so none past a never claimed one is held
+		}
+		if current.Holder == holder && !current.Expired(now) {
+			l, err := Claim(ctx, store, site, holder, logger)
+			if err == nil {
+				return site, l, nil
+			}
+		}
+	}
+
+	for n := 1; n <= MaxAutoSites; n++ {
+		site := autoSite(n)
+		l, err := Claim(ctx, store, site, holder, logger)
+		if errors.Is(err, ErrSiteTaken) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return site, l, nil
+	}
+	return "", nil, fmt.Errorf("no free sensor site among %s to %s", autoSite(1), autoSite(MaxAutoSites))
+}
+
+// autoSite returns the code of the nth automatically allocated site
+func autoSite(n int) string {
+	return fmt.Sprintf("S%d", n)
+}
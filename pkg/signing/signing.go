@@ -0,0 +1,487 @@
+// Package signing signs pipeline messages with per-agent ed25519 keys.
+//
+// Each agent generates its own keypair and publishes the public half in the
+// key registry, a JetStream KV bucket keyed by agent ID and key ID, before
+// signing anything with it. A signature verifies only under a key registered
+// to the agent the message names as its source, and only if the message was
+// stored while the key was valid, so a leaked key lets its holder speak for
+// one agent until the key expires or is deleted from the registry, rather
+// than for every agent as a shared secret would.
+//
+// Keys are rotated before they expire. A new key takes over signing while
+// the old one stays valid for an overlap, so messages signed just before a
+// rotation still verify when they are stored or delivered after it. Retired
+// keys stay in the registry so backlogs signed under them still verify.
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Bucket is the KV bucket of the key registry
+const Bucket = "SIGNING_KEYS"
+
+// SignatureHeader is the NATS header carrying a message's signature over its
+// encoded payload. The key it was made with is named in the envelope, inside
+// what is signed.
+const SignatureHeader = "Cjadc2-Signature"
+
+// Key timing
+const (
+	DefaultKeyLifetime = 24 * time.Hour
+	DefaultKeyOverlap  = time.Hour
+
+	// ClockSkew is how far before a key's start a message may be stored, for
+	// agents whose clocks run ahead of the NATS server's
+	ClockSkew = 30 * time.Second
+
+	// RotationRetryInterval is how soon a failed key rotation is retried;
+	// the current key stays valid through the overlap meanwhile
+	RotationRetryInterval = 30 * time.Second
+
+	// CacheTTL is how long a looked-up key is trusted before the registry is
+	// asked again, so a key deleted from the registry stops verifying
+	CacheTTL = time.Minute
+)
+
+// Verification failures. Each is a reason to refuse the message rather than
+// retry it.
+var (
+	ErrUnsigned     = errors.New("message is not signed")
+	ErrUnknownKey   = errors.New("unknown signing key")
+	ErrKeyNotValid  = errors.New("signing key is not valid")
+	ErrBadSignature = errors.New("signature does not match")
+)
+
+// Rejected reports whether a verification error refuses the message, as
+// opposed to a registry that could not be reached
+func Rejected(err error) bool {
+	return errors.Is(err, ErrUnsigned) || errors.Is(err, ErrUnknownKey) ||
+		errors.Is(err, ErrKeyNotValid) || errors.Is(err, ErrBadSignature)
+}
+
+// Policy is how an agent treats the messages it consumes
+type Policy string
+
+const (
+	// PolicyEnforce refuses unsigned messages, messages with a bad
+	// signature, and those made with an unknown or expired key
+	PolicyEnforce Policy = "enforce"
+
+	// PolicyPermissive refuses what PolicyEnforce does but accepts unsigned
+	// messages. It is for development and refused outside CJADC2_MODE=dev.
+	PolicyPermissive Policy = "permissive"
+
+	// PolicyOff accepts messages without verifying them
+	PolicyOff Policy = "off"
+)
+
+// ParsePolicy parses SIGNATURE_POLICY; empty selects PolicyEnforce
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return PolicyEnforce, nil
+	case PolicyPermissive, PolicyEnforce, PolicyOff:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid SIGNATURE_POLICY %q: must be enforce, permissive, or off", s)
+}
+
+// Config is how a service signs what it publishes and verifies what it
+// consumes
+type Config struct {
+	Policy   Policy        // SIGNATURE_POLICY
+	Lifetime time.Duration // SIGNING_KEY_LIFETIME
+	Overlap  time.Duration // SIGNING_KEY_OVERLAP
+}
+
+// ConfigFromVars parses SIGNATURE_POLICY, SIGNING_KEY_LIFETIME, and
+// SIGNING_KEY_OVERLAP. PolicyPermissive is refused outside CJADC2_MODE=dev.
+func ConfigFromVars(vars map[string]string) (Config, error) {
+	policy, err := ParsePolicy(vars["SIGNATURE_POLICY"])
+	if err != nil {
+		return Config{}, err
+	}
+	if policy == PolicyPermissive && config.Mode() != config.ModeDev {
+		return Config{}, fmt.Errorf("SIGNATURE_POLICY=permissive accepts unsigned messages and is for development; use enforce")
+	}
+	cfg := Config{Policy: policy, Lifetime: DefaultKeyLifetime, Overlap: DefaultKeyOverlap}
+	if v := vars["SIGNING_KEY_LIFETIME"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SIGNING_KEY_LIFETIME %q: must be a positive duration", v)
+		}
+		cfg.Lifetime = d
+	}
+	if v := vars["SIGNING_KEY_OVERLAP"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid SIGNING_KEY_OVERLAP %q: must be a positive duration", v)
+		}
+		cfg.Overlap = d
+	}
+	if cfg.Overlap >= cfg.Lifetime {
+		return cfg, fmt.Errorf("SIGNING_KEY_OVERLAP %s must be shorter than SIGNING_KEY_LIFETIME %s", cfg.Overlap, cfg.Lifetime)
+	}
+	return cfg, nil
+}
+
+// PublicKey is a registry entry: an agent's public key and when it is valid
+type PublicKey struct {
+	KeyID     string            `json:"key_id"`
+	AgentID   string            `json:"agent_id"`
+	AgentType string            `json:"agent_type"`
+	PublicKey ed25519.PublicKey `json:"public_key"`
+	NotBefore time.Time         `json:"not_before"`
+	NotAfter  time.Time         `json:"not_after"`
+}
+
+// ValidAt reports whether a message stored at t may be signed with the key
+func (k PublicKey) ValidAt(t time.Time) bool {
+	return !t.Before(k.NotBefore.Add(-ClockSkew)) && !t.After(k.NotAfter)
+}
+
+// Verify checks a base64 signature over data
+func (k PublicKey) Verify(data []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", ErrBadSignature)
+	}
+	if !ed25519.Verify(k.PublicKey, data, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Key is an agent's signing keypair
+type Key struct {
+	PublicKey
+	private ed25519.PrivateKey
+}
+
+// GenerateKey creates a keypair for an agent, valid from notBefore for lifetime
+func GenerateKey(agentID, agentType string, notBefore time.Time, lifetime time.Duration) (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return &Key{
+		PublicKey: PublicKey{
+			KeyID:     KeyIDOf(pub),
+			AgentID:   agentID,
+			AgentType: agentType,
+			PublicKey: pub,
+			NotBefore: notBefore,
+			NotAfter:  notBefore.Add(lifetime),
+		},
+		private: priv,
+	}, nil
+}
+
+// KeyIDOf returns the ID of a public key: the start of its SHA-256 in hex
+func KeyIDOf(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Sign returns the base64 signature of data
+func (k *Key) Sign(data []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(k.private, data))
+}
+
+// SignMsg signs an encoded message with key in SignatureHeader. A nil key
+// leaves the message unsigned.
+func SignMsg(m *nats.Msg, key *Key) {
+	if key == nil {
+		return
+	}
+	if m.Header == nil {
+		m.Header = nats.Header{}
+	}
+	m.Header.Set(SignatureHeader, key.Sign(m.Data))
+}
+
+// RegistryKey is the KV key of an agent's key. Keys are grouped by agent so
+// NATS permissions can limit each agent to registering keys of its own.
+func RegistryKey(agentID, keyID string) string {
+	return agentID + "." + keyID
+}
+
+// Store is the subset of jetstream.KeyValue used by the key registry
+type Store interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+	Delete(ctx context.Context, key string, opts ...jetstream.KVDeleteOpt) error
+}
+
+// EnsureBucket returns the key registry bucket, creating it if needed
+func EnsureBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, Bucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, jetstream.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get signing key bucket: %w", err)
+	}
+
+	kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      Bucket,
+		Description: "Agent public keys for message signatures by agent and key ID",
+		History:     1,
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signing key bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// Registry looks up and verifies against the public keys in a store,
+// caching each for CacheTTL
+type Registry struct {
+	store Store
+
+	mu    sync.Mutex
+	cache map[string]cachedKey
+}
+
+type cachedKey struct {
+	key       PublicKey
+	fetchedAt time.Time
+}
+
+// NewRegistry creates a key registry on store
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store, cache: make(map[string]cachedKey)}
+}
+
+// Register publishes a public key
+func (r *Registry) Register(ctx context.Context, key PublicKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	if _, err := r.store.Put(ctx, RegistryKey(key.AgentID, key.KeyID), data); err != nil {
+		return fmt.Errorf("failed to register signing key %s: %w", key.KeyID, err)
+	}
+	return nil
+}
+
+// Revoke deletes a key from the registry. Verifiers that cached it stop
+// accepting it within CacheTTL.
+func (r *Registry) Revoke(ctx context.Context, agentID, keyID string) error {
+	name := RegistryKey(agentID, keyID)
+	if err := r.store.Delete(ctx, name); err != nil {
+		return fmt.Errorf("failed to revoke signing key %s: %w", keyID, err)
+	}
+	r.mu.Lock()
+	delete(r.cache, name)
+	r.mu.Unlock()
+	return nil
+}
+
+// Lookup returns an agent's key by ID
+func (r *Registry) Lookup(ctx context.Context, agentID, keyID string) (PublicKey, error) {
+	name := RegistryKey(agentID, keyID)
+	r.mu.Lock()
+	cached, ok := r.cache[name]
+	r.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < CacheTTL {
+		return cached.key, nil
+	}
+
+	entry, err := r.store.Get(ctx, name)
+	if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
+		r.mu.Lock()
+		delete(r.cache, name)
+		r.mu.Unlock()
+		return PublicKey{}, fmt.Errorf("%w %s of %s", ErrUnknownKey, keyID, agentID)
+	}
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("failed to look up signing key %s: %w", keyID, err)
+	}
+	var key PublicKey
+	if err := json.Unmarshal(entry.Value(), &key); err != nil {
+		return PublicKey{}, fmt.Errorf("%w %s of %s: malformed registry entry", ErrUnknownKey, keyID, agentID)
+	}
+	if key.AgentID != agentID || key.KeyID != keyID || len(key.PublicKey) != ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("%w %s of %s: registry entry does not match", ErrUnknownKey, keyID, agentID)
+	}
+
+	r.mu.Lock()
+	r.cache[name] = cachedKey{key: key, fetchedAt: time.Now()}
+	r.mu.Unlock()
+	return key, nil
+}
+
+// Verify checks the signature of a message's encoded payload, made with the
+// key keyID of its source agent, stored at storedAt
+func (r *Registry) Verify(ctx context.Context, source, keyID string, data []byte, signature string, storedAt time.Time) error {
+	if signature == "" {
+		return ErrUnsigned
+	}
+	if keyID == "" {
+		return fmt.Errorf("%w: signed message names no signing key", ErrUnknownKey)
+	}
+	key, err := r.Lookup(ctx, source, keyID)
+	if err != nil {
+		return err
+	}
+	if !key.ValidAt(storedAt) {
+		return fmt.Errorf("%w at %s: valid from %s to %s", ErrKeyNotValid,
+			storedAt.Format(time.RFC3339), key.NotBefore.Format(time.RFC3339), key.NotAfter.Format(time.RFC3339))
+	}
+	return key.Verify(data, signature)
+}
+
+// VerifyMsg checks the signature of a consumed message of the named schema
+// under its source agent's key as of storedAt, when the stream stored it. A
+// zero storedAt falls back to the timestamp in the message's envelope.
+func (r *Registry) VerifyMsg(ctx context.Context, schema string, header nats.Header, data []byte, storedAt time.Time) error {
+	signature := header.Get(SignatureHeader)
+	if signature == "" {
+		return ErrUnsigned
+	}
+	env, err := messages.EnvelopeOf(schema, header.Get(messages.ContentTypeHeader), data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	if storedAt.IsZero() {
+		storedAt = env.Timestamp
+	}
+	return r.Verify(ctx, env.Source, env.SigningKeyID, data, signature, storedAt)
+}
+
+// Verifier checks consumed messages against SIGNATURE_POLICY for consumers
+// outside the agent framework's consume loop, such as ordered consumers and
+// core NATS subscriptions
+type Verifier struct {
+	Registry *Registry
+	Policy   Policy
+}
+
+// Check returns nil for a message the policy accepts, and otherwise why it
+// is refused or, for a registry that could not be reached, not yet checked.
+// Without a registry, which only a permissive service tolerates, every
+// message is accepted.
+func (v Verifier) Check(ctx context.Context, schema string, header nats.Header, data []byte, storedAt time.Time) error {
+	if v.Policy == PolicyOff || v.Registry == nil {
+		return nil
+	}
+	err := v.Registry.VerifyMsg(ctx, schema, header, data, storedAt)
+	if errors.Is(err, ErrUnsigned) && v.Policy == PolicyPermissive {
+		return nil
+	}
+	return err
+}
+
+// Signer holds an agent's current signing key and rotates it
+type Signer struct {
+	registry  *Registry
+	agentID   string
+	agentType string
+	lifetime  time.Duration
+	overlap   time.Duration
+
+	key atomic.Pointer[Key]
+}
+
+// NewSigner creates a signer for an agent. It has no key until Rotate.
+func NewSigner(registry *Registry, agentID, agentType string, lifetime, overlap time.Duration) *Signer {
+	return &Signer{registry: registry, agentID: agentID, agentType: agentType, lifetime: lifetime, overlap: overlap}
+}
+
+// Key returns the key to sign with, nil before the first rotation
+func (s *Signer) Key() *Key {
+	return s.key.Load()
+}
+
+// Registry returns the key registry the signer registers its keys in
+func (s *Signer) Registry() *Registry {
+	return s.registry
+}
+
+// Rotate generates a key valid from now, registers it, and signs with it from
+// then on. The previous key stays valid until it expires. If registering
+// fails the previous key is kept.
+func (s *Signer) Rotate(ctx context.Context, now time.Time) (*Key, error) {
+	key, err := GenerateKey(s.agentID, s.agentType, now, s.lifetime)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.registry.Register(ctx, key.PublicKey); err != nil {
+		return nil, err
+	}
+	s.key.Store(key)
+	return key, nil
+}
+
+// NextRotation returns when the current key should be replaced: the overlap
+// before it expires
+func (s *Signer) NextRotation() time.Time {
+	key := s.key.Load()
+	if key == nil {
+		return time.Time{}
+	}
+	return key.NotAfter.Add(-s.overlap)
+}
+
+// KeepRotating replaces the key the overlap before it expires until ctx is
+// done, retrying a failed rotation after RotationRetryInterval. Each attempt
+// is passed to report, which may be nil.
+func (s *Signer) KeepRotating(ctx context.Context, report func(*Key, error)) {
+	timer := time.NewTimer(time.Until(s.NextRotation()))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		key, err := s.Rotate(ctx, time.Now().UTC())
+		if report != nil {
+			report(key, err)
+		}
+		if err != nil {
+			timer.Reset(RotationRetryInterval)
+			continue
+		}
+		timer.Reset(time.Until(s.NextRotation()))
+	}
+}
+
+// Start joins the key registry on js as agentID, registers a first key, and
+// keeps rotating it until ctx is done. It signs for services outside the
+// agent framework, such as the gateway and load generator, and into
+// registries other than an agent's own, such as a federation partner's.
+func Start(ctx context.Context, js jetstream.JetStream, agentID, agentType string, cfg Config, report func(*Key, error)) (*Signer, error) {
+	kv, err := EnsureBucket(ctx, js)
+	if err != nil {
+		return nil, err
+	}
+	signer := NewSigner(NewRegistry(kv), agentID, agentType, cfg.Lifetime, cfg.Overlap)
+	if _, err := signer.Rotate(ctx, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	go signer.KeepRotating(ctx, report)
+	return signer, nil
+}
@@ -0,0 +1,220 @@
+// Package simclock provides the pipeline-wide simulation clock.
+//
+// The gateway owns the clock and publishes its state on the SIMCONTROL stream
+// whenever an operator pauses, resumes, or changes the speed of the
+// simulation. Every agent watches the stream and keeps a local Clock in step,
+// so a pause freezes the whole kill chain rather than only detection
+// emission, and a speed change makes every simulated interval pass faster or
+// slower.
+package simclock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// SIMCONTROL stream and subject
+const (
+	StreamName   = "SIMCONTROL"
+	StateSubject = "simcontrol.state"
+)
+
+// Speed bounds
+const (
+	MinSpeed = 0.1
+	MaxSpeed = 20.0
+)
+
+// State is a snapshot of the simulation clock. Simulated time at wall time w is
+// SimTime + (w - WallTime) * Speed while running, and SimTime while paused.
+type State struct {
+	Seq       uint64    `json:"seq"` // Increases with every change; older states are ignored
+	Paused    bool      `json:"paused"`
+	Speed     float64   `json:"speed"`
+	SimTime   time.Time `json:"sim_time"`
+	WallTime  time.Time `json:"wall_time"`
+	PausedAt  time.Time `json:"paused_at"` // Wall time the current pause began; zero while running
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// RealTime returns the initial state: running at 1x with simulated time equal to wall time
+func RealTime(wall time.Time) State {
+	return State{Speed: 1, SimTime: wall, WallTime: wall}
+}
+
+// SimAt returns the simulated time at wall time wall
+func (s State) SimAt(wall time.Time) time.Time {
+	if s.Paused || wall.Before(s.WallTime) {
+		return s.SimTime
+	}
+	return s.SimTime.Add(time.Duration(float64(wall.Sub(s.WallTime)) * s.Speed))
+}
+
+// next re-anchors the state at wall and advances the sequence
+func (s State) next(wall time.Time, by, reason string) State {
+	s.SimTime = s.SimAt(wall)
+	s.WallTime = wall
+	s.Seq++
+	s.UpdatedBy = by
+	s.Reason = reason
+	return s
+}
+
+// Pause returns the state frozen at wall
+func (s State) Pause(wall time.Time, by, reason string) State {
+	n := s.next(wall, by, reason)
+	if !n.Paused {
+		n.Paused = true
+		n.PausedAt = wall
+	}
+	return n
+}
+
+// Resume returns the state running again from wall
+func (s State) Resume(wall time.Time, by, reason string) State {
+	n := s.next(wall, by, reason)
+	n.Paused = false
+	n.PausedAt = time.Time{}
+	return n
+}
+
+// PauseEnded reports how long the simulation was paused when the change from
+// prev to next resumes it
+func PauseEnded(prev, next State) (time.Duration, bool) {
+	if !prev.Paused || next.Paused || prev.PausedAt.IsZero() {
+		return 0, false
+	}
+	return next.WallTime.Sub(prev.PausedAt), true
+}
+
+// WithSpeed returns the state running at speed from wall. The paused flag is kept.
+func (s State) WithSpeed(speed float64, wall time.Time, by, reason string) (State, error) {
+	if speed < MinSpeed || speed > MaxSpeed {
+		return s, fmt.Errorf("speed must be between %g and %g", MinSpeed, MaxSpeed)
+	}
+	n := s.next(wall, by, reason)
+	n.Speed = speed
+	return n, nil
+}
+
+// Clock is an agent's local view of the simulation clock
+type Clock struct {
+	mu        sync.RWMutex
+	state     State
+	wall      func() time.Time
+	listeners []func(prev, next State)
+}
+
+// New creates a clock running in real time
+func New() *Clock {
+	return NewWithWallClock(time.Now)
+}
+
+// NewWithWallClock creates a clock that reads wall time from wall
+func NewWithWallClock(wall func() time.Time) *Clock {
+	return &Clock{state: RealTime(wall()), wall: wall}
+}
+
+// State returns the current state
+func (c *Clock) State() State {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// Now returns the current simulated time
+func (c *Clock) Now() time.Time {
+	return c.State().SimAt(c.wall())
+}
+
+// Paused reports whether the simulation is frozen
+func (c *Clock) Paused() bool {
+	return c.State().Paused
+}
+
+// Speed returns the simulation speed multiplier
+func (c *Clock) Speed() float64 {
+	return c.State().Speed
+}
+
+// WallDuration converts a simulated duration into the wall time it takes at the current speed
+func (c *Clock) WallDuration(d time.Duration) time.Duration {
+	speed := c.Speed()
+	if speed <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) / speed)
+}
+
+// OnChange registers fn to be called after each applied state change
+func (c *Clock) OnChange(fn func(prev, next State)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+// Apply adopts next if it is newer than the current state, reporting whether it did
+func (c *Clock) Apply(next State) bool {
+	c.mu.Lock()
+	prev := c.state
+	if next.Seq <= prev.Seq || next.Speed <= 0 {
+		c.mu.Unlock()
+		return false
+	}
+	c.state = next
+	listeners := append([]func(prev, next State){}, c.listeners...)
+	c.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(prev, next)
+	}
+	return true
+}
+
+// Watch keeps clock in step with the SIMCONTROL stream until ctx is done. The
+// last published state is delivered first, so an agent that starts after a
+// pause or speed change joins the simulation where the others are.
+func Watch(ctx context.Context, js jetstream.JetStream, clock *Clock) error {
+	consumer, err := js.OrderedConsumer(ctx, StreamName, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{StateSubject},
+		DeliverPolicy:  jetstream.DeliverLastPerSubjectPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create simulation control consumer: %w", err)
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		var state State
+		if err := json.Unmarshal(msg.Data(), &state); err != nil {
+			return
+		}
+		clock.Apply(state)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch simulation control: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		cc.Stop()
+	}()
+	return nil
+}
+
+// Publish announces a new state to every agent
+func Publish(ctx context.Context, js jetstream.JetStream, state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal simulation state: %w", err)
+	}
+	if _, err := js.Publish(ctx, StateSubject, data); err != nil {
+		return fmt.Errorf("failed to publish simulation state: %w", err)
+	}
+	return nil
+}
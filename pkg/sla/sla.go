@@ -0,0 +1,156 @@
+// Package sla defines decision latency service levels for pending proposals.
+//
+// A policy is a list of thresholds, each giving the longest a proposal at or
+// above a priority may wait for a human decision. The authorizer checks
+// pending proposals against the policy and publishes one alert per proposal
+// when it breaches, so watch-floor dashboards can surface proposals that are
+// waiting too long.
+package sla
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSpec is the policy used when DECISION_SLA is unset: proposals at
+// priority 8 or above must be decided within two minutes
+const DefaultSpec = "8:2m"
+
+// Disabled is the DECISION_SLA value that turns SLA monitoring off
+const Disabled = "off"
+
+// Priority buckets label decision latency metrics, keeping their cardinality low
+const (
+	PriorityLow      = "low"      // 1-3
+	PriorityMedium   = "medium"   // 4-6
+	PriorityHigh     = "high"     // 7-8
+	PriorityCritical = "critical" // 9-10
+)
+
+// PriorityBuckets lists the priority buckets from lowest to highest
+var PriorityBuckets = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical}
+
+// LatencyBuckets are the histogram bounds, in seconds, of human decision
+// latency: from a snap decision to one made near a proposal's expiry
+var LatencyBuckets = []float64{1, 2, 5, 10, 15, 30, 60, 120, 180, 300, 600, 900}
+
+// PriorityBucket returns the bucket holding a proposal priority
+func PriorityBucket(priority int) string {
+	switch {
+	case priority >= 9:
+		return PriorityCritical
+	case priority >= 7:
+		return PriorityHigh
+	case priority >= 4:
+		return PriorityMedium
+	default:
+		return PriorityLow
+	}
+}
+
+// Threshold is the longest a proposal at or above MinPriority may stay undecided
+type Threshold struct {
+	MinPriority int
+	After       time.Duration
+}
+
+// String formats the threshold in DECISION_SLA syntax
+func (t Threshold) String() string {
+	return fmt.Sprintf("%d:%s", t.MinPriority, t.After)
+}
+
+// Policy is a set of decision latency thresholds
+type Policy struct {
+	Thresholds []Threshold // Sorted by descending MinPriority
+}
+
+// ParsePolicy parses DECISION_SLA, a comma-separated list of priority:duration
+// thresholds such as "8:2m,5:10m". An empty value uses DefaultSpec and "off"
+// disables monitoring.
+func ParsePolicy(spec string) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = DefaultSpec
+	}
+	if spec == Disabled {
+		return Policy{}, nil
+	}
+
+	var p Policy
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		priority, after, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return Policy{}, fmt.Errorf("invalid DECISION_SLA threshold %q: must be priority:duration", part)
+		}
+		n, err := strconv.Atoi(priority)
+		if err != nil || n < 1 || n > 10 {
+			return Policy{}, fmt.Errorf("invalid DECISION_SLA priority %q: must be between 1 and 10", priority)
+		}
+		d, err := time.ParseDuration(after)
+		if err != nil || d <= 0 {
+			return Policy{}, fmt.Errorf("invalid DECISION_SLA duration %q: must be a positive duration", after)
+		}
+		if seen[n] {
+			return Policy{}, fmt.Errorf("invalid DECISION_SLA: priority %d listed twice", n)
+		}
+		seen[n] = true
+		p.Thresholds = append(p.Thresholds, Threshold{MinPriority: n, After: d})
+	}
+
+	sort.Slice(p.Thresholds, func(i, j int) bool {
+		return p.Thresholds[i].MinPriority > p.Thresholds[j].MinPriority
+	})
+	return p, nil
+}
+
+// Enabled reports whether the policy has any thresholds
+func (p Policy) Enabled() bool {
+	return len(p.Thresholds) > 0
+}
+
+// MinPriority returns the lowest priority any threshold covers, or zero when disabled
+func (p Policy) MinPriority() int {
+	if !p.Enabled() {
+		return 0
+	}
+	return p.Thresholds[len(p.Thresholds)-1].MinPriority
+}
+
+// For returns the tightest threshold covering a priority
+func (p Policy) For(priority int) (Threshold, bool) {
+	var best Threshold
+	found := false
+	for _, t := range p.Thresholds {
+		if priority >= t.MinPriority && (!found || t.After < best.After) {
+			best = t
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Breached returns the threshold a proposal of the given priority has
+// breached after waiting pending, if any
+func (p Policy) Breached(priority int, pending time.Duration) (Threshold, bool) {
+	t, ok := p.For(priority)
+	if !ok || pending <= t.After {
+		return Threshold{}, false
+	}
+	return t, true
+}
+
+// String formats the policy in DECISION_SLA syntax
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return Disabled
+	}
+	parts := make([]string, len(p.Thresholds))
+	for i, t := range p.Thresholds {
+		parts[i] = t.String()
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,372 @@
+// Package streamarchive mirrors NATS JetStream streams to long-term object
+// storage and reads them back.
+//
+// The archiver writes each stream's messages as hourly segments of
+// newline-delimited JSON records, optionally gzipped, keyed
+// <STREAM>/<YYYY>/<MM>/<DD>/<HH>/<STREAM>-<YYYYMMDDTHH>-<part>.ndjson[.gz].
+// An hour normally fills one segment; restarts and size limits add parts.
+// A manifest at manifest.json lists every segment with its sequence range,
+// record count, and SHA-256, and records the last sequence archived from
+// each stream, so a restarted archiver resumes where it stopped. A segment
+// is committed only once the manifest lists it; an object the manifest does
+// not list is an interrupted upload and is overwritten.
+//
+// Hydrate publishes an archive's records back to a NATS server, in time
+// order, so an exercise can be preserved and replayed in a local
+// environment.
+package streamarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ManifestKey is the key of the archive's manifest
+const ManifestKey = "manifest.json"
+
+// ManifestVersion is the manifest format written by this package
+const ManifestVersion = 1
+
+// Content types of archive objects
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeGzip   = "application/gzip"
+	ContentTypeJSON   = "application/json"
+)
+
+// Record is one archived message
+type Record struct {
+	Stream   string              `json:"stream"`
+	Subject  string              `json:"subject"`
+	Sequence uint64              `json:"seq,omitempty"` // Stream sequence; zero for messages captured without a consumer
+	Time     time.Time           `json:"time"`          // When the stream stored the message
+	Headers  map[string][]string `json:"headers,omitempty"`
+	Data     []byte              `json:"data"` // Message payload as published, base64 in JSON
+}
+
+// Segment describes one archived segment object
+type Segment struct {
+	Key        string    `json:"key"`
+	Stream     string    `json:"stream"`
+	Hour       time.Time `json:"hour"` // Start of the hour the records were stored in
+	Part       int       `json:"part"`
+	FirstSeq   uint64    `json:"first_seq,omitempty"`
+	LastSeq    uint64    `json:"last_seq,omitempty"`
+	FirstTime  time.Time `json:"first_time"`
+	LastTime   time.Time `json:"last_time"`
+	Records    int       `json:"records"`
+	Bytes      int       `json:"bytes"` // Size of the stored object
+	SHA256     string    `json:"sha256"`
+	Compressed bool      `json:"compressed"`
+	WrittenAt  time.Time `json:"written_at"`
+}
+
+// Manifest lists an archive's segments and how far each stream is archived
+type Manifest struct {
+	Version   int               `json:"version"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Streams   map[string]uint64 `json:"streams"` // Last stream sequence archived, by stream
+	Segments  []Segment         `json:"segments"`
+}
+
+// Archive writes and reads the segments of one archive and keeps its
+// manifest. Only one process may write an archive at a time.
+type Archive struct {
+	store    Store
+	compress bool
+
+	mu       sync.Mutex
+	manifest Manifest
+}
+
+// OpenArchive loads an archive's manifest, starting an empty one if the
+// store has none. New segments are gzipped when compress is set.
+func OpenArchive(ctx context.Context, store Store, compress bool) (*Archive, error) {
+	a := &Archive{
+		store:    store,
+		compress: compress,
+		manifest: Manifest{Version: ManifestVersion, Streams: map[string]uint64{}, Segments: []Segment{}},
+	}
+	data, err := store.Get(ctx, ManifestKey)
+	if errors.Is(err, ErrNotFound) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &a.manifest); err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %w", err)
+	}
+	if a.manifest.Version > ManifestVersion {
+		return nil, fmt.Errorf("archive manifest version %d is newer than supported version %d", a.manifest.Version, ManifestVersion)
+	}
+	if a.manifest.Streams == nil {
+		a.manifest.Streams = map[string]uint64{}
+	}
+	return a, nil
+}
+
+// Manifest returns a copy of the archive's manifest
+func (a *Archive) Manifest() Manifest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	m := a.manifest
+	m.Streams = make(map[string]uint64, len(a.manifest.Streams))
+	for k, v := range a.manifest.Streams {
+		m.Streams[k] = v
+	}
+	m.Segments = append([]Segment(nil), a.manifest.Segments...)
+	return m
+}
+
+// Checkpoint returns the last sequence archived from stream, or zero
+func (a *Archive) Checkpoint(stream string) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.manifest.Streams[stream]
+}
+
+// WriteSegment stores records from one stream and hour as the next part of
+// that hour, then commits it to the manifest. Records must be in stream
+// order.
+func (a *Archive) WriteSegment(ctx context.Context, stream string, hour time.Time, records []Record) (Segment, error) {
+	if len(records) == 0 {
+		return Segment{}, fmt.Errorf("segment has no records")
+	}
+	hour = hour.UTC().Truncate(time.Hour)
+
+	data, err := encodeRecords(records, a.compress)
+	if err != nil {
+		return Segment{}, err
+	}
+
+	a.mu.Lock()
+	part := 0
+	for _, s := range a.manifest.Segments {
+		if s.Stream == stream && s.Hour.Equal(hour) && s.Part >= part {
+			part = s.Part + 1
+		}
+	}
+	a.mu.Unlock()
+
+	seg := Segment{
+		Key:        segmentKey(stream, hour, part, a.compress),
+		Stream:     stream,
+		Hour:       hour,
+		Part:       part,
+		FirstSeq:   records[0].Sequence,
+		LastSeq:    records[len(records)-1].Sequence,
+		FirstTime:  records[0].Time,
+		LastTime:   records[len(records)-1].Time,
+		Records:    len(records),
+		Bytes:      len(data),
+		SHA256:     sha256Hex(data),
+		Compressed: a.compress,
+		WrittenAt:  time.Now().UTC(),
+	}
+	contentType := ContentTypeNDJSON
+	if a.compress {
+		contentType = ContentTypeGzip
+	}
+	if err := a.store.Put(ctx, seg.Key, data, contentType); err != nil {
+		return Segment{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	previous := a.manifest
+	a.manifest.Segments = append(append([]Segment(nil), a.manifest.Segments...), seg)
+	streams := make(map[string]uint64, len(a.manifest.Streams)+1)
+	for k, v := range a.manifest.Streams {
+		streams[k] = v
+	}
+	if seg.LastSeq > streams[stream] {
+		streams[stream] = seg.LastSeq
+	}
+	a.manifest.Streams = streams
+	a.manifest.UpdatedAt = seg.WrittenAt
+	if err := a.saveLocked(ctx); err != nil {
+		a.manifest = previous
+		return Segment{}, err
+	}
+	return seg, nil
+}
+
+// saveLocked writes the manifest; a.mu must be held
+func (a *Archive) saveLocked(ctx context.Context) error {
+	data, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal archive manifest: %w", err)
+	}
+	if err := a.store.Put(ctx, ManifestKey, data, ContentTypeJSON); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadSegment reads a segment's records, verifying its checksum
+func (a *Archive) ReadSegment(ctx context.Context, seg Segment) ([]Record, error) {
+	data, err := a.store.Get(ctx, seg.Key)
+	if err != nil {
+		return nil, err
+	}
+	if seg.SHA256 != "" && sha256Hex(data) != seg.SHA256 {
+		return nil, fmt.Errorf("segment %s does not match its checksum", seg.Key)
+	}
+	return decodeRecords(data, seg.Compressed)
+}
+
+// Filter selects archived records
+type Filter struct {
+	Streams []string  // Streams to read; empty reads all
+	From    time.Time // Records stored at or after From; zero means the start
+	To      time.Time // Records stored before To; zero means the end
+}
+
+// selects reports whether the filter selects stream
+func (f Filter) selects(stream string) bool {
+	if len(f.Streams) == 0 {
+		return true
+	}
+	for _, s := range f.Streams {
+		if s == stream {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the filter selects records of stream stored at t
+func (f Filter) matches(stream string, t time.Time) bool {
+	return f.selects(stream) && (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || t.Before(f.To))
+}
+
+// Segments returns the segments holding records the filter may select, in
+// hour order
+func (a *Archive) Segments(filter Filter) []Segment {
+	var segs []Segment
+	for _, s := range a.Manifest().Segments {
+		overlaps := (filter.From.IsZero() || s.Hour.Add(time.Hour).After(filter.From)) &&
+			(filter.To.IsZero() || s.Hour.Before(filter.To))
+		if filter.selects(s.Stream) && overlaps {
+			segs = append(segs, s)
+		}
+	}
+	sort.SliceStable(segs, func(i, j int) bool {
+		if !segs[i].Hour.Equal(segs[j].Hour) {
+			return segs[i].Hour.Before(segs[j].Hour)
+		}
+		if segs[i].Stream != segs[j].Stream {
+			return segs[i].Stream < segs[j].Stream
+		}
+		return segs[i].Part < segs[j].Part
+	})
+	return segs
+}
+
+// Records calls fn with each record the filter selects, in the order the
+// streams stored them. Segments are read an hour at a time, so memory use is
+// bounded by the largest hour rather than the whole archive.
+func (a *Archive) Records(ctx context.Context, filter Filter, fn func(Record) error) error {
+	segs := a.Segments(filter)
+	for start := 0; start < len(segs); {
+		end := start
+		for end < len(segs) && segs[end].Hour.Equal(segs[start].Hour) {
+			end++
+		}
+
+		var hour []Record
+		for _, seg := range segs[start:end] {
+			records, err := a.ReadSegment(ctx, seg)
+			if err != nil {
+				return err
+			}
+			for _, r := range records {
+				if filter.matches(r.Stream, r.Time) {
+					hour = append(hour, r)
+				}
+			}
+		}
+		sort.SliceStable(hour, func(i, j int) bool { return hour[i].Time.Before(hour[j].Time) })
+		for _, r := range hour {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		start = end
+	}
+	return nil
+}
+
+// segmentKey returns the object key of a segment part
+func segmentKey(stream string, hour time.Time, part int, compressed bool) string {
+	key := fmt.Sprintf("%s/%s/%s-%s-%03d.ndjson", stream, hour.Format("2006/01/02/15"), stream, hour.Format("20060102T15"), part)
+	if compressed {
+		key += ".gz"
+	}
+	return key
+}
+
+// encodeRecords writes records as NDJSON, gzipped if compress is set
+func encodeRecords(records []Record, compress bool) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+	enc := json.NewEncoder(w)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return nil, fmt.Errorf("failed to encode record: %w", err)
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress segment: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeRecords reads NDJSON records, gunzipping them if compressed is set
+func decodeRecords(data []byte, compressed bool) ([]Record, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if compressed {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress segment: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("invalid segment record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read segment: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,382 @@
+package streamarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// Defaults and limits for archiving
+const (
+	DefaultSegmentMaxBytes = 64 * 1024 * 1024
+	MinSegmentMaxBytes     = 64 * 1024
+	FetchBatch             = 500
+	PollInterval           = time.Second
+	RetryDelay             = 5 * time.Second
+	ShutdownFlushTimeout   = 30 * time.Second
+)
+
+// Config controls the archiver
+type Config struct {
+	URL             string   // s3://bucket/prefix or file:///path
+	Streams         []string // Streams to archive
+	Compress        bool     // Gzip segments
+	SegmentMaxBytes int      // Segments are cut early at this much uncompressed NDJSON
+	S3              S3Config
+}
+
+// DefaultStreams returns every platform stream except AUDIT, which only
+// sources DECISIONS and EFFECTS and would archive them twice
+func DefaultStreams() []string {
+	var streams []string
+	for name := range natsutil.StreamConfigs {
+		if name != natsutil.AuditStream {
+			streams = append(streams, name)
+		}
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// ParseConfig parses ARCHIVE_URL, ARCHIVE_STREAMS, ARCHIVE_GZIP, and
+// ARCHIVE_SEGMENT_MAX_BYTES, using the defaults for unset values
+func ParseConfig(archiveURL, streams, compress, segmentMaxBytes string) (Config, error) {
+	cfg := Config{URL: archiveURL, Streams: DefaultStreams(), SegmentMaxBytes: DefaultSegmentMaxBytes}
+	if archiveURL == "" {
+		return cfg, fmt.Errorf("ARCHIVE_URL is required")
+	}
+	if streams != "" {
+		cfg.Streams = nil
+		for _, s := range strings.Split(streams, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				cfg.Streams = append(cfg.Streams, s)
+			}
+		}
+		if len(cfg.Streams) == 0 {
+			return cfg, fmt.Errorf("invalid ARCHIVE_STREAMS %q: no streams", streams)
+		}
+	}
+	if compress != "" {
+		b, err := strconv.ParseBool(compress)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid ARCHIVE_GZIP %q: must be true or false", compress)
+		}
+		cfg.Compress = b
+	}
+	if segmentMaxBytes != "" {
+		n, err := strconv.Atoi(segmentMaxBytes)
+		if err != nil || n < MinSegmentMaxBytes {
+			return cfg, fmt.Errorf("invalid ARCHIVE_SEGMENT_MAX_BYTES %q: must be at least %d", segmentMaxBytes, MinSegmentMaxBytes)
+		}
+		cfg.SegmentMaxBytes = n
+	}
+	return cfg, nil
+}
+
+// Archiver mirrors streams into an Archive. Each stream is read by an
+// ordered consumer from just after the archive's checkpoint, so the archiver
+// holds no server-side state and never delays acknowledgement of the
+// pipeline's consumers. Work-queue streams, which admit no second consumer,
+// are captured from a plain subscription to their subjects instead; their
+// messages published while the archiver is down are not archived.
+type Archiver struct {
+	cfg     Config
+	nc      *nats.Conn
+	js      jetstream.JetStream
+	archive *Archive
+	logger  zerolog.Logger
+
+	mu      sync.Mutex
+	lastErr error
+
+	records  *prometheus.CounterVec
+	segments *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	lag      *prometheus.GaugeVec
+}
+
+// NewArchiver creates an archiver writing to archive
+func NewArchiver(cfg Config, nc *nats.Conn, js jetstream.JetStream, archive *Archive, logger zerolog.Logger) *Archiver {
+	if cfg.SegmentMaxBytes <= 0 {
+		cfg.SegmentMaxBytes = DefaultSegmentMaxBytes
+	}
+	return &Archiver{
+		cfg:     cfg,
+		nc:      nc,
+		js:      js,
+		archive: archive,
+		logger:  logger.With().Str("component", "stream_archiver").Logger(),
+		records: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "archiver_records_total",
+			Help: "Messages written to archive segments",
+		}, []string{"stream"}),
+		segments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "archiver_segments_total",
+			Help: "Archive segments written",
+		}, []string{"stream"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "archiver_bytes_total",
+			Help: "Bytes of archive segments written, after compression",
+		}, []string{"stream"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "archiver_errors_total",
+			Help: "Failed segment writes and stream reads, retried",
+		}, []string{"stream"}),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "archiver_buffered_records",
+			Help: "Messages read but not yet written to a segment",
+		}, []string{"stream"}),
+	}
+}
+
+// Collectors returns the archiver's metrics for registration
+func (a *Archiver) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{a.records, a.segments, a.bytes, a.errors, a.lag}
+}
+
+// Err returns the most recent failure to read or write, or nil once the
+// stream it came from has written a segment since
+func (a *Archiver) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lastErr
+}
+
+func (a *Archiver) setErr(err error) {
+	a.mu.Lock()
+	a.lastErr = err
+	a.mu.Unlock()
+}
+
+// Run archives every configured stream until ctx is done, then writes what
+// it has buffered
+func (a *Archiver) Run(ctx context.Context) error {
+	if a.js == nil {
+		return fmt.Errorf("stream archiving requires JetStream")
+	}
+
+	var wg sync.WaitGroup
+	for _, stream := range a.cfg.Streams {
+		wg.Add(1)
+		go func(stream string) {
+			defer wg.Done()
+			a.runStream(ctx, stream)
+		}(stream)
+	}
+	wg.Wait()
+	return nil
+}
+
+// runStream archives one stream, retrying until ctx is done if the stream
+// cannot be read
+func (a *Archiver) runStream(ctx context.Context, stream string) {
+	buf := &segmentBuffer{stream: stream}
+	defer a.flushOnShutdown(buf)
+
+	for ctx.Err() == nil {
+		info, err := a.js.Stream(ctx, stream)
+		if err == nil {
+			if info.CachedInfo().Config.Retention == jetstream.WorkQueuePolicy {
+				err = a.subscribe(ctx, buf, info.CachedInfo().Config.Subjects)
+			} else {
+				err = a.consume(ctx, buf)
+			}
+		}
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		a.errors.WithLabelValues(stream).Inc()
+		a.setErr(fmt.Errorf("%s: %w", stream, err))
+		a.logger.Warn().Err(err).Str("stream", stream).Msg("Cannot read stream, retrying")
+		sleep(ctx, RetryDelay)
+	}
+}
+
+// consume reads a stream with an ordered consumer from after the checkpoint,
+// or from the last record buffered
+func (a *Archiver) consume(ctx context.Context, buf *segmentBuffer) error {
+	start := a.archive.Checkpoint(buf.stream)
+	if n := len(buf.records); n > 0 && buf.records[n-1].Sequence > start {
+		start = buf.records[n-1].Sequence
+	}
+	cfg := jetstream.OrderedConsumerConfig{DeliverPolicy: jetstream.DeliverAllPolicy}
+	if start > 0 {
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = start + 1
+	}
+	consumer, err := a.js.OrderedConsumer(ctx, buf.stream, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ordered consumer: %w", err)
+	}
+
+	a.logger.Info().Str("stream", buf.stream).Uint64("from_seq", start+1).Msg("Archiving stream")
+	for ctx.Err() == nil {
+		batch, err := consumer.Fetch(FetchBatch, jetstream.FetchMaxWait(PollInterval))
+		if err == nil {
+			for msg := range batch.Messages() {
+				meta, err := msg.Metadata()
+				if err != nil {
+					continue
+				}
+				a.add(ctx, buf, Record{
+					Stream:   buf.stream,
+					Subject:  msg.Subject(),
+					Sequence: meta.Sequence.Stream,
+					Time:     meta.Timestamp.UTC(),
+					Headers:  msg.Headers(),
+					Data:     msg.Data(),
+				})
+			}
+			err = batch.Error()
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, jetstream.ErrNoMessages) && ctx.Err() == nil {
+			return fmt.Errorf("failed to fetch: %w", err)
+		}
+		a.flushDue(ctx, buf, time.Now())
+	}
+	return nil
+}
+
+// subscribe captures a work-queue stream's messages as they are published
+func (a *Archiver) subscribe(ctx context.Context, buf *segmentBuffer, subjects []string) error {
+	if a.nc == nil {
+		return fmt.Errorf("work-queue stream needs a NATS connection")
+	}
+	msgs := make(chan *nats.Msg, 4096)
+	var subs []*nats.Subscription
+	defer func() {
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	}()
+	for _, subject := range subjects {
+		sub, err := a.nc.ChanSubscribe(subject, msgs)
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", subject, err)
+		}
+		subs = append(subs, sub)
+	}
+
+	a.logger.Info().Str("stream", buf.stream).Strs("subjects", subjects).Msg("Archiving work-queue stream from live subjects")
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-msgs:
+			a.add(ctx, buf, Record{
+				Stream:  buf.stream,
+				Subject: msg.Subject,
+				Time:    time.Now().UTC(),
+				Headers: msg.Header,
+				Data:    msg.Data,
+			})
+		case now := <-ticker.C:
+			a.flushDue(ctx, buf, now)
+		}
+	}
+}
+
+// add buffers a record, first writing the buffer if the record starts a new
+// hour or the buffer is full
+func (a *Archiver) add(ctx context.Context, buf *segmentBuffer, rec Record) {
+	hour := rec.Time.Truncate(time.Hour)
+	if len(buf.records) > 0 && (!hour.Equal(buf.hour) || buf.size >= a.cfg.SegmentMaxBytes) {
+		a.flush(ctx, buf)
+	}
+	if len(buf.records) == 0 {
+		buf.hour = hour
+	}
+	buf.records = append(buf.records, rec)
+	buf.size += len(rec.Data) + len(rec.Subject) + 128
+	a.lag.WithLabelValues(buf.stream).Set(float64(len(buf.records)))
+}
+
+// flushDue writes the buffer once its hour has ended, so quiet streams still
+// close their hours, or when it is full
+func (a *Archiver) flushDue(ctx context.Context, buf *segmentBuffer, now time.Time) {
+	if len(buf.records) == 0 {
+		return
+	}
+	if !now.Before(buf.hour.Add(time.Hour)) || buf.size >= a.cfg.SegmentMaxBytes {
+		a.flush(ctx, buf)
+	}
+}
+
+// flush writes the buffer as a segment, retrying until it succeeds or ctx is
+// done. Records stay buffered until written, so a failed write loses nothing
+// while the archiver runs.
+func (a *Archiver) flush(ctx context.Context, buf *segmentBuffer) {
+	for len(buf.records) > 0 {
+		seg, err := a.archive.WriteSegment(ctx, buf.stream, buf.hour, buf.records)
+		if err == nil {
+			a.records.WithLabelValues(buf.stream).Add(float64(seg.Records))
+			a.segments.WithLabelValues(buf.stream).Inc()
+			a.bytes.WithLabelValues(buf.stream).Add(float64(seg.Bytes))
+			a.lag.WithLabelValues(buf.stream).Set(0)
+			a.setErr(nil)
+			a.logger.Info().
+				Str("stream", buf.stream).
+				Str("key", seg.Key).
+				Int("records", seg.Records).
+				Int("bytes", seg.Bytes).
+				Msg("Wrote archive segment")
+			buf.records, buf.size = nil, 0
+			return
+		}
+		a.errors.WithLabelValues(buf.stream).Inc()
+		a.setErr(fmt.Errorf("%s: %w", buf.stream, err))
+		a.logger.Error().Err(err).Str("stream", buf.stream).Int("records", len(buf.records)).Msg("Failed to write archive segment, retrying")
+		if !sleep(ctx, RetryDelay) {
+			return
+		}
+	}
+}
+
+// flushOnShutdown writes what remains buffered when the archiver stops, as a
+// partial segment the next run continues after
+func (a *Archiver) flushOnShutdown(buf *segmentBuffer) {
+	if len(buf.records) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownFlushTimeout)
+	defer cancel()
+	a.flush(ctx, buf)
+	if len(buf.records) > 0 {
+		a.logger.Error().Str("stream", buf.stream).Int("records", len(buf.records)).Msg("Records not archived at shutdown; the next run reads them again unless the stream is a work queue")
+	}
+}
+
+// segmentBuffer holds one stream's records for the hour being archived
+type segmentBuffer struct {
+	stream  string
+	hour    time.Time
+	records []Record
+	size    int // Approximate NDJSON bytes
+}
+
+// sleep waits for d or until ctx is done, reporting whether it waited
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
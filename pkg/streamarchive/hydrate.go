@@ -0,0 +1,84 @@
+package streamarchive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Publisher publishes messages to JetStream; jetstream.JetStream satisfies it
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// HydrateOptions controls how an archive is replayed
+type HydrateOptions struct {
+	Filter Filter
+	// Speed scales the gaps between records: 1 replays in real time, 10 ten
+	// times faster. Zero publishes as fast as the server accepts.
+	Speed float64
+	// DryRun counts the records that would be published without publishing
+	DryRun bool
+}
+
+// HydrateReport summarizes a replay
+type HydrateReport struct {
+	Records  int            `json:"records"`
+	ByStream map[string]int `json:"by_stream"`
+	First    time.Time      `json:"first,omitempty"`
+	Last     time.Time      `json:"last,omitempty"`
+}
+
+// Hydrate publishes the archived records opts selects to pub, on their
+// original subjects with their original headers, in the order the streams
+// stored them. Publish expectations recorded in headers are dropped, since
+// they described the source server's streams. A failed publish stops the
+// replay; the report counts what was published before it.
+func Hydrate(ctx context.Context, archive *Archive, pub Publisher, opts HydrateOptions) (HydrateReport, error) {
+	report := HydrateReport{ByStream: make(map[string]int)}
+	start := time.Now()
+
+	err := archive.Records(ctx, opts.Filter, func(r Record) error {
+		if report.Records == 0 {
+			report.First = r.Time
+		}
+		if opts.Speed > 0 {
+			due := start.Add(time.Duration(float64(r.Time.Sub(report.First)) / opts.Speed))
+			if wait := time.Until(due); wait > 0 && !sleep(ctx, wait) {
+				return ctx.Err()
+			}
+		}
+
+		if !opts.DryRun {
+			msg := &nats.Msg{Subject: r.Subject, Data: r.Data, Header: replayHeaders(r.Headers)}
+			if _, err := pub.PublishMsg(ctx, msg); err != nil {
+				return fmt.Errorf("failed to publish %s record %d to %s: %w", r.Stream, r.Sequence, r.Subject, err)
+			}
+		}
+		report.Records++
+		report.ByStream[r.Stream]++
+		report.Last = r.Time
+		return nil
+	})
+	return report, err
+}
+
+// replayHeaders copies archived headers without the Nats-Expected-*
+// publish expectations
+func replayHeaders(headers map[string][]string) nats.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	h := make(nats.Header, len(headers))
+	for name, values := range headers {
+		if strings.HasPrefix(strings.ToLower(name), "nats-expected-") {
+			continue
+		}
+		h[name] = append([]string(nil), values...)
+	}
+	return h
+}
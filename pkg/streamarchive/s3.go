@@ -0,0 +1,271 @@
+package streamarchive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/config"
+)
+
+// DefaultS3Region is used when no region is configured; MinIO accepts it
+const DefaultS3Region = "us-east-1"
+
+// S3Config locates an S3-compatible bucket and the credentials to write it
+type S3Config struct {
+	Endpoint  string // Base URL, e.g. http://minio:9000; empty uses AWS S3 in Region
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Prefix    string // Key prefix within the bucket, without slashes at either end
+}
+
+// S3ConfigFromEnv reads the endpoint and credentials for an s3:// archive
+// URL from ARCHIVE_S3_ENDPOINT, ARCHIVE_S3_REGION, ARCHIVE_S3_ACCESS_KEY, and
+// ARCHIVE_S3_SECRET_KEY. The keys may be given as files with the _FILE
+// suffix and default to MinIO's in dev mode. Other archive URLs need none.
+func S3ConfigFromEnv(archiveURL string) (S3Config, error) {
+	if !strings.HasPrefix(archiveURL, "s3://") {
+		return S3Config{}, nil
+	}
+	secrets := config.NewLoader()
+	cfg := S3Config{
+		Endpoint:  os.Getenv("ARCHIVE_S3_ENDPOINT"),
+		Region:    os.Getenv("ARCHIVE_S3_REGION"),
+		AccessKey: secrets.Secret("ARCHIVE_S3_ACCESS_KEY", "minioadmin"),
+		SecretKey: secrets.Secret("ARCHIVE_S3_SECRET_KEY", "minioadmin"),
+	}
+	return cfg, secrets.Err()
+}
+
+// S3Store keeps objects in an S3 bucket or MinIO. It speaks the REST API
+// directly with Signature Version 4 and path-style addressing, which both
+// AWS and MinIO accept.
+type S3Store struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Store creates a store for the configured bucket
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("S3 access key and secret key are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultS3Region
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	return &S3Store{
+		cfg:    cfg,
+		base:   base,
+		client: &http.Client{Timeout: 5 * time.Minute},
+		now:    time.Now,
+	}, nil
+}
+
+// Put uploads an object, replacing any existing one
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload %s: %s", key, s3Error(resp))
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	default:
+		return nil, fmt.Errorf("failed to download %s: %s", key, s3Error(resp))
+	}
+}
+
+// request builds a path-style request for an object key
+func (s *S3Store) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	if s.cfg.Prefix != "" {
+		key = s.cfg.Prefix + "/" + key
+	}
+	u := *s.base
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + s.cfg.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	return req, nil
+}
+
+// do signs and sends a request
+func (s *S3Store) do(req *http.Request, body []byte) (*http.Response, error) {
+	SignV4(req, body, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, "s3", s.now())
+	return s.client.Do(req)
+}
+
+// s3Error summarizes an S3 error response
+func s3Error(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(body) == 0 {
+		return resp.Status
+	}
+	return resp.Status + ": " + strings.TrimSpace(string(body))
+}
+
+// SignV4 signs req with AWS Signature Version 4 for service in region at now.
+// It sets X-Amz-Date, X-Amz-Content-Sha256, and Authorization, signing the
+// host, any Range and Content-Type headers, and every X-Amz header.
+func SignV4(req *http.Request, body []byte, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.EscapedPath()),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalURI re-encodes an escaped path the way Signature Version 4 expects
+func canonicalURI(escaped string) string {
+	if escaped == "" {
+		return "/"
+	}
+	segments := strings.Split(escaped, "/")
+	for i, seg := range segments {
+		if raw, err := url.PathUnescape(seg); err == nil {
+			seg = raw
+		}
+		segments[i] = uriEncode(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery sorts and encodes query parameters
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
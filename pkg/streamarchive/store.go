@@ -0,0 +1,116 @@
+package streamarchive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by Store.Get for a missing object
+var ErrNotFound = errors.New("object not found")
+
+// Store holds archive objects by key. Keys are slash-separated paths relative
+// to the archive's root.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// OpenStore opens the store named by an archive URL: s3://bucket/prefix for
+// S3 or MinIO with the given credentials, or file:///path for a local
+// directory
+func OpenStore(archiveURL string, s3 S3Config) (Store, error) {
+	u, err := url.Parse(archiveURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive URL %q: %w", archiveURL, err)
+	}
+	switch u.Scheme {
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid archive URL %q: missing bucket", archiveURL)
+		}
+		s3.Bucket = u.Host
+		s3.Prefix = strings.Trim(u.Path, "/")
+		return NewS3Store(s3)
+	case "file", "":
+		path := u.Path
+		if u.Scheme == "" {
+			path = archiveURL
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid archive URL %q: missing path", archiveURL)
+		}
+		return NewDirStore(path), nil
+	default:
+		return nil, fmt.Errorf("invalid archive URL %q: scheme must be s3 or file", archiveURL)
+	}
+}
+
+// DirStore keeps objects as files under a local directory
+type DirStore struct {
+	root string
+}
+
+// NewDirStore creates a store rooted at dir, created on first write
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{root: dir}
+}
+
+// Put writes an object, replacing any existing one. The file is renamed into
+// place so readers never see a partial object.
+func (s *DirStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// Get reads an object
+func (s *DirStore) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// path maps a key to a file under the root, refusing keys that escape it
+func (s *DirStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid archive key %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,330 @@
+// Package threat provides a weighted, rule-driven threat scoring engine for correlated tracks
+package threat
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// Factor identifies which track attribute a scoring rule evaluates
+type Factor string
+
+const (
+	FactorClassification Factor = "classification"
+	FactorType           Factor = "type"
+	FactorSpeed          Factor = "speed"
+	FactorAltitude       Factor = "altitude"
+	FactorHeadingToAsset Factor = "heading_to_asset"
+	FactorTimeToZone     Factor = "time_to_zone" // Seconds until entering any zone (0 when inside)
+	FactorZoneCPA        Factor = "zone_cpa"     // Closest point of approach to any zone in meters
+)
+
+// Threat levels produced by the engine
+const (
+	LevelLow      = "low"
+	LevelMedium   = "medium"
+	LevelHigh     = "high"
+	LevelCritical = "critical"
+)
+
+// Rule is a single weighted scoring rule. A rule contributes its weight to the
+// score when its factor condition matches and the optional gates are satisfied.
+type Rule struct {
+	Name    string  `json:"name"`
+	Factor  Factor  `json:"factor"`
+	Weight  float64 `json:"weight"`
+	Enabled bool    `json:"enabled"`
+
+	// Values matched for classification and type factors
+	Match []string `json:"match,omitempty"`
+
+	// Inclusive range for speed (m/s), altitude (m), time_to_zone (s) and zone_cpa (m) factors
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+
+	// Protected asset for the heading_to_asset factor
+	AssetLat     float64 `json:"asset_lat,omitempty"`
+	AssetLon     float64 `json:"asset_lon,omitempty"`
+	ToleranceDeg float64 `json:"tolerance_deg,omitempty"` // Max heading offset from bearing to asset
+	RangeMeters  float64 `json:"range_meters,omitempty"`  // Only applies within this range (0 = unlimited)
+
+	// Optional gates restricting the rule to certain tracks
+	Classifications []string `json:"classifications,omitempty"`
+	Types           []string `json:"types,omitempty"`
+
+	EvaluationOrder int `json:"evaluation_order"`
+}
+
+// Thresholds maps a numeric score to a threat level (score >= threshold)
+type Thresholds struct {
+	Medium   float64 `json:"medium"`
+	High     float64 `json:"high"`
+	Critical float64 `json:"critical"`
+}
+
+// DefaultThresholds returns the default level thresholds
+func DefaultThresholds() Thresholds {
+	return Thresholds{Medium: 35, High: 60, Critical: 85}
+}
+
+// Contribution records how much a single rule added to a score
+type Contribution struct {
+	Rule   string  `json:"rule"`
+	Weight float64 `json:"weight"`
+}
+
+// Result is the outcome of scoring a track
+type Result struct {
+	Score         float64        `json:"score"`
+	Level         string         `json:"level"`
+	Contributions []Contribution `json:"contributions,omitempty"`
+}
+
+// Config is the on-disk representation of a rule set
+type Config struct {
+	Thresholds Thresholds `json:"thresholds"`
+	Rules      []Rule     `json:"rules"`
+}
+
+// Engine scores tracks against a set of weighted rules
+type Engine struct {
+	mu         sync.RWMutex
+	rules      []Rule
+	thresholds Thresholds
+}
+
+// NewEngine creates an engine with the given rules and thresholds
+func NewEngine(rules []Rule, thresholds Thresholds) *Engine {
+	e := &Engine{}
+	e.SetRules(rules, thresholds)
+	return e
+}
+
+// NewDefaultEngine creates an engine using the built-in rule set
+func NewDefaultEngine() *Engine {
+	return NewEngine(DefaultRules(), DefaultThresholds())
+}
+
+// SetRules atomically replaces the engine's rules and thresholds
+func (e *Engine) SetRules(rules []Rule, thresholds Thresholds) {
+	sorted := make([]Rule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EvaluationOrder < sorted[j].EvaluationOrder
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = sorted
+	e.thresholds = thresholds
+}
+
+// Rules returns a copy of the active rules
+func (e *Engine) Rules() []Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]Rule, len(e.rules))
+	copy(rules, e.rules)
+	return rules
+}
+
+// Thresholds returns the active level thresholds
+func (e *Engine) Thresholds() Thresholds {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.thresholds
+}
+
+// Score computes the threat score and level for a correlated track
+func (e *Engine) Score(ct *messages.CorrelatedTrack) Result {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := Result{}
+	for _, rule := range e.rules {
+		if !rule.Enabled || !rule.gatesMatch(ct) {
+			continue
+		}
+		if rule.matches(ct) {
+			result.Score += rule.Weight
+			result.Contributions = append(result.Contributions, Contribution{
+				Rule:   rule.Name,
+				Weight: rule.Weight,
+			})
+		}
+	}
+
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	result.Level = e.thresholds.Level(result.Score)
+	return result
+}
+
+// Level maps a score onto a threat level
+func (t Thresholds) Level(score float64) string {
+	switch {
+	case score >= t.Critical:
+		return LevelCritical
+	case score >= t.High:
+		return LevelHigh
+	case score >= t.Medium:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
+
+// Validate checks that a rule is well-formed
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	switch r.Factor {
+	case FactorClassification, FactorType:
+		if len(r.Match) == 0 {
+			return fmt.Errorf("rule %q: match values are required for factor %s", r.Name, r.Factor)
+		}
+	case FactorSpeed, FactorAltitude, FactorTimeToZone, FactorZoneCPA:
+		if r.Min == nil && r.Max == nil {
+			return fmt.Errorf("rule %q: min or max is required for factor %s", r.Name, r.Factor)
+		}
+	case FactorHeadingToAsset:
+		if r.ToleranceDeg <= 0 || r.ToleranceDeg > 180 {
+			return fmt.Errorf("rule %q: tolerance_deg must be in (0, 180]", r.Name)
+		}
+	default:
+		return fmt.Errorf("rule %q: unknown factor %q", r.Name, r.Factor)
+	}
+	return nil
+}
+
+// gatesMatch checks the optional classification/type restrictions
+func (r Rule) gatesMatch(ct *messages.CorrelatedTrack) bool {
+	if len(r.Classifications) > 0 && !contains(r.Classifications, ct.Classification) {
+		return false
+	}
+	if len(r.Types) > 0 && !contains(r.Types, ct.Type) {
+		return false
+	}
+	return true
+}
+
+// matches evaluates the rule's factor condition
+func (r Rule) matches(ct *messages.CorrelatedTrack) bool {
+	switch r.Factor {
+	case FactorClassification:
+		return contains(r.Match, ct.Classification)
+	case FactorType:
+		return contains(r.Match, ct.Type)
+	case FactorSpeed:
+		return inRange(ct.Velocity.Speed, r.Min, r.Max)
+	case FactorAltitude:
+		return inRange(ct.Position.Alt, r.Min, r.Max)
+	case FactorHeadingToAsset:
+		asset := messages.Position{Lat: r.AssetLat, Lon: r.AssetLon}
+		if r.RangeMeters > 0 && geo.Distance(ct.Position, asset) > r.RangeMeters {
+			return false
+		}
+		bearing := geo.InitialBearing(ct.Position, asset)
+		return AngleDifference(ct.Velocity.Heading, bearing) <= r.ToleranceDeg
+	case FactorTimeToZone:
+		for _, zp := range ct.ZoneProximity {
+			if zp.TimeToZoneSeconds != nil && inRange(*zp.TimeToZoneSeconds, r.Min, r.Max) {
+				return true
+			}
+		}
+	case FactorZoneCPA:
+		for _, zp := range ct.ZoneProximity {
+			if inRange(zp.CPAMeters, r.Min, r.Max) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DefaultRules reproduces the original hardcoded threat cascade as weighted rules,
+// with additional weight for tracks closing on protected assets or restricted zones
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "hostile-classification", Factor: FactorClassification, Weight: 50, Enabled: true,
+			Match: []string{"hostile"}, EvaluationOrder: 10},
+		{Name: "unknown-classification", Factor: FactorClassification, Weight: 20, Enabled: true,
+			Match: []string{"unknown"}, EvaluationOrder: 20},
+		{Name: "hostile-missile", Factor: FactorType, Weight: 40, Enabled: true,
+			Match: []string{"missile"}, Classifications: []string{"hostile"}, EvaluationOrder: 30},
+		{Name: "hostile-fast-aircraft", Factor: FactorSpeed, Weight: 25, Enabled: true,
+			Min: floatPtr(300.01), Classifications: []string{"hostile"}, Types: []string{"aircraft"}, EvaluationOrder: 40},
+		{Name: "unknown-fast-mover", Factor: FactorSpeed, Weight: 20, Enabled: true,
+			Min: floatPtr(200.01), Classifications: []string{"unknown"}, EvaluationOrder: 50},
+		{Name: "unknown-very-fast-mover", Factor: FactorSpeed, Weight: 25, Enabled: true,
+			Min: floatPtr(500.01), Classifications: []string{"unknown"}, EvaluationOrder: 60},
+		{Name: "low-altitude-ingress", Factor: FactorAltitude, Weight: 8, Enabled: true,
+			Max: floatPtr(150), Classifications: []string{"hostile", "unknown"}, Types: []string{"aircraft", "missile"}, EvaluationOrder: 70},
+		{Name: "zone-entry-imminent", Factor: FactorTimeToZone, Weight: 15, Enabled: true,
+			Max: floatPtr(300), Classifications: []string{"hostile", "unknown"}, EvaluationOrder: 80},
+		{Name: "zone-close-approach", Factor: FactorZoneCPA, Weight: 10, Enabled: true,
+			Max: floatPtr(5000), Classifications: []string{"hostile", "unknown"}, EvaluationOrder: 90},
+	}
+}
+
+// LoadConfigFile reads a JSON rule set from disk and validates it
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read threat rules file: %w", err)
+	}
+
+	cfg := Config{Thresholds: DefaultThresholds()}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse threat rules file: %w", err)
+	}
+
+	for _, rule := range cfg.Rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cfg, nil
+}
+
+// AngleDifference returns the absolute difference between two headings in degrees (0-180)
+func AngleDifference(a, b float64) float64 {
+	diff := math.Mod(math.Abs(a-b), 360)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff
+}
+
+func inRange(v float64, min, max *float64) bool {
+	if min != nil && v < *min {
+		return false
+	}
+	if max != nil && v > *max {
+		return false
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,183 @@
+// Package topology tracks which pipeline agents are running and how they are
+// keeping up.
+//
+// Every agent periodically publishes a Heartbeat on the HEARTBEATS stream with
+// its identity, version, health, consumer lag, and most recent error. The
+// stream keeps only the latest heartbeat of each agent, so the gateway's
+// Registry starts from the current picture and serves it at
+// /api/v1/system/agents. An agent that misses MissedHeartbeats consecutive
+// heartbeats is reported as not alive.
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HEARTBEATS stream and subject prefix; each agent publishes on
+// heartbeat.<agent_type>.<agent_id>
+const (
+	StreamName    = "HEARTBEATS"
+	SubjectPrefix = "heartbeat"
+)
+
+// DefaultInterval is how often agents publish a heartbeat
+const DefaultInterval = 10 * time.Second
+
+// MissedHeartbeats is how many intervals may pass without a heartbeat before
+// an agent is reported as not alive
+const MissedHeartbeats = 3
+
+// StatusStopped is the status of the final heartbeat an agent sends on shutdown
+const StatusStopped = "stopped"
+
+// Heartbeat is one agent's periodic report of its state
+type Heartbeat struct {
+	AgentID     string     `json:"agent_id"`
+	AgentType   string     `json:"agent_type"`
+	Version     string     `json:"version"`
+	Healthy     bool       `json:"healthy"`
+	Status      string     `json:"status"`
+	Details     string     `json:"details,omitempty"`
+	Stream      string     `json:"stream,omitempty"`   // Stream the agent consumes, if any
+	Consumer    string     `json:"consumer,omitempty"` // Durable consumer the agent fetches from
+	ConsumerLag *int       `json:"consumer_lag,omitempty"`
+	LastError   string     `json:"last_error,omitempty"` // Error type last recorded by the agent
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	SentAt      time.Time  `json:"sent_at"`
+	IntervalMs  int64      `json:"interval_ms"`
+}
+
+// Subject returns the subject an agent's heartbeats are published on
+func Subject(agentType, agentID string) string {
+	return SubjectPrefix + "." + agentType + "." + agentID
+}
+
+// ParseInterval parses a HEARTBEAT_INTERVAL setting. Empty yields
+// DefaultInterval; zero disables heartbeats.
+func ParseInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultInterval, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid HEARTBEAT_INTERVAL %q: must be a non-negative duration", value)
+	}
+	return d, nil
+}
+
+// Publish sends a heartbeat
+func Publish(ctx context.Context, js jetstream.JetStream, hb Heartbeat) error {
+	data, err := json.Marshal(hb)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heartbeat: %w", err)
+	}
+	if _, err := js.Publish(ctx, Subject(hb.AgentType, hb.AgentID), data); err != nil {
+		return fmt.Errorf("failed to publish heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Watch passes the latest heartbeat of every agent, then each new one, to
+// observe until ctx is done. Malformed heartbeats are ignored.
+func Watch(ctx context.Context, js jetstream.JetStream, observe func(Heartbeat)) error {
+	consumer, err := js.OrderedConsumer(ctx, StreamName, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{SubjectPrefix + ".>"},
+		DeliverPolicy:  jetstream.DeliverLastPerSubjectPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat consumer: %w", err)
+	}
+
+	cc, err := consumer.Consume(func(msg jetstream.Msg) {
+		var hb Heartbeat
+		if err := json.Unmarshal(msg.Data(), &hb); err != nil || hb.AgentID == "" {
+			return
+		}
+		observe(hb)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch heartbeats: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		cc.Stop()
+	}()
+	return nil
+}
+
+// AgentState is an agent's last heartbeat and whether it is still arriving
+type AgentState struct {
+	Heartbeat
+	Alive      bool    `json:"alive"`
+	AgeSeconds float64 `json:"age_seconds"` // Time since the heartbeat was sent
+}
+
+// Registry holds the latest heartbeat of every agent. It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	agents map[string]AgentState // By agent type and ID
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{agents: make(map[string]AgentState)}
+}
+
+// Observe records a heartbeat. One sent before the heartbeat already held for
+// the agent is ignored.
+func (r *Registry) Observe(hb Heartbeat) {
+	key := Subject(hb.AgentType, hb.AgentID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if prev, ok := r.agents[key]; ok && hb.SentAt.Before(prev.SentAt) {
+		return
+	}
+	r.agents[key] = AgentState{Heartbeat: hb}
+}
+
+// Agents returns every known agent as of now, ordered by type then ID. An
+// agent is alive until it announces it stopped or misses MissedHeartbeats
+// heartbeats.
+func (r *Registry) Agents(now time.Time) []AgentState {
+	r.mu.RLock()
+	agents := make([]AgentState, 0, len(r.agents))
+	for _, state := range r.agents {
+		agents = append(agents, state)
+	}
+	r.mu.RUnlock()
+
+	for i := range agents {
+		age := now.Sub(agents[i].SentAt)
+		if age < 0 {
+			age = 0 // Clock skew between hosts
+		}
+		agents[i].AgeSeconds = age.Seconds()
+		agents[i].Alive = agents[i].Status != StatusStopped && age <= MissedHeartbeats*agents[i].interval()
+	}
+
+	sort.Slice(agents, func(i, j int) bool {
+		if agents[i].AgentType != agents[j].AgentType {
+			return agents[i].AgentType < agents[j].AgentType
+		}
+		return agents[i].AgentID < agents[j].AgentID
+	})
+	return agents
+}
+
+// interval is the heartbeat period the agent announced, or the default
+func (s AgentState) interval() time.Duration {
+	if s.IntervalMs <= 0 {
+		return DefaultInterval
+	}
+	return time.Duration(s.IntervalMs) * time.Millisecond
+}
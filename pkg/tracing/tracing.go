@@ -0,0 +1,157 @@
+// Package tracing provides OpenTelemetry tracing for the CJADC2 pipeline.
+//
+// Trace context travels between agents in the message Envelope (TraceID and
+// SpanID) rather than in NATS headers, so a span started by the sensor for a
+// detection is the ancestor of every span the classifier, correlator, planner,
+// authorizer, and effector create for messages derived from it.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TracerName is the instrumentation scope for all CJADC2 spans
+const TracerName = "github.com/agile-defense/cjadc2"
+
+// Span attribute keys
+const (
+	AttrCorrelationID = attribute.Key("cjadc2.correlation_id")
+	AttrMessageID     = attribute.Key("cjadc2.message_id")
+	AttrCausationID   = attribute.Key("cjadc2.causation_id")
+	AttrSource        = attribute.Key("cjadc2.source")
+	AttrTrackID       = attribute.Key("cjadc2.track_id")
+	AttrProposalID    = attribute.Key("cjadc2.proposal_id")
+	AttrDecisionID    = attribute.Key("cjadc2.decision_id")
+)
+
+// ShutdownFunc flushes and stops the tracer provider
+type ShutdownFunc func(ctx context.Context) error
+
+// Init installs a global tracer provider that exports spans over OTLP/gRPC to
+// endpoint (host:port, an optional http:// scheme is ignored). With an empty
+// endpoint tracing stays a no-op and the returned shutdown does nothing.
+func Init(ctx context.Context, serviceName, instanceID, endpoint string) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
+
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceInstanceID(instanceID),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the CJADC2 tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartFromEnvelope starts a consumer span for processing a message. The span
+// is a child of the span recorded in the envelope, if any, and is tagged with
+// the envelope's correlation and message IDs.
+func StartFromEnvelope(ctx context.Context, name string, env messages.Envelope, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if parent := SpanContextFromEnvelope(env); parent.IsValid() {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, parent)
+	}
+
+	correlationID := env.CorrelationID
+	if correlationID == "" {
+		correlationID = env.MessageID
+	}
+	attrs = append(attrs,
+		AttrCorrelationID.String(correlationID),
+		AttrMessageID.String(env.MessageID),
+		AttrCausationID.String(env.CausationID),
+		AttrSource.String(env.Source),
+	)
+
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// SpanContextFromEnvelope rebuilds the remote span context carried in an envelope.
+// The result is invalid if the envelope carries no trace context.
+func SpanContextFromEnvelope(env messages.Envelope) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex(env.TraceID)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	spanID, err := trace.SpanIDFromHex(env.SpanID)
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+// InjectEnvelope records the active span in ctx on an outgoing envelope so the
+// next agent's span becomes its child. The envelope is returned unchanged if
+// ctx has no valid span.
+func InjectEnvelope(ctx context.Context, env messages.Envelope) messages.Envelope {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return env
+	}
+	return env.WithTracing(sc.TraceID().String(), sc.SpanID().String())
+}
+
+// TraceID returns the hex trace ID of the active span in ctx, or "" if none
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// RecordError marks the span as failed
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,332 @@
+// Package trackid assigns stable system track IDs to sensor tracks.
+//
+// Sensors number their tracks themselves and reuse those numbers: a sensor
+// that restarts or reinitializes its tracks reports a new object under an old
+// ID, and an object handed between sensors arrives under a new one. The
+// correlator resolves every sensor track ID to a system track ID, a UUID that
+// is carried as track_id on every message downstream and keys the tracks
+// table, so one object keeps one history.
+//
+// A sensor track ID keeps its system ID while it is reported continuously
+// and plausibly. It is given a new one when it reappears after more than the
+// reuse gap, or at a position the object could not have reached since it was
+// last seen. When the correlator fuses tracks, every sensor ID involved adopts
+// the oldest system ID among them; when a sensor track no longer correlates
+// with the others sharing its system ID, it splits off under a new one.
+//
+// Assignments are stored in JetStream KV, so a restarted correlator keeps
+// them, and cached in memory for the correlator that writes them. Correlators
+// sharded by geographic cell share the bucket and evict a track's cached
+// assignment when it arrives from another shard's cell.
+package trackid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/picture"
+)
+
+// Bucket is the KV bucket holding sensor track ID assignments
+const Bucket = "TRACK_IDS"
+
+// BucketTTL forgets assignments for sensor tracks not reported for a day
+const BucketTTL = 24 * time.Hour
+
+// DefaultReuseGap is how long a sensor track ID may go unreported before it
+// is treated as a new object when it reappears
+const DefaultReuseGap = 2 * time.Minute
+
+// Plausibility bounds for a reported position. An object moves at most
+// MaxSpeed since it was last seen, plus PositionSlackMeters for sensor error;
+// the slack is wide enough for a jammer's returns, which wander tens of
+// kilometers between scans.
+const (
+	MaxSpeed            = 3500.0 // m/s, beyond any simulated missile
+	PositionSlackMeters = 50000.0
+)
+
+// Reasons a sensor track ID was given a new system ID
+const (
+	ReasonNew      = "new"
+	ReasonIdle     = "idle"
+	ReasonPosition = "position"
+	ReasonSplit    = "split"
+)
+
+// Assignment maps one sensor track ID to its system track ID
+type Assignment struct {
+	SensorTrackID string            `json:"sensor_track_id"`
+	SystemTrackID string            `json:"system_track_id"`
+	AssignedAt    time.Time         `json:"assigned_at"` // When the system ID was first assigned to any sensor track
+	LastSeen      time.Time         `json:"last_seen"`
+	Position      messages.Position `json:"position"`
+}
+
+// Store is the subset of jetstream.KeyValue used by the registry
+type Store interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+}
+
+// EnsureBucket returns the assignment bucket, creating it if needed
+func EnsureBucket(ctx context.Context, js jetstream.JetStream) (jetstream.KeyValue, error) {
+	kv, err := js.KeyValue(ctx, Bucket)
+	if err == nil {
+		return kv, nil
+	}
+	if !errors.Is(err, jetstream.ErrBucketNotFound) {
+		return nil, fmt.Errorf("failed to get track ID bucket: %w", err)
+	}
+
+	kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{
+		Bucket:      Bucket,
+		Description: "Sensor track ID to system track ID assignments",
+		History:     1,
+		TTL:         BucketTTL,
+		Storage:     jetstream.FileStorage,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create track ID bucket: %w", err)
+	}
+	return kv, nil
+}
+
+// ParseReuseGap parses TRACK_ID_REUSE_GAP, using the default when unset
+func ParseReuseGap(value string) (time.Duration, error) {
+	if value == "" {
+		return DefaultReuseGap, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Second {
+		return 0, fmt.Errorf("invalid TRACK_ID_REUSE_GAP %q: must be a duration of at least 1s", value)
+	}
+	return d, nil
+}
+
+// Registry resolves sensor track IDs to system track IDs
+type Registry struct {
+	store    Store
+	reuseGap time.Duration
+
+	mu    sync.Mutex
+	cache map[string]Assignment
+
+	assigned *prometheus.CounterVec
+	adopted  prometheus.Counter
+}
+
+// New creates a registry backed by store, which may be set later with SetStore
+func New(store Store, reuseGap time.Duration) *Registry {
+	if reuseGap <= 0 {
+		reuseGap = DefaultReuseGap
+	}
+	return &Registry{
+		store:    store,
+		reuseGap: reuseGap,
+		cache:    make(map[string]Assignment),
+		assigned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "correlator_system_track_ids_assigned_total",
+			Help: "System track IDs assigned to sensor tracks, by reason (new, idle, position, split)",
+		}, []string{"reason"}),
+		adopted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "correlator_system_track_ids_adopted_total",
+			Help: "Sensor track IDs moved to another system track ID when their tracks were fused",
+		}),
+	}
+}
+
+// SetStore replaces the assignment store. It must be called before Resolve.
+func (r *Registry) SetStore(store Store) {
+	r.store = store
+}
+
+// ReuseGap returns how long a sensor track ID may go unreported and keep its system ID
+func (r *Registry) ReuseGap() time.Duration {
+	return r.reuseGap
+}
+
+// Collectors returns the registry metrics for registration
+func (r *Registry) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{r.assigned, r.adopted}
+}
+
+// Resolve returns the system track ID for a sensor track reported at pos,
+// assigning a new one when the sensor ID is unknown or has been reused. The
+// second result is the reason a new ID was assigned, or empty.
+func (r *Registry) Resolve(ctx context.Context, sensorTrackID string, pos messages.Position, now time.Time) (Assignment, string, error) {
+	current, err := r.load(ctx, sensorTrackID)
+	if err != nil {
+		return Assignment{}, "", err
+	}
+
+	reason := Reuse(current, pos, now, r.reuseGap)
+	next := Assignment{SensorTrackID: sensorTrackID, LastSeen: now, Position: pos}
+	if reason == "" {
+		next.SystemTrackID, next.AssignedAt = current.SystemTrackID, current.AssignedAt
+	} else {
+		next.SystemTrackID, next.AssignedAt = uuid.New().String(), now
+		r.assigned.WithLabelValues(reason).Inc()
+	}
+
+	if err := r.save(ctx, next); err != nil {
+		return Assignment{}, "", err
+	}
+	return next, reason, nil
+}
+
+// Adopt moves a sensor track ID to the system track of survivor, after the
+// correlator fused their tracks
+func (r *Registry) Adopt(ctx context.Context, sensorTrackID string, survivor Assignment) error {
+	current, err := r.load(ctx, sensorTrackID)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.SystemTrackID == survivor.SystemTrackID {
+		return nil
+	}
+
+	next := Assignment{
+		SensorTrackID: sensorTrackID,
+		SystemTrackID: survivor.SystemTrackID,
+		AssignedAt:    survivor.AssignedAt,
+		LastSeen:      survivor.LastSeen,
+		Position:      survivor.Position,
+	}
+	if current != nil {
+		next.LastSeen, next.Position = current.LastSeen, current.Position
+	}
+	if err := r.save(ctx, next); err != nil {
+		return err
+	}
+	r.adopted.Inc()
+	return nil
+}
+
+// Split gives a sensor track a new system ID of its own, after it stopped
+// correlating with the other sensor tracks sharing current's system ID
+func (r *Registry) Split(ctx context.Context, current Assignment, now time.Time) (Assignment, error) {
+	next := current
+	next.SystemTrackID, next.AssignedAt = uuid.New().String(), now
+	if err := r.save(ctx, next); err != nil {
+		return Assignment{}, err
+	}
+	r.assigned.WithLabelValues(ReasonSplit).Inc()
+	return next, nil
+}
+
+// Evict drops the cached assignment for a sensor track ID, so the next
+// lookup reads the store, after another correlator may have changed it
+func (r *Registry) Evict(sensorTrackID string) {
+	r.mu.Lock()
+	delete(r.cache, sensorTrackID)
+	r.mu.Unlock()
+}
+
+// Lookup returns the assignment for a sensor track ID, or nil when it has none
+func (r *Registry) Lookup(ctx context.Context, sensorTrackID string) (*Assignment, error) {
+	return r.load(ctx, sensorTrackID)
+}
+
+// Reuse reports why a sensor track reported at pos needs a new system ID
+// rather than current's, or returns empty when it keeps it
+func Reuse(current *Assignment, pos messages.Position, now time.Time, reuseGap time.Duration) string {
+	if current == nil || current.SystemTrackID == "" {
+		return ReasonNew
+	}
+	elapsed := now.Sub(current.LastSeen)
+	if elapsed > reuseGap {
+		return ReasonIdle
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if geo.Distance(current.Position, pos) > MaxSpeed*elapsed.Seconds()+PositionSlackMeters {
+		return ReasonPosition
+	}
+	return ""
+}
+
+// Survivor returns the assignment whose system ID fused tracks keep: the
+// earliest assigned, with ties broken by ID so every correlator agrees
+func Survivor(assignments ...Assignment) Assignment {
+	var best Assignment
+	for i, a := range assignments {
+		if i == 0 || a.AssignedAt.Before(best.AssignedAt) ||
+			(a.AssignedAt.Equal(best.AssignedAt) && a.SystemTrackID < best.SystemTrackID) {
+			best = a
+		}
+	}
+	return best
+}
+
+// load returns the cached or stored assignment for a sensor track ID, or nil
+func (r *Registry) load(ctx context.Context, sensorTrackID string) (*Assignment, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[sensorTrackID]
+	r.mu.Unlock()
+	if ok {
+		return &cached, nil
+	}
+
+	return Load(ctx, r.store, sensorTrackID)
+}
+
+// Load reads the stored assignment for a sensor track ID without a registry,
+// for services that follow the correlator's assignments. It returns nil when
+// the sensor track ID has none.
+func Load(ctx context.Context, store Store, sensorTrackID string) (*Assignment, error) {
+	entry, err := store.Get(ctx, picture.Key(sensorTrackID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track ID assignment %s: %w", sensorTrackID, err)
+	}
+	var a Assignment
+	if err := json.Unmarshal(entry.Value(), &a); err != nil {
+		return nil, nil // An unreadable assignment is replaced by a new one
+	}
+	return &a, nil
+}
+
+// save stores an assignment and caches it
+func (r *Registry) save(ctx context.Context, a Assignment) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal track ID assignment: %w", err)
+	}
+	if _, err := r.store.Put(ctx, picture.Key(a.SensorTrackID), data); err != nil {
+		return fmt.Errorf("failed to store track ID assignment %s: %w", a.SensorTrackID, err)
+	}
+
+	r.mu.Lock()
+	r.cache[a.SensorTrackID] = a
+	r.mu.Unlock()
+	return nil
+}
+
+// Forget drops cached assignments not seen since before cutoff. They remain
+// in the bucket until its TTL expires.
+func (r *Registry) Forget(cutoff time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id, a := range r.cache {
+		if a.LastSeen.Before(cutoff) {
+			delete(r.cache, id)
+			n++
+		}
+	}
+	return n
+}
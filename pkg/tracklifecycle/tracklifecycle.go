@@ -0,0 +1,95 @@
+// Package tracklifecycle ages out tracks that stop receiving detections.
+//
+// The correlator only publishes a track while detections keep arriving for
+// it, so a track whose target has left sensor coverage would otherwise stay
+// active in Postgres forever. The gateway sweeps the tracks table: an active
+// track not updated for StaleAfter becomes stale, and a stale track not
+// updated for DropAfter is dropped. Each transition is announced on
+// track.lifecycle.<state>. A new detection returns the track to active.
+package tracklifecycle
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lifecycle states, matching the track_state enum
+const (
+	StateActive  = "active"
+	StateStale   = "stale"
+	StateDropped = "dropped"
+)
+
+// Default timeouts. The stale timeout matches the 60 second window the
+// tracks API has always used for the active picture.
+const (
+	DefaultStaleAfter    = 60 * time.Second
+	DefaultDropAfter     = 5 * time.Minute
+	DefaultSweepInterval = 5 * time.Second
+)
+
+// Config holds the lifecycle timeouts, measured from a track's last update
+type Config struct {
+	StaleAfter time.Duration
+	DropAfter  time.Duration
+}
+
+// DefaultConfig returns the default timeouts
+func DefaultConfig() Config {
+	return Config{StaleAfter: DefaultStaleAfter, DropAfter: DefaultDropAfter}
+}
+
+// ParseConfig parses the timeouts from the environment, using the defaults
+// for unset values
+func ParseConfig(staleAfter, dropAfter string) (Config, error) {
+	cfg := DefaultConfig()
+	if staleAfter != "" {
+		d, err := time.ParseDuration(staleAfter)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid TRACK_STALE_AFTER %q: must be a positive duration", staleAfter)
+		}
+		cfg.StaleAfter = d
+	}
+	if dropAfter != "" {
+		d, err := time.ParseDuration(dropAfter)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid TRACK_DROP_AFTER %q: must be a positive duration", dropAfter)
+		}
+		cfg.DropAfter = d
+	}
+	if cfg.DropAfter <= cfg.StaleAfter {
+		return cfg, fmt.Errorf("TRACK_DROP_AFTER (%s) must be longer than TRACK_STALE_AFTER (%s)", cfg.DropAfter, cfg.StaleAfter)
+	}
+	return cfg, nil
+}
+
+// Cutoffs returns the last update times before which, at now, active tracks
+// become stale and stale tracks are dropped. No detections arrive while the
+// simulation is paused, so tracks get their full timeout again from
+// resumedAt, the end of the most recent pause. A zero cutoff means no track
+// can make that transition yet.
+func (c Config) Cutoffs(now, resumedAt time.Time) (stale, drop time.Time) {
+	return cutoff(now, resumedAt, c.StaleAfter), cutoff(now, resumedAt, c.DropAfter)
+}
+
+// cutoff returns now less after, or zero if the simulation resumed since then
+func cutoff(now, resumedAt time.Time, after time.Duration) time.Time {
+	c := now.Add(-after)
+	if !resumedAt.Before(c) {
+		return time.Time{}
+	}
+	return c
+}
+
+// ValidStates lists the states a track can be filtered by
+var ValidStates = []string{StateActive, StateStale, StateDropped, "lost", "merged", "neutralized"}
+
+// IsValidState reports whether state is a known track state
+func IsValidState(state string) bool {
+	for _, s := range ValidStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
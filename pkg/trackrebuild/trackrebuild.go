@@ -0,0 +1,313 @@
+// Package trackrebuild reconstructs the tracks table by replaying streams.
+//
+// The tracks table is a projection of the correlated track messages on the
+// TRACKS stream, with neutralized tracks taken from the ASSESSMENTS stream. A
+// rebuild folds every retained message into the latest state of each track and
+// writes those states back, so it can recover the table after an accidental
+// clear or corruption. Rows are overwritten rather than incremented, so a
+// rebuild can be repeated safely.
+package trackrebuild
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/agile-defense/cjadc2/pkg/bda"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// TracksSubject selects the correlated track messages the tracks table is built from
+const TracksSubject = "track.correlated.>"
+
+// Replay settings
+const (
+	ReplayBatchSize = 500
+	ReplayFetchWait = 2 * time.Second
+)
+
+// Progress reports how far a replay has got through one stream
+type Progress struct {
+	Stream string `json:"stream"`
+	Done   uint64 `json:"done"`
+	Total  uint64 `json:"total"` // Messages replayed plus those still pending
+}
+
+// Replay passes the data of every message on stream matching subject to fn, in
+// stream order, and reports progress after each batch. Protobuf pipeline
+// messages are passed as JSON. It stops at the end of
+// the stream as it was when the replay began, so live traffic cannot keep it
+// running. A stream that does not exist replays nothing. Returns the number of
+// messages replayed.
+func Replay(ctx context.Context, js jetstream.JetStream, stream, subject string, fn func(data []byte) error, progress func(Progress)) (uint64, error) {
+	s, err := js.Stream(ctx, stream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream %s: %w", stream, err)
+	}
+	info, err := s.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream info for %s: %w", stream, err)
+	}
+	lastSeq := info.State.LastSeq
+	if info.State.Msgs == 0 {
+		return 0, nil
+	}
+
+	consumer, err := js.OrderedConsumer(ctx, stream, jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{subject},
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create replay consumer on %s: %w", stream, err)
+	}
+
+	var done, pending uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+
+		batch, err := consumer.Fetch(ReplayBatchSize, jetstream.FetchMaxWait(ReplayFetchWait))
+		if err != nil {
+			return done, fmt.Errorf("failed to fetch from %s: %w", stream, err)
+		}
+
+		received := 0
+		finished := false
+		for msg := range batch.Messages() {
+			received++
+			meta, err := msg.Metadata()
+			if err != nil {
+				return done, fmt.Errorf("failed to read message metadata on %s: %w", stream, err)
+			}
+			if meta.Sequence.Stream > lastSeq {
+				finished = true
+				continue // Published after the replay began
+			}
+			data, err := messages.ToJSON(messages.SchemaForSubject(msg.Subject()), msg.Headers().Get(messages.ContentTypeHeader), msg.Data())
+			if err != nil {
+				return done, fmt.Errorf("failed to decode message %d on %s: %w", meta.Sequence.Stream, stream, err)
+			}
+			if err := fn(data); err != nil {
+				return done, err
+			}
+			done++
+			pending = meta.NumPending
+			if pending == 0 || meta.Sequence.Stream == lastSeq {
+				finished = true
+			}
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, jetstream.ErrNoMessages) {
+			return done, fmt.Errorf("failed to replay %s: %w", stream, err)
+		}
+
+		if progress != nil {
+			progress(Progress{Stream: stream, Done: done, Total: done + pending})
+		}
+		if finished || received == 0 {
+			return done, nil
+		}
+	}
+}
+
+// Builder folds correlated track messages and assessments into track rows.
+// Messages may arrive in any order and from overlapping streams; each message
+// counts once.
+type Builder struct {
+	tracks      map[string]*trackFold
+	seen        map[string]bool // Message IDs already folded
+	neutralized map[string]bool
+	messages    int
+	duplicates  int
+}
+
+type trackFold struct {
+	latest    *messages.CorrelatedTrack
+	earliest  *messages.CorrelatedTrack
+	updates   int
+	sensorIDs map[string]bool
+}
+
+// NewBuilder creates an empty builder
+func NewBuilder() *Builder {
+	return &Builder{
+		tracks:      make(map[string]*trackFold),
+		seen:        make(map[string]bool),
+		neutralized: make(map[string]bool),
+	}
+}
+
+// Apply folds one correlated track message, reporting false if it was already folded
+func (b *Builder) Apply(track *messages.CorrelatedTrack) bool {
+	if id := track.Envelope.MessageID; id != "" {
+		if b.seen[id] {
+			b.duplicates++
+			return false
+		}
+		b.seen[id] = true
+	}
+	b.messages++
+
+	fold, ok := b.tracks[track.TrackID]
+	if !ok {
+		fold = &trackFold{latest: track, earliest: track, sensorIDs: make(map[string]bool)}
+		b.tracks[track.TrackID] = fold
+	}
+	for _, id := range postgres.SensorTrackIDs(track) {
+		fold.sensorIDs[id] = true
+	}
+	fold.updates++
+	if !ok {
+		return true
+	}
+	if !track.LastUpdated.Before(fold.latest.LastUpdated) {
+		fold.latest = track
+	}
+	if track.LastUpdated.Before(fold.earliest.LastUpdated) {
+		fold.earliest = track
+	}
+	return true
+}
+
+// ApplyData decodes and folds a correlated track message
+func (b *Builder) ApplyData(data []byte) error {
+	var track messages.CorrelatedTrack
+	if err := messages.Unmarshal(messages.ContentTypeJSON, data, &track); err != nil {
+		return fmt.Errorf("failed to unmarshal correlated track: %w", err)
+	}
+	if track.TrackID == "" {
+		return nil
+	}
+	b.Apply(&track)
+	return nil
+}
+
+// ApplyAssessmentData decodes an assessment and marks its track neutralized if it was
+func (b *Builder) ApplyAssessmentData(data []byte) error {
+	var assessment messages.EffectAssessment
+	if err := json.Unmarshal(data, &assessment); err != nil {
+		return fmt.Errorf("failed to unmarshal effect assessment: %w", err)
+	}
+	if assessment.Neutralized && assessment.TrackID != "" {
+		b.neutralized[assessment.TrackID] = true
+	}
+	return nil
+}
+
+// Tracks returns the rebuilt tracks ordered by track ID. The detection count
+// and first seen time match what the live upsert would have recorded for the
+// same messages.
+func (b *Builder) Tracks() []postgres.RebuiltTrack {
+	tracks := make([]postgres.RebuiltTrack, 0, len(b.tracks))
+	for id, fold := range b.tracks {
+		firstSeen := fold.earliest.WindowStart
+		if fold.earliest.LastUpdated.Before(firstSeen) {
+			firstSeen = fold.earliest.LastUpdated
+		}
+		sensorIDs := make([]string, 0, len(fold.sensorIDs))
+		for sensorID := range fold.sensorIDs {
+			sensorIDs = append(sensorIDs, sensorID)
+		}
+		sort.Strings(sensorIDs)
+		tracks = append(tracks, postgres.RebuiltTrack{
+			Track:          fold.latest,
+			FirstSeen:      firstSeen,
+			DetectionCount: fold.earliest.DetectionCount + fold.updates - 1,
+			Neutralized:    b.neutralized[id],
+			SensorTrackIDs: sensorIDs,
+		})
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].Track.TrackID < tracks[j].Track.TrackID
+	})
+	return tracks
+}
+
+// Messages returns the number of distinct messages folded
+func (b *Builder) Messages() int {
+	return b.messages
+}
+
+// Duplicates returns the number of messages skipped because they were already folded
+func (b *Builder) Duplicates() int {
+	return b.duplicates
+}
+
+// Options selects what a rebuild replays and writes
+type Options struct {
+	Streams []string // Streams holding correlated tracks, e.g. TRACKS and an archive mirror of it
+	Reset   bool     // Delete every existing row before writing
+	DryRun  bool     // Replay and compare without writing
+}
+
+// Report summarizes a rebuild and its consistency check
+type Report struct {
+	Replayed    map[string]uint64 `json:"replayed"` // Messages replayed per stream
+	Duplicates  int               `json:"duplicates"`
+	Tracks      int               `json:"tracks"` // Tracks rebuilt
+	Neutralized int               `json:"neutralized"`
+	RowsBefore  int64             `json:"rows_before"`
+	RowsWritten int64             `json:"rows_written"`
+	RowsAfter   int64             `json:"rows_after"`
+	Consistent  bool              `json:"consistent"` // The table holds exactly the rebuilt tracks
+	DryRun      bool              `json:"dry_run"`
+	Duration    time.Duration     `json:"duration"`
+}
+
+// Rebuild replays the track streams and the ASSESSMENTS stream, writes the
+// rebuilt tracks, and compares the table's row count with the number of
+// rebuilt tracks. A dry run compares against the rows already present.
+func Rebuild(ctx context.Context, js jetstream.JetStream, db *postgres.Pool, opts Options, progress func(Progress)) (*Report, error) {
+	start := time.Now()
+	report := &Report{Replayed: make(map[string]uint64), DryRun: opts.DryRun}
+
+	var err error
+	if report.RowsBefore, err = db.CountTracks(ctx); err != nil {
+		return nil, err
+	}
+
+	builder := NewBuilder()
+	for _, stream := range opts.Streams {
+		n, err := Replay(ctx, js, stream, TracksSubject, builder.ApplyData, progress)
+		if err != nil {
+			return nil, err
+		}
+		report.Replayed[stream] = n
+	}
+	n, err := Replay(ctx, js, bda.StreamName, bda.Neutralized, builder.ApplyAssessmentData, progress)
+	if err != nil {
+		return nil, err
+	}
+	report.Replayed[bda.StreamName] = n
+
+	tracks := builder.Tracks()
+	report.Duplicates = builder.Duplicates()
+	report.Tracks = len(tracks)
+	for _, t := range tracks {
+		if t.Neutralized {
+			report.Neutralized++
+		}
+	}
+
+	report.RowsAfter = report.RowsBefore
+	if !opts.DryRun {
+		if report.RowsWritten, err = db.WriteRebuiltTracks(ctx, tracks, opts.Reset); err != nil {
+			return nil, err
+		}
+		if report.RowsAfter, err = db.CountTracks(ctx); err != nil {
+			return nil, err
+		}
+	}
+	report.Consistent = report.RowsAfter == int64(report.Tracks)
+	report.Duration = time.Since(start)
+	return report, nil
+}
@@ -0,0 +1,156 @@
+// Package trackshard splits track correlation across correlator instances by
+// geographic cell.
+//
+// With GEOCELL_PRECISION set, the classifier appends the geohash cell of each
+// track's position to its subject, track.classified.<classification>.<cell>.
+// Each correlator shard consumes only the cells it owns. A track within the
+// handoff margin of a neighbouring cell is also published to that cell as
+// track.handoff.<classification>.<cell>, so the shard owning the neighbour
+// holds it as a merge candidate and reports across the border still fuse. Only the shard owning a
+// track's home cell reports it, and system track IDs are shared between
+// shards, so a track crossing a border keeps its identity.
+package trackshard
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// DefaultMarginMeters is how close to a neighbouring cell a track must be to
+// be handed off to it: twice the correlator's default merge distance
+const DefaultMarginMeters = 1000.0
+
+// Config is how the classifier stamps cells onto classified tracks
+type Config struct {
+	Precision    int     // Geohash characters in a cell; zero disables stamping
+	MarginMeters float64 // Distance from a neighbouring cell within which tracks are handed off
+}
+
+// ParseConfig parses GEOCELL_PRECISION and GEOCELL_MARGIN_METERS. Empty
+// precision disables sharding; empty margin yields DefaultMarginMeters.
+func ParseConfig(precision, margin string) (Config, error) {
+	cfg := Config{MarginMeters: DefaultMarginMeters}
+	if precision != "" {
+		p, err := strconv.Atoi(precision)
+		if err != nil || p < 0 || p > geo.MaxGeohashPrecision {
+			return Config{}, fmt.Errorf("invalid GEOCELL_PRECISION %q: must be 0 to %d", precision, geo.MaxGeohashPrecision)
+		}
+		cfg.Precision = p
+	}
+	if margin != "" {
+		m, err := strconv.ParseFloat(margin, 64)
+		if err != nil || m < 0 {
+			return Config{}, fmt.Errorf("invalid GEOCELL_MARGIN_METERS %q: must be a non-negative number", margin)
+		}
+		cfg.MarginMeters = m
+	}
+	return cfg, nil
+}
+
+// Enabled reports whether tracks are stamped with cells
+func (c Config) Enabled() bool {
+	return c.Precision > 0
+}
+
+// Cells returns the cell a position lies in and the neighbouring cells within
+// the handoff margin of it, in order
+func (c Config) Cells(p messages.Position) (home string, border []string) {
+	home = geo.Geohash(p.Lat, p.Lon, c.Precision)
+	if c.MarginMeters <= 0 {
+		return home, nil
+	}
+	for _, cell := range geo.GeohashNeighbors(home) {
+		if geo.DistanceToBox(p, geo.GeohashBox(cell)) <= c.MarginMeters {
+			border = append(border, cell)
+		}
+	}
+	sort.Strings(border)
+	return home, border
+}
+
+// Subject returns the subject of a classified track stamped with its cell
+func Subject(t *messages.Track, cell string) string {
+	return t.Subject() + "." + cell
+}
+
+// HandoffSubject returns the subject of a border copy of a classified track
+// handed off to a neighbouring cell
+func HandoffSubject(t *messages.Track, cell string) string {
+	return messages.TrackHandoffPrefix + t.Classification + "." + cell
+}
+
+// IsHandoff reports whether a subject carries a border copy of a track
+func IsHandoff(subject string) bool {
+	return strings.HasPrefix(subject, messages.TrackHandoffPrefix)
+}
+
+// CellOf returns the cell stamped on a classified or handed off track's
+// subject, or "" for an unstamped one
+func CellOf(subject string) string {
+	tokens := strings.Split(subject, ".")
+	if len(tokens) != 4 || tokens[0] != "track" || (tokens[1] != "classified" && tokens[1] != "handoff") {
+		return ""
+	}
+	return tokens[3]
+}
+
+// Shard is the set of cells one correlator owns
+type Shard struct {
+	Name  string
+	Cells []string
+	owned map[string]bool
+}
+
+// ParseShard parses CORRELATOR_SHARD and CORRELATOR_CELLS, a comma-separated
+// list of geohash cells of the classifier's GEOCELL_PRECISION. Without cells
+// the correlator is not sharded and nil is returned.
+func ParseShard(name, cells string) (*Shard, error) {
+	if strings.TrimSpace(cells) == "" {
+		if name != "" {
+			return nil, fmt.Errorf("CORRELATOR_SHARD %q needs CORRELATOR_CELLS", name)
+		}
+		return nil, nil
+	}
+	s := &Shard{Name: strings.TrimSpace(name), owned: make(map[string]bool)}
+	if s.Name == "" {
+		return nil, fmt.Errorf("CORRELATOR_CELLS needs a CORRELATOR_SHARD name")
+	}
+	if strings.ContainsAny(s.Name, ".*> ") {
+		return nil, fmt.Errorf("invalid CORRELATOR_SHARD %q: must not contain '.', '*', '>' or spaces", s.Name)
+	}
+	for _, cell := range strings.Split(cells, ",") {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		if cell == "" || s.owned[cell] {
+			continue
+		}
+		if err := geo.ValidateGeohash(cell); err != nil {
+			return nil, fmt.Errorf("invalid CORRELATOR_CELLS: %w", err)
+		}
+		if len(s.Cells) > 0 && len(cell) != len(s.Cells[0]) {
+			return nil, fmt.Errorf("invalid CORRELATOR_CELLS: cells %q and %q differ in precision", s.Cells[0], cell)
+		}
+		s.Cells = append(s.Cells, cell)
+		s.owned[cell] = true
+	}
+	sort.Strings(s.Cells)
+	return s, nil
+}
+
+// Owns reports whether the shard owns cell. Every cell belongs to an
+// unsharded correlator, a nil Shard.
+func (s *Shard) Owns(cell string) bool {
+	return s == nil || s.owned[cell]
+}
+
+// OwnsPosition reports whether the shard owns the cell a position lies in
+func (s *Shard) OwnsPosition(p messages.Position) bool {
+	if s == nil {
+		return true
+	}
+	return s.owned[geo.Geohash(p.Lat, p.Lon, len(s.Cells[0]))]
+}
@@ -0,0 +1,179 @@
+// Package twoperson enforces the two-person integrity rule on kinetic actions.
+//
+// An engage proposal at or above the configured priority is only approved
+// once two distinct operators, each with authority for the action, have
+// approved it. Every approval is recorded in proposal_approvals with the role
+// that authorized it; the first leaves the proposal pending, and the second
+// completes it so the decision can be published carrying both approvals. Both
+// must approve the same course of action. A denial needs only one operator
+// and is final.
+package twoperson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// RequiredApprovals is the number of distinct approvers the rule demands
+const RequiredApprovals = 2
+
+// DefaultMinPriority is the lowest engage priority the rule applies to
+const DefaultMinPriority = 8
+
+// ActionType is the action the rule applies to
+const ActionType = "engage"
+
+// Errors returned by Record
+var (
+	ErrAlreadyApproved = errors.New("operator has already approved this proposal; a second operator must approve")
+	ErrNotPending      = errors.New("proposal is not pending")
+	ErrOptionMismatch  = errors.New("approval selects a different course of action than the first approval")
+)
+
+// StatusAwaitingApproval describes a proposal holding fewer approvals than required
+const StatusAwaitingApproval = "awaiting_second_approval"
+
+// Rule decides which proposals need two approvers
+type Rule struct {
+	MinPriority int // Engage proposals at or above this priority need two approvers; zero disables
+}
+
+// ParseRule parses TWO_PERSON_MIN_PRIORITY, using DefaultMinPriority when unset
+func ParseRule(value string) (Rule, error) {
+	if value == "" {
+		return Rule{MinPriority: DefaultMinPriority}, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return Rule{}, fmt.Errorf("invalid TWO_PERSON_MIN_PRIORITY %q: must be a non-negative integer", value)
+	}
+	return Rule{MinPriority: n}, nil
+}
+
+// Applies reports whether a proposal needs two approvers
+func (r Rule) Applies(actionType string, priority int) bool {
+	return r.MinPriority > 0 && actionType == ActionType && priority >= r.MinPriority
+}
+
+// Complete reports whether approvals come from enough distinct operators
+func Complete(approvals []messages.Approval) bool {
+	seen := make(map[string]bool)
+	for _, a := range approvals {
+		seen[a.ApprovedBy] = true
+	}
+	return len(seen) >= RequiredApprovals
+}
+
+// SameOption checks an approval selects the course of action other
+// operators' approvals did, so the two approvers agree on what is approved
+func SameOption(approvals []messages.Approval, approval messages.Approval) error {
+	for _, a := range approvals {
+		if a.ApprovedBy != approval.ApprovedBy && a.Option != approval.Option {
+			return fmt.Errorf("%w (%s)", ErrOptionMismatch, a.Option)
+		}
+	}
+	return nil
+}
+
+// Querier is the subset of pgx used to load approvals
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// Approvals returns the approvals recorded for a proposal, oldest first
+func Approvals(ctx context.Context, q Querier, proposalID string) ([]messages.Approval, error) {
+	rows, err := q.Query(ctx, `
+		SELECT approved_by, role, approved_at, COALESCE(reason, ''), COALESCE(break_glass_grant_id::text, ''),
+			COALESCE(option, '')
+		FROM proposal_approvals
+		WHERE proposal_id = $1
+		ORDER BY approved_at ASC
+	`, proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := []messages.Approval{}
+	for rows.Next() {
+		var a messages.Approval
+		if err := rows.Scan(&a.ApprovedBy, &a.Role, &a.ApprovedAt, &a.Reason, &a.BreakGlassGrantID, &a.Option); err != nil {
+			return nil, fmt.Errorf("failed to scan approval: %w", err)
+		}
+		approvals = append(approvals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// Beginner starts transactions; satisfied by pools and connections
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Record stores one approval and returns every approval of the proposal. The
+// proposal row is locked while recording but left pending even once the
+// approvals are complete; the caller settles it when it stores the decision,
+// whose conditional update lets exactly one completing caller publish.
+func Record(ctx context.Context, db Beginner, proposalID string, approval messages.Approval) ([]messages.Approval, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM proposals WHERE proposal_id = $1 FOR UPDATE`, proposalID).Scan(&status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock proposal: %w", err)
+	}
+	if status != "pending" {
+		return nil, fmt.Errorf("%w: %s", ErrNotPending, status)
+	}
+
+	var grantID *string
+	if approval.BreakGlassGrantID != "" {
+		grantID = &approval.BreakGlassGrantID
+	}
+	if approval.ApprovedAt.IsZero() {
+		approval.ApprovedAt = time.Now().UTC()
+	}
+
+	existing, err := Approvals(ctx, tx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := SameOption(existing, approval); err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO proposal_approvals (proposal_id, approved_by, role, break_glass_grant_id, reason, approved_at, option)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''))
+		ON CONFLICT (proposal_id, approved_by) DO NOTHING
+	`, proposalID, approval.ApprovedBy, approval.Role, grantID, approval.Reason, approval.ApprovedAt, approval.Option)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record approval: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrAlreadyApproved
+	}
+
+	approvals, err := Approvals(ctx, tx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit approval: %w", err)
+	}
+	return approvals, nil
+}
@@ -0,0 +1,84 @@
+# Approval Authority Policy
+# Decides whether an operator may approve an action, either through their
+# assigned role or through a time-boxed break-glass grant
+
+package cjadc2.authority
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.roles
+
+# Default: no authority, no grant used
+default allow := false
+default break_glass_grant := ""
+
+# Operator's assigned role, falling back to the default role
+role := roles.assignments[input.user_id]
+
+role := roles.default_role if {
+    not roles.assignments[input.user_id]
+}
+
+# The operator's own role covers the action
+role_authorizes if {
+    input.action_type in roles.approval_authority[role]
+}
+
+# A grant is usable if it belongs to the approver, passed the second factor,
+# is neither revoked nor expired, and elevates to a role covering the action
+valid_grant(grant) if {
+    grant.user_id == input.user_id
+    grant.status == "active"
+    grant.second_factor_verified == true
+    object.get(grant, "revoked_at", null) == null
+    time.parse_rfc3339_ns(grant.expires_at) > time.now_ns()
+    grant.role in roles.break_glass_roles
+    input.action_type in roles.approval_authority[grant.role]
+}
+
+usable_grants contains grant.grant_id if {
+    some grant in input.break_glass
+    valid_grant(grant)
+}
+
+allow if role_authorizes
+
+allow if {
+    not role_authorizes
+    count(usable_grants) > 0
+}
+
+# The grant relied on when the role alone is not enough
+break_glass_grant := min(usable_grants) if {
+    not role_authorizes
+    count(usable_grants) > 0
+}
+
+# Denial reasons for explainability
+deny contains msg if {
+    not role_authorizes
+    count(usable_grants) == 0
+    msg := sprintf("Role '%s' of '%s' cannot approve '%s' and no valid break-glass grant is active",
+                   [role, input.user_id, input.action_type])
+}
+
+deny contains msg if {
+    not role_authorizes
+    some grant in input.break_glass
+    grant.user_id == input.user_id
+    time.parse_rfc3339_ns(grant.expires_at) <= time.now_ns()
+    msg := sprintf("Break-glass grant %s expired at %s", [grant.grant_id, grant.expires_at])
+}
+
+# Decision metadata for audit trail
+decision := {
+    "allowed": allow,
+    "role": role,
+    "break_glass": break_glass_grant != "",
+    "break_glass_grant": break_glass_grant,
+    "reasons": deny,
+    "action_type": input.action_type,
+    "user_id": input.user_id
+}
@@ -0,0 +1,65 @@
+# Auto-Approval Policy
+# Decides whether the authorizer may approve a proposal on behalf of an
+# auto_approve intervention rule, without an operator
+
+package cjadc2.auto_approve
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.auto_approve_actions
+import data.human_approval_required
+
+# Default: proposals wait for an operator
+default allow := false
+
+# Only non-kinetic actions listed for auto-approval, at or below their priority ceiling
+action_eligible if {
+    not input.action_type in human_approval_required
+    limits := auto_approve_actions[input.action_type]
+    input.priority <= limits.max_priority
+}
+
+allow if {
+    input.rule_id != ""
+    action_eligible
+    input.threat_level != "critical"
+}
+
+# Denial reasons for explainability
+deny contains msg if {
+    input.action_type in human_approval_required
+    msg := sprintf("Action type '%s' always requires human approval", [input.action_type])
+}
+
+deny contains msg if {
+    not input.action_type in human_approval_required
+    not auto_approve_actions[input.action_type]
+    msg := sprintf("Action type '%s' is not eligible for auto-approval", [input.action_type])
+}
+
+deny contains msg if {
+    limits := auto_approve_actions[input.action_type]
+    input.priority > limits.max_priority
+    msg := sprintf("Priority %d exceeds the auto-approval ceiling of %d for '%s'",
+                   [input.priority, limits.max_priority, input.action_type])
+}
+
+deny contains msg if {
+    input.threat_level == "critical"
+    msg := "Critical threat level requires human verification"
+}
+
+deny contains msg if {
+    input.rule_id == ""
+    msg := "Auto-approval must be attributed to an intervention rule"
+}
+
+# Decision metadata for audit trail
+decision := {
+    "allowed": allow,
+    "reasons": deny,
+    "action_type": input.action_type,
+    "rule_id": input.rule_id
+}
@@ -27,9 +27,19 @@ allow if {
     valid_signature
 }
 
-# Signature validation (simplified for MVP - checks presence)
+# Signature validation (simplified for MVP - checks the envelope names the
+# key it is signed with; agents verify the signature itself)
 valid_signature if {
-    input.envelope.signature != ""
+    not missing_signing_key
+}
+
+# Unsigned messages carry no signing_key_id, or an empty one
+missing_signing_key if {
+    not input.envelope.signing_key_id
+}
+
+missing_signing_key if {
+    input.envelope.signing_key_id == ""
 }
 
 # Also allow if signature validation is disabled (for local dev)
@@ -51,9 +61,9 @@ deny[msg] if {
 }
 
 deny[msg] if {
-    input.envelope.signature == ""
+    missing_signing_key
     input.skip_signature_check != true
-    msg := "Missing message signature"
+    msg := "Missing message signing key"
 }
 
 # Metadata for decision explanation
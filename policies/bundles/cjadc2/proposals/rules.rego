@@ -27,6 +27,7 @@ allow if {
     valid_rationale
     valid_track_reference
     not conflicting_proposal
+    not asset_conflict
 }
 
 # Validate action type
@@ -57,6 +58,14 @@ conflicting_proposal if {
     input.pending_proposals[_].action_type == input.proposal.action_type
 }
 
+# Engagements of other tracks may not task the same asset
+asset_conflict if {
+    some pending in input.pending_proposals
+    pending.track_id != input.proposal.track_id
+    pending.asset_id != ""
+    pending.asset_id == input.proposal.asset.asset_id
+}
+
 # Denial reasons
 deny[msg] if {
     not valid_action_type
@@ -90,6 +99,15 @@ deny[msg] if {
                    [input.proposal.track_id, input.proposal.action_type])
 }
 
+deny[msg] if {
+    some pending in input.pending_proposals
+    pending.track_id != input.proposal.track_id
+    pending.asset_id != ""
+    pending.asset_id == input.proposal.asset.asset_id
+    msg := sprintf("Asset '%s' is already tasked by %s proposal '%s' for track '%s'",
+                   [pending.asset_id, pending.status, pending.proposal_id, pending.track_id])
+}
+
 # Warnings (don't block, but note in decision)
 warnings[msg] if {
     threat_priority_map[input.track.threat_level] > input.proposal.priority
@@ -104,6 +122,13 @@ warnings[msg] if {
                    [input.track.classification])
 }
 
+warnings[msg] if {
+    some conflict in input.proposal.conflicts
+    conflict.kind == "airspace"
+    msg := sprintf("Engagement airspace overlaps %s proposal '%s' for track '%s' (%.1f km apart)",
+                   [conflict.action_type, conflict.proposal_id, conflict.track_id, conflict.distance_meters / 1000])
+}
+
 # Decision metadata
 decision := {
     "allowed": allow,
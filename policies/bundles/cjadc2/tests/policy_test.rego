@@ -7,6 +7,8 @@ import data.cjadc2.origin
 import data.cjadc2.data_handling
 import data.cjadc2.proposals
 import data.cjadc2.effects
+import data.cjadc2.authority
+import data.cjadc2.auto_approve
 
 import future.keywords.if
 import future.keywords.in
@@ -21,7 +23,7 @@ test_origin_valid_sensor if {
         "envelope": {
             "source": "sensor-001",
             "source_type": "sensor",
-            "signature": "valid-signature"
+            "signing_key_id": "valid-signature"
         },
         "skip_signature_check": false
     }
@@ -33,7 +35,7 @@ test_origin_valid_sensor_wildcard if {
         "envelope": {
             "source": "sensor-radar-coastal-001",
             "source_type": "sensor",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -45,7 +47,7 @@ test_origin_valid_classifier if {
         "envelope": {
             "source": "classifier-prod-001",
             "source_type": "classifier",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -57,7 +59,7 @@ test_origin_valid_correlator if {
         "envelope": {
             "source": "correlator-main",
             "source_type": "correlator",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -69,7 +71,7 @@ test_origin_valid_planner if {
         "envelope": {
             "source": "planner-tactical",
             "source_type": "planner",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -81,7 +83,7 @@ test_origin_valid_authorizer if {
         "envelope": {
             "source": "authorizer-human",
             "source_type": "authorizer",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -93,7 +95,7 @@ test_origin_valid_effector if {
         "envelope": {
             "source": "effector-weapons-system",
             "source_type": "effector",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -105,7 +107,7 @@ test_origin_valid_api if {
         "envelope": {
             "source": "api-gateway",
             "source_type": "api",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -117,7 +119,7 @@ test_origin_invalid_source_type if {
         "envelope": {
             "source": "unknown-001",
             "source_type": "unknown",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -129,7 +131,7 @@ test_origin_source_pattern_mismatch if {
         "envelope": {
             "source": "invalid-source-001",
             "source_type": "sensor",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -141,7 +143,7 @@ test_origin_missing_signature if {
         "envelope": {
             "source": "sensor-001",
             "source_type": "sensor",
-            "signature": ""
+            "signing_key_id": ""
         },
         "skip_signature_check": false
     }
@@ -153,7 +155,7 @@ test_origin_skip_signature_check if {
         "envelope": {
             "source": "sensor-001",
             "source_type": "sensor",
-            "signature": ""
+            "signing_key_id": ""
         },
         "skip_signature_check": true
     }
@@ -165,7 +167,7 @@ test_origin_deny_unknown_source_type if {
         "envelope": {
             "source": "unknown-001",
             "source_type": "unknown",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -177,7 +179,7 @@ test_origin_deny_pattern_mismatch if {
         "envelope": {
             "source": "invalid-pattern",
             "source_type": "sensor",
-            "signature": "sig"
+            "signing_key_id": "sig"
         },
         "skip_signature_check": false
     }
@@ -189,7 +191,7 @@ test_origin_deny_missing_signature if {
         "envelope": {
             "source": "sensor-001",
             "source_type": "sensor",
-            "signature": ""
+            "signing_key_id": ""
         },
         "skip_signature_check": false
     }
@@ -576,6 +578,81 @@ test_proposals_warning_engage_non_hostile if {
     }
 }
 
+# Test engagement tasking an asset another track's engagement holds
+test_proposals_asset_conflict if {
+    not proposals.allow with input as {
+        "proposal": {
+            "action_type": "intercept",
+            "priority": 8,
+            "rationale": "This is a valid rationale with sufficient length",
+            "track_id": "track-001",
+            "asset": {"asset_id": "asset-1", "name": "Interceptor Flight Alpha", "kind": "interceptor"}
+        },
+        "track_exists": true,
+        "pending_proposals": [
+            {
+                "proposal_id": "proposal-2",
+                "track_id": "track-002",
+                "action_type": "engage",
+                "asset_id": "asset-1",
+                "status": "pending"
+            }
+        ],
+        "track": {}
+    }
+}
+
+# Test engagements with different assets do not conflict
+test_proposals_different_assets_allowed if {
+    proposals.allow with input as {
+        "proposal": {
+            "action_type": "intercept",
+            "priority": 8,
+            "rationale": "This is a valid rationale with sufficient length",
+            "track_id": "track-001",
+            "asset": {"asset_id": "asset-1", "name": "Interceptor Flight Alpha", "kind": "interceptor"}
+        },
+        "track_exists": true,
+        "pending_proposals": [
+            {
+                "proposal_id": "proposal-2",
+                "track_id": "track-002",
+                "action_type": "engage",
+                "asset_id": "asset-2",
+                "status": "pending"
+            }
+        ],
+        "track": {}
+    }
+}
+
+# Test warning for overlapping engagement airspace
+test_proposals_warning_airspace_conflict if {
+    count(proposals.warnings) > 0 with input as {
+        "proposal": {
+            "action_type": "intercept",
+            "priority": 8,
+            "rationale": "This is a valid rationale with sufficient length",
+            "track_id": "track-001",
+            "conflicts": [
+                {
+                    "proposal_id": "proposal-2",
+                    "track_id": "track-002",
+                    "action_type": "engage",
+                    "kind": "airspace",
+                    "distance_meters": 4200
+                }
+            ]
+        },
+        "track_exists": true,
+        "pending_proposals": [],
+        "track": {
+            "threat_level": "high",
+            "classification": "hostile"
+        }
+    }
+}
+
 #############################
 # Effect Release Tests
 #############################
@@ -843,3 +920,169 @@ test_effects_approval_reasons_high_priority if {
     }
     with data.cjadc2.human_approval_required as []
 }
+
+#############################
+# Approval Authority Tests
+#############################
+
+# A live, second-factor-verified grant to commander authority
+valid_grant := {
+    "grant_id": "grant-001",
+    "user_id": "watch-officer-1",
+    "role": "commander",
+    "status": "active",
+    "second_factor_verified": true,
+    "expires_at": "2099-12-31T23:59:59Z"
+}
+
+# Test commander can approve engage by role
+test_authority_commander_engage if {
+    authority.allow with input as {
+        "user_id": "commander",
+        "action_type": "engage",
+        "break_glass": []
+    }
+}
+
+# Test role approval does not consume a break-glass grant
+test_authority_role_does_not_use_grant if {
+    authority.break_glass_grant == "" with input as {
+        "user_id": "commander",
+        "action_type": "engage",
+        "break_glass": [object.union(valid_grant, {"user_id": "commander"})]
+    }
+}
+
+# Test watch officer cannot approve engage without a grant
+test_authority_watch_officer_engage_denied if {
+    not authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": []
+    }
+}
+
+# Test unassigned users fall back to the default role
+test_authority_default_role if {
+    authority.role == "watch_officer" with input as {
+        "user_id": "unknown-user",
+        "action_type": "monitor",
+        "break_glass": []
+    }
+}
+
+# Test watch officer can approve engage with a valid break-glass grant
+test_authority_break_glass_engage if {
+    authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [valid_grant]
+    }
+}
+
+# Test the grant used is reported for the audit trail
+test_authority_break_glass_grant_reported if {
+    authority.decision.break_glass_grant == "grant-001" with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [valid_grant]
+    }
+}
+
+# Test expired grants confer no authority
+test_authority_expired_grant_denied if {
+    not authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [object.union(valid_grant, {"expires_at": "2000-01-01T00:00:00Z"})]
+    }
+}
+
+# Test grants without a verified second factor confer no authority
+test_authority_unverified_grant_denied if {
+    not authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [object.union(valid_grant, {"second_factor_verified": false})]
+    }
+}
+
+# Test revoked grants confer no authority
+test_authority_revoked_grant_denied if {
+    not authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [object.union(valid_grant, {"revoked_at": "2024-01-01T00:00:00Z"})]
+    }
+}
+
+# Test another operator's grant cannot be borrowed
+test_authority_other_users_grant_denied if {
+    not authority.allow with input as {
+        "user_id": "tao-1",
+        "action_type": "engage",
+        "break_glass": [valid_grant]
+    }
+}
+
+# Test grants only elevate to break-glass roles
+test_authority_grant_role_limited if {
+    not authority.allow with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": [object.union(valid_grant, {"role": "tactical_action_officer"})]
+    }
+}
+
+# Test denial explains missing authority
+test_authority_deny_reason if {
+    count(authority.deny) > 0 with input as {
+        "user_id": "watch-officer-1",
+        "action_type": "engage",
+        "break_glass": []
+    }
+}
+
+#############################
+# Auto-Approval Tests
+#############################
+
+# A low-priority monitor proposal matched by an auto_approve rule
+auto_approve_input := {
+    "rule_id": "6f1c2d1e-3b0a-4c55-9a57-1f3c8e0b9d21",
+    "action_type": "monitor",
+    "priority": 3,
+    "threat_level": "low",
+    "classification": "unknown"
+}
+
+# Test eligible proposals are auto-approved
+test_auto_approve_monitor if {
+    auto_approve.allow with input as auto_approve_input
+}
+
+# Test kinetic actions are never auto-approved
+test_auto_approve_engage_denied if {
+    not auto_approve.allow with input as object.union(auto_approve_input, {"action_type": "engage", "priority": 1})
+}
+
+# Test priority above the action's ceiling waits for an operator
+test_auto_approve_priority_ceiling if {
+    not auto_approve.allow with input as object.union(auto_approve_input, {"priority": 6})
+}
+
+# Test critical threats always go to an operator
+test_auto_approve_critical_denied if {
+    not auto_approve.allow with input as object.union(auto_approve_input, {"threat_level": "critical"})
+}
+
+# Test approvals must be attributed to a rule
+test_auto_approve_requires_rule if {
+    not auto_approve.allow with input as object.union(auto_approve_input, {"rule_id": ""})
+}
+
+# Test denial explains why the proposal needs an operator
+test_auto_approve_deny_reason if {
+    "Action type 'engage' always requires human approval" in auto_approve.deny
+        with input as object.union(auto_approve_input, {"action_type": "engage"})
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+func newAdminAgent(t *testing.T, addr string) *agent.BaseAgent {
+	t.Helper()
+	base, err := agent.NewBaseAgent(agent.Config{ID: "correlator-001", Type: agent.AgentTypeCorrelator, ExtraVars: map[string]string{"METRICS_ADDR": addr}})
+	require.NoError(t, err)
+	return base
+}
+
+func serveAdmin(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestAdminServerStandardRoutes(t *testing.T) {
+	base := newAdminAgent(t, "")
+	assert.Equal(t, agent.DefaultAdminAddr, base.AdminAddr())
+	h := base.NewAdminServer().Handler()
+
+	assert.Equal(t, http.StatusOK, serveAdmin(h, http.MethodGet, "/health/live").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, serveAdmin(h, http.MethodGet, "/health").Code, "an agent that has not started is not healthy")
+	assert.Equal(t, http.StatusOK, serveAdmin(h, http.MethodGet, "/capabilities").Code)
+	assert.Equal(t, http.StatusOK, serveAdmin(h, http.MethodGet, "/metrics").Code)
+
+	rec := serveAdmin(h, http.MethodGet, agent.ConfigPath)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var cfg struct {
+		AgentID   string                 `json:"agent_id"`
+		AgentType string                 `json:"agent_type"`
+		Runtime   map[string]interface{} `json:"runtime"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&cfg))
+	assert.Equal(t, "correlator-001", cfg.AgentID)
+	assert.Equal(t, "correlator", cfg.AgentType)
+	assert.NotNil(t, cfg.Runtime)
+
+	rec = serveAdmin(h, http.MethodGet, "/api/v1/unknown")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	rec = serveAdmin(h, http.MethodDelete, "/health/live")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+}
+
+func TestAdminServerAgentConfigRoute(t *testing.T) {
+	admin := newAdminAgent(t, "").NewAdminServer()
+	admin.Get(agent.ConfigPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	assert.Equal(t, http.StatusTeapot, serveAdmin(admin.Handler(), http.MethodGet, agent.ConfigPath).Code)
+}
+
+func TestAdminAddrValidation(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:9191", newAdminAgent(t, "127.0.0.1:9191").AdminAddr())
+
+	_, err := agent.NewBaseAgent(agent.Config{ID: "planner-001", Type: agent.AgentTypePlanner, ExtraVars: map[string]string{"METRICS_ADDR": "9090"}})
+	assert.ErrorContains(t, err, "METRICS_ADDR")
+}
+
+func TestAdminServerStopsWithContext(t *testing.T) {
+	admin := newAdminAgent(t, "127.0.0.1:0").NewAdminServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- admin.Serve(ctx) }()
+
+	cancel()
+	select {
+	case err := <-errc:
+		assert.NoError(t, err)
+	case <-time.After(agent.DefaultAdminShutdownTimeout):
+		t.Fatal("admin server did not stop with its context")
+	}
+}
+
+// freeAddr returns a loopback address nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+// serveBlocking serves an admin server whose /slow handler waits for release,
+// returning once a request to it is in flight
+func serveBlocking(t *testing.T, base *agent.BaseAgent, release <-chan struct{}) (served, answered <-chan error) {
+	t.Helper()
+	entered := make(chan struct{})
+	admin := base.NewAdminServer()
+	admin.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- admin.Serve(context.Background()) }()
+
+	getErr := make(chan error, 1)
+	go func() {
+		var err error
+		for i := 0; i < 50; i++ {
+			var resp *http.Response
+			if resp, err = http.Get("http://" + base.AdminAddr() + "/slow"); err == nil {
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					err = fmt.Errorf("status %d", resp.StatusCode)
+				}
+				break
+			}
+			time.Sleep(20 * time.Millisecond) // Not listening yet
+		}
+		getErr <- err
+	}()
+	<-entered
+	return serveErr, getErr
+}
+
+func TestStopFinishesAdminRequestsInFlight(t *testing.T) {
+	base := newAdminAgent(t, freeAddr(t))
+	release := make(chan struct{})
+	served, answered := serveBlocking(t, base, release)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- base.Stop(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned with a request in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-answered)
+	assert.NoError(t, <-stopped)
+	assert.NoError(t, <-served)
+}
+
+func TestStopClosesAdminRequestsAtDeadline(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "correlator-001", Type: agent.AgentTypeCorrelator, ExtraVars: map[string]string{
+		"METRICS_ADDR":           freeAddr(t),
+		"ADMIN_SHUTDOWN_TIMEOUT": "50ms",
+	}})
+	require.NoError(t, err)
+	release := make(chan struct{})
+	defer close(release)
+	served, answered := serveBlocking(t, base, release)
+
+	start := time.Now()
+	require.NoError(t, base.Stop(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Error(t, <-answered, "the request still in flight is cut off")
+	assert.ErrorContains(t, <-served, "admin server shutdown")
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/admission"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestRateLimiterShedsLowestThreatFirst tests that a draining bucket refuses low-threat detections before critical ones
+func TestRateLimiterShedsLowestThreatFirst(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := admission.NewRateLimiter(8)
+
+	admitted := 0
+	for limiter.Admit(admission.RankLow, now) {
+		admitted++
+	}
+	assert.Equal(t, 2, admitted, "low threat may only use the top quarter of the bucket")
+
+	assert.True(t, limiter.Admit(admission.RankMedium, now), "medium threat still has reserve")
+	assert.True(t, limiter.Admit(admission.RankHigh, now))
+	assert.True(t, limiter.Admit(admission.RankCritical, now))
+	assert.False(t, limiter.Admit(admission.RankLow, now))
+
+	for limiter.Admit(admission.RankCritical, now) {
+	}
+	assert.False(t, limiter.Admit(admission.RankCritical, now), "an empty bucket refuses everything")
+
+	// A full second refills the bucket
+	now = now.Add(time.Second)
+	assert.True(t, limiter.Admit(admission.RankLow, now))
+}
+
+// TestActiveSetDisplacesLowestThreat tests that a full set admits a higher threat by evicting the lowest
+func TestActiveSetDisplacesLowestThreat(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	set := admission.NewActiveSet(2, time.Minute)
+
+	ok, evicted := set.Admit("track-a", 0.2, now)
+	require.True(t, ok)
+	assert.Empty(t, evicted)
+	ok, _ = set.Admit("track-b", 0.5, now)
+	require.True(t, ok)
+
+	ok, evicted = set.Admit("track-c", 0.2, now)
+	assert.False(t, ok, "an equal threat does not displace an admitted track")
+	assert.Empty(t, evicted)
+
+	ok, evicted = set.Admit("track-a", 0.1, now)
+	assert.True(t, ok, "tracks already admitted are always updated")
+	assert.Empty(t, evicted)
+
+	ok, evicted = set.Admit("track-d", 0.9, now)
+	assert.True(t, ok)
+	assert.Equal(t, "track-a", evicted)
+	assert.Equal(t, 2, set.Len(now))
+
+	set.Remove("track-b")
+	ok, evicted = set.Admit("track-e", 0.1, now)
+	assert.True(t, ok, "removing a track frees its slot")
+	assert.Empty(t, evicted)
+}
+
+// TestActiveSetExpiresStaleTracks tests that tracks not seen within the TTL no longer hold a slot
+func TestActiveSetExpiresStaleTracks(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	set := admission.NewActiveSet(1, admission.ActiveTrackTTL)
+
+	ok, _ := set.Admit("track-a", 0.9, now)
+	require.True(t, ok)
+
+	ok, evicted := set.Admit("track-b", 0.1, now.Add(admission.ActiveTrackTTL+time.Second))
+	assert.True(t, ok)
+	assert.Empty(t, evicted, "an expired track is dropped, not displaced")
+	assert.Equal(t, 1, set.Len(now.Add(admission.ActiveTrackTTL+time.Second)))
+}
+
+// TestParseLimit tests parsing admission limits from the environment
+func TestParseLimit(t *testing.T) {
+	n, err := admission.ParseLimit("MAX_ACTIVE_TRACKS", "", admission.DefaultMaxActiveTracks)
+	require.NoError(t, err)
+	assert.Equal(t, admission.DefaultMaxActiveTracks, n)
+
+	n, err = admission.ParseLimit("MAX_ACTIVE_TRACKS", "0", admission.DefaultMaxActiveTracks)
+	require.NoError(t, err)
+	assert.Zero(t, n, "zero disables the limit")
+
+	_, err = admission.ParseLimit("MAX_ACTIVE_TRACKS", "-1", admission.DefaultMaxActiveTracks)
+	assert.Error(t, err)
+	_, err = admission.ParseLimit("MAX_ACTIVE_TRACKS", "many", admission.DefaultMaxActiveTracks)
+	assert.Error(t, err)
+}
+
+// TestAdmissionRanks tests converting threat levels and classifications into ranks
+func TestAdmissionRanks(t *testing.T) {
+	assert.Equal(t, admission.RankCritical, admission.RankForThreatLevel("critical"))
+	assert.Equal(t, admission.RankMedium, admission.RankForThreatLevel("MEDIUM"))
+	assert.Equal(t, admission.RankLow, admission.RankForThreatLevel("unknown"))
+
+	assert.Equal(t, admission.RankCritical, admission.RankForClassification("friendly", "missile"))
+	assert.Equal(t, admission.RankHigh, admission.RankForClassification("hostile", "aircraft"))
+	assert.Equal(t, admission.RankMedium, admission.RankForClassification("unknown", "vessel"))
+	assert.Equal(t, admission.RankLow, admission.RankForClassification("civilian", "aircraft"))
+	assert.Equal(t, "high", admission.RankHigh.String())
+}
+
+// TestAdmissionShedSubject tests that shed events are published per kind on the notification stream
+func TestAdmissionShedSubject(t *testing.T) {
+	shed := messages.AdmissionShed{Kind: admission.KindProposal, Reason: admission.ReasonDisplaced}
+	assert.Equal(t, "notify.admission.proposal", shed.Subject())
+}
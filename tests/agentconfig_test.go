@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// configDoc builds a config document with the given values
+func configDoc(values map[string]string) *agent.ConfigDocument {
+	return &agent.ConfigDocument{Values: values, UpdatedBy: "operator-1", UpdatedAt: time.Now()}
+}
+
+// TestRuntimeConfigScopes verifies agent ID settings override agent type settings and removal restores defaults
+func TestRuntimeConfigScopes(t *testing.T) {
+	rc := agent.NewRuntimeConfig("planner", "planner-001", zerolog.Nop())
+
+	var batches []int
+	rc.WatchInt("batch", 10, func(n int) { batches = append(batches, n) })
+	assert.Equal(t, []int{10}, batches, "watchers receive the current value immediately")
+
+	rc.Apply("planner", configDoc(map[string]string{"batch": "25", "mode": "fast"}))
+	rc.Apply("planner-001", configDoc(map[string]string{"batch": "50"}))
+	assert.Equal(t, 50, rc.Int("batch", 10))
+	assert.Equal(t, "fast", rc.String("mode", "slow"))
+
+	rc.Apply("classifier", configDoc(map[string]string{"batch": "99"}))
+	rc.Apply("planner-002", configDoc(map[string]string{"batch": "99"}))
+	assert.Equal(t, 50, rc.Int("batch", 10), "other agents' scopes are ignored")
+
+	rc.Apply("planner-001", nil)
+	assert.Equal(t, 25, rc.Int("batch", 10), "removing the ID scope falls back to the type")
+	rc.Apply("planner", nil)
+	assert.Equal(t, 10, rc.Int("batch", 10))
+
+	assert.Equal(t, []int{10, 25, 50, 25, 10}, batches)
+}
+
+// TestRuntimeConfigInvalidValues verifies malformed values are ignored by watchers and typed getters
+func TestRuntimeConfigInvalidValues(t *testing.T) {
+	rc := agent.NewRuntimeConfig("correlator", "correlator-001", zerolog.Nop())
+
+	var threshold float64
+	var window time.Duration
+	rc.WatchFloat("threshold", 500, func(v float64) { threshold = v })
+	rc.WatchDuration("window", 10*time.Second, func(d time.Duration) { window = d })
+
+	rc.Apply("correlator", configDoc(map[string]string{"threshold": "250.5", "window": "30s"}))
+	assert.Equal(t, 250.5, threshold)
+	assert.Equal(t, 30*time.Second, window)
+
+	rc.Apply("correlator", configDoc(map[string]string{"threshold": "far", "window": "soon"}))
+	assert.Equal(t, 250.5, threshold, "an invalid value keeps the previous one")
+	assert.Equal(t, 30*time.Second, window)
+	assert.Equal(t, 500.0, rc.Float("threshold", 500))
+	assert.False(t, rc.Bool("enabled", false))
+}
+
+// TestBaseAgentRuntimeConfig verifies the base agent applies fetch_batch_size at runtime
+func TestBaseAgentRuntimeConfig(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "effector-001", Type: "effector"})
+	require.NoError(t, err)
+	assert.Equal(t, agent.DefaultFetchBatchSize, base.FetchBatchSize())
+
+	base.RuntimeConfig().Apply("effector", configDoc(map[string]string{agent.ConfigFetchBatchSize: "64"}))
+	assert.Equal(t, 64, base.FetchBatchSize())
+
+	base.RuntimeConfig().Apply("effector", configDoc(map[string]string{agent.ConfigFetchBatchSize: "0"}))
+	assert.Equal(t, 64, base.FetchBatchSize(), "out of range values are ignored")
+
+	base.RuntimeConfig().Apply("effector", nil)
+	assert.Equal(t, agent.DefaultFetchBatchSize, base.FetchBatchSize())
+
+	names := map[string]bool{}
+	for _, f := range base.Capabilities().ConfigSchema {
+		names[f.Name] = f.Runtime
+	}
+	assert.True(t, names[agent.ConfigLogLevel])
+	assert.True(t, names[agent.ConfigFetchBatchSize])
+}
+
+// TestValidateConfigValue verifies runtime values are checked against their field type
+func TestValidateConfigValue(t *testing.T) {
+	level := agent.RuntimeConfigFields[0]
+	batch := agent.RuntimeConfigFields[1]
+
+	assert.NoError(t, agent.ValidateConfigValue(level, "debug"))
+	assert.Error(t, agent.ValidateConfigValue(level, "verbose"))
+	assert.Error(t, agent.ValidateConfigValue(level, ""))
+	assert.NoError(t, agent.ValidateConfigValue(batch, "100"))
+	assert.Error(t, agent.ValidateConfigValue(batch, "ten"))
+	assert.Error(t, agent.ValidateConfigValue(batch, "5000"))
+	assert.Error(t, agent.ValidateConfigValue(agent.ConfigField{Name: "window", Type: "duration"}, "10"))
+}
+
+// TestAgentConfigHandlerValidation verifies bad settings are rejected before NATS is needed
+func TestAgentConfigHandlerValidation(t *testing.T) {
+	h := handler.NewAgentConfigHandler(nil, zerolog.Nop())
+
+	cases := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"invalid log level", "/planner", `{"values":{"log_level":"verbose"}}`, http.StatusBadRequest},
+		{"invalid setting name", "/planner", `{"values":{"Log-Level":"debug"}}`, http.StatusBadRequest},
+		{"nested value", "/planner", `{"values":{"weights":{"a":1}}}`, http.StatusBadRequest},
+		{"invalid scope", "/planner.001", `{"values":{"log_level":"debug"}}`, http.StatusBadRequest},
+		{"valid without NATS", "/planner-001", `{"values":{"log_level":"debug","fetch_batch_size":25}}`, http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		h.Routes().ServeHTTP(rec, req)
+		assert.Equal(t, tc.want, rec.Code, tc.name)
+	}
+}
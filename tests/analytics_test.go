@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// TestHeatmapValidation verifies malformed heatmap requests are rejected
+// before reaching the database
+func TestHeatmapValidation(t *testing.T) {
+	h := handler.NewAnalyticsHandler(nil, zerolog.Nop())
+
+	for _, query := range []string{
+		"",
+		"bbox=-118,34,-116",
+		"bbox=-118,36,-116,34",
+		"bbox=-118,34,-116,36&cell=0",
+		"bbox=-118,34,-116,36&cell=abc",
+		"bbox=-180,-90,180,90&cell=0.01",
+		"bbox=-118,34,-116,36&since=yesterday",
+		"bbox=-118,34,-116,36&since=2024-05-02T00:00:00Z&until=2024-05-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		h.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/heatmap?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"), query)
+	}
+}
+
+// TestHeatmapGrid verifies the grid spans the box, including across the antimeridian
+func TestHeatmapGrid(t *testing.T) {
+	rows, cols := postgres.HeatmapFilter{BBox: geo.BBox{MinLon: -118, MinLat: 34, MaxLon: -116, MaxLat: 36}, Cell: 0.5}.HeatmapGrid()
+	assert.Equal(t, 5, rows)
+	assert.Equal(t, 5, cols)
+
+	rows, cols = postgres.HeatmapFilter{BBox: geo.BBox{MinLon: 179, MinLat: 0, MaxLon: -179, MaxLat: 1}, Cell: 1}.HeatmapGrid()
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 3, cols)
+}
+
+// TestApproverStatsValidation verifies malformed time ranges are rejected
+func TestApproverStatsValidation(t *testing.T) {
+	h := handler.NewAnalyticsHandler(nil, zerolog.Nop())
+
+	for _, query := range []string{
+		"from=yesterday",
+		"to=2024-13-01T00:00:00Z",
+		"from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		h.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/approvers?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const anonymizeSample = `{
+	"track_id": "TRK-ALPHA-7",
+	"approved_by": "maj.smith",
+	"rationale": "Track TRK-ALPHA-7 is closing on the airbase",
+	"position": {"lat": 35.0, "lon": -117.0, "alt": 5000},
+	"priority": 8
+}`
+
+// TestAnonymizerTransform verifies coordinates, track IDs, and operators are rewritten
+func TestAnonymizerTransform(t *testing.T) {
+	a := handler.NewAnonymizer(true, "demo-seed", zerolog.Nop())
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(a.Transform([]byte(anonymizeSample)), &out))
+
+	pseudonym := a.Pseudonym("TRK-ALPHA-7")
+	assert.Equal(t, pseudonym, out["track_id"])
+	assert.Equal(t, handler.RedactedOperator, out["approved_by"])
+	assert.Equal(t, "Track "+pseudonym+" is closing on the airbase", out["rationale"])
+	assert.Equal(t, float64(8), out["priority"])
+
+	pos := out["position"].(map[string]interface{})
+	assert.NotEqual(t, 35.0, pos["lat"])
+	assert.NotEqual(t, -117.0, pos["lon"])
+	assert.Equal(t, float64(5000), pos["alt"], "altitude is left alone")
+
+	// Pseudonyms and offsets are stable for a seed
+	again := handler.NewAnonymizer(true, "demo-seed", zerolog.Nop())
+	assert.Equal(t, pseudonym, again.Pseudonym("TRK-ALPHA-7"))
+	assert.JSONEq(t, string(a.Transform([]byte(anonymizeSample))), string(again.Transform([]byte(anonymizeSample))))
+
+	assert.Equal(t, "not json", string(a.Transform([]byte("not json"))))
+}
+
+// TestAnonymizerMiddleware verifies responses are rewritten only while enabled
+func TestAnonymizerMiddleware(t *testing.T) {
+	a := handler.NewAnonymizer(false, "demo-seed", zerolog.Nop())
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.WriteJSON(w, http.StatusCreated, json.RawMessage(anonymizeSample))
+	})
+	h := a.Middleware(next)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tracks", nil))
+	assert.Contains(t, rec.Body.String(), "maj.smith")
+
+	toggle := httptest.NewRecorder()
+	a.Routes().ServeHTTP(toggle, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"enabled": true}`)))
+	require.Equal(t, http.StatusOK, toggle.Code)
+	assert.True(t, a.Enabled())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tracks", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("X-Anonymized"))
+	assert.NotContains(t, rec.Body.String(), "maj.smith")
+	assert.NotContains(t, rec.Body.String(), "TRK-ALPHA-7")
+
+	bad := httptest.NewRecorder()
+	a.Routes().ServeHTTP(bad, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, bad.Code)
+}
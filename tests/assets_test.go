@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/assets"
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// testAsset builds a ready asset of a kind at a location
+func testAsset(id, kind string, lat, lon, rangeMeters float64) assets.Asset {
+	return assets.Asset{
+		AssetID:     id,
+		Name:        id,
+		Kind:        kind,
+		Location:    geo.Point{Lat: lat, Lon: lon},
+		RangeMeters: rangeMeters,
+		Readiness:   assets.ReadinessReady,
+	}
+}
+
+// TestAssetValidate verifies asset validation
+func TestAssetValidate(t *testing.T) {
+	valid := testAsset("alpha", assets.KindInterceptor, 37.5, -115.0, 150000)
+	assert.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name   string
+		mutate func(a *assets.Asset)
+	}{
+		{"missing name", func(a *assets.Asset) { a.Name = "" }},
+		{"unknown kind", func(a *assets.Asset) { a.Kind = "tank" }},
+		{"unknown readiness", func(a *assets.Asset) { a.Readiness = "asleep" }},
+		{"latitude out of range", func(a *assets.Asset) { a.Location.Lat = 91 }},
+		{"non-positive range", func(a *assets.Asset) { a.RangeMeters = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.mutate(&a)
+			assert.Error(t, a.Validate())
+		})
+	}
+}
+
+// TestAssetSupports verifies which kinds of asset carry out which actions
+func TestAssetSupports(t *testing.T) {
+	assert.True(t, assets.Supports(assets.KindInterceptor, "engage"))
+	assert.True(t, assets.Supports(assets.KindInterceptor, "intercept"))
+	assert.True(t, assets.Supports(assets.KindJammer, "engage"))
+	assert.False(t, assets.Supports(assets.KindJammer, "intercept"))
+	assert.False(t, assets.Supports(assets.KindSensor, "engage"))
+
+	assert.Equal(t, []string{assets.KindInterceptor, assets.KindJammer}, assets.KindsFor("engage"))
+	assert.Equal(t, []string{assets.KindInterceptor}, assets.KindsFor("intercept"))
+	assert.Empty(t, assets.KindsFor("ignore"))
+
+	assert.True(t, assets.RequiresAsset("engage"))
+	assert.True(t, assets.RequiresAsset("intercept"))
+	assert.False(t, assets.RequiresAsset("identify"))
+	assert.False(t, assets.RequiresAsset("monitor"))
+}
+
+// TestAssetNearest verifies the nearest ready, capable asset in range is recommended
+func TestAssetNearest(t *testing.T) {
+	track := messages.Position{Lat: 37.0, Lon: -115.0}
+
+	near := testAsset("near", assets.KindInterceptor, 37.1, -115.0, 50000)
+	far := testAsset("far", assets.KindInterceptor, 37.5, -115.0, 150000)
+	sensor := testAsset("sensor", assets.KindSensor, 37.0, -115.0, 250000)
+
+	asset, ok := assets.Nearest([]assets.Asset{far, sensor, near}, "intercept", track)
+	require.True(t, ok)
+	assert.Equal(t, "near", asset.AssetID)
+	assert.Equal(t, assets.KindInterceptor, asset.Kind)
+	assert.InDelta(t, 11100, asset.DistanceMeters, 200)
+
+	committed := near
+	committed.Readiness = assets.ReadinessCommitted
+	asset, ok = assets.Nearest([]assets.Asset{far, committed}, "intercept", track)
+	require.True(t, ok)
+	assert.Equal(t, "far", asset.AssetID, "committed assets are not recommended")
+
+	short := testAsset("short", assets.KindInterceptor, 37.5, -115.0, 10000)
+	_, ok = assets.Nearest([]assets.Asset{short, sensor}, "intercept", track)
+	assert.False(t, ok, "the track is out of range and sensors cannot intercept")
+
+	jammer := testAsset("jammer", assets.KindJammer, 37.1, -115.0, 50000)
+	_, ok = assets.Nearest([]assets.Asset{jammer}, "intercept", track)
+	assert.False(t, ok, "jammers cannot intercept")
+	asset, ok = assets.Nearest([]assets.Asset{jammer}, "engage", track)
+	require.True(t, ok)
+	assert.Equal(t, "jammer", asset.AssetID)
+}
+
+// TestAssetNearestTie verifies equally distant assets favour the longer reach
+func TestAssetNearestTie(t *testing.T) {
+	track := messages.Position{Lat: 37.0, Lon: -115.0}
+	shortReach := testAsset("short-reach", assets.KindInterceptor, 37.1, -115.0, 20000)
+	longReach := testAsset("long-reach", assets.KindInterceptor, 37.1, -115.0, 90000)
+
+	asset, ok := assets.Nearest([]assets.Asset{shortReach, longReach}, "engage", track)
+	require.True(t, ok)
+	assert.Equal(t, "long-reach", asset.AssetID)
+}
@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// TestAuditSearchValidation verifies malformed searches are rejected before
+// reaching the database
+func TestAuditSearchValidation(t *testing.T) {
+	h := handler.NewAuditHandler(nil, nil, zerolog.Nop())
+
+	for _, query := range []string{
+		"outcome=pending",
+		"from=yesterday",
+		"to=2024-13-01T00:00:00Z",
+		"from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		h.Routes().ServeHTTP(w, httptest.NewRequest("GET", "/search?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"), query)
+	}
+}
+
+// TestAuditOutcomes verifies the outcomes an audit search accepts
+func TestAuditOutcomes(t *testing.T) {
+	for _, outcome := range []string{postgres.AuditOutcomeApproved, postgres.AuditOutcomeDenied, postgres.AuditOutcomeExecuted, postgres.AuditOutcomeFailed} {
+		assert.True(t, postgres.IsValidAuditOutcome(outcome), outcome)
+	}
+	assert.False(t, postgres.IsValidAuditOutcome("proposed"))
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAuditChain links a decision and its effect the same way the writers do
+func buildAuditChain(t *testing.T) ([]audit.Link, audit.Head) {
+	approvedAt := time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC)
+	payloads := []struct {
+		entityType string
+		entityID   string
+		payload    []byte
+	}{
+		{audit.EntityDecision, "dec-1", audit.DecisionRecord{
+			DecisionID: "dec-1", ProposalID: "prop-1", Approved: true, ApprovedBy: "operator-1",
+			ApprovedAt: approvedAt, ActionType: "intercept", TrackID: "track-1",
+		}.Payload()},
+		{audit.EntityEffect, "eff-1", audit.EffectRecord{
+			EffectID: "eff-1", DecisionID: "dec-1", ProposalID: "prop-1", TrackID: "track-1",
+			ActionType: "intercept", Status: "executed", IdempotentKey: "dec-1-prop-1-intercept",
+			ExecutedAt: approvedAt.Add(time.Second), FencingToken: 3,
+		}.Payload()},
+		{audit.EntityDecision, "dec-2", audit.DecisionRecord{
+			DecisionID: "dec-2", ProposalID: "prop-2", ApprovedBy: "operator-2",
+			ApprovedAt: approvedAt.Add(time.Minute), Reason: "friendly", ActionType: "engage", TrackID: "track-2",
+		}.Payload()},
+	}
+
+	var links []audit.Link
+	prev := audit.GenesisHash
+	for i, p := range payloads {
+		seq := int64(i + 1)
+		link := audit.Link{
+			Seq:        seq,
+			EntityType: p.entityType,
+			EntityID:   p.entityID,
+			PrevHash:   prev,
+			Hash:       audit.Hash(prev, seq, p.entityType, p.payload),
+			Payload:    p.payload,
+		}
+		links = append(links, link)
+		prev = link.Hash
+	}
+	require.Len(t, links, 3)
+	return links, audit.Head{Seq: 3, Hash: prev}
+}
+
+// TestAuditChainVerifies verifies an untouched chain is valid
+func TestAuditChainVerifies(t *testing.T) {
+	links, head := buildAuditChain(t)
+
+	result := audit.Verify(links, head)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.LinksChecked)
+	assert.Nil(t, result.FirstBroken)
+
+	empty := audit.Verify(nil, audit.Head{Hash: audit.GenesisHash})
+	assert.True(t, empty.Valid, "an empty chain at genesis is valid")
+}
+
+// TestAuditChainDetectsTampering verifies the first broken link is reported
+func TestAuditChainDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(links []audit.Link, head *audit.Head) []audit.Link
+		brokenSeq  int64
+		reasonPart string
+	}{
+		{
+			name: "edited payload",
+			tamper: func(links []audit.Link, head *audit.Head) []audit.Link {
+				links[1].Payload = audit.EffectRecord{EffectID: "eff-1", Status: "failed"}.Payload()
+				return links
+			},
+			brokenSeq:  2,
+			reasonPart: "does not match its hash",
+		},
+		{
+			name: "rehashed link",
+			tamper: func(links []audit.Link, head *audit.Head) []audit.Link {
+				links[0].Payload = []byte(`{"decision_id":"dec-1","approved":false}`)
+				links[0].Hash = audit.Hash(links[0].PrevHash, 1, links[0].EntityType, links[0].Payload)
+				return links
+			},
+			brokenSeq:  2,
+			reasonPart: "previous hash",
+		},
+		{
+			name: "deleted link",
+			tamper: func(links []audit.Link, head *audit.Head) []audit.Link {
+				return append(links[:1], links[2:]...)
+			},
+			brokenSeq:  2,
+			reasonPart: "missing link",
+		},
+		{
+			name: "truncated tail",
+			tamper: func(links []audit.Link, head *audit.Head) []audit.Link {
+				return links[:2]
+			},
+			brokenSeq:  3,
+			reasonPart: "truncated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, head := buildAuditChain(t)
+			links = tt.tamper(links, &head)
+
+			result := audit.Verify(links, head)
+			assert.False(t, result.Valid)
+			require.NotNil(t, result.FirstBroken)
+			assert.Equal(t, tt.brokenSeq, result.FirstBroken.Seq)
+			assert.Contains(t, result.FirstBroken.Reason, tt.reasonPart)
+		})
+	}
+}
+
+// TestAuditPayloadCanonical verifies payloads match after a database round trip
+func TestAuditPayloadCanonical(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.FixedZone("EST", -5*3600))
+
+	written := audit.DecisionRecord{DecisionID: "ABC", ApprovedAt: at}
+	read := audit.DecisionRecord{DecisionID: "abc", ApprovedAt: at.UTC().Truncate(time.Microsecond), Conditions: []string{}}
+
+	assert.Equal(t, string(written.Payload()), string(read.Payload()))
+}
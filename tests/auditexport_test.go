@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/auditexport"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+var exportKey = []byte("0123456789abcdef-test-key")
+
+func buildTestBundle(t *testing.T) ([]byte, auditexport.Manifest) {
+	t.Helper()
+	entries := []postgres.AuditEntry{
+		{ID: "d-1", Timestamp: "2024-05-01T10:00:00Z", ActionType: "engage", UserID: "operator-1", TrackID: "TRK-1", DecisionID: "d-1", Status: "executed", Reason: "Hostile, closing"},
+		{ID: "d-2", Timestamp: "2024-05-01T11:00:00Z", ActionType: "identify", UserID: "operator-2", TrackID: "TRK-2", DecisionID: "d-2", Status: "denied", Reason: "Friendly aircraft, \"IFF\" confirmed"},
+	}
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	bundle, m, err := auditexport.Build(entries, auditexport.Manifest{
+		ExportID:    "export-1",
+		From:        from,
+		To:          from.Add(24 * time.Hour),
+		RequestedBy: "auditor-1",
+		GeneratedAt: from.Add(25 * time.Hour),
+	}, exportKey)
+	require.NoError(t, err)
+	return bundle, m
+}
+
+// TestAuditExportBundle verifies a bundle's contents and that Verify accepts it
+func TestAuditExportBundle(t *testing.T) {
+	bundle, m := buildTestBundle(t)
+	assert.Equal(t, 2, m.Records)
+	assert.Equal(t, auditexport.KeyID(exportKey), m.KeyID)
+	require.Len(t, m.Files, 2)
+
+	verified, err := auditexport.Verify(bundle, exportKey)
+	require.NoError(t, err)
+	assert.Equal(t, m, verified)
+
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	require.NoError(t, err)
+	var rows [][]string
+	for _, f := range zr.File {
+		if f.Name != auditexport.CSVFile {
+			continue
+		}
+		rc, err := f.Open()
+		require.NoError(t, err)
+		rows, err = csv.NewReader(rc).ReadAll()
+		rc.Close()
+		require.NoError(t, err)
+	}
+	require.Len(t, rows, 3, "header and two entries")
+	assert.Equal(t, "Friendly aircraft, \"IFF\" confirmed", rows[2][10])
+
+	_, err = auditexport.Verify(bundle, []byte("another-key-of-16-bytes"))
+	assert.ErrorIs(t, err, auditexport.ErrInvalidSignature)
+}
+
+// TestAuditExportTampering verifies an edited file fails verification
+func TestAuditExportTampering(t *testing.T) {
+	bundle, _ := buildTestBundle(t)
+	zr, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		if f.Name == auditexport.CSVFile {
+			data = []byte(strings.Replace(string(data), "denied", "approved", 1))
+		}
+		w, err := zw.Create(f.Name)
+		require.NoError(t, err)
+		w.Write(data)
+	}
+	require.NoError(t, zw.Close())
+
+	_, err = auditexport.Verify(buf.Bytes(), exportKey)
+	assert.ErrorContains(t, err, auditexport.CSVFile)
+}
+
+// TestAuditExportKey verifies AUDIT_EXPORT_KEY parsing
+func TestAuditExportKey(t *testing.T) {
+	key, generated, err := auditexport.ParseKey("")
+	require.NoError(t, err)
+	assert.True(t, generated)
+	assert.Len(t, key, 32)
+
+	_, _, err = auditexport.ParseKey("short")
+	assert.Error(t, err)
+
+	key, generated, err = auditexport.ParseKey(string(exportKey))
+	require.NoError(t, err)
+	assert.False(t, generated)
+	assert.Equal(t, exportKey, key)
+}
+
+// TestAuditExportRequests verifies export requests are validated and unknown
+// exports are not found
+func TestAuditExportRequests(t *testing.T) {
+	routes := handler.NewAuditHandler(nil, exportKey, zerolog.Nop()).Routes()
+
+	for _, body := range []string{
+		`not json`,
+		`{"from":"2024-05-01T00:00:00Z"}`,
+		`{"from":"2024-05-02T00:00:00Z","to":"2024-05-01T00:00:00Z"}`,
+	} {
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, httptest.NewRequest("POST", "/export", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	for _, path := range []string{"/export/missing", "/export/missing/download"} {
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
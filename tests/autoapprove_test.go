@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/audit"
+	"github.com/agile-defense/cjadc2/pkg/autoapprove"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+)
+
+// stubPolicy returns a fixed auto-approval policy decision
+type stubPolicy struct {
+	decision *opa.Decision
+	err      error
+	input    map[string]interface{}
+}
+
+func (s *stubPolicy) Decide(ctx context.Context, policyPath string, input interface{}) (*opa.Decision, error) {
+	s.input, _ = input.(map[string]interface{})
+	return s.decision, s.err
+}
+
+func autoApproveProposal() *messages.ActionProposal {
+	return &messages.ActionProposal{
+		ProposalID:  "P-1",
+		TrackID:     "TRK-1",
+		ActionType:  "monitor",
+		Priority:    3,
+		ThreatLevel: "low",
+		Track:       &messages.CorrelatedTrack{TrackID: "TRK-1", Classification: "unknown"},
+	}
+}
+
+// TestAutoApproveParseEnabled verifies auto-approval is off unless enabled
+func TestAutoApproveParseEnabled(t *testing.T) {
+	enabled, err := autoapprove.ParseEnabled("")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	enabled, err = autoapprove.ParseEnabled("true")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = autoapprove.ParseEnabled("sometimes")
+	assert.Error(t, err)
+}
+
+// TestAutoApproveCheck verifies the policy input and that fallback decisions never approve
+func TestAutoApproveCheck(t *testing.T) {
+	ctx := context.Background()
+	rule := autoapprove.Rule{RuleID: "rule-1", Name: "Low priority monitoring", AutoApprove: true}
+
+	policy := &stubPolicy{decision: &opa.Decision{Allowed: true}}
+	allowed, _, err := autoapprove.Check(ctx, policy, autoApproveProposal(), rule)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, "rule-1", policy.input["rule_id"])
+	assert.Equal(t, "unknown", policy.input["classification"])
+
+	policy.decision = &opa.Decision{Allowed: true, Metadata: map[string]interface{}{"fallback": true}}
+	allowed, reasons, err := autoapprove.Check(ctx, policy, autoApproveProposal(), rule)
+	require.NoError(t, err)
+	assert.False(t, allowed, "an OPA outage must not auto-approve")
+	assert.NotEmpty(t, reasons)
+
+	policy.err = errors.New("opa down")
+	_, _, err = autoapprove.Check(ctx, policy, autoApproveProposal(), rule)
+	assert.Error(t, err)
+}
+
+// TestAutoApproveEmbeddedPolicy evaluates the real auto-approval policy in-process
+func TestAutoApproveEmbeddedPolicy(t *testing.T) {
+	ctx := context.Background()
+	client, err := opa.NewEmbeddedClient(ctx, policyBundlePath)
+	require.NoError(t, err)
+	rule := autoapprove.Rule{RuleID: "rule-1", Name: "Low priority monitoring", AutoApprove: true}
+
+	proposal := autoApproveProposal()
+	allowed, reasons, err := autoapprove.Check(ctx, client, proposal, rule)
+	require.NoError(t, err)
+	assert.True(t, allowed, "reasons: %v", reasons)
+
+	proposal.Priority = 9
+	allowed, reasons, err = autoapprove.Check(ctx, client, proposal, rule)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	require.NotEmpty(t, reasons)
+	assert.Contains(t, reasons[0], "auto-approval ceiling")
+
+	proposal = autoApproveProposal()
+	proposal.ActionType = "engage"
+	proposal.Priority = 1
+	allowed, _, err = autoapprove.Check(ctx, client, proposal, rule)
+	require.NoError(t, err)
+	assert.False(t, allowed, "kinetic actions always need an operator")
+}
+
+// TestAutoApproveDecisionFlagged verifies machine approvals are attributed to
+// the rule and flagged in the chained audit payload, leaving operator decisions' hashes alone
+func TestAutoApproveDecisionFlagged(t *testing.T) {
+	rule := autoapprove.Rule{RuleID: "6F1C2D1E-3B0A-4C55-9A57-1F3C8E0B9D21", Name: "Low priority monitoring", AutoApprove: true}
+	decision := messages.NewDecision(autoApproveProposal(), "authorizer-001")
+	autoapprove.Apply(decision, rule)
+
+	assert.True(t, decision.Approved)
+	assert.True(t, decision.MachineApproved)
+	assert.Equal(t, autoapprove.ApproverPrefix+rule.RuleID, decision.ApprovedBy)
+	assert.Equal(t, rule.RuleID, decision.AutoApproveRuleID)
+	assert.Contains(t, decision.Reason, rule.Name)
+
+	// The flag survives either wire encoding
+	want, err := json.Marshal(decision)
+	require.NoError(t, err)
+	data, contentType, err := messages.Marshal(decision, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	got, err := messages.ToJSON(messages.SchemaDecision, contentType, data)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+
+	record := audit.DecisionRecord{
+		DecisionID: "D-1",
+		ProposalID: "P-1",
+		Approved:   true,
+		ApprovedBy: "operator",
+		ApprovedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		ActionType: "monitor",
+		TrackID:    "TRK-1",
+	}
+	assert.NotContains(t, string(record.Payload()), "machine_approved")
+
+	record.ApprovedBy = decision.ApprovedBy
+	record.MachineApproved = true
+	record.AutoApproveRuleID = rule.RuleID
+	payload := string(record.Payload())
+	assert.Contains(t, payload, `"machine_approved":true`)
+	assert.Contains(t, payload, `"auto_approve_rule_id":"6f1c2d1e-3b0a-4c55-9a57-1f3c8e0b9d21"`)
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/backpressure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackpressureThrottlesLinearly verifies the slowdown grows from 1 at the
+// throttle threshold towards MaxSlowdown at the pause threshold
+func TestBackpressureThrottlesLinearly(t *testing.T) {
+	c, err := backpressure.NewController(backpressure.Config{
+		ThrottleThreshold: 100,
+		PauseThreshold:    300,
+		ResumeThreshold:   100,
+		MaxSlowdown:       5,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+
+	_, state := c.Observe(50, now)
+	assert.Equal(t, backpressure.LevelNormal, state.Level)
+	assert.Equal(t, 1.0, state.Slowdown)
+	assert.Equal(t, time.Second, state.Interval(time.Second))
+
+	_, state = c.Observe(100, now)
+	assert.Equal(t, backpressure.LevelThrottled, state.Level)
+	assert.Equal(t, 1.0, state.Slowdown)
+
+	_, state = c.Observe(200, now)
+	assert.Equal(t, backpressure.LevelThrottled, state.Level)
+	assert.InDelta(t, 3.0, state.Slowdown, 1e-9)
+	assert.Equal(t, 3*time.Second, state.Interval(time.Second))
+	assert.Equal(t, "throttled", state.LevelName)
+}
+
+// TestBackpressurePauseHysteresis verifies a paused producer stays paused until
+// the backlog drains below the resume threshold
+func TestBackpressurePauseHysteresis(t *testing.T) {
+	c, err := backpressure.NewController(backpressure.Config{
+		ThrottleThreshold: 100,
+		PauseThreshold:    300,
+		ResumeThreshold:   50,
+		MaxSlowdown:       4,
+	})
+	require.NoError(t, err)
+	now := time.Now()
+
+	prev, state := c.Observe(300, now)
+	assert.Equal(t, backpressure.LevelNormal, prev.Level)
+	assert.Equal(t, backpressure.LevelPaused, state.Level)
+	assert.Equal(t, 4.0, state.Slowdown)
+
+	_, state = c.Observe(150, now)
+	assert.Equal(t, backpressure.LevelPaused, state.Level, "still above resume threshold")
+
+	_, state = c.Observe(60, now)
+	assert.Equal(t, backpressure.LevelPaused, state.Level)
+
+	prev, state = c.Observe(49, now)
+	assert.Equal(t, backpressure.LevelPaused, prev.Level)
+	assert.Equal(t, backpressure.LevelNormal, state.Level)
+	assert.Equal(t, 49, c.State().Backlog)
+}
+
+// TestBackpressureConfigValidation verifies misordered thresholds are rejected
+func TestBackpressureConfigValidation(t *testing.T) {
+	assert.NoError(t, backpressure.DefaultConfig().Validate())
+
+	cfg := backpressure.DefaultConfig()
+	cfg.PauseThreshold = cfg.ThrottleThreshold
+	assert.Error(t, cfg.Validate())
+
+	cfg = backpressure.DefaultConfig()
+	cfg.ResumeThreshold = cfg.PauseThreshold + 1
+	assert.Error(t, cfg.Validate())
+
+	cfg = backpressure.DefaultConfig()
+	cfg.MaxSlowdown = 0.5
+	assert.Error(t, cfg.Validate())
+
+	_, err := backpressure.NewController(backpressure.Config{})
+	assert.Error(t, err)
+}
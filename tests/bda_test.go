@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/bda"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+// TestAssessEstimatesKineticActions tests that kinetic actions are drawn against their kill probability
+func TestAssessEstimatesKineticActions(t *testing.T) {
+	outcome, ok := bda.Assess("engage", nil, 0.1)
+	assert.True(t, ok)
+	assert.True(t, outcome.Neutralized)
+	assert.Equal(t, bda.KillProbabilities["engage"], outcome.SuccessProbability)
+	assert.Equal(t, bda.BasisEstimated, outcome.Basis)
+
+	outcome, ok = bda.Assess("intercept", nil, 0.95)
+	assert.True(t, ok)
+	assert.False(t, outcome.Neutralized, "a draw above the kill probability leaves the target intact")
+
+	_, ok = bda.Assess("identify", nil, 0)
+	assert.False(t, ok, "non-kinetic actions are not assessed")
+}
+
+// TestAssessPrefersReportedOutcome tests that an outcome reported by the effector backend overrides the estimate
+func TestAssessPrefersReportedOutcome(t *testing.T) {
+	outcome, ok := bda.Assess("engage", map[string]interface{}{
+		bda.DetailNeutralized:        false,
+		bda.DetailSuccessProbability: 0.4,
+	}, 0)
+	assert.True(t, ok)
+	assert.False(t, outcome.Neutralized)
+	assert.Equal(t, 0.4, outcome.SuccessProbability)
+	assert.Equal(t, bda.BasisReported, outcome.Basis)
+
+	// A backend may assess actions that are not assessed by default
+	outcome, ok = bda.Assess("monitor", map[string]interface{}{bda.DetailSuccessProbability: 0.5}, 0.2)
+	assert.True(t, ok)
+	assert.True(t, outcome.Neutralized)
+	assert.Equal(t, bda.BasisEstimated, outcome.Basis)
+}
+
+// TestEffectAssessmentSubject tests that assessments are published by outcome and action type
+func TestEffectAssessmentSubject(t *testing.T) {
+	decision := &messages.Decision{DecisionID: "decision-1", ProposalID: "proposal-1", TrackID: "track-1", ActionType: "intercept"}
+	effectLog := messages.NewEffectLog(decision, "effector-001")
+	effectLog.EffectID = "effect-1"
+
+	assessment := messages.NewEffectAssessment(effectLog, "effector-001")
+	assert.Equal(t, "effect-1", assessment.EffectID)
+	assert.Equal(t, "track-1", assessment.TrackID)
+	assert.Equal(t, effectLog.Envelope.MessageID, assessment.Envelope.CausationID)
+	assert.Equal(t, "assessment.survived.intercept", assessment.Subject())
+
+	assessment.Neutralized = true
+	assert.Equal(t, "assessment.neutralized.intercept", assessment.Subject())
+}
+
+// TestForgedNeutralizationIgnored verifies the correlator acts only on
+// neutralizations the effector signed: an unsigned, altered, or
+// impersonated assessment does not hide the track
+func TestForgedNeutralizationIgnored(t *testing.T) {
+	ctx := context.Background()
+	registry := signing.NewRegistry(newMemoryKV())
+	now := time.Now().UTC()
+	effectorKey, err := signing.GenerateKey("effector-001", "effector", now.Add(-time.Minute), time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(ctx, effectorKey.PublicKey))
+	plannerKey, err := signing.GenerateKey("planner-001", "planner", now.Add(-time.Minute), time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(ctx, plannerKey.PublicKey))
+	verifier := signing.Verifier{Registry: registry, Policy: signing.PolicyEnforce}
+
+	encode := func(key *signing.Key) *nats.Msg {
+		assessment := messages.NewEffectAssessment(&messages.EffectLog{EffectID: "effect-1", TrackID: "track-1"}, "effector-001")
+		assessment.Neutralized = true
+		assessment.Envelope.SigningKeyID = key.KeyID
+		data, contentType, err := messages.Marshal(assessment, messages.EncodingJSON)
+		require.NoError(t, err)
+		m := nats.NewMsg(assessment.Subject())
+		m.Data = data
+		m.Header.Set(messages.ContentTypeHeader, contentType)
+		signing.SignMsg(m, key)
+		return m
+	}
+	var neutralized []string
+	handle := func(m *nats.Msg) error {
+		return bda.Handle(ctx, verifier, m.Header, m.Data, now, func(a *messages.EffectAssessment) {
+			neutralized = append(neutralized, a.TrackID)
+		})
+	}
+
+	signed := encode(effectorKey)
+	require.NoError(t, handle(signed))
+	assert.Equal(t, []string{"track-1"}, neutralized)
+	neutralized = nil
+
+	unsigned := encode(effectorKey)
+	unsigned.Header.Del(signing.SignatureHeader)
+	assert.ErrorIs(t, handle(unsigned), signing.ErrUnsigned)
+
+	altered := encode(effectorKey)
+	altered.Data = bytes.Replace(altered.Data, []byte(`"track-1"`), []byte(`"track-2"`), 1)
+	assert.ErrorIs(t, handle(altered), signing.ErrBadSignature)
+
+	// Another agent's key cannot sign for the effector
+	assert.ErrorIs(t, handle(encode(plannerKey)), signing.ErrUnknownKey)
+	assert.Empty(t, neutralized, "no refused assessment reaches the correlator")
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/audit"
+	"github.com/agile-defense/cjadc2/pkg/breakglass"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 key from the RFC 6238 test vectors
+var rfc6238Secret = []byte("12345678901234567890")
+
+// TestTOTPRFC6238Vectors verifies codes against the RFC 6238 SHA1 vectors (last six digits)
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		assert.Equal(t, want, breakglass.TOTP(rfc6238Secret, time.Unix(unix, 0)), "t=%d", unix)
+	}
+}
+
+// TestVerifyTOTP verifies one step of clock drift is tolerated and no more
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := breakglass.TOTP(rfc6238Secret, now)
+
+	assert.True(t, breakglass.VerifyTOTP(rfc6238Secret, code, now))
+	assert.True(t, breakglass.VerifyTOTP(rfc6238Secret, code, now.Add(breakglass.TOTPStep)))
+	assert.True(t, breakglass.VerifyTOTP(rfc6238Secret, code, now.Add(-breakglass.TOTPStep)))
+	assert.False(t, breakglass.VerifyTOTP(rfc6238Secret, code, now.Add(3*breakglass.TOTPStep)))
+
+	assert.False(t, breakglass.VerifyTOTP(rfc6238Secret, "000000", now))
+	assert.False(t, breakglass.VerifyTOTP(rfc6238Secret, "", now))
+	assert.False(t, breakglass.VerifyTOTP(nil, code, now), "users without a secret never verify")
+}
+
+// TestParseSecrets verifies the user=BASE32SECRET list format
+func TestParseSecrets(t *testing.T) {
+	secrets, err := breakglass.ParseSecrets("watch-officer-1=JBSWY3DPEHPK3PXP, tao-1 = jbswy3dpehpk3pxp ,")
+	require.NoError(t, err)
+	assert.Len(t, secrets, 2)
+	assert.Equal(t, []byte("Hello!\xde\xad\xbe\xef"), secrets["watch-officer-1"])
+	assert.Equal(t, secrets["watch-officer-1"], secrets["tao-1"])
+
+	empty, err := breakglass.ParseSecrets("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	_, err = breakglass.ParseSecrets("no-separator")
+	assert.Error(t, err)
+	_, err = breakglass.ParseSecrets("user=not*base32")
+	assert.Error(t, err)
+}
+
+// TestClampDuration verifies grants default to 15 minutes and never exceed an hour
+func TestClampDuration(t *testing.T) {
+	assert.Equal(t, breakglass.DefaultDuration, breakglass.ClampDuration(0))
+	assert.Equal(t, breakglass.DefaultDuration, breakglass.ClampDuration(-time.Minute))
+	assert.Equal(t, 5*time.Minute, breakglass.ClampDuration(5*time.Minute))
+	assert.Equal(t, breakglass.MaxDuration, breakglass.ClampDuration(8*time.Hour))
+}
+
+// TestGrantActive verifies expired, revoked, and unverified grants confer no authority
+func TestGrantActive(t *testing.T) {
+	now := time.Now()
+	grant := breakglass.Grant{
+		Status:               breakglass.StatusActive,
+		SecondFactorVerified: true,
+		ActivatedAt:          now.Add(-time.Minute),
+		ExpiresAt:            now.Add(time.Minute),
+	}
+	assert.True(t, grant.Active(now))
+	assert.False(t, grant.Active(now.Add(time.Minute)), "expires at ExpiresAt")
+
+	unverified := grant
+	unverified.SecondFactorVerified = false
+	assert.False(t, unverified.Active(now))
+
+	revoked := grant
+	revokedAt := now
+	revoked.RevokedAt = &revokedAt
+	assert.False(t, revoked.Active(now))
+
+	expired := grant
+	expired.Status = breakglass.StatusExpired
+	assert.False(t, expired.Active(now))
+}
+
+// fakeAuthorityChecker returns a canned approval authority decision
+type fakeAuthorityChecker struct {
+	decision map[string]interface{}
+	err      error
+	grants   interface{}
+}
+
+func (f *fakeAuthorityChecker) CheckApprovalAuthority(_ context.Context, _, _ string, grants interface{}) (map[string]interface{}, error) {
+	f.grants = grants
+	return f.decision, f.err
+}
+
+// TestAuthorize verifies the policy decision is mapped onto an Authority
+func TestAuthorize(t *testing.T) {
+	grants := []breakglass.Grant{{GrantID: "g-1", Role: "commander"}}
+	checker := &fakeAuthorityChecker{decision: map[string]interface{}{
+		"allowed":           true,
+		"role":              "commander",
+		"break_glass":       true,
+		"break_glass_grant": "g-1",
+		"reasons":           []interface{}{},
+	}}
+
+	auth, err := breakglass.Authorize(context.Background(), checker, "watch-officer-1", "engage", grants)
+	require.NoError(t, err)
+	assert.True(t, auth.Allowed)
+	assert.Equal(t, "commander", auth.Role)
+	assert.Equal(t, "g-1", auth.GrantID)
+	assert.Equal(t, grants, checker.grants, "active grants are passed to the policy")
+
+	checker.decision = map[string]interface{}{
+		"allowed":           false,
+		"role":              "watch_officer",
+		"break_glass_grant": "",
+		"reasons":           []interface{}{"role watch_officer may not approve engage"},
+	}
+	auth, err = breakglass.Authorize(context.Background(), checker, "watch-officer-1", "engage", nil)
+	require.NoError(t, err)
+	assert.False(t, auth.Allowed)
+	assert.Empty(t, auth.GrantID)
+	assert.Equal(t, []string{"role watch_officer may not approve engage"}, auth.Reasons)
+
+	checker.err = errors.New("opa unavailable")
+	_, err = breakglass.Authorize(context.Background(), checker, "watch-officer-1", "engage", nil)
+	assert.Error(t, err)
+}
+
+// TestDecisionRecordBreakGlassPayload verifies only break-glass decisions change their chained payload
+func TestDecisionRecordBreakGlassPayload(t *testing.T) {
+	record := audit.DecisionRecord{
+		DecisionID: "D-1",
+		ProposalID: "P-1",
+		Approved:   true,
+		ApprovedBy: "operator",
+		ApprovedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		ActionType: "engage",
+		TrackID:    "TRK-1",
+	}
+	assert.NotContains(t, string(record.Payload()), "break_glass_grant_id")
+
+	record.BreakGlassGrantID = "ABC-123"
+	assert.Contains(t, string(record.Payload()), `"break_glass_grant_id":"abc-123"`)
+}
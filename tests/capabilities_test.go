@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCapabilitiesAgent creates a base agent with a small capabilities document
+func newCapabilitiesAgent(t *testing.T) *agent.BaseAgent {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "planner-test", Type: agent.AgentTypePlanner})
+	require.NoError(t, err)
+
+	base.SetCapabilities(agent.Capabilities{
+		Messages: []agent.MessageCapability{
+			{Type: "correlated_track", Subject: "track.correlated.>", Stream: "TRACKS", Direction: agent.DirectionConsumes},
+		},
+		ConfigSchema: []agent.ConfigField{
+			{Name: "postgres_url", Type: "url", Env: "POSTGRES_URL", Description: "PostgreSQL URL"},
+		},
+	})
+	return base
+}
+
+// TestAgentCapabilitiesDocument verifies identity and common entries are merged into the document
+func TestAgentCapabilitiesDocument(t *testing.T) {
+	caps := newCapabilitiesAgent(t).Capabilities()
+
+	assert.Equal(t, agent.CapabilitiesSchemaVersion, caps.SchemaVersion)
+	assert.Equal(t, "planner-test", caps.AgentID)
+	assert.Equal(t, agent.AgentTypePlanner, caps.AgentType)
+	assert.Equal(t, agent.Version, caps.Version)
+	require.Len(t, caps.Messages, 1)
+
+	paths := make([]string, 0, len(caps.Routes))
+	for _, r := range caps.Routes {
+		paths = append(paths, r.Path)
+	}
+	assert.Contains(t, paths, "/capabilities")
+	assert.Contains(t, paths, "/health")
+
+	envs := make([]string, 0, len(caps.ConfigSchema))
+	for _, f := range caps.ConfigSchema {
+		envs = append(envs, f.Env)
+	}
+	assert.Contains(t, envs, "NATS_URL")
+	assert.Contains(t, envs, "POSTGRES_URL")
+}
+
+// TestGatewayAgentDiscovery verifies the gateway aggregates reachable and unreachable agents
+func TestGatewayAgentDiscovery(t *testing.T) {
+	base := newCapabilitiesAgent(t)
+	agentServer := httptest.NewServer(base.CapabilitiesHandler())
+	defer agentServer.Close()
+
+	urls := map[string]string{
+		"planner": agentServer.URL,
+		"missing": "http://127.0.0.1:1",
+	}
+	h := handler.NewAgentHandler(urls, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list handler.AgentListResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Agents, 2)
+
+	assert.Equal(t, "missing", list.Agents[0].Name)
+	assert.False(t, list.Agents[0].Reachable)
+	assert.NotEmpty(t, list.Agents[0].Error)
+
+	assert.Equal(t, "planner", list.Agents[1].Name)
+	assert.True(t, list.Agents[1].Reachable)
+	require.NotNil(t, list.Agents[1].Capabilities)
+	assert.Equal(t, "planner-test", list.Agents[1].Capabilities.AgentID)
+
+	rec = httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown/capabilities", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestParseAgentURLs verifies overrides are merged with the defaults
+func TestParseAgentURLs(t *testing.T) {
+	urls := handler.ParseAgentURLs("planner=http://localhost:9094/, bridge=http://bridge:9090, bogus")
+
+	assert.Equal(t, "http://localhost:9094", urls["planner"])
+	assert.Equal(t, "http://bridge:9090", urls["bridge"])
+	assert.Equal(t, handler.DefaultAgentURLs["sensor"], urls["sensor"])
+	assert.NotContains(t, urls, "bogus")
+}
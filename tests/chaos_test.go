@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/chaos"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// chaosMsg records how a fetched message was settled
+type chaosMsg struct {
+	jetstream.Msg
+	acked, naked bool
+}
+
+// Ack records an acknowledgement
+func (m *chaosMsg) Ack() error { m.acked = true; return nil }
+
+// Nak records a negative acknowledgement
+func (m *chaosMsg) Nak() error { m.naked = true; return nil }
+
+// chaosPlan returns a plan with fault applied to stage
+func chaosPlan(t *testing.T, stage string, fault chaos.Fault) chaos.Plan {
+	t.Helper()
+	plan, err := chaos.Plan{}.With(stage, fault, "tester", time.Now())
+	require.NoError(t, err)
+	return plan
+}
+
+// TestChaosPlanValidation verifies stage and fault bounds are enforced
+func TestChaosPlanValidation(t *testing.T) {
+	now := time.Now()
+	plan := chaos.Plan{}
+
+	_, err := plan.With("sensor", chaos.Fault{DropPercent: 10}, "tester", now)
+	assert.Error(t, err, "sensor does not consume pipeline messages")
+
+	for _, bad := range []chaos.Fault{
+		{LatencyMs: -1},
+		{LatencyMs: chaos.MaxLatency.Milliseconds() + 1},
+		{DropPercent: 101},
+		{NakPercent: -5},
+		{DropPercent: 60, NakPercent: 50},
+	} {
+		_, err := plan.With("planner", bad, "tester", now)
+		assert.Error(t, err, "%+v", bad)
+	}
+
+	next, err := plan.With("planner", chaos.Fault{LatencyMs: 200, NakPercent: 25}, "tester", now)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), next.Seq)
+	assert.Equal(t, int64(200), next.Faults["planner"].LatencyMs)
+	assert.Empty(t, plan.Faults, "With must not modify the original plan")
+
+	cleared, err := next.With("planner", chaos.Fault{}, "tester", now)
+	require.NoError(t, err)
+	assert.NotContains(t, cleared.Faults, "planner", "a zero fault clears the stage")
+	assert.Equal(t, uint64(2), cleared.Seq)
+
+	assert.Empty(t, next.Cleared("tester", now).Faults)
+}
+
+// TestChaosInjectorIntercept verifies drops are acked, Naks are Nak'd, other
+// stages are untouched, and stale plans are ignored
+func TestChaosInjectorIntercept(t *testing.T) {
+	ctx := context.Background()
+
+	drop := chaos.NewInjector("classifier")
+	require.True(t, drop.Apply(chaosPlan(t, "classifier", chaos.Fault{DropPercent: 100})))
+	msg := &chaosMsg{}
+	assert.True(t, drop.Intercept(ctx, msg))
+	assert.True(t, msg.acked)
+	assert.False(t, msg.naked)
+
+	nak := chaos.NewInjector("effector")
+	require.True(t, nak.Apply(chaosPlan(t, "effector", chaos.Fault{NakPercent: 100})))
+	msg = &chaosMsg{}
+	assert.True(t, nak.Intercept(ctx, msg))
+	assert.True(t, msg.naked)
+
+	other := chaos.NewInjector("planner")
+	require.True(t, other.Apply(chaosPlan(t, "effector", chaos.Fault{NakPercent: 100})))
+	msg = &chaosMsg{}
+	assert.False(t, other.Intercept(ctx, msg), "faults apply only to their own stage")
+	assert.False(t, msg.acked || msg.naked)
+
+	slow := chaos.NewInjector("correlator")
+	require.True(t, slow.Apply(chaosPlan(t, "correlator", chaos.Fault{LatencyMs: 50})))
+	start := time.Now()
+	assert.False(t, slow.Intercept(ctx, &chaosMsg{}))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	assert.False(t, slow.Apply(chaos.Plan{Seq: 1}), "a plan that is not newer is ignored")
+
+	var disabled *chaos.Injector
+	assert.False(t, disabled.Intercept(ctx, &chaosMsg{}), "a nil injector injects nothing")
+}
+
+// TestChaosAgentFlag verifies agents only get an injector when CHAOS_ENABLED is set
+func TestChaosAgentFlag(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "chaos-off", Type: agent.AgentTypePlanner})
+	require.NoError(t, err)
+	assert.Nil(t, base.Chaos())
+
+	base, err = agent.NewBaseAgent(agent.Config{
+		ID:        "chaos-on",
+		Type:      agent.AgentTypePlanner,
+		ExtraVars: map[string]string{"CHAOS_ENABLED": "true"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, base.Chaos())
+}
+
+// TestChaosHandlerGuards verifies the API refuses changes when disabled and
+// validates them before publishing
+func TestChaosHandlerGuards(t *testing.T) {
+	disabled := handler.NewChaosHandler(nil, false, zerolog.Nop()).Routes()
+
+	rec := httptest.NewRecorder()
+	disabled.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"enabled":false`)
+
+	rec = httptest.NewRecorder()
+	disabled.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/planner", strings.NewReader(`{"drop_percent":10}`)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	enabled := handler.NewChaosHandler(nil, true, zerolog.Nop()).Routes()
+
+	rec = httptest.NewRecorder()
+	enabled.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/planner", strings.NewReader(`{"drop_percent":150}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	enabled.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/planner", strings.NewReader(`{"drop_percent":10}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "a valid change still needs NATS")
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
+)
+
+// TestParseClaimTimeout verifies the claim timeout default and validation
+func TestParseClaimTimeout(t *testing.T) {
+	d, err := handler.ParseClaimTimeout("")
+	require.NoError(t, err)
+	assert.Equal(t, handler.DefaultClaimTimeout, d)
+
+	d, err = handler.ParseClaimTimeout("90s")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+
+	for _, value := range []string{"0s", "-1m", "soon"} {
+		_, err := handler.ParseClaimTimeout(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestProposalClaimNotificationSubject verifies claim events publish under notify.claim
+func TestProposalClaimNotificationSubject(t *testing.T) {
+	for _, event := range []string{messages.ClaimClaimed, messages.ClaimReleased, messages.ClaimExpired} {
+		n := &messages.ProposalClaimNotification{Event: event, ProposalID: "prop-1", ClaimedBy: "operator-1"}
+		assert.Equal(t, "notify.claim."+event, n.Subject())
+	}
+}
+
+// TestClaimRoutes verifies claim routes exist only with claims enabled and need an operator, the authenticated one when there is one
+func TestClaimRoutes(t *testing.T) {
+	request := func(h *handler.ProposalHandler, method, body string) int {
+		req := httptest.NewRequest(method, "/prop-1/claim", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.Routes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	h := handler.NewProposalHandler(nil, nil, nil, twoperson.Rule{}, zerolog.Nop())
+	assert.Equal(t, http.StatusNotFound, request(h, http.MethodPost, ""))
+
+	h.SetClaims(handler.NewClaimHandler(nil, nil, 0, zerolog.Nop()))
+	assert.Equal(t, http.StatusBadRequest, request(h, http.MethodPost, ""))
+	assert.Equal(t, http.StatusBadRequest, request(h, http.MethodDelete, ""))
+	assert.Equal(t, http.StatusBadRequest, request(h, http.MethodPost, "not json"))
+
+	// An authenticated operator cannot claim or release for another
+	for _, method := range []string{http.MethodPost, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/prop-1/claim", strings.NewReader(`{"user_id":"bob"}`))
+		req.Header.Set(handler.UserIDHeader, "alice")
+		rec := httptest.NewRecorder()
+		handler.UserIDMiddleware(h.Routes()).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code, method)
+	}
+}
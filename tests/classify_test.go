@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// classifyDetection builds a detection with the given kinematics for rule tests
+func classifyDetection(trackID string, lat, lon, alt, speed, confidence float64) *messages.Detection {
+	return &messages.Detection{
+		TrackID:    trackID,
+		Position:   messages.Position{Lat: lat, Lon: lon, Alt: alt},
+		Velocity:   messages.Velocity{Speed: speed},
+		Confidence: confidence,
+		SensorType: "radar",
+	}
+}
+
+// TestClassifyDefaultTypeRules verifies the default rules reproduce the original type heuristics
+func TestClassifyDefaultTypeRules(t *testing.T) {
+	engine := classify.NewDefaultEngine()
+
+	cases := []struct {
+		name     string
+		det      *messages.Detection
+		wantType string
+	}{
+		{"high fast flyer", classifyDetection("T1", 35, -115, 11000, 250, 0.5), "aircraft"},
+		{"climbing missile", classifyDetection("T2", 35, -115, 2000, 800, 0.5), "missile"},
+		{"slow surface in the Pacific", classifyDetection("T3", 20, -150, 0, 10, 0.5), "vessel"},
+		{"slow surface in the South Atlantic", classifyDetection("T4", -20, -10, 0, 10, 0.5), "vessel"},
+		{"slow surface over land", classifyDetection("T5", 35, -80, 0, 10, 0.5), "ground"},
+		{"low aircraft", classifyDetection("T6", 35, -115, 3000, 150, 0.5), "aircraft"},
+		{"stationary", classifyDetection("T7", 35, -115, 3000, 0, 0.5), "ground"},
+		{"no match", classifyDetection("T8", 35, -115, 7000, 400, 0.5), "unknown"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.wantType, engine.Classify(tc.det).Type, tc.name)
+	}
+
+	hinted := classifyDetection("T9", 35, -115, 0, 0, 0.5)
+	hinted.Type = "missile"
+	result := engine.Classify(hinted)
+	assert.Equal(t, "missile", result.Type, "the sensor's type hint is trusted")
+	assert.Empty(t, result.TypeRule)
+}
+
+// TestClassifyDefaultClassificationRules verifies the default rules reproduce the original classification cascade
+func TestClassifyDefaultClassificationRules(t *testing.T) {
+	engine := classify.NewDefaultEngine()
+
+	assert.Equal(t, "neutral", engine.Classify(classifyDetection("N-100", 35, -115, 11000, 250, 0.5)).Classification)
+	assert.Equal(t, "friendly", engine.Classify(classifyDetection("F-100", 35, -115, 11000, 250, 0.5)).Classification)
+	assert.Equal(t, "hostile", engine.Classify(classifyDetection("H-100", 35, -115, 11000, 250, 0.5)).Classification)
+	assert.Equal(t, "neutral", engine.Classify(classifyDetection("T-100", 35, -115, 11000, 250, 0.9)).Classification)
+	assert.Equal(t, "unknown", engine.Classify(classifyDetection("T-100", 35, -115, 11000, 250, 0.85)).Classification)
+
+	missile := classifyDetection("T-200", 35, -115, 2000, 800, 0.5)
+	result := engine.Classify(missile)
+	assert.Equal(t, "hostile", result.Classification)
+	assert.Equal(t, "fast-missile", result.ClassificationRule)
+
+	assert.Equal(t, "neutral", engine.Classify(classifyDetection("N-200", 35, -115, 2000, 800, 0.5)).Classification,
+		"neutral IDs are checked before the missile pattern")
+}
+
+// TestClassifyRulesHotSwap verifies replaced rules take effect and are evaluated in order
+func TestClassifyRulesHotSwap(t *testing.T) {
+	engine := classify.NewDefaultEngine()
+	det := classifyDetection("T-300", 35, -80, 50, 40, 0.5)
+	det.SensorType = "eo"
+	assert.Equal(t, "ground", engine.Classify(det).Type)
+
+	maxSpeed := 60.0
+	rules := append(classify.DefaultRules(), classify.Rule{
+		Name: "eo-slow-flyer", Kind: classify.KindType, Result: "uav", Enabled: true,
+		SensorTypes: []string{"eo"}, MaxSpeed: &maxSpeed, EvaluationOrder: 5,
+	})
+	engine.SetRules(rules)
+
+	result := engine.Classify(det)
+	assert.Equal(t, "uav", result.Type)
+	assert.Equal(t, "eo-slow-flyer", result.TypeRule)
+
+	det.SensorType = "radar"
+	assert.Equal(t, "ground", engine.Classify(det).Type, "sensor type condition must match")
+
+	rules[len(rules)-1].Enabled = false
+	engine.SetRules(rules)
+	det.SensorType = "eo"
+	assert.Equal(t, "ground", engine.Classify(det).Type, "disabled rules are skipped")
+}
+
+// TestClassifyRuleValidate verifies malformed rules are rejected
+func TestClassifyRuleValidate(t *testing.T) {
+	low, high := 10.0, 5.0
+
+	for _, rule := range classify.DefaultRules() {
+		assert.NoError(t, rule.Validate(), rule.Name)
+	}
+
+	assert.Error(t, classify.Rule{Kind: classify.KindType, Result: "aircraft"}.Validate(), "name required")
+	assert.Error(t, classify.Rule{Name: "r", Kind: classify.KindType}.Validate(), "result required")
+	assert.Error(t, classify.Rule{Name: "r", Kind: "threat", Result: "x"}.Validate(), "unknown kind")
+	assert.Error(t, classify.Rule{Name: "r", Kind: classify.KindClassification, Result: "enemy"}.Validate(), "unknown classification")
+	assert.Error(t, classify.Rule{Name: "r", Kind: classify.KindType, Result: "uav", TrackTypes: []string{"aircraft"}}.Validate(), "track_types on a type rule")
+	assert.Error(t, classify.Rule{Name: "r", Kind: classify.KindType, Result: "uav", MinSpeed: &low, MaxSpeed: &high}.Validate(), "inverted bounds")
+}
+
+// TestClassificationHysteresis verifies a track's classification changes only after consistent or confident detections
+func TestClassificationHysteresis(t *testing.T) {
+	ctx := context.Background()
+	cfg := classify.HysteresisConfig{MinConsecutive: 3, Margin: 0.3, StateTTL: time.Minute}
+	h := classify.NewHysteresis(cfg, classify.NewMemoryStateStore(cfg.StateTTL))
+	decide := func(proposed string, confidence float64) classify.Decision {
+		d, err := h.Decide(ctx, "T1", proposed, confidence, time.Now())
+		require.NoError(t, err)
+		return d
+	}
+
+	assert.Equal(t, "unknown", decide("unknown", 0.5).Classification, "first classification is published")
+
+	// Noise alternating between hostile and unknown never flips the track
+	for i := 0; i < 4; i++ {
+		d := decide("hostile", 0.6)
+		assert.True(t, d.Held)
+		assert.Equal(t, "unknown", d.Classification)
+		assert.Equal(t, "unknown", decide("unknown", 0.5).Classification)
+	}
+
+	decide("hostile", 0.6)
+	decide("hostile", 0.6)
+	d := decide("hostile", 0.6)
+	assert.True(t, d.Changed, "third consecutive detection flips")
+	assert.Equal(t, "hostile", d.Classification)
+
+	d = decide("friendly", 0.95)
+	assert.True(t, d.Changed, "a confident detection flips at once")
+
+	off := classify.NewHysteresis(classify.HysteresisConfig{MinConsecutive: 1}, classify.NewMemoryStateStore(time.Minute))
+	off.Decide(ctx, "T2", "unknown", 0.5, time.Now())
+	d, err := off.Decide(ctx, "T2", "hostile", 0.5, time.Now())
+	require.NoError(t, err)
+	assert.True(t, d.Changed)
+
+	_, err = classify.ParseHysteresisConfig("0", "", "")
+	assert.Error(t, err)
+	_, err = classify.ParseHysteresisConfig("", "1.5", "")
+	assert.Error(t, err)
+	parsed, err := classify.ParseHysteresisConfig("", "", "30s")
+	require.NoError(t, err)
+	assert.Equal(t, classify.DefaultMinConsecutive, parsed.MinConsecutive)
+	assert.Equal(t, 30*time.Second, parsed.StateTTL)
+}
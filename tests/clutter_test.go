@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/clutter"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// TestClutterDisabledByDefault verifies the default configuration injects nothing
+func TestClutterDisabledByDefault(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cfg := clutter.DefaultConfig()
+	require.NoError(t, cfg.Validate())
+	assert.False(t, cfg.Enabled())
+
+	gen := clutter.NewGenerator()
+	for i := 0; i < 60; i++ {
+		assert.Empty(t, gen.Step(rng, cfg, time.Second, []string{"radar-1"}))
+	}
+}
+
+// TestClutterRate verifies clutter spawns at roughly the configured rate
+func TestClutterRate(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	cfg := clutter.Config{RatePerMinute: 30, MinLifetimeSec: 1, MaxLifetimeSec: 1}
+	gen := clutter.NewGenerator()
+
+	// One-second lifetimes mean every object reported on a scan is new
+	spawned := 0
+	for i := 0; i < 600; i++ {
+		spawned += len(gen.Step(rng, cfg, time.Second, []string{"radar-1"}))
+	}
+	assert.InDelta(t, 300, spawned, 45) // 10 minutes at 30 per minute
+}
+
+// TestClutterLifetime verifies clutter vanishes once its lifetime is spent
+func TestClutterLifetime(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	cfg := clutter.Config{RatePerMinute: 60, MinLifetimeSec: 5, MaxLifetimeSec: 10}
+	gen := clutter.NewGenerator()
+
+	seen := make(map[string]int)
+	for i := 0; i < 60; i++ {
+		for _, o := range gen.Step(rng, cfg, time.Second, []string{"radar-1"}) {
+			seen[o.TrackID]++
+		}
+	}
+	require.NotEmpty(t, seen)
+
+	cfg.RatePerMinute = 0
+	for i := 0; i < 10; i++ {
+		gen.Step(rng, cfg, time.Second, []string{"radar-1"})
+	}
+	assert.Zero(t, gen.Len(), "stopping the rate lets live clutter expire")
+
+	for id, scans := range seen {
+		assert.True(t, strings.HasPrefix(id, "U-CL-"), id)
+		assert.LessOrEqual(t, scans, 10, "%s outlived its lifetime", id)
+	}
+}
+
+// TestClutterKinds verifies only the configured kinds are injected, each
+// looking like a low-confidence track
+func TestClutterKinds(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	cfg := clutter.Config{RatePerMinute: 120, MinLifetimeSec: 5, MaxLifetimeSec: 5, Kinds: []string{clutter.KindBirds}}
+	gen := clutter.NewGenerator()
+
+	for i := 0; i < 30; i++ {
+		for _, o := range gen.Step(rng, cfg, time.Second, []string{"radar-1", "eo-1"}) {
+			assert.Equal(t, clutter.KindBirds, o.Kind)
+			assert.Contains(t, []string{"radar-1", "eo-1"}, o.SensorID)
+			assert.Less(t, o.Velocity.Speed, 25.0)
+			assert.Less(t, o.Position.Alt, 1600.0)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		assert.Less(t, clutter.Confidence(rng, clutter.KindBirds), 0.4)
+		assert.Less(t, clutter.Confidence(rng, clutter.KindJamming), 0.4)
+	}
+}
+
+// TestClutterMaxObjects verifies spawning stops at the live object cap
+func TestClutterMaxObjects(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	cfg := clutter.Config{RatePerMinute: clutter.MaxRatePerMinute, MinLifetimeSec: 600, MaxLifetimeSec: 600}
+	gen := clutter.NewGenerator()
+
+	for i := 0; i < 60; i++ {
+		gen.Step(rng, cfg, time.Second, []string{"radar-1"})
+	}
+	assert.Equal(t, clutter.MaxObjects, gen.Len())
+}
+
+// TestClutterConfigValidate verifies out-of-range clutter settings are rejected
+func TestClutterConfigValidate(t *testing.T) {
+	cases := map[string]clutter.Config{
+		"negative rate":     {RatePerMinute: -1, MinLifetimeSec: 5, MaxLifetimeSec: 30},
+		"rate too high":     {RatePerMinute: clutter.MaxRatePerMinute + 1, MinLifetimeSec: 5, MaxLifetimeSec: 30},
+		"zero lifetime":     {RatePerMinute: 10, MinLifetimeSec: 0, MaxLifetimeSec: 30},
+		"inverted lifetime": {RatePerMinute: 10, MinLifetimeSec: 30, MaxLifetimeSec: 5},
+		"lifetime too long": {RatePerMinute: 10, MinLifetimeSec: 5, MaxLifetimeSec: clutter.MaxLifetimeSec + 1},
+		"unknown kind":      {RatePerMinute: 10, MinLifetimeSec: 5, MaxLifetimeSec: 30, Kinds: []string{"ghosts"}},
+	}
+	for name, cfg := range cases {
+		assert.Error(t, cfg.Validate(), name)
+	}
+
+	cfg := clutter.Config{RatePerMinute: 10}.WithDefaults()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, clutter.DefaultMinLifetimeSec, cfg.MinLifetimeSec)
+	assert.Equal(t, clutter.DefaultMaxLifetimeSec, cfg.MaxLifetimeSec)
+}
+
+// TestClutterParse verifies CLUTTER_RATE and CLUTTER_KINDS parsing
+func TestClutterParse(t *testing.T) {
+	cfg, err := clutter.Parse("", "")
+	require.NoError(t, err)
+	assert.Equal(t, clutter.DefaultConfig(), cfg)
+
+	cfg, err = clutter.Parse("12.5", "birds, weather")
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, cfg.RatePerMinute)
+	assert.Equal(t, []string{"birds", "weather"}, cfg.Kinds)
+
+	_, err = clutter.Parse("lots", "")
+	assert.Error(t, err)
+	_, err = clutter.Parse("5", "birds,ufo")
+	assert.Error(t, err)
+}
+
+// TestClutterTruth verifies ground truth identifies a spurious detection
+// without anything being added to the detection itself
+func TestClutterTruth(t *testing.T) {
+	det := &messages.Detection{
+		Envelope:   messages.NewEnvelope("sensor-1", "sensor"),
+		TrackID:    "U-CL-0000abcd",
+		Type:       "unknown",
+		Confidence: 0.2,
+		SensorType: "radar",
+		SensorID:   "radar-1",
+	}
+	before, err := json.Marshal(det)
+	require.NoError(t, err)
+
+	truth := clutter.NewTruth(det, clutter.KindWeather)
+	assert.Equal(t, det.Envelope.MessageID, truth.MessageID)
+	assert.Equal(t, "truth.detection.radar-1", truth.Subject())
+	assert.True(t, truth.Spurious)
+
+	after, err := json.Marshal(det)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+	assert.NotContains(t, string(after), "spurious")
+
+	data, err := truth.Marshal()
+	require.NoError(t, err)
+	var decoded clutter.Truth
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, truth.Kind, decoded.Kind)
+	assert.True(t, decoded.Spurious)
+}
+
+// TestGroundTruthStream verifies ground truth has its own stream that no
+// detection subject feeds
+func TestGroundTruthStream(t *testing.T) {
+	stream, ok := natsutil.StreamConfigs["GROUNDTRUTH"]
+	require.True(t, ok)
+	assert.Equal(t, []string{"truth.>"}, stream.Subjects)
+	assert.NotContains(t, natsutil.StreamConfigs["DETECTIONS"].Subjects, "truth.>")
+
+	consumers, ok := natsutil.PipelineConsumers["GROUNDTRUTH"]
+	require.True(t, ok, "ground truth is purged with the pipeline on exercise reset")
+	assert.Empty(t, consumers)
+}
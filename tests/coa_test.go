@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/coa"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// coaTrack builds a correlated track for course-of-action scoring
+func coaTrack(classification, threatLevel string, threatScore float64) *messages.CorrelatedTrack {
+	return &messages.CorrelatedTrack{
+		TrackID:        "trk-1",
+		Classification: classification,
+		Type:           "aircraft",
+		ThreatLevel:    threatLevel,
+		ThreatScore:    threatScore,
+	}
+}
+
+// TestCOAParseMaxOptions verifies PROPOSAL_OPTIONS parsing
+func TestCOAParseMaxOptions(t *testing.T) {
+	n, err := coa.ParseMaxOptions("")
+	require.NoError(t, err)
+	assert.Equal(t, coa.DefaultMaxOptions, n)
+
+	n, err = coa.ParseMaxOptions("5")
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	for _, bad := range []string{"0", "-2", "three"} {
+		_, err := coa.ParseMaxOptions(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestCOAGenerateRanksAlternatives verifies the recommended action leads and
+// alternatives follow by descending score
+func TestCOAGenerateRanksAlternatives(t *testing.T) {
+	track := coaTrack("hostile", "critical", 90)
+	options := coa.Generate(track, "intercept", 3)
+	require.Len(t, options, 3)
+
+	assert.Equal(t, "intercept", options[0].ActionType)
+	for i, o := range options {
+		assert.Equal(t, i+1, o.Rank)
+		assert.InDelta(t, o.Benefit-o.Risk, o.Score, 0.11, o.ActionType)
+		assert.NotEmpty(t, o.Rationale)
+	}
+	assert.Equal(t, "engage", options[1].ActionType, "a critical hostile track is best answered by engagement")
+	assert.GreaterOrEqual(t, options[1].Score, options[2].Score)
+}
+
+// TestCOAGenerateFitsTheThreat verifies the best alternative matches how
+// forceful a response the track calls for
+func TestCOAGenerateFitsTheThreat(t *testing.T) {
+	friendly := coa.Generate(coaTrack("friendly", "low", 0), "track", 5)
+	require.NotEmpty(t, friendly)
+	assert.Equal(t, "monitor", friendly[1].ActionType)
+	for _, o := range friendly {
+		assert.NotEqual(t, "engage", o.ActionType, "engagement is only offered against hostile tracks")
+	}
+
+	unknown := coa.Generate(coaTrack("unknown", "high", 0), "intercept", 2)
+	require.Len(t, unknown, 2)
+	assert.Equal(t, "identify", unknown[1].ActionType)
+
+	passive, _ := coa.Score(coaTrack("hostile", "critical", 95), "monitor")
+	forceful, _ := coa.Score(coaTrack("hostile", "critical", 95), "engage")
+	assert.Greater(t, passive.Risk, forceful.Risk, "watching a critical threat risks leaving it unanswered")
+}
+
+// TestCOAGenerateLimits verifies the option count and unscored recommendations
+func TestCOAGenerateLimits(t *testing.T) {
+	track := coaTrack("hostile", "high", 0)
+	only := coa.Generate(track, "intercept", 1)
+	require.Len(t, only, 1)
+	assert.Equal(t, "intercept", only[0].ActionType)
+
+	assert.Nil(t, coa.Generate(track, "ignore", 3), "ignore has no profile to score")
+	assert.Len(t, coa.Generate(track, "intercept", 10), 5, "every profiled action is offered at most once")
+}
+
+// TestCOASelect verifies an approver's selection resolves against the offered options
+func TestCOASelect(t *testing.T) {
+	options := coa.Generate(coaTrack("hostile", "high", 0), "intercept", 3)
+
+	selected, err := coa.Select(options, "intercept", "")
+	require.NoError(t, err)
+	assert.Equal(t, "intercept", selected, "no selection approves the recommended action")
+
+	selected, err = coa.Select(options, "intercept", options[2].ActionType)
+	require.NoError(t, err)
+	assert.Equal(t, options[2].ActionType, selected)
+
+	_, err = coa.Select(options, "intercept", "ignore")
+	assert.ErrorIs(t, err, coa.ErrUnknownOption)
+
+	_, err = coa.Select(nil, "track", "engage")
+	assert.ErrorIs(t, err, coa.ErrUnknownOption, "proposals without options only approve their action")
+}
+
+// TestCOAOptionsValidate verifies proposals carrying options pass their schema
+// and options of unknown actions do not
+func TestCOAOptionsValidate(t *testing.T) {
+	proposal := schemaFixtures()[messages.SchemaActionProposal].(*messages.ActionProposal)
+	proposal.Options = coa.Generate(proposal.Track, proposal.ActionType, coa.DefaultMaxOptions)
+	require.NotEmpty(t, proposal.Options)
+
+	data, _, err := messages.Marshal(proposal, messages.EncodingJSON)
+	require.NoError(t, err)
+	assert.NoError(t, messages.Validate(messages.SchemaActionProposal, data))
+
+	proposal.Options[0].ActionType = "launch"
+	data, _, err = messages.Marshal(proposal, messages.EncodingJSON)
+	require.NoError(t, err)
+	assert.Error(t, messages.Validate(messages.SchemaActionProposal, data))
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// settleMsg is a fetched message that records how it was settled; unused
+// jetstream.Msg methods panic
+type settleMsg struct {
+	jetstream.Msg
+	settled string
+	delay   time.Duration
+}
+
+func (m *settleMsg) Ack() error  { m.settled = "ack"; return nil }
+func (m *settleMsg) Nak() error  { m.settled = "nak"; return nil }
+func (m *settleMsg) Term() error { m.settled = "term"; return nil }
+
+func (m *settleMsg) NakWithDelay(delay time.Duration) error {
+	m.settled, m.delay = "nak", delay
+	return nil
+}
+
+// handleOne handles a single message as Consume does with opts
+func handleOne(t *testing.T, opts agent.ConsumeOptions, err error) *settleMsg {
+	t.Helper()
+	base := newPoolAgent(t, "1")
+	msg := &settleMsg{}
+	ch := make(chan jetstream.Msg, 1)
+	ch <- msg
+	close(ch)
+	base.HandleBatch(context.Background(), ch, func(context.Context, jetstream.Msg) error { return err }, opts)
+	return msg
+}
+
+func TestHandleBatchSettlement(t *testing.T) {
+	assert.Equal(t, "ack", handleOne(t, agent.ConsumeOptions{}, nil).settled)
+	assert.Equal(t, "nak", handleOne(t, agent.ConsumeOptions{}, errors.New("database unavailable")).settled)
+
+	undecodable := agent.Permanent(errors.New("failed to unmarshal"))
+	assert.Equal(t, "term", handleOne(t, agent.ConsumeOptions{}, undecodable).settled)
+	wrapped := fmt.Errorf("proposal P1: %w", undecodable)
+	assert.Equal(t, "term", handleOne(t, agent.ConsumeOptions{}, wrapped).settled, "permanence survives wrapping")
+	assert.Nil(t, agent.Permanent(nil))
+}
+
+func TestHandleBatchManualAck(t *testing.T) {
+	opts := agent.ConsumeOptions{ManualAck: true}
+	assert.Empty(t, handleOne(t, opts, nil).settled, "the handler settles the message later")
+	assert.Equal(t, "nak", handleOne(t, opts, errors.New("database unavailable")).settled)
+}
+
+func TestHandleBatchNakDelay(t *testing.T) {
+	msg := handleOne(t, agent.ConsumeOptions{NakDelay: 5 * time.Second}, errors.New("partner unreachable"))
+	assert.Equal(t, "nak", msg.settled)
+	assert.Equal(t, 5*time.Second, msg.delay)
+}
+
+func TestHandleBatchWithoutKeyKeepsFetchOrder(t *testing.T) {
+	base := newPoolAgent(t, "4")
+	var msgs []*poolMsg
+	for seq := 0; seq < 20; seq++ {
+		msgs = append(msgs, &poolMsg{track: fmt.Sprintf("T%d", seq), seq: seq})
+	}
+
+	var seen []int
+	base.HandleBatch(context.Background(), poolBatch(msgs...), func(_ context.Context, msg jetstream.Msg) error {
+		seen = append(seen, msg.(*poolMsg).seq)
+		return nil
+	}, agent.ConsumeOptions{})
+
+	for i, seq := range seen {
+		assert.Equal(t, i, seq)
+	}
+	assert.Len(t, seen, 20)
+}
+
+func TestConsumerGone(t *testing.T) {
+	assert.True(t, natsutil.ConsumerGone(errors.New("nats: no responders available for request")))
+	assert.True(t, natsutil.ConsumerGone(jetstream.ErrConsumerNotFound))
+	assert.True(t, natsutil.ConsumerGone(jetstream.ErrConsumerDeleted))
+	assert.False(t, natsutil.ConsumerGone(context.DeadlineExceeded))
+	assert.False(t, natsutil.ConsumerGone(nil))
+}
@@ -0,0 +1,76 @@
+//go:build integration
+
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// TestSetupConsumerUpdatesStaleFilter verifies a durable created with an old
+// subject filter is moved to its configured filter rather than reused. It
+// needs a JetStream server at NATS_URL and is skipped when NATS_URL is unset.
+func TestSetupConsumerUpdatesStaleFilter(t *testing.T) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		t.Skip("NATS_URL not set")
+	}
+
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A stream and consumer of the test's own, so a live pipeline is untouched
+	id := uuid.New().String()[:8]
+	streamName := "TEST_FILTER_" + id
+	prefix := "test.filter." + id
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{prefix + ".>"},
+		Storage:  jetstream.MemoryStorage,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { js.DeleteStream(context.Background(), streamName) })
+
+	consumerName := "test-filter-" + id
+	cfg := jetstream.ConsumerConfig{
+		Durable:       consumerName,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: prefix + ".input.>",
+	}
+	natsutil.ConsumerConfigs[consumerName] = cfg
+	t.Cleanup(func() { delete(natsutil.ConsumerConfigs, consumerName) })
+
+	stale := cfg
+	stale.FilterSubject = prefix + ".>"
+	_, err = stream.CreateConsumer(ctx, stale)
+	require.NoError(t, err)
+
+	consumer, err := natsutil.SetupConsumer(ctx, js, streamName, consumerName)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.FilterSubject, consumer.CachedInfo().Config.FilterSubject)
+
+	stored, err := stream.Consumer(ctx, consumerName)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.FilterSubject, stored.CachedInfo().Config.FilterSubject, "the stored consumer should carry the configured filter")
+
+	// A consumer already on its configured filter is reused as it is
+	again, err := natsutil.SetupConsumer(ctx, js, streamName, consumerName)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.FilterSubject, again.CachedInfo().Config.FilterSubject)
+}
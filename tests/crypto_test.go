@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/crypto"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// payloadKey returns a base64 payload key filled with b
+func payloadKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), crypto.KeySize)))
+}
+
+// usePayloadKeys installs a keyring for the test and removes it afterwards
+func usePayloadKeys(t *testing.T, keys *crypto.Keyring) {
+	t.Helper()
+	messages.SetPayloadKeys(keys)
+	t.Cleanup(func() { messages.SetPayloadKeys(nil) })
+}
+
+// TestParseKeyring verifies PAYLOAD_KEYS and PAYLOAD_KEY_ID parsing
+func TestParseKeyring(t *testing.T) {
+	keys, err := crypto.ParseKeyring("", "")
+	require.NoError(t, err)
+	assert.Nil(t, keys)
+
+	keys, err = crypto.ParseKeyring("k1:"+payloadKey('a')+", k2:"+payloadKey('b'), "")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keys.ActiveKeyID(), "the last key listed is active by default")
+	assert.Equal(t, []string{"k1", "k2"}, keys.KeyIDs())
+
+	keys, err = crypto.ParseKeyring("k1:"+payloadKey('a')+",k2:"+payloadKey('b'), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keys.ActiveKeyID())
+
+	for _, bad := range [][2]string{
+		{"k1", ""},
+		{"k1:not-base64!", ""},
+		{"k1:" + base64.StdEncoding.EncodeToString([]byte("short")), ""},
+		{"k1:" + payloadKey('a') + ",k1:" + payloadKey('b'), ""},
+		{"k1:" + payloadKey('a'), "k9"},
+		{"", "k1"},
+	} {
+		_, err := crypto.ParseKeyring(bad[0], bad[1])
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestKeyringRotation verifies payloads sealed under a retired key still open
+// while it stays in the ring, and fail once it is removed
+func TestKeyringRotation(t *testing.T) {
+	old, err := crypto.ParseKeyring("k1:"+payloadKey('a'), "")
+	require.NoError(t, err)
+	keyID, sealed, err := old.Seal([]byte("track data"), []byte("msg-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+
+	rotated, err := crypto.ParseKeyring("k1:"+payloadKey('a')+",k2:"+payloadKey('b'), "k2")
+	require.NoError(t, err)
+	plaintext, err := rotated.Open(keyID, sealed, []byte("msg-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "track data", string(plaintext))
+
+	_, err = rotated.Open(keyID, sealed, []byte("msg-2"))
+	assert.Error(t, err, "the payload is bound to its message ID")
+
+	retired, err := crypto.ParseKeyring("k2:"+payloadKey('b'), "")
+	require.NoError(t, err)
+	_, err = retired.Open(keyID, sealed, []byte("msg-1"))
+	assert.ErrorIs(t, err, crypto.ErrUnknownKey)
+}
+
+// TestEncryptionPolicy verifies ENCRYPT_CLASSIFICATIONS parsing
+func TestEncryptionPolicy(t *testing.T) {
+	policy, err := crypto.ParsePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{seclabel.Secret, seclabel.TopSecret}, policy.Labels())
+	assert.True(t, policy.Requires(seclabel.Secret))
+	assert.False(t, policy.Requires(seclabel.Confidential))
+	assert.False(t, policy.Requires(""))
+
+	policy, err = crypto.ParsePolicy("none")
+	require.NoError(t, err)
+	assert.False(t, policy.Requires(seclabel.TopSecret))
+
+	policy, err = crypto.ParsePolicy("confidential, secret")
+	require.NoError(t, err)
+	assert.True(t, policy.Requires(seclabel.Confidential))
+
+	_, err = crypto.ParsePolicy("secret,restricted")
+	assert.Error(t, err)
+}
+
+// TestSealedMessageRoundTrip verifies sealed messages in either encoding
+// decode, validate, and convert to JSON with the payload keys, and keep the
+// envelope readable without them
+func TestSealedMessageRoundTrip(t *testing.T) {
+	keys, err := crypto.ParseKeyring("k1:"+payloadKey('a'), "")
+	require.NoError(t, err)
+
+	for _, enc := range []messages.Encoding{messages.EncodingJSON, messages.EncodingProtobuf} {
+		var d messages.Detection
+		require.NoError(t, json.Unmarshal(sinkDetection(t, "TRK-SEALED"), &d))
+		d.Envelope.Classification = seclabel.Secret
+
+		data, contentType, err := messages.Marshal(&d, enc)
+		require.NoError(t, err)
+		sealed, err := messages.Seal(&d, data, contentType, keys)
+		require.NoError(t, err)
+		assert.NotContains(t, string(sealed), "TRK-SEALED", "the payload must not travel in the clear")
+
+		var outer messages.SealedMessage
+		require.NoError(t, json.Unmarshal(sealed, &outer))
+		assert.Equal(t, d.Envelope.MessageID, outer.Envelope.MessageID)
+		assert.Equal(t, "k1", outer.Envelope.KeyID)
+		assert.Equal(t, seclabel.Secret, outer.Envelope.Classification)
+
+		messages.SetPayloadKeys(nil)
+		var none messages.Detection
+		assert.ErrorIs(t, messages.Unmarshal(messages.ContentTypeSealed, sealed, &none), messages.ErrNoPayloadKeys)
+
+		usePayloadKeys(t, keys)
+		var got messages.Detection
+		require.NoError(t, messages.Unmarshal(messages.ContentTypeSealed, sealed, &got), enc)
+		assert.Equal(t, "TRK-SEALED", got.TrackID)
+		assert.Equal(t, "k1", got.Envelope.KeyID)
+		assert.Equal(t, seclabel.Secret, got.Envelope.Classification)
+
+		require.NoError(t, messages.ValidateEncoded(messages.SchemaDetection, messages.ContentTypeSealed, sealed))
+		converted, err := messages.ToJSON(messages.SchemaDetection, messages.ContentTypeSealed, sealed)
+		require.NoError(t, err)
+		assert.Contains(t, string(converted), "TRK-SEALED")
+	}
+}
+
+// TestEncoderSealsByLabel verifies the shared encoder the gateway publishes
+// through labels unlabelled messages and seals those its policy selects
+func TestEncoderSealsByLabel(t *testing.T) {
+	keys, err := crypto.ParseKeyring("k1:"+payloadKey('a'), "")
+	require.NoError(t, err)
+	policy, err := crypto.ParsePolicy("")
+	require.NoError(t, err)
+	usePayloadKeys(t, keys)
+
+	encoder := messages.Encoder{
+		Encoding:       messages.EncodingJSON,
+		Classification: seclabel.Unclassified,
+		Releasability:  []string{"FVEY"},
+		Encryption:     policy,
+		PayloadKeys:    keys,
+	}
+
+	claim := &messages.ProposalClaimNotification{
+		Envelope:   messages.NewEnvelope("api-gateway", "api-gateway"),
+		ProposalID: "P-CLEAR",
+	}
+	data, contentType, err := encoder.Encode(claim)
+	require.NoError(t, err)
+	assert.Equal(t, messages.ContentTypeJSON, contentType)
+	assert.Contains(t, string(data), "P-CLEAR")
+	assert.Equal(t, []string{"FVEY"}, claim.Envelope.Releasability, "unlabelled messages take the publisher's label")
+
+	decision := &messages.Decision{
+		Envelope:   messages.NewEnvelope("api-gateway", "authorizer").WithLabels(messages.Envelope{Classification: seclabel.Secret}),
+		DecisionID: "D-SEALED",
+		ProposalID: "P-SEALED",
+	}
+	data, contentType, err = encoder.Encode(decision)
+	require.NoError(t, err)
+	assert.Equal(t, messages.ContentTypeSealed, contentType)
+	assert.NotContains(t, string(data), "D-SEALED", "a secret decision must not travel in the clear")
+
+	var got messages.Decision
+	require.NoError(t, messages.Unmarshal(contentType, data, &got))
+	assert.Equal(t, "D-SEALED", got.DecisionID)
+
+	encoder.PayloadKeys = nil
+	_, _, err = encoder.Encode(decision)
+	assert.Error(t, err, "a message that must be sealed is refused without keys")
+}
+
+// TestAgentEncryptionConfig verifies an agent whose data must be encrypted
+// refuses to start without payload keys
+func TestAgentEncryptionConfig(t *testing.T) {
+	t.Cleanup(func() { messages.SetPayloadKeys(nil) })
+
+	for name, vars := range map[string]map[string]string{
+		"secret without keys":     {"DATA_CLASSIFICATION": "secret"},
+		"unknown classification":  {"DATA_CLASSIFICATION": "cosmic"},
+		"unknown encrypted label": {"ENCRYPT_CLASSIFICATIONS": "restricted"},
+		"malformed keys":          {"PAYLOAD_KEYS": "k1"},
+	} {
+		_, err := agent.NewBaseAgent(agent.Config{ID: "crypto-test", Type: agent.AgentTypeClassifier, ExtraVars: vars})
+		assert.Error(t, err, name)
+	}
+
+	_, err := agent.NewBaseAgent(agent.Config{ID: "crypto-test", Type: agent.AgentTypeClassifier, ExtraVars: map[string]string{
+		"DATA_CLASSIFICATION": "secret",
+		"PAYLOAD_KEYS":        "k1:" + payloadKey('a'),
+	}})
+	require.NoError(t, err)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/ctl"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestCtlDecide verifies decisions are posted as the operator and published and partial outcomes are told apart
+func TestCtlDecide(t *testing.T) {
+	var received handler.DecisionRequest
+	var userHeader string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userHeader = r.Header.Get(handler.UserIDHeader)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		switch r.URL.Path {
+		case "/api/v1/proposals/prop-1/decision":
+			handler.WriteJSON(w, http.StatusCreated, handler.DecisionResponse{DecisionID: "dec-1", ProposalID: "prop-1", Approved: received.Approved, ApprovedBy: received.ApprovedBy})
+		case "/api/v1/proposals/prop-2/decision":
+			handler.WriteJSON(w, http.StatusAccepted, handler.PartialApprovalResponse{ProposalID: "prop-2", Status: "awaiting_second_approval", Approvals: []messages.Approval{{ApprovedBy: "operator-1"}}, RequiredApprovals: 2})
+		default:
+			handler.WriteError(w, http.StatusConflict, "Proposal is not pending", "corr-1")
+		}
+	}))
+	defer gateway.Close()
+
+	client := ctl.NewClient(gateway.URL, "", "operator-1", time.Second)
+	ctx := context.Background()
+
+	outcome, err := client.Decide(ctx, "prop-1", false, "not hostile")
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Decision)
+	assert.Nil(t, outcome.Pending)
+	assert.Equal(t, "dec-1", outcome.Decision.DecisionID)
+	assert.Equal(t, "operator-1", userHeader)
+	assert.Equal(t, "operator-1", received.ApprovedBy)
+	assert.Equal(t, "not hostile", received.Reason)
+	assert.False(t, received.Approved)
+
+	outcome, err = client.Decide(ctx, "prop-2", true, "")
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Pending)
+	assert.Equal(t, 2, outcome.Pending.RequiredApprovals)
+
+	_, err = client.Decide(ctx, "prop-3", true, "")
+	var apiErr *ctl.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "Proposal is not pending", apiErr.Message)
+}
+
+// TestCtlHealth verifies an unhealthy gateway still reports its components and degrades pipeline health
+func TestCtlHealth(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			handler.WriteJSON(w, http.StatusServiceUnavailable, ctl.GatewayHealth{Status: "unhealthy", Components: map[string]string{"nats": "unhealthy: disconnected"}})
+		case "/api/v1/agents":
+			handler.WriteJSON(w, http.StatusOK, handler.AgentListResponse{Agents: []handler.AgentCapabilitiesResponse{{Name: "sensor", Reachable: true}}})
+		case "/api/v1/metrics/stages":
+			handler.WriteJSON(w, http.StatusOK, handler.StageMetricsResponse{Stages: []handler.StageMetricResponse{{Stage: "classifier", MessagesTotal: 10}}})
+		}
+	}))
+	defer gateway.Close()
+
+	health, err := ctl.NewClient(gateway.URL, "", "", time.Second).Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "unhealthy: disconnected", health.Gateway.Components["nats"])
+	assert.Len(t, health.Agents, 1)
+	assert.Len(t, health.Stages, 1)
+	assert.False(t, health.Healthy())
+
+	health.Gateway.Status = "healthy"
+	assert.True(t, health.Healthy())
+	health.Agents[0].Reachable = false
+	assert.False(t, health.Healthy())
+}
+
+// TestCtlParseConfigFields verifies key=value settings keep their JSON types
+func TestCtlParseConfigFields(t *testing.T) {
+	fields, err := ctl.ParseConfigFields([]string{"emission_interval_ms=500", "paused=true", `type_weights={"aircraft":70}`, "mode=burst"})
+	require.NoError(t, err)
+	assert.Equal(t, float64(500), fields["emission_interval_ms"])
+	assert.Equal(t, true, fields["paused"])
+	assert.Equal(t, map[string]interface{}{"aircraft": float64(70)}, fields["type_weights"])
+	assert.Equal(t, "burst", fields["mode"])
+
+	_, err = ctl.ParseConfigFields([]string{"paused"})
+	assert.Error(t, err)
+	_, err = ctl.ParseConfigFields([]string{"=true"})
+	assert.Error(t, err)
+}
+
+// TestCtlResolveStreams verifies stream selection and that protected streams are refused
+func TestCtlResolveStreams(t *testing.T) {
+	names, err := ctl.ResolveStreams([]string{"detections", "TRACKS"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DETECTIONS", "TRACKS"}, names)
+
+	all, err := ctl.ResolveStreams(nil, true)
+	require.NoError(t, err)
+	assert.Contains(t, all, "DETECTIONS")
+	assert.NotContains(t, all, "SIMCONTROL")
+
+	for _, bad := range [][]string{nil, {"NOPE"}, {"SIMCONTROL"}} {
+		_, err := ctl.ResolveStreams(bad, false)
+		assert.Error(t, err, bad)
+	}
+	_, err = ctl.ResolveStreams([]string{"TRACKS"}, true)
+	assert.Error(t, err)
+}
+
+// TestCtlAuditFollower verifies followed polls print only new entries, oldest first
+func TestCtlAuditFollower(t *testing.T) {
+	f := ctl.NewAuditFollower()
+	entry := func(id string) handler.AuditEntryResponse { return handler.AuditEntryResponse{ID: id} }
+	ids := func(entries []handler.AuditEntryResponse) []string {
+		out := make([]string, len(entries))
+		for i, e := range entries {
+			out[i] = e.ID
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"a1", "a2"}, ids(f.Next([]handler.AuditEntryResponse{entry("a2"), entry("a1")})))
+	assert.Equal(t, []string{"a3", "a4"}, ids(f.Next([]handler.AuditEntryResponse{entry("a4"), entry("a3"), entry("a2")})))
+	assert.Empty(t, f.Next([]handler.AuditEntryResponse{entry("a4"), entry("a3")}))
+}
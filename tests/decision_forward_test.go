@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
+)
+
+// TestParseDecisionForwardConfig verifies forwarding defaults and validation
+func TestParseDecisionForwardConfig(t *testing.T) {
+	cfg, err := handler.ParseDecisionForwardConfig("", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, handler.DecisionForwardNATS, cfg.Mode)
+	assert.Equal(t, handler.DefaultAgentURLs["authorizer"], cfg.AuthorizerURL)
+	assert.Equal(t, handler.DefaultDecisionForwardTimeout, cfg.Timeout)
+
+	cfg, err = handler.ParseDecisionForwardConfig("http", "http://localhost:9095/", "3s")
+	require.NoError(t, err)
+	assert.Equal(t, handler.DecisionForwardHTTP, cfg.Mode)
+	assert.Equal(t, "http://localhost:9095", cfg.AuthorizerURL)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+
+	_, err = handler.ParseDecisionForwardConfig("grpc", "", "")
+	assert.Error(t, err)
+	_, err = handler.ParseDecisionForwardConfig("", "", "0s")
+	assert.Error(t, err)
+}
+
+// TestHTTPDecisionForwarder verifies authorizer HTTP replies, problems, and plain-text errors map to decision command replies
+func TestHTTPDecisionForwarder(t *testing.T) {
+	var received messages.DecisionCommand
+	authorizer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/decisions", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		switch received.ProposalID {
+		case "published":
+			json.NewEncoder(w).Encode(messages.DecisionCommandReply{Status: messages.DecisionCommandPublished, Code: http.StatusOK, DecisionID: "dec-1"})
+		case "pending":
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(messages.DecisionCommandReply{
+				Status:            messages.DecisionCommandPending,
+				Code:              http.StatusAccepted,
+				Approvals:         []messages.Approval{{ApprovedBy: "operator-1", Role: "commander"}},
+				RequiredApprovals: twoperson.RequiredApprovals,
+			})
+		case "refused":
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(messages.DecisionCommandReply{Status: messages.DecisionCommandRejected, Code: http.StatusForbidden, Error: "not authorized to approve engage"})
+		case "malformed":
+			problem.Write(w, problem.New(problem.CodeValidation, "Invalid request body").For(r))
+		default:
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		}
+	}))
+	defer authorizer.Close()
+
+	cfg, err := handler.ParseDecisionForwardConfig("http", authorizer.URL, "")
+	require.NoError(t, err)
+	f := handler.NewDecisionForwarder(cfg, nil)
+	ctx := context.Background()
+
+	reply, err := f.Forward(ctx, messages.DecisionCommand{ProposalID: "published", Approved: true, ApprovedBy: "operator-1"})
+	require.NoError(t, err)
+	assert.Equal(t, messages.DecisionCommandPublished, reply.Status)
+	assert.Equal(t, "dec-1", reply.DecisionID)
+	assert.Equal(t, "operator-1", received.ApprovedBy)
+
+	reply, err = f.Forward(ctx, messages.DecisionCommand{ProposalID: "pending", Approved: true, ApprovedBy: "operator-1"})
+	require.NoError(t, err)
+	assert.Equal(t, messages.DecisionCommandPending, reply.Status)
+	assert.Len(t, reply.Approvals, 1)
+
+	reply, err = f.Forward(ctx, messages.DecisionCommand{ProposalID: "refused", Approved: true, ApprovedBy: "analyst-1"})
+	require.NoError(t, err)
+	assert.Equal(t, messages.DecisionCommandRejected, reply.Status)
+	assert.Equal(t, http.StatusForbidden, reply.Code)
+	assert.Equal(t, "not authorized to approve engage", reply.Error)
+
+	reply, err = f.Forward(ctx, messages.DecisionCommand{ProposalID: "malformed", ApprovedBy: "operator-1"})
+	require.NoError(t, err)
+	assert.Equal(t, messages.DecisionCommandRejected, reply.Status)
+	assert.Equal(t, http.StatusBadRequest, reply.Code)
+	assert.Equal(t, "Invalid request body", reply.Error, "problem+json errors yield their detail")
+	assert.Equal(t, string(problem.CodeValidation), reply.ErrorCode)
+
+	reply, err = f.Forward(ctx, messages.DecisionCommand{ProposalID: "proxied", ApprovedBy: "operator-1"})
+	require.NoError(t, err)
+	assert.Equal(t, messages.DecisionCommandRejected, reply.Status, "non-JSON errors are still rejections")
+	assert.Equal(t, http.StatusBadGateway, reply.Code)
+	assert.Equal(t, "upstream unavailable", reply.Error)
+}
+
+// TestDecisionForwarderUnavailable verifies an unreachable authorizer is reported as ErrNoAuthorizer
+func TestDecisionForwarderUnavailable(t *testing.T) {
+	cmd := messages.DecisionCommand{ProposalID: "prop-1", ApprovedBy: "operator-1"}
+
+	_, err := handler.NewDecisionForwarder(handler.DecisionForwardConfig{Mode: handler.DecisionForwardNATS, Timeout: time.Second}, nil).Forward(context.Background(), cmd)
+	assert.ErrorIs(t, err, handler.ErrNoAuthorizer)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	cfg := handler.DecisionForwardConfig{Mode: handler.DecisionForwardHTTP, AuthorizerURL: closed.URL, Timeout: time.Second}
+	_, err = handler.NewDecisionForwarder(cfg, nil).Forward(context.Background(), cmd)
+	assert.ErrorIs(t, err, handler.ErrNoAuthorizer)
+}
+
+// TestForwardDecisionWithoutForwarder verifies the proxy endpoint refuses decisions when forwarding is not configured
+func TestForwardDecisionWithoutForwarder(t *testing.T) {
+	h := handler.NewProposalHandler(nil, nil, nil, twoperson.Rule{}, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/prop-1/decision", strings.NewReader(`{"approved":true}`))
+	h.Routes().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+// TestCreateDecisionValidation verifies POST /api/v1/decisions needs a writer and a proposal ID
+func TestCreateDecisionValidation(t *testing.T) {
+	post := func(h *handler.DecisionHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		h.Routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	h := handler.NewDecisionHandler(nil, zerolog.Nop())
+	assert.Equal(t, http.StatusServiceUnavailable, post(h, `{"proposal_id":"prop-1","approved":true}`).Code)
+
+	h.SetWriter(handler.NewProposalHandler(nil, nil, nil, twoperson.Rule{}, zerolog.Nop()))
+	assert.Equal(t, http.StatusBadRequest, post(h, `{"approved":true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(h, `not json`).Code)
+}
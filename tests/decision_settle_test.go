@@ -0,0 +1,301 @@
+//go:build integration
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/postgres/migrations"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
+)
+
+// decisionPool connects to POSTGRES_URL and migrates it. The test is skipped
+// when POSTGRES_URL is unset. Decisions are append-only, so the proposals and
+// decisions a test writes are left in place under fresh IDs.
+func decisionPool(t *testing.T) *postgres.Pool {
+	t.Helper()
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := postgres.NewPoolFromURL(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	_, _, err = migrations.Up(ctx, db.Pool)
+	require.NoError(t, err)
+	return db
+}
+
+// insertProposal stores a proposal in the given status expiring at expiresAt
+func insertProposal(t *testing.T, db *postgres.Pool, actionType string, priority int, status string, expiresAt time.Time) string {
+	t.Helper()
+	id := uuid.New().String()
+	_, err := db.Exec(context.Background(), `
+		INSERT INTO proposals (proposal_id, track_id, action_type, priority, threat_level, rationale, status, expires_at)
+		VALUES ($1, $2, $3, $4, 'high', 'test proposal', $5, $6)
+	`, id, "TEST-"+id[:8], actionType, priority, status, expiresAt)
+	require.NoError(t, err)
+	return id
+}
+
+// testDecision builds a decision on a proposal by an operator
+func testDecision(proposalID, approvedBy string, approved bool) *messages.Decision {
+	return &messages.Decision{
+		Envelope:   messages.NewEnvelope("api-gateway", "authorizer"),
+		DecisionID: uuid.New().String(),
+		ProposalID: proposalID,
+		TrackID:    "TEST",
+		ActionType: "track",
+		Approved:   approved,
+		ApprovedBy: approvedBy,
+		ApprovedAt: time.Now().UTC(),
+	}
+}
+
+// proposalStatus reads a proposal's stored status
+func proposalStatus(t *testing.T, db *postgres.Pool, proposalID string) string {
+	t.Helper()
+	var status string
+	err := db.QueryRow(context.Background(), `SELECT status FROM proposals WHERE proposal_id = $1`, proposalID).Scan(&status)
+	require.NoError(t, err)
+	return status
+}
+
+// decisionCount counts the decisions stored for a proposal
+func decisionCount(t *testing.T, db *postgres.Pool, proposalID string) int {
+	t.Helper()
+	var n int
+	err := db.QueryRow(context.Background(), `SELECT COUNT(*) FROM decisions WHERE proposal_id = $1`, proposalID).Scan(&n)
+	require.NoError(t, err)
+	return n
+}
+
+// TestInsertDecisionSettlesOnce verifies the first decision settles a pending
+// proposal and a second is refused without storing anything
+func TestInsertDecisionSettlesOnce(t *testing.T) {
+	db := decisionPool(t)
+	ctx := context.Background()
+	id := insertProposal(t, db, "track", 5, "pending", time.Now().Add(time.Hour))
+
+	require.NoError(t, db.InsertDecision(ctx, testDecision(id, "alice", true)))
+	assert.Equal(t, "approved", proposalStatus(t, db, id))
+
+	err := db.InsertDecision(ctx, testDecision(id, "bob", false))
+	require.ErrorIs(t, err, postgres.ErrProposalNotPending)
+	assert.Contains(t, err.Error(), "approved")
+	assert.Equal(t, "approved", proposalStatus(t, db, id))
+	assert.Equal(t, 1, decisionCount(t, db, id))
+}
+
+// TestInsertDecisionRefusesExpiredProposal verifies a proposal past its expiry
+// is not settled even while its status is still pending
+func TestInsertDecisionRefusesExpiredProposal(t *testing.T) {
+	db := decisionPool(t)
+	id := insertProposal(t, db, "track", 5, "pending", time.Now().Add(-time.Minute))
+
+	err := db.InsertDecision(context.Background(), testDecision(id, "alice", true))
+	require.ErrorIs(t, err, postgres.ErrProposalNotPending)
+	assert.Contains(t, err.Error(), "expired")
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+	assert.Equal(t, 0, decisionCount(t, db, id))
+}
+
+// TestExpireProposalWithDecision verifies an expiry moves the proposal and
+// stores its decision together, that a failed decision leaves the proposal
+// pending to be retried, and that a proposal decided meanwhile is left alone
+func TestExpireProposalWithDecision(t *testing.T) {
+	db := decisionPool(t)
+	ctx := context.Background()
+	expire := func(id string, decision *messages.Decision) (bool, error) {
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+		expired, err := postgres.ExpireProposal(ctx, tx, id)
+		if err != nil || !expired {
+			return expired, err
+		}
+		if err := postgres.StoreDecision(ctx, tx, decision); err != nil {
+			return false, err
+		}
+		return true, tx.Commit(ctx)
+	}
+
+	id := insertProposal(t, db, "track", 5, "pending", time.Now().Add(-time.Minute))
+
+	// A decision that cannot be stored rolls the status back
+	bad := testDecision(id, "authorizer-001", false)
+	bad.DecisionID = "not-a-uuid"
+	_, err := expire(id, bad)
+	require.Error(t, err)
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+	assert.Equal(t, 0, decisionCount(t, db, id))
+
+	expired, err := expire(id, testDecision(id, "authorizer-001", false))
+	require.NoError(t, err)
+	assert.True(t, expired)
+	assert.Equal(t, "expired", proposalStatus(t, db, id))
+	assert.Equal(t, 1, decisionCount(t, db, id))
+
+	expired, err = expire(id, testDecision(id, "authorizer-002", false))
+	require.NoError(t, err)
+	assert.False(t, expired, "an expired proposal is not expired twice")
+	assert.Equal(t, 1, decisionCount(t, db, id))
+}
+
+// TestInsertDecisionRespectsClaims verifies a proposal claimed by one operator
+// is refused to another and settled by the claimant, releasing the claim
+func TestInsertDecisionRespectsClaims(t *testing.T) {
+	db := decisionPool(t)
+	ctx := context.Background()
+	id := insertProposal(t, db, "track", 5, "pending", time.Now().Add(time.Hour))
+	_, err := db.ClaimProposal(ctx, id, "alice", time.Minute, time.Now())
+	require.NoError(t, err)
+
+	err = db.InsertDecision(ctx, testDecision(id, "bob", true))
+	require.ErrorIs(t, err, postgres.ErrProposalClaimed)
+	assert.Contains(t, err.Error(), "alice")
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+	assert.Equal(t, 0, decisionCount(t, db, id))
+
+	require.NoError(t, db.InsertDecision(ctx, testDecision(id, "alice", false)))
+	assert.Equal(t, "denied", proposalStatus(t, db, id))
+	claims, err := db.GetProposalClaims(ctx, []string{id}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, claims)
+}
+
+// commanderOPA allows every approval with the commander role
+func commanderOPA(t *testing.T) *opa.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":{"allowed":true,"role":"commander"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return opa.NewClient(srv.URL)
+}
+
+// TestTwoPersonApprovalThroughDecisions verifies a two-person engage approval
+// completed through POST /api/v1/decisions stores one decision and settles
+// the proposal, and that a later approval is refused
+func TestTwoPersonApprovalThroughDecisions(t *testing.T) {
+	db := decisionPool(t)
+	id := insertProposal(t, db, "engage", 9, "pending", time.Now().Add(time.Hour))
+
+	proposals := handler.NewProposalHandler(db, nil, commanderOPA(t), twoperson.Rule{MinPriority: 8}, zerolog.Nop())
+	decisions := handler.NewDecisionHandler(db, zerolog.Nop())
+	decisions.SetWriter(proposals)
+	r := chi.NewRouter()
+	r.Mount("/api/v1/decisions", decisions.Routes())
+
+	approve := func(user string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"proposal_id": id, "approved": true, "approved_by": user})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/decisions", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := approve("alice")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+
+	rec = approve("bob")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "approved", proposalStatus(t, db, id))
+	assert.Equal(t, 1, decisionCount(t, db, id))
+
+	approvals, err := twoperson.Approvals(context.Background(), db, id)
+	require.NoError(t, err)
+	assert.Len(t, approvals, 2)
+
+	rec = approve("carol")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, 1, decisionCount(t, db, id))
+}
+
+// TestTwoPersonRefusesOneOperatorTwice verifies an operator who approved a
+// two-person proposal cannot complete it by sending a second approval under
+// another approved_by
+func TestTwoPersonRefusesOneOperatorTwice(t *testing.T) {
+	db := decisionPool(t)
+	id := insertProposal(t, db, "engage", 9, "pending", time.Now().Add(time.Hour))
+
+	proposals := handler.NewProposalHandler(db, nil, commanderOPA(t), twoperson.Rule{MinPriority: 8}, zerolog.Nop())
+	h := handler.UserIDMiddleware(proposals.Routes())
+	approve := func(user, approvedBy string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"approved": true, "approved_by": approvedBy})
+		req := httptest.NewRequest(http.MethodPost, "/"+id+"/decide", bytes.NewReader(body))
+		req.Header.Set(handler.UserIDHeader, user)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := approve("alice", "alice")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	rec = approve("alice", "bob")
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+	assert.Equal(t, 0, decisionCount(t, db, id))
+	approvals, err := twoperson.Approvals(context.Background(), db, id)
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+	assert.Equal(t, "alice", approvals[0].ApprovedBy)
+
+	rec = approve("bob", "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "approved", proposalStatus(t, db, id))
+}
+
+// TestClaimCheckedAgainstAuthenticatedUser verifies the claim on a proposal
+// is held against the authenticated caller, so another operator cannot
+// decide it by naming the claimant in approved_by
+func TestClaimCheckedAgainstAuthenticatedUser(t *testing.T) {
+	db := decisionPool(t)
+	id := insertProposal(t, db, "track", 5, "pending", time.Now().Add(time.Hour))
+
+	proposals := handler.NewProposalHandler(db, nil, commanderOPA(t), twoperson.Rule{}, zerolog.Nop())
+	proposals.SetClaims(handler.NewClaimHandler(db, nil, time.Minute, zerolog.Nop()))
+	h := handler.UserIDMiddleware(proposals.Routes())
+	send := func(user, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/"+id+path, bytes.NewReader([]byte(body)))
+		req.Header.Set(handler.UserIDHeader, user)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("alice", "/claim", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = send("bob", "/decide", `{"approved":true,"approved_by":"alice"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+	rec = send("bob", "/decide", `{"approved":true}`)
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "alice")
+	assert.Equal(t, "pending", proposalStatus(t, db, id))
+
+	rec = send("alice", "/decide", `{"approved":true}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "approved", proposalStatus(t, db, id))
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/deconflict"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// engagement builds an engagement for a track at a position
+func engagement(proposalID, trackID, actionType, assetID string, lat, lon float64) deconflict.Engagement {
+	return deconflict.Engagement{
+		ProposalID: proposalID,
+		TrackID:    trackID,
+		ActionType: actionType,
+		Position:   messages.Position{Lat: lat, Lon: lon},
+		AssetID:    assetID,
+		Status:     "pending",
+	}
+}
+
+// TestDeconflictParseConfig verifies DECONFLICT_RADIUS_METERS and DECONFLICT_WINDOW parsing
+func TestDeconflictParseConfig(t *testing.T) {
+	cfg, err := deconflict.ParseConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, deconflict.DefaultRadiusMeters, cfg.RadiusMeters)
+	assert.Equal(t, deconflict.DefaultWindow, cfg.Window)
+
+	cfg, err = deconflict.ParseConfig("2500", "90s")
+	require.NoError(t, err)
+	assert.Equal(t, 2500.0, cfg.RadiusMeters)
+	assert.Equal(t, 90*time.Second, cfg.Window)
+
+	cfg, err = deconflict.ParseConfig("0", "0")
+	require.NoError(t, err)
+	assert.Zero(t, cfg.RadiusMeters)
+
+	_, err = deconflict.ParseConfig("-1", "")
+	assert.Error(t, err)
+	_, err = deconflict.ParseConfig("", "soon")
+	assert.Error(t, err)
+}
+
+// TestDeconflictCheck verifies conflicts by shared asset and overlapping airspace
+func TestDeconflictCheck(t *testing.T) {
+	cfg := deconflict.Config{RadiusMeters: 10000, Window: time.Minute}
+	proposed := engagement("P-1", "TRK-1", "intercept", "A-1", 37.0, -115.0)
+
+	active := []deconflict.Engagement{
+		engagement("P-2", "TRK-2", "engage", "A-1", 38.0, -115.0),     // Same asset, far away
+		engagement("P-3", "TRK-3", "intercept", "A-2", 37.05, -115.0), // Close by, other asset
+		engagement("P-4", "TRK-4", "engage", "A-3", 38.0, -116.0),     // Far away, other asset
+		engagement("P-5", "TRK-1", "engage", "A-1", 37.0, -115.0),     // Same track, merged instead
+		engagement("P-6", "TRK-6", "identify", "", 37.0, -115.0),      // Not an engagement
+	}
+
+	conflicts := deconflict.Check(proposed, active, cfg)
+	require.Len(t, conflicts, 2)
+
+	assert.Equal(t, "P-2", conflicts[0].ProposalID)
+	assert.Equal(t, deconflict.KindAsset, conflicts[0].Kind)
+	assert.Equal(t, "A-1", conflicts[0].AssetID)
+	assert.InDelta(t, 111000, conflicts[0].DistanceMeters, 500)
+
+	assert.Equal(t, "P-3", conflicts[1].ProposalID)
+	assert.Equal(t, deconflict.KindAirspace, conflicts[1].Kind)
+	assert.Empty(t, conflicts[1].AssetID)
+	assert.InDelta(t, 5560, conflicts[1].DistanceMeters, 50)
+
+	assert.True(t, deconflict.HasAssetConflict(conflicts))
+	assert.False(t, deconflict.HasAssetConflict(conflicts[1:]))
+
+	cfg.RadiusMeters = 0
+	conflicts = deconflict.Check(proposed, active, cfg)
+	require.Len(t, conflicts, 1, "a zero radius disables airspace checks")
+	assert.Equal(t, deconflict.KindAsset, conflicts[0].Kind)
+
+	proposed.ActionType = "identify"
+	assert.Empty(t, deconflict.Check(proposed, active, cfg), "only engagements are deconflicted")
+}
+
+// TestDeconflictReserved verifies only other tracks' engagements reserve assets
+func TestDeconflictReserved(t *testing.T) {
+	active := []deconflict.Engagement{
+		engagement("P-1", "TRK-1", "engage", "A-1", 37.0, -115.0),
+		engagement("P-2", "TRK-2", "intercept", "A-2", 37.0, -115.0),
+		engagement("P-3", "TRK-3", "engage", "", 37.0, -115.0),
+	}
+	assert.Equal(t, map[string]bool{"A-2": true}, deconflict.Reserved("TRK-1", active))
+}
+
+// TestDeconflictFromProposal verifies proposals are described by their track position and asset
+func TestDeconflictFromProposal(t *testing.T) {
+	proposal := &messages.ActionProposal{
+		ProposalID: "P-1",
+		TrackID:    "TRK-1",
+		ActionType: "engage",
+		Track:      &messages.CorrelatedTrack{Position: messages.Position{Lat: 37.1, Lon: -115.2}},
+		Asset:      &messages.AssetAssignment{AssetID: "A-1"},
+	}
+	e := deconflict.FromProposal(proposal)
+	assert.Equal(t, "P-1", e.ProposalID)
+	assert.Equal(t, 37.1, e.Position.Lat)
+	assert.Equal(t, "A-1", e.AssetID)
+	assert.Equal(t, "pending", e.Status)
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/detectionsink"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// sinkMsg records how the sink settled a message; unused jetstream.Msg methods panic
+type sinkMsg struct {
+	jetstream.Msg
+	data    []byte
+	header  nats.Header
+	settled string
+}
+
+func (m *sinkMsg) Data() []byte                       { return m.data }
+func (m *sinkMsg) Headers() nats.Header               { return m.header }
+func (m *sinkMsg) Ack() error                         { m.settled = "ack"; return nil }
+func (m *sinkMsg) NakWithDelay(_ time.Duration) error { m.settled = "nak"; return nil }
+func (m *sinkMsg) Term() error                        { m.settled = "term"; return nil }
+
+// sinkWriter records the batches it is asked to store
+type sinkWriter struct {
+	batches [][]*messages.Detection
+	err     error
+}
+
+func (w *sinkWriter) BatchInsertDetections(_ context.Context, detections []*messages.Detection) (int64, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.batches = append(w.batches, detections)
+	return int64(len(detections)), nil
+}
+
+// sinkDetection builds a valid detection message body
+func sinkDetection(t *testing.T, trackID string) []byte {
+	t.Helper()
+	d := messages.NewDetection("sensor-001", "radar")
+	d.TrackID = trackID
+	d.Position = messages.Position{Lat: 35, Lon: -115, Alt: 3000}
+	d.Velocity = messages.Velocity{Speed: 200, Heading: 90}
+	d.Confidence = 0.8
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	return data
+}
+
+// sinkProtobufDetection builds a valid detection message body in protobuf
+func sinkProtobufDetection(t *testing.T, trackID string) []byte {
+	t.Helper()
+	var d messages.Detection
+	require.NoError(t, json.Unmarshal(sinkDetection(t, trackID), &d))
+	data, contentType, err := messages.Marshal(&d, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	require.Equal(t, messages.ContentTypeProtobuf, contentType)
+	return data
+}
+
+// TestDetectionSinkParseConfig verifies batching settings and their validation
+func TestDetectionSinkParseConfig(t *testing.T) {
+	cfg, err := detectionsink.ParseConfig("", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, detectionsink.DefaultConfig(), cfg)
+	assert.True(t, cfg.Enabled)
+
+	cfg, err = detectionsink.ParseConfig("false", "2000", "250ms")
+	require.NoError(t, err)
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, 2000, cfg.BatchSize)
+	assert.Equal(t, 250*time.Millisecond, cfg.FlushInterval)
+
+	_, err = detectionsink.ParseConfig("maybe", "", "")
+	assert.Error(t, err)
+	_, err = detectionsink.ParseConfig("", "0", "")
+	assert.Error(t, err)
+	_, err = detectionsink.ParseConfig("", "100000", "")
+	assert.Error(t, err)
+	_, err = detectionsink.ParseConfig("", "", "-1s")
+	assert.Error(t, err)
+}
+
+// TestDetectionSinkFlush verifies stored batches are acked, failed ones redelivered, and bad messages terminated
+func TestDetectionSinkFlush(t *testing.T) {
+	writer := &sinkWriter{}
+	sink := detectionsink.New(detectionsink.DefaultConfig(), nil, writer, zerolog.Nop())
+
+	good1 := &sinkMsg{data: sinkDetection(t, "T-1")}
+	good2 := &sinkMsg{data: sinkDetection(t, "T-2")}
+	bad := &sinkMsg{data: []byte("not json")}
+	invalid := &sinkMsg{data: []byte(`{"track_id":"T-4","confidence":2}`)}
+	encoded := &sinkMsg{data: sinkProtobufDetection(t, "T-5"), header: nats.Header{}}
+	encoded.header.Set(messages.ContentTypeHeader, messages.ContentTypeProtobuf)
+	sink.Flush(context.Background(), []jetstream.Msg{good1, bad, good2, invalid, encoded})
+
+	require.Len(t, writer.batches, 1)
+	assert.Len(t, writer.batches[0], 3, "one COPY per batch")
+	assert.Equal(t, "ack", good1.settled)
+	assert.Equal(t, "ack", good2.settled)
+	assert.Equal(t, "ack", encoded.settled, "protobuf detections are stored too")
+	assert.Equal(t, "T-5", writer.batches[0][2].TrackID)
+	assert.Equal(t, "term", bad.settled)
+	assert.Equal(t, "term", invalid.settled, "detections that break the schema are not stored")
+
+	writer.err = errors.New("database unavailable")
+	retry := &sinkMsg{data: sinkDetection(t, "T-3")}
+	sink.Flush(context.Background(), []jetstream.Msg{retry})
+	assert.Equal(t, "nak", retry.settled, "a failed write is redelivered")
+}
+
+// TestDetectionCopyRow verifies detections map to COPY rows and unstorable ones are rejected
+func TestDetectionCopyRow(t *testing.T) {
+	d := messages.NewDetection("sensor-001", "radar")
+	d.TrackID = "T-1"
+	d.Position = messages.Position{Lat: 35, Lon: -115, Alt: 3000}
+	d.Confidence = 0.8
+
+	row, err := postgres.DetectionCopyRow(d)
+	require.NoError(t, err)
+	require.Len(t, row, 13)
+	assert.Equal(t, uuid.MustParse(d.Envelope.MessageID), row[0])
+	assert.Equal(t, row[0], row[1], "a missing correlation ID falls back to the message ID")
+	assert.Equal(t, "T-1", *row[2].(*string))
+
+	d.Envelope.CorrelationID = uuid.New().String()
+	row, err = postgres.DetectionCopyRow(d)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.MustParse(d.Envelope.CorrelationID), row[1])
+
+	for name, mutate := range map[string]func(*messages.Detection){
+		"message ID":  func(d *messages.Detection) { d.Envelope.MessageID = "msg-1" },
+		"no sensor":   func(d *messages.Detection) { d.SensorID = "" },
+		"latitude":    func(d *messages.Detection) { d.Position.Lat = 91 },
+		"confidence":  func(d *messages.Detection) { d.Confidence = 1.5 },
+		"long track":  func(d *messages.Detection) { d.TrackID = string(make([]byte, 65)) },
+		"big heading": func(d *messages.Detection) { d.Velocity.Heading = 1200 },
+	} {
+		bad := *d
+		mutate(&bad)
+		_, err := postgres.DetectionCopyRow(&bad)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+// newDrainTestAgent creates a base agent with the given drain timeout
+func newDrainTestAgent(t *testing.T, drainTimeout string) *agent.BaseAgent {
+	t.Helper()
+	base, err := agent.NewBaseAgent(agent.Config{
+		ID:        "drain-test",
+		Type:      agent.AgentTypeClassifier,
+		ExtraVars: map[string]string{"DRAIN_TIMEOUT": drainTimeout},
+	})
+	require.NoError(t, err)
+	return base
+}
+
+// TestDrainWaitsForInFlightBatch tests that Drain stops new fetches and waits for the current batch
+func TestDrainWaitsForInFlightBatch(t *testing.T) {
+	base := newDrainTestAgent(t, "2s")
+
+	var fetched, processed atomic.Int32
+	started := make(chan struct{})
+	loopDone := make(chan struct{})
+
+	go func() {
+		defer close(loopDone)
+		done, ok := base.BeginConsuming()
+		if !ok {
+			return
+		}
+		defer done()
+
+		for {
+			select {
+			case <-base.Draining():
+				return
+			default:
+			}
+
+			// A batch of three messages that takes a while to process
+			fetched.Add(3)
+			if fetched.Load() == 3 {
+				close(started)
+			}
+			for i := 0; i < 3; i++ {
+				time.Sleep(50 * time.Millisecond)
+				processed.Add(1)
+			}
+		}
+	}()
+
+	<-started
+	require.NoError(t, base.Drain(context.Background()))
+	<-loopDone
+
+	assert.True(t, base.IsDraining())
+	assert.Equal(t, fetched.Load(), processed.Load(), "every fetched message should be processed before Drain returns")
+
+	_, ok := base.BeginConsuming()
+	assert.False(t, ok, "no new consume loop should start once draining")
+}
+
+// TestDrainTimeout tests that Drain gives up after the configured timeout
+func TestDrainTimeout(t *testing.T) {
+	base := newDrainTestAgent(t, "100ms")
+
+	done, ok := base.BeginConsuming()
+	require.True(t, ok)
+	defer done()
+
+	start := time.Now()
+	err := base.Drain(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestDrainWithoutConsumers tests that agents with no consume loops drain immediately
+func TestDrainWithoutConsumers(t *testing.T) {
+	base := newDrainTestAgent(t, "")
+	assert.False(t, base.IsDraining())
+	require.NoError(t, base.Drain(context.Background()))
+	require.NoError(t, base.Drain(context.Background()), "draining twice should be safe")
+}
+
+// TestDrainTimeoutConfig tests DRAIN_TIMEOUT validation
+func TestDrainTimeoutConfig(t *testing.T) {
+	for _, v := range []string{"soon", "-1s", "0s"} {
+		_, err := agent.NewBaseAgent(agent.Config{
+			ID:        "drain-test",
+			Type:      agent.AgentTypeClassifier,
+			ExtraVars: map[string]string{"DRAIN_TIMEOUT": v},
+		})
+		assert.Error(t, err, v)
+	}
+}
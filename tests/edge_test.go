@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+// TestLeafLinkConfig verifies the leaf node link settings are validated and
+// an agent without a remote domain reads from its own JetStream
+func TestLeafLinkConfig(t *testing.T) {
+	for name, vars := range map[string]map[string]string{
+		"both domains":      {"HUB_JS_DOMAIN": "hub", "UPSTREAM_JS_DOMAIN": "edge"},
+		"bad interval":      {"HUB_JS_DOMAIN": "hub", "LINK_CHECK_INTERVAL": "soon"},
+		"negative interval": {"LINK_CHECK_INTERVAL": "-5s"},
+	} {
+		_, err := agent.NewBaseAgent(agent.Config{ID: "edge-test", Type: agent.AgentTypeClassifier, ExtraVars: vars})
+		assert.Error(t, err, name)
+	}
+
+	base, err := agent.NewBaseAgent(agent.Config{ID: "edge-test", Type: agent.AgentTypeCorrelator, ExtraVars: map[string]string{
+		"UPSTREAM_JS_DOMAIN":  "edge",
+		"LINK_CHECK_INTERVAL": "2s",
+	}})
+	require.NoError(t, err)
+	assert.True(t, base.LinkUp(), "the link is assumed up until a check fails")
+	assert.Nil(t, base.UpstreamJetStream(), "the upstream domain is reached only once connected")
+}
+
+// TestLeafLinkCapabilities verifies edge and hub link settings are described
+func TestLeafLinkCapabilities(t *testing.T) {
+	envs := map[string]bool{}
+	for _, field := range append(append([]agent.ConfigField{}, agent.EdgeLinkConfig...), agent.UpstreamLinkConfig...) {
+		envs[field.Env] = true
+	}
+	for _, env := range []string{"HUB_JS_DOMAIN", "UPSTREAM_JS_DOMAIN", "LINK_CHECK_INTERVAL"} {
+		assert.True(t, envs[env], env)
+	}
+}
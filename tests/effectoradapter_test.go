@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/bda"
+	"github.com/agile-defense/cjadc2/pkg/effectoradapter"
+	"github.com/agile-defense/cjadc2/pkg/effectoradapter/adaptertest"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/simrand"
+)
+
+// TestSimulatorConformance runs the adapter conformance suite against the reference simulator
+func TestSimulatorConformance(t *testing.T) {
+	adaptertest.Run(t, func(t *testing.T) effectoradapter.Adapter {
+		adapter, err := effectoradapter.New(effectoradapter.SimulatorName, map[string]string{"speedup": "100"})
+		require.NoError(t, err)
+		return adapter
+	}, adaptertest.Options{ActionTypes: []string{"engage", "intercept", "identify", "track", "monitor"}})
+}
+
+// TestEffectorAdapterRegistry tests backend registration and lookup
+func TestEffectorAdapterRegistry(t *testing.T) {
+	registry := effectoradapter.NewRegistry()
+	factory := func(map[string]string) (effectoradapter.Adapter, error) {
+		return effectoradapter.NewSimulator(1), nil
+	}
+
+	require.NoError(t, registry.Register("alpha", factory))
+	require.NoError(t, registry.Register("bravo", factory))
+	assert.Error(t, registry.Register("alpha", factory), "duplicate names should be rejected")
+	assert.Equal(t, []string{"alpha", "bravo"}, registry.Names())
+
+	adapter, err := registry.New("alpha", nil)
+	require.NoError(t, err)
+	assert.Equal(t, effectoradapter.SimulatorName, adapter.Name())
+
+	_, err = registry.New("charlie", nil)
+	assert.Error(t, err)
+
+	assert.Contains(t, effectoradapter.DefaultRegistry.Names(), effectoradapter.SimulatorName)
+	_, err = effectoradapter.New(effectoradapter.SimulatorName, map[string]string{"speedup": "fast"})
+	assert.Error(t, err, "invalid backend config should fail")
+}
+
+// TestEffectorAdapterIdempotencyKey tests that the key matches the effects table format
+func TestEffectorAdapterIdempotencyKey(t *testing.T) {
+	assert.Equal(t, "dec-1-prop-1-engage", effectoradapter.IdempotencyKey("dec-1", "prop-1", "engage"))
+}
+
+// TestEffectorAdapterIdempotencyRetriesFailures tests that failed executions are not cached
+func TestEffectorAdapterIdempotencyRetriesFailures(t *testing.T) {
+	flaky := &flakyAdapter{failures: 1}
+	adapter := effectoradapter.WithIdempotency(flaky, effectoradapter.NewMemoryStore())
+	req := adaptertest.NewRequest("track")
+
+	_, err := adapter.Execute(context.Background(), req, effectoradapter.NopReporter)
+	require.Error(t, err)
+
+	first, err := adapter.Execute(context.Background(), req, effectoradapter.NopReporter)
+	require.NoError(t, err)
+	second, err := adapter.Execute(context.Background(), req, effectoradapter.NopReporter)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 2, flaky.calls, "a stored result should not be executed again")
+}
+
+// TestEffectorAdapterPermanentErrors tests permanent error classification
+func TestEffectorAdapterPermanentErrors(t *testing.T) {
+	base := errors.New("target rejected")
+	assert.False(t, effectoradapter.IsPermanent(base))
+	assert.True(t, effectoradapter.IsPermanent(effectoradapter.Permanent(base)))
+	assert.ErrorIs(t, effectoradapter.Permanent(base), base)
+	assert.True(t, effectoradapter.IsPermanent(errors.Join(errors.New("backend failed"), effectoradapter.ErrUnsupportedAction)))
+	assert.Nil(t, effectoradapter.Permanent(nil))
+}
+
+// TestEffectorAdapterProgressClient tests that progress is published as an EffectProgress notification
+func TestEffectorAdapterProgressClient(t *testing.T) {
+	publisher := &capturePublisher{}
+	client := effectoradapter.NewProgressClient(publisher, "effector-001", "simulated")
+	req := adaptertest.NewRequest("engage")
+
+	err := client.For(req).Report(context.Background(), effectoradapter.Progress{
+		Stage:   effectoradapter.StageExecuting,
+		Percent: 150,
+		Message: "weapon away",
+	})
+	require.NoError(t, err)
+	require.Len(t, publisher.subjects, 1)
+	assert.Equal(t, "notify.effect_progress.engage", publisher.subjects[0])
+
+	var update messages.EffectProgress
+	require.NoError(t, json.Unmarshal(publisher.payloads[0], &update))
+	assert.Equal(t, req.EffectID, update.EffectID)
+	assert.Equal(t, req.DecisionID, update.DecisionID)
+	assert.Equal(t, req.CorrelationID, update.Envelope.CorrelationID)
+	assert.Equal(t, "simulated", update.Backend)
+	assert.Equal(t, effectoradapter.StageExecuting, update.Stage)
+	assert.Equal(t, 100, update.Percent, "percent should be clamped")
+	assert.Equal(t, "weapon away", update.Message)
+}
+
+// flakyAdapter fails its first executions and then succeeds
+type flakyAdapter struct {
+	failures int
+	calls    int
+}
+
+func (f *flakyAdapter) Name() string         { return "flaky" }
+func (f *flakyAdapter) Supports(string) bool { return true }
+
+func (f *flakyAdapter) Execute(_ context.Context, req effectoradapter.Request, _ effectoradapter.Reporter) (effectoradapter.Result, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return effectoradapter.Result{}, errors.New("backend unavailable")
+	}
+	return effectoradapter.Result{Summary: "done", ExternalRef: req.EffectID}, nil
+}
+
+// capturePublisher records published messages
+type capturePublisher struct {
+	subjects []string
+	payloads [][]byte
+}
+
+func (p *capturePublisher) Publish(subject string, data []byte) error {
+	p.subjects = append(p.subjects, subject)
+	p.payloads = append(p.payloads, data)
+	return nil
+}
+
+// TestSimulatorOutcomeModels tests outcome model parsing and the outcomes drawn from them
+func TestSimulatorOutcomeModels(t *testing.T) {
+	models, err := effectoradapter.ParseOutcomeModels(`{"engage":{"success_probability":0,"partial_probability":1,"collateral_probability":1,"neutralizes":true}}`)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, models["engage"].PartialProbability, "named actions replace the defaults")
+	assert.Equal(t, effectoradapter.DefaultOutcomeModels()["intercept"], models["intercept"], "others keep them")
+
+	for _, bad := range []string{
+		`not json`,
+		`{"engage":{"success_probability":1.5}}`,
+		`{"engage":{"success_probability":0.7,"partial_probability":0.4}}`,
+		`{"engage":{"duration_ms":-1}}`,
+	} {
+		_, err := effectoradapter.ParseOutcomeModels(bad)
+		assert.Error(t, err, bad)
+	}
+
+	// A partial engagement is reported, with collateral, and does not neutralize
+	adapter, err := effectoradapter.New(effectoradapter.SimulatorName, map[string]string{
+		"speedup":  "100",
+		"seed":     "7",
+		"outcomes": `{"engage":{"partial_probability":1,"collateral_probability":1,"duration_ms":100,"neutralizes":true}}`,
+	})
+	require.NoError(t, err)
+	result, err := adapter.Execute(context.Background(), adaptertest.NewRequest("engage"), effectoradapter.NopReporter)
+	require.NoError(t, err)
+	assert.Equal(t, effectoradapter.OutcomePartial, result.Outcome)
+	assert.True(t, result.Collateral)
+	assert.Equal(t, false, result.Details[bda.DetailNeutralized])
+	outcome, assessed := bda.Assess("engage", result.Details, 0)
+	assert.True(t, assessed)
+	assert.False(t, outcome.Neutralized, "the assessment follows the reported outcome")
+	assert.Equal(t, bda.BasisReported, outcome.Basis)
+
+	_, err = effectoradapter.New(effectoradapter.SimulatorName, map[string]string{"seed": "soon"})
+	assert.Error(t, err)
+}
+
+// TestSimulatorOutcomeRolls tests that a seeded model replays and roughly follows its probabilities
+func TestSimulatorOutcomeRolls(t *testing.T) {
+	model := effectoradapter.OutcomeModel{SuccessProbability: 0.6, PartialProbability: 0.3, DurationMs: 100, DurationStdDevMs: 20, CollateralProbability: 0.1}
+	roll := func(seed int64) []effectoradapter.Draw {
+		rng := simrand.New(seed)
+		draws := make([]effectoradapter.Draw, 2000)
+		for i := range draws {
+			draws[i] = model.Roll(rng)
+		}
+		return draws
+	}
+
+	draws := roll(42)
+	assert.Equal(t, draws, roll(42), "the same seed replays the same outcomes")
+
+	counts := map[string]int{}
+	collateral := 0
+	for _, d := range draws {
+		counts[d.Outcome]++
+		if d.Collateral {
+			collateral++
+		}
+		assert.GreaterOrEqual(t, d.Duration, time.Duration(0))
+	}
+	assert.InDelta(t, 0.6, float64(counts[effectoradapter.OutcomeSuccess])/2000, 0.05)
+	assert.InDelta(t, 0.3, float64(counts[effectoradapter.OutcomePartial])/2000, 0.05)
+	assert.InDelta(t, 0.1, float64(counts[effectoradapter.OutcomeFailure])/2000, 0.05)
+	assert.InDelta(t, 0.1, float64(collateral)/2000, 0.05)
+}
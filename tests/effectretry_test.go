@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/effectoradapter"
+	"github.com/agile-defense/cjadc2/pkg/effectretry"
+)
+
+// TestParseEffectRetryPolicy verifies the retry policy settings and their defaults
+func TestParseEffectRetryPolicy(t *testing.T) {
+	p, err := effectretry.ParsePolicy(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, effectretry.DefaultPolicy(), p)
+
+	p, err = effectretry.ParsePolicy(map[string]string{
+		"EFFECT_TIMEOUT":           "20s, engage:5s,intercept:10s",
+		"EFFECT_MAX_ATTEMPTS":      "5",
+		"EFFECT_RETRY_BACKOFF":     "500ms",
+		"EFFECT_RETRY_MAX_BACKOFF": "4s",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, p.Timeout("engage"))
+	assert.Equal(t, 10*time.Second, p.Timeout("intercept"))
+	assert.Equal(t, 20*time.Second, p.Timeout("track"))
+	assert.Equal(t, 5, p.MaxAttempts)
+	assert.Equal(t, "20s,engage:5s,intercept:10s", p.String())
+
+	// Overrides alone keep the default timeout
+	p, err = effectretry.ParsePolicy(map[string]string{"EFFECT_TIMEOUT": "engage:5s"})
+	require.NoError(t, err)
+	assert.Equal(t, effectretry.DefaultTimeout, p.Timeout("identify"))
+
+	for name, vars := range map[string]map[string]string{
+		"bad duration":        {"EFFECT_TIMEOUT": "soon"},
+		"zero timeout":        {"EFFECT_TIMEOUT": "engage:0s"},
+		"empty action":        {"EFFECT_TIMEOUT": ":5s"},
+		"duplicate action":    {"EFFECT_TIMEOUT": "engage:5s,engage:6s"},
+		"zero attempts":       {"EFFECT_MAX_ATTEMPTS": "0"},
+		"bad backoff":         {"EFFECT_RETRY_BACKOFF": "-1s"},
+		"max below initial":   {"EFFECT_RETRY_BACKOFF": "10s", "EFFECT_RETRY_MAX_BACKOFF": "5s"},
+		"non-numeric attempt": {"EFFECT_MAX_ATTEMPTS": "three"},
+	} {
+		_, err := effectretry.ParsePolicy(vars)
+		assert.Error(t, err, name)
+	}
+}
+
+// TestEffectRetryBackoff verifies the backoff doubles per attempt up to its cap
+func TestEffectRetryBackoff(t *testing.T) {
+	p := effectretry.Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second, MaxAttempts: 10}
+	assert.Equal(t, time.Second, p.Delay(1))
+	assert.Equal(t, 2*time.Second, p.Delay(2))
+	assert.Equal(t, 4*time.Second, p.Delay(3))
+	assert.Equal(t, 5*time.Second, p.Delay(4))
+	assert.Equal(t, 5*time.Second, p.Delay(60), "large attempt counts stay at the cap")
+}
+
+// TestEffectRetryable verifies only transient failures within the attempt budget are retried
+func TestEffectRetryable(t *testing.T) {
+	p := effectretry.Policy{MaxAttempts: 3}
+	transient := errors.New("backend unavailable")
+
+	assert.True(t, p.Retryable(1, transient))
+	assert.True(t, p.Retryable(2, transient))
+	assert.False(t, p.Retryable(3, transient), "the last attempt is not retried")
+	assert.False(t, p.Retryable(1, nil))
+	assert.False(t, p.Retryable(1, effectoradapter.Permanent(transient)))
+	assert.False(t, p.Retryable(1, effectoradapter.ErrUnsupportedAction))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestParseEncoding verifies MESSAGE_ENCODING values and their content types
+func TestParseEncoding(t *testing.T) {
+	for value, want := range map[string]messages.Encoding{
+		"":          messages.EncodingJSON,
+		"json":      messages.EncodingJSON,
+		"Protobuf":  messages.EncodingProtobuf,
+		" protobuf": messages.EncodingProtobuf,
+	} {
+		got, err := messages.ParseEncoding(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := messages.ParseEncoding("xml")
+	assert.Error(t, err)
+
+	assert.Equal(t, messages.ContentTypeJSON, messages.EncodingJSON.ContentType())
+	assert.Equal(t, messages.ContentTypeProtobuf, messages.EncodingProtobuf.ContentType())
+}
+
+// TestProtobufRoundTrip verifies every pipeline message survives protobuf
+// encoding and converts back to the JSON it would have been published as
+func TestProtobufRoundTrip(t *testing.T) {
+	fixtures := schemaFixtures()
+	correlated := fixtures[messages.SchemaCorrelatedTrack].(*messages.CorrelatedTrack)
+	eta := 42.5
+	correlated.ZoneProximity = []messages.ZoneProximity{
+		{ZoneID: "z-1", ZoneName: "Base", ZoneKind: "protected_asset", DistanceMeters: 1200, TimeToZoneSeconds: &eta},
+		{ZoneID: "z-2", ZoneName: "Corridor", ZoneKind: "restricted_zone", Inside: true},
+	}
+	proposal := fixtures[messages.SchemaActionProposal].(*messages.ActionProposal)
+	proposal.PolicyDecision.Metadata = map[string]string{"rule": "intercept_hostile"}
+	proposal.Options = []messages.CourseOfAction{
+		{ActionType: "intercept", Rank: 1, Benefit: 85, Risk: 27.5, Score: 57.5, Rationale: "Close with the track", Constraints: []string{"Coordinate with command"}},
+		{ActionType: "identify", Rank: 2, Benefit: 60, Risk: 47, Score: 13},
+	}
+	proposal.Asset = &messages.AssetAssignment{AssetID: "a-1", Name: "Interceptor Flight Alpha", Kind: "interceptor", DistanceMeters: 42000}
+	proposal.Conflicts = []messages.ProposalConflict{{ProposalID: "p-2", TrackID: "trk-2", ActionType: "engage", Kind: "airspace", DistanceMeters: 4200}}
+	decision := fixtures[messages.SchemaDecision].(*messages.Decision)
+	decision.SelectedOption = "identify"
+	decision.Approvals = []messages.Approval{{ApprovedBy: "operator-1", Role: "commander", Option: "identify"}}
+	effect := fixtures[messages.SchemaEffectLog].(*messages.EffectLog)
+	effect.Outcome, effect.Collateral, effect.DurationMs = "partial", true, 1250
+
+	for schema, msg := range fixtures {
+		want, err := json.Marshal(msg)
+		require.NoError(t, err, schema)
+
+		data, contentType, err := messages.Marshal(msg, messages.EncodingProtobuf)
+		require.NoError(t, err, schema)
+		assert.Equal(t, messages.ContentTypeProtobuf, contentType, schema)
+		assert.Less(t, len(data), len(want), "%s is smaller as protobuf", schema)
+
+		got, err := messages.ToJSON(schema, contentType, data)
+		require.NoError(t, err, schema)
+		assert.JSONEq(t, string(want), string(got), schema)
+		assert.NoError(t, messages.ValidateEncoded(schema, contentType, data), schema)
+	}
+}
+
+// TestUnmarshalDefaultsToJSON verifies messages without a content type are
+// decoded as JSON, as they were before protobuf was added
+func TestUnmarshalDefaultsToJSON(t *testing.T) {
+	det := schemaFixtures()[messages.SchemaDetection].(*messages.Detection)
+
+	data, contentType, err := messages.Marshal(det, messages.EncodingJSON)
+	require.NoError(t, err)
+	assert.Equal(t, messages.ContentTypeJSON, contentType)
+
+	var decoded messages.Detection
+	require.NoError(t, messages.Unmarshal("", data, &decoded))
+	assert.Equal(t, det.TrackID, decoded.TrackID)
+	assert.Equal(t, det.Envelope.MessageID, decoded.Envelope.MessageID)
+
+	same, err := messages.ToJSON(messages.SchemaDetection, "", data)
+	require.NoError(t, err)
+	assert.Equal(t, data, same, "JSON is passed through unchanged")
+}
+
+// TestMarshalFallsBackToJSON verifies messages without a protobuf version are
+// published as JSON whatever the configured encoding
+func TestMarshalFallsBackToJSON(t *testing.T) {
+	dl := messages.NewDeadLetter("classifier-1", "classifier", messages.SchemaDetection, []byte("{}"), assert.AnError)
+
+	data, contentType, err := messages.Marshal(dl, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	assert.Equal(t, messages.ContentTypeJSON, contentType)
+	assert.True(t, json.Valid(data))
+}
+
+// TestValidateEncodedRejectsBadProtobuf verifies undecodable protobuf is a validation error
+func TestValidateEncodedRejectsBadProtobuf(t *testing.T) {
+	err := messages.ValidateEncoded(messages.SchemaTrack, messages.ContentTypeProtobuf, []byte{0xff, 0xff, 0xff})
+	var verr *messages.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, messages.SchemaTrack, verr.Schema)
+}
+
+// TestSchemaForSubject verifies pipeline subjects map to their schemas
+func TestSchemaForSubject(t *testing.T) {
+	for subject, want := range map[string]string{
+		"detect.sensor-001.radar":     messages.SchemaDetection,
+		"track.classified.hostile":    messages.SchemaTrack,
+		"track.correlated.high":       messages.SchemaCorrelatedTrack,
+		"proposal.pending.9":          messages.SchemaActionProposal,
+		"decision.approved.intercept": messages.SchemaDecision,
+		"decision.revoked.intercept":  "",
+		"effect.executed.intercept":   messages.SchemaEffectLog,
+		"notify.sla.prop-1":           "",
+	} {
+		assert.Equal(t, want, messages.SchemaForSubject(subject), subject)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// fakeEngagementStore serves at most one proposal and one track
+type fakeEngagementStore struct {
+	proposal *postgres.ProposalRow
+	track    *postgres.TrackRow
+	err      error
+}
+
+// GetProposal returns the proposal when the ID matches
+func (f *fakeEngagementStore) GetProposal(ctx context.Context, proposalID string) (*postgres.ProposalRow, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.proposal == nil || f.proposal.ProposalID != proposalID {
+		return nil, nil
+	}
+	return f.proposal, nil
+}
+
+// GetTrack returns the track when the ID matches
+func (f *fakeEngagementStore) GetTrack(ctx context.Context, trackID string) (*postgres.TrackRow, error) {
+	if f.track == nil || f.track.TrackID != trackID {
+		return nil, nil
+	}
+	return f.track, nil
+}
+
+// TestBuildEngagementCompleted verifies effects are joined with their proposal and track
+func TestBuildEngagementCompleted(t *testing.T) {
+	now := time.Now().UTC()
+	effect := &messages.EffectLog{
+		Envelope:   messages.Envelope{MessageID: "msg-1", CorrelationID: "corr-1"},
+		EffectID:   "eff-1",
+		DecisionID: "dec-1",
+		ProposalID: "prop-1",
+		TrackID:    "trk-1",
+		ActionType: "engage",
+		Status:     "executed",
+		ExecutedAt: now,
+	}
+	store := &fakeEngagementStore{
+		proposal: &postgres.ProposalRow{ProposalID: "prop-1", TrackID: "trk-1", ActionType: "engage", Status: "approved", ExpiresAt: now.Add(time.Minute)},
+		track:    &postgres.TrackRow{TrackID: "trk-1", Classification: "hostile", State: "active"},
+	}
+
+	ec, err := handler.BuildEngagementCompleted(context.Background(), store, effect)
+	require.NoError(t, err)
+	assert.Equal(t, "eff-1", ec.EffectID)
+	require.NotNil(t, ec.Proposal)
+	assert.Equal(t, "prop-1", ec.Proposal.ProposalID)
+	require.NotNil(t, ec.Track)
+	assert.Equal(t, "hostile", ec.Track.Classification)
+
+	raw, err := json.Marshal(ec)
+	require.NoError(t, err)
+	ev, err := handler.NewEvent(handler.MessageTypeEngagementCompleted, raw, now)
+	require.NoError(t, err)
+	assert.Equal(t, "corr-1", ev.CorrelationID, "event shares the effect's correlation ID")
+
+	ec, err = handler.BuildEngagementCompleted(context.Background(), &fakeEngagementStore{}, effect)
+	require.NoError(t, err)
+	assert.Nil(t, ec.Proposal, "purged proposal is omitted")
+	assert.Nil(t, ec.Track)
+
+	ec, err = handler.BuildEngagementCompleted(context.Background(), &fakeEngagementStore{err: errors.New("down")}, effect)
+	assert.Error(t, err)
+	assert.Equal(t, "dec-1", ec.DecisionID, "effect fields survive a failed lookup")
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTTLFractionElapsed verifies the elapsed fraction is clamped to the TTL window
+func TestTTLFractionElapsed(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	expires := created.Add(10 * time.Minute)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected float64
+	}{
+		{"before creation", created.Add(-time.Minute), 0},
+		{"at creation", created, 0},
+		{"halfway", created.Add(5 * time.Minute), 0.5},
+		{"eighty percent", created.Add(8 * time.Minute), 0.8},
+		{"after expiry", expires.Add(time.Minute), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, messages.TTLFractionElapsed(created, expires, tt.now), 1e-9)
+		})
+	}
+
+	assert.Equal(t, 1.0, messages.TTLFractionElapsed(created, created, created), "zero TTL is fully elapsed")
+}
+
+// TestEscalationLevelFor verifies the 50% and 80% escalation thresholds
+func TestEscalationLevelFor(t *testing.T) {
+	tests := []struct {
+		fraction float64
+		level    int
+		urgency  string
+	}{
+		{0, messages.EscalationNone, "normal"},
+		{0.49, messages.EscalationNone, "normal"},
+		{0.5, messages.EscalationWarning, "warning"},
+		{0.79, messages.EscalationWarning, "warning"},
+		{0.8, messages.EscalationUrgent, "urgent"},
+		{1, messages.EscalationUrgent, "urgent"},
+	}
+
+	for _, tt := range tests {
+		level := messages.EscalationLevelFor(tt.fraction)
+		assert.Equal(t, tt.level, level, "fraction %.2f", tt.fraction)
+		assert.Equal(t, tt.urgency, messages.Urgency(level), "fraction %.2f", tt.fraction)
+	}
+}
+
+// TestProposalEscalationMessage verifies escalation notifications route to the NOTIFICATIONS stream
+func TestProposalEscalationMessage(t *testing.T) {
+	proposal := &messages.ActionProposal{
+		Envelope:    messages.NewEnvelope("planner-1", "planner").WithCorrelation("corr-123", "msg-1"),
+		ProposalID:  "prop-1",
+		TrackID:     "track-1",
+		ActionType:  "intercept",
+		Priority:    7,
+		ThreatLevel: "high",
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	escalation := messages.NewProposalEscalation(proposal, "authorizer-1", messages.EscalationUrgent)
+	assert.Equal(t, "notify.escalation.urgent", escalation.Subject())
+	assert.Equal(t, "corr-123", escalation.Envelope.CorrelationID)
+	assert.Equal(t, "authorizer", escalation.Envelope.SourceType)
+	assert.Equal(t, 7, escalation.PreviousPriority)
+
+	data, err := json.Marshal(escalation)
+	require.NoError(t, err)
+	var decoded messages.ProposalEscalation
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "prop-1", decoded.ProposalID)
+	assert.Equal(t, messages.EscalationUrgent, decoded.EscalationLevel)
+
+	stream, ok := natsutil.StreamConfigs["NOTIFICATIONS"]
+	require.True(t, ok)
+	assert.Contains(t, stream.Subjects, "notify.>")
+}
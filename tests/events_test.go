@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestNewEvent verifies raw messages become events carrying their envelope metadata
+func TestNewEvent(t *testing.T) {
+	track := messages.CorrelatedTrack{
+		Envelope: messages.NewEnvelope("correlator-1", "correlator").WithCorrelation("corr-1", "msg-0"),
+		TrackID:  "track-1",
+	}
+	payload, err := json.Marshal(track)
+	require.NoError(t, err)
+	received := time.Now().UTC().Add(time.Second)
+
+	ev, err := handler.NewEvent(handler.MessageTypeTrackUpdate, payload, received)
+	require.NoError(t, err)
+	assert.Equal(t, handler.CurrentSchemaVersion, ev.SchemaVersion)
+	assert.Equal(t, handler.MessageTypeTrackUpdate, ev.EventType)
+	assert.Equal(t, 1, ev.Version)
+	assert.Equal(t, track.Envelope.MessageID, ev.MessageID)
+	assert.Equal(t, "corr-1", ev.CorrelationID)
+	assert.Equal(t, "msg-0", ev.CausationID)
+	assert.Equal(t, "correlator-1", ev.Source)
+	assert.Equal(t, "correlator", ev.SourceType)
+	assert.True(t, ev.TS.Equal(track.Envelope.Timestamp), "ts is when the message was created")
+
+	legacy := ev.Legacy()
+	assert.Equal(t, handler.MessageTypeTrackUpdate, legacy.Type)
+	assert.Equal(t, "corr-1", legacy.CorrelationID)
+	assert.True(t, legacy.Timestamp.Equal(received), "legacy timestamp is when the gateway received it")
+	assert.JSONEq(t, string(payload), string(legacy.Payload))
+
+	// Payloads without an envelope, like a deleted track's ID, use the receive time
+	ev, err = handler.NewEvent(handler.MessageTypeTrackDelete, json.RawMessage(`"track-1"`), received)
+	require.NoError(t, err)
+	assert.True(t, ev.TS.Equal(received))
+	assert.Empty(t, ev.Source)
+
+	_, err = handler.NewEvent(handler.MessageTypeTrackUpdate, json.RawMessage(`{"track_id":`), received)
+	assert.Error(t, err)
+}
+
+// TestParseSchemaVersion verifies schema negotiation defaults to the legacy schema and refuses unknown versions
+func TestParseSchemaVersion(t *testing.T) {
+	v, err := handler.ParseSchemaVersion("")
+	require.NoError(t, err)
+	assert.Equal(t, handler.SchemaVersionLegacy, v)
+
+	v, err = handler.ParseSchemaVersion("2")
+	require.NoError(t, err)
+	assert.Equal(t, handler.SchemaVersionEvents, v)
+
+	for _, bad := range []string{"0", "3", "v2"} {
+		_, err := handler.ParseSchemaVersion(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestSSEEventSchema verifies event clients are told their schema and then receive event envelopes
+func TestSSEEventSchema(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	go hub.Run(ctx)
+
+	server := httptest.NewServer(handler.NewSSEHandler(hub, zerolog.Nop()))
+	defer server.Close()
+
+	stream, closeStream := openSSE(t, server.URL+"?schema_version=2", "")
+	defer closeStream()
+	readSSE(t, stream)
+
+	status := readSSERaw(t, stream)
+	var hello struct {
+		EventType string                   `json:"event_type"`
+		Payload   handler.ConnectionStatus `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(status, &hello))
+	assert.Equal(t, handler.MessageTypeConnectionStatus, hello.EventType)
+	assert.Equal(t, handler.SchemaVersionEvents, hello.Payload.SchemaVersion)
+	assert.Equal(t, handler.SupportedSchemaVersions, hello.Payload.SupportedSchemaVersions)
+	require.Eventually(t, func() bool { return hub.StreamClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeProposalNew, Payload: json.RawMessage(`{"proposal_id":"prop-1"}`), Timestamp: time.Now().UTC(), CorrelationID: "corr-9"})
+	var ev handler.Event
+	require.NoError(t, json.Unmarshal(readSSERaw(t, stream), &ev))
+	assert.Equal(t, handler.SchemaVersionEvents, ev.SchemaVersion)
+	assert.Equal(t, handler.MessageTypeProposalNew, ev.EventType)
+	assert.Equal(t, "corr-9", ev.CorrelationID)
+	assert.JSONEq(t, `{"proposal_id":"prop-1"}`, string(ev.Payload))
+
+	rec := httptest.NewRecorder()
+	handler.NewSSEHandler(hub, zerolog.Nop()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?schema_version=9", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// readSSERaw reads the next event from the stream and returns its data
+func readSSERaw(t *testing.T, r *bufio.Reader) []byte {
+	var data []byte
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return data
+		}
+		if value, ok := strings.CutPrefix(line, "data: "); ok {
+			data = []byte(value)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// TestExpandClearTables tests that clearing a table also clears the tables that depend on it
+func TestExpandClearTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		tables   []string
+		expected []string
+	}{
+		{"tracks alone", []string{"tracks"}, []string{"tracks"}},
+		{"proposals pull in decisions and effects", []string{"proposals"}, []string{"effects", "decisions", "proposals"}},
+		{"effects keep the audit chain whole", []string{"effects"}, []string{"effects", "decisions"}},
+		{"defaults in foreign key order", postgres.DefaultClearTables, []string{"effects", "decisions", "proposals", "detections", "tracks"}},
+		{"duplicates collapse", []string{"audit_log", "audit_log"}, []string{"audit_log"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expanded, err := postgres.ExpandClearTables(tt.tables)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expanded)
+		})
+	}
+
+	_, err := postgres.ExpandClearTables([]string{"zones"})
+	assert.Error(t, err, "reference data tables should not be clearable")
+	_, err = postgres.ExpandClearTables([]string{"tracks; DROP TABLE tracks"})
+	assert.Error(t, err)
+}
+
+// TestExerciseResetValidation tests that invalid reset requests are rejected before anything is paused or purged
+func TestExerciseResetValidation(t *testing.T) {
+	sensorCalls := 0
+	sensor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sensorCalls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer sensor.Close()
+
+	h := handler.NewExerciseHandler(nil, nil, sensor.URL, zerolog.Nop())
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"malformed body", `{"streams":`, http.StatusBadRequest},
+		{"unknown stream", `{"streams":["NOPE"]}`, http.StatusBadRequest},
+		{"unknown table", `{"tables":["zones"]}`, http.StatusBadRequest},
+		{"protected bucket", `{"kv_buckets":["EFFECTOR_LEASE"]}`, http.StatusBadRequest},
+		{"protected stream", `{"streams":["SIMCONTROL"]}`, http.StatusBadRequest},
+		{"scenario sets paused", `{"scenario":{"paused":false}}`, http.StatusBadRequest},
+		{"settle too long", `{"settle_ms":60000}`, http.StatusBadRequest},
+		{"valid without NATS", `{"scenario":{"track_count":5},"settle_ms":0}`, http.StatusServiceUnavailable},
+		{"empty body without NATS", ``, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/reset", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.Routes().ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+
+	assert.Zero(t, sensorCalls, "the sensor should not be touched by a rejected reset")
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/federation"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// federationVars returns a valid bridge configuration from USA to GBR
+func federationVars() map[string]string {
+	return map[string]string{
+		"FEDERATION_ENCLAVE":         "usa",
+		"FEDERATION_PARTNER":         "GBR",
+		"FEDERATION_REMOTE_NATS_URL": "nats://partner:4222",
+	}
+}
+
+// TestFederationConfig verifies bridge configuration parsing
+func TestFederationConfig(t *testing.T) {
+	cfg, err := federation.ConfigFromVars(federationVars())
+	require.NoError(t, err)
+	assert.Equal(t, "USA", cfg.Enclave)
+	assert.Equal(t, "GBR", cfg.Partner)
+	assert.Equal(t, seclabel.Unclassified, cfg.MaxClassification, "only unclassified tracks are exported by default")
+
+	for name, change := range map[string][2]string{
+		"missing enclave":      {"FEDERATION_ENCLAVE", ""},
+		"two partners":         {"FEDERATION_PARTNER", "GBR,AUS"},
+		"partner is enclave":   {"FEDERATION_PARTNER", "USA"},
+		"missing remote":       {"FEDERATION_REMOTE_NATS_URL", ""},
+		"unknown max label":    {"FEDERATION_MAX_CLASSIFICATION", "cosmic"},
+		"malformed enclave id": {"FEDERATION_ENCLAVE", "U"},
+	} {
+		vars := federationVars()
+		vars[change[0]] = change[1]
+		_, err := federation.ConfigFromVars(vars)
+		assert.Error(t, err, name)
+	}
+}
+
+// TestFederationExport verifies only tracks releasable to the partner and
+// not already federated through either enclave are exported
+func TestFederationExport(t *testing.T) {
+	vars := federationVars()
+	vars["FEDERATION_MAX_CLASSIFICATION"] = "confidential"
+	cfg, err := federation.ConfigFromVars(vars)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		env    messages.Envelope
+		reason string
+	}{
+		{"releasable", messages.Envelope{Classification: seclabel.Confidential, Releasability: []string{"FVEY", "GBR"}}, ""},
+		{"not released", messages.Envelope{Classification: seclabel.Unclassified}, federation.SkipNotReleasable},
+		{"released elsewhere", messages.Envelope{Releasability: []string{"AUS"}}, federation.SkipNotReleasable},
+		{"above the bridge", messages.Envelope{Classification: seclabel.Secret, Releasability: []string{"GBR"}}, federation.SkipClassification},
+		{"from the partner", messages.Envelope{Releasability: []string{"GBR"}, Origins: []string{"GBR"}}, federation.SkipLoop},
+		{"through the partner", messages.Envelope{Releasability: []string{"GBR"}, Origins: []string{"AUS", "GBR"}}, federation.SkipLoop},
+		{"back home", messages.Envelope{Releasability: []string{"GBR"}, Origins: []string{"USA", "AUS"}}, federation.SkipReturned},
+		{"relayed", messages.Envelope{Releasability: []string{"GBR"}, Origins: []string{"AUS"}}, ""},
+	} {
+		ok, reason := cfg.Export(tc.env)
+		assert.Equal(t, tc.reason == "", ok, tc.name)
+		assert.Equal(t, tc.reason, reason, tc.name)
+	}
+}
+
+// TestFederationStamp verifies exported tracks are marked with the enclave
+// and local track IDs cannot collide with the partner's
+func TestFederationStamp(t *testing.T) {
+	cfg, err := federation.ConfigFromVars(federationVars())
+	require.NoError(t, err)
+
+	local := &messages.Track{TrackID: "TRK-001", Envelope: messages.Envelope{MessageID: "m1"}}
+	cfg.Stamp(local)
+	assert.Equal(t, "USA:TRK-001", local.TrackID)
+	assert.Equal(t, []string{"USA"}, local.Envelope.Origins)
+	assert.Equal(t, "m1", local.Envelope.MessageID)
+	assert.Equal(t, "USA", federation.Origin(local.Envelope))
+
+	relayed := &messages.Track{TrackID: "AUS:TRK-009", Envelope: messages.Envelope{Origins: []string{"AUS"}}}
+	cfg.Stamp(relayed)
+	assert.Equal(t, "AUS:TRK-009", relayed.TrackID)
+	assert.Equal(t, []string{"AUS", "USA"}, relayed.Envelope.Origins)
+	assert.Equal(t, "AUS", federation.Origin(relayed.Envelope))
+}
+
+// TestFederationConflicts verifies reports from different enclaves that
+// disagree are flagged, and fused tracks keep every origin
+func TestFederationConflicts(t *testing.T) {
+	local := &messages.Track{TrackID: "TRK-001", Classification: "hostile", Type: "aircraft"}
+	partner := &messages.Track{TrackID: "GBR:TRK-7", Classification: "unknown", Type: "aircraft",
+		Envelope: messages.Envelope{Origins: []string{"GBR"}}}
+	sameEnclave := &messages.Track{TrackID: "TRK-002", Classification: "unknown", Type: "vessel"}
+
+	assert.Equal(t, []string{"classification"}, federation.Conflicts(local, partner))
+	assert.Empty(t, federation.Conflicts(local, sameEnclave), "reports from one enclave are not federation conflicts")
+
+	correlated := messages.NewCorrelatedTrack(partner, "correlator-1")
+	assert.Equal(t, []string{"GBR"}, correlated.Envelope.Origins)
+	fused := correlated.Envelope.WithMergedOrigins(messages.Envelope{Origins: []string{"AUS", "GBR"}})
+	assert.Equal(t, []string{"GBR", "AUS"}, fused.Origins)
+	assert.Equal(t, []string{"GBR"}, correlated.Envelope.Origins, "merging does not alter the original")
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestGeoDistanceAndBearing verifies great-circle distance and initial bearing
+func TestGeoDistanceAndBearing(t *testing.T) {
+	origin := messages.Position{Lat: 0, Lon: 0}
+
+	// One degree of longitude on the equator is ~111.2 km
+	assert.InDelta(t, 111195, geo.Distance(origin, messages.Position{Lat: 0, Lon: 1}), 10)
+	assert.Equal(t, 0.0, geo.Distance(origin, origin))
+
+	assert.InDelta(t, 90, geo.InitialBearing(origin, messages.Position{Lat: 0, Lon: 1}), 0.01)
+	assert.InDelta(t, 0, geo.InitialBearing(origin, messages.Position{Lat: 1, Lon: 0}), 0.01)
+	assert.InDelta(t, 270, geo.InitialBearing(origin, messages.Position{Lat: 0, Lon: -1}), 0.01)
+}
+
+// TestGeoZoneContains verifies point containment for circle and polygon zones
+func TestGeoZoneContains(t *testing.T) {
+	circle := testCircleZone()
+	assert.True(t, circle.Contains(geo.Point{Lat: 0, Lon: 1}))
+	assert.True(t, circle.Contains(geo.Point{Lat: 0, Lon: 1.08}), "~8.9 km from the centre")
+	assert.False(t, circle.Contains(geo.Point{Lat: 0, Lon: 1.1}), "~11.1 km from the centre")
+
+	poly := testPolygonZone()
+	assert.True(t, poly.Contains(geo.Point{Lat: 0, Lon: 1.5}))
+	assert.False(t, poly.Contains(geo.Point{Lat: 0, Lon: 0.5}))
+	assert.False(t, poly.Contains(geo.Point{Lat: 0.6, Lon: 1.5}))
+
+	var backend geo.Backend = geo.Spherical{}
+	inside, err := backend.Contains(context.Background(), poly, geo.Point{Lat: 0, Lon: 1.5})
+	require.NoError(t, err)
+	assert.True(t, inside)
+}
+
+// TestGeoParseBBox verifies bounding box parsing, validation and containment
+func TestGeoParseBBox(t *testing.T) {
+	box, err := geo.ParseBBox("-118, 34, -116, 36")
+	require.NoError(t, err)
+	assert.Equal(t, geo.BBox{MinLon: -118, MinLat: 34, MaxLon: -116, MaxLat: 36}, box)
+	assert.False(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(geo.Point{Lat: 35, Lon: -117}))
+	assert.True(t, box.Contains(geo.Point{Lat: 34, Lon: -118}), "edges are inside")
+	assert.False(t, box.Contains(geo.Point{Lat: 35, Lon: -119}))
+
+	for _, bad := range []string{"", "1,2,3", "a,2,3,4", "0,10,1,5", "0,0,200,1"} {
+		_, err := geo.ParseBBox(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestGeoBBoxAntimeridian verifies boxes that wrap from 180 to -180 longitude
+func TestGeoBBoxAntimeridian(t *testing.T) {
+	box, err := geo.ParseBBox("170,-10,-170,10")
+	require.NoError(t, err)
+	assert.True(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(geo.Point{Lat: 0, Lon: 175}))
+	assert.True(t, box.Contains(geo.Point{Lat: 0, Lon: -175}))
+	assert.False(t, box.Contains(geo.Point{Lat: 0, Lon: 0}))
+}
+
+// TestGeoBBoxPredicates verifies the SQL each backend generates for a bounding box
+func TestGeoBBoxPredicates(t *testing.T) {
+	box := geo.BBox{MinLon: -118, MinLat: 34, MaxLon: -116, MaxLat: 36}
+
+	clause, args := geo.Spherical{}.BBoxPredicate("lat", "lon", box, 3)
+	assert.Equal(t, "(lat BETWEEN $3 AND $4 AND (lon >= $5 AND lon <= $6))", clause)
+	assert.Equal(t, []interface{}{34.0, 36.0, -118.0, -116.0}, args)
+
+	wrapped := geo.BBox{MinLon: 170, MinLat: -10, MaxLon: -170, MaxLat: 10}
+	clause, _ = geo.Spherical{}.BBoxPredicate("lat", "lon", wrapped, 1)
+	assert.Contains(t, clause, "lon >= $3 OR lon <= $4")
+
+	postgis := geo.NewPostGIS(nil)
+	clause, args = postgis.BBoxPredicate("lat", "lon", box, 1)
+	assert.Equal(t, "ST_Intersects(ST_SetSRID(ST_MakePoint(lon, lat), 4326), ST_MakeEnvelope($1, $2, $3, $4, 4326))", clause)
+	assert.Equal(t, []interface{}{-118.0, 34.0, -116.0, 36.0}, args)
+
+	clause, args = postgis.BBoxPredicate("lat", "lon", wrapped, 1)
+	assert.Contains(t, clause, "ST_MakeEnvelope($5, $6, $7, $8, 4326)")
+	assert.Equal(t, []interface{}{170.0, -10.0, 180.0, 10.0, -180.0, -10.0, -170.0, 10.0}, args)
+}
+
+// TestGeoSelectBackend verifies backend selection without a database
+func TestGeoSelectBackend(t *testing.T) {
+	ctx := context.Background()
+
+	b, err := geo.Select(ctx, nil, geo.BackendAuto)
+	require.NoError(t, err)
+	assert.Equal(t, geo.BackendSpherical, b.Name(), "auto falls back to spherical without a database")
+
+	b, err = geo.Select(ctx, nil, geo.BackendSpherical)
+	require.NoError(t, err)
+	assert.Equal(t, geo.BackendSpherical, b.Name())
+
+	_, err = geo.Select(ctx, nil, geo.BackendPostGIS)
+	assert.Error(t, err)
+
+	_, err = geo.Select(ctx, nil, "flat")
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/threat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCircleZone is a 10 km protected asset roughly 111 km east of the test origin
+func testCircleZone() geo.Zone {
+	return geo.Zone{
+		ZoneID: "zone-circle", Name: "Base", Kind: geo.KindProtectedAsset, Shape: geo.ShapeCircle,
+		Center: geo.Point{Lat: 0, Lon: 1}, RadiusMeters: 10000, Enabled: true,
+	}
+}
+
+// testPolygonZone is a 1x1 degree restricted box east of the test origin
+func testPolygonZone() geo.Zone {
+	return geo.Zone{
+		ZoneID: "zone-poly", Name: "Range", Kind: geo.KindRestrictedZone, Shape: geo.ShapePolygon,
+		Polygon: []geo.Point{{Lat: -0.5, Lon: 1}, {Lat: 0.5, Lon: 1}, {Lat: 0.5, Lon: 2}, {Lat: -0.5, Lon: 2}},
+		Enabled: true,
+	}
+}
+
+// TestZoneCircleTimeToZone verifies CPA and entry time for a track inbound to a circular zone
+func TestZoneCircleTimeToZone(t *testing.T) {
+	pos := messages.Position{Lat: 0, Lon: 0}
+
+	inbound := geo.Assess(testCircleZone(), pos, messages.Velocity{Speed: 100, Heading: 90})
+	assert.False(t, inbound.Inside)
+	assert.InDelta(t, 0, inbound.CPAMeters, 1)
+	require.NotNil(t, inbound.TimeToZoneSeconds)
+	// ~111.2 km to centre minus 10 km radius at 100 m/s
+	assert.InDelta(t, 1012, *inbound.TimeToZoneSeconds, 5)
+
+	outbound := geo.Assess(testCircleZone(), pos, messages.Velocity{Speed: 100, Heading: 270})
+	assert.Nil(t, outbound.TimeToZoneSeconds)
+	assert.InDelta(t, outbound.DistanceMeters, outbound.CPAMeters, 1)
+	assert.Equal(t, 0.0, outbound.TimeToCPASeconds)
+
+	// Passing north of the zone at ~0.2 degrees latitude
+	passing := geo.Assess(testCircleZone(), messages.Position{Lat: 0.2, Lon: 0}, messages.Velocity{Speed: 100, Heading: 90})
+	assert.Nil(t, passing.TimeToZoneSeconds)
+	assert.InDelta(t, 12240, passing.CPAMeters, 100)
+	assert.Greater(t, passing.TimeToCPASeconds, 0.0)
+}
+
+// TestZonePolygonAssessment verifies containment, entry and miss cases for polygon zones
+func TestZonePolygonAssessment(t *testing.T) {
+	zone := testPolygonZone()
+
+	inside := geo.Assess(zone, messages.Position{Lat: 0, Lon: 1.5}, messages.Velocity{Speed: 50, Heading: 0})
+	assert.True(t, inside.Inside)
+	require.NotNil(t, inside.TimeToZoneSeconds)
+	assert.Equal(t, 0.0, *inside.TimeToZoneSeconds)
+
+	entering := geo.Assess(zone, messages.Position{Lat: 0, Lon: 0}, messages.Velocity{Speed: 200, Heading: 90})
+	assert.False(t, entering.Inside)
+	require.NotNil(t, entering.TimeToZoneSeconds)
+	assert.InDelta(t, 556, *entering.TimeToZoneSeconds, 5)
+	assert.Equal(t, 0.0, entering.CPAMeters)
+
+	missing := geo.Assess(zone, messages.Position{Lat: 1, Lon: 0}, messages.Velocity{Speed: 200, Heading: 0})
+	assert.Nil(t, missing.TimeToZoneSeconds)
+	assert.Greater(t, missing.CPAMeters, 100000.0)
+}
+
+// TestAssessAllOrdersByUrgency verifies soonest-entry zones come first and disabled zones are skipped
+func TestAssessAllOrdersByUrgency(t *testing.T) {
+	disabled := testCircleZone()
+	disabled.ZoneID = "zone-disabled"
+	disabled.Enabled = false
+
+	results := geo.AssessAll(
+		[]geo.Zone{testPolygonZone(), testCircleZone(), disabled},
+		messages.Position{Lat: 0, Lon: 0},
+		messages.Velocity{Speed: 100, Heading: 90},
+	)
+
+	// Circle edge is ~101 km away, polygon edge ~111 km
+	require.Len(t, results, 2)
+	assert.Equal(t, "zone-circle", results[0].ZoneID)
+	assert.Equal(t, "zone-poly", results[1].ZoneID)
+}
+
+// TestZoneValidation verifies malformed zones are rejected
+func TestZoneValidation(t *testing.T) {
+	assert.NoError(t, testCircleZone().Validate())
+	assert.NoError(t, testPolygonZone().Validate())
+
+	badKind := testCircleZone()
+	badKind.Kind = "airspace"
+	assert.Error(t, badKind.Validate())
+
+	badPolygon := testPolygonZone()
+	badPolygon.Polygon = badPolygon.Polygon[:2]
+	assert.Error(t, badPolygon.Validate())
+}
+
+// TestZoneProximityRaisesThreatLevel verifies the default rules escalate tracks closing on a zone
+func TestZoneProximityRaisesThreatLevel(t *testing.T) {
+	engine := threat.NewDefaultEngine()
+	ct := &messages.CorrelatedTrack{
+		Classification: "hostile",
+		Type:           "vessel",
+		Position:       messages.Position{Lat: 0, Lon: 0.9},
+		Velocity:       messages.Velocity{Speed: 20, Heading: 90},
+	}
+
+	assert.Equal(t, threat.LevelMedium, engine.Score(ct).Level)
+
+	ct.ZoneProximity = geo.AssessAll([]geo.Zone{testCircleZone()}, ct.Position, ct.Velocity)
+	result := engine.Score(ct)
+	assert.Equal(t, threat.LevelHigh, result.Level)
+	assert.Equal(t, 75.0, result.Score)
+}
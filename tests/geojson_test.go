@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// TestTrackFeature tests that a track becomes a Point feature with lon, lat, alt order and track properties
+func TestTrackFeature(t *testing.T) {
+	track := handler.TrackResponse{
+		TrackID:        "TRK-ALPHA-7",
+		Classification: "hostile",
+		ThreatLevel:    "high",
+		Position:       json.RawMessage(`{"lat": 35.5, "lon": -117.25, "alt": 5000}`),
+		Velocity:       json.RawMessage(`{"speed": 250, "heading": 90}`),
+		State:          "active",
+		LastUpdated:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	f := handler.TrackFeature(track)
+	assert.Equal(t, "Feature", f.Type)
+	assert.Equal(t, "TRK-ALPHA-7", f.ID)
+	require.NotNil(t, f.Geometry)
+	assert.Equal(t, "Point", f.Geometry.Type)
+	assert.Equal(t, []float64{-117.25, 35.5, 5000}, f.Geometry.Coordinates)
+	assert.Equal(t, "hostile", f.Properties["classification"])
+	assert.Equal(t, "active", f.Properties["state"])
+	assert.NotContains(t, f.Properties, "position", "the position is the geometry")
+
+	// Altitude is omitted when unknown, and a missing position has no geometry
+	assert.Equal(t, []float64{1, 2}, handler.PointFromPosition(json.RawMessage(`{"lat": 2, "lon": 1}`)).Coordinates)
+	assert.Nil(t, handler.PointFromPosition(json.RawMessage(`{}`)))
+}
+
+// TestGeoJSONFormatValidation tests that unknown output formats are rejected before querying
+func TestGeoJSONFormatValidation(t *testing.T) {
+	router := handler.NewTrackHandler(nil, zerolog.Nop()).Routes()
+
+	for _, path := range []string{"/?format=kml", "/TRK-1?format=kml", "/TRK-1/history?format=kml"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, path)
+	}
+}
+
+// TestAnonymizerShiftsGeoJSON tests that GeoJSON responses are anonymized like JSON ones
+func TestAnonymizerShiftsGeoJSON(t *testing.T) {
+	a := handler.NewAnonymizer(true, "demo-seed", zerolog.Nop())
+	fc := handler.NewFeatureCollection("corr-1")
+	fc.Features = append(fc.Features, handler.TrackFeature(handler.TrackResponse{
+		TrackID:  "TRK-ALPHA-7",
+		Position: json.RawMessage(`{"lat": 35.5, "lon": -117.25, "alt": 5000}`),
+	}))
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.WriteGeoJSON(w, http.StatusOK, fc)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tracks?format=geojson", nil))
+	assert.Equal(t, handler.GeoJSONContentType, rec.Header().Get("Content-Type"))
+	assert.NotContains(t, rec.Body.String(), "TRK-ALPHA-7")
+
+	var out handler.FeatureCollection
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	require.Len(t, out.Features, 1)
+	coords := out.Features[0].Geometry.Coordinates
+	assert.NotEqual(t, -117.25, coords[0])
+	assert.NotEqual(t, 35.5, coords[1])
+	assert.Equal(t, float64(5000), coords[2], "altitude is left alone")
+	assert.Equal(t, a.Pseudonym("TRK-ALPHA-7"), out.Features[0].ID)
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+func dependency(t *testing.T, r agent.Readiness, name string) agent.DependencyStatus {
+	t.Helper()
+	for _, d := range r.Dependencies {
+		if d.Name == name {
+			return d
+		}
+	}
+	t.Fatalf("no %s dependency in %+v", name, r.Dependencies)
+	return agent.DependencyStatus{}
+}
+
+func TestLivenessBeforeStart(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "planner-001", Type: agent.AgentTypePlanner})
+	require.NoError(t, err)
+
+	live := base.Liveness()
+	assert.True(t, live.Live, "a starting agent is not restarted")
+	assert.Equal(t, "starting", live.Status)
+
+	rec := httptest.NewRecorder()
+	base.LiveHandler()(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestReadinessDependencies(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "authorizer-001", Type: agent.AgentTypeAuthorizer})
+	require.NoError(t, err)
+
+	dbErr := errors.New("connection refused")
+	base.AddDependency(agent.DependencyPostgres, func(context.Context) error { return dbErr })
+	base.AddDependency(agent.DependencyOPA, func(context.Context) error { return nil })
+
+	ready := base.Readiness(context.Background())
+	assert.False(t, ready.Ready)
+	assert.Equal(t, "not_running", ready.Status)
+	assert.False(t, dependency(t, ready, agent.DependencyNATS).Ready, "NATS is not connected before start")
+
+	db := dependency(t, ready, agent.DependencyPostgres)
+	assert.False(t, db.Ready)
+	assert.Equal(t, "connection refused", db.LastError)
+	require.NotNil(t, db.LastErrorAt)
+
+	opa := dependency(t, ready, agent.DependencyOPA)
+	assert.True(t, opa.Ready)
+	assert.Empty(t, opa.LastError)
+
+	// The last error is kept after the dependency recovers
+	dbErr = nil
+	db = dependency(t, base.Readiness(context.Background()), agent.DependencyPostgres)
+	assert.True(t, db.Ready)
+	assert.Equal(t, "connection refused", db.LastError)
+
+	rec := httptest.NewRecorder()
+	base.ReadyHandler()(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var body agent.Readiness
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, []string{"nats", "postgres", "opa"}, []string{body.Dependencies[0].Name, body.Dependencies[1].Name, body.Dependencies[2].Name})
+}
+
+func TestCapabilitiesListProbes(t *testing.T) {
+	base, err := agent.NewBaseAgent(agent.Config{ID: "effector-001", Type: agent.AgentTypeEffector})
+	require.NoError(t, err)
+
+	var paths []string
+	for _, r := range base.Capabilities().Routes {
+		paths = append(paths, r.Path)
+	}
+	assert.Contains(t, paths, "/health/live")
+	assert.Contains(t, paths, "/health/ready")
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/inference"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// modelServer serves a fixed prediction and records the features it received
+func modelServer(t *testing.T, pred inference.Prediction, delay time.Duration) (*httptest.Server, *inference.Features) {
+	t.Helper()
+	var got inference.Features
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		time.Sleep(delay)
+		json.NewEncoder(w).Encode(pred)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// TestInferenceOptionsFromVars verifies MODEL_* parsing and defaults
+func TestInferenceOptionsFromVars(t *testing.T) {
+	opts, err := inference.OptionsFromVars(map[string]string{})
+	require.NoError(t, err)
+	assert.False(t, opts.Enabled())
+	assert.Equal(t, inference.ModeActive, opts.Mode)
+	assert.Equal(t, inference.DefaultTimeout, opts.Timeout)
+	assert.Equal(t, inference.DefaultMinConfidence, opts.MinConfidence)
+
+	opts, err = inference.OptionsFromVars(map[string]string{
+		"MODEL_URL":            "http://model:8501/v1/score/",
+		"MODEL_TIMEOUT":        "50ms",
+		"MODEL_MODE":           "Shadow",
+		"MODEL_MIN_CONFIDENCE": "0.75",
+	})
+	require.NoError(t, err)
+	assert.True(t, opts.Enabled())
+	assert.Equal(t, "http://model:8501/v1/score", opts.URL)
+	assert.Equal(t, 50*time.Millisecond, opts.Timeout)
+	assert.Equal(t, inference.ModeShadow, opts.Mode)
+	assert.Equal(t, 0.75, opts.MinConfidence)
+
+	for key, bad := range map[string]string{
+		"MODEL_TIMEOUT":        "0s",
+		"MODEL_MODE":           "enforce",
+		"MODEL_MIN_CONFIDENCE": "1.5",
+	} {
+		_, err := inference.OptionsFromVars(map[string]string{key: bad})
+		assert.Error(t, err, key)
+	}
+}
+
+// TestInferenceScoreAndDecide verifies active mode uses confident predictions and sends the features
+func TestInferenceScoreAndDecide(t *testing.T) {
+	srv, got := modelServer(t, inference.Prediction{
+		ModelVersion:  "v7",
+		Probabilities: map[string]float64{"hostile": 0.82, "unknown": 0.15, "neutral": 0.03},
+	}, 0)
+	client := inference.NewClient(inference.Options{URL: srv.URL, Timeout: time.Second, Mode: inference.ModeActive, MinConfidence: 0.6})
+
+	det := &messages.Detection{
+		TrackID:    "T-1",
+		SensorType: "radar",
+		Position:   messages.Position{Lat: 35, Lon: -115, Alt: 2000},
+		Velocity:   messages.Velocity{Speed: 800, Heading: 90},
+		Confidence: 0.7,
+	}
+	pred, err := client.Score(context.Background(), inference.FeaturesFor(det, "missile"))
+	require.NoError(t, err)
+	assert.Equal(t, "T-1", got.TrackID)
+	assert.Equal(t, "missile", got.TrackType)
+	assert.Equal(t, 800.0, got.Speed)
+
+	class, outcome := client.Decide(pred, "unknown")
+	assert.Equal(t, "hostile", class)
+	assert.Equal(t, inference.OutcomeUsed, outcome)
+
+	strict := inference.NewClient(inference.Options{URL: srv.URL, Timeout: time.Second, Mode: inference.ModeActive, MinConfidence: 0.9})
+	class, outcome = strict.Decide(pred, "unknown")
+	assert.Equal(t, "unknown", class)
+	assert.Equal(t, inference.OutcomeLowConfidence, outcome)
+
+	bogus := &inference.Prediction{ModelVersion: "v7", Probabilities: map[string]float64{"enemy": 0.99}}
+	class, outcome = client.Decide(bogus, "unknown")
+	assert.Equal(t, "unknown", class)
+	assert.Equal(t, inference.OutcomeInvalidClass, outcome)
+}
+
+// TestInferenceShadowMode verifies shadow predictions never change the output but count disagreements per version
+func TestInferenceShadowMode(t *testing.T) {
+	client := inference.NewClient(inference.Options{URL: "http://unused", Timeout: time.Second, Mode: inference.ModeShadow, MinConfidence: 0.6})
+	pred := &inference.Prediction{ModelVersion: "v8-candidate", Probabilities: map[string]float64{"hostile": 0.95}}
+
+	class, outcome := client.Decide(pred, "neutral")
+	assert.Equal(t, "neutral", class)
+	assert.Equal(t, inference.OutcomeShadow, outcome)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(client.Collectors()...)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	counts := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["model_version"] == "v8-candidate" && m.GetCounter() != nil {
+				counts[mf.GetName()] += m.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, 1.0, counts["classifier_model_predictions_total"])
+	assert.Equal(t, 1.0, counts["classifier_model_disagreements_total"])
+}
+
+// TestInferenceTimeout verifies a slow model returns an error so the caller falls back to the rules
+func TestInferenceTimeout(t *testing.T) {
+	srv, _ := modelServer(t, inference.Prediction{ModelVersion: "v7", Probabilities: map[string]float64{"hostile": 1}}, 200*time.Millisecond)
+	client := inference.NewClient(inference.Options{URL: srv.URL, Timeout: 20 * time.Millisecond, Mode: inference.ModeActive})
+
+	_, err := client.Score(context.Background(), inference.Features{TrackID: "T-2"})
+	assert.Error(t, err)
+
+	empty, _ := modelServer(t, inference.Prediction{ModelVersion: "v7"}, 0)
+	_, err = inference.NewClient(inference.Options{URL: empty.URL, Timeout: time.Second}).Score(context.Background(), inference.Features{})
+	assert.Error(t, err, "a prediction without probabilities is rejected")
+}
+
+// TestInferencePredictionTop verifies the most probable class wins, with ties broken by name
+func TestInferencePredictionTop(t *testing.T) {
+	class, prob := inference.Prediction{Probabilities: map[string]float64{"unknown": 0.4, "neutral": 0.4, "hostile": 0.2}}.Top()
+	assert.Equal(t, "neutral", class)
+	assert.Equal(t, 0.4, prob)
+
+	class, prob = inference.Prediction{}.Top()
+	assert.Empty(t, class)
+	assert.Zero(t, prob)
+	assert.Equal(t, "unknown", inference.Prediction{}.Version())
+}
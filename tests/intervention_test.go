@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/intervention"
+)
+
+func intPtr(v int) *int { return &v }
+
+// interventionRule builds an enabled rule matching action types within a priority range
+func interventionRule(id string, order int, actionTypes []string, minPriority, maxPriority *int) intervention.Rule {
+	return intervention.Rule{
+		RuleID:           id,
+		Name:             "rule-" + id,
+		ActionTypes:      actionTypes,
+		MinPriority:      minPriority,
+		MaxPriority:      maxPriority,
+		RequiresApproval: true,
+		Enabled:          true,
+		EvaluationOrder:  order,
+	}
+}
+
+// TestInterventionRuleValidate verifies match values, priority bounds, and
+// behavior flags are checked
+func TestInterventionRuleValidate(t *testing.T) {
+	valid := interventionRule("r1", 10, []string{"engage", "intercept"}, intPtr(3), intPtr(8))
+	valid.ThreatLevels = []string{"high", "critical"}
+	valid.Classifications = []string{"hostile"}
+	valid.TrackTypes = []string{"missile"}
+	require.NoError(t, valid.Validate())
+
+	for name, mutate := range map[string]func(r *intervention.Rule){
+		"missing name":        func(r *intervention.Rule) { r.Name = " " },
+		"unknown action":      func(r *intervention.Rule) { r.ActionTypes = []string{"nuke"} },
+		"unknown threat":      func(r *intervention.Rule) { r.ThreatLevels = []string{"severe"} },
+		"unknown class":       func(r *intervention.Rule) { r.Classifications = []string{"enemy"} },
+		"unknown track type":  func(r *intervention.Rule) { r.TrackTypes = []string{"satellite"} },
+		"priority too low":    func(r *intervention.Rule) { r.MinPriority = intPtr(0) },
+		"priority too high":   func(r *intervention.Rule) { r.MaxPriority = intPtr(11) },
+		"inverted priorities": func(r *intervention.Rule) { r.MinPriority, r.MaxPriority = intPtr(9), intPtr(2) },
+		"negative order":      func(r *intervention.Rule) { r.EvaluationOrder = -1 },
+		"contradictory flags": func(r *intervention.Rule) { r.AutoApprove = true },
+	} {
+		r := valid
+		mutate(&r)
+		assert.Error(t, r.Validate(), name)
+	}
+}
+
+// TestInterventionRuleOverlaps verifies overlap needs every criterion and the
+// priority ranges to intersect, with empty lists matching anything
+func TestInterventionRuleOverlaps(t *testing.T) {
+	kinetic := interventionRule("kinetic", 10, []string{"engage", "intercept"}, nil, nil)
+	broad := interventionRule("broad", 50, nil, intPtr(7), nil)
+	passive := interventionRule("passive", 30, []string{"track", "monitor"}, nil, nil)
+	lowEngage := interventionRule("low-engage", 20, []string{"engage"}, nil, intPtr(6))
+
+	assert.True(t, intervention.Overlaps(kinetic, broad), "an empty action list matches engage")
+	assert.False(t, intervention.Overlaps(kinetic, passive), "no action type in common")
+	assert.False(t, intervention.Overlaps(broad, lowEngage), "priorities 7-10 and 1-6 are disjoint")
+	assert.True(t, intervention.Overlaps(kinetic, lowEngage))
+
+	hostile := kinetic
+	hostile.Classifications = []string{"hostile"}
+	friendly := lowEngage
+	friendly.Classifications = []string{"friendly"}
+	assert.False(t, intervention.Overlaps(hostile, friendly), "disjoint classifications")
+
+	disabled := broad
+	disabled.Enabled = false
+	assert.False(t, intervention.Overlaps(kinetic, disabled), "disabled rules match nothing")
+
+	overlapping := intervention.Overlapping(kinetic, []intervention.Rule{kinetic, broad, passive, lowEngage})
+	require.Len(t, overlapping, 2, "a rule does not overlap itself")
+	assert.Equal(t, "broad", overlapping[0].RuleID)
+	assert.Equal(t, "low-engage", overlapping[1].RuleID)
+}
+
+// TestInterventionRuleConflicts verifies only overlaps at the same evaluation
+// order are conflicts
+func TestInterventionRuleConflicts(t *testing.T) {
+	kinetic := interventionRule("kinetic", 10, []string{"engage", "intercept"}, nil, nil)
+	others := []intervention.Rule{
+		interventionRule("tie", 10, []string{"intercept"}, intPtr(5), nil),
+		interventionRule("later", 40, []string{"engage"}, nil, nil),
+		interventionRule("tie-disjoint", 10, []string{"identify"}, nil, nil),
+	}
+
+	conflicts := intervention.Conflicts(kinetic, others)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "tie", conflicts[0].RuleID)
+
+	kinetic.EvaluationOrder = 5
+	assert.Empty(t, intervention.Conflicts(kinetic, others))
+	assert.Len(t, intervention.Overlapping(kinetic, others), 2)
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/lease"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKVEntry is a minimal jetstream.KeyValueEntry
+type memoryKVEntry struct {
+	key      string
+	value    []byte
+	revision uint64
+}
+
+func (e memoryKVEntry) Bucket() string                  { return "test" }
+func (e memoryKVEntry) Key() string                     { return e.key }
+func (e memoryKVEntry) Value() []byte                   { return e.value }
+func (e memoryKVEntry) Revision() uint64                { return e.revision }
+func (e memoryKVEntry) Created() time.Time              { return time.Time{} }
+func (e memoryKVEntry) Delta() uint64                   { return 0 }
+func (e memoryKVEntry) Operation() jetstream.KeyValueOp { return jetstream.KeyValuePut }
+
+// memoryKV is an in-memory compare-and-set store shared by competing leases
+type memoryKV struct {
+	mu       sync.Mutex
+	entries  map[string]memoryKVEntry
+	revision uint64
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{entries: make(map[string]memoryKVEntry)}
+}
+
+func (m *memoryKV) Get(_ context.Context, key string) (jetstream.KeyValueEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return e, nil
+}
+
+func (m *memoryKV) Create(_ context.Context, key string, value []byte) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[key]; ok {
+		return 0, jetstream.ErrKeyExists
+	}
+	m.revision++
+	m.entries[key] = memoryKVEntry{key: key, value: value, revision: m.revision}
+	return m.revision, nil
+}
+
+func (m *memoryKV) Update(_ context.Context, key string, value []byte, revision uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[key]; !ok || e.revision != revision {
+		return 0, jetstream.ErrKeyExists
+	}
+	m.revision++
+	m.entries[key] = memoryKVEntry{key: key, value: value, revision: m.revision}
+	return m.revision, nil
+}
+
+// TestLeaseClaim verifies renewal keeps the fencing token and takeover increments it
+func TestLeaseClaim(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 10 * time.Second
+
+	first, ok := lease.Claim(nil, "effector-001", now, ttl)
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), first.Token)
+
+	renewed, ok := lease.Claim(&first, "effector-001", now.Add(5*time.Second), ttl)
+	require.True(t, ok)
+	assert.Equal(t, first.Token, renewed.Token)
+	assert.Equal(t, first.AcquiredAt, renewed.AcquiredAt)
+	assert.Equal(t, now.Add(15*time.Second), renewed.ExpiresAt)
+
+	_, ok = lease.Claim(&renewed, "effector-002", now.Add(10*time.Second), ttl)
+	assert.False(t, ok, "standby must not take an unexpired lease")
+
+	takeover, ok := lease.Claim(&renewed, "effector-002", renewed.ExpiresAt, ttl)
+	require.True(t, ok)
+	assert.Equal(t, "effector-002", takeover.Holder)
+	assert.Equal(t, uint64(2), takeover.Token)
+
+	// A former holder returning after expiry starts a new tenure
+	rejoin, ok := lease.Claim(&takeover, "effector-002", takeover.ExpiresAt.Add(time.Second), ttl)
+	require.True(t, ok)
+	assert.Equal(t, uint64(3), rejoin.Token)
+}
+
+// TestLeaseSingleActive verifies only one of two competing instances is active
+// and that releasing hands over with a higher fencing token
+func TestLeaseSingleActive(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	cfg := lease.Config{Key: "effector.active", TTL: time.Minute}
+
+	cfg.Holder = "effector-001"
+	active := lease.New(kv, cfg, zerolog.Nop())
+	cfg.Holder = "effector-002"
+	standby := lease.New(kv, cfg, zerolog.Nop())
+
+	var changes []bool
+	standby.OnChange(func(active bool, token uint64) { changes = append(changes, active) })
+
+	ok, err := active.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = standby.TryAcquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	firstToken, isActive := active.Active()
+	assert.True(t, isActive)
+	_, isActive = standby.Active()
+	assert.False(t, isActive)
+
+	require.NoError(t, active.Release(ctx))
+	_, isActive = active.Active()
+	assert.False(t, isActive)
+
+	ok, err = standby.TryAcquire(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	secondToken, isActive := standby.Active()
+	assert.True(t, isActive)
+	assert.Greater(t, secondToken, firstToken)
+	assert.Equal(t, []bool{true}, changes)
+
+	current, err := standby.Current(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, "effector-002", current.Holder)
+}
@@ -0,0 +1,76 @@
+//go:build integration
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
+)
+
+// TestListTotalsCountEveryPage verifies the paged lists report the number of
+// matching rows across all pages in the body, as in X-Total-Count, rather
+// than the size of the page returned. It needs POSTGRES_URL.
+func TestListTotalsCountEveryPage(t *testing.T) {
+	db := decisionPool(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i := 0; i < 2; i++ {
+		id := insertProposal(t, db, "track", 5, "pending", now.Add(time.Hour))
+		decided := insertProposal(t, db, "track", 5, "pending", now.Add(time.Hour))
+		require.NoError(t, db.InsertDecision(ctx, testDecision(decided, "alice", true)))
+		_, err := db.Exec(ctx, `
+			INSERT INTO effects (track_id, action_type, proposal_id, status, executed_at, idempotent_key)
+			VALUES ($1, 'track', $2, 'executed', NOW(), $3)
+		`, "TEST-"+id[:8], decided, uuid.New().String())
+		require.NoError(t, err)
+		require.NoError(t, db.UpsertTrack(ctx, &messages.CorrelatedTrack{
+			TrackID:        "TEST-" + id[:8],
+			Classification: "unknown",
+			Type:           "aircraft",
+			ThreatLevel:    "low",
+			Position:       messages.Position{Lat: 35, Lon: -115, Alt: 3000},
+			Confidence:     0.5,
+			Sources:        []string{"sensor-001"},
+			DetectionCount: 1,
+			WindowStart:    now,
+			LastUpdated:    now,
+		}))
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/api/v1/proposals", handler.NewProposalHandler(db, nil, nil, twoperson.Rule{}, zerolog.Nop()).Routes())
+	r.Mount("/api/v1/decisions", handler.NewDecisionHandler(db, zerolog.Nop()).Routes())
+	r.Mount("/api/v1/effects", handler.NewEffectHandler(db, zerolog.Nop()).Routes())
+	r.Mount("/api/v1/tracks", handler.NewTrackHandler(db, zerolog.Nop()).Routes())
+
+	for _, path := range []string{"/api/v1/proposals", "/api/v1/decisions", "/api/v1/effects", "/api/v1/tracks"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path+"?limit=1", nil))
+		require.Equal(t, http.StatusOK, rec.Code, path)
+
+		var body struct {
+			Total int64 `json:"total"`
+			Limit int   `json:"limit"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body), path)
+		assert.Equal(t, 1, body.Limit, path)
+		assert.Greater(t, body.Total, int64(body.Limit), "%s total counts beyond the page", path)
+		assert.Equal(t, strconv.FormatInt(body.Total, 10), rec.Header().Get(handler.TotalCountHeader), path)
+	}
+}
@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/loadgen"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// loadgenMsg marshals a stage message for the recorder
+func loadgenMsg(t *testing.T, correlationID, trackID string, extra map[string]interface{}) []byte {
+	t.Helper()
+	msg := map[string]interface{}{
+		"envelope": map[string]interface{}{"correlation_id": correlationID},
+		"track_id": trackID,
+	}
+	for k, v := range extra {
+		msg[k] = v
+	}
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	return data
+}
+
+// TestLoadgenGenerator verifies detections carry the run's track prefix and
+// their own correlation ID, and cycle through the tracks
+func TestLoadgenGenerator(t *testing.T) {
+	opts := loadgen.DefaultOptions()
+	opts.RunID = "abc"
+	opts.Tracks = 3
+	gen := loadgen.NewGenerator(opts, 1)
+
+	var first, fourth *messages.Detection
+	for i := 0; i < 4; i++ {
+		det := gen.Next()
+		assert.True(t, strings.HasPrefix(det.TrackID, "LOAD-abc-"), det.TrackID)
+		assert.Equal(t, det.Envelope.MessageID, det.Envelope.CorrelationID)
+		assert.Equal(t, "detect.loadgen-abc.radar", det.Subject())
+		switch i {
+		case 0:
+			first = det
+		case 3:
+			fourth = det
+		}
+	}
+	assert.Equal(t, first.TrackID, fourth.TrackID, "detections cycle through the tracks")
+	assert.NotEqual(t, first.Position, fourth.Position, "tracks move between detections")
+	assert.NotEqual(t, first.Envelope.CorrelationID, fourth.Envelope.CorrelationID)
+}
+
+// TestLoadgenRecorder verifies stage messages are matched by correlation ID or
+// track, counted once per stage, and ignored when foreign
+func TestLoadgenRecorder(t *testing.T) {
+	opts := loadgen.DefaultOptions()
+	opts.RunID = "abc"
+	opts.Tracks = 2
+	gen := loadgen.NewGenerator(opts, 1)
+	rec := loadgen.NewRecorder(opts)
+
+	start := time.Now()
+	a := gen.Next()
+	b := gen.Next()
+	rec.Published(a, start, nil)
+	rec.Published(b, start.Add(10*time.Millisecond), nil)
+	rec.Published(gen.Next(), start.Add(20*time.Millisecond), assert.AnError)
+
+	_, ok := rec.Observe(loadgen.StageClassified, loadgenMsg(t, a.Envelope.CorrelationID, a.TrackID, nil), start.Add(5*time.Millisecond))
+	assert.True(t, ok)
+	_, ok = rec.Observe(loadgen.StageClassified, loadgenMsg(t, b.Envelope.CorrelationID, b.TrackID, nil), start.Add(40*time.Millisecond))
+	assert.True(t, ok)
+	rec.Observe(loadgen.StageClassified, loadgenMsg(t, a.Envelope.CorrelationID, a.TrackID, nil), start.Add(time.Second))
+
+	_, ok = rec.Observe(loadgen.StageClassified, loadgenMsg(t, "other", "TRK-001", nil), start)
+	assert.False(t, ok, "simulator traffic is not matched")
+
+	// A proposal starting its own chain is matched through the merged track
+	proposalID, ok := rec.Observe(loadgen.StageProposal, loadgenMsg(t, "new-chain", "TRK-MERGED", map[string]interface{}{
+		"merged_from": []string{"TRK-MERGED", b.TrackID},
+		"proposal_id": "P-1",
+	}), start.Add(110*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, "P-1", proposalID)
+
+	proposalID, _ = rec.Observe(loadgen.StageProposal, loadgenMsg(t, "new-chain", b.TrackID, map[string]interface{}{"proposal_id": "P-2"}), start.Add(time.Second))
+	assert.Empty(t, proposalID, "only the first proposal per detection is reported")
+
+	report := rec.Report("abc")
+	assert.Equal(t, 2, report.Published)
+	assert.Equal(t, 1, report.PublishErrors)
+
+	classified, ok := report.Stage(loadgen.StageClassified)
+	require.True(t, ok)
+	assert.Equal(t, 2, classified.Count)
+	assert.InDelta(t, 5, classified.P50Ms, 0.001)
+	assert.InDelta(t, 30, classified.MaxMs, 0.001)
+	assert.InDelta(t, 2/0.035, classified.Throughput, 0.001)
+
+	proposal, _ := report.Stage(loadgen.StageProposal)
+	assert.Equal(t, 1, proposal.Count)
+	assert.InDelta(t, 100, proposal.P99Ms, 0.001)
+
+	effect, _ := report.Stage(loadgen.StageEffect)
+	assert.Zero(t, effect.Count)
+}
+
+// TestLoadgenPercentile verifies nearest-rank percentiles
+func TestLoadgenPercentile(t *testing.T) {
+	assert.Zero(t, loadgen.Percentile(nil, 99))
+
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, loadgen.Percentile(sorted, 50))
+	assert.Equal(t, 99*time.Millisecond, loadgen.Percentile(sorted, 99))
+	assert.Equal(t, 100*time.Millisecond, loadgen.Percentile(sorted, 100))
+	assert.Equal(t, time.Millisecond, loadgen.Percentile(sorted, 0))
+
+	assert.Equal(t, 3*time.Millisecond, loadgen.Percentile(sorted[:3], 90))
+}
+
+// TestLoadgenOptions verifies invalid runs are rejected
+func TestLoadgenOptions(t *testing.T) {
+	opts := loadgen.DefaultOptions()
+	require.NoError(t, opts.Validate())
+	assert.NotEmpty(t, opts.RunID)
+
+	for _, mutate := range []func(*loadgen.Options){
+		func(o *loadgen.Options) { o.Rate = 0 },
+		func(o *loadgen.Options) { o.Duration = 0 },
+		func(o *loadgen.Options) { o.Tracks = 0 },
+		func(o *loadgen.Options) { o.Settle = -time.Second },
+		func(o *loadgen.Options) { o.RunID = "" },
+	} {
+		bad := loadgen.DefaultOptions()
+		mutate(&bad)
+		assert.Error(t, bad.Validate())
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/classify"
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// TestManualTrackDetection verifies operator contacts become detections the
+// pipeline accepts from the API source
+func TestManualTrackDetection(t *testing.T) {
+	req := handler.ManualTrackRequest{
+		Position:       &messages.Position{Lat: 35.2, Lon: -117.1, Alt: 3000},
+		Velocity:       messages.Velocity{Speed: 120, Heading: 270},
+		Type:           "aircraft",
+		Classification: "hostile",
+		Note:           "visual from OP North",
+	}
+	det, err := req.Detection("operator-1")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(det.TrackID, handler.ManualTrackIDPrefix))
+	assert.Equal(t, "api", det.Envelope.SourceType)
+	assert.Equal(t, handler.ManualSourceID, det.Envelope.Source)
+	assert.Equal(t, "detect.api-gateway.manual", det.Subject())
+	assert.Equal(t, 1.0, det.Confidence)
+	assert.JSONEq(t, `{"entered_by":"operator-1","note":"visual from OP North"}`, string(det.RawData))
+
+	data, err := json.Marshal(det)
+	require.NoError(t, err)
+	assert.NoError(t, messages.Validate(messages.SchemaDetection, data))
+
+	// The reported classification survives protobuf and is trusted by the classifier
+	encoded, contentType, err := messages.Marshal(det, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	var decoded messages.Detection
+	require.NoError(t, messages.Unmarshal(contentType, encoded, &decoded))
+	assert.Equal(t, "hostile", decoded.Classification)
+	result := classify.NewDefaultEngine().Classify(&decoded)
+	assert.Equal(t, "hostile", result.Classification)
+	assert.Equal(t, classify.ReportedRule, result.ClassificationRule)
+
+	req.TrackID, req.Note = "MAN-0001", ""
+	det, err = req.Detection("")
+	require.NoError(t, err)
+	assert.Equal(t, "MAN-0001", det.TrackID, "posting a track_id updates that contact")
+	assert.Empty(t, det.RawData)
+
+	for name, change := range map[string]func(*handler.ManualTrackRequest){
+		"no position":     func(r *handler.ManualTrackRequest) { r.Position = nil },
+		"latitude":        func(r *handler.ManualTrackRequest) { r.Position = &messages.Position{Lat: 91} },
+		"heading":         func(r *handler.ManualTrackRequest) { r.Velocity.Heading = 360 },
+		"speed":           func(r *handler.ManualTrackRequest) { r.Velocity.Speed = -1 },
+		"type":            func(r *handler.ManualTrackRequest) { r.Type = "submarine" },
+		"classification":  func(r *handler.ManualTrackRequest) { r.Classification = "suspect" },
+		"confidence":      func(r *handler.ManualTrackRequest) { c := 1.5; r.Confidence = &c },
+		"sensor track ID": func(r *handler.ManualTrackRequest) { r.TrackID = "H-TRK-0001" },
+	} {
+		bad := req
+		change(&bad)
+		_, err := bad.Detection("operator-1")
+		assert.Error(t, err, name)
+	}
+}
+
+// TestManualTrackEndpoint verifies invalid contacts are refused before
+// anything is published, and valid ones without NATS are unavailable
+func TestManualTrackEndpoint(t *testing.T) {
+	router := handler.NewTrackHandler(nil, zerolog.Nop()).Routes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/manual", strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"velocity":{"speed":10}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`not json`).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, post(`{"position":{"lat":35,"lon":-117}}`).Code)
+}
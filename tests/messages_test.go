@@ -128,70 +128,6 @@ func TestEnvelopeWithTracing(t *testing.T) {
 	}
 }
 
-// TestEnvelopeSignature tests HMAC signature generation and verification
-func TestEnvelopeSignature(t *testing.T) {
-	secret := []byte("test-secret-key-for-hmac")
-	payload := []byte(`{"test": "data"}`)
-
-	tests := []struct {
-		name          string
-		payload       []byte
-		secret        []byte
-		verifySecret  []byte
-		expectValid   bool
-	}{
-		{
-			name:         "valid signature with correct secret",
-			payload:      payload,
-			secret:       secret,
-			verifySecret: secret,
-			expectValid:  true,
-		},
-		{
-			name:         "invalid signature with wrong secret",
-			payload:      payload,
-			secret:       secret,
-			verifySecret: []byte("wrong-secret"),
-			expectValid:  false,
-		},
-		{
-			name:         "valid signature with different payload",
-			payload:      []byte(`{"different": "payload"}`),
-			secret:       secret,
-			verifySecret: secret,
-			expectValid:  true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			env := messages.NewEnvelope("test-source", "test")
-
-			// Sign the envelope
-			env.Sign(tt.payload, tt.secret)
-			assert.NotEmpty(t, env.Signature, "Signature should be generated")
-
-			// Verify the signature
-			isValid := env.VerifySignature(tt.payload, tt.verifySecret)
-			assert.Equal(t, tt.expectValid, isValid)
-		})
-	}
-}
-
-// TestEnvelopeSignatureModifiedPayload tests that modified payloads fail verification
-func TestEnvelopeSignatureModifiedPayload(t *testing.T) {
-	secret := []byte("test-secret-key-for-hmac")
-	originalPayload := []byte(`{"test": "data"}`)
-	modifiedPayload := []byte(`{"test": "modified"}`)
-
-	env := messages.NewEnvelope("test-source", "test")
-	env.Sign(originalPayload, secret)
-
-	// Verification should fail with modified payload
-	isValid := env.VerifySignature(modifiedPayload, secret)
-	assert.False(t, isValid, "Modified payload should fail verification")
-}
-
 // TestDetectionMessage tests Detection message creation and interface
 func TestDetectionMessage(t *testing.T) {
 	tests := []struct {
@@ -492,6 +428,41 @@ func TestDecisionSubject(t *testing.T) {
 	}
 }
 
+// TestExpiredDecision tests the decision recording a proposal's expiry
+func TestExpiredDecision(t *testing.T) {
+	det := messages.NewDetection("sensor-001", "radar")
+	track := messages.NewTrack(det, "classifier-001")
+	corrTrack := messages.NewCorrelatedTrack(track, "correlator-001")
+	proposal := messages.NewActionProposal(corrTrack, "planner-001")
+	proposal.ActionType = "engage"
+	proposal.ProposalID, proposal.TrackID = "proposal-001", "TRK-001"
+
+	decision := messages.NewExpiredDecision(proposal, "authorizer-001")
+	decision.DecisionID = "decision-001"
+	assert.False(t, decision.Approved)
+	assert.True(t, decision.Expired())
+	assert.Equal(t, messages.DecisionReasonExpired, decision.Reason)
+	assert.Equal(t, "decision.expired.engage", decision.Subject())
+	assert.Equal(t, messages.SchemaDecision, messages.SchemaForSubject(decision.Subject()))
+
+	data, err := json.Marshal(decision)
+	require.NoError(t, err)
+	assert.NoError(t, messages.Validate(messages.SchemaDecision, data))
+
+	// Still an expiry after protobuf
+	encoded, contentType, err := messages.Marshal(decision, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	var decoded messages.Decision
+	require.NoError(t, messages.Unmarshal(contentType, encoded, &decoded))
+	assert.True(t, decoded.Expired())
+
+	// An operator denial giving the same reason is still a denial
+	denial := messages.NewDecision(proposal, "authorizer-001")
+	denial.ApprovedBy, denial.Reason = "operator-1", messages.DecisionReasonExpired
+	assert.False(t, denial.Expired())
+	assert.Equal(t, "decision.denied.engage", denial.Subject())
+}
+
 // TestEffectLogMessage tests EffectLog message creation
 func TestEffectLogMessage(t *testing.T) {
 	det := messages.NewDetection("sensor-001", "radar")
@@ -548,26 +519,6 @@ func TestEffectLogSubject(t *testing.T) {
 	}
 }
 
-// TestMarshalWithSignature tests marshaling messages with signature
-func TestMarshalWithSignature(t *testing.T) {
-	secret := []byte("test-secret")
-
-	det := messages.NewDetection("sensor-001", "radar")
-	det.TrackID = "track-001"
-	det.Position = messages.Position{Lat: 37.7749, Lon: -122.4194, Alt: 10000}
-	det.Confidence = 0.9
-
-	data, err := messages.MarshalWithSignature(det, secret)
-	require.NoError(t, err)
-	assert.NotEmpty(t, data)
-
-	// Unmarshal and verify signature is set
-	var unmarshaled messages.Detection
-	err = json.Unmarshal(data, &unmarshaled)
-	require.NoError(t, err)
-	assert.NotEmpty(t, unmarshaled.Envelope.Signature)
-}
-
 // TestMessageJSONSerialization tests JSON serialization/deserialization
 func TestMessageJSONSerialization(t *testing.T) {
 	t.Run("Detection serialization", func(t *testing.T) {
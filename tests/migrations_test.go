@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/postgres/migrations"
+)
+
+// TestMigrationsEmbedded verifies every schema file is embedded in version
+// order without gaps
+func TestMigrationsEmbedded(t *testing.T) {
+	all, err := migrations.All()
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for i, m := range all {
+		assert.Equal(t, uint(i+1), m.Version)
+		assert.NotEmpty(t, m.Name)
+		assert.NotEmpty(t, m.SQL, "migration %d is empty", m.Version)
+	}
+	assert.Equal(t, "initial_schema", all[0].Name)
+	assert.Contains(t, all[0].SQL, "CREATE TABLE tracks")
+	assert.Equal(t, all[len(all)-1].Version, migrations.Latest())
+}
+
+// TestMigrationsParseName verifies migration file names are validated
+func TestMigrationsParseName(t *testing.T) {
+	version, name, err := migrations.ParseName("007_proposal_escalation.sql")
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), version)
+	assert.Equal(t, "proposal_escalation", name)
+
+	for _, bad := range []string{"007_proposal_escalation.up", "proposal_escalation.sql", "000_zero.sql", "7_.sql", "abc_name.sql"} {
+		_, _, err := migrations.ParseName(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestMigrationsStatus verifies when a recorded version counts as current
+func TestMigrationsStatus(t *testing.T) {
+	assert.True(t, migrations.Status{Version: 14, Latest: 14}.Current())
+	assert.True(t, migrations.Status{Version: 15, Latest: 14}.Current(), "a newer schema from a rolling upgrade is accepted")
+	assert.False(t, migrations.Status{Version: 13, Latest: 14}.Current())
+	assert.False(t, migrations.Status{Version: 14, Latest: 14, Dirty: true}.Current())
+}
+
+// TestAgentDBMigrateFlag verifies DB_MIGRATE is validated by every agent
+func TestAgentDBMigrateFlag(t *testing.T) {
+	_, err := agent.NewBaseAgent(agent.Config{
+		ID:        "migrate-bad",
+		Type:      agent.AgentTypePlanner,
+		ExtraVars: map[string]string{"DB_MIGRATE": "sometimes"},
+	})
+	assert.Error(t, err)
+
+	base, err := agent.NewBaseAgent(agent.Config{
+		ID:        "migrate-off",
+		Type:      agent.AgentTypePlanner,
+		ExtraVars: map[string]string{"DB_MIGRATE": "false"},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, base.Health().SchemaVersion, "no schema version before PrepareSchema")
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/opa"
+)
+
+// fakeOPA serves allow decisions, or 503 while down is set, counting requests
+func fakeOPA(t *testing.T, down *atomic.Bool) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if down.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":{"allow":true}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// TestOPACacheAnswersRepeatedQueries verifies identical inputs are served from
+// the cache while different inputs reach OPA
+func TestOPACacheAnswersRepeatedQueries(t *testing.T) {
+	var down atomic.Bool
+	srv, calls := fakeOPA(t, &down)
+
+	opts := opa.DefaultOptions()
+	client := opa.NewClientWithOptions(srv.URL, opts)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		decision, err := client.Decide(ctx, "cjadc2/proposal", map[string]string{"track_id": "T1"})
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+	assert.Equal(t, int64(1), calls.Load(), "repeated input should be cached")
+
+	_, err := client.Decide(ctx, "cjadc2/proposal", map[string]string{"track_id": "T2"})
+	require.NoError(t, err)
+	_, err = client.Decide(ctx, "cjadc2/effect", map[string]string{"track_id": "T1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), calls.Load(), "different input or policy should miss the cache")
+
+	opts.CacheTTL = 0
+	uncached := opa.NewClientWithOptions(srv.URL, opts)
+	for i := 0; i < 2; i++ {
+		_, err := uncached.Decide(ctx, "cjadc2/proposal", map[string]string{"track_id": "T1"})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(5), calls.Load(), "a zero TTL disables caching")
+}
+
+// TestOPABreakerOpensAndRecovers verifies the breaker fails fast after
+// consecutive failures and closes again once a probe succeeds
+func TestOPABreakerOpensAndRecovers(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv, calls := fakeOPA(t, &down)
+
+	client := opa.NewClientWithOptions(srv.URL, opa.Options{
+		BreakerThreshold: 2,
+		BreakerCooldown:  50 * time.Millisecond,
+		Fallback:         opa.FallbackNone,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Decide(ctx, "cjadc2/proposal", map[string]int{"n": i})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, opa.ErrUnavailable)
+	}
+	_, err := client.Decide(ctx, "cjadc2/proposal", map[string]int{"n": 2})
+	assert.ErrorIs(t, err, opa.ErrCircuitOpen)
+	assert.Equal(t, int64(2), calls.Load(), "an open breaker should not contact OPA")
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+
+	decision, err := client.Decide(ctx, "cjadc2/proposal", map[string]int{"n": 3})
+	require.NoError(t, err, "the probe after the cooldown should reach OPA")
+	assert.True(t, decision.Allowed)
+
+	_, err = client.Decide(ctx, "cjadc2/proposal", map[string]int{"n": 4})
+	require.NoError(t, err, "a successful probe should close the breaker")
+	assert.Equal(t, int64(4), calls.Load())
+}
+
+// TestOPAFallbackDecision verifies the configured fallback replaces the error
+// while OPA is unavailable
+func TestOPAFallbackDecision(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv, _ := fakeOPA(t, &down)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		fallback string
+		allowed  bool
+	}{
+		{opa.FallbackAllow, true},
+		{opa.FallbackDeny, false},
+	} {
+		opts := opa.DefaultOptions()
+		opts.Fallback = tc.fallback
+		client := opa.NewClientWithOptions(srv.URL, opts)
+
+		decision, err := client.Decide(ctx, "cjadc2/proposal", map[string]string{"track_id": "T1"})
+		require.NoError(t, err, tc.fallback)
+		assert.Equal(t, tc.allowed, decision.Allowed, tc.fallback)
+		assert.Equal(t, true, decision.Metadata["fallback"], tc.fallback)
+	}
+
+	client := opa.NewClientWithOptions(srv.URL, opa.DefaultOptions())
+	_, err := client.Decide(ctx, "cjadc2/proposal", map[string]string{"track_id": "T1"})
+	assert.ErrorIs(t, err, opa.ErrUnavailable, "the default fallback returns the error")
+}
+
+// TestOPAParseOptions verifies environment parsing and validation
+func TestOPAParseOptions(t *testing.T) {
+	opts, err := opa.ParseOptions("", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, opa.DefaultOptions(), opts)
+
+	opts, err = opa.ParseOptions("0", "3", "1m", "DENY")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), opts.CacheTTL)
+	assert.Equal(t, 3, opts.BreakerThreshold)
+	assert.Equal(t, time.Minute, opts.BreakerCooldown)
+	assert.Equal(t, opa.FallbackDeny, opts.Fallback)
+
+	for _, bad := range [][4]string{
+		{"soon", "", "", ""},
+		{"", "-1", "", ""},
+		{"", "", "0s", ""},
+		{"", "", "", "maybe"},
+	} {
+		_, err := opa.ParseOptions(bad[0], bad[1], bad[2], bad[3])
+		assert.Error(t, err, bad)
+	}
+}
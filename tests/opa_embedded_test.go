@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/deconflict"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/opa"
+)
+
+// policyBundlePath is the repository's policy bundle, relative to this package
+const policyBundlePath = "../policies/bundles/cjadc2"
+
+// TestEmbeddedProposalPolicy evaluates the real proposal policy in-process
+func TestEmbeddedProposalPolicy(t *testing.T) {
+	ctx := context.Background()
+	client, err := opa.NewEmbeddedClient(ctx, policyBundlePath)
+	require.NoError(t, err)
+	require.NoError(t, client.Health(ctx))
+
+	track := map[string]interface{}{"threat_level": "high", "classification": "hostile"}
+	proposal := map[string]interface{}{
+		"track_id":    "TRK-001",
+		"action_type": "intercept",
+		"priority":    8,
+		"rationale":   "Hostile aircraft closing on the defended asset",
+	}
+
+	decision, err := client.CheckProposal(ctx, proposal, track, true, nil)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed, "reasons: %v", decision.Reasons)
+	assert.Empty(t, decision.Warnings)
+
+	proposal["priority"] = 3
+	decision, err = client.CheckProposal(ctx, proposal, track, true, nil)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	require.Len(t, decision.Warnings, 1)
+	assert.Contains(t, decision.Warnings[0], "may be too low")
+	proposal["priority"] = 8
+
+	proposal["action_type"] = "launch"
+	decision, err = client.CheckProposal(ctx, proposal, track, true, nil)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	require.NotEmpty(t, decision.Reasons)
+	assert.Contains(t, decision.Reasons[0], "Invalid action type")
+
+	proposal["action_type"] = "intercept"
+	decision, err = client.CheckProposal(ctx, proposal, track, false, nil)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed, "an unknown track should be denied")
+}
+
+// TestEmbeddedProposalDeconfliction verifies policy refuses an engagement
+// tasking an asset another track's engagement holds and warns on shared airspace
+func TestEmbeddedProposalDeconfliction(t *testing.T) {
+	ctx := context.Background()
+	client, err := opa.NewEmbeddedClient(ctx, policyBundlePath)
+	require.NoError(t, err)
+
+	track := map[string]interface{}{"threat_level": "high", "classification": "hostile"}
+	proposal := &messages.ActionProposal{
+		ProposalID: "P-1",
+		TrackID:    "TRK-001",
+		ActionType: "intercept",
+		Priority:   8,
+		Rationale:  "Hostile aircraft closing on the defended asset",
+		Asset:      &messages.AssetAssignment{AssetID: "A-1", Name: "Interceptor Flight Alpha", Kind: "interceptor"},
+	}
+	other := deconflict.Engagement{ProposalID: "P-2", TrackID: "TRK-002", ActionType: "engage", AssetID: "A-1", Status: "pending"}
+
+	decision, err := client.CheckProposal(ctx, proposal, track, true, []interface{}{other})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	require.NotEmpty(t, decision.Reasons)
+	assert.Contains(t, decision.Reasons[0], "already tasked")
+
+	other.AssetID = "A-2"
+	proposal.Conflicts = []messages.ProposalConflict{{ProposalID: "P-2", TrackID: "TRK-002", ActionType: "engage", Kind: deconflict.KindAirspace, DistanceMeters: 4200}}
+	decision, err = client.CheckProposal(ctx, proposal, track, true, []interface{}{other})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed, "reasons: %v", decision.Reasons)
+	require.Len(t, decision.Warnings, 1)
+	assert.Contains(t, decision.Warnings[0], "4.2 km apart")
+}
+
+// TestEmbeddedEffectPolicy verifies the human approval requirement holds in-process
+func TestEmbeddedEffectPolicy(t *testing.T) {
+	ctx := context.Background()
+	client, err := opa.NewEmbeddedClient(ctx, policyBundlePath)
+	require.NoError(t, err)
+
+	proposal := map[string]interface{}{"proposal_id": "P-1", "expires_at": "2099-01-01T00:00:00Z"}
+	decision, err := client.CheckEffectRelease(ctx, map[string]interface{}{
+		"proposal_id": "P-1",
+		"approved":    true,
+		"approved_by": "system",
+	}, proposal, "intercept", false)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed, "system approvals must not release effects")
+}
+
+// TestEmbeddedUndefinedPolicy verifies an unknown policy path is undefined,
+// as the server reports it, rather than an error
+func TestEmbeddedUndefinedPolicy(t *testing.T) {
+	ctx := context.Background()
+	client, err := opa.NewEmbeddedClient(ctx, policyBundlePath)
+	require.NoError(t, err)
+
+	result, err := client.Query(ctx, "cjadc2/nonexistent", map[string]string{})
+	require.NoError(t, err)
+	assert.Nil(t, result.Result)
+
+	decision, err := client.Decide(ctx, "cjadc2/nonexistent", map[string]string{})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+}
+
+// TestEmbeddedClientConfig verifies mode parsing and bundle load failures
+func TestEmbeddedClientConfig(t *testing.T) {
+	opts, err := opa.OptionsFromVars(map[string]string{})
+	require.NoError(t, err)
+	assert.Equal(t, opa.ModeServer, opts.Mode)
+
+	opts, err = opa.OptionsFromVars(map[string]string{"OPA_MODE": "Embedded", "OPA_BUNDLE_PATH": "/etc/policies"})
+	require.NoError(t, err)
+	assert.Equal(t, opa.ModeEmbedded, opts.Mode)
+	assert.Equal(t, "/etc/policies", opts.BundlePath)
+
+	_, err = opa.OptionsFromVars(map[string]string{"OPA_MODE": "sidecar"})
+	assert.Error(t, err)
+
+	_, err = opa.NewEmbeddedClient(context.Background(), "does/not/exist")
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// TestParseSort verifies sort parameters, defaults, and rejected fields
+func TestParseSort(t *testing.T) {
+	keys, err := postgres.ProposalSort.ParseSort("")
+	require.NoError(t, err)
+	assert.Equal(t, "-priority,-created_at", postgres.FormatSort(keys))
+
+	keys, err = postgres.TrackSort.ParseSort("-threat_score, first_seen")
+	require.NoError(t, err)
+	assert.Equal(t, []postgres.SortKey{{Field: "threat_score", Desc: true}, {Field: "first_seen"}}, keys)
+
+	for _, v := range []string{"track_id", "-last_updated,last_updated"} {
+		_, err := postgres.TrackSort.ParseSort(v)
+		assert.ErrorIs(t, err, postgres.ErrInvalidSort, v)
+	}
+}
+
+// TestCursorRoundTrip verifies cursors decode only under the sort they were issued for
+func TestCursorRoundTrip(t *testing.T) {
+	keys, err := postgres.EffectSort.ParseSort("-executed_at")
+	require.NoError(t, err)
+	values := []string{"2024-05-01 12:00:00.123456+00", "5b0b7f8e-7a36-4b8e-9d1c-0c1f5e0a9a11"}
+
+	token := postgres.EncodeCursor(keys, values)
+	got, err := postgres.EffectSort.DecodeCursor(token, keys)
+	require.NoError(t, err)
+	assert.Equal(t, values, got)
+
+	other, err := postgres.EffectSort.ParseSort("created_at")
+	require.NoError(t, err)
+	_, err = postgres.EffectSort.DecodeCursor(token, other)
+	assert.ErrorIs(t, err, postgres.ErrInvalidCursor)
+
+	_, err = postgres.EffectSort.DecodeCursor("not a cursor!", keys)
+	assert.ErrorIs(t, err, postgres.ErrInvalidCursor)
+}
+
+// TestParsePage verifies list paging parameters and the next-page headers
+func TestParsePage(t *testing.T) {
+	page, err := handler.ParsePage(httptest.NewRequest("GET", "/api/v1/tracks?limit=abc", nil), postgres.TrackSort)
+	require.NoError(t, err)
+	assert.Equal(t, handler.DefaultPageLimit, page.Limit)
+	assert.Empty(t, page.NextCursor(0, nil))
+
+	keys, _ := postgres.TrackSort.ParseSort("")
+	token := postgres.EncodeCursor(keys, []string{"2024-05-01 12:00:00+00", "5b0b7f8e-7a36-4b8e-9d1c-0c1f5e0a9a11"})
+
+	_, err = handler.ParsePage(httptest.NewRequest("GET", "/api/v1/tracks?offset=10&cursor="+token, nil), postgres.TrackSort)
+	assert.Error(t, err, "cursor and offset are exclusive")
+
+	_, err = handler.ParsePage(httptest.NewRequest("GET", "/api/v1/tracks?sort=confidence&cursor="+token, nil), postgres.TrackSort)
+	assert.ErrorIs(t, err, postgres.ErrInvalidCursor)
+
+	r := httptest.NewRequest("GET", "/api/v1/tracks?limit=2&offset=4&state=all", nil)
+	page, err = handler.ParsePage(r, postgres.TrackSort)
+	require.NoError(t, err)
+	next := page.NextCursor(2, func() string { return token })
+	assert.Equal(t, token, next)
+
+	w := httptest.NewRecorder()
+	handler.WritePageHeaders(w, r, 7, next)
+	assert.Equal(t, "7", w.Header().Get(handler.TotalCountHeader))
+
+	link := w.Header().Get("Link")
+	require.Contains(t, link, `rel="next"`)
+	u, err := url.Parse(link[1 : len(link)-len(`>; rel="next"`)])
+	require.NoError(t, err)
+	assert.Equal(t, token, u.Query().Get("cursor"))
+	assert.Equal(t, "all", u.Query().Get("state"))
+	assert.Empty(t, u.Query().Get("offset"))
+}
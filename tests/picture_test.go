@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/picture"
+)
+
+func (m *memoryKV) Put(_ context.Context, key string, value []byte) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revision++
+	m.entries[key] = memoryKVEntry{key: key, value: value, revision: m.revision}
+	return m.revision, nil
+}
+
+func (m *memoryKV) Delete(_ context.Context, key string, _ ...jetstream.KVDeleteOpt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memoryKV) Keys(_ context.Context, _ ...jetstream.WatchOpt) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) == 0 {
+		return nil, jetstream.ErrNoKeysFound
+	}
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// pictureEntry builds a window entry whose correlated track has the given threat score
+func pictureEntry(trackID string, score float64, merged bool) picture.Entry {
+	track := &messages.Track{TrackID: trackID, Classification: "hostile", Type: "aircraft"}
+	correlated := messages.NewCorrelatedTrack(track, "correlator-test")
+	correlated.ThreatScore = score
+	return picture.Entry{
+		Track:      track,
+		Correlated: correlated,
+		ExpiresAt:  time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC),
+		Merged:     merged,
+	}
+}
+
+// TestPictureRoundTrip verifies stored entries reload with their expiry and merge state
+func TestPictureRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	p := picture.New(newMemoryKV())
+
+	entries, err := p.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "an empty bucket loads no entries")
+
+	require.NoError(t, p.Put(ctx, pictureEntry("T-1", 40, false)))
+	require.NoError(t, p.Put(ctx, pictureEntry("T-2", 80, true)))
+	require.NoError(t, p.Put(ctx, pictureEntry("T-1", 55, false)), "a track update replaces its entry")
+
+	entries, err = p.Load(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	byID := map[string]picture.Entry{}
+	for _, e := range entries {
+		byID[e.Track.TrackID] = e
+	}
+	assert.Equal(t, 55.0, byID["T-1"].Correlated.ThreatScore)
+	assert.True(t, byID["T-2"].Merged)
+	assert.True(t, byID["T-1"].ExpiresAt.Equal(time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)))
+
+	require.NoError(t, p.Delete(ctx, "T-2"))
+	require.NoError(t, p.Delete(ctx, "T-missing"), "deleting an absent track is not an error")
+	entries, err = p.Load(ctx)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, p.Put(ctx, picture.Entry{}), "an entry without a track is rejected")
+}
+
+// TestPictureTracks verifies the picture leaves out merged tracks and orders by threat
+func TestPictureTracks(t *testing.T) {
+	ctx := context.Background()
+	p := picture.New(newMemoryKV())
+	require.NoError(t, p.Put(ctx, pictureEntry("T-low", 20, false)))
+	require.NoError(t, p.Put(ctx, pictureEntry("T-high", 90, false)))
+	require.NoError(t, p.Put(ctx, pictureEntry("T-merged", 95, true)))
+
+	tracks, err := p.Tracks(ctx)
+	require.NoError(t, err)
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "T-high", tracks[0].TrackID)
+	assert.Equal(t, "T-low", tracks[1].TrackID)
+}
+
+// TestPictureKey verifies track IDs map to valid KV keys
+func TestPictureKey(t *testing.T) {
+	assert.Equal(t, "LOAD-a1b2-7", picture.Key("LOAD-a1b2-7"))
+	assert.Equal(t, "T_1_radar_x", picture.Key("T.1 radar*x"))
+}
+
+// TestPictureHandlerWithoutNATS verifies the picture endpoint refuses requests without JetStream
+func TestPictureHandlerWithoutNATS(t *testing.T) {
+	h := handler.NewPictureHandler(nil, zerolog.Nop())
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -54,9 +54,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid sensor source",
 			envelope: map[string]interface{}{
-				"source":      "sensor-001",
-				"source_type": "sensor",
-				"signature":   "valid-sig",
+				"source":         "sensor-001",
+				"source_type":    "sensor",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -65,9 +65,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid classifier source",
 			envelope: map[string]interface{}{
-				"source":      "classifier-prod-001",
-				"source_type": "classifier",
-				"signature":   "valid-sig",
+				"source":         "classifier-prod-001",
+				"source_type":    "classifier",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -76,9 +76,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid correlator source",
 			envelope: map[string]interface{}{
-				"source":      "correlator-main",
-				"source_type": "correlator",
-				"signature":   "valid-sig",
+				"source":         "correlator-main",
+				"source_type":    "correlator",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -87,9 +87,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid planner source",
 			envelope: map[string]interface{}{
-				"source":      "planner-tactical",
-				"source_type": "planner",
-				"signature":   "valid-sig",
+				"source":         "planner-tactical",
+				"source_type":    "planner",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -98,9 +98,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid authorizer source",
 			envelope: map[string]interface{}{
-				"source":      "authorizer-human",
-				"source_type": "authorizer",
-				"signature":   "valid-sig",
+				"source":         "authorizer-human",
+				"source_type":    "authorizer",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -109,9 +109,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid effector source",
 			envelope: map[string]interface{}{
-				"source":      "effector-weapons",
-				"source_type": "effector",
-				"signature":   "valid-sig",
+				"source":         "effector-weapons",
+				"source_type":    "effector",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -120,9 +120,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "valid api source",
 			envelope: map[string]interface{}{
-				"source":      "api-gateway",
-				"source_type": "api",
-				"signature":   "valid-sig",
+				"source":         "api-gateway",
+				"source_type":    "api",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: true,
@@ -131,9 +131,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "invalid source type",
 			envelope: map[string]interface{}{
-				"source":      "unknown-001",
-				"source_type": "unknown",
-				"signature":   "valid-sig",
+				"source":         "unknown-001",
+				"source_type":    "unknown",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: false,
@@ -142,9 +142,9 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "source ID does not match pattern",
 			envelope: map[string]interface{}{
-				"source":      "invalid-pattern",
-				"source_type": "sensor",
-				"signature":   "valid-sig",
+				"source":         "invalid-pattern",
+				"source_type":    "sensor",
+				"signing_key_id": "valid-sig",
 			},
 			skipSignature: false,
 			expectAllowed: false,
@@ -153,20 +153,20 @@ func TestOriginAttestationPolicy(t *testing.T) {
 		{
 			name: "missing signature",
 			envelope: map[string]interface{}{
-				"source":      "sensor-001",
-				"source_type": "sensor",
-				"signature":   "",
+				"source":         "sensor-001",
+				"source_type":    "sensor",
+				"signing_key_id": "",
 			},
 			skipSignature: false,
 			expectAllowed: false,
-			expectReasons: []string{"Missing message signature"},
+			expectReasons: []string{"Missing message signing key"},
 		},
 		{
 			name: "skip signature check enabled",
 			envelope: map[string]interface{}{
-				"source":      "sensor-001",
-				"source_type": "sensor",
-				"signature":   "",
+				"source":         "sensor-001",
+				"source_type":    "sensor",
+				"signing_key_id": "",
 			},
 			skipSignature: true,
 			expectAllowed: true,
@@ -826,9 +826,9 @@ func TestOPAClientCheckOrigin(t *testing.T) {
 		{
 			name: "valid origin",
 			envelope: map[string]interface{}{
-				"source":      "sensor-001",
-				"source_type": "sensor",
-				"signature":   "sig",
+				"source":         "sensor-001",
+				"source_type":    "sensor",
+				"signing_key_id": "sig",
 			},
 			mockResponse: map[string]interface{}{
 				"result": map[string]interface{}{
@@ -841,9 +841,9 @@ func TestOPAClientCheckOrigin(t *testing.T) {
 		{
 			name: "invalid origin",
 			envelope: map[string]interface{}{
-				"source":      "invalid",
-				"source_type": "unknown",
-				"signature":   "",
+				"source":         "invalid",
+				"source_type":    "unknown",
+				"signing_key_id": "",
 			},
 			mockResponse: map[string]interface{}{
 				"result": map[string]interface{}{
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/opa"
+	"github.com/agile-defense/cjadc2/pkg/policydata"
+)
+
+// fakeOPAServer answers the status endpoints and records data documents PUT to it
+type fakeOPAServer struct {
+	mu   sync.Mutex
+	data map[string]json.RawMessage
+}
+
+func newFakeOPAServer(t *testing.T) (*httptest.Server, *fakeOPAServer) {
+	f := &fakeOPAServer{data: make(map[string]json.RawMessage)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/health":
+			w.Write([]byte(`{}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/data/system/bundles":
+			w.Write([]byte(`{"result":{"/bundles/cjadc2":{"manifest":{"revision":"cjadc2-policies-1","roots":["cjadc2","roles"]}}}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/policies":
+			w.Write([]byte(`{"result":[{"id":"proposals/rules.rego"},{"id":"authority/approval.rego"}]}`))
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			f.mu.Lock()
+			f.data[r.URL.Path] = body
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, f
+}
+
+// fakePolicyStore returns fixed documents, or an error when err is set
+type fakePolicyStore struct {
+	docs map[string]interface{}
+	err  error
+}
+
+func (s *fakePolicyStore) PolicyDocuments(ctx context.Context) (map[string]interface{}, error) {
+	return s.docs, s.err
+}
+
+// TestOPAStatus verifies bundle revisions and modules are reported from an
+// OPA server, and that an unreachable server is reported unhealthy
+func TestOPAStatus(t *testing.T) {
+	srv, _ := newFakeOPAServer(t)
+	client := opa.NewClient(srv.URL)
+
+	status := client.Status(context.Background())
+	assert.Equal(t, opa.ModeServer, status.Mode)
+	assert.True(t, status.Healthy)
+	assert.Empty(t, status.Error)
+	require.Len(t, status.Bundles, 1)
+	assert.Equal(t, "cjadc2-policies-1", status.Bundles[0].Revision)
+	assert.Equal(t, []string{"cjadc2", "roles"}, status.Bundles[0].Roots)
+	assert.Equal(t, []string{"authority/approval.rego", "proposals/rules.rego"}, status.Policies)
+
+	srv.Close()
+	status = client.Status(context.Background())
+	assert.False(t, status.Healthy)
+	assert.NotEmpty(t, status.Error)
+}
+
+// TestOPAStatusEmbedded verifies the embedded bundle reports its manifest revision
+func TestOPAStatusEmbedded(t *testing.T) {
+	client, err := opa.NewEmbeddedClient(context.Background(), policyBundlePath)
+	require.NoError(t, err)
+
+	status := client.Status(context.Background())
+	assert.Equal(t, opa.ModeEmbedded, status.Mode)
+	assert.True(t, status.Healthy)
+	require.Len(t, status.Bundles, 1)
+	assert.NotEmpty(t, status.Bundles[0].Revision)
+	assert.NotContains(t, status.Bundles[0].Roots, policydata.Root, "pushed data must lie outside the bundle roots")
+	assert.NotEmpty(t, status.Policies)
+
+	assert.ErrorIs(t, client.PutData(context.Background(), "external/zones", []string{}), opa.ErrEmbeddedData)
+}
+
+// TestPolicyDataSync verifies every document is pushed under data.external and
+// the last sync is recorded, including failures
+func TestPolicyDataSync(t *testing.T) {
+	srv, fake := newFakeOPAServer(t)
+	store := &fakePolicyStore{docs: map[string]interface{}{
+		"zones":              []map[string]string{{"zone_id": "Z1", "kind": "protected_asset"}},
+		"break_glass_grants": []map[string]string{},
+	}}
+	syncer := policydata.New(store, opa.NewClient(srv.URL), 0, zerolog.Nop())
+	assert.Nil(t, syncer.Last())
+
+	result, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"external/break_glass_grants", "external/zones"}, result.Documents)
+	assert.JSONEq(t, `[{"zone_id":"Z1","kind":"protected_asset"}]`, string(fake.data["/v1/data/external/zones"]))
+	assert.JSONEq(t, `[]`, string(fake.data["/v1/data/external/break_glass_grants"]))
+
+	store.err = errors.New("database down")
+	_, err = syncer.Sync(context.Background())
+	require.Error(t, err)
+	last := syncer.Last()
+	require.NotNil(t, last)
+	assert.Contains(t, last.Error, "database down")
+}
+
+// TestPolicyDataParseInterval verifies POLICY_DATA_SYNC_INTERVAL parsing
+func TestPolicyDataParseInterval(t *testing.T) {
+	d, err := policydata.ParseInterval("")
+	require.NoError(t, err)
+	assert.Zero(t, d)
+
+	d, err = policydata.ParseInterval("30s")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, d)
+
+	for _, bad := range []string{"1s", "-5s", "often"} {
+		_, err := policydata.ParseInterval(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,164 @@
+//go:build integration
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/postgres/migrations"
+)
+
+// benchBatchSize matches the gateway's track persistence batch size
+const benchBatchSize = 100
+
+// benchPool connects to POSTGRES_URL, migrates it, and removes benchmark rows
+// when the benchmark ends. The benchmark is skipped when POSTGRES_URL is unset.
+func benchPool(b *testing.B) *postgres.Pool {
+	b.Helper()
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		b.Skip("POSTGRES_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := postgres.NewPoolFromURL(ctx, url)
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	if _, _, err := migrations.Up(ctx, db.Pool); err != nil {
+		db.Close()
+		b.Fatalf("migrate: %v", err)
+	}
+
+	b.Cleanup(func() {
+		db.Exec(ctx, `DELETE FROM detections WHERE sensor_id = 'bench-sensor'`)
+		db.Exec(ctx, `DELETE FROM tracks WHERE external_track_id LIKE 'BENCH-%'`)
+		db.Close()
+	})
+	return db
+}
+
+// benchTracks builds n correlated tracks spread over benchBatchSize track IDs
+func benchTracks(n int) []*messages.CorrelatedTrack {
+	now := time.Now().UTC()
+	tracks := make([]*messages.CorrelatedTrack, n)
+	for i := range tracks {
+		tracks[i] = &messages.CorrelatedTrack{
+			TrackID:        fmt.Sprintf("BENCH-%03d", i%benchBatchSize),
+			Classification: "hostile",
+			Type:           "aircraft",
+			ThreatLevel:    "high",
+			ThreatScore:    0.8,
+			Position:       messages.Position{Lat: 35, Lon: -115, Alt: 3000},
+			Velocity:       messages.Velocity{Speed: 200, Heading: 90},
+			Confidence:     0.9,
+			Sources:        []string{"sensor-001"},
+			DetectionCount: 1,
+			WindowStart:    now,
+			LastUpdated:    now,
+		}
+	}
+	return tracks
+}
+
+// benchDetections builds n detections with unique message IDs
+func benchDetections(n int) []*messages.Detection {
+	detections := make([]*messages.Detection, n)
+	for i := range detections {
+		d := messages.NewDetection("bench-sensor", "radar")
+		d.TrackID = fmt.Sprintf("BENCH-%03d", i%benchBatchSize)
+		d.Position = messages.Position{Lat: 35, Lon: -115, Alt: 3000}
+		d.Velocity = messages.Velocity{Speed: 200, Heading: 90}
+		d.Confidence = 0.8
+		detections[i] = d
+	}
+	return detections
+}
+
+// reportRowRate adds a rows/s metric to the benchmark output
+func reportRowRate(b *testing.B) {
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+// BenchmarkPostgresUpsertTrack measures one round trip per track
+func BenchmarkPostgresUpsertTrack(b *testing.B) {
+	db := benchPool(b)
+	ctx := context.Background()
+	tracks := benchTracks(b.N)
+
+	b.ResetTimer()
+	for _, track := range tracks {
+		if err := db.UpsertTrack(ctx, track); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRowRate(b)
+}
+
+// BenchmarkPostgresBatchUpsertTracks measures tracks upserted benchBatchSize per round trip
+func BenchmarkPostgresBatchUpsertTracks(b *testing.B) {
+	db := benchPool(b)
+	ctx := context.Background()
+	tracks := benchTracks(b.N)
+
+	b.ResetTimer()
+	for start := 0; start < len(tracks); start += benchBatchSize {
+		end := min(start+benchBatchSize, len(tracks))
+		if err := db.BatchUpsertTracks(ctx, tracks[start:end]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRowRate(b)
+}
+
+// BenchmarkPostgresInsertDetection measures one INSERT round trip per detection
+func BenchmarkPostgresInsertDetection(b *testing.B) {
+	db := benchPool(b)
+	ctx := context.Background()
+	detections := benchDetections(b.N)
+
+	b.ResetTimer()
+	for _, d := range detections {
+		row, err := postgres.DetectionCopyRow(d)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = db.Exec(ctx, `
+			INSERT INTO detections (
+				message_id, correlation_id, external_track_id,
+				sensor_id, sensor_type,
+				position_lat, position_lon, position_alt,
+				velocity_speed, velocity_heading,
+				confidence, raw_data, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (message_id, created_at) DO NOTHING
+		`, row...)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRowRate(b)
+}
+
+// BenchmarkPostgresBatchInsertDetections measures detections copied 500 per batch, the sink default
+func BenchmarkPostgresBatchInsertDetections(b *testing.B) {
+	db := benchPool(b)
+	ctx := context.Background()
+	detections := benchDetections(b.N)
+	const batch = 500
+
+	b.ResetTimer()
+	for start := 0; start < len(detections); start += batch {
+		end := min(start+batch, len(detections))
+		if _, err := db.BatchInsertDetections(ctx, detections[start:end]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportRowRate(b)
+}
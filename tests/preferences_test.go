@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// newPreferenceRouter returns the preference routes behind the user ID middleware.
+// The handler has no database, so only requests rejected before storage can be served.
+func newPreferenceRouter() http.Handler {
+	return handler.UserIDMiddleware(handler.NewPreferenceHandler(nil, zerolog.Nop()).Routes())
+}
+
+// TestPreferencesRequireUser tests that preference requests without a user are refused
+func TestPreferencesRequireUser(t *testing.T) {
+	router := newPreferenceRouter()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/", nil),
+		httptest.NewRequest(http.MethodGet, "/map_filter/hostiles", nil),
+		httptest.NewRequest(http.MethodPut, "/map_filter/hostiles", strings.NewReader(`{"value":{}}`)),
+		httptest.NewRequest(http.MethodDelete, "/map_filter/hostiles", nil),
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "%s %s", req.Method, req.URL.Path)
+	}
+}
+
+// TestPreferencesValidateKey tests that unknown kinds, bad names, and empty values are rejected
+func TestPreferencesValidateKey(t *testing.T) {
+	router := newPreferenceRouter()
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/?kind=theme", ""},
+		{http.MethodGet, "/theme/dark", ""},
+		{http.MethodPut, "/map_filter/bad%2Fname", `{"value":{}}`},
+		{http.MethodPut, "/map_filter/" + strings.Repeat("x", 65), `{"value":{}}`},
+		{http.MethodPut, "/column_layout/tracks", `{}`},
+		{http.MethodPut, "/column_layout/tracks", `{"value":null}`},
+		{http.MethodPut, "/default_sector/home", `not json`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set(handler.UserIDHeader, "operator-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%s %s %s", tc.method, tc.path, tc.body)
+	}
+}
+
+// TestUserIDMiddleware tests that the user ID header reaches the request context
+func TestUserIDMiddleware(t *testing.T) {
+	var seen string
+	h := handler.UserIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = handler.GetUserID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(handler.UserIDHeader, "  operator-1 ")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "operator-1", seen)
+
+	seen = "unset"
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, seen)
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/admission"
+	"github.com/agile-defense/cjadc2/pkg/priorityqueue"
+)
+
+// TestPriorityQueueOrder verifies tracks are popped most threatening first,
+// in arrival order within a threat level
+func TestPriorityQueueOrder(t *testing.T) {
+	q := priorityqueue.New[string]()
+	now := time.Now()
+	for _, subject := range []string{
+		"track.correlated.low",
+		"track.correlated.medium",
+		"track.correlated.critical",
+		"track.correlated.low",
+		"track.correlated.high",
+		"track.correlated.critical",
+	} {
+		q.Push(subject+"#"+strconv.Itoa(q.Len()), priorityqueue.RankForSubject(subject), now)
+	}
+	assert.Equal(t, 2, q.Depth(admission.RankCritical))
+	assert.Equal(t, 2, q.Depth(admission.RankLow))
+
+	var order []string
+	for _, e := range q.Drain() {
+		order = append(order, e.Item)
+	}
+	assert.Equal(t, []string{
+		"track.correlated.critical#2",
+		"track.correlated.critical#5",
+		"track.correlated.high#4",
+		"track.correlated.medium#1",
+		"track.correlated.low#0",
+		"track.correlated.low#3",
+	}, order)
+	assert.Zero(t, q.Len())
+	assert.Zero(t, q.Depth(admission.RankCritical))
+
+	_, ok := q.Pop()
+	assert.False(t, ok)
+}
+
+// TestPriorityQueueSize verifies the queue size setting, where zero keeps FIFO processing
+func TestPriorityQueueSize(t *testing.T) {
+	size, err := priorityqueue.ParseSize("PLANNER_QUEUE_SIZE", "")
+	require.NoError(t, err)
+	assert.Equal(t, priorityqueue.DefaultSize, size)
+
+	size, err = priorityqueue.ParseSize("PLANNER_QUEUE_SIZE", "0")
+	require.NoError(t, err)
+	assert.Equal(t, 1, size, "a one-message queue processes in stream order")
+
+	_, err = priorityqueue.ParseSize("PLANNER_QUEUE_SIZE", "-5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+)
+
+// TestWriteErrorProblem verifies gateway errors are problem+json classified by status
+func TestWriteErrorProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handler.WriteError(rec, http.StatusNotFound, "Proposal not found", "corr-1")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.Problem{
+		Type:          "urn:cjadc2:problem:not_found",
+		Title:         "Resource not found",
+		Status:        http.StatusNotFound,
+		Detail:        "Proposal not found",
+		Code:          problem.CodeNotFound,
+		CorrelationID: "corr-1",
+	}, p)
+
+	// An explicit code keeps the status its code is served with
+	rec = httptest.NewRecorder()
+	handler.WriteProblem(rec, problem.CodePolicyDenied, "Not authorized to approve engage", "corr-2")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	parsed, ok := problem.Parse(rec.Header().Get("Content-Type"), rec.Body.Bytes())
+	require.True(t, ok)
+	assert.Equal(t, problem.CodePolicyDenied, parsed.Code)
+}
+
+// TestProblemCodeForStatus verifies every error status falls into the taxonomy
+func TestProblemCodeForStatus(t *testing.T) {
+	for status, want := range map[int]problem.Code{
+		http.StatusBadRequest:          problem.CodeValidation,
+		http.StatusUnprocessableEntity: problem.CodeValidation,
+		http.StatusForbidden:           problem.CodeForbidden,
+		http.StatusNotFound:            problem.CodeNotFound,
+		http.StatusConflict:            problem.CodeConflict,
+		http.StatusTooManyRequests:     problem.CodeRateLimited,
+		http.StatusBadGateway:          problem.CodeUpstreamUnavailable,
+		http.StatusServiceUnavailable:  problem.CodeUpstreamUnavailable,
+		http.StatusGatewayTimeout:      problem.CodeUpstreamUnavailable,
+		http.StatusInternalServerError: problem.CodeInternal,
+		http.StatusTeapot:              problem.CodeInternal,
+	} {
+		assert.Equal(t, want, problem.CodeForStatus(status), status)
+	}
+}
+
+// TestProblemForRequest verifies agent errors carry the request path and correlation ID
+func TestProblemForRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/decisions/abc/revoke", nil)
+	req.Header.Set(problem.CorrelationIDHeader, "corr-3")
+
+	rec := httptest.NewRecorder()
+	problem.Write(rec, problem.New(problem.CodeValidation, "Invalid decision ID").For(req))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	p, ok := problem.Parse(rec.Header().Get("Content-Type"), rec.Body.Bytes())
+	require.True(t, ok)
+	assert.Equal(t, "/api/decisions/abc/revoke", p.Instance)
+	assert.Equal(t, "corr-3", p.CorrelationID)
+	assert.Equal(t, "Invalid request", p.Title)
+
+	_, ok = problem.Parse("application/json", rec.Body.Bytes())
+	assert.False(t, ok, "only problem+json bodies are problems")
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/proposaldedup"
+)
+
+// TestProposalDedupParseWindow verifies PROPOSAL_DEDUP_WINDOW parsing
+func TestProposalDedupParseWindow(t *testing.T) {
+	window, err := proposaldedup.ParseWindow("")
+	require.NoError(t, err)
+	assert.Equal(t, proposaldedup.DefaultWindow, window)
+
+	window, err = proposaldedup.ParseWindow("30s")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, window)
+
+	window, err = proposaldedup.ParseWindow("0")
+	require.NoError(t, err)
+	assert.Zero(t, window)
+
+	_, err = proposaldedup.ParseWindow("-1s")
+	assert.Error(t, err)
+	_, err = proposaldedup.ParseWindow("soon")
+	assert.Error(t, err)
+}
+
+// TestProposalDedupSuppressesRepeats verifies repeats within the window are
+// suppressed while other tracks, other actions, and escalations go out
+func TestProposalDedupSuppressesRepeats(t *testing.T) {
+	cache := proposaldedup.NewCache(10 * time.Second)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, cache.Suppressed("T-1", "intercept", 7, now), "nothing published yet")
+	cache.Published("T-1", "intercept", 7, now)
+
+	assert.True(t, cache.Suppressed("T-1", "intercept", 7, now.Add(2*time.Second)))
+	assert.True(t, cache.Suppressed("T-1", "intercept", 5, now.Add(2*time.Second)), "a lower priority repeat is suppressed")
+	assert.False(t, cache.Suppressed("T-1", "intercept", 9, now.Add(2*time.Second)), "an escalation is published")
+	assert.False(t, cache.Suppressed("T-1", "engage", 7, now.Add(2*time.Second)), "another action is published")
+	assert.False(t, cache.Suppressed("T-2", "intercept", 7, now.Add(2*time.Second)), "another track is published")
+	assert.False(t, cache.Suppressed("T-1", "intercept", 7, now.Add(10*time.Second)), "the window has passed")
+
+	cache.Published("T-1", "intercept", 9, now.Add(3*time.Second))
+	assert.True(t, cache.Suppressed("T-1", "intercept", 9, now.Add(12*time.Second)), "publishing restarts the window")
+	assert.Equal(t, 1, cache.Len(now.Add(12*time.Second)))
+	assert.Equal(t, 0, cache.Len(now.Add(13*time.Second)), "expired proposals are forgotten")
+}
+
+// TestProposalDedupDisabled verifies a zero window never suppresses
+func TestProposalDedupDisabled(t *testing.T) {
+	cache := proposaldedup.NewCache(10 * time.Second)
+	now := time.Now()
+	cache.Published("T-1", "intercept", 7, now)
+	require.True(t, cache.Suppressed("T-1", "intercept", 7, now))
+
+	cache.SetWindow(0)
+	assert.Equal(t, time.Duration(0), cache.Window())
+	assert.False(t, cache.Suppressed("T-1", "intercept", 7, now))
+}
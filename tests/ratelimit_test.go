@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/handler/problem"
+	"github.com/agile-defense/cjadc2/pkg/ratelimit"
+)
+
+func TestRateLimitParseConfig(t *testing.T) {
+	cfg, err := ratelimit.ParseConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.DefaultConfig(), cfg)
+
+	cfg, err = ratelimit.ParseConfig("0", "")
+	require.NoError(t, err)
+	assert.False(t, cfg.Enabled())
+
+	for _, args := range [][2]string{{"-1", ""}, {"fast", ""}, {"", "0"}, {"", "20000"}} {
+		_, err := ratelimit.ParseConfig(args[0], args[1])
+		assert.Error(t, err, "%v", args)
+	}
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	l := ratelimit.New(ratelimit.Config{Rate: 2, Burst: 3})
+	l.SetClock(func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		ok, _, _ := l.Allow("ip:a")
+		require.True(t, ok, "burst request %d", i)
+	}
+	ok, empty, retryAfter := l.Allow("ip:a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, empty)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// A refused request takes no token from the other bucket
+	ok, empty, _ = l.Allow("ip:b", "ip:a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, empty)
+	for i := 0; i < 3; i++ {
+		ok, _, _ := l.Allow("ip:b")
+		assert.True(t, ok)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _, _ = l.Allow("ip:a")
+	assert.True(t, ok, "one token refilled")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	l := ratelimit.New(ratelimit.Config{Rate: 0.5, Burst: 1})
+	h := handler.UserIDMiddleware(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	do := func(method, remote, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/clear", nil)
+		req.RemoteAddr = remote
+		if user != "" {
+			req.Header.Set(handler.UserIDHeader, user)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "10.0.0.1:5000", "alice").Code)
+
+	rec := do(http.MethodPost, "10.0.0.1:5001", "")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "same address on another port")
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	p, ok := problem.Parse(rec.Header().Get("Content-Type"), rec.Body.Bytes())
+	require.True(t, ok)
+	assert.Equal(t, problem.CodeRateLimited, p.Code)
+
+	// The caller is limited from a new address too
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPatch, "10.0.0.2:5000", "alice").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "10.0.0.3:5000", "bob").Code)
+
+	// Reads are never limited
+	assert.Equal(t, http.StatusNoContent, do(http.MethodGet, "10.0.0.1:5000", "alice").Code)
+}
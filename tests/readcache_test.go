@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// fakeTableNotifier delivers table changes sent on its channel until the
+// context ends or fail is closed
+type fakeTableNotifier struct {
+	changes chan string
+	fail    chan struct{}
+	listens chan struct{}
+}
+
+func newFakeTableNotifier() *fakeTableNotifier {
+	return &fakeTableNotifier{changes: make(chan string), fail: make(chan struct{}), listens: make(chan struct{}, 10)}
+}
+
+func (f *fakeTableNotifier) ListenTableChanges(ctx context.Context, ready func(), changed func(table string)) error {
+	ready()
+	f.listens <- struct{}{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-f.fail:
+			return errors.New("connection reset")
+		case table := <-f.changes:
+			changed(table)
+		}
+	}
+}
+
+// startReadCache runs a cache until the test ends and waits for it to listen
+func startReadCache(t *testing.T, notifier *fakeTableNotifier, maxAge time.Duration) *handler.ReadCache {
+	t.Helper()
+	cache := handler.NewReadCache(notifier, maxAge, zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		cache.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+	<-notifier.listens
+	require.True(t, cache.Listening())
+	return cache
+}
+
+// countingLoad returns a load function that counts its calls
+func countingLoad(calls *int) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		*calls++
+		return *calls, nil
+	}
+}
+
+func TestReadCacheHitsUntilTableChanges(t *testing.T) {
+	notifier := newFakeTableNotifier()
+	cache := startReadCache(t, notifier, time.Minute)
+
+	var calls int
+	for i := 0; i < 3; i++ {
+		v, err := cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	assert.Equal(t, 1, calls)
+
+	// A proposal write leaves cached tracks alone
+	notifier.changes <- handler.CacheTableProposals
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 1, calls)
+
+	notifier.changes <- handler.CacheTableTracks
+	v, _ := cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 2, v)
+}
+
+func TestReadCacheDropsLoadRacingChange(t *testing.T) {
+	notifier := newFakeTableNotifier()
+	cache := startReadCache(t, notifier, time.Minute)
+
+	var calls int
+	cache.Load(handler.CacheTableProposals, "q", func() (interface{}, error) {
+		calls++
+		cache.Invalidate(handler.CacheTableProposals) // The table changes mid-query
+		return "stale", nil
+	})
+	v, _ := cache.Load(handler.CacheTableProposals, "q", countingLoad(&calls))
+	assert.Equal(t, 2, v, "a result loaded across a change is not cached")
+}
+
+func TestReadCacheMaxAge(t *testing.T) {
+	notifier := newFakeTableNotifier()
+	cache := startReadCache(t, notifier, 20*time.Millisecond)
+
+	var calls int
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	time.Sleep(30 * time.Millisecond)
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 2, calls)
+}
+
+func TestReadCacheErrorsNotCached(t *testing.T) {
+	notifier := newFakeTableNotifier()
+	cache := startReadCache(t, notifier, time.Minute)
+
+	_, err := cache.Load(handler.CacheTableTracks, "q", func() (interface{}, error) { return nil, errors.New("timeout") })
+	require.Error(t, err)
+	var calls int
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 1, calls)
+}
+
+func TestReadCacheBypassedWithoutNotifications(t *testing.T) {
+	notifier := newFakeTableNotifier()
+	cache := startReadCache(t, notifier, time.Minute)
+
+	var calls int
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+
+	close(notifier.fail)
+	require.Eventually(t, func() bool { return !cache.Listening() }, time.Second, time.Millisecond)
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 3, calls, "every request reaches the database while changes cannot be heard")
+}
+
+func TestReadCacheNil(t *testing.T) {
+	var cache *handler.ReadCache
+	var calls int
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	cache.Load(handler.CacheTableTracks, "q", countingLoad(&calls))
+	assert.Equal(t, 2, calls)
+}
+
+func TestReadCacheKey(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?threat_level=high&classification=hostile", nil)
+	same := httptest.NewRequest("GET", "/?classification=hostile&threat_level=high", nil)
+	assert.Equal(t, handler.ReadCacheKey(r), handler.ReadCacheKey(same), "parameter order does not matter")
+
+	restricted := r.WithContext(handler.WithAccess(r.Context(), seclabel.Access{Clearance: "SECRET", Releasability: []string{"USA"}}))
+	assert.NotEqual(t, handler.ReadCacheKey(r), handler.ReadCacheKey(restricted), "callers with different access never share results")
+}
+
+func TestParseReadCacheMaxAge(t *testing.T) {
+	d, err := handler.ParseReadCacheMaxAge("")
+	require.NoError(t, err)
+	assert.Equal(t, handler.DefaultReadCacheMaxAge, d)
+
+	d, err = handler.ParseReadCacheMaxAge("0")
+	require.NoError(t, err)
+	assert.Zero(t, d)
+
+	_, err = handler.ParseReadCacheMaxAge("-1s")
+	assert.Error(t, err)
+	_, err = handler.ParseReadCacheMaxAge("soon")
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/rescore"
+)
+
+// realTime leaves simulated durations unscaled
+func realTime(d time.Duration) time.Duration { return d }
+
+// TestRecommend verifies the planner's action and priority rules
+func TestRecommend(t *testing.T) {
+	for _, tc := range []struct {
+		threat, classification, trackType string
+		action                            string
+		priority                          int
+	}{
+		{"critical", "hostile", "missile", "engage", 10},
+		{"critical", "unknown", "aircraft", "intercept", 9},
+		{"high", "hostile", "aircraft", "intercept", 8},
+		{"medium", "unknown", "vessel", "identify", 5},
+		{"low", "friendly", "aircraft", "monitor", 2},
+		{"low", "hostile", "aircraft", "track", 4},
+	} {
+		track := &messages.CorrelatedTrack{ThreatLevel: tc.threat, Classification: tc.classification, Type: tc.trackType}
+		action, priority, rationale := rescore.Recommend(track)
+		assert.Equal(t, tc.action, action, tc)
+		assert.Equal(t, tc.priority, priority, tc)
+		assert.NotEmpty(t, rationale)
+	}
+
+	assert.Equal(t, 10*time.Minute, rescore.TTL(9))
+	assert.Equal(t, 30*time.Minute, rescore.TTL(5))
+	assert.Equal(t, 60*time.Minute, rescore.TTL(2))
+}
+
+// TestRescore verifies pending proposals follow their track's threat level
+// without being expired on the spot
+func TestRescore(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	pending := rescore.Pending{
+		Priority:    5,
+		ThreatLevel: "medium",
+		CreatedAt:   created,
+		ExpiresAt:   created.Add(30 * time.Minute),
+	}
+	critical := &messages.CorrelatedTrack{ThreatLevel: "critical", Classification: "unknown", Type: "aircraft"}
+
+	// Escalating early brings the deadline forward to the critical TTL
+	now := created.Add(2 * time.Minute)
+	u, ok := rescore.Rescore(pending, critical, now, realTime)
+	assert.True(t, ok)
+	assert.Equal(t, 9, u.Priority)
+	assert.Equal(t, "critical", u.ThreatLevel)
+	assert.Equal(t, now.Add(10*time.Minute), u.ExpiresAt)
+
+	// Escalating late never moves the deadline into the past, or later
+	u, ok = rescore.Rescore(pending, critical, created.Add(25*time.Minute), realTime)
+	assert.True(t, ok)
+	assert.Equal(t, pending.ExpiresAt, u.ExpiresAt)
+
+	// Escalation steps are kept, up to the maximum priority
+	escalated := pending
+	escalated.EscalationLevel = messages.EscalationUrgent
+	u, _ = rescore.Rescore(escalated, critical, now, realTime)
+	assert.Equal(t, rescore.MaxPriority, u.Priority)
+
+	// De-escalating pushes the deadline back
+	low := &messages.CorrelatedTrack{ThreatLevel: "low", Classification: "neutral", Type: "vessel"}
+	u, ok = rescore.Rescore(pending, low, now, realTime)
+	assert.True(t, ok)
+	assert.Equal(t, 3, u.Priority)
+	assert.Equal(t, created.Add(60*time.Minute), u.ExpiresAt)
+
+	// Simulated TTLs are converted to wall time
+	u, _ = rescore.Rescore(pending, low, now, func(d time.Duration) time.Duration { return d / 2 })
+	assert.Equal(t, pending.ExpiresAt, u.ExpiresAt, "a shorter wall TTL never shortens a lowered proposal's deadline")
+
+	// Updates at the same threat level leave the proposal alone
+	_, ok = rescore.Rescore(pending, &messages.CorrelatedTrack{ThreatLevel: "medium", Classification: "hostile"}, now, realTime)
+	assert.False(t, ok)
+	_, ok = rescore.Rescore(pending, &messages.CorrelatedTrack{}, now, realTime)
+	assert.False(t, ok)
+}
+
+// TestProposalRescoreNotification verifies the update event UIs receive
+func TestProposalRescoreNotification(t *testing.T) {
+	proposal := &messages.ActionProposal{
+		ProposalID:  "p1",
+		TrackID:     "TRK-001",
+		ActionType:  "identify",
+		Priority:    5,
+		ThreatLevel: "medium",
+		Envelope:    messages.Envelope{MessageID: "m1", CorrelationID: "c1"},
+	}
+	notice := messages.NewProposalRescore(proposal, "authorizer-1")
+	assert.Equal(t, "notify.rescore.identify", notice.Subject())
+	assert.Equal(t, "c1", notice.Envelope.CorrelationID)
+	assert.Equal(t, 5, notice.PreviousPriority)
+	assert.Equal(t, "medium", notice.PreviousThreatLevel)
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/retention"
+)
+
+// fakeRetentionStore holds counts of archivable rows per table
+type fakeRetentionStore struct {
+	rows    map[string]int64
+	cutoffs map[string]time.Time
+	batches int
+	failOn  string
+
+	expired  map[string]int64 // Rows per expired detection partition
+	created  []time.Time
+	dropped  []string
+	aheadFor int
+}
+
+func (s *fakeRetentionStore) CountArchivable(ctx context.Context, table string, cutoff time.Time) (int64, error) {
+	s.cutoffs[table] = cutoff
+	return s.rows[table], nil
+}
+
+func (s *fakeRetentionStore) archive(table string, limit int) (int64, error) {
+	s.batches++
+	if s.failOn == table {
+		return 0, errors.New("archive failed")
+	}
+	n := s.rows[table]
+	if n > int64(limit) {
+		n = int64(limit)
+	}
+	s.rows[table] -= n
+	return n, nil
+}
+
+func (s *fakeRetentionStore) ArchiveDetections(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return s.archive(retention.TableDetections, limit)
+}
+
+func (s *fakeRetentionStore) ArchiveEffects(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	return s.archive(retention.TableEffects, limit)
+}
+
+func (s *fakeRetentionStore) EnsureDetectionPartitions(ctx context.Context, from time.Time, days int) ([]string, error) {
+	s.created = append(s.created, from)
+	s.aheadFor = days
+	return nil, nil
+}
+
+func (s *fakeRetentionStore) ArchiveDetectionPartition(ctx context.Context, cutoff time.Time) (string, int64, error) {
+	for name, n := range s.expired {
+		delete(s.expired, name)
+		s.rows[retention.TableDetections] -= n
+		s.dropped = append(s.dropped, name)
+		return name, n, nil
+	}
+	return "", 0, nil
+}
+
+func newFakeRetentionStore(detections, effects int64) *fakeRetentionStore {
+	return &fakeRetentionStore{
+		rows:    map[string]int64{retention.TableDetections: detections, retention.TableEffects: effects},
+		cutoffs: map[string]time.Time{},
+	}
+}
+
+func TestRetentionParseConfig(t *testing.T) {
+	cfg, err := retention.ParseConfig("", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, retention.DefaultConfig(), cfg)
+
+	cfg, err = retention.ParseConfig("0", "48h", "720h", "true")
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), cfg.Interval)
+	assert.Equal(t, 48*time.Hour, cfg.DetectionAge)
+	assert.Equal(t, 720*time.Hour, cfg.EffectAge)
+	assert.True(t, cfg.DryRun)
+
+	for _, args := range [][4]string{
+		{"-1h", "", "", ""},
+		{"", "1h", "", ""},  // Shorter than the DETECTIONS stream keeps messages
+		{"", "", "24h", ""}, // Shorter than the DECISIONS stream keeps messages
+		{"", "", "", "maybe"},
+	} {
+		_, err := retention.ParseConfig(args[0], args[1], args[2], args[3])
+		assert.Error(t, err, "%v", args)
+	}
+}
+
+func TestRetentionRunOnceArchivesInBatches(t *testing.T) {
+	store := newFakeRetentionStore(2*retention.BatchSize+5, 10)
+	cfg := retention.DefaultConfig()
+	archiver := retention.New(cfg, store, zerolog.Nop())
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	report, err := archiver.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, report.Tables, 2)
+
+	assert.Equal(t, retention.TableDetections, report.Tables[0].Table)
+	assert.Equal(t, int64(2*retention.BatchSize+5), report.Tables[0].Archived)
+	assert.Equal(t, now.Add(-cfg.DetectionAge), store.cutoffs[retention.TableDetections])
+	assert.Equal(t, int64(10), report.Tables[1].Archived)
+	assert.Equal(t, now.Add(-cfg.EffectAge), store.cutoffs[retention.TableEffects])
+	assert.Equal(t, 4, store.batches)
+	assert.Zero(t, store.rows[retention.TableDetections])
+}
+
+func TestRetentionDryRunMovesNothing(t *testing.T) {
+	store := newFakeRetentionStore(50, 7)
+	cfg := retention.DefaultConfig()
+	cfg.DryRun = true
+
+	report, err := retention.New(cfg, store, zerolog.Nop()).RunOnce(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(50), report.Tables[0].Eligible)
+	assert.Zero(t, report.Tables[0].Archived)
+	assert.Equal(t, int64(7), report.Tables[1].Eligible)
+	assert.Zero(t, store.batches)
+}
+
+func TestRetentionRunOnceStopsOnError(t *testing.T) {
+	store := newFakeRetentionStore(5, 5)
+	store.failOn = retention.TableDetections
+
+	report, err := retention.New(retention.DefaultConfig(), store, zerolog.Nop()).RunOnce(context.Background(), time.Now())
+	require.Error(t, err)
+	require.Len(t, report.Tables, 1)
+	assert.Equal(t, int64(5), store.rows[retention.TableEffects])
+}
+
+func TestArchivePartitionNames(t *testing.T) {
+	month := postgres.ArchiveMonth(time.Date(2026, 1, 31, 23, 0, 0, 0, time.FixedZone("EST", -5*3600)))
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), month)
+	assert.Equal(t, "effects_archive_y2026m02", postgres.ArchivePartition("effects", month))
+	assert.Equal(t, "detections_archive_y2026m02", postgres.ArchivePartition("detections", month))
+}
+
+func TestRetentionArchivesWholePartitionsFirst(t *testing.T) {
+	store := newFakeRetentionStore(3*retention.BatchSize, 0)
+	store.expired = map[string]int64{"detections_p20240501": 2 * retention.BatchSize, "detections_p20240502": 0}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	report, err := retention.New(retention.DefaultConfig(), store, zerolog.Nop()).RunOnce(context.Background(), now)
+	require.NoError(t, err)
+
+	// Empty expired partitions are dropped too; the rest moves in one batch
+	assert.ElementsMatch(t, []string{"detections_p20240501", "detections_p20240502"}, report.Tables[0].PartitionsDropped)
+	assert.Equal(t, int64(3*retention.BatchSize), report.Tables[0].Archived)
+	assert.Equal(t, 1, store.batches)
+	assert.Equal(t, []time.Time{now}, store.created)
+	assert.Equal(t, retention.PartitionDaysAhead, store.aheadFor)
+}
+
+func TestRetentionDisabledStillCreatesPartitions(t *testing.T) {
+	store := newFakeRetentionStore(10, 10)
+	cfg := retention.DefaultConfig()
+	cfg.Interval = 0
+
+	report, err := retention.New(cfg, store, zerolog.Nop()).RunOnce(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, report.Tables)
+	assert.Len(t, store.created, 1)
+	assert.Zero(t, store.batches)
+}
+
+func TestDetectionPartitionNames(t *testing.T) {
+	day := postgres.DetectionDay(time.Date(2026, 3, 4, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)))
+	assert.Equal(t, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), day)
+	assert.Equal(t, "detections_p20260305", postgres.DetectionPartition(day))
+}
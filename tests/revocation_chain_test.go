@@ -0,0 +1,58 @@
+//go:build integration
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/revocation"
+)
+
+// TestRevokeChainedDecision verifies a decision appended to the audit chain
+// can be revoked, and that the chain guard still refuses any other change to
+// it, including undoing the revocation. It needs POSTGRES_URL.
+func TestRevokeChainedDecision(t *testing.T) {
+	db := decisionPool(t)
+	ctx := context.Background()
+	id := insertProposal(t, db, "engage", 5, "pending", time.Now().Add(time.Hour))
+	decision := testDecision(id, "alice", true)
+	require.NoError(t, db.InsertDecision(ctx, decision))
+
+	var chainSeq *int64
+	require.NoError(t, db.QueryRow(ctx, `SELECT chain_seq FROM decisions WHERE decision_id = $1`, decision.DecisionID).Scan(&chainSeq))
+	require.NotNil(t, chainSeq, "the decision should be chained")
+
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	locked, err := revocation.Lock(ctx, tx, decision.DecisionID)
+	require.NoError(t, err)
+	require.NoError(t, revocation.Record(ctx, tx, locked, revocation.Revocation{
+		RevokedBy: "bob",
+		RevokedAt: time.Now().UTC(),
+		Reason:    "friendly in area",
+	}))
+	require.NoError(t, tx.Commit(ctx))
+
+	tx, err = db.Begin(ctx)
+	require.NoError(t, err)
+	rev, err := revocation.Check(ctx, tx, decision.DecisionID)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+	require.NotNil(t, rev)
+	assert.Equal(t, "bob", rev.RevokedBy)
+	assert.Equal(t, "friendly in area", rev.Reason)
+
+	_, err = db.Exec(ctx, `UPDATE decisions SET revoked_at = NULL, revoked_by = NULL WHERE decision_id = $1`, decision.DecisionID)
+	assert.ErrorContains(t, err, "append-only", "a revocation cannot be undone")
+
+	// A revocation cannot carry other changes with it
+	other := testDecision(insertProposal(t, db, "engage", 5, "pending", time.Now().Add(time.Hour)), "alice", true)
+	require.NoError(t, db.InsertDecision(ctx, other))
+	_, err = db.Exec(ctx, `UPDATE decisions SET approved_by = 'mallory', revoked_at = NOW() WHERE decision_id = $1`, other.DecisionID)
+	assert.ErrorContains(t, err, "append-only", "other columns stay append-only")
+}
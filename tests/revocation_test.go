@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/revocation"
+)
+
+// revocationTx answers the effects lookup made by revocation.Record and
+// records the statements executed
+type revocationTx struct {
+	effectStatus string // Empty when no effect has been claimed
+	execs        []string
+}
+
+func (tx *revocationTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.execs = append(tx.execs, sql)
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (tx *revocationTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return statusRow{status: tx.effectStatus}
+}
+
+type statusRow struct {
+	status string
+}
+
+func (r statusRow) Scan(dest ...any) error {
+	if r.status == "" {
+		return pgx.ErrNoRows
+	}
+	*dest[0].(*string) = r.status
+	return nil
+}
+
+// TestRecordRevocation verifies only approved, unrevoked decisions whose
+// effect has not started can be revoked, and that revocations are audited
+func TestRecordRevocation(t *testing.T) {
+	ctx := context.Background()
+	rev := revocation.Revocation{RevokedBy: "operator-1", RevokedAt: time.Now().UTC(), Reason: "friendly in area"}
+	approved := revocation.Decision{DecisionID: "d-1", ActionType: "engage", Approved: true}
+
+	tx := &revocationTx{}
+	require.NoError(t, revocation.Record(ctx, tx, approved, rev))
+	require.Len(t, tx.execs, 2)
+	assert.Contains(t, tx.execs[0], "UPDATE decisions SET revoked_at")
+	assert.Contains(t, tx.execs[1], "INSERT INTO audit_log")
+
+	denied := approved
+	denied.Approved = false
+	assert.ErrorIs(t, revocation.Record(ctx, &revocationTx{}, denied, rev), revocation.ErrNotApproved)
+
+	revoked := approved
+	revokedAt := time.Now()
+	revoked.RevokedAt = &revokedAt
+	assert.ErrorIs(t, revocation.Record(ctx, &revocationTx{}, revoked, rev), revocation.ErrAlreadyRevoked)
+
+	started := &revocationTx{effectStatus: "pending"}
+	err := revocation.Record(ctx, started, approved, rev)
+	assert.ErrorIs(t, err, revocation.ErrEffectStarted)
+	assert.Contains(t, err.Error(), "pending")
+	assert.Empty(t, started.execs, "nothing is written once the effect has started")
+}
+
+// TestRevocationResult verifies the aborted effect names who revoked the decision
+func TestRevocationResult(t *testing.T) {
+	assert.Equal(t, "Aborted: decision revoked by operator-1: friendly in area",
+		revocation.Revocation{RevokedBy: "operator-1", Reason: "friendly in area"}.Result())
+	assert.Equal(t, "Aborted: decision revoked by operator-1",
+		revocation.Revocation{RevokedBy: "operator-1"}.Result())
+}
+
+// TestDecisionRevocationSubject verifies revocations share the DECISIONS
+// stream without matching the effector's decision.approved.> filter
+func TestDecisionRevocationSubject(t *testing.T) {
+	rev := &messages.DecisionRevocation{
+		Envelope:   messages.NewEnvelope("authorizer-1", "authorizer"),
+		DecisionID: "d-1",
+		ActionType: "engage",
+		RevokedBy:  "operator-1",
+		RevokedAt:  time.Now().UTC(),
+	}
+	assert.Equal(t, "decision.revoked.engage", rev.Subject())
+
+	data, contentType, err := messages.Marshal(rev, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	assert.Equal(t, messages.ContentTypeJSON, contentType, "revocations have no protobuf encoding")
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "d-1", decoded["decision_id"])
+	assert.Equal(t, "operator-1", decoded["revoked_by"])
+}
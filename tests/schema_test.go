@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// schemaFixtures builds one valid message of each schema the way agents do
+func schemaFixtures() map[string]messages.Message {
+	det := messages.NewDetection("sensor-001", "radar")
+	det.TrackID = "T-1"
+	det.Type = "aircraft"
+	det.Position = messages.Position{Lat: 35, Lon: -115, Alt: 3000}
+	det.Velocity = messages.Velocity{Speed: 200, Heading: 90}
+	det.Confidence = 0.8
+
+	track := messages.NewTrack(det, "classifier-1")
+	track.Classification = "hostile"
+	track.Type = "aircraft"
+
+	correlated := messages.NewCorrelatedTrack(track, "correlator-1")
+	correlated.ThreatLevel = "high"
+	correlated.ThreatScore = 72
+
+	proposal := messages.NewActionProposal(correlated, "planner-1")
+	proposal.ProposalID = "prop-1"
+	proposal.ActionType = "intercept"
+	proposal.Priority = 9
+	proposal.Rationale = "Hostile aircraft closing"
+	proposal.PolicyDecision = messages.PolicyDecision{Allowed: true}
+
+	decision := messages.NewDecision(proposal, "authorizer-1")
+	decision.DecisionID = "dec-1"
+	decision.Approved = true
+	decision.ApprovedBy = "operator-1"
+
+	effect := messages.NewEffectLog(decision, "effector-1")
+	effect.EffectID = "eff-1"
+	effect.Status = "executed"
+	effect.IdempotentKey = "dec-1:intercept"
+
+	return map[string]messages.Message{
+		messages.SchemaDetection:       det,
+		messages.SchemaTrack:           track,
+		messages.SchemaCorrelatedTrack: correlated,
+		messages.SchemaActionProposal:  proposal,
+		messages.SchemaDecision:        decision,
+		messages.SchemaEffectLog:       effect,
+	}
+}
+
+// TestValidateMessages verifies messages built by the agents pass their schemas
+func TestValidateMessages(t *testing.T) {
+	fixtures := schemaFixtures()
+	require.Len(t, fixtures, len(messages.SchemaNames))
+	for name, msg := range fixtures {
+		data, err := json.Marshal(msg)
+		require.NoError(t, err)
+		assert.NoError(t, messages.Validate(name, data), name)
+
+		doc, err := messages.SchemaDocument(name)
+		require.NoError(t, err, name)
+		assert.True(t, json.Valid(doc), name)
+	}
+
+	_, err := messages.SchemaDocument("nope")
+	assert.Error(t, err)
+	assert.Error(t, messages.Validate("nope", []byte(`{}`)))
+}
+
+// TestValidateRejectsWrongFields verifies each violated rule is reported against its field
+func TestValidateRejectsWrongFields(t *testing.T) {
+	fixtures := schemaFixtures()
+
+	det := fixtures[messages.SchemaDetection].(*messages.Detection)
+	det.Confidence = 1.5
+	det.Position.Lat = 91
+	det.Envelope.MessageID = ""
+	data, err := json.Marshal(det)
+	require.NoError(t, err)
+
+	err = messages.Validate(messages.SchemaDetection, data)
+	var verr *messages.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, messages.SchemaDetection, verr.Schema)
+	assert.Len(t, verr.Errors, 3)
+	assert.Contains(t, err.Error(), "confidence")
+	assert.Contains(t, err.Error(), "position.lat")
+	assert.Contains(t, err.Error(), "envelope.message_id")
+
+	// A proposal carries its track, which is checked against the correlated track schema
+	proposal := fixtures[messages.SchemaActionProposal].(*messages.ActionProposal)
+	proposal.Track.ThreatLevel = "severe"
+	proposal.Priority = 11
+	data, err = json.Marshal(proposal)
+	require.NoError(t, err)
+	err = messages.Validate(messages.SchemaActionProposal, data)
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, err.Error(), "track.threat_level")
+	assert.Contains(t, err.Error(), "priority")
+
+	err = messages.Validate(messages.SchemaDecision, []byte(`{"decision_id":`))
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{"payload is not valid JSON"}, verr.Errors)
+
+	err = messages.Validate(messages.SchemaEffectLog, []byte(`{"effect_id":"eff-1"}`))
+	require.ErrorAs(t, err, &verr)
+	assert.Contains(t, err.Error(), "envelope is required")
+}
+
+// TestNewDeadLetter verifies dead letters keep the payload, the validation errors, and the correlation chain
+func TestNewDeadLetter(t *testing.T) {
+	det := schemaFixtures()[messages.SchemaDetection].(*messages.Detection)
+	det.Envelope.CorrelationID = "corr-1"
+	det.Confidence = 2
+	data, err := json.Marshal(det)
+	require.NoError(t, err)
+
+	cause := messages.Validate(messages.SchemaDetection, data)
+	require.Error(t, cause)
+	dl := messages.NewDeadLetter("classifier-1", "classifier", messages.SchemaDetection, data, cause)
+	dl.Consumer = "classifier"
+
+	assert.Equal(t, "dlq.classifier.detection", dl.Subject())
+	assert.Equal(t, "corr-1", dl.Envelope.CorrelationID)
+	assert.Equal(t, det.Envelope.MessageID, dl.Envelope.CausationID)
+	assert.Equal(t, data, dl.Payload)
+	assert.Equal(t, cause.Error(), dl.Error)
+	require.Len(t, dl.ValidationErrors, 1)
+	assert.Contains(t, dl.ValidationErrors[0], "confidence")
+	assert.WithinDuration(t, time.Now(), dl.RejectedAt, time.Second)
+
+	// Payloads that are not JSON start a new chain and keep only the error
+	dl = messages.NewDeadLetter("planner-1", "planner", messages.SchemaCorrelatedTrack, []byte("garbage"), errors.New("boom"))
+	assert.Empty(t, dl.Envelope.CorrelationID)
+	assert.Empty(t, dl.ValidationErrors)
+	assert.Equal(t, []byte("garbage"), dl.Payload)
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/clutter"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/scoring"
+)
+
+func truthScan(trackID, trackType, classification string, at time.Time) scoring.TrackTruth {
+	return scoring.TrackTruth{TrackID: trackID, SimulatorID: "sensor-001", Type: trackType, Classification: classification, ObservedAt: at}
+}
+
+// TestScorerClassificationPrecisionRecall verifies per-class precision and recall of classifier output
+func TestScorerClassificationPrecisionRecall(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := scoring.NewScorer(now)
+
+	s.ObserveTruth(truthScan("H-TRK-0001", "aircraft", "hostile", now))
+	s.ObserveTruth(truthScan("F-TRK-0002", "aircraft", "friendly", now))
+
+	// Two right, one friendly mistaken for hostile
+	s.ObserveClassified(&messages.Track{TrackID: "H-TRK-0001", Classification: "hostile", Type: "aircraft"})
+	s.ObserveClassified(&messages.Track{TrackID: "F-TRK-0002", Classification: "friendly", Type: "aircraft"})
+	s.ObserveClassified(&messages.Track{TrackID: "F-TRK-0002", Classification: "hostile", Type: "missile"})
+	s.ObserveClassified(&messages.Track{TrackID: "LOAD-1-1", Classification: "hostile"})
+
+	r := s.Report(now)
+	assert.Equal(t, 3, r.Classification.Scored)
+	assert.InDelta(t, 2.0/3, r.Classification.Accuracy, 1e-9)
+
+	hostile := r.Classification.Classes["hostile"]
+	assert.Equal(t, 2, hostile.Predicted)
+	assert.Equal(t, 1, hostile.Actual)
+	assert.InDelta(t, 0.5, hostile.Precision, 1e-9)
+	assert.InDelta(t, 1.0, hostile.Recall, 1e-9)
+
+	friendly := r.Classification.Classes["friendly"]
+	assert.InDelta(t, 1.0, friendly.Precision, 1e-9)
+	assert.InDelta(t, 0.5, friendly.Recall, 1e-9)
+
+	assert.InDelta(t, 2.0/3, r.Type.Accuracy, 1e-9)
+	assert.Equal(t, 1, r.Unscored, "tracks without ground truth are not scored")
+}
+
+// TestScorerTrackPrecisionRecall verifies correlated tracks are matched to truth and clutter
+func TestScorerTrackPrecisionRecall(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := scoring.NewScorer(now)
+
+	s.ObserveTruth(truthScan("H-TRK-0001", "aircraft", "hostile", now))
+	s.ObserveTruth(truthScan("H-TRK-0002", "vessel", "hostile", now))
+	s.ObserveSpurious(clutter.Truth{TrackID: "U-CL-0001", Kind: clutter.KindBirds, Spurious: true, ObservedAt: now})
+
+	s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "H-TRK-0001", MergedFrom: []string{"H-TRK-0001"}})
+	s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "U-CL-0001", MergedFrom: []string{"U-CL-0001"}})
+
+	r := s.Report(now)
+	assert.Equal(t, 2, r.Tracks.TruthTracks)
+	assert.Equal(t, 1, r.Tracks.ReportedTracks)
+	assert.Equal(t, 1, r.Tracks.TrueTracks)
+	assert.Equal(t, 1, r.Tracks.FalseTracks)
+	assert.InDelta(t, 0.5, r.Tracks.Precision, 1e-9)
+	assert.InDelta(t, 0.5, r.Tracks.Recall, 1e-9)
+
+	// Clutter merged into a real track does not make it false
+	s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "U-CL-0001", MergedFrom: []string{"U-CL-0001", "H-TRK-0002"}})
+	r = s.Report(now)
+	assert.Equal(t, 0, r.Tracks.FalseTracks)
+	assert.InDelta(t, 1.0, r.Tracks.Recall, 1e-9)
+}
+
+// TestScorerContinuity verifies fragmentation and scan coverage of true tracks
+func TestScorerContinuity(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := scoring.NewScorer(now)
+
+	// Four scans of one track: reported on three, under two correlated IDs
+	for i := 0; i < 4; i++ {
+		at := now.Add(time.Duration(i) * time.Second)
+		s.ObserveTruth(truthScan("H-TRK-0001", "aircraft", "hostile", at))
+		switch i {
+		case 0, 1:
+			s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "H-TRK-0001"})
+			s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "H-TRK-0001"}) // A second sensor's report, same scan
+		case 3:
+			s.ObserveCorrelated(&messages.CorrelatedTrack{TrackID: "H-TRK-0009", MergedFrom: []string{"H-TRK-0009", "H-TRK-0001"}})
+		}
+	}
+
+	r := s.Report(now)
+	assert.Equal(t, 1, r.Continuity.Tracks)
+	assert.Equal(t, 1, r.Continuity.Fragmented)
+	assert.InDelta(t, 2.0, r.Continuity.MeanIDsPerTrack, 1e-9)
+	assert.InDelta(t, 0.75, r.Continuity.MeanCoverage, 1e-9)
+}
+
+// TestScorerReset verifies a reset starts a new scoring period
+func TestScorerReset(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := scoring.NewScorer(now)
+	s.ObserveTruth(truthScan("H-TRK-0001", "aircraft", "hostile", now))
+	s.ObserveClassified(&messages.Track{TrackID: "H-TRK-0001", Classification: "hostile"})
+
+	later := now.Add(time.Hour)
+	s.Reset(later)
+	r := s.Report(later)
+	assert.Equal(t, later, r.Since)
+	assert.Zero(t, r.Classification.Scored)
+	assert.Zero(t, r.Tracks.TruthTracks)
+}
+
+// TestScoringHandlesStreamMessages verifies ground truth and track messages are routed by subject
+func TestScoringHandlesStreamMessages(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := scoring.NewScorer(now)
+
+	truth := truthScan("H-TRK-0001", "aircraft", "hostile", now)
+	data, err := truth.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, "truth.track.sensor-001", truth.Subject())
+	scoring.HandleTruth(s, truth.Subject(), "", data)
+
+	spurious := clutter.NewTruth(&messages.Detection{TrackID: "U-CL-0001", SensorID: "radar-1"}, clutter.KindWeather)
+	data, err = spurious.Marshal()
+	require.NoError(t, err)
+	scoring.HandleTruth(s, spurious.Subject(), "", data)
+
+	classified, err := json.Marshal(messages.Track{TrackID: "H-TRK-0001", Classification: "hostile", Type: "aircraft"})
+	require.NoError(t, err)
+	scoring.HandleTrack(s, "track.classified.hostile", messages.ContentTypeJSON, classified)
+
+	correlated, err := json.Marshal(messages.CorrelatedTrack{TrackID: "U-CL-0001", MergedFrom: []string{"U-CL-0001"}})
+	require.NoError(t, err)
+	scoring.HandleTrack(s, "track.correlated.low", messages.ContentTypeJSON, correlated)
+	scoring.HandleTrack(s, "track.correlated.low", messages.ContentTypeJSON, []byte("not json"))
+
+	r := s.Report(now)
+	assert.Equal(t, 1, r.Classification.Scored)
+	assert.Equal(t, 1, r.Tracks.FalseTracks)
+	assert.Equal(t, 0, r.Unscored)
+}
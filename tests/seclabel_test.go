@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/seclabel"
+)
+
+// TestParseAccess verifies caller clearance and releasability parsing
+func TestParseAccess(t *testing.T) {
+	access, err := seclabel.ParseAccess("", "")
+	require.NoError(t, err)
+	assert.False(t, access.Restricted())
+	assert.Nil(t, access.Labels())
+
+	access, err = seclabel.ParseAccess("Secret", " gbr, fvey,GBR ")
+	require.NoError(t, err)
+	assert.True(t, access.Restricted())
+	assert.Equal(t, seclabel.Secret, access.Clearance)
+	assert.Equal(t, []string{"FVEY", "GBR"}, access.Releasability)
+	assert.Equal(t, []string{seclabel.Unclassified, seclabel.Confidential, seclabel.Secret}, access.Labels())
+
+	for _, bad := range [][2]string{{"cosmic", ""}, {"", "G"}, {"", "GBR;USA"}} {
+		_, err := seclabel.ParseAccess(bad[0], bad[1])
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestAccessPermits verifies which labelled data each kind of caller sees
+func TestAccessPermits(t *testing.T) {
+	var operator seclabel.Access
+	assert.True(t, operator.Permits(seclabel.TopSecret, nil))
+
+	cleared := seclabel.Access{Clearance: seclabel.Confidential}
+	assert.True(t, cleared.Permits(seclabel.Confidential, nil))
+	assert.True(t, cleared.Permits("", nil), "unlabelled data is unclassified")
+	assert.False(t, cleared.Permits(seclabel.Secret, nil))
+	assert.False(t, cleared.Permits("cosmic", nil), "unknown labels are hidden")
+
+	partner := seclabel.Access{Clearance: seclabel.Secret, Releasability: []string{"GBR"}}
+	assert.True(t, partner.Permits(seclabel.Secret, []string{"FVEY", "GBR"}))
+	assert.False(t, partner.Permits(seclabel.Secret, []string{"AUS"}))
+	assert.False(t, partner.Permits(seclabel.Unclassified, nil), "unreleased data is hidden from partners")
+}
+
+// TestEnvelopeLabels verifies derived messages keep their parent's labels,
+// fused tracks take the most restrictive labels of their sources, and the
+// labels survive the protobuf encoding
+func TestEnvelopeLabels(t *testing.T) {
+	parent := messages.Envelope{Classification: seclabel.Confidential, Releasability: []string{"FVEY", "GBR"}}
+	child := messages.NewEnvelope("classifier-1", "classifier").WithLabels(parent)
+	assert.Equal(t, seclabel.Confidential, child.Classification)
+	assert.Equal(t, []string{"FVEY", "GBR"}, child.Releasability)
+
+	fused := child.WithMergedLabels(messages.Envelope{Classification: seclabel.Secret, Releasability: []string{"GBR", "USA"}})
+	assert.Equal(t, seclabel.Secret, fused.Classification)
+	assert.Equal(t, []string{"GBR"}, fused.Releasability)
+
+	var d messages.Detection
+	require.NoError(t, json.Unmarshal(sinkDetection(t, "TRK-LABELS"), &d))
+	d.Envelope = d.Envelope.WithLabels(parent)
+	data, contentType, err := messages.Marshal(&d, messages.EncodingProtobuf)
+	require.NoError(t, err)
+	var got messages.Detection
+	require.NoError(t, messages.Unmarshal(contentType, data, &got))
+	assert.Equal(t, seclabel.Confidential, got.Envelope.Classification)
+	assert.Equal(t, []string{"FVEY", "GBR"}, got.Envelope.Releasability)
+}
+
+// TestAccessMiddleware verifies caller attributes reach handlers, malformed
+// attributes are refused, and restricted callers cannot open the live feeds
+func TestAccessMiddleware(t *testing.T) {
+	var seen seclabel.Access
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = handler.GetAccess(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	h := handler.AccessMiddleware(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tracks", nil)
+	req.Header.Set(handler.ClearanceHeader, "secret")
+	req.Header.Set(handler.ReleasabilityHeader, "GBR")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, seclabel.Access{Clearance: seclabel.Secret, Releasability: []string{"GBR"}}, seen)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/tracks", nil)
+	req.Header.Set(handler.ClearanceHeader, "cosmic")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	feed := handler.AccessMiddleware(handler.RequireUnrestricted(next))
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/stream", nil)
+	req.Header.Set(handler.ReleasabilityHeader, "GBR")
+	rec = httptest.NewRecorder()
+	feed.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	feed.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stream", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/sensormodel"
+)
+
+// TestSensorModelPerfect verifies the zero error model reports the truth
+func TestSensorModelPerfect(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	truth := messages.Position{Lat: 37.0, Lon: -115.0, Alt: 9000}
+
+	var model sensormodel.ErrorModel
+	for i := 0; i < 100; i++ {
+		pos, seen := model.Observe(rng, truth)
+		require.True(t, seen)
+		assert.Equal(t, truth, pos)
+		assert.Zero(t, model.FalseAlarms(rng))
+	}
+}
+
+// TestSensorModelBias verifies a systematic bias shifts every report the same way
+func TestSensorModelBias(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	truth := messages.Position{Lat: 37.0, Lon: -115.0, Alt: 9000}
+	model := sensormodel.ErrorModel{BiasNorthMeters: 3000, BiasEastMeters: -4000}
+
+	pos, seen := model.Observe(rng, truth)
+	require.True(t, seen)
+	assert.Greater(t, pos.Lat, truth.Lat)
+	assert.Less(t, pos.Lon, truth.Lon)
+	assert.Equal(t, truth.Alt, pos.Alt)
+	assert.InDelta(t, 5000, geo.Distance(truth, pos), 25)
+}
+
+// TestSensorModelNoise verifies position noise scatters reports with the configured sigma
+func TestSensorModelNoise(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	truth := messages.Position{Lat: 37.0, Lon: -115.0}
+	model := sensormodel.ErrorModel{PositionSigmaMeters: 200}
+
+	const n = 5000
+	var sumSq float64
+	for i := 0; i < n; i++ {
+		pos, seen := model.Observe(rng, truth)
+		require.True(t, seen)
+		d := geo.Distance(truth, pos)
+		sumSq += d * d
+	}
+	// Horizontal error combines two axes, so its RMS is sigma * sqrt(2)
+	assert.InDelta(t, 200*math.Sqrt2, math.Sqrt(sumSq/n), 15)
+}
+
+// TestSensorModelDropout verifies tracks are missed at the dropout probability
+func TestSensorModelDropout(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	model := sensormodel.ErrorModel{DropoutProbability: 0.25}
+
+	const n = 10000
+	missed := 0
+	for i := 0; i < n; i++ {
+		if _, seen := model.Observe(rng, messages.Position{Lat: 37, Lon: -115}); !seen {
+			missed++
+		}
+	}
+	assert.InDelta(t, 0.25, float64(missed)/n, 0.02)
+
+	model.DropoutProbability = 1
+	_, seen := model.Observe(rng, messages.Position{Lat: 37, Lon: -115})
+	assert.False(t, seen)
+}
+
+// TestSensorModelFalseAlarms verifies the mean false alarms per scan matches the rate
+func TestSensorModelFalseAlarms(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	model := sensormodel.ErrorModel{FalseAlarmRate: 1.5}
+
+	const n = 10000
+	total := 0
+	for i := 0; i < n; i++ {
+		k := model.FalseAlarms(rng)
+		assert.True(t, k == 1 || k == 2, "whole part every scan, fraction by chance")
+		total += k
+	}
+	assert.InDelta(t, 1.5, float64(total)/n, 0.03)
+}
+
+// TestSensorModelValidate verifies error model limits and sensor set checks
+func TestSensorModelValidate(t *testing.T) {
+	valid := sensormodel.Sensor{SensorID: "radar-1", SensorType: "radar", Model: sensormodel.ErrorModel{PositionSigmaMeters: 100, DropoutProbability: 0.1, FalseAlarmRate: 0.5}}
+	assert.NoError(t, sensormodel.Validate([]sensormodel.Sensor{valid}))
+	assert.NoError(t, sensormodel.Validate(nil))
+
+	bad := []sensormodel.ErrorModel{
+		{PositionSigmaMeters: -1},
+		{PositionSigmaMeters: sensormodel.MaxPositionSigmaMeters + 1},
+		{BiasEastMeters: -sensormodel.MaxBiasMeters - 1},
+		{DropoutProbability: 1.1},
+		{FalseAlarmRate: -0.5},
+	}
+	for _, m := range bad {
+		assert.Error(t, m.Validate(), "%+v", m)
+	}
+
+	assert.Error(t, sensormodel.Validate([]sensormodel.Sensor{valid, valid}), "duplicate sensor IDs")
+	assert.Error(t, sensormodel.Validate([]sensormodel.Sensor{{SensorID: "eo-1"}}), "sensor type is required")
+	assert.Error(t, sensormodel.Validate([]sensormodel.Sensor{{SensorType: "eo"}}), "sensor ID is required")
+}
+
+// TestSensorModelParse verifies SENSOR_ERROR_MODELS parsing
+func TestSensorModelParse(t *testing.T) {
+	sensors, err := sensormodel.Parse("")
+	require.NoError(t, err)
+	assert.Empty(t, sensors)
+
+	sensors, err = sensormodel.Parse(`[{"sensor_id":"eo-1","sensor_type":"eo","error_model":{"position_sigma_meters":40,"bias_north_meters":250}}]`)
+	require.NoError(t, err)
+	require.Len(t, sensors, 1)
+	assert.Equal(t, "eo", sensors[0].SensorType)
+	assert.Equal(t, 40.0, sensors[0].Model.PositionSigmaMeters)
+	assert.Equal(t, 250.0, sensors[0].Model.BiasNorthMeters)
+
+	_, err = sensormodel.Parse(`{"sensor_id":"eo-1"}`)
+	assert.Error(t, err)
+	_, err = sensormodel.Parse(`[{"sensor_id":"eo-1","sensor_type":"eo","error_model":{"dropout_probability":2}}]`)
+	assert.Error(t, err)
+}
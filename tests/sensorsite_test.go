@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/sensorsite"
+)
+
+// TestSensorSiteParse verifies site codes from SENSOR_SITE and agent IDs
+func TestSensorSiteParse(t *testing.T) {
+	for _, tc := range []struct {
+		value, agentID, want string
+	}{
+		{"", "sensor-001", "001"},
+		{"", "sensor-west", "WEST"},
+		{"north2", "sensor-001", "NORTH2"},
+		{"AUTO", "sensor-001", sensorsite.Auto},
+	} {
+		site, err := sensorsite.Parse(tc.value, tc.agentID)
+		require.NoError(t, err, tc)
+		assert.Equal(t, tc.want, site, tc)
+	}
+
+	for _, bad := range []string{"west-1", "TOOLONGSITE", "site one"} {
+		_, err := sensorsite.Parse(bad, "sensor-001")
+		assert.Error(t, err, bad)
+	}
+	_, err := sensorsite.Parse("", "sensor-")
+	assert.Error(t, err, "an agent ID with no site code needs SENSOR_SITE")
+
+	assert.Equal(t, "H-WEST-TRK-0001", sensorsite.TrackID("H", "WEST", 1))
+}
+
+// TestSensorSiteClaim verifies two simulators cannot hold one site
+func TestSensorSiteClaim(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	logger := zerolog.Nop()
+
+	first, err := sensorsite.Claim(ctx, kv, "WEST", "sensor-a", logger)
+	require.NoError(t, err)
+	_, held := first.Active()
+	assert.True(t, held)
+
+	_, err = sensorsite.Claim(ctx, kv, "WEST", "sensor-b", logger)
+	assert.True(t, errors.Is(err, sensorsite.ErrSiteTaken))
+	assert.Contains(t, err.Error(), "sensor-a")
+
+	restarted, err := sensorsite.Claim(ctx, kv, "WEST", "sensor-a", logger)
+	require.NoError(t, err, "a restarted simulator reclaims its own site")
+
+	// Released sites are free for others
+	require.NoError(t, restarted.Release(ctx))
+	_, err = sensorsite.Claim(ctx, kv, "WEST", "sensor-b", logger)
+	assert.NoError(t, err)
+}
+
+// TestSensorSiteAllocate verifies auto sites are distinct and stable
+func TestSensorSiteAllocate(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	logger := zerolog.Nop()
+
+	a, _, err := sensorsite.Allocate(ctx, kv, "sensor-a", logger)
+	require.NoError(t, err)
+	b, _, err := sensorsite.Allocate(ctx, kv, "sensor-b", logger)
+	require.NoError(t, err)
+	assert.Equal(t, "S1", a)
+	assert.Equal(t, "S2", b)
+
+	again, claim, err := sensorsite.Allocate(ctx, kv, "sensor-b", logger)
+	require.NoError(t, err)
+	assert.Equal(t, "S2", again, "a restarted simulator keeps its site")
+
+	require.NoError(t, claim.Release(ctx))
+	c, _, err := sensorsite.Allocate(ctx, kv, "sensor-c", logger)
+	require.NoError(t, err)
+	assert.Equal(t, "S2", c, "the lowest free site is taken")
+}
@@ -0,0 +1,292 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// fakeSessionStore keeps sessions and their events in memory
+type fakeSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*postgres.SessionRow
+	events   map[string][]postgres.SessionEventRow
+}
+
+func newFakeSessionStore() *fakeSessionStore {
+	return &fakeSessionStore{sessions: map[string]*postgres.SessionRow{}, events: map[string][]postgres.SessionEventRow{}}
+}
+
+func (f *fakeSessionStore) StartSession(_ context.Context, s *postgres.SessionRow) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.sessions {
+		if existing.Recording() {
+			return postgres.ErrSessionRecording
+		}
+	}
+	s.SessionID = uuid.New().String()
+	s.StartedAt = time.Now().UTC()
+	row := *s
+	f.sessions[s.SessionID] = &row
+	return nil
+}
+
+func (f *fakeSessionStore) StopSession(_ context.Context, id string, now time.Time) (*postgres.SessionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	if s.EndedAt == nil {
+		s.EndedAt = &now
+	}
+	row := *s
+	return &row, nil
+}
+
+func (f *fakeSessionStore) GetSession(_ context.Context, id string) (*postgres.SessionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	row := *s
+	return &row, nil
+}
+
+func (f *fakeSessionStore) RecordingSession(_ context.Context) (*postgres.SessionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.sessions {
+		if s.Recording() {
+			row := *s
+			return &row, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSessionStore) ListSessions(_ context.Context, _ int) ([]postgres.SessionRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows []postgres.SessionRow
+	for _, s := range f.sessions {
+		rows = append(rows, *s)
+	}
+	return rows, nil
+}
+
+func (f *fakeSessionStore) DeleteSession(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.sessions[id]
+	delete(f.sessions, id)
+	delete(f.events, id)
+	return ok, nil
+}
+
+func (f *fakeSessionStore) InsertSessionEvents(_ context.Context, id string, events []postgres.SessionEventRow) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events[id] = append(f.events[id], events...)
+	f.sessions[id].EventCount += len(events)
+	return nil
+}
+
+func (f *fakeSessionStore) ListSessionEvents(_ context.Context, id string, afterSeq, limit int) ([]postgres.SessionEventRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var rows []postgres.SessionEventRow
+	for _, e := range f.events[id] {
+		if e.Seq > afterSeq && len(rows) < limit {
+			rows = append(rows, e)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeSessionStore) recorded(id string) []postgres.SessionEventRow {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]postgres.SessionEventRow(nil), f.events[id]...)
+}
+
+// captureRecorder collects every event the hub broadcasts
+type captureRecorder struct {
+	mu     sync.Mutex
+	events []handler.Event
+}
+
+func (c *captureRecorder) Record(ev handler.Event) {
+	c.mu.Lock()
+	c.events = append(c.events, ev)
+	c.mu.Unlock()
+}
+
+func (c *captureRecorder) snapshot() []handler.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]handler.Event(nil), c.events...)
+}
+
+func sessionRequest(t *testing.T, h *handler.SessionHandler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestSessionRecords verifies which broadcasts a session records
+func TestSessionRecords(t *testing.T) {
+	for _, eventType := range []string{handler.MessageTypeProposalNew, handler.MessageTypeDecisionMade, handler.MessageTypeEffectExecuted, handler.MessageTypeEngagementCompleted} {
+		assert.True(t, handler.SessionRecords(eventType, false), eventType)
+	}
+	assert.False(t, handler.SessionRecords(handler.MessageTypeTrackUpdate, false))
+	assert.True(t, handler.SessionRecords(handler.MessageTypeTrackUpdate, true))
+	assert.False(t, handler.SessionRecords(handler.MessageTypeMetricsUpdate, true))
+	assert.False(t, handler.SessionRecords(handler.MessageTypeSessionReplay, true))
+}
+
+// TestSessionStartValidation verifies start requests are validated and one session records at a time
+func TestSessionStartValidation(t *testing.T) {
+	h := handler.NewSessionHandler(newFakeSessionStore(), nil, zerolog.Nop())
+
+	assert.Equal(t, http.StatusBadRequest, sessionRequest(t, h, http.MethodPost, "/", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sessionRequest(t, h, http.MethodPost, "/", `{"name":"`+strings.Repeat("x", 201)+`"}`).Code)
+	assert.Equal(t, http.StatusCreated, sessionRequest(t, h, http.MethodPost, "/", `{"name":"Exercise Alpha"}`).Code)
+	assert.Equal(t, http.StatusConflict, sessionRequest(t, h, http.MethodPost, "/", `{"name":"Exercise Bravo"}`).Code)
+
+	assert.Equal(t, http.StatusNotFound, sessionRequest(t, h, http.MethodGet, "/not-a-uuid", "").Code)
+	assert.Equal(t, http.StatusNotFound, sessionRequest(t, h, http.MethodPost, "/"+uuid.New().String()+"/stop", "").Code)
+}
+
+// TestSessionRecordsBroadcasts verifies a recording session stores the events
+// it records in broadcast order and stops recording when stopped
+func TestSessionRecordsBroadcasts(t *testing.T) {
+	store := newFakeSessionStore()
+	h := handler.NewSessionHandler(store, nil, zerolog.Nop())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Run(ctx)
+		close(done)
+	}()
+
+	rec := sessionRequest(t, h, http.MethodPost, "/", `{"name":"Exercise Alpha"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var started handler.SessionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &started))
+	id := started.Session.SessionID
+
+	event := func(eventType, payload string) handler.Event {
+		ev, err := handler.NewEvent(eventType, json.RawMessage(payload), time.Now().UTC())
+		require.NoError(t, err)
+		return ev
+	}
+	h.Record(event(handler.MessageTypeProposalNew, `{"proposal_id":"p1"}`))
+	h.Record(event(handler.MessageTypeTrackUpdate, `{"track_id":"t1"}`))
+	replayed := event(handler.MessageTypeDecisionMade, `{"decision_id":"old"}`)
+	replayed.Replay = &handler.EventReplay{ReplayID: "r1", SessionID: "s0", Seq: 1}
+	h.Record(replayed)
+	h.Record(event(handler.MessageTypeDecisionMade, `{"decision_id":"d1"}`))
+
+	rec = sessionRequest(t, h, http.MethodPost, "/"+id+"/stop", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	h.Record(event(handler.MessageTypeEffectExecuted, `{"effect_id":"e1"}`))
+
+	cancel()
+	<-done
+
+	events := store.recorded(id)
+	require.Len(t, events, 2, "tracks, replayed events, and events after stop are not recorded")
+	assert.Equal(t, 1, events[0].Seq)
+	assert.Equal(t, handler.MessageTypeProposalNew, events[0].EventType)
+	assert.Equal(t, 2, events[1].Seq)
+	assert.Equal(t, handler.MessageTypeDecisionMade, events[1].EventType)
+
+	var stored handler.Event
+	require.NoError(t, json.Unmarshal(events[1].Event, &stored))
+	assert.JSONEq(t, `{"decision_id":"d1"}`, string(stored.Payload))
+}
+
+// TestSessionReplay verifies a replay re-broadcasts the session's events in
+// order, marked as replayed, between session.replay announcements
+func TestSessionReplay(t *testing.T) {
+	store := newFakeSessionStore()
+	session := postgres.SessionRow{Name: "Exercise Alpha", StartedBy: "trainer"}
+	require.NoError(t, store.StartSession(context.Background(), &session))
+	_, _ = store.StopSession(context.Background(), session.SessionID, time.Now())
+
+	recorded := time.Date(2024, 3, 9, 14, 0, 0, 0, time.UTC)
+	var rows []postgres.SessionEventRow
+	for i, eventType := range []string{handler.MessageTypeProposalNew, handler.MessageTypeDecisionMade, handler.MessageTypeEffectExecuted} {
+		ev, err := handler.NewEvent(eventType, json.RawMessage(`{"n":1}`), recorded)
+		require.NoError(t, err)
+		data, err := json.Marshal(ev)
+		require.NoError(t, err)
+		rows = append(rows, postgres.SessionEventRow{Seq: i + 1, EventType: eventType, RecordedAt: recorded.Add(time.Duration(i) * time.Minute), Event: data})
+	}
+	require.NoError(t, store.InsertSessionEvents(context.Background(), session.SessionID, rows))
+
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	capture := &captureRecorder{}
+	hub.SetRecorder(capture)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	h := handler.NewSessionHandler(store, hub, zerolog.Nop())
+	path := "/" + session.SessionID + "/replay"
+
+	assert.Equal(t, http.StatusBadRequest, sessionRequest(t, h, http.MethodPost, path, `{"speed":1000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, sessionRequest(t, h, http.MethodPost, path, `{"max_gap":"soon"}`).Code)
+	assert.Equal(t, http.StatusNotFound, sessionRequest(t, h, http.MethodPost, "/"+uuid.New().String()+"/replay", "").Code)
+
+	// A minute between events capped at 100ms and halved keeps the replay short
+	rec := sessionRequest(t, h, http.MethodPost, path, `{"speed":2,"max_gap":"100ms"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, http.StatusConflict, sessionRequest(t, h, http.MethodPost, path, "").Code, "one replay at a time")
+	assert.Equal(t, http.StatusConflict, sessionRequest(t, h, http.MethodDelete, "/"+session.SessionID, "").Code, "a replaying session cannot be deleted")
+
+	require.Eventually(t, func() bool {
+		events := capture.snapshot()
+		return len(events) == 5
+	}, 5*time.Second, 10*time.Millisecond)
+
+	events := capture.snapshot()
+	assert.Equal(t, handler.MessageTypeSessionReplay, events[0].EventType)
+	assert.Contains(t, string(events[0].Payload), `"status":"started"`)
+	for i, eventType := range []string{handler.MessageTypeProposalNew, handler.MessageTypeDecisionMade, handler.MessageTypeEffectExecuted} {
+		ev := events[i+1]
+		assert.Equal(t, eventType, ev.EventType)
+		require.NotNil(t, ev.Replay)
+		assert.Equal(t, session.SessionID, ev.Replay.SessionID)
+		assert.Equal(t, i+1, ev.Replay.Seq)
+		assert.Equal(t, recorded, ev.TS, "replayed events keep their original time")
+	}
+	assert.Equal(t, handler.MessageTypeSessionReplay, events[4].EventType)
+	assert.Contains(t, string(events[4].Payload), `"status":"completed"`)
+	assert.Contains(t, string(events[4].Payload), `"events":3`)
+
+	require.Eventually(t, func() bool {
+		return sessionRequest(t, h, http.MethodDelete, "/"+session.SessionID+"/replay", "").Code == http.StatusNotFound
+	}, time.Second, 10*time.Millisecond, "the finished replay can no longer be stopped")
+	assert.Equal(t, http.StatusNoContent, sessionRequest(t, h, http.MethodDelete, "/"+session.SessionID, "").Code)
+}
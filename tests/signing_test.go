@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/config"
+	"github.com/agile-defense/cjadc2/pkg/crypto"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/signing"
+)
+
+func TestSignatureVerifiesUnderSourceKey(t *testing.T) {
+	ctx := context.Background()
+	registry := signing.NewRegistry(newMemoryKV())
+	now := time.Now().UTC()
+
+	key, err := signing.GenerateKey("planner-001", "planner", now, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(ctx, key.PublicKey))
+
+	data := []byte(`{"message_id":"msg-1"}`)
+	sig := key.Sign(data)
+	require.NoError(t, registry.Verify(ctx, "planner-001", key.KeyID, data, sig, now))
+
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", key.KeyID, []byte(`{"message_id":"msg-2"}`), sig, now), signing.ErrBadSignature)
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", key.KeyID, data, "not-base64!", now), signing.ErrBadSignature)
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", key.KeyID, data, "", now), signing.ErrUnsigned)
+
+	// Another agent cannot claim the key, and a message cannot name another
+	// agent's key as its own
+	assert.ErrorIs(t, registry.Verify(ctx, "authorizer-001", key.KeyID, data, sig, now), signing.ErrUnknownKey)
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", "", data, sig, now), signing.ErrUnknownKey)
+
+	// Only messages stored while the key is valid verify
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", key.KeyID, data, sig, now.Add(2*time.Hour)), signing.ErrKeyNotValid)
+	assert.ErrorIs(t, registry.Verify(ctx, "planner-001", key.KeyID, data, sig, now.Add(-time.Minute)), signing.ErrKeyNotValid)
+	assert.NoError(t, registry.Verify(ctx, "planner-001", key.KeyID, data, sig, now.Add(-signing.ClockSkew/2)))
+}
+
+func TestRevokedKeyStopsVerifying(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	registry := signing.NewRegistry(kv)
+	now := time.Now().UTC()
+
+	key, err := signing.GenerateKey("sensor-001", "sensor", now, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(ctx, key.PublicKey))
+	_, err = kv.Get(ctx, signing.RegistryKey("sensor-001", key.KeyID))
+	require.NoError(t, err, "keys are registered under the agent's ID")
+
+	data := []byte("detection")
+	require.NoError(t, registry.Verify(ctx, "sensor-001", key.KeyID, data, key.Sign(data), now))
+
+	require.NoError(t, registry.Revoke(ctx, "sensor-001", key.KeyID))
+	err = registry.Verify(ctx, "sensor-001", key.KeyID, data, key.Sign(data), now)
+	assert.ErrorIs(t, err, signing.ErrUnknownKey)
+	assert.True(t, signing.Rejected(err))
+}
+
+func TestRegistryRefusesMismatchedEntry(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	now := time.Now().UTC()
+
+	key, err := signing.GenerateKey("effector-001", "effector", now, time.Hour)
+	require.NoError(t, err)
+
+	// An entry filed under one agent but naming another does not verify for either
+	entry, err := json.Marshal(key.PublicKey)
+	require.NoError(t, err)
+	_, err = kv.Put(ctx, signing.RegistryKey("authorizer-001", key.KeyID), entry)
+	require.NoError(t, err)
+
+	data := []byte("decision")
+	err = signing.NewRegistry(kv).Verify(ctx, "authorizer-001", key.KeyID, data, key.Sign(data), now)
+	assert.ErrorIs(t, err, signing.ErrUnknownKey)
+}
+
+func TestSignerRotatesWithOverlap(t *testing.T) {
+	ctx := context.Background()
+	registry := signing.NewRegistry(newMemoryKV())
+	signer := signing.NewSigner(registry, "classifier-001", "classifier", 24*time.Hour, time.Hour)
+	assert.Nil(t, signer.Key())
+	assert.True(t, signer.NextRotation().IsZero())
+
+	start := time.Now().UTC()
+	first, err := signer.Rotate(ctx, start)
+	require.NoError(t, err)
+	assert.Equal(t, first, signer.Key())
+	assert.Equal(t, signing.KeyIDOf(first.PublicKey.PublicKey), first.KeyID)
+	assert.Equal(t, start.Add(23*time.Hour), signer.NextRotation())
+
+	second, err := signer.Rotate(ctx, signer.NextRotation())
+	require.NoError(t, err)
+	assert.NotEqual(t, first.KeyID, second.KeyID)
+	assert.Equal(t, second, signer.Key())
+
+	// A message signed with the old key just before the rotation, stored
+	// after it, still verifies during the overlap
+	data := []byte("track")
+	storedAt := first.NotAfter.Add(-30 * time.Minute)
+	assert.NoError(t, registry.Verify(ctx, "classifier-001", first.KeyID, data, first.Sign(data), storedAt))
+	assert.NoError(t, registry.Verify(ctx, "classifier-001", second.KeyID, data, second.Sign(data), storedAt))
+}
+
+func TestParseSignaturePolicy(t *testing.T) {
+	for in, want := range map[string]signing.Policy{
+		"":           signing.PolicyEnforce,
+		"permissive": signing.PolicyPermissive,
+		"ENFORCE":    signing.PolicyEnforce,
+		" off ":      signing.PolicyOff,
+	} {
+		got, err := signing.ParsePolicy(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := signing.ParsePolicy("strict")
+	assert.Error(t, err)
+}
+
+// TestPermissivePolicyIsForDevelopment verifies SIGNATURE_POLICY=permissive,
+// which accepts unsigned messages, is refused outside CJADC2_MODE=dev
+func TestPermissivePolicyIsForDevelopment(t *testing.T) {
+	vars := map[string]string{"SIGNATURE_POLICY": "permissive"}
+
+	t.Setenv("CJADC2_MODE", config.ModeDev)
+	cfg, err := signing.ConfigFromVars(vars)
+	require.NoError(t, err)
+	assert.Equal(t, signing.PolicyPermissive, cfg.Policy)
+
+	t.Setenv("CJADC2_MODE", config.ModeProduction)
+	_, err = signing.ConfigFromVars(vars)
+	assert.Error(t, err)
+	cfg, err = signing.ConfigFromVars(nil)
+	require.NoError(t, err)
+	assert.Equal(t, signing.PolicyEnforce, cfg.Policy)
+	assert.Equal(t, signing.DefaultKeyLifetime, cfg.Lifetime)
+}
+
+// TestSignMsg verifies a message signed in SignatureHeader verifies under
+// the key, and a nil key leaves it unsigned
+func TestSignMsg(t *testing.T) {
+	key, err := signing.GenerateKey("api-gateway", "api-gateway", time.Now(), time.Hour)
+	require.NoError(t, err)
+
+	m := nats.NewMsg("decision.approved.track")
+	m.Data = []byte(`{"decision_id":"d1"}`)
+	signing.SignMsg(m, key)
+	assert.NoError(t, key.Verify(m.Data, m.Header.Get(signing.SignatureHeader)))
+
+	unsigned := nats.NewMsg("decision.approved.track")
+	signing.SignMsg(unsigned, nil)
+	assert.Empty(t, unsigned.Header.Get(signing.SignatureHeader))
+}
+
+func TestSigningConfigValidation(t *testing.T) {
+	for name, vars := range map[string]map[string]string{
+		"bad policy":            {"SIGNATURE_POLICY": "strict"},
+		"bad lifetime":          {"SIGNING_KEY_LIFETIME": "forever"},
+		"negative overlap":      {"SIGNING_KEY_OVERLAP": "-1h"},
+		"overlap over lifetime": {"SIGNING_KEY_LIFETIME": "1h", "SIGNING_KEY_OVERLAP": "1h"},
+	} {
+		_, err := agent.NewBaseAgent(agent.Config{ID: "planner-001", Type: agent.AgentTypePlanner, ExtraVars: vars})
+		assert.Error(t, err, name)
+	}
+
+	base, err := agent.NewBaseAgent(agent.Config{ID: "planner-001", Type: agent.AgentTypePlanner, ExtraVars: map[string]string{
+		"SIGNATURE_POLICY": "enforce", "SIGNING_KEY_LIFETIME": "2h", "SIGNING_KEY_OVERLAP": "10m",
+	}})
+	require.NoError(t, err)
+	assert.Empty(t, base.SigningKeyID(), "an agent signs nothing before it joins the registry")
+}
+
+// TestEnvelopeOfEveryEncoding verifies the envelope naming a message's
+// signing key reads alike from JSON, protobuf, and sealed messages
+func TestEnvelopeOfEveryEncoding(t *testing.T) {
+	keys, err := crypto.ParseKeyring("k1:"+payloadKey('a'), "")
+	require.NoError(t, err)
+
+	for _, enc := range []messages.Encoding{messages.EncodingJSON, messages.EncodingProtobuf} {
+		d := messages.NewDetection("sensor-001", "radar")
+		d.Envelope.SigningKeyID = "0123456789abcdef"
+
+		data, contentType, err := messages.Marshal(d, enc)
+		require.NoError(t, err)
+		env, err := messages.EnvelopeOf(messages.SchemaDetection, contentType, data)
+		require.NoError(t, err, enc)
+		assert.Equal(t, "sensor-001", env.Source, enc)
+		assert.Equal(t, "0123456789abcdef", env.SigningKeyID, enc)
+		assert.Equal(t, d.Envelope.MessageID, env.MessageID, enc)
+
+		sealed, err := messages.Seal(d, data, contentType, keys)
+		require.NoError(t, err)
+		env, err = messages.EnvelopeOf(messages.SchemaDetection, messages.ContentTypeSealed, sealed)
+		require.NoError(t, err, enc)
+		assert.Equal(t, "0123456789abcdef", env.SigningKeyID, "the key is named in the clear envelope")
+	}
+
+	_, err = messages.EnvelopeOf(messages.SchemaDetection, messages.ContentTypeJSON, []byte("{"))
+	assert.Error(t, err)
+}
+
+// TestVerifierAppliesPolicy verifies consumers outside the agent framework
+// refuse what SIGNATURE_POLICY refuses: a forged or unsigned message under
+// enforce, and only a forged one under permissive
+func TestVerifierAppliesPolicy(t *testing.T) {
+	ctx := context.Background()
+	registry := signing.NewRegistry(newMemoryKV())
+	key, err := signing.GenerateKey("correlator-001", "correlator", time.Now().UTC().Add(-time.Minute), time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, registry.Register(ctx, key.PublicKey))
+
+	merge := &messages.TrackMerge{
+		Envelope:           messages.NewEnvelope("correlator-001", "correlator"),
+		TrackID:            "track-1",
+		SupersededTrackIDs: []string{"track-2"},
+	}
+	merge.Envelope.SigningKeyID = key.KeyID
+	data, contentType, err := messages.Marshal(merge, messages.EncodingJSON)
+	require.NoError(t, err)
+	signed := nats.NewMsg(merge.Subject())
+	signed.Data = data
+	signed.Header.Set(messages.ContentTypeHeader, contentType)
+	signing.SignMsg(signed, key)
+
+	unsigned := nats.NewMsg(merge.Subject())
+	unsigned.Data = data
+	forged := nats.NewMsg(merge.Subject())
+	forged.Data = bytes.Replace(data, []byte(`"track-2"`), []byte(`"track-9"`), 1)
+	forged.Header.Set(signing.SignatureHeader, signed.Header.Get(signing.SignatureHeader))
+
+	check := func(policy signing.Policy, m *nats.Msg) error {
+		v := signing.Verifier{Registry: registry, Policy: policy}
+		return v.Check(ctx, "", m.Header, m.Data, time.Time{})
+	}
+	assert.NoError(t, check(signing.PolicyEnforce, signed))
+	assert.ErrorIs(t, check(signing.PolicyEnforce, unsigned), signing.ErrUnsigned)
+	assert.ErrorIs(t, check(signing.PolicyEnforce, forged), signing.ErrBadSignature)
+	assert.NoError(t, check(signing.PolicyPermissive, unsigned))
+	assert.ErrorIs(t, check(signing.PolicyPermissive, forged), signing.ErrBadSignature)
+	assert.NoError(t, check(signing.PolicyOff, forged))
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/simclock"
+)
+
+// TestSimClockPauseAndSpeed tests that simulated time freezes while paused and scales with speed
+func TestSimClockPauseAndSpeed(t *testing.T) {
+	wall := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := simclock.NewWithWallClock(func() time.Time { return wall })
+	start := clock.Now()
+
+	wall = wall.Add(10 * time.Second)
+	assert.Equal(t, start.Add(10*time.Second), clock.Now(), "a new clock runs in real time")
+
+	paused := clock.State().Pause(wall, "watch-officer", "brief")
+	require.True(t, clock.Apply(paused))
+	assert.True(t, clock.Paused())
+
+	wall = wall.Add(time.Minute)
+	assert.Equal(t, start.Add(10*time.Second), clock.Now(), "simulated time should not advance while paused")
+
+	resumed := clock.State().Resume(wall, "watch-officer", "")
+	d, ok := simclock.PauseEnded(clock.State(), resumed)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+	require.True(t, clock.Apply(resumed))
+
+	fast, err := clock.State().WithSpeed(5, wall, "", "")
+	require.NoError(t, err)
+	require.True(t, clock.Apply(fast))
+
+	wall = wall.Add(2 * time.Second)
+	assert.Equal(t, start.Add(20*time.Second), clock.Now(), "two wall seconds at 5x are ten simulated seconds")
+	assert.Equal(t, 100*time.Millisecond, clock.WallDuration(500*time.Millisecond))
+}
+
+// TestSimClockApplyOrdering tests that stale or invalid states are ignored
+func TestSimClockApplyOrdering(t *testing.T) {
+	now := time.Now()
+	clock := simclock.New()
+
+	var changes int
+	clock.OnChange(func(prev, next simclock.State) { changes++ })
+
+	first := clock.State().Pause(now, "", "")
+	second := first.Resume(now, "", "")
+	require.True(t, clock.Apply(second))
+	assert.False(t, clock.Apply(first), "an older state should not replace a newer one")
+	assert.False(t, clock.Apply(second), "the same state should not apply twice")
+	assert.False(t, clock.Paused())
+
+	bad := second.Resume(now, "", "")
+	bad.Speed = 0
+	assert.False(t, clock.Apply(bad))
+	assert.Equal(t, 1, changes)
+
+	_, err := second.WithSpeed(simclock.MaxSpeed*2, now, "", "")
+	assert.Error(t, err)
+	_, err = second.WithSpeed(0, now, "", "")
+	assert.Error(t, err)
+}
+
+// TestSimClockPauseWhilePaused tests that a speed change during a pause keeps the original pause start
+func TestSimClockPauseWhilePaused(t *testing.T) {
+	start := time.Now()
+	s := simclock.RealTime(start).Pause(start, "", "")
+	s, err := s.WithSpeed(2, start.Add(30*time.Second), "", "")
+	require.NoError(t, err)
+	s = s.Pause(start.Add(40*time.Second), "", "")
+
+	resumed := s.Resume(start.Add(time.Minute), "", "")
+	d, ok := simclock.PauseEnded(s, resumed)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	_, ok = simclock.PauseEnded(resumed, resumed.Resume(start.Add(2*time.Minute), "", ""))
+	assert.False(t, ok, "resuming a running clock ends no pause")
+}
+
+// TestSimControlValidation tests the simulation control endpoints without NATS
+func TestSimControlValidation(t *testing.T) {
+	h := handler.NewSimControlHandler(nil, nil, zerolog.Nop())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"get state", http.MethodGet, "/", ``, http.StatusOK},
+		{"malformed body", http.MethodPost, "/pause", `{"reason":`, http.StatusBadRequest},
+		{"speed missing", http.MethodPost, "/speed", `{}`, http.StatusBadRequest},
+		{"speed too fast", http.MethodPost, "/speed", `{"speed":100}`, http.StatusBadRequest},
+		{"pause without NATS", http.MethodPost, "/pause", ``, http.StatusServiceUnavailable},
+		{"speed without NATS", http.MethodPost, "/speed", `{"speed":2}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.Routes().ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/chaos"
+	"github.com/agile-defense/cjadc2/pkg/simrand"
+)
+
+// TestSimrandReplay verifies a seed replays the same sequence
+func TestSimrandReplay(t *testing.T) {
+	draw := func(src *simrand.Source) []int64 {
+		out := make([]int64, 5)
+		for i := range out {
+			out[i] = src.Int63()
+		}
+		return out
+	}
+
+	a, b := simrand.NewSource(42), simrand.NewSource(42)
+	first := draw(a)
+	assert.Equal(t, first, draw(b))
+	assert.NotEqual(t, first, draw(simrand.NewSource(43)))
+
+	a.Seed(42)
+	assert.Equal(t, first, draw(a), "reseeding restarts the sequence")
+	assert.Equal(t, int64(42), a.CurrentSeed())
+
+	assert.Equal(t, simrand.New(7).Float64(), simrand.New(7).Float64())
+}
+
+// TestSimrandParseSeed verifies SEED values
+func TestSimrandParseSeed(t *testing.T) {
+	seed, err := simrand.ParseSeed(" -12 ")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-12), seed)
+
+	_, err = simrand.ParseSeed("")
+	assert.NoError(t, err, "unset picks a seed from the clock")
+
+	for _, bad := range []string{"abc", "1.5", "99999999999999999999"} {
+		_, err := simrand.ParseSeed(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestChaosSeedReplay verifies seeded injectors fault the same messages
+func TestChaosSeedReplay(t *testing.T) {
+	plan := chaosPlan(t, "planner", chaos.Fault{DropPercent: 30, NakPercent: 30})
+	decide := func(seed int64) []string {
+		injector := chaos.NewInjector("planner")
+		injector.Seed(seed)
+		require.True(t, injector.Apply(plan))
+		out := make([]string, 20)
+		for i := range out {
+			_, out[i] = injector.Decide()
+		}
+		return out
+	}
+	assert.Equal(t, decide(42), decide(42))
+}
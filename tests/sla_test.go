@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/sla"
+)
+
+// TestParseSLAPolicy verifies DECISION_SLA parsing, defaults, and validation
+func TestParseSLAPolicy(t *testing.T) {
+	p, err := sla.ParsePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, []sla.Threshold{{MinPriority: 8, After: 2 * time.Minute}}, p.Thresholds)
+	assert.Equal(t, "8:2m0s", p.String())
+
+	p, err = sla.ParsePolicy("5:10m, 9:30s,8:2m")
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.MinPriority())
+	assert.Equal(t, "9:30s,8:2m0s,5:10m0s", p.String(), "thresholds sort by descending priority")
+
+	p, err = sla.ParsePolicy("off")
+	require.NoError(t, err)
+	assert.False(t, p.Enabled())
+	assert.Equal(t, 0, p.MinPriority())
+
+	for _, bad := range []string{"8", "eight:2m", "0:2m", "11:2m", "8:soon", "8:-1m", "8:2m,8:5m"} {
+		_, err := sla.ParsePolicy(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestSLAPolicyBreached verifies the tightest covering threshold decides a breach
+func TestSLAPolicyBreached(t *testing.T) {
+	p, err := sla.ParsePolicy("8:2m,5:10m,9:5m")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		priority int
+		pending  time.Duration
+		breached bool
+		after    time.Duration
+	}{
+		{"below every threshold", 4, time.Hour, false, 0},
+		{"low priority within SLA", 5, 9 * time.Minute, false, 0},
+		{"low priority past SLA", 6, 11 * time.Minute, true, 10 * time.Minute},
+		{"high priority within SLA", 8, 2 * time.Minute, false, 0},
+		{"high priority past SLA", 8, 3 * time.Minute, true, 2 * time.Minute},
+		{"looser higher threshold ignored", 10, 3 * time.Minute, true, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		threshold, ok := p.Breached(tt.priority, tt.pending)
+		assert.Equal(t, tt.breached, ok, tt.name)
+		assert.Equal(t, tt.after, threshold.After, tt.name)
+	}
+
+	disabled, err := sla.ParsePolicy("off")
+	require.NoError(t, err)
+	_, ok := disabled.Breached(10, time.Hour)
+	assert.False(t, ok)
+}
+
+// TestDecisionSLABreachMessage verifies SLA breach notifications route to the NOTIFICATIONS stream
+func TestDecisionSLABreachMessage(t *testing.T) {
+	created := time.Now().Add(-3 * time.Minute)
+	proposal := &messages.ActionProposal{
+		Envelope:    messages.NewEnvelope("planner-1", "planner").WithCorrelation("corr-123", "msg-1"),
+		ProposalID:  "prop-1",
+		TrackID:     "track-1",
+		ActionType:  "engage",
+		Priority:    9,
+		ThreatLevel: "critical",
+		ExpiresAt:   created.Add(10 * time.Minute),
+	}
+
+	breach := messages.NewDecisionSLABreach(proposal, "authorizer-1", created)
+	assert.Equal(t, "notify.sla.engage", breach.Subject())
+	assert.Equal(t, "corr-123", breach.Envelope.CorrelationID)
+	assert.Equal(t, "authorizer", breach.Envelope.SourceType)
+	assert.Equal(t, 9, breach.Priority)
+
+	data, err := json.Marshal(breach)
+	require.NoError(t, err)
+	var decoded messages.DecisionSLABreach
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "prop-1", decoded.ProposalID)
+	assert.True(t, decoded.CreatedAt.Equal(created))
+}
+
+// TestPriorityBucket verifies proposal priorities map onto the metric buckets
+func TestPriorityBucket(t *testing.T) {
+	for priority, want := range map[int]string{
+		1: sla.PriorityLow, 3: sla.PriorityLow,
+		4: sla.PriorityMedium, 6: sla.PriorityMedium,
+		7: sla.PriorityHigh, 8: sla.PriorityHigh,
+		9: sla.PriorityCritical, 10: sla.PriorityCritical,
+	} {
+		assert.Equal(t, want, sla.PriorityBucket(priority), "priority %d", priority)
+	}
+}
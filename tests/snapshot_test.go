@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// fakeSnapshotStore serves fixed tracks and proposals and records the filters it was asked for
+type fakeSnapshotStore struct {
+	tracks          []postgres.TrackRow
+	proposals       []postgres.ProposalRow
+	proposalFilters []postgres.ProposalFilter
+}
+
+// ListTracks returns the fixed tracks
+func (f *fakeSnapshotStore) ListTracks(ctx context.Context, filter postgres.TrackFilter) ([]postgres.TrackRow, error) {
+	return f.tracks, nil
+}
+
+// ListProposals returns the fixed proposals
+func (f *fakeSnapshotStore) ListProposals(ctx context.Context, filter postgres.ProposalFilter) ([]postgres.ProposalRow, error) {
+	f.proposalFilters = append(f.proposalFilters, filter)
+	return f.proposals, nil
+}
+
+// readWSEvent reads the next event from a schema version 2 connection
+func readWSEvent(t *testing.T, ctx context.Context, conn *websocket.Conn) handler.Event {
+	var ev handler.Event
+	require.NoError(t, wsjson.Read(ctx, conn, &ev))
+	return ev
+}
+
+// TestWebSocketSnapshotAndDeltas verifies event clients get a snapshot on connect, numbered deltas, and a snapshot on resync
+func TestWebSocketSnapshotAndDeltas(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := &fakeSnapshotStore{
+		tracks: []postgres.TrackRow{{ExternalID: "track-1", Classification: "hostile", State: "active"}},
+		proposals: []postgres.ProposalRow{{
+			ProposalID: "prop-1",
+			TrackID:    "track-1",
+			Status:     "pending",
+			CreatedAt:  time.Now().Add(-time.Minute),
+			ExpiresAt:  time.Now().Add(time.Minute),
+		}},
+	}
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	hub.SetSnapshotStore(store)
+	go hub.Run(ctx)
+
+	// A broadcast before the client connects is covered by its snapshot
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeTrackUpdate, Payload: json.RawMessage(`{"track_id":"track-0"}`), Timestamp: time.Now().UTC()})
+	require.Eventually(t, func() bool { return hub.LastSeq() == 1 }, time.Second, 10*time.Millisecond)
+
+	server := httptest.NewServer(handler.NewWebSocketHandler(hub, zerolog.Nop()))
+	defer server.Close()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"?schema_version=2", nil)
+	require.NoError(t, err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	assert.Equal(t, handler.MessageTypeConnectionStatus, readWSEvent(t, ctx, conn).EventType)
+
+	ev := readWSEvent(t, ctx, conn)
+	require.Equal(t, handler.MessageTypePictureSnapshot, ev.EventType)
+	var snapshot handler.PictureSnapshot
+	require.NoError(t, json.Unmarshal(ev.Payload, &snapshot))
+	assert.Equal(t, uint64(1), snapshot.Seq)
+	assert.Equal(t, handler.SnapshotReasonConnect, snapshot.Reason)
+	require.Len(t, snapshot.Tracks, 1)
+	assert.Equal(t, "track-1", snapshot.Tracks[0].TrackID)
+	require.Len(t, snapshot.Proposals, 1)
+	assert.Greater(t, snapshot.Proposals[0].TimeRemainingSeconds, 0.0)
+	assert.Equal(t, "pending", store.proposalFilters[0].Status)
+
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeProposalNew, Payload: json.RawMessage(`{"proposal_id":"prop-2"}`), Timestamp: time.Now().UTC()})
+	delta := readWSEvent(t, ctx, conn)
+	assert.Equal(t, handler.MessageTypeProposalNew, delta.EventType)
+	assert.Equal(t, snapshot.Seq+1, delta.Seq, "deltas continue from the snapshot")
+
+	require.NoError(t, wsjson.Write(ctx, conn, handler.WebSocketMessage{Type: handler.MessageTypeResync}))
+	ev = readWSEvent(t, ctx, conn)
+	require.Equal(t, handler.MessageTypePictureSnapshot, ev.EventType)
+	require.NoError(t, json.Unmarshal(ev.Payload, &snapshot))
+	assert.Equal(t, handler.SnapshotReasonResync, snapshot.Reason)
+	assert.Equal(t, delta.Seq, snapshot.Seq)
+}
+
+// TestWebSocketLegacyClientsGetNoSnapshot verifies clients that do not request events see only raw broadcasts
+func TestWebSocketLegacyClientsGetNoSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	hub.SetSnapshotStore(&fakeSnapshotStore{})
+	go hub.Run(ctx)
+
+	server := httptest.NewServer(handler.NewWebSocketHandler(hub, zerolog.Nop()))
+	defer server.Close()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	require.Eventually(t, func() bool { return hub.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeTrackUpdate, Payload: json.RawMessage(`{"track_id":"track-1"}`), Timestamp: time.Now().UTC()})
+	var msg handler.WebSocketMessage
+	require.NoError(t, wsjson.Read(ctx, conn, &msg))
+	assert.Equal(t, handler.MessageTypeTrackUpdate, msg.Type)
+	assert.JSONEq(t, `{"track_id":"track-1"}`, string(msg.Payload))
+}
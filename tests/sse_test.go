@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+)
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	ID    string
+	Retry string
+	Msg   handler.WebSocketMessage
+}
+
+// openSSE connects to the stream and returns a reader of its events
+func openSSE(t *testing.T, url, lastEventID string) (*bufio.Reader, func()) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+}
+
+// readSSE reads the next event from the stream
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			return ev
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			ev.ID = value
+		case "retry":
+			ev.Retry = value
+		case "data":
+			require.NoError(t, json.Unmarshal([]byte(value), &ev.Msg))
+		}
+	}
+}
+
+// TestSSEStreamAndReplay tests topic filtering, event IDs, and replay after a reconnect with Last-Event-ID
+func TestSSEStreamAndReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	go hub.Run(ctx)
+
+	server := httptest.NewServer(handler.NewSSEHandler(hub, zerolog.Nop()))
+	defer server.Close()
+
+	first, closeFirst := openSSE(t, server.URL+"?topics=track", "")
+	assert.Equal(t, "3000", readSSE(t, first).Retry)
+	require.Eventually(t, func() bool { return hub.StreamClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	now := time.Now().UTC()
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeTrackUpdate, Payload: json.RawMessage(`{"track_id":"a"}`), Timestamp: now})
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeProposalNew, Payload: json.RawMessage(`{}`), Timestamp: now})
+	hub.Broadcast(handler.WebSocketMessage{Type: handler.MessageTypeTrackNew, Payload: json.RawMessage(`{"track_id":"b"}`), Timestamp: now})
+
+	ev1 := readSSE(t, first)
+	assert.Equal(t, handler.MessageTypeTrackUpdate, ev1.Msg.Type)
+	require.NotEmpty(t, ev1.ID)
+	ev2 := readSSE(t, first)
+	assert.Equal(t, handler.MessageTypeTrackNew, ev2.Msg.Type, "proposal.new is filtered out")
+	closeFirst()
+
+	// Reconnecting after the first event replays only what was missed
+	second, closeSecond := openSSE(t, server.URL+"?topics=track", ev1.ID)
+	defer closeSecond()
+	readSSE(t, second)
+	replayed := readSSE(t, second)
+	assert.Equal(t, ev2.ID, replayed.ID)
+	assert.Equal(t, handler.MessageTypeTrackNew, replayed.Msg.Type)
+
+	// An ID from an earlier gateway run asks the client to refetch state
+	third, closeThird := openSSE(t, server.URL, "earlier-run-7")
+	defer closeThird()
+	readSSE(t, third)
+	assert.Equal(t, handler.MessageTypeStreamReset, readSSE(t, third).Msg.Type)
+}
+
+// TestSSERejectsUnknownTopic tests that the topic filter is validated
+func TestSSERejectsUnknownTopic(t *testing.T) {
+	hub := handler.NewWebSocketHub(nil, zerolog.Nop())
+	h := handler.NewSSEHandler(hub, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?topics=track,weather", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	topics, err := handler.ParseStreamTopics("track, proposal.new")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"track", "proposal.new"}, topics)
+}
+
+// TestParseSendQueueConfig tests WebSocket send queue defaults and validation
+func TestParseSendQueueConfig(t *testing.T) {
+	cfg, err := handler.ParseSendQueueConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, handler.DefaultSendQueueConfig(), cfg)
+
+	cfg, err = handler.ParseSendQueueConfig("16", handler.OverflowDisconnect)
+	require.NoError(t, err)
+	assert.Equal(t, handler.SendQueueConfig{Size: 16, Overflow: handler.OverflowDisconnect}, cfg)
+
+	for _, args := range [][2]string{{"0", ""}, {"5000", ""}, {"many", ""}, {"", "block"}} {
+		_, err := handler.ParseSendQueueConfig(args[0], args[1])
+		assert.Error(t, err, "%v", args)
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+)
+
+// writeStreamPolicies writes a stream policy file and returns its path
+func writeStreamPolicies(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "streams.json")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	return path
+}
+
+// TestLoadStreamPolicies verifies policies are read and applied over the defaults
+func TestLoadStreamPolicies(t *testing.T) {
+	path := writeStreamPolicies(t, `{
+		"TRACKS": {"max_age": "168h", "max_bytes": 8589934592},
+		"DETECTIONS": {"retention": "interest", "max_msgs": 5000000}
+	}`)
+	policies, err := natsutil.LoadStreamPolicies(path)
+	require.NoError(t, err)
+
+	tracks := policies.Apply(natsutil.StreamConfigs["TRACKS"])
+	assert.Equal(t, 168*time.Hour, tracks.MaxAge)
+	assert.Equal(t, int64(8589934592), tracks.MaxBytes)
+	assert.Equal(t, jetstream.LimitsPolicy, tracks.Retention, "unset fields keep the default")
+	assert.Equal(t, natsutil.StreamConfigs["TRACKS"].Subjects, tracks.Subjects)
+
+	detections := policies.Apply(natsutil.StreamConfigs["DETECTIONS"])
+	assert.Equal(t, jetstream.InterestPolicy, detections.Retention)
+	assert.Equal(t, int64(5000000), detections.MaxMsgs)
+	assert.Equal(t, natsutil.StreamConfigs["DETECTIONS"].MaxAge, detections.MaxAge)
+
+	assert.Equal(t, natsutil.StreamConfigs["EFFECTS"], policies.Apply(natsutil.StreamConfigs["EFFECTS"]))
+	var none natsutil.StreamPolicies
+	assert.Equal(t, natsutil.StreamConfigs["TRACKS"], none.Apply(natsutil.StreamConfigs["TRACKS"]))
+}
+
+// TestStreamPoliciesValidate verifies bad policy files are refused
+func TestStreamPoliciesValidate(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown stream": `{"BOGUS": {"max_age": "1h"}}`,
+		"retention":      `{"TRACKS": {"retention": "forever"}}`,
+		"max age":        `{"TRACKS": {"max_age": "a week"}}`,
+		"negative size":  `{"TRACKS": {"max_bytes": -1}}`,
+		"replicas":       `{"TRACKS": {"replicas": 9}}`,
+		"not json":       `TRACKS: 1h`,
+	} {
+		_, err := natsutil.LoadStreamPolicies(writeStreamPolicies(t, body))
+		assert.Error(t, err, name)
+	}
+
+	_, err := natsutil.LoadStreamPolicies(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestAuditStreamConfig verifies decisions and effects are tiered into a
+// long-retention audit stream that cannot be purged
+func TestAuditStreamConfig(t *testing.T) {
+	audit, ok := natsutil.StreamConfigs[natsutil.AuditStream]
+	require.True(t, ok)
+	assert.Empty(t, audit.Subjects, "AUDIT only sources other streams")
+
+	var sources []string
+	for _, source := range audit.Sources {
+		sources = append(sources, source.Name)
+	}
+	assert.ElementsMatch(t, []string{"DECISIONS", "EFFECTS"}, sources)
+	assert.Equal(t, jetstream.LimitsPolicy, audit.Retention)
+	assert.True(t, audit.DenyDelete)
+	assert.True(t, audit.DenyPurge)
+
+	for _, name := range sources {
+		working := natsutil.StreamConfigs[name]
+		assert.Equal(t, jetstream.InterestPolicy, working.Retention, name)
+		assert.Greater(t, audit.MaxAge, working.MaxAge, "%s is kept longer in AUDIT", name)
+	}
+}
+
+// TestTrackConsumerFilters verifies the correlator and planner each receive
+// only their own input from the shared TRACKS stream
+func TestTrackConsumerFilters(t *testing.T) {
+	receives := func(consumer, subject string) bool {
+		filter := natsutil.ConsumerConfigs[consumer].FilterSubject
+		require.True(t, strings.HasSuffix(filter, ".>"), "%s must filter the TRACKS stream", consumer)
+		return strings.HasPrefix(subject, strings.TrimSuffix(filter, ">"))
+	}
+
+	classified := (&messages.Track{Classification: "aircraft"}).Subject()
+	correlated := (&messages.CorrelatedTrack{ThreatLevel: "high"}).Subject()
+	lifecycle := (&messages.TrackLifecycle{State: "dropped"}).Subject()
+
+	assert.True(t, receives("correlator", classified))
+	assert.False(t, receives("correlator", correlated), "the correlator must not see its own output")
+	assert.False(t, receives("correlator", lifecycle))
+
+	assert.True(t, receives("planner", correlated))
+	assert.False(t, receives("planner", classified), "the planner must not reprocess classified tracks")
+	assert.False(t, receives("planner", lifecycle))
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// fakeSummaryStore returns fixed counts and counts how often it is queried
+type fakeSummaryStore struct {
+	mu      sync.Mutex
+	summary *postgres.DashboardSummary
+	err     error
+	calls   int
+}
+
+func (f *fakeSummaryStore) GetDashboardSummary(_ context.Context) (*postgres.DashboardSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.summary, f.err
+}
+
+func (f *fakeSummaryStore) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func testDashboardSummary() *postgres.DashboardSummary {
+	return &postgres.DashboardSummary{
+		ActiveTracks: []postgres.TrackCount{
+			{Classification: "hostile", ThreatLevel: "high", Count: 3},
+			{Classification: "hostile", ThreatLevel: "critical", Count: 1},
+			{Classification: "unknown", ThreatLevel: "high", Count: 2},
+		},
+		PendingProposals: []postgres.PriorityCount{{Priority: 9, Count: 2}, {Priority: 5, Count: 4}},
+		Decisions:        postgres.DecisionThroughput{LastMinute: 1, Last5Minutes: 6, LastHour: 40, ApprovedLast5m: 5, DeniedLast5m: 1},
+		MessagesLastMin:  postgres.MessageRates{Detections: 600, Tracks: 12, Proposals: 3, Decisions: 1, Effects: 1},
+	}
+}
+
+func getSummary(t *testing.T, h *handler.SummaryHandler) handler.SummaryResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp handler.SummaryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestSummaryTotals(t *testing.T) {
+	store := &fakeSummaryStore{summary: testDashboardSummary()}
+	h := handler.NewSummaryHandler(store, zerolog.Nop())
+
+	resp := getSummary(t, h)
+	assert.Equal(t, int64(6), resp.ActiveTracks.Total)
+	assert.Equal(t, map[string]int64{"hostile": 4, "unknown": 2}, resp.ActiveTracks.ByClassification)
+	assert.Equal(t, map[string]int64{"high": 5, "critical": 1}, resp.ActiveTracks.ByThreatLevel)
+	assert.Len(t, resp.ActiveTracks.Breakdown, 3)
+	assert.Equal(t, int64(6), resp.PendingProposals.Total)
+	assert.Equal(t, map[string]int64{"9": 2, "5": 4}, resp.PendingProposals.ByPriority)
+	assert.Equal(t, int64(6), resp.Decisions.Last5Minutes)
+	assert.Equal(t, int64(600), resp.MessagesLastMin.Detections)
+	assert.Empty(t, resp.Stages, "no registry means no stage health")
+}
+
+func TestSummaryCached(t *testing.T) {
+	store := &fakeSummaryStore{summary: testDashboardSummary()}
+	h := handler.NewSummaryHandler(store, zerolog.Nop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getSummary(t, h)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, store.Calls(), "concurrent requests within the TTL share one query batch")
+
+	h.SetTTL(0)
+	getSummary(t, h)
+	getSummary(t, h)
+	assert.Equal(t, 3, store.Calls())
+}
+
+func TestSummaryStoreError(t *testing.T) {
+	store := &fakeSummaryStore{err: errors.New("connection refused")}
+	h := handler.NewSummaryHandler(store, zerolog.Nop())
+
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// A failure is not cached
+	store.mu.Lock()
+	store.summary, store.err = testDashboardSummary(), nil
+	store.mu.Unlock()
+	assert.Equal(t, int64(6), getSummary(t, h).ActiveTracks.Total)
+}
+
+func TestStageHealthFor(t *testing.T) {
+	now := time.Now().UTC()
+	lag := func(n int) *int { return &n }
+	registry := topology.NewRegistry()
+	for _, hb := range []topology.Heartbeat{
+		{AgentID: "classifier-001", AgentType: "classifier", Healthy: true, ConsumerLag: lag(3), SentAt: now},
+		{AgentID: "classifier-002", AgentType: "classifier", Healthy: true, ConsumerLag: lag(40), SentAt: now.Add(-time.Second)},
+		{AgentID: "correlator-001", AgentType: "correlator", Healthy: false, SentAt: now},
+		{AgentID: "planner-001", AgentType: "planner", Healthy: true, SentAt: now.Add(-time.Hour)},
+	} {
+		registry.Observe(hb)
+	}
+
+	stages := handler.StageHealthFor(registry.Agents(now))
+	require.Len(t, stages, 3)
+
+	assert.Equal(t, "classifier", stages[0].Stage)
+	assert.Equal(t, handler.StageHealthy, stages[0].Status)
+	assert.Equal(t, 2, stages[0].Healthy)
+	require.NotNil(t, stages[0].MaxLag)
+	assert.Equal(t, 40, *stages[0].MaxLag)
+	assert.True(t, stages[0].LastSeen.Equal(now))
+
+	assert.Equal(t, handler.StageDegraded, stages[1].Status, "a live but unhealthy agent degrades its stage")
+	assert.Equal(t, handler.StageDown, stages[2].Status, "no live agent")
+	assert.Nil(t, stages[2].MaxLag)
+}
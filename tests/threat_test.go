@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/threat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDefaultThreatRulesMatchLegacyLevels verifies the default rule set reproduces the original threat cascade
+func TestDefaultThreatRulesMatchLegacyLevels(t *testing.T) {
+	engine := threat.NewDefaultEngine()
+
+	tests := []struct {
+		name           string
+		classification string
+		trackType      string
+		speed          float64
+		alt            float64
+		expectedLevel  string
+	}{
+		{"hostile missile", "hostile", "missile", 800, 5000, threat.LevelCritical},
+		{"fast hostile aircraft", "hostile", "aircraft", 350, 8000, threat.LevelHigh},
+		{"slow hostile aircraft", "hostile", "aircraft", 250, 8000, threat.LevelMedium},
+		{"hostile vessel", "hostile", "vessel", 15, 0, threat.LevelMedium},
+		{"very fast unknown", "unknown", "aircraft", 600, 8000, threat.LevelHigh},
+		{"fast unknown", "unknown", "aircraft", 250, 8000, threat.LevelMedium},
+		{"slow unknown", "unknown", "vessel", 10, 0, threat.LevelLow},
+		{"friendly aircraft", "friendly", "aircraft", 400, 8000, threat.LevelLow},
+		{"neutral vessel", "neutral", "vessel", 12, 0, threat.LevelLow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := &messages.CorrelatedTrack{
+				Classification: tt.classification,
+				Type:           tt.trackType,
+				Position:       messages.Position{Lat: 34.0, Lon: -118.0, Alt: tt.alt},
+				Velocity:       messages.Velocity{Speed: tt.speed, Heading: 90},
+			}
+			result := engine.Score(ct)
+			assert.Equal(t, tt.expectedLevel, result.Level, "score %.1f", result.Score)
+		})
+	}
+}
+
+// TestThreatScoreHeadingToAsset verifies heading toward a protected asset raises the score
+func TestThreatScoreHeadingToAsset(t *testing.T) {
+	rules := []threat.Rule{
+		{Name: "inbound-to-base", Factor: threat.FactorHeadingToAsset, Weight: 40, Enabled: true,
+			AssetLat: 34.0, AssetLon: -117.0, ToleranceDeg: 20, RangeMeters: 200000},
+	}
+	engine := threat.NewEngine(rules, threat.DefaultThresholds())
+
+	inbound := &messages.CorrelatedTrack{
+		Position: messages.Position{Lat: 34.0, Lon: -118.0},
+		Velocity: messages.Velocity{Speed: 200, Heading: 90},
+	}
+	outbound := &messages.CorrelatedTrack{
+		Position: messages.Position{Lat: 34.0, Lon: -118.0},
+		Velocity: messages.Velocity{Speed: 200, Heading: 270},
+	}
+
+	assert.Equal(t, 40.0, engine.Score(inbound).Score)
+	assert.Equal(t, 0.0, engine.Score(outbound).Score)
+}
+
+// TestThreatRuleGatesAndDisabledRules verifies gates and the enabled flag restrict rule application
+func TestThreatRuleGatesAndDisabledRules(t *testing.T) {
+	max := 100.0
+	rules := []threat.Rule{
+		{Name: "low-alt", Factor: threat.FactorAltitude, Weight: 30, Enabled: true,
+			Max: &max, Types: []string{"aircraft"}},
+		{Name: "disabled", Factor: threat.FactorClassification, Weight: 90, Enabled: false,
+			Match: []string{"unknown"}},
+	}
+	engine := threat.NewEngine(rules, threat.DefaultThresholds())
+
+	aircraft := &messages.CorrelatedTrack{Classification: "unknown", Type: "aircraft", Position: messages.Position{Alt: 50}}
+	vessel := &messages.CorrelatedTrack{Classification: "unknown", Type: "vessel", Position: messages.Position{Alt: 0}}
+
+	result := engine.Score(aircraft)
+	assert.Equal(t, 30.0, result.Score)
+	require.Len(t, result.Contributions, 1)
+	assert.Equal(t, "low-alt", result.Contributions[0].Rule)
+
+	assert.Equal(t, 0.0, engine.Score(vessel).Score)
+}
+
+// TestLoadThreatConfigFile verifies rule files are parsed and validated
+func TestLoadThreatConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{
+		"thresholds": {"medium": 20, "high": 40, "critical": 60},
+		"rules": [{"name": "hostile", "factor": "classification", "weight": 45, "enabled": true, "match": ["hostile"]}]
+	}`), 0o600))
+
+	cfg, err := threat.LoadConfigFile(valid)
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, 40.0, cfg.Thresholds.High)
+
+	engine := threat.NewEngine(cfg.Rules, cfg.Thresholds)
+	assert.Equal(t, threat.LevelHigh, engine.Score(&messages.CorrelatedTrack{Classification: "hostile"}).Level)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"rules": [{"name": "bad", "factor": "color", "weight": 10}]}`), 0o600))
+
+	_, err = threat.LoadConfigFile(invalid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+)
+
+// writeTempFiles creates empty files in a temporary directory and returns their paths
+func writeTempFiles(t *testing.T, names ...string) []string {
+	dir := t.TempDir()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(paths[i], []byte("-"), 0o600))
+	}
+	return paths
+}
+
+// TestNATSSecurityValidate verifies TLS and credential file combinations
+func TestNATSSecurityValidate(t *testing.T) {
+	files := writeTempFiles(t, "ca.pem", "client.pem", "client-key.pem", "user.creds")
+
+	var none natsutil.Security
+	opts, err := none.Options()
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+	assert.False(t, none.HasCredentials())
+
+	mtls := natsutil.Security{CAFile: files[0], CertFile: files[1], KeyFile: files[2], CredsFile: files[3]}
+	opts, err = mtls.Options()
+	require.NoError(t, err)
+	assert.Len(t, opts, 3)
+	assert.True(t, mtls.HasCredentials())
+
+	for name, bad := range map[string]natsutil.Security{
+		"cert without key":  {CertFile: files[1]},
+		"creds and nkey":    {CredsFile: files[3], NKeyFile: files[3]},
+		"missing root file": {CAFile: filepath.Join(t.TempDir(), "absent.pem")},
+	} {
+		assert.Error(t, bad.Validate(), name)
+	}
+}
+
+// TestPostgresTLSApply verifies TLS settings replace those in the connection URL
+func TestPostgresTLSApply(t *testing.T) {
+	files := writeTempFiles(t, "root.crt", "client.crt", "client.key")
+	base := "postgres://cjadc2:pw@postgres:5432/cjadc2?sslmode=disable&pool_max_conns=5"
+
+	unchanged, err := postgres.TLSConfig{}.Apply(base)
+	require.NoError(t, err)
+	assert.Equal(t, base, unchanged)
+
+	applied, err := postgres.TLSConfig{SSLMode: "verify-full", RootCert: files[0], Cert: files[1], Key: files[2]}.Apply(base)
+	require.NoError(t, err)
+	u, err := url.Parse(applied)
+	require.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "verify-full", q.Get("sslmode"))
+	assert.Equal(t, files[0], q.Get("sslrootcert"))
+	assert.Equal(t, files[1], q.Get("sslcert"))
+	assert.Equal(t, files[2], q.Get("sslkey"))
+	assert.Equal(t, "5", q.Get("pool_max_conns"))
+
+	empty, err := postgres.TLSConfig{SSLMode: "require"}.Apply("")
+	require.NoError(t, err)
+	assert.Empty(t, empty, "services without a database stay without one")
+
+	for name, bad := range map[string]postgres.TLSConfig{
+		"unknown mode":     {SSLMode: "strict"},
+		"cert without key": {Cert: files[1]},
+	} {
+		_, err := bad.Apply(base)
+		assert.Error(t, err, name)
+	}
+	_, err = postgres.TLSConfig{SSLMode: "require"}.Apply("host=postgres dbname=cjadc2")
+	assert.Error(t, err, "keyword/value strings are not rewritten")
+}
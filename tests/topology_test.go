@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/topology"
+)
+
+// TestParseHeartbeatInterval verifies the default, disabling, and rejection of bad intervals
+func TestParseHeartbeatInterval(t *testing.T) {
+	d, err := topology.ParseInterval("")
+	require.NoError(t, err)
+	assert.Equal(t, topology.DefaultInterval, d)
+
+	d, err = topology.ParseInterval("0s")
+	require.NoError(t, err)
+	assert.Zero(t, d, "zero disables heartbeats")
+
+	for _, v := range []string{"soon", "-5s"} {
+		_, err := topology.ParseInterval(v)
+		assert.Error(t, err, v)
+	}
+}
+
+// TestTopologyRegistry verifies agents are listed in order and reported dead
+// once they stop or miss heartbeats
+func TestTopologyRegistry(t *testing.T) {
+	now := time.Now().UTC()
+	lag := 12
+	registry := topology.NewRegistry()
+
+	registry.Observe(topology.Heartbeat{AgentID: "planner-1", AgentType: "planner", Healthy: true, Status: "running", ConsumerLag: &lag, SentAt: now.Add(-5 * time.Second), IntervalMs: 10000})
+	registry.Observe(topology.Heartbeat{AgentID: "classifier-1", AgentType: "classifier", Healthy: true, Status: "running", SentAt: now.Add(-time.Minute), IntervalMs: 10000})
+	registry.Observe(topology.Heartbeat{AgentID: "effector-1", AgentType: "effector", Status: topology.StatusStopped, SentAt: now})
+
+	// A heartbeat delivered out of order does not replace a newer one
+	registry.Observe(topology.Heartbeat{AgentID: "planner-1", AgentType: "planner", Status: "draining", SentAt: now.Add(-20 * time.Second), IntervalMs: 10000})
+
+	agents := registry.Agents(now)
+	require.Len(t, agents, 3)
+	assert.Equal(t, []string{"classifier-1", "effector-1", "planner-1"},
+		[]string{agents[0].AgentID, agents[1].AgentID, agents[2].AgentID})
+
+	assert.False(t, agents[0].Alive, "a minute without heartbeats at a 10s interval")
+	assert.InDelta(t, 60, agents[0].AgeSeconds, 0.001)
+	assert.False(t, agents[1].Alive, "stopped agents are not alive")
+	assert.True(t, agents[2].Alive)
+	assert.Equal(t, "running", agents[2].Status)
+	assert.Equal(t, 12, *agents[2].ConsumerLag)
+}
+
+// TestPipelineStream verifies heartbeats find the stream each stage consumes
+func TestPipelineStream(t *testing.T) {
+	for consumer, want := range map[string]string{
+		"classifier": "DETECTIONS",
+		"correlator": "TRACKS",
+		"planner":    "TRACKS",
+		"authorizer": "PROPOSALS",
+		"effector":   "DECISIONS",
+	} {
+		stream, ok := natsutil.PipelineStream(consumer)
+		assert.True(t, ok, consumer)
+		assert.Equal(t, want, stream, consumer)
+	}
+	_, ok := natsutil.PipelineStream("sensor")
+	assert.False(t, ok)
+}
+
+// TestConsumerLagIntervalConfig verifies CONSUMER_LAG_INTERVAL validation
+func TestConsumerLagIntervalConfig(t *testing.T) {
+	for v, valid := range map[string]bool{"": true, "0s": true, "30s": true, "soon": false, "-1s": false} {
+		_, err := agent.NewBaseAgent(agent.Config{
+			ID:        "lag-test",
+			Type:      agent.AgentTypePlanner,
+			ExtraVars: map[string]string{"CONSUMER_LAG_INTERVAL": v},
+		})
+		if valid {
+			assert.NoError(t, err, v)
+		} else {
+			assert.Error(t, err, v)
+		}
+	}
+}
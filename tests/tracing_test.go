@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// withSpanRecorder installs an in-memory tracer provider for the duration of a test
+func withSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// TestTraceContextPropagatesThroughEnvelopes verifies each agent's span is a child of the previous agent's
+func TestTraceContextPropagatesThroughEnvelopes(t *testing.T) {
+	recorder := withSpanRecorder(t)
+	ctx := context.Background()
+
+	// Sensor starts the trace and records it on the detection
+	sensorCtx, sensorSpan := tracing.Tracer().Start(ctx, "sensor.publish_detection")
+	detection := messages.Envelope{MessageID: "det-1"}
+	detection = tracing.InjectEnvelope(sensorCtx, detection)
+	sensorSpan.End()
+	require.NotEmpty(t, detection.TraceID)
+	require.NotEmpty(t, detection.SpanID)
+
+	// Classifier continues it and passes it on
+	classifierCtx, classifierSpan := tracing.StartFromEnvelope(ctx, "classifier.classify", detection)
+	track := tracing.InjectEnvelope(classifierCtx, messages.Envelope{MessageID: "trk-1", CorrelationID: "det-1"})
+	classifierSpan.End()
+
+	// Correlator continues from the track
+	_, correlatorSpan := tracing.StartFromEnvelope(ctx, "correlator.correlate", track)
+	correlatorSpan.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	sensor, classifier, correlator := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, sensor.SpanContext().TraceID(), classifier.SpanContext().TraceID())
+	assert.Equal(t, sensor.SpanContext().TraceID(), correlator.SpanContext().TraceID())
+	assert.Equal(t, sensor.SpanContext().SpanID(), classifier.Parent().SpanID())
+	assert.Equal(t, classifier.SpanContext().SpanID(), correlator.Parent().SpanID())
+	assert.Equal(t, sensor.SpanContext().TraceID().String(), track.TraceID)
+
+	// Correlation ID falls back to the message ID for the first hop
+	attrs := map[string]string{}
+	for _, kv := range classifier.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	assert.Equal(t, "det-1", attrs[string(tracing.AttrCorrelationID)])
+}
+
+// TestStartFromEnvelopeWithoutTraceContext verifies untraced messages start a new trace
+func TestStartFromEnvelopeWithoutTraceContext(t *testing.T) {
+	recorder := withSpanRecorder(t)
+
+	assert.False(t, tracing.SpanContextFromEnvelope(messages.Envelope{TraceID: "not-hex", SpanID: "0102030405060708"}).IsValid())
+
+	ctx, span := tracing.StartFromEnvelope(context.Background(), "planner.plan", messages.Envelope{MessageID: "m-1"})
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), tracing.TraceID(ctx))
+	assert.Empty(t, tracing.TraceID(context.Background()))
+	assert.Equal(t, messages.Envelope{}, tracing.InjectEnvelope(context.Background(), messages.Envelope{}))
+}
+
+// TestRecordLatencyExemplar verifies latency observations carry the trace ID as an exemplar
+func TestRecordLatencyExemplar(t *testing.T) {
+	withSpanRecorder(t)
+
+	base, err := agent.NewBaseAgent(agent.Config{ID: "classifier-test", Type: agent.AgentTypeClassifier})
+	require.NoError(t, err)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "classifier.classify")
+	base.RecordLatency(ctx, "detection", 20*time.Millisecond)
+	span.End()
+	base.RecordLatency(context.Background(), "detection", 3*time.Second)
+
+	families, err := base.Metrics().Gather()
+	require.NoError(t, err)
+
+	var exemplars []string
+	for _, mf := range families {
+		if mf.GetName() != "agent_processing_latency_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			assert.Equal(t, uint64(2), m.GetHistogram().GetSampleCount())
+			for _, b := range m.GetHistogram().GetBucket() {
+				if ex := b.GetExemplar(); ex != nil {
+					for _, l := range ex.GetLabel() {
+						if l.GetName() == "trace_id" {
+							exemplars = append(exemplars, l.GetValue())
+						}
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, []string{tracing.TraceID(ctx)}, exemplars)
+}
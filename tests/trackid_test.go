@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/postgres"
+	"github.com/agile-defense/cjadc2/pkg/trackid"
+)
+
+// TestTrackIDRegistryResolve verifies sensor track IDs keep their system ID until reused
+func TestTrackIDRegistryResolve(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	r := trackid.New(kv, time.Minute)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pos := messages.Position{Lat: 36, Lon: -118, Alt: 9000}
+
+	first, reason, err := r.Resolve(ctx, "H-TRK-0001", pos, start)
+	require.NoError(t, err)
+	assert.Equal(t, trackid.ReasonNew, reason)
+	assert.NotEmpty(t, first.SystemTrackID)
+
+	moved := messages.Position{Lat: 36.01, Lon: -118, Alt: 9000}
+	again, reason, err := r.Resolve(ctx, "H-TRK-0001", moved, start.Add(time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, first.SystemTrackID, again.SystemTrackID, "continuous reports keep the system ID")
+
+	// A restarted correlator reads the stored assignment
+	restarted := trackid.New(kv, time.Minute)
+	again, reason, err = restarted.Resolve(ctx, "H-TRK-0001", moved, start.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, first.SystemTrackID, again.SystemTrackID)
+
+	// A reinitialized sensor reuses the ID for an object far away
+	far := messages.Position{Lat: 39, Lon: -112}
+	reused, reason, err := restarted.Resolve(ctx, "H-TRK-0001", far, start.Add(3*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, trackid.ReasonPosition, reason)
+	assert.NotEqual(t, first.SystemTrackID, reused.SystemTrackID)
+
+	_, reason, err = restarted.Resolve(ctx, "H-TRK-0001", far, start.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, trackid.ReasonIdle, reason, "an ID silent past the reuse gap is a new object")
+}
+
+// TestTrackIDRegistryAdopt verifies fused tracks keep the oldest system ID
+func TestTrackIDRegistryAdopt(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	r := trackid.New(kv, time.Minute)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pos := messages.Position{Lat: 36, Lon: -118}
+
+	older, _, err := r.Resolve(ctx, "H-TRK-0001", pos, start)
+	require.NoError(t, err)
+	newer, _, err := r.Resolve(ctx, "H-TRK-0042", pos, start.Add(time.Second))
+	require.NoError(t, err)
+
+	survivor := trackid.Survivor(newer, older)
+	assert.Equal(t, older.SystemTrackID, survivor.SystemTrackID)
+
+	require.NoError(t, r.Adopt(ctx, "H-TRK-0042", survivor))
+	stored, err := trackid.Load(ctx, kv, "H-TRK-0042")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, older.SystemTrackID, stored.SystemTrackID)
+	assert.Equal(t, newer.LastSeen, stored.LastSeen, "adoption keeps the sensor track's own last report")
+
+	next, reason, err := r.Resolve(ctx, "H-TRK-0042", pos, start.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, older.SystemTrackID, next.SystemTrackID)
+
+	_, err = trackid.ParseReuseGap("500ms")
+	assert.Error(t, err)
+}
+
+// TestTrackIDRegistrySplit verifies a sensor track split off a fused track
+// gets its own system ID and keeps it
+func TestTrackIDRegistrySplit(t *testing.T) {
+	ctx := context.Background()
+	kv := newMemoryKV()
+	r := trackid.New(kv, time.Minute)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pos := messages.Position{Lat: 36, Lon: -118}
+
+	fused, _, err := r.Resolve(ctx, "H-TRK-0001", pos, start)
+	require.NoError(t, err)
+	other, _, err := r.Resolve(ctx, "H-TRK-0042", pos, start)
+	require.NoError(t, err)
+	require.NoError(t, r.Adopt(ctx, "H-TRK-0042", fused))
+
+	current, err := r.Lookup(ctx, "H-TRK-0042")
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	split, err := r.Split(ctx, *current, start.Add(time.Second))
+	require.NoError(t, err)
+	assert.NotEqual(t, fused.SystemTrackID, split.SystemTrackID)
+	assert.NotEqual(t, other.SystemTrackID, split.SystemTrackID, "a split track never takes back an ID it gave up")
+	assert.Equal(t, "H-TRK-0042", split.SensorTrackID)
+
+	next, reason, err := r.Resolve(ctx, "H-TRK-0042", pos, start.Add(2*time.Second))
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	assert.Equal(t, split.SystemTrackID, next.SystemTrackID)
+
+	merge := &messages.TrackMerge{TrackID: fused.SystemTrackID}
+	assert.Equal(t, messages.TrackMergedSubject, merge.Subject())
+	assert.Equal(t, messages.TrackSplitSubject, (&messages.TrackSplit{}).Subject())
+}
+
+// TestSensorTrackIDs verifies the sensor IDs recorded for a correlated track
+func TestSensorTrackIDs(t *testing.T) {
+	track := &messages.CorrelatedTrack{TrackID: "sys-1", MergedFrom: []string{"H-TRK-0001", "H-TRK-0042", "H-TRK-0001"}}
+	assert.Equal(t, []string{"H-TRK-0001", "H-TRK-0042"}, postgres.SensorTrackIDs(track))
+	assert.Equal(t, []string{"H-TRK-0003"}, postgres.SensorTrackIDs(&messages.CorrelatedTrack{TrackID: "H-TRK-0003"}), "older messages name the sensor track")
+}
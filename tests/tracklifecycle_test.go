@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/tracklifecycle"
+)
+
+// TestTrackLifecycleParseConfig tests defaults, overrides, and rejection of inconsistent timeouts
+func TestTrackLifecycleParseConfig(t *testing.T) {
+	cfg, err := tracklifecycle.ParseConfig("", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracklifecycle.DefaultStaleAfter, cfg.StaleAfter)
+	assert.Equal(t, tracklifecycle.DefaultDropAfter, cfg.DropAfter)
+
+	cfg, err = tracklifecycle.ParseConfig("30s", "2m")
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.StaleAfter)
+	assert.Equal(t, 2*time.Minute, cfg.DropAfter)
+
+	_, err = tracklifecycle.ParseConfig("soon", "")
+	assert.Error(t, err)
+	_, err = tracklifecycle.ParseConfig("", "-1m")
+	assert.Error(t, err)
+	_, err = tracklifecycle.ParseConfig("10m", "5m")
+	assert.Error(t, err, "tracks must go stale before they are dropped")
+}
+
+// TestTrackLifecycleCutoffs tests that tracks get their full timeout again after a pause ends
+func TestTrackLifecycleCutoffs(t *testing.T) {
+	cfg := tracklifecycle.Config{StaleAfter: time.Minute, DropAfter: 5 * time.Minute}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	stale, drop := cfg.Cutoffs(now, time.Time{})
+	assert.Equal(t, now.Add(-time.Minute), stale)
+	assert.Equal(t, now.Add(-5*time.Minute), drop)
+
+	// Resumed two minutes ago: tracks can go stale but none can be dropped yet
+	stale, drop = cfg.Cutoffs(now, now.Add(-2*time.Minute))
+	assert.Equal(t, now.Add(-time.Minute), stale)
+	assert.True(t, drop.IsZero())
+
+	// Just resumed: nothing ages out
+	stale, drop = cfg.Cutoffs(now, now)
+	assert.True(t, stale.IsZero())
+	assert.True(t, drop.IsZero())
+}
+
+// TestTrackLifecycleSubject tests that lifecycle events stay off the subjects agents consume
+func TestTrackLifecycleSubject(t *testing.T) {
+	event := &messages.TrackLifecycle{TrackID: "track-1", State: tracklifecycle.StateDropped}
+	assert.Equal(t, "track.lifecycle.dropped", event.Subject())
+
+	assert.True(t, tracklifecycle.IsValidState("stale"))
+	assert.True(t, tracklifecycle.IsValidState("neutralized"))
+	assert.False(t, tracklifecycle.IsValidState("all"))
+	assert.False(t, tracklifecycle.IsValidState("gone"))
+}
+
+// TestListTracksRejectsUnknownState tests that the tracks API validates the state filter before querying
+func TestListTracksRejectsUnknownState(t *testing.T) {
+	router := handler.NewTrackHandler(nil, zerolog.Nop()).Routes()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?state=active,gone", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/trackrebuild"
+)
+
+// correlatedTrackAt builds a correlated track message last updated at at
+func correlatedTrackAt(trackID string, at time.Time, threatLevel string) *messages.CorrelatedTrack {
+	return &messages.CorrelatedTrack{
+		Envelope:       messages.NewEnvelope("correlator-001", "correlator"),
+		TrackID:        trackID,
+		Classification: "hostile",
+		Type:           "aircraft",
+		ThreatLevel:    threatLevel,
+		WindowStart:    at.Add(-10 * time.Second),
+		LastUpdated:    at,
+		DetectionCount: 3,
+	}
+}
+
+// TestTrackRebuildFoldsLatestState tests that a track is rebuilt from its latest message regardless of replay order
+func TestTrackRebuildFoldsLatestState(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := correlatedTrackAt("track-a", base, "low")
+	second := correlatedTrackAt("track-a", base.Add(5*time.Second), "medium")
+	third := correlatedTrackAt("track-a", base.Add(10*time.Second), "critical")
+
+	builder := trackrebuild.NewBuilder()
+	builder.Apply(third)
+	builder.Apply(first)
+	builder.Apply(second)
+	builder.Apply(correlatedTrackAt("track-b", base, "low"))
+
+	tracks := builder.Tracks()
+	require.Len(t, tracks, 2)
+	assert.Equal(t, "track-a", tracks[0].Track.TrackID)
+	assert.Equal(t, "critical", tracks[0].Track.ThreatLevel)
+	assert.Equal(t, first.WindowStart, tracks[0].FirstSeen)
+	assert.Equal(t, 5, tracks[0].DetectionCount, "first message's count plus one per later message, as the live upsert records")
+	assert.Equal(t, 3, tracks[1].DetectionCount)
+}
+
+// TestTrackRebuildIsIdempotent tests that a message replayed from overlapping streams counts once
+func TestTrackRebuildIsIdempotent(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	track := correlatedTrackAt("track-a", base, "high")
+	data, err := json.Marshal(track)
+	require.NoError(t, err)
+
+	builder := trackrebuild.NewBuilder()
+	require.NoError(t, builder.ApplyData(data))
+	require.NoError(t, builder.ApplyData(data)) // Same message from an archive mirror
+
+	assert.Equal(t, 1, builder.Messages())
+	assert.Equal(t, 1, builder.Duplicates())
+	tracks := builder.Tracks()
+	require.Len(t, tracks, 1)
+	assert.Equal(t, 3, tracks[0].DetectionCount)
+
+	assert.Error(t, builder.ApplyData([]byte("not json")))
+}
+
+// TestTrackRebuildRestoresNeutralized tests that neutralizing assessments carry over to rebuilt tracks
+func TestTrackRebuildRestoresNeutralized(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	builder := trackrebuild.NewBuilder()
+	builder.Apply(correlatedTrackAt("track-a", base, "critical"))
+	builder.Apply(correlatedTrackAt("track-b", base, "critical"))
+
+	for _, assessment := range []messages.EffectAssessment{
+		{TrackID: "track-a", Neutralized: true},
+		{TrackID: "track-b", Neutralized: false},
+	} {
+		data, err := json.Marshal(assessment)
+		require.NoError(t, err)
+		require.NoError(t, builder.ApplyAssessmentData(data))
+	}
+
+	tracks := builder.Tracks()
+	require.Len(t, tracks, 2)
+	assert.True(t, tracks[0].Neutralized)
+	assert.False(t, tracks[1].Neutralized)
+}
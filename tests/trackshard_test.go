@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/geo"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	natsutil "github.com/agile-defense/cjadc2/pkg/nats"
+	"github.com/agile-defense/cjadc2/pkg/trackshard"
+)
+
+func TestGeohash(t *testing.T) {
+	assert.Equal(t, "u4pruy", geo.Geohash(57.64911, 10.40744, 6))
+	assert.Equal(t, "9q", geo.Geohash(37.5, -118, 2))
+	assert.Equal(t, "", geo.Geohash(0, 0, 0))
+
+	box := geo.GeohashBox("9q")
+	assert.InDelta(t, 33.75, box.MinLat, 1e-9)
+	assert.InDelta(t, 39.375, box.MaxLat, 1e-9)
+	assert.InDelta(t, -123.75, box.MinLon, 1e-9)
+	assert.InDelta(t, -112.5, box.MaxLon, 1e-9)
+
+	assert.NoError(t, geo.ValidateGeohash("9q8yy"))
+	assert.Error(t, geo.ValidateGeohash("9qa"), "a is not in the geohash alphabet")
+	assert.Error(t, geo.ValidateGeohash("9q8yyzz"), "longer than the maximum precision")
+	assert.Error(t, geo.ValidateGeohash(""))
+}
+
+func TestGeohashNeighbors(t *testing.T) {
+	assert.ElementsMatch(t, []string{"9p", "9r", "9x", "9n", "9w", "9j", "9m", "9t"}, geo.GeohashNeighbors("9q"))
+
+	// Across the antimeridian
+	assert.Contains(t, geo.GeohashNeighbors(geo.Geohash(0, 179.9, 3)), geo.Geohash(0, -179.9, 3))
+
+	// Nothing lies beyond the pole
+	assert.Len(t, geo.GeohashNeighbors(geo.Geohash(89.9, 0, 3)), 5)
+}
+
+func TestTrackshardCells(t *testing.T) {
+	cfg, err := trackshard.ParseConfig("2", "")
+	require.NoError(t, err)
+	require.True(t, cfg.Enabled())
+	assert.Equal(t, trackshard.DefaultMarginMeters, cfg.MarginMeters)
+
+	home, border := cfg.Cells(messages.Position{Lat: 37, Lon: -118})
+	assert.Equal(t, "9q", home)
+	assert.Empty(t, border, "a track deep inside its cell is not handed off")
+
+	// Just west of 112.5W, the border between 9q and 9w
+	home, border = cfg.Cells(messages.Position{Lat: 37, Lon: -112.505})
+	assert.Equal(t, "9q", home)
+	assert.Equal(t, []string{"9w"}, border)
+
+	// Near the corner shared by 9q, 9w, 9r and 9x
+	_, border = cfg.Cells(messages.Position{Lat: 39.37, Lon: -112.505})
+	assert.Equal(t, []string{"9r", "9w", "9x"}, border)
+
+	cfg.MarginMeters = 0
+	_, border = cfg.Cells(messages.Position{Lat: 37, Lon: -112.505})
+	assert.Empty(t, border)
+}
+
+func TestTrackshardParseConfig(t *testing.T) {
+	cfg, err := trackshard.ParseConfig("", "")
+	require.NoError(t, err)
+	assert.False(t, cfg.Enabled())
+
+	cfg, err = trackshard.ParseConfig("3", "250")
+	require.NoError(t, err)
+	assert.Equal(t, 3, cfg.Precision)
+	assert.Equal(t, 250.0, cfg.MarginMeters)
+
+	for _, bad := range [][2]string{{"7", ""}, {"-1", ""}, {"x", ""}, {"3", "-5"}, {"3", "far"}} {
+		_, err := trackshard.ParseConfig(bad[0], bad[1])
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestTrackshardSubjects(t *testing.T) {
+	track := &messages.Track{Classification: "hostile"}
+	assert.Equal(t, "track.classified.hostile.9q", trackshard.Subject(track, "9q"))
+	assert.Equal(t, "track.handoff.hostile.9w", trackshard.HandoffSubject(track, "9w"))
+
+	assert.Equal(t, "9q", trackshard.CellOf("track.classified.hostile.9q"))
+	assert.Equal(t, "9w", trackshard.CellOf("track.handoff.hostile.9w"))
+	assert.Equal(t, "", trackshard.CellOf("track.classified.hostile"))
+	assert.Equal(t, "", trackshard.CellOf("track.correlated.high.9q"))
+
+	assert.True(t, trackshard.IsHandoff("track.handoff.hostile.9w"))
+	assert.False(t, trackshard.IsHandoff("track.classified.hostile.9q"))
+	assert.Equal(t, messages.SchemaTrack, messages.SchemaForSubject("track.handoff.hostile.9w"))
+}
+
+func TestTrackshardParseShard(t *testing.T) {
+	shard, err := trackshard.ParseShard("", "")
+	require.NoError(t, err)
+	assert.Nil(t, shard)
+	assert.True(t, shard.Owns("9q"), "an unsharded correlator owns every cell")
+	assert.True(t, shard.OwnsPosition(messages.Position{Lat: -33, Lon: 151}))
+
+	shard, err = trackshard.ParseShard("west", " 9R, 9q,9q ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"9q", "9r"}, shard.Cells)
+	assert.True(t, shard.Owns("9q"))
+	assert.False(t, shard.Owns("9w"))
+	assert.True(t, shard.OwnsPosition(messages.Position{Lat: 37, Lon: -118}))
+	assert.False(t, shard.OwnsPosition(messages.Position{Lat: 37, Lon: -112}))
+
+	for _, bad := range [][2]string{{"west", ""}, {"", "9q"}, {"a.b", "9q"}, {"west", "9q,9q8"}, {"west", "9a"}} {
+		_, err := trackshard.ParseShard(bad[0], bad[1])
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCorrelatorShardConsumer(t *testing.T) {
+	cfg := natsutil.CorrelatorShardConsumer("West", []string{"9q", "9r"})
+	assert.Equal(t, "correlator-west", cfg.Durable)
+	assert.Empty(t, cfg.FilterSubject)
+	assert.Equal(t, []string{
+		"track.classified.*.9q", "track.handoff.*.9q",
+		"track.classified.*.9r", "track.handoff.*.9r",
+	}, cfg.FilterSubjects)
+	assert.Equal(t, natsutil.ConsumerConfigs["correlator"].AckPolicy, cfg.AckPolicy)
+	assert.Equal(t, natsutil.ConsumerConfigs["correlator"].MaxAckPending, cfg.MaxAckPending)
+}
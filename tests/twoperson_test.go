@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/handler"
+	"github.com/agile-defense/cjadc2/pkg/messages"
+	"github.com/agile-defense/cjadc2/pkg/twoperson"
+)
+
+// TestTwoPersonParseRule verifies TWO_PERSON_MIN_PRIORITY parsing
+func TestTwoPersonParseRule(t *testing.T) {
+	rule, err := twoperson.ParseRule("")
+	require.NoError(t, err)
+	assert.Equal(t, twoperson.DefaultMinPriority, rule.MinPriority)
+
+	rule, err = twoperson.ParseRule("6")
+	require.NoError(t, err)
+	assert.Equal(t, 6, rule.MinPriority)
+
+	rule, err = twoperson.ParseRule("0")
+	require.NoError(t, err)
+	assert.Zero(t, rule.MinPriority)
+
+	for _, bad := range []string{"-1", "high", "8.5"} {
+		_, err := twoperson.ParseRule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// TestTwoPersonApplies verifies only engage proposals at or above the threshold need two approvers
+func TestTwoPersonApplies(t *testing.T) {
+	rule := twoperson.Rule{MinPriority: 8}
+	assert.True(t, rule.Applies("engage", 8))
+	assert.True(t, rule.Applies("engage", 10))
+	assert.False(t, rule.Applies("engage", 7))
+	assert.False(t, rule.Applies("intercept", 10))
+	assert.False(t, rule.Applies("track", 10))
+
+	disabled := twoperson.Rule{}
+	assert.False(t, disabled.Applies("engage", 10), "zero disables the rule")
+}
+
+// TestTwoPersonComplete verifies approvals must come from distinct operators
+func TestTwoPersonComplete(t *testing.T) {
+	assert.False(t, twoperson.Complete(nil))
+	assert.False(t, twoperson.Complete([]messages.Approval{{ApprovedBy: "commander"}}))
+	assert.False(t, twoperson.Complete([]messages.Approval{
+		{ApprovedBy: "commander"},
+		{ApprovedBy: "commander"},
+	}), "the same operator twice does not count")
+	assert.True(t, twoperson.Complete([]messages.Approval{
+		{ApprovedBy: "commander", Role: "commander"},
+		{ApprovedBy: "watch-officer-1", Role: "commander", BreakGlassGrantID: "grant-1"},
+	}))
+}
+
+// TestTwoPersonSameOption verifies the second approver must approve the same course of action
+func TestTwoPersonSameOption(t *testing.T) {
+	first := []messages.Approval{{ApprovedBy: "commander", Option: "engage"}}
+
+	assert.NoError(t, twoperson.SameOption(nil, messages.Approval{ApprovedBy: "commander", Option: "intercept"}))
+	assert.NoError(t, twoperson.SameOption(first, messages.Approval{ApprovedBy: "watch-officer-1", Option: "engage"}))
+	assert.NoError(t, twoperson.SameOption(first, messages.Approval{ApprovedBy: "commander", Option: "intercept"}),
+		"an operator's own approval is left to the duplicate check")
+	assert.ErrorIs(t, twoperson.SameOption(first, messages.Approval{ApprovedBy: "watch-officer-1", Option: "intercept"}), twoperson.ErrOptionMismatch)
+	assert.NoError(t, twoperson.SameOption([]messages.Approval{{ApprovedBy: "commander"}}, messages.Approval{ApprovedBy: "watch-officer-1"}),
+		"proposals without options record no option")
+}
+
+// TestDecisionApprovalsJSON verifies a decision carries every approval and its role
+func TestDecisionApprovalsJSON(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	decision := messages.Decision{
+		DecisionID: "decision-1",
+		ProposalID: "proposal-1",
+		ActionType: "engage",
+		Approved:   true,
+		ApprovedBy: "watch-officer-1",
+		Approvals: []messages.Approval{
+			{ApprovedBy: "commander", Role: "commander", ApprovedAt: at},
+			{ApprovedBy: "watch-officer-1", Role: "commander", ApprovedAt: at.Add(time.Minute), BreakGlassGrantID: "grant-1"},
+		},
+	}
+
+	data, err := json.Marshal(decision)
+	require.NoError(t, err)
+
+	var decoded messages.Decision
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Approvals, 2)
+	assert.Equal(t, "commander", decoded.Approvals[0].Role)
+	assert.Equal(t, "grant-1", decoded.Approvals[1].BreakGlassGrantID)
+	assert.True(t, at.Equal(decoded.Approvals[0].ApprovedAt))
+
+	single, err := json.Marshal(messages.Decision{DecisionID: "decision-2", Approved: true})
+	require.NoError(t, err)
+	assert.NotContains(t, string(single), "approvals", "decisions outside the rule omit approvals")
+}
+
+// TestDecisionApproverIsAuthenticatedUser verifies an authenticated operator
+// cannot name someone else as the approver, which would let one operator
+// satisfy the two-person rule alone
+func TestDecisionApproverIsAuthenticatedUser(t *testing.T) {
+	h := handler.UserIDMiddleware(handler.NewProposalHandler(nil, nil, nil, twoperson.Rule{MinPriority: 8}, zerolog.Nop()).Routes())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/prop-1/decide", strings.NewReader(`{"approved":true,"approved_by":"bob"}`))
+	req.Header.Set(handler.UserIDHeader, "alice")
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "alice")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/prop-1/decide", strings.NewReader(`{"approved":true}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "a decision needs an approver")
+}
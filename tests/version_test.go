@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/messages"
+)
+
+// v1CorrelatedTrack builds a correlated track as published before envelopes
+// carried schema_version and tracks carried threat scores
+func v1CorrelatedTrack(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(schemaFixtures()[messages.SchemaCorrelatedTrack])
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	delete(fields, "threat_score")
+	delete(fields["envelope"].(map[string]any), "schema_version")
+	return fields
+}
+
+// TestNewEnvelopeSchemaVersion verifies new messages carry the current version
+func TestNewEnvelopeSchemaVersion(t *testing.T) {
+	env := messages.NewEnvelope("sensor-001", "sensor")
+	assert.Equal(t, messages.CurrentSchemaVersion, env.SchemaVersion)
+	assert.Equal(t, messages.CurrentSchemaVersion, env.Version())
+	assert.Equal(t, 1, messages.Envelope{}.Version(), "a missing version is version 1")
+}
+
+// TestUpgradeV1CorrelatedTrack verifies a version 1 track is read with the
+// score its threat level implies and passes the current schema
+func TestUpgradeV1CorrelatedTrack(t *testing.T) {
+	data, err := json.Marshal(v1CorrelatedTrack(t))
+	require.NoError(t, err)
+	require.Error(t, messages.Validate(messages.SchemaCorrelatedTrack, data), "version 1 breaks the current schema")
+	assert.NoError(t, messages.ValidateEncoded(messages.SchemaCorrelatedTrack, messages.ContentTypeJSON, data))
+
+	var track messages.CorrelatedTrack
+	require.NoError(t, messages.Unmarshal(messages.ContentTypeJSON, data, &track))
+	assert.Equal(t, "high", track.ThreatLevel)
+	assert.Equal(t, 60.0, track.ThreatScore)
+	assert.Equal(t, messages.CurrentSchemaVersion, track.Envelope.SchemaVersion)
+}
+
+// TestUpgradeV1ActionProposal verifies the track inside a version 1 proposal is upgraded
+func TestUpgradeV1ActionProposal(t *testing.T) {
+	data, err := json.Marshal(schemaFixtures()[messages.SchemaActionProposal])
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	delete(fields["envelope"].(map[string]any), "schema_version")
+	fields["track"] = v1CorrelatedTrack(t)
+	data, err = json.Marshal(fields)
+	require.NoError(t, err)
+
+	var proposal messages.ActionProposal
+	require.NoError(t, messages.Unmarshal(messages.ContentTypeJSON, data, &proposal))
+	require.NotNil(t, proposal.Track)
+	assert.Equal(t, 60.0, proposal.Track.ThreatScore)
+	assert.Equal(t, messages.CurrentSchemaVersion, proposal.Envelope.SchemaVersion)
+	assert.NoError(t, messages.ValidateEncoded(messages.SchemaActionProposal, messages.ContentTypeJSON, data))
+}
+
+// TestUpgradeKeepsCurrentAndNewer verifies current payloads are untouched and
+// newer ones are read by ignoring unknown fields
+func TestUpgradeKeepsCurrentAndNewer(t *testing.T) {
+	correlated := schemaFixtures()[messages.SchemaCorrelatedTrack].(*messages.CorrelatedTrack)
+	correlated.ThreatLevel = "low"
+	data, err := json.Marshal(correlated)
+	require.NoError(t, err)
+
+	same, err := messages.Upgrade(messages.SchemaCorrelatedTrack, data)
+	require.NoError(t, err)
+	assert.Equal(t, data, same)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	fields["envelope"].(map[string]any)["schema_version"] = messages.CurrentSchemaVersion + 1
+	fields["intent"] = "loiter"
+	newer, err := json.Marshal(fields)
+	require.NoError(t, err)
+
+	var track messages.CorrelatedTrack
+	require.NoError(t, messages.Unmarshal(messages.ContentTypeJSON, newer, &track))
+	assert.Equal(t, correlated.TrackID, track.TrackID)
+	assert.Equal(t, correlated.ThreatScore, track.ThreatScore)
+	assert.Equal(t, messages.CurrentSchemaVersion+1, track.Envelope.SchemaVersion)
+}
+
+// TestUpgradeStampsVersion verifies schemas without upgrades only gain the current version
+func TestUpgradeStampsVersion(t *testing.T) {
+	data, err := json.Marshal(schemaFixtures()[messages.SchemaDetection])
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	delete(fields["envelope"].(map[string]any), "schema_version")
+	data, err = json.Marshal(fields)
+	require.NoError(t, err)
+
+	upgraded, err := messages.Upgrade(messages.SchemaDetection, data)
+	require.NoError(t, err)
+	var det messages.Detection
+	require.NoError(t, json.Unmarshal(upgraded, &det))
+	assert.Equal(t, messages.CurrentSchemaVersion, det.Envelope.SchemaVersion)
+	assert.Equal(t, "T-1", det.TrackID)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/agile-defense/cjadc2/pkg/agent"
+)
+
+// poolMsg is a fetched message about a track that records how it was settled;
+// unused jetstream.Msg methods panic
+type poolMsg struct {
+	jetstream.Msg
+	track   string
+	seq     int
+	settled atomic.Value
+}
+
+func (m *poolMsg) Ack() error { m.settled.Store("ack"); return nil }
+func (m *poolMsg) Nak() error { m.settled.Store("nak"); return nil }
+
+// poolBatch queues messages as a fetched batch
+func poolBatch(msgs ...*poolMsg) <-chan jetstream.Msg {
+	ch := make(chan jetstream.Msg, len(msgs))
+	for _, m := range msgs {
+		ch <- m
+	}
+	close(ch)
+	return ch
+}
+
+func poolTrackKey(msg jetstream.Msg) string {
+	return msg.(*poolMsg).track
+}
+
+func newPoolAgent(t *testing.T, workers string) *agent.BaseAgent {
+	t.Helper()
+	base, err := agent.NewBaseAgent(agent.Config{ID: "classifier-001", Type: "classifier", ExtraVars: map[string]string{"WORKER_CONCURRENCY": workers}})
+	require.NoError(t, err)
+	return base
+}
+
+func TestProcessBatchKeepsTrackOrder(t *testing.T) {
+	base := newPoolAgent(t, "4")
+	require.Equal(t, 4, base.WorkerConcurrency())
+
+	var msgs []*poolMsg
+	for seq := 0; seq < 50; seq++ {
+		for _, track := range []string{"T1", "T2", "T3", "T4", "T5", "T6"} {
+			msgs = append(msgs, &poolMsg{track: track, seq: seq})
+		}
+	}
+
+	var mu sync.Mutex
+	seen := map[string][]int{}
+	var running, peak atomic.Int32
+	base.ProcessBatch(context.Background(), poolBatch(msgs...), poolTrackKey, func(_ context.Context, msg jetstream.Msg) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(100 * time.Microsecond)
+
+		m := msg.(*poolMsg)
+		mu.Lock()
+		seen[m.track] = append(seen[m.track], m.seq)
+		mu.Unlock()
+		return nil
+	})
+
+	for track, seqs := range seen {
+		require.Len(t, seqs, 50, track)
+		for i, seq := range seqs {
+			assert.Equal(t, i, seq, "messages about %s are handled in fetch order", track)
+		}
+	}
+	assert.Greater(t, peak.Load(), int32(1), "different tracks are handled in parallel")
+	for _, m := range msgs {
+		assert.Equal(t, "ack", m.settled.Load())
+	}
+}
+
+func TestProcessBatchNaksFailures(t *testing.T) {
+	for _, workers := range []string{"1", "3"} {
+		base := newPoolAgent(t, workers)
+		ok, bad := &poolMsg{track: "T1"}, &poolMsg{track: "T2"}
+		base.ProcessBatch(context.Background(), poolBatch(ok, bad), poolTrackKey, func(_ context.Context, msg jetstream.Msg) error {
+			if msg == bad {
+				return errors.New("policy unavailable")
+			}
+			return nil
+		})
+		assert.Equal(t, "ack", ok.settled.Load(), workers)
+		assert.Equal(t, "nak", bad.settled.Load(), workers)
+	}
+}
+
+func TestWorkerConcurrencyConfig(t *testing.T) {
+	base := newPoolAgent(t, "")
+	assert.Equal(t, 1, base.WorkerConcurrency(), "one worker unless configured")
+
+	base.RuntimeConfig().Apply("classifier", configDoc(map[string]string{agent.ConfigWorkerConcurrency: "8"}))
+	assert.Equal(t, 8, base.WorkerConcurrency())
+	base.RuntimeConfig().Apply("classifier", configDoc(map[string]string{agent.ConfigWorkerConcurrency: "0"}))
+	assert.Equal(t, 8, base.WorkerConcurrency(), "out of range values are ignored")
+	base.RuntimeConfig().Apply("classifier", nil)
+	assert.Equal(t, 1, base.WorkerConcurrency())
+
+	_, err := agent.NewBaseAgent(agent.Config{ID: "planner-001", Type: "planner", ExtraVars: map[string]string{"WORKER_CONCURRENCY": "100"}})
+	assert.Error(t, err)
+
+	field := agent.ConfigField{Name: agent.ConfigWorkerConcurrency, Type: "int"}
+	assert.NoError(t, agent.ValidateConfigValue(field, "16"))
+	assert.Error(t, agent.ValidateConfigValue(field, "65"))
+}
+
+func TestPartition(t *testing.T) {
+	assert.Equal(t, agent.Partition("T1", 8), agent.Partition("T1", 8))
+	used := map[int]bool{}
+	for _, key := range []string{"T1", "T2", "T3", "T4", "T5", "T6", "T7", "T8", "T9", "T10"} {
+		p := agent.Partition(key, 4)
+		require.True(t, p >= 0 && p < 4)
+		used[p] = true
+	}
+	assert.Greater(t, len(used), 1, "keys spread over workers")
+}
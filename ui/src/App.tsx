@@ -16,7 +16,7 @@ import { SensorControlPage } from './pages/SensorControlPage';
 import { sensorApi } from './api/sensor';
 import { classifierApi } from './api/classifier';
 import { api } from './api/client';
-import type { ConnectionStatus, SystemMetrics } from './types';
+import type { ConnectionStatus, SystemMetrics, WSPictureSnapshot } from './types';
 
 // Create a client
 const queryClient = new QueryClient({
@@ -205,6 +205,8 @@ function DashboardContent() {
     toggleSort,
     handleTrackUpdate,
     handleTrackDelete,
+    handleTrackMerged,
+    handleTracksSnapshot,
   } = useTracks();
 
   // Proposal hooks
@@ -222,15 +224,31 @@ function DashboardContent() {
     closeDecisionModal,
     handleProposalNew,
     handleProposalUpdate,
+    handleProposalRescored,
+    handleProposalClaim,
     handleProposalExpired,
+    handleProposalsSnapshot,
   } = useProposals();
 
+  // Replace tracks and proposals with the picture the gateway sends on connect and resync
+  const handleSnapshot = useCallback(
+    (snapshot: WSPictureSnapshot) => {
+      handleTracksSnapshot(snapshot.tracks);
+      handleProposalsSnapshot(snapshot.proposals);
+    },
+    [handleTracksSnapshot, handleProposalsSnapshot]
+  );
+
   // WebSocket connection
   const { status, reconnect } = useWebSocket({
+    onSnapshot: handleSnapshot,
     onTrackUpdate: handleTrackUpdate,
     onTrackDelete: handleTrackDelete,
+    onTrackMerged: handleTrackMerged,
     onProposalNew: handleProposalNew,
     onProposalUpdate: handleProposalUpdate,
+    onProposalRescored: handleProposalRescored,
+    onProposalClaim: handleProposalClaim,
     onProposalExpired: handleProposalExpired,
     onMetricsUpdate: setWsMetrics,
   });
@@ -10,9 +10,21 @@ import type {
   EffectLog,
   SystemMetrics,
   AuditEntry,
+  AuditChainVerification,
   InterventionRule,
   InterventionRuleCreate,
   InterventionRuleUpdate,
+  InterventionRuleVersion,
+  Zone,
+  ZoneCreate,
+  BreakGlassGrant,
+  BreakGlassActivation,
+  SimControlResponse,
+  TrackFeatureCollection,
+  PreferenceKind,
+  UserPreference,
+  DetectionHeatmap,
+  ApproverStats,
 } from '../types';
 
 const API_BASE_URL = import.meta.env.VITE_API_URL || 'http://localhost:8080';
@@ -57,15 +69,17 @@ async function apiFetch<T>(
 
     if (!response.ok) {
       const errorData: APIError = await response.json().catch(() => ({
-        error: `HTTP_${response.status}`,
-        message: `HTTP ${response.status}: ${response.statusText}`,
+        type: 'about:blank',
+        title: `HTTP ${response.status}: ${response.statusText}`,
+        status: response.status,
+        code: `HTTP_${response.status}`,
         correlation_id: corrId,
       }));
 
       throw new APIClientError(
-        errorData.message || errorData.error,  // Use message if available, fallback to error type
-        errorData.error,                        // Error type/code
-        errorData.correlation_id
+        errorData.detail || errorData.title,  // Use the detail if available, fall back to the summary
+        errorData.code,
+        errorData.correlation_id || corrId
       );
     }
 
@@ -93,6 +107,7 @@ interface TrackListResponse {
   total: number;
   limit: number;
   offset: number;
+  next_cursor?: string;
   correlation_id: string;
 }
 
@@ -132,6 +147,24 @@ export const tracksApi = {
     );
     return response.data;
   },
+
+  // Get active tracks as a GeoJSON FeatureCollection for map libraries
+  getAllGeoJSON: async (correlationId?: string): Promise<APIResponse<TrackFeatureCollection>> => {
+    return apiFetch<TrackFeatureCollection>('/api/v1/tracks?format=geojson', {}, correlationId);
+  },
+
+  // Get a track's detection history as GeoJSON points, numbered oldest first by seq
+  getHistoryGeoJSON: async (
+    trackId: string,
+    limit = 100,
+    correlationId?: string
+  ): Promise<APIResponse<TrackFeatureCollection>> => {
+    return apiFetch<TrackFeatureCollection>(
+      `/api/v1/tracks/${encodeURIComponent(trackId)}/history?format=geojson&limit=${limit}`,
+      {},
+      correlationId
+    );
+  },
 };
 
 // Proposal API endpoints
@@ -167,6 +200,7 @@ export const proposalsApi = {
       approved_by: request.approved_by || 'operator', // Default to 'operator' if not set
       reason: request.reason || '',
       conditions: request.conditions,
+      option: request.option,
     };
     console.log('[submitDecision] Request body:', body);
     return apiFetch<Decision>(
@@ -279,6 +313,11 @@ export const auditApi = {
       correlationId
     );
   },
+
+  // Verify the decision/effect hash chain
+  verify: async (correlationId?: string): Promise<APIResponse<AuditChainVerification>> => {
+    return apiFetch<AuditChainVerification>('/api/v1/audit/verify', {}, correlationId);
+  },
 };
 
 // Health check
@@ -319,6 +358,16 @@ export const interventionRulesApi = {
     );
   },
 
+  // Get an intervention rule's version history, newest first
+  getVersions: async (ruleId: string, correlationId?: string): Promise<APIResponse<InterventionRuleVersion[]>> => {
+    const response = await apiFetch<{ versions: InterventionRuleVersion[] }>(
+      `/api/v1/intervention-rules/${encodeURIComponent(ruleId)}/versions`,
+      {},
+      correlationId
+    );
+    return { ...response, data: response.data.versions || [] };
+  },
+
   // Create a new intervention rule
   create: async (rule: InterventionRuleCreate, correlationId?: string): Promise<APIResponse<InterventionRule>> => {
     return apiFetch<InterventionRule>(
@@ -355,6 +404,61 @@ export const interventionRulesApi = {
   },
 };
 
+// Protected asset and restricted zone API endpoints
+export const zonesApi = {
+  // Get all zones
+  getAll: async (params?: { kind?: string; enabled?: boolean }, correlationId?: string): Promise<APIResponse<Zone[]>> => {
+    const searchParams = new URLSearchParams();
+    if (params?.kind) {
+      searchParams.set('kind', params.kind);
+    }
+    if (params?.enabled !== undefined) {
+      searchParams.set('enabled', String(params.enabled));
+    }
+    const query = searchParams.toString();
+    const url = `/api/v1/zones${query ? `?${query}` : ''}`;
+    const response = await apiFetch<{ zones: Zone[] }>(url, {}, correlationId);
+    return { ...response, data: response.data.zones || [] };
+  },
+
+  // Create a new zone
+  create: async (zone: ZoneCreate, correlationId?: string): Promise<APIResponse<Zone>> => {
+    const response = await apiFetch<{ zone: Zone }>(
+      '/api/v1/zones',
+      {
+        method: 'POST',
+        body: JSON.stringify(zone),
+      },
+      correlationId
+    );
+    return { ...response, data: response.data.zone };
+  },
+
+  // Update an existing zone
+  update: async (zoneId: string, zone: ZoneCreate, correlationId?: string): Promise<APIResponse<Zone>> => {
+    const response = await apiFetch<{ zone: Zone }>(
+      `/api/v1/zones/${encodeURIComponent(zoneId)}`,
+      {
+        method: 'PUT',
+        body: JSON.stringify(zone),
+      },
+      correlationId
+    );
+    return { ...response, data: response.data.zone };
+  },
+
+  // Delete a zone
+  delete: async (zoneId: string, correlationId?: string): Promise<APIResponse<void>> => {
+    return apiFetch<void>(
+      `/api/v1/zones/${encodeURIComponent(zoneId)}`,
+      {
+        method: 'DELETE',
+      },
+      correlationId
+    );
+  },
+};
+
 // Clear all data response
 interface ClearAllResponse {
   success: boolean;
@@ -383,6 +487,306 @@ export const clearApi = {
   },
 };
 
+// Exercise reset request; every field is optional
+export interface ExerciseResetRequest {
+  streams?: string[];
+  tables?: string[];
+  kv_buckets?: string[];
+  scenario?: Record<string, unknown>; // Sensor config fields applied on top of the defaults
+  resume?: boolean;
+  settle_ms?: number;
+}
+
+// Response from the exercise reset endpoint
+export interface ExerciseResetResponse {
+  success: boolean;
+  message: string;
+  steps: {
+    name: string;
+    status: 'ok' | 'failed' | 'skipped';
+    detail?: string;
+    duration_ms: number;
+  }[];
+  purged: Record<string, number>;
+  deleted: Record<string, number>;
+  kv_keys_purged?: Record<string, number>;
+  resumed: boolean;
+  correlation_id: string;
+}
+
+// Exercise API endpoints
+export const exerciseApi = {
+  // Pause the sensor, purge streams, clear data, reload the scenario, and resume
+  reset: async (
+    request: ExerciseResetRequest = {},
+    correlationId?: string
+  ): Promise<APIResponse<ExerciseResetResponse>> => {
+    return apiFetch<ExerciseResetResponse>(
+      '/api/v1/exercise/reset',
+      {
+        method: 'POST',
+        body: JSON.stringify(request),
+      },
+      correlationId
+    );
+  },
+};
+
+// Simulation control API endpoints (pause, resume, or speed up the whole pipeline)
+export interface SimControlRequest {
+  speed?: number;
+  updated_by?: string;
+  reason?: string;
+}
+
+export const simApi = {
+  // Get the current simulation clock
+  getState: async (correlationId?: string): Promise<APIResponse<SimControlResponse>> => {
+    return apiFetch<SimControlResponse>('/api/v1/sim', {}, correlationId);
+  },
+
+  // Freeze every agent in the pipeline
+  pause: async (
+    request: SimControlRequest = {},
+    correlationId?: string
+  ): Promise<APIResponse<SimControlResponse>> => {
+    return apiFetch<SimControlResponse>(
+      '/api/v1/sim/pause',
+      {
+        method: 'POST',
+        body: JSON.stringify(request),
+      },
+      correlationId
+    );
+  },
+
+  // Resume the pipeline; pending proposals get back the time spent paused
+  resume: async (
+    request: SimControlRequest = {},
+    correlationId?: string
+  ): Promise<APIResponse<SimControlResponse>> => {
+    return apiFetch<SimControlResponse>(
+      '/api/v1/sim/resume',
+      {
+        method: 'POST',
+        body: JSON.stringify(request),
+      },
+      correlationId
+    );
+  },
+
+  // Change the simulation speed multiplier (0.1x to 20x)
+  setSpeed: async (
+    speed: number,
+    request: SimControlRequest = {},
+    correlationId?: string
+  ): Promise<APIResponse<SimControlResponse>> => {
+    return apiFetch<SimControlResponse>(
+      '/api/v1/sim/speed',
+      {
+        method: 'POST',
+        body: JSON.stringify({ ...request, speed }),
+      },
+      correlationId
+    );
+  },
+};
+
+// Anonymization API endpoints (demo mode)
+export const anonymizationApi = {
+  // Get whether responses are anonymized
+  getStatus: async (correlationId?: string): Promise<APIResponse<{ enabled: boolean }>> => {
+    return apiFetch<{ enabled: boolean }>('/api/v1/anonymization', {}, correlationId);
+  },
+
+  // Turn anonymization on or off
+  setEnabled: async (
+    enabled: boolean,
+    correlationId?: string
+  ): Promise<APIResponse<{ enabled: boolean }>> => {
+    return apiFetch<{ enabled: boolean }>(
+      '/api/v1/anonymization',
+      {
+        method: 'PUT',
+        body: JSON.stringify({ enabled }),
+      },
+      correlationId
+    );
+  },
+};
+
+// Break-glass API endpoints (time-boxed elevated approval authority)
+export const breakGlassApi = {
+  // List grants, optionally only those still active
+  list: async (activeOnly = false, correlationId?: string): Promise<APIResponse<BreakGlassGrant[]>> => {
+    const response = await apiFetch<{ grants: BreakGlassGrant[] }>(
+      `/api/v1/break-glass${activeOnly ? '?active=true' : ''}`,
+      {},
+      correlationId
+    );
+    return { ...response, data: response.data.grants || [] };
+  },
+
+  // Activate a grant with a justification and TOTP code
+  activate: async (
+    request: BreakGlassActivation,
+    correlationId?: string
+  ): Promise<APIResponse<BreakGlassGrant>> => {
+    const response = await apiFetch<{ grant: BreakGlassGrant }>(
+      '/api/v1/break-glass',
+      {
+        method: 'POST',
+        body: JSON.stringify(request),
+      },
+      correlationId
+    );
+    return { ...response, data: response.data.grant };
+  },
+
+  // Revoke an active grant before it expires
+  revoke: async (
+    grantId: string,
+    revokedBy: string,
+    reason?: string,
+    correlationId?: string
+  ): Promise<APIResponse<BreakGlassGrant>> => {
+    const response = await apiFetch<{ grant: BreakGlassGrant }>(
+      `/api/v1/break-glass/${encodeURIComponent(grantId)}`,
+      {
+        method: 'DELETE',
+        body: JSON.stringify({ revoked_by: revokedBy, reason }),
+      },
+      correlationId
+    );
+    return { ...response, data: response.data.grant };
+  },
+};
+
+// Preferences API endpoints (saved filters, column layouts, and default sector for one user)
+function preferenceHeaders(userId: string): HeadersInit {
+  return { 'X-User-ID': userId };
+}
+
+export const preferencesApi = {
+  // List the user's preferences, optionally of one kind
+  list: async (
+    userId: string,
+    kind?: PreferenceKind,
+    correlationId?: string
+  ): Promise<APIResponse<UserPreference[]>> => {
+    const response = await apiFetch<{ preferences: UserPreference[] }>(
+      `/api/v1/preferences${kind ? `?kind=${kind}` : ''}`,
+      { headers: preferenceHeaders(userId) },
+      correlationId
+    );
+    return { ...response, data: response.data.preferences || [] };
+  },
+
+  // Get one preference
+  get: async <T = unknown>(
+    userId: string,
+    kind: PreferenceKind,
+    name: string,
+    correlationId?: string
+  ): Promise<APIResponse<UserPreference<T>>> => {
+    const response = await apiFetch<{ preference: UserPreference<T> }>(
+      `/api/v1/preferences/${kind}/${encodeURIComponent(name)}`,
+      { headers: preferenceHeaders(userId) },
+      correlationId
+    );
+    return { ...response, data: response.data.preference };
+  },
+
+  // Create or replace a preference
+  save: async <T = unknown>(
+    userId: string,
+    kind: PreferenceKind,
+    name: string,
+    value: T,
+    correlationId?: string
+  ): Promise<APIResponse<UserPreference<T>>> => {
+    const response = await apiFetch<{ preference: UserPreference<T> }>(
+      `/api/v1/preferences/${kind}/${encodeURIComponent(name)}`,
+      {
+        method: 'PUT',
+        headers: preferenceHeaders(userId),
+        body: JSON.stringify({ value }),
+      },
+      correlationId
+    );
+    return { ...response, data: response.data.preference };
+  },
+
+  // Delete a preference
+  remove: async (
+    userId: string,
+    kind: PreferenceKind,
+    name: string,
+    correlationId?: string
+  ): Promise<APIResponse<void>> => {
+    return apiFetch<void>(
+      `/api/v1/preferences/${kind}/${encodeURIComponent(name)}`,
+      {
+        method: 'DELETE',
+        headers: preferenceHeaders(userId),
+      },
+      correlationId
+    );
+  },
+};
+
+// Analytics API endpoints
+export const analyticsApi = {
+  // Detection counts per grid cell; bbox is [minLon, minLat, maxLon, maxLat]
+  // and cell is the cell size in degrees
+  getHeatmap: async (
+    options: {
+      bbox: [number, number, number, number];
+      cell?: number;
+      since?: string;
+      until?: string;
+    },
+    correlationId?: string
+  ): Promise<APIResponse<DetectionHeatmap>> => {
+    const params = new URLSearchParams({ bbox: options.bbox.join(',') });
+    if (options.cell) params.set('cell', options.cell.toString());
+    if (options.since) params.set('since', options.since);
+    if (options.until) params.set('until', options.until);
+    return apiFetch<DetectionHeatmap>(`/api/v1/analytics/heatmap?${params.toString()}`, {}, correlationId);
+  },
+
+  // Decision statistics per human approver
+  getApprovers: async (
+    options: { from?: string; to?: string; approved_by?: string; action_type?: string } = {},
+    correlationId?: string
+  ): Promise<APIResponse<{ approvers: ApproverStats[] }>> => {
+    const params = new URLSearchParams();
+    if (options.from) params.set('from', options.from);
+    if (options.to) params.set('to', options.to);
+    if (options.approved_by) params.set('approved_by', options.approved_by);
+    if (options.action_type) params.set('action_type', options.action_type);
+
+    const query = params.toString();
+    return apiFetch<{ approvers: ApproverStats[] }>(
+      `/api/v1/analytics/approvers${query ? `?${query}` : ''}`,
+      {},
+      correlationId
+    );
+  },
+};
+
+// Server-Sent Events stream, for clients that cannot open a WebSocket. Each
+// event's data is a WSEvent in the requested schema version (a WSMessage for
+// version 1); EventSource resumes from Last-Event-ID on reconnect, and a
+// 'stream.reset' event means events were missed.
+export function streamUrl(topics: string[] = [], schemaVersion = 2): string {
+  const params = new URLSearchParams({ schema_version: String(schemaVersion) });
+  if (topics.length > 0) {
+    params.set('topics', topics.join(','));
+  }
+  return `${API_BASE_URL}/api/v1/stream?${params.toString()}`;
+}
+
 // Export all APIs as a single object
 export const api = {
   tracks: tracksApi,
@@ -393,7 +797,13 @@ export const api = {
   audit: auditApi,
   health: healthApi,
   clear: clearApi,
+  exercise: exerciseApi,
+  sim: simApi,
   interventionRules: interventionRulesApi,
+  anonymization: anonymizationApi,
+  breakGlass: breakGlassApi,
+  preferences: preferencesApi,
+  analytics: analyticsApi,
 };
 
 export default api;
@@ -8,6 +8,34 @@ export interface SensorConfig {
   paused: boolean;
   type_weights?: TrackTypeWeights;
   classification_weights?: ClassificationWeights;
+  sensors?: SimulatedSensor[]; // Empty simulates one perfect radar
+  clutter?: ClutterConfig;
+  live_clutter?: number; // Clutter objects currently being reported
+  seed?: number; // Seed the simulation was last started from; PUT /api/v1/seed replays it
+}
+
+// ClutterConfig controls injection of short-lived spurious detections
+export interface ClutterConfig {
+  rate_per_minute: number; // New clutter objects per simulated minute; 0 disables
+  min_lifetime_sec: number;
+  max_lifetime_sec: number;
+  kinds: ('birds' | 'weather' | 'jamming')[] | null; // Empty injects every kind
+}
+
+// SensorErrorModel describes how a simulated sensor's reports differ from the truth
+export interface SensorErrorModel {
+  position_sigma_meters: number; // Standard deviation of horizontal position noise
+  bias_north_meters: number;
+  bias_east_meters: number;
+  dropout_probability: number; // 0-1, chance a track is missed on a scan
+  false_alarm_rate: number; // Mean false detections per scan
+}
+
+// SimulatedSensor is one sensor the simulator reports tracks through
+export interface SimulatedSensor {
+  sensor_id: string;
+  sensor_type: string;
+  error_model: SensorErrorModel;
 }
 
 // Response type for clear streams operation
@@ -59,12 +87,12 @@ async function sensorFetch<T>(
 
     if (!response.ok) {
       const errorData = await response.json().catch(() => ({
-        error: `HTTP ${response.status}: ${response.statusText}`,
+        title: `HTTP ${response.status}: ${response.statusText}`,
         code: `HTTP_${response.status}`,
       }));
 
       throw new SensorAPIError(
-        errorData.error || errorData.message || 'Unknown error',
+        errorData.detail || errorData.title || 'Unknown error',
         errorData.code || `HTTP_${response.status}`,
         corrId
       );
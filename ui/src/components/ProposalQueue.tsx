@@ -193,6 +193,14 @@ function ProposalCard({ proposal, onApprove, onDeny }: ProposalCardProps) {
             </span>
           )}
         </div>
+        {proposal.claim && (
+          <div className="flex items-center gap-2">
+            <span className="text-xs text-gray-500">Claimed by:</span>
+            <span className="px-1.5 py-0.5 text-xs font-medium bg-amber-900/50 text-amber-300 rounded">
+              {proposal.claim.claimed_by}
+            </span>
+          </div>
+        )}
       </div>
 
       {/* Rationale */}
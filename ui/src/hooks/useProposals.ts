@@ -2,7 +2,7 @@ import { useCallback, useMemo } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { create } from 'zustand';
 import { api } from '../api/client';
-import type { ActionProposal, Decision, DecisionRequest } from '../types';
+import type { ActionProposal, Decision, DecisionRequest, ProposalClaimNotification, ProposalRescore } from '../types';
 
 // Zustand store for proposal state
 interface ProposalStore {
@@ -112,7 +112,7 @@ export function useProposals() {
     },
     onError: (error: Error & { code?: string }, variables: DecisionRequest) => {
       // 409 Conflict means already decided - remove from list anyway
-      if (error.code === 'HTTP_409' || error.message?.includes('conflict')) {
+      if (error.code === 'conflict' || error.code === 'HTTP_409') {
         removeProposal(variables.proposal_id);
         queryClient.setQueryData<ActionProposal[]>(PROPOSALS_QUERY_KEY, (old) => {
           if (!old) return [];
@@ -152,6 +152,42 @@ export function useProposals() {
     [updateProposal, queryClient]
   );
 
+  // Handle WebSocket proposal re-scored after its track changed threat level
+  const handleProposalRescored = useCallback(
+    (rescore: ProposalRescore) => {
+      const proposal = useProposalStore.getState().proposals.get(rescore.proposal_id);
+      if (!proposal) return;
+      handleProposalUpdate({
+        ...proposal,
+        priority: rescore.priority,
+        threat_level: rescore.threat_level,
+        expires_at: rescore.expires_at,
+      });
+    },
+    [handleProposalUpdate]
+  );
+
+  // Handle WebSocket proposal claimed, released, or claim lapsed
+  const handleProposalClaim = useCallback(
+    (notification: ProposalClaimNotification) => {
+      const proposal = useProposalStore.getState().proposals.get(notification.proposal_id);
+      if (!proposal) return;
+      handleProposalUpdate({
+        ...proposal,
+        claim:
+          notification.event === 'claimed'
+            ? {
+                proposal_id: notification.proposal_id,
+                claimed_by: notification.claimed_by,
+                claimed_at: notification.claimed_at,
+                expires_at: notification.expires_at,
+              }
+            : undefined,
+      });
+    },
+    [handleProposalUpdate]
+  );
+
   // Handle WebSocket proposal expired
   const handleProposalExpired = useCallback(
     (proposalId: string) => {
@@ -164,6 +200,15 @@ export function useProposals() {
     [removeProposal, queryClient]
   );
 
+  // Handle WebSocket picture snapshot - replaces all pending proposals
+  const handleProposalsSnapshot = useCallback(
+    (snapshotProposals: ActionProposal[]) => {
+      setProposals(snapshotProposals);
+      queryClient.setQueryData<ActionProposal[]>(PROPOSALS_QUERY_KEY, snapshotProposals);
+    },
+    [setProposals, queryClient]
+  );
+
   // Get sorted proposals (by priority, then expiration), excluding expired
   const sortedProposals = useMemo(() => {
     const now = Date.now();
@@ -267,7 +312,10 @@ export function useProposals() {
     // WebSocket handlers
     handleProposalNew,
     handleProposalUpdate,
+    handleProposalRescored,
+    handleProposalClaim,
     handleProposalExpired,
+    handleProposalsSnapshot,
   };
 }
 
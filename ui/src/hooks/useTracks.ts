@@ -2,7 +2,7 @@ import { useCallback, useEffect } from 'react';
 import { useQuery, useQueryClient } from '@tanstack/react-query';
 import { create } from 'zustand';
 import { api } from '../api/client';
-import type { CorrelatedTrack, SortConfig } from '../types';
+import type { CorrelatedTrack, SortConfig, TrackMerge } from '../types';
 
 // How long before a track is considered stale (30 seconds)
 // This ensures tracks are purged shortly after sensor config changes
@@ -204,6 +204,28 @@ export function useTracks() {
     [deleteTrack, queryClient]
   );
 
+  // Handle WebSocket track merge - superseded tracks leave the picture, and a
+  // selected one is followed to the track it was merged into
+  const handleTrackMerged = useCallback(
+    (merge: TrackMerge) => {
+      const wasSelected = merge.superseded_track_ids.includes(selectedTrackId ?? '');
+      merge.superseded_track_ids.forEach(handleTrackDelete);
+      if (wasSelected) {
+        selectTrack(merge.track_id);
+      }
+    },
+    [handleTrackDelete, selectTrack, selectedTrackId]
+  );
+
+  // Handle WebSocket picture snapshot - replaces all tracks
+  const handleTracksSnapshot = useCallback(
+    (snapshotTracks: CorrelatedTrack[]) => {
+      setTracks(snapshotTracks);
+      queryClient.setQueryData<CorrelatedTrack[]>(TRACKS_QUERY_KEY, snapshotTracks);
+    },
+    [setTracks, queryClient]
+  );
+
   // Get filtered and sorted tracks
   const getFilteredTracks = useCallback((): CorrelatedTrack[] => {
     let result = Array.from(tracks.values());
@@ -308,6 +330,8 @@ export function useTracks() {
     // WebSocket handlers
     handleTrackUpdate,
     handleTrackDelete,
+    handleTrackMerged,
+    handleTracksSnapshot,
   };
 }
 
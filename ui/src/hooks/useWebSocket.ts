@@ -1,26 +1,48 @@
 import { useEffect, useRef, useCallback, useState } from 'react';
 import type {
-  WSMessage,
+  WSEvent,
+  WSConnectionStatus,
+  WSPictureSnapshot,
   ConnectionStatus,
   CorrelatedTrack,
+  TrackLifecycleEvent,
+  TrackMerge,
+  TrackSplit,
   ActionProposal,
+  ProposalEscalation,
+  DecisionSLABreach,
+  ProposalRescore,
+  ProposalClaimNotification,
   Decision,
+  DecisionRevocation,
   EffectLog,
   SystemMetrics,
 } from '../types';
 
 const WS_URL = import.meta.env.VITE_WS_URL || 'ws://localhost:8080/ws';
+// Outbound schema the UI speaks, and the newest payload version it understands
+const WS_SCHEMA_VERSION = 2;
+const MAX_EVENT_VERSION = 1;
 const RECONNECT_DELAY_MS = 3000;
 const MAX_RECONNECT_ATTEMPTS = 10;
 const HEARTBEAT_INTERVAL_MS = 30000;
 
 interface UseWebSocketOptions {
+  onSnapshot?: (snapshot: WSPictureSnapshot) => void;
   onTrackUpdate?: (track: CorrelatedTrack) => void;
+  onTrackStale?: (event: TrackLifecycleEvent) => void;
   onTrackDelete?: (trackId: string) => void;
+  onTrackMerged?: (merge: TrackMerge) => void;
+  onTrackSplit?: (split: TrackSplit) => void;
   onProposalNew?: (proposal: ActionProposal) => void;
   onProposalUpdate?: (proposal: ActionProposal) => void;
   onProposalExpired?: (proposalId: string) => void;
+  onProposalEscalated?: (escalation: ProposalEscalation) => void;
+  onProposalSLABreach?: (breach: DecisionSLABreach) => void;
+  onProposalRescored?: (rescore: ProposalRescore) => void;
+  onProposalClaim?: (claim: ProposalClaimNotification) => void;
   onDecisionMade?: (decision: Decision) => void;
+  onDecisionRevoked?: (revocation: DecisionRevocation) => void;
   onEffectExecuted?: (effect: EffectLog) => void;
   onMetricsUpdate?: (metrics: SystemMetrics) => void;
   onConnectionChange?: (status: ConnectionStatus) => void;
@@ -29,19 +51,21 @@ interface UseWebSocketOptions {
 interface UseWebSocketReturn {
   status: ConnectionStatus;
   reconnect: () => void;
-  lastMessage: WSMessage | null;
+  lastMessage: WSEvent | null;
   messageCount: number;
 }
 
 export function useWebSocket(options: UseWebSocketOptions = {}): UseWebSocketReturn {
   const [status, setStatus] = useState<ConnectionStatus>('disconnected');
-  const [lastMessage, setLastMessage] = useState<WSMessage | null>(null);
+  const [lastMessage, setLastMessage] = useState<WSEvent | null>(null);
   const [messageCount, setMessageCount] = useState(0);
 
   const wsRef = useRef<WebSocket | null>(null);
   const reconnectAttemptsRef = useRef(0);
   const reconnectTimeoutRef = useRef<ReturnType<typeof setTimeout>>();
   const heartbeatIntervalRef = useRef<ReturnType<typeof setInterval>>();
+  // Last broadcast sequence number applied; null until the first snapshot
+  const lastSeqRef = useRef<number | null>(null);
 
   const optionsRef = useRef(options);
   optionsRef.current = options;
@@ -53,17 +77,50 @@ export function useWebSocket(options: UseWebSocketOptions = {}): UseWebSocketRet
 
   const handleMessage = useCallback((event: MessageEvent) => {
     try {
-      const message: WSMessage = JSON.parse(event.data);
+      const message: WSEvent = JSON.parse(event.data);
+      if (message.version > MAX_EVENT_VERSION) {
+        console.warn(`Ignoring ${message.event_type} event with unsupported version ${message.version}`);
+        return;
+      }
+
+      if (message.event_type === 'picture.snapshot') {
+        const snapshot = message.payload as WSPictureSnapshot;
+        lastSeqRef.current = snapshot.seq;
+        optionsRef.current.onSnapshot?.(snapshot);
+        return;
+      }
+      if (message.seq !== undefined && lastSeqRef.current !== null) {
+        // Events already covered by the snapshot are dropped; a gap means
+        // events were lost, so ask for a fresh snapshot
+        if (message.seq <= lastSeqRef.current) {
+          return;
+        }
+        if (message.seq > lastSeqRef.current + 1) {
+          console.warn(`Missed events ${lastSeqRef.current + 1}-${message.seq - 1}, resyncing`);
+          wsRef.current?.send(JSON.stringify({ type: 'resync' }));
+        }
+        lastSeqRef.current = message.seq;
+      }
+
       setLastMessage(message);
       setMessageCount((prev) => prev + 1);
 
-      switch (message.type) {
+      switch (message.event_type) {
         case 'track.update':
           optionsRef.current.onTrackUpdate?.(message.payload as CorrelatedTrack);
           break;
+        case 'track.stale':
+          optionsRef.current.onTrackStale?.(message.payload as TrackLifecycleEvent);
+          break;
         case 'track.delete':
           optionsRef.current.onTrackDelete?.(message.payload as string);
           break;
+        case 'track.merged':
+          optionsRef.current.onTrackMerged?.(message.payload as TrackMerge);
+          break;
+        case 'track.split':
+          optionsRef.current.onTrackSplit?.(message.payload as TrackSplit);
+          break;
         case 'proposal.new':
           optionsRef.current.onProposalNew?.(message.payload as ActionProposal);
           break;
@@ -73,16 +130,41 @@ export function useWebSocket(options: UseWebSocketOptions = {}): UseWebSocketRet
         case 'proposal.expired':
           optionsRef.current.onProposalExpired?.(message.payload as string);
           break;
+        case 'proposal.escalated':
+          optionsRef.current.onProposalEscalated?.(message.payload as ProposalEscalation);
+          break;
+        case 'proposal.sla_breach':
+          optionsRef.current.onProposalSLABreach?.(message.payload as DecisionSLABreach);
+          break;
+        case 'proposal.rescored':
+          optionsRef.current.onProposalRescored?.(message.payload as ProposalRescore);
+          break;
+        case 'proposal.claimed':
+        case 'proposal.released':
+          optionsRef.current.onProposalClaim?.(message.payload as ProposalClaimNotification);
+          break;
         case 'decision.made':
           optionsRef.current.onDecisionMade?.(message.payload as Decision);
           break;
+        case 'decision.revoked':
+          optionsRef.current.onDecisionRevoked?.(message.payload as DecisionRevocation);
+          break;
         case 'effect.executed':
           optionsRef.current.onEffectExecuted?.(message.payload as EffectLog);
           break;
         case 'metrics.update':
           optionsRef.current.onMetricsUpdate?.(message.payload as SystemMetrics);
           break;
-        case 'connection.status':
+        case 'connection.status': {
+          const connection = message.payload as WSConnectionStatus;
+          if (connection.schema_version !== WS_SCHEMA_VERSION) {
+            console.warn(`Gateway is sending schema version ${connection.schema_version}, expected ${WS_SCHEMA_VERSION}`);
+          }
+          break;
+        }
+        case 'error':
+          console.error('WebSocket error event:', message.payload);
+          break;
         case 'ping':
         case 'pong':
           // Server keepalive and acknowledgment messages, no action needed
@@ -92,7 +174,7 @@ export function useWebSocket(options: UseWebSocketOptions = {}): UseWebSocketRet
           optionsRef.current.onTrackUpdate?.(message.payload as CorrelatedTrack);
           break;
         default:
-          console.warn('Unknown WebSocket message type:', message.type);
+          console.warn('Unknown WebSocket event type:', message.event_type);
       }
     } catch (error) {
       console.error('Failed to parse WebSocket message:', error);
@@ -132,7 +214,8 @@ export function useWebSocket(options: UseWebSocketOptions = {}): UseWebSocketRet
     updateStatus('connecting');
 
     try {
-      const ws = new WebSocket(WS_URL);
+      const ws = new WebSocket(`${WS_URL}?schema_version=${WS_SCHEMA_VERSION}`);
+      lastSeqRef.current = null;
 
       ws.onopen = () => {
         console.log('WebSocket connected');
@@ -2,12 +2,12 @@ import { useState, useEffect, useCallback } from 'react';
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import clsx from 'clsx';
 import { sensorApi, SensorConfig, SensorAPIError, ClearStreamsResponse } from '../api/sensor';
-import { clearApi, APIClientError } from '../api/client';
+import { clearApi, exerciseApi, simApi, APIClientError } from '../api/client';
 import type { TrackTypeWeights, ClassificationWeights } from '../types';
 import { ConfirmationModal } from '../components/ConfirmationModal';
 
 // Modal state type
-type ModalType = 'clearAll' | 'clearQueue' | null;
+type ModalType = 'clearAll' | 'clearQueue' | 'resetExercise' | null;
 
 // Toast notification component
 interface ToastProps {
@@ -467,6 +467,116 @@ function adjustClassificationWeightsProportionally(
   return result;
 }
 
+// Simulation speed presets offered in the clock card
+const SIM_SPEEDS = [0.5, 1, 2, 5, 10];
+
+// Pipeline-wide simulation clock: freezes or speeds up every agent, not just the sensor
+function SimulationClockCard({ onToast }: { onToast: (toast: { message: string; type: 'success' | 'error' }) => void }) {
+  const queryClient = useQueryClient();
+
+  const { data: simData } = useQuery({
+    queryKey: ['simClock'],
+    queryFn: async () => {
+      const response = await simApi.getState();
+      return response.data;
+    },
+    refetchInterval: 2000,
+  });
+
+  const onError = (error: Error) => {
+    const message = error instanceof APIClientError ? error.message : 'Failed to update simulation clock';
+    onToast({ message, type: 'error' });
+  };
+
+  const togglePauseMutation = useMutation({
+    mutationFn: async (pause: boolean) => {
+      const response = pause ? await simApi.pause() : await simApi.resume();
+      return response.data;
+    },
+    onSuccess: (data) => {
+      queryClient.setQueryData(['simClock'], data);
+      queryClient.invalidateQueries({ queryKey: ['proposals'] });
+      const extended = data.proposals_extended ? ` (${data.proposals_extended} pending proposals extended)` : '';
+      onToast({
+        message: data.state.paused ? 'Pipeline paused' : `Pipeline resumed${extended}`,
+        type: 'success',
+      });
+    },
+    onError,
+  });
+
+  const speedMutation = useMutation({
+    mutationFn: async (speed: number) => {
+      const response = await simApi.setSpeed(speed);
+      return response.data;
+    },
+    onSuccess: (data) => {
+      queryClient.setQueryData(['simClock'], data);
+      onToast({ message: `Simulation speed set to ${data.state.speed}x`, type: 'success' });
+    },
+    onError,
+  });
+
+  const state = simData?.state;
+  const isMutating = togglePauseMutation.isPending || speedMutation.isPending;
+
+  return (
+    <div className="bg-gray-800 rounded-lg p-6 border border-gray-700">
+      <div className="flex items-center justify-between mb-6">
+        <h3 className="text-sm font-medium text-gray-300 uppercase tracking-wide">
+          Simulation Clock
+        </h3>
+        {simData && (
+          <span className="text-xs text-gray-400 font-mono">
+            {new Date(simData.sim_now).toLocaleTimeString()} sim time
+          </span>
+        )}
+      </div>
+
+      <div className="flex flex-wrap items-center gap-4">
+        <button
+          onClick={() => togglePauseMutation.mutate(!state?.paused)}
+          disabled={!state || isMutating}
+          className={clsx(
+            'px-6 py-3 text-sm font-medium rounded-lg transition-colors',
+            state?.paused
+              ? 'bg-green-600 hover:bg-green-700 text-white'
+              : 'bg-yellow-600 hover:bg-yellow-700 text-white',
+            (!state || isMutating) && 'opacity-50 cursor-not-allowed'
+          )}
+        >
+          {state?.paused ? 'Resume Pipeline' : 'Pause Pipeline'}
+        </button>
+
+        <div className="flex items-center gap-2">
+          <span className="text-sm text-gray-400">Speed</span>
+          {SIM_SPEEDS.map((speed) => (
+            <button
+              key={speed}
+              onClick={() => speedMutation.mutate(speed)}
+              disabled={!state || isMutating || state.speed === speed}
+              className={clsx(
+                'px-3 py-2 text-sm font-medium rounded-lg border transition-colors',
+                state?.speed === speed
+                  ? 'bg-blue-600 border-blue-600 text-white'
+                  : 'border-gray-600 text-gray-300 hover:bg-gray-700',
+                isMutating && 'opacity-50 cursor-not-allowed'
+              )}
+            >
+              {speed}x
+            </button>
+          ))}
+        </div>
+      </div>
+
+      <p className="text-xs text-gray-500 mt-4">
+        Pausing freezes every agent: detections stop, queued messages stay in their streams, and proposal
+        expiry clocks stop until the pipeline resumes.
+      </p>
+    </div>
+  );
+}
+
 // Main SensorControlPage component
 export function SensorControlPage() {
   const queryClient = useQueryClient();
@@ -583,6 +693,33 @@ export function SensorControlPage() {
     },
   });
 
+  // Reset exercise mutation (pause, purge, clear, reload, resume in one gateway call)
+  const resetExerciseMutation = useMutation({
+    mutationFn: async () => {
+      const response = await exerciseApi.reset();
+      return response.data;
+    },
+    onSuccess: (data) => {
+      queryClient.invalidateQueries({ queryKey: ['tracks'] });
+      queryClient.invalidateQueries({ queryKey: ['proposals'] });
+      queryClient.invalidateQueries({ queryKey: ['decisions'] });
+      queryClient.invalidateQueries({ queryKey: ['effects'] });
+      queryClient.invalidateQueries({ queryKey: ['sensorConfig'] });
+      const totalDeleted = Object.values(data.deleted).reduce((sum, n) => sum + n, 0);
+      const totalPurged = Object.values(data.purged).reduce((sum, n) => sum + n, 0);
+      setActiveModal(null);
+      setToast({
+        message: `Exercise reset: ${totalDeleted} records cleared, ${totalPurged} queued messages discarded`,
+        type: 'success'
+      });
+    },
+    onError: (error) => {
+      const message = error instanceof APIClientError ? error.message : 'Failed to reset exercise';
+      setActiveModal(null);
+      setToast({ message, type: 'error' });
+    },
+  });
+
   // Clear message queue mutation
   const clearQueueMutation = useMutation({
     mutationFn: async (): Promise<ClearStreamsResponse> => {
@@ -688,6 +825,10 @@ export function SensorControlPage() {
     clearAllMutation.mutate();
   }, [clearAllMutation]);
 
+  const handleConfirmResetExercise = useCallback(() => {
+    resetExerciseMutation.mutate();
+  }, [resetExerciseMutation]);
+
   const handleConfirmClearQueue = useCallback(() => {
     clearQueueMutation.mutate();
   }, [clearQueueMutation]);
@@ -756,7 +897,7 @@ export function SensorControlPage() {
   const config = localConfig || configData;
   if (!config) return null;
 
-  const isMutating = updateMutation.isPending || resetMutation.isPending || togglePauseMutation.isPending || clearAllMutation.isPending || clearQueueMutation.isPending;
+  const isMutating = updateMutation.isPending || resetMutation.isPending || togglePauseMutation.isPending || clearAllMutation.isPending || clearQueueMutation.isPending || resetExerciseMutation.isPending;
   const canClear = config.paused && !isMutating;
   const canClearQueue = config.paused && !isMutating;
 
@@ -973,6 +1114,9 @@ export function SensorControlPage() {
         )}
       </div>
 
+      {/* Simulation Clock Card */}
+      <SimulationClockCard onToast={setToast} />
+
       {/* Controls Card */}
       <div className="bg-gray-800 rounded-lg p-6 border border-gray-700">
         <h3 className="text-sm font-medium text-gray-300 uppercase tracking-wide mb-6">
@@ -1070,6 +1214,26 @@ export function SensorControlPage() {
             )}
             Clear All Data
           </button>
+
+          {/* Reset Exercise Button - does not require pausing first */}
+          <button
+            onClick={() => setActiveModal('resetExercise')}
+            disabled={isMutating}
+            className={clsx(
+              'px-6 py-3 text-sm font-medium rounded-lg transition-colors flex items-center gap-2',
+              'bg-orange-600 hover:bg-orange-700 text-white border border-orange-600',
+              isMutating && 'opacity-50 cursor-not-allowed'
+            )}
+          >
+            {resetExerciseMutation.isPending ? (
+              <div className="animate-spin rounded-full h-4 w-4 border-b-2 border-white" />
+            ) : (
+              <svg className="w-4 h-4" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+                <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15" />
+              </svg>
+            )}
+            Reset Exercise
+          </button>
         </div>
       </div>
 
@@ -1124,6 +1288,31 @@ export function SensorControlPage() {
         }
       />
 
+      {/* Reset Exercise Confirmation Modal */}
+      <ConfirmationModal
+        isOpen={activeModal === 'resetExercise'}
+        onClose={handleCloseModal}
+        onConfirm={handleConfirmResetExercise}
+        variant="danger"
+        title="Reset Exercise"
+        message="This pauses the sensors, discards all queued messages, deletes all exercise data, and restarts the simulation from default settings."
+        confirmText="Reset Exercise"
+        cancelText="Cancel"
+        isLoading={resetExerciseMutation.isPending}
+        details={[
+          'Sensors are paused for the duration of the reset',
+          'All pipeline streams are purged and consumers recreated',
+          'Tracks, detections, proposals, decisions, and effects are deleted',
+          'The audit chain and system counters are reset',
+          'Default tracks are regenerated and sensors resume',
+        ]}
+        icon={
+          <svg className="w-6 h-6" fill="none" stroke="currentColor" viewBox="0 0 24 24">
+            <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={2} d="M4 4v5h.582m15.356 2A8.001 8.001 0 004.582 9m0 0H9m11 11v-5h-.581m0 0a8.003 8.003 0 01-15.357-2m15.357 2H15" />
+          </svg>
+        }
+      />
+
       {/* Toast Notification */}
       {toast && (
         <Toast
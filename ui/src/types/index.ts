@@ -8,12 +8,20 @@ export interface Envelope {
   source: string;
   source_type: string;
   timestamp: string;
-  signature: string;
   policy_version: string;
   trace_id?: string;
   span_id?: string;
+  schema_version?: number; // Message format version; missing means 1
+  classification?: SecurityLabel;
+  key_id?: string; // Payload key the message was sealed under
+  signing_key_id?: string; // Source's key the message is signed with
+  releasability?: string[]; // Coalition partners the message is releasable to
+  origins?: string[]; // Enclaves the message was federated through, the originating one first
 }
 
+// Security label of a stored track or proposal (track and proposal APIs)
+export type SecurityLabel = 'unclassified' | 'confidential' | 'secret' | 'top_secret';
+
 // Position represents a geographic position
 export interface Position {
   lat: number;
@@ -66,22 +74,80 @@ export interface Track {
 // CorrelatedTrack represents a track after correlation/deduplication
 export interface CorrelatedTrack {
   envelope: Envelope;
-  track_id: string;
-  merged_from: string[];
+  track_id: string; // System track ID assigned by the correlator
+  merged_from: string[]; // Sensor track IDs fused into the track, the reporting one first
+  sensor_track_ids?: string[]; // Every sensor track ID the track was reported under (track APIs)
   classification: 'friendly' | 'hostile' | 'unknown' | 'neutral';
   type: 'aircraft' | 'vessel' | 'ground' | 'missile' | 'unknown';
   position: Position;
   velocity: Velocity;
   confidence: number;
   threat_level: ThreatLevel;
+  threat_score?: number;
+  zone_proximity?: ZoneProximity[];
   window_start: string;
   window_end: string;
   last_updated: string;
   detection_count: number;
   sources: string[];
+  state?: TrackState;
+  state_changed_at?: string;
+  merged_into?: string; // Track a merged track was fused into
+  security_label?: SecurityLabel;
+  releasability?: string[];
   [key: string]: unknown; // Index signature for compatibility
 }
 
+// GeoJSON output of the track APIs (?format=geojson)
+export interface TrackFeature {
+  type: 'Feature';
+  id?: string;
+  geometry: { type: 'Point'; coordinates: [number, number] | [number, number, number] } | null;
+  properties: Record<string, unknown>;
+}
+
+export interface TrackFeatureCollection {
+  type: 'FeatureCollection';
+  features: TrackFeature[];
+  correlation_id?: string;
+}
+
+// Track lifecycle state; idle tracks go stale and are then dropped
+export type TrackState = 'active' | 'stale' | 'dropped' | 'lost' | 'merged' | 'neutralized';
+
+// Published on track.lifecycle.<state> when a track ages out
+export interface TrackLifecycleEvent {
+  envelope: Envelope;
+  track_id: string;
+  classification: string;
+  type: string;
+  threat_level: ThreatLevel;
+  previous_state: TrackState;
+  state: TrackState;
+  last_updated: string;
+  changed_at: string;
+}
+
+// TrackMerge is published when tracks reported under different IDs are fused;
+// the superseded IDs are not reported again
+export interface TrackMerge {
+  envelope: Envelope;
+  track_id: string;
+  superseded_track_ids: string[];
+  sensor_track_ids: string[];
+  merged_at: string;
+}
+
+// TrackSplit is published when a sensor track splits off a track it no longer
+// correlates with and is reported under a new ID
+export interface TrackSplit {
+  envelope: Envelope;
+  track_id: string;
+  split_from: string;
+  sensor_track_id: string;
+  split_at: string;
+}
+
 // ThreatLevel enum
 export type ThreatLevel = 'critical' | 'high' | 'medium' | 'low' | 'unknown';
 
@@ -97,6 +163,9 @@ export interface ActionProposal {
   priority: number;
   rationale: string;
   constraints?: string[];
+  options?: CourseOfAction[]; // Ranked alternatives; the first is action_type
+  asset?: AssetAssignment; // Recommended asset for engage and intercept proposals
+  conflicts?: ProposalConflict[]; // Engagements competing for the same asset or airspace
   track?: CorrelatedTrack;
   threat_level: ThreatLevel;
   expires_at: string;
@@ -105,6 +174,115 @@ export interface ActionProposal {
   created_at?: string; // Added - returned by backend
   hit_count?: number; // Number of sensor hits for this track (de-duplication counter)
   last_hit_at?: string; // When the most recent sensor hit occurred
+  escalation_level?: number; // 0 none, 1 warning (50% of TTL), 2 urgent (80% of TTL)
+  urgency?: ProposalUrgency;
+  time_remaining_seconds?: number; // Seconds until the proposal expires
+  security_label?: SecurityLabel;
+  releasability?: string[]; // Coalition partners the proposal is releasable to
+  claim?: ProposalClaim; // Operator working the proposal, if anyone has claimed it
+}
+
+// ProposalClaim is an operator's claim to work a pending proposal; it lapses
+// at expires_at unless its holder stays active
+export interface ProposalClaim {
+  proposal_id: string;
+  claimed_by: string;
+  claimed_at: string;
+  last_active_at?: string;
+  expires_at: string;
+}
+
+// ProposalClaimNotification is published when a proposal is claimed, released, or its claim lapses
+export interface ProposalClaimNotification {
+  envelope: Envelope;
+  notification_id: string;
+  event: 'claimed' | 'released' | 'expired';
+  proposal_id: string;
+  claimed_by: string;
+  claimed_at: string;
+  expires_at: string;
+}
+
+// CourseOfAction is one action a proposal offers, with estimated benefit and risk (0-100)
+export interface CourseOfAction {
+  action_type: ActionType;
+  rank: number; // 1 is the recommended action
+  benefit: number;
+  risk: number;
+  score: number; // Benefit less risk
+  rationale?: string;
+  constraints?: string[];
+}
+
+// AssetAssignment is the asset a proposal recommends for its action
+export interface AssetAssignment {
+  asset_id: string;
+  name: string;
+  kind: 'interceptor' | 'jammer' | 'sensor';
+  distance_meters: number; // Distance from the asset to the track when proposed
+}
+
+// ProposalConflict is another engagement competing with a proposal
+export interface ProposalConflict {
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  kind: 'asset' | 'airspace';
+  asset_id?: string; // Shared asset, for asset conflicts
+  distance_meters: number; // Between the two tracks when proposed
+}
+
+// ProposalUrgency reflects how much of a proposal's TTL has elapsed
+export type ProposalUrgency = 'normal' | 'warning' | 'urgent';
+
+// ProposalEscalation is published when a pending proposal nears expiration
+export interface ProposalEscalation {
+  envelope: Envelope;
+  notification_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  threat_level: ThreatLevel;
+  escalation_level: number;
+  urgency: ProposalUrgency;
+  previous_priority: number;
+  priority: number;
+  ttl_fraction_elapsed: number;
+  time_remaining_seconds: number;
+  expires_at: string;
+}
+
+// DecisionSLABreach is published when a pending proposal waits longer than its decision SLA
+export interface DecisionSLABreach {
+  envelope: Envelope;
+  notification_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  threat_level: ThreatLevel;
+  priority: number;
+  sla_min_priority: number;
+  sla_seconds: number;
+  pending_seconds: number;
+  time_remaining_seconds: number;
+  created_at: string;
+  expires_at: string;
+}
+
+// ProposalRescore is published when a pending proposal is re-scored after its
+// track changes threat level
+export interface ProposalRescore {
+  envelope: Envelope;
+  notification_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  previous_priority: number;
+  priority: number;
+  previous_threat_level: ThreatLevel;
+  threat_level: ThreatLevel;
+  previous_expires_at: string;
+  expires_at: string;
 }
 
 // Decision represents a human decision on an action proposal
@@ -119,6 +297,69 @@ export interface Decision {
   conditions?: string[];
   action_type?: ActionType; // Optional - may not be in API response
   track_id?: string; // Optional - may not be in API response
+  break_glass_grant_id?: string; // Set when approved under a break-glass grant
+  selected_option?: ActionType; // Course of action approved, when the proposal offered options
+  machine_approved?: boolean; // Approved by an auto_approve intervention rule, not an operator
+  auto_approve_rule_id?: string; // Rule that approved the proposal
+  revoked_at?: string; // Set when the decision was revoked before its effect executed
+  revoked_by?: string;
+  revoke_reason?: string;
+}
+
+// DecisionRevocation recalls an approved decision before its effect executes
+export interface DecisionRevocation {
+  envelope: Envelope;
+  decision_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  revoked_by: string;
+  revoked_at: string;
+  reason?: string;
+}
+
+// BreakGlassGrant is a time-boxed elevation of an operator's approval authority
+export interface BreakGlassGrant {
+  grant_id: string;
+  user_id: string;
+  role: string;
+  justification: string;
+  second_factor: string;
+  second_factor_verified: boolean;
+  status: 'active' | 'expired' | 'revoked';
+  activated_at: string;
+  expires_at: string;
+  revoked_at?: string;
+  revoked_by?: string;
+  revoke_reason?: string;
+  use_count: number;
+}
+
+// BreakGlassActivation is the request body for activating a grant
+export interface BreakGlassActivation {
+  user_id: string;
+  role?: string;
+  justification: string;
+  duration_minutes?: number; // Default 15, capped at 60
+  totp_code: string;
+}
+
+// BreakGlassNotification is published when a grant is activated, used, revoked, or expires
+export interface BreakGlassNotification {
+  envelope: Envelope;
+  notification_id: string;
+  event: 'activated' | 'used' | 'revoked' | 'expired';
+  grant_id: string;
+  user_id: string;
+  role: string;
+  justification: string;
+  activated_at: string;
+  expires_at: string;
+  actor: string;
+  reason?: string;
+  proposal_id?: string;
+  decision_id?: string;
+  action_type?: ActionType;
 }
 
 // EffectLog represents the execution of an approved action
@@ -129,32 +370,203 @@ export interface EffectLog {
   proposal_id: string;
   track_id: string;
   action_type: ActionType;
-  status: 'executed' | 'failed' | 'simulated' | 'pending';
+  status: 'executed' | 'failed' | 'failed_permanent' | 'simulated' | 'pending' | 'aborted';
   executed_at: string;
   result: string;
   idempotent_key: string;
   idempotent: boolean;
+  fencing_token?: number; // Lease tenure of the effector that executed it
+  executor_id?: string;
+  attempts?: number; // Execution attempts made under the effector's retry policy
+  outcome?: 'success' | 'partial' | 'failure'; // What the backend reported of the executed action
+  collateral?: boolean; // The action caused collateral damage
+  duration_ms?: number;
+}
+
+// Battle damage assessment of an executed effect
+export interface EffectAssessment {
+  envelope: Envelope;
+  assessment_id: string;
+  effect_id: string;
+  decision_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  success_probability: number; // 0-1
+  neutralized: boolean; // Neutralized tracks receive no further proposals
+  basis: 'reported' | 'estimated';
+  assessed_at: string;
+}
+
+// Progress reported by the effector's backend adapter while an effect executes
+export interface EffectProgress {
+  envelope: Envelope;
+  effect_id: string;
+  decision_id: string;
+  track_id: string;
+  action_type: ActionType;
+  backend: string;
+  stage: 'accepted' | 'executing' | 'completed' | 'failed';
+  percent: number;
+  message?: string;
+}
+
+// Item shed by admission control to keep the pipeline bounded during saturation
+export interface AdmissionShed {
+  envelope: Envelope;
+  notification_id: string;
+  kind: 'detection' | 'track' | 'proposal';
+  reason: 'rate_limit' | 'capacity' | 'displaced';
+  track_id?: string;
+  proposal_id?: string;
+  threat_level?: ThreatLevel;
+  priority?: number;
+  count: number; // Detections shed by the rate limit are summarized per report
+  limit: number;
+  displaced_by?: string;
+}
+
+// Pipeline-wide simulation clock published on SIMCONTROL
+export interface SimClockState {
+  seq: number;
+  paused: boolean;
+  speed: number;
+  sim_time: string;
+  wall_time: string;
+  paused_at: string;
+  updated_by?: string;
+  reason?: string;
+}
+
+export interface SimControlResponse {
+  state: SimClockState;
+  sim_now: string;
+  proposals_extended?: number;
+  correlation_id: string;
+}
+
+// Per-user UI preferences
+export type PreferenceKind = 'map_filter' | 'column_layout' | 'default_sector';
+
+export interface UserPreference<T = unknown> {
+  kind: PreferenceKind;
+  name: string;
+  value: T;
+  created_at: string;
+  updated_at: string;
 }
 
 // WebSocket message types
 export type WSMessageType =
   | 'track.update'
   | 'track.new'
+  | 'track.stale'
   | 'track.delete'
+  | 'track.merged'
+  | 'track.split'
   | 'proposal.new'
   | 'proposal.update'
   | 'proposal.expired'
+  | 'proposal.escalated'
+  | 'proposal.sla_breach'
+  | 'proposal.rescored'
+  | 'proposal.claimed'
+  | 'proposal.released'
+  | 'break_glass.event'
   | 'decision.made'
+  | 'decision.revoked'
   | 'effect.executed'
+  | 'effect.progress'
+  | 'effect.assessment'
+  | 'engagement.completed'
+  | 'sim.control'
+  | 'admission.shed'
   | 'metrics.update'
   | 'connection.status'
+  | 'picture.snapshot'
+  | 'stream.reset'
+  | 'session.replay'
+  | 'error'
   | 'ping'
   | 'pong';
 
+// Schema version 1 message, sent to clients that do not request a version
 export interface WSMessage<T = unknown> {
   type: WSMessageType;
   payload: T;
   timestamp: string;
+  correlation_id?: string;
+}
+
+// Schema version 2 event envelope; routing fields are lifted from the source
+// message's envelope
+export interface WSEvent<T = unknown> {
+  schema_version: number;
+  event_type: WSMessageType;
+  version: number; // Payload version for the event type
+  seq?: number; // Broadcast sequence number, absent on events sent to one client
+  ts: string;
+  message_id?: string;
+  correlation_id?: string;
+  causation_id?: string;
+  source?: string;
+  source_type?: string;
+  payload: T;
+  replay?: WSEventReplay; // Set on events re-broadcast from a recorded session
+}
+
+// Marks an event re-broadcast from a recorded session
+export interface WSEventReplay {
+  replay_id: string;
+  session_id: string;
+  seq: number; // The event's position in the session
+}
+
+// Payload of session.replay events, sent when a replay starts and ends
+export interface SessionReplayStatus {
+  replay_id: string;
+  session_id: string;
+  status: 'started' | 'completed' | 'stopped' | 'failed';
+  speed: number;
+  max_gap?: string;
+  from_seq?: number;
+  events: number;
+  started_at: string;
+  error?: string;
+}
+
+// Payload of the connection.status event sent when a client connects
+export interface WSConnectionStatus {
+  client_id: string;
+  schema_version: number;
+  current_schema_version: number;
+  supported_schema_versions: number[];
+  event_versions: Record<string, number>;
+}
+
+// Payload of the picture.snapshot event sent on connect and on resync. Events
+// numbered at or below seq are already reflected in the snapshot.
+export interface WSPictureSnapshot {
+  seq: number;
+  reason: 'connect' | 'resync';
+  tracks: CorrelatedTrack[];
+  proposals: ActionProposal[];
+}
+
+// Payload of the engagement.completed event that follows each effect log with
+// the proposal and track it was taken against; either is absent once deleted
+export interface WSEngagementCompleted {
+  envelope: Envelope; // The effect log's envelope
+  effect_id: string;
+  decision_id: string;
+  proposal_id: string;
+  track_id: string;
+  action_type: ActionType;
+  status: EffectLog['status'];
+  result: string;
+  executed_at: string;
+  proposal?: ActionProposal;
+  track?: CorrelatedTrack;
 }
 
 // Metrics types
@@ -204,6 +616,74 @@ export interface AuditEntry {
   reason?: string;
 }
 
+// AuditBrokenLink describes the first link that failed hash chain verification
+export interface AuditBrokenLink {
+  seq: number;
+  entity_type?: 'decision' | 'effect';
+  entity_id?: string;
+  reason: string;
+  expected_hash?: string;
+  actual_hash?: string;
+}
+
+// AuditChainVerification reports the integrity of the decision/effect hash chain
+export interface AuditChainVerification {
+  valid: boolean;
+  links_checked: number;
+  head_seq: number;
+  head_hash: string;
+  first_broken?: AuditBrokenLink;
+  correlation_id: string;
+}
+
+// HeatmapCell is one grid cell of a detection heatmap; row and col count
+// cells from the bounding box's south-west corner
+export interface HeatmapCell {
+  row: number;
+  col: number;
+  min_lat: number;
+  min_lon: number;
+  max_lat: number;
+  max_lon: number;
+  count: number;
+  dominant_classification: string;
+  dominant_count: number;
+}
+
+// DetectionHeatmap is detection density over a bounding box; cells without
+// detections are omitted
+export interface DetectionHeatmap {
+  bbox: { min_lon: number; min_lat: number; max_lon: number; max_lat: number };
+  cell: number;
+  since: string;
+  until?: string;
+  rows: number;
+  cols: number;
+  cells: HeatmapCell[];
+  total: number;
+  max_count: number;
+  correlation_id: string;
+}
+
+// DecisionStats summarizes human decisions; latency runs from proposal to decision
+export interface DecisionStats {
+  decisions: number;
+  approved: number;
+  denied: number;
+  approval_rate: number;
+  median_latency_seconds?: number;
+  p90_latency_seconds?: number;
+}
+
+// ApproverStats is one approver's decisions overall and by action type
+export interface ApproverStats extends DecisionStats {
+  approved_by: string;
+  revoked: number;
+  first_decision_at: string;
+  last_decision_at: string;
+  by_action_type: (DecisionStats & { action_type: string })[];
+}
+
 // API response types
 export interface APIResponse<T> {
   data: T;
@@ -211,10 +691,28 @@ export interface APIResponse<T> {
   timestamp: string;
 }
 
+// Error codes of problem+json responses
+export type APIErrorCode =
+  | 'validation'
+  | 'unauthorized'
+  | 'forbidden'
+  | 'policy_denied'
+  | 'not_found'
+  | 'method_not_allowed'
+  | 'conflict'
+  | 'rate_limited'
+  | 'upstream_unavailable'
+  | 'internal';
+
+// RFC 7807 problem details returned by the gateway and agents for every error
 export interface APIError {
-  error: string;        // Error type (e.g., "bad_request", "not_found")
-  message: string;      // Human-readable error message
-  correlation_id: string;
+  type: string;         // urn:cjadc2:problem:<code>
+  title: string;        // Short summary of the code
+  status: number;
+  detail?: string;      // Human-readable explanation of this occurrence
+  instance?: string;    // Request path
+  code: APIErrorCode | string;
+  correlation_id?: string;
 }
 
 export interface PaginatedResponse<T> {
@@ -232,6 +730,7 @@ export interface DecisionRequest {
   approved_by: string;
   reason: string;
   conditions?: string[];
+  option?: ActionType; // Course of action to approve; omitted approves the recommended one
 }
 
 // Sort configuration
@@ -275,12 +774,24 @@ export interface InterventionRule {
   auto_approve: boolean;
   enabled: boolean;
   evaluation_order: number;
+  version: number; // Incremented on every update
   created_by: string | null;
   created_at: string;
   updated_by: string | null;
   updated_at: string;
 }
 
+// One entry in an intervention rule's history: the rule as it stood after the change
+export interface InterventionRuleVersion {
+  rule_id: string;
+  version: number;
+  change: 'created' | 'updated' | 'deleted';
+  rule: Omit<InterventionRule, 'version' | 'created_by' | 'created_at' | 'updated_by' | 'updated_at'>;
+  changed_by?: string;
+  changed_at: string;
+  correlation_id?: string;
+}
+
 export interface InterventionRuleCreate {
   name: string;
   description?: string;
@@ -297,3 +808,52 @@ export interface InterventionRuleCreate {
 }
 
 export interface InterventionRuleUpdate extends Partial<InterventionRuleCreate> {}
+
+// Protected asset or restricted zone
+export type ZoneKind = 'protected_asset' | 'restricted_zone';
+
+export interface GeoPoint {
+  lat: number;
+  lon: number;
+}
+
+export interface Zone {
+  zone_id: string;
+  name: string;
+  description?: string;
+  kind: ZoneKind;
+  shape: 'circle' | 'polygon';
+  center?: GeoPoint;
+  radius_meters?: number;
+  polygon?: GeoPoint[];
+  priority: number;
+  enabled: boolean;
+  created_by?: string;
+  created_at: string;
+  updated_by?: string;
+  updated_at: string;
+}
+
+export interface ZoneCreate {
+  name: string;
+  description?: string;
+  kind: ZoneKind;
+  shape: 'circle' | 'polygon';
+  center?: GeoPoint;
+  radius_meters?: number;
+  polygon?: GeoPoint[];
+  priority?: number;
+  enabled?: boolean;
+}
+
+// Track geometry relative to a zone, computed by the correlator
+export interface ZoneProximity {
+  zone_id: string;
+  zone_name: string;
+  zone_kind: ZoneKind;
+  inside: boolean;
+  distance_meters: number;
+  cpa_meters: number;
+  time_to_cpa_seconds: number;
+  time_to_zone_seconds?: number;
+}